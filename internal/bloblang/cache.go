@@ -0,0 +1,81 @@
+package bloblang
+
+import (
+	"container/list"
+	"sync"
+)
+
+// defaultCacheLimit is the maximum number of parsed mappings and fields held
+// by the process-wide cache before the least recently used are evicted.
+const defaultCacheLimit = 4096
+
+type cacheEntry struct {
+	key   string
+	value interface{}
+}
+
+// parsedCache is a bounded, process-wide cache of parsed Bloblang mappings and
+// interpolation fields keyed by their source. Parsed mappings and fields are
+// immutable once created, and therefore configs containing identical sources,
+// such as streams mode with hundreds of similar streams, are able to share a
+// single parsed representation rather than each holding their own.
+type parsedCache struct {
+	mut   sync.Mutex
+	limit int
+	items map[string]*list.Element
+	order *list.List
+}
+
+func newParsedCache(limit int) *parsedCache {
+	return &parsedCache{
+		limit: limit,
+		items: map[string]*list.Element{},
+		order: list.New(),
+	}
+}
+
+// getOrCreate returns a cached value by its key, or calls the provided
+// constructor and caches the result when it succeeds and reports that the
+// result can be cached.
+func (c *parsedCache) getOrCreate(key string, ctor func() (interface{}, bool, error)) (interface{}, error) {
+	c.mut.Lock()
+	if ele, exists := c.items[key]; exists {
+		c.order.MoveToFront(ele)
+		c.mut.Unlock()
+		return ele.Value.(*cacheEntry).value, nil
+	}
+	c.mut.Unlock()
+
+	// Parse outside of the lock as it could take a while, if another caller
+	// races us then we simply adopt whichever result was cached first.
+	v, cacheable, err := ctor()
+	if err != nil {
+		return nil, err
+	}
+	if !cacheable {
+		return v, nil
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if ele, exists := c.items[key]; exists {
+		c.order.MoveToFront(ele)
+		return ele.Value.(*cacheEntry).value, nil
+	}
+	c.items[key] = c.order.PushFront(&cacheEntry{key: key, value: v})
+	for c.order.Len() > c.limit {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+	return v, nil
+}
+
+func (c *parsedCache) len() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.order.Len()
+}
+
+var globalCache = newParsedCache(defaultCacheLimit)
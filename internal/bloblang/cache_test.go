@@ -0,0 +1,229 @@
+package bloblang
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang/parser"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/lib/message"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsedCacheLRU(t *testing.T) {
+	c := newParsedCache(2)
+
+	var calls int
+	ctor := func(v string) func() (interface{}, bool, error) {
+		return func() (interface{}, bool, error) {
+			calls++
+			return v, true, nil
+		}
+	}
+
+	v, err := c.getOrCreate("a", ctor("a"))
+	require.NoError(t, err)
+	assert.Equal(t, "a", v)
+
+	v, err = c.getOrCreate("a", ctor("not a"))
+	require.NoError(t, err)
+	assert.Equal(t, "a", v)
+	assert.Equal(t, 1, calls)
+
+	_, err = c.getOrCreate("b", ctor("b"))
+	require.NoError(t, err)
+
+	// Touch a so that b becomes the least recently used.
+	_, err = c.getOrCreate("a", ctor("not a"))
+	require.NoError(t, err)
+
+	_, err = c.getOrCreate("c", ctor("c"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, c.len())
+	assert.Equal(t, 3, calls)
+
+	v, err = c.getOrCreate("a", ctor("not a"))
+	require.NoError(t, err)
+	assert.Equal(t, "a", v)
+
+	v, err = c.getOrCreate("b", ctor("new b"))
+	require.NoError(t, err)
+	assert.Equal(t, "new b", v)
+	assert.Equal(t, 4, calls)
+}
+
+func TestParsedCacheErrors(t *testing.T) {
+	c := newParsedCache(10)
+
+	_, err := c.getOrCreate("a", func() (interface{}, bool, error) {
+		return nil, false, errors.New("nope")
+	})
+	require.EqualError(t, err, "nope")
+	assert.Equal(t, 0, c.len())
+
+	v, err := c.getOrCreate("a", func() (interface{}, bool, error) {
+		return "a", true, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "a", v)
+}
+
+func TestParsedCacheUncacheable(t *testing.T) {
+	c := newParsedCache(10)
+
+	v, err := c.getOrCreate("a", func() (interface{}, bool, error) {
+		return "a", false, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "a", v)
+	assert.Equal(t, 0, c.len())
+
+	v, err = c.getOrCreate("a", func() (interface{}, bool, error) {
+		return "new a", false, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "new a", v)
+}
+
+func TestMappingCacheReuse(t *testing.T) {
+	mapping := fmt.Sprintf(`root.id = "%v"`, t.Name())
+
+	exeOne, err := NewMapping("", mapping)
+	require.NoError(t, err)
+
+	exeTwo, err := NewMapping("", mapping)
+	require.NoError(t, err)
+	assert.True(t, exeOne == exeTwo)
+
+	exeThree, err := NewMapping("./foo.blobl", mapping)
+	require.NoError(t, err)
+	assert.False(t, exeOne == exeThree)
+
+	_, err = NewMapping("", `root = this.`)
+	require.Error(t, err)
+
+	fieldOne, err := NewField("${! meta(\"" + t.Name() + "\") }")
+	require.NoError(t, err)
+
+	fieldTwo, err := NewField("${! meta(\"" + t.Name() + "\") }")
+	require.NoError(t, err)
+	assert.True(t, fieldOne == fieldTwo)
+
+	_, err = NewField("${! meta( }")
+	require.Error(t, err)
+}
+
+func TestMappingCacheImportBypass(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "foo.blobl"), []byte(`map foo {
+  root.foo = "first"
+}`), 0644))
+
+	mapping := `import "./foo.blobl"
+root = this.apply("foo")`
+	path := filepath.Join(dir, "main.blobl")
+
+	exeOne, err := NewMapping(path, mapping)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "foo.blobl"), []byte(`map foo {
+  root.foo = "second"
+}`), 0644))
+
+	exeTwo, err := NewMapping(path, mapping)
+	require.NoError(t, err)
+	assert.False(t, exeOne == exeTwo)
+
+	p, err := exeTwo.MapPart(0, message.New([][]byte{[]byte(`{}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"second"}`, string(p.Get()))
+}
+
+func TestMappingCacheUncacheableBypass(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "foo.txt")
+	require.NoError(t, ioutil.WriteFile(filePath, []byte(`first`), 0644))
+	fileMapping := fmt.Sprintf(`root.foo = file("%v").string()`, filepath.ToSlash(filePath))
+
+	for _, mapping := range []string{
+		`root.foo = env("BENTHOS_TEST_BLOBLANG_CACHE")`,
+		fileMapping,
+		`root.foo = random_int(5)`,
+		`root.foo = counter("foo")`,
+		`root.foo = this.line.grok("%{WORD:first}")`,
+		`root.foo = this.json_schema("{}")`,
+		`root.foo = this.json_schema_errors("{}")`,
+	} {
+		_, cacheable, err := parser.ParseMappingCacheable("", mapping, parser.Context{
+			Functions: query.AllFunctions,
+			Methods:   query.AllMethods,
+		})
+		require.Nil(t, err, mapping)
+		assert.False(t, cacheable, mapping)
+	}
+
+	for _, mapping := range []string{
+		`root.foo = this.environment`,
+		`root.foo = "env(\"foo\")"`,
+		`root.foo = hostfile("./foo.txt").catch("")`,
+	} {
+		_, cacheable, err := parser.ParseMappingCacheable("", mapping, parser.Context{
+			Functions: query.AllFunctions,
+			Methods:   query.AllMethods,
+		})
+		require.Nil(t, err, mapping)
+		assert.True(t, cacheable, mapping)
+	}
+
+	exeOne, err := NewMapping("", fileMapping)
+	require.NoError(t, err)
+
+	p, err := exeOne.MapPart(0, message.New([][]byte{[]byte(`{}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"first"}`, string(p.Get()))
+
+	require.NoError(t, ioutil.WriteFile(filePath, []byte(`second`), 0644))
+
+	exeTwo, err := NewMapping("", fileMapping)
+	require.NoError(t, err)
+	assert.False(t, exeOne == exeTwo)
+
+	p, err = exeTwo.MapPart(0, message.New([][]byte{[]byte(`{}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"second"}`, string(p.Get()))
+
+	field := `${! env("BENTHOS_TEST_BLOBLANG_CACHE") }`
+
+	fieldOne, err := NewField(field)
+	require.NoError(t, err)
+
+	fieldTwo, err := NewField(field)
+	require.NoError(t, err)
+	assert.False(t, fieldOne == fieldTwo)
+}
+
+func TestMappingCacheJSONSchemaReload(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, ioutil.WriteFile(schemaPath, []byte(`{"type":"object","properties":{"foo":{"type":"string"}}}`), 0644))
+
+	mapping := fmt.Sprintf(`root = this.json_schema("file://%v")`, filepath.ToSlash(schemaPath))
+
+	msg := message.New([][]byte{[]byte(`{"foo":5}`)})
+
+	exeOne, err := NewMapping("", mapping)
+	require.NoError(t, err)
+	_, err = exeOne.MapPart(0, msg)
+	require.Error(t, err)
+
+	// Reloading a stream with the same mapping observes the updated schema.
+	require.NoError(t, ioutil.WriteFile(schemaPath, []byte(`{"type":"object","properties":{"foo":{"type":"number"}}}`), 0644))
+
+	exeTwo, err := NewMapping("", mapping)
+	require.NoError(t, err)
+	_, err = exeTwo.MapPart(0, msg)
+	require.NoError(t, err)
+}
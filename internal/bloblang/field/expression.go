@@ -3,6 +3,7 @@ package field
 import (
 	"bytes"
 
+	"github.com/Jeffail/benthos/v3/internal/bufpool"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
@@ -85,15 +86,34 @@ type expression struct {
 	resolvers []Resolver
 }
 
+func (e *expression) resolveInto(buf *bytes.Buffer, index int, msg Message, escaped, legacy bool) {
+	for _, r := range e.resolvers {
+		buf.Write(r.ResolveBytes(index, msg, escaped, legacy))
+	}
+}
+
 func (e *expression) resolve(index int, msg Message, escaped, legacy bool) []byte {
 	if len(e.resolvers) == 1 {
 		return e.resolvers[0].ResolveBytes(index, msg, escaped, legacy)
 	}
-	var buf bytes.Buffer
-	for _, r := range e.resolvers {
-		buf.Write(r.ResolveBytes(index, msg, escaped, legacy))
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+
+	e.resolveInto(buf, index, msg, escaped, legacy)
+	return bufpool.Bytes(buf)
+}
+
+// resolveString avoids allocating an intermediate byte slice by converting the
+// contents of a pooled buffer directly into a string.
+func (e *expression) resolveString(index int, msg Message, legacy bool) string {
+	if len(e.resolvers) == 1 {
+		return string(e.resolvers[0].ResolveBytes(index, msg, false, legacy))
 	}
-	return buf.Bytes()
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+
+	e.resolveInto(buf, index, msg, false, legacy)
+	return buf.String()
 }
 
 // Bytes returns a byte slice representing the expression resolved for a message
@@ -140,7 +160,7 @@ func (e *expression) String(index int, msg Message) string {
 	if len(e.resolvers) == 0 {
 		return e.static
 	}
-	return string(e.Bytes(index, msg))
+	return e.resolveString(index, msg, false)
 }
 
 // StringLegacy is DEPRECATED - Instructs deprecated functions to disregard
@@ -150,7 +170,7 @@ func (e *expression) StringLegacy(index int, msg Message) string {
 	if len(e.resolvers) == 0 {
 		return e.static
 	}
-	return string(e.BytesLegacy(index, msg))
+	return e.resolveString(index, msg, true)
 }
 
 //------------------------------------------------------------------------------
@@ -64,6 +64,7 @@ type Executor struct {
 	annotation string
 	input      []rune
 	maps       map[string]query.Function
+	params     []string
 	statements []Statement
 }
 
@@ -72,7 +73,14 @@ type Executor struct {
 // is an optional slice pointing to the parsed expression that created the
 // executor.
 func NewExecutor(annotation string, input []rune, maps map[string]query.Function, statements ...Statement) *Executor {
-	return &Executor{annotation, input, maps, statements}
+	return &Executor{annotation, input, maps, nil, statements}
+}
+
+// NewParameterisedExecutor initialises a new mapping executor that declares a
+// list of named parameters, which are bound to variables when the mapping is
+// applied with arguments.
+func NewParameterisedExecutor(annotation string, input []rune, maps map[string]query.Function, params []string, statements ...Statement) *Executor {
+	return &Executor{annotation, input, maps, params, statements}
 }
 
 // Annotation returns a string annotation that describes the mapping executor.
@@ -80,6 +88,11 @@ func (e *Executor) Annotation() string {
 	return e.annotation
 }
 
+// Params returns the names of any parameters declared by the mapping.
+func (e *Executor) Params() []string {
+	return e.params
+}
+
 // Maps returns any map definitions contained within the mapping.
 func (e *Executor) Maps() map[string]query.Function {
 	return e.maps
@@ -256,6 +269,11 @@ func (e *Executor) mapPart(appendTo types.Part, index int, reference Message) (t
 		case []byte:
 			newPart.Set(t)
 		default:
+			if appendTo == nil && valuePtr != nil {
+				// The reference document was parsed after the part was copied,
+				// so carry over any key order recorded whilst parsing it.
+				message.InheritKeyOrder(newPart, reference.Get(index))
+			}
 			if err := newPart.SetJSON(newObj); err != nil {
 				return nil, fmt.Errorf("failed to set result of mapping: %w", err)
 			}
@@ -10,29 +10,60 @@ import (
 // NewField attempts to parse and create a dynamic field expression from a
 // string. If the expression is invalid an error is returned.
 //
+// Parsed fields are cached process-wide by their source and are therefore
+// shared between callers that provide an identical expression, unless the
+// expression uses impure functions or methods, such as env or counter, which
+// resolve external state when parsed or hold state for each parsed instance.
+//
 // When a parsing error occurs the returned error will be a *parser.Error type,
 // which allows you to gain positional and structured error messages.
 func NewField(expr string) (field.Expression, error) {
-	e, err := parser.ParseField(expr)
+	e, err := globalCache.getOrCreate("field\x00"+expr, func() (interface{}, bool, error) {
+		e, cacheable, err := parser.ParseFieldCacheable(expr)
+		if err != nil {
+			return nil, false, err
+		}
+		return e, cacheable, nil
+	})
 	if err != nil {
-		return e, err
+		return nil, err
 	}
-	return e, nil
+	return e.(field.Expression), nil
 }
 
 // NewMapping attempts to parse and create a Bloblang mapping from a string. If
 // the mapping was read from a file the path should be provided in order to
 // resolve relative imports, otherwise the path can be left empty.
 //
+// Parsed mappings are cached process-wide by their path and source and are
+// therefore shared between callers that provide an identical mapping, unless
+// the mapping imports other files, as their contents could change between
+// parses, or uses impure functions or methods.
+//
 // When a parsing error occurs the returned error may be a *parser.Error type,
 // which allows you to gain positional and structured error messages.
 func NewMapping(path, expr string) (*mapping.Executor, error) {
-	e, err := parser.ParseMapping(path, expr, parser.Context{
-		Functions: query.AllFunctions,
-		Methods:   query.AllMethods,
+	return NewMappingWithResources(path, expr, nil)
+}
+
+// NewMappingWithResources attempts to parse and create a Bloblang mapping from
+// a string, where functions that require access to the resources of a
+// component, such as counter when persisting to a cache, are provided with
+// them. Mappings that use resources are impure and are therefore never shared
+// with other callers.
+func NewMappingWithResources(path, expr string, res query.Resources) (*mapping.Executor, error) {
+	e, err := globalCache.getOrCreate("mapping\x00"+path+"\x00"+expr, func() (interface{}, bool, error) {
+		e, cacheable, err := parser.ParseMappingCacheable(path, expr, parser.Context{
+			Functions: query.AllFunctions,
+			Methods:   query.AllMethods,
+		}.WithResources(res))
+		if err != nil {
+			return nil, false, err
+		}
+		return e, cacheable, nil
 	})
 	if err != nil {
 		return nil, err
 	}
-	return e, nil
+	return e.(*mapping.Executor), nil
 }
@@ -30,6 +30,11 @@ func TestFunctionExamples(t *testing.T) {
 		os.Unsetenv(key)
 	})
 
+	os.Setenv("TARGET_URL", "http://example.com")
+	t.Cleanup(func() {
+		os.Unsetenv("TARGET_URL")
+	})
+
 	for _, spec := range query.FunctionDocs() {
 		spec := spec
 		t.Run(spec.Name, func(t *testing.T) {
@@ -45,6 +50,9 @@ func TestFunctionExamples(t *testing.T) {
 					if strings.HasPrefix(exp, "Error(") {
 						exp = exp[7 : len(exp)-2]
 						require.EqualError(t, err, exp, fmt.Sprintf("%v-%v", i, j))
+					} else if exp == "<Message deleted>" {
+						require.NoError(t, err)
+						require.Nil(t, p)
 					} else {
 						require.NoError(t, err)
 						assert.Equal(t, exp, string(p.Get()), fmt.Sprintf("%v-%v", i, j))
@@ -78,6 +86,11 @@ func TestMethodExamples(t *testing.T) {
 		os.Unsetenv(key)
 	})
 
+	os.Setenv("TARGET_URL", "http://example.com")
+	t.Cleanup(func() {
+		os.Unsetenv("TARGET_URL")
+	})
+
 	for _, spec := range query.MethodDocs() {
 		spec := spec
 		t.Run(spec.Name, func(t *testing.T) {
@@ -114,6 +127,9 @@ func TestMethodExamples(t *testing.T) {
 						if strings.HasPrefix(exp, "Error(") {
 							exp = exp[7 : len(exp)-2]
 							require.EqualError(t, err, exp, fmt.Sprintf("%v-%v-%v", target.Category, i, j))
+						} else if exp == "<Message deleted>" {
+							require.NoError(t, err)
+							require.Nil(t, p)
 						} else {
 							require.NoError(t, err)
 							assert.Equal(t, exp, string(p.Get()), fmt.Sprintf("%v-%v-%v", target.Category, i, j))
@@ -166,14 +166,14 @@ func Number() Func {
 			}
 			res.Payload = f
 		} else {
+			if negative {
+				resStr = "-" + resStr
+			}
 			i, err := strconv.ParseInt(resStr, 10, 64)
 			if err != nil {
 				err = fmt.Errorf("failed to parse '%v' as integer: %v", resStr, err)
 				return Fail(NewFatalError(input, err), input)
 			}
-			if negative {
-				i = -i
-			}
 			res.Payload = i
 		}
 		return res
@@ -1111,6 +1111,16 @@ func TestNumber(t *testing.T) {
 			result:    int64(123),
 			remaining: " foo",
 		},
+		"max int64": {
+			input:     "9223372036854775807",
+			result:    int64(9223372036854775807),
+			remaining: "",
+		},
+		"min int64": {
+			input:     "-9223372036854775808",
+			result:    int64(-9223372036854775808),
+			remaining: "",
+		},
 		"float number": {
 			input:     "0.123",
 			result:    float64(0.123),
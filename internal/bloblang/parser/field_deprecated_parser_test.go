@@ -484,3 +484,35 @@ func TestFieldTimestamps(t *testing.T) {
 		t.Errorf("Timestamps too far out of sync: %v and %v", tThen, now)
 	}
 }
+
+func TestDeprecatedFieldFunctions(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		output []string
+	}{
+		"no functions": {
+			input: `foo bar`,
+		},
+		"bloblang queries": {
+			input: `${! this.foo } ${! hostname() } ${! content() }`,
+		},
+		"deprecated functions": {
+			input:  `${!json_field:foo,1} ${! this.bar } ${! metadata:baz }`,
+			output: []string{`json_field:foo,1`, `metadata:baz `},
+		},
+		"escaped deprecated functions": {
+			input:  `${{!hostname}} ${!hostname}`,
+			output: []string{`hostname`},
+		},
+		"unterminated function": {
+			input: `foo ${!hostname`,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.output, DeprecatedFieldFunctions(test.input))
+		})
+	}
+}
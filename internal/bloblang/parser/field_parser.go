@@ -19,14 +19,20 @@ func intoStaticResolver(p Func) Func {
 	}
 }
 
-func aFunction(input []rune) Result {
+func aFunction(pCtx Context) Func {
+	return func(input []rune) Result {
+		return parseFunctionBlock(input, pCtx)
+	}
+}
+
+func parseFunctionBlock(input []rune, pCtx Context) Result {
 	if len(input) < 3 || input[0] != '$' || input[1] != '{' || input[2] != '!' {
 		return Fail(NewError(input, "${!"), input)
 	}
 	i := 3
 	for ; i < len(input); i++ {
 		if input[i] == '}' {
-			res := ParseDeprecatedQuery(input[3:i])
+			res := parseDeprecatedQuery(input[3:i], pCtx)
 			if res.Err == nil {
 				if len(res.Remaining) > 0 {
 					pos := len(input[3:i]) - len(res.Remaining)
@@ -63,12 +69,12 @@ func escapedBlock(input []rune) Result {
 
 //------------------------------------------------------------------------------
 
-func parseFieldResolvers(expr string) ([]field.Resolver, *Error) {
+func parseFieldResolvers(expr string, pCtx Context) ([]field.Resolver, *Error) {
 	var resolvers []field.Resolver
 
 	p := OneOf(
 		escapedBlock,
-		aFunction,
+		aFunction(pCtx),
 		intoStaticResolver(Char('$')),
 		intoStaticResolver(NotChar('$')),
 	)
@@ -86,13 +92,61 @@ func parseFieldResolvers(expr string) ([]field.Resolver, *Error) {
 	return resolvers, nil
 }
 
+// DeprecatedFieldFunctions returns the contents of any interpolation functions
+// within a field expression that are written in the deprecated function syntax
+// (e.g. `${!json_field:foo}`) rather than as a Bloblang query.
+//
+// TODO: V4 Remove this
+func DeprecatedFieldFunctions(expr string) []string {
+	var deprecated []string
+
+	input := []rune(expr)
+	for i := 0; i < len(input); i++ {
+		if res := escapedBlock(input[i:]); res.Err == nil {
+			i = len(input) - len(res.Remaining) - 1
+			continue
+		}
+		if len(input)-i < 3 || input[i] != '$' || input[i+1] != '{' || input[i+2] != '!' {
+			continue
+		}
+		j := i + 3
+		for ; j < len(input) && input[j] != '}'; j++ {
+		}
+		if j == len(input) {
+			break
+		}
+		body := SpacesAndTabs()(input[i+3 : j]).Remaining
+		if res := parseDeprecatedFunction(body); res.Err == nil {
+			deprecated = append(deprecated, string(body))
+		}
+		i = j
+	}
+
+	return deprecated
+}
+
 // ParseField attempts to parse a field expression.
 func ParseField(expr string) (field.Expression, *Error) {
-	resolvers, err := parseFieldResolvers(expr)
+	e, _, err := ParseFieldCacheable(expr)
+	return e, err
+}
+
+// ParseFieldCacheable attempts to parse a field expression and also returns
+// whether the parsed expression can be shared between callers that provide an
+// identical expression, which is false when it uses impure functions or
+// methods.
+func ParseFieldCacheable(expr string) (field.Expression, bool, *Error) {
+	cacheable := true
+	resolvers, err := parseFieldResolvers(expr, Context{
+		Functions: query.AllFunctions,
+		Methods:   query.AllMethods,
+	}.WithImpureHandler(func() {
+		cacheable = false
+	}))
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	return field.NewExpression(resolvers...), nil
+	return field.NewExpression(resolvers...), cacheable, nil
 }
 
 //------------------------------------------------------------------------------
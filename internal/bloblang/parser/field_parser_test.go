@@ -3,7 +3,6 @@ package parser
 import (
 	"testing"
 
-	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,10 +19,9 @@ func TestFieldStaticExpressionOptimization(t *testing.T) {
 
 	for k, v := range tests {
 		t.Run(k, func(t *testing.T) {
-			rs, err := parseFieldResolvers(k)
+			e, err := ParseField(k)
 			require.Nil(t, err)
 
-			e := field.NewExpression(rs...)
 			assert.Equal(t, v, e.String(0, message.New(nil)))
 			assert.Equal(t, v, e.StringLegacy(0, message.New(nil)))
 			assert.Equal(t, v, string(e.Bytes(0, message.New(nil))))
@@ -67,6 +65,18 @@ func TestFieldExpressionParserErrors(t *testing.T) {
 			input: `foo ${!json} bar`,
 			err:   `char 12: required: expected function arguments`,
 		},
+		"bad function typo": {
+			input: `foo ${!jsn} bar`,
+			err:   `char 11: required: expected function arguments`,
+		},
+		"bad field path": {
+			input: `foo ${!this.} bar`,
+			err:   `char 13: required: expected method or field path`,
+		},
+		"field path not allowed": {
+			input: `foo ${!json.foo} bar`,
+			err:   `char 12: required: expected function arguments`,
+		},
 	}
 
 	for name, test := range tests {
@@ -117,6 +127,34 @@ func TestFieldExpressions(t *testing.T) {
 			input:  `$ hello world`,
 			output: `$ hello world`,
 		},
+		"field path": {
+			input:  `foo ${! this.foo.bar } baz`,
+			output: `foo bar1 baz`,
+			messages: []easyMsg{
+				{content: `{"foo":{"bar":"bar1"}}`},
+			},
+		},
+		"field path with methods": {
+			input:  `foo ${! this.foo.bar.uppercase() } ${! this.foo.baz.or("nope") }`,
+			output: `foo BAR1 nope`,
+			messages: []easyMsg{
+				{content: `{"foo":{"bar":"bar1"}}`},
+			},
+		},
+		"field path arithmetic": {
+			input:  `${! this.a + this.b }`,
+			output: `12`,
+			messages: []easyMsg{
+				{content: `{"a":5,"b":7}`},
+			},
+		},
+		"deprecated function precedence": {
+			input:  `${!content}`,
+			output: `{"content":"nope"}`,
+			messages: []easyMsg{
+				{content: `{"content":"nope"}`},
+			},
+		},
 		"escaped string": {
 			input:  `hello ${{!this is escaped}} world`,
 			output: `hello ${!this is escaped} world`,
@@ -23,6 +23,13 @@ func ParseMapping(filepath string, expr string, pCtx Context) (*mapping.Executor
 	dir := ""
 	if len(filepath) > 0 {
 		dir = path.Dir(filepath)
+		pCtx.importChain = &importChain{path: path.Clean(filepath)}
+	}
+	if pCtx.imported == nil {
+		pCtx.imported = map[string]*mapping.Executor{}
+	}
+	if pCtx.counters == nil {
+		pCtx.counters = query.NewNamedCounters()
 	}
 
 	resDirectImport := singleRootImport(dir, pCtx)(in)
@@ -121,21 +128,11 @@ func singleRootImport(baseDir string, pCtx Context) Func {
 		}
 
 		fpath := res.Payload.([]interface{})[3].(string)
-		if !filepath.IsAbs(fpath) {
-			fpath = path.Join(baseDir, fpath)
-		}
-
-		contents, err := ioutil.ReadFile(fpath)
+		exec, err := parseImport(input, baseDir, fpath, pCtx)
 		if err != nil {
-			return Fail(NewFatalError(input, fmt.Errorf("failed to read import: %w", err)), input)
+			return Fail(err, input)
 		}
-
-		importContent := []rune(string(contents))
-		execRes := parseExecutor(path.Dir(fpath), pCtx)(importContent)
-		if execRes.Err != nil {
-			return Fail(NewFatalError(input, NewImportError(fpath, importContent, execRes.Err)), input)
-		}
-		return Success(execRes.Payload.(*mapping.Executor), res.Remaining)
+		return Success(exec, res.Remaining)
 	}
 }
 
@@ -196,21 +193,11 @@ func importParser(baseDir string, maps map[string]query.Function, pCtx Context)
 		}
 
 		fpath := res.Payload.([]interface{})[2].(string)
-		if !filepath.IsAbs(fpath) {
-			fpath = path.Join(baseDir, fpath)
-		}
-		contents, err := ioutil.ReadFile(fpath)
-		if err != nil {
-			return Fail(NewFatalError(input, fmt.Errorf("failed to read import: %w", err)), input)
+		exec, perr := parseImport(input, baseDir, fpath, pCtx)
+		if perr != nil {
+			return Fail(perr, input)
 		}
 
-		importContent := []rune(string(contents))
-		execRes := parseExecutor(path.Dir(fpath), pCtx)(importContent)
-		if execRes.Err != nil {
-			return Fail(NewFatalError(input, NewImportError(fpath, importContent, execRes.Err)), input)
-		}
-
-		exec := execRes.Payload.(*mapping.Executor)
 		if len(exec.Maps()) == 0 {
 			err := fmt.Errorf("no maps to import from '%v'", fpath)
 			return Fail(NewFatalError(input, err), input)
@@ -218,8 +205,12 @@ func importParser(baseDir string, maps map[string]query.Function, pCtx Context)
 
 		collisions := []string{}
 		for k, v := range exec.Maps() {
-			if _, exists := maps[k]; exists {
-				collisions = append(collisions, k)
+			if existing, exists := maps[k]; exists {
+				// The same file imported via multiple paths results in the
+				// same map, which is not a collision.
+				if existing != v {
+					collisions = append(collisions, k)
+				}
 			} else {
 				maps[k] = v
 			}
@@ -233,6 +224,52 @@ func importParser(baseDir string, maps map[string]query.Function, pCtx Context)
 	}
 }
 
+// parseImport reads and parses a mapping file, where relative paths are
+// resolved from the directory of the importing mapping. Files that have already
+// been imported whilst parsing the root mapping are reused, and a file that
+// directly or indirectly imports itself results in an error.
+func parseImport(input []rune, baseDir, fpath string, pCtx Context) (*mapping.Executor, *Error) {
+	if !filepath.IsAbs(fpath) {
+		fpath = path.Join(baseDir, fpath)
+	}
+	fpath = path.Clean(fpath)
+
+	if exec, exists := pCtx.imported[fpath]; exists {
+		return exec, nil
+	}
+
+	chain := []string{fpath}
+	for c := pCtx.importChain; c != nil; c = c.next {
+		chain = append([]string{c.path}, chain...)
+		if c.path == fpath {
+			return nil, NewFatalError(input, fmt.Errorf("import cycle detected: %v", strings.Join(chain, " -> ")))
+		}
+	}
+
+	contents, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return nil, NewFatalError(input, fmt.Errorf("failed to read import: %w", err))
+	}
+	if pCtx.onImpure != nil {
+		pCtx.onImpure()
+	}
+
+	importCtx := pCtx
+	importCtx.importChain = &importChain{path: fpath, next: pCtx.importChain}
+
+	importContent := []rune(string(contents))
+	execRes := parseExecutor(path.Dir(fpath), importCtx)(importContent)
+	if execRes.Err != nil {
+		return nil, NewFatalError(input, NewImportError(fpath, importContent, execRes.Err))
+	}
+
+	exec := execRes.Payload.(*mapping.Executor)
+	if pCtx.imported != nil {
+		pCtx.imported[fpath] = exec
+	}
+	return exec, nil
+}
+
 func mapParser(maps map[string]query.Function, pCtx Context) Func {
 	newline := NewlineAllowComment()
 	whitespace := SpacesAndTabs()
@@ -251,6 +288,7 @@ func mapParser(maps map[string]query.Function, pCtx Context) Func {
 				"map name",
 			),
 		),
+		Optional(mapParamsParser()),
 		SpacesAndTabs(),
 		DelimitedPattern(
 			Sequence(
@@ -283,23 +321,52 @@ func mapParser(maps map[string]query.Function, pCtx Context) Func {
 
 		seqSlice := res.Payload.([]interface{})
 		ident := seqSlice[2].(string)
-		stmtSlice := seqSlice[4].([]interface{})
+		stmtSlice := seqSlice[5].([]interface{})
 
 		if _, exists := maps[ident]; exists {
 			return Fail(NewFatalError(input, fmt.Errorf("map name collision: %v", ident)), input)
 		}
 
+		var params []string
+		if paramSlice, ok := seqSlice[3].([]interface{}); ok {
+			seen := map[string]struct{}{}
+			for _, v := range paramSlice {
+				param := v.(string)
+				if _, exists := seen[param]; exists {
+					return Fail(NewFatalError(input, fmt.Errorf("map %v parameter name collision: %v", ident, param)), input)
+				}
+				seen[param] = struct{}{}
+				params = append(params, param)
+			}
+		}
+
 		statements := make([]mapping.Statement, len(stmtSlice))
 		for i, v := range stmtSlice {
 			statements[i] = v.(mapping.Statement)
 		}
 
-		maps[ident] = mapping.NewExecutor("map "+ident, input, maps, statements...)
+		maps[ident] = mapping.NewParameterisedExecutor("map "+ident, input, maps, params, statements...)
 
 		return Success(ident, res.Remaining)
 	}
 }
 
+func mapParamsParser() Func {
+	whitespace := DiscardAll(
+		OneOf(
+			SpacesAndTabs(),
+			NewlineAllowComment(),
+		),
+	)
+	return DelimitedPattern(
+		Expect(Sequence(Char('('), whitespace), "map parameters"),
+		MustBe(Expect(varNameParser(), "parameter name")),
+		MustBe(Expect(Sequence(Discard(SpacesAndTabs()), Char(','), whitespace), "comma")),
+		MustBe(Expect(Sequence(whitespace, Char(')')), "closing bracket")),
+		false,
+	)
+}
+
 func letStatementParser(pCtx Context) Func {
 	p := Sequence(
 		Expect(Term("let"), "assignment"),
@@ -28,6 +28,14 @@ func TestMappingErrors(t *testing.T) {
 	require.NoError(t, ioutil.WriteFile(noMapsFile, []byte(`foo = "this is valid but has no maps"`), 0777))
 	require.NoError(t, ioutil.WriteFile(goodMapFile, []byte(`map foo { foo = "this is valid" }`), 0777))
 
+	cycleAFile := filepath.Join(dir, "cycle_a.blobl")
+	cycleBFile := filepath.Join(dir, "cycle_b.blobl")
+
+	require.NoError(t, ioutil.WriteFile(cycleAFile, []byte(`import "./cycle_b.blobl"
+map a { root = this }`), 0777))
+	require.NoError(t, ioutil.WriteFile(cycleBFile, []byte(`import "cycle_a.blobl"
+map b { root = this }`), 0777))
+
 	tests := map[string]struct {
 		mapping string
 		err     string
@@ -116,6 +124,20 @@ foo = bar.apply("foo")`,
 foo = bar.apply("foo")`,
 			err: `line 2 char 3: setting meta fields from within a map is not allowed`,
 		},
+		"duplicate map parameter": {
+			mapping: `map foo(a, a) {
+  foo = $a
+}
+foo = bar.apply("foo", 1, 2)`,
+			err: `line 1 char 1: map foo parameter name collision: a`,
+		},
+		"bad map parameter": {
+			mapping: `map foo(a, "b") {
+  foo = $a
+}
+foo = bar.apply("foo", 1, 2)`,
+			err: `line 1 char 12: required: expected parameter name`,
+		},
 		"no name map definition": {
 			mapping: `map {
   foo = bar
@@ -149,6 +171,15 @@ import "%v"
 foo = bar.apply("foo")`, goodMapFile),
 			err: fmt.Sprintf(`line 3 char 1: map name collisions from import '%v': [foo]`, goodMapFile),
 		},
+		"cyclic file import": {
+			mapping: fmt.Sprintf(`import "%v"
+
+foo = bar.apply("a")`, cycleAFile),
+			err: fmt.Sprintf(
+				`line 1 char 1: failed to parse import '%v': line 1 char 1: failed to parse import '%v': line 1 char 1: import cycle detected: %v -> %v -> %v`,
+				cycleAFile, cycleBFile, cycleAFile, cycleBFile, cycleAFile,
+			),
+		},
 		"quotes at root": {
 			mapping: `
 "root.something" = 5 + 2`,
@@ -186,6 +217,28 @@ func TestMappings(t *testing.T) {
 	directMapFile := filepath.Join(dir, "direct_map.blobl")
 	require.NoError(t, ioutil.WriteFile(directMapFile, []byte(`root.nested = this`), 0777))
 
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "lib"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "lib", "common.blobl"), []byte(`map upper {
+  root = this.uppercase()
+}`), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "lib", "names.blobl"), []byte(`import "./common.blobl"
+
+map name {
+  root = this.name.apply("upper")
+}`), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "lib", "places.blobl"), []byte(`import "common.blobl"
+
+map place {
+  root = this.place.apply("upper")
+}`), 0777))
+	sharedImportFile := filepath.Join(dir, "shared_import.blobl")
+	require.NoError(t, ioutil.WriteFile(sharedImportFile, []byte(`import "./lib/names.blobl"
+import "./lib/places.blobl"
+
+map greeting {
+  root = "hello " + this.apply("name") + " from " + this.apply("place")
+}`), 0777))
+
 	type part struct {
 		Content string
 		Meta    map[string]string
@@ -453,6 +506,21 @@ root = this.apply("foo")`,
 				Content: `{"applied":["bar","foo"],"foo":{"bar":{"outter":{"inner":"hello world"}},"static":"this is valid"}}`,
 			},
 		},
+		"test map parameters": {
+			mapping: `map greet(greeting,
+  name) {
+  root.message = $greeting + " " + $name
+  root.original = this
+}
+let greeting = "this is not visible"
+root = this.apply("greet", "hello", this.name)`,
+			input: []part{
+				{Content: `{"name":"world"}`},
+			},
+			output: part{
+				Content: `{"message":"hello world","original":{"name":"world"}}`,
+			},
+		},
 		"test imported map": {
 			mapping: fmt.Sprintf(`import "%v"
 
@@ -464,6 +532,17 @@ root = this.apply("foo")`, goodMapFile),
 				Content: `{"foo":"this is valid","nested":{"outter":{"inner":"hello world"}}}`,
 			},
 		},
+		"test shared nested imports": {
+			mapping: fmt.Sprintf(`import "%v"
+
+root = this.apply("greeting")`, sharedImportFile),
+			input: []part{
+				{Content: `{"name":"ash","place":"london"}`},
+			},
+			output: part{
+				Content: `hello ASH from LONDON`,
+			},
+		},
 		"test directly imported map": {
 			mapping: fmt.Sprintf(`from "%v"`, directMapFile),
 			input: []part{
@@ -512,3 +591,107 @@ root = this.apply("foo")`, goodMapFile),
 		})
 	}
 }
+
+func TestMappingCounters(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_mapping_counters")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	importFile := filepath.Join(dir, "counters.blobl")
+	require.NoError(t, ioutil.WriteFile(importFile, []byte(`map next_a {
+  root = counter("a")
+}`), 0777))
+
+	mapping := fmt.Sprintf(`import "%v"
+root.a = [ counter("a"), counter("a"), counter("b") ]
+root.b = null.apply("next_a")`, importFile)
+
+	pCtx := Context{
+		Functions: query.AllFunctions,
+		Methods:   query.AllMethods,
+	}
+
+	exec, perr := ParseMapping("", mapping, pCtx)
+	require.Nil(t, perr)
+
+	otherExec, perr := ParseMapping("", mapping, pCtx)
+	require.Nil(t, perr)
+
+	msg := message.New([][]byte{[]byte(`{}`)})
+	for _, exp := range []string{
+		`{"a":[1,2,1],"b":3}`,
+		`{"a":[4,5,2],"b":6}`,
+	} {
+		resPart, err := exec.MapPart(0, msg)
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(resPart.Get()))
+	}
+
+	// Separately parsed mappings count independently.
+	resPart, err := otherExec.MapPart(0, msg)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":[1,2,1],"b":3}`, string(resPart.Get()))
+}
+
+func TestMappingDeprecations(t *testing.T) {
+	functions := query.AllFunctions.Without()
+	require.NoError(t, functions.Alias("old_uuid", "uuid_v4"))
+
+	methods := query.AllMethods.Without()
+	require.NoError(t, methods.Alias("old_uppercase", "uppercase"))
+
+	pCtx := Context{
+		Functions: functions,
+		Methods:   methods,
+	}
+
+	tests := map[string]struct {
+		mapping string
+		output  []string
+		err     string
+	}{
+		"no deprecations": {
+			mapping: `root = this.foo.uppercase()`,
+		},
+		"deprecated function": {
+			mapping: `root = timestamp_utc()`,
+			output: []string{
+				"function `timestamp_utc` is deprecated, use `now` instead",
+			},
+		},
+		"aliases": {
+			mapping: `root.id = old_uuid()
+root.name = this.name.old_uppercase()
+root.other_id = old_uuid()`,
+			output: []string{
+				"function `old_uuid` is deprecated, use `uuid_v4` instead",
+				"method `old_uppercase` is deprecated, use `uppercase` instead",
+			},
+		},
+		"bad mapping": {
+			mapping: `root = old_uuid(`,
+			err:     "line 1 char 17: required: expected function argument",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			deprecations, err := MappingDeprecations("", test.mapping, pCtx)
+			if len(test.err) > 0 {
+				require.NotNil(t, err)
+				assert.Equal(t, test.err, err.ErrorAtPosition([]rune(test.mapping)))
+				return
+			}
+			require.Nil(t, err)
+
+			var output []string
+			for _, d := range deprecations {
+				output = append(output, d.String())
+			}
+			assert.Equal(t, test.output, output)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
 )
@@ -211,10 +212,30 @@ func lambdaExpressionParser(pCtx Context) Func {
 		"context name",
 	)
 
+	// Multiple contexts can be named within brackets, e.g. `(a, b) -> a + b`,
+	// in which case the context must be an array of the same length.
+	contextNamesParser := Sequence(
+		Char('('),
+		Discard(SpacesAndTabs()),
+		Delimited(
+			contextNameParser,
+			Sequence(
+				Discard(SpacesAndTabs()),
+				Char(','),
+				Discard(SpacesAndTabs()),
+			),
+		),
+		Discard(SpacesAndTabs()),
+		Char(')'),
+	)
+
 	return func(input []rune) Result {
 		res := Expect(
 			Sequence(
-				contextNameParser,
+				OneOf(
+					contextNamesParser,
+					contextNameParser,
+				),
 				SpacesAndTabs(),
 				Term("->"),
 				SpacesAndTabs(),
@@ -225,10 +246,26 @@ func lambdaExpressionParser(pCtx Context) Func {
 			return res
 		}
 
-		seqSlice := res.Payload.([]interface{})
-		name := seqSlice[0].(string)
+		var names []string
+		switch t := res.Payload.([]interface{})[0].(type) {
+		case string:
+			names = []string{t}
+		case []interface{}:
+			for _, n := range t[2].(DelimitedResult).Primary {
+				names = append(names, n.(string))
+			}
+		}
 
-		if name != "_" {
+		childCtx := pCtx
+		seen := map[string]struct{}{}
+		for _, name := range names {
+			if name == "_" {
+				continue
+			}
+			if _, exists := seen[name]; exists {
+				return Fail(NewFatalError(input, fmt.Errorf("context label `%v` is declared more than once", name)), input)
+			}
+			seen[name] = struct{}{}
 			if pCtx.HasNamedContext(name) {
 				return Fail(NewFatalError(input, fmt.Errorf("context label `%v` would shadow a parent context", name)), input)
 			}
@@ -238,21 +275,25 @@ func lambdaExpressionParser(pCtx Context) Func {
 			}[name]; exists {
 				return Fail(NewFatalError(input, fmt.Errorf("context label `%v` is not allowed", name)), input)
 			}
-			pCtx = pCtx.WithNamedContext(name)
+			childCtx = childCtx.WithNamedContext(name)
 		}
 
-		res = MustBe(queryParser(pCtx))(res.Remaining)
+		res = MustBe(queryParser(childCtx))(res.Remaining)
 		if res.Err != nil {
 			return res
 		}
 
 		queryFn := res.Payload.(query.Function)
 		if chained, isChained := queryFn.(*query.NamedContextFunction); isChained {
-			err := fmt.Errorf("it would be in poor taste to capture the same context under both '%v' and '%v'", name, chained.Name())
+			err := fmt.Errorf("it would be in poor taste to capture the same context under both '%v' and '%v'", strings.Join(names, ", "), chained.Name())
 			return Fail(NewFatalError(input, err), input)
 		}
 
-		res.Payload = query.NewNamedContextFunction(name, queryFn)
+		if len(names) == 1 {
+			res.Payload = query.NewNamedContextFunction(names[0], queryFn)
+		} else {
+			res.Payload = query.NewNamedContextsFunction(names, queryFn)
+		}
 		return res
 	}
 }
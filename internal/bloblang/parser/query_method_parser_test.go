@@ -36,6 +36,21 @@ func TestMethodParser(t *testing.T) {
 				{content: `{"foo":4}`},
 			},
 		},
+		"named contexts of sibling arguments": {
+			input:    `json("value").loop(v -> v < 100, v -> v * 2)`,
+			output:   `192`,
+			messages: []easyMsg{{content: `{"value":3}`}},
+		},
+		"sort with multiple named contexts": {
+			input:    `json("values").sort((l, r) -> l.v > r.v).map_each(ele -> ele.id)`,
+			output:   `["c","a","b","d"]`,
+			messages: []easyMsg{{content: `{"values":[{"id":"a","v":2},{"id":"b","v":2},{"id":"c","v":3},{"id":"d","v":1}]}`}},
+		},
+		"sort by multiple keys": {
+			input:    `json("values").sort_by(ele -> [ ele.v, ele.w ]).map_each(ele -> ele.id)`,
+			output:   `["d","b","a","c"]`,
+			messages: []easyMsg{{content: `{"values":[{"id":"a","v":2,"w":"y"},{"id":"b","v":2,"w":"x"},{"id":"c","v":3,"w":"a"},{"id":"d","v":1,"w":"z"}]}`}},
+		},
 		"json from 2": {
 			input:  `json("foo").from(1)`,
 			output: `2`,
@@ -492,6 +507,11 @@ func TestMethodErrors(t *testing.T) {
 			errStr:   "string literal: strconv.ParseFloat: parsing \"not a number\": invalid syntax",
 			messages: []easyMsg{{}},
 		},
+		"map_each failure beyond a slice": {
+			input:    `[1,"a",3].map_each(x -> x + 1).slice(0,1)`,
+			errStr:   "array literal: failed to process element 1: cannot add types string (from field `x`) and number (from number literal)",
+			messages: []easyMsg{{}},
+		},
 	}
 
 	for name, test := range tests {
@@ -547,6 +567,14 @@ func TestMethodMaps(t *testing.T) {
 			maps:     map[string]query.Function{},
 			messages: []easyMsg{{}},
 		},
+		"map unexpected arguments": {
+			input: `"foo".apply("foo", "bar")`,
+			err:   "map foo expects 0 arguments, received 1",
+			maps: map[string]query.Function{
+				"foo": query.NewLiteralFunction("", "hello world"),
+			},
+			messages: []easyMsg{{}},
+		},
 		"map static": {
 			input:  `"foo".apply("foo")`,
 			output: "hello world",
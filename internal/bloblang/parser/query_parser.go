@@ -1,6 +1,9 @@
 package parser
 
 import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
 )
 
@@ -20,6 +23,33 @@ type Context struct {
 	Functions    FunctionSet
 	Methods      MethodSet
 	namedContext *namedContext
+	onDeprecated func(Deprecation)
+	onImpure     func()
+	importChain  *importChain
+	imported     map[string]*mapping.Executor
+	counters     *query.NamedCounters
+	resources    query.Resources
+}
+
+// Deprecation describes the usage of a deprecated function or method within a
+// parsed Bloblang query.
+type Deprecation struct {
+	// Either "function" or "method".
+	Kind string
+
+	// The name of the deprecated function or method.
+	Name string
+
+	// An optional name of a function or method to use instead.
+	Replacement string
+}
+
+// String returns a human readable warning describing the deprecation.
+func (d Deprecation) String() string {
+	if len(d.Replacement) > 0 {
+		return fmt.Sprintf("%v `%v` is deprecated, use `%v` instead", d.Kind, d.Name, d.Replacement)
+	}
+	return fmt.Sprintf("%v `%v` is deprecated", d.Kind, d.Name)
 }
 
 type namedContext struct {
@@ -34,6 +64,11 @@ func (pCtx Context) WithNamedContext(name string) Context {
 	return pCtx
 }
 
+type importChain struct {
+	path string
+	next *importChain
+}
+
 // HasNamedContext returns true if a given name exists as a named context.
 func (pCtx Context) HasNamedContext(name string) bool {
 	tmp := pCtx.namedContext
@@ -46,16 +81,122 @@ func (pCtx Context) HasNamedContext(name string) bool {
 	return false
 }
 
+// WithDeprecationHandler returns a Context where the provided closure is called
+// each time a deprecated function or method is successfully parsed. The same
+// usage may be reported more than once as parsers are attempted.
+func (pCtx Context) WithDeprecationHandler(fn func(Deprecation)) Context {
+	pCtx.onDeprecated = fn
+	return pCtx
+}
+
+// WithImpureHandler returns a Context where the provided closure is called each
+// time a function or method flagged as impure, or an import, is successfully
+// parsed. The closure may be called for usages that are later discarded as
+// parsers are attempted.
+func (pCtx Context) WithImpureHandler(fn func()) Context {
+	pCtx.onImpure = fn
+	return pCtx
+}
+
+// WithResources returns a Context where functions that require access to the
+// resources of a component, such as caches, are provided with them.
+func (pCtx Context) WithResources(res query.Resources) Context {
+	pCtx.resources = res
+	return pCtx
+}
+
 // InitFunction attempts to initialise a function from the available
 // constructors of the parser context.
 func (pCtx Context) InitFunction(name string, args ...interface{}) (query.Function, error) {
-	return pCtx.Functions.Init(name, args...)
+	fn, err := pCtx.Functions.Init(name, args...)
+	if err == nil && pCtx.counters != nil {
+		if sharer, ok := fn.(interface {
+			ShareCounts(*query.NamedCounters)
+		}); ok {
+			sharer.ShareCounts(pCtx.counters)
+		}
+	}
+	if err == nil {
+		if user, ok := fn.(interface {
+			UseResources(query.Resources) error
+		}); ok {
+			err = user.UseResources(pCtx.resources)
+		}
+	}
+	if err == nil && (pCtx.onDeprecated != nil || pCtx.onImpure != nil) {
+		if specs, ok := pCtx.Functions.(interface {
+			Spec(string) (query.FunctionSpec, bool)
+		}); ok {
+			spec, exists := specs.Spec(name)
+			if exists && spec.Status == query.StatusDeprecated && pCtx.onDeprecated != nil {
+				pCtx.onDeprecated(Deprecation{
+					Kind:        "function",
+					Name:        name,
+					Replacement: spec.Replacement,
+				})
+			}
+			if exists && spec.Impure && pCtx.onImpure != nil {
+				pCtx.onImpure()
+			}
+		}
+	}
+	return fn, err
 }
 
 // InitMethod attempts to initialise a method from the available constructors of
 // the parser context.
 func (pCtx Context) InitMethod(name string, target query.Function, args ...interface{}) (query.Function, error) {
-	return pCtx.Methods.Init(name, target, args...)
+	fn, err := pCtx.Methods.Init(name, target, args...)
+	if err == nil && (pCtx.onDeprecated != nil || pCtx.onImpure != nil) {
+		if specs, ok := pCtx.Methods.(interface {
+			Spec(string) (query.MethodSpec, bool)
+		}); ok {
+			spec, exists := specs.Spec(name)
+			if exists && spec.Status == query.StatusDeprecated && pCtx.onDeprecated != nil {
+				pCtx.onDeprecated(Deprecation{
+					Kind:        "method",
+					Name:        name,
+					Replacement: spec.Replacement,
+				})
+			}
+			if exists && spec.Impure && pCtx.onImpure != nil {
+				pCtx.onImpure()
+			}
+		}
+	}
+	return fn, err
+}
+
+// MappingDeprecations parses a mapping and returns a deduplicated list of any
+// deprecated functions or methods that it uses, in the order that they were
+// first encountered. An error is returned if the mapping fails to parse.
+func MappingDeprecations(filepath string, expr string, pCtx Context) ([]Deprecation, *Error) {
+	var deprecations []Deprecation
+	seen := map[Deprecation]struct{}{}
+	if _, err := ParseMapping(filepath, expr, pCtx.WithDeprecationHandler(func(d Deprecation) {
+		if _, exists := seen[d]; !exists {
+			seen[d] = struct{}{}
+			deprecations = append(deprecations, d)
+		}
+	})); err != nil {
+		return nil, err
+	}
+	return deprecations, nil
+}
+
+// ParseMappingCacheable parses a mapping and also returns whether the parsed
+// mapping can be shared between callers that provide an identical mapping,
+// which is false when it imports other files or uses impure functions or
+// methods.
+func ParseMappingCacheable(filepath string, expr string, pCtx Context) (*mapping.Executor, bool, *Error) {
+	cacheable := true
+	exec, err := ParseMapping(filepath, expr, pCtx.WithImpureHandler(func() {
+		cacheable = false
+	}))
+	if err != nil {
+		return nil, false, err
+	}
+	return exec, cacheable, nil
 }
 
 func queryParser(pCtx Context) func(input []rune) Result {
@@ -78,16 +219,48 @@ func queryParser(pCtx Context) func(input []rune) Result {
 	}
 }
 
+// deprecatedQueryFieldRoots are the names that a field literal within a
+// deprecated query may begin with. Other names are rejected so that a typo of a
+// deprecated function such as `${!jsn}` remains an error rather than becoming a
+// field path.
+var deprecatedQueryFieldRoots = map[string]struct{}{
+	"this": {},
+}
+
+// deprecatedFieldLiteralParser parses a field literal with tails, but only when
+// it begins with a name within deprecatedQueryFieldRoots.
+func deprecatedFieldLiteralParser(pCtx Context) Func {
+	fieldParser := parseWithTails(fieldLiteralRootParser(pCtx), pCtx)
+	return func(input []rune) Result {
+		i := 0
+		for ; i < len(input); i++ {
+			c := input[i]
+			if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_') {
+				break
+			}
+		}
+		if _, exists := deprecatedQueryFieldRoots[string(input[:i])]; !exists {
+			return Fail(NewError(input), input)
+		}
+		return fieldParser(input)
+	}
+}
+
 // ParseDeprecatedQuery parses an input into a query.Function, but permits
-// deprecated function interpolations. In order to support old functions this
-// parser does not include field literals.
+// deprecated function interpolations. In order to support old functions field
+// literals are only attempted once the input fails to match a deprecated
+// function, and only when they begin with an explicitly allowed name such as
+// `this`.
 //
 // TODO: V4 Remove this
 func ParseDeprecatedQuery(input []rune) Result {
-	pCtx := Context{
+	return parseDeprecatedQuery(input, Context{
 		Functions: query.AllFunctions,
 		Methods:   query.AllMethods,
-	}
+	})
+}
+
+func parseDeprecatedQuery(input []rune, pCtx Context) Result {
 	rootParser := OneOf(
 		matchExpressionParser(pCtx),
 		ifExpressionParser(pCtx),
@@ -95,6 +268,7 @@ func ParseDeprecatedQuery(input []rune) Result {
 		parseWithTails(literalValueParser(pCtx), pCtx),
 		parseWithTails(functionParser(pCtx), pCtx),
 		parseDeprecatedFunction,
+		deprecatedFieldLiteralParser(pCtx),
 	)
 
 	res := SpacesAndTabs()(input)
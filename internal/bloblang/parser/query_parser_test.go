@@ -41,6 +41,16 @@ func TestFunctionParserErrors(t *testing.T) {
 			deprecated: true,
 			err:        `line 1 char 5: expected function arguments`,
 		},
+		"bad function typo": {
+			input:      `jsn`,
+			deprecated: true,
+			err:        `line 1 char 4: expected function arguments`,
+		},
+		"bad field path": {
+			input:      `this.`,
+			deprecated: true,
+			err:        `line 1 char 6: required: expected method or field path`,
+		},
 		"bad args 7": {
 			input: `json(5)`,
 			err:   `line 1 char 1: expected string argument, received int64`,
@@ -118,6 +128,14 @@ func TestFunctionParserErrors(t *testing.T) {
 			input: `this.(root -> root.foo)`,
 			err:   "line 1 char 7: context label `root` is not allowed",
 		},
+		"duplicate context labels": {
+			input: `this.sort((foo, foo) -> foo.bar)`,
+			err:   "line 1 char 11: context label `foo` is declared more than once",
+		},
+		"shadowed context of multiple labels": {
+			input: `this.(foo -> foo.sort((bar, foo) -> foo.bar))`,
+			err:   "line 1 char 23: context label `foo` would shadow a parent context",
+		},
 		"chained captured context": {
 			input: `this.(foo -> bar -> baz -> baz.foo)`,
 			err:   "line 1 char 14: it would be in poor taste to capture the same context under both 'bar' and 'baz'",
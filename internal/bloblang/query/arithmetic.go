@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -95,14 +96,27 @@ var ErrDivideByZero = errors.New("attempted to divide by zero")
 type intArithmeticFunc func(left, right int64) (int64, error)
 type floatArithmeticFunc func(left, right float64) (float64, error)
 
-// Takes two arithmetic funcs, one for integer values and one for float values
-// and returns a generic arithmetic func. If both values can be represented as
-// integers the integer func is called, otherwise the float func is called.
-func numberDegradationFunc(op ArithmeticOperator, iFn intArithmeticFunc, fFn floatArithmeticFunc) arithmeticOpFunc {
+// Takes three arithmetic funcs, one for decimal values, one for integer values
+// and one for float values and returns a generic arithmetic func. If either
+// value is a decimal the decimal func is called, if both values can be
+// represented as integers the integer func is called, otherwise the float func
+// is called.
+func numberDegradationFunc(op ArithmeticOperator, dFn decimalArithmeticFunc, iFn intArithmeticFunc, fFn floatArithmeticFunc) arithmeticOpFunc {
 	return func(lhs, rhs Function, left, right interface{}) (interface{}, error) {
 		left = ISanitize(left)
 		right = ISanitize(right)
 
+		if leftDec, rightDec, isDec, err := decimalOperands(left, right); isDec {
+			if err != nil {
+				return nil, NewTypeMismatch(op.String(), lhs, rhs, left, right)
+			}
+			res, err := dFn(leftDec, rightDec)
+			if err != nil {
+				return nil, ErrFrom(err, rhs)
+			}
+			return res, nil
+		}
+
 		if leftFloat, leftIsFloat := left.(float64); leftIsFloat {
 			rightFloat, err := IGetNumber(right)
 			if err != nil {
@@ -134,7 +148,7 @@ func numberDegradationFunc(op ArithmeticOperator, iFn intArithmeticFunc, fFn flo
 func prodOp(op ArithmeticOperator) (arithmeticOpFunc, bool) {
 	switch op {
 	case ArithmeticMul:
-		return numberDegradationFunc(op,
+		return numberDegradationFunc(op, decimalMul,
 			func(lhs, rhs int64) (int64, error) {
 				return lhs * rhs, nil
 			},
@@ -143,8 +157,18 @@ func prodOp(op ArithmeticOperator) (arithmeticOpFunc, bool) {
 			},
 		), true
 	case ArithmeticDiv:
-		// Only executes on float values.
+		// Only executes on float or decimal values.
 		return func(lFn, rFn Function, left, right interface{}) (interface{}, error) {
+			if lhs, rhs, isDec, err := decimalOperands(left, right); isDec {
+				if err != nil {
+					return nil, NewTypeMismatch(op.String(), lFn, rFn, left, right)
+				}
+				res, err := decimalDiv(lhs, rhs)
+				if err != nil {
+					return nil, ErrFrom(err, rFn)
+				}
+				return res, nil
+			}
 			lhs, err := IGetNumber(left)
 			if err != nil {
 				return nil, NewTypeMismatch(op.String(), lFn, rFn, left, right)
@@ -159,8 +183,18 @@ func prodOp(op ArithmeticOperator) (arithmeticOpFunc, bool) {
 			return lhs / rhs, nil
 		}, true
 	case ArithmeticMod:
-		// Only executes on integer values.
+		// Only executes on integer or decimal values.
 		return func(lFn, rFn Function, left, right interface{}) (interface{}, error) {
+			if lhs, rhs, isDec, err := decimalOperands(left, right); isDec {
+				if err != nil {
+					return nil, NewTypeMismatch(op.String(), lFn, rFn, left, right)
+				}
+				res, err := decimalMod(lhs, rhs)
+				if err != nil {
+					return nil, ErrFrom(err, rFn)
+				}
+				return res, nil
+			}
 			lhs, err := IGetInt(left)
 			if err != nil {
 				return nil, NewTypeMismatch(op.String(), lFn, rFn, left, right)
@@ -181,7 +215,7 @@ func prodOp(op ArithmeticOperator) (arithmeticOpFunc, bool) {
 func sumOp(op ArithmeticOperator) (arithmeticOpFunc, bool) {
 	switch op {
 	case ArithmeticAdd:
-		numberAdd := numberDegradationFunc(op,
+		numberAdd := numberDegradationFunc(op, decimalAdd,
 			func(left, right int64) (int64, error) {
 				return left + right, nil
 			},
@@ -191,7 +225,7 @@ func sumOp(op ArithmeticOperator) (arithmeticOpFunc, bool) {
 		)
 		return func(lFn, rFn Function, left, right interface{}) (interface{}, error) {
 			switch left.(type) {
-			case float64, int, int64, uint64, json.Number:
+			case float64, int, int64, uint64, json.Number, Decimal:
 				return numberAdd(lFn, rFn, left, right)
 			case string, []byte:
 				lhs, err := IGetString(left)
@@ -207,7 +241,7 @@ func sumOp(op ArithmeticOperator) (arithmeticOpFunc, bool) {
 			return nil, NewTypeMismatch(op.String(), lFn, rFn, left, right)
 		}, true
 	case ArithmeticSub:
-		return numberDegradationFunc(op,
+		return numberDegradationFunc(op, decimalSub,
 			func(lhs, rhs int64) (int64, error) {
 				return lhs - rhs, nil
 			},
@@ -309,6 +343,27 @@ func compareGenericFn(op ArithmeticOperator) func(lhs, rhs interface{}) bool {
 	return nil
 }
 
+// Returns both values as integers when they are both integer types, which
+// allows them to be compared without being coerced into floats and therefore
+// losing precision.
+func integerOperands(left, right interface{}) (l, r *big.Int, ok bool) {
+	if l, ok = iGetBigInt(ISanitize(left)); !ok {
+		return
+	}
+	r, ok = iGetBigInt(ISanitize(right))
+	return
+}
+
+func iGetBigInt(v interface{}) (*big.Int, bool) {
+	switch t := v.(type) {
+	case int64:
+		return big.NewInt(t), true
+	case uint64:
+		return new(big.Int).SetUint64(t), true
+	}
+	return nil, false
+}
+
 func restrictForComparison(v interface{}) interface{} {
 	v = ISanitize(v)
 	switch t := v.(type) {
@@ -339,6 +394,21 @@ func compareOp(op ArithmeticOperator) (arithmeticOpFunc, bool) {
 		boolOpFn := compareBoolFn(op)
 		genericOpFn := compareGenericFn(op)
 		return func(lFn, rFn Function, left, right interface{}) (interface{}, error) {
+			if lhs, rhs, isInt := integerOperands(left, right); isInt && numOpFn != nil {
+				return numOpFn(float64(lhs.Cmp(rhs)), 0), nil
+			}
+			if lhs, rhs, isDec, err := decimalOperands(left, right); isDec {
+				if err == nil && numOpFn != nil {
+					return numOpFn(float64(lhs.Cmp(rhs)), 0), nil
+				}
+				if op == ArithmeticNeq {
+					return true, nil
+				}
+				if op == ArithmeticEq {
+					return false, nil
+				}
+				return nil, NewTypeMismatch(op.String(), lFn, rFn, left, right)
+			}
 			switch lhs := restrictForComparison(left).(type) {
 			case string:
 				if strOpFn == nil {
@@ -11,7 +11,7 @@ import (
 )
 
 func TestArithmeticNumberDegradation(t *testing.T) {
-	fn := numberDegradationFunc(ArithmeticAdd,
+	fn := numberDegradationFunc(ArithmeticAdd, decimalDiv,
 		func(left, right int64) (int64, error) {
 			return left / right, nil
 		},
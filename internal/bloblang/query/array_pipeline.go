@@ -0,0 +1,278 @@
+package query
+
+import (
+	"fmt"
+)
+
+//------------------------------------------------------------------------------
+
+// arrayStage is a method that can be applied to the elements of an array one
+// at a time, allowing a chain of such methods to be executed in a single pass
+// without allocating an intermediate array for each method.
+type arrayStage interface {
+	// step applies the stage to the element at index i of its input, and
+	// returns the resulting element and whether it is kept. When done is true
+	// the stage accepts no further elements.
+	step(ctx FunctionContext, i int, v interface{}) (res interface{}, keep, done bool, err error)
+
+	// finish is called with the length of the input of the stage once all
+	// elements have been stepped through.
+	finish(l int) error
+
+	// fallible returns true when stepping an element through the stage may
+	// return an error, in which case the elements following a later stage that
+	// is done must still be stepped through it.
+	fallible() bool
+}
+
+type arrayStageCtor func(args ...interface{}) (arrayStage, error)
+
+type pipelineStage struct {
+	name   string
+	target Function
+	eager  simpleMethod
+
+	// Nil when the stage requires the whole array, in which case the array is
+	// materialised and the eager method is applied to it.
+	lazy arrayStage
+}
+
+// arrayPipeline is a chain of array methods, where consecutive stages are
+// fused and executed in a single pass over the elements of an array. Values
+// that aren't arrays are passed through the eager implementation of each
+// stage in turn.
+type arrayPipeline struct {
+	source Function
+	stages []pipelineStage
+}
+
+// lazyArrayMethod creates a method constructor where the method is added as a
+// stage of an arrayPipeline, which is fused with any array pipeline that the
+// method is applied to.
+func lazyArrayMethod(name string, eager simpleMethodConstructor, lazy arrayStageCtor) MethodCtor {
+	return func(target Function, args ...interface{}) (Function, error) {
+		fn, err := eager(args...)
+		if err != nil {
+			return nil, err
+		}
+		stage, err := lazy(args...)
+		if err != nil {
+			return nil, err
+		}
+
+		newStage := pipelineStage{
+			name:   name,
+			target: target,
+			eager:  fn,
+			lazy:   stage,
+		}
+		if p, ok := target.(*arrayPipeline); ok {
+			stages := make([]pipelineStage, 0, len(p.stages)+1)
+			stages = append(stages, p.stages...)
+			return &arrayPipeline{
+				source: p.source,
+				stages: append(stages, newStage),
+			}, nil
+		}
+		return &arrayPipeline{
+			source: target,
+			stages: []pipelineStage{newStage},
+		}, nil
+	}
+}
+
+// Annotation returns the annotation of the last method of the pipeline.
+func (p *arrayPipeline) Annotation() string {
+	return "method " + p.stages[len(p.stages)-1].name
+}
+
+// QueryTargets returns the targets of the source of the pipeline.
+func (p *arrayPipeline) QueryTargets(ctx TargetsContext) (TargetsContext, []TargetPath) {
+	return p.source.QueryTargets(ctx)
+}
+
+// Exec executes the source of the pipeline and applies each stage to the
+// result.
+func (p *arrayPipeline) Exec(ctx FunctionContext) (interface{}, error) {
+	v, err := p.source.Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(p.stages); {
+		arr, isArray := v.([]interface{})
+		if !isArray || p.stages[i].lazy == nil {
+			if v, err = p.stages[i].eager(v, ctx); err != nil {
+				return nil, ErrFrom(err, p.stages[i].target)
+			}
+			i++
+			continue
+		}
+
+		j := i + 1
+		for j < len(p.stages) && p.stages[j].lazy != nil {
+			j++
+		}
+		if v, err = runArrayStages(ctx, arr, p.stages[i:j]); err != nil {
+			return nil, err
+		}
+		i = j
+	}
+	return v, nil
+}
+
+func runArrayStages(ctx FunctionContext, arr []interface{}, stages []pipelineStage) ([]interface{}, error) {
+	counts := make([]int, len(stages))
+	result := make([]interface{}, 0, len(arr))
+
+	// The index of a stage that accepts no further elements, as elements
+	// produced by the stages preceding it are no longer needed. Elements are
+	// only skipped when none of the preceding stages can fail, so that an
+	// error is reported for any element that the eager methods would fail on.
+	doneAt := -1
+	canStop := make([]bool, len(stages))
+	for i := range stages {
+		canStop[i] = i == 0 || (canStop[i-1] && !stages[i-1].lazy.fallible())
+	}
+
+	for _, v := range arr {
+		keep := true
+		for i, s := range stages {
+			res, stageKeep, done, err := s.lazy.step(ctx, counts[i], v)
+			if err != nil {
+				return nil, ErrFrom(err, s.target)
+			}
+			counts[i]++
+			if done && doneAt == -1 && canStop[i] {
+				doneAt = i
+			}
+			if !stageKeep {
+				keep = false
+				break
+			}
+			v = res
+		}
+		if keep {
+			result = append(result, v)
+		}
+		if doneAt >= 0 {
+			break
+		}
+	}
+
+	// Stages preceding a stage that finished early have not seen the whole of
+	// their input, and therefore cannot validate its length.
+	first := 0
+	if doneAt >= 0 {
+		first = doneAt
+	}
+	for i := first; i < len(stages); i++ {
+		if err := stages[i].lazy.finish(counts[i]); err != nil {
+			return nil, ErrFrom(err, stages[i].target)
+		}
+	}
+	return result, nil
+}
+
+//------------------------------------------------------------------------------
+
+type mapEachArrayStage struct {
+	mapFn Function
+}
+
+func mapEachStage(args ...interface{}) (arrayStage, error) {
+	mapFn, ok := args[0].(Function)
+	if !ok {
+		return nil, fmt.Errorf("expected query argument, received %T", args[0])
+	}
+	return &mapEachArrayStage{mapFn: mapFn}, nil
+}
+
+func (m *mapEachArrayStage) step(ctx FunctionContext, i int, v interface{}) (interface{}, bool, bool, error) {
+	newV, err := m.mapFn.Exec(ctx.WithValue(v))
+	if err != nil {
+		return nil, false, false, fmt.Errorf("failed to process element %v: %w", i, ErrFrom(err, m.mapFn))
+	}
+	switch newV.(type) {
+	case Delete:
+		return nil, false, false, nil
+	case Nothing:
+		return v, true, false, nil
+	}
+	return newV, true, false, nil
+}
+
+func (m *mapEachArrayStage) finish(int) error {
+	return nil
+}
+
+func (m *mapEachArrayStage) fallible() bool {
+	return true
+}
+
+//------------------------------------------------------------------------------
+
+type filterArrayStage struct {
+	mapFn Function
+}
+
+func filterStage(args ...interface{}) (arrayStage, error) {
+	mapFn, ok := args[0].(Function)
+	if !ok {
+		return nil, fmt.Errorf("expected query argument, received %T", args[0])
+	}
+	return &filterArrayStage{mapFn: mapFn}, nil
+}
+
+func (f *filterArrayStage) step(ctx FunctionContext, i int, v interface{}) (interface{}, bool, bool, error) {
+	res, err := f.mapFn.Exec(ctx.WithValue(v))
+	if err != nil {
+		return nil, false, false, err
+	}
+	b, _ := res.(bool)
+	return v, b, false, nil
+}
+
+func (f *filterArrayStage) finish(int) error {
+	return nil
+}
+
+func (f *filterArrayStage) fallible() bool {
+	return true
+}
+
+//------------------------------------------------------------------------------
+
+type sliceArrayStage struct {
+	start int64
+	end   *int64
+}
+
+// sliceStage returns a nil stage when either bound is negative, as the length
+// of the input is then required before any element can be selected.
+func sliceStage(args ...interface{}) (arrayStage, error) {
+	s := &sliceArrayStage{start: args[0].(int64)}
+	if len(args) > 1 {
+		end := args[1].(int64)
+		s.end = &end
+	}
+	if s.start < 0 || (s.end != nil && *s.end < 0) {
+		return nil, nil
+	}
+	return s, nil
+}
+
+func (s *sliceArrayStage) step(ctx FunctionContext, i int, v interface{}) (interface{}, bool, bool, error) {
+	keep := int64(i) >= s.start && (s.end == nil || int64(i) < *s.end)
+	done := s.end != nil && int64(i)+1 >= *s.end
+	return v, keep, done, nil
+}
+
+func (s *sliceArrayStage) finish(l int) error {
+	_, _, err := sliceBounds(s.start, s.end, int64(l))
+	return err
+}
+
+func (s *sliceArrayStage) fallible() bool {
+	return false
+}
@@ -0,0 +1,168 @@
+package query
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArrayPipelineFusion(t *testing.T) {
+	var calls int
+	double := ClosureFunction("double", func(ctx FunctionContext) (interface{}, error) {
+		calls++
+		return (*ctx.Value()).(int64) * 2, nil
+	}, nil)
+	notSix := ClosureFunction("not six", func(ctx FunctionContext) (interface{}, error) {
+		return (*ctx.Value()).(int64) != 6, nil
+	}, nil)
+
+	source := NewLiteralFunction("", []interface{}{
+		int64(1), int64(2), int64(3), int64(4), int64(5), int64(6), int64(7),
+	})
+
+	fn, err := InitMethod("map_each", source, double)
+	require.NoError(t, err)
+	fn, err = InitMethod("filter", fn, notSix)
+	require.NoError(t, err)
+	fn, err = InitMethod("slice", fn, int64(1), int64(3))
+	require.NoError(t, err)
+
+	p, ok := fn.(*arrayPipeline)
+	require.True(t, ok)
+	assert.Len(t, p.stages, 3)
+	assert.Equal(t, "method slice", fn.Annotation())
+
+	res, err := fn.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(4), int64(8)}, res)
+
+	// Every element is mapped as any of them could fail.
+	assert.Equal(t, 7, calls)
+
+	calls = 0
+	fn, err = InitMethod("slice", source, int64(0), int64(2))
+	require.NoError(t, err)
+	fn, err = InitMethod("map_each", fn, double)
+	require.NoError(t, err)
+
+	res, err = fn.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(2), int64(4)}, res)
+
+	// Elements following the end of a leading slice are not mapped.
+	assert.Equal(t, 2, calls)
+}
+
+func TestArrayPipelineEager(t *testing.T) {
+	isFoo := ClosureFunction("is foo", func(ctx FunctionContext) (interface{}, error) {
+		v := *ctx.Value()
+		if obj, ok := v.(map[string]interface{}); ok {
+			v = obj["value"]
+		}
+		return v == "foo", nil
+	}, nil)
+	upper := ClosureFunction("upper", func(ctx FunctionContext) (interface{}, error) {
+		v := *ctx.Value()
+		if obj, ok := v.(map[string]interface{}); ok {
+			v = obj["value"]
+		}
+		return v.(string) + "!", nil
+	}, nil)
+
+	tests := []struct {
+		name  string
+		input interface{}
+		build func(Function) (Function, error)
+		exp   interface{}
+	}{
+		{
+			name:  "objects",
+			input: map[string]interface{}{"a": "foo", "b": "bar", "c": "foo"},
+			build: func(fn Function) (Function, error) {
+				fn, err := InitMethod("filter", fn, isFoo)
+				if err != nil {
+					return nil, err
+				}
+				return InitMethod("map_each", fn, upper)
+			},
+			exp: map[string]interface{}{"a": "foo!", "c": "foo!"},
+		},
+		{
+			name:  "negative slice",
+			input: []interface{}{"foo", "bar", "foo", "baz", "foo"},
+			build: func(fn Function) (Function, error) {
+				fn, err := InitMethod("filter", fn, isFoo)
+				if err != nil {
+					return nil, err
+				}
+				if fn, err = InitMethod("slice", fn, int64(-2)); err != nil {
+					return nil, err
+				}
+				return InitMethod("map_each", fn, upper)
+			},
+			exp: []interface{}{"foo!", "foo!"},
+		},
+		{
+			name:  "string slice",
+			input: "foo bar",
+			build: func(fn Function) (Function, error) {
+				return InitMethod("slice", fn, int64(4))
+			},
+			exp: "bar",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			fn, err := test.build(NewLiteralFunction("", test.input))
+			require.NoError(t, err)
+
+			res, err := fn.Exec(FunctionContext{})
+			require.NoError(t, err)
+			assert.Equal(t, test.exp, res)
+		})
+	}
+}
+
+func TestArrayPipelineErrors(t *testing.T) {
+	isEven := ClosureFunction("is even", func(ctx FunctionContext) (interface{}, error) {
+		return (*ctx.Value()).(int64)%2 == 0, nil
+	}, nil)
+	failOnFour := ClosureFunction("fail on four", func(ctx FunctionContext) (interface{}, error) {
+		if v := (*ctx.Value()).(int64); v == 4 {
+			return nil, errors.New("nope")
+		}
+		return nil, nil
+	}, nil)
+
+	source := NewLiteralFunction("", []interface{}{
+		int64(1), int64(2), int64(3), int64(4),
+	})
+
+	fn, err := InitMethod("filter", source, isEven)
+	require.NoError(t, err)
+	mapped, err := InitMethod("map_each", fn, failOnFour)
+	require.NoError(t, err)
+
+	// The index of the failed element is relative to the input of map_each.
+	_, err = mapped.Exec(FunctionContext{})
+	require.EqualError(t, err, "failed to process element 1: fail on four: nope")
+
+	sliced, err := InitMethod("slice", fn, int64(3))
+	require.NoError(t, err)
+
+	_, err = sliced.Exec(FunctionContext{})
+	require.EqualError(t, err, "method filter: lower slice bound 3 must be lower than or equal to upper bound (2) and target length (2)")
+
+	// Elements beyond the end of a slice still fail the stages preceding it.
+	mapped, err = InitMethod("map_each", source, failOnFour)
+	require.NoError(t, err)
+	sliced, err = InitMethod("slice", mapped, int64(0), int64(1))
+	require.NoError(t, err)
+
+	_, err = sliced.Exec(FunctionContext{})
+	require.EqualError(t, err, "failed to process element 3: fail on four: nope")
+}
@@ -0,0 +1,267 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// DecimalDivisionPlaces is the number of decimal places that the result of a
+// division between decimal values is rounded to, as the exact result may not
+// have a finite decimal representation.
+const DecimalDivisionPlaces = 16
+
+// Decimal is an arbitrary precision decimal number. Arithmetic where either
+// operand is a Decimal is performed without coercing values into float64, and
+// results are serialized exactly.
+//
+// Decimals are immutable, operations always return a new value.
+type Decimal struct {
+	rat *big.Rat
+}
+
+// NewDecimal attempts to parse a string as a decimal number, exponents are
+// supported (e.g. `1.5e3`) but fractions are not.
+func NewDecimal(s string) (Decimal, error) {
+	for _, c := range s {
+		if c == '/' {
+			return Decimal{}, fmt.Errorf("failed to parse '%v' as a decimal", s)
+		}
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Decimal{}, fmt.Errorf("failed to parse '%v' as a decimal", s)
+	}
+	return Decimal{rat: r}, nil
+}
+
+func newDecimalFromRat(r *big.Rat) Decimal {
+	return Decimal{rat: r}
+}
+
+func (d Decimal) getRat() *big.Rat {
+	if d.rat == nil {
+		return new(big.Rat)
+	}
+	return d.rat
+}
+
+// places returns the number of decimal places required in order to represent
+// the value exactly, which is always finite for values that are the result of
+// parsing or arithmetic between decimals.
+func (d Decimal) places() int {
+	denom := new(big.Int).Set(d.getRat().Denom())
+	two, five := big.NewInt(2), big.NewInt(5)
+	mod := new(big.Int)
+
+	var twos, fives int
+	for {
+		if q, _ := new(big.Int).QuoRem(denom, two, mod); mod.Sign() == 0 {
+			denom, twos = q, twos+1
+			continue
+		}
+		break
+	}
+	for {
+		if q, _ := new(big.Int).QuoRem(denom, five, mod); mod.Sign() == 0 {
+			denom, fives = q, fives+1
+			continue
+		}
+		break
+	}
+	if fives > twos {
+		return fives
+	}
+	return twos
+}
+
+// String returns the exact decimal representation of the value.
+func (d Decimal) String() string {
+	return d.getRat().FloatString(d.places())
+}
+
+// MarshalJSON serializes the decimal as an exact JSON number.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Float64 returns the nearest float64 value of the decimal.
+func (d Decimal) Float64() float64 {
+	f, _ := d.getRat().Float64()
+	return f
+}
+
+// Int64 returns the decimal truncated towards zero as an int64.
+func (d Decimal) Int64() int64 {
+	r := d.getRat()
+	return new(big.Int).Quo(r.Num(), r.Denom()).Int64()
+}
+
+// Cmp compares two decimals and returns -1, 0 or +1 depending on whether d is
+// less than, equal to or greater than o.
+func (d Decimal) Cmp(o Decimal) int {
+	return d.getRat().Cmp(o.getRat())
+}
+
+//------------------------------------------------------------------------------
+
+// IToDecimal takes a boxed value and attempts to convert it into a Decimal.
+// Floating point numbers are converted via their shortest exact string
+// representation, such that `0.1` becomes exactly `0.1`.
+func IToDecimal(v interface{}) (Decimal, error) {
+	switch t := v.(type) {
+	case Decimal:
+		return t, nil
+	case int:
+		return newDecimalFromRat(new(big.Rat).SetInt64(int64(t))), nil
+	case int64:
+		return newDecimalFromRat(new(big.Rat).SetInt64(t)), nil
+	case uint64:
+		return newDecimalFromRat(new(big.Rat).SetInt(new(big.Int).SetUint64(t))), nil
+	case float64:
+		return NewDecimal(strconv.FormatFloat(t, 'f', -1, 64))
+	case json.Number:
+		return NewDecimal(t.String())
+	case []byte:
+		return NewDecimal(string(t))
+	case string:
+		return NewDecimal(t)
+	}
+	return Decimal{}, NewTypeError(v, ValueNumber, ValueString)
+}
+
+// Returns both values as decimals when either of them is a decimal, otherwise
+// false is returned.
+func decimalOperands(left, right interface{}) (l, r Decimal, ok bool, err error) {
+	_, leftIsDec := left.(Decimal)
+	_, rightIsDec := right.(Decimal)
+	if !leftIsDec && !rightIsDec {
+		return
+	}
+	ok = true
+	if l, err = iNumberToDecimal(left); err != nil {
+		return
+	}
+	r, err = iNumberToDecimal(right)
+	return
+}
+
+// Similar to IToDecimal but does not parse strings.
+func iNumberToDecimal(v interface{}) (Decimal, error) {
+	if ITypeOf(v) != ValueNumber {
+		return Decimal{}, NewTypeError(v, ValueNumber)
+	}
+	return IToDecimal(ISanitize(v))
+}
+
+//------------------------------------------------------------------------------
+
+// DecimalRoundingMode describes how a decimal is rounded when precision is
+// lost.
+type DecimalRoundingMode string
+
+// Decimal rounding modes.
+var (
+	DecimalRoundHalfUp   DecimalRoundingMode = "half_up"
+	DecimalRoundHalfDown DecimalRoundingMode = "half_down"
+	DecimalRoundHalfEven DecimalRoundingMode = "half_even"
+	DecimalRoundUp       DecimalRoundingMode = "up"
+	DecimalRoundDown     DecimalRoundingMode = "down"
+	DecimalRoundCeiling  DecimalRoundingMode = "ceiling"
+	DecimalRoundFloor    DecimalRoundingMode = "floor"
+)
+
+func parseDecimalRoundingMode(s string) (DecimalRoundingMode, error) {
+	switch m := DecimalRoundingMode(s); m {
+	case DecimalRoundHalfUp, DecimalRoundHalfDown, DecimalRoundHalfEven,
+		DecimalRoundUp, DecimalRoundDown, DecimalRoundCeiling, DecimalRoundFloor:
+		return m, nil
+	}
+	return "", fmt.Errorf("unrecognised rounding mode: %v", s)
+}
+
+// Round returns the decimal rounded to a number of decimal places using the
+// provided rounding mode. Negative places round to the left of the decimal
+// point.
+func (d Decimal) Round(places int, mode DecimalRoundingMode) Decimal {
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(places))), nil))
+	if places < 0 {
+		scale.Inv(scale)
+	}
+
+	scaled := new(big.Rat).Mul(d.getRat(), scale)
+	quo, rem := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if rem.Sign() != 0 {
+		// Compare twice the remainder against the denominator in order to
+		// determine whether we're below, at, or above the halfway point.
+		half := new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(scaled.Denom())
+		negative := scaled.Sign() < 0
+
+		var awayFromZero bool
+		switch mode {
+		case DecimalRoundHalfUp:
+			awayFromZero = half >= 0
+		case DecimalRoundHalfDown:
+			awayFromZero = half > 0
+		case DecimalRoundHalfEven:
+			awayFromZero = half > 0 || (half == 0 && quo.Bit(0) == 1)
+		case DecimalRoundUp:
+			awayFromZero = true
+		case DecimalRoundDown:
+			awayFromZero = false
+		case DecimalRoundCeiling:
+			awayFromZero = !negative
+		case DecimalRoundFloor:
+			awayFromZero = negative
+		}
+		if awayFromZero {
+			if negative {
+				quo.Sub(quo, big.NewInt(1))
+			} else {
+				quo.Add(quo, big.NewInt(1))
+			}
+		}
+	}
+
+	return newDecimalFromRat(new(big.Rat).Quo(new(big.Rat).SetInt(quo), scale))
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+//------------------------------------------------------------------------------
+
+type decimalArithmeticFunc func(left, right Decimal) (Decimal, error)
+
+func decimalAdd(left, right Decimal) (Decimal, error) {
+	return newDecimalFromRat(new(big.Rat).Add(left.getRat(), right.getRat())), nil
+}
+
+func decimalSub(left, right Decimal) (Decimal, error) {
+	return newDecimalFromRat(new(big.Rat).Sub(left.getRat(), right.getRat())), nil
+}
+
+func decimalMul(left, right Decimal) (Decimal, error) {
+	return newDecimalFromRat(new(big.Rat).Mul(left.getRat(), right.getRat())), nil
+}
+
+func decimalDiv(left, right Decimal) (Decimal, error) {
+	if right.getRat().Sign() == 0 {
+		return Decimal{}, ErrDivideByZero
+	}
+	res := newDecimalFromRat(new(big.Rat).Quo(left.getRat(), right.getRat()))
+	return res.Round(DecimalDivisionPlaces, DecimalRoundHalfEven), nil
+}
+
+func decimalMod(left, right Decimal) (Decimal, error) {
+	if right.getRat().Sign() == 0 {
+		return Decimal{}, ErrDivideByZero
+	}
+	quo := newDecimalFromRat(new(big.Rat).Quo(left.getRat(), right.getRat())).Round(0, DecimalRoundDown)
+	return newDecimalFromRat(new(big.Rat).Sub(left.getRat(), new(big.Rat).Mul(quo.getRat(), right.getRat()))), nil
+}
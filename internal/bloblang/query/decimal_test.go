@@ -0,0 +1,189 @@
+package query
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustDecimal(t testing.TB, s string) Decimal {
+	t.Helper()
+	d, err := NewDecimal(s)
+	require.NoError(t, err)
+	return d
+}
+
+func TestDecimalParse(t *testing.T) {
+	tests := map[string]struct {
+		input  interface{}
+		output string
+		err    string
+	}{
+		"float":           {input: 0.1, output: "0.1"},
+		"negative float":  {input: -12.5, output: "-12.5"},
+		"int":             {input: int64(10), output: "10"},
+		"uint":            {input: uint64(18446744073709551615), output: "18446744073709551615"},
+		"json number":     {input: json.Number("1.000000000000000000001"), output: "1.000000000000000000001"},
+		"string":          {input: "123.4500", output: "123.45"},
+		"exponent string": {input: "1.5e3", output: "1500"},
+		"bad string":      {input: "nope", err: "failed to parse 'nope' as a decimal"},
+		"fraction string": {input: "1/3", err: "failed to parse '1/3' as a decimal"},
+		"bool":            {input: true, err: "expected number or string value, got bool (true)"},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			d, err := IToDecimal(test.input)
+			if len(test.err) > 0 {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.output, d.String())
+		})
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		input  string
+		places int
+		mode   DecimalRoundingMode
+		output string
+	}{
+		{input: "2.675", places: 2, mode: DecimalRoundHalfEven, output: "2.68"},
+		{input: "2.665", places: 2, mode: DecimalRoundHalfEven, output: "2.66"},
+		{input: "2.665", places: 2, mode: DecimalRoundHalfUp, output: "2.67"},
+		{input: "2.665", places: 2, mode: DecimalRoundHalfDown, output: "2.66"},
+		{input: "2.6651", places: 2, mode: DecimalRoundHalfDown, output: "2.67"},
+		{input: "-2.665", places: 2, mode: DecimalRoundHalfUp, output: "-2.67"},
+		{input: "-2.665", places: 2, mode: DecimalRoundHalfDown, output: "-2.66"},
+		{input: "2.661", places: 2, mode: DecimalRoundUp, output: "2.67"},
+		{input: "-2.661", places: 2, mode: DecimalRoundUp, output: "-2.67"},
+		{input: "2.669", places: 2, mode: DecimalRoundDown, output: "2.66"},
+		{input: "-2.669", places: 2, mode: DecimalRoundDown, output: "-2.66"},
+		{input: "2.661", places: 2, mode: DecimalRoundCeiling, output: "2.67"},
+		{input: "-2.669", places: 2, mode: DecimalRoundCeiling, output: "-2.66"},
+		{input: "2.669", places: 2, mode: DecimalRoundFloor, output: "2.66"},
+		{input: "-2.661", places: 2, mode: DecimalRoundFloor, output: "-2.67"},
+		{input: "1250", places: -2, mode: DecimalRoundHalfEven, output: "1200"},
+		{input: "1350", places: -2, mode: DecimalRoundHalfEven, output: "1400"},
+		{input: "5.5", places: 0, mode: DecimalRoundHalfEven, output: "6"},
+		{input: "1.5", places: 4, mode: DecimalRoundHalfEven, output: "1.5"},
+	}
+
+	for _, test := range tests {
+		res := mustDecimal(t, test.input).Round(test.places, test.mode)
+		assert.Equal(t, test.output, res.String(), "%v %v %v", test.input, test.places, test.mode)
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	tests := map[string]struct {
+		left   interface{}
+		right  interface{}
+		op     ArithmeticOperator
+		output interface{}
+		err    string
+	}{
+		"add": {
+			left: mustDecimal(t, "0.1"), right: 0.2,
+			op: ArithmeticAdd, output: "0.3",
+		},
+		"subtract": {
+			left: int64(1), right: mustDecimal(t, "0.9"),
+			op: ArithmeticSub, output: "0.1",
+		},
+		"multiply": {
+			left: mustDecimal(t, "19.99"), right: int64(3),
+			op: ArithmeticMul, output: "59.97",
+		},
+		"divide": {
+			left: mustDecimal(t, "1"), right: mustDecimal(t, "3"),
+			op: ArithmeticDiv, output: "0.3333333333333333",
+		},
+		"divide exact": {
+			left: mustDecimal(t, "10"), right: int64(4),
+			op: ArithmeticDiv, output: "2.5",
+		},
+		"modulo": {
+			left: mustDecimal(t, "10.5"), right: int64(3),
+			op: ArithmeticMod, output: "1.5",
+		},
+		"divide by zero": {
+			left: mustDecimal(t, "10"), right: int64(0),
+			op:  ArithmeticDiv,
+			err: "right: attempted to divide by zero",
+		},
+		"add string": {
+			left: mustDecimal(t, "10"), right: "5",
+			op:  ArithmeticAdd,
+			err: "cannot add types number (from left) and string (from right)",
+		},
+		"equals": {
+			left: mustDecimal(t, "0.3"), right: 0.3,
+			op: ArithmeticEq, output: true,
+		},
+		"not equals": {
+			left: 0.1, right: mustDecimal(t, "0.10000000000000001"),
+			op: ArithmeticNeq, output: true,
+		},
+		"greater than": {
+			left: mustDecimal(t, "2.5"), right: int64(2),
+			op: ArithmeticGt, output: true,
+		},
+		"less than or equal": {
+			left: mustDecimal(t, "2.5"), right: int64(2),
+			op: ArithmeticLte, output: false,
+		},
+		"equals string": {
+			left: mustDecimal(t, "2.5"), right: "2.5",
+			op: ArithmeticEq, output: false,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			fn, err := NewArithmeticExpression([]Function{
+				ClosureFunction("left", func(ctx FunctionContext) (interface{}, error) {
+					return test.left, nil
+				}, nil),
+				ClosureFunction("right", func(ctx FunctionContext) (interface{}, error) {
+					return test.right, nil
+				}, nil),
+			}, []ArithmeticOperator{test.op})
+			require.NoError(t, err)
+
+			res, err := fn.Exec(FunctionContext{})
+			if len(test.err) > 0 {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			if d, ok := res.(Decimal); ok {
+				res = d.String()
+			}
+			assert.Equal(t, test.output, res)
+		})
+	}
+}
+
+func TestDecimalSerialize(t *testing.T) {
+	b, err := json.Marshal(map[string]interface{}{
+		"a": mustDecimal(t, "1234567890.0987654321"),
+		"b": mustDecimal(t, "-0.5"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1234567890.0987654321,"b":-0.5}`, string(b))
+
+	assert.Equal(t, ValueNumber, ITypeOf(mustDecimal(t, "1")))
+	assert.Equal(t, "2.5", IToString(mustDecimal(t, "2.50")))
+
+	f, err := IToNumber(mustDecimal(t, "2.5"))
+	require.NoError(t, err)
+	assert.Equal(t, 2.5, f)
+}
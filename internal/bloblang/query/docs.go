@@ -66,6 +66,15 @@ type FunctionSpec struct {
 
 	// Examples shows general usage for the function.
 	Examples []ExampleSpec
+
+	// Replacement is the name of a function that should be used instead of
+	// this one when it is deprecated.
+	Replacement string
+
+	// Impure is true when the function resolves external state when it is
+	// parsed, or holds state for each parsed instance, and therefore a parsed
+	// mapping that uses it cannot be shared.
+	Impure bool
 }
 
 // NewFunctionSpec creates a new function spec.
@@ -85,6 +94,21 @@ func (s FunctionSpec) Beta() FunctionSpec {
 	return s
 }
 
+// ReplacedBy sets the name of a function that should be used instead of this
+// one, which is suggested to users when a deprecated function is used.
+func (s FunctionSpec) ReplacedBy(name string) FunctionSpec {
+	s.Replacement = name
+	return s
+}
+
+// MarkImpure flags the function as resolving external state when it is parsed,
+// or holding state for each parsed instance, which prevents parsed mappings
+// that use it from being shared.
+func (s FunctionSpec) MarkImpure() FunctionSpec {
+	s.Impure = true
+	return s
+}
+
 // NewDeprecatedFunctionSpec creates a new function spec that is deprecated.
 func NewDeprecatedFunctionSpec(name, description string, examples ...ExampleSpec) FunctionSpec {
 	return FunctionSpec{
@@ -147,6 +171,15 @@ type MethodSpec struct {
 
 	// Categories that this method fits within.
 	Categories []MethodCatSpec
+
+	// Replacement is the name of a method that should be used instead of this
+	// one when it is deprecated.
+	Replacement string
+
+	// Impure is true when the method resolves external state when it is
+	// parsed, or holds state for each parsed instance, and therefore a parsed
+	// mapping that uses it cannot be shared.
+	Impure bool
 }
 
 // NewMethodSpec creates a new method spec.
@@ -184,6 +217,21 @@ func (m MethodSpec) Beta() MethodSpec {
 	return m
 }
 
+// ReplacedBy sets the name of a method that should be used instead of this
+// one, which is suggested to users when a deprecated method is used.
+func (m MethodSpec) ReplacedBy(name string) MethodSpec {
+	m.Replacement = name
+	return m
+}
+
+// MarkImpure flags the method as resolving external state when it is parsed,
+// or holding state for each parsed instance, which prevents parsed mappings
+// that use it from being shared.
+func (m MethodSpec) MarkImpure() MethodSpec {
+	m.Impure = true
+	return m
+}
+
 // InCategory describes the methods behaviour in the context of a given
 // category, methods can belong to multiple categories. For example, the
 // `contains` method behaves differently in the object and array category versus
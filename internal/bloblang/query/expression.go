@@ -2,6 +2,7 @@ package query
 
 import (
 	"fmt"
+	"strings"
 )
 
 // MatchCase represents a single match case of a match expression, where a case
@@ -89,19 +90,33 @@ func NewIfFunction(queryFn Function, ifFn Function, elseFn Function) Function {
 // is executed with a new context the context is captured under a new name, with
 // the "main" context left intact.
 func NewNamedContextFunction(name string, fn Function) Function {
-	return &NamedContextFunction{name, fn}
+	return &NamedContextFunction{names: []string{name}, fn: fn}
+}
+
+// NewNamedContextsFunction wraps a function and ensures that when the function
+// is executed with a new context, which must be an array with a length matching
+// the number of names, each element of the context is captured under the name
+// of the same index, with the "main" context left intact.
+func NewNamedContextsFunction(names []string, fn Function) Function {
+	return &NamedContextFunction{names: names, fn: fn}
 }
 
 // NamedContextFunction wraps a query function in a mechanism that captures the
 // current context under an alias.
 type NamedContextFunction struct {
-	name string
-	fn   Function
+	names []string
+	fn    Function
 }
 
-// Name returns the alias under which the context will be captured.
+// Name returns the alias under which the context will be captured, or a comma
+// separated list of aliases when the context is captured under multiple names.
 func (n *NamedContextFunction) Name() string {
-	return n.name
+	return strings.Join(n.names, ", ")
+}
+
+// Names returns the aliases under which the context will be captured.
+func (n *NamedContextFunction) Names() []string {
+	return n.names
 }
 
 // Annotation returns the annotation of the underlying function.
@@ -114,10 +129,25 @@ func (n *NamedContextFunction) Annotation() string {
 func (n *NamedContextFunction) Exec(ctx FunctionContext) (interface{}, error) {
 	v, nextCtx := ctx.PopValue()
 	if v == nil {
-		return nil, fmt.Errorf("failed to capture context %v: %w", n.name, ErrNoContext)
+		return nil, fmt.Errorf("failed to capture context %v: %w", n.Name(), ErrNoContext)
+	}
+	if len(n.names) == 1 {
+		if n.names[0] != "_" {
+			nextCtx = nextCtx.WithNamedValue(n.names[0], *v)
+		}
+		return n.fn.Exec(nextCtx)
+	}
+	values, ok := (*v).([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to capture context %v: %w", n.Name(), NewTypeError(*v, ValueArray))
 	}
-	if n.name != "_" {
-		nextCtx = nextCtx.WithNamedValue(n.name, *v)
+	if len(values) != len(n.names) {
+		return nil, fmt.Errorf("failed to capture context %v: expected array of %v values, received %v", n.Name(), len(n.names), len(values))
+	}
+	for i, name := range n.names {
+		if name != "_" {
+			nextCtx = nextCtx.WithNamedValue(name, values[i])
+		}
 	}
 	return n.fn.Exec(nextCtx)
 }
@@ -125,10 +155,23 @@ func (n *NamedContextFunction) Exec(ctx FunctionContext) (interface{}, error) {
 // QueryTargets provides a summary of which fields the underlying query function
 // targets.
 func (n *NamedContextFunction) QueryTargets(ctx TargetsContext) (TargetsContext, []TargetPath) {
-	if n.name == "_" {
-		ctx = ctx.PopContext()
-	} else {
-		ctx = ctx.WithContextAsNamed(n.name)
+	if len(n.names) == 1 {
+		if n.names[0] == "_" {
+			ctx = ctx.PopContext()
+		} else {
+			ctx = ctx.WithContextAsNamed(n.names[0])
+		}
+		return n.fn.QueryTargets(ctx)
+	}
+
+	// The paths of individual elements are unknown and so each name is given
+	// the paths of the context as a whole.
+	paths := ctx.MainContext()
+	ctx = ctx.PopContext()
+	for _, name := range n.names {
+		if name != "_" {
+			ctx = ctx.withNamedPaths(name, paths)
+		}
 	}
 	return n.fn.QueryTargets(ctx)
 }
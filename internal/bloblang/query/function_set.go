@@ -37,6 +37,35 @@ func (f *FunctionSet) Add(spec FunctionSpec, ctor FunctionCtor, autoResolveFunct
 	return nil
 }
 
+// Alias adds a deprecated function to this set under a new name that behaves
+// identically to an existing function. Usage of the alias suggests the target
+// function as a replacement.
+func (f *FunctionSet) Alias(alias, target string) error {
+	if !nameRegexp.MatchString(alias) {
+		return fmt.Errorf("function name '%v' does not match the required regular expression /%v/", alias, nameRegexpRaw)
+	}
+	ctor, exists := f.constructors[target]
+	if !exists {
+		return fmt.Errorf("function alias target does not exist: %v", target)
+	}
+	if _, exists := f.constructors[alias]; exists {
+		return fmt.Errorf("conflicting function name: %v", alias)
+	}
+	f.constructors[alias] = ctor
+	f.specs = append(f.specs, NewDeprecatedFunctionSpec(alias, fmt.Sprintf("This function is a deprecated alias of `%v`, which should be used instead.", target)).ReplacedBy(target))
+	return nil
+}
+
+// Spec returns the spec of a function of the set by name.
+func (f *FunctionSet) Spec(name string) (FunctionSpec, bool) {
+	for _, spec := range f.specs {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return FunctionSpec{}, false
+}
+
 // Docs returns a slice of function specs, which document each function.
 func (f *FunctionSet) Docs() []FunctionSpec {
 	return f.specs
@@ -51,3 +51,22 @@ func TestFunctionBadName(t *testing.T) {
 		})
 	}
 }
+
+func TestFunctionSetAlias(t *testing.T) {
+	setOne := AllFunctions.Without()
+	assert.NoError(t, setOne.Alias("uuid_v4_alias", "uuid_v4"))
+	assert.EqualError(t, setOne.Alias("uuid_v4_alias", "uuid_v4"), "conflicting function name: uuid_v4_alias")
+	assert.EqualError(t, setOne.Alias("nope_alias", "nope"), "function alias target does not exist: nope")
+	assert.NotContains(t, AllFunctions.List(), "uuid_v4_alias")
+
+	_, err := setOne.Init("uuid_v4_alias")
+	assert.NoError(t, err)
+
+	spec, exists := setOne.Spec("uuid_v4_alias")
+	assert.True(t, exists)
+	assert.Equal(t, StatusDeprecated, spec.Status)
+	assert.Equal(t, "uuid_v4", spec.Replacement)
+
+	_, exists = setOne.Spec("nope")
+	assert.False(t, exists)
+}
@@ -2,13 +2,18 @@ package query
 
 import (
 	"context"
+	crand "crypto/rand"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/cel"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Jeffail/gabs/v2"
 	"github.com/gofrs/uuid"
@@ -178,6 +183,60 @@ var _ = registerSimpleFunction(
 
 //------------------------------------------------------------------------------
 
+var _ = RegisterFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "cel",
+		"Evaluates an expression written in the [Common Expression Language (CEL)](https://github.com/google/cel-spec) and returns the result, which allows existing CEL policies to be used as the `check` of components. Within the expression the variable `this` references the current context in the same way as a mapping, `meta` is a map of the metadata of the message and `content` is the raw contents of the message as a string. The expression is compiled when the mapping is parsed, and therefore must be a string literal.\n\nNumbers parsed from JSON documents are doubles, and since CEL does not compare numbers of different types they must be compared with double literals such as `18.0` rather than `18`.",
+		NewExampleSpec("",
+			`root.adult = cel("this.age >= 18.0")`,
+			`{"age":20}`,
+			`{"adult":true}`,
+			`{"age":15}`,
+			`{"adult":false}`,
+		),
+		NewExampleSpec("",
+			`root.tags = cel("this.tags.filter(t, t.startsWith('a'))")`,
+			`{"tags":["apple","banana","avocado"]}`,
+			`{"tags":["apple","avocado"]}`,
+		),
+		NewExampleSpec(
+			"Expressions containing double quotes can be written within triple quoted strings.",
+			`root = if cel("""meta.topic == "orders" && this.total > 100.0""") { this } else { deleted() }`,
+		),
+	).Beta(),
+	false, celFunction,
+	ExpectNArgs(1),
+	ExpectStringArg(0),
+)
+
+func celFunction(args ...interface{}) (Function, error) {
+	prg, err := cel.Compile(args[0].(string))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile CEL expression: %w", err)
+	}
+	return ClosureFunction("function cel", func(ctx FunctionContext) (interface{}, error) {
+		var this interface{}
+		if v := ctx.Value(); v != nil {
+			this = *v
+		}
+		part := ctx.MsgBatch.Get(ctx.Index)
+		meta := map[string]string{}
+		part.Metadata().Iter(func(k, v string) error {
+			meta[k] = v
+			return nil
+		})
+		return prg.Eval(this, meta, part.Get())
+	}, func(ctx TargetsContext) (TargetsContext, []TargetPath) {
+		paths := []TargetPath{
+			NewTargetPath(TargetValue),
+			NewTargetPath(TargetMetadata),
+		}
+		return ctx, paths
+	}), nil
+}
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleFunction(
 	NewFunctionSpec(
 		FunctionCategoryMessage, "content",
@@ -263,19 +322,30 @@ root.bar = deleted()`,
 var _ = RegisterFunction(
 	NewFunctionSpec(
 		FunctionCategoryEnvironment, "env",
-		"Returns the value of an environment variable.",
+		"Returns the value of an environment variable. An optional second argument can be provided, in which case it is returned when the variable is unset or empty. In order to fail when a variable is missing chain the method [`required`](/docs/guides/bloblang/methods#required), which for a variable without a default results in an error when the mapping is parsed, and therefore when a config is linted or started.",
 		NewExampleSpec("",
 			`root.thing.key = env("key")`,
 		),
-	),
+		NewExampleSpec("",
+			`root.thing.key = env("key", "default value")`,
+		),
+		NewExampleSpec("",
+			`root.thing.url = env("TARGET_URL").required()`,
+		),
+	).MarkImpure(),
 	true, envFunction,
-	ExpectNArgs(1),
+	ExpectBetweenNAndMArgs(1, 2),
 	ExpectStringArg(0),
+	ExpectStringArg(1),
 )
 
 func envFunction(args ...interface{}) (Function, error) {
-	key := os.Getenv(args[0].(string))
-	return NewLiteralFunction("env "+key, key), nil
+	name := args[0].(string)
+	value := os.Getenv(name)
+	if value == "" && len(args) > 1 {
+		value = args[1].(string)
+	}
+	return NewLiteralFunction("environment variable "+name, value), nil
 }
 
 //------------------------------------------------------------------------------
@@ -306,26 +376,104 @@ var _ = registerSimpleFunction(
 	},
 )
 
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "error_class",
+		"If an error has occurred during the processing of a message this function returns the class of the error, which is either `retryable` for errors that are likely to be temporary, such as timeouts and rate limits, or `permanent` otherwise. Returns an empty string if the message has not failed. For more information about error handling patterns read [here][error_handling].",
+		NewExampleSpec("",
+			`root = if error_class() == "permanent" { deleted() }`,
+		),
+	),
+	func(ctx FunctionContext) (interface{}, error) {
+		part := ctx.MsgBatch.Get(ctx.Index)
+		if len(part.Metadata().Get(types.FailFlagKey)) == 0 {
+			return "", nil
+		}
+		if class := message.GetFailureDetails(part).Class; len(class) > 0 {
+			return class, nil
+		}
+		return types.ErrorClassPermanent, nil
+	},
+)
+
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "error_source",
+		"If an error has occurred during the processing of a message this function returns the path of the pipeline component that reported the error, which is the label of the processor if it has one. Returns an empty string if the message has not failed or the path is unknown. For more information about error handling patterns read [here][error_handling].",
+		NewExampleSpec("",
+			`root.doc.error_source = error_source()`,
+		),
+	),
+	func(ctx FunctionContext) (interface{}, error) {
+		return message.GetFailureDetails(ctx.MsgBatch.Get(ctx.Index)).Path, nil
+	},
+)
+
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "error_count",
+		"Returns the number of times a message has been flagged with an error since its errors were last cleared, or zero if the message has not failed. For more information about error handling patterns read [here][error_handling].",
+		NewExampleSpec("",
+			`root.doc.attempts = error_count()`,
+		),
+	),
+	func(ctx FunctionContext) (interface{}, error) {
+		return int64(message.GetFailureDetails(ctx.MsgBatch.Get(ctx.Index)).Count), nil
+	},
+)
+
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "error_timestamp",
+		"If an error has occurred during the processing of a message this function returns the time at which it occurred as an RFC 3339 formatted string. Returns an empty string if the message has not failed. For more information about error handling patterns read [here][error_handling].",
+		NewExampleSpec("",
+			`root.doc.failed_at = error_timestamp()`,
+		),
+	),
+	func(ctx FunctionContext) (interface{}, error) {
+		ts := message.GetFailureDetails(ctx.MsgBatch.Get(ctx.Index)).Timestamp
+		if ts.IsZero() {
+			return "", nil
+		}
+		return ts.UTC().Format(time.RFC3339Nano), nil
+	},
+)
+
 //------------------------------------------------------------------------------
 
 var _ = RegisterFunction(
 	NewFunctionSpec(
 		FunctionCategoryEnvironment, "file",
-		"Reads a file and returns its contents. Relative paths are resolved from the directory of the process executing the mapping.",
+		"Reads a file and returns its contents. Relative paths are resolved from the directory of the process executing the mapping. The file is read once when the mapping is initialised and its contents are cached for all executions, which makes it suitable for loading lookup tables and templates. An optional second boolean argument `no_cache` can be set to `true` in order to instead read the file each time the function is executed, in which case a missing file results in an error at execution rather than when the mapping is parsed.",
 		NewExampleSpec("",
 			`root.doc = file(env("BENTHOS_TEST_BLOBLANG_FILE")).parse_json()`,
 			`{}`,
 			`{"doc":{"foo":"bar"}}`,
 		),
-	).Beta(),
+		NewExampleSpec("",
+			`root.doc = file(env("BENTHOS_TEST_BLOBLANG_FILE"), true).parse_json()`,
+			`{}`,
+			`{"doc":{"foo":"bar"}}`,
+		),
+	).Beta().MarkImpure(),
 	true, fileFunction,
-	ExpectNArgs(1),
+	ExpectBetweenNAndMArgs(1, 2),
 	ExpectStringArg(0),
+	ExpectBoolArg(1),
 )
 
 func fileFunction(args ...interface{}) (Function, error) {
 	path := args[0].(string)
-	pathBytes, err := ioutil.ReadFile(args[0].(string))
+	if len(args) > 1 && args[1].(bool) {
+		return ClosureFunction("function file", func(ctx FunctionContext) (interface{}, error) {
+			pathBytes, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			return pathBytes, nil
+		}, nil), nil
+	}
+	pathBytes, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
@@ -334,6 +482,188 @@ func fileFunction(args ...interface{}) (Function, error) {
 
 //------------------------------------------------------------------------------
 
+var _ = RegisterFunction(
+	NewFunctionSpec(
+		FunctionCategoryEnvironment, "hostfile",
+		"Reads a file from the host executing the mapping and returns its contents. Relative paths are resolved from the directory of the process executing the mapping. Unlike [`file`](#file) the file is not required to exist when the mapping is initialised, and changes to it are observed without restarting. Each execution checks the modification time and size of the file, and the contents are cached and only read again when either has changed. A missing file results in an error at execution.",
+		NewExampleSpec("",
+			`root.doc = hostfile(env("BENTHOS_TEST_BLOBLANG_FILE")).parse_json()`,
+			`{}`,
+			`{"doc":{"foo":"bar"}}`,
+		),
+	).Beta(),
+	true, hostfileFunction,
+	ExpectNArgs(1),
+	ExpectStringArg(0),
+)
+
+func hostfileFunction(args ...interface{}) (Function, error) {
+	path := args[0].(string)
+
+	var mut sync.Mutex
+	var cachedMod time.Time
+	var cachedSize int64
+	var cached []byte
+
+	return ClosureFunction("function hostfile", func(ctx FunctionContext) (interface{}, error) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		mut.Lock()
+		defer mut.Unlock()
+
+		if cached != nil && info.ModTime().Equal(cachedMod) && info.Size() == cachedSize {
+			return cached, nil
+		}
+		pathBytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		cached, cachedMod, cachedSize = pathBytes, info.ModTime(), info.Size()
+		return cached, nil
+	}, nil), nil
+}
+
+//------------------------------------------------------------------------------
+
+var _ = RegisterFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "counter",
+		"Returns an integer that increments each time the function is executed, starting at 1, which is useful for numbering messages in sequence or sampling every Nth message. Calls that share a name within a mapping, including any mappings it imports, share the same count, whereas calls within separate mappings or interpolation functions count independently. Counts are scoped to the component instance executing the mapping, and therefore processors running across multiple pipeline threads count independently.\n\nBy default counts are held in memory and begin again when a config is restarted. An optional second argument names a [cache resource](/docs/components/caches/about) in which to persist the count, stored under the name of the counter, in which case the count is loaded from the cache when the function is first executed and the new count is written to the cache on each execution, allowing it to continue after a restart. Component instances that persist a counter of the same name to the same cache overwrite each other's counts, and therefore each should be given a unique name. Caches are only available to the mappings of the [`bloblang`](/docs/components/processors/bloblang) and [`branch`](/docs/components/processors/branch) processors.\n\nThis differs from the function [`count`](#count), whose counters are shared by name across every mapping and component of the process and cannot be persisted.",
+		NewExampleSpec("",
+			`root = this
+root.seq = counter("seq")`,
+			`{"id":"a"}`,
+			`{"id":"a","seq":1}`,
+			`{"id":"b"}`,
+			`{"id":"b","seq":2}`,
+		),
+		NewExampleSpec("Sample every third message by deleting the others.",
+			`root = if counter("sample") % 3 != 0 { deleted() }`,
+			`{"id":"a"}`,
+			`<Message deleted>`,
+			`{"id":"b"}`,
+			`<Message deleted>`,
+			`{"id":"c"}`,
+			`{"id":"c"}`,
+		),
+	).Beta().MarkImpure(),
+	false, counterFunctionCtor,
+	ExpectBetweenNAndMArgs(1, 2),
+	ExpectStringArg(0),
+	ExpectStringArg(1),
+)
+
+// NamedCounters contains the counts of counter functions by name, allowing
+// counter functions of the same name within a mapping to share a count.
+type NamedCounters struct {
+	counts map[string]*counterState
+}
+
+// NewNamedCounters returns an empty set of named counters.
+func NewNamedCounters() *NamedCounters {
+	return &NamedCounters{counts: map[string]*counterState{}}
+}
+
+func (n *NamedCounters) get(name string) *counterState {
+	count, exists := n.counts[name]
+	if !exists {
+		count = &counterState{}
+		n.counts[name] = count
+	}
+	return count
+}
+
+type counterState struct {
+	mut    sync.Mutex
+	count  int64
+	loaded bool
+}
+
+type counterFunction struct {
+	name      string
+	cacheName string
+	resources Resources
+	state     *counterState
+}
+
+func counterFunctionCtor(args ...interface{}) (Function, error) {
+	c := &counterFunction{
+		name:  args[0].(string),
+		state: &counterState{},
+	}
+	if len(args) > 1 {
+		c.cacheName = args[1].(string)
+	}
+	return c, nil
+}
+
+// ShareCounts replaces the count of the function with that of the counter of
+// the same name within a set of named counters.
+func (c *counterFunction) ShareCounts(counters *NamedCounters) {
+	c.state = counters.get(c.name)
+}
+
+// UseResources provides the function with the resources of the component
+// executing the mapping, which are required in order to persist the count
+// within a cache.
+func (c *counterFunction) UseResources(res Resources) error {
+	if len(c.cacheName) > 0 && res == nil {
+		return fmt.Errorf("counter %v cannot be persisted to cache %v as resources are not available to this mapping", c.name, c.cacheName)
+	}
+	c.resources = res
+	return nil
+}
+
+func (c *counterFunction) Annotation() string {
+	return "counter " + c.name
+}
+
+func (c *counterFunction) Exec(ctx FunctionContext) (interface{}, error) {
+	if len(c.cacheName) == 0 {
+		c.state.mut.Lock()
+		c.state.count++
+		count := c.state.count
+		c.state.mut.Unlock()
+		return count, nil
+	}
+
+	cache, err := c.resources.GetCache(c.cacheName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain cache %v: %v", c.cacheName, err)
+	}
+
+	c.state.mut.Lock()
+	defer c.state.mut.Unlock()
+
+	if !c.state.loaded {
+		countBytes, err := cache.Get(c.name)
+		if err == nil {
+			if c.state.count, err = strconv.ParseInt(string(countBytes), 10, 64); err != nil {
+				return nil, fmt.Errorf("failed to parse count of counter %v from cache: %v", c.name, err)
+			}
+		} else if err != types.ErrKeyNotFound {
+			return nil, fmt.Errorf("failed to read count of counter %v from cache: %v", c.name, err)
+		}
+		c.state.loaded = true
+	}
+
+	count := c.state.count + 1
+	if err := cache.Set(c.name, []byte(strconv.FormatInt(count, 10))); err != nil {
+		return nil, fmt.Errorf("failed to write count of counter %v to cache: %v", c.name, err)
+	}
+	c.state.count = count
+	return count, nil
+}
+
+func (c *counterFunction) QueryTargets(ctx TargetsContext) (TargetsContext, []TargetPath) {
+	return ctx, nil
+}
+
+//------------------------------------------------------------------------------
+
 var _ = RegisterFunction(
 	NewFunctionSpec(
 		FunctionCategoryGeneral, "range",
@@ -518,7 +848,7 @@ var _ = RegisterFunction(
 			`root.first = random_int()
 root.second = random_int(1)`,
 		),
-	),
+	).MarkImpure(),
 	true, randomIntFunction,
 	ExpectOneOrZeroArgs(),
 	ExpectIntArg(1),
@@ -566,7 +896,7 @@ var _ = RegisterFunction(
 		NewExampleSpec("",
 			`root.received_at = timestamp("15:04:05")`,
 		),
-	),
+	).ReplacedBy("now"),
 	true, func(args ...interface{}) (Function, error) {
 		format := "Mon Jan 2 15:04:05 -0700 MST 2006"
 		if len(args) > 0 {
@@ -587,7 +917,7 @@ var _ = RegisterFunction(
 		NewExampleSpec("",
 			`root.received_at = timestamp_utc("15:04:05")`,
 		),
-	),
+	).ReplacedBy("now"),
 	true, func(args ...interface{}) (Function, error) {
 		format := "Mon Jan 2 15:04:05 -0700 MST 2006"
 		if len(args) > 0 {
@@ -673,6 +1003,84 @@ var _ = registerSimpleFunction(
 	},
 )
 
+var _ = RegisterFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "uuid_v5",
+		"Generates an RFC-4122 UUID from a namespace and a name by hashing them with SHA-1, and therefore the same namespace and name always result in the same UUID. The namespace is either a UUID string or one of the predefined namespaces `dns`, `url`, `oid` and `x500`.",
+		NewExampleSpec("",
+			`root.id = uuid_v5("dns", this.domain)`,
+			`{"domain":"www.example.com"}`,
+			`{"id":"2ed6657d-e927-568b-95e1-2665a8aea6a2"}`,
+		),
+	).Beta(),
+	true, func(args ...interface{}) (Function, error) {
+		ns, err := uuidNamespace(args[0].(string))
+		if err != nil {
+			return nil, err
+		}
+		u5 := uuid.NewV5(ns, args[1].(string)).String()
+		return ClosureFunction("function uuid_v5", func(_ FunctionContext) (interface{}, error) {
+			return u5, nil
+		}, nil), nil
+	},
+	ExpectNArgs(2),
+	ExpectStringArg(0),
+	ExpectStringArg(1),
+)
+
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "ulid",
+		"Generates a new [ULID](https://github.com/ulid/spec) each time it is invoked and prints a string representation. ULIDs begin with a timestamp in milliseconds and therefore sort lexicographically by the time they were generated, which makes them useful as keys of time ordered records.",
+		NewExampleSpec("", `root.id = ulid()`),
+	).Beta(),
+	func(_ FunctionContext) (interface{}, error) {
+		return newULID(time.Now(), crand.Reader)
+	},
+)
+
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "ksuid",
+		"Generates a new [KSUID](https://github.com/segmentio/ksuid) each time it is invoked and prints a string representation. KSUIDs begin with a timestamp in seconds and therefore sort lexicographically by the time they were generated.",
+		NewExampleSpec("", `root.id = ksuid()`),
+	).Beta(),
+	func(_ FunctionContext) (interface{}, error) {
+		return newKSUID(time.Now(), crand.Reader)
+	},
+)
+
+var _ = RegisterFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "nanoid",
+		"Generates a new [Nano ID](https://github.com/ai/nanoid) each time it is invoked, which is a random string of 21 URL-safe characters by default. An optional first argument sets the number of characters, and an optional second argument sets a custom alphabet of up to 256 characters to draw from.",
+		NewExampleSpec("", `root.id = nanoid()`),
+		NewExampleSpec("", `root.id = nanoid(10, "0123456789abcdef")`),
+	).Beta(),
+	true, func(args ...interface{}) (Function, error) {
+		size := int64(21)
+		if len(args) > 0 {
+			size = args[0].(int64)
+		}
+		if size < 1 {
+			return nil, fmt.Errorf("size must be greater than zero, received %v", size)
+		}
+		alphabet := []rune(nanoidAlphabet)
+		if len(args) > 1 {
+			alphabet = []rune(args[1].(string))
+		}
+		if len(alphabet) == 0 || len(alphabet) > 256 {
+			return nil, fmt.Errorf("alphabet must contain between 1 and 256 characters, received %v", len(alphabet))
+		}
+		return ClosureFunction("function nanoid", func(_ FunctionContext) (interface{}, error) {
+			return newNanoID(int(size), alphabet, crand.Reader)
+		}, nil), nil
+	},
+	ExpectBetweenNAndMArgs(0, 2),
+	ExpectIntArg(0),
+	ExpectStringArg(1),
+)
+
 //------------------------------------------------------------------------------
 
 var _ = RegisterFunction(
@@ -1,11 +1,19 @@
 package query
 
 import (
+	"bytes"
+	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -205,6 +213,50 @@ func TestEnvFunction(t *testing.T) {
 	res, err := e.Exec(FunctionContext{})
 	require.NoError(t, err)
 	assert.Equal(t, "foobar", res)
+
+	e, err = InitFunction("env", key, "barbaz")
+	require.NoError(t, err)
+
+	res, err = e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, "foobar", res)
+
+	e, err = InitFunction("env", key+"_MISSING", "barbaz")
+	require.NoError(t, err)
+
+	res, err = e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, "barbaz", res)
+}
+
+func TestEnvFunctionRequired(t *testing.T) {
+	key := "BENTHOS_TEST_BLOBLANG_FUNCTION_REQUIRED"
+	os.Setenv(key, "foobar")
+	t.Cleanup(func() {
+		os.Unsetenv(key)
+	})
+
+	e, err := InitFunction("env", key)
+	require.NoError(t, err)
+
+	e, err = InitMethod("required", e)
+	require.NoError(t, err)
+
+	res, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, "foobar", res)
+
+	e, err = InitFunction("env", key+"_MISSING", "barbaz")
+	require.NoError(t, err)
+
+	_, err = InitMethod("required", e)
+	require.NoError(t, err)
+
+	e, err = InitFunction("env", key+"_MISSING")
+	require.NoError(t, err)
+
+	_, err = InitMethod("required", e)
+	require.EqualError(t, err, "environment variable "+key+"_MISSING: value is required")
 }
 
 func TestRandomInt(t *testing.T) {
@@ -227,3 +279,293 @@ func TestRandomInt(t *testing.T) {
 		assert.LessOrEqual(t, v, int64(10))
 	}
 }
+
+func TestIDFunctions(t *testing.T) {
+	exec := func(name string, args ...interface{}) string {
+		t.Helper()
+		e, err := InitFunction(name, args...)
+		require.NoError(t, err)
+		res, err := e.Exec(FunctionContext{})
+		require.NoError(t, err)
+		require.IsType(t, "", res)
+		return res.(string)
+	}
+
+	assert.Equal(t, "2ed6657d-e927-568b-95e1-2665a8aea6a2", exec("uuid_v5", "dns", "www.example.com"))
+	assert.Equal(t, "2ed6657d-e927-568b-95e1-2665a8aea6a2", exec("uuid_v5", "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "www.example.com"))
+
+	_, err := InitFunction("uuid_v5", "nope", "www.example.com")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "namespace must be one of dns, url, oid, x500 or a UUID")
+
+	var ulids []string
+	for i := 0; i < 3; i++ {
+		ulids = append(ulids, exec("ulid"))
+		assert.Regexp(t, regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`), ulids[i])
+		time.Sleep(2 * time.Millisecond)
+	}
+	assert.True(t, sort.StringsAreSorted(ulids), ulids)
+
+	assert.Regexp(t, regexp.MustCompile(`^[0-9A-Za-z]{27}$`), exec("ksuid"))
+
+	assert.Regexp(t, regexp.MustCompile(`^[0-9A-Za-z_-]{21}$`), exec("nanoid"))
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{10}$`), exec("nanoid", int64(10), "0123456789abcdef"))
+	assert.NotEqual(t, exec("nanoid"), exec("nanoid"))
+
+	_, err = InitFunction("nanoid", int64(0))
+	require.EqualError(t, err, "size must be greater than zero, received 0")
+
+	_, err = InitFunction("nanoid", int64(10), "")
+	require.EqualError(t, err, "alphabet must contain between 1 and 256 characters, received 0")
+}
+
+func TestIDHelpers(t *testing.T) {
+	ts := time.Unix(0, 1469918176385*int64(time.Millisecond))
+
+	id, err := newULID(ts, bytes.NewReader(make([]byte, 10)))
+	require.NoError(t, err)
+	assert.Equal(t, "01ARYZ6S410000000000000000", id)
+
+	id, err = newULID(ts, bytes.NewReader(bytes.Repeat([]byte{0xff}, 10)))
+	require.NoError(t, err)
+	assert.Equal(t, "01ARYZ6S41ZZZZZZZZZZZZZZZZ", id)
+
+	payload, err := hex.DecodeString("B5A1CD34B5F99D1154FB6853345C9735")
+	require.NoError(t, err)
+	id, err = newKSUID(time.Unix(ksuidEpoch+107608047, 0), bytes.NewReader(payload))
+	require.NoError(t, err)
+	assert.Equal(t, "0ujtsYcgvSTl8PAuAdqWYSMnLOv", id)
+
+	_, err = newKSUID(time.Unix(ksuidEpoch-1, 0), bytes.NewReader(payload))
+	require.Error(t, err)
+
+	// Bytes outside of the alphabet once masked are discarded.
+	id, err = newNanoID(4, []rune("abc"), bytes.NewReader([]byte{0, 1, 2, 3, 4, 5, 6, 7}))
+	require.NoError(t, err)
+	assert.Equal(t, "abca", id)
+}
+
+func TestErrorDetailFunctions(t *testing.T) {
+	ts := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	failed := message.NewPart([]byte("foo"))
+	failed.Metadata().Set(types.FailFlagKey, "nope")
+	message.SetFailureDetails(failed, message.FailureDetails{
+		Class:     types.ErrorClassRetryable,
+		Path:      "foo",
+		Timestamp: ts,
+		Count:     2,
+	})
+
+	flagOnly := message.NewPart([]byte("bar"))
+	flagOnly.Metadata().Set(types.FailFlagKey, "nope")
+
+	msg := message.New(nil)
+	msg.Append(failed, flagOnly, message.NewPart([]byte("baz")))
+
+	tests := []struct {
+		function string
+		index    int
+		output   interface{}
+	}{
+		{function: "error_class", index: 0, output: "retryable"},
+		{function: "error_class", index: 1, output: "permanent"},
+		{function: "error_class", index: 2, output: ""},
+		{function: "error_source", index: 0, output: "foo"},
+		{function: "error_source", index: 2, output: ""},
+		{function: "error_count", index: 0, output: int64(2)},
+		{function: "error_count", index: 2, output: int64(0)},
+		{function: "error_timestamp", index: 0, output: "2021-03-04T05:06:07Z"},
+		{function: "error_timestamp", index: 2, output: ""},
+	}
+
+	for _, test := range tests {
+		e, err := InitFunction(test.function)
+		require.NoError(t, err)
+
+		res, err := e.Exec(FunctionContext{
+			MsgBatch: msg,
+			Index:    test.index,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, test.output, res, "%v(%v)", test.function, test.index)
+	}
+}
+
+func TestFileFunction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foo.txt")
+	require.NoError(t, ioutil.WriteFile(path, []byte("first"), 0o644))
+
+	cached, err := InitFunction("file", path)
+	require.NoError(t, err)
+
+	uncached, err := InitFunction("file", path, true)
+	require.NoError(t, err)
+
+	res, err := cached.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), res)
+
+	res, err = uncached.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), res)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("second"), 0o644))
+
+	res, err = cached.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), res)
+
+	res, err = uncached.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("second"), res)
+
+	require.NoError(t, os.Remove(path))
+
+	_, err = uncached.Exec(FunctionContext{})
+	require.Error(t, err)
+
+	_, err = InitFunction("file", path)
+	require.Error(t, err)
+
+	_, err = InitFunction("file", path, "true")
+	require.EqualError(t, err, "expected bool argument, received string")
+}
+
+func TestHostfileFunction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foo.txt")
+
+	fn, err := InitFunction("hostfile", path)
+	require.NoError(t, err)
+
+	_, err = fn.Exec(FunctionContext{})
+	require.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("first"), 0o644))
+
+	res, err := fn.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), res)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("second!"), 0o644))
+
+	res, err = fn.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("second!"), res)
+
+	require.NoError(t, os.Remove(path))
+
+	_, err = fn.Exec(FunctionContext{})
+	require.Error(t, err)
+
+	_, err = InitFunction("hostfile", 5)
+	require.Error(t, err)
+}
+
+func TestCounterFunction(t *testing.T) {
+	first, err := InitFunction("counter", "foo")
+	require.NoError(t, err)
+
+	second, err := InitFunction("counter", "foo")
+	require.NoError(t, err)
+
+	for i := int64(1); i <= 3; i++ {
+		res, err := first.Exec(FunctionContext{})
+		require.NoError(t, err)
+		assert.Equal(t, i, res)
+	}
+
+	res, err := second.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), res)
+
+	_, err = InitFunction("counter")
+	require.Error(t, err)
+}
+
+func TestCounterFunctionShared(t *testing.T) {
+	counters := NewNamedCounters()
+
+	var fns []Function
+	for _, name := range []string{"foo", "foo", "bar"} {
+		fn, err := InitFunction("counter", name)
+		require.NoError(t, err)
+		fn.(interface {
+			ShareCounts(*NamedCounters)
+		}).ShareCounts(counters)
+		fns = append(fns, fn)
+	}
+
+	var results []interface{}
+	for _, fn := range fns {
+		res, err := fn.Exec(FunctionContext{})
+		require.NoError(t, err)
+		results = append(results, res)
+	}
+	assert.Equal(t, []interface{}{int64(1), int64(2), int64(1)}, results)
+}
+
+type mockCache struct {
+	types.Cache
+	values map[string][]byte
+}
+
+func (m *mockCache) Get(key string) ([]byte, error) {
+	v, exists := m.values[key]
+	if !exists {
+		return nil, types.ErrKeyNotFound
+	}
+	return v, nil
+}
+
+func (m *mockCache) Set(key string, value []byte) error {
+	m.values[key] = value
+	return nil
+}
+
+type mockResources map[string]types.Cache
+
+func (m mockResources) GetCache(name string) (types.Cache, error) {
+	c, exists := m[name]
+	if !exists {
+		return nil, types.ErrCacheNotFound
+	}
+	return c, nil
+}
+
+func TestCounterFunctionCache(t *testing.T) {
+	cache := &mockCache{values: map[string][]byte{"foo": []byte("5")}}
+	res := mockResources{"bar": cache}
+
+	newCounter := func(cacheName string) Function {
+		t.Helper()
+		fn, err := InitFunction("counter", "foo", cacheName)
+		require.NoError(t, err)
+		require.NoError(t, fn.(interface {
+			UseResources(Resources) error
+		}).UseResources(res))
+		return fn
+	}
+
+	fn := newCounter("bar")
+	for _, exp := range []int64{6, 7} {
+		v, err := fn.Exec(FunctionContext{})
+		require.NoError(t, err)
+		assert.Equal(t, exp, v)
+	}
+	assert.Equal(t, "7", string(cache.values["foo"]))
+
+	// A new counter, such as after a restart, continues from the cached count.
+	v, err := newCounter("bar").Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), v)
+
+	_, err = newCounter("baz").Exec(FunctionContext{})
+	require.Error(t, err)
+
+	fn, err = InitFunction("counter", "foo", "bar")
+	require.NoError(t, err)
+	require.Error(t, fn.(interface {
+		UseResources(Resources) error
+	}).UseResources(nil))
+}
@@ -0,0 +1,163 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// earthRadiusMetres is the mean radius of the Earth.
+const earthRadiusMetres = 6371008.8
+
+// geoPoint is a coordinate in decimal degrees.
+type geoPoint struct {
+	lat, lon float64
+}
+
+// geoPointFromValue extracts a coordinate from either an object containing the
+// fields `lat` and `lon`, or an array of two numbers ordered latitude then
+// longitude.
+func geoPointFromValue(v interface{}) (geoPoint, error) {
+	var lat, lon interface{}
+	switch t := ISanitize(v).(type) {
+	case map[string]interface{}:
+		var exists bool
+		if lat, exists = t["lat"]; !exists {
+			return geoPoint{}, errors.New("expected coordinate object to contain a field lat")
+		}
+		if lon, exists = t["lon"]; !exists {
+			return geoPoint{}, errors.New("expected coordinate object to contain a field lon")
+		}
+	case []interface{}:
+		if len(t) != 2 {
+			return geoPoint{}, fmt.Errorf("expected coordinate array to contain two numbers, found %v", len(t))
+		}
+		lat, lon = t[0], t[1]
+	default:
+		return geoPoint{}, NewTypeError(v, ValueObject, ValueArray)
+	}
+
+	var p geoPoint
+	var err error
+	if p.lat, err = IGetNumber(lat); err != nil {
+		return p, fmt.Errorf("latitude: %w", err)
+	}
+	if p.lon, err = IGetNumber(lon); err != nil {
+		return p, fmt.Errorf("longitude: %w", err)
+	}
+	if err = p.validate(); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+func (p geoPoint) validate() error {
+	if p.lat < -90 || p.lat > 90 {
+		return fmt.Errorf("latitude %v is outside of the range -90 to 90", p.lat)
+	}
+	if p.lon < -180 || p.lon > 180 {
+		return fmt.Errorf("longitude %v is outside of the range -180 to 180", p.lon)
+	}
+	return nil
+}
+
+func (p geoPoint) object() map[string]interface{} {
+	return map[string]interface{}{
+		"lat": p.lat,
+		"lon": p.lon,
+	}
+}
+
+// haversineDistance returns the great-circle distance in metres between two
+// coordinates.
+func haversineDistance(a, b geoPoint) float64 {
+	toRad := func(deg float64) float64 {
+		return deg * math.Pi / 180
+	}
+	dLat := toRad(b.lat - a.lat)
+	dLon := toRad(b.lon - a.lon)
+	h := math.Pow(math.Sin(dLat/2), 2) +
+		math.Cos(toRad(a.lat))*math.Cos(toRad(b.lat))*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadiusMetres * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// withinPolygon returns whether a coordinate lies within a polygon, where the
+// polygon is closed implicitly and its edges are treated as straight lines
+// between coordinates.
+func withinPolygon(p geoPoint, polygon []geoPoint) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.lat > p.lat) != (b.lat > p.lat) &&
+			p.lon < (b.lon-a.lon)*(p.lat-a.lat)/(b.lat-a.lat)+a.lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+//------------------------------------------------------------------------------
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// geohashEncode encodes a coordinate as a geohash of a given number of
+// characters.
+func geohashEncode(p geoPoint, precision int) string {
+	latRange, lonRange := [2]float64{-90, 90}, [2]float64{-180, 180}
+
+	var b strings.Builder
+	even := true
+	for b.Len() < precision {
+		var c byte
+		for bit := 4; bit >= 0; bit-- {
+			r, v := &latRange, p.lat
+			if even {
+				r, v = &lonRange, p.lon
+			}
+			if mid := (r[0] + r[1]) / 2; v >= mid {
+				c |= 1 << uint(bit)
+				r[0] = mid
+			} else {
+				r[1] = mid
+			}
+			even = !even
+		}
+		b.WriteByte(geohashAlphabet[c])
+	}
+	return b.String()
+}
+
+// geohashDecode decodes a geohash into the coordinate at the center of the
+// area that it represents.
+func geohashDecode(hash string) (geoPoint, error) {
+	if hash == "" {
+		return geoPoint{}, errors.New("geohash is empty")
+	}
+	latRange, lonRange := [2]float64{-90, 90}, [2]float64{-180, 180}
+
+	even := true
+	for _, char := range strings.ToLower(hash) {
+		c := strings.IndexRune(geohashAlphabet, char)
+		if c < 0 {
+			return geoPoint{}, fmt.Errorf("geohash contains invalid character: %q", char)
+		}
+		for bit := 4; bit >= 0; bit-- {
+			r := &latRange
+			if even {
+				r = &lonRange
+			}
+			mid := (r[0] + r[1]) / 2
+			if c&(1<<uint(bit)) != 0 {
+				r[0] = mid
+			} else {
+				r[1] = mid
+			}
+			even = !even
+		}
+	}
+	return geoPoint{
+		lat: (latRange[0] + latRange[1]) / 2,
+		lon: (lonRange[0] + lonRange[1]) / 2,
+	}, nil
+}
@@ -0,0 +1,124 @@
+package query
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"math/bits"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// uuidNamespace resolves either the name of a well known RFC-4122 namespace or
+// a UUID string.
+func uuidNamespace(s string) (uuid.UUID, error) {
+	switch s {
+	case "dns":
+		return uuid.NamespaceDNS, nil
+	case "url":
+		return uuid.NamespaceURL, nil
+	case "oid":
+		return uuid.NamespaceOID, nil
+	case "x500":
+		return uuid.NamespaceX500, nil
+	}
+	ns, err := uuid.FromString(s)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("namespace must be one of dns, url, oid, x500 or a UUID: %w", err)
+	}
+	return ns, nil
+}
+
+//------------------------------------------------------------------------------
+
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newULID generates a ULID, which is a 48 bit timestamp in milliseconds
+// followed by 80 bits of entropy encoded as 26 characters of Crockford's
+// base32, and therefore sorts lexicographically by time.
+func newULID(t time.Time, rnd io.Reader) (string, error) {
+	var id [16]byte
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	if ms >= 1<<48 {
+		return "", errors.New("timestamp exceeds the range of a ULID")
+	}
+	binary.BigEndian.PutUint16(id[:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(id[2:6], uint32(ms))
+	if _, err := io.ReadFull(rnd, id[6:]); err != nil {
+		return "", err
+	}
+
+	// 128 bits are encoded as 26 characters of 5 bits each, where the first
+	// character holds only the two most significant bits.
+	hi, lo := binary.BigEndian.Uint64(id[:8]), binary.BigEndian.Uint64(id[8:])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockfordAlphabet[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:]), nil
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	ksuidEpoch    = 1400000000
+	base62Charset = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// newKSUID generates a KSUID, which is a 32 bit timestamp in seconds since a
+// custom epoch followed by 128 bits of entropy encoded as 27 characters of
+// base62, and therefore sorts lexicographically by time.
+func newKSUID(t time.Time, rnd io.Reader) (string, error) {
+	var id [20]byte
+	ts := t.Unix() - ksuidEpoch
+	if ts < 0 || ts > 1<<32-1 {
+		return "", errors.New("timestamp exceeds the range of a KSUID")
+	}
+	binary.BigEndian.PutUint32(id[:4], uint32(ts))
+	if _, err := io.ReadFull(rnd, id[4:]); err != nil {
+		return "", err
+	}
+
+	n := new(big.Int).SetBytes(id[:])
+	base, mod := big.NewInt(62), new(big.Int)
+	var out [27]byte
+	for i := 26; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = base62Charset[mod.Int64()]
+	}
+	return string(out[:]), nil
+}
+
+//------------------------------------------------------------------------------
+
+const nanoidAlphabet = "_-0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// newNanoID generates a random string of a given size from an alphabet of up
+// to 256 characters. Random bytes are masked to the smallest power of two that
+// covers the alphabet and values outside of it are discarded, which avoids
+// biasing the distribution of characters.
+func newNanoID(size int, alphabet []rune, rnd io.Reader) (string, error) {
+	mask := byte(1<<uint(bits.Len(uint(len(alphabet)-1))) - 1)
+
+	out := make([]rune, 0, size)
+	buf := make([]byte, size)
+	for len(out) < size {
+		if _, err := io.ReadFull(rnd, buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if i := int(b & mask); i < len(alphabet) {
+				out = append(out, alphabet[i])
+				if len(out) == size {
+					break
+				}
+			}
+		}
+	}
+	return string(out), nil
+}
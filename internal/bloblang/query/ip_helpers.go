@@ -0,0 +1,92 @@
+package query
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+)
+
+// parseIP parses an IPv4 or IPv6 address, optionally enclosed in square
+// brackets, where IPv4 addresses mapped to IPv6 are returned as IPv4.
+func parseIP(s string) (net.IP, error) {
+	ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(s), "["), "]"))
+	if ip == nil {
+		return nil, fmt.Errorf("failed to parse '%v' as an IP address", s)
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4, nil
+	}
+	return ip, nil
+}
+
+var privateIPNets = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"} {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// isPrivateIP returns whether an address is within a private range as defined
+// by RFC 1918 for IPv4 and RFC 4193 for IPv6.
+func isPrivateIP(ip net.IP) bool {
+	for _, n := range privateIPNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func ipVersion(ip net.IP) int64 {
+	if len(ip) == net.IPv4len {
+		return 4
+	}
+	return 6
+}
+
+// ipToInt returns an IPv4 address as an integer and an IPv6 address as a
+// string of the integer, as it exceeds the range of 64 bit integers.
+func ipToInt(ip net.IP) interface{} {
+	if len(ip) == net.IPv4len {
+		return int64(binary.BigEndian.Uint32(ip))
+	}
+	return new(big.Int).SetBytes(ip).String()
+}
+
+// parseCIDRs parses either a single CIDR string or an array of them.
+func parseCIDRs(v interface{}) ([]*net.IPNet, error) {
+	var cidrs []string
+	switch t := ISanitize(v).(type) {
+	case string:
+		cidrs = []string{t}
+	case []byte:
+		cidrs = []string{string(t)}
+	case []interface{}:
+		for _, c := range t {
+			s, err := IGetString(c)
+			if err != nil {
+				return nil, err
+			}
+			cidrs = append(cidrs, s)
+		}
+	default:
+		return nil, NewTypeError(v, ValueString, ValueArray)
+	}
+
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
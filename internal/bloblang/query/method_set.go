@@ -33,6 +33,35 @@ func (m *MethodSet) Add(spec MethodSpec, ctor MethodCtor, autoResolveFunctionArg
 	return nil
 }
 
+// Alias adds a deprecated method to this set under a new name that behaves
+// identically to an existing method. Usage of the alias suggests the target
+// method as a replacement.
+func (m *MethodSet) Alias(alias, target string) error {
+	if !nameRegexp.MatchString(alias) {
+		return fmt.Errorf("method name '%v' does not match the required regular expression /%v/", alias, nameRegexpRaw)
+	}
+	ctor, exists := m.constructors[target]
+	if !exists {
+		return fmt.Errorf("method alias target does not exist: %v", target)
+	}
+	if _, exists := m.constructors[alias]; exists {
+		return fmt.Errorf("conflicting method name: %v", alias)
+	}
+	m.constructors[alias] = ctor
+	m.specs = append(m.specs, NewDeprecatedMethodSpec(alias, "").InCategory(MethodCategoryDeprecated, fmt.Sprintf("This method is a deprecated alias of `%v`, which should be used instead.", target)).ReplacedBy(target))
+	return nil
+}
+
+// Spec returns the spec of a method of the set by name.
+func (m *MethodSet) Spec(name string) (MethodSpec, bool) {
+	for _, spec := range m.specs {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return MethodSpec{}, false
+}
+
 // Docs returns a slice of method specs, which document each method.
 func (m *MethodSet) Docs() []MethodSpec {
 	return m.specs
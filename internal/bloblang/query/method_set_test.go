@@ -51,3 +51,19 @@ func TestMethodBadName(t *testing.T) {
 		})
 	}
 }
+
+func TestMethodSetAlias(t *testing.T) {
+	setOne := AllMethods.Without()
+	assert.NoError(t, setOne.Alias("explode_alias", "explode"))
+	assert.EqualError(t, setOne.Alias("explode_alias", "explode"), "conflicting method name: explode_alias")
+	assert.EqualError(t, setOne.Alias("nope_alias", "nope"), "method alias target does not exist: nope")
+	assert.NotContains(t, AllMethods.List(), "explode_alias")
+
+	_, err := setOne.Init("explode_alias", NewLiteralFunction("", nil), "foo.bar")
+	assert.NoError(t, err)
+
+	spec, exists := setOne.Spec("explode_alias")
+	assert.True(t, exists)
+	assert.Equal(t, StatusDeprecated, spec.Status)
+	assert.Equal(t, "explode", spec.Replacement)
+}
@@ -3,6 +3,7 @@ package query
 import (
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 
 	"github.com/Jeffail/gabs/v2"
@@ -11,7 +12,7 @@ import (
 var _ = registerMethod(
 	NewMethodSpec(
 		"apply",
-		"Apply a declared map on a value.",
+		"Apply a declared map on a value. Maps can declare named parameters, in which case the values of those parameters are provided as additional arguments and are accessible within the map as variables.",
 		NewExampleSpec("",
 			`map thing {
   root.inner = this.first
@@ -32,14 +33,26 @@ root.foo = null.apply("create_foo")`,
 			`{"id":"1234"}`,
 			`{"foo":{"name":"a foo","purpose":"to be a foo"},"id":"1234"}`,
 		),
+		NewExampleSpec("",
+			`map price(currency, rate) {
+  root.amount = this * $rate
+  root.currency = $currency
+}
+
+root.eur = this.amount.apply("price", "EUR", 0.5)
+root.gbp = this.amount.apply("price", "GBP", this.gbp_rate)`,
+			`{"amount":10,"gbp_rate":0.25}`,
+			`{"eur":{"amount":5,"currency":"EUR"},"gbp":{"amount":2.5,"currency":"GBP"}}`,
+		),
 	),
 	true, applyMethod,
-	ExpectNArgs(1),
+	ExpectAtLeastOneArg(),
 	ExpectStringArg(0),
 )
 
 func applyMethod(target Function, args ...interface{}) (Function, error) {
 	targetMap := args[0].(string)
+	mapArgs := args[1:]
 
 	return ClosureFunction("map "+targetMap, func(ctx FunctionContext) (interface{}, error) {
 		res, err := target.Exec(ctx)
@@ -56,8 +69,19 @@ func applyMethod(target Function, args ...interface{}) (Function, error) {
 			return nil, fmt.Errorf("map %v was not found", targetMap)
 		}
 
+		var params []string
+		if p, ok := m.(interface{ Params() []string }); ok {
+			params = p.Params()
+		}
+		if len(params) != len(mapArgs) {
+			return nil, fmt.Errorf("map %v expects %v arguments, received %v", targetMap, len(params), len(mapArgs))
+		}
+
 		// ISOLATED VARIABLES
-		ctx.Vars = map[string]interface{}{}
+		ctx.Vars = make(map[string]interface{}, len(params))
+		for i, param := range params {
+			ctx.Vars[param] = mapArgs[i]
+		}
 		return m.Exec(ctx)
 	}, func(ctx TargetsContext) (TargetsContext, []TargetPath) {
 		mapFn, ok := ctx.Maps[targetMap]
@@ -309,6 +333,91 @@ func getMethodCtor(target Function, args ...interface{}) (Function, error) {
 
 //------------------------------------------------------------------------------
 
+// defaultLoopMaxIterations is the maximum number of iterations of the loop
+// method when a maximum is not specified.
+const defaultLoopMaxIterations = 1000
+
+var _ = registerMethod(
+	NewMethodSpec(
+		"loop",
+		"Executes a query repeatedly for as long as a condition query resolves to `true`, and returns the final result. The context of both queries is the result of the previous iteration, starting with the target value. In order to protect against infinite loops an error is returned when the number of iterations exceeds a maximum, which is 1000 by default and can be changed with an optional third argument.",
+		NewExampleSpec("",
+			`root.result = this.value.loop(v -> v < 100, v -> v * 2)`,
+			`{"value":3}`,
+			`{"result":192}`,
+		),
+		NewExampleSpec("Loops are useful for unrolling nested structures such as paginated results, where an object can be used in order to carry state between iterations.",
+			`root.items = {"page": this, "items": []}.loop(s -> s.page != null, s -> {"page": s.page.next, "items": s.items.merge(s.page.items)}).items`,
+			`{"items":["a","b"],"next":{"items":["c"],"next":{"items":["d"]}}}`,
+			`{"items":["a","b","c","d"]}`,
+		),
+		NewExampleSpec("",
+			`root.result = this.value.loop(v -> true, v -> v + 1, 10).catch("too many iterations")`,
+			`{"value":0}`,
+			`{"result":"too many iterations"}`,
+		),
+	),
+	false, loopMethod,
+	ExpectBetweenNAndMArgs(2, 3),
+	ExpectFunctionArg(0),
+	ExpectFunctionArg(1),
+	ExpectIntArg(2),
+)
+
+func loopMethod(target Function, args ...interface{}) (Function, error) {
+	condFn, ok := args[0].(Function)
+	if !ok {
+		return nil, fmt.Errorf("expected query argument, received %T", args[0])
+	}
+	bodyFn, ok := args[1].(Function)
+	if !ok {
+		return nil, fmt.Errorf("expected query argument, received %T", args[1])
+	}
+	maxIterations := int64(defaultLoopMaxIterations)
+	if len(args) > 2 {
+		if maxIterations = args[2].(int64); maxIterations < 1 {
+			return nil, fmt.Errorf("maximum iterations must be greater than zero, received %v", maxIterations)
+		}
+	}
+
+	return ClosureFunction("method loop", func(ctx FunctionContext) (interface{}, error) {
+		res, err := target.Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for i := int64(0); ; i++ {
+			v, err := condFn.Exec(ctx.WithValue(res))
+			if err != nil {
+				return nil, err
+			}
+			b, ok := v.(bool)
+			if !ok {
+				return nil, NewTypeErrorFrom(condFn.Annotation(), v, ValueBool)
+			}
+			if !b {
+				return res, nil
+			}
+			if i >= maxIterations {
+				return nil, fmt.Errorf("loop exceeded the maximum of %v iterations", maxIterations)
+			}
+			if res, err = bodyFn.Exec(ctx.WithValue(res)); err != nil {
+				return nil, err
+			}
+		}
+	}, func(ctx TargetsContext) (TargetsContext, []TargetPath) {
+		loopCtx, targets := target.QueryTargets(ctx)
+		loopCtx = loopCtx.WithValues(targets).WithValuesAsContext()
+
+		_, condTargets := condFn.QueryTargets(loopCtx)
+		returnCtx, bodyTargets := bodyFn.QueryTargets(loopCtx)
+
+		targets = append(targets, condTargets...)
+		return returnCtx, append(targets, bodyTargets...)
+	}), nil
+}
+
+//------------------------------------------------------------------------------
+
 var _ = registerMethod(
 	NewHiddenMethodSpec("map"), false, mapMethod,
 	ExpectNArgs(1),
@@ -412,6 +521,60 @@ var _ = registerSimpleMethod(
 
 //------------------------------------------------------------------------------
 
+var _ = registerMethod(
+	NewMethodSpec(
+		"required", "",
+	).InCategory(
+		MethodCategoryCoercion,
+		"Ensures that the given value is neither `null` nor an empty string, and if so returns it, otherwise an error is returned. When the target is a static value, such as the result of the function [`env`](/docs/guides/bloblang/functions#env), the check is performed when the mapping is parsed, and therefore a missing value fails config linting rather than each message.",
+		NewExampleSpec("",
+			`root.a = this.a.required()`,
+			`{"a":"foobar"}`,
+			`{"a":"foobar"}`,
+			`{"a":""}`,
+			`Error("failed assignment (line 1): field `+"`this.a`"+`: value is required")`,
+		),
+		NewExampleSpec("",
+			`root.url = env("TARGET_URL").required()`,
+		),
+	).Beta(),
+	false, requiredMethod,
+	ExpectNArgs(0),
+)
+
+func isRequiredValueMissing(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case []byte:
+		return len(t) == 0
+	}
+	return false
+}
+
+func requiredMethod(target Function, _ ...interface{}) (Function, error) {
+	if lit, isLit := target.(*Literal); isLit {
+		if isRequiredValueMissing(lit.Value) {
+			return nil, fmt.Errorf("%v: value is required", lit.Annotation())
+		}
+		return lit, nil
+	}
+	return ClosureFunction("method required", func(ctx FunctionContext) (interface{}, error) {
+		v, err := target.Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if isRequiredValueMissing(v) {
+			return nil, ErrFrom(errors.New("value is required"), target)
+		}
+		return v, nil
+	}, target.QueryTargets), nil
+}
+
+//------------------------------------------------------------------------------
+
 var _ = registerMethod(
 	NewMethodSpec(
 		"number", "",
@@ -454,6 +617,69 @@ func numberCoerceMethod(target Function, args ...interface{}) (Function, error)
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"to_int64", "",
+	).InCategory(
+		MethodCategoryCoercion,
+		"Attempt to convert a number, or a string containing a number, into a 64-bit signed integer without losing precision. An error is returned if the value has a fractional component or does not fit within a 64-bit signed integer. Large integers parsed from JSON documents, such as snowflake IDs, are preserved exactly.",
+		NewExampleSpec("",
+			`root.id = this.id.to_int64()
+root.next_id = this.id.to_int64() + 1`,
+			`{"id":"1234567890123456789"}`,
+			`{"id":1234567890123456789,"next_id":1234567890123456790}`,
+		),
+	),
+	func(...interface{}) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			return iToInt64Exact(v)
+		}, nil
+	},
+	false,
+	ExpectNArgs(0),
+)
+
+func iToInt64Exact(v interface{}) (int64, error) {
+	switch t := ISanitize(v).(type) {
+	case int64:
+		return t, nil
+	case uint64:
+		if t > maxInt {
+			return 0, fmt.Errorf("value %v does not fit within a 64-bit signed integer", t)
+		}
+		return int64(t), nil
+	case float64:
+		if t != math.Trunc(t) {
+			return 0, fmt.Errorf("value %v has a fractional component", t)
+		}
+		if t < math.MinInt64 || t >= math.MaxInt64 {
+			return 0, fmt.Errorf("value %v does not fit within a 64-bit signed integer", t)
+		}
+		return int64(t), nil
+	case Decimal:
+		if !t.getRat().IsInt() {
+			return 0, fmt.Errorf("value %v has a fractional component", t)
+		}
+		if !t.getRat().Num().IsInt64() {
+			return 0, fmt.Errorf("value %v does not fit within a 64-bit signed integer", t)
+		}
+		return t.getRat().Num().Int64(), nil
+	case string, []byte:
+		s := IToString(t)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+		d, err := NewDecimal(s)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse '%v' as an integer", s)
+		}
+		return iToInt64Exact(d)
+	}
+	return 0, NewTypeError(v, ValueNumber, ValueString)
+}
+
+//------------------------------------------------------------------------------
+
 var _ = registerMethod(
 	NewMethodSpec(
 		"or", "If the result of the target query fails or resolves to `null`, returns the argument instead. This is an explicit method alternative to the coalesce pipe operator `|`.",
@@ -263,3 +263,215 @@ var _ = registerSimpleMethod(
 	false,
 	ExpectNArgs(0),
 )
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"round_decimal", "",
+	).InCategory(
+		MethodCategoryNumbers,
+		"Rounds a number to a given number of decimal places using an optional rounding mode, returning a decimal. The rounding mode defaults to `half_even`, also known as banker's rounding, and can be one of `half_up`, `half_down`, `half_even`, `up`, `down`, `ceiling` or `floor`. Floating point numbers are converted to decimals before rounding, and therefore the result is exact.",
+		NewExampleSpec("",
+			`root.total = this.value.round_decimal(2)`,
+			`{"value":2.675}`,
+			`{"total":2.68}`,
+			`{"value":2.665}`,
+			`{"total":2.66}`,
+		),
+		NewExampleSpec("",
+			`root.total = this.value.round_decimal(1, "half_up")`,
+			`{"value":0.25}`,
+			`{"total":0.3}`,
+			`{"value":-0.25}`,
+			`{"total":-0.3}`,
+		),
+	),
+	func(args ...interface{}) (simpleMethod, error) {
+		places := args[0].(int64)
+		mode := DecimalRoundHalfEven
+		if len(args) > 1 {
+			var err error
+			if mode, err = parseDecimalRoundingMode(args[1].(string)); err != nil {
+				return nil, err
+			}
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			d, err := iNumberToDecimal(v)
+			if err != nil {
+				return nil, err
+			}
+			return d.Round(int(places), mode), nil
+		}, nil
+	},
+	true,
+	ExpectBetweenNAndMArgs(1, 2),
+	ExpectIntArg(0),
+	ExpectStringArg(1),
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"to_decimal", "",
+	).InCategory(
+		MethodCategoryNumbers,
+		"Converts a number, or a string containing a number, into an arbitrary precision decimal. Arithmetic where either operand is a decimal is performed precisely rather than with floating point numbers, and the result is a decimal. Division results are rounded to 16 decimal places. Decimals are serialized as exact JSON numbers, and can be converted back into a floating point number with the `number` method.",
+		NewExampleSpec("",
+			`root.total = this.price.to_decimal() + this.tax.to_decimal()`,
+			`{"price":0.1,"tax":0.2}`,
+			`{"total":0.3}`,
+		),
+		NewExampleSpec("",
+			`root.total = this.price.to_decimal() * this.quantity`,
+			`{"price":"19.99","quantity":3}`,
+			`{"total":59.97}`,
+		),
+	),
+	func(...interface{}) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			return IToDecimal(ISanitize(v))
+		}, nil
+	},
+	false,
+	ExpectNArgs(0),
+)
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"geohash_encode", "",
+	).InCategory(
+		MethodCategoryNumbers,
+		"Encodes a coordinate as a [geohash](https://en.wikipedia.org/wiki/Geohash) string with an optional number of characters between 1 and 12, which defaults to 12. Coordinates can either be an object containing the fields `lat` and `lon`, or an array of two numbers ordered latitude then longitude, where both are in decimal degrees.",
+		NewExampleSpec("",
+			`root.geohash = this.location.geohash_encode(7)`,
+			`{"location":{"lat":51.5007,"lon":-0.1246}}`,
+			`{"geohash":"gcpuvpm"}`,
+		),
+		NewExampleSpec("",
+			`root.geohash = this.location.geohash_encode()`,
+			`{"location":[57.64911,10.40744]}`,
+			`{"geohash":"u4pruydqqvj8"}`,
+		),
+	).Beta(),
+	func(args ...interface{}) (simpleMethod, error) {
+		precision := int64(12)
+		if len(args) > 0 {
+			precision = args[0].(int64)
+		}
+		if precision < 1 || precision > 12 {
+			return nil, fmt.Errorf("precision must be between 1 and 12, received %v", precision)
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			p, err := geoPointFromValue(v)
+			if err != nil {
+				return nil, err
+			}
+			return geohashEncode(p, int(precision)), nil
+		}, nil
+	},
+	true,
+	ExpectOneOrZeroArgs(),
+	ExpectIntArg(0),
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"geohash_decode", "",
+	).InCategory(
+		MethodCategoryNumbers,
+		"Decodes a [geohash](https://en.wikipedia.org/wiki/Geohash) string into an object containing the fields `lat` and `lon` of the coordinate at the center of the area it represents.",
+		NewExampleSpec("",
+			`root.location = this.geohash.geohash_decode()`,
+			`{"geohash":"gcpuv"}`,
+			`{"location":{"lat":51.48193359375,"lon":-0.10986328125}}`,
+		),
+	).Beta(),
+	func(...interface{}) (simpleMethod, error) {
+		return stringMethod(func(s string) (interface{}, error) {
+			p, err := geohashDecode(s)
+			if err != nil {
+				return nil, err
+			}
+			return p.object(), nil
+		}), nil
+	},
+	false,
+	ExpectNArgs(0),
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"haversine", "",
+	).InCategory(
+		MethodCategoryNumbers,
+		"Calculates the great-circle distance in metres between a coordinate and the latitude and longitude provided as arguments, using the haversine formula with the mean radius of the Earth. Coordinates can either be an object containing the fields `lat` and `lon`, or an array of two numbers ordered latitude then longitude, where both are in decimal degrees.",
+		NewExampleSpec("",
+			`root.distance = this.from.haversine(this.to.lat, this.to.lon).round()`,
+			`{"from":{"lat":51.5007,"lon":-0.1246},"to":{"lat":48.8584,"lon":2.2945}}`,
+			`{"distance":340539}`,
+		),
+	).Beta(),
+	func(args ...interface{}) (simpleMethod, error) {
+		to := geoPoint{lat: args[0].(float64), lon: args[1].(float64)}
+		if err := to.validate(); err != nil {
+			return nil, err
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			from, err := geoPointFromValue(v)
+			if err != nil {
+				return nil, err
+			}
+			return haversineDistance(from, to), nil
+		}, nil
+	},
+	true,
+	ExpectNArgs(2),
+	ExpectFloatArg(0),
+	ExpectFloatArg(1),
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"within_polygon", "",
+	).InCategory(
+		MethodCategoryNumbers,
+		"Checks whether a coordinate lies within a polygon provided as an array of at least three coordinates, where the last coordinate is joined to the first. Coordinates can either be an object containing the fields `lat` and `lon`, or an array of two numbers ordered latitude then longitude, where both are in decimal degrees. Edges of the polygon are treated as straight lines of latitude and longitude, and therefore polygons should not cross the antimeridian.",
+		NewExampleSpec("",
+			`root.in_zone = this.location.within_polygon([[51.4,-0.3],[51.4,0.1],[51.6,0.1],[51.6,-0.3]])`,
+			`{"location":{"lat":51.5007,"lon":-0.1246}}`,
+			`{"in_zone":true}`,
+			`{"location":{"lat":48.8584,"lon":2.2945}}`,
+			`{"in_zone":false}`,
+		),
+		NewExampleSpec("",
+			`root.in_zone = this.location.within_polygon(this.zone)`,
+			`{"location":[2,3],"zone":[{"lat":0,"lon":0},{"lat":10,"lon":0},{"lat":0,"lon":10}]}`,
+			`{"in_zone":true}`,
+		),
+	).Beta(),
+	func(args ...interface{}) (simpleMethod, error) {
+		coords, ok := ISanitize(args[0]).([]interface{})
+		if !ok {
+			return nil, NewTypeError(args[0], ValueArray)
+		}
+		if len(coords) < 3 {
+			return nil, fmt.Errorf("expected polygon to contain at least three coordinates, found %v", len(coords))
+		}
+		polygon := make([]geoPoint, len(coords))
+		for i, c := range coords {
+			var err error
+			if polygon[i], err = geoPointFromValue(c); err != nil {
+				return nil, fmt.Errorf("polygon coordinate %v: %w", i, err)
+			}
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			p, err := geoPointFromValue(v)
+			if err != nil {
+				return nil, err
+			}
+			return withinPolygon(p, polygon), nil
+		}, nil
+	},
+	true,
+	ExpectNArgs(1),
+)
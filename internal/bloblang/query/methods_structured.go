@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/Jeffail/gabs/v2"
+	"github.com/itchyny/gojq"
 	jsonschema "github.com/xeipuuv/gojsonschema"
 )
 
@@ -147,6 +150,48 @@ var _ = registerSimpleMethod(
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"chunk", "",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Splits an array into an array of arrays of a given size, where the last array contains any remaining elements and may therefore be smaller.",
+		NewExampleSpec("",
+			`root.chunks = this.values.chunk(2)`,
+			`{"values":[1,2,3,4,5]}`,
+			`{"chunks":[[1,2],[3,4],[5]]}`,
+		),
+	).Beta(),
+	func(args ...interface{}) (simpleMethod, error) {
+		size := args[0].(int64)
+		if size <= 0 {
+			return nil, fmt.Errorf("chunk size must be greater than zero, received %v", size)
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			arr, ok := v.([]interface{})
+			if !ok {
+				return nil, NewTypeError(v, ValueArray)
+			}
+			chunks := make([]interface{}, 0, (int64(len(arr))+size-1)/size)
+			for i := int64(0); i < int64(len(arr)); i += size {
+				end := i + size
+				if end > int64(len(arr)) {
+					end = int64(len(arr))
+				}
+				chunk := make([]interface{}, end-i)
+				copy(chunk, arr[i:end])
+				chunks = append(chunks, chunk)
+			}
+			return chunks, nil
+		}, nil
+	},
+	true,
+	ExpectNArgs(1),
+	ExpectIntArg(0),
+)
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"collapse", "",
@@ -361,7 +406,7 @@ Exploding objects results in an object where the keys match the target object, a
 
 //------------------------------------------------------------------------------
 
-var _ = registerSimpleMethod(
+var _ = registerMethod(
 	NewMethodSpec(
 		"filter", "",
 	).InCategory(
@@ -380,53 +425,55 @@ When filtering objects the mapping query argument is provided a context with a f
 			`{"new_dict":{"first":"hello foo","third":"this foo is great"}}`,
 		),
 	),
-	func(args ...interface{}) (simpleMethod, error) {
-		mapFn, ok := args[0].(Function)
-		if !ok {
-			return nil, fmt.Errorf("expected query argument, received %T", args[0])
-		}
-		return func(res interface{}, ctx FunctionContext) (interface{}, error) {
-			var resValue interface{}
-			switch t := res.(type) {
-			case []interface{}:
-				newSlice := make([]interface{}, 0, len(t))
-				for _, v := range t {
-					f, err := mapFn.Exec(ctx.WithValue(v))
-					if err != nil {
-						return nil, err
-					}
-					if b, _ := f.(bool); b {
-						newSlice = append(newSlice, v)
-					}
-				}
-				resValue = newSlice
-			case map[string]interface{}:
-				newMap := make(map[string]interface{}, len(t))
-				for k, v := range t {
-					var ctxMap interface{} = map[string]interface{}{
-						"key":   k,
-						"value": v,
-					}
-					f, err := mapFn.Exec(ctx.WithValue(ctxMap))
-					if err != nil {
-						return nil, err
-					}
-					if b, _ := f.(bool); b {
-						newMap[k] = v
-					}
-				}
-				resValue = newMap
-			default:
-				return nil, NewTypeError(res, ValueArray, ValueObject)
-			}
-			return resValue, nil
-		}, nil
-	},
 	false,
+	lazyArrayMethod("filter", filterMethod, filterStage),
 	ExpectNArgs(1),
 	ExpectFunctionArg(0),
 )
 
+func filterMethod(args ...interface{}) (simpleMethod, error) {
+	mapFn, ok := args[0].(Function)
+	if !ok {
+		return nil, fmt.Errorf("expected query argument, received %T", args[0])
+	}
+	return func(res interface{}, ctx FunctionContext) (interface{}, error) {
+		var resValue interface{}
+		switch t := res.(type) {
+		case []interface{}:
+			newSlice := make([]interface{}, 0, len(t))
+			for _, v := range t {
+				f, err := mapFn.Exec(ctx.WithValue(v))
+				if err != nil {
+					return nil, err
+				}
+				if b, _ := f.(bool); b {
+					newSlice = append(newSlice, v)
+				}
+			}
+			resValue = newSlice
+		case map[string]interface{}:
+			newMap := make(map[string]interface{}, len(t))
+			for k, v := range t {
+				var ctxMap interface{} = map[string]interface{}{
+					"key":   k,
+					"value": v,
+				}
+				f, err := mapFn.Exec(ctx.WithValue(ctxMap))
+				if err != nil {
+					return nil, err
+				}
+				if b, _ := f.(bool); b {
+					newMap[k] = v
+				}
+			}
+			resValue = newMap
+		default:
+			return nil, NewTypeError(res, ValueArray, ValueObject)
+		}
+		return resValue, nil
+	}, nil
+}
+
 //------------------------------------------------------------------------------
 
 var _ = registerSimpleMethod(
@@ -537,6 +584,91 @@ var _ = registerSimpleMethod(
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"group_by", "",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Groups the elements of an array into an object, where each element is added to an array under the key returned by a query argument executed on it. Keys can be strings, numbers or booleans, and elements retain their original order within each group. An optional second query argument can be provided in order to aggregate each group, which is executed with the array of elements in the group as its context and the result replaces the group.",
+		NewExampleSpec("",
+			`root.by_type = this.events.group_by(ele -> ele.type)`,
+			`{"events":[{"id":1,"type":"click"},{"id":2,"type":"view"},{"id":3,"type":"click"}]}`,
+			`{"by_type":{"click":[{"id":1,"type":"click"},{"id":3,"type":"click"}],"view":[{"id":2,"type":"view"}]}}`,
+		),
+		NewExampleSpec("Groups can be aggregated, for example by counting or summing their elements.",
+			`root.counts = this.orders.group_by(ele -> ele.customer, group -> group.length())
+root.totals = this.orders.group_by(ele -> ele.customer, group -> group.map_each(ele -> ele.amount).sum())`,
+			`{"orders":[{"customer":"ada","amount":5},{"customer":"bob","amount":3},{"customer":"ada","amount":7}]}`,
+			`{"counts":{"ada":2,"bob":1},"totals":{"ada":12,"bob":3}}`,
+		),
+	).Beta(),
+	func(args ...interface{}) (simpleMethod, error) {
+		keyFn, ok := args[0].(Function)
+		if !ok {
+			return nil, fmt.Errorf("expected query argument, received %T", args[0])
+		}
+		var aggFn Function
+		if len(args) > 1 {
+			if aggFn, ok = args[1].(Function); !ok {
+				return nil, fmt.Errorf("expected query argument, received %T", args[1])
+			}
+		}
+		return func(res interface{}, ctx FunctionContext) (interface{}, error) {
+			resArray, ok := res.([]interface{})
+			if !ok {
+				return nil, NewTypeError(res, ValueArray)
+			}
+
+			var keys []string
+			groups := map[string][]interface{}{}
+			for i, v := range resArray {
+				key, err := groupingKey(keyFn, ctx, v)
+				if err != nil {
+					return nil, fmt.Errorf("element %v: %w", i, err)
+				}
+				if _, exists := groups[key]; !exists {
+					keys = append(keys, key)
+				}
+				groups[key] = append(groups[key], v)
+			}
+
+			result := make(map[string]interface{}, len(groups))
+			for _, key := range keys {
+				if aggFn == nil {
+					result[key] = groups[key]
+					continue
+				}
+				aggV, err := aggFn.Exec(ctx.WithValue(groups[key]))
+				if err != nil {
+					return nil, fmt.Errorf("group %v: %w", key, err)
+				}
+				result[key] = aggV
+			}
+			return result, nil
+		}, nil
+	},
+	false,
+	ExpectBetweenNAndMArgs(1, 2),
+	ExpectFunctionArg(0),
+	ExpectFunctionArg(1),
+)
+
+// groupingKey executes a query on a value and returns the result as an object
+// key, which must be a string, number or boolean.
+func groupingKey(keyFn Function, ctx FunctionContext, v interface{}) (string, error) {
+	key, err := keyFn.Exec(ctx.WithValue(v))
+	if err != nil {
+		return "", err
+	}
+	switch key.(type) {
+	case string, []byte, int64, uint64, float64, json.Number, bool:
+		return IToString(key), nil
+	}
+	return "", NewTypeError(key, ValueString, ValueNumber, ValueBool)
+}
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"index",
@@ -588,10 +720,166 @@ var _ = registerSimpleMethod(
 
 //------------------------------------------------------------------------------
 
+// jsonSchemaFromArg parses a JSON schema, which is either a document or the
+// URL of a document when prefixed with file://, http:// or https://. Relative
+// file paths are resolved from the working directory.
+func jsonSchemaFromArg(arg string) (*jsonschema.Schema, error) {
+	if path := strings.TrimPrefix(arg, "file://"); path != arg && !filepath.IsAbs(path) {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve json schema path: %w", err)
+		}
+		arg = "file://" + filepath.ToSlash(absPath)
+	}
+
+	loader := jsonschema.NewStringLoader(arg)
+	for _, scheme := range []string{"file://", "http://", "https://"} {
+		if strings.HasPrefix(arg, scheme) {
+			loader = jsonschema.NewReferenceLoader(arg)
+			break
+		}
+	}
+	schema, err := jsonschema.NewSchema(loader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse json schema definition: %w", err)
+	}
+	return schema, nil
+}
+
+// jsonSchemaFailures validates a value against a schema and returns a
+// description of each validation failure.
+func jsonSchemaFailures(schema *jsonschema.Schema, v interface{}) ([]string, error) {
+	result, err := schema.Validate(jsonschema.NewGoLoader(v))
+	if err != nil {
+		return nil, err
+	}
+	failures := make([]string, 0, len(result.Errors()))
+	for _, desc := range result.Errors() {
+		description := strings.ToLower(desc.Description())
+		if property := desc.Details()["property"]; property != nil {
+			description = property.(string) + strings.TrimPrefix(description, strings.ToLower(property.(string)))
+		}
+		failures = append(failures, desc.Field()+" "+description)
+	}
+	return failures, nil
+}
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"jq",
+		"Executes a [jq](https://stedolan.github.io/jq/manual/) program against a value, allowing existing jq programs to be embedded within a mapping whilst they are being ported to Bloblang. When the program emits a single value it is returned as is, when it emits multiple values they are returned as an array and when it emits no values the result is `null`. In order to always receive an array wrap the program in brackets (`[ ... ]`).\n\nPrograms are executed with the [gojq library](https://github.com/itchyny/gojq), which has some [differences](https://github.com/itchyny/gojq#difference-to-jq) to the original jq implementation.",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"",
+		NewExampleSpec("",
+			`root.names = this.jq(".users[] | select(.age >= 18) | .name")`,
+			`{"users":[{"name":"Ash","age":34},{"name":"Blake","age":12},{"name":"Cam","age":21}]}`,
+			`{"names":["Ash","Cam"]}`,
+		),
+		NewExampleSpec("",
+			`root.totals = this.jq("[.orders | group_by(.customer)[] | {customer: .[0].customer, total: (map(.amount) | add)}]")`,
+			`{"orders":[{"customer":"a","amount":10},{"customer":"b","amount":5},{"customer":"a","amount":2}]}`,
+			`{"totals":[{"customer":"a","total":12},{"customer":"b","total":5}]}`,
+		),
+	).Beta(),
+	jqMethod,
+	true,
+	ExpectNArgs(1),
+	ExpectStringArg(0),
+)
+
+func jqMethod(args ...interface{}) (simpleMethod, error) {
+	query, err := gojq.Parse(args[0].(string))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse jq program: %w", err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile jq program: %w", err)
+	}
+	return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+		var emitted []interface{}
+		iter := code.Run(toJQValue(v))
+		for {
+			out, ok := iter.Next()
+			if !ok {
+				break
+			}
+			if err, ok := out.(error); ok {
+				return nil, err
+			}
+			emitted = append(emitted, fromJQValue(out))
+		}
+		switch len(emitted) {
+		case 0:
+			return nil, nil
+		case 1:
+			return emitted[0], nil
+		}
+		return emitted, nil
+	}, nil
+}
+
+// toJQValue converts a value into the types supported by gojq.
+func toJQValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[k] = toJQValue(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, e := range t {
+			s[i] = toJQValue(e)
+		}
+		return s
+	case Decimal:
+		return t.Float64()
+	}
+	switch t := ISanitize(v).(type) {
+	case []byte:
+		return string(t)
+	case int64:
+		return int(t)
+	case uint64:
+		return float64(t)
+	case string, float64, bool:
+		return t
+	}
+	return nil
+}
+
+// fromJQValue converts a value emitted by gojq into the types of Bloblang.
+func fromJQValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = fromJQValue(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = fromJQValue(e)
+		}
+	case int:
+		return int64(t)
+	case *big.Int:
+		if t.IsInt64() {
+			return t.Int64()
+		}
+		f, _ := new(big.Float).SetInt(t).Float64()
+		return f
+	}
+	return v
+}
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"json_schema",
-		"Checks a [JSON schema](https://json-schema.org/) against a value and returns the value if it matches or throws and error if it does not.",
+		"Checks a [JSON schema](https://json-schema.org/) against a value and returns the value if it matches or throws and error if it does not. The schema is either a JSON document or, when prefixed with `file://`, `http://` or `https://`, the URL of a document to load when the mapping is parsed, where relative `file://` paths such as `file://./schemas/order.json` are resolved from the directory of the process executing the mapping. Schemas following drafts 4, 6 and 7 of the specification are supported.\n\nThe error can be handled with a [`catch`](#catch), and in order to annotate a value with validation failures rather than throwing an error use the method [`json_schema_errors`](#json_schema_errors).",
 	).InCategory(
 		MethodCategoryObjectAndArray,
 		"",
@@ -613,30 +901,19 @@ var _ = registerSimpleMethod(
 			"In order to load a schema from a file use the `file` function.",
 			`root = this.json_schema(file(var("BENTHOS_TEST_BLOBLANG_SCHEMA_FILE")))`,
 		),
-	).Beta(),
+	).Beta().MarkImpure(),
 	func(args ...interface{}) (simpleMethod, error) {
-		schema, err := jsonschema.NewSchema(jsonschema.NewStringLoader(args[0].(string)))
+		schema, err := jsonSchemaFromArg(args[0].(string))
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse json schema definition: %w", err)
+			return nil, err
 		}
 		return func(res interface{}, ctx FunctionContext) (interface{}, error) {
-			result, err := schema.Validate(jsonschema.NewGoLoader(res))
+			failures, err := jsonSchemaFailures(schema, res)
 			if err != nil {
 				return nil, err
 			}
-			if !result.Valid() {
-				var errStr string
-				for i, desc := range result.Errors() {
-					if i > 0 {
-						errStr = errStr + "\n"
-					}
-					description := strings.ToLower(desc.Description())
-					if property := desc.Details()["property"]; property != nil {
-						description = property.(string) + strings.TrimPrefix(description, strings.ToLower(property.(string)))
-					}
-					errStr = errStr + desc.Field() + " " + description
-				}
-				return nil, errors.New(errStr)
+			if len(failures) > 0 {
+				return nil, errors.New(strings.Join(failures, "\n"))
 			}
 			return res, nil
 		}, nil
@@ -648,6 +925,93 @@ var _ = registerSimpleMethod(
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"json_schema_errors",
+		"Checks a [JSON schema](https://json-schema.org/) against a value and returns an array describing each validation failure, which is empty when the value matches. The schema argument follows the same rules as the method [`json_schema`](#json_schema).",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"",
+		NewExampleSpec("",
+			`root = this
+root.validation_errors = this.json_schema_errors("""{
+  "type":"object",
+  "properties":{
+    "foo":{
+      "type":"string"
+    }
+  }
+}""")`,
+			`{"foo":"bar"}`,
+			`{"foo":"bar","validation_errors":[]}`,
+			`{"foo":5}`,
+			`{"foo":5,"validation_errors":["foo invalid type. expected: string, given: integer"]}`,
+		),
+	).Beta().MarkImpure(),
+	func(args ...interface{}) (simpleMethod, error) {
+		schema, err := jsonSchemaFromArg(args[0].(string))
+		if err != nil {
+			return nil, err
+		}
+		return func(res interface{}, ctx FunctionContext) (interface{}, error) {
+			failures, err := jsonSchemaFailures(schema, res)
+			if err != nil {
+				return nil, err
+			}
+			errs := make([]interface{}, len(failures))
+			for i, f := range failures {
+				errs[i] = f
+			}
+			return errs, nil
+		}, nil
+	},
+	true,
+	ExpectNArgs(1),
+	ExpectStringArg(0),
+)
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"key_by", "",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Converts an array into an object, where each element is set under the key returned by a query argument executed on it. Keys can be strings, numbers or booleans, and when multiple elements result in the same key the last element is kept.",
+		NewExampleSpec("",
+			`root.users = this.users.key_by(ele -> ele.id)`,
+			`{"users":[{"id":"a","name":"Ada"},{"id":"b","name":"Bob"}]}`,
+			`{"users":{"a":{"id":"a","name":"Ada"},"b":{"id":"b","name":"Bob"}}}`,
+		),
+	).Beta(),
+	func(args ...interface{}) (simpleMethod, error) {
+		keyFn, ok := args[0].(Function)
+		if !ok {
+			return nil, fmt.Errorf("expected query argument, received %T", args[0])
+		}
+		return func(res interface{}, ctx FunctionContext) (interface{}, error) {
+			resArray, ok := res.([]interface{})
+			if !ok {
+				return nil, NewTypeError(res, ValueArray)
+			}
+			result := make(map[string]interface{}, len(resArray))
+			for i, v := range resArray {
+				key, err := groupingKey(keyFn, ctx, v)
+				if err != nil {
+					return nil, fmt.Errorf("element %v: %w", i, err)
+				}
+				result[key] = v
+			}
+			return result, nil
+		}, nil
+	},
+	false,
+	ExpectNArgs(1),
+	ExpectFunctionArg(0),
+)
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"keys",
@@ -725,7 +1089,7 @@ var _ = registerSimpleMethod(
 
 //------------------------------------------------------------------------------
 
-var _ = registerSimpleMethod(
+var _ = registerMethod(
 	NewMethodSpec(
 		"map_each", "",
 	).InCategory(
@@ -749,64 +1113,66 @@ Apply a mapping to each value of an object and replace the value with the result
 			`{"new_dict":{"bar":"WORLD","foo":"HELLO"}}`,
 		),
 	),
-	func(args ...interface{}) (simpleMethod, error) {
-		mapFn, ok := args[0].(Function)
-		if !ok {
-			return nil, fmt.Errorf("expected query argument, received %T", args[0])
-		}
-		return func(res interface{}, ctx FunctionContext) (interface{}, error) {
-			var resValue interface{}
-			var err error
-			switch t := res.(type) {
-			case []interface{}:
-				newSlice := make([]interface{}, 0, len(t))
-				for i, v := range t {
-					newV, mapErr := mapFn.Exec(ctx.WithValue(v))
-					if mapErr != nil {
-						return nil, fmt.Errorf("failed to process element %v: %w", i, ErrFrom(mapErr, mapFn))
-					}
-					switch newV.(type) {
-					case Delete:
-					case Nothing:
-						newSlice = append(newSlice, v)
-					default:
-						newSlice = append(newSlice, newV)
-					}
+	false,
+	lazyArrayMethod("map_each", mapEachMethod, mapEachStage),
+	ExpectNArgs(1),
+	ExpectFunctionArg(0),
+)
+
+func mapEachMethod(args ...interface{}) (simpleMethod, error) {
+	mapFn, ok := args[0].(Function)
+	if !ok {
+		return nil, fmt.Errorf("expected query argument, received %T", args[0])
+	}
+	return func(res interface{}, ctx FunctionContext) (interface{}, error) {
+		var resValue interface{}
+		var err error
+		switch t := res.(type) {
+		case []interface{}:
+			newSlice := make([]interface{}, 0, len(t))
+			for i, v := range t {
+				newV, mapErr := mapFn.Exec(ctx.WithValue(v))
+				if mapErr != nil {
+					return nil, fmt.Errorf("failed to process element %v: %w", i, ErrFrom(mapErr, mapFn))
 				}
-				resValue = newSlice
-			case map[string]interface{}:
-				newMap := make(map[string]interface{}, len(t))
-				for k, v := range t {
-					var ctxMap interface{} = map[string]interface{}{
-						"key":   k,
-						"value": v,
-					}
-					newV, mapErr := mapFn.Exec(ctx.WithValue(ctxMap))
-					if mapErr != nil {
-						return nil, fmt.Errorf("failed to process element %v: %w", k, ErrFrom(mapErr, mapFn))
-					}
-					switch newV.(type) {
-					case Delete:
-					case Nothing:
-						newMap[k] = v
-					default:
-						newMap[k] = newV
-					}
+				switch newV.(type) {
+				case Delete:
+				case Nothing:
+					newSlice = append(newSlice, v)
+				default:
+					newSlice = append(newSlice, newV)
 				}
-				resValue = newMap
-			default:
-				return nil, NewTypeError(res, ValueArray)
 			}
-			if err != nil {
-				return nil, err
+			resValue = newSlice
+		case map[string]interface{}:
+			newMap := make(map[string]interface{}, len(t))
+			for k, v := range t {
+				var ctxMap interface{} = map[string]interface{}{
+					"key":   k,
+					"value": v,
+				}
+				newV, mapErr := mapFn.Exec(ctx.WithValue(ctxMap))
+				if mapErr != nil {
+					return nil, fmt.Errorf("failed to process element %v: %w", k, ErrFrom(mapErr, mapFn))
+				}
+				switch newV.(type) {
+				case Delete:
+				case Nothing:
+					newMap[k] = v
+				default:
+					newMap[k] = newV
+				}
 			}
-			return resValue, nil
-		}, nil
-	},
-	false,
-	ExpectNArgs(1),
-	ExpectFunctionArg(0),
-)
+			resValue = newMap
+		default:
+			return nil, NewTypeError(res, ValueArray)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return resValue, nil
+	}, nil
+}
 
 //------------------------------------------------------------------------------
 
@@ -994,6 +1360,11 @@ var _ = registerMethod(
 			`{"foo":[{"id":"foo","v":"bbb"},{"id":"bar","v":"ccc"},{"id":"baz","v":"aaa"}]}`,
 			`{"sorted":[{"id":"baz","v":"aaa"},{"id":"foo","v":"bbb"},{"id":"bar","v":"ccc"}]}`,
 		),
+		NewExampleSpec("The mapping argument can also capture the `left` and `right` values under two names. The sort is stable, and therefore values that are equal retain their original order.",
+			`root.sorted = this.foo.sort((left, right) -> left.v < right.v)`,
+			`{"foo":[{"id":"foo","v":"bbb"},{"id":"bar","v":"aaa"},{"id":"baz","v":"aaa"}]}`,
+			`{"sorted":[{"id":"bar","v":"aaa"},{"id":"baz","v":"aaa"},{"id":"foo","v":"bbb"}]}`,
+		),
 	),
 	false, sortMethod,
 	ExpectOneOrZeroArgs(),
@@ -1032,11 +1403,18 @@ func sortMethod(target Function, args ...interface{}) (Function, error) {
 		if mapFn, ok = args[0].(Function); !ok {
 			return nil, fmt.Errorf("expected query argument, received %T", args[0])
 		}
+		pairs := false
+		if named, ok := mapFn.(*NamedContextFunction); ok && len(named.Names()) == 2 {
+			pairs = true
+		}
 		compareFn = func(ctx FunctionContext, values []interface{}, i, j int) (bool, error) {
 			var ctxValue interface{} = map[string]interface{}{
 				"left":  values[i],
 				"right": values[j],
 			}
+			if pairs {
+				ctxValue = []interface{}{values[i], values[j]}
+			}
 			v, err := mapFn.Exec(ctx.WithValue(ctxValue))
 			if err != nil {
 				return false, err
@@ -1060,7 +1438,7 @@ func sortMethod(target Function, args ...interface{}) (Function, error) {
 			values := make([]interface{}, 0, len(m))
 			values = append(values, m...)
 
-			sort.Slice(values, func(i, j int) bool {
+			sort.SliceStable(values, func(i, j int) bool {
 				if err == nil {
 					var b bool
 					b, err = compareFn(ctx, values, i, j)
@@ -1077,9 +1455,139 @@ func sortMethod(target Function, args ...interface{}) (Function, error) {
 	}, targets), nil
 }
 
+var _ = registerMethod(
+	NewMethodSpec(
+		"sort_by", "",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Attempts to sort the elements of an array in increasing order by a value extracted from each element with a query argument. The sort is stable, and therefore elements with equal values retain their original order. The query may return an array in order to sort by multiple keys, where each key is only compared when all prior keys are equal. Supports string and number values, and the type of keys at the same position must match.",
+		NewExampleSpec("",
+			`root.sorted = this.foo.sort_by(ele -> ele.id)`,
+			`{"foo":[{"id":"bbb"},{"id":"ccc"},{"id":"aaa"}]}`,
+			`{"sorted":[{"id":"aaa"},{"id":"bbb"},{"id":"ccc"}]}`,
+		),
+		NewExampleSpec("",
+			`root.sorted = this.people.sort_by(ele -> [ ele.surname, ele.age ]).map_each(ele -> ele.name)`,
+			`{"people":[{"name":"Ada","surname":"Smith","age":41},{"name":"Bob","surname":"Jones","age":30},{"name":"Cal","surname":"Smith","age":25}]}`,
+			`{"sorted":["Bob","Cal","Ada"]}`,
+		),
+	).Beta(),
+	false, sortByMethod,
+	ExpectNArgs(1),
+	ExpectFunctionArg(0),
+)
+
+func sortByMethod(target Function, args ...interface{}) (Function, error) {
+	mapFn, ok := args[0].(Function)
+	if !ok {
+		return nil, fmt.Errorf("expected query argument, received %T", args[0])
+	}
+
+	return ClosureFunction("method sort_by", func(ctx FunctionContext) (interface{}, error) {
+		v, err := target.Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := v.([]interface{})
+		if !ok {
+			return nil, NewTypeError(v, ValueArray)
+		}
+
+		type keyedValue struct {
+			index int
+			key   interface{}
+			value interface{}
+		}
+		values := make([]keyedValue, len(m))
+		for i, ele := range m {
+			key, err := mapFn.Exec(ctx.WithValue(ele))
+			if err != nil {
+				return nil, fmt.Errorf("element %v: %w", i, err)
+			}
+			values[i] = keyedValue{index: i, key: key, value: ele}
+		}
+
+		sort.SliceStable(values, func(i, j int) bool {
+			if err != nil {
+				return false
+			}
+			var c int
+			if c, err = compareSortKeys(values[i].key, values[j].key); err != nil {
+				// Errors refer to elements by their index within the target
+				// array rather than their current position in the sort.
+				lhs, rhs := values[i].index, values[j].index
+				if lhs > rhs {
+					lhs, rhs = rhs, lhs
+				}
+				err = fmt.Errorf("elements %v and %v: %w", lhs, rhs, err)
+			}
+			return c < 0
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		sorted := make([]interface{}, len(values))
+		for i, v := range values {
+			sorted[i] = v.value
+		}
+		return sorted, nil
+	}, aggregateTargetPaths(target, mapFn)), nil
+}
+
+// compareSortKeys returns a negative number when the left key is less than the
+// right, a positive number when greater, and zero when they are equal. Arrays
+// are compared element by element.
+func compareSortKeys(left, right interface{}) (int, error) {
+	switch l := left.(type) {
+	case float64, int, int64, uint64, json.Number:
+		lhs, err := IGetNumber(l)
+		if err != nil {
+			return 0, err
+		}
+		rhs, err := IGetNumber(right)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case lhs < rhs:
+			return -1, nil
+		case lhs > rhs:
+			return 1, nil
+		}
+		return 0, nil
+	case string, []byte:
+		lhs, err := IGetString(l)
+		if err != nil {
+			return 0, err
+		}
+		rhs, err := IGetString(right)
+		if err != nil {
+			return 0, err
+		}
+		return strings.Compare(lhs, rhs), nil
+	case []interface{}:
+		r, ok := right.([]interface{})
+		if !ok {
+			return 0, NewTypeError(right, ValueArray)
+		}
+		for i := 0; i < len(l) && i < len(r); i++ {
+			c, err := compareSortKeys(l[i], r[i])
+			if err != nil {
+				return 0, fmt.Errorf("key %v: %w", i, err)
+			}
+			if c != 0 {
+				return c, nil
+			}
+		}
+		return len(l) - len(r), nil
+	}
+	return 0, NewTypeError(left, ValueNumber, ValueString, ValueArray)
+}
+
 //------------------------------------------------------------------------------
 
-var _ = registerSimpleMethod(
+var _ = registerMethod(
 	NewMethodSpec(
 		"slice", "",
 	).InCategory(
@@ -1114,8 +1622,8 @@ root.the_rest = this.value.slice(0, -2)`,
 			`{"last_chunk":["buz","bev"],"the_rest":["foo","bar","baz"]}`,
 		),
 	),
-	sliceMethod,
 	true,
+	lazyArrayMethod("slice", sliceMethod, sliceStage),
 	ExpectAtLeastOneArg(),
 	ExpectIntArg(0),
 	ExpectIntArg(1),
@@ -1131,58 +1639,61 @@ func sliceMethod(args ...interface{}) (simpleMethod, error) {
 			return nil, fmt.Errorf("lower slice bound %v must be lower than upper (%v)", start, endV)
 		}
 	}
-	getBounds := func(l int64) (startV, endV int64, err error) {
-		endV = l
-		if end != nil {
-			if *end < 0 {
-				endV = endV + *end
-			} else {
-				endV = *end
-			}
-		}
-		if endV > l {
-			endV = l
-		}
-		if endV < 0 {
-			endV = 0
-		}
-		startV = start
-		if startV < 0 {
-			startV = l + startV
-			if startV < 0 {
-				startV = 0
-			}
-		}
-		if startV > endV {
-			err = fmt.Errorf("lower slice bound %v must be lower than or equal to upper bound (%v) and target length (%v)", startV, endV, l)
-		}
-		return
-	}
 	return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 		switch t := v.(type) {
 		case string:
-			start, end, err := getBounds(int64(len(t)))
+			from, to, err := sliceBounds(start, end, int64(len(t)))
 			if err != nil {
 				return nil, err
 			}
-			return t[start:end], nil
+			return t[from:to], nil
 		case []byte:
-			start, end, err := getBounds(int64(len(t)))
+			from, to, err := sliceBounds(start, end, int64(len(t)))
 			if err != nil {
 				return nil, err
 			}
-			return t[start:end], nil
+			return t[from:to], nil
 		case []interface{}:
-			start, end, err := getBounds(int64(len(t)))
+			from, to, err := sliceBounds(start, end, int64(len(t)))
 			if err != nil {
 				return nil, err
 			}
-			return t[start:end], nil
+			return t[from:to], nil
 		}
 		return nil, NewTypeError(v, ValueArray, ValueString)
 	}, nil
 }
 
+// sliceBounds returns the bounds of a slice of a sequence of length l, where
+// negative bounds are offsets from the end of the sequence.
+func sliceBounds(start int64, end *int64, l int64) (startV, endV int64, err error) {
+	endV = l
+	if end != nil {
+		if *end < 0 {
+			endV = endV + *end
+		} else {
+			endV = *end
+		}
+	}
+	if endV > l {
+		endV = l
+	}
+	if endV < 0 {
+		endV = 0
+	}
+	startV = start
+	if startV < 0 {
+		startV = l + startV
+		if startV < 0 {
+			startV = 0
+		}
+	}
+	if startV > endV {
+		err = fmt.Errorf("lower slice bound %v must be lower than or equal to upper bound (%v) and target length (%v)", startV, endV, l)
+	}
+	return
+}
+
 //------------------------------------------------------------------------------
 
 var _ = registerMethod(
@@ -1231,6 +1742,143 @@ func sumMethod(target Function, _ ...interface{}) (Function, error) {
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"mean", "",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Calculates the arithmetic mean of the numerical values of an array. An error is returned if the array is empty or contains non-numerical values.",
+		NewExampleSpec("",
+			`root.mean = this.values.mean()`,
+			`{"values":[3,8,4]}`,
+			`{"mean":5}`,
+		),
+	).Beta(),
+	func(args ...interface{}) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			nums, err := numbersFromArray(v)
+			if err != nil {
+				return nil, err
+			}
+			return statsMean(nums), nil
+		}, nil
+	},
+	false,
+	ExpectNArgs(0),
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"median", "",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Calculates the median of the numerical values of an array, where the median of an array with an even number of values is the mean of the two middle values. An error is returned if the array is empty or contains non-numerical values.",
+		NewExampleSpec("",
+			`root.median = this.values.median()`,
+			`{"values":[3,8,4,1]}`,
+			`{"median":3.5}`,
+		),
+	).Beta(),
+	func(args ...interface{}) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			nums, err := numbersFromArray(v)
+			if err != nil {
+				return nil, err
+			}
+			return statsPercentile(nums, 50), nil
+		}, nil
+	},
+	false,
+	ExpectNArgs(0),
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"variance", "",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Calculates the population variance of the numerical values of an array. An error is returned if the array is empty or contains non-numerical values.",
+		NewExampleSpec("",
+			`root.variance = this.values.variance()`,
+			`{"values":[2,4,4,4,5,5,7,9]}`,
+			`{"variance":4}`,
+		),
+	).Beta(),
+	func(args ...interface{}) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			nums, err := numbersFromArray(v)
+			if err != nil {
+				return nil, err
+			}
+			return statsVariance(nums), nil
+		}, nil
+	},
+	false,
+	ExpectNArgs(0),
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"stddev", "",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Calculates the population standard deviation of the numerical values of an array. An error is returned if the array is empty or contains non-numerical values.",
+		NewExampleSpec("",
+			`root.stddev = this.values.stddev()`,
+			`{"values":[2,4,4,4,5,5,7,9]}`,
+			`{"stddev":2}`,
+		),
+	).Beta(),
+	func(args ...interface{}) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			nums, err := numbersFromArray(v)
+			if err != nil {
+				return nil, err
+			}
+			return statsStdDev(nums), nil
+		}, nil
+	},
+	false,
+	ExpectNArgs(0),
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"percentile", "",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Calculates a percentile, between 0 and 100, of the numerical values of an array by linear interpolation between the two closest ranks. An error is returned if the array is empty or contains non-numerical values.",
+		NewExampleSpec("",
+			`root.p75 = this.values.percentile(75)`,
+			`{"values":[15,20,35,40,50]}`,
+			`{"p75":40}`,
+		),
+		NewExampleSpec("",
+			`root.p50 = this.values.percentile(50)`,
+			`{"values":[1,2,3,4]}`,
+			`{"p50":2.5}`,
+		),
+	).Beta(),
+	func(args ...interface{}) (simpleMethod, error) {
+		p := args[0].(float64)
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("percentile must be between 0 and 100, received %v", p)
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			nums, err := numbersFromArray(v)
+			if err != nil {
+				return nil, err
+			}
+			return statsPercentile(nums, p), nil
+		}, nil
+	},
+	true,
+	ExpectNArgs(1),
+	ExpectFloatArg(0),
+)
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"unique", "",
@@ -1336,6 +1984,55 @@ func uniqueMethod(args ...interface{}) (simpleMethod, error) {
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"unzip", "",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Converts an array of arrays of equal length into an array of arrays where each contains the elements found at one index of the original arrays. This is the inverse of the method `zip`.",
+		NewExampleSpec("",
+			`root.columns = this.rows.unzip()`,
+			`{"rows":[["a",1],["b",2],["c",3]]}`,
+			`{"columns":[["a","b","c"],[1,2,3]]}`,
+		),
+	).Beta(),
+	func(args ...interface{}) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			arr, ok := v.([]interface{})
+			if !ok {
+				return nil, NewTypeError(v, ValueArray)
+			}
+			rows := make([][]interface{}, len(arr))
+			for i, ele := range arr {
+				row, ok := ele.([]interface{})
+				if !ok {
+					return nil, fmt.Errorf("index %v: %w", i, NewTypeError(ele, ValueArray))
+				}
+				if i > 0 && len(row) != len(rows[0]) {
+					return nil, fmt.Errorf("index %v: expected array of length %v, received length %v", i, len(rows[0]), len(row))
+				}
+				rows[i] = row
+			}
+			if len(rows) == 0 {
+				return []interface{}{}, nil
+			}
+			columns := make([]interface{}, len(rows[0]))
+			for j := range columns {
+				column := make([]interface{}, len(rows))
+				for i, row := range rows {
+					column[i] = row[j]
+				}
+				columns[j] = column
+			}
+			return columns, nil
+		}, nil
+	},
+	false,
+	ExpectNArgs(0),
+)
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"values", "",
@@ -1366,6 +2063,56 @@ var _ = registerSimpleMethod(
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"window", "",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Returns an array of sliding windows over an array, where each window is an array of a given size. An optional second argument specifies the number of elements the window moves by each step, which defaults to one. Only complete windows are returned, and therefore an array smaller than the window size results in an empty array.",
+		NewExampleSpec("",
+			`root.pairs = this.values.window(2)`,
+			`{"values":[1,2,3,4]}`,
+			`{"pairs":[[1,2],[2,3],[3,4]]}`,
+		),
+		NewExampleSpec("",
+			`root.windows = this.values.window(3, 2)`,
+			`{"values":[1,2,3,4,5,6]}`,
+			`{"windows":[[1,2,3],[3,4,5]]}`,
+		),
+	).Beta(),
+	func(args ...interface{}) (simpleMethod, error) {
+		size, step := args[0].(int64), int64(1)
+		if len(args) > 1 {
+			step = args[1].(int64)
+		}
+		if size <= 0 {
+			return nil, fmt.Errorf("window size must be greater than zero, received %v", size)
+		}
+		if step <= 0 {
+			return nil, fmt.Errorf("window step must be greater than zero, received %v", step)
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			arr, ok := v.([]interface{})
+			if !ok {
+				return nil, NewTypeError(v, ValueArray)
+			}
+			windows := []interface{}{}
+			for i := int64(0); i+size <= int64(len(arr)); i += step {
+				window := make([]interface{}, size)
+				copy(window, arr[i:i+size])
+				windows = append(windows, window)
+			}
+			return windows, nil
+		}, nil
+	},
+	true,
+	ExpectBetweenNAndMArgs(1, 2),
+	ExpectIntArg(0),
+	ExpectIntArg(1),
+)
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"without", "",
@@ -1429,3 +2176,58 @@ func mapWithout(m map[string]interface{}, paths [][]string) map[string]interface
 }
 
 //------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"zip", "",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Pairs the elements of an array with the elements at the same index of one or more array arguments, returning an array of arrays. The length of the result is that of the shortest array, and any remaining elements of longer arrays are ignored.",
+		NewExampleSpec("",
+			`root.pairs = this.names.zip(this.ages)`,
+			`{"names":["alice","bob"],"ages":[31,28]}`,
+			`{"pairs":[["alice",31],["bob",28]]}`,
+		),
+		NewExampleSpec("",
+			`root.people = this.names.zip(this.ages).map_each(pair -> {"name":pair.index(0),"age":pair.index(1)})`,
+			`{"names":["alice","bob","carol"],"ages":[31,28]}`,
+			`{"people":[{"age":31,"name":"alice"},{"age":28,"name":"bob"}]}`,
+		),
+	).Beta(),
+	func(args ...interface{}) (simpleMethod, error) {
+		others := make([][]interface{}, len(args))
+		for i, arg := range args {
+			arr, ok := arg.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("argument %v: %w", i, NewTypeError(arg, ValueArray))
+			}
+			others[i] = arr
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			arr, ok := v.([]interface{})
+			if !ok {
+				return nil, NewTypeError(v, ValueArray)
+			}
+			length := len(arr)
+			for _, other := range others {
+				if len(other) < length {
+					length = len(other)
+				}
+			}
+			zipped := make([]interface{}, length)
+			for i := range zipped {
+				tuple := make([]interface{}, 0, len(others)+1)
+				tuple = append(tuple, arr[i])
+				for _, other := range others {
+					tuple = append(tuple, other[i])
+				}
+				zipped[i] = tuple
+			}
+			return zipped, nil
+		}, nil
+	},
+	true,
+	ExpectAtLeastOneArg(),
+)
+
+//------------------------------------------------------------------------------
@@ -1,6 +1,9 @@
 package query
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -69,3 +72,94 @@ func TestMethodImmutability(t *testing.T) {
 		})
 	}
 }
+
+func TestJSONSchemaFile(t *testing.T) {
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "schema.json")
+	require.NoError(t, ioutil.WriteFile(schemaPath, []byte(`{
+  "type":"object",
+  "properties":{
+    "foo":{"type":"string"},
+    "bar":{"type":"number"}
+  },
+  "required":["foo"]
+}`), 0644))
+
+	valid := map[string]interface{}{"foo": "hello"}
+	invalid := map[string]interface{}{"bar": "nope"}
+
+	fn, err := InitMethod("json_schema", NewLiteralFunction("", valid), "file://"+filepath.ToSlash(schemaPath))
+	require.NoError(t, err)
+
+	res, err := fn.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, valid, res)
+
+	fn, err = InitMethod("json_schema_errors", NewLiteralFunction("", invalid), "file://"+filepath.ToSlash(schemaPath))
+	require.NoError(t, err)
+
+	res, err = fn.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []interface{}{
+		"(root) foo is required",
+		"bar invalid type. expected: number, given: string",
+	}, res)
+
+	_, err = InitMethod("json_schema", NewLiteralFunction("", valid), "file://"+filepath.ToSlash(filepath.Join(dir, "nope.json")))
+	require.Error(t, err)
+}
+
+func TestJQMethod(t *testing.T) {
+	input := map[string]interface{}{
+		"id":    int64(5),
+		"price": json.Number("1.5"),
+		"tags":  []interface{}{"a", []byte("b")},
+	}
+
+	tests := map[string]struct {
+		program string
+		output  interface{}
+		err     string
+	}{
+		"single value": {
+			program: ".id + 1",
+			output:  int64(6),
+		},
+		"multiple values": {
+			program: ".tags[]",
+			output:  []interface{}{"a", "b"},
+		},
+		"no values": {
+			program: "empty",
+			output:  nil,
+		},
+		"object": {
+			program: "{id, total: (.price * 2)}",
+			output:  map[string]interface{}{"id": int64(5), "total": 3.0},
+		},
+		"error": {
+			program: `error("nope")`,
+			err:     "nope",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			fn, err := InitMethod("jq", NewLiteralFunction("", input), test.program)
+			require.NoError(t, err)
+
+			res, err := fn.Exec(FunctionContext{})
+			if test.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.output, res)
+		})
+	}
+
+	_, err := InitMethod("jq", NewLiteralFunction("", input), ".foo |")
+	require.Error(t, err)
+}
@@ -1,9 +1,19 @@
 package query
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/gabs/v2"
@@ -116,6 +126,54 @@ func TestMethods(t *testing.T) {
 			),
 			err: "string literal: record on line 2: wrong number of fields",
 		},
+		"check parse csv options": {
+			input: methods(
+				literalFn("id|qty|paid\nfoo|2|true\nbar||false"),
+				method("parse_csv", map[string]interface{}{
+					"delimiter": "|",
+					"types": map[string]interface{}{
+						"qty":  "int",
+						"paid": "bool",
+					},
+				}),
+			),
+			output: []interface{}{
+				map[string]interface{}{
+					"id":   "foo",
+					"qty":  int64(2),
+					"paid": true,
+				},
+				map[string]interface{}{
+					"id":   "bar",
+					"qty":  nil,
+					"paid": false,
+				},
+			},
+		},
+		"check parse csv no header row": {
+			input: methods(
+				literalFn("foo\t1.5\nbar \"baz\"\t2"),
+				method("parse_csv", map[string]interface{}{
+					"delimiter":   "\t",
+					"lazy_quotes": true,
+					"header_row":  false,
+					"types":       []interface{}{"string", "float"},
+				}),
+			),
+			output: []interface{}{
+				[]interface{}{"foo", 1.5},
+				[]interface{}{`bar "baz"`, 2.0},
+			},
+		},
+		"check parse csv type error": {
+			input: methods(
+				literalFn("foo,bar\n1,nope"),
+				method("parse_csv", map[string]interface{}{
+					"types": []interface{}{"int", "int"},
+				}),
+			),
+			err: `string literal: record on line 0: column bar: strconv.ParseInt: parsing "nope": invalid syntax`,
+		},
 		"check explode 1": {
 			input: methods(
 				jsonFn(`{"foo":[1,2,3],"id":"bar"}`),
@@ -372,6 +430,51 @@ func TestMethods(t *testing.T) {
 				[]interface{}{"ab", ""},
 			},
 		},
+		"check regexp find object": {
+			input: methods(
+				literalFn("-axxb-ab-"),
+				method("re_find_object", "a(?P<foo>x*)b"),
+			),
+			output: map[string]interface{}{
+				"0":   "axxb",
+				"foo": "xx",
+			},
+		},
+		"check regexp find object bytes": {
+			input: methods(
+				function(`content`),
+				method("re_find_object", "a(?P<foo>x*)b"),
+			),
+			messages: []easyMsg{{content: `-axxb-ab-`}},
+			output: map[string]interface{}{
+				"0":   []byte("axxb"),
+				"foo": []byte("xx"),
+			},
+		},
+		"check regexp find object no match": {
+			input: methods(
+				literalFn("nope"),
+				method("re_find_object", "a(?P<foo>x*)b"),
+			),
+			output: map[string]interface{}{},
+		},
+		"check regexp find all object": {
+			input: methods(
+				literalFn("k1=v1 k2=v2"),
+				method("re_find_all_object", `(\w+)=(?P<value>\w+)`),
+			),
+			output: []interface{}{
+				map[string]interface{}{"0": "k1=v1", "1": "k1", "value": "v1"},
+				map[string]interface{}{"0": "k2=v2", "1": "k2", "value": "v2"},
+			},
+		},
+		"check regexp find all object no match": {
+			input: methods(
+				literalFn("nope"),
+				method("re_find_all_object", `(\w+)=(?P<value>\w+)`),
+			),
+			output: []interface{}{},
+		},
 		"check regexp find all": {
 			input: methods(
 				literalFn("paranormal"),
@@ -529,6 +632,48 @@ func TestMethods(t *testing.T) {
 			),
 			output: float64(5.2),
 		},
+		"check to_int64": {
+			input: methods(
+				literalFn("9223372036854775807"),
+				method("to_int64"),
+			),
+			output: int64(9223372036854775807),
+		},
+		"check to_int64 2": {
+			input: methods(
+				literalFn(json.Number("1234567890123456789")),
+				method("to_int64"),
+			),
+			output: int64(1234567890123456789),
+		},
+		"check to_int64 3": {
+			input: methods(
+				literalFn(5.0),
+				method("to_int64"),
+			),
+			output: int64(5),
+		},
+		"check to_int64 4": {
+			input: methods(
+				literalFn(5.5),
+				method("to_int64"),
+			),
+			err: "number literal: value 5.5 has a fractional component",
+		},
+		"check to_int64 5": {
+			input: methods(
+				literalFn(uint64(18446744073709551615)),
+				method("to_int64"),
+			),
+			err: "number literal: value 18446744073709551615 does not fit within a 64-bit signed integer",
+		},
+		"check to_int64 6": {
+			input: methods(
+				literalFn("nope"),
+				method("to_int64"),
+			),
+			err: "string literal: failed to parse 'nope' as an integer",
+		},
 		"check not_null": {
 			input: methods(
 				literalFn(21.0),
@@ -543,6 +688,30 @@ func TestMethods(t *testing.T) {
 			),
 			err: `null literal: value is null`,
 		},
+		"check required": {
+			input: methods(
+				function("json", "foo"),
+				method("required"),
+			),
+			messages: []easyMsg{{content: `{"foo":"bar"}`}},
+			output:   "bar",
+		},
+		"check required 2": {
+			input: methods(
+				function("json", "foo"),
+				method("required"),
+			),
+			messages: []easyMsg{{content: `{"foo":""}`}},
+			err:      "json path `foo`: value is required",
+		},
+		"check required 3": {
+			input: methods(
+				function("json", "foo"),
+				method("required"),
+			),
+			messages: []easyMsg{{content: `{"bar":"baz"}`}},
+			err:      "json path `foo`: value is required",
+		},
 		"check index": {
 			input: methods(
 				jsonFn(`["foo","bar","baz"]`),
@@ -733,6 +902,36 @@ func TestMethods(t *testing.T) {
 			),
 			output: `5020219685658847592`,
 		},
+		"check murmur3 hash": {
+			input: methods(
+				literalFn("hello world"),
+				method("hash", "murmur3"),
+				method("string"),
+			),
+			output: `1586663183`,
+		},
+		"check bucket string": {
+			input: methods(
+				literalFn("hello world"),
+				method("bucket", int64(10)),
+			),
+			output: int64(9),
+		},
+		"check bucket kafka compatible": {
+			input: methods(
+				literalFn("21"),
+				method("bucket", int64(3)),
+			),
+			output: int64(0),
+		},
+		"check bucket bytes": {
+			input: methods(
+				literalFn("foo"),
+				method("bytes"),
+				method("bucket", int64(10)),
+			),
+			output: int64(6),
+		},
 		"check md5 hash": {
 			input: methods(
 				literalFn("hello world"),
@@ -1138,6 +1337,24 @@ func TestMethods(t *testing.T) {
 				"foo": "bar",
 			},
 		},
+		"check parse json numbers": {
+			input: methods(
+				literalFn(`{"id":1234567890123456789}`),
+				method("parse_json"),
+			),
+			output: map[string]interface{}{
+				"id": float64(1234567890123456789),
+			},
+		},
+		"check parse json use number": {
+			input: methods(
+				literalFn(`{"id":1234567890123456789}`),
+				method("parse_json", true),
+			),
+			output: map[string]interface{}{
+				"id": json.Number("1234567890123456789"),
+			},
+		},
 		"check parse json invalid": {
 			input: methods(
 				literalFn("not valid json"),
@@ -1393,6 +1610,34 @@ func TestMethods(t *testing.T) {
 			),
 			output: []interface{}{"FOO", "BAR"},
 		},
+		"check render template": {
+			input: methods(
+				literalFn(`{{ .name | upper }}: {{ join ", " .tags }}{{ if .missing }} nope{{ end }}`),
+				method("render_template", jsonFn(`{"name":"foo","tags":["a","b"]}`)),
+			),
+			output: "FOO: a, b",
+		},
+		"check render template no data": {
+			input: methods(
+				literalFn(`{{ "foo" | quote | repeat 2 }}`),
+				method("render_template"),
+			),
+			output: `"foo""foo"`,
+		},
+		"check render template bad template": {
+			input: methods(
+				literalFn(`{{ .foo `),
+				method("render_template"),
+			),
+			err: "string literal: failed to parse template: template: render_template:1: unclosed action",
+		},
+		"check render template bad function": {
+			input: methods(
+				literalFn(`{{ env "HOME" }}`),
+				method("render_template"),
+			),
+			err: `string literal: failed to parse template: template: render_template:1: function "env" not defined`,
+		},
 		"check map each 2": {
 			input: methods(
 				jsonFn(`["foo","bar"]`),
@@ -1481,6 +1726,52 @@ func TestMethods(t *testing.T) {
 			},
 			err: "expected number value, got null from field `this.does.not.exist`",
 		},
+		"check loop": {
+			input: methods(
+				literalFn(int64(3)),
+				method("loop",
+					arithmetic(NewFieldFunction(""), literalFn(int64(100)), ArithmeticLt),
+					arithmetic(NewFieldFunction(""), literalFn(int64(2)), ArithmeticMul),
+				),
+			),
+			output: int64(192),
+		},
+		"check loop no iterations": {
+			input: methods(
+				literalFn("foo"),
+				method("loop", false, literalFn("bar")),
+			),
+			output: "foo",
+		},
+		"check loop max iterations": {
+			input: methods(
+				literalFn(int64(0)),
+				method("loop",
+					true,
+					arithmetic(NewFieldFunction(""), literalFn(int64(1)), ArithmeticAdd),
+					int64(5),
+				),
+			),
+			err: "loop exceeded the maximum of 5 iterations",
+		},
+		"check loop max iterations reached exactly": {
+			input: methods(
+				literalFn(int64(0)),
+				method("loop",
+					arithmetic(NewFieldFunction(""), literalFn(int64(5)), ArithmeticLt),
+					arithmetic(NewFieldFunction(""), literalFn(int64(1)), ArithmeticAdd),
+					int64(5),
+				),
+			),
+			output: int64(5),
+		},
+		"check loop condition not bool": {
+			input: methods(
+				literalFn(int64(0)),
+				method("loop", literalFn("nope"), literalFn("bar")),
+			),
+			err: `expected bool value, got string from string literal ("nope")`,
+		},
 		"check keys literal": {
 			input: methods(
 				jsonFn(`{"foo":1,"bar":2}`),
@@ -2031,3 +2322,612 @@ func TestMethodNoArgsTargets(t *testing.T) {
 		assert.Contains(t, targets, exp, "method: %v", k)
 	}
 }
+
+func TestJWTMethodsRS256(t *testing.T) {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	privPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(pk),
+	})
+	pubBytes, err := x509.MarshalPKIXPublicKey(&pk.PublicKey)
+	require.NoError(t, err)
+	pubPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: pubBytes,
+	})
+
+	sign := func(claims map[string]interface{}) string {
+		t.Helper()
+		fn, err := InitMethod("sign_jwt", NewLiteralFunction("", claims), "RS256", string(privPEM))
+		require.NoError(t, err)
+		token, err := fn.Exec(FunctionContext{})
+		require.NoError(t, err)
+		return token.(string)
+	}
+
+	parse := func(token, alg, key string) (interface{}, error) {
+		t.Helper()
+		fn, err := InitMethod("parse_jwt", NewLiteralFunction("", token), alg, key)
+		require.NoError(t, err)
+		return fn.Exec(FunctionContext{})
+	}
+
+	token := sign(map[string]interface{}{"sub": "1234"})
+	claims, err := parse(token, "RS256", string(pubPEM))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"sub": "1234"}, claims)
+
+	_, err = parse(token, "HS256", "dont-tell-anyone")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "token algorithm RS256 does not match HS256")
+
+	token = sign(map[string]interface{}{"sub": "1234", "exp": time.Now().Add(-time.Minute).Unix()})
+	_, err = parse(token, "RS256", string(pubPEM))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "token has expired")
+
+	token = sign(map[string]interface{}{"sub": "1234", "nbf": time.Now().Add(time.Hour).Unix()})
+	_, err = parse(token, "RS256", string(pubPEM))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "token is not valid yet")
+
+	_, err = InitMethod("sign_jwt", NewLiteralFunction("", map[string]interface{}{}), "RS256", "not a key")
+	require.Error(t, err)
+}
+
+func TestAESGCMRandomNonce(t *testing.T) {
+	key, err := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	require.NoError(t, err)
+
+	fn, err := InitMethod("encrypt_aes", NewLiteralFunction("", "hello world!"), "gcm", string(key))
+	require.NoError(t, err)
+
+	first, err := fn.Exec(FunctionContext{})
+	require.NoError(t, err)
+
+	second, err := fn.Exec(FunctionContext{})
+	require.NoError(t, err)
+
+	// Each call generates a new nonce, which is prepended to the result.
+	assert.NotEqual(t, first, second)
+	assert.NotEqual(t, first.(string)[:12], second.(string)[:12])
+
+	for _, encrypted := range []interface{}{first, second} {
+		fn, err = InitMethod("decrypt_aes", NewLiteralFunction("", encrypted), "gcm", string(key))
+		require.NoError(t, err)
+
+		decrypted, err := fn.Exec(FunctionContext{})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("hello world!"), decrypted)
+	}
+
+	fn, err = InitMethod("decrypt_aes", NewLiteralFunction("", "short"), "gcm", string(key))
+	require.NoError(t, err)
+	_, err = fn.Exec(FunctionContext{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ciphertext is shorter than the nonce")
+
+	_, err = InitMethod("encrypt_aes", NewLiteralFunction("", "hello world!"), "ctr", string(key))
+	require.EqualError(t, err, "the ctr scheme requires an initialization vector")
+}
+
+func TestCompressionMethods(t *testing.T) {
+	input := []byte(strings.Repeat("hello world, ", 100))
+
+	for _, algorithm := range []string{"gzip", "zlib", "flate", "lz4", "snappy", "zstd"} {
+		algorithm := algorithm
+		t.Run(algorithm, func(t *testing.T) {
+			fn, err := InitMethod("compress", NewLiteralFunction("", input), algorithm)
+			require.NoError(t, err)
+
+			compressed, err := fn.Exec(FunctionContext{})
+			require.NoError(t, err)
+			assert.Less(t, len(compressed.([]byte)), len(input))
+
+			fn, err = InitMethod("decompress", NewLiteralFunction("", compressed), algorithm)
+			require.NoError(t, err)
+
+			decompressed, err := fn.Exec(FunctionContext{})
+			require.NoError(t, err)
+			assert.Equal(t, input, decompressed)
+		})
+	}
+
+	_, err := InitMethod("compress", NewLiteralFunction("", input), "nope")
+	require.EqualError(t, err, "unrecognized compression algorithm: nope")
+
+	fn, err := InitMethod("decompress", NewLiteralFunction("", input), "gzip")
+	require.NoError(t, err)
+	_, err = fn.Exec(FunctionContext{})
+	require.Error(t, err)
+}
+
+func TestTimestampLocalesAndDurations(t *testing.T) {
+	exec := func(method string, target interface{}, args ...interface{}) (interface{}, error) {
+		t.Helper()
+		fn, err := InitMethod(method, NewLiteralFunction("", target), args...)
+		if err != nil {
+			return nil, err
+		}
+		return fn.Exec(FunctionContext{})
+	}
+
+	for _, test := range []struct {
+		value, layout, locale, output string
+	}{
+		{value: "3 März 2021", layout: "2 January 2006", locale: "de", output: "2021-03-03T00:00:00Z"},
+		{value: "3 mär 2021", layout: "2 Jan 2006", locale: "de", output: "2021-03-03T00:00:00Z"},
+		{value: "martes, 2 marzo 2021", locale: "es", output: "2021-03-02T00:00:00Z"},
+		{value: "terça-feira, 2 março 2021", locale: "pt", output: "2021-03-02T00:00:00Z"},
+		{value: "2021-03-02 10:11:12", output: "2021-03-02T10:11:12Z"},
+	} {
+		res, err := exec("parse_timestamp", test.value, test.layout, test.locale)
+		require.NoError(t, err, test.value)
+		assert.Equal(t, test.output, res, test.value)
+	}
+
+	_, err := exec("parse_timestamp", "3 März 2021", "", "xx")
+	require.EqualError(t, err, "unrecognised locale: xx")
+
+	_, err = exec("parse_timestamp", "nope")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse 'nope' as a timestamp of a recognised format")
+
+	for _, d := range []string{"P", "PT", "1h", "P1H"} {
+		_, err = exec("parse_duration_iso8601", d)
+		require.Error(t, err, d)
+	}
+
+	res, err := exec("parse_duration_iso8601", "-P1W")
+	require.NoError(t, err)
+	assert.Equal(t, float64(-604800), res)
+
+	res, err = exec("ts_add", "2021-01-15T10:00:00Z", "-P1DT1H")
+	require.NoError(t, err)
+	assert.Equal(t, "2021-01-14T09:00:00Z", res)
+
+	res, err = exec("ts_diff", "2021-01-15T10:00:00Z", "2021-01-15T10:01:30.5Z")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"total_seconds": -90.5,
+		"days":          int64(0),
+		"hours":         int64(0),
+		"minutes":       int64(-1),
+		"seconds":       -30.5,
+		"iso8601":       "-PT1M30.5S",
+	}, res)
+}
+
+func TestGeoMethods(t *testing.T) {
+	exec := func(method string, target interface{}, args ...interface{}) (interface{}, error) {
+		t.Helper()
+		fn, err := InitMethod(method, NewLiteralFunction("", target), args...)
+		if err != nil {
+			return nil, err
+		}
+		return fn.Exec(FunctionContext{})
+	}
+
+	res, err := exec("geohash_encode", []interface{}{57.64911, 10.40744}, int64(11))
+	require.NoError(t, err)
+	assert.Equal(t, "u4pruydqqvj", res)
+
+	res, err = exec("geohash_decode", "u4pruydqqvj")
+	require.NoError(t, err)
+	assert.InDelta(t, 57.64911, res.(map[string]interface{})["lat"], 0.00001)
+	assert.InDelta(t, 10.40744, res.(map[string]interface{})["lon"], 0.00001)
+
+	_, err = exec("geohash_decode", "u4pa")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "geohash contains invalid character: 'a'")
+
+	_, err = exec("geohash_encode", map[string]interface{}{"lat": 1.0}, int64(5))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected coordinate object to contain a field lon")
+
+	_, err = exec("geohash_encode", []interface{}{1.0, 2.0}, int64(13))
+	require.EqualError(t, err, "precision must be between 1 and 12, received 13")
+
+	res, err = exec("haversine", map[string]interface{}{"lat": 0.0, "lon": 0.0}, 0.0, 1.0)
+	require.NoError(t, err)
+	assert.InDelta(t, 111195.08, res, 0.01)
+
+	_, err = exec("haversine", []interface{}{91.0, 0.0}, 0.0, 1.0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "latitude 91 is outside of the range -90 to 90")
+
+	square := []interface{}{
+		[]interface{}{0.0, 0.0},
+		[]interface{}{0.0, 10.0},
+		[]interface{}{10.0, 10.0},
+		[]interface{}{10.0, 0.0},
+	}
+	for _, test := range []struct {
+		point  []interface{}
+		inside bool
+	}{
+		{point: []interface{}{5.0, 5.0}, inside: true},
+		{point: []interface{}{9.9, 0.1}, inside: true},
+		{point: []interface{}{15.0, 5.0}, inside: false},
+		{point: []interface{}{-1.0, 5.0}, inside: false},
+		{point: []interface{}{5.0, 10.1}, inside: false},
+	} {
+		res, err = exec("within_polygon", test.point, square)
+		require.NoError(t, err)
+		assert.Equal(t, test.inside, res, test.point)
+	}
+
+	_, err = exec("within_polygon", []interface{}{5.0, 5.0}, square[:2])
+	require.EqualError(t, err, "expected polygon to contain at least three coordinates, found 2")
+}
+
+func TestIPMethods(t *testing.T) {
+	exec := func(method string, target interface{}, args ...interface{}) (interface{}, error) {
+		t.Helper()
+		fn, err := InitMethod(method, NewLiteralFunction("", target), args...)
+		if err != nil {
+			return nil, err
+		}
+		return fn.Exec(FunctionContext{})
+	}
+
+	res, err := exec("parse_ip", "fe80::1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"ip":         "fe80::1",
+		"version":    int64(6),
+		"private":    false,
+		"loopback":   false,
+		"multicast":  false,
+		"link_local": true,
+	}, res)
+
+	_, err = exec("parse_ip", "nope")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse 'nope' as an IP address")
+
+	for _, test := range []struct {
+		ip     string
+		cidrs  interface{}
+		inside bool
+	}{
+		{ip: "10.1.2.3", cidrs: "10.0.0.0/8", inside: true},
+		{ip: "::ffff:10.1.2.3", cidrs: "10.0.0.0/8", inside: true},
+		{ip: "11.1.2.3", cidrs: "10.0.0.0/8", inside: false},
+		{ip: "fd00::1", cidrs: []interface{}{"10.0.0.0/8", "fd00::/8"}, inside: true},
+		{ip: "fe00::1", cidrs: []interface{}{"10.0.0.0/8", "fd00::/8"}, inside: false},
+	} {
+		res, err = exec("ip_in_cidr", test.ip, test.cidrs)
+		require.NoError(t, err, test.ip)
+		assert.Equal(t, test.inside, res, test.ip)
+	}
+
+	_, err = exec("ip_in_cidr", "10.1.2.3", "10.0.0.0")
+	require.EqualError(t, err, "invalid CIDR address: 10.0.0.0")
+
+	res, err = exec("ip_to_int", "0.0.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, int64(256), res)
+
+	res, err = exec("ip_to_int", "::1:0")
+	require.NoError(t, err)
+	assert.Equal(t, "65536", res)
+
+	res, err = exec("ip_normalize", "2001:DB8:0:1:0:0:0:1")
+	require.NoError(t, err)
+	assert.Equal(t, "2001:db8:0:1::1", res)
+
+	res, err = exec("ip_mask", "10.20.30.40", int64(16))
+	require.NoError(t, err)
+	assert.Equal(t, "10.20.0.0", res)
+
+	res, err = exec("ip_mask", "2001:db8:1:2:3:4:5:6", int64(16))
+	require.NoError(t, err)
+	assert.Equal(t, "2001:db8:1:2::", res)
+
+	_, err = exec("ip_mask", "10.20.30.40", int64(33))
+	require.EqualError(t, err, "IPv4 prefix length must be between 0 and 32, received 33")
+}
+
+func TestStatsMethods(t *testing.T) {
+	exec := func(method string, target interface{}, args ...interface{}) (interface{}, error) {
+		t.Helper()
+		fn, err := InitMethod(method, NewLiteralFunction("", target), args...)
+		if err != nil {
+			return nil, err
+		}
+		return fn.Exec(FunctionContext{})
+	}
+
+	values := []interface{}{int64(2), 4.0, json.Number("4"), int64(4), 5.0, 5.0, 7.0, 9.0}
+
+	res, err := exec("mean", values)
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, res)
+
+	res, err = exec("median", values)
+	require.NoError(t, err)
+	assert.Equal(t, 4.5, res)
+
+	res, err = exec("variance", values)
+	require.NoError(t, err)
+	assert.Equal(t, 4.0, res)
+
+	res, err = exec("stddev", values)
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, res)
+
+	res, err = exec("percentile", values, int64(0))
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, res)
+
+	res, err = exec("percentile", values, int64(100))
+	require.NoError(t, err)
+	assert.Equal(t, 9.0, res)
+
+	res, err = exec("percentile", []interface{}{1.0, 2.0, 3.0, 4.0, 5.0}, 12.5)
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, res)
+
+	res, err = exec("median", []interface{}{7.0})
+	require.NoError(t, err)
+	assert.Equal(t, 7.0, res)
+
+	_, err = exec("percentile", values, int64(101))
+	require.EqualError(t, err, "percentile must be between 0 and 100, received 101")
+
+	_, err = exec("mean", []interface{}{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "array must contain at least one number")
+
+	_, err = exec("stddev", []interface{}{1.0, "nope"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "index 1")
+
+	_, err = exec("variance", "nope")
+	require.Error(t, err)
+}
+
+func TestGrokMethod(t *testing.T) {
+	exec := func(target interface{}, args ...interface{}) (interface{}, error) {
+		t.Helper()
+		fn, err := InitMethod("grok", NewLiteralFunction("", target), args...)
+		if err != nil {
+			return nil, err
+		}
+		return fn.Exec(FunctionContext{})
+	}
+
+	res, err := exec([]byte("foo 12.5"), "%{WORD:name} %{NUMBER:value:float}")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "foo", "value": 12.5}, res)
+
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "custom"), []byte(`# Custom patterns
+EVENT %{WORD:kind}:%{INT:id:int}
+`), 0644))
+
+	res, err = exec("create:42", "%{EVENT}", dir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"kind": "create", "id": int64(42)}, res)
+
+	_, err = exec("nope", "%{EVENT}", dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expression did not match")
+
+	_, err = exec("create:42", "%{EVENT}", filepath.Join(dir, "missing"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse patterns from path")
+
+	_, err = exec(int64(5), "%{WORD:name}")
+	require.Error(t, err)
+}
+
+func TestArrayUtilityMethods(t *testing.T) {
+	exec := func(method string, target interface{}, args ...interface{}) (interface{}, error) {
+		t.Helper()
+		fn, err := InitMethod(method, NewLiteralFunction("", target), args...)
+		if err != nil {
+			return nil, err
+		}
+		return fn.Exec(FunctionContext{})
+	}
+
+	values := []interface{}{"a", "b", "c", "d", "e"}
+
+	res, err := exec("chunk", values, int64(2))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		[]interface{}{"a", "b"},
+		[]interface{}{"c", "d"},
+		[]interface{}{"e"},
+	}, res)
+
+	res, err = exec("chunk", values, int64(10))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{values}, res)
+
+	res, err = exec("chunk", []interface{}{}, int64(2))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{}, res)
+
+	_, err = exec("chunk", values, int64(0))
+	require.EqualError(t, err, "chunk size must be greater than zero, received 0")
+
+	res, err = exec("window", values, int64(3))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		[]interface{}{"a", "b", "c"},
+		[]interface{}{"b", "c", "d"},
+		[]interface{}{"c", "d", "e"},
+	}, res)
+
+	res, err = exec("window", values, int64(2), int64(2))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		[]interface{}{"a", "b"},
+		[]interface{}{"c", "d"},
+	}, res)
+
+	res, err = exec("window", values, int64(6))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{}, res)
+
+	_, err = exec("window", values, int64(2), int64(-1))
+	require.EqualError(t, err, "window step must be greater than zero, received -1")
+
+	res, err = exec("zip", []interface{}{"a", "b", "c"}, []interface{}{int64(1), int64(2)}, []interface{}{true, false, true})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		[]interface{}{"a", int64(1), true},
+		[]interface{}{"b", int64(2), false},
+	}, res)
+
+	_, err = exec("zip", values, "nope")
+	require.EqualError(t, err, "argument 0: expected array value, got string (\"nope\")")
+
+	res, err = exec("unzip", []interface{}{
+		[]interface{}{"a", int64(1)},
+		[]interface{}{"b", int64(2)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		[]interface{}{"a", "b"},
+		[]interface{}{int64(1), int64(2)},
+	}, res)
+
+	res, err = exec("unzip", []interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{}, res)
+
+	_, err = exec("unzip", []interface{}{
+		[]interface{}{"a", int64(1)},
+		[]interface{}{"b"},
+	})
+	require.EqualError(t, err, "array literal: index 1: expected array of length 2, received length 1")
+}
+
+func TestSortByMethod(t *testing.T) {
+	exec := func(target interface{}, key Function) (interface{}, error) {
+		t.Helper()
+		fn, err := InitMethod("sort_by", NewLiteralFunction("", target), key)
+		require.NoError(t, err)
+		return fn.Exec(FunctionContext{})
+	}
+
+	values := []interface{}{
+		map[string]interface{}{"id": "a", "keys": []interface{}{"x", int64(2)}},
+		map[string]interface{}{"id": "b", "keys": []interface{}{"x", int64(1)}},
+		map[string]interface{}{"id": "c", "keys": []interface{}{"w", int64(3)}},
+		map[string]interface{}{"id": "d", "keys": []interface{}{"x", int64(1)}},
+		map[string]interface{}{"id": "e", "keys": []interface{}{"x"}},
+	}
+
+	res, err := exec(values, NewFieldFunction("keys"))
+	require.NoError(t, err)
+	var ids []interface{}
+	for _, v := range res.([]interface{}) {
+		ids = append(ids, v.(map[string]interface{})["id"])
+	}
+	assert.Equal(t, []interface{}{"c", "e", "b", "d", "a"}, ids)
+
+	_, err = exec([]interface{}{
+		map[string]interface{}{"v": "foo"},
+		map[string]interface{}{"v": int64(5)},
+	}, NewFieldFunction("v"))
+	require.Error(t, err)
+
+	// Elements are referenced by their index within the target array.
+	_, err = exec([]interface{}{
+		map[string]interface{}{"v": int64(3)},
+		map[string]interface{}{"v": int64(2)},
+		map[string]interface{}{"v": int64(1)},
+		map[string]interface{}{"v": "foo"},
+	}, NewFieldFunction("v"))
+	require.EqualError(t, err, "elements 0 and 3: expected string value, got number (3)")
+
+	_, err = exec([]interface{}{map[string]interface{}{"v": true}, map[string]interface{}{"v": false}}, NewFieldFunction("v"))
+	require.Error(t, err)
+
+	_, err = exec("nope", NewFieldFunction("v"))
+	require.EqualError(t, err, `expected array value, got string ("nope")`)
+}
+
+func TestNamedContextsFunction(t *testing.T) {
+	sum, err := NewArithmeticExpression(
+		[]Function{NewNamedContextFieldFunction("a", ""), NewNamedContextFieldFunction("b", "")},
+		[]ArithmeticOperator{ArithmeticAdd},
+	)
+	require.NoError(t, err)
+	fn := NewNamedContextsFunction([]string{"a", "_", "b"}, sum)
+
+	res, err := fn.Exec(FunctionContext{}.WithValue([]interface{}{int64(1), int64(2), int64(3)}))
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), res)
+
+	_, err = fn.Exec(FunctionContext{}.WithValue([]interface{}{int64(1), int64(2)}))
+	require.EqualError(t, err, "failed to capture context a, _, b: expected array of 3 values, received 2")
+
+	_, err = fn.Exec(FunctionContext{}.WithValue("nope"))
+	require.EqualError(t, err, `failed to capture context a, _, b: expected array value, got string ("nope")`)
+}
+
+func TestGroupingMethods(t *testing.T) {
+	exec := func(method string, target interface{}, args ...interface{}) (interface{}, error) {
+		t.Helper()
+		fn, err := InitMethod(method, NewLiteralFunction("", target), args...)
+		require.NoError(t, err)
+		return fn.Exec(FunctionContext{})
+	}
+
+	values := []interface{}{
+		map[string]interface{}{"id": "a", "type": "foo", "n": int64(1)},
+		map[string]interface{}{"id": "b", "type": "bar", "n": int64(2)},
+		map[string]interface{}{"id": "c", "type": "foo", "n": int64(2)},
+	}
+
+	res, err := exec("group_by", values, NewFieldFunction("type"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"foo": []interface{}{values[0], values[2]},
+		"bar": []interface{}{values[1]},
+	}, res)
+
+	res, err = exec("group_by", values, NewFieldFunction("n"), NewFieldFunction("0.id"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"1": "a",
+		"2": "b",
+	}, res)
+
+	_, err = exec("group_by", values, NewFieldFunction("type"), ClosureFunction("", func(ctx FunctionContext) (interface{}, error) {
+		return nil, errors.New("nope")
+	}, nil))
+	require.EqualError(t, err, "array literal: group foo: nope")
+
+	_, err = exec("group_by", values, NewFieldFunction("nope"))
+	require.EqualError(t, err, "array literal: element 0: expected string, number or bool value, got null")
+
+	_, err = exec("group_by", "nope", NewFieldFunction("type"))
+	require.EqualError(t, err, `expected array value, got string from string literal ("nope")`)
+
+	res, err = exec("key_by", values, NewFieldFunction("id"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a": values[0],
+		"b": values[1],
+		"c": values[2],
+	}, res)
+
+	res, err = exec("key_by", values, NewFieldFunction("type"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"foo": values[2],
+		"bar": values[1],
+	}, res)
+
+	_, err = exec("key_by", values, NewFieldFunction("nope"))
+	require.EqualError(t, err, "array literal: element 0: expected string, number or bool value, got null")
+}
@@ -49,6 +49,12 @@ type MessageBatch interface {
 	Len() int
 }
 
+// Resources provides functions with access to the resources of the component
+// executing a mapping, such as caches.
+type Resources interface {
+	GetCache(name string) (types.Cache, error)
+}
+
 // FunctionContext provides access to a range of query targets for functions to
 // reference.
 type FunctionContext struct {
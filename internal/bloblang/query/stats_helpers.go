@@ -0,0 +1,66 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// numbersFromArray returns the numerical values of a non-empty array.
+func numbersFromArray(v interface{}) ([]float64, error) {
+	arr, ok := ISanitize(v).([]interface{})
+	if !ok {
+		return nil, NewTypeError(v, ValueArray)
+	}
+	if len(arr) == 0 {
+		return nil, errors.New("array must contain at least one number")
+	}
+	nums := make([]float64, len(arr))
+	for i, e := range arr {
+		n, err := IGetNumber(e)
+		if err != nil {
+			return nil, fmt.Errorf("index %v: %w", i, err)
+		}
+		nums[i] = n
+	}
+	return nums, nil
+}
+
+func statsMean(nums []float64) float64 {
+	var total float64
+	for _, n := range nums {
+		total += n
+	}
+	return total / float64(len(nums))
+}
+
+// statsVariance returns the population variance of a set of numbers.
+func statsVariance(nums []float64) float64 {
+	mean := statsMean(nums)
+	var total float64
+	for _, n := range nums {
+		total += (n - mean) * (n - mean)
+	}
+	return total / float64(len(nums))
+}
+
+func statsStdDev(nums []float64) float64 {
+	return math.Sqrt(statsVariance(nums))
+}
+
+// statsPercentile returns the percentile p, between 0 and 100, of a set of
+// numbers by linear interpolation between the two closest ranks.
+func statsPercentile(nums []float64, p float64) float64 {
+	sorted := make([]float64, len(nums))
+	copy(sorted, nums)
+	sort.Float64s(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	frac := rank - float64(lower)
+	return sorted[lower] + frac*(sorted[lower+1]-sorted[lower])
+}
@@ -142,6 +142,17 @@ func (ctx TargetsContext) WithContextAsNamed(name string) TargetsContext {
 	return ctx
 }
 
+// withNamedPaths returns a targets context with a named context of the provided
+// paths, leaving the main context unchanged.
+func (ctx TargetsContext) withNamedPaths(name string, paths []TargetPath) TargetsContext {
+	ctx.namedContext = &namedContextPath{
+		name:  name,
+		paths: paths,
+		next:  ctx.namedContext,
+	}
+	return ctx
+}
+
 // PopContext returns a targets context with the latest context dropped and the
 // previous (when applicable) returned.
 func (ctx TargetsContext) PopContext() TargetsContext {
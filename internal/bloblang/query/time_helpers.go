@@ -0,0 +1,302 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampLocale contains the month and weekday names of a language, both
+// ordered by their respective time package constants.
+type timestampLocale struct {
+	months      [12]string
+	shortMonths [12]string
+	days        [7]string
+	shortDays   [7]string
+}
+
+var timestampLocales = map[string]timestampLocale{
+	"de": {
+		months:      [12]string{"januar", "februar", "märz", "april", "mai", "juni", "juli", "august", "september", "oktober", "november", "dezember"},
+		shortMonths: [12]string{"jan", "feb", "mär", "apr", "mai", "jun", "jul", "aug", "sep", "okt", "nov", "dez"},
+		days:        [7]string{"sonntag", "montag", "dienstag", "mittwoch", "donnerstag", "freitag", "samstag"},
+		shortDays:   [7]string{"so", "mo", "di", "mi", "do", "fr", "sa"},
+	},
+	"es": {
+		months:      [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		shortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sep", "oct", "nov", "dic"},
+		days:        [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		shortDays:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	},
+	"fr": {
+		months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonths: [12]string{"janv", "févr", "mars", "avr", "mai", "juin", "juil", "août", "sept", "oct", "nov", "déc"},
+		days:        [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		shortDays:   [7]string{"dim", "lun", "mar", "mer", "jeu", "ven", "sam"},
+	},
+	"it": {
+		months:      [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		shortMonths: [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		days:        [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		shortDays:   [7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+	},
+	"nl": {
+		months:      [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		shortMonths: [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		days:        [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		shortDays:   [7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+	},
+	"pt": {
+		months:      [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		shortMonths: [12]string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+		days:        [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		shortDays:   [7]string{"dom", "seg", "ter", "qua", "qui", "sex", "sáb"},
+	},
+}
+
+var timestampWordRegexp = regexp.MustCompile(`\p{L}+(?:-\p{L}+)*`)
+
+// translator returns a function that replaces the month and weekday names of
+// the locale within a string with their English equivalents, using either
+// full or abbreviated English names. Month names take precedence over weekday
+// names where an abbreviation is ambiguous.
+func (l timestampLocale) translator(shortMonths, shortDays bool) func(s string) string {
+	words := map[string]string{}
+	addWords := func(names []string, english func(i int) string, short bool) {
+		for i, name := range names {
+			if short {
+				words[name] = english(i)[:3]
+			} else {
+				words[name] = english(i)
+			}
+		}
+	}
+	weekday := func(i int) string { return time.Weekday(i).String() }
+	month := func(i int) string { return time.Month(i + 1).String() }
+	addWords(l.shortDays[:], weekday, shortDays)
+	addWords(l.days[:], weekday, shortDays)
+	addWords(l.shortMonths[:], month, shortMonths)
+	addWords(l.months[:], month, shortMonths)
+
+	return func(s string) string {
+		return timestampWordRegexp.ReplaceAllStringFunc(s, func(word string) string {
+			if english, exists := words[strings.ToLower(word)]; exists {
+				return english
+			}
+			return word
+		})
+	}
+}
+
+// fuzzyTimestampLayouts are attempted in order when parsing a timestamp without
+// a specified format.
+var fuzzyTimestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.RFC822Z,
+	time.RFC822,
+	time.RubyDate,
+	time.UnixDate,
+	time.ANSIC,
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006",
+	"2 January 2006 15:04:05",
+	"2 January 2006",
+	"Jan 2, 2006 15:04:05",
+	"Jan 2, 2006",
+	"January 2, 2006 15:04:05",
+	"January 2, 2006",
+	"Monday, January 2, 2006",
+	"Monday, 2 January 2006",
+}
+
+// parseTimestamp parses a timestamp following a layout, or by attempting a
+// range of common layouts when the layout is empty. When a locale is specified
+// the names of months and weekdays are expected in its language.
+func parseTimestamp(layout, locale, value string) (time.Time, error) {
+	candidates := []string{value}
+	if locale != "" {
+		l, exists := timestampLocales[locale]
+		if !exists {
+			return time.Time{}, fmt.Errorf("unrecognised locale: %v", locale)
+		}
+		if layout != "" {
+			shortMonths := !strings.Contains(layout, "January") && strings.Contains(layout, "Jan")
+			shortDays := !strings.Contains(layout, "Monday") && strings.Contains(layout, "Mon")
+			candidates[0] = l.translator(shortMonths, shortDays)(value)
+		} else {
+			candidates = []string{
+				l.translator(false, false)(value),
+				l.translator(true, true)(value),
+			}
+		}
+	}
+
+	if layout != "" {
+		return time.Parse(layout, candidates[0])
+	}
+	for _, c := range candidates {
+		for _, l := range fuzzyTimestampLayouts {
+			if t, err := time.Parse(l, c); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("failed to parse '%v' as a timestamp of a recognised format", value)
+}
+
+//------------------------------------------------------------------------------
+
+var iso8601DurationRegexp = regexp.MustCompile(`^([+-])?P(?:([0-9.,]+)Y)?(?:([0-9.,]+)M)?(?:([0-9.,]+)W)?(?:([0-9.,]+)D)?(?:T(?:([0-9.,]+)H)?(?:([0-9.,]+)M)?(?:([0-9.,]+)S)?)?$`)
+
+// iso8601Duration is a duration expressed as ISO 8601 components, where
+// years, months, weeks and days are calendar based.
+type iso8601Duration struct {
+	negative bool
+	years    float64
+	months   float64
+	weeks    float64
+	days     float64
+	hours    float64
+	minutes  float64
+	seconds  float64
+}
+
+func parseISO8601Duration(s string) (iso8601Duration, error) {
+	var d iso8601Duration
+	matches := iso8601DurationRegexp.FindStringSubmatch(s)
+	if matches == nil || strings.HasSuffix(s, "T") {
+		return d, fmt.Errorf("failed to parse '%v' as an ISO 8601 duration", s)
+	}
+	d.negative = matches[1] == "-"
+
+	found := false
+	for i, c := range []*float64{&d.years, &d.months, &d.weeks, &d.days, &d.hours, &d.minutes, &d.seconds} {
+		str := matches[i+2]
+		if str == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.Replace(str, ",", ".", 1), 64)
+		if err != nil {
+			return d, fmt.Errorf("failed to parse '%v' as an ISO 8601 duration: %w", s, err)
+		}
+		*c = v
+		found = true
+	}
+	if !found {
+		return d, fmt.Errorf("failed to parse '%v' as an ISO 8601 duration", s)
+	}
+	return d, nil
+}
+
+// Seconds returns the total number of seconds of the duration, where years are
+// approximated as 365 days and months as 30 days.
+func (d iso8601Duration) Seconds() float64 {
+	days := d.years*365 + d.months*30 + d.weeks*7 + d.days
+	secs := days*86400 + d.hours*3600 + d.minutes*60 + d.seconds
+	if d.negative {
+		return -secs
+	}
+	return secs
+}
+
+// AddTo adds the duration to a timestamp, where whole years, months, weeks and
+// days are added following the calendar.
+func (d iso8601Duration) AddTo(t time.Time) time.Time {
+	sign := 1
+	if d.negative {
+		sign = -1
+	}
+	whole := func(f float64) bool {
+		return f == math.Trunc(f)
+	}
+	if whole(d.years) && whole(d.months) && whole(d.weeks) && whole(d.days) {
+		t = t.AddDate(sign*int(d.years), sign*int(d.months), sign*int(d.weeks*7+d.days))
+		clock := d
+		clock.years, clock.months, clock.weeks, clock.days = 0, 0, 0, 0
+		return t.Add(secondsToDuration(clock.Seconds()))
+	}
+	return t.Add(secondsToDuration(d.Seconds()))
+}
+
+func secondsToDuration(secs float64) time.Duration {
+	return time.Duration(math.Round(secs * float64(time.Second)))
+}
+
+// durationToISO8601 formats a duration following ISO 8601 using days, hours,
+// minutes and seconds.
+func durationToISO8601(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	b.WriteByte('P')
+	if days := d / (24 * time.Hour); days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+		d -= days * 24 * time.Hour
+	}
+	if d > 0 {
+		b.WriteByte('T')
+		if hours := d / time.Hour; hours > 0 {
+			fmt.Fprintf(&b, "%dH", hours)
+			d -= hours * time.Hour
+		}
+		if minutes := d / time.Minute; minutes > 0 {
+			fmt.Fprintf(&b, "%dM", minutes)
+			d -= minutes * time.Minute
+		}
+		if d > 0 {
+			b.WriteString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64))
+			b.WriteByte('S')
+		}
+	}
+	return b.String()
+}
+
+// durationFromValue extracts a duration from a number of seconds, an ISO 8601
+// duration string or a duration string as parsed by time.ParseDuration.
+func durationFromValue(v interface{}) (func(t time.Time) time.Time, error) {
+	switch t := ISanitize(v).(type) {
+	case string:
+		if strings.HasPrefix(strings.TrimLeft(t, "+-"), "P") {
+			d, err := parseISO8601Duration(t)
+			if err != nil {
+				return nil, err
+			}
+			return d.AddTo, nil
+		}
+		d, err := time.ParseDuration(t)
+		if err != nil {
+			return nil, err
+		}
+		return func(ts time.Time) time.Time {
+			return ts.Add(d)
+		}, nil
+	case []byte:
+		return durationFromValue(string(t))
+	}
+	secs, err := IGetNumber(v)
+	if err != nil {
+		return nil, errors.New("expected a duration as a number of seconds or a string")
+	}
+	return func(ts time.Time) time.Time {
+		return ts.Add(secondsToDuration(secs))
+	}, nil
+}
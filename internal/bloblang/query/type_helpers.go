@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/big"
 	"strconv"
 	"strings"
 	"time"
@@ -50,7 +51,7 @@ func ITypeOf(i interface{}) ValueType {
 		return ValueString
 	case []byte:
 		return ValueBytes
-	case int, int64, uint64, float64, json.Number:
+	case int, int64, uint64, float64, json.Number, Decimal:
 		return ValueNumber
 	case bool:
 		return ValueBool
@@ -92,6 +93,8 @@ func IGetNumber(v interface{}) (float64, error) {
 		return t, nil
 	case json.Number:
 		return t.Float64()
+	case Decimal:
+		return t.Float64(), nil
 	}
 	return 0, NewTypeError(v, ValueNumber)
 }
@@ -117,6 +120,8 @@ func IGetInt(v interface{}) (int64, error) {
 			return int64(f), nil
 		}
 		return 0, err
+	case Decimal:
+		return t.Int64(), nil
 	}
 	return 0, NewTypeError(v, ValueNumber)
 }
@@ -136,6 +141,8 @@ func IGetBool(v interface{}) (bool, error) {
 		return t != 0, nil
 	case json.Number:
 		return t.String() != "0", nil
+	case Decimal:
+		return t.getRat().Sign() != 0, nil
 	}
 	return false, NewTypeError(v, ValueBool)
 }
@@ -177,6 +184,9 @@ func IGetTimestamp(v interface{}) (time.Time, error) {
 		fint := math.Trunc(t)
 		fdec := t - fint
 		return time.Unix(int64(fint), int64(fdec*1e9)), nil
+	case Decimal:
+		nanos := newDecimalFromRat(new(big.Rat).Mul(t.getRat(), big.NewRat(1e9, 1)))
+		return time.Unix(0, nanos.Int64()), nil
 	case json.Number:
 		if i, err := t.Int64(); err == nil {
 			return time.Unix(i, 0), nil
@@ -210,10 +220,10 @@ func IIsNull(i interface{}) bool {
 
 // ISanitize takes a boxed value of any type and attempts to convert it into one
 // of the following types: string, []byte, int64, uint64, float64, bool,
-// []interface{}, map[string]interface{}, Delete, Nothing.
+// []interface{}, map[string]interface{}, Delete, Nothing, Decimal.
 func ISanitize(i interface{}) interface{} {
 	switch t := i.(type) {
-	case string, []byte, int64, uint64, float64, bool, []interface{}, map[string]interface{}, Delete, Nothing:
+	case string, []byte, int64, uint64, float64, bool, []interface{}, map[string]interface{}, Delete, Nothing, Decimal:
 		return i
 	case json.RawMessage:
 		return []byte(t)
@@ -252,6 +262,8 @@ func IToBytes(i interface{}) []byte {
 		return t
 	case json.Number:
 		return []byte(t.String())
+	case Decimal:
+		return []byte(t.String())
 	case int64, uint64, float64:
 		return []byte(fmt.Sprintf("%v", t)) // TODO
 	case bool:
@@ -278,6 +290,8 @@ func IToString(i interface{}) string {
 		return fmt.Sprintf("%v", t) // TODO
 	case json.Number:
 		return t.String()
+	case Decimal:
+		return t.String()
 	case bool:
 		if t {
 			return "true"
@@ -304,6 +318,8 @@ func IToNumber(v interface{}) (float64, error) {
 		return t, nil
 	case json.Number:
 		return t.Float64()
+	case Decimal:
+		return t.Float64(), nil
 	case []byte:
 		return strconv.ParseFloat(string(t), 64)
 	case string:
@@ -332,6 +348,8 @@ func IToInt(v interface{}) (int64, error) {
 		return int64(t), nil
 	case json.Number:
 		return t.Int64()
+	case Decimal:
+		return t.Int64(), nil
 	case []byte:
 		return strconv.ParseInt(string(t), 10, 64)
 	case string:
@@ -356,6 +374,8 @@ func IToBool(v interface{}) (bool, error) {
 		return t != 0, nil
 	case json.Number:
 		return t.String() != "0", nil
+	case Decimal:
+		return t.getRat().Sign() != 0, nil
 	case []byte:
 		if v, err := strconv.ParseBool(string(t)); err == nil {
 			return v, nil
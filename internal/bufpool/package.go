@@ -0,0 +1,47 @@
+// Package bufpool provides a process wide pool of reusable byte buffers for
+// hot paths such as message serialisation and field interpolation.
+package bufpool
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledCap is the largest buffer capacity that will be returned to the
+// pool, larger buffers are left for the garbage collector in order to prevent a
+// single pathological message from pinning a large allocation indefinitely.
+const maxPooledCap = 1 << 20
+
+var pool = sync.Pool{
+	New: func() interface{} {
+		return &bytes.Buffer{}
+	},
+}
+
+// Get returns an empty buffer from the pool.
+func Get() *bytes.Buffer {
+	return pool.Get().(*bytes.Buffer)
+}
+
+// Put returns a buffer to the pool. The buffer must not be used after calling
+// Put, including any byte slices obtained from it.
+func Put(b *bytes.Buffer) {
+	if b == nil || b.Cap() > maxPooledCap {
+		return
+	}
+	b.Reset()
+	pool.Put(b)
+}
+
+// Bytes returns an exactly sized copy of the contents of a buffer, allowing the
+// buffer to be returned to the pool. Building a result within a pooled buffer
+// and copying it out costs a single allocation, whereas building it within a
+// new buffer costs an allocation each time the buffer grows.
+func Bytes(b *bytes.Buffer) []byte {
+	if b.Len() == 0 {
+		return nil
+	}
+	c := make([]byte, b.Len())
+	copy(c, b.Bytes())
+	return c
+}
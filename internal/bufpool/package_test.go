@@ -0,0 +1,92 @@
+package bufpool
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolReuse(t *testing.T) {
+	b := Get()
+	b.WriteString("hello world")
+
+	c := Bytes(b)
+	Put(b)
+
+	assert.Equal(t, "hello world", string(c))
+
+	b = Get()
+	assert.Equal(t, 0, b.Len())
+	Put(b)
+}
+
+func TestPoolOversized(t *testing.T) {
+	b := &bytes.Buffer{}
+	b.Grow(maxPooledCap * 2)
+	b.WriteString("hello world")
+	Put(b)
+
+	// Buffers that are returned to the pool are reset, oversized buffers are
+	// left untouched.
+	assert.Equal(t, "hello world", b.String())
+
+	b = &bytes.Buffer{}
+	b.WriteString("hello world")
+	Put(b)
+	assert.Equal(t, 0, b.Len())
+}
+
+//------------------------------------------------------------------------------
+
+var benchChunks = [][]byte{
+	[]byte("the first part of a message, "),
+	[]byte("followed by an interpolated value "),
+	[]byte("and then a relatively long suffix that forces the buffer to grow"),
+}
+
+func BenchmarkBytesUnpooled(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var buf bytes.Buffer
+		for _, c := range benchChunks {
+			buf.Write(c)
+		}
+		_ = buf.Bytes()
+	}
+}
+
+func BenchmarkBytesPooled(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := Get()
+		for _, c := range benchChunks {
+			buf.Write(c)
+		}
+		_ = Bytes(buf)
+		Put(buf)
+	}
+}
+
+func BenchmarkStringUnpooled(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var buf bytes.Buffer
+		for _, c := range benchChunks {
+			buf.Write(c)
+		}
+		_ = string(buf.Bytes())
+	}
+}
+
+func BenchmarkStringPooled(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := Get()
+		for _, c := range benchChunks {
+			buf.Write(c)
+		}
+		_ = buf.String()
+		Put(buf)
+	}
+}
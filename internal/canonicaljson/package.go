@@ -0,0 +1,230 @@
+// Package canonicaljson serializes JSON values following the JSON
+// Canonicalization Scheme (JCS) described in RFC 8785, where object keys are
+// sorted, numbers are normalized and no insignificant whitespace is written,
+// such that documents that are semantically equal serialize identically.
+package canonicaljson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Canonicalize parses a JSON document and returns its canonical form.
+func Canonicalize(doc []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data following JSON document")
+	}
+	return Marshal(v)
+}
+
+// Marshal returns the canonical JSON form of a value. Numbers are represented
+// as IEEE 754 double precision values as required by the RFC, and therefore
+// integers beyond 2^53 lose precision. Values of types other than those
+// produced by parsing JSON are serialized with encoding/json and then
+// canonicalized.
+func Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeValue(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeValue(buf *bytes.Buffer, v interface{}) error {
+	switch t := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		if t {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case string:
+		return writeString(buf, t)
+	case json.Number:
+		f, err := strconv.ParseFloat(t.String(), 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %v: %w", t, err)
+		}
+		return writeNumber(buf, f)
+	case float64:
+		return writeNumber(buf, t)
+	case float32:
+		return writeNumber(buf, float64(t))
+	case int:
+		return writeNumber(buf, float64(t))
+	case int64:
+		return writeNumber(buf, float64(t))
+	case int32:
+		return writeNumber(buf, float64(t))
+	case uint64:
+		return writeNumber(buf, float64(t))
+	case uint32:
+		return writeNumber(buf, float64(t))
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, e := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeValue(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sortKeys(keys)
+
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeString(buf, k); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeValue(buf, t[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		doc, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		canon, err := Canonicalize(doc)
+		if err != nil {
+			return err
+		}
+		buf.Write(canon)
+	}
+	return nil
+}
+
+// sortKeys sorts object keys by their UTF-16 code units, which differs from
+// sorting by bytes for characters outside of the Basic Multilingual Plane.
+func sortKeys(keys []string) {
+	units := make(map[string][]uint16, len(keys))
+	for _, k := range keys {
+		units[k] = utf16.Encode([]rune(k))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := units[keys[i]], units[keys[j]]
+		for n := 0; n < len(a) && n < len(b); n++ {
+			if a[n] != b[n] {
+				return a[n] < b[n]
+			}
+		}
+		return len(a) < len(b)
+	})
+}
+
+func writeString(buf *bytes.Buffer, s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("string contains invalid UTF-8: %q", s)
+	}
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return nil
+}
+
+// writeNumber writes a number following the serialization of numbers in
+// ECMAScript, which uses the shortest decimal that round trips to the same
+// value, and an exponent only for numbers outside of the range 1e-7 to 1e21.
+func writeNumber(buf *bytes.Buffer, f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("number %v cannot be represented in JSON", f)
+	}
+	if f == 0 {
+		buf.WriteByte('0')
+		return nil
+	}
+	if f < 0 {
+		buf.WriteByte('-')
+		f = -f
+	}
+
+	// Formatted as d.ddde±xx, from which the digits and exponent are taken.
+	sci := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, expStr := sci[:strings.IndexByte(sci, 'e')], sci[strings.IndexByte(sci, 'e')+1:]
+	digits := strings.Replace(mantissa, ".", "", 1)
+	exp, err := strconv.Atoi(expStr)
+	if err != nil {
+		return err
+	}
+
+	// The position of the decimal point relative to the start of the digits.
+	k, n := len(digits), exp+1
+	switch {
+	case k <= n && n <= 21:
+		buf.WriteString(digits)
+		buf.WriteString(strings.Repeat("0", n-k))
+	case 0 < n && n <= 21:
+		buf.WriteString(digits[:n])
+		buf.WriteByte('.')
+		buf.WriteString(digits[n:])
+	case -6 < n && n <= 0:
+		buf.WriteString("0.")
+		buf.WriteString(strings.Repeat("0", -n))
+		buf.WriteString(digits)
+	default:
+		buf.WriteByte(digits[0])
+		if k > 1 {
+			buf.WriteByte('.')
+			buf.WriteString(digits[1:])
+		}
+		buf.WriteByte('e')
+		if n-1 >= 0 {
+			buf.WriteByte('+')
+		}
+		buf.WriteString(strconv.Itoa(n - 1))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package canonicaljson
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		output string
+	}{
+		"rfc example": {
+			input:  `{"numbers":[333333333.33333329,1E30,4.50,2e-3,0.000000000000000000000000001],"string":"\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/","literals":[null,true,false]}`,
+			output: `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		},
+		"key sorting by utf-16": {
+			input:  `{"€":"Euro Sign","\r":"Carriage Return","\ufb33":"Hebrew Letter Dalet With Dagesh","1":"One","😀":"Emoji: Grinning Face","\u0080":"Control","ö":"Latin Small Letter O With Diaeresis"}`,
+			output: `{"\r":"Carriage Return","1":"One","` + "\u0080" + `":"Control","ö":"Latin Small Letter O With Diaeresis","€":"Euro Sign","😀":"Emoji: Grinning Face","` + "\ufb33" + `":"Hebrew Letter Dalet With Dagesh"}`,
+		},
+		"nested whitespace": {
+			input: `{
+  "b": [ 1, { "d": 2, "c": 3 } ],
+  "a": {}
+}`,
+			output: `{"a":{},"b":[1,{"c":3,"d":2}]}`,
+		},
+		"html characters unescaped": {
+			input:  `{"a":"<b>&</b>"}`,
+			output: `{"a":"<b>&</b>"}`,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			res, err := Canonicalize([]byte(test.input))
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(res))
+		})
+	}
+}
+
+func TestCanonicalizeErrors(t *testing.T) {
+	for _, input := range []string{
+		`{"a":`,
+		`{"a":1} {"b":2}`,
+		`{"a":1e999}`,
+	} {
+		_, err := Canonicalize([]byte(input))
+		assert.Error(t, err, input)
+	}
+}
+
+func TestMarshalNumbers(t *testing.T) {
+	tests := []struct {
+		input  interface{}
+		output string
+	}{
+		{input: 0.0, output: "0"},
+		{input: math.Copysign(0, -1), output: "0"},
+		{input: 1.0, output: "1"},
+		{input: -1.5, output: "-1.5"},
+		{input: 1e20, output: "100000000000000000000"},
+		{input: 1e21, output: "1e+21"},
+		{input: 295147905179352830000.0, output: "295147905179352830000"},
+		{input: 0.000001, output: "0.000001"},
+		{input: 1e-7, output: "1e-7"},
+		{input: 123e-20, output: "1.23e-18"},
+		{input: 5e-324, output: "5e-324"},
+		{input: 1.7976931348623157e308, output: "1.7976931348623157e+308"},
+		{input: int64(9007199254740992), output: "9007199254740992"},
+		{input: int64(9007199254740993), output: "9007199254740992"},
+		{input: uint64(10), output: "10"},
+		{input: 10, output: "10"},
+	}
+
+	for _, test := range tests {
+		res, err := Marshal(test.input)
+		require.NoError(t, err, test.input)
+		assert.Equal(t, test.output, string(res), test.input)
+	}
+
+	_, err := Marshal(math.NaN())
+	assert.EqualError(t, err, "number NaN cannot be represented in JSON")
+
+	_, err = Marshal(math.Inf(1))
+	assert.EqualError(t, err, "number +Inf cannot be represented in JSON")
+}
+
+type marshaler struct{}
+
+func (marshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`{"b": 1.50, "a": 2}`), nil
+}
+
+func TestMarshalStructured(t *testing.T) {
+	res, err := Marshal(map[string]interface{}{
+		"z": []interface{}{"\x01", []byte("foo"), marshaler{}},
+		"a": map[string]interface{}{"y": nil, "x": true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":{"x":true,"y":null},"z":["\u0001","Zm9v",{"a":2,"b":1.5}]}`, string(res))
+
+	_, err = Marshal("\xff")
+	assert.EqualError(t, err, `string contains invalid UTF-8: "\xff"`)
+}
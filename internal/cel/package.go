@@ -0,0 +1,108 @@
+// Package cel evaluates expressions written in the Common Expression Language
+// (CEL) against messages, allowing predicates written for other systems to be
+// used within Benthos.
+package cel
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	celgo "github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var env *celgo.Env
+
+func init() {
+	var err error
+	if env, err = celgo.NewEnv(celgo.Declarations(
+		decls.NewVar("this", decls.Dyn),
+		decls.NewVar("meta", decls.NewMapType(decls.String, decls.String)),
+		decls.NewVar("content", decls.String),
+	)); err != nil {
+		panic(err)
+	}
+}
+
+// Program is a compiled CEL expression, which is safe to execute from
+// multiple goroutines.
+type Program struct {
+	prg celgo.Program
+}
+
+// Compile parses and type checks a CEL expression. Expressions can reference
+// the following variables:
+//
+// - this: The structured contents of a message, or null if it is not JSON.
+// - meta: The metadata of a message as a map of strings.
+// - content: The raw contents of a message as a string.
+func Compile(expr string) (*Program, error) {
+	ast, iss := env.Compile(expr)
+	if iss != nil && iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{prg: prg}, nil
+}
+
+var jsonValueType = reflect.TypeOf(&structpb.Value{})
+
+// Eval executes the program against a message and returns the result as a
+// generic structure that can be serialized to JSON.
+func (p *Program) Eval(this interface{}, meta map[string]string, content []byte) (interface{}, error) {
+	out, _, err := p.prg.Eval(map[string]interface{}{
+		"this":    sanitiseNumbers(this),
+		"meta":    meta,
+		"content": string(content),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromVal(out)
+}
+
+// sanitiseNumbers converts numbers parsed from JSON documents into doubles, as
+// CEL does not support the json.Number type.
+func sanitiseNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+		return t.String()
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[k] = sanitiseNumbers(v)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, v := range t {
+			s[i] = sanitiseNumbers(v)
+		}
+		return s
+	}
+	return v
+}
+
+func fromVal(v ref.Val) (interface{}, error) {
+	switch v.Type() {
+	case types.NullType:
+		return nil, nil
+	case types.BoolType, types.IntType, types.UintType, types.DoubleType, types.StringType, types.BytesType:
+		return v.Value(), nil
+	}
+	jv, err := v.ConvertToNative(jsonValueType)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported result type %v: %w", v.Type().TypeName(), err)
+	}
+	return jv.(*structpb.Value).AsInterface(), nil
+}
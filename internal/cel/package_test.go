@@ -0,0 +1,103 @@
+package cel
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgramEval(t *testing.T) {
+	this := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name": "George",
+			"age":  42.0,
+			"tags": []interface{}{"admin", "staff"},
+		},
+	}
+	meta := map[string]string{
+		"topic": "users",
+	}
+
+	tests := map[string]struct {
+		expr   string
+		output interface{}
+	}{
+		"bool": {
+			expr:   `this.user.age >= 18.0 && meta.topic == "users"`,
+			output: true,
+		},
+		"string": {
+			expr:   `this.user.name + " (" + meta.topic + ")"`,
+			output: "George (users)",
+		},
+		"int": {
+			expr:   `size(this.user.tags)`,
+			output: int64(2),
+		},
+		"content": {
+			expr:   `content.startsWith("{")`,
+			output: true,
+		},
+		"list": {
+			expr:   `this.user.tags.filter(t, t != "staff")`,
+			output: []interface{}{"admin"},
+		},
+		"map": {
+			expr:   `{"name": this.user.name}`,
+			output: map[string]interface{}{"name": "George"},
+		},
+		"null": {
+			expr:   `null`,
+			output: nil,
+		},
+		"missing metadata key": {
+			expr:   `"nope" in meta`,
+			output: false,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			prg, err := Compile(test.expr)
+			require.NoError(t, err)
+
+			res, err := prg.Eval(this, meta, []byte(`{"user":{}}`))
+			require.NoError(t, err)
+			assert.Equal(t, test.output, res)
+		})
+	}
+}
+
+func TestProgramJSONNumbers(t *testing.T) {
+	prg, err := Compile(`this.ages.all(a, a >= 18.0) && this.user.age == 42.5`)
+	require.NoError(t, err)
+
+	res, err := prg.Eval(map[string]interface{}{
+		"ages": []interface{}{json.Number("20"), json.Number("31")},
+		"user": map[string]interface{}{"age": json.Number("42.5")},
+	}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, true, res)
+}
+
+func TestProgramErrors(t *testing.T) {
+	_, err := Compile(`this.user.name ==`)
+	require.Error(t, err)
+
+	_, err = Compile(`nope == 1`)
+	require.Error(t, err)
+
+	prg, err := Compile(`this.user.age > 18`)
+	require.NoError(t, err)
+
+	_, err = prg.Eval(map[string]interface{}{
+		"user": map[string]interface{}{"age": 42.0},
+	}, nil, nil)
+	require.Error(t, err)
+
+	_, err = prg.Eval(map[string]interface{}{}, nil, nil)
+	require.Error(t, err)
+}
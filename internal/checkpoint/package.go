@@ -1,4 +1,5 @@
 // Package checkpoint implements a mechanism for tracking checkpointed integer
 // offsets for sequential read at-least-once queue systems such as Kafka or
-// Kinesis.
+// Kinesis, and for persisting the resume positions of sequentially read
+// sources such as files within cache resources.
 package checkpoint
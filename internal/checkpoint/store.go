@@ -0,0 +1,218 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/checkpoint"
+)
+
+// storeKeyPrefix is prepended to source names in order to avoid collisions with
+// other keys stored within the same cache resource.
+const storeKeyPrefix = "benthos_checkpoint:"
+
+// Store persists the resume positions of sequentially read sources within a
+// cache resource, keyed by the name of each source. Positions are the byte
+// offsets of sources immediately following their last acknowledged message.
+//
+// This component is safe to use concurrently across goroutines.
+type Store struct {
+	cache     types.Cache
+	keyPrefix string
+
+	mut       sync.Mutex
+	positions map[string]int64
+}
+
+// GetStore returns a checkpoint store backed by a cache resource. Managers that
+// provide checkpoint stores share a store between all components that use the
+// same cache resource, otherwise a new store is created with NewStore.
+func GetStore(mgr types.Manager, cacheName string) (*Store, error) {
+	if storeProv, ok := mgr.(interface {
+		GetCheckpointStore(name string) (*Store, error)
+	}); ok {
+		return storeProv.GetCheckpointStore(cacheName)
+	}
+	return NewStore(mgr, cacheName, "")
+}
+
+// NewStore creates a checkpoint store backed by a cache resource obtained from
+// a manager, and registers an HTTP endpoint with that manager at
+// /checkpoints/{cache} for inspecting and resetting the checkpoints within it.
+// An optional namespace is added to the keys of checkpoints, which prevents the
+// stores of different streams from overwriting each others checkpoints.
+func NewStore(mgr types.Manager, cacheName, namespace string) (*Store, error) {
+	cache, err := mgr.GetCache(cacheName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain checkpoint cache '%v': %w", cacheName, err)
+	}
+
+	keyPrefix := storeKeyPrefix
+	if len(namespace) > 0 {
+		keyPrefix += namespace + ":"
+	}
+
+	s := &Store{
+		cache:     cache,
+		keyPrefix: keyPrefix,
+		positions: map[string]int64{},
+	}
+
+	mgr.RegisterEndpoint(
+		"/checkpoints/"+cacheName,
+		"Get a map of source checkpoints stored within the cache resource '"+cacheName+
+			"', send a DELETE request with a source query parameter to reset the checkpoint of a source.",
+		s.handleHTTP,
+	)
+	return s, nil
+}
+
+// Get returns the stored resume position of a source, or zero if the source
+// does not have a checkpoint.
+func (s *Store) Get(source string) (int64, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	b, err := s.cache.Get(s.keyPrefix + source)
+	if err != nil {
+		if err == types.ErrKeyNotFound {
+			s.positions[source] = 0
+			return 0, nil
+		}
+		return 0, err
+	}
+	pos, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse checkpoint of source '%v': %w", source, err)
+	}
+	s.positions[source] = pos
+	return pos, nil
+}
+
+// Set stores the resume position of a source.
+func (s *Store) Set(source string, position int64) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if err := s.cache.Set(s.keyPrefix+source, []byte(strconv.FormatInt(position, 10))); err != nil {
+		return err
+	}
+	s.positions[source] = position
+	return nil
+}
+
+// Delete removes the checkpoint of a source, causing it to be read from the
+// beginning the next time it is opened.
+func (s *Store) Delete(source string) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if err := s.cache.Delete(s.keyPrefix + source); err != nil && err != types.ErrKeyNotFound {
+		return err
+	}
+	delete(s.positions, source)
+	return nil
+}
+
+func (s *Store) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mut.Lock()
+		resBytes, err := json.Marshal(s.positions)
+		s.mut.Unlock()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(resBytes)
+	case http.MethodDelete:
+		source := r.URL.Query().Get("source")
+		if len(source) == 0 {
+			http.Error(w, "a source query parameter is required", http.StatusBadRequest)
+			return
+		}
+		if err := s.Delete(source); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+	default:
+		http.Error(w, "method not supported", http.StatusMethodNotAllowed)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Source tracks the messages read from a sequential source by their byte
+// offsets, and commits the position of the source to a store as messages are
+// acknowledged, ensuring that the offset of an unacknowledged message is never
+// committed.
+type Source struct {
+	store *Store
+	name  string
+
+	mut    sync.Mutex
+	t      *checkpoint.Type
+	resume int64
+}
+
+// Source returns a tracker for the source of a given name, which resumes from
+// the stored position of that source.
+func (s *Store) Source(name string) (*Source, error) {
+	resume, err := s.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Source{
+		store:  s,
+		name:   name,
+		t:      checkpoint.New(0),
+		resume: resume,
+	}, nil
+}
+
+// Resume returns the byte offset of the source following the messages that
+// were acknowledged prior to it being opened, which the source should be read
+// from.
+func (s *Source) Resume() int64 {
+	return s.resume
+}
+
+// Track a newly read message of the source by the byte offset immediately
+// following it, returning a function to be called once the message is
+// acknowledged, which commits the highest offset of the source that has no
+// unacknowledged messages preceding it.
+func (s *Source) Track(offset int64) func() error {
+	// Offsets are tracked relative to the position the source was resumed
+	// from.
+	relative := int(offset - s.resume)
+
+	s.mut.Lock()
+	err := s.t.Track(relative)
+	s.mut.Unlock()
+	if err != nil {
+		return func() error {
+			return fmt.Errorf("failed to track offset %v: %w", offset, err)
+		}
+	}
+
+	return func() error {
+		s.mut.Lock()
+		defer s.mut.Unlock()
+
+		highest, err := s.t.Resolve(relative)
+		if err != nil {
+			return err
+		}
+		return s.store.Set(s.name, s.resume+int64(highest))
+	}
+}
+
+// Reset removes the checkpoint of the source.
+func (s *Source) Reset() error {
+	return s.store.Delete(s.name)
+}
@@ -0,0 +1,143 @@
+package checkpoint
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type storeMgr struct {
+	types.DudMgr
+	caches    map[string]types.Cache
+	endpoints map[string]http.HandlerFunc
+}
+
+func (s *storeMgr) RegisterEndpoint(path, desc string, h http.HandlerFunc) {
+	s.endpoints[path] = h
+}
+
+func (s *storeMgr) GetCache(name string) (types.Cache, error) {
+	if c, exists := s.caches[name]; exists {
+		return c, nil
+	}
+	return nil, types.ErrCacheNotFound
+}
+
+func newStoreMgr(t *testing.T) *storeMgr {
+	t.Helper()
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	return &storeMgr{
+		caches:    map[string]types.Cache{"foo": memCache},
+		endpoints: map[string]http.HandlerFunc{},
+	}
+}
+
+func TestStoreSourceResume(t *testing.T) {
+	mgr := newStoreMgr(t)
+
+	store, err := GetStore(mgr, "foo")
+	require.NoError(t, err)
+
+	src, err := store.Source("a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), src.Resume())
+
+	ack1, ack2, ack3 := src.Track(4), src.Track(9), src.Track(13)
+	require.NoError(t, ack2())
+
+	pos, err := store.Get("a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), pos, "position must not pass an unacknowledged message")
+
+	require.NoError(t, ack1())
+	pos, err = store.Get("a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), pos)
+
+	require.NoError(t, ack3())
+
+	store2, err := GetStore(mgr, "foo")
+	require.NoError(t, err)
+
+	src, err = store2.Source("a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(13), src.Resume())
+
+	ack4, ack5 := src.Track(20), src.Track(25)
+	require.NoError(t, ack5())
+	require.NoError(t, ack4())
+
+	pos, err = store2.Get("a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(25), pos)
+
+	require.NoError(t, src.Reset())
+	pos, err = store.Get("a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), pos)
+}
+
+func TestStoreNamespaces(t *testing.T) {
+	mgr := newStoreMgr(t)
+
+	storeA, err := NewStore(mgr, "foo", "a")
+	require.NoError(t, err)
+
+	storeB, err := NewStore(mgr, "foo", "b")
+	require.NoError(t, err)
+
+	require.NoError(t, storeA.Set("a.txt", 5))
+
+	pos, err := storeB.Get("a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), pos)
+
+	pos, err = storeA.Get("a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), pos)
+}
+
+func TestStoreMissingCache(t *testing.T) {
+	mgr := newStoreMgr(t)
+
+	_, err := GetStore(mgr, "bar")
+	require.Error(t, err)
+	assert.Empty(t, mgr.endpoints)
+}
+
+func TestStoreEndpoint(t *testing.T) {
+	mgr := newStoreMgr(t)
+
+	store, err := GetStore(mgr, "foo")
+	require.NoError(t, err)
+	require.NoError(t, store.Set("a.txt", 5))
+	require.NoError(t, store.Set("b.txt", 10))
+
+	handler, exists := mgr.endpoints["/checkpoints/foo"]
+	require.True(t, exists)
+
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodGet, "/checkpoints/foo", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `{"a.txt":5,"b.txt":10}`, res.Body.String())
+
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodDelete, "/checkpoints/foo?source=a.txt", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodGet, "/checkpoints/foo", nil))
+	assert.JSONEq(t, `{"b.txt":10}`, res.Body.String())
+
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodDelete, "/checkpoints/foo", nil))
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
@@ -0,0 +1,240 @@
+// Package cloudevents contains utilities for constructing, validating and
+// converting events following the CloudEvents v1.0 specification, where an
+// event is represented by its structured mode JSON form.
+package cloudevents
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SpecVersion is the version of the CloudEvents specification supported.
+const SpecVersion = "1.0"
+
+// Content types of events in structured and batched mode.
+const (
+	ContentTypeStructured = "application/cloudevents+json"
+	ContentTypeBatch      = "application/cloudevents-batch+json"
+)
+
+// RequiredAttributes are the context attributes that every event must have.
+var RequiredAttributes = []string{"id", "source", "specversion", "type"}
+
+// isDataKey returns whether a key of a structured event holds the event data
+// rather than a context attribute.
+func isDataKey(k string) bool {
+	return k == "data" || k == "data_base64"
+}
+
+// IsJSONContentType returns whether a datacontenttype indicates JSON data,
+// where an empty content type is assumed to be JSON.
+func IsJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// Validate checks that an event conforms to the CloudEvents v1.0
+// specification.
+func Validate(event map[string]interface{}) error {
+	for _, k := range RequiredAttributes {
+		v, exists := event[k]
+		if !exists {
+			return fmt.Errorf("missing required attribute: %v", k)
+		}
+		if s, _ := v.(string); s == "" {
+			return fmt.Errorf("attribute %v must be a non-empty string", k)
+		}
+	}
+	if v := event["specversion"]; v != SpecVersion {
+		return fmt.Errorf("unsupported specversion: %v", v)
+	}
+	if _, exists := event["data"]; exists {
+		if _, exists = event["data_base64"]; exists {
+			return errors.New("attributes data and data_base64 are mutually exclusive")
+		}
+	}
+
+	for k, v := range event {
+		if isDataKey(k) {
+			continue
+		}
+		if err := validateAttributeName(k); err != nil {
+			return err
+		}
+		switch t := v.(type) {
+		case string, bool, nil:
+		case json.Number:
+			if _, err := t.Int64(); err != nil {
+				return fmt.Errorf("attribute %v must be an integer: %v", k, t)
+			}
+		case float64:
+			if t != float64(int64(t)) {
+				return fmt.Errorf("attribute %v must be an integer: %v", k, t)
+			}
+		case int, int64, uint64:
+		default:
+			return fmt.Errorf("attribute %v has unsupported type: %T", k, v)
+		}
+	}
+
+	for _, k := range []string{"datacontenttype", "dataschema", "subject", "time"} {
+		v, exists := event[k]
+		if !exists {
+			continue
+		}
+		s, ok := v.(string)
+		if !ok || s == "" {
+			return fmt.Errorf("attribute %v must be a non-empty string", k)
+		}
+		var err error
+		switch k {
+		case "datacontenttype":
+			_, _, err = mime.ParseMediaType(s)
+		case "dataschema":
+			_, err = url.Parse(s)
+		case "time":
+			_, err = time.Parse(time.RFC3339Nano, s)
+		}
+		if err != nil {
+			return fmt.Errorf("attribute %v is invalid: %w", k, err)
+		}
+	}
+	if v, exists := event["data_base64"]; exists {
+		s, _ := v.(string)
+		if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+			return fmt.Errorf("attribute data_base64 is invalid: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateAttributeName(name string) error {
+	if name == "" {
+		return errors.New("attribute names must not be empty")
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return fmt.Errorf("attribute name %v must only contain lowercase letters and digits", name)
+		}
+	}
+	return nil
+}
+
+// New creates an event in structured form from a set of context attributes and
+// a payload. When the payload is JSON and the datacontenttype indicates JSON
+// data it is embedded as a JSON value, otherwise it is added as a string when
+// it is valid UTF-8 or base64 encoded when it is not. A datacontenttype is
+// added to match the payload when the attributes do not include one.
+func New(attributes map[string]interface{}, data []byte) map[string]interface{} {
+	event := make(map[string]interface{}, len(attributes)+2)
+	for k, v := range attributes {
+		event[k] = v
+	}
+	if _, exists := event["specversion"]; !exists {
+		event["specversion"] = SpecVersion
+	}
+	if data == nil {
+		return event
+	}
+
+	contentType, _ := event["datacontenttype"].(string)
+	if IsJSONContentType(contentType) {
+		var jv interface{}
+		if err := json.Unmarshal(data, &jv); err == nil {
+			if contentType == "" {
+				event["datacontenttype"] = "application/json"
+			}
+			event["data"] = jv
+			return event
+		}
+	}
+	if utf8.Valid(data) {
+		if contentType == "" {
+			event["datacontenttype"] = "text/plain"
+		}
+		event["data"] = string(data)
+	} else {
+		if contentType == "" {
+			event["datacontenttype"] = "application/octet-stream"
+		}
+		event["data_base64"] = base64.StdEncoding.EncodeToString(data)
+	}
+	return event
+}
+
+// Data returns the payload of an event as raw bytes.
+func Data(event map[string]interface{}) ([]byte, error) {
+	if v, exists := event["data_base64"]; exists {
+		s, _ := v.(string)
+		return base64.StdEncoding.DecodeString(s)
+	}
+	v, exists := event["data"]
+	if !exists {
+		return nil, nil
+	}
+	contentType, _ := event["datacontenttype"].(string)
+	if s, ok := v.(string); ok && !IsJSONContentType(contentType) {
+		return []byte(s), nil
+	}
+	return json.Marshal(v)
+}
+
+// AttributeString converts the value of a context attribute into its string
+// form, as used by binary mode bindings.
+func AttributeString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case bool:
+		return strconv.FormatBool(t)
+	case json.Number:
+		return t.String()
+	case float64:
+		return strconv.FormatInt(int64(t), 10)
+	case nil:
+		return ""
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// Attributes returns the names of the context attributes of an event in
+// lexicographical order.
+func Attributes(event map[string]interface{}) []string {
+	names := make([]string, 0, len(event))
+	for k := range event {
+		if !isDataKey(k) {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EncodeHeaderValue percent-encodes the characters of an attribute value that
+// must not appear verbatim within an HTTP header of a binary mode event.
+func EncodeHeaderValue(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if c < 0x20 || c > 0x7e || c == '"' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,170 @@
+package cloudevents
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	base := func() map[string]interface{} {
+		return map[string]interface{}{
+			"specversion": "1.0",
+			"id":          "foo",
+			"source":      "/bar",
+			"type":        "com.example.baz",
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(e map[string]interface{})
+		err    string
+	}{
+		{
+			name:   "valid",
+			modify: func(e map[string]interface{}) {},
+		},
+		{
+			name: "valid with optional attributes",
+			modify: func(e map[string]interface{}) {
+				e["time"] = "2021-01-01T00:00:00Z"
+				e["datacontenttype"] = "application/json"
+				e["data"] = map[string]interface{}{"a": "b"}
+				e["myext"] = true
+			},
+		},
+		{
+			name:   "missing id",
+			modify: func(e map[string]interface{}) { delete(e, "id") },
+			err:    "missing required attribute: id",
+		},
+		{
+			name:   "empty source",
+			modify: func(e map[string]interface{}) { e["source"] = "" },
+			err:    "attribute source must be a non-empty string",
+		},
+		{
+			name:   "wrong specversion",
+			modify: func(e map[string]interface{}) { e["specversion"] = "0.3" },
+			err:    "unsupported specversion: 0.3",
+		},
+		{
+			name: "both data fields",
+			modify: func(e map[string]interface{}) {
+				e["data"] = "a"
+				e["data_base64"] = "Yg=="
+			},
+			err: "attributes data and data_base64 are mutually exclusive",
+		},
+		{
+			name:   "bad attribute name",
+			modify: func(e map[string]interface{}) { e["my_ext"] = "a" },
+			err:    "attribute name my_ext must only contain lowercase letters and digits",
+		},
+		{
+			name:   "bad time",
+			modify: func(e map[string]interface{}) { e["time"] = "yesterday" },
+			err:    `attribute time is invalid: parsing time "yesterday" as "2006-01-02T15:04:05.999999999Z07:00": cannot parse "yesterday" as "2006"`,
+		},
+		{
+			name:   "object attribute",
+			modify: func(e map[string]interface{}) { e["myext"] = map[string]interface{}{} },
+			err:    "attribute myext has unsupported type: map[string]interface {}",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			e := base()
+			test.modify(e)
+			if test.err == "" {
+				assert.NoError(t, Validate(e))
+			} else {
+				assert.EqualError(t, Validate(e), test.err)
+			}
+		})
+	}
+}
+
+func TestNewAndData(t *testing.T) {
+	attrs := map[string]interface{}{"id": "foo", "source": "bar", "type": "baz"}
+
+	tests := []struct {
+		name     string
+		attrs    map[string]interface{}
+		data     []byte
+		expected map[string]interface{}
+	}{
+		{
+			name: "json",
+			data: []byte(`{"a":"b"}`),
+			expected: map[string]interface{}{
+				"datacontenttype": "application/json",
+				"data":            map[string]interface{}{"a": "b"},
+			},
+		},
+		{
+			name: "text",
+			data: []byte(`hello world`),
+			expected: map[string]interface{}{
+				"datacontenttype": "text/plain",
+				"data":            "hello world",
+			},
+		},
+		{
+			name:  "json as text",
+			attrs: map[string]interface{}{"datacontenttype": "text/csv"},
+			data:  []byte(`"a","b"`),
+			expected: map[string]interface{}{
+				"datacontenttype": "text/csv",
+				"data":            `"a","b"`,
+			},
+		},
+		{
+			name: "binary",
+			data: []byte{0xff, 0x00},
+			expected: map[string]interface{}{
+				"datacontenttype": "application/octet-stream",
+				"data_base64":     "/wA=",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			in := map[string]interface{}{}
+			for k, v := range attrs {
+				in[k] = v
+			}
+			for k, v := range test.attrs {
+				in[k] = v
+			}
+			e := New(in, test.data)
+
+			exp := map[string]interface{}{
+				"specversion": "1.0",
+				"id":          "foo",
+				"source":      "bar",
+				"type":        "baz",
+			}
+			for k, v := range test.expected {
+				exp[k] = v
+			}
+			assert.Equal(t, exp, e)
+			require.NoError(t, Validate(e))
+
+			data, err := Data(e)
+			require.NoError(t, err)
+			assert.Equal(t, test.data, data)
+		})
+	}
+}
+
+func TestEncodeHeaderValue(t *testing.T) {
+	assert.Equal(t, "foo bar", EncodeHeaderValue("foo bar"))
+	assert.Equal(t, "%22100%25%22 %E2%82%AC", EncodeHeaderValue(`"100%" €`))
+}
@@ -6,12 +6,16 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/binary"
 	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -19,6 +23,12 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/klauspost/compress/zstd"
+	"github.com/linkedin/goavro/v2"
+	pbuffer "github.com/xitongsys/parquet-go-source/buffer"
+	pcommon "github.com/xitongsys/parquet-go/common"
+	preader "github.com/xitongsys/parquet-go/reader"
+	pschema "github.com/xitongsys/parquet-go/schema"
 )
 
 // ReaderDocs is a static field documentation for input codecs.
@@ -27,13 +37,18 @@ var ReaderDocs = docs.FieldCommon(
 ).HasAnnotatedOptions(
 	"auto", "EXPERIMENTAL: Attempts to derive a codec for each file based on information such as the extension. For example, a .tar.gz file would be consumed with the `gzip/tar` codec. Defaults to all-bytes.",
 	"all-bytes", "Consume the entire file as a single binary message.",
+	"avro-ocf", "Parse the file as an Avro Object Container File, and consume each record as a JSON message.",
 	"chunker:x", "Consume the file in chunks of a given number of bytes.",
 	"csv", "Consume structured rows as comma separated values, the first row must be a header row.",
 	"delim:x", "Consume the file in segments divided by a custom delimiter.",
 	"gzip", "Decompress a gzip file, this codec should precede another codec, e.g. `gzip/all-bytes`, `gzip/tar`, `gzip/csv`, etc.",
+	"length-prefixed", "Consume the file in segments where each segment is preceded by its length as a 4 byte big endian unsigned integer.",
 	"lines", "Consume the file in segments divided by linebreaks.",
+	"mime-multipart", "Parse the file as a MIME multipart body, where the first line must be a boundary delimiter, and consume each part as a message with its headers added as metadata.",
 	"multipart", "Consumes the output of another codec and batches messages together. A batch ends when an empty message is consumed. For example, the codec `lines/multipart` could be used to consume multipart messages where an empty line indicates the end of each batch.",
+	"parquet", "Parse the file as an Apache Parquet file, and consume each row as a JSON message. The file is read into memory in its entirety, and logical types such as dates and decimals are emitted in their physical representation.",
 	"tar", "Parse the file as a tar archive, and consume each file of the archive as a message.",
+	"zstd", "Decompress a zstd file, this codec should precede another codec, e.g. `zstd/all-bytes`, `zstd/lines`, etc.",
 )
 
 //------------------------------------------------------------------------------
@@ -41,13 +56,59 @@ var ReaderDocs = docs.FieldCommon(
 // ReaderConfig is a general configuration struct that covers all reader codecs.
 type ReaderConfig struct {
 	MaxScanTokenSize int
+
+	// MaxMessageSize is the largest message in bytes that a reader will
+	// consume, which prevents a single pathological record from being loaded
+	// into memory. Records that exceed it are rejected with a
+	// message.ErrMessageTooLarge error. Zero means no limit.
+	MaxMessageSize int
 }
 
 // NewReaderConfig creates a reader configuration with default values.
 func NewReaderConfig() ReaderConfig {
 	return ReaderConfig{
 		MaxScanTokenSize: bufio.MaxScanTokenSize,
+		MaxMessageSize:   0,
+	}
+}
+
+// readAllLimited reads the entirety of a reader, returning an error without
+// buffering further once the size exceeds a limit.
+func readAllLimited(r io.Reader, limit int) ([]byte, error) {
+	if limit <= 0 {
+		return ioutil.ReadAll(r)
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > limit {
+		return nil, message.ErrMessageTooLarge{Limit: limit}
 	}
+	return b, nil
+}
+
+// newLimitedScanner creates a scanner where tokens are limited by both the max
+// scan token size and the max message size.
+func newLimitedScanner(conf ReaderConfig, r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	maxTokenSize := conf.MaxScanTokenSize
+	if conf.MaxMessageSize > 0 && conf.MaxMessageSize < maxTokenSize {
+		maxTokenSize = conf.MaxMessageSize
+	}
+	if maxTokenSize != bufio.MaxScanTokenSize {
+		scanner.Buffer([]byte{}, maxTokenSize)
+	}
+	return scanner
+}
+
+// scanErr converts scanner errors caused by tokens exceeding the max message
+// size into a message.ErrMessageTooLarge error.
+func scanErr(conf ReaderConfig, err error) error {
+	if err == bufio.ErrTooLong && conf.MaxMessageSize > 0 && conf.MaxMessageSize < conf.MaxScanTokenSize {
+		return message.ErrMessageTooLarge{Limit: conf.MaxMessageSize}
+	}
+	return err
 }
 
 //------------------------------------------------------------------------------
@@ -73,6 +134,35 @@ type Reader interface {
 	Close(context.Context) error
 }
 
+// OffsetReader is implemented by readers that are able to report the byte
+// offset of their source immediately following the last message returned by
+// Next. A source can therefore be resumed from an acknowledged message by
+// seeking to its offset before providing it to a new reader of the same codec.
+type OffsetReader interface {
+	Reader
+	Offset() int64
+}
+
+// ReaderSupportsOffsets returns whether the readers of a codec implement
+// OffsetReader.
+func ReaderSupportsOffsets(codec string) bool {
+	switch codec {
+	case "lines", "length-prefixed":
+		return true
+	}
+	return strings.HasPrefix(codec, "delim:") || strings.HasPrefix(codec, "chunker:")
+}
+
+// countAdvance wraps a split function in order to add the number of bytes
+// advanced by each call to an offset.
+func countAdvance(split bufio.SplitFunc, offset *int64) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := split(data, atEOF)
+		*offset += int64(advance)
+		return advance, token, err
+	}
+}
+
 type ioReaderConstructor func(string, io.ReadCloser) (io.ReadCloser, error)
 
 // ReaderConstructor creates a reader from a filename, an io.ReadCloser and an
@@ -111,7 +201,9 @@ func chainIOIntoPartCtor(first ioReaderConstructor, second ReaderConstructor) Re
 			r1.Close()
 			return nil, err
 		}
-		return r2, nil
+		// Offsets within a decompressed stream do not correspond to the
+		// source, and are therefore hidden.
+		return struct{ Reader }{r2}, nil
 	}
 }
 
@@ -190,6 +282,8 @@ func ioReader(codec string, conf ReaderConfig) (ioReaderConstructor, bool) {
 			}
 			return g, nil
 		}, true
+	case "zstd":
+		return newZstdReader, true
 	}
 	return nil, false
 }
@@ -208,7 +302,7 @@ func partReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error
 	switch codec {
 	case "all-bytes":
 		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
-			return &allBytesReader{r, fn, false}, nil
+			return &allBytesReader{r, fn, conf.MaxMessageSize, false}, nil
 		}, true, nil
 	case "lines":
 		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
@@ -219,7 +313,25 @@ func partReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error
 			return newCSVReader(r, fn)
 		}, true, nil
 	case "tar":
-		return newTarReader, true, nil
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newTarReader(conf, r, fn)
+		}, true, nil
+	case "length-prefixed":
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newLengthPrefixedReader(conf, r, fn)
+		}, true, nil
+	case "mime-multipart":
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newMIMEMultipartReader(conf, r, fn)
+		}, true, nil
+	case "avro-ocf":
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newAvroOCFReader(r, fn)
+		}, true, nil
+	case "parquet":
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newParquetReader(r, fn)
+		}, true, nil
 	}
 	if strings.HasPrefix(codec, "delim:") {
 		by := strings.TrimPrefix(codec, "delim:")
@@ -235,6 +347,9 @@ func partReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error
 		if err != nil {
 			return nil, false, fmt.Errorf("invalid chunk size for chunker codec: %w", err)
 		}
+		if conf.MaxMessageSize > 0 && chunkSize > uint64(conf.MaxMessageSize) {
+			return nil, false, fmt.Errorf("chunk size %v exceeds the max message size of %v bytes", chunkSize, conf.MaxMessageSize)
+		}
 		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
 			return newChunkerReader(conf, r, chunkSize, fn)
 		}, true, nil
@@ -273,11 +388,21 @@ func autoCodec(conf ReaderConfig) ReaderConstructor {
 			codec = "tar"
 		case ".tgz":
 			codec = "gzip/tar"
+		case ".avro":
+			codec = "avro-ocf"
+		case ".parquet":
+			codec = "parquet"
+		case ".zst":
+			codec = "zstd/all-bytes"
 		}
 		if strings.HasSuffix(path, ".tar.gzip") {
 			codec = "gzip/tar"
 		} else if strings.HasSuffix(path, ".tar.gz") {
 			codec = "gzip/tar"
+		} else if strings.HasSuffix(path, ".tar.zst") {
+			codec = "zstd/tar"
+		} else if strings.HasSuffix(path, ".csv.zst") {
+			codec = "zstd/csv"
 		}
 
 		ctor, err := GetReader(codec, conf)
@@ -293,6 +418,7 @@ func autoCodec(conf ReaderConfig) ReaderConstructor {
 type allBytesReader struct {
 	i        io.ReadCloser
 	ack      ReaderAckFn
+	limit    int
 	consumed bool
 }
 
@@ -301,7 +427,7 @@ func (a *allBytesReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, e
 		return nil, nil, io.EOF
 	}
 	a.consumed = true
-	b, err := ioutil.ReadAll(a.i)
+	b, err := readAllLimited(a.i, a.limit)
 	if err != nil {
 		a.ack(ctx, err)
 		return nil, nil, err
@@ -320,9 +446,11 @@ func (a *allBytesReader) Close(ctx context.Context) error {
 //------------------------------------------------------------------------------
 
 type linesReader struct {
+	conf      ReaderConfig
 	buf       *bufio.Scanner
 	r         io.ReadCloser
 	sourceAck ReaderAckFn
+	offset    int64
 
 	mut      sync.Mutex
 	finished bool
@@ -330,15 +458,18 @@ type linesReader struct {
 }
 
 func newLinesReader(conf ReaderConfig, r io.ReadCloser, ackFn ReaderAckFn) (Reader, error) {
-	scanner := bufio.NewScanner(r)
-	if conf.MaxScanTokenSize != bufio.MaxScanTokenSize {
-		scanner.Buffer([]byte{}, conf.MaxScanTokenSize)
-	}
-	return &linesReader{
-		buf:       scanner,
+	a := &linesReader{
+		conf:      conf,
+		buf:       newLimitedScanner(conf, r),
 		r:         r,
 		sourceAck: ackOnce(ackFn),
-	}, nil
+	}
+	a.buf.Split(countAdvance(bufio.ScanLines, &a.offset))
+	return a, nil
+}
+
+func (a *linesReader) Offset() int64 {
+	return a.offset
 }
 
 func (a *linesReader) ack(ctx context.Context, err error) error {
@@ -368,7 +499,7 @@ func (a *linesReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, erro
 		return []types.Part{message.NewPart(bytesCopy)}, a.ack, nil
 	}
 
-	err := a.buf.Err()
+	err := scanErr(a.conf, a.buf.Err())
 	if err == nil {
 		err = io.EOF
 		a.finished = true
@@ -484,9 +615,11 @@ func (a *csvReader) Close(ctx context.Context) error {
 //------------------------------------------------------------------------------
 
 type customDelimReader struct {
+	conf      ReaderConfig
 	buf       *bufio.Scanner
 	r         io.ReadCloser
 	sourceAck ReaderAckFn
+	offset    int64
 
 	mut      sync.Mutex
 	finished bool
@@ -494,14 +627,18 @@ type customDelimReader struct {
 }
 
 func newCustomDelimReader(conf ReaderConfig, r io.ReadCloser, delim string, ackFn ReaderAckFn) (Reader, error) {
-	scanner := bufio.NewScanner(r)
-	if conf.MaxScanTokenSize != bufio.MaxScanTokenSize {
-		scanner.Buffer([]byte{}, conf.MaxScanTokenSize)
-	}
+	scanner := newLimitedScanner(conf, r)
 
 	delimBytes := []byte(delim)
 
-	scanner.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	a := &customDelimReader{
+		conf:      conf,
+		buf:       scanner,
+		r:         r,
+		sourceAck: ackOnce(ackFn),
+	}
+
+	scanner.Split(countAdvance(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
 		if atEOF && len(data) == 0 {
 			return 0, nil, nil
 		}
@@ -518,13 +655,13 @@ func newCustomDelimReader(conf ReaderConfig, r io.ReadCloser, delim string, ackF
 
 		// Request more data.
 		return 0, nil, nil
-	})
+	}, &a.offset))
 
-	return &customDelimReader{
-		buf:       scanner,
-		r:         r,
-		sourceAck: ackOnce(ackFn),
-	}, nil
+	return a, nil
+}
+
+func (a *customDelimReader) Offset() int64 {
+	return a.offset
 }
 
 func (a *customDelimReader) ack(ctx context.Context, err error) error {
@@ -555,7 +692,7 @@ func (a *customDelimReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn
 		copy(bytesCopy, a.buf.Bytes())
 		return []types.Part{message.NewPart(bytesCopy)}, a.ack, nil
 	}
-	err := a.buf.Err()
+	err := scanErr(a.conf, a.buf.Err())
 	if err == nil {
 		err = io.EOF
 		a.finished = true
@@ -585,6 +722,7 @@ type chunkerReader struct {
 	buf       []byte
 	r         io.ReadCloser
 	sourceAck ReaderAckFn
+	offset    int64
 
 	mut      sync.Mutex
 	finished bool
@@ -636,6 +774,7 @@ func (a *chunkerReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, er
 
 	if n > 0 {
 		a.pending++
+		a.offset += int64(n)
 
 		bytesCopy := make([]byte, n)
 		copy(bytesCopy, a.buf)
@@ -645,6 +784,12 @@ func (a *chunkerReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, er
 	return nil, nil, err
 }
 
+func (a *chunkerReader) Offset() int64 {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	return a.offset
+}
+
 func (a *chunkerReader) Close(ctx context.Context) error {
 	a.mut.Lock()
 	defer a.mut.Unlock()
@@ -661,6 +806,7 @@ func (a *chunkerReader) Close(ctx context.Context) error {
 //------------------------------------------------------------------------------
 
 type tarReader struct {
+	limit     int
 	buf       *tar.Reader
 	r         io.ReadCloser
 	sourceAck ReaderAckFn
@@ -670,8 +816,9 @@ type tarReader struct {
 	pending  int32
 }
 
-func newTarReader(path string, r io.ReadCloser, ackFn ReaderAckFn) (Reader, error) {
+func newTarReader(conf ReaderConfig, r io.ReadCloser, ackFn ReaderAckFn) (Reader, error) {
 	return &tarReader{
+		limit:     conf.MaxMessageSize,
 		buf:       tar.NewReader(r),
 		r:         r,
 		sourceAck: ackOnce(ackFn),
@@ -694,11 +841,14 @@ func (a *tarReader) ack(ctx context.Context, err error) error {
 }
 
 func (a *tarReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, error) {
-	_, err := a.buf.Next()
+	hdr, err := a.buf.Next()
 
 	a.mut.Lock()
 	defer a.mut.Unlock()
 
+	if err == nil && a.limit > 0 && hdr.Size > int64(a.limit) {
+		err = message.ErrMessageTooLarge{Size: int(hdr.Size), Limit: a.limit}
+	}
 	if err == nil {
 		fileBuf := bytes.Buffer{}
 		if _, err = fileBuf.ReadFrom(a.buf); err != nil {
@@ -787,3 +937,497 @@ func (m *multipartReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn,
 func (m *multipartReader) Close(ctx context.Context) error {
 	return m.child.Close(ctx)
 }
+
+//------------------------------------------------------------------------------
+
+type closeBoth struct {
+	io.Reader
+	closeFns []func() error
+}
+
+func (c *closeBoth) Close() error {
+	var err error
+	for _, fn := range c.closeFns {
+		if cErr := fn(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}
+	return err
+}
+
+func newZstdReader(_ string, r io.ReadCloser) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	return &closeBoth{
+		Reader: d,
+		closeFns: []func() error{
+			func() error {
+				d.Close()
+				return nil
+			},
+			r.Close,
+		},
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+type lengthPrefixedReader struct {
+	limit     int
+	r         io.ReadCloser
+	sourceAck ReaderAckFn
+	lenBuf    [4]byte
+	offset    int64
+
+	mut      sync.Mutex
+	finished bool
+	pending  int32
+}
+
+func newLengthPrefixedReader(conf ReaderConfig, r io.ReadCloser, ackFn ReaderAckFn) (Reader, error) {
+	return &lengthPrefixedReader{
+		limit:     conf.MaxMessageSize,
+		r:         r,
+		sourceAck: ackOnce(ackFn),
+	}, nil
+}
+
+func (a *lengthPrefixedReader) ack(ctx context.Context, err error) error {
+	a.mut.Lock()
+	a.pending--
+	doAck := a.pending == 0 && a.finished
+	a.mut.Unlock()
+
+	if err != nil {
+		return a.sourceAck(ctx, err)
+	}
+	if doAck {
+		return a.sourceAck(ctx, nil)
+	}
+	return nil
+}
+
+func (a *lengthPrefixedReader) readNext() ([]byte, error) {
+	if _, err := io.ReadFull(a.r, a.lenBuf[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(a.lenBuf[:])
+	if a.limit > 0 && uint64(size) > uint64(a.limit) {
+		return nil, message.ErrMessageTooLarge{Size: int(size), Limit: a.limit}
+	}
+	b := make([]byte, size)
+	if _, err := io.ReadFull(a.r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+func (a *lengthPrefixedReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, error) {
+	b, err := a.readNext()
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if err == nil {
+		a.pending++
+		a.offset += int64(len(a.lenBuf) + len(b))
+		return []types.Part{message.NewPart(b)}, a.ack, nil
+	}
+
+	if err == io.EOF {
+		a.finished = true
+	} else {
+		a.sourceAck(ctx, err)
+	}
+	return nil, nil, err
+}
+
+func (a *lengthPrefixedReader) Offset() int64 {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	return a.offset
+}
+
+func (a *lengthPrefixedReader) Close(ctx context.Context) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if !a.finished {
+		a.sourceAck(ctx, errors.New("service shutting down"))
+	}
+	if a.pending == 0 {
+		a.sourceAck(ctx, nil)
+	}
+	return a.r.Close()
+}
+
+//------------------------------------------------------------------------------
+
+type mimeMultipartReader struct {
+	limit     int
+	buf       *multipart.Reader
+	r         io.ReadCloser
+	sourceAck ReaderAckFn
+
+	mut      sync.Mutex
+	finished bool
+	pending  int32
+}
+
+// newMIMEMultipartReader consumes a MIME multipart body where the boundary is
+// derived from the first line of the source, which must be a boundary
+// delimiter.
+func newMIMEMultipartReader(conf ReaderConfig, r io.ReadCloser, ackFn ReaderAckFn) (Reader, error) {
+	br := bufio.NewReader(r)
+	firstLine, err := br.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	boundary := strings.TrimSpace(firstLine)
+	if !strings.HasPrefix(boundary, "--") || len(boundary) == 2 {
+		return nil, errors.New("expected multipart body to begin with a boundary delimiter")
+	}
+	boundary = strings.TrimPrefix(boundary, "--")
+
+	return &mimeMultipartReader{
+		limit:     conf.MaxMessageSize,
+		buf:       multipart.NewReader(io.MultiReader(strings.NewReader(firstLine), br), boundary),
+		r:         r,
+		sourceAck: ackOnce(ackFn),
+	}, nil
+}
+
+func (a *mimeMultipartReader) ack(ctx context.Context, err error) error {
+	a.mut.Lock()
+	a.pending--
+	doAck := a.pending == 0 && a.finished
+	a.mut.Unlock()
+
+	if err != nil {
+		return a.sourceAck(ctx, err)
+	}
+	if doAck {
+		return a.sourceAck(ctx, nil)
+	}
+	return nil
+}
+
+func (a *mimeMultipartReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, error) {
+	mPart, err := a.buf.NextPart()
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if err == nil {
+		partBytes, err := readAllLimited(mPart, a.limit)
+		if err != nil {
+			a.sourceAck(ctx, err)
+			return nil, nil, err
+		}
+		part := message.NewPart(partBytes)
+		for k, v := range mPart.Header {
+			if len(v) > 0 {
+				part.Metadata().Set(k, v[0])
+			}
+		}
+		a.pending++
+		return []types.Part{part}, a.ack, nil
+	}
+
+	if err == io.EOF {
+		a.finished = true
+	} else {
+		a.sourceAck(ctx, err)
+	}
+	return nil, nil, err
+}
+
+func (a *mimeMultipartReader) Close(ctx context.Context) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if !a.finished {
+		a.sourceAck(ctx, errors.New("service shutting down"))
+	}
+	if a.pending == 0 {
+		a.sourceAck(ctx, nil)
+	}
+	return a.r.Close()
+}
+
+//------------------------------------------------------------------------------
+
+type avroOCFReader struct {
+	ocf       *goavro.OCFReader
+	r         io.ReadCloser
+	sourceAck ReaderAckFn
+
+	mut      sync.Mutex
+	finished bool
+	pending  int32
+}
+
+func newAvroOCFReader(r io.ReadCloser, ackFn ReaderAckFn) (Reader, error) {
+	ocf, err := goavro.NewOCFReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &avroOCFReader{
+		ocf:       ocf,
+		r:         r,
+		sourceAck: ackOnce(ackFn),
+	}, nil
+}
+
+func (a *avroOCFReader) ack(ctx context.Context, err error) error {
+	a.mut.Lock()
+	a.pending--
+	doAck := a.pending == 0 && a.finished
+	a.mut.Unlock()
+
+	if err != nil {
+		return a.sourceAck(ctx, err)
+	}
+	if doAck {
+		return a.sourceAck(ctx, nil)
+	}
+	return nil
+}
+
+func (a *avroOCFReader) readNext() (interface{}, error) {
+	if !a.ocf.Scan() {
+		if err := a.ocf.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	datum, err := a.ocf.Read()
+	if err != nil {
+		return nil, err
+	}
+	textual, err := a.ocf.Codec().TextualFromNative(nil, datum)
+	if err != nil {
+		return nil, err
+	}
+	var obj interface{}
+	if err = json.Unmarshal(textual, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (a *avroOCFReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, error) {
+	obj, err := a.readNext()
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if err == nil {
+		a.pending++
+		part := message.NewPart(nil)
+		part.SetJSON(obj)
+		return []types.Part{part}, a.ack, nil
+	}
+
+	if err == io.EOF {
+		a.finished = true
+	} else {
+		a.sourceAck(ctx, err)
+	}
+	return nil, nil, err
+}
+
+func (a *avroOCFReader) Close(ctx context.Context) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if !a.finished {
+		a.sourceAck(ctx, errors.New("service shutting down"))
+	}
+	if a.pending == 0 {
+		a.sourceAck(ctx, nil)
+	}
+	return a.r.Close()
+}
+
+//------------------------------------------------------------------------------
+
+// parquetReadBatchSize is the number of rows read from a Parquet file at a time.
+const parquetReadBatchSize = 100
+
+type parquetReader struct {
+	pr        *preader.ParquetReader
+	r         io.ReadCloser
+	sourceAck ReaderAckFn
+
+	remaining int64
+	rows      []interface{}
+
+	mut      sync.Mutex
+	finished bool
+	pending  int32
+}
+
+func newParquetReader(r io.ReadCloser, ackFn ReaderAckFn) (Reader, error) {
+	// The footer of a Parquet file, which describes where its rows can be
+	// found, is at the end of the file and therefore the file is read into
+	// memory in its entirety.
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	pf, err := pbuffer.NewBufferFile(b)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := preader.NewParquetReader(pf, nil, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read parquet file: %w", err)
+	}
+	return &parquetReader{
+		pr:        pr,
+		r:         r,
+		sourceAck: ackOnce(ackFn),
+		remaining: pr.GetNumRows(),
+	}, nil
+}
+
+func (p *parquetReader) ack(ctx context.Context, err error) error {
+	p.mut.Lock()
+	p.pending--
+	doAck := p.pending == 0 && p.finished
+	p.mut.Unlock()
+
+	if err != nil {
+		return p.sourceAck(ctx, err)
+	}
+	if doAck {
+		return p.sourceAck(ctx, nil)
+	}
+	return nil
+}
+
+func (p *parquetReader) readNext() (interface{}, error) {
+	if len(p.rows) == 0 {
+		if p.remaining <= 0 {
+			return nil, io.EOF
+		}
+		n := int64(parquetReadBatchSize)
+		if n > p.remaining {
+			n = p.remaining
+		}
+		rows, err := p.pr.ReadByNumber(int(n))
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			return nil, io.EOF
+		}
+		p.remaining -= int64(len(rows))
+		p.rows = rows
+	}
+	row := p.rows[0]
+	p.rows = p.rows[1:]
+	sh := p.pr.SchemaHandler
+	return parquetValueToJSON(sh, sh.GetRootInName(), reflect.ValueOf(row)), nil
+}
+
+// parquetValueToJSON converts a row read from a Parquet file into a generic
+// JSON structure, where the fields of objects take their names from the
+// schema of the file rather than the exported names of the row struct.
+func parquetValueToJSON(sh *pschema.SchemaHandler, inPath string, v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return parquetValueToJSON(sh, inPath, v.Elem())
+	case reflect.Struct:
+		obj := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			name := v.Type().Field(i).Name
+			fieldPath := pcommon.PathToStr([]string{inPath, name})
+			if exPath, exists := sh.InPathToExPath[fieldPath]; exists {
+				exNames := pcommon.StrToPath(exPath)
+				name = exNames[len(exNames)-1]
+			}
+			obj[name] = parquetValueToJSON(sh, fieldPath, v.Field(i))
+		}
+		return obj
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		elemPath := inPath
+		if listPath := pcommon.PathToStr([]string{inPath, "List", "Element"}); hasParquetPath(sh, listPath) {
+			elemPath = listPath
+		}
+		arr := make([]interface{}, v.Len())
+		for i := range arr {
+			arr[i] = parquetValueToJSON(sh, elemPath, v.Index(i))
+		}
+		return arr
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		valuePath := pcommon.PathToStr([]string{inPath, "Key_value", "Value"})
+		obj := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			obj[fmt.Sprintf("%v", iter.Key().Interface())] = parquetValueToJSON(sh, valuePath, iter.Value())
+		}
+		return obj
+	}
+	return v.Interface()
+}
+
+func hasParquetPath(sh *pschema.SchemaHandler, inPath string) bool {
+	_, exists := sh.MapIndex[inPath]
+	return exists
+}
+
+func (p *parquetReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, error) {
+	obj, err := p.readNext()
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	if err == nil {
+		p.pending++
+		part := message.NewPart(nil)
+		if err = part.SetJSON(obj); err != nil {
+			p.pending--
+			p.sourceAck(ctx, err)
+			return nil, nil, err
+		}
+		return []types.Part{part}, p.ack, nil
+	}
+
+	if err == io.EOF {
+		p.finished = true
+	} else {
+		p.sourceAck(ctx, err)
+	}
+	return nil, nil, err
+}
+
+func (p *parquetReader) Close(ctx context.Context) error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	p.pr.ReadStop()
+	if !p.finished {
+		p.sourceAck(ctx, errors.New("service shutting down"))
+	}
+	if p.pending == 0 {
+		p.sourceAck(ctx, nil)
+	}
+	return p.r.Close()
+}
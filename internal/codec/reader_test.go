@@ -12,8 +12,12 @@ import (
 	"testing"
 
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/klauspost/compress/zstd"
+	"github.com/linkedin/goavro/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/writerfile"
+	"github.com/xitongsys/parquet-go/writer"
 )
 
 type noopCloser struct {
@@ -658,3 +662,273 @@ func TestMultipartLinesReader(t *testing.T) {
 	data = []byte("")
 	testReaderSuite(t, "lines/multipart", "", data)
 }
+
+func TestZstdLinesReader(t *testing.T) {
+	var zstdBuf bytes.Buffer
+	zw, err := zstd.NewWriter(&zstdBuf)
+	require.NoError(t, err)
+	zw.Write([]byte("foo\nbar\nbaz"))
+	zw.Close()
+
+	testReaderSuite(t, "zstd/lines", "", zstdBuf.Bytes(), "foo", "bar", "baz")
+}
+
+func TestLengthPrefixedReader(t *testing.T) {
+	data := []byte{0, 0, 0, 3, 'f', 'o', 'o', 0, 0, 0, 0, 0, 0, 0, 3, 'b', 'a', 'z'}
+	testReaderSuite(t, "length-prefixed", "", data, "foo", "", "baz")
+
+	testReaderSuite(t, "length-prefixed", "", []byte{})
+}
+
+func TestReaderOffsets(t *testing.T) {
+	tests := []struct {
+		codec   string
+		data    []byte
+		parts   []string
+		offsets []int64
+	}{
+		{
+			codec:   "lines",
+			data:    []byte("foo\nbar\r\n\nbaz"),
+			parts:   []string{"foo", "bar", "", "baz"},
+			offsets: []int64{4, 9, 10, 13},
+		},
+		{
+			codec:   "delim:XY",
+			data:    []byte("fooXYbarXYbaz"),
+			parts:   []string{"foo", "bar", "baz"},
+			offsets: []int64{5, 10, 13},
+		},
+		{
+			codec:   "chunker:3",
+			data:    []byte("foobarba"),
+			parts:   []string{"foo", "bar", "ba"},
+			offsets: []int64{3, 6, 8},
+		},
+		{
+			codec:   "length-prefixed",
+			data:    []byte{0, 0, 0, 3, 'f', 'o', 'o', 0, 0, 0, 0, 0, 0, 0, 3, 'b', 'a', 'z'},
+			parts:   []string{"foo", "", "baz"},
+			offsets: []int64{7, 11, 18},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.codec, func(t *testing.T) {
+			assert.True(t, ReaderSupportsOffsets(test.codec))
+
+			ctor, err := GetReader(test.codec, NewReaderConfig())
+			require.NoError(t, err)
+
+			for start := range test.parts {
+				var resumeAt int64
+				if start > 0 {
+					resumeAt = test.offsets[start-1]
+				}
+
+				buf := noopCloser{bytes.NewReader(test.data[resumeAt:]), false}
+				r, err := ctor("", &buf, func(ctx context.Context, err error) error {
+					return nil
+				})
+				require.NoError(t, err)
+
+				oR, ok := r.(OffsetReader)
+				require.True(t, ok)
+
+				for i := start; i < len(test.parts); i++ {
+					p, ackFn, err := r.Next(context.Background())
+					require.NoError(t, err)
+					require.Len(t, p, 1)
+					assert.Equal(t, test.parts[i], string(p[0].Get()))
+					assert.Equal(t, test.offsets[i], resumeAt+oR.Offset())
+					require.NoError(t, ackFn(context.Background(), nil))
+				}
+				require.NoError(t, r.Close(context.Background()))
+			}
+		})
+	}
+
+	for _, codec := range []string{"gzip/lines", "lines/multipart", "csv", "all-bytes"} {
+		assert.False(t, ReaderSupportsOffsets(codec), codec)
+	}
+
+	var gzipBuf bytes.Buffer
+	zw := gzip.NewWriter(&gzipBuf)
+	zw.Write([]byte("foo\nbar"))
+	zw.Close()
+
+	ctor, err := GetReader("gzip/lines", NewReaderConfig())
+	require.NoError(t, err)
+
+	r, err := ctor("", noopCloser{bytes.NewReader(gzipBuf.Bytes()), false}, func(ctx context.Context, err error) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, ok := r.(OffsetReader)
+	assert.False(t, ok, "offsets of decompressed streams must not be exposed")
+	require.NoError(t, r.Close(context.Background()))
+}
+
+func TestMIMEMultipartReader(t *testing.T) {
+	data := []byte("--foo\r\nContent-Type: text/plain\r\n\r\nfirst\r\n--foo\r\nContent-Type: application/json\r\n\r\n{\"second\":true}\r\n--foo--\r\n")
+	testReaderSuite(t, "mime-multipart", "", data, "first", `{"second":true}`)
+
+	ctor, err := GetReader("mime-multipart", NewReaderConfig())
+	require.NoError(t, err)
+
+	r, err := ctor("", noopCloser{bytes.NewReader(data), false}, func(ctx context.Context, err error) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	p, _, err := r.Next(context.Background())
+	require.NoError(t, err)
+	require.Len(t, p, 1)
+	assert.Equal(t, "text/plain", p[0].Metadata().Get("Content-Type"))
+	require.NoError(t, r.Close(context.Background()))
+
+	_, err = ctor("", noopCloser{bytes.NewReader([]byte("not multipart")), false}, func(ctx context.Context, err error) error {
+		return nil
+	})
+	require.Error(t, err)
+}
+
+func TestReaderMaxMessageSize(t *testing.T) {
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	for _, content := range []string{"foo", "this is too large"} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: "file.txt",
+			Mode: 0600,
+			Size: int64(len(content)),
+		}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	tests := map[string]struct {
+		codec string
+		data  []byte
+		err   string
+	}{
+		"lines": {
+			codec: "lines",
+			data:  []byte("foo\nthis is too large\n"),
+			err:   "message size exceeds the limit of 10 bytes",
+		},
+		"custom delimiter": {
+			codec: "delim:X",
+			data:  []byte("fooXthis is too largeX"),
+			err:   "message size exceeds the limit of 10 bytes",
+		},
+		"all bytes": {
+			codec: "all-bytes",
+			data:  []byte("this is too large"),
+			err:   "message size exceeds the limit of 10 bytes",
+		},
+		"length prefixed": {
+			codec: "length-prefixed",
+			data:  []byte{0, 0, 0, 3, 'f', 'o', 'o', 0xff, 0xff, 0xff, 0xff},
+			err:   "message size of 4294967295 bytes exceeds the limit of 10 bytes",
+		},
+		"tar": {
+			codec: "tar",
+			data:  tarBuf.Bytes(),
+			err:   "message size of 17 bytes exceeds the limit of 10 bytes",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewReaderConfig()
+			conf.MaxMessageSize = 10
+
+			ctor, err := GetReader(test.codec, conf)
+			require.NoError(t, err)
+
+			var ackErr error
+			r, err := ctor("", noopCloser{bytes.NewReader(test.data), false}, func(ctx context.Context, err error) error {
+				ackErr = err
+				return nil
+			})
+			require.NoError(t, err)
+
+			for {
+				p, ackFn, err := r.Next(context.Background())
+				if err != nil {
+					assert.EqualError(t, err, test.err)
+					break
+				}
+				require.Len(t, p, 1)
+				assert.Equal(t, "foo", string(p[0].Get()))
+				require.NoError(t, ackFn(context.Background(), nil))
+			}
+			assert.EqualError(t, ackErr, test.err)
+			require.NoError(t, r.Close(context.Background()))
+		})
+	}
+
+	conf := NewReaderConfig()
+	conf.MaxMessageSize = 10
+	_, err := GetReader("chunker:20", conf)
+	require.EqualError(t, err, "chunk size 20 exceeds the max message size of 10 bytes")
+}
+
+func TestAvroOCFReader(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := goavro.NewOCFWriter(goavro.OCFConfig{
+		W:      &buf,
+		Schema: `{"type":"record","name":"foo","fields":[{"name":"bar","type":"string"},{"name":"baz","type":"long"}]}`,
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Append([]interface{}{
+		map[string]interface{}{"bar": "first", "baz": 1},
+		map[string]interface{}{"bar": "second", "baz": 2},
+	}))
+
+	testReaderSuite(
+		t, "avro-ocf", "", buf.Bytes(),
+		`{"bar":"first","baz":1}`,
+		`{"bar":"second","baz":2}`,
+	)
+	testReaderSuite(
+		t, "auto", "foo.avro", buf.Bytes(),
+		`{"bar":"first","baz":1}`,
+		`{"bar":"second","baz":2}`,
+	)
+}
+
+type parquetTestRow struct {
+	Bar  string           `parquet:"name=bar, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Baz  int64            `parquet:"name=baz, type=INT64"`
+	Tags []string         `parquet:"name=tags, type=MAP, convertedtype=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8"`
+	Meta map[string]int32 `parquet:"name=meta, type=MAP, convertedtype=MAP, keytype=BYTE_ARRAY, keyconvertedtype=UTF8, valuetype=INT32"`
+}
+
+func TestParquetReader(t *testing.T) {
+	var buf bytes.Buffer
+	pw, err := writer.NewParquetWriter(writerfile.NewWriterFile(&buf), new(parquetTestRow), 1)
+	require.NoError(t, err)
+	require.NoError(t, pw.Write(parquetTestRow{
+		Bar: "first", Baz: 1, Tags: []string{"a", "b"}, Meta: map[string]int32{"c": 3},
+	}))
+	require.NoError(t, pw.Write(parquetTestRow{
+		Bar: "second", Baz: 2, Tags: []string{}, Meta: map[string]int32{},
+	}))
+	require.NoError(t, pw.WriteStop())
+
+	testReaderSuite(
+		t, "parquet", "", buf.Bytes(),
+		`{"bar":"first","baz":1,"meta":{"c":3},"tags":["a","b"]}`,
+		`{"bar":"second","baz":2,"meta":{},"tags":[]}`,
+	)
+	testReaderSuite(
+		t, "auto", "foo.parquet", buf.Bytes(),
+		`{"bar":"first","baz":1,"meta":{"c":3},"tags":["a","b"]}`,
+		`{"bar":"second","baz":2,"meta":{},"tags":[]}`,
+	)
+}
@@ -2,7 +2,9 @@ package codec
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -10,16 +12,20 @@ import (
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/klauspost/compress/zstd"
 )
 
 // WriterDocs is a static field documentation for output codecs.
 var WriterDocs = docs.FieldCommon(
-	"codec", "The way in which the bytes of messages should be written out into the output data stream. It's possible to write lines using a custom delimiter with the `delim:x` codec, where x is the character sequence custom delimiter.", "lines", "delim:\t", "delim:foobar",
+	"codec", "The way in which the bytes of messages should be written out into the output data stream. It's possible to write lines using a custom delimiter with the `delim:x` codec, where x is the character sequence custom delimiter. Output can be compressed by prefixing a codec with `gzip/` or `zstd/`, for example `gzip/lines`.", "lines", "delim:\t", "delim:foobar", "gzip/lines", "zstd/all-bytes",
 ).HasAnnotatedOptions(
 	"all-bytes", "Write the message to the file in full. If the file already exists the old content is deleted.",
 	"append", "Append messages to the file.",
 	"lines", "Append messages to the file followed by a line break.",
 	"delim:x", "Append messages to the file followed by a custom delimiter.",
+	"length-prefixed", "Append messages to the file preceded by their length as a 4 byte big endian unsigned integer.",
+	"gzip/x", "Compress the output of another codec with gzip, where appended data is written as a new gzip member.",
+	"zstd/x", "Compress the output of another codec with zstd, where appended data is written as a new zstd frame.",
 )
 
 //------------------------------------------------------------------------------
@@ -47,6 +53,24 @@ type WriterConstructor func(io.WriteCloser) (Writer, error)
 
 // GetWriter returns a constructor that creates write codecs.
 func GetWriter(codec string) (WriterConstructor, WriterConfig, error) {
+	if i := strings.Index(codec, "/"); i > 0 {
+		compressCtor, ok := compressWriter(codec[:i])
+		if !ok {
+			return nil, WriterConfig{}, fmt.Errorf("codec was not recognised: %v", codec[:i])
+		}
+		ctor, conf, err := GetWriter(codec[i+1:])
+		if err != nil {
+			return nil, WriterConfig{}, err
+		}
+		return func(w io.WriteCloser) (Writer, error) {
+			cw, err := compressCtor(w)
+			if err != nil {
+				return nil, err
+			}
+			return ctor(cw)
+		}, conf, nil
+	}
+
 	switch codec {
 	case "all-bytes":
 		return func(w io.WriteCloser) (Writer, error) {
@@ -58,6 +82,8 @@ func GetWriter(codec string) (WriterConstructor, WriterConfig, error) {
 		}, customDelimConfig, nil
 	case "lines":
 		return newLinesWriter, linesWriterConfig, nil
+	case "length-prefixed":
+		return newLengthPrefixedWriter, lengthPrefixedConfig, nil
 	}
 	if strings.HasPrefix(codec, "delim:") {
 		by := strings.TrimPrefix(codec, "delim:")
@@ -166,3 +192,85 @@ func (d *customDelimWriter) EndBatch() error {
 func (d *customDelimWriter) Close(ctx context.Context) error {
 	return d.w.Close()
 }
+
+//------------------------------------------------------------------------------
+
+var lengthPrefixedConfig = WriterConfig{
+	Append: true,
+}
+
+type lengthPrefixedWriter struct {
+	w      io.WriteCloser
+	lenBuf [4]byte
+}
+
+func newLengthPrefixedWriter(w io.WriteCloser) (Writer, error) {
+	return &lengthPrefixedWriter{w: w}, nil
+}
+
+func (l *lengthPrefixedWriter) Write(ctx context.Context, p types.Part) error {
+	partBytes := p.Get()
+	binary.BigEndian.PutUint32(l.lenBuf[:], uint32(len(partBytes)))
+	if _, err := l.w.Write(l.lenBuf[:]); err != nil {
+		return err
+	}
+	_, err := l.w.Write(partBytes)
+	return err
+}
+
+func (l *lengthPrefixedWriter) EndBatch() error {
+	return nil
+}
+
+func (l *lengthPrefixedWriter) Close(ctx context.Context) error {
+	return l.w.Close()
+}
+
+//------------------------------------------------------------------------------
+
+type flushWriteCloser interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// compressedWriteCloser compresses data written to an underlying writer,
+// flushing after each write so that written data is never left buffered for
+// long lived handles.
+type compressedWriteCloser struct {
+	c flushWriteCloser
+	w io.WriteCloser
+}
+
+func (c *compressedWriteCloser) Write(p []byte) (int, error) {
+	n, err := c.c.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, c.c.Flush()
+}
+
+func (c *compressedWriteCloser) Close() error {
+	cErr := c.c.Close()
+	if err := c.w.Close(); err != nil {
+		return err
+	}
+	return cErr
+}
+
+func compressWriter(codec string) (func(io.WriteCloser) (io.WriteCloser, error), bool) {
+	switch codec {
+	case "gzip":
+		return func(w io.WriteCloser) (io.WriteCloser, error) {
+			return &compressedWriteCloser{c: gzip.NewWriter(w), w: w}, nil
+		}, true
+	case "zstd":
+		return func(w io.WriteCloser) (io.WriteCloser, error) {
+			z, err := zstd.NewWriter(w)
+			if err != nil {
+				return nil, err
+			}
+			return &compressedWriteCloser{c: z, w: w}, nil
+		}, true
+	}
+	return nil, false
+}
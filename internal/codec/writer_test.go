@@ -0,0 +1,78 @@
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bufCloser struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *bufCloser) Close() error {
+	b.closed = true
+	return nil
+}
+
+func writeParts(t *testing.T, codec string, parts ...string) *bufCloser {
+	t.Helper()
+
+	ctor, _, err := GetWriter(codec)
+	require.NoError(t, err)
+
+	buf := &bufCloser{}
+	w, err := ctor(buf)
+	require.NoError(t, err)
+
+	for _, p := range parts {
+		require.NoError(t, w.Write(context.Background(), message.NewPart([]byte(p))))
+	}
+	require.NoError(t, w.Close(context.Background()))
+	assert.True(t, buf.closed)
+	return buf
+}
+
+func TestLengthPrefixedWriter(t *testing.T) {
+	buf := writeParts(t, "length-prefixed", "foo", "", "baz")
+	assert.Equal(t, []byte{0, 0, 0, 3, 'f', 'o', 'o', 0, 0, 0, 0, 0, 0, 0, 3, 'b', 'a', 'z'}, buf.Bytes())
+}
+
+func TestGzipLinesWriter(t *testing.T) {
+	buf := writeParts(t, "gzip/lines", "foo", "bar")
+
+	r, err := gzip.NewReader(&buf.Buffer)
+	require.NoError(t, err)
+
+	b, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nbar\n", string(b))
+}
+
+func TestZstdAllBytesWriter(t *testing.T) {
+	buf := writeParts(t, "zstd/all-bytes", "foo bar")
+
+	r, err := zstd.NewReader(&buf.Buffer)
+	require.NoError(t, err)
+	defer r.Close()
+
+	b, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "foo bar", string(b))
+}
+
+func TestWriterBadCodec(t *testing.T) {
+	_, _, err := GetWriter("nope/lines")
+	require.Error(t, err)
+
+	_, _, err = GetWriter("gzip/nope")
+	require.Error(t, err)
+}
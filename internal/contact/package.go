@@ -0,0 +1,227 @@
+// Package contact provides parsing and normalization of contact details such
+// as phone numbers and email addresses.
+package contact
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// phoneRegion describes the numbering plan of a region.
+type phoneRegion struct {
+	// The country calling code.
+	callingCode string
+
+	// The prefix dialled before national numbers within the region, which is
+	// not part of the E.164 format.
+	trunkPrefix string
+
+	// The prefix dialled before international numbers within the region.
+	intlPrefix string
+
+	// The bounds of the length of national significant numbers.
+	minLen, maxLen int
+}
+
+// phoneRegions contains the numbering plans of supported regions, keyed by
+// their ISO 3166-1 alpha-2 code.
+var phoneRegions = map[string]phoneRegion{
+	"AT": {"43", "0", "00", 4, 13},
+	"AU": {"61", "0", "0011", 9, 9},
+	"BE": {"32", "0", "00", 8, 9},
+	"BR": {"55", "0", "00", 10, 11},
+	"CA": {"1", "1", "011", 10, 10},
+	"CH": {"41", "0", "00", 9, 9},
+	"CN": {"86", "0", "00", 7, 11},
+	"DE": {"49", "0", "00", 5, 13},
+	"DK": {"45", "", "00", 8, 8},
+	"ES": {"34", "", "00", 9, 9},
+	"FI": {"358", "0", "00", 5, 12},
+	"FR": {"33", "0", "00", 9, 9},
+	"GB": {"44", "0", "00", 9, 10},
+	"IE": {"353", "0", "00", 7, 9},
+	"IN": {"91", "0", "00", 10, 10},
+	"IT": {"39", "", "00", 6, 11},
+	"JP": {"81", "0", "010", 9, 10},
+	"MX": {"52", "", "00", 10, 10},
+	"NL": {"31", "0", "00", 9, 9},
+	"NO": {"47", "", "00", 8, 8},
+	"NZ": {"64", "0", "00", 8, 10},
+	"PL": {"48", "", "00", 9, 9},
+	"PT": {"351", "", "00", 9, 9},
+	"SE": {"46", "0", "00", 7, 13},
+	"SG": {"65", "", "000", 8, 8},
+	"US": {"1", "1", "011", 10, 10},
+	"ZA": {"27", "0", "00", 9, 9},
+}
+
+// phoneRegionsByCode contains the numbering plans of supported regions keyed
+// by their calling code, where regions sharing a code share a numbering plan.
+var phoneRegionsByCode = func() map[string]phoneRegion {
+	m := make(map[string]phoneRegion, len(phoneRegions))
+	for _, r := range phoneRegions {
+		m[r.callingCode] = r
+	}
+	return m
+}()
+
+// ErrInvalidPhone is returned when a phone number cannot be parsed.
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// UnsupportedRegionError is returned when a phone number without a country
+// calling code is parsed for a region that is not supported, and wraps
+// ErrInvalidPhone.
+type UnsupportedRegionError struct {
+	Region string
+}
+
+// Error returns a human readable description of the error.
+func (e *UnsupportedRegionError) Error() string {
+	return fmt.Sprintf("%v: region not supported: %v", ErrInvalidPhone, e.Region)
+}
+
+// Unwrap returns ErrInvalidPhone.
+func (e *UnsupportedRegionError) Unwrap() error {
+	return ErrInvalidPhone
+}
+
+// Bounds of the number of digits of an E.164 number, including the country
+// calling code.
+const (
+	e164MinDigits = 7
+	e164MaxDigits = 15
+)
+
+func (r phoneRegion) validNational(nsn string) bool {
+	return len(nsn) >= r.minLen && len(nsn) <= r.maxLen
+}
+
+// ParsePhone parses a phone number and returns it in the E.164 format. Numbers
+// beginning with a + are parsed as international numbers, otherwise they are
+// parsed as being dialled from within a region identified by its ISO 3166-1
+// alpha-2 code, which may be empty if all numbers are international.
+//
+// Spaces, hyphens, dots, slashes and parentheses are ignored. The length of
+// numbers is validated for supported regions, and numbers of other regions are
+// only checked against the bounds of the E.164 format.
+func ParsePhone(number, region string) (string, error) {
+	var digits strings.Builder
+	international := false
+	for i, c := range strings.TrimSpace(number) {
+		switch {
+		case c >= '0' && c <= '9':
+			digits.WriteRune(c)
+		case c == '+' && i == 0:
+			international = true
+		case c == ' ', c == '-', c == '.', c == '/', c == '(', c == ')':
+		default:
+			return "", fmt.Errorf("%w: unexpected character '%c'", ErrInvalidPhone, c)
+		}
+	}
+	num := digits.String()
+	if num == "" {
+		return "", fmt.Errorf("%w: no digits found", ErrInvalidPhone)
+	}
+
+	if !international {
+		if region == "" {
+			return "", fmt.Errorf("%w: a region is required for numbers without a country calling code", ErrInvalidPhone)
+		}
+		r, exists := phoneRegions[strings.ToUpper(region)]
+		if !exists {
+			return "", &UnsupportedRegionError{Region: region}
+		}
+		if !strings.HasPrefix(num, r.intlPrefix) {
+			if r.trunkPrefix != "" && strings.HasPrefix(num, r.trunkPrefix) && r.validNational(num[len(r.trunkPrefix):]) {
+				num = num[len(r.trunkPrefix):]
+			}
+			if !r.validNational(num) {
+				return "", fmt.Errorf("%w: expected between %v and %v digits for region %v", ErrInvalidPhone, r.minLen, r.maxLen, strings.ToUpper(region))
+			}
+			return "+" + r.callingCode + num, nil
+		}
+		num = num[len(r.intlPrefix):]
+	}
+
+	if len(num) < e164MinDigits || len(num) > e164MaxDigits {
+		return "", fmt.Errorf("%w: expected between %v and %v digits", ErrInvalidPhone, e164MinDigits, e164MaxDigits)
+	}
+
+	// Calling codes are prefix free and between one and three digits.
+	for l := 1; l <= 3; l++ {
+		r, exists := phoneRegionsByCode[num[:l]]
+		if !exists {
+			continue
+		}
+		nsn := num[l:]
+		// Numbers are often written with their trunk prefix in parentheses
+		// after the calling code, e.g. +44 (0)20 7946 0958.
+		if r.trunkPrefix != "" && !r.validNational(nsn) && strings.HasPrefix(nsn, r.trunkPrefix) {
+			nsn = nsn[len(r.trunkPrefix):]
+		}
+		if !r.validNational(nsn) {
+			return "", fmt.Errorf("%w: expected between %v and %v digits after calling code %v", ErrInvalidPhone, r.minLen, r.maxLen, r.callingCode)
+		}
+		return "+" + r.callingCode + nsn, nil
+	}
+	return "+" + num, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ErrInvalidEmail is returned when an email address cannot be parsed.
+var ErrInvalidEmail = errors.New("invalid email address")
+
+// NormalizeEmail parses an email address according to RFC 5322 and returns it
+// in a normalized form, where any display name and surrounding whitespace are
+// removed and the domain is lower cased. The local part is left unchanged as
+// it is case sensitive.
+func NormalizeEmail(address string) (string, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(address))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidEmail, err)
+	}
+
+	at := strings.LastIndex(addr.Address, "@")
+	if at <= 0 {
+		return "", fmt.Errorf("%w: missing local part", ErrInvalidEmail)
+	}
+	local, domain := addr.Address[:at], strings.ToLower(addr.Address[at+1:])
+	if len(local) > 64 {
+		return "", fmt.Errorf("%w: local part exceeds 64 characters", ErrInvalidEmail)
+	}
+	if err := validateEmailDomain(domain); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidEmail, err)
+	}
+	return local + "@" + domain, nil
+}
+
+func validateEmailDomain(domain string) error {
+	if strings.HasPrefix(domain, "[") && strings.HasSuffix(domain, "]") {
+		// Address literals are validated by the RFC 5322 parser.
+		return nil
+	}
+	if len(domain) > 253 {
+		return errors.New("domain exceeds 253 characters")
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 {
+			return fmt.Errorf("domain label '%v' must be between 1 and 63 characters", label)
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Errorf("domain label '%v' must not begin or end with a hyphen", label)
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '-' && c < 0x80 {
+				return fmt.Errorf("domain label '%v' contains invalid character '%c'", label, c)
+			}
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
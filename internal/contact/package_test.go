@@ -0,0 +1,89 @@
+package contact
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePhone(t *testing.T) {
+	tests := []struct {
+		number string
+		region string
+		output string
+		err    string
+	}{
+		{number: "+1 (415) 555-2671", output: "+14155552671"},
+		{number: "(415) 555-2671", region: "US", output: "+14155552671"},
+		{number: "1-415-555-2671", region: "us", output: "+14155552671"},
+		{number: "011 44 20 7946 0958", region: "US", output: "+442079460958"},
+		{number: "020 7946 0958", region: "GB", output: "+442079460958"},
+		{number: "+44 (0)20 7946 0958", output: "+442079460958"},
+		{number: "0044 20 7946 0958", region: "DE", output: "+442079460958"},
+		{number: "06 1234 5678", region: "IT", output: "+390612345678"},
+		{number: "0412 345 678", region: "AU", output: "+61412345678"},
+		{number: "+7 495 123 45 67", output: "+74951234567"},
+		{number: "+44 20 7946", err: "invalid phone number: expected between 9 and 10 digits after calling code 44"},
+		{number: "555-2671", region: "US", err: "invalid phone number: expected between 10 and 10 digits for region US"},
+		{number: "415 555 2671", err: "invalid phone number: a region is required for numbers without a country calling code"},
+		{number: "415 555 2671", region: "XX", err: "invalid phone number: region not supported: XX"},
+		{number: "+1 415 CALL NOW", err: "invalid phone number: unexpected character 'C'"},
+		{number: "1+415", region: "US", err: "invalid phone number: unexpected character '+'"},
+		{number: "+123", err: "invalid phone number: expected between 7 and 15 digits"},
+		{number: " ", region: "US", err: "invalid phone number: no digits found"},
+	}
+
+	for _, test := range tests {
+		res, err := ParsePhone(test.number, test.region)
+		if test.err != "" {
+			require.EqualError(t, err, test.err, test.number)
+			assert.True(t, errors.Is(err, ErrInvalidPhone), test.number)
+		} else {
+			require.NoError(t, err, test.number)
+			assert.Equal(t, test.output, res, test.number)
+		}
+	}
+}
+
+func TestParsePhoneUnsupportedRegion(t *testing.T) {
+	_, err := ParsePhone("415 555 2671", "XX")
+
+	var regionErr *UnsupportedRegionError
+	require.True(t, errors.As(err, &regionErr))
+	assert.Equal(t, "XX", regionErr.Region)
+
+	_, err = ParsePhone("555-2671", "US")
+	assert.False(t, errors.As(err, &regionErr))
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		address string
+		output  string
+		err     string
+	}{
+		{address: "foo@example.com", output: "foo@example.com"},
+		{address: "  Foo.Bar@Example.COM ", output: "Foo.Bar@example.com"},
+		{address: "Foo Bar <foo+tag@Sub.Example.com>", output: "foo+tag@sub.example.com"},
+		{address: "foo@[192.168.0.1]", output: "foo@[192.168.0.1]"},
+		{address: "foo@bücher.example", output: "foo@bücher.example"},
+		{address: "foo", err: "invalid email address: mail: missing '@' or angle-addr"},
+		{address: "foo@-example.com", err: "invalid email address: domain label '-example' must not begin or end with a hyphen"},
+		{address: "foo@example..com", err: "invalid email address: mail: missing '@' or angle-addr"},
+		{address: "foo@exa_mple.com", err: "invalid email address: domain label 'exa_mple' contains invalid character '_'"},
+		{address: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa@example.com", err: "invalid email address: local part exceeds 64 characters"},
+	}
+
+	for _, test := range tests {
+		res, err := NormalizeEmail(test.address)
+		if test.err != "" {
+			require.EqualError(t, err, test.err, test.address)
+			assert.True(t, errors.Is(err, ErrInvalidEmail), test.address)
+		} else {
+			require.NoError(t, err, test.address)
+			assert.Equal(t, test.output, res, test.address)
+		}
+	}
+}
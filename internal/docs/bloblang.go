@@ -21,17 +21,21 @@ func LintBloblangMapping(ctx LintContext, line, col int, v interface{}) []Lint {
 	if len(str) == 0 {
 		return nil
 	}
-	_, err := bloblang.NewMapping("", str)
-	if err == nil {
-		return nil
-	}
-	if mErr, ok := err.(*parser.Error); ok {
-		bline, bcol := parser.LineAndColOf([]rune(str), mErr.Input)
-		lint := NewLintError(line+bline, mErr.ErrorAtPositionStructured("", []rune(str)))
+	deprecations, err := parser.MappingDeprecations("", str, parser.Context{
+		Functions: query.AllFunctions,
+		Methods:   query.AllMethods,
+	})
+	if err != nil {
+		bline, bcol := parser.LineAndColOf([]rune(str), err.Input)
+		lint := NewLintError(line+bline, err.ErrorAtPositionStructured("", []rune(str)))
 		lint.Column = col + bcol
 		return []Lint{lint}
 	}
-	return []Lint{NewLintError(line, err.Error())}
+	var lints []Lint
+	for _, d := range deprecations {
+		lints = append(lints, NewLintWarning(line, d.String()))
+	}
+	return lints
 }
 
 // LintBloblangField is function for linting a config field expected to be an
@@ -46,7 +50,11 @@ func LintBloblangField(ctx LintContext, line, col int, v interface{}) []Lint {
 	}
 	_, err := bloblang.NewField(str)
 	if err == nil {
-		return nil
+		var lints []Lint
+		for _, fn := range parser.DeprecatedFieldFunctions(str) {
+			lints = append(lints, NewLintWarning(line, fmt.Sprintf("interpolation function `%v` uses a deprecated syntax, use a Bloblang query instead", fn)))
+		}
+		return lints
 	}
 	if mErr, ok := err.(*parser.Error); ok {
 		bline, bcol := parser.LineAndColOf([]rune(str), mErr.Input)
@@ -500,6 +500,7 @@ func TestLinting(t *testing.T) {
 				docs.FieldAdvanced("foo8", "").Map().WithChildren(
 					docs.FieldCommon("foochild1", ""),
 				),
+				docs.FieldCommon("foo9", "").IsInterpolated(),
 			),
 		})
 	}
@@ -601,6 +602,23 @@ processors: []`,
 				docs.NewLintError(4, "field processors is empty and can be removed"),
 			},
 		},
+		{
+			name:      "interpolated bloblang query",
+			inputType: docs.TypeInput,
+			inputConf: `
+testlintfooinput:
+  foo9: ${! this.foo.uppercase() }`,
+		},
+		{
+			name:      "interpolated deprecated function",
+			inputType: docs.TypeInput,
+			inputConf: `
+testlintfooinput:
+  foo9: ${!json_field:foo} ${! hostname() }`,
+			res: []docs.Lint{
+				docs.NewLintWarning(3, "interpolation function `json_field:foo` uses a deprecated syntax, use a Bloblang query instead"),
+			},
+		},
 		{
 			name:      "custom omit func",
 			inputType: docs.TypeInput,
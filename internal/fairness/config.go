@@ -0,0 +1,54 @@
+package fairness
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+)
+
+// Config contains configuration fields for a fairness scheduler.
+type Config struct {
+	Enabled bool           `json:"enabled" yaml:"enabled"`
+	Weights map[string]int `json:"weights" yaml:"weights"`
+}
+
+// NewConfig returns a Config with default values.
+func NewConfig() Config {
+	return Config{
+		Enabled: false,
+		Weights: map[string]int{},
+	}
+}
+
+// Weight returns the weight of a source, which is the first of the provided
+// keys that has a configured weight, or 1 if none do.
+func (c Config) Weight(keys ...string) int {
+	for _, k := range keys {
+		if w, exists := c.Weights[k]; exists {
+			return w
+		}
+	}
+	return 1
+}
+
+// Validate returns an error if any configured weight is not a positive
+// integer.
+func (c Config) Validate() error {
+	for k, w := range c.Weights {
+		if w < 1 {
+			return fmt.Errorf("weight of source '%v' must be greater than zero, received %v", k, w)
+		}
+	}
+	return nil
+}
+
+// FieldSpec returns a spec for a fairness field, where the description of
+// weights explains how sources are identified for the component.
+func FieldSpec(weightsDesc string, weightsExamples ...interface{}) docs.FieldSpec {
+	return docs.FieldAdvanced(
+		"fairness", "Allows you to configure fair scheduling of messages consumed from multiple sources, where each source with a message ready is given turns in proportion to its weight rather than whichever sources are busiest being consumed from most often.",
+	).WithChildren(
+		docs.FieldCommon("enabled", "Whether to schedule messages from multiple sources fairly."),
+		docs.FieldCommon("weights", weightsDesc, weightsExamples...).Map(),
+	).AtVersion("3.44.0")
+}
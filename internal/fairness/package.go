@@ -0,0 +1,4 @@
+// Package fairness implements a scheduler for interleaving messages consumed
+// from many sources, such as the partitions of a Kafka topic, in proportion to
+// configurable weights so that a busy source cannot starve the others.
+package fairness
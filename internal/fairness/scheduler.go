@@ -0,0 +1,171 @@
+package fairness
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClosed is returned when sending to or receiving from a closed scheduler.
+var ErrClosed = errors.New("scheduler is closed")
+
+type pendingSend struct {
+	value interface{}
+	taken chan struct{}
+}
+
+type source struct {
+	id      string
+	weight  int
+	current int
+	pending []*pendingSend
+}
+
+// Scheduler interleaves values sent concurrently from many sources to a
+// receiver. When multiple sources have values waiting the next is chosen by
+// smooth weighted round robin, such that over time each waiting source is
+// given turns in proportion to its weight, and turns are spread evenly rather
+// than given in bursts.
+//
+// This component is safe to use concurrently across goroutines.
+type Scheduler struct {
+	weightFn func(source string) int
+
+	mut     sync.Mutex
+	sources map[string]*source
+	ready   chan struct{}
+	closed  bool
+	closedC chan struct{}
+}
+
+// NewScheduler returns a scheduler that obtains the weight of each source the
+// first time it is sent from.
+func NewScheduler(weightFn func(source string) int) *Scheduler {
+	return &Scheduler{
+		weightFn: weightFn,
+		sources:  map[string]*source{},
+		ready:    make(chan struct{}, 1),
+		closedC:  make(chan struct{}),
+	}
+}
+
+// Send a value from a source and block until it has been received, the
+// context is cancelled, or the scheduler is closed.
+func (s *Scheduler) Send(ctx context.Context, sourceID string, v interface{}) error {
+	p := &pendingSend{
+		value: v,
+		taken: make(chan struct{}),
+	}
+
+	s.mut.Lock()
+	if s.closed {
+		s.mut.Unlock()
+		return ErrClosed
+	}
+	src, exists := s.sources[sourceID]
+	if !exists {
+		weight := 1
+		if s.weightFn != nil {
+			if weight = s.weightFn(sourceID); weight < 1 {
+				weight = 1
+			}
+		}
+		src = &source{id: sourceID, weight: weight}
+		s.sources[sourceID] = src
+	}
+	src.pending = append(src.pending, p)
+	s.mut.Unlock()
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+
+	var err error
+	select {
+	case <-p.taken:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-s.closedC:
+		err = ErrClosed
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	for i, pending := range src.pending {
+		if pending == p {
+			src.pending = append(src.pending[:i], src.pending[i+1:]...)
+			return err
+		}
+	}
+	// The value was received before we were able to withdraw it.
+	return nil
+}
+
+// next removes and returns the value of the waiting source with the highest
+// current weight, where ties are broken by source identifier, or false if no
+// sources are waiting.
+func (s *Scheduler) next() (interface{}, bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	var chosen *source
+	total := 0
+	for _, src := range s.sources {
+		if len(src.pending) == 0 {
+			continue
+		}
+		src.current += src.weight
+		total += src.weight
+		if chosen == nil || src.current > chosen.current ||
+			(src.current == chosen.current && src.id < chosen.id) {
+			chosen = src
+		}
+	}
+	if chosen == nil {
+		return nil, false
+	}
+	chosen.current -= total
+
+	p := chosen.pending[0]
+	chosen.pending = chosen.pending[1:]
+	close(p.taken)
+
+	// Values may remain that another receiver has not been woken for.
+	if total > chosen.weight || len(chosen.pending) > 0 {
+		select {
+		case s.ready <- struct{}{}:
+		default:
+		}
+	}
+	return p.value, true
+}
+
+// Receive the next value chosen from the sources that are waiting, blocking
+// until a value is sent, the context is cancelled, or the scheduler is closed.
+func (s *Scheduler) Receive(ctx context.Context) (interface{}, error) {
+	for {
+		if v, ok := s.next(); ok {
+			return v, nil
+		}
+		select {
+		case <-s.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-s.closedC:
+			return nil, ErrClosed
+		}
+	}
+}
+
+// Close the scheduler, causing all blocked and future calls to Send and
+// Receive to return ErrClosed.
+func (s *Scheduler) Close() {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.closedC)
+	}
+}
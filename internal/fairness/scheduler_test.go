@@ -0,0 +1,147 @@
+package fairness
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func waitForPending(t *testing.T, s *Scheduler, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		s.mut.Lock()
+		defer s.mut.Unlock()
+		total := 0
+		for _, src := range s.sources {
+			total += len(src.pending)
+		}
+		return total == n
+	}, time.Second, time.Millisecond)
+}
+
+func TestSchedulerWeighted(t *testing.T) {
+	s := NewScheduler(func(source string) int {
+		if source == "hot" {
+			return 3
+		}
+		return 1
+	})
+
+	var wg sync.WaitGroup
+	for _, src := range []string{"hot", "cold"} {
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(src string) {
+				defer wg.Done()
+				assert.NoError(t, s.Send(context.Background(), src, src))
+			}(src)
+		}
+	}
+	waitForPending(t, s, 16)
+
+	var received []interface{}
+	for i := 0; i < 8; i++ {
+		v, err := s.Receive(context.Background())
+		require.NoError(t, err)
+		received = append(received, v)
+	}
+
+	// Turns are spread evenly rather than given in bursts.
+	assert.Equal(t, []interface{}{
+		"hot", "cold", "hot", "hot",
+		"hot", "cold", "hot", "hot",
+	}, received)
+
+	for i := 0; i < 8; i++ {
+		_, err := s.Receive(context.Background())
+		require.NoError(t, err)
+	}
+	wg.Wait()
+}
+
+func TestSchedulerEqualWeights(t *testing.T) {
+	s := NewScheduler(nil)
+
+	var wg sync.WaitGroup
+	for _, src := range []string{"a", "b", "c"} {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(src string) {
+				defer wg.Done()
+				assert.NoError(t, s.Send(context.Background(), src, src))
+			}(src)
+		}
+	}
+	waitForPending(t, s, 12)
+
+	counts := map[interface{}]int{}
+	for i := 0; i < 6; i++ {
+		v, err := s.Receive(context.Background())
+		require.NoError(t, err)
+		counts[v]++
+	}
+	assert.Equal(t, map[interface{}]int{"a": 2, "b": 2, "c": 2}, counts)
+
+	for i := 0; i < 6; i++ {
+		_, err := s.Receive(context.Background())
+		require.NoError(t, err)
+	}
+	wg.Wait()
+}
+
+func TestSchedulerCancelSend(t *testing.T) {
+	s := NewScheduler(nil)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer done()
+	require.Equal(t, context.DeadlineExceeded, s.Send(ctx, "a", "foo"))
+
+	// A withdrawn value is never received.
+	rctx, rdone := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer rdone()
+	_, err := s.Receive(rctx)
+	require.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestSchedulerClose(t *testing.T) {
+	s := NewScheduler(nil)
+
+	errs := make(chan error, 2)
+	go func() {
+		errs <- s.Send(context.Background(), "a", "foo")
+	}()
+	waitForPending(t, s, 1)
+
+	v, err := s.Receive(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "foo", v)
+	require.NoError(t, <-errs)
+
+	go func() {
+		_, err := s.Receive(context.Background())
+		errs <- err
+	}()
+	s.Close()
+	require.Equal(t, ErrClosed, <-errs)
+	require.Equal(t, ErrClosed, s.Send(context.Background(), "a", "bar"))
+}
+
+func TestConfigWeight(t *testing.T) {
+	conf := NewConfig()
+	conf.Weights = map[string]int{
+		"foo":   2,
+		"foo:1": 5,
+	}
+	require.NoError(t, conf.Validate())
+
+	assert.Equal(t, 5, conf.Weight("foo:1", "foo"))
+	assert.Equal(t, 2, conf.Weight("foo:2", "foo"))
+	assert.Equal(t, 1, conf.Weight("bar:0", "bar"))
+
+	conf.Weights["bar"] = 0
+	require.EqualError(t, conf.Validate(), "weight of source 'bar' must be greater than zero, received 0")
+}
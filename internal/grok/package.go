@@ -0,0 +1,3 @@
+// Package grok contains utilities shared by components that parse data with
+// Grok expressions.
+package grok
@@ -0,0 +1,47 @@
+package grok
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/Jeffail/benthos/v3/internal/filepath"
+)
+
+// AddPatternsFromPath parses Grok pattern definitions from a file, or all
+// files within a directory or matching a glob pattern, and adds them to a map
+// of patterns. Each line of a file defines a pattern by its name followed by a
+// space and its expression, and lines beginning with # are ignored.
+func AddPatternsFromPath(path string, patterns map[string]string) error {
+	if s, err := os.Stat(path); err != nil {
+		return err
+	} else if s.IsDir() {
+		path = path + "/*"
+	}
+
+	files, err := filepath.Globs([]string{path})
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		file, err := os.Open(f)
+		if err != nil {
+			return err
+		}
+
+		scanner := bufio.NewScanner(file)
+
+		for scanner.Scan() {
+			l := scanner.Text()
+			if len(l) > 0 && l[0] != '#' {
+				names := strings.SplitN(l, " ", 2)
+				patterns[names[0]] = names[1]
+			}
+		}
+
+		file.Close()
+	}
+
+	return nil
+}
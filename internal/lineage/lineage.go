@@ -0,0 +1,115 @@
+package lineage
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/gofrs/uuid"
+)
+
+// Reserved metadata keys used for recording the lineage of a message.
+const (
+	MetaID     = "benthos_lineage_id"
+	MetaOffset = "benthos_lineage_offset"
+	MetaChain  = "benthos_lineage_chain"
+)
+
+// Metadata fields set by inputs that identify the position of a message within
+// its source, a source offset is recorded from the first group of fields that
+// are all present.
+var offsetKeys = [][]string{
+	{"kafka_topic", "kafka_partition", "kafka_offset"},
+	{"kinesis_shard", "kinesis_sequence_number"},
+	{"redis_stream"},
+	{"path", "replay_position"},
+}
+
+func sourceOffset(part types.Part, seq int64) string {
+	meta := part.Metadata()
+keysLoop:
+	for _, keys := range offsetKeys {
+		values := make([]string, 0, len(keys))
+		for _, k := range keys {
+			v := meta.Get(k)
+			if v == "" {
+				continue keysLoop
+			}
+			values = append(values, v)
+		}
+		return strings.Join(values, ":")
+	}
+	return strconv.FormatInt(seq, 10)
+}
+
+// Stamp records a message part as having been consumed by an input component.
+// If the part does not yet have a lineage identifier then one is assigned and
+// the source offset is recorded, otherwise the component is appended to its
+// chain.
+func Stamp(part types.Part, component string, seq int64) {
+	meta := part.Metadata()
+	if meta.Get(MetaID) != "" {
+		Append(part, component)
+		return
+	}
+	id := ""
+	if u4, err := uuid.NewV4(); err == nil {
+		id = u4.String()
+	}
+	meta.Set(MetaID, id)
+	meta.Set(MetaOffset, sourceOffset(part, seq))
+	meta.Set(MetaChain, component)
+}
+
+// Append adds a component to the chain of a message part. Parts without a
+// lineage identifier are ignored.
+func Append(part types.Part, component string) {
+	meta := part.Metadata()
+	if meta.Get(MetaID) == "" {
+		return
+	}
+	if chain := meta.Get(MetaChain); chain != "" {
+		meta.Set(MetaChain, chain+","+component)
+	} else {
+		meta.Set(MetaChain, component)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Event describes the delivery attempt of a message to an output, including
+// the lineage of the message.
+type Event struct {
+	ID        string    `json:"id"`
+	Offset    string    `json:"offset"`
+	Chain     []string  `json:"chain"`
+	Output    string    `json:"output"`
+	Delivered bool      `json:"delivered"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewEvent creates an event for a message part delivered to an output, and
+// returns false if the part has no lineage identifier.
+func NewEvent(part types.Part, output string, err error) (Event, bool) {
+	meta := part.Metadata()
+	id := meta.Get(MetaID)
+	if id == "" {
+		return Event{}, false
+	}
+	e := Event{
+		ID:        id,
+		Offset:    meta.Get(MetaOffset),
+		Output:    output,
+		Delivered: err == nil,
+		Timestamp: time.Now().UTC(),
+	}
+	if chain := meta.Get(MetaChain); chain != "" {
+		e.Chain = strings.Split(chain, ",")
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	return e, true
+}
@@ -0,0 +1,140 @@
+package lineage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineageStamp(t *testing.T) {
+	tests := map[string]struct {
+		meta   map[string]string
+		offset string
+	}{
+		"no offset fields": {
+			offset: "5",
+		},
+		"kafka": {
+			meta: map[string]string{
+				"kafka_topic":     "foo",
+				"kafka_partition": "2",
+				"kafka_offset":    "1042",
+			},
+			offset: "foo:2:1042",
+		},
+		"partial kafka": {
+			meta: map[string]string{
+				"kafka_partition": "2",
+				"kafka_offset":    "1042",
+			},
+			offset: "5",
+		},
+		"replay": {
+			meta: map[string]string{
+				"path":            "./foo.jsonl",
+				"replay_position": "7",
+			},
+			offset: "./foo.jsonl:7",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			part := message.NewPart([]byte("hello"))
+			for k, v := range test.meta {
+				part.Metadata().Set(k, v)
+			}
+			Stamp(part, "input.foo", 5)
+			assert.NotEmpty(t, part.Metadata().Get(MetaID))
+			assert.Equal(t, test.offset, part.Metadata().Get(MetaOffset))
+			assert.Equal(t, "input.foo", part.Metadata().Get(MetaChain))
+		})
+	}
+}
+
+func TestLineageChain(t *testing.T) {
+	part := message.NewPart([]byte("hello"))
+
+	Append(part, "processor.ignored")
+	_, ok := NewEvent(part, "output.foo", nil)
+	assert.False(t, ok)
+	assert.Equal(t, "", part.Metadata().Get(MetaChain))
+
+	Stamp(part, "input.foo", 1)
+	id := part.Metadata().Get(MetaID)
+
+	Stamp(part, "input.broker", 1)
+	Append(part, "processor.bar")
+	assert.Equal(t, id, part.Metadata().Get(MetaID))
+	assert.Equal(t, "1", part.Metadata().Get(MetaOffset))
+
+	e, ok := NewEvent(part, "output.baz", errors.New("nope"))
+	require.True(t, ok)
+	assert.Equal(t, id, e.ID)
+	assert.Equal(t, []string{"input.foo", "input.broker", "processor.bar"}, e.Chain)
+	assert.Equal(t, "output.baz", e.Output)
+	assert.False(t, e.Delivered)
+	assert.Equal(t, "nope", e.Error)
+}
+
+type ctxProcessor struct {
+	ctx context.Context
+}
+
+func (c *ctxProcessor) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return c.ProcessMessageWithContext(context.Background(), msg)
+}
+
+func (c *ctxProcessor) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
+	c.ctx = ctx
+	return []types.Message{msg}, nil
+}
+
+func (c *ctxProcessor) CloseAsync() {}
+
+func (c *ctxProcessor) WaitForClose(time.Duration) error {
+	return nil
+}
+
+func TestWrapProcessorContext(t *testing.T) {
+	inner := &ctxProcessor{}
+	proc := WrapProcessor("processor.foo", inner)
+
+	cProc, ok := proc.(interface {
+		ProcessMessageWithContext(context.Context, types.Message) ([]types.Message, types.Response)
+	})
+	require.True(t, ok)
+
+	part := message.NewPart([]byte("hello"))
+	Stamp(part, "input.foo", 1)
+
+	msg := message.New(nil)
+	msg.Append(part)
+
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+
+	msgs, res := cProc.ProcessMessageWithContext(ctx, msg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, ctx, inner.ctx)
+
+	e, ok := NewEvent(msgs[0].Get(0), "output.bar", nil)
+	require.True(t, ok)
+	assert.Equal(t, []string{"input.foo", "processor.foo"}, e.Chain)
+
+	unwrapped, component := UnwrapProcessor(proc)
+	assert.Equal(t, inner, unwrapped)
+	assert.Equal(t, "processor.foo", component)
+
+	unwrapped, component = UnwrapProcessor(inner)
+	assert.Equal(t, inner, unwrapped)
+	assert.Equal(t, "", component)
+}
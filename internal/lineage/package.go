@@ -0,0 +1,4 @@
+// Package lineage implements optional provenance tracking of messages, where
+// each message is assigned a stable identifier and the chain of components
+// that it passes through is recorded within reserved metadata fields.
+package lineage
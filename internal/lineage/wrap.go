@@ -0,0 +1,164 @@
+package lineage
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+type inputWrapper struct {
+	types.Input
+
+	component string
+	seq       int64
+	tranChan  chan types.Transaction
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+// WrapInput returns an input that stamps the lineage of each message part it
+// produces with the provided component name.
+func WrapInput(component string, in types.Input) types.Input {
+	w := &inputWrapper{
+		Input:     in,
+		component: component,
+		tranChan:  make(chan types.Transaction),
+		closeChan: make(chan struct{}),
+	}
+	go w.loop()
+	return w
+}
+
+func (w *inputWrapper) loop() {
+	defer close(w.tranChan)
+	for t := range w.Input.TransactionChan() {
+		_ = t.Payload.Iter(func(i int, p types.Part) error {
+			Stamp(p, w.component, atomic.AddInt64(&w.seq, 1))
+			return nil
+		})
+		select {
+		case w.tranChan <- t:
+		case <-w.closeChan:
+			return
+		}
+	}
+}
+
+func (w *inputWrapper) TransactionChan() <-chan types.Transaction {
+	return w.tranChan
+}
+
+func (w *inputWrapper) CloseAsync() {
+	w.closeOnce.Do(func() {
+		close(w.closeChan)
+	})
+	w.Input.CloseAsync()
+}
+
+//------------------------------------------------------------------------------
+
+type processorWrapper struct {
+	types.Processor
+
+	component string
+}
+
+// WrapProcessor returns a processor that appends the provided component name
+// to the lineage of each message part it outputs.
+func WrapProcessor(component string, proc types.Processor) types.Processor {
+	return &processorWrapper{
+		Processor: proc,
+		component: component,
+	}
+}
+
+// UnwrapProcessor returns the processor wrapped by WrapProcessor along with the
+// component name it appends. If the processor is not wrapped it is returned
+// as is with an empty component name.
+func UnwrapProcessor(proc types.Processor) (types.Processor, string) {
+	if w, ok := proc.(*processorWrapper); ok {
+		return w.Processor, w.component
+	}
+	return proc, ""
+}
+
+func (w *processorWrapper) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return w.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext is the context aware version of ProcessMessage,
+// the context is passed to the wrapped processor if it supports it.
+func (w *processorWrapper) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
+	var msgs []types.Message
+	var res types.Response
+	if cProc, ok := w.Processor.(interface {
+		ProcessMessageWithContext(context.Context, types.Message) ([]types.Message, types.Response)
+	}); ok {
+		msgs, res = cProc.ProcessMessageWithContext(ctx, msg)
+	} else {
+		msgs, res = w.Processor.ProcessMessage(msg)
+	}
+	for _, m := range msgs {
+		_ = m.Iter(func(i int, p types.Part) error {
+			Append(p, w.component)
+			return nil
+		})
+	}
+	return msgs, res
+}
+
+//------------------------------------------------------------------------------
+
+type outputWrapper struct {
+	types.Output
+
+	component string
+	emit      func([]Event)
+	tranChan  chan types.Transaction
+}
+
+// WrapOutput returns an output that, once each message has been either
+// delivered or rejected by the provided output, calls a function with lineage
+// events for each of its parts. The response is forwarded to the source once
+// the function returns.
+func WrapOutput(component string, out types.Output, emit func([]Event)) types.Output {
+	return &outputWrapper{
+		Output:    out,
+		component: component,
+		emit:      emit,
+		tranChan:  make(chan types.Transaction),
+	}
+}
+
+func (w *outputWrapper) Consume(ts <-chan types.Transaction) error {
+	if err := w.Output.Consume(w.tranChan); err != nil {
+		return err
+	}
+	go w.loop(ts)
+	return nil
+}
+
+func (w *outputWrapper) loop(ts <-chan types.Transaction) {
+	defer close(w.tranChan)
+	for t := range ts {
+		resChan := make(chan types.Response)
+		w.tranChan <- types.NewTransaction(t.Payload, resChan)
+		go func(t types.Transaction) {
+			res := <-resChan
+			var events []Event
+			_ = t.Payload.Iter(func(i int, p types.Part) error {
+				if e, ok := NewEvent(p, w.component, res.Error()); ok {
+					events = append(events, e)
+				}
+				return nil
+			})
+			if len(events) > 0 {
+				w.emit(events)
+			}
+			t.ResponseChan <- res
+		}(t)
+	}
+}
@@ -0,0 +1,183 @@
+// Package protobuf provides a process wide registry of protobuf message
+// descriptors, which are loaded either from .proto files or from compiled
+// descriptor sets, and utilities for converting between protobuf messages and
+// generic structures.
+package protobuf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	// SA1019 Ignore deprecation warning until we can switch to "google.golang.org/protobuf/types/dynamicpb"
+	// nolint:staticcheck
+	"github.com/golang/protobuf/proto"
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+//------------------------------------------------------------------------------
+
+// LoadImportPaths walks a list of directories and parses all .proto files
+// found within them. If the list is empty the current directory is used.
+func LoadImportPaths(importPaths []string) ([]*desc.FileDescriptor, error) {
+	var parser protoparse.Parser
+	if len(importPaths) == 0 {
+		importPaths = []string{"."}
+	} else {
+		parser.ImportPaths = importPaths
+	}
+
+	var files []string
+	for _, importPath := range importPaths {
+		if err := filepath.Walk(importPath, func(path string, info os.FileInfo, ferr error) error {
+			if ferr != nil || info.IsDir() {
+				return ferr
+			}
+			if filepath.Ext(info.Name()) == ".proto" {
+				rPath, ferr := filepath.Rel(importPath, path)
+				if ferr != nil {
+					return fmt.Errorf("failed to get relative path: %v", ferr)
+				}
+				files = append(files, rPath)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	fds, err := parser.ParseFiles(files...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse .proto file: %v", err)
+	}
+	if len(fds) == 0 {
+		return nil, fmt.Errorf("no .proto files were found in the paths '%v'", importPaths)
+	}
+	return fds, nil
+}
+
+// LoadDescriptorSet reads a file containing a serialized FileDescriptorSet, as
+// produced by protoc with the flag --descriptor_set_out.
+func LoadDescriptorSet(path string) ([]*desc.FileDescriptor, error) {
+	setBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set dpb.FileDescriptorSet
+	if err := proto.Unmarshal(setBytes, &set); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal descriptor set '%v': %v", path, err)
+	}
+	fdMap, err := desc.CreateFileDescriptorsFromSet(&set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create descriptors from set '%v': %v", path, err)
+	}
+	fds := make([]*desc.FileDescriptor, 0, len(fdMap))
+	for _, fd := range fdMap {
+		fds = append(fds, fd)
+	}
+	return fds, nil
+}
+
+// FindMessage attempts to find a message descriptor by its fully qualified
+// name within a list of file descriptors.
+func FindMessage(fds []*desc.FileDescriptor, message string) *desc.MessageDescriptor {
+	for _, d := range fds {
+		if msg := d.FindMessage(message); msg != nil {
+			return msg
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+var (
+	registryMut sync.RWMutex
+	registry    = map[string]*desc.MessageDescriptor{}
+)
+
+func registerMessages(msgs []*desc.MessageDescriptor) {
+	for _, m := range msgs {
+		registry[m.GetFullyQualifiedName()] = m
+		registerMessages(m.GetNestedMessageTypes())
+	}
+}
+
+// Register adds the messages of file descriptors to the process wide registry.
+// Messages that have already been registered with the same fully qualified
+// name are replaced.
+func Register(fds ...*desc.FileDescriptor) {
+	registryMut.Lock()
+	defer registryMut.Unlock()
+	for _, fd := range fds {
+		registerMessages(fd.GetMessageTypes())
+	}
+}
+
+// Registered attempts to find a message descriptor from the process wide
+// registry by its fully qualified name.
+func Registered(message string) (*desc.MessageDescriptor, error) {
+	if len(message) == 0 {
+		return nil, errors.New("message name must not be empty")
+	}
+	registryMut.RLock()
+	defer registryMut.RUnlock()
+	if m, exists := registry[message]; exists {
+		return m, nil
+	}
+	return nil, fmt.Errorf("protobuf message '%v' has not been registered", message)
+}
+
+//------------------------------------------------------------------------------
+
+// ToValue decodes a serialized protobuf message into a generic structure
+// following the JSON mapping of protobuf.
+func ToValue(m *desc.MessageDescriptor, data []byte) (interface{}, error) {
+	msg := dynamic.NewMessage(m)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+
+	jsonBytes, err := msg.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal protobuf message: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(jsonBytes))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// FromValue serializes a generic structure as a protobuf message following the
+// JSON mapping of protobuf.
+func FromValue(m *desc.MessageDescriptor, v interface{}) ([]byte, error) {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := dynamic.NewMessage(m)
+	if err := msg.UnmarshalJSON(jsonBytes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON message: %w", err)
+	}
+
+	data, err := msg.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal protobuf message: %w", err)
+	}
+	return data, nil
+}
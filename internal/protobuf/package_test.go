@@ -0,0 +1,83 @@
+package protobuf
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	// nolint:staticcheck
+	"github.com/golang/protobuf/proto"
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtobufRegistry(t *testing.T) {
+	_, err := Registered("testing.Nope")
+	require.EqualError(t, err, "protobuf message 'testing.Nope' has not been registered")
+
+	fds, err := LoadImportPaths([]string{"../../config/test/protobuf/schema"})
+	require.NoError(t, err)
+	Register(fds...)
+
+	m, err := Registered("testing.Person")
+	require.NoError(t, err)
+
+	data, err := FromValue(m, map[string]interface{}{
+		"firstName": "john",
+		"lastName":  "oates",
+		"age":       10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x0a, 0x04, 0x6a, 0x6f, 0x68, 0x6e, 0x12, 0x05, 0x6f, 0x61, 0x74, 0x65, 0x73, 0x20, 0x0a}, data)
+
+	v, err := ToValue(m, data)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"firstName": "john",
+		"lastName":  "oates",
+		"age":       json.Number("10"),
+	}, v)
+}
+
+func TestProtobufDescriptorSet(t *testing.T) {
+	fds, err := LoadImportPaths([]string{"../../config/test/protobuf/schema"})
+	require.NoError(t, err)
+
+	var set dpb.FileDescriptorSet
+	seen := map[string]struct{}{}
+	var add func(fd *desc.FileDescriptor)
+	add = func(fd *desc.FileDescriptor) {
+		if _, exists := seen[fd.GetName()]; exists {
+			return
+		}
+		seen[fd.GetName()] = struct{}{}
+		for _, dep := range fd.GetDependencies() {
+			add(dep)
+		}
+		set.File = append(set.File, fd.AsFileDescriptorProto())
+	}
+	for _, fd := range fds {
+		add(fd)
+	}
+
+	setBytes, err := proto.Marshal(&set)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "benthos_protobuf_test")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	setPath := filepath.Join(dir, "all.pb")
+	require.NoError(t, ioutil.WriteFile(setPath, setBytes, 0644))
+
+	loaded, err := LoadDescriptorSet(setPath)
+	require.NoError(t, err)
+	assert.NotNil(t, FindMessage(loaded, "testing.Person"))
+	assert.Nil(t, FindMessage(loaded, "testing.Nope"))
+}
@@ -0,0 +1,201 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry describes a single message written to an archived file. The position
+// is the index of the message amongst the non-empty messages of the file.
+type Entry struct {
+	Path      string    `json:"path"`
+	Position  int       `json:"position"`
+	Timestamp time.Time `json:"timestamp"`
+	Key       string    `json:"key,omitempty"`
+}
+
+// ReadIndex reads each entry of an index file in the order that they were
+// written.
+func ReadIndex(indexPath string, fn func(e Entry) error) error {
+	f, err := os.Open(indexPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("failed to parse index line %v: %w", line, err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+//------------------------------------------------------------------------------
+
+// IndexWriter appends entries to an index file as messages are written to
+// archived files, tracking the position of the next message of each file.
+//
+// This component is safe to use concurrently across goroutines.
+type IndexWriter struct {
+	mut       sync.Mutex
+	file      *os.File
+	positions map[string]int
+}
+
+// NewIndexWriter opens an index file for appending, creating it if it does not
+// yet exist. Positions of archived files are resumed from existing entries.
+func NewIndexWriter(indexPath string) (*IndexWriter, error) {
+	positions := map[string]int{}
+	err := ReadIndex(indexPath, func(e Entry) error {
+		if e.Position >= positions[e.Path] {
+			positions[e.Path] = e.Position + 1
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(indexPath), os.FileMode(0777)); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(indexPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, os.FileMode(0666))
+	if err != nil {
+		return nil, err
+	}
+	return &IndexWriter{
+		file:      file,
+		positions: positions,
+	}, nil
+}
+
+// Reset the position of an archived file, which should be called when the file
+// is truncated.
+func (w *IndexWriter) Reset(path string) {
+	w.mut.Lock()
+	delete(w.positions, path)
+	w.mut.Unlock()
+}
+
+// Add an entry for the next message written to an archived file.
+func (w *IndexWriter) Add(path string, ts time.Time, key string) error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	b, err := json.Marshal(Entry{
+		Path:      path,
+		Position:  w.positions[path],
+		Timestamp: ts.UTC(),
+		Key:       key,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = w.file.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	w.positions[path]++
+	return nil
+}
+
+// Close the index file.
+func (w *IndexWriter) Close() error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	return w.file.Close()
+}
+
+//------------------------------------------------------------------------------
+
+// Filter describes a range of archived messages to select. Zero values of each
+// field are unbounded. Start bounds are inclusive and end bounds are exclusive.
+type Filter struct {
+	StartTime time.Time
+	EndTime   time.Time
+	StartKey  string
+	EndKey    string
+}
+
+// Matches returns whether an entry is within the range of the filter.
+func (f Filter) Matches(e Entry) bool {
+	if !f.StartTime.IsZero() && e.Timestamp.Before(f.StartTime) {
+		return false
+	}
+	if !f.EndTime.IsZero() && !e.Timestamp.Before(f.EndTime) {
+		return false
+	}
+	if f.StartKey != "" && e.Key < f.StartKey {
+		return false
+	}
+	if f.EndKey != "" && e.Key >= f.EndKey {
+		return false
+	}
+	return true
+}
+
+// Selection is the set of entries of a single archived file that match a
+// filter, keyed by their position, and the highest of those positions.
+type Selection struct {
+	Path    string
+	Entries map[int]Entry
+	Last    int
+}
+
+// Select reads an index file and returns the entries matching a filter grouped
+// by archived file, in the order that the files were first written. When an
+// index contains multiple entries for the same position of a file, which
+// happens when the file was truncated, the most recent entry is used.
+func Select(indexPath string, filter Filter) ([]*Selection, error) {
+	var selections []*Selection
+	byPath := map[string]*Selection{}
+
+	if err := ReadIndex(indexPath, func(e Entry) error {
+		sel, exists := byPath[e.Path]
+		if !exists {
+			sel = &Selection{Path: e.Path, Entries: map[int]Entry{}}
+			byPath[e.Path] = sel
+			selections = append(selections, sel)
+		}
+		if filter.Matches(e) {
+			sel.Entries[e.Position] = e
+		} else {
+			delete(sel.Entries, e.Position)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	filtered := selections[:0]
+	for _, sel := range selections {
+		if len(sel.Entries) == 0 {
+			continue
+		}
+		for p := range sel.Entries {
+			if p > sel.Last {
+				sel.Last = p
+			}
+		}
+		filtered = append(filtered, sel)
+	}
+	return filtered, nil
+}
@@ -0,0 +1,89 @@
+package replay
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexSelect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_replay_index_test")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	indexPath := filepath.Join(dir, "index.jsonl")
+	start := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	w, err := NewIndexWriter(indexPath)
+	require.NoError(t, err)
+	for i, path := range []string{"a", "a", "b", "a", "b"} {
+		require.NoError(t, w.Add(path, start.Add(time.Duration(i)*time.Hour), ""))
+	}
+	require.NoError(t, w.Close())
+
+	// Positions are resumed when an index is reopened.
+	w, err = NewIndexWriter(indexPath)
+	require.NoError(t, err)
+	require.NoError(t, w.Add("a", start.Add(5*time.Hour), "foo"))
+	w.Reset("b")
+	require.NoError(t, w.Add("b", start.Add(6*time.Hour), "bar"))
+	require.NoError(t, w.Close())
+
+	sels, err := Select(indexPath, Filter{})
+	require.NoError(t, err)
+	require.Len(t, sels, 2)
+
+	assert.Equal(t, "a", sels[0].Path)
+	assert.Equal(t, 3, sels[0].Last)
+	assert.Len(t, sels[0].Entries, 4)
+	assert.Equal(t, "foo", sels[0].Entries[3].Key)
+
+	assert.Equal(t, "b", sels[1].Path)
+	assert.Equal(t, 1, sels[1].Last)
+	assert.Len(t, sels[1].Entries, 2)
+	assert.Equal(t, "bar", sels[1].Entries[0].Key, "truncated position replaced")
+
+	sels, err = Select(indexPath, Filter{
+		StartTime: start.Add(time.Hour),
+		EndTime:   start.Add(5 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.Len(t, sels, 2)
+
+	assert.Equal(t, "a", sels[0].Path)
+	assert.Equal(t, 2, sels[0].Last)
+	assert.Len(t, sels[0].Entries, 2)
+	assert.Contains(t, sels[0].Entries, 1)
+	assert.Contains(t, sels[0].Entries, 2)
+
+	// The original entry for position 0 of b was replaced by one outside of
+	// the range.
+	assert.Equal(t, "b", sels[1].Path)
+	assert.Len(t, sels[1].Entries, 1)
+	assert.Contains(t, sels[1].Entries, 1)
+}
+
+func TestIndexReadErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_replay_index_test")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	indexPath := filepath.Join(dir, "index.jsonl")
+	require.NoError(t, ioutil.WriteFile(indexPath, []byte("{\"path\":\"a\",\"position\":0}\nnope\n"), 0666))
+
+	_, err = Select(indexPath, Filter{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse index line 2")
+
+	_, err = NewIndexWriter(indexPath)
+	require.Error(t, err)
+}
@@ -0,0 +1,4 @@
+// Package replay implements an index of messages written to archived files,
+// which allows a requested range of those messages to be located and read back
+// into a pipeline for backfills and disaster recovery.
+package replay
@@ -0,0 +1,93 @@
+package webhdfs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	krbclient "github.com/jcmturner/gokrb5/v8/client"
+	krbconfig "github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+// KerberosDocs returns a documentation field spec for the fields of a
+// KerberosConfig.
+func KerberosDocs() docs.FieldSpec {
+	return docs.FieldAdvanced("kerberos", "Allows you to authenticate requests with Kerberos using SPNEGO. Credentials are obtained from either a keytab file or a password.").WithChildren(
+		docs.FieldCommon("enabled", "Whether to use Kerberos authentication."),
+		docs.FieldCommon("config_file", "The path of a Kerberos configuration file."),
+		docs.FieldCommon("realm", "The realm of the principal to authenticate as."),
+		docs.FieldCommon("username", "The username of the principal to authenticate as."),
+		docs.FieldCommon("keytab_file", "The path of a keytab file containing the keys of the principal."),
+		docs.FieldCommon("password", "A password of the principal to use when a keytab file is not provided."),
+		docs.FieldAdvanced("service_principal_name", "An optional service principal name of the WebHDFS server, which defaults to `HTTP/<host>` using the host of the URL."),
+	)
+}
+
+// KerberosConfig contains fields for authenticating requests with Kerberos.
+type KerberosConfig struct {
+	Enabled              bool   `json:"enabled" yaml:"enabled"`
+	ConfigFile           string `json:"config_file" yaml:"config_file"`
+	Realm                string `json:"realm" yaml:"realm"`
+	Username             string `json:"username" yaml:"username"`
+	KeytabFile           string `json:"keytab_file" yaml:"keytab_file"`
+	Password             string `json:"password" yaml:"password"`
+	ServicePrincipalName string `json:"service_principal_name" yaml:"service_principal_name"`
+}
+
+// NewKerberosConfig creates a new KerberosConfig with default values.
+func NewKerberosConfig() KerberosConfig {
+	return KerberosConfig{
+		Enabled:              false,
+		ConfigFile:           "/etc/krb5.conf",
+		Realm:                "",
+		Username:             "",
+		KeytabFile:           "",
+		Password:             "",
+		ServicePrincipalName: "",
+	}
+}
+
+type kerberosAuth struct {
+	client *krbclient.Client
+	spn    string
+}
+
+func (k KerberosConfig) login() (*kerberosAuth, error) {
+	if k.Username == "" || k.Realm == "" {
+		return nil, errors.New("kerberos authentication requires a username and realm")
+	}
+
+	conf, err := krbconfig.Load(k.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kerberos config: %w", err)
+	}
+
+	var client *krbclient.Client
+	if k.KeytabFile != "" {
+		kt, err := keytab.Load(k.KeytabFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load keytab: %w", err)
+		}
+		client = krbclient.NewWithKeytab(k.Username, k.Realm, kt, conf)
+	} else {
+		client = krbclient.NewWithPassword(k.Username, k.Realm, k.Password, conf)
+	}
+	if err := client.Login(); err != nil {
+		return nil, fmt.Errorf("failed to login to kerberos: %w", err)
+	}
+	return &kerberosAuth{
+		client: client,
+		spn:    k.ServicePrincipalName,
+	}, nil
+}
+
+func (k *kerberosAuth) sign(req *http.Request) error {
+	return spnego.SetSPNEGOHeader(k.client, req, k.spn)
+}
+
+func (k *kerberosAuth) close() {
+	k.client.Destroy()
+}
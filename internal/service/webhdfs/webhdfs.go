@@ -0,0 +1,316 @@
+package webhdfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+// Docs returns documentation field specs for the fields of a Config.
+func Docs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldCommon(
+			"url", "The base URL of the WebHDFS REST API, which is usually served by the NameNode or by an Apache Knox gateway.",
+			"http://localhost:9870/webhdfs/v1",
+			"https://knox.example.com:8443/gateway/default/webhdfs/v1",
+		),
+		docs.FieldCommon("user", "An optional user to act as when the cluster uses simple authentication."),
+		docs.FieldAdvanced("delegation_token", "An optional delegation token to authenticate requests with, which is an alternative to Kerberos authentication for secure clusters."),
+		auth.BasicAuthFieldSpec(),
+		KerberosDocs(),
+		btls.FieldSpec(),
+		docs.FieldAdvanced("timeout", "The maximum period to wait for each request to complete."),
+	}
+}
+
+// Config contains the fields required to connect to a WebHDFS REST API.
+type Config struct {
+	URL             string               `json:"url" yaml:"url"`
+	User            string               `json:"user" yaml:"user"`
+	DelegationToken string               `json:"delegation_token" yaml:"delegation_token"`
+	BasicAuth       auth.BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
+	Kerberos        KerberosConfig       `json:"kerberos" yaml:"kerberos"`
+	TLS             btls.Config          `json:"tls" yaml:"tls"`
+	Timeout         string               `json:"timeout" yaml:"timeout"`
+}
+
+// NewConfig creates a new Config with default values.
+func NewConfig() Config {
+	return Config{
+		URL:             "",
+		User:            "",
+		DelegationToken: "",
+		BasicAuth:       auth.NewBasicAuthConfig(),
+		Kerberos:        NewKerberosConfig(),
+		TLS:             btls.NewConfig(),
+		Timeout:         "30s",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// RemoteError is an error returned by the WebHDFS REST API.
+type RemoteError struct {
+	Exception     string `json:"exception"`
+	JavaClassName string `json:"javaClassName"`
+	Message       string `json:"message"`
+}
+
+// Error returns a human readable description of the error.
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Exception, e.Message)
+}
+
+// IsNotFound returns true if an error was caused by a path not existing.
+func IsNotFound(err error) bool {
+	var rErr *RemoteError
+	return errors.As(err, &rErr) && rErr.Exception == "FileNotFoundException"
+}
+
+// IsAlreadyExists returns true if an error was caused by a path existing.
+func IsAlreadyExists(err error) bool {
+	var rErr *RemoteError
+	return errors.As(err, &rErr) && rErr.Exception == "FileAlreadyExistsException"
+}
+
+func readError(res *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 64*1024))
+
+	var rErr struct {
+		RemoteException *RemoteError `json:"RemoteException"`
+	}
+	if err := json.Unmarshal(body, &rErr); err == nil && rErr.RemoteException != nil {
+		return rErr.RemoteException
+	}
+	return fmt.Errorf("unexpected response status %v: %s", res.StatusCode, bytes.TrimSpace(body))
+}
+
+//------------------------------------------------------------------------------
+
+// Client performs operations on files via the WebHDFS REST API.
+type Client struct {
+	base       *url.URL
+	user       string
+	delegation string
+	basicAuth  auth.BasicAuthConfig
+	kerberos   *kerberosAuth
+	client     *http.Client
+}
+
+// NewClient creates a client from a Config. When Kerberos authentication is
+// enabled this logs in to the KDC.
+func (c Config) NewClient() (*Client, error) {
+	base, err := url.Parse(strings.TrimSuffix(c.URL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("url '%v' must have a scheme of http or https", c.URL)
+	}
+
+	var timeout time.Duration
+	if c.Timeout != "" {
+		if timeout, err = time.ParseDuration(c.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse timeout: %w", err)
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.TLS.Enabled {
+		if transport.TLSClientConfig, err = c.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+
+	client := &Client{
+		base:       base,
+		user:       c.User,
+		delegation: c.DelegationToken,
+		basicAuth:  c.BasicAuth,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+			// Redirects are followed manually as they carry request bodies.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+	if c.Kerberos.Enabled {
+		if client.kerberos, err = c.Kerberos.login(); err != nil {
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// Close releases any resources held by the client.
+func (c *Client) Close() {
+	if c.kerberos != nil {
+		c.kerberos.close()
+	}
+}
+
+func (c *Client) opURL(path, op string, params url.Values) string {
+	u := *c.base
+	u.Path = c.base.Path + "/" + strings.TrimPrefix(path, "/")
+
+	q := url.Values{}
+	for k, v := range params {
+		q[k] = v
+	}
+	q.Set("op", op)
+	if c.delegation != "" {
+		q.Set("delegation", c.delegation)
+	} else if c.user != "" {
+		q.Set("user.name", c.user)
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// do performs a request, authentication is only added to requests targeting
+// the host of the configured URL, as requests redirected to DataNodes are
+// authorised by a token within the redirect location instead.
+func (c *Client) do(ctx context.Context, method, u string, body []byte) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, u, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	if req.URL.Host == c.base.Host {
+		if err := c.basicAuth.Sign(req); err != nil {
+			return nil, err
+		}
+		if c.kerberos != nil {
+			if err := c.kerberos.sign(req); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return c.client.Do(req)
+}
+
+func (c *Client) call(ctx context.Context, method, path, op string, params url.Values, out interface{}) error {
+	res, err := c.do(ctx, method, c.opURL(path, op, params), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return readError(res)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %v response: %w", op, err)
+	}
+	return nil
+}
+
+// upload performs the two step process of writing data, where the NameNode
+// first redirects the request to a DataNode that receives the data.
+func (c *Client) upload(ctx context.Context, method, path, op string, params url.Values, data []byte) error {
+	res, err := c.do(ctx, method, c.opURL(path, op, params), nil)
+	if err != nil {
+		return err
+	}
+
+	switch res.StatusCode {
+	case http.StatusTemporaryRedirect, http.StatusFound, http.StatusSeeOther:
+		res.Body.Close()
+	default:
+		defer res.Body.Close()
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return readError(res)
+		}
+		return fmt.Errorf("expected %v request to be redirected, received status %v", op, res.StatusCode)
+	}
+
+	location, err := res.Location()
+	if err != nil {
+		return fmt.Errorf("failed to read %v redirect location: %w", op, err)
+	}
+
+	if res, err = c.do(ctx, method, location.String(), data); err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return readError(res)
+	}
+	return nil
+}
+
+// Mkdirs creates a directory and any parents that do not exist.
+func (c *Client) Mkdirs(ctx context.Context, path string) error {
+	var res struct {
+		Boolean bool `json:"boolean"`
+	}
+	if err := c.call(ctx, http.MethodPut, path, "MKDIRS", nil, &res); err != nil {
+		return err
+	}
+	if !res.Boolean {
+		return fmt.Errorf("failed to create directory %v", path)
+	}
+	return nil
+}
+
+// Create writes data to a file, replacing its contents if overwrite is true.
+// Any parent directories that do not exist are created.
+func (c *Client) Create(ctx context.Context, path string, data []byte, overwrite bool) error {
+	return c.upload(ctx, http.MethodPut, path, "CREATE", url.Values{
+		"overwrite": []string{fmt.Sprintf("%v", overwrite)},
+	}, data)
+}
+
+// Append writes data to the end of an existing file.
+func (c *Client) Append(ctx context.Context, path string, data []byte) error {
+	return c.upload(ctx, http.MethodPost, path, "APPEND", nil, data)
+}
+
+// Rename moves a file to a new path, replacing any existing file.
+func (c *Client) Rename(ctx context.Context, path, destination string) error {
+	var res struct {
+		Boolean bool `json:"boolean"`
+	}
+	if err := c.call(ctx, http.MethodPut, path, "RENAME", url.Values{
+		"destination":   []string{destination},
+		"renameoptions": []string{"OVERWRITE"},
+	}, &res); err != nil {
+		return err
+	}
+	if !res.Boolean {
+		return fmt.Errorf("failed to rename %v to %v", path, destination)
+	}
+	return nil
+}
+
+// Delete removes a file.
+func (c *Client) Delete(ctx context.Context, path string) error {
+	return c.call(ctx, http.MethodDelete, path, "DELETE", nil, nil)
+}
@@ -0,0 +1,204 @@
+package webhdfs
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHDFS emulates the NameNode and DataNode endpoints of the WebHDFS REST
+// API, with files held in memory.
+type fakeHDFS struct {
+	mut   sync.Mutex
+	files map[string]string
+	dirs  map[string]struct{}
+
+	nameNode *httptest.Server
+	dataNode *httptest.Server
+
+	// Records the authorization header and query of each request.
+	nameNodeAuth  []string
+	dataNodeAuth  []string
+	nameNodeQuery []string
+}
+
+func newFakeHDFS(t *testing.T) *fakeHDFS {
+	t.Helper()
+
+	f := &fakeHDFS{
+		files: map[string]string{},
+		dirs:  map[string]struct{}{},
+	}
+	f.nameNode = httptest.NewServer(http.HandlerFunc(f.handleNameNode))
+	f.dataNode = httptest.NewServer(http.HandlerFunc(f.handleDataNode))
+	t.Cleanup(func() {
+		f.nameNode.Close()
+		f.dataNode.Close()
+	})
+	return f
+}
+
+func remoteErr(w http.ResponseWriter, status int, exception, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"RemoteException":{"exception":%q,"javaClassName":"java.io.%v","message":%q}}`, exception, exception, msg)
+}
+
+func (f *fakeHDFS) handleNameNode(w http.ResponseWriter, r *http.Request) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	f.nameNodeAuth = append(f.nameNodeAuth, r.Header.Get("Authorization"))
+	f.nameNodeQuery = append(f.nameNodeQuery, r.URL.RawQuery)
+
+	path := strings.TrimPrefix(r.URL.Path, "/webhdfs/v1")
+	q := r.URL.Query()
+
+	switch q.Get("op") {
+	case "MKDIRS":
+		f.dirs[path] = struct{}{}
+		fmt.Fprint(w, `{"boolean":true}`)
+	case "CREATE":
+		if _, exists := f.files[path]; exists && q.Get("overwrite") != "true" {
+			remoteErr(w, http.StatusForbidden, "FileAlreadyExistsException", path+" already exists")
+			return
+		}
+		http.Redirect(w, r, f.dataNode.URL+"/webhdfs/v1"+path+"?op=CREATE&namenoderpcaddress=foo", http.StatusTemporaryRedirect)
+	case "APPEND":
+		if _, exists := f.files[path]; !exists {
+			remoteErr(w, http.StatusNotFound, "FileNotFoundException", "File does not exist: "+path)
+			return
+		}
+		http.Redirect(w, r, f.dataNode.URL+"/webhdfs/v1"+path+"?op=APPEND&namenoderpcaddress=foo", http.StatusTemporaryRedirect)
+	case "RENAME":
+		content, exists := f.files[path]
+		if !exists {
+			fmt.Fprint(w, `{"boolean":false}`)
+			return
+		}
+		delete(f.files, path)
+		f.files[q.Get("destination")] = content
+		fmt.Fprint(w, `{"boolean":true}`)
+	case "DELETE":
+		delete(f.files, path)
+		fmt.Fprint(w, `{"boolean":true}`)
+	default:
+		remoteErr(w, http.StatusBadRequest, "IllegalArgumentException", "Invalid value for webhdfs parameter \"op\"")
+	}
+}
+
+func (f *fakeHDFS) handleDataNode(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	f.dataNodeAuth = append(f.dataNodeAuth, r.Header.Get("Authorization"))
+
+	path := strings.TrimPrefix(r.URL.Path, "/webhdfs/v1")
+	switch r.URL.Query().Get("op") {
+	case "CREATE":
+		f.files[path] = string(body)
+		w.WriteHeader(http.StatusCreated)
+	case "APPEND":
+		f.files[path] += string(body)
+	}
+}
+
+func TestClientOperations(t *testing.T) {
+	f := newFakeHDFS(t)
+
+	conf := NewConfig()
+	conf.URL = f.nameNode.URL + "/webhdfs/v1/"
+	conf.User = "benthos"
+	conf.BasicAuth.Enabled = true
+	conf.BasicAuth.Username = "foo"
+	conf.BasicAuth.Password = "bar"
+
+	c, err := conf.NewClient()
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx := context.Background()
+
+	require.NoError(t, c.Mkdirs(ctx, "/data"))
+	require.NoError(t, c.Create(ctx, "/data/foo.txt", []byte("hello"), true))
+	require.NoError(t, c.Append(ctx, "/data/foo.txt", []byte(" world")))
+	require.NoError(t, c.Create(ctx, "/data/bar.txt.tmp", []byte("bar"), true))
+	require.NoError(t, c.Rename(ctx, "/data/bar.txt.tmp", "/data/bar.txt"))
+
+	err = c.Create(ctx, "/data/foo.txt", []byte("nope"), false)
+	require.Error(t, err)
+	assert.True(t, IsAlreadyExists(err))
+
+	err = c.Append(ctx, "/data/baz.txt", []byte("baz"))
+	require.Error(t, err)
+	assert.True(t, IsNotFound(err))
+	assert.EqualError(t, err, "FileNotFoundException: File does not exist: /data/baz.txt")
+
+	err = c.Rename(ctx, "/data/baz.txt", "/data/buz.txt")
+	require.EqualError(t, err, "failed to rename /data/baz.txt to /data/buz.txt")
+
+	require.NoError(t, c.Delete(ctx, "/data/bar.txt"))
+
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	assert.Equal(t, map[string]string{
+		"/data/foo.txt": "hello world",
+	}, f.files)
+	assert.Contains(t, f.dirs, "/data")
+
+	// Credentials are only sent to the host of the configured URL.
+	for _, a := range f.nameNodeAuth {
+		assert.Equal(t, "Basic Zm9vOmJhcg==", a)
+	}
+	for _, a := range f.dataNodeAuth {
+		assert.Equal(t, "", a)
+	}
+	assert.Contains(t, f.nameNodeQuery, "destination=%2Fdata%2Fbar.txt&op=RENAME&renameoptions=OVERWRITE&user.name=benthos")
+}
+
+func TestClientDelegationToken(t *testing.T) {
+	f := newFakeHDFS(t)
+
+	conf := NewConfig()
+	conf.URL = f.nameNode.URL + "/webhdfs/v1"
+	conf.User = "benthos"
+	conf.DelegationToken = "footoken"
+
+	c, err := conf.NewClient()
+	require.NoError(t, err)
+	defer c.Close()
+
+	require.NoError(t, c.Mkdirs(context.Background(), "/data"))
+
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	assert.Equal(t, []string{"delegation=footoken&op=MKDIRS"}, f.nameNodeQuery)
+}
+
+func TestClientBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.URL = "localhost:9870/webhdfs/v1"
+	_, err := conf.NewClient()
+	require.Error(t, err)
+
+	conf.URL = "http://localhost:9870/webhdfs/v1"
+	conf.Timeout = "nope"
+	_, err = conf.NewClient()
+	require.Error(t, err)
+
+	conf.Timeout = "1s"
+	conf.Kerberos.Enabled = true
+	_, err = conf.NewClient()
+	require.EqualError(t, err, "kerberos authentication requires a username and realm")
+}
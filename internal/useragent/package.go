@@ -0,0 +1,207 @@
+// Package useragent provides parsing of HTTP user agent strings into the
+// browser, operating system and device that they describe, following the
+// structure and naming conventions of the ua-parser project.
+package useragent
+
+import (
+	"regexp"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// Version describes the family and version of a browser or operating system.
+type Version struct {
+	Family string
+	Major  string
+	Minor  string
+	Patch  string
+}
+
+// Device describes the device of a user agent.
+type Device struct {
+	Family string
+	Brand  string
+	Model  string
+}
+
+// Client describes the browser, operating system and device of a user agent.
+type Client struct {
+	Browser Version
+	OS      Version
+	Device  Device
+}
+
+// Object returns the client as a generic structure.
+func (c Client) Object() map[string]interface{} {
+	version := func(v Version) map[string]interface{} {
+		return map[string]interface{}{
+			"family": v.Family,
+			"major":  v.Major,
+			"minor":  v.Minor,
+			"patch":  v.Patch,
+		}
+	}
+	return map[string]interface{}{
+		"browser": version(c.Browser),
+		"os":      version(c.OS),
+		"device": map[string]interface{}{
+			"family": c.Device.Family,
+			"brand":  c.Device.Brand,
+			"model":  c.Device.Model,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// versionRule matches a user agent and extracts a family and version from it.
+// When the family is empty it is taken from the first capture group, and the
+// remaining groups are the major, minor and patch versions. Otherwise the
+// family is fixed and all groups are versions. Fixed versions take precedence
+// over capture groups.
+type versionRule struct {
+	re     *regexp.Regexp
+	family string
+	major  string
+	minor  string
+}
+
+func (r versionRule) match(ua string) (Version, bool) {
+	groups := r.re.FindStringSubmatch(ua)
+	if groups == nil {
+		return Version{}, false
+	}
+	groups = groups[1:]
+
+	v := Version{Family: r.family}
+	if v.Family == "" {
+		v.Family, groups = groups[0], groups[1:]
+	}
+	for i, dst := range []*string{&v.Major, &v.Minor, &v.Patch} {
+		if i < len(groups) {
+			*dst = groups[i]
+		}
+	}
+	if r.major != "" {
+		v.Major, v.Minor = r.major, r.minor
+	}
+	return v, true
+}
+
+func rule(expr, family string) versionRule {
+	return versionRule{re: regexp.MustCompile(expr), family: family}
+}
+
+func fixedRule(expr, family, major, minor string) versionRule {
+	return versionRule{re: regexp.MustCompile(expr), family: family, major: major, minor: minor}
+}
+
+const versionExpr = `(\d+)(?:\.(\d+))?(?:\.(\d+))?`
+
+var botRule = rule(`(Googlebot|bingbot|Baiduspider|YandexBot|DuckDuckBot|Applebot|AhrefsBot|SemrushBot|Twitterbot|facebookexternalhit)(?:/`+versionExpr+`)?`, "")
+
+// browserRules are attempted in order, where the first to match is used.
+var browserRules = []versionRule{
+	botRule,
+	rule(`Yahoo! Slurp`, "Yahoo! Slurp"),
+	rule(`EdgiOS/`+versionExpr, "Edge Mobile"),
+	rule(`EdgA/`+versionExpr, "Edge Mobile"),
+	rule(`Edge?/`+versionExpr, "Edge"),
+	rule(`Opera Mini/`+versionExpr, "Opera Mini"),
+	rule(`OPR/`+versionExpr, "Opera"),
+	rule(`Opera/.+Version/`+versionExpr, "Opera"),
+	rule(`SamsungBrowser/`+versionExpr, "Samsung Internet"),
+	rule(`YaBrowser/`+versionExpr, "Yandex Browser"),
+	rule(`FxiOS/`+versionExpr, "Firefox iOS"),
+	rule(`Mobile.*Firefox/`+versionExpr, "Firefox Mobile"),
+	rule(`Firefox/`+versionExpr, "Firefox"),
+	rule(`CriOS/`+versionExpr, "Chrome Mobile iOS"),
+	rule(`; wv\).+Chrome/`+versionExpr, "Chrome Mobile WebView"),
+	rule(`Chrome/`+versionExpr+`[\d.]* Mobile`, "Chrome Mobile"),
+	rule(`Chromium/`+versionExpr, "Chromium"),
+	rule(`Chrome/`+versionExpr, "Chrome"),
+	rule(`MSIE (\d+)\.(\d+)`, "IE"),
+	rule(`Trident/.+rv:(\d+)\.(\d+)`, "IE"),
+	rule(`Version/`+versionExpr+` Mobile/\S+ Safari/`, "Mobile Safari"),
+	rule(`(?:iPhone|iPad|iPod).+AppleWebKit`, "Mobile Safari UI/WKWebView"),
+	rule(`Version/`+versionExpr+`.* Safari/`, "Safari"),
+	rule(`curl/`+versionExpr, "curl"),
+	rule(`Wget/`+versionExpr, "Wget"),
+	rule(`python-requests/`+versionExpr, "Python Requests"),
+	rule(`Go-http-client/`+versionExpr, "Go-http-client"),
+	rule(`okhttp/`+versionExpr, "okhttp"),
+	rule(`PostmanRuntime/`+versionExpr, "PostmanRuntime"),
+}
+
+// osRules are attempted in order, where the first to match is used.
+var osRules = []versionRule{
+	rule(`Windows Phone (?:OS )?`+versionExpr, "Windows Phone"),
+	fixedRule(`Windows NT 10\.0`, "Windows", "10", ""),
+	fixedRule(`Windows NT 6\.3`, "Windows", "8", "1"),
+	fixedRule(`Windows NT 6\.2`, "Windows", "8", ""),
+	fixedRule(`Windows NT 6\.1`, "Windows", "7", ""),
+	fixedRule(`Windows NT 6\.0`, "Windows", "Vista", ""),
+	fixedRule(`Windows NT 5\.[12]`, "Windows", "XP", ""),
+	rule(`Windows`, "Windows"),
+	rule(`Android[ /]`+versionExpr, "Android"),
+	rule(`Android`, "Android"),
+	rule(`(?:iPhone|CPU) OS (\d+)_(\d+)(?:_(\d+))?`, "iOS"),
+	rule(`(?:iPhone|iPad|iPod)`, "iOS"),
+	rule(`Mac OS X (\d+)[_.](\d+)(?:[_.](\d+))?`, "Mac OS X"),
+	rule(`CrOS \S+ `+versionExpr, "Chrome OS"),
+	rule(`Ubuntu`, "Ubuntu"),
+	rule(`Fedora`, "Fedora"),
+	rule(`Linux`, "Linux"),
+}
+
+var androidModelRegexp = regexp.MustCompile(`Android[^;)]*; (?:[a-zA-Z]{2}[-_][a-zA-Z]{2}; )?([^;)]+?)(?: Build/[^;)]+)?[;)]`)
+
+func parseDevice(ua string) Device {
+	switch {
+	case botRule.re.MatchString(ua) || strings.Contains(ua, "Yahoo! Slurp"):
+		return Device{Family: "Spider", Brand: "Spider", Model: "Desktop"}
+	case strings.Contains(ua, "iPhone"):
+		return Device{Family: "iPhone", Brand: "Apple", Model: "iPhone"}
+	case strings.Contains(ua, "iPad"):
+		return Device{Family: "iPad", Brand: "Apple", Model: "iPad"}
+	case strings.Contains(ua, "iPod"):
+		return Device{Family: "iPod", Brand: "Apple", Model: "iPod"}
+	case strings.Contains(ua, "Macintosh"):
+		return Device{Family: "Mac", Brand: "Apple", Model: "Mac"}
+	}
+	if groups := androidModelRegexp.FindStringSubmatch(ua); groups != nil {
+		model := strings.TrimSpace(groups[1])
+		switch {
+		case strings.HasPrefix(model, "SM-") || strings.HasPrefix(model, "GT-"):
+			return Device{Family: "Samsung " + model, Brand: "Samsung", Model: model}
+		case strings.HasPrefix(model, "Pixel"):
+			return Device{Family: model, Brand: "Google", Model: model}
+		}
+		return Device{Family: model, Brand: "Generic_Android", Model: model}
+	}
+	return Device{Family: "Other"}
+}
+
+// Parse a user agent string into the browser, operating system and device that
+// it describes. Components that are not recognised have the family "Other".
+func Parse(ua string) Client {
+	c := Client{
+		Browser: Version{Family: "Other"},
+		OS:      Version{Family: "Other"},
+		Device:  parseDevice(ua),
+	}
+	for _, r := range browserRules {
+		if v, ok := r.match(ua); ok {
+			c.Browser = v
+			break
+		}
+	}
+	for _, r := range osRules {
+		if v, ok := r.match(ua); ok {
+			c.OS = v
+			break
+		}
+	}
+	return c
+}
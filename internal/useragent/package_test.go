@@ -0,0 +1,119 @@
+package useragent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		ua      string
+		browser Version
+		os      Version
+		device  Device
+	}{
+		{
+			name:    "chrome windows",
+			ua:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/89.0.4389.90 Safari/537.36",
+			browser: Version{Family: "Chrome", Major: "89", Minor: "0", Patch: "4389"},
+			os:      Version{Family: "Windows", Major: "10"},
+			device:  Device{Family: "Other"},
+		},
+		{
+			name:    "edge windows",
+			ua:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/89.0.4389.90 Safari/537.36 Edg/89.0.774.57",
+			browser: Version{Family: "Edge", Major: "89", Minor: "0", Patch: "774"},
+			os:      Version{Family: "Windows", Major: "10"},
+			device:  Device{Family: "Other"},
+		},
+		{
+			name:    "firefox linux",
+			ua:      "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:86.0) Gecko/20100101 Firefox/86.0",
+			browser: Version{Family: "Firefox", Major: "86", Minor: "0"},
+			os:      Version{Family: "Ubuntu"},
+			device:  Device{Family: "Other"},
+		},
+		{
+			name:    "safari mac",
+			ua:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.0.3 Safari/605.1.15",
+			browser: Version{Family: "Safari", Major: "14", Minor: "0", Patch: "3"},
+			os:      Version{Family: "Mac OS X", Major: "10", Minor: "15", Patch: "7"},
+			device:  Device{Family: "Mac", Brand: "Apple", Model: "Mac"},
+		},
+		{
+			name:    "mobile safari iphone",
+			ua:      "Mozilla/5.0 (iPhone; CPU iPhone OS 14_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.0.3 Mobile/15E148 Safari/604.1",
+			browser: Version{Family: "Mobile Safari", Major: "14", Minor: "0", Patch: "3"},
+			os:      Version{Family: "iOS", Major: "14", Minor: "4"},
+			device:  Device{Family: "iPhone", Brand: "Apple", Model: "iPhone"},
+		},
+		{
+			name:    "chrome ios ipad",
+			ua:      "Mozilla/5.0 (iPad; CPU OS 14_4_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/89.0.4389.100 Mobile/15E148 Safari/604.1",
+			browser: Version{Family: "Chrome Mobile iOS", Major: "89", Minor: "0", Patch: "4389"},
+			os:      Version{Family: "iOS", Major: "14", Minor: "4", Patch: "1"},
+			device:  Device{Family: "iPad", Brand: "Apple", Model: "iPad"},
+		},
+		{
+			name:    "chrome android pixel",
+			ua:      "Mozilla/5.0 (Linux; Android 11; Pixel 5) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/89.0.4389.105 Mobile Safari/537.36",
+			browser: Version{Family: "Chrome Mobile", Major: "89", Minor: "0", Patch: "4389"},
+			os:      Version{Family: "Android", Major: "11"},
+			device:  Device{Family: "Pixel 5", Brand: "Google", Model: "Pixel 5"},
+		},
+		{
+			name:    "samsung internet",
+			ua:      "Mozilla/5.0 (Linux; Android 10; SM-G981B Build/QP1A.190711.020) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/13.2 Chrome/83.0.4103.106 Mobile Safari/537.36",
+			browser: Version{Family: "Samsung Internet", Major: "13", Minor: "2"},
+			os:      Version{Family: "Android", Major: "10"},
+			device:  Device{Family: "Samsung SM-G981B", Brand: "Samsung", Model: "SM-G981B"},
+		},
+		{
+			name:    "webview android",
+			ua:      "Mozilla/5.0 (Linux; Android 9; Nokia 7.2; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/88.0.4324.181 Mobile Safari/537.36",
+			browser: Version{Family: "Chrome Mobile WebView", Major: "88", Minor: "0", Patch: "4324"},
+			os:      Version{Family: "Android", Major: "9"},
+			device:  Device{Family: "Nokia 7.2", Brand: "Generic_Android", Model: "Nokia 7.2"},
+		},
+		{
+			name:    "internet explorer",
+			ua:      "Mozilla/5.0 (Windows NT 6.1; WOW64; Trident/7.0; rv:11.0) like Gecko",
+			browser: Version{Family: "IE", Major: "11", Minor: "0"},
+			os:      Version{Family: "Windows", Major: "7"},
+			device:  Device{Family: "Other"},
+		},
+		{
+			name:    "googlebot",
+			ua:      "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			browser: Version{Family: "Googlebot", Major: "2", Minor: "1"},
+			os:      Version{Family: "Other"},
+			device:  Device{Family: "Spider", Brand: "Spider", Model: "Desktop"},
+		},
+		{
+			name:    "curl",
+			ua:      "curl/7.68.0",
+			browser: Version{Family: "curl", Major: "7", Minor: "68", Patch: "0"},
+			os:      Version{Family: "Other"},
+			device:  Device{Family: "Other"},
+		},
+		{
+			name:    "unknown",
+			ua:      "nope",
+			browser: Version{Family: "Other"},
+			os:      Version{Family: "Other"},
+			device:  Device{Family: "Other"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			c := Parse(test.ua)
+			assert.Equal(t, test.browser, c.Browser)
+			assert.Equal(t, test.os, c.OS)
+			assert.Equal(t, test.device, c.Device)
+		})
+	}
+}
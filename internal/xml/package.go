@@ -5,17 +5,25 @@
 package xml
 
 import (
+	"bytes"
 	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
 
 	"github.com/clbanning/mxj/v2"
 	"golang.org/x/net/html/charset"
 )
 
+// attrPrefix is the prefix given to attribute keys by mxj.
+const attrPrefix = "-"
+
 func init() {
 	dec := xml.NewDecoder(nil)
 	dec.Strict = false
 	dec.CharsetReader = charset.NewReaderLabel
 	mxj.CustomDecoder = dec
+	mxj.XMLEscapeChars(true)
 }
 
 // ToMap parses a byte slice as XML and returns a generic structure that can be
@@ -27,3 +35,142 @@ func ToMap(xmlBytes []byte) (map[string]interface{}, error) {
 	}
 	return map[string]interface{}(root), nil
 }
+
+//------------------------------------------------------------------------------
+
+// DecodeOptions customise the structure returned by ToMapWithOptions.
+type DecodeOptions struct {
+	// Cast numerical and boolean element and attribute values into their
+	// respective types rather than strings.
+	Cast bool
+
+	// AttributePrefix is prepended to the keys of attributes, when empty the
+	// default of a hyphen is used.
+	AttributePrefix string
+
+	// StripNamespaces drops namespace declarations, which would otherwise
+	// appear as attributes. Element and attribute names are always given
+	// without their namespace prefix.
+	StripNamespaces bool
+}
+
+// ToMapWithOptions parses a byte slice as XML and returns a generic structure
+// that can be serialized to JSON, customised by options.
+func ToMapWithOptions(xmlBytes []byte, opts DecodeOptions) (map[string]interface{}, error) {
+	if opts.StripNamespaces {
+		var err error
+		if xmlBytes, err = stripNamespaces(xmlBytes); err != nil {
+			return nil, err
+		}
+	}
+	root, err := mxj.NewMapXml(xmlBytes, opts.Cast)
+	if err != nil {
+		return nil, err
+	}
+	if opts.AttributePrefix == "" || opts.AttributePrefix == attrPrefix {
+		return map[string]interface{}(root), nil
+	}
+	return renameAttributes(map[string]interface{}(root), attrPrefix, opts.AttributePrefix), nil
+}
+
+// stripNamespaces rewrites a document without namespace declarations or
+// prefixes.
+func stripNamespaces(xmlBytes []byte) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(xmlBytes))
+	dec.Strict = false
+	dec.CharsetReader = charset.NewReaderLabel
+
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			attrs := make([]xml.Attr, 0, len(t.Attr))
+			for _, a := range t.Attr {
+				if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+					continue
+				}
+				attrs = append(attrs, xml.Attr{Name: xml.Name{Local: a.Name.Local}, Value: a.Value})
+			}
+			tok = xml.StartElement{Name: xml.Name{Local: t.Name.Local}, Attr: attrs}
+		case xml.EndElement:
+			tok = xml.EndElement{Name: xml.Name{Local: t.Name.Local}}
+		case xml.ProcInst:
+			// The document is rewritten as UTF-8 and so the declaration,
+			// which may specify a different encoding, is dropped.
+			if t.Target == "xml" {
+				continue
+			}
+		}
+		if err = enc.EncodeToken(tok); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renameAttributes returns a copy of a structure where the prefix of attribute
+// keys has been replaced.
+func renameAttributes(v map[string]interface{}, from, to string) map[string]interface{} {
+	res := make(map[string]interface{}, len(v))
+	for k, child := range v {
+		switch c := child.(type) {
+		case map[string]interface{}:
+			child = renameAttributes(c, from, to)
+		case []interface{}:
+			arr := make([]interface{}, len(c))
+			for i, e := range c {
+				if m, ok := e.(map[string]interface{}); ok {
+					e = renameAttributes(m, from, to)
+				}
+				arr[i] = e
+			}
+			child = arr
+		}
+		if strings.HasPrefix(k, from) {
+			k = to + k[len(from):]
+		}
+		res[k] = child
+	}
+	return res
+}
+
+//------------------------------------------------------------------------------
+
+// EncodeOptions customise the document returned by FromMap.
+type EncodeOptions struct {
+	// Indent, when not empty, results in a document where each element is on
+	// a new line and indented.
+	Indent string
+
+	// AttributePrefix identifies the keys of attributes, when empty the default
+	// of a hyphen is used.
+	AttributePrefix string
+}
+
+// FromMap serializes a generic structure as an XML document. When the structure
+// has a single key it is used as the root element, otherwise the root element
+// is named doc.
+func FromMap(v interface{}, opts EncodeOptions) ([]byte, error) {
+	root, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("expected an object")
+	}
+	if opts.AttributePrefix != "" && opts.AttributePrefix != attrPrefix {
+		root = renameAttributes(root, opts.AttributePrefix, attrPrefix)
+	}
+	if opts.Indent != "" {
+		return mxj.Map(root).XmlIndent("", opts.Indent)
+	}
+	return mxj.Map(root).Xml()
+}
@@ -0,0 +1,76 @@
+package xml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToMapWithOptions(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		opts   DecodeOptions
+		output map[string]interface{}
+	}{
+		"defaults": {
+			input: `<root xmlns="http://example.com"><item id="1">10</item></root>`,
+			output: map[string]interface{}{
+				"root": map[string]interface{}{
+					"-xmlns": "http://example.com",
+					"item": map[string]interface{}{
+						"-id":   "1",
+						"#text": "10",
+					},
+				},
+			},
+		},
+		"cast and prefix": {
+			input: `<root><item id="1">10</item><item id="2">true</item></root>`,
+			opts:  DecodeOptions{Cast: true, AttributePrefix: "@"},
+			output: map[string]interface{}{
+				"root": map[string]interface{}{
+					"item": []interface{}{
+						map[string]interface{}{"@id": float64(1), "#text": float64(10)},
+						map[string]interface{}{"@id": float64(2), "#text": true},
+					},
+				},
+			},
+		},
+		"strip namespaces": {
+			input: `<?xml version="1.0" encoding="ISO-8859-1"?><a:root xmlns="http://example.com" xmlns:a="http://example.com/a"><a:item xlink:href="foo">caf` + "\xe9" + `</a:item></a:root>`,
+			opts:  DecodeOptions{StripNamespaces: true},
+			output: map[string]interface{}{
+				"root": map[string]interface{}{
+					"item": map[string]interface{}{
+						"-href": "foo",
+						"#text": "café",
+					},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			res, err := ToMapWithOptions([]byte(test.input), test.opts)
+			require.NoError(t, err)
+			assert.Equal(t, test.output, res)
+		})
+	}
+}
+
+func TestFromMap(t *testing.T) {
+	res, err := FromMap(map[string]interface{}{
+		"root": map[string]interface{}{
+			"@lang": "en",
+			"title": "<b>Fish & Chips</b>",
+		},
+	}, EncodeOptions{AttributePrefix: "@"})
+	require.NoError(t, err)
+	assert.Equal(t, `<root lang="en"><title>&lt;b&gt;Fish &amp; Chips&lt;/b&gt;</title></root>`, string(res))
+
+	_, err = FromMap([]interface{}{"foo"}, EncodeOptions{})
+	require.Error(t, err)
+}
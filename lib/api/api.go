@@ -14,6 +14,7 @@ import (
 
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
 	"github.com/gorilla/mux"
 	yaml "gopkg.in/yaml.v3"
 )
@@ -22,13 +23,15 @@ import (
 
 // Config contains the configuration fields for the Benthos API.
 type Config struct {
-	Address        string `json:"address" yaml:"address"`
-	Enabled        bool   `json:"enabled" yaml:"enabled"`
-	ReadTimeout    string `json:"read_timeout" yaml:"read_timeout"`
-	RootPath       string `json:"root_path" yaml:"root_path"`
-	DebugEndpoints bool   `json:"debug_endpoints" yaml:"debug_endpoints"`
-	CertFile       string `json:"cert_file" yaml:"cert_file"`
-	KeyFile        string `json:"key_file" yaml:"key_file"`
+	Address        string            `json:"address" yaml:"address"`
+	Enabled        bool              `json:"enabled" yaml:"enabled"`
+	ReadTimeout    string            `json:"read_timeout" yaml:"read_timeout"`
+	RootPath       string            `json:"root_path" yaml:"root_path"`
+	DebugEndpoints bool              `json:"debug_endpoints" yaml:"debug_endpoints"`
+	FlowMonitoring bool              `json:"flow_monitoring" yaml:"flow_monitoring"`
+	CertFile       string            `json:"cert_file" yaml:"cert_file"`
+	KeyFile        string            `json:"key_file" yaml:"key_file"`
+	Auth           auth.ServerConfig `json:"auth" yaml:"auth"`
 }
 
 // NewConfig creates a new API config with default values.
@@ -39,8 +42,10 @@ func NewConfig() Config {
 		ReadTimeout:    "5s",
 		RootPath:       "/benthos",
 		DebugEndpoints: false,
+		FlowMonitoring: false,
 		CertFile:       "",
 		KeyFile:        "",
+		Auth:           auth.NewServerConfig(),
 	}
 }
 
@@ -93,9 +98,17 @@ func New(
 ) (*Type, error) {
 	handler := mux.NewRouter()
 
+	serverAuth, err := auth.NewServerAuth(conf.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auth: %v", err)
+	}
+	for _, p := range conf.Auth.ExemptPaths {
+		serverAuth.Exempt(conf.RootPath + p)
+	}
+
 	server := &http.Server{
 		Addr:    conf.Address,
-		Handler: handler,
+		Handler: serverAuth.Wrap(handler),
 	}
 
 	if len(conf.CertFile) > 0 || len(conf.KeyFile) > 0 {
@@ -104,8 +117,23 @@ func New(
 		}
 	}
 
+	if len(conf.Auth.ClientCAFile) > 0 {
+		if len(conf.CertFile) == 0 {
+			return nil, errors.New("cert_file and key_file must be specified in order to use a client_ca_file")
+		}
+		cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load server certificate: %v", err)
+		}
+		server.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+		}
+		if err := conf.Auth.ApplyTLS(server.TLSConfig); err != nil {
+			return nil, err
+		}
+	}
+
 	if tout := conf.ReadTimeout; len(tout) > 0 {
-		var err error
 		if server.ReadTimeout, err = time.ParseDuration(tout); err != nil {
 			return nil, fmt.Errorf("failed to parse read timeout string: %v", err)
 		}
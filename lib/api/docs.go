@@ -1,6 +1,9 @@
 package api
 
-import "github.com/Jeffail/benthos/v3/internal/docs"
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+)
 
 // Spec returns a field spec for the API configuration fields.
 func Spec() docs.FieldSpecs {
@@ -9,8 +12,10 @@ func Spec() docs.FieldSpecs {
 		docs.FieldCommon("address", "The address to bind to."),
 		docs.FieldCommon("root_path", "Specifies a general prefix for all endpoints, this can help isolate the service endpoints when using a reverse proxy with other shared services. All endpoints will still be registered at the root as well as behind the prefix, e.g. with a root_path set to `/foo` the endpoint `/version` will be accessible from both `/version` and `/foo/version`."),
 		docs.FieldAdvanced("debug_endpoints", "Whether to register a few extra endpoints that can be useful for debugging performance or behavioral problems."),
+		docs.FieldAdvanced("flow_monitoring", "Whether to measure the flow of messages between the layers of each stream and serve a summary at the endpoint `/flow`. Measuring the flow adds a small overhead to the transfer of each message between layers, and allows each boundary to hold a message. When enabled the number of messages in flight is also logged whilst shutting down."),
 		docs.FieldAdvanced("cert_file", "An optional certificate file for enabling TLS."),
 		docs.FieldAdvanced("key_file", "An optional key file for enabling TLS."),
+		auth.ServerFieldSpec(),
 		docs.FieldDeprecated("read_timeout"),
 	}
 }
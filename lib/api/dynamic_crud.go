@@ -78,6 +78,9 @@ type Dynamic struct {
 	onUpdate func(id string, conf []byte) error
 	onDelete func(id string) error
 
+	// store optionally persists the configs of CRUD clients.
+	store DynamicStore
+
 	// configs is a map of the latest sanitised configs from our CRUD clients.
 	configs      map[string][]byte
 	configHashes *dynamicConfMgr
@@ -116,6 +119,21 @@ func (d *Dynamic) OnDelete(onDelete func(id string) error) {
 	d.onDelete = onDelete
 }
 
+// SetStore sets a store for persisting the configurations of components that
+// are set or removed via CRUD requests.
+func (d *Dynamic) SetStore(store DynamicStore) {
+	d.store = store
+}
+
+// Restored should be called for each component that was restored from the
+// store when the dynamic component was created, in order for CRUD requests
+// with an identical configuration to be ignored.
+func (d *Dynamic) Restored(id string, conf []byte) {
+	d.configsMut.Lock()
+	d.configHashes.Set(id, conf)
+	d.configsMut.Unlock()
+}
+
 // Stopped should be called whenever an active dynamic component has closed,
 // whether by naturally winding down or from a request.
 func (d *Dynamic) Stopped(id string) {
@@ -226,6 +244,12 @@ func (d *Dynamic) handlePOSTInput(w http.ResponseWriter, r *http.Request) error
 	d.configsMut.Lock()
 	d.configHashes.Set(id, reqBytes)
 	d.configsMut.Unlock()
+
+	if d.store != nil {
+		if err = d.store.Set(id, reqBytes); err != nil {
+			return fmt.Errorf("failed to persist config: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -241,6 +265,11 @@ func (d *Dynamic) handleDELInput(w http.ResponseWriter, r *http.Request) error {
 	delete(d.configs, id)
 	d.configsMut.Unlock()
 
+	if d.store != nil {
+		if err := d.store.Delete(id); err != nil {
+			return fmt.Errorf("failed to remove persisted config: %w", err)
+		}
+	}
 	return nil
 }
 
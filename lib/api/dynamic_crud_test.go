@@ -9,7 +9,10 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 //------------------------------------------------------------------------------
@@ -251,3 +254,51 @@ func TestDynamicListing(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestDynamicPersistence(t *testing.T) {
+	conf := NewDynamicPersistenceConfig()
+	conf.Path = t.TempDir()
+
+	store, err := NewDynamicStore(conf, "inputs", types.NoopMgr())
+	require.NoError(t, err)
+	require.NoError(t, store.Set("foo", []byte("foo conf")))
+
+	dAPI := NewDynamic()
+	r := router(dAPI)
+
+	var updates []string
+	dAPI.OnUpdate(func(id string, content []byte) error {
+		updates = append(updates, id+": "+string(content))
+		return nil
+	})
+	dAPI.OnDelete(func(id string) error {
+		return nil
+	})
+
+	dAPI.Restored("foo", []byte("foo conf"))
+	dAPI.SetStore(store)
+
+	// Setting an identical config to a restored one is ignored.
+	request, _ := http.NewRequest("POST", "/input/foo", bytes.NewReader([]byte("foo conf")))
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Empty(t, updates)
+
+	request, _ = http.NewRequest("POST", "/input/bar", bytes.NewReader([]byte("bar conf")))
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, []string{"bar: bar conf"}, updates)
+
+	request, _ = http.NewRequest("DELETE", "/input/foo", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	confs, err := store.List()
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"bar": []byte("bar conf"),
+	}, confs)
+}
@@ -0,0 +1,290 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// DynamicPersistenceConfig describes where the configurations of dynamic
+// components that are added at runtime are persisted, in order for them to be
+// restored after a restart.
+type DynamicPersistenceConfig struct {
+	Path      string `json:"path" yaml:"path"`
+	Cache     string `json:"cache" yaml:"cache"`
+	KeyPrefix string `json:"key_prefix" yaml:"key_prefix"`
+}
+
+// NewDynamicPersistenceConfig returns a DynamicPersistenceConfig with default
+// values.
+func NewDynamicPersistenceConfig() DynamicPersistenceConfig {
+	return DynamicPersistenceConfig{
+		Path:      "",
+		Cache:     "",
+		KeyPrefix: "",
+	}
+}
+
+// DynamicPersistenceFieldSpec returns a field spec for the persistence of
+// dynamic components, where kind is the plural name of the component type
+// (inputs or outputs).
+func DynamicPersistenceFieldSpec(kind string) docs.FieldSpec {
+	return docs.FieldAdvanced(
+		"persistence",
+		fmt.Sprintf("Optionally persist the configurations of %[1]v that are added, changed or removed via the REST API, so that they are restored when Benthos restarts. Persisted %[1]v are restored alongside those listed within the config, replacing any with the same label.", kind),
+	).WithChildren(
+		docs.FieldCommon("path", fmt.Sprintf("A directory to persist %v within, where each is written as a file named by its label. The directory is created if it does not already exist.", kind)),
+		docs.FieldCommon("cache", fmt.Sprintf("A [`cache` resource](/docs/components/caches/about) to persist %v within. Only one of `path` or `cache` may be set.", kind)),
+		docs.FieldAdvanced("key_prefix", fmt.Sprintf("A prefix to add to cache keys, which should be unique to this component when a cache is shared. Defaults to `dynamic_%v_` when empty.", kind)),
+	).AtVersion("3.44.0")
+}
+
+//------------------------------------------------------------------------------
+
+// DynamicStore persists the configurations of dynamic components.
+type DynamicStore interface {
+	// Set persists the configuration of a component.
+	Set(id string, conf []byte) error
+
+	// Delete removes the persisted configuration of a component.
+	Delete(id string) error
+
+	// List returns all persisted configurations by their component ids.
+	List() (map[string][]byte, error)
+}
+
+// NewDynamicStore creates a DynamicStore from a persistence config, where kind
+// is the plural name of the component type (inputs or outputs). If neither a
+// path nor a cache is configured then nil is returned.
+func NewDynamicStore(conf DynamicPersistenceConfig, kind string, mgr types.Manager) (DynamicStore, error) {
+	if conf.Path != "" && conf.Cache != "" {
+		return nil, errors.New("only one of path or cache may be set for persistence")
+	}
+	if conf.Path != "" {
+		if err := os.MkdirAll(conf.Path, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create persistence directory: %w", err)
+		}
+		return &dynamicDirStore{dir: conf.Path}, nil
+	}
+	if conf.Cache != "" {
+		if _, err := mgr.GetCache(conf.Cache); err != nil {
+			return nil, fmt.Errorf("failed to obtain persistence cache resource '%v': %v", conf.Cache, err)
+		}
+		prefix := conf.KeyPrefix
+		if prefix == "" {
+			prefix = "dynamic_" + kind + "_"
+		}
+		return &dynamicCacheStore{
+			mgr:    mgr,
+			name:   conf.Cache,
+			prefix: prefix,
+		}, nil
+	}
+	return nil, nil
+}
+
+//------------------------------------------------------------------------------
+
+const dynamicFileExt = ".conf"
+
+type dynamicDirStore struct {
+	dir string
+	mut sync.Mutex
+}
+
+func (d *dynamicDirStore) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return "", fmt.Errorf("id '%v' cannot be persisted as a file", id)
+	}
+	return filepath.Join(d.dir, id+dynamicFileExt), nil
+}
+
+func (d *dynamicDirStore) Set(id string, conf []byte) error {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+
+	p, err := d.path(id)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a partially written config is
+	// never restored.
+	tmpPath := p + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, conf, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, p)
+}
+
+func (d *dynamicDirStore) Delete(id string) error {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+
+	p, err := d.path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (d *dynamicDirStore) List() (map[string][]byte, error) {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+
+	infos, err := ioutil.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	confs := map[string][]byte{}
+	for _, info := range infos {
+		if info.IsDir() || filepath.Ext(info.Name()) != dynamicFileExt {
+			continue
+		}
+		conf, err := ioutil.ReadFile(filepath.Join(d.dir, info.Name()))
+		if err != nil {
+			return nil, err
+		}
+		confs[strings.TrimSuffix(info.Name(), dynamicFileExt)] = conf
+	}
+	return confs, nil
+}
+
+//------------------------------------------------------------------------------
+
+// dynamicCacheStore persists configs within a cache resource. Since caches
+// cannot be listed the ids of persisted configs are stored as a JSON array
+// under a separate key.
+type dynamicCacheStore struct {
+	mgr    types.Manager
+	name   string
+	prefix string
+	mut    sync.Mutex
+}
+
+func (d *dynamicCacheStore) idsKey() string {
+	return d.prefix + "ids"
+}
+
+func (d *dynamicCacheStore) confKey(id string) string {
+	return d.prefix + "config_" + id
+}
+
+func (d *dynamicCacheStore) getIDs(c types.Cache) ([]string, error) {
+	idsBytes, err := c.Get(d.idsKey())
+	if err != nil {
+		if errors.Is(err, types.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var ids []string
+	if err := json.Unmarshal(idsBytes, &ids); err != nil {
+		return nil, fmt.Errorf("failed to parse persisted ids: %w", err)
+	}
+	return ids, nil
+}
+
+func (d *dynamicCacheStore) setIDs(c types.Cache, ids []string) error {
+	sort.Strings(ids)
+	idsBytes, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	return c.Set(d.idsKey(), idsBytes)
+}
+
+func (d *dynamicCacheStore) Set(id string, conf []byte) error {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+
+	c, err := d.mgr.GetCache(d.name)
+	if err != nil {
+		return err
+	}
+	if err = c.Set(d.confKey(id), conf); err != nil {
+		return err
+	}
+
+	ids, err := d.getIDs(c)
+	if err != nil {
+		return err
+	}
+	for _, existing := range ids {
+		if existing == id {
+			return nil
+		}
+	}
+	return d.setIDs(c, append(ids, id))
+}
+
+func (d *dynamicCacheStore) Delete(id string) error {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+
+	c, err := d.mgr.GetCache(d.name)
+	if err != nil {
+		return err
+	}
+
+	ids, err := d.getIDs(c)
+	if err != nil {
+		return err
+	}
+	newIDs := make([]string, 0, len(ids))
+	for _, existing := range ids {
+		if existing != id {
+			newIDs = append(newIDs, existing)
+		}
+	}
+	if err = d.setIDs(c, newIDs); err != nil {
+		return err
+	}
+	if err = c.Delete(d.confKey(id)); err != nil && !errors.Is(err, types.ErrKeyNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (d *dynamicCacheStore) List() (map[string][]byte, error) {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+
+	c, err := d.mgr.GetCache(d.name)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := d.getIDs(c)
+	if err != nil {
+		return nil, err
+	}
+
+	confs := map[string][]byte{}
+	for _, id := range ids {
+		conf, err := c.Get(d.confKey(id))
+		if err != nil {
+			if errors.Is(err, types.ErrKeyNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		confs[id] = conf
+	}
+	return confs, nil
+}
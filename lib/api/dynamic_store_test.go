@@ -0,0 +1,142 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mapCache map[string][]byte
+
+func (m mapCache) Get(key string) ([]byte, error) {
+	v, exists := m[key]
+	if !exists {
+		return nil, types.ErrKeyNotFound
+	}
+	return v, nil
+}
+
+func (m mapCache) Set(key string, value []byte) error {
+	m[key] = value
+	return nil
+}
+
+func (m mapCache) SetMulti(items map[string][]byte) error {
+	for k, v := range items {
+		m[k] = v
+	}
+	return nil
+}
+
+func (m mapCache) Add(key string, value []byte) error {
+	if _, exists := m[key]; exists {
+		return types.ErrKeyAlreadyExists
+	}
+	m[key] = value
+	return nil
+}
+
+func (m mapCache) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m mapCache) CloseAsync() {}
+
+func (m mapCache) WaitForClose(time.Duration) error {
+	return nil
+}
+
+type cacheMgr struct {
+	types.Manager
+	caches map[string]types.Cache
+}
+
+func (c cacheMgr) GetCache(name string) (types.Cache, error) {
+	if cache, exists := c.caches[name]; exists {
+		return cache, nil
+	}
+	return nil, types.ErrCacheNotFound
+}
+
+func testDynamicStore(t *testing.T, store DynamicStore) {
+	t.Helper()
+
+	confs, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, confs)
+
+	require.NoError(t, store.Set("foo", []byte("foo conf")))
+	require.NoError(t, store.Set("bar", []byte("bar conf")))
+	require.NoError(t, store.Set("foo", []byte("foo conf 2")))
+
+	confs, err = store.List()
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"foo": []byte("foo conf 2"),
+		"bar": []byte("bar conf"),
+	}, confs)
+
+	require.NoError(t, store.Delete("foo"))
+	require.NoError(t, store.Delete("baz"))
+
+	confs, err = store.List()
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"bar": []byte("bar conf"),
+	}, confs)
+}
+
+func TestDynamicDirStore(t *testing.T) {
+	conf := NewDynamicPersistenceConfig()
+	conf.Path = t.TempDir()
+
+	store, err := NewDynamicStore(conf, "inputs", types.NoopMgr())
+	require.NoError(t, err)
+
+	testDynamicStore(t, store)
+
+	require.Error(t, store.Set("../foo", []byte("nope")))
+}
+
+func TestDynamicCacheStore(t *testing.T) {
+	cache := mapCache{}
+	mgr := cacheMgr{
+		Manager: types.NoopMgr(),
+		caches: map[string]types.Cache{
+			"foocache": cache,
+		},
+	}
+
+	conf := NewDynamicPersistenceConfig()
+	conf.Cache = "foocache"
+
+	store, err := NewDynamicStore(conf, "inputs", mgr)
+	require.NoError(t, err)
+
+	testDynamicStore(t, store)
+
+	assert.Equal(t, `["bar"]`, string(cache["dynamic_inputs_ids"]))
+	assert.Equal(t, "bar conf", string(cache["dynamic_inputs_config_bar"]))
+
+	conf.Cache = "barcache"
+	_, err = NewDynamicStore(conf, "inputs", mgr)
+	require.Error(t, err)
+}
+
+func TestDynamicStoreConfigErrors(t *testing.T) {
+	conf := NewDynamicPersistenceConfig()
+
+	store, err := NewDynamicStore(conf, "inputs", types.NoopMgr())
+	require.NoError(t, err)
+	assert.Nil(t, store)
+
+	conf.Path = t.TempDir()
+	conf.Cache = "foocache"
+
+	_, err = NewDynamicStore(conf, "inputs", types.NoopMgr())
+	require.EqualError(t, err, "only one of path or cache may be set for persistence")
+}
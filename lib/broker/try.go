@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
@@ -100,6 +101,32 @@ func (t *Try) Connected() bool {
 
 //------------------------------------------------------------------------------
 
+type tryErrorKeyType int
+
+const tryErrorKey tryErrorKeyType = iota
+
+// withTryError returns a shallow copy of a message where each part has the
+// error returned by the previous output attached to its context.
+func withTryError(msg types.Message, err error) types.Message {
+	newMsg := message.New(nil)
+	msg.Iter(func(i int, p types.Part) error {
+		ctx := context.WithValue(message.GetContext(p), tryErrorKey, err)
+		newMsg.Append(message.WithContext(ctx, p.Copy()))
+		return nil
+	})
+	return newMsg
+}
+
+// TryError returns the error that caused a message part to be routed to a
+// fallback output of a Try broker, or nil if the part was not routed by a Try
+// broker.
+func TryError(p types.Part) error {
+	err, _ := message.GetContext(p).Value(tryErrorKey).(error)
+	return err
+}
+
+//------------------------------------------------------------------------------
+
 // loop is an internal loop that brokers incoming messages to many outputs.
 func (t *Try) loop() {
 	var (
@@ -164,7 +191,7 @@ func (t *Try) loop() {
 
 				if i < len(t.outputTsChans) {
 					select {
-					case t.outputTsChans[i] <- types.NewTransaction(tran.Payload, rChan):
+					case t.outputTsChans[i] <- types.NewTransaction(withTryError(tran.Payload, res.Error()), rChan):
 					case <-t.ctx.Done():
 						return
 					}
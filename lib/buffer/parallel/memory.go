@@ -3,6 +3,7 @@ package parallel
 import (
 	"sync"
 
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
@@ -50,11 +51,7 @@ func (m *Memory) NextMessage() (types.Message, AckFunc, error) {
 	m.messages[0] = nil
 	m.messages = m.messages[1:]
 
-	messageSize := 0
-	msg.Iter(func(i int, b types.Part) error {
-		messageSize += len(b.Get())
-		return nil
-	})
+	messageSize := message.GetSize(msg)
 	m.pendingBytes += messageSize
 
 	m.cond.Broadcast()
@@ -83,11 +80,7 @@ func (m *Memory) NextMessage() (types.Message, AckFunc, error) {
 
 // PushMessage adds a new message to the stack. Returns the backlog in bytes.
 func (m *Memory) PushMessage(msg types.Message) (int, error) {
-	extraBytes := 0
-	msg.Iter(func(i int, b types.Part) error {
-		extraBytes += len(b.Get())
-		return nil
-	})
+	extraBytes := message.GetSize(msg)
 
 	if extraBytes > m.cap {
 		return 0, types.ErrMessageTooLarge
@@ -26,20 +26,22 @@ type Type struct {
 	Metrics                metrics.Config `json:"metrics" yaml:"metrics"`
 	Tracer                 tracer.Config  `json:"tracer" yaml:"tracer"`
 	SystemCloseTimeout     string         `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	JSONPreserveKeyOrder   bool           `json:"json_preserve_key_order" yaml:"json_preserve_key_order"`
 	Tests                  interface{}    `json:"tests,omitempty" yaml:"tests,omitempty"`
 }
 
 // New returns a new configuration with default values.
 func New() Type {
 	return Type{
-		HTTP:               api.NewConfig(),
-		Config:             stream.NewConfig(),
-		ResourceConfig:     manager.NewResourceConfig(),
-		Logger:             log.NewConfig(),
-		Metrics:            metrics.NewConfig(),
-		Tracer:             tracer.NewConfig(),
-		SystemCloseTimeout: "20s",
-		Tests:              nil,
+		HTTP:                 api.NewConfig(),
+		Config:               stream.NewConfig(),
+		ResourceConfig:       manager.NewResourceConfig(),
+		Logger:               log.NewConfig(),
+		Metrics:              metrics.NewConfig(),
+		Tracer:               tracer.NewConfig(),
+		SystemCloseTimeout:   "20s",
+		JSONPreserveKeyOrder: false,
+		Tests:                nil,
 	}
 }
 
@@ -47,17 +49,18 @@ func New() Type {
 //
 // TODO: V4 Remove this
 type SanitisedConfig struct {
-	HTTP               interface{} `json:"http" yaml:"http"`
-	Input              interface{} `json:"input" yaml:"input"`
-	Buffer             interface{} `json:"buffer" yaml:"buffer"`
-	Pipeline           interface{} `json:"pipeline" yaml:"pipeline"`
-	Output             interface{} `json:"output" yaml:"output"`
-	Manager            interface{} `json:"resources" yaml:"resources"`
-	Logger             interface{} `json:"logger" yaml:"logger"`
-	Metrics            interface{} `json:"metrics" yaml:"metrics"`
-	Tracer             interface{} `json:"tracer" yaml:"tracer"`
-	SystemCloseTimeout interface{} `json:"shutdown_timeout" yaml:"shutdown_timeout"`
-	Tests              interface{} `json:"tests,omitempty" yaml:"tests,omitempty"`
+	HTTP                 interface{} `json:"http" yaml:"http"`
+	Input                interface{} `json:"input" yaml:"input"`
+	Buffer               interface{} `json:"buffer" yaml:"buffer"`
+	Pipeline             interface{} `json:"pipeline" yaml:"pipeline"`
+	Output               interface{} `json:"output" yaml:"output"`
+	Manager              interface{} `json:"resources" yaml:"resources"`
+	Logger               interface{} `json:"logger" yaml:"logger"`
+	Metrics              interface{} `json:"metrics" yaml:"metrics"`
+	Tracer               interface{} `json:"tracer" yaml:"tracer"`
+	SystemCloseTimeout   interface{} `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	JSONPreserveKeyOrder interface{} `json:"json_preserve_key_order" yaml:"json_preserve_key_order"`
+	Tests                interface{} `json:"tests,omitempty" yaml:"tests,omitempty"`
 }
 
 // Sanitised is deprecated and will be removed in V4.
@@ -123,17 +126,18 @@ func (c Type) sanitised(skipDeprecated bool) (*SanitisedConfig, error) {
 	}
 
 	return &SanitisedConfig{
-		HTTP:               c.HTTP,
-		Input:              inConf,
-		Buffer:             bufConf,
-		Pipeline:           pipeConf,
-		Output:             outConf,
-		Manager:            mgrConf,
-		Logger:             logConf,
-		Metrics:            metConf,
-		Tracer:             tracConf,
-		SystemCloseTimeout: c.SystemCloseTimeout,
-		Tests:              c.Tests,
+		HTTP:                 c.HTTP,
+		Input:                inConf,
+		Buffer:               bufConf,
+		Pipeline:             pipeConf,
+		Output:               outConf,
+		Manager:              mgrConf,
+		Logger:               logConf,
+		Metrics:              metConf,
+		Tracer:               tracConf,
+		SystemCloseTimeout:   c.SystemCloseTimeout,
+		JSONPreserveKeyOrder: c.JSONPreserveKeyOrder,
+		Tests:                c.Tests,
 	}, nil
 }
 
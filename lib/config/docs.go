@@ -15,6 +15,15 @@ func Spec() docs.FieldSpecs {
 		docs.FieldCommon("buffer", "An optional buffer to store messages during transit.").HasType(docs.FieldBuffer),
 		docs.FieldCommon("pipeline", "Describes optional processing pipelines used for mutating messages.").WithChildren(
 			docs.FieldCommon("threads", "The number of threads to execute processing pipelines across."),
+			docs.FieldAdvanced("autoscaling", "Allows the number of processing threads to be scaled automatically between a minimum and maximum based on how saturated the current threads are and the CPU utilisation of the process. Saturation is measured as the time messages spend waiting to be dispatched to a thread rather than the depth of a queue, as pipelines do not buffer messages, and threads that are removed finish their in-flight message before closing. When enabled the field `threads` is ignored.").WithChildren(
+				docs.FieldCommon("enabled", "Whether processing threads should be scaled automatically."),
+				docs.FieldCommon("min_threads", "The minimum number of processing threads to run."),
+				docs.FieldCommon("max_threads", "The maximum number of processing threads to run. If set to zero or below the number of logical CPUs is used, or `min_threads` if that is greater."),
+				docs.FieldAdvanced("check_interval", "The period between checks of thread saturation, at most one thread is added or removed per check.", "1s", "500ms"),
+			),
+			docs.FieldAdvanced("max_message_size", "An optional maximum size in bytes of message parts entering the pipeline, including the keys and values of their metadata. Parts that exceed this size skip the processors of the pipeline and are flagged with an error, keeping their position within the batch unless the processors change the number of messages or parts, in which case they continue as a separate batch, so that they can be handled using [error handling patterns](/docs/configuration/error_handling) such as routing them to a dead letter queue. In order to prevent large records from being read into memory at all use the `max_message_size` field of inputs that support it. Set to zero in order to disable the limit."),
+			docs.FieldAdvanced("processing_timeout", "An optional maximum period of time that a message may spend within the processors of the pipeline. When exceeded the processors are abandoned and the original message is flagged with a timeout error, allowing it to be routed using [error handling patterns](/docs/configuration/error_handling). Processors that support cancellation, such as `http`, have their work cancelled. Processors that do not are left to return in the background, and as processors are never executed in parallel within a processing thread the next message waits for them within its own timeout, being flagged with a timeout error without processing if they have not returned in time.", "5s", "1m"),
+			docs.FieldAdvanced("fuse_bloblang", "Whether runs of consecutive `bloblang` processors should be fused into a single processor that executes each mapping across an entire batch before the next begins, without constructing intermediate messages or serializing structured documents between mappings. This is an optimisation that changes how the processors of the pipeline are executed, and is therefore disabled by default."),
 			docs.FieldCommon("processors", "A list of processors to apply to messages.").Array().HasType(docs.FieldProcessor),
 		),
 		docs.FieldCommon("output", "An output to sink messages to.").HasType(docs.FieldOutput),
@@ -26,7 +35,8 @@ func Spec() docs.FieldSpecs {
 		docs.FieldCommon("logger", "Describes how operational logs should be emitted.").WithChildren(log.Spec()...),
 		docs.FieldCommon("metrics", "A mechanism for exporting metrics.").HasType(docs.FieldMetrics),
 		docs.FieldCommon("tracer", "A mechanism for exporting traces.").HasType(docs.FieldTracer),
-		docs.FieldCommon("shutdown_timeout", "The maximum period of time to wait for a clean shutdown. If this time is exceeded Benthos will forcefully close."),
+		docs.FieldCommon("shutdown_timeout", "The maximum period of time to wait for a clean shutdown, during which inputs are closed and in-flight messages are drained through the pipeline and outputs. If this time is exceeded Benthos will forcefully close."),
+		docs.FieldAdvanced("json_preserve_key_order", "Whether the key order of parsed JSON documents is preserved when they are serialized, including documents mapped with Bloblang. Keys added to an object are written after its original keys in alphabetical order. This can also be enabled by setting the environment variable `BENTHOS_JSON_PRESERVE_ORDER` to `true`."),
 		docs.FieldCommon("tests", "Optional unit tests for the config, to be run with the `benthos test` subcommand."),
 	}...)
 
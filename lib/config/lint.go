@@ -11,6 +11,17 @@ import (
 // Lint attempts to report errors within a user config. Returns a slice of lint
 // results.
 func Lint(rawBytes []byte, _ Type) ([]string, error) {
+	return lintAtLevel(rawBytes, docs.LintError)
+}
+
+// LintWarnings attempts to report warnings within a user config, such as the
+// usage of deprecated interpolation functions or Bloblang functions and
+// methods. Returns a slice of lint results.
+func LintWarnings(rawBytes []byte) ([]string, error) {
+	return lintAtLevel(rawBytes, docs.LintWarning)
+}
+
+func lintAtLevel(rawBytes []byte, level docs.LintLevel) ([]string, error) {
 	if bytes.HasPrefix(rawBytes, []byte("# BENTHOS LINT DISABLE")) {
 		return nil, nil
 	}
@@ -29,7 +40,7 @@ func Lint(rawBytes []byte, _ Type) ([]string, error) {
 
 	var lintStrs []string
 	for _, lint := range Spec().LintNode(docs.NewLintContext(), rawNode.Content[0]) {
-		if lint.Level == docs.LintError {
+		if lint.Level == level {
 			lintStrs = append(lintStrs, fmt.Sprintf("line %v: %v", lint.Line, lint.What))
 		}
 	}
@@ -109,3 +109,33 @@ tests:
 }
 
 //------------------------------------------------------------------------------
+
+func TestConfigLintWarnings(t *testing.T) {
+	conf := `pipeline:
+  processors:
+    - bloblang: |
+        root.id = uuid_v4()
+        root.ts = timestamp("15:04")
+    - log:
+        message: '${!json_field:foo}'
+`
+
+	lints, err := config.LintWarnings([]byte(conf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{
+		"line 3: function `timestamp` is deprecated, use `now` instead",
+		"line 7: interpolation function `json_field:foo` uses a deprecated syntax, use a Bloblang query instead",
+	}
+	if !reflect.DeepEqual(exp, lints) {
+		t.Errorf("Wrong lint results: %v != %v", lints, exp)
+	}
+
+	if lints, err = config.Lint([]byte(conf), config.New()); err != nil {
+		t.Fatal(err)
+	}
+	if len(lints) > 0 {
+		t.Errorf("Unexpected lint errors: %v", lints)
+	}
+}
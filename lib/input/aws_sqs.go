@@ -51,9 +51,15 @@ This input adds the following metadata fields to each message:
 - sqs_message_id
 - sqs_receipt_handle
 - sqs_approximate_receive_count
+- sqs_message_group_id
+- sqs_message_deduplication_id
+- sqs_sequence_number
 - All message attributes
 ` + "```" + `
 
+The fields ` + "`sqs_message_group_id`, `sqs_message_deduplication_id` and `sqs_sequence_number`" + `
+are only set for messages consumed from FIFO queues.
+
 You can access these metadata fields using
 [function interpolation](/docs/configuration/interpolation#metadata).`,
 		FieldSpecs: append(docs.FieldSpecs{
@@ -344,6 +350,15 @@ func addSQSMetadata(p types.Part, sqsMsg *sqs.Message) {
 	if rCountStr := sqsMsg.Attributes["ApproximateReceiveCount"]; rCountStr != nil {
 		meta.Set("sqs_approximate_receive_count", *rCountStr)
 	}
+	if groupID := sqsMsg.Attributes["MessageGroupId"]; groupID != nil {
+		meta.Set("sqs_message_group_id", *groupID)
+	}
+	if dedupeID := sqsMsg.Attributes["MessageDeduplicationId"]; dedupeID != nil {
+		meta.Set("sqs_message_deduplication_id", *dedupeID)
+	}
+	if seqNum := sqsMsg.Attributes["SequenceNumber"]; seqNum != nil {
+		meta.Set("sqs_sequence_number", *seqNum)
+	}
 	for k, v := range sqsMsg.MessageAttributes {
 		if v.StringValue != nil {
 			meta.Set(k, *v.StringValue)
@@ -198,172 +198,202 @@ var Constructors = map[string]TypeSpec{}
 
 // String constants representing each input type.
 const (
-	TypeAMQP              = "amqp"
-	TypeAMQP09            = "amqp_0_9"
-	TypeAMQP1             = "amqp_1"
-	TypeAWSKinesis        = "aws_kinesis"
-	TypeAWSS3             = "aws_s3"
-	TypeAWSSQS            = "aws_sqs"
-	TypeAzureBlobStorage  = "azure_blob_storage"
-	TypeAzureQueueStorage = "azure_queue_storage"
-	TypeBloblang          = "bloblang"
-	TypeBroker            = "broker"
-	TypeCSVFile           = "csv"
-	TypeDynamic           = "dynamic"
-	TypeFile              = "file"
-	TypeFiles             = "files"
-	TypeGCPCloudStorage   = "gcp_cloud_storage"
-	TypeGCPPubSub         = "gcp_pubsub"
-	TypeGenerate          = "generate"
-	TypeHDFS              = "hdfs"
-	TypeHTTPClient        = "http_client"
-	TypeHTTPServer        = "http_server"
-	TypeInproc            = "inproc"
-	TypeKafka             = "kafka"
-	TypeKafkaBalanced     = "kafka_balanced"
-	TypeKinesis           = "kinesis"
-	TypeKinesisBalanced   = "kinesis_balanced"
-	TypeMQTT              = "mqtt"
-	TypeNanomsg           = "nanomsg"
-	TypeNATS              = "nats"
-	TypeNATSStream        = "nats_stream"
-	TypeNSQ               = "nsq"
-	TypePulsar            = "pulsar"
-	TypeReadUntil         = "read_until"
-	TypeRedisList         = "redis_list"
-	TypeRedisPubSub       = "redis_pubsub"
-	TypeRedisStreams      = "redis_streams"
-	TypeResource          = "resource"
-	TypeS3                = "s3"
-	TypeSequence          = "sequence"
-	TypeSFTP              = "sftp"
-	TypeSocket            = "socket"
-	TypeSocketServer      = "socket_server"
-	TypeSQS               = "sqs"
-	TypeSTDIN             = "stdin"
-	TypeSubprocess        = "subprocess"
-	TypeTCP               = "tcp"
-	TypeTCPServer         = "tcp_server"
-	TypeUDPServer         = "udp_server"
-	TypeWebsocket         = "websocket"
-	TypeZMQ4              = "zmq4"
+	TypeAMQP                = "amqp"
+	TypeAMQP09              = "amqp_0_9"
+	TypeAMQP1               = "amqp_1"
+	TypeAWSKinesis          = "aws_kinesis"
+	TypeAWSS3               = "aws_s3"
+	TypeAWSSQS              = "aws_sqs"
+	TypeAzureBlobStorage    = "azure_blob_storage"
+	TypeAzureQueueStorage   = "azure_queue_storage"
+	TypeBloblang            = "bloblang"
+	TypeBroker              = "broker"
+	TypeContainerLogs       = "container_logs"
+	TypeCSVFile             = "csv"
+	TypeDiscord             = "discord"
+	TypeDynamic             = "dynamic"
+	TypeFile                = "file"
+	TypeFileEvents          = "file_events"
+	TypeFiles               = "files"
+	TypeGCPCloudStorage     = "gcp_cloud_storage"
+	TypeGCPPubSub           = "gcp_pubsub"
+	TypeGenerate            = "generate"
+	TypeGraphQLSubscription = "graphql_subscription"
+	TypeHDFS                = "hdfs"
+	TypeHTTPClient          = "http_client"
+	TypeHTTPServer          = "http_server"
+	TypeInproc              = "inproc"
+	TypeInprocTopic         = "inproc_topic"
+	TypeKafka               = "kafka"
+	TypeKafkaBalanced       = "kafka_balanced"
+	TypeKinesis             = "kinesis"
+	TypeKinesisBalanced     = "kinesis_balanced"
+	TypeMQTT                = "mqtt"
+	TypeNanomsg             = "nanomsg"
+	TypeNATS                = "nats"
+	TypeNATSStream          = "nats_stream"
+	TypeNDJSONFiles         = "ndjson_files"
+	TypeNSQ                 = "nsq"
+	TypePulsar              = "pulsar"
+	TypeReadUntil           = "read_until"
+	TypeRedisList           = "redis_list"
+	TypeRedisPubSub         = "redis_pubsub"
+	TypeRedisStreams        = "redis_streams"
+	TypeReplay              = "replay"
+	TypeResource            = "resource"
+	TypeS3                  = "s3"
+	TypeSequence            = "sequence"
+	TypeSFTP                = "sftp"
+	TypeSlackEvents         = "slack_events"
+	TypeSocket              = "socket"
+	TypeSocketServer        = "socket_server"
+	TypeSQS                 = "sqs"
+	TypeSTDIN               = "stdin"
+	TypeSubprocess          = "subprocess"
+	TypeTCP                 = "tcp"
+	TypeTCPServer           = "tcp_server"
+	TypeTelegram            = "telegram"
+	TypeUDPServer           = "udp_server"
+	TypeWebhook             = "webhook"
+	TypeWebsocket           = "websocket"
+	TypeZMQ4                = "zmq4"
 )
 
 //------------------------------------------------------------------------------
 
 // Config is the all encompassing configuration struct for all input types.
 type Config struct {
-	Label             string                       `json:"label" yaml:"label"`
-	Type              string                       `json:"type" yaml:"type"`
-	AMQP              reader.AMQPConfig            `json:"amqp" yaml:"amqp"`
-	AMQP09            reader.AMQP09Config          `json:"amqp_0_9" yaml:"amqp_0_9"`
-	AMQP1             reader.AMQP1Config           `json:"amqp_1" yaml:"amqp_1"`
-	AWSKinesis        AWSKinesisConfig             `json:"aws_kinesis" yaml:"aws_kinesis"`
-	AWSS3             AWSS3Config                  `json:"aws_s3" yaml:"aws_s3"`
-	AWSSQS            AWSSQSConfig                 `json:"aws_sqs" yaml:"aws_sqs"`
-	AzureBlobStorage  AzureBlobStorageConfig       `json:"azure_blob_storage" yaml:"azure_blob_storage"`
-	AzureQueueStorage AzureQueueStorageConfig      `json:"azure_queue_storage" yaml:"azure_queue_storage"`
-	Bloblang          BloblangConfig               `json:"bloblang" yaml:"bloblang"`
-	Broker            BrokerConfig                 `json:"broker" yaml:"broker"`
-	CSVFile           CSVFileConfig                `json:"csv" yaml:"csv"`
-	Dynamic           DynamicConfig                `json:"dynamic" yaml:"dynamic"`
-	File              FileConfig                   `json:"file" yaml:"file"`
-	Files             reader.FilesConfig           `json:"files" yaml:"files"`
-	GCPCloudStorage   GCPCloudStorageConfig        `json:"gcp_cloud_storage" yaml:"gcp_cloud_storage"`
-	GCPPubSub         reader.GCPPubSubConfig       `json:"gcp_pubsub" yaml:"gcp_pubsub"`
-	Generate          BloblangConfig               `json:"generate" yaml:"generate"`
-	HDFS              reader.HDFSConfig            `json:"hdfs" yaml:"hdfs"`
-	HTTPClient        HTTPClientConfig             `json:"http_client" yaml:"http_client"`
-	HTTPServer        HTTPServerConfig             `json:"http_server" yaml:"http_server"`
-	Inproc            InprocConfig                 `json:"inproc" yaml:"inproc"`
-	Kafka             reader.KafkaConfig           `json:"kafka" yaml:"kafka"`
-	KafkaBalanced     reader.KafkaBalancedConfig   `json:"kafka_balanced" yaml:"kafka_balanced"`
-	Kinesis           reader.KinesisConfig         `json:"kinesis" yaml:"kinesis"`
-	KinesisBalanced   reader.KinesisBalancedConfig `json:"kinesis_balanced" yaml:"kinesis_balanced"`
-	MQTT              reader.MQTTConfig            `json:"mqtt" yaml:"mqtt"`
-	Nanomsg           reader.ScaleProtoConfig      `json:"nanomsg" yaml:"nanomsg"`
-	NATS              reader.NATSConfig            `json:"nats" yaml:"nats"`
-	NATSStream        reader.NATSStreamConfig      `json:"nats_stream" yaml:"nats_stream"`
-	NSQ               reader.NSQConfig             `json:"nsq" yaml:"nsq"`
-	Plugin            interface{}                  `json:"plugin,omitempty" yaml:"plugin,omitempty"`
-	Pulsar            PulsarConfig                 `json:"pulsar" yaml:"pulsar"`
-	ReadUntil         ReadUntilConfig              `json:"read_until" yaml:"read_until"`
-	RedisList         reader.RedisListConfig       `json:"redis_list" yaml:"redis_list"`
-	RedisPubSub       reader.RedisPubSubConfig     `json:"redis_pubsub" yaml:"redis_pubsub"`
-	RedisStreams      reader.RedisStreamsConfig    `json:"redis_streams" yaml:"redis_streams"`
-	Resource          string                       `json:"resource" yaml:"resource"`
-	S3                reader.AmazonS3Config        `json:"s3" yaml:"s3"`
-	Sequence          SequenceConfig               `json:"sequence" yaml:"sequence"`
-	SFTP              SFTPConfig                   `json:"sftp" yaml:"sftp"`
-	Socket            SocketConfig                 `json:"socket" yaml:"socket"`
-	SocketServer      SocketServerConfig           `json:"socket_server" yaml:"socket_server"`
-	SQS               reader.AmazonSQSConfig       `json:"sqs" yaml:"sqs"`
-	STDIN             STDINConfig                  `json:"stdin" yaml:"stdin"`
-	Subprocess        SubprocessConfig             `json:"subprocess" yaml:"subprocess"`
-	TCP               TCPConfig                    `json:"tcp" yaml:"tcp"`
-	TCPServer         TCPServerConfig              `json:"tcp_server" yaml:"tcp_server"`
-	UDPServer         UDPServerConfig              `json:"udp_server" yaml:"udp_server"`
-	Websocket         reader.WebsocketConfig       `json:"websocket" yaml:"websocket"`
-	ZMQ4              *reader.ZMQ4Config           `json:"zmq4,omitempty" yaml:"zmq4,omitempty"`
-	Processors        []processor.Config           `json:"processors" yaml:"processors"`
+	Label               string                       `json:"label" yaml:"label"`
+	Type                string                       `json:"type" yaml:"type"`
+	AMQP                reader.AMQPConfig            `json:"amqp" yaml:"amqp"`
+	AMQP09              reader.AMQP09Config          `json:"amqp_0_9" yaml:"amqp_0_9"`
+	AMQP1               reader.AMQP1Config           `json:"amqp_1" yaml:"amqp_1"`
+	AWSKinesis          AWSKinesisConfig             `json:"aws_kinesis" yaml:"aws_kinesis"`
+	AWSS3               AWSS3Config                  `json:"aws_s3" yaml:"aws_s3"`
+	AWSSQS              AWSSQSConfig                 `json:"aws_sqs" yaml:"aws_sqs"`
+	AzureBlobStorage    AzureBlobStorageConfig       `json:"azure_blob_storage" yaml:"azure_blob_storage"`
+	AzureQueueStorage   AzureQueueStorageConfig      `json:"azure_queue_storage" yaml:"azure_queue_storage"`
+	Bloblang            BloblangConfig               `json:"bloblang" yaml:"bloblang"`
+	Broker              BrokerConfig                 `json:"broker" yaml:"broker"`
+	ContainerLogs       ContainerLogsConfig          `json:"container_logs" yaml:"container_logs"`
+	CSVFile             CSVFileConfig                `json:"csv" yaml:"csv"`
+	Discord             DiscordConfig                `json:"discord" yaml:"discord"`
+	Dynamic             DynamicConfig                `json:"dynamic" yaml:"dynamic"`
+	File                FileConfig                   `json:"file" yaml:"file"`
+	FileEvents          FileEventsConfig             `json:"file_events" yaml:"file_events"`
+	Files               reader.FilesConfig           `json:"files" yaml:"files"`
+	GCPCloudStorage     GCPCloudStorageConfig        `json:"gcp_cloud_storage" yaml:"gcp_cloud_storage"`
+	GCPPubSub           reader.GCPPubSubConfig       `json:"gcp_pubsub" yaml:"gcp_pubsub"`
+	Generate            BloblangConfig               `json:"generate" yaml:"generate"`
+	GraphQLSubscription GraphQLSubscriptionConfig    `json:"graphql_subscription" yaml:"graphql_subscription"`
+	HDFS                reader.HDFSConfig            `json:"hdfs" yaml:"hdfs"`
+	HTTPClient          HTTPClientConfig             `json:"http_client" yaml:"http_client"`
+	HTTPServer          HTTPServerConfig             `json:"http_server" yaml:"http_server"`
+	Inproc              InprocConfig                 `json:"inproc" yaml:"inproc"`
+	InprocTopic         InprocTopicConfig            `json:"inproc_topic" yaml:"inproc_topic"`
+	Kafka               reader.KafkaConfig           `json:"kafka" yaml:"kafka"`
+	KafkaBalanced       reader.KafkaBalancedConfig   `json:"kafka_balanced" yaml:"kafka_balanced"`
+	Kinesis             reader.KinesisConfig         `json:"kinesis" yaml:"kinesis"`
+	KinesisBalanced     reader.KinesisBalancedConfig `json:"kinesis_balanced" yaml:"kinesis_balanced"`
+	MQTT                reader.MQTTConfig            `json:"mqtt" yaml:"mqtt"`
+	Nanomsg             reader.ScaleProtoConfig      `json:"nanomsg" yaml:"nanomsg"`
+	NATS                reader.NATSConfig            `json:"nats" yaml:"nats"`
+	NATSStream          reader.NATSStreamConfig      `json:"nats_stream" yaml:"nats_stream"`
+	NDJSONFiles         NDJSONFilesConfig            `json:"ndjson_files" yaml:"ndjson_files"`
+	NSQ                 reader.NSQConfig             `json:"nsq" yaml:"nsq"`
+	Plugin              interface{}                  `json:"plugin,omitempty" yaml:"plugin,omitempty"`
+	Pulsar              PulsarConfig                 `json:"pulsar" yaml:"pulsar"`
+	ReadUntil           ReadUntilConfig              `json:"read_until" yaml:"read_until"`
+	RedisList           reader.RedisListConfig       `json:"redis_list" yaml:"redis_list"`
+	RedisPubSub         reader.RedisPubSubConfig     `json:"redis_pubsub" yaml:"redis_pubsub"`
+	RedisStreams        reader.RedisStreamsConfig    `json:"redis_streams" yaml:"redis_streams"`
+	Replay              ReplayConfig                 `json:"replay" yaml:"replay"`
+	Resource            string                       `json:"resource" yaml:"resource"`
+	S3                  reader.AmazonS3Config        `json:"s3" yaml:"s3"`
+	Sequence            SequenceConfig               `json:"sequence" yaml:"sequence"`
+	SFTP                SFTPConfig                   `json:"sftp" yaml:"sftp"`
+	SlackEvents         SlackEventsConfig            `json:"slack_events" yaml:"slack_events"`
+	Socket              SocketConfig                 `json:"socket" yaml:"socket"`
+	SocketServer        SocketServerConfig           `json:"socket_server" yaml:"socket_server"`
+	SQS                 reader.AmazonSQSConfig       `json:"sqs" yaml:"sqs"`
+	STDIN               STDINConfig                  `json:"stdin" yaml:"stdin"`
+	Subprocess          SubprocessConfig             `json:"subprocess" yaml:"subprocess"`
+	TCP                 TCPConfig                    `json:"tcp" yaml:"tcp"`
+	TCPServer           TCPServerConfig              `json:"tcp_server" yaml:"tcp_server"`
+	Telegram            TelegramConfig               `json:"telegram" yaml:"telegram"`
+	UDPServer           UDPServerConfig              `json:"udp_server" yaml:"udp_server"`
+	Webhook             WebhookConfig                `json:"webhook" yaml:"webhook"`
+	Websocket           reader.WebsocketConfig       `json:"websocket" yaml:"websocket"`
+	ZMQ4                *reader.ZMQ4Config           `json:"zmq4,omitempty" yaml:"zmq4,omitempty"`
+	Processors          []processor.Config           `json:"processors" yaml:"processors"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Label:             "",
-		Type:              "stdin",
-		AMQP:              reader.NewAMQPConfig(),
-		AMQP09:            reader.NewAMQP09Config(),
-		AMQP1:             reader.NewAMQP1Config(),
-		AWSKinesis:        NewAWSKinesisConfig(),
-		AWSS3:             NewAWSS3Config(),
-		AWSSQS:            NewAWSSQSConfig(),
-		AzureBlobStorage:  NewAzureBlobStorageConfig(),
-		AzureQueueStorage: NewAzureQueueStorageConfig(),
-		Bloblang:          NewBloblangConfig(),
-		Broker:            NewBrokerConfig(),
-		CSVFile:           NewCSVFileConfig(),
-		Dynamic:           NewDynamicConfig(),
-		File:              NewFileConfig(),
-		Files:             reader.NewFilesConfig(),
-		GCPCloudStorage:   NewGCPCloudStorageConfig(),
-		GCPPubSub:         reader.NewGCPPubSubConfig(),
-		Generate:          NewBloblangConfig(),
-		HDFS:              reader.NewHDFSConfig(),
-		HTTPClient:        NewHTTPClientConfig(),
-		HTTPServer:        NewHTTPServerConfig(),
-		Inproc:            NewInprocConfig(),
-		Kafka:             reader.NewKafkaConfig(),
-		KafkaBalanced:     reader.NewKafkaBalancedConfig(),
-		Kinesis:           reader.NewKinesisConfig(),
-		KinesisBalanced:   reader.NewKinesisBalancedConfig(),
-		MQTT:              reader.NewMQTTConfig(),
-		Nanomsg:           reader.NewScaleProtoConfig(),
-		NATS:              reader.NewNATSConfig(),
-		NATSStream:        reader.NewNATSStreamConfig(),
-		NSQ:               reader.NewNSQConfig(),
-		Plugin:            nil,
-		Pulsar:            NewPulsarConfig(),
-		ReadUntil:         NewReadUntilConfig(),
-		RedisList:         reader.NewRedisListConfig(),
-		RedisPubSub:       reader.NewRedisPubSubConfig(),
-		RedisStreams:      reader.NewRedisStreamsConfig(),
-		Resource:          "",
-		S3:                reader.NewAmazonS3Config(),
-		Sequence:          NewSequenceConfig(),
-		SFTP:              NewSFTPConfig(),
-		Socket:            NewSocketConfig(),
-		SocketServer:      NewSocketServerConfig(),
-		SQS:               reader.NewAmazonSQSConfig(),
-		STDIN:             NewSTDINConfig(),
-		Subprocess:        NewSubprocessConfig(),
-		TCP:               NewTCPConfig(),
-		TCPServer:         NewTCPServerConfig(),
-		UDPServer:         NewUDPServerConfig(),
-		Websocket:         reader.NewWebsocketConfig(),
-		ZMQ4:              reader.NewZMQ4Config(),
-		Processors:        []processor.Config{},
+		Label:               "",
+		Type:                "stdin",
+		AMQP:                reader.NewAMQPConfig(),
+		AMQP09:              reader.NewAMQP09Config(),
+		AMQP1:               reader.NewAMQP1Config(),
+		AWSKinesis:          NewAWSKinesisConfig(),
+		AWSS3:               NewAWSS3Config(),
+		AWSSQS:              NewAWSSQSConfig(),
+		AzureBlobStorage:    NewAzureBlobStorageConfig(),
+		AzureQueueStorage:   NewAzureQueueStorageConfig(),
+		Bloblang:            NewBloblangConfig(),
+		Broker:              NewBrokerConfig(),
+		ContainerLogs:       NewContainerLogsConfig(),
+		CSVFile:             NewCSVFileConfig(),
+		Discord:             NewDiscordConfig(),
+		Dynamic:             NewDynamicConfig(),
+		File:                NewFileConfig(),
+		FileEvents:          NewFileEventsConfig(),
+		Files:               reader.NewFilesConfig(),
+		GCPCloudStorage:     NewGCPCloudStorageConfig(),
+		GCPPubSub:           reader.NewGCPPubSubConfig(),
+		Generate:            NewBloblangConfig(),
+		GraphQLSubscription: NewGraphQLSubscriptionConfig(),
+		HDFS:                reader.NewHDFSConfig(),
+		HTTPClient:          NewHTTPClientConfig(),
+		HTTPServer:          NewHTTPServerConfig(),
+		Inproc:              NewInprocConfig(),
+		InprocTopic:         NewInprocTopicConfig(),
+		Kafka:               reader.NewKafkaConfig(),
+		KafkaBalanced:       reader.NewKafkaBalancedConfig(),
+		Kinesis:             reader.NewKinesisConfig(),
+		KinesisBalanced:     reader.NewKinesisBalancedConfig(),
+		MQTT:                reader.NewMQTTConfig(),
+		Nanomsg:             reader.NewScaleProtoConfig(),
+		NATS:                reader.NewNATSConfig(),
+		NATSStream:          reader.NewNATSStreamConfig(),
+		NDJSONFiles:         NewNDJSONFilesConfig(),
+		NSQ:                 reader.NewNSQConfig(),
+		Plugin:              nil,
+		Pulsar:              NewPulsarConfig(),
+		ReadUntil:           NewReadUntilConfig(),
+		RedisList:           reader.NewRedisListConfig(),
+		RedisPubSub:         reader.NewRedisPubSubConfig(),
+		RedisStreams:        reader.NewRedisStreamsConfig(),
+		Replay:              NewReplayConfig(),
+		Resource:            "",
+		S3:                  reader.NewAmazonS3Config(),
+		Sequence:            NewSequenceConfig(),
+		SFTP:                NewSFTPConfig(),
+		SlackEvents:         NewSlackEventsConfig(),
+		Socket:              NewSocketConfig(),
+		SocketServer:        NewSocketServerConfig(),
+		SQS:                 reader.NewAmazonSQSConfig(),
+		STDIN:               NewSTDINConfig(),
+		Subprocess:          NewSubprocessConfig(),
+		TCP:                 NewTCPConfig(),
+		TCPServer:           NewTCPServerConfig(),
+		Telegram:            NewTelegramConfig(),
+		UDPServer:           NewUDPServerConfig(),
+		Webhook:             NewWebhookConfig(),
+		Websocket:           reader.NewWebsocketConfig(),
+		ZMQ4:                reader.NewZMQ4Config(),
+		Processors:          []processor.Config{},
 	}
 }
 
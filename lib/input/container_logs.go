@@ -0,0 +1,455 @@
+package input
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+func init() {
+	Constructors[TypeContainerLogs] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			r, err := newContainerLogsReader(conf.ContainerLogs, log)
+			if err != nil {
+				return nil, err
+			}
+			return NewAsyncReader(TypeContainerLogs, true, r, log, stats)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `Discovers running containers by their labels and tails their logs, creating a message for each line.`,
+		Description: `
+Containers are discovered either from a Docker daemon or from the pods of a Kubernetes cluster, where a target is any running container with labels (or pod labels) that match all of the entries of ` + "`labels`" + `. Discovery is repeated every ` + "`poll_interval`" + `, new containers are tailed as soon as they are found and the logs of a container are consumed until it stops.
+
+By default only log lines written after the input was started are consumed, which can be changed with the field ` + "`from_beginning`" + `. When the log stream of a container is interrupted it is resumed from the timestamp of the last line consumed, and lines that were already consumed are skipped. Log lines are delivered at most once, as the logs of a container cannot be acknowledged.
+
+### Docker
+
+The Docker daemon is accessed via the ` + "`docker.address`" + ` field, which can be a unix socket (` + "`unix:///var/run/docker.sock`" + `) or a TCP address (` + "`tcp://localhost:2375`" + `).
+
+### Kubernetes
+
+The logs of pods are obtained via the Kubernetes API server, which proxies them from the kubelet of each node. The defaults of the ` + "`kubernetes`" + ` fields are suitable for running Benthos within a pod using a service account that is permitted to list pods and get their logs.
+
+## Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```" + `
+- container_id
+- container_name
+- container_image (docker only)
+- container_stream (docker only)
+- container_log_timestamp
+- kubernetes_namespace (kubernetes only)
+- kubernetes_pod (kubernetes only)
+- kubernetes_node (kubernetes only)
+` + "```" + `
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("source", "The source to discover containers from.").HasOptions("docker", "kubernetes"),
+			docs.FieldCommon("labels", "A map of labels that a container (or pod) must have in order to be tailed. When empty all running containers are tailed.", map[string]string{
+				"app": "my-app",
+			}).Map(),
+			docs.FieldAdvanced("poll_interval", "The period between each attempt to discover new containers."),
+			docs.FieldAdvanced("from_beginning", "Whether to consume the full logs of containers that were already running when the input started, rather than only lines written after it started."),
+			docs.FieldCommon("docker", "Configuration for discovering containers from a Docker daemon.").WithChildren(
+				docs.FieldCommon("address", "The address of the Docker daemon.", "unix:///var/run/docker.sock", "tcp://localhost:2375"),
+			),
+			docs.FieldCommon("kubernetes", "Configuration for discovering pods from a Kubernetes cluster.").WithChildren(
+				docs.FieldCommon("address", "The address of the Kubernetes API server."),
+				docs.FieldCommon("namespaces", "A list of namespaces to discover pods within. When empty pods are discovered within all namespaces.").Array(),
+				docs.FieldAdvanced("token_file", "A file containing a bearer token to authenticate with, which is read for each request in order to support token rotation."),
+				btls.FieldSpec(),
+			),
+		},
+		Categories: []Category{
+			CategoryLocal,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ContainerLogsDockerConfig contains configuration fields for discovering
+// containers from a Docker daemon.
+type ContainerLogsDockerConfig struct {
+	Address string `json:"address" yaml:"address"`
+}
+
+// ContainerLogsKubernetesConfig contains configuration fields for discovering
+// pods from a Kubernetes cluster.
+type ContainerLogsKubernetesConfig struct {
+	Address    string      `json:"address" yaml:"address"`
+	Namespaces []string    `json:"namespaces" yaml:"namespaces"`
+	TokenFile  string      `json:"token_file" yaml:"token_file"`
+	TLS        btls.Config `json:"tls" yaml:"tls"`
+}
+
+// ContainerLogsConfig contains configuration fields for the container_logs
+// input type.
+type ContainerLogsConfig struct {
+	Source        string                        `json:"source" yaml:"source"`
+	Labels        map[string]string             `json:"labels" yaml:"labels"`
+	PollInterval  string                        `json:"poll_interval" yaml:"poll_interval"`
+	FromBeginning bool                          `json:"from_beginning" yaml:"from_beginning"`
+	Docker        ContainerLogsDockerConfig     `json:"docker" yaml:"docker"`
+	Kubernetes    ContainerLogsKubernetesConfig `json:"kubernetes" yaml:"kubernetes"`
+}
+
+// NewContainerLogsConfig creates a new ContainerLogsConfig with default values.
+func NewContainerLogsConfig() ContainerLogsConfig {
+	tlsConf := btls.NewConfig()
+	tlsConf.Enabled = true
+	tlsConf.RootCAsFile = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	return ContainerLogsConfig{
+		Source:        "docker",
+		Labels:        map[string]string{},
+		PollInterval:  "10s",
+		FromBeginning: false,
+		Docker: ContainerLogsDockerConfig{
+			Address: "unix:///var/run/docker.sock",
+		},
+		Kubernetes: ContainerLogsKubernetesConfig{
+			Address:    "https://kubernetes.default.svc",
+			Namespaces: []string{},
+			TokenFile:  "/var/run/secrets/kubernetes.io/serviceaccount/token",
+			TLS:        tlsConf,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// containerTarget is a running container discovered from a source.
+type containerTarget struct {
+	key  string
+	meta map[string]string
+
+	// Source specific details required in order to tail the container.
+	id        string
+	namespace string
+	pod       string
+	container string
+}
+
+// containerSource discovers running containers and streams their logs.
+type containerSource interface {
+	discover(ctx context.Context) ([]containerTarget, error)
+
+	// tail streams the logs of a container since a given time (or all logs
+	// when the time is zero) until the container stops or the context is
+	// cancelled. Each line is provided to fn along with the stream it was
+	// written to, and is expected to be prefixed with a RFC3339 timestamp.
+	tail(ctx context.Context, target containerTarget, since time.Time, fn func(stream string, line []byte) error) error
+}
+
+// labelSelector returns the labels of a config as a sorted list of key=value
+// pairs.
+func labelSelector(labels map[string]string) []string {
+	selector := make([]string, 0, len(labels))
+	for k, v := range labels {
+		selector = append(selector, k+"="+v)
+	}
+	sort.Strings(selector)
+	return selector
+}
+
+//------------------------------------------------------------------------------
+
+type containerLogsReader struct {
+	source        containerSource
+	log           log.Modular
+	pollInterval  time.Duration
+	startedAt     time.Time
+	fromBeginning bool
+
+	msgChan chan types.Message
+
+	mut     sync.Mutex
+	started bool
+	tails   map[string]struct{}
+
+	// The timestamp of the last line consumed from each stream of each
+	// container.
+	lastSeen map[string]map[string]time.Time
+
+	ctx    context.Context
+	cancel func()
+	wg     sync.WaitGroup
+}
+
+func newContainerLogsReader(conf ContainerLogsConfig, log log.Modular) (*containerLogsReader, error) {
+	pollInterval, err := time.ParseDuration(conf.PollInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse poll interval: %w", err)
+	}
+
+	var source containerSource
+	switch conf.Source {
+	case "docker":
+		if source, err = newDockerContainerSource(conf.Docker, conf.Labels); err != nil {
+			return nil, err
+		}
+	case "kubernetes":
+		if source, err = newKubernetesContainerSource(conf.Kubernetes, conf.Labels); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("source not recognised: %v", conf.Source)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &containerLogsReader{
+		source:        source,
+		log:           log,
+		pollInterval:  pollInterval,
+		startedAt:     time.Now(),
+		fromBeginning: conf.FromBeginning,
+		msgChan:       make(chan types.Message),
+		tails:         map[string]struct{}{},
+		lastSeen:      map[string]map[string]time.Time{},
+		ctx:           ctx,
+		cancel:        cancel,
+	}, nil
+}
+
+// ConnectWithContext starts the discovery of containers, which runs until the
+// input is closed.
+func (c *containerLogsReader) ConnectWithContext(ctx context.Context) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.started {
+		return nil
+	}
+	if _, err := c.source.discover(ctx); err != nil {
+		return err
+	}
+
+	c.started = true
+	c.wg.Add(1)
+	go c.discoveryLoop()
+	return nil
+}
+
+func (c *containerLogsReader) discoveryLoop() {
+	defer c.wg.Done()
+	for {
+		targets, err := c.source.discover(c.ctx)
+		if err != nil {
+			if c.ctx.Err() != nil {
+				return
+			}
+			c.log.Errorf("Failed to discover containers: %v\n", err)
+		} else {
+			c.prune(targets)
+		}
+		for _, t := range targets {
+			c.startTail(t)
+		}
+		select {
+		case <-time.After(c.pollInterval):
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// prune removes the state of containers that are no longer running.
+func (c *containerLogsReader) prune(targets []containerTarget) {
+	running := make(map[string]struct{}, len(targets))
+	for _, t := range targets {
+		running[t.key] = struct{}{}
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	for k := range c.lastSeen {
+		_, isRunning := running[k]
+		_, isTailed := c.tails[k]
+		if !isRunning && !isTailed {
+			delete(c.lastSeen, k)
+		}
+	}
+}
+
+func (c *containerLogsReader) startTail(target containerTarget) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if _, exists := c.tails[target.key]; exists {
+		return
+	}
+
+	// Resume from the oldest line consumed across the streams of the
+	// container, lines that are older than the last line consumed from their
+	// own stream are then skipped.
+	var since time.Time
+	for _, ts := range c.lastSeen[target.key] {
+		if since.IsZero() || ts.Before(since) {
+			since = ts
+		}
+	}
+	if since.IsZero() && !c.fromBeginning {
+		since = c.startedAt
+	}
+
+	c.tails[target.key] = struct{}{}
+	c.log.Debugf("Tailing logs of container: %v\n", target.key)
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		err := c.source.tail(c.ctx, target, since, func(stream string, line []byte) error {
+			return c.emit(target, stream, line)
+		})
+		if err != nil && c.ctx.Err() == nil {
+			c.log.Errorf("Failed to tail logs of container '%v': %v\n", target.key, err)
+		}
+
+		c.mut.Lock()
+		delete(c.tails, target.key)
+		c.mut.Unlock()
+	}()
+}
+
+// emit creates a message from a timestamped log line, skipping lines that are
+// not newer than the last line consumed from the same stream of the container.
+func (c *containerLogsReader) emit(target containerTarget, stream string, line []byte) error {
+	var ts time.Time
+	if i := bytes.IndexByte(line, ' '); i > 0 {
+		var err error
+		if ts, err = time.Parse(time.RFC3339Nano, string(line[:i])); err == nil {
+			line = line[i+1:]
+		}
+	}
+
+	if !ts.IsZero() {
+		c.mut.Lock()
+		streams, exists := c.lastSeen[target.key]
+		if !exists {
+			streams = map[string]time.Time{}
+			c.lastSeen[target.key] = streams
+		}
+		if last, seen := streams[stream]; seen && !ts.After(last) {
+			c.mut.Unlock()
+			return nil
+		}
+		streams[stream] = ts
+		c.mut.Unlock()
+	}
+
+	part := message.NewPart(append([]byte(nil), line...))
+	meta := part.Metadata()
+	for k, v := range target.meta {
+		meta.Set(k, v)
+	}
+	if stream != "" {
+		meta.Set("container_stream", stream)
+	}
+	if !ts.IsZero() {
+		meta.Set("container_log_timestamp", ts.Format(time.RFC3339Nano))
+	}
+
+	msg := message.New(nil)
+	msg.Append(part)
+
+	select {
+	case c.msgChan <- msg:
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	}
+	return nil
+}
+
+// ReadWithContext attempts to read the next log line of any container.
+func (c *containerLogsReader) ReadWithContext(ctx context.Context) (types.Message, reader.AsyncAckFn, error) {
+	select {
+	case msg := <-c.msgChan:
+		return msg, func(context.Context, types.Response) error {
+			return nil
+		}, nil
+	case <-ctx.Done():
+		return nil, nil, types.ErrTimeout
+	case <-c.ctx.Done():
+		return nil, nil, types.ErrTypeClosed
+	}
+}
+
+// CloseAsync stops the discovery and tailing of containers.
+func (c *containerLogsReader) CloseAsync() {
+	c.cancel()
+}
+
+// WaitForClose blocks until the input has closed down.
+func (c *containerLogsReader) WaitForClose(timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// lineSplitter accumulates written bytes and calls a function for each
+// complete line.
+type lineSplitter struct {
+	buf bytes.Buffer
+	fn  func(line []byte) error
+}
+
+func (l *lineSplitter) Write(p []byte) (int, error) {
+	l.buf.Write(p)
+	for {
+		i := bytes.IndexByte(l.buf.Bytes(), '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := bytes.TrimSuffix(l.buf.Next(i + 1)[:i], []byte("\r"))
+		if err := l.fn(line); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Flush calls the function with any remaining partial line.
+func (l *lineSplitter) Flush() error {
+	if l.buf.Len() == 0 {
+		return nil
+	}
+	line := append([]byte(nil), l.buf.Bytes()...)
+	l.buf.Reset()
+	return l.fn(line)
+}
+
+func containerLogsErrResponse(action string, code int, body []byte) error {
+	msg := strings.TrimSpace(string(body))
+	if msg == "" {
+		return fmt.Errorf("failed to %v: status code %v", action, code)
+	}
+	return fmt.Errorf("failed to %v: status code %v: %v", action, code, msg)
+}
+
+var errContainerLogsBadFrame = errors.New("failed to parse log stream frame")
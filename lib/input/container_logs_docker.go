@@ -0,0 +1,216 @@
+package input
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dockerContainerSource discovers containers and streams their logs via the
+// Docker Engine API.
+type dockerContainerSource struct {
+	client  *http.Client
+	baseURL string
+	labels  []string
+}
+
+func newDockerContainerSource(conf ContainerLogsDockerConfig, labels map[string]string) (*dockerContainerSource, error) {
+	u, err := url.Parse(conf.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse docker address: %w", err)
+	}
+
+	d := &dockerContainerSource{
+		client: &http.Client{},
+		labels: labelSelector(labels),
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socketPath := u.Path
+		d.client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		}
+		d.baseURL = "http://docker"
+	case "tcp":
+		d.baseURL = "http://" + u.Host
+	case "http", "https":
+		d.baseURL = strings.TrimSuffix(conf.Address, "/")
+	default:
+		return nil, fmt.Errorf("docker address scheme not recognised: %v", u.Scheme)
+	}
+	return d, nil
+}
+
+func (d *dockerContainerSource) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	reqURL := d.baseURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		return nil, containerLogsErrResponse("request "+path, res.StatusCode, body)
+	}
+	return res, nil
+}
+
+func (d *dockerContainerSource) discover(ctx context.Context) ([]containerTarget, error) {
+	filters := map[string][]string{
+		"status": {"running"},
+	}
+	if len(d.labels) > 0 {
+		filters["label"] = d.labels
+	}
+	filtersBytes, err := json.Marshal(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := d.get(ctx, "/containers/json", url.Values{
+		"filters": []string{string(filtersBytes)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var containers []struct {
+		ID    string   `json:"Id"`
+		Names []string `json:"Names"`
+		Image string   `json:"Image"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&containers); err != nil {
+		return nil, fmt.Errorf("failed to parse containers: %w", err)
+	}
+
+	targets := make([]containerTarget, 0, len(containers))
+	for _, c := range containers {
+		var name string
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		targets = append(targets, containerTarget{
+			key: c.ID,
+			id:  c.ID,
+			meta: map[string]string{
+				"container_id":    c.ID,
+				"container_name":  name,
+				"container_image": c.Image,
+			},
+		})
+	}
+	return targets, nil
+}
+
+func (d *dockerContainerSource) isTTY(ctx context.Context, id string) (bool, error) {
+	res, err := d.get(ctx, "/containers/"+id+"/json", nil)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	var inspect struct {
+		Config struct {
+			Tty bool `json:"Tty"`
+		} `json:"Config"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&inspect); err != nil {
+		return false, fmt.Errorf("failed to parse container: %w", err)
+	}
+	return inspect.Config.Tty, nil
+}
+
+func (d *dockerContainerSource) tail(ctx context.Context, target containerTarget, since time.Time, fn func(stream string, line []byte) error) error {
+	tty, err := d.isTTY(ctx, target.id)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{
+		"follow":     []string{"1"},
+		"stdout":     []string{"1"},
+		"stderr":     []string{"1"},
+		"timestamps": []string{"1"},
+	}
+	if !since.IsZero() {
+		query.Set("since", strconv.FormatInt(since.Unix(), 10)+"."+fmt.Sprintf("%09d", since.Nanosecond()))
+	}
+
+	res, err := d.get(ctx, "/containers/"+target.id+"/logs", query)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	stdout := &lineSplitter{fn: func(line []byte) error {
+		return fn("stdout", line)
+	}}
+	if tty {
+		// Containers with a TTY have a single raw stream.
+		if _, err = io.Copy(stdout, res.Body); err != nil {
+			return err
+		}
+		return stdout.Flush()
+	}
+
+	stderr := &lineSplitter{fn: func(line []byte) error {
+		return fn("stderr", line)
+	}}
+	if err = demuxDockerStream(res.Body, stdout, stderr); err != nil {
+		return err
+	}
+	if err = stdout.Flush(); err != nil {
+		return err
+	}
+	return stderr.Flush()
+}
+
+// demuxDockerStream splits a multiplexed Docker log stream, where each frame
+// has an eight byte header containing the stream type and size of the frame.
+func demuxDockerStream(r io.Reader, stdout, stderr io.Writer) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		var w io.Writer
+		switch header[0] {
+		case 0, 1:
+			w = stdout
+		case 2:
+			w = stderr
+		default:
+			return errContainerLogsBadFrame
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(w, r, size); err != nil {
+			return err
+		}
+	}
+}
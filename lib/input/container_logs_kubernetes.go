@@ -0,0 +1,180 @@
+package input
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// kubernetesContainerSource discovers the containers of pods and streams their
+// logs via the Kubernetes API server.
+type kubernetesContainerSource struct {
+	client     *http.Client
+	baseURL    string
+	namespaces []string
+	tokenFile  string
+	selector   string
+}
+
+func newKubernetesContainerSource(conf ContainerLogsKubernetesConfig, labels map[string]string) (*kubernetesContainerSource, error) {
+	k := &kubernetesContainerSource{
+		client:     &http.Client{},
+		baseURL:    strings.TrimSuffix(conf.Address, "/"),
+		namespaces: conf.Namespaces,
+		tokenFile:  conf.TokenFile,
+		selector:   strings.Join(labelSelector(labels), ","),
+	}
+	if conf.TLS.Enabled {
+		var tlsConf *tls.Config
+		var err error
+		if tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+		k.client.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConf,
+		}
+	}
+	return k, nil
+}
+
+func (k *kubernetesContainerSource) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	reqURL := k.baseURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if k.tokenFile != "" {
+		token, err := ioutil.ReadFile(k.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	res, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		return nil, containerLogsErrResponse("request "+path, res.StatusCode, body)
+	}
+	return res, nil
+}
+
+type kubernetesPodList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			NodeName string `json:"nodeName"`
+		} `json:"spec"`
+		Status struct {
+			ContainerStatuses []struct {
+				Name        string `json:"name"`
+				ContainerID string `json:"containerID"`
+				State       struct {
+					Running *struct{} `json:"running"`
+				} `json:"state"`
+			} `json:"containerStatuses"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+func (k *kubernetesContainerSource) listPods(ctx context.Context, path string) ([]containerTarget, error) {
+	query := url.Values{
+		"fieldSelector": []string{"status.phase=Running"},
+	}
+	if k.selector != "" {
+		query.Set("labelSelector", k.selector)
+	}
+
+	res, err := k.get(ctx, path, query)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var pods kubernetesPodList
+	if err = json.NewDecoder(res.Body).Decode(&pods); err != nil {
+		return nil, fmt.Errorf("failed to parse pods: %w", err)
+	}
+
+	var targets []containerTarget
+	for _, pod := range pods.Items {
+		for _, c := range pod.Status.ContainerStatuses {
+			if c.State.Running == nil {
+				continue
+			}
+			targets = append(targets, containerTarget{
+				key:       pod.Metadata.Namespace + "/" + pod.Metadata.Name + "/" + c.Name,
+				namespace: pod.Metadata.Namespace,
+				pod:       pod.Metadata.Name,
+				container: c.Name,
+				meta: map[string]string{
+					"container_id":         c.ContainerID,
+					"container_name":       c.Name,
+					"kubernetes_namespace": pod.Metadata.Namespace,
+					"kubernetes_pod":       pod.Metadata.Name,
+					"kubernetes_node":      pod.Spec.NodeName,
+				},
+			})
+		}
+	}
+	return targets, nil
+}
+
+func (k *kubernetesContainerSource) discover(ctx context.Context) ([]containerTarget, error) {
+	if len(k.namespaces) == 0 {
+		return k.listPods(ctx, "/api/v1/pods")
+	}
+	var targets []containerTarget
+	for _, ns := range k.namespaces {
+		nsTargets, err := k.listPods(ctx, "/api/v1/namespaces/"+url.PathEscape(ns)+"/pods")
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, nsTargets...)
+	}
+	return targets, nil
+}
+
+func (k *kubernetesContainerSource) tail(ctx context.Context, target containerTarget, since time.Time, fn func(stream string, line []byte) error) error {
+	query := url.Values{
+		"container":  []string{target.container},
+		"follow":     []string{"true"},
+		"timestamps": []string{"true"},
+	}
+	if !since.IsZero() {
+		// The API only supports a precision of seconds, lines that were already
+		// consumed are skipped by their timestamps.
+		query.Set("sinceTime", since.UTC().Format(time.RFC3339))
+	}
+
+	res, err := k.get(ctx, "/api/v1/namespaces/"+url.PathEscape(target.namespace)+"/pods/"+url.PathEscape(target.pod)+"/log", query)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	lines := &lineSplitter{fn: func(line []byte) error {
+		return fn("", line)
+	}}
+	if _, err = io.Copy(lines, res.Body); err != nil {
+		return err
+	}
+	return lines.Flush()
+}
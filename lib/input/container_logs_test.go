@@ -0,0 +1,449 @@
+package input
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dockerFrame(stream byte, payload string) []byte {
+	frame := make([]byte, 8, 8+len(payload))
+	frame[0] = stream
+	binary.BigEndian.PutUint32(frame[4:], uint32(len(payload)))
+	return append(frame, payload...)
+}
+
+func readContainerLogs(t *testing.T, r *containerLogsReader, n int) []types.Part {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	var parts []types.Part
+	for len(parts) < n {
+		msg, _, err := r.ReadWithContext(ctx)
+		require.NoError(t, err)
+		parts = append(parts, msg.Get(0))
+	}
+	return parts
+}
+
+func TestContainerLogsDocker(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/containers/json":
+			var filters map[string][]string
+			require.NoError(t, json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters))
+			assert.Equal(t, []string{"app=foo"}, filters["label"])
+			w.Write([]byte(`[{"Id":"abc","Names":["/foo_1"],"Image":"foo:latest"}]`))
+		case "/containers/abc/json":
+			w.Write([]byte(`{"Config":{"Tty":false}}`))
+		case "/containers/abc/logs":
+			// The same lines are returned for each request in order to
+			// exercise the skipping of lines already consumed.
+			w.Write(dockerFrame(1, "2021-01-01T00:00:01.000000001Z first line\n2021-01-01T00:00:02Z sec"))
+			w.Write(dockerFrame(2, "2021-01-01T00:00:03Z error line\n"))
+			w.Write(dockerFrame(1, "ond line\n"))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	conf := NewContainerLogsConfig()
+	conf.Labels["app"] = "foo"
+	conf.PollInterval = "10ms"
+	conf.FromBeginning = true
+	conf.Docker.Address = ts.URL
+
+	r, err := newContainerLogsReader(conf, log.Noop())
+	require.NoError(t, err)
+	defer func() {
+		r.CloseAsync()
+		require.NoError(t, r.WaitForClose(time.Second))
+	}()
+
+	require.NoError(t, r.ConnectWithContext(context.Background()))
+
+	parts := readContainerLogs(t, r, 3)
+	assert.Equal(t, "first line", string(parts[0].Get()))
+	assert.Equal(t, "error line", string(parts[1].Get()))
+	assert.Equal(t, "second line", string(parts[2].Get()))
+
+	for k, v := range map[string]string{
+		"container_id":            "abc",
+		"container_name":          "foo_1",
+		"container_image":         "foo:latest",
+		"container_stream":        "stdout",
+		"container_log_timestamp": "2021-01-01T00:00:01.000000001Z",
+	} {
+		assert.Equal(t, v, parts[0].Metadata().Get(k), k)
+	}
+	assert.Equal(t, "stderr", parts[1].Metadata().Get("container_stream"))
+
+	// The lines are seen again as the container is re-tailed, but are
+	// skipped as they have already been consumed.
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer done()
+	_, _, err = r.ReadWithContext(ctx)
+	assert.Equal(t, types.ErrTimeout, err)
+}
+
+func TestContainerLogsKubernetes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer footoken", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/api/v1/namespaces/bar/pods":
+			assert.Equal(t, "app=foo,tier=web", r.URL.Query().Get("labelSelector"))
+			w.Write([]byte(`{"items":[{
+	"metadata":{"name":"foo-1","namespace":"bar"},
+	"spec":{"nodeName":"node-a"},
+	"status":{"containerStatuses":[
+		{"name":"main","containerID":"docker://abc","state":{"running":{}}},
+		{"name":"sidecar","containerID":"docker://def","state":{"terminated":{}}}
+	]}
+}]}`))
+		case "/api/v1/namespaces/bar/pods/foo-1/log":
+			assert.Equal(t, "main", r.URL.Query().Get("container"))
+			assert.Equal(t, "true", r.URL.Query().Get("follow"))
+			if since := r.URL.Query().Get("sinceTime"); since != "" {
+				assert.Equal(t, "2021-01-01T00:00:02Z", since)
+			}
+			w.Write([]byte("2021-01-01T00:00:01Z first line\n2021-01-01T00:00:02.5Z second line\n"))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	conf := NewContainerLogsConfig()
+	conf.Source = "kubernetes"
+	conf.Labels["app"] = "foo"
+	conf.Labels["tier"] = "web"
+	conf.PollInterval = "10ms"
+	conf.FromBeginning = true
+	conf.Kubernetes.Address = ts.URL
+	conf.Kubernetes.Namespaces = []string{"bar"}
+	conf.Kubernetes.TokenFile = filepath.Join(t.TempDir(), "token")
+	conf.Kubernetes.TLS.Enabled = false
+	require.NoError(t, ioutil.WriteFile(conf.Kubernetes.TokenFile, []byte("footoken\n"), 0600))
+
+	r, err := newContainerLogsReader(conf, log.Noop())
+	require.NoError(t, err)
+	defer func() {
+		r.CloseAsync()
+		require.NoError(t, r.WaitForClose(time.Second))
+	}()
+
+	require.NoError(t, r.ConnectWithContext(context.Background()))
+
+	parts := readContainerLogs(t, r, 2)
+	assert.Equal(t, "first line", string(parts[0].Get()))
+	assert.Equal(t, "second line", string(parts[1].Get()))
+	for k, v := range map[string]string{
+		"container_id":            "docker://abc",
+		"container_name":          "main",
+		"kubernetes_namespace":    "bar",
+		"kubernetes_pod":          "foo-1",
+		"kubernetes_node":         "node-a",
+		"container_log_timestamp": "2021-01-01T00:00:02.5Z",
+	} {
+		assert.Equal(t, v, parts[1].Metadata().Get(k), k)
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer done()
+	_, _, err = r.ReadWithContext(ctx)
+	assert.Equal(t, types.ErrTimeout, err)
+}
+
+func TestContainerLogsBadSource(t *testing.T) {
+	conf := NewContainerLogsConfig()
+	conf.Source = "nope"
+
+	_, err := newContainerLogsReader(conf, log.Noop())
+	require.EqualError(t, err, "source not recognised: nope")
+}
+
+//------------------------------------------------------------------------------
+
+// Responses recorded from the Docker Engine API v1.41.
+const (
+	dockerContainersResponse = `[
+  {
+    "Id": "8dfafdbc3a40b5b7d9d2d7e0f7b2c9a2c1d41e5e3f9a8e6c5b4a39281706f5e4",
+    "Names": ["/web_1"],
+    "Image": "nginx:1.19",
+    "ImageID": "sha256:f6d0b4767a6c466c178bf718f99bea0d3742b26679081e52dbf8e0c7c4c42d74",
+    "Command": "/docker-entrypoint.sh nginx -g 'daemon off;'",
+    "Created": 1614268218,
+    "Ports": [{"PrivatePort": 80, "Type": "tcp"}],
+    "Labels": {"app": "web", "maintainer": "NGINX Docker Maintainers <docker-maint@nginx.com>"},
+    "State": "running",
+    "Status": "Up 2 hours",
+    "HostConfig": {"NetworkMode": "default"},
+    "NetworkSettings": {"Networks": {"bridge": {"IPAddress": "172.17.0.2", "Gateway": "172.17.0.1"}}},
+    "Mounts": []
+  },
+  {
+    "Id": "3c2e4f7a9b1d",
+    "Names": [],
+    "Image": "sha256:a24bb4013296f61e89ba57005a7b3e52274d8edd3ae2077d04395f806b63d83e",
+    "Command": "sh",
+    "Created": 1614268000,
+    "Labels": {"app": "web"},
+    "State": "running",
+    "Status": "Up 2 hours"
+  }
+]`
+
+	dockerInspectTTYResponse = `{
+  "Id": "8dfafdbc3a40b5b7d9d2d7e0f7b2c9a2c1d41e5e3f9a8e6c5b4a39281706f5e4",
+  "Created": "2021-02-25T15:50:18.211873455Z",
+  "Path": "/docker-entrypoint.sh",
+  "State": {"Status": "running", "Running": true, "Pid": 4242},
+  "Name": "/web_1",
+  "Config": {
+    "Hostname": "8dfafdbc3a40",
+    "Tty": true,
+    "OpenStdin": false,
+    "Env": ["PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"],
+    "Image": "nginx:1.19",
+    "Labels": {"app": "web"}
+  }
+}`
+
+	dockerNotFoundResponse = `{"message":"No such container: nope"}
+`
+)
+
+func TestContainerLogsDockerClient(t *testing.T) {
+	sockPath := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", sockPath)
+	require.NoError(t, err)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "docker", r.Host)
+		switch r.URL.Path {
+		case "/containers/json":
+			var filters map[string][]string
+			require.NoError(t, json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters))
+			assert.Equal(t, map[string][]string{
+				"status": {"running"},
+				"label":  {"app=web"},
+			}, filters)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(dockerContainersResponse))
+		case "/containers/8dfafdbc3a40b5b7d9d2d7e0f7b2c9a2c1d41e5e3f9a8e6c5b4a39281706f5e4/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(dockerInspectTTYResponse))
+		case "/containers/8dfafdbc3a40b5b7d9d2d7e0f7b2c9a2c1d41e5e3f9a8e6c5b4a39281706f5e4/logs":
+			assert.Equal(t, "1614268218.000000500", r.URL.Query().Get("since"))
+			assert.Equal(t, "1", r.URL.Query().Get("timestamps"))
+			// Containers with a TTY are not multiplexed.
+			w.Header().Set("Content-Type", "application/vnd.docker.raw-stream")
+			w.Write([]byte("2021-02-25T15:50:18.5Z first\r\n2021-02-25T15:50:19Z second"))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(dockerNotFoundResponse))
+		}
+	}))
+	ts.Listener = listener
+	ts.Start()
+	defer ts.Close()
+
+	conf := NewContainerLogsConfig().Docker
+	conf.Address = "unix://" + sockPath
+
+	d, err := newDockerContainerSource(conf, map[string]string{"app": "web"})
+	require.NoError(t, err)
+
+	targets, err := d.discover(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+
+	assert.Equal(t, map[string]string{
+		"container_id":    "8dfafdbc3a40b5b7d9d2d7e0f7b2c9a2c1d41e5e3f9a8e6c5b4a39281706f5e4",
+		"container_name":  "web_1",
+		"container_image": "nginx:1.19",
+	}, targets[0].meta)
+	assert.Equal(t, "", targets[1].meta["container_name"])
+
+	var lines []string
+	require.NoError(t, d.tail(context.Background(), targets[0], time.Unix(1614268218, 500), func(stream string, line []byte) error {
+		lines = append(lines, stream+": "+string(line))
+		return nil
+	}))
+	assert.Equal(t, []string{
+		"stdout: 2021-02-25T15:50:18.5Z first",
+		"stdout: 2021-02-25T15:50:19Z second",
+	}, lines)
+
+	err = d.tail(context.Background(), containerTarget{id: "nope"}, time.Time{}, func(string, []byte) error {
+		return nil
+	})
+	require.EqualError(t, err, `failed to request /containers/nope/json: status code 404: {"message":"No such container: nope"}`)
+}
+
+func TestContainerLogsDockerAddress(t *testing.T) {
+	for addr, exp := range map[string]string{
+		"tcp://127.0.0.1:2375":     "http://127.0.0.1:2375",
+		"https://docker.local/v1/": "https://docker.local/v1",
+		"unix:///run/docker.sock":  "http://docker",
+	} {
+		conf := NewContainerLogsConfig().Docker
+		conf.Address = addr
+
+		d, err := newDockerContainerSource(conf, nil)
+		require.NoError(t, err, addr)
+		assert.Equal(t, exp, d.baseURL, addr)
+	}
+
+	conf := NewContainerLogsConfig().Docker
+	conf.Address = "ftp://nope"
+
+	_, err := newDockerContainerSource(conf, nil)
+	require.EqualError(t, err, "docker address scheme not recognised: ftp")
+}
+
+func TestDemuxDockerStream(t *testing.T) {
+	var stream []byte
+	stream = append(stream, dockerFrame(1, "foo\n")...)
+	stream = append(stream, dockerFrame(2, "bar\n")...)
+	stream = append(stream, dockerFrame(0, "baz\n")...)
+
+	var stdout, stderr bytes.Buffer
+	require.NoError(t, demuxDockerStream(bytes.NewReader(stream), &stdout, &stderr))
+	assert.Equal(t, "foo\nbaz\n", stdout.String())
+	assert.Equal(t, "bar\n", stderr.String())
+
+	err := demuxDockerStream(bytes.NewReader(dockerFrame(3, "nope")), &stdout, &stderr)
+	assert.Equal(t, errContainerLogsBadFrame, err)
+
+	err = demuxDockerStream(bytes.NewReader(dockerFrame(1, "truncated")[:12]), &stdout, &stderr)
+	assert.Equal(t, io.EOF, err)
+}
+
+// Responses recorded from the Kubernetes API server v1.20.
+const (
+	kubernetesPodsResponse = `{
+  "kind": "PodList",
+  "apiVersion": "v1",
+  "metadata": {"resourceVersion": "123456"},
+  "items": [
+    {
+      "metadata": {
+        "name": "web-6b474476c4-x7k2p",
+        "generateName": "web-6b474476c4-",
+        "namespace": "default",
+        "uid": "4ae3b8c4-4b2b-4c1f-9a6e-8f6f0d2f3c11",
+        "labels": {"app": "web", "pod-template-hash": "6b474476c4"},
+        "ownerReferences": [{"apiVersion": "apps/v1", "kind": "ReplicaSet", "name": "web-6b474476c4"}]
+      },
+      "spec": {
+        "containers": [{"name": "nginx", "image": "nginx:1.19"}, {"name": "istio-proxy", "image": "istio/proxyv2:1.9.0"}],
+        "nodeName": "worker-1",
+        "serviceAccountName": "default"
+      },
+      "status": {
+        "phase": "Running",
+        "podIP": "10.244.1.7",
+        "initContainerStatuses": [
+          {"name": "istio-init", "state": {"terminated": {"exitCode": 0, "reason": "Completed"}}, "ready": true, "restartCount": 0, "containerID": "containerd://0a1b"}
+        ],
+        "containerStatuses": [
+          {"name": "istio-proxy", "state": {"waiting": {"reason": "CrashLoopBackOff"}}, "ready": false, "restartCount": 4, "image": "istio/proxyv2:1.9.0", "containerID": "containerd://5e6f"},
+          {"name": "nginx", "state": {"running": {"startedAt": "2021-02-25T15:50:18Z"}}, "ready": true, "restartCount": 0, "image": "nginx:1.19", "containerID": "containerd://9c8d"}
+        ]
+      }
+    },
+    {
+      "metadata": {"name": "pending-1", "namespace": "kube-system"},
+      "spec": {"containers": [{"name": "main", "image": "busybox"}]},
+      "status": {"phase": "Pending"}
+    }
+  ]
+}`
+
+	kubernetesForbiddenResponse = `{"kind":"Status","apiVersion":"v1","metadata":{},"status":"Failure","message":"pods is forbidden: User \"system:serviceaccount:default:benthos\" cannot list resource \"pods\" in API group \"\" at the cluster scope","reason":"Forbidden","details":{"kind":"pods"},"code":403}`
+)
+
+func TestContainerLogsKubernetesClient(t *testing.T) {
+	var forbidden int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/pods":
+			assert.Equal(t, "status.phase=Running", r.URL.Query().Get("fieldSelector"))
+			assert.Equal(t, "", r.URL.Query().Get("labelSelector"))
+			if atomic.LoadInt32(&forbidden) == 1 {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(kubernetesForbiddenResponse))
+				return
+			}
+			w.Write([]byte(kubernetesPodsResponse))
+		case "/api/v1/namespaces/default/pods/web-6b474476c4-x7k2p/log":
+			assert.Equal(t, "nginx", r.URL.Query().Get("container"))
+			assert.Equal(t, "2021-02-25T15:50:18Z", r.URL.Query().Get("sinceTime"))
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("2021-02-25T15:50:18.123456789Z 10.244.1.1 - - \"GET / HTTP/1.1\" 200\n2021-02-25T15:50:19.000000001Z 10.244.1.1 - - \"GET /healthz HTTP/1.1\" 200\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	conf := NewContainerLogsConfig().Kubernetes
+	conf.Address = ts.URL + "/"
+	conf.TokenFile = ""
+	conf.TLS.Enabled = false
+
+	k, err := newKubernetesContainerSource(conf, nil)
+	require.NoError(t, err)
+
+	targets, err := k.discover(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+
+	assert.Equal(t, "default/web-6b474476c4-x7k2p/nginx", targets[0].key)
+	assert.Equal(t, map[string]string{
+		"container_id":         "containerd://9c8d",
+		"container_name":       "nginx",
+		"kubernetes_namespace": "default",
+		"kubernetes_pod":       "web-6b474476c4-x7k2p",
+		"kubernetes_node":      "worker-1",
+	}, targets[0].meta)
+
+	var lines []string
+	since := time.Date(2021, 2, 25, 16, 50, 18, 500, time.FixedZone("", 3600))
+	require.NoError(t, k.tail(context.Background(), targets[0], since, func(stream string, line []byte) error {
+		assert.Equal(t, "", stream)
+		lines = append(lines, string(line))
+		return nil
+	}))
+	assert.Equal(t, []string{
+		`2021-02-25T15:50:18.123456789Z 10.244.1.1 - - "GET / HTTP/1.1" 200`,
+		`2021-02-25T15:50:19.000000001Z 10.244.1.1 - - "GET /healthz HTTP/1.1" 200`,
+	}, lines)
+
+	atomic.StoreInt32(&forbidden, 1)
+	_, err = k.discover(context.Background())
+	require.EqualError(t, err, "failed to request /api/v1/pods: status code 403: "+kubernetesForbiddenResponse)
+}
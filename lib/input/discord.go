@@ -0,0 +1,336 @@
+package input
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/gorilla/websocket"
+)
+
+func init() {
+	Constructors[TypeDiscord] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			r, err := newDiscordReader(conf.Discord, log)
+			if err != nil {
+				return nil, err
+			}
+			return NewAsyncReader(
+				TypeDiscord,
+				true,
+				reader.NewAsyncPreserver(r),
+				log, stats,
+			)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `Connects to the [Discord gateway](https://discord.com/developers/docs/topics/gateway) as a bot and creates a message for each event received.`,
+		Description: `
+The bot identifies with the configured ` + "`intents`" + `, which determine the events that are sent by the gateway. The default intents receive guild and guild message events, and some intents are privileged and must be enabled for the bot within the developer portal.
+
+Each event is emitted as a message containing the data of the event, and ` + "`events`" + ` can be used in order to only emit events of certain types. Heartbeats are sent at the interval requested by the gateway, and when the connection is lost or the gateway requests a reconnect the input identifies again.
+
+## Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```" + `
+- discord_event_type
+- discord_sequence
+` + "```" + `
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("bot_token", "The token of the bot."),
+			docs.FieldCommon("events", "An optional list of event types to emit, when empty all events are emitted.", []string{"MESSAGE_CREATE", "MESSAGE_REACTION_ADD"}).Array(),
+			docs.FieldCommon("intents", "The [gateway intents](https://discord.com/developers/docs/topics/gateway#gateway-intents) of the bot as a bit set."),
+			docs.FieldAdvanced("gateway_url", "The URL of the gateway to connect to."),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// DiscordConfig contains configuration fields for the Discord input type.
+type DiscordConfig struct {
+	BotToken   string   `json:"bot_token" yaml:"bot_token"`
+	Events     []string `json:"events" yaml:"events"`
+	Intents    int      `json:"intents" yaml:"intents"`
+	GatewayURL string   `json:"gateway_url" yaml:"gateway_url"`
+}
+
+// NewDiscordConfig creates a new DiscordConfig with default values.
+func NewDiscordConfig() DiscordConfig {
+	return DiscordConfig{
+		BotToken:   "",
+		Events:     []string{},
+		Intents:    513,
+		GatewayURL: "wss://gateway.discord.gg/?v=8&encoding=json",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Gateway opcodes, see
+// https://discord.com/developers/docs/topics/opcodes-and-status-codes.
+const (
+	discordOpDispatch       = 0
+	discordOpHeartbeat      = 1
+	discordOpIdentify       = 2
+	discordOpReconnect      = 7
+	discordOpInvalidSession = 9
+	discordOpHello          = 10
+	discordOpHeartbeatAck   = 11
+)
+
+type discordPayload struct {
+	Op       int             `json:"op"`
+	Data     json.RawMessage `json:"d"`
+	Sequence *int64          `json:"s,omitempty"`
+	Type     string          `json:"t,omitempty"`
+}
+
+type discordConn struct {
+	ws *websocket.Conn
+
+	writeMut sync.Mutex
+	seqMut   sync.Mutex
+	seq      *int64
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+func (c *discordConn) write(op int, data interface{}) error {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	c.writeMut.Lock()
+	defer c.writeMut.Unlock()
+	return c.ws.WriteJSON(discordPayload{Op: op, Data: dataBytes})
+}
+
+func (c *discordConn) setSequence(seq *int64) {
+	if seq == nil {
+		return
+	}
+	c.seqMut.Lock()
+	c.seq = seq
+	c.seqMut.Unlock()
+}
+
+func (c *discordConn) heartbeat() error {
+	c.seqMut.Lock()
+	seq := c.seq
+	c.seqMut.Unlock()
+	return c.write(discordOpHeartbeat, seq)
+}
+
+func (c *discordConn) heartbeatLoop(interval time.Duration, log log.Modular) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.heartbeat(); err != nil {
+				log.Errorf("Failed to send heartbeat: %v\n", err)
+				c.close()
+				return
+			}
+		case <-c.closeChan:
+			return
+		}
+	}
+}
+
+func (c *discordConn) close() {
+	c.closeOnce.Do(func() {
+		close(c.closeChan)
+		c.ws.Close()
+	})
+}
+
+type discordReader struct {
+	conf   DiscordConfig
+	log    log.Modular
+	events map[string]struct{}
+
+	connMut sync.Mutex
+	conn    *discordConn
+}
+
+func newDiscordReader(conf DiscordConfig, log log.Modular) (*discordReader, error) {
+	if conf.BotToken == "" {
+		return nil, errors.New("a bot_token must be specified")
+	}
+	if conf.GatewayURL == "" {
+		return nil, errors.New("a gateway_url must be specified")
+	}
+
+	d := &discordReader{
+		conf:   conf,
+		log:    log,
+		events: map[string]struct{}{},
+	}
+	for _, e := range conf.Events {
+		d.events[e] = struct{}{}
+	}
+	return d, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext connects to the gateway, waits for the hello message in
+// order to start sending heartbeats and then identifies.
+func (d *discordReader) ConnectWithContext(ctx context.Context) error {
+	d.connMut.Lock()
+	defer d.connMut.Unlock()
+
+	if d.conn != nil {
+		return nil
+	}
+
+	ws, _, err := websocket.DefaultDialer.DialContext(ctx, d.conf.GatewayURL, nil)
+	if err != nil {
+		return err
+	}
+	conn := &discordConn{
+		ws:        ws,
+		closeChan: make(chan struct{}),
+	}
+
+	var hello discordPayload
+	_ = ws.SetReadDeadline(time.Now().Add(time.Second * 10))
+	if err = ws.ReadJSON(&hello); err != nil {
+		conn.close()
+		return fmt.Errorf("failed to read hello: %w", err)
+	}
+	if hello.Op != discordOpHello {
+		conn.close()
+		return fmt.Errorf("expected hello, received opcode: %v", hello.Op)
+	}
+	var helloData struct {
+		HeartbeatInterval int64 `json:"heartbeat_interval"`
+	}
+	if err = json.Unmarshal(hello.Data, &helloData); err != nil || helloData.HeartbeatInterval <= 0 {
+		conn.close()
+		return fmt.Errorf("failed to parse hello: %v", err)
+	}
+	_ = ws.SetReadDeadline(time.Time{})
+
+	if err = conn.write(discordOpIdentify, map[string]interface{}{
+		"token":   d.conf.BotToken,
+		"intents": d.conf.Intents,
+		"properties": map[string]string{
+			"$os":      runtime.GOOS,
+			"$browser": "benthos",
+			"$device":  "benthos",
+		},
+	}); err != nil {
+		conn.close()
+		return fmt.Errorf("failed to identify: %w", err)
+	}
+
+	go conn.heartbeatLoop(time.Duration(helloData.HeartbeatInterval)*time.Millisecond, d.log)
+	d.conn = conn
+	return nil
+}
+
+func (d *discordReader) getConn() *discordConn {
+	d.connMut.Lock()
+	conn := d.conn
+	d.connMut.Unlock()
+	return conn
+}
+
+func (d *discordReader) disconnect(conn *discordConn) {
+	d.connMut.Lock()
+	if d.conn == conn {
+		d.conn.close()
+		d.conn = nil
+	}
+	d.connMut.Unlock()
+}
+
+// ReadWithContext attempts to read the next event from the gateway.
+func (d *discordReader) ReadWithContext(ctx context.Context) (types.Message, reader.AsyncAckFn, error) {
+	conn := d.getConn()
+	if conn == nil {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	for {
+		var payload discordPayload
+		if err := conn.ws.ReadJSON(&payload); err != nil {
+			d.log.Errorf("Failed to read from gateway: %v\n", err)
+			d.disconnect(conn)
+			return nil, nil, types.ErrNotConnected
+		}
+		conn.setSequence(payload.Sequence)
+
+		switch payload.Op {
+		case discordOpDispatch:
+			if len(d.events) > 0 {
+				if _, exists := d.events[payload.Type]; !exists {
+					continue
+				}
+			}
+			part := message.NewPart(payload.Data)
+			part.Metadata().Set("discord_event_type", payload.Type)
+			if payload.Sequence != nil {
+				part.Metadata().Set("discord_sequence", strconv.FormatInt(*payload.Sequence, 10))
+			}
+			msg := message.New(nil)
+			msg.Append(part)
+			return msg, func(context.Context, types.Response) error {
+				return nil
+			}, nil
+		case discordOpHeartbeat:
+			if err := conn.heartbeat(); err != nil {
+				d.disconnect(conn)
+				return nil, nil, types.ErrNotConnected
+			}
+		case discordOpReconnect:
+			d.log.Warnln("Gateway requested a reconnect")
+			d.disconnect(conn)
+			return nil, nil, types.ErrNotConnected
+		case discordOpInvalidSession:
+			d.log.Errorln("Gateway invalidated the session, identifying again")
+			d.disconnect(conn)
+			return nil, nil, types.ErrNotConnected
+		case discordOpHeartbeatAck:
+		default:
+			d.log.Debugf("Ignoring payload with unrecognised opcode: %v\n", payload.Op)
+		}
+	}
+}
+
+// CloseAsync closes the connection to the gateway.
+func (d *discordReader) CloseAsync() {
+	d.connMut.Lock()
+	if d.conn != nil {
+		d.conn.close()
+		d.conn = nil
+	}
+	d.connMut.Unlock()
+}
+
+// WaitForClose blocks until the input has closed down.
+func (d *discordReader) WaitForClose(timeout time.Duration) error {
+	return nil
+}
@@ -0,0 +1,107 @@
+package input
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscordGateway(t *testing.T) {
+	var connections int32
+	upgrader := websocket.Upgrader{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		connection := atomic.AddInt32(&connections, 1)
+
+		require.NoError(t, conn.WriteJSON(discordPayload{
+			Op:   discordOpHello,
+			Data: json.RawMessage(`{"heartbeat_interval":10}`),
+		}))
+
+		var identify discordPayload
+		require.NoError(t, conn.ReadJSON(&identify))
+		assert.Equal(t, discordOpIdentify, identify.Op)
+
+		var identifyData struct {
+			Token   string `json:"token"`
+			Intents int    `json:"intents"`
+		}
+		require.NoError(t, json.Unmarshal(identify.Data, &identifyData))
+		assert.Equal(t, "foo", identifyData.Token)
+		assert.Equal(t, 512, identifyData.Intents)
+
+		dispatch := func(seq int64, eventType, data string) {
+			require.NoError(t, conn.WriteJSON(discordPayload{
+				Op:       discordOpDispatch,
+				Sequence: &seq,
+				Type:     eventType,
+				Data:     json.RawMessage(data),
+			}))
+		}
+
+		if connection == 1 {
+			dispatch(1, "READY", `{"v":8}`)
+			dispatch(2, "MESSAGE_CREATE", `{"content":"first"}`)
+
+			// Wait for a heartbeat that acknowledges the latest sequence.
+			for {
+				var heartbeat discordPayload
+				require.NoError(t, conn.ReadJSON(&heartbeat))
+				assert.Equal(t, discordOpHeartbeat, heartbeat.Op)
+				if string(heartbeat.Data) == "2" {
+					break
+				}
+			}
+			require.NoError(t, conn.WriteJSON(discordPayload{Op: discordOpReconnect}))
+			return
+		}
+
+		dispatch(1, "MESSAGE_CREATE", `{"content":"second"}`)
+		_, _, _ = conn.ReadMessage()
+	}))
+	defer ts.Close()
+
+	conf := NewDiscordConfig()
+	conf.BotToken = "foo"
+	conf.Intents = 512
+	conf.Events = []string{"MESSAGE_CREATE"}
+	conf.GatewayURL = "ws" + strings.TrimPrefix(ts.URL, "http")
+
+	d, err := newDiscordReader(conf, log.Noop())
+	require.NoError(t, err)
+	defer d.CloseAsync()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	require.NoError(t, d.ConnectWithContext(ctx))
+
+	msg, _, err := d.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `{"content":"first"}`, string(msg.Get(0).Get()))
+	assert.Equal(t, "MESSAGE_CREATE", msg.Get(0).Metadata().Get("discord_event_type"))
+	assert.Equal(t, "2", msg.Get(0).Metadata().Get("discord_sequence"))
+
+	_, _, err = d.ReadWithContext(ctx)
+	require.Error(t, err)
+
+	require.NoError(t, d.ConnectWithContext(ctx))
+
+	msg, _, err = d.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `{"content":"second"}`, string(msg.Get(0).Get()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&connections))
+}
@@ -50,6 +50,7 @@ already exists it will be changed.`,
 			docs.FieldCommon("inputs", "A map of inputs to statically create.").Map().HasType(docs.FieldInput),
 			docs.FieldCommon("prefix", "A path prefix for HTTP endpoints that are registered."),
 			docs.FieldCommon("timeout", "The server side timeout of HTTP requests."),
+			api.DynamicPersistenceFieldSpec("inputs"),
 		},
 	}
 }
@@ -58,17 +59,19 @@ already exists it will be changed.`,
 
 // DynamicConfig contains configuration for the Dynamic input type.
 type DynamicConfig struct {
-	Inputs  map[string]Config `json:"inputs" yaml:"inputs"`
-	Prefix  string            `json:"prefix" yaml:"prefix"`
-	Timeout string            `json:"timeout" yaml:"timeout"`
+	Inputs      map[string]Config            `json:"inputs" yaml:"inputs"`
+	Prefix      string                       `json:"prefix" yaml:"prefix"`
+	Timeout     string                       `json:"timeout" yaml:"timeout"`
+	Persistence api.DynamicPersistenceConfig `json:"persistence" yaml:"persistence"`
 }
 
 // NewDynamicConfig creates a new DynamicConfig with default values.
 func NewDynamicConfig() DynamicConfig {
 	return DynamicConfig{
-		Inputs:  map[string]Config{},
-		Prefix:  "",
-		Timeout: "5s",
+		Inputs:      map[string]Config{},
+		Prefix:      "",
+		Timeout:     "5s",
+		Persistence: api.NewDynamicPersistenceConfig(),
 	}
 }
 
@@ -84,8 +87,48 @@ func NewDynamic(
 ) (Type, error) {
 	dynAPI := api.NewDynamic()
 
+	newDynamicInput := func(id string, c []byte) (Config, Type, error) {
+		newConf := NewConfig()
+		if err := yaml.Unmarshal(c, &newConf); err != nil {
+			return newConf, nil, err
+		}
+		iMgr, iLog, iStats := interop.LabelChild(fmt.Sprintf("dynamic.inputs.%v", id), mgr, log, stats)
+		iStats = metrics.Combine(stats, iStats)
+		newInput, err := New(newConf, iMgr, iLog, iStats, pipelines...)
+		return newConf, newInput, err
+	}
+
+	inputConfigs := conf.Dynamic.Inputs
+	inputConfigsMut := sync.RWMutex{}
+
 	inputs := map[string]broker.DynamicInput{}
+
+	store, err := api.NewDynamicStore(conf.Dynamic.Persistence, "inputs", mgr)
+	if err != nil {
+		return nil, err
+	}
+	if store != nil {
+		persisted, err := store.List()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read persisted inputs: %w", err)
+		}
+		for id, c := range persisted {
+			newConf, newInput, err := newDynamicInput(id, c)
+			if err != nil {
+				log.Errorf("Failed to restore persisted input '%v': %v\n", id, err)
+				continue
+			}
+			inputs[id] = newInput
+			inputConfigs[id] = newConf
+			dynAPI.Restored(id, c)
+		}
+		dynAPI.SetStore(store)
+	}
+
 	for k, v := range conf.Dynamic.Inputs {
+		if _, exists := inputs[k]; exists {
+			continue
+		}
 		newInput, err := New(v, mgr, log, stats, pipelines...)
 		if err != nil {
 			return nil, err
@@ -101,9 +144,6 @@ func NewDynamic(
 		}
 	}
 
-	inputConfigs := conf.Dynamic.Inputs
-	inputConfigsMut := sync.RWMutex{}
-
 	fanIn, err := broker.NewDynamicFanIn(
 		inputs, log, stats,
 		broker.OptDynamicFanInSetOnAdd(func(l string) {
@@ -132,18 +172,12 @@ func NewDynamic(
 	}
 
 	dynAPI.OnUpdate(func(id string, c []byte) error {
-		newConf := NewConfig()
-		if err := yaml.Unmarshal(c, &newConf); err != nil {
-			return err
-		}
-		iMgr, iLog, iStats := interop.LabelChild(fmt.Sprintf("dynamic.inputs.%v", id), mgr, log, stats)
-		iStats = metrics.Combine(stats, iStats)
-		newInput, err := New(Config(newConf), iMgr, iLog, iStats, pipelines...)
+		newConf, newInput, err := newDynamicInput(id, c)
 		if err != nil {
 			return err
 		}
 		inputConfigsMut.Lock()
-		inputConfigs[id] = Config(newConf)
+		inputConfigs[id] = newConf
 		inputConfigsMut.Unlock()
 		if err = fanIn.SetInput(id, newInput, timeout); err != nil {
 			log.Errorf("Failed to set input '%v': %v", id, err)
@@ -3,12 +3,14 @@ package input
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/checkpoint"
 	"github.com/Jeffail/benthos/v3/internal/codec"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/internal/filepath"
@@ -30,10 +32,12 @@ Consumes data from files on disk, emitting messages according to a chosen codec.
 			docs.FieldCommon("paths", "A list of paths to consume sequentially. Glob patterns are supported.").Array(),
 			codec.ReaderDocs,
 			docs.FieldAdvanced("max_buffer", "The largest token size expected when consuming delimited files."),
+			docs.FieldAdvanced("max_message_size", "The largest message in bytes that will be consumed, records that exceed this size are rejected as they are read in order to prevent them from being loaded into memory. Set to zero in order to disable the limit.").AtVersion("3.44.0"),
 			docs.FieldDeprecated("path"),
 			docs.FieldDeprecated("delimiter"),
 			docs.FieldDeprecated("multipart"),
 			docs.FieldAdvanced("delete_on_finish", "Whether to delete consumed files from the disk once they are fully consumed."),
+			docs.FieldAdvanced("checkpoint_cache", "An optional [cache resource](/docs/components/caches/about) for storing the byte offset of each file as messages are acknowledged. When set, a file that was partially consumed before a restart is resumed from the first unacknowledged message by seeking to its offset, which requires one of the codecs `lines`, `delim`, `chunker` or `length-prefixed`. Checkpoints can be inspected and reset via the `/checkpoints/{cache}` HTTP endpoint.").AtVersion("3.44.0"),
 		},
 		Description: `
 ### Metadata
@@ -73,8 +77,10 @@ type FileConfig struct {
 	Codec          string   `json:"codec" yaml:"codec"`
 	Multipart      bool     `json:"multipart" yaml:"multipart"`
 	MaxBuffer      int      `json:"max_buffer" yaml:"max_buffer"`
+	MaxMessageSize int      `json:"max_message_size" yaml:"max_message_size"`
 	Delim          string   `json:"delimiter" yaml:"delimiter"`
 	DeleteOnFinish bool     `json:"delete_on_finish" yaml:"delete_on_finish"`
+	Checkpoint     string   `json:"checkpoint_cache" yaml:"checkpoint_cache"`
 }
 
 // NewFileConfig creates a new FileConfig with default values.
@@ -86,8 +92,10 @@ func NewFileConfig() FileConfig {
 		Codec:          "lines",
 		Multipart:      false,
 		MaxBuffer:      1000000,
+		MaxMessageSize: 0,
 		Delim:          "",
 		DeleteOnFinish: false,
+		Checkpoint:     "",
 	}
 }
 
@@ -104,7 +112,7 @@ func NewFile(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type
 	if conf.File.Multipart && !strings.HasSuffix(conf.File.Codec, "/multipart") {
 		conf.File.Codec = conf.File.Codec + "/multipart"
 	}
-	rdr, err := newFileConsumer(conf.File, log)
+	rdr, err := newFileConsumer(conf.File, mgr, log)
 	if err != nil {
 		return nil, err
 	}
@@ -124,9 +132,13 @@ type fileConsumer struct {
 	currentPath string
 
 	delete bool
+
+	checkpoints *checkpoint.Store
+	source      *checkpoint.Source
+	offsets     codec.OffsetReader
 }
 
-func newFileConsumer(conf FileConfig, log log.Modular) (*fileConsumer, error) {
+func newFileConsumer(conf FileConfig, mgr types.Manager, log log.Modular) (*fileConsumer, error) {
 	expandedPaths, err := filepath.Globs(conf.Paths)
 	if err != nil {
 		return nil, err
@@ -134,16 +146,28 @@ func newFileConsumer(conf FileConfig, log log.Modular) (*fileConsumer, error) {
 
 	codecConf := codec.NewReaderConfig()
 	codecConf.MaxScanTokenSize = conf.MaxBuffer
+	codecConf.MaxMessageSize = conf.MaxMessageSize
 	ctor, err := codec.GetReader(conf.Codec, codecConf)
 	if err != nil {
 		return nil, err
 	}
 
+	var checkpoints *checkpoint.Store
+	if len(conf.Checkpoint) > 0 {
+		if !codec.ReaderSupportsOffsets(conf.Codec) {
+			return nil, fmt.Errorf("codec '%v' does not support checkpoints, a checkpoint cache requires one of the codecs lines, delim, chunker or length-prefixed", conf.Codec)
+		}
+		if checkpoints, err = checkpoint.GetStore(mgr, conf.Checkpoint); err != nil {
+			return nil, err
+		}
+	}
+
 	return &fileConsumer{
 		log:         log,
 		scannerCtor: ctor,
 		paths:       expandedPaths,
 		delete:      conf.DeleteOnFinish,
+		checkpoints: checkpoints,
 	}, nil
 }
 
@@ -163,13 +187,35 @@ func (f *fileConsumer) ConnectWithContext(ctx context.Context) error {
 
 	nextPath := f.paths[0]
 
+	var source *checkpoint.Source
+	if f.checkpoints != nil {
+		var err error
+		if source, err = f.checkpoints.Source(nextPath); err != nil {
+			return err
+		}
+	}
+
 	file, err := os.Open(nextPath)
 	if err != nil {
 		return err
 	}
 
+	if source != nil && source.Resume() > 0 {
+		// Messages prior to the checkpoint were acknowledged before the file
+		// was last closed.
+		if _, err = file.Seek(source.Resume(), io.SeekStart); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
 	if f.scanner, err = f.scannerCtor(nextPath, file, func(ctx context.Context, err error) error {
 		if err == nil && f.delete {
+			if source != nil {
+				if err := source.Reset(); err != nil {
+					return err
+				}
+			}
 			return os.Remove(nextPath)
 		}
 		return nil
@@ -180,8 +226,14 @@ func (f *fileConsumer) ConnectWithContext(ctx context.Context) error {
 
 	f.currentPath = nextPath
 	f.paths = f.paths[1:]
+	f.source = source
+	f.offsets, _ = f.scanner.(codec.OffsetReader)
 
-	f.log.Infof("Consuming from file '%v'\n", nextPath)
+	if source != nil && source.Resume() > 0 {
+		f.log.Infof("Resuming from file '%v' at byte offset %v\n", nextPath, source.Resume())
+	} else {
+		f.log.Infof("Consuming from file '%v'\n", nextPath)
+	}
 	return nil
 }
 
@@ -210,6 +262,11 @@ func (f *fileConsumer) ReadWithContext(ctx context.Context) (types.Message, read
 		return nil, nil, err
 	}
 
+	checkpointFn := func() error { return nil }
+	if f.source != nil && f.offsets != nil {
+		checkpointFn = f.source.Track(f.source.Resume() + f.offsets.Offset())
+	}
+
 	msg := message.New(nil)
 	for _, part := range parts {
 		if len(part.Get()) > 0 {
@@ -218,11 +275,19 @@ func (f *fileConsumer) ReadWithContext(ctx context.Context) (types.Message, read
 		}
 	}
 	if msg.Len() == 0 {
+		if err = checkpointFn(); err != nil {
+			f.log.Errorf("Failed to store checkpoint: %v\n", err)
+		}
 		codecAckFn(ctx, nil)
 		return nil, nil, types.ErrTimeout
 	}
 
 	return msg, func(rctx context.Context, res types.Response) error {
+		if res.Error() == nil {
+			if err := checkpointFn(); err != nil {
+				f.log.Errorf("Failed to store checkpoint: %v\n", err)
+			}
+		}
 		return codecAckFn(rctx, res.Error())
 	}, nil
 }
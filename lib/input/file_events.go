@@ -0,0 +1,284 @@
+package input
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	ifilepath "github.com/Jeffail/benthos/v3/internal/filepath"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+func init() {
+	Constructors[TypeFileEvents] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			r, err := newFileEventsReader(conf.FileEvents, log)
+			if err != nil {
+				return nil, err
+			}
+			return NewAsyncReader(TypeFileEvents, true, r, log, stats)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `Watches paths of the local filesystem and emits a message for each file that is created, modified or deleted.`,
+		Description: `
+The contents of files are not read, instead each message is a JSON object describing the event:
+
+` + "```json" + `
+{"event":"create","path":"/data/inbox/foo.csv","size":1024,"mod_time":"2021-01-01T00:00:00Z"}
+` + "```" + `
+
+This makes it possible to trigger workflows as files arrive, for example by reading the file with a ` + "[`bloblang` processor](/docs/components/processors/bloblang)" + ` using the ` + "`file`" + ` function, or by passing the path to a subprocess.
+
+Paths are scanned every ` + "`poll_interval`" + ` and compared with the previous scan, a file is considered modified when its size or modification time changes. Directories that match a path are watched recursively. Since events are detected by polling, a file that is created and deleted between two scans does not produce any events, and events are delivered at most once.
+
+## Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```" + `
+- file_event
+- file_path
+` + "```" + `
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("paths", "A list of paths to watch. Glob patterns are supported, and are expanded on each scan so that new matches are watched.", []string{"/data/inbox", "/data/*.csv"}).Array(),
+			docs.FieldCommon("events", "A list of event types to emit.").Array().HasOptions("create", "modify", "delete"),
+			docs.FieldCommon("poll_interval", "The period between each scan of the watched paths."),
+			docs.FieldAdvanced("emit_existing", "Whether to emit create events for files that already exist when the input starts."),
+		},
+		Categories: []Category{
+			CategoryLocal,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// FileEventsConfig contains configuration fields for the file_events input
+// type.
+type FileEventsConfig struct {
+	Paths        []string `json:"paths" yaml:"paths"`
+	Events       []string `json:"events" yaml:"events"`
+	PollInterval string   `json:"poll_interval" yaml:"poll_interval"`
+	EmitExisting bool     `json:"emit_existing" yaml:"emit_existing"`
+}
+
+// NewFileEventsConfig creates a new FileEventsConfig with default values.
+func NewFileEventsConfig() FileEventsConfig {
+	return FileEventsConfig{
+		Paths:        []string{},
+		Events:       []string{"create", "modify", "delete"},
+		PollInterval: "1s",
+		EmitExisting: false,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type fileEvent struct {
+	Event   string `json:"event"`
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime string `json:"mod_time"`
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+type fileEventsReader struct {
+	paths        []string
+	events       map[string]struct{}
+	pollInterval time.Duration
+	emitExisting bool
+	log          log.Modular
+
+	mut      sync.Mutex
+	snapshot map[string]fileState
+	pending  []fileEvent
+	lastScan time.Time
+}
+
+func newFileEventsReader(conf FileEventsConfig, log log.Modular) (*fileEventsReader, error) {
+	if len(conf.Paths) == 0 {
+		return nil, errors.New("at least one path must be specified")
+	}
+
+	events := map[string]struct{}{}
+	for _, e := range conf.Events {
+		switch e {
+		case "create", "modify", "delete":
+			events[e] = struct{}{}
+		default:
+			return nil, fmt.Errorf("event type not recognised: %v", e)
+		}
+	}
+
+	pollInterval, err := time.ParseDuration(conf.PollInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse poll interval: %w", err)
+	}
+
+	return &fileEventsReader{
+		paths:        conf.Paths,
+		events:       events,
+		pollInterval: pollInterval,
+		emitExisting: conf.EmitExisting,
+		log:          log,
+	}, nil
+}
+
+// scan returns the current state of all files within the watched paths.
+func (f *fileEventsReader) scan() (map[string]fileState, error) {
+	paths, err := ifilepath.Globs(f.paths)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]fileState{}
+	for _, p := range paths {
+		err := filepath.Walk(p, func(path string, info os.FileInfo, werr error) error {
+			if werr != nil {
+				if os.IsNotExist(werr) {
+					// Files can be deleted during a scan.
+					return nil
+				}
+				return werr
+			}
+			if info.IsDir() {
+				return nil
+			}
+			files[path] = fileState{
+				size:    info.Size(),
+				modTime: info.ModTime(),
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// diff compares a scan with the previous snapshot and queues events.
+func (f *fileEventsReader) diff(files map[string]fileState) {
+	var events []fileEvent
+	for path, state := range files {
+		prev, existed := f.snapshot[path]
+		if !existed {
+			events = append(events, newFileEvent("create", path, state))
+		} else if prev.size != state.size || !prev.modTime.Equal(state.modTime) {
+			events = append(events, newFileEvent("modify", path, state))
+		}
+	}
+	for path, state := range f.snapshot {
+		if _, exists := files[path]; !exists {
+			events = append(events, newFileEvent("delete", path, state))
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Path < events[j].Path
+	})
+
+	for _, e := range events {
+		if _, emit := f.events[e.Event]; emit {
+			f.pending = append(f.pending, e)
+		}
+	}
+	f.snapshot = files
+}
+
+func newFileEvent(event, path string, state fileState) fileEvent {
+	return fileEvent{
+		Event:   event,
+		Path:    path,
+		Size:    state.size,
+		ModTime: state.modTime.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// ConnectWithContext takes the initial snapshot of the watched paths.
+func (f *fileEventsReader) ConnectWithContext(ctx context.Context) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if f.snapshot != nil {
+		return nil
+	}
+
+	files, err := f.scan()
+	if err != nil {
+		return err
+	}
+	if f.emitExisting {
+		f.snapshot = map[string]fileState{}
+		f.diff(files)
+	} else {
+		f.snapshot = files
+	}
+	f.lastScan = time.Now()
+	return nil
+}
+
+// ReadWithContext returns the next file event, scanning the watched paths
+// when there are no pending events.
+func (f *fileEventsReader) ReadWithContext(ctx context.Context) (types.Message, reader.AsyncAckFn, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	for len(f.pending) == 0 {
+		select {
+		case <-time.After(time.Until(f.lastScan.Add(f.pollInterval))):
+		case <-ctx.Done():
+			return nil, nil, types.ErrTimeout
+		}
+		files, err := f.scan()
+		f.lastScan = time.Now()
+		if err != nil {
+			return nil, nil, err
+		}
+		f.diff(files)
+	}
+
+	e := f.pending[0]
+	f.pending = f.pending[1:]
+
+	eventBytes, err := json.Marshal(e)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	part := message.NewPart(eventBytes)
+	part.Metadata().Set("file_event", e.Event)
+	part.Metadata().Set("file_path", e.Path)
+
+	msg := message.New(nil)
+	msg.Append(part)
+	return msg, func(context.Context, types.Response) error {
+		return nil
+	}, nil
+}
+
+// CloseAsync shuts down the input.
+func (f *fileEventsReader) CloseAsync() {
+}
+
+// WaitForClose blocks until the input has closed down.
+func (f *fileEventsReader) WaitForClose(timeout time.Duration) error {
+	return nil
+}
@@ -0,0 +1,107 @@
+package input
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileEvents(t *testing.T) {
+	dir := t.TempDir()
+
+	existingPath := filepath.Join(dir, "existing.txt")
+	require.NoError(t, ioutil.WriteFile(existingPath, []byte("foo"), 0644))
+
+	conf := NewFileEventsConfig()
+	conf.Paths = []string{dir}
+	conf.PollInterval = "10ms"
+
+	r, err := newFileEventsReader(conf, log.Noop())
+	require.NoError(t, err)
+	require.NoError(t, r.ConnectWithContext(context.Background()))
+
+	readEvent := func() (string, string) {
+		t.Helper()
+		ctx, done := context.WithTimeout(context.Background(), time.Second)
+		defer done()
+		msg, _, err := r.ReadWithContext(ctx)
+		require.NoError(t, err)
+		return msg.Get(0).Metadata().Get("file_event"), msg.Get(0).Metadata().Get("file_path")
+	}
+
+	newPath := filepath.Join(dir, "sub", "new.txt")
+	require.NoError(t, os.MkdirAll(filepath.Dir(newPath), 0755))
+	require.NoError(t, ioutil.WriteFile(newPath, []byte("bar"), 0644))
+
+	event, path := readEvent()
+	assert.Equal(t, "create", event)
+	assert.Equal(t, newPath, path)
+
+	require.NoError(t, ioutil.WriteFile(existingPath, []byte("foo bar baz"), 0644))
+
+	event, path = readEvent()
+	assert.Equal(t, "modify", event)
+	assert.Equal(t, existingPath, path)
+
+	require.NoError(t, os.Remove(newPath))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+	msg, _, err := r.ReadWithContext(ctx)
+	require.NoError(t, err)
+	var e fileEvent
+	require.NoError(t, json.Unmarshal(msg.Get(0).Get(), &e))
+	assert.Equal(t, "delete", e.Event)
+	assert.Equal(t, newPath, e.Path)
+	assert.Equal(t, int64(3), e.Size)
+}
+
+func TestFileEventsFilterAndExisting(t *testing.T) {
+	dir := t.TempDir()
+
+	existingPath := filepath.Join(dir, "existing.csv")
+	require.NoError(t, ioutil.WriteFile(existingPath, []byte("foo"), 0644))
+
+	conf := NewFileEventsConfig()
+	conf.Paths = []string{filepath.Join(dir, "*.csv")}
+	conf.Events = []string{"create"}
+	conf.PollInterval = "10ms"
+	conf.EmitExisting = true
+
+	r, err := newFileEventsReader(conf, log.Noop())
+	require.NoError(t, err)
+	require.NoError(t, r.ConnectWithContext(context.Background()))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+
+	msg, _, err := r.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, existingPath, msg.Get(0).Metadata().Get("file_path"))
+
+	require.NoError(t, os.Remove(existingPath))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ignored.txt"), []byte("bar"), 0644))
+
+	shortCtx, shortDone := context.WithTimeout(ctx, time.Millisecond*100)
+	defer shortDone()
+	_, _, err = r.ReadWithContext(shortCtx)
+	assert.Equal(t, types.ErrTimeout, err)
+}
+
+func TestFileEventsBadEvent(t *testing.T) {
+	conf := NewFileEventsConfig()
+	conf.Paths = []string{"/tmp"}
+	conf.Events = []string{"rename"}
+
+	_, err := newFileEventsReader(conf, log.Noop())
+	require.EqualError(t, err, "event type not recognised: rename")
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/lib/cache"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
@@ -144,3 +145,95 @@ func TestFileMultiPartDeprecated(t *testing.T) {
 		t.Error("Timed out waiting for channel close")
 	}
 }
+
+type checkpointMgr struct {
+	types.DudMgr
+	cache types.Cache
+}
+
+func (c checkpointMgr) GetCache(name string) (types.Cache, error) {
+	if name == "foo" {
+		return c.cache, nil
+	}
+	return nil, types.ErrCacheNotFound
+}
+
+func TestFileCheckpointResume(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "benthos_file_test")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		os.Remove(tmpfile.Name())
+	})
+
+	messages := []string{
+		"first message",
+		"second message",
+		"third message",
+		"fourth message",
+	}
+	for _, msg := range messages {
+		tmpfile.Write([]byte(msg))
+		tmpfile.Write([]byte("\n"))
+	}
+
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	mgr := checkpointMgr{cache: memCache}
+
+	conf := NewConfig()
+	conf.File.Paths = []string{tmpfile.Name()}
+	conf.File.Checkpoint = "foo"
+
+	consume := func(f Type, exp string) {
+		t.Helper()
+		var ts types.Transaction
+		select {
+		case ts = <-f.TransactionChan():
+			assert.Equal(t, exp, string(ts.Payload.Get(0).Get()))
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for message")
+		}
+		select {
+		case ts.ResponseChan <- response.NewAck():
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for response")
+		}
+	}
+
+	f, err := NewFile(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	consume(f, messages[0])
+	consume(f, messages[1])
+
+	f.CloseAsync()
+	require.NoError(t, f.WaitForClose(time.Second))
+
+	offset, err := memCache.Get("benthos_checkpoint:" + tmpfile.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "29", string(offset))
+
+	f, err = NewFile(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	defer func() {
+		f.CloseAsync()
+		assert.NoError(t, f.WaitForClose(time.Second))
+	}()
+
+	consume(f, messages[2])
+	consume(f, messages[3])
+}
+
+func TestFileCheckpointBadCodec(t *testing.T) {
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := NewConfig()
+	conf.File.Codec = "gzip/lines"
+	conf.File.Checkpoint = "foo"
+
+	_, err = NewFile(conf, checkpointMgr{cache: memCache}, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "codec 'gzip/lines' does not support checkpoints, a checkpoint cache requires one of the codecs lines, delim, chunker or length-prefixed")
+}
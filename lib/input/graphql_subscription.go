@@ -0,0 +1,423 @@
+package input
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/gorilla/websocket"
+)
+
+func init() {
+	Constructors[TypeGraphQLSubscription] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			r, err := newGraphQLSubscriptionReader(conf.GraphQLSubscription, log)
+			if err != nil {
+				return nil, err
+			}
+			return NewAsyncReader(
+				TypeGraphQLSubscription,
+				true,
+				reader.NewAsyncPreserver(r),
+				log, stats,
+			)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `Subscribes to a GraphQL query over a websocket connection and creates a message for each result.`,
+		Description: `
+Connects to a GraphQL server using the ` + "`graphql-ws`" + ` protocol (as implemented by ` + "`subscriptions-transport-ws`" + `) or the newer ` + "`graphql-transport-ws`" + ` protocol, and starts a subscription with the configured query. The ` + "`data`" + ` field of each result is emitted as a message.
+
+When the connection is lost, the server completes the subscription or an error frame is received for the subscription, the error is logged and the input reconnects and subscribes again.
+
+Results that contain errors alongside data are emitted with the errors logged, and results that only contain errors are dropped.
+
+## Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```" + `
+- graphql_operation_name
+` + "```" + `
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("url", "The URL of the GraphQL server to connect to.", "ws://localhost:4000/graphql"),
+			docs.FieldCommon("query", "The subscription query to execute.", `subscription { newOrders { id total } }`),
+			docs.FieldCommon(
+				"variables_mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) that results in an object of variables for the query. The mapping is executed each time a subscription is started.",
+				`root.since = now()`, `root.region = env("REGION")`,
+			),
+			docs.FieldAdvanced("operation_name", "An optional name of the operation to execute, which is required when the query contains multiple operations."),
+			docs.FieldAdvanced("protocol", "The subprotocol to use.").HasOptions("graphql-ws", "graphql-transport-ws"),
+			docs.FieldAdvanced("headers", "A map of headers to add to the websocket handshake request.", map[string]string{
+				"Authorization": "Bearer ${TOKEN}",
+			}).Map(),
+			docs.FieldAdvanced("connection_params", "A map of parameters to send within the payload of the connection initialisation message, which is commonly used for authentication.", map[string]string{
+				"authToken": "${TOKEN}",
+			}).Map(),
+			docs.FieldAdvanced("ack_timeout", "The maximum period of time to wait for the server to acknowledge a connection."),
+		},
+		Categories: []Category{
+			CategoryNetwork,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GraphQLSubscriptionConfig contains configuration fields for the GraphQL
+// subscription input type.
+type GraphQLSubscriptionConfig struct {
+	URL              string            `json:"url" yaml:"url"`
+	Query            string            `json:"query" yaml:"query"`
+	VariablesMapping string            `json:"variables_mapping" yaml:"variables_mapping"`
+	OperationName    string            `json:"operation_name" yaml:"operation_name"`
+	Protocol         string            `json:"protocol" yaml:"protocol"`
+	Headers          map[string]string `json:"headers" yaml:"headers"`
+	ConnectionParams map[string]string `json:"connection_params" yaml:"connection_params"`
+	AckTimeout       string            `json:"ack_timeout" yaml:"ack_timeout"`
+}
+
+// NewGraphQLSubscriptionConfig creates a new GraphQLSubscriptionConfig with
+// default values.
+func NewGraphQLSubscriptionConfig() GraphQLSubscriptionConfig {
+	return GraphQLSubscriptionConfig{
+		URL:              "",
+		Query:            "",
+		VariablesMapping: "",
+		OperationName:    "",
+		Protocol:         "graphql-ws",
+		Headers:          map[string]string{},
+		ConnectionParams: map[string]string{},
+		AckTimeout:       "10s",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Message types of the graphql-ws protocol, the graphql-transport-ws protocol
+// renames some of them and replaces keep alive messages with ping and pong.
+const (
+	gqlConnectionInit  = "connection_init"
+	gqlConnectionAck   = "connection_ack"
+	gqlConnectionError = "connection_error"
+	gqlConnectionTerm  = "connection_terminate"
+	gqlKeepAlive       = "ka"
+	gqlStart           = "start"
+	gqlStop            = "stop"
+	gqlData            = "data"
+	gqlError           = "error"
+	gqlComplete        = "complete"
+
+	gqlTransportSubscribe = "subscribe"
+	gqlTransportNext      = "next"
+	gqlTransportPing      = "ping"
+	gqlTransportPong      = "pong"
+)
+
+const gqlSubscriptionID = "1"
+
+type gqlMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type gqlResult struct {
+	Data   json.RawMessage `json:"data"`
+	Errors json.RawMessage `json:"errors"`
+}
+
+type graphQLSubscriptionReader struct {
+	conf GraphQLSubscriptionConfig
+	log  log.Modular
+
+	transportProtocol bool
+	variables         *mapping.Executor
+	ackTimeout        time.Duration
+
+	connMut sync.Mutex
+	conn    *websocket.Conn
+}
+
+func newGraphQLSubscriptionReader(conf GraphQLSubscriptionConfig, log log.Modular) (*graphQLSubscriptionReader, error) {
+	if conf.URL == "" {
+		return nil, errors.New("a url must be specified")
+	}
+	if conf.Query == "" {
+		return nil, errors.New("a query must be specified")
+	}
+
+	g := &graphQLSubscriptionReader{
+		conf: conf,
+		log:  log,
+	}
+
+	switch conf.Protocol {
+	case "graphql-ws":
+	case "graphql-transport-ws":
+		g.transportProtocol = true
+	default:
+		return nil, fmt.Errorf("protocol not recognised: %v", conf.Protocol)
+	}
+
+	var err error
+	if conf.VariablesMapping != "" {
+		if g.variables, err = bloblang.NewMapping("", conf.VariablesMapping); err != nil {
+			return nil, fmt.Errorf("failed to parse variables mapping: %w", err)
+		}
+	}
+	if g.ackTimeout, err = time.ParseDuration(conf.AckTimeout); err != nil {
+		return nil, fmt.Errorf("failed to parse ack timeout: %w", err)
+	}
+	return g, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (g *graphQLSubscriptionReader) startPayload() ([]byte, error) {
+	payload := map[string]interface{}{
+		"query": g.conf.Query,
+	}
+	if g.conf.OperationName != "" {
+		payload["operationName"] = g.conf.OperationName
+	}
+	if g.variables != nil {
+		p, err := g.variables.MapPart(0, message.New(nil))
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute variables mapping: %w", err)
+		}
+		v, err := p.JSON()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse variables mapping result: %w", err)
+		}
+		vars, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("variables mapping yielded a non-object result: %T", v)
+		}
+		payload["variables"] = vars
+	}
+	return json.Marshal(payload)
+}
+
+func (g *graphQLSubscriptionReader) write(conn *websocket.Conn, msg gqlMessage) error {
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, msgBytes)
+}
+
+func (g *graphQLSubscriptionReader) read(conn *websocket.Conn) (gqlMessage, error) {
+	var msg gqlMessage
+	_, msgBytes, err := conn.ReadMessage()
+	if err != nil {
+		return msg, err
+	}
+	if err = json.Unmarshal(msgBytes, &msg); err != nil {
+		return msg, fmt.Errorf("failed to parse message from server: %w", err)
+	}
+	return msg, nil
+}
+
+// ConnectWithContext establishes a connection with the server, waits for it to
+// be acknowledged and then starts the subscription.
+func (g *graphQLSubscriptionReader) ConnectWithContext(ctx context.Context) error {
+	g.connMut.Lock()
+	defer g.connMut.Unlock()
+
+	if g.conn != nil {
+		return nil
+	}
+
+	headers := http.Header{}
+	for k, v := range g.conf.Headers {
+		headers.Add(k, v)
+	}
+
+	dialer := *websocket.DefaultDialer
+	dialer.Subprotocols = []string{g.conf.Protocol}
+
+	conn, _, err := dialer.DialContext(ctx, g.conf.URL, headers)
+	if err != nil {
+		return err
+	}
+
+	if err = g.initConnection(conn); err != nil {
+		conn.Close()
+		return err
+	}
+
+	g.conn = conn
+	return nil
+}
+
+func (g *graphQLSubscriptionReader) initConnection(conn *websocket.Conn) error {
+	var initPayload []byte
+	if len(g.conf.ConnectionParams) > 0 {
+		var err error
+		if initPayload, err = json.Marshal(g.conf.ConnectionParams); err != nil {
+			return err
+		}
+	}
+	if err := g.write(conn, gqlMessage{Type: gqlConnectionInit, Payload: initPayload}); err != nil {
+		return err
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(g.ackTimeout)); err != nil {
+		return err
+	}
+	for acked := false; !acked; {
+		msg, err := g.read(conn)
+		if err != nil {
+			return fmt.Errorf("failed to obtain connection ack: %w", err)
+		}
+		switch msg.Type {
+		case gqlConnectionAck:
+			acked = true
+		case gqlConnectionError:
+			return fmt.Errorf("connection rejected by server: %s", msg.Payload)
+		case gqlKeepAlive:
+		case gqlTransportPing:
+			if err = g.write(conn, gqlMessage{Type: gqlTransportPong}); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected message type before connection ack: %v", msg.Type)
+		}
+	}
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return err
+	}
+
+	startPayload, err := g.startPayload()
+	if err != nil {
+		return err
+	}
+	startType := gqlStart
+	if g.transportProtocol {
+		startType = gqlTransportSubscribe
+	}
+	return g.write(conn, gqlMessage{
+		ID:      gqlSubscriptionID,
+		Type:    startType,
+		Payload: startPayload,
+	})
+}
+
+func (g *graphQLSubscriptionReader) getConn() *websocket.Conn {
+	g.connMut.Lock()
+	conn := g.conn
+	g.connMut.Unlock()
+	return conn
+}
+
+func (g *graphQLSubscriptionReader) disconnect(conn *websocket.Conn) {
+	g.connMut.Lock()
+	if g.conn == conn {
+		g.conn.Close()
+		g.conn = nil
+	}
+	g.connMut.Unlock()
+}
+
+// ReadWithContext attempts to read the next result of the subscription.
+func (g *graphQLSubscriptionReader) ReadWithContext(ctx context.Context) (types.Message, reader.AsyncAckFn, error) {
+	conn := g.getConn()
+	if conn == nil {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	for {
+		msg, err := g.read(conn)
+		if err != nil {
+			g.log.Errorf("Failed to read subscription result: %v\n", err)
+			g.disconnect(conn)
+			return nil, nil, types.ErrNotConnected
+		}
+
+		switch msg.Type {
+		case gqlData, gqlTransportNext:
+			if msg.ID != gqlSubscriptionID {
+				continue
+			}
+			var res gqlResult
+			if err = json.Unmarshal(msg.Payload, &res); err != nil {
+				g.log.Errorf("Failed to parse subscription result: %v\n", err)
+				continue
+			}
+			if len(res.Errors) > 0 && string(res.Errors) != "null" {
+				g.log.Errorf("Subscription result contained errors: %s\n", res.Errors)
+			}
+			if len(res.Data) == 0 || string(res.Data) == "null" {
+				continue
+			}
+			part := message.NewPart(res.Data)
+			part.Metadata().Set("graphql_operation_name", g.conf.OperationName)
+			resMsg := message.New(nil)
+			resMsg.Append(part)
+			return resMsg, func(context.Context, types.Response) error {
+				return nil
+			}, nil
+		case gqlError:
+			g.log.Errorf("Subscription error received, resubscribing: %s\n", msg.Payload)
+			g.disconnect(conn)
+			return nil, nil, types.ErrNotConnected
+		case gqlComplete:
+			g.log.Warnln("Subscription completed by server, resubscribing")
+			g.disconnect(conn)
+			return nil, nil, types.ErrNotConnected
+		case gqlConnectionError:
+			g.log.Errorf("Connection error received: %s\n", msg.Payload)
+			g.disconnect(conn)
+			return nil, nil, types.ErrNotConnected
+		case gqlTransportPing:
+			g.connMut.Lock()
+			err = g.write(conn, gqlMessage{Type: gqlTransportPong})
+			g.connMut.Unlock()
+			if err != nil {
+				g.disconnect(conn)
+				return nil, nil, types.ErrNotConnected
+			}
+		case gqlKeepAlive, gqlTransportPong, gqlConnectionAck:
+		default:
+			g.log.Debugf("Ignoring message of unrecognised type: %v\n", msg.Type)
+		}
+	}
+}
+
+// CloseAsync stops the subscription and closes the connection.
+func (g *graphQLSubscriptionReader) CloseAsync() {
+	g.connMut.Lock()
+	if g.conn != nil {
+		stopType, termType := gqlStop, gqlConnectionTerm
+		if g.transportProtocol {
+			stopType, termType = gqlComplete, ""
+		}
+		_ = g.write(g.conn, gqlMessage{ID: gqlSubscriptionID, Type: stopType})
+		if termType != "" {
+			_ = g.write(g.conn, gqlMessage{Type: termType})
+		}
+		g.conn.Close()
+		g.conn = nil
+	}
+	g.connMut.Unlock()
+}
+
+// WaitForClose blocks until the input has closed down.
+func (g *graphQLSubscriptionReader) WaitForClose(timeout time.Duration) error {
+	return nil
+}
@@ -0,0 +1,164 @@
+package input
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func graphQLTestServer(t *testing.T, protocol string, handle func(conn *websocket.Conn, subscription int32)) *httptest.Server {
+	t.Helper()
+
+	var subscriptions int32
+	upgrader := websocket.Upgrader{
+		Subprotocols: []string{protocol},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer foo", r.Header.Get("Authorization"))
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var msg gqlMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		assert.Equal(t, gqlConnectionInit, msg.Type)
+		assert.JSONEq(t, `{"authToken":"bar"}`, string(msg.Payload))
+
+		require.NoError(t, conn.WriteJSON(gqlMessage{Type: gqlConnectionAck}))
+
+		require.NoError(t, conn.ReadJSON(&msg))
+		if protocol == "graphql-ws" {
+			assert.Equal(t, gqlStart, msg.Type)
+		} else {
+			assert.Equal(t, gqlTransportSubscribe, msg.Type)
+		}
+		assert.Equal(t, gqlSubscriptionID, msg.ID)
+		assert.JSONEq(t, `{"query":"subscription { foo }","variables":{"limit":10}}`, string(msg.Payload))
+
+		handle(conn, atomic.AddInt32(&subscriptions, 1))
+	}))
+}
+
+func newGraphQLTestReader(t *testing.T, url, protocol string) *graphQLSubscriptionReader {
+	t.Helper()
+
+	conf := NewGraphQLSubscriptionConfig()
+	conf.URL = "ws" + strings.TrimPrefix(url, "http")
+	conf.Query = "subscription { foo }"
+	conf.VariablesMapping = "root.limit = 10"
+	conf.Protocol = protocol
+	conf.Headers["Authorization"] = "Bearer foo"
+	conf.ConnectionParams["authToken"] = "bar"
+
+	g, err := newGraphQLSubscriptionReader(conf, log.Noop())
+	require.NoError(t, err)
+	return g
+}
+
+func TestGraphQLSubscriptionResubscribe(t *testing.T) {
+	ts := graphQLTestServer(t, "graphql-ws", func(conn *websocket.Conn, subscription int32) {
+		payload := func(v string) json.RawMessage {
+			return json.RawMessage(v)
+		}
+		if subscription == 1 {
+			require.NoError(t, conn.WriteJSON(gqlMessage{Type: gqlKeepAlive}))
+			require.NoError(t, conn.WriteJSON(gqlMessage{ID: "1", Type: gqlData, Payload: payload(`{"data":{"foo":"first"}}`)}))
+			require.NoError(t, conn.WriteJSON(gqlMessage{ID: "1", Type: gqlData, Payload: payload(`{"data":null,"errors":[{"message":"nope"}]}`)}))
+			require.NoError(t, conn.WriteJSON(gqlMessage{ID: "1", Type: gqlData, Payload: payload(`{"data":{"foo":"second"}}`)}))
+			require.NoError(t, conn.WriteJSON(gqlMessage{ID: "1", Type: gqlError, Payload: payload(`[{"message":"bad thing"}]`)}))
+		} else {
+			require.NoError(t, conn.WriteJSON(gqlMessage{ID: "1", Type: gqlData, Payload: payload(`{"data":{"foo":"third"}}`)}))
+		}
+		var msg gqlMessage
+		_ = conn.ReadJSON(&msg)
+	})
+	defer ts.Close()
+
+	g := newGraphQLTestReader(t, ts.URL, "graphql-ws")
+	defer g.CloseAsync()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	require.NoError(t, g.ConnectWithContext(ctx))
+
+	for _, exp := range []string{`{"foo":"first"}`, `{"foo":"second"}`} {
+		msg, _, err := g.ReadWithContext(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(msg.Get(0).Get()))
+	}
+
+	_, _, err := g.ReadWithContext(ctx)
+	assert.Equal(t, types.ErrNotConnected, err)
+
+	require.NoError(t, g.ConnectWithContext(ctx))
+
+	msg, _, err := g.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"third"}`, string(msg.Get(0).Get()))
+}
+
+func TestGraphQLSubscriptionTransportProtocol(t *testing.T) {
+	ts := graphQLTestServer(t, "graphql-transport-ws", func(conn *websocket.Conn, subscription int32) {
+		require.NoError(t, conn.WriteJSON(gqlMessage{Type: gqlTransportPing}))
+
+		var msg gqlMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		assert.Equal(t, gqlTransportPong, msg.Type)
+
+		require.NoError(t, conn.WriteJSON(gqlMessage{ID: "1", Type: gqlTransportNext, Payload: json.RawMessage(`{"data":{"foo":"first"}}`)}))
+		require.NoError(t, conn.WriteJSON(gqlMessage{ID: "1", Type: gqlComplete}))
+
+		_ = conn.ReadJSON(&msg)
+	})
+	defer ts.Close()
+
+	g := newGraphQLTestReader(t, ts.URL, "graphql-transport-ws")
+	defer g.CloseAsync()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	require.NoError(t, g.ConnectWithContext(ctx))
+
+	msg, _, err := g.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"first"}`, string(msg.Get(0).Get()))
+
+	_, _, err = g.ReadWithContext(ctx)
+	assert.Equal(t, types.ErrNotConnected, err)
+}
+
+func TestGraphQLSubscriptionConnectionError(t *testing.T) {
+	upgrader := websocket.Upgrader{
+		Subprotocols: []string{"graphql-ws"},
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var msg gqlMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		require.NoError(t, conn.WriteJSON(gqlMessage{Type: gqlConnectionError, Payload: json.RawMessage(`{"message":"unauthorised"}`)}))
+	}))
+	defer ts.Close()
+
+	g := newGraphQLTestReader(t, ts.URL, "graphql-ws")
+	defer g.CloseAsync()
+
+	err := g.ConnectWithContext(context.Background())
+	require.EqualError(t, err, `connection rejected by server: {"message":"unauthorised"}`)
+}
@@ -3,11 +3,15 @@ package input
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
 	"github.com/Jeffail/benthos/v3/internal/codec"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/internal/interop"
@@ -16,6 +20,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/checkpoint"
 	"github.com/Jeffail/benthos/v3/lib/util/http/client"
 )
 
@@ -39,6 +44,18 @@ func httpClientSpecs() docs.FieldSpecs {
 		docs.FieldCommon(
 			"stream", "Allows you to set streaming mode, where requests are kept open and messages are processed line-by-line.",
 		).WithChildren(streamSpecs...),
+		docs.FieldAdvanced(
+			"pagination", "Allows you to consume a paginated API by following a cursor extracted from each response. Pagination cannot be combined with streaming mode.",
+		).WithChildren(
+			docs.FieldCommon(
+				"cursor_mapping", "A [Bloblang mapping](/docs/guides/bloblang/about) executed on each response in order to obtain the cursor of the next page. The cursor is available to the interpolated fields of the next request with `meta(\"cursor\")`. Pagination is disabled when this field is empty.",
+				`root = this.next_page_token`,
+				`root = meta("link").re_find_all_submatch("<([^>]+)>; rel=\"next\"").index(0).index(1).catch(deleted())`,
+			),
+			docs.FieldCommon("interval", "The period to wait before requesting the last page again once it has been reached."),
+			docs.FieldAdvanced("cache", "An optional [cache resource](/docs/components/caches/about) to persist the cursor within as the messages of each page are acknowledged, allowing consumption to resume from the same page after a restart."),
+			docs.FieldAdvanced("cache_key", "The key to persist the cursor under within the cache."),
+		).AtVersion("3.44.0"),
 	)
 	return specs
 }
@@ -54,7 +71,32 @@ interpolations described [here](/docs/configuration/interpolation#bloblang-queri
 
 ### Streaming
 
-If you enable streaming then Benthos will consume the body of the response as a continuous stream of data, breaking messages out following a chosen codec. This allows you to consume APIs that provide long lived streamed data feeds (such as Twitter).`,
+If you enable streaming then Benthos will consume the body of the response as a continuous stream of data, breaking messages out following a chosen codec. This allows you to consume APIs that provide long lived streamed data feeds (such as Twitter).
+
+### Pagination
+
+When the field ` + "`pagination.cursor_mapping`" + ` is set Benthos will follow the pages of an API by executing the mapping on each response in order to obtain a cursor for the next page. The cursor is added to the request as the metadata key ` + "`cursor`" + `, and can therefore be referenced from the URL, headers and payload of the request with [interpolation functions](/docs/configuration/interpolation#bloblang-queries). The cursor is empty for the first request.
+
+Once the mapping yields an empty cursor, deletes the result or returns the same cursor as the request the last page has been reached, at which point the same page is requested again after the period ` + "`pagination.interval`" + `. Response headers are only available to the mapping as metadata when ` + "`copy_response_headers`" + ` is ` + "`true`" + `.
+
+If a cache is configured then the cursor is persisted once all messages of the preceding pages have been acknowledged, and consumption resumes from the persisted cursor after a restart.
+
+` + "```yaml" + `
+input:
+  http_client:
+    url: https://api.example.com/events?page_token=${! meta("cursor") }
+    verb: GET
+    rate_limit: api_limit
+    pagination:
+      cursor_mapping: root = this.next_page_token
+      interval: 1m
+      cache: cursors
+      cache_key: example_events
+  processors:
+    - bloblang: root = this.events
+    - unarchive:
+        format: json_array
+` + "```" + ``,
 		FieldSpecs: httpClientSpecs(),
 		Categories: []Category{
 			CategoryNetwork,
@@ -75,12 +117,22 @@ type StreamConfig struct {
 	Delim     string `json:"delimiter" yaml:"delimiter"`
 }
 
+// HTTPClientPaginationConfig contains fields for following the pages of an
+// API with a cursor extracted from each response.
+type HTTPClientPaginationConfig struct {
+	CursorMapping string `json:"cursor_mapping" yaml:"cursor_mapping"`
+	Interval      string `json:"interval" yaml:"interval"`
+	Cache         string `json:"cache" yaml:"cache"`
+	CacheKey      string `json:"cache_key" yaml:"cache_key"`
+}
+
 // HTTPClientConfig contains configuration for the HTTPClient output type.
 type HTTPClientConfig struct {
 	client.Config   `json:",inline" yaml:",inline"`
-	Payload         string       `json:"payload" yaml:"payload"`
-	DropEmptyBodies bool         `json:"drop_empty_bodies" yaml:"drop_empty_bodies"`
-	Stream          StreamConfig `json:"stream" yaml:"stream"`
+	Payload         string                     `json:"payload" yaml:"payload"`
+	DropEmptyBodies bool                       `json:"drop_empty_bodies" yaml:"drop_empty_bodies"`
+	Stream          StreamConfig               `json:"stream" yaml:"stream"`
+	Pagination      HTTPClientPaginationConfig `json:"pagination" yaml:"pagination"`
 }
 
 // NewHTTPClientConfig creates a new HTTPClientConfig with default values.
@@ -100,6 +152,12 @@ func NewHTTPClientConfig() HTTPClientConfig {
 			MaxBuffer: 1000000,
 			Delim:     "",
 		},
+		Pagination: HTTPClientPaginationConfig{
+			CursorMapping: "",
+			Interval:      "1m",
+			Cache:         "",
+			CacheKey:      "http_client_cursor",
+		},
 	}
 }
 
@@ -117,6 +175,23 @@ type HTTPClient struct {
 
 	codecMut sync.Mutex
 	codec    codec.Reader
+
+	mgr          types.Manager
+	log          log.Modular
+	pageMapping  *mapping.Executor
+	pageInterval time.Duration
+
+	pageMut      sync.Mutex
+	cursor       string
+	cursorLoaded bool
+	nextPoll     time.Time
+
+	// Cursors are committed in the order of their pages regardless of the
+	// order in which their messages are acknowledged.
+	commitMut   sync.Mutex
+	pageSeq     int
+	pageTracker *checkpoint.Type
+	pageCursors map[int]string
 }
 
 // NewHTTPClient creates a new HTTPClient input type.
@@ -154,6 +229,26 @@ func newHTTPClient(conf HTTPClientConfig, mgr types.Manager, log log.Modular, st
 		payload = message.New([][]byte{[]byte(conf.Payload)})
 	}
 
+	var pageMapping *mapping.Executor
+	var pageInterval time.Duration
+	if len(conf.Pagination.CursorMapping) > 0 {
+		if conf.Stream.Enabled {
+			return nil, errors.New("pagination cannot be combined with streaming mode")
+		}
+		var err error
+		if pageMapping, err = bloblang.NewMapping("", conf.Pagination.CursorMapping); err != nil {
+			return nil, fmt.Errorf("failed to parse cursor mapping: %w", err)
+		}
+		if pageInterval, err = time.ParseDuration(conf.Pagination.Interval); err != nil {
+			return nil, fmt.Errorf("failed to parse pagination interval: %w", err)
+		}
+		if len(conf.Pagination.Cache) > 0 {
+			if _, err = mgr.GetCache(conf.Pagination.Cache); err != nil {
+				return nil, fmt.Errorf("failed to obtain pagination cache '%v': %w", conf.Pagination.Cache, err)
+			}
+		}
+	}
+
 	cMgr, cLog, cStats := interop.LabelChild("client", mgr, log, stats)
 	client, err := client.New(
 		conf.Config,
@@ -171,6 +266,13 @@ func newHTTPClient(conf HTTPClientConfig, mgr types.Manager, log log.Modular, st
 		client:  client,
 
 		codecCtor: codecCtor,
+
+		mgr:          mgr,
+		log:          log,
+		pageMapping:  pageMapping,
+		pageInterval: pageInterval,
+		pageTracker:  checkpoint.New(0),
+		pageCursors:  map[int]string{},
 	}, nil
 }
 
@@ -211,6 +313,9 @@ func (h *HTTPClient) ReadWithContext(ctx context.Context) (types.Message, reader
 	if h.conf.Stream.Enabled {
 		return h.readStreamed(ctx)
 	}
+	if h.pageMapping != nil {
+		return h.readPaginated(ctx)
+	}
 	return h.readNotStreamed(ctx)
 }
 
@@ -284,6 +389,142 @@ func (h *HTTPClient) readNotStreamed(ctx context.Context) (types.Message, reader
 	}, nil
 }
 
+func (h *HTTPClient) readPaginated(ctx context.Context) (types.Message, reader.AsyncAckFn, error) {
+	h.pageMut.Lock()
+	defer h.pageMut.Unlock()
+
+	if !h.cursorLoaded {
+		if err := h.loadCursor(); err != nil {
+			return nil, nil, err
+		}
+		h.cursorLoaded = true
+	}
+
+	if wait := time.Until(h.nextPoll); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, nil, types.ErrTimeout
+		}
+	}
+
+	reqMsg := message.New([][]byte{[]byte(h.conf.Payload)})
+	reqMsg.Get(0).Metadata().Set("cursor", h.cursor)
+
+	res, err := h.client.DoWithContext(ctx, reqMsg)
+	if err != nil {
+		if strings.Contains(err.Error(), "(Client.Timeout exceeded while awaiting headers)") {
+			err = types.ErrTimeout
+		}
+		return nil, nil, err
+	}
+
+	var msg types.Message
+	if msg, err = h.client.ParseResponse(res); err != nil {
+		return nil, nil, err
+	}
+
+	if msg.Len() == 0 || (msg.Len() == 1 && msg.Get(0).IsEmpty() && h.conf.DropEmptyBodies) {
+		h.nextPoll = time.Now().Add(h.pageInterval)
+		return nil, nil, types.ErrTimeout
+	}
+
+	nextCursor, err := h.nextCursor(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(nextCursor) == 0 || nextCursor == h.cursor {
+		h.nextPoll = time.Now().Add(h.pageInterval)
+	} else {
+		h.cursor = nextCursor
+	}
+
+	h.commitMut.Lock()
+	h.pageSeq++
+	seq := h.pageSeq
+	_ = h.pageTracker.Track(seq)
+	h.pageCursors[seq] = h.cursor
+	h.commitMut.Unlock()
+
+	return msg, func(rctx context.Context, res types.Response) error {
+		if res.Error() != nil {
+			return nil
+		}
+		return h.commitCursor(seq)
+	}, nil
+}
+
+// nextCursor executes the cursor mapping on a response, returning an empty
+// string when the mapping indicates that there are no further pages.
+func (h *HTTPClient) nextCursor(msg types.Message) (string, error) {
+	v, err := h.pageMapping.Exec(query.FunctionContext{
+		Maps:     map[string]query.Function{},
+		Vars:     map[string]interface{}{},
+		Index:    0,
+		MsgBatch: msg,
+	}.WithValueFunc(func() *interface{} {
+		jObj, err := msg.Get(0).JSON()
+		if err != nil {
+			return nil
+		}
+		return &jObj
+	}))
+	if err != nil {
+		return "", fmt.Errorf("failed to execute cursor mapping: %w", err)
+	}
+	if _, isNothing := v.(query.Nothing); isNothing || query.IIsNull(v) {
+		return "", nil
+	}
+	return query.IToString(v), nil
+}
+
+func (h *HTTPClient) loadCursor() error {
+	if len(h.conf.Pagination.Cache) == 0 {
+		return nil
+	}
+	cache, err := h.mgr.GetCache(h.conf.Pagination.Cache)
+	if err != nil {
+		return err
+	}
+	cursorBytes, err := cache.Get(h.conf.Pagination.CacheKey)
+	if err != nil {
+		if errors.Is(err, types.ErrKeyNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to load pagination cursor: %w", err)
+	}
+	h.cursor = string(cursorBytes)
+	h.log.Debugf("Resuming pagination from cursor: %s\n", cursorBytes)
+	return nil
+}
+
+// commitCursor resolves the page of a sequence number, persisting the cursor
+// of the highest page that has no unacknowledged pages preceding it.
+func (h *HTTPClient) commitCursor(seq int) error {
+	h.commitMut.Lock()
+	defer h.commitMut.Unlock()
+
+	highest, err := h.pageTracker.Resolve(seq)
+	if err != nil {
+		return err
+	}
+	cursor, exists := h.pageCursors[highest]
+	for k := range h.pageCursors {
+		if k <= highest {
+			delete(h.pageCursors, k)
+		}
+	}
+	if !exists || len(h.conf.Pagination.Cache) == 0 {
+		return nil
+	}
+
+	cache, err := h.mgr.GetCache(h.conf.Pagination.Cache)
+	if err != nil {
+		return err
+	}
+	return cache.Set(h.conf.Pagination.CacheKey, []byte(cursor))
+}
+
 // CloseAsync shuts down the HTTPClient input and stops processing requests.
 func (h *HTTPClient) CloseAsync() {
 	h.client.CloseAsync()
@@ -2,6 +2,7 @@ package input
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"io/ioutil"
 	"mime/multipart"
@@ -13,10 +14,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHTTPClientGET(t *testing.T) {
@@ -792,3 +797,91 @@ func BenchmarkHTTPClientGETMultipart(b *testing.B) {
 		b.Error(err)
 	}
 }
+
+func TestHTTPClientPagination(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"items":"first","next":"p2"}`,
+		"p2": `{"items":"second","next":"p3"}`,
+		"p3": `{"items":"third"}`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, exists := pages[r.URL.Query().Get("page")]
+		if !exists {
+			http.Error(w, "page not found", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(page))
+	}))
+	defer ts.Close()
+
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	mgr := checkpointMgr{cache: memCache}
+
+	conf := NewHTTPClientConfig()
+	conf.URL = ts.URL + `/events?page=${! meta("cursor") }`
+	conf.Pagination.CursorMapping = `root = this.next`
+	conf.Pagination.Interval = "1h"
+	conf.Pagination.Cache = "foo"
+	conf.Pagination.CacheKey = "cursor_key"
+
+	h, err := newHTTPClient(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	var ackFns []reader.AsyncAckFn
+	for _, page := range []string{"", "p2", "p3"} {
+		msg, ackFn, err := h.ReadWithContext(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, pages[page], string(msg.Get(0).Get()))
+		ackFns = append(ackFns, ackFn)
+	}
+
+	// The last page has been reached and so the next read waits for the
+	// interval.
+	shortCtx, shortDone := context.WithTimeout(ctx, time.Millisecond*50)
+	_, _, err = h.ReadWithContext(shortCtx)
+	shortDone()
+	assert.Equal(t, types.ErrTimeout, err)
+
+	getCursor := func() string {
+		t.Helper()
+		v, err := memCache.Get("cursor_key")
+		if err == types.ErrKeyNotFound {
+			return ""
+		}
+		require.NoError(t, err)
+		return string(v)
+	}
+
+	require.NoError(t, ackFns[1](ctx, response.NewAck()))
+	assert.Equal(t, "", getCursor())
+
+	require.NoError(t, ackFns[0](ctx, response.NewAck()))
+	assert.Equal(t, "p3", getCursor())
+
+	require.NoError(t, ackFns[2](ctx, response.NewAck()))
+	assert.Equal(t, "p3", getCursor())
+
+	h.CloseAsync()
+
+	// A new input resumes from the persisted cursor.
+	h, err = newHTTPClient(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer h.CloseAsync()
+
+	msg, _, err := h.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `{"items":"third"}`, string(msg.Get(0).Get()))
+}
+
+func TestHTTPClientPaginationStreamErr(t *testing.T) {
+	conf := NewHTTPClientConfig()
+	conf.Stream.Enabled = true
+	conf.Pagination.CursorMapping = `root = this.next`
+
+	_, err := newHTTPClient(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "pagination cannot be combined with streaming mode")
+}
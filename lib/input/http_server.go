@@ -3,6 +3,7 @@ package input
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
@@ -28,6 +30,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	httputil "github.com/Jeffail/benthos/v3/lib/util/http"
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
 	"github.com/Jeffail/benthos/v3/lib/util/throttle"
 	"github.com/gorilla/websocket"
 	"github.com/opentracing/opentracing-go"
@@ -61,6 +64,33 @@ customise headers with the ` + "`sync_response` field `headers`" + `, which can
 also use [function interpolation](/docs/configuration/interpolation#bloblang-queries)
 in the value based on the response message contents.
 
+The ` + "`sync_response` field `mapping`" + ` allows you to compute the body and
+metadata of each response message with a [Bloblang mapping](/docs/guides/bloblang/about)
+before the status and headers are resolved, which makes it possible to return
+different responses depending on the outcome of processing:
+
+` + "```yaml" + `
+input:
+  http_server:
+    path: /orders
+    sync_response:
+      status: ${! meta("status") }
+      headers:
+        Content-Type: application/json
+      mapping: |
+        meta status = if errored() { "400" } else { "201" }
+        root = if errored() {
+          { "error": error() }
+        } else {
+          { "id": this.id }
+        }
+` + "```" + `
+
+When a response consists of multiple messages they are returned as a multipart
+body by default. Setting the ` + "`sync_response` field `streaming`" + ` to
+` + "`true`" + ` instead writes each message as a chunk of a chunked response,
+flushing it to the client as soon as it is written.
+
 ### Endpoints
 
 The following fields specify endpoints that are registered for sending messages:
@@ -111,6 +141,7 @@ You can access these metadata fields using
 			docs.FieldCommon("rate_limit", "An optional [rate limit](/docs/components/rate_limits/about) to throttle requests by."),
 			docs.FieldAdvanced("cert_file", "Only valid with a custom `address`."),
 			docs.FieldAdvanced("key_file", "Only valid with a custom `address`."),
+			auth.ServerFieldSpec().AtVersion("3.44.0"),
 			docs.FieldAdvanced("sync_response", "Customise messages returned via [synchronous responses](/docs/guides/sync_responses).").WithChildren(
 				docs.FieldCommon(
 					"status",
@@ -118,6 +149,12 @@ You can access these metadata fields using
 					"200", `${! json("status") }`, `${! meta("status") }`,
 				).IsInterpolated(),
 				docs.FieldCommon("headers", "Specify headers to return with synchronous responses.").IsInterpolated().Map(),
+				docs.FieldAdvanced(
+					"mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) to execute on each response message before the status and headers are resolved. Response messages that are deleted by the mapping are not returned.",
+					`root = { "result": this }`,
+					`meta status = if errored() { "500" } else { "200" }`,
+				).AtVersion("3.44.0"),
+				docs.FieldAdvanced("streaming", "Whether responses consisting of multiple messages should be written as a chunked response, where each message is flushed to the client as it is written, rather than as a multipart body.").AtVersion("3.44.0"),
 			),
 		},
 		Categories: []Category{
@@ -131,8 +168,10 @@ You can access these metadata fields using
 // HTTPServerResponseConfig provides config fields for customising the response
 // given from successful requests.
 type HTTPServerResponseConfig struct {
-	Status  string            `json:"status" yaml:"status"`
-	Headers map[string]string `json:"headers" yaml:"headers"`
+	Status    string            `json:"status" yaml:"status"`
+	Headers   map[string]string `json:"headers" yaml:"headers"`
+	Mapping   string            `json:"mapping" yaml:"mapping"`
+	Streaming bool              `json:"streaming" yaml:"streaming"`
 }
 
 // NewHTTPServerResponseConfig creates a new HTTPServerConfig with default values.
@@ -142,6 +181,8 @@ func NewHTTPServerResponseConfig() HTTPServerResponseConfig {
 		Headers: map[string]string{
 			"Content-Type": "application/octet-stream",
 		},
+		Mapping:   "",
+		Streaming: false,
 	}
 }
 
@@ -157,6 +198,7 @@ type HTTPServerConfig struct {
 	RateLimit          string                   `json:"rate_limit" yaml:"rate_limit"`
 	CertFile           string                   `json:"cert_file" yaml:"cert_file"`
 	KeyFile            string                   `json:"key_file" yaml:"key_file"`
+	Auth               auth.ServerConfig        `json:"auth" yaml:"auth"`
 	Response           HTTPServerResponseConfig `json:"sync_response" yaml:"sync_response"`
 }
 
@@ -175,6 +217,7 @@ func NewHTTPServerConfig() HTTPServerConfig {
 		RateLimit: "",
 		CertFile:  "",
 		KeyFile:   "",
+		Auth:      auth.NewServerConfig(),
 		Response:  NewHTTPServerResponseConfig(),
 	}
 }
@@ -201,6 +244,7 @@ type HTTPServer struct {
 
 	responseStatus  field.Expression
 	responseHeaders map[string]field.Expression
+	responseMapping *mapping.Executor
 
 	handlerWG    sync.WaitGroup
 	transactions chan types.Transaction
@@ -232,9 +276,25 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 	var mux *http.ServeMux
 	var server *http.Server
 
+	serverAuth, err := auth.NewServerAuth(conf.HTTPServer.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auth: %v", err)
+	}
+
 	if len(conf.HTTPServer.Address) > 0 {
 		mux = http.NewServeMux()
-		server = &http.Server{Addr: conf.HTTPServer.Address, Handler: mux}
+		server = &http.Server{Addr: conf.HTTPServer.Address, Handler: serverAuth.Wrap(mux)}
+		if len(conf.HTTPServer.Auth.ClientCAFile) > 0 {
+			if len(conf.HTTPServer.CertFile) == 0 || len(conf.HTTPServer.KeyFile) == 0 {
+				return nil, errors.New("cert_file and key_file must be specified in order to use a client_ca_file")
+			}
+			server.TLSConfig = &tls.Config{}
+			if err := conf.HTTPServer.Auth.ApplyTLS(server.TLSConfig); err != nil {
+				return nil, err
+			}
+		}
+	} else if len(conf.HTTPServer.Auth.ClientCAFile) > 0 {
+		return nil, errors.New("a client_ca_file can only be used with a custom address")
 	}
 
 	var timeout time.Duration
@@ -293,7 +353,6 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 		mAsyncSucc:     stats.GetCounter("send.async_success"),
 	}
 
-	var err error
 	if h.responseStatus, err = bloblang.NewField(h.conf.HTTPServer.Response.Status); err != nil {
 		return nil, fmt.Errorf("failed to parse response status expression: %v", err)
 	}
@@ -302,6 +361,11 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 			return nil, fmt.Errorf("failed to parse response header '%v' expression: %v", k, err)
 		}
 	}
+	if len(h.conf.HTTPServer.Response.Mapping) > 0 {
+		if h.responseMapping, err = bloblang.NewMapping("", h.conf.HTTPServer.Response.Mapping); err != nil {
+			return nil, fmt.Errorf("failed to parse response mapping: %v", err)
+		}
+	}
 
 	postHdlr := httputil.GzipHandler(h.postHandler)
 	wsHdlr := httputil.GzipHandler(h.wsHandler)
@@ -313,6 +377,7 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 			mux.HandleFunc(h.conf.HTTPServer.WSPath, wsHdlr)
 		}
 	} else {
+		postHdlr, wsHdlr = serverAuth.WrapFunc(postHdlr), serverAuth.WrapFunc(wsHdlr)
 		if len(h.conf.HTTPServer.Path) > 0 {
 			mgr.RegisterEndpoint(
 				h.conf.HTTPServer.Path, "Post a message into Benthos.", postHdlr,
@@ -396,6 +461,33 @@ func extractMessageFromRequest(r *http.Request) (types.Message, error) {
 	return msg, nil
 }
 
+// getResponse combines the messages of a result store into a single response
+// message, executing the response mapping on each message when configured.
+func (h *HTTPServer) getResponse(store roundtrip.ResultStore) (types.Message, error) {
+	responseMsg := message.New(nil)
+	for _, resMsg := range store.Get() {
+		resMsg.Iter(func(i int, part types.Part) error {
+			responseMsg.Append(part)
+			return nil
+		})
+	}
+	if h.responseMapping == nil {
+		return responseMsg, nil
+	}
+
+	mappedMsg := message.New(nil)
+	for i := 0; i < responseMsg.Len(); i++ {
+		part, err := h.responseMapping.MapPart(i, responseMsg)
+		if err != nil {
+			return nil, err
+		}
+		if part != nil {
+			mappedMsg.Append(part)
+		}
+	}
+	return mappedMsg, nil
+}
+
 func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 	h.handlerWG.Add(1)
 	defer h.handlerWG.Done()
@@ -479,12 +571,11 @@ func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responseMsg := message.New(nil)
-	for _, resMsg := range store.Get() {
-		resMsg.Iter(func(i int, part types.Part) error {
-			responseMsg.Append(part)
-			return nil
-		})
+	responseMsg, err := h.getResponse(store)
+	if err != nil {
+		h.log.Errorf("Failed to execute sync response mapping: %v\n", err)
+		w.WriteHeader(http.StatusBadGateway)
+		return
 	}
 	if responseMsg.Len() > 0 {
 		for k, v := range h.responseHeaders {
@@ -507,6 +598,21 @@ func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 			}
 			w.WriteHeader(statusCode)
 			w.Write(payload)
+		} else if plen > 1 && h.conf.HTTPServer.Response.Streaming {
+			if len(w.Header().Get("Content-Type")) == 0 {
+				w.Header().Set("Content-Type", http.DetectContentType(responseMsg.Get(0).Get()))
+			}
+			w.WriteHeader(statusCode)
+			flusher, _ := w.(http.Flusher)
+			for i := 0; i < plen; i++ {
+				if _, werr := w.Write(responseMsg.Get(i).Get()); werr != nil {
+					h.log.Errorf("Failed to stream sync response: %v\n", werr)
+					return
+				}
+				if flusher != nil {
+					flusher.Flush()
+				}
+			}
 		} else if plen > 1 {
 			customContentType, customContentTypeExists := h.responseHeaders["Content-Type"]
 
@@ -646,12 +752,12 @@ func (h *HTTPServer) wsHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		for _, responseMsg := range store.Get() {
-			if err := responseMsg.Iter(func(i int, part types.Part) error {
-				return ws.WriteMessage(websocket.TextMessage, part.Get())
-			}); err != nil {
-				h.log.Errorf("Failed to send sync response over websocket: %v\n", err)
-			}
+		if responseMsg, err := h.getResponse(store); err != nil {
+			h.log.Errorf("Failed to execute sync response mapping: %v\n", err)
+		} else if err = responseMsg.Iter(func(i int, part types.Part) error {
+			return ws.WriteMessage(websocket.TextMessage, part.Get())
+		}); err != nil {
+			h.log.Errorf("Failed to send sync response over websocket: %v\n", err)
 		}
 
 		tracing.FinishSpans(msg)
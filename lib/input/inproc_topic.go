@@ -0,0 +1,170 @@
+package input
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/topic"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeInprocTopic] = TypeSpec{
+		constructor: fromSimpleConstructor(NewInprocTopic),
+		Status:      docs.StatusExperimental,
+		Version:     "3.44.0",
+		Summary: `
+Consumes messages from a named topic shared by all streams of a Benthos process.`,
+		Description: `
+Topics are written to by ` + "[`inproc_topic` outputs](/docs/components/outputs/inproc_topic)" + `, and unlike the ` + "[`inproc` input](/docs/components/inputs/inproc)" + ` any number of streams can both publish to and consume from the same topic.
+
+Each consumer group of a topic has its own cursor and therefore receives every message published to the topic, whereas inputs that share a group divide the messages of that group between them. When the field ` + "`group`" + ` is left empty the input is given a group of its own. New groups begin reading from the oldest message retained by the topic.
+
+Messages that are rejected downstream are redelivered to the group. When the last input of a group is closed the group is removed from the topic, along with any messages that it has not yet acknowledged, so that it no longer blocks producers.
+
+### Metrics
+
+This input emits the gauge ` + "`topic.lag`" + `, which is the number of messages published to the topic that have not yet been read by its group.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("topic", "The name of the topic to consume from."),
+			docs.FieldCommon("group", "An optional consumer group to join."),
+		},
+		Categories: []Category{
+			CategoryUtility,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title:   "Fan-in and Fan-out",
+				Summary: "When running in streams mode a stream can publish to a topic:",
+				Config: `
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ orders ]
+    consumer_group: benthos
+
+output:
+  inproc_topic:
+    topic: orders
+`,
+			},
+			{
+				Title:   "Consuming a Topic",
+				Summary: "Which can then be consumed by any number of other streams, each receiving every message:",
+				Config: `
+input:
+  inproc_topic:
+    topic: orders
+
+output:
+  http_client:
+    url: http://localhost:8080/orders
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// InprocTopicConfig contains configuration fields for the InprocTopic input
+// type.
+type InprocTopicConfig struct {
+	Topic string `json:"topic" yaml:"topic"`
+	Group string `json:"group" yaml:"group"`
+}
+
+// NewInprocTopicConfig creates a new InprocTopicConfig with default values.
+func NewInprocTopicConfig() InprocTopicConfig {
+	return InprocTopicConfig{
+		Topic: "",
+		Group: "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewInprocTopic creates a new InprocTopic input type.
+func NewInprocTopic(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	rdr, err := newInprocTopicReader(conf.InprocTopic, mgr, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypeInprocTopic, false, rdr, log, stats)
+}
+
+type inprocTopicReader struct {
+	topic *topic.Topic
+	group string
+
+	subMut sync.Mutex
+	sub    *topic.Subscription
+
+	mLag metrics.StatGauge
+}
+
+func newInprocTopicReader(conf InprocTopicConfig, mgr types.Manager, stats metrics.Type) (*inprocTopicReader, error) {
+	if len(conf.Topic) == 0 {
+		return nil, errors.New("a topic must be specified")
+	}
+	t, err := topic.FromManager(mgr, conf.Topic)
+	if err != nil {
+		return nil, err
+	}
+	return &inprocTopicReader{
+		topic: t,
+		group: conf.Group,
+		mLag:  stats.GetGauge("topic.lag"),
+	}, nil
+}
+
+func (r *inprocTopicReader) ConnectWithContext(ctx context.Context) error {
+	r.subMut.Lock()
+	defer r.subMut.Unlock()
+	if r.sub == nil {
+		r.sub = r.topic.Subscribe(r.group)
+	}
+	return nil
+}
+
+func (r *inprocTopicReader) ReadWithContext(ctx context.Context) (types.Message, reader.AsyncAckFn, error) {
+	r.subMut.Lock()
+	sub := r.sub
+	r.subMut.Unlock()
+	if sub == nil {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	msg, ackFn, err := sub.Next(ctx)
+	if err != nil {
+		if err == topic.ErrClosed {
+			err = types.ErrTypeClosed
+		}
+		return nil, nil, err
+	}
+	r.mLag.Set(sub.Lag())
+	return msg, func(ctx context.Context, res types.Response) error {
+		ackFn(res.Error())
+		return nil
+	}, nil
+}
+
+func (r *inprocTopicReader) CloseAsync() {
+	r.subMut.Lock()
+	if r.sub != nil {
+		r.sub.Close()
+	}
+	r.subMut.Unlock()
+}
+
+func (r *inprocTopicReader) WaitForClose(timeout time.Duration) error {
+	return nil
+}
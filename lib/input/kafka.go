@@ -12,6 +12,7 @@ import (
 
 	"github.com/Jeffail/benthos/v3/internal/checkpoint"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/fairness"
 	"github.com/Jeffail/benthos/v3/lib/input/reader"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
@@ -54,7 +55,13 @@ This input adds the following metadata fields to each message:
 
 The field ` + "`kafka_lag`" + ` is the calculated difference between the high water mark offset of the partition at the time of ingestion and the current message offset.
 
-You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`,
+Header values are copied into metadata byte for byte. Binary header values can instead be base64 encoded by setting the field ` + "[`header_encoding`](#header_encoding)" + ` to ` + "`base64`" + `, in which case they can be decoded within a mapping with ` + "`meta(\"foo\").decode(\"base64\")`" + `.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).
+
+### Fairness
+
+By default messages are read from whichever partitions have them ready, which means that when processing can't keep up a partition with a large backlog can dominate. Setting ` + "[`fairness.enabled`](#fairnessenabled)" + ` to ` + "`true`" + ` instead interleaves the messages of partitions that have them ready, giving each partition turns in proportion to its weight. Weights can be set per topic or per partition with the field ` + "[`fairness.weights`](#fairnessweights)" + `.`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon(
 				"addresses", "A list of broker addresses to connect to. If an item of the list contains commas it will be expanded into multiple addresses.",
@@ -86,6 +93,14 @@ You can access these metadata fields using [function interpolation](/docs/config
 			),
 			docs.FieldAdvanced("fetch_buffer_cap", "The maximum number of unprocessed messages to fetch at a given time."),
 			docs.FieldAdvanced("target_version", "The version of the Kafka protocol to use."),
+			docs.FieldAdvanced("header_encoding", "The encoding of header values when they are added to message metadata.").HasAnnotatedOptions(
+				"none", "Copy header values verbatim.",
+				"base64", "Encode header values as base64, which is useful when headers contain binary data.",
+			).AtVersion("3.44.0"),
+			fairness.FieldSpec(
+				"A map of topics, or partitions of a topic in the form `foo:0`, to integer weights. Partitions without a weight of their own take the weight of their topic, and topics without a weight have a weight of 1.",
+				map[string]interface{}{"foo": 3, "bar:0": 2},
+			),
 			func() docs.FieldSpec {
 				b := batch.FieldSpec()
 				b.Advanced = true
@@ -170,6 +185,7 @@ type kafkaReader struct {
 	consumerCloseFn context.CancelFunc
 	consumerDoneCtx context.Context
 	msgChan         chan asyncMessage
+	scheduler       *fairness.Scheduler
 	session         offsetMarker
 
 	mRebalanced metrics.StatCounter
@@ -231,6 +247,14 @@ func newKafkaReader(
 		closedChan:      make(chan struct{}),
 		topicPartitions: map[string][]int32{},
 	}
+	switch conf.HeaderEncoding {
+	case "none", "base64":
+	default:
+		return nil, fmt.Errorf("header_encoding not recognised: %v", conf.HeaderEncoding)
+	}
+	if err := conf.Fairness.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to parse fairness weights: %w", err)
+	}
 	if conf.TLS.Enabled {
 		var err error
 		if k.tlsConf, err = conf.TLS.Get(); err != nil {
@@ -328,8 +352,7 @@ func (k *kafkaReader) asyncCheckpointer(topic string, partition int32) func(cont
 			}
 			return false
 		}
-		select {
-		case c <- asyncMessage{
+		return k.sendMessage(ctx, c, topic, partition, asyncMessage{
 			msg: msg,
 			ackFn: func(ctx context.Context, res types.Response) error {
 				maxOffset, err := cp.Resolve(int(offset))
@@ -346,11 +369,7 @@ func (k *kafkaReader) asyncCheckpointer(topic string, partition int32) func(cont
 				k.cMut.Unlock()
 				return nil
 			},
-		}:
-		case <-ctx.Done():
-			return false
-		}
-		return true
+		})
 	}
 }
 
@@ -360,8 +379,7 @@ func (k *kafkaReader) syncCheckpointer(topic string, partition int32) func(conte
 		if msg == nil {
 			return true
 		}
-		select {
-		case c <- asyncMessage{
+		if !k.sendMessage(ctx, c, topic, partition, asyncMessage{
 			msg: msg,
 			ackFn: func(ctx context.Context, res types.Response) error {
 				resErr := res.Error()
@@ -381,14 +399,13 @@ func (k *kafkaReader) syncCheckpointer(topic string, partition int32) func(conte
 				}
 				return nil
 			},
-		}:
-			select {
-			case resErr := <-ackedChan:
-				if resErr != nil {
-					k.log.Errorf("Received error from message batch: %v, shutting down consumer.\n", resErr)
-					return false
-				}
-			case <-ctx.Done():
+		}) {
+			return false
+		}
+		select {
+		case resErr := <-ackedChan:
+			if resErr != nil {
+				k.log.Errorf("Received error from message batch: %v, shutting down consumer.\n", resErr)
 				return false
 			}
 		case <-ctx.Done():
@@ -398,12 +415,50 @@ func (k *kafkaReader) syncCheckpointer(topic string, partition int32) func(conte
 	}
 }
 
-func dataToPart(highestOffset int64, data *sarama.ConsumerMessage) types.Part {
+// newScheduler returns a scheduler for interleaving the messages of consumed
+// partitions when fairness is enabled, otherwise nil.
+func (k *kafkaReader) newScheduler() *fairness.Scheduler {
+	if !k.conf.Fairness.Enabled {
+		return nil
+	}
+	return fairness.NewScheduler(k.partitionWeight)
+}
+
+// partitionWeight returns the fairness weight of a partition in the form
+// topic:partition, falling back to the weight of the topic.
+func (k *kafkaReader) partitionWeight(source string) int {
+	topic := source
+	if i := strings.LastIndex(source, ":"); i >= 0 {
+		topic = source[:i]
+	}
+	return k.conf.Fairness.Weight(source, topic)
+}
+
+// sendMessage passes a message consumed from a topic partition to the reader,
+// via the fairness scheduler when enabled. Returns false if the context is
+// cancelled or the connection is closed before the message is read.
+func (k *kafkaReader) sendMessage(ctx context.Context, c chan<- asyncMessage, topic string, partition int32, m asyncMessage) bool {
+	k.cMut.Lock()
+	scheduler := k.scheduler
+	k.cMut.Unlock()
+
+	if scheduler != nil {
+		return scheduler.Send(ctx, topic+":"+strconv.Itoa(int(partition)), m) == nil
+	}
+	select {
+	case c <- m:
+	case <-ctx.Done():
+		return false
+	}
+	return true
+}
+
+func dataToPart(highestOffset int64, headerEncoding string, data *sarama.ConsumerMessage) types.Part {
 	part := message.NewPart(data.Value)
 
 	meta := part.Metadata()
 	for _, hdr := range data.Headers {
-		meta.Set(string(hdr.Key), string(hdr.Value))
+		meta.Set(string(hdr.Key), reader.KafkaHeaderValue(headerEncoding, hdr.Value))
 	}
 
 	lag := highestOffset - data.Offset - 1
@@ -493,12 +548,25 @@ func (k *kafkaReader) ConnectWithContext(ctx context.Context) error {
 func (k *kafkaReader) ReadWithContext(ctx context.Context) (types.Message, reader.AsyncAckFn, error) {
 	k.cMut.Lock()
 	msgChan := k.msgChan
+	scheduler := k.scheduler
 	k.cMut.Unlock()
 
 	if msgChan == nil {
 		return nil, nil, types.ErrNotConnected
 	}
 
+	if scheduler != nil {
+		v, err := scheduler.Receive(ctx)
+		if err != nil {
+			if err == fairness.ErrClosed {
+				return nil, nil, types.ErrNotConnected
+			}
+			return nil, nil, types.ErrTimeout
+		}
+		m := v.(asyncMessage)
+		return m.msg, m.ackFn, nil
+	}
+
 	select {
 	case m, open := <-msgChan:
 		if !open {
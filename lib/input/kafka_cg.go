@@ -73,7 +73,7 @@ func (k *kafkaReader) ConsumeClaim(sess sarama.ConsumerGroupSession, claim saram
 			}
 
 			latestOffset = data.Offset
-			part := dataToPart(claim.HighWaterMarkOffset(), data)
+			part := dataToPart(claim.HighWaterMarkOffset(), k.conf.HeaderEncoding, data)
 
 			if batchPolicy.Add(part) {
 				nextTimedBatchChan = nil
@@ -150,10 +150,15 @@ func (k *kafkaReader) connectBalancedTopics(ctx context.Context, config *sarama.
 			close(k.msgChan)
 			k.msgChan = nil
 		}
+		if k.scheduler != nil {
+			k.scheduler.Close()
+			k.scheduler = nil
+		}
 		k.cMut.Unlock()
 	}()
 
 	k.msgChan = make(chan asyncMessage)
+	k.scheduler = k.newScheduler()
 	k.consumerDoneCtx = consumerDoneCtx
 	k.log.Infof("Consuming kafka topics %v from brokers %s as group '%v'\n", k.balancedTopics, k.addresses, k.conf.ConsumerGroup)
 	return nil
@@ -74,7 +74,7 @@ partMsgLoop:
 			k.log.Tracef("Received message from topic %v partition %v\n", topic, partition)
 
 			latestOffset = data.Offset
-			part := dataToPart(consumer.HighWaterMarkOffset(), data)
+			part := dataToPart(consumer.HighWaterMarkOffset(), k.conf.HeaderEncoding, data)
 
 			if batchPolicy.Add(part) {
 				nextTimedBatchChan = nil
@@ -265,6 +265,10 @@ func (k *kafkaReader) connectExplicitTopics(ctx context.Context, config *sarama.
 			close(k.msgChan)
 			k.msgChan = nil
 		}
+		if k.scheduler != nil {
+			k.scheduler.Close()
+			k.scheduler = nil
+		}
 		k.cMut.Unlock()
 
 		if coordinator != nil {
@@ -277,5 +281,6 @@ func (k *kafkaReader) connectExplicitTopics(ctx context.Context, config *sarama.
 	k.consumerDoneCtx = doneCtx
 	k.session = offsetTracker
 	k.msgChan = msgChan
+	k.scheduler = k.newScheduler()
 	return nil
 }
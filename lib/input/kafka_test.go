@@ -1,11 +1,17 @@
 package input
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Shopify/sarama"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestKafkaBadParams(t *testing.T) {
@@ -44,3 +50,68 @@ func TestKafkaBadParams(t *testing.T) {
 		})
 	}
 }
+
+func TestKafkaDataToPartHeaders(t *testing.T) {
+	data := &sarama.ConsumerMessage{
+		Value:     []byte("hello world"),
+		Topic:     "foo",
+		Partition: 1,
+		Offset:    10,
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte("bar"), Value: []byte{0xff, 0x00, 0x01}},
+		},
+	}
+
+	part := dataToPart(12, "none", data)
+	assert.Equal(t, string([]byte{0xff, 0x00, 0x01}), part.Metadata().Get("bar"))
+	assert.Equal(t, "1", part.Metadata().Get("kafka_lag"))
+
+	part = dataToPart(12, "base64", data)
+	assert.Equal(t, "/wAB", part.Metadata().Get("bar"))
+}
+
+func TestKafkaFairness(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeKafka
+	conf.Kafka.Addresses = []string{"example.com:1234"}
+	conf.Kafka.Topics = []string{"foo:0-1", "bar:0"}
+	conf.Kafka.Fairness.Enabled = true
+	conf.Kafka.Fairness.Weights = map[string]int{"foo": 0}
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "failed to create input 'kafka': failed to parse fairness weights: weight of source 'foo' must be greater than zero, received 0")
+
+	conf.Kafka.Fairness.Weights = map[string]int{"foo": 3, "foo:1": 2}
+	k, err := newKafkaReader(conf.Kafka, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, k.partitionWeight("foo:0"))
+	assert.Equal(t, 2, k.partitionWeight("foo:1"))
+	assert.Equal(t, 1, k.partitionWeight("bar:0"))
+
+	k.msgChan = make(chan asyncMessage)
+	k.scheduler = k.newScheduler()
+	require.NotNil(t, k.scheduler)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+
+	go func() {
+		assert.True(t, k.sendMessage(ctx, k.msgChan, "foo", 1, asyncMessage{
+			msg: message.New([][]byte{[]byte("hello world")}),
+		}))
+	}()
+
+	msg, _, err := k.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(msg.Get(0).Get()))
+
+	k.scheduler.Close()
+	_, _, err = k.ReadWithContext(ctx)
+	assert.Equal(t, types.ErrNotConnected, err)
+
+	conf.Kafka.Fairness.Enabled = false
+	k, err = newKafkaReader(conf.Kafka, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Nil(t, k.newScheduler())
+}
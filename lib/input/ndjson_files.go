@@ -0,0 +1,645 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/checkpoint"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	ifilepath "github.com/Jeffail/benthos/v3/internal/filepath"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/klauspost/compress/zstd"
+)
+
+func init() {
+	Constructors[TypeNDJSONFiles] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			r, err := newNDJSONFilesReader(conf.NDJSONFiles, mgr, log)
+			if err != nil {
+				return nil, err
+			}
+			return NewAsyncReader(TypeNDJSONFiles, true, reader.NewAsyncPreserver(r), log, stats)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `Reads directories of newline delimited JSON files, which may be gzip or zstd compressed, from the local filesystem or an S3 prefix with multiple parallel readers.`,
+		Description: `
+This input is intended for bulk reprocessing of large archives, where each line of a file is emitted as a message. Files are distributed between a number of ` + "`readers`" + `, each of which consumes a single file at a time from start to finish, and therefore messages of a file are emitted in the order in which they were written, whereas messages of different files are interleaved. Empty lines are skipped.
+
+Paths can be files, directories, which are consumed recursively, or glob patterns. Paths of the form ` + "`s3://bucket/prefix`" + ` list all objects of a bucket under a prefix, and the ` + "`s3`" + ` field configures how the bucket is accessed. Files are consumed in lexical order of their paths.
+
+The compression of each file is derived from its extension by default, where files ending in ` + "`.gz`" + ` are decompressed with gzip and files ending in ` + "`.zst`" + ` or ` + "`.zstd`" + ` are decompressed with zstd.
+
+A file that cannot be read, for example due to corruption, is logged and skipped without affecting the other files.
+
+### Checkpoints
+
+When a ` + "`checkpoint_cache`" + ` is set the line number of each file following its last acknowledged message is stored within the cache, and when the input is restarted each file is resumed from its first unacknowledged message. Since compressed files cannot be seeked the lines preceding a checkpoint are read and discarded, and a file that was fully consumed is therefore read once more without emitting any messages. Checkpoints can be inspected and reset via the ` + "`/checkpoints/{cache}`" + ` HTTP endpoint.
+
+### Progress
+
+The overall progress of the input can be obtained as a JSON object from the ` + "`/ndjson_files/progress`" + ` HTTP endpoint, which includes the number of files listed, completed and failed, the number of messages read and acknowledged, and the progress of each file currently being consumed:
+
+` + "```json" + `
+{
+  "files_total": 120,
+  "files_completed": 37,
+  "files_failed": 0,
+  "records_read": 1840020,
+  "records_acked": 1839500,
+  "in_progress": {
+    "/data/archive/2021-03-01.ndjson.gz": {"resumed_from_line": 0, "records_read": 20311, "records_acked": 20100, "reading": true}
+  }
+}
+` + "```" + `
+
+A file is considered completed once it has been read to the end and all of its messages have been acknowledged.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```text" + `
+- path
+- line
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("paths", "A list of files, directories or glob patterns to consume, where a path of the form `s3://bucket/prefix` consumes all objects of a bucket under a prefix.", []string{"./archive", "./archive/*.ndjson.gz"}, []string{"s3://foo-bucket/archive/2021/"}).Array(),
+			docs.FieldCommon("compression", "The compression of files, where `auto` derives the compression of each file from its extension.").HasOptions("auto", "none", "gzip", "zstd"),
+			docs.FieldCommon("readers", "The number of files to consume in parallel."),
+			docs.FieldAdvanced("max_buffer", "The largest line in bytes that is expected within a file."),
+			docs.FieldAdvanced("checkpoint_cache", "An optional [cache resource](/docs/components/caches/about) for storing the line number of each file as messages are acknowledged, allowing the input to resume each file from its first unacknowledged message after a restart."),
+			docs.FieldAdvanced("s3", "Configuration for accessing S3 buckets, which is only used when a path of the form `s3://bucket/prefix` is consumed.").WithChildren(
+				append(sess.FieldSpecs(),
+					docs.FieldAdvanced("force_path_style_urls", "Forces the client API to use path style URLs for downloading keys, which is often required when connecting to custom endpoints."),
+				)...,
+			),
+		},
+		Categories: []Category{
+			CategoryLocal,
+			CategoryAWS,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title:   "Reprocess an Archive",
+				Summary: "In order to reprocess a directory of compressed archives with eight parallel readers, resuming from where we left off should the process be restarted, we can store checkpoints in a file cache:",
+				Config: `
+input:
+  ndjson_files:
+    paths: [ ./archive ]
+    readers: 8
+    checkpoint_cache: checkpoints
+
+resources:
+  caches:
+    checkpoints:
+      file:
+        directory: ./checkpoints
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NDJSONFilesS3Config contains configuration fields for accessing the S3
+// buckets of an ndjson_files input.
+type NDJSONFilesS3Config struct {
+	sess.Config        `json:",inline" yaml:",inline"`
+	ForcePathStyleURLs bool `json:"force_path_style_urls" yaml:"force_path_style_urls"`
+}
+
+// NDJSONFilesConfig contains configuration fields for the ndjson_files input
+// type.
+type NDJSONFilesConfig struct {
+	Paths       []string            `json:"paths" yaml:"paths"`
+	Compression string              `json:"compression" yaml:"compression"`
+	Readers     int                 `json:"readers" yaml:"readers"`
+	MaxBuffer   int                 `json:"max_buffer" yaml:"max_buffer"`
+	Checkpoint  string              `json:"checkpoint_cache" yaml:"checkpoint_cache"`
+	S3          NDJSONFilesS3Config `json:"s3" yaml:"s3"`
+}
+
+// NewNDJSONFilesConfig creates a new NDJSONFilesConfig with default values.
+func NewNDJSONFilesConfig() NDJSONFilesConfig {
+	return NDJSONFilesConfig{
+		Paths:       []string{},
+		Compression: "auto",
+		Readers:     4,
+		MaxBuffer:   1000000,
+		Checkpoint:  "",
+		S3: NDJSONFilesS3Config{
+			Config:             sess.NewConfig(),
+			ForcePathStyleURLs: false,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type ndjsonFile struct {
+	path string
+	open func(ctx context.Context) (io.ReadCloser, error)
+}
+
+type ndjsonRecord struct {
+	msg   types.Message
+	ackFn reader.AsyncAckFn
+}
+
+type ndjsonFilesReader struct {
+	conf        NDJSONFilesConfig
+	log         log.Modular
+	checkpoints *checkpoint.Store
+	progress    *ndjsonProgress
+
+	mut     sync.Mutex
+	started bool
+	records chan ndjsonRecord
+
+	ctx        context.Context
+	done       func()
+	closedChan chan struct{}
+}
+
+func newNDJSONFilesReader(conf NDJSONFilesConfig, mgr types.Manager, log log.Modular) (*ndjsonFilesReader, error) {
+	if len(conf.Paths) == 0 {
+		return nil, errors.New("at least one path must be specified")
+	}
+	switch conf.Compression {
+	case "auto", "none", "gzip", "zstd":
+	default:
+		return nil, fmt.Errorf("compression not recognised: %v", conf.Compression)
+	}
+	if conf.Readers < 1 {
+		return nil, fmt.Errorf("readers must be greater than zero, received %v", conf.Readers)
+	}
+
+	var checkpoints *checkpoint.Store
+	if len(conf.Checkpoint) > 0 {
+		var err error
+		if checkpoints, err = checkpoint.GetStore(mgr, conf.Checkpoint); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &ndjsonFilesReader{
+		conf:        conf,
+		log:         log,
+		checkpoints: checkpoints,
+		progress:    newNDJSONProgress(),
+		records:     make(chan ndjsonRecord),
+		closedChan:  make(chan struct{}),
+	}
+	r.ctx, r.done = context.WithCancel(context.Background())
+
+	mgr.RegisterEndpoint(
+		"/ndjson_files/progress",
+		"Get the progress of consuming files from an ndjson_files input.",
+		r.progress.handleHTTP,
+	)
+	return r, nil
+}
+
+// listFiles expands the configured paths into the files to consume, sorted
+// by path.
+func (n *ndjsonFilesReader) listFiles(ctx context.Context) ([]ndjsonFile, error) {
+	var localPaths []string
+	var s3Client *s3.S3
+
+	files := map[string]ndjsonFile{}
+	for _, p := range n.conf.Paths {
+		if !strings.HasPrefix(p, "s3://") {
+			localPaths = append(localPaths, p)
+			continue
+		}
+		if s3Client == nil {
+			awsSess, err := n.conf.S3.GetSession(func(c *aws.Config) {
+				c.S3ForcePathStyle = aws.Bool(n.conf.S3.ForcePathStyleURLs)
+			})
+			if err != nil {
+				return nil, err
+			}
+			s3Client = s3.New(awsSess)
+		}
+		if err := listNDJSONObjects(ctx, s3Client, p, files); err != nil {
+			return nil, err
+		}
+	}
+
+	expanded, err := ifilepath.Globs(localPaths)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range expanded {
+		if err := filepath.Walk(p, func(path string, info os.FileInfo, werr error) error {
+			if werr != nil {
+				return werr
+			}
+			if info.Mode().IsRegular() {
+				files[path] = ndjsonFile{
+					path: path,
+					open: func(context.Context) (io.ReadCloser, error) {
+						return os.Open(path)
+					},
+				}
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	sorted := make([]ndjsonFile, 0, len(files))
+	for _, f := range files {
+		sorted = append(sorted, f)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].path < sorted[j].path
+	})
+	return sorted, nil
+}
+
+func listNDJSONObjects(ctx context.Context, client *s3.S3, path string, files map[string]ndjsonFile) error {
+	u, err := url.Parse(path)
+	if err != nil {
+		return fmt.Errorf("failed to parse S3 path '%v': %w", path, err)
+	}
+	bucket, prefix := u.Host, strings.TrimPrefix(u.Path, "/")
+
+	return client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, obj := range page.Contents {
+			if obj.Key == nil || strings.HasSuffix(*obj.Key, "/") {
+				continue
+			}
+			key := *obj.Key
+			objPath := "s3://" + bucket + "/" + key
+			files[objPath] = ndjsonFile{
+				path: objPath,
+				open: func(ctx context.Context) (io.ReadCloser, error) {
+					out, err := client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+						Bucket: aws.String(bucket),
+						Key:    aws.String(key),
+					})
+					if err != nil {
+						return nil, err
+					}
+					return out.Body, nil
+				},
+			}
+		}
+		return true
+	})
+}
+
+// ConnectWithContext lists the files to consume and starts the readers that
+// consume them.
+func (n *ndjsonFilesReader) ConnectWithContext(ctx context.Context) error {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	if n.started {
+		return nil
+	}
+	if n.ctx.Err() != nil {
+		return types.ErrTypeClosed
+	}
+
+	files, err := n.listFiles(ctx)
+	if err != nil {
+		return err
+	}
+	n.progress.setTotal(len(files))
+	n.started = true
+
+	fileChan := make(chan ndjsonFile, len(files))
+	for _, f := range files {
+		fileChan <- f
+	}
+	close(fileChan)
+
+	var wg sync.WaitGroup
+	wg.Add(n.conf.Readers)
+	for i := 0; i < n.conf.Readers; i++ {
+		go func() {
+			defer wg.Done()
+			for f := range fileChan {
+				if err := n.consumeFile(f); err != nil {
+					if n.ctx.Err() != nil {
+						return
+					}
+					n.log.Errorf("Failed to consume file '%v': %v\n", f.path, err)
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(n.records)
+		close(n.closedChan)
+	}()
+
+	n.log.Infof("Consuming %v files with %v readers\n", len(files), n.conf.Readers)
+	return nil
+}
+
+func (n *ndjsonFilesReader) decompress(path string, r io.Reader) (io.Reader, func(), error) {
+	compression := n.conf.Compression
+	if compression == "auto" {
+		switch {
+		case strings.HasSuffix(path, ".gz"):
+			compression = "gzip"
+		case strings.HasSuffix(path, ".zst"), strings.HasSuffix(path, ".zstd"):
+			compression = "zstd"
+		default:
+			compression = "none"
+		}
+	}
+	switch compression {
+	case "gzip":
+		g, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return g, func() { g.Close() }, nil
+	case "zstd":
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return d, d.Close, nil
+	}
+	return r, func() {}, nil
+}
+
+// consumeFile reads each line of a file in order, sending a record for each
+// non-empty line until the file is exhausted or the reader is closed.
+func (n *ndjsonFilesReader) consumeFile(f ndjsonFile) (err error) {
+	var source *checkpoint.Source
+	if n.checkpoints != nil {
+		if source, err = n.checkpoints.Source(f.path); err != nil {
+			return err
+		}
+	}
+
+	var resume int64
+	if source != nil {
+		resume = source.Resume()
+	}
+
+	prog := n.progress.start(f.path, resume)
+	defer func() {
+		n.progress.finish(prog, err)
+	}()
+
+	rc, err := f.open(n.ctx)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	r, closeFn, err := n.decompress(f.path, rc)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	if resume > 0 {
+		n.log.Infof("Resuming file '%v' from line %v\n", f.path, resume+1)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, n.conf.MaxBuffer)
+
+	var line int64
+	for scanner.Scan() {
+		if line++; line <= resume {
+			// Lines prior to the checkpoint were acknowledged before the
+			// file was last consumed.
+			continue
+		}
+
+		// Lines are tracked as positions such that the checkpoint of a file
+		// is the number of lines preceding its first unacknowledged message.
+		checkpointFn := func() error { return nil }
+		if source != nil {
+			checkpointFn = source.Track(line)
+		}
+
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			if err := checkpointFn(); err != nil {
+				n.log.Errorf("Failed to store checkpoint: %v\n", err)
+			}
+			continue
+		}
+
+		part := message.NewPart(append([]byte(nil), scanner.Bytes()...))
+		part.Metadata().Set("path", f.path)
+		part.Metadata().Set("line", strconv.FormatInt(line, 10))
+		msg := message.New(nil)
+		msg.Append(part)
+
+		n.progress.read(prog)
+		select {
+		case n.records <- ndjsonRecord{
+			msg: msg,
+			ackFn: func(ctx context.Context, res types.Response) error {
+				if res.Error() == nil {
+					if err := checkpointFn(); err != nil {
+						n.log.Errorf("Failed to store checkpoint: %v\n", err)
+					}
+					n.progress.ack(prog)
+				}
+				return nil
+			},
+		}:
+		case <-n.ctx.Done():
+			return n.ctx.Err()
+		}
+	}
+	return scanner.Err()
+}
+
+// ReadWithContext attempts to read a new message from the files being
+// consumed.
+func (n *ndjsonFilesReader) ReadWithContext(ctx context.Context) (types.Message, reader.AsyncAckFn, error) {
+	n.mut.Lock()
+	started := n.started
+	n.mut.Unlock()
+	if !started {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	select {
+	case rec, open := <-n.records:
+		if !open {
+			return nil, nil, types.ErrTypeClosed
+		}
+		return rec.msg, rec.ackFn, nil
+	case <-ctx.Done():
+		return nil, nil, types.ErrTimeout
+	}
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (n *ndjsonFilesReader) CloseAsync() {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	n.done()
+	if !n.started {
+		n.started = true
+		close(n.records)
+		close(n.closedChan)
+	}
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (n *ndjsonFilesReader) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-n.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type ndjsonFileProgress struct {
+	path         string
+	ResumedFrom  int64 `json:"resumed_from_line"`
+	RecordsRead  int64 `json:"records_read"`
+	RecordsAcked int64 `json:"records_acked"`
+	Reading      bool  `json:"reading"`
+}
+
+// ndjsonProgress tracks the files and records consumed by an ndjson_files
+// input.
+type ndjsonProgress struct {
+	mut          sync.Mutex
+	total        int
+	completed    int
+	failed       int
+	recordsRead  int64
+	recordsAcked int64
+	active       map[string]*ndjsonFileProgress
+}
+
+func newNDJSONProgress() *ndjsonProgress {
+	return &ndjsonProgress{
+		active: map[string]*ndjsonFileProgress{},
+	}
+}
+
+func (p *ndjsonProgress) setTotal(total int) {
+	p.mut.Lock()
+	p.total = total
+	p.mut.Unlock()
+}
+
+func (p *ndjsonProgress) start(path string, resumedFrom int64) *ndjsonFileProgress {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	f := &ndjsonFileProgress{
+		path:        path,
+		ResumedFrom: resumedFrom,
+		Reading:     true,
+	}
+	p.active[path] = f
+	return f
+}
+
+func (p *ndjsonProgress) read(f *ndjsonFileProgress) {
+	p.mut.Lock()
+	f.RecordsRead++
+	p.recordsRead++
+	p.mut.Unlock()
+}
+
+func (p *ndjsonProgress) ack(f *ndjsonFileProgress) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	f.RecordsAcked++
+	p.recordsAcked++
+	if !f.Reading && f.RecordsAcked == f.RecordsRead {
+		p.completeLocked(f)
+	}
+}
+
+// finish marks a file as having been read, where a file that was read to the
+// end is completed once all of its records are acknowledged.
+func (p *ndjsonProgress) finish(f *ndjsonFileProgress, err error) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	f.Reading = false
+	if err != nil {
+		delete(p.active, f.path)
+		if !errors.Is(err, context.Canceled) {
+			p.failed++
+		}
+		return
+	}
+	if f.RecordsAcked == f.RecordsRead {
+		p.completeLocked(f)
+	}
+}
+
+func (p *ndjsonProgress) completeLocked(f *ndjsonFileProgress) {
+	if _, exists := p.active[f.path]; exists {
+		delete(p.active, f.path)
+		p.completed++
+	}
+}
+
+func (p *ndjsonProgress) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mut.Lock()
+	resBytes, err := json.Marshal(map[string]interface{}{
+		"files_total":     p.total,
+		"files_completed": p.completed,
+		"files_failed":    p.failed,
+		"records_read":    p.recordsRead,
+		"records_acked":   p.recordsAcked,
+		"in_progress":     p.active,
+	})
+	p.mut.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resBytes)
+}
@@ -0,0 +1,186 @@
+package input
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeNDJSONFile(t *testing.T, path string, lines []string) {
+	t.Helper()
+
+	var raw bytes.Buffer
+	for _, l := range lines {
+		raw.WriteString(l)
+		raw.WriteByte('\n')
+	}
+
+	var buf bytes.Buffer
+	switch filepath.Ext(path) {
+	case ".gz":
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write(raw.Bytes())
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	case ".zst":
+		w, err := zstd.NewWriter(&buf)
+		require.NoError(t, err)
+		_, err = w.Write(raw.Bytes())
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	default:
+		buf = raw
+	}
+	require.NoError(t, ioutil.WriteFile(path, buf.Bytes(), 0644))
+}
+
+func readNDJSONRecord(t *testing.T, r *ndjsonFilesReader) (types.Message, func()) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+
+	msg, ackFn, err := r.ReadWithContext(ctx)
+	require.NoError(t, err)
+	return msg, func() {
+		require.NoError(t, ackFn(ctx, response.NewAck()))
+	}
+}
+
+func TestNDJSONFilesParallel(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string][]string{}
+	for _, name := range []string{"a.ndjson.gz", "b.ndjson.zst", "c.ndjson"} {
+		var lines []string
+		for i := 0; i < 20; i++ {
+			lines = append(lines, fmt.Sprintf(`{"file":"%v","n":%v}`, name, i))
+		}
+		path := filepath.Join(dir, name)
+		writeNDJSONFile(t, path, append(lines, ""))
+		files[path] = lines
+	}
+
+	conf := NewNDJSONFilesConfig()
+	conf.Paths = []string{dir}
+	conf.Readers = 2
+
+	r, err := newNDJSONFilesReader(conf, types.DudMgr{}, log.Noop())
+	require.NoError(t, err)
+	require.NoError(t, r.ConnectWithContext(context.Background()))
+
+	received := map[string][]string{}
+	for i := 0; i < 60; i++ {
+		msg, ackFn := readNDJSONRecord(t, r)
+		path := msg.Get(0).Metadata().Get("path")
+		received[path] = append(received[path], string(msg.Get(0).Get()))
+		assert.Equal(t, strconv.Itoa(len(received[path])), msg.Get(0).Metadata().Get("line"))
+		ackFn()
+	}
+
+	// Messages of each file are emitted in order.
+	assert.Equal(t, files, received)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+	_, _, err = r.ReadWithContext(ctx)
+	require.Equal(t, types.ErrTypeClosed, err)
+
+	rec := httptest.NewRecorder()
+	r.progress.handleHTTP(rec, httptest.NewRequest("GET", "/ndjson_files/progress", nil))
+
+	var progress map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &progress))
+	assert.Equal(t, map[string]interface{}{
+		"files_total":     3.0,
+		"files_completed": 3.0,
+		"files_failed":    0.0,
+		"records_read":    60.0,
+		"records_acked":   60.0,
+		"in_progress":     map[string]interface{}{},
+	}, progress)
+
+	r.CloseAsync()
+	require.NoError(t, r.WaitForClose(time.Second))
+}
+
+func TestNDJSONFilesCheckpointResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foo.ndjson.gz")
+	writeNDJSONFile(t, path, []string{`{"n":0}`, `{"n":1}`, `{"n":2}`, `{"n":3}`})
+
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	mgr := checkpointMgr{cache: memCache}
+
+	conf := NewNDJSONFilesConfig()
+	conf.Paths = []string{path}
+	conf.Checkpoint = "foo"
+
+	r, err := newNDJSONFilesReader(conf, mgr, log.Noop())
+	require.NoError(t, err)
+	require.NoError(t, r.ConnectWithContext(context.Background()))
+
+	for _, exp := range []string{`{"n":0}`, `{"n":1}`} {
+		msg, ackFn := readNDJSONRecord(t, r)
+		assert.Equal(t, exp, string(msg.Get(0).Get()))
+		ackFn()
+	}
+
+	// Read but never acknowledged.
+	msg, _ := readNDJSONRecord(t, r)
+	assert.Equal(t, `{"n":2}`, string(msg.Get(0).Get()))
+
+	r.CloseAsync()
+	require.NoError(t, r.WaitForClose(time.Second))
+
+	line, err := memCache.Get("benthos_checkpoint:" + path)
+	require.NoError(t, err)
+	assert.Equal(t, "2", string(line))
+
+	r, err = newNDJSONFilesReader(conf, mgr, log.Noop())
+	require.NoError(t, err)
+	require.NoError(t, r.ConnectWithContext(context.Background()))
+
+	for _, exp := range []string{`{"n":2}`, `{"n":3}`} {
+		msg, ackFn := readNDJSONRecord(t, r)
+		assert.Equal(t, exp, string(msg.Get(0).Get()))
+		assert.Equal(t, path, msg.Get(0).Metadata().Get("path"))
+		ackFn()
+	}
+
+	r.CloseAsync()
+	require.NoError(t, r.WaitForClose(time.Second))
+}
+
+func TestNDJSONFilesBadConfig(t *testing.T) {
+	conf := NewNDJSONFilesConfig()
+	_, err := newNDJSONFilesReader(conf, types.DudMgr{}, log.Noop())
+	require.EqualError(t, err, "at least one path must be specified")
+
+	conf.Paths = []string{"./foo"}
+	conf.Compression = "lz4"
+	_, err = newNDJSONFilesReader(conf, types.DudMgr{}, log.Noop())
+	require.EqualError(t, err, "compression not recognised: lz4")
+
+	conf.Compression = "auto"
+	conf.Readers = 0
+	_, err = newNDJSONFilesReader(conf, types.DudMgr{}, log.Noop())
+	require.EqualError(t, err, "readers must be greater than zero, received 0")
+}
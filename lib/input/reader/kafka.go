@@ -3,12 +3,14 @@ package reader
 import (
 	"context"
 	"crypto/tls"
+	"encoding/base64"
 	"fmt"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/fairness"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
@@ -37,6 +39,8 @@ type KafkaConfig struct {
 	Partition           int32                    `json:"partition" yaml:"partition"`
 	StartFromOldest     bool                     `json:"start_from_oldest" yaml:"start_from_oldest"`
 	TargetVersion       string                   `json:"target_version" yaml:"target_version"`
+	HeaderEncoding      string                   `json:"header_encoding" yaml:"header_encoding"`
+	Fairness            fairness.Config          `json:"fairness" yaml:"fairness"`
 	// TODO: V4 Remove this.
 	MaxBatchCount int                `json:"max_batch_count" yaml:"max_batch_count"`
 	TLS           btls.Config        `json:"tls" yaml:"tls"`
@@ -68,6 +72,8 @@ func NewKafkaConfig() KafkaConfig {
 		Partition:           0,
 		StartFromOldest:     true,
 		TargetVersion:       sarama.V1_0_0_0.String(),
+		HeaderEncoding:      "none",
+		Fairness:            fairness.NewConfig(),
 		MaxBatchCount:       1,
 		TLS:                 btls.NewConfig(),
 		SASL:                sasl.NewConfig(),
@@ -137,6 +143,16 @@ type Kafka struct {
 	closedChan chan struct{}
 }
 
+// KafkaHeaderValue returns the metadata value of a Kafka record header
+// according to a header encoding, where "base64" encodes the raw value of the
+// header and any other encoding copies it verbatim.
+func KafkaHeaderValue(encoding string, value []byte) string {
+	if encoding == "base64" {
+		return base64.StdEncoding.EncodeToString(value)
+	}
+	return string(value)
+}
+
 // NewKafka creates a new Kafka input type.
 func NewKafka(
 	conf KafkaConfig, mgr types.Manager, log log.Modular, stats metrics.Type,
@@ -366,7 +382,7 @@ func (k *Kafka) ReadNextWithContext(ctx context.Context) (types.Message, error)
 
 		meta := part.Metadata()
 		for _, hdr := range data.Headers {
-			meta.Set(string(hdr.Key), string(hdr.Value))
+			meta.Set(string(hdr.Key), KafkaHeaderValue(k.conf.HeaderEncoding, hdr.Value))
 		}
 
 		lag := hwm - data.Offset
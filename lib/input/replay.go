@@ -0,0 +1,357 @@
+package input
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/codec"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/replay"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeReplay] = TypeSpec{
+		constructor: fromSimpleConstructor(NewReplay),
+		Status:      docs.StatusExperimental,
+		Version:     "3.44.0",
+		Summary: `
+Reads a range of messages back from files archived by a ` + "[`file` output](/docs/components/outputs/file)" + ` with a replay index.`,
+		Description: `
+When the field ` + "`replay_index`" + ` of a ` + "`file`" + ` output is set an entry is appended to the index for each message written, recording the file it was written to, its position within that file, the time that it was written and an optional key. This input reads the index, selects the entries within a requested time and key range, and then reads only those messages from the archived files in the order that they were written.
+
+Archived files are read with a codec, which should match the codec used by the output that wrote them. Empty messages are not indexed and are skipped when read back.
+
+The rate at which messages are replayed can be controlled with a ` + "[`rate_limit` resource](/docs/components/rate_limits/about)" + `, which is useful for backfilling systems that cannot absorb the archive at full speed. Once all selected messages are consumed the input closes, which triggers a graceful shutdown of the pipeline.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```text" + `
+- path
+- replay_position
+- replay_timestamp
+- replay_key
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("index", "The path of a replay index written by a `file` output.", "/tmp/archive/index.jsonl"),
+			codec.ReaderDocs,
+			docs.FieldAdvanced("max_buffer", "The largest token size expected when consuming delimited files."),
+			docs.FieldAdvanced("max_message_size", "The largest message in bytes that will be consumed, records that exceed this size are rejected as they are read in order to prevent them from being loaded into memory. Set to zero in order to disable the limit.").AtVersion("3.44.0"),
+			docs.FieldCommon("start_time", "An optional RFC3339 timestamp, messages written before this time are not replayed.", "2021-04-01T00:00:00Z"),
+			docs.FieldCommon("end_time", "An optional RFC3339 timestamp, messages written at or after this time are not replayed.", "2021-04-02T00:00:00Z"),
+			docs.FieldAdvanced("start_key", "An optional key, messages with a replay key that sorts lexicographically before this key are not replayed."),
+			docs.FieldAdvanced("end_key", "An optional key, messages with a replay key that sorts lexicographically at or after this key are not replayed."),
+			docs.FieldCommon("rate_limit", "An optional [rate limit](/docs/components/rate_limits/about) to throttle the replay by."),
+		},
+		Categories: []Category{
+			CategoryLocal,
+			CategoryUtility,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title:   "Backfill a Day",
+				Summary: "Archiving messages to files with a replay index allows us to backfill a downstream system with the messages written during a single day, throttled by a rate limit:",
+				Config: `
+input:
+  replay:
+    index: /tmp/archive/index.jsonl
+    start_time: 2021-04-01T00:00:00Z
+    end_time: 2021-04-02T00:00:00Z
+    rate_limit: backfill
+
+rate_limit_resources:
+  - label: backfill
+    local:
+      count: 500
+      interval: 1s
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ReplayConfig contains configuration values for the Replay input type.
+type ReplayConfig struct {
+	Index          string `json:"index" yaml:"index"`
+	Codec          string `json:"codec" yaml:"codec"`
+	MaxBuffer      int    `json:"max_buffer" yaml:"max_buffer"`
+	MaxMessageSize int    `json:"max_message_size" yaml:"max_message_size"`
+	StartTime      string `json:"start_time" yaml:"start_time"`
+	EndTime        string `json:"end_time" yaml:"end_time"`
+	StartKey       string `json:"start_key" yaml:"start_key"`
+	EndKey         string `json:"end_key" yaml:"end_key"`
+	RateLimit      string `json:"rate_limit" yaml:"rate_limit"`
+}
+
+// NewReplayConfig creates a new ReplayConfig with default values.
+func NewReplayConfig() ReplayConfig {
+	return ReplayConfig{
+		Index:          "",
+		Codec:          "lines",
+		MaxBuffer:      1000000,
+		MaxMessageSize: 0,
+		StartTime:      "",
+		EndTime:        "",
+		StartKey:       "",
+		EndKey:         "",
+		RateLimit:      "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewReplay creates a new Replay input type.
+func NewReplay(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	rdr, err := newReplayConsumer(conf.Replay, mgr, log)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypeReplay, true, reader.NewAsyncPreserver(rdr), log, stats)
+}
+
+//------------------------------------------------------------------------------
+
+type replayConsumer struct {
+	log log.Modular
+
+	index       string
+	filter      replay.Filter
+	scannerCtor codec.ReaderConstructor
+	rateLimit   types.RateLimit
+
+	scannerMut sync.Mutex
+	selections []*replay.Selection
+	selected   bool
+	scanner    codec.Reader
+	current    *replay.Selection
+	position   int
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+func newReplayConsumer(conf ReplayConfig, mgr types.Manager, log log.Modular) (*replayConsumer, error) {
+	if len(conf.Index) == 0 {
+		return nil, errors.New("a replay index must be specified")
+	}
+
+	var filter replay.Filter
+	var err error
+	if len(conf.StartTime) > 0 {
+		if filter.StartTime, err = time.Parse(time.RFC3339Nano, conf.StartTime); err != nil {
+			return nil, fmt.Errorf("failed to parse start_time: %w", err)
+		}
+	}
+	if len(conf.EndTime) > 0 {
+		if filter.EndTime, err = time.Parse(time.RFC3339Nano, conf.EndTime); err != nil {
+			return nil, fmt.Errorf("failed to parse end_time: %w", err)
+		}
+	}
+	filter.StartKey = conf.StartKey
+	filter.EndKey = conf.EndKey
+
+	codecConf := codec.NewReaderConfig()
+	codecConf.MaxScanTokenSize = conf.MaxBuffer
+	codecConf.MaxMessageSize = conf.MaxMessageSize
+	ctor, err := codec.GetReader(conf.Codec, codecConf)
+	if err != nil {
+		return nil, err
+	}
+
+	var rateLimit types.RateLimit
+	if len(conf.RateLimit) > 0 {
+		if rateLimit, err = mgr.GetRateLimit(conf.RateLimit); err != nil {
+			return nil, fmt.Errorf("unable to locate rate_limit resource '%v': %v", conf.RateLimit, err)
+		}
+	}
+
+	return &replayConsumer{
+		log:         log,
+		index:       conf.Index,
+		filter:      filter,
+		scannerCtor: ctor,
+		rateLimit:   rateLimit,
+		closeChan:   make(chan struct{}),
+	}, nil
+}
+
+// ConnectWithContext selects the archived messages to replay from the index
+// and opens the next archived file to be read.
+func (r *replayConsumer) ConnectWithContext(ctx context.Context) error {
+	r.scannerMut.Lock()
+	defer r.scannerMut.Unlock()
+
+	if r.scanner != nil {
+		return nil
+	}
+
+	if !r.selected {
+		selections, err := replay.Select(r.index, r.filter)
+		if err != nil {
+			return fmt.Errorf("failed to read replay index: %w", err)
+		}
+		r.selections = selections
+		r.selected = true
+	}
+
+	if len(r.selections) == 0 {
+		return types.ErrTypeClosed
+	}
+
+	next := r.selections[0]
+	file, err := os.Open(next.Path)
+	if err != nil {
+		return err
+	}
+	if r.scanner, err = r.scannerCtor(next.Path, file, func(ctx context.Context, err error) error {
+		return nil
+	}); err != nil {
+		file.Close()
+		return err
+	}
+
+	r.current = next
+	r.position = 0
+	r.selections = r.selections[1:]
+
+	r.log.Infof("Replaying %v messages from file '%v'\n", len(next.Entries), next.Path)
+	return nil
+}
+
+func (r *replayConsumer) closeScanner(ctx context.Context) {
+	r.scanner.Close(ctx)
+	r.scanner = nil
+	r.current = nil
+}
+
+// ReadWithContext attempts to read the next selected message of the current
+// archived file.
+func (r *replayConsumer) ReadWithContext(ctx context.Context) (types.Message, reader.AsyncAckFn, error) {
+	r.scannerMut.Lock()
+	defer r.scannerMut.Unlock()
+
+	if r.scanner == nil {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	// Wait for the rate limit before reading so that a message is never read
+	// and then abandoned.
+	if err := r.waitForAccess(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	for {
+		if r.position > r.current.Last {
+			// All selected messages of this file have been read.
+			r.closeScanner(ctx)
+			return nil, nil, types.ErrTimeout
+		}
+
+		parts, codecAckFn, err := r.scanner.Next(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) ||
+				errors.Is(err, context.DeadlineExceeded) {
+				err = types.ErrTimeout
+			}
+			if err != types.ErrTimeout {
+				r.closeScanner(ctx)
+			}
+			if errors.Is(err, io.EOF) {
+				return nil, nil, types.ErrTimeout
+			}
+			return nil, nil, err
+		}
+
+		msg := message.New(nil)
+		for _, part := range parts {
+			if len(part.Get()) == 0 {
+				continue
+			}
+			entry, selected := r.current.Entries[r.position]
+			r.position++
+			if !selected {
+				continue
+			}
+			part.Metadata().
+				Set("path", entry.Path).
+				Set("replay_position", strconv.Itoa(entry.Position)).
+				Set("replay_timestamp", entry.Timestamp.Format(time.RFC3339Nano)).
+				Set("replay_key", entry.Key)
+			msg.Append(part)
+		}
+		if msg.Len() == 0 {
+			codecAckFn(ctx, nil)
+			continue
+		}
+		return msg, func(rctx context.Context, res types.Response) error {
+			return codecAckFn(rctx, res.Error())
+		}, nil
+	}
+}
+
+func (r *replayConsumer) waitForAccess(ctx context.Context) error {
+	if r.rateLimit == nil {
+		return nil
+	}
+	for {
+		waitFor, err := r.rateLimit.Access()
+		if err == types.ErrTypeClosed {
+			return err
+		}
+		if err != nil {
+			r.log.Errorf("Failed to access rate limit: %v\n", err)
+			waitFor = time.Second
+		}
+		if waitFor <= 0 {
+			return nil
+		}
+		select {
+		case <-time.After(waitFor):
+		case <-ctx.Done():
+			return types.ErrTimeout
+		case <-r.closeChan:
+			return types.ErrTypeClosed
+		}
+	}
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (r *replayConsumer) CloseAsync() {
+	r.closeOnce.Do(func() {
+		close(r.closeChan)
+	})
+	go func() {
+		r.scannerMut.Lock()
+		if r.scanner != nil {
+			r.closeScanner(context.Background())
+		}
+		r.selections = nil
+		r.selected = true
+		r.scannerMut.Unlock()
+	}()
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (r *replayConsumer) WaitForClose(time.Duration) error {
+	return nil
+}
@@ -0,0 +1,131 @@
+package input
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeReplayArchive(t *testing.T, dir string, docs ...string) {
+	t.Helper()
+
+	conf := output.NewConfig()
+	conf.Type = output.TypeFile
+	conf.File.Path = filepath.Join(dir, `${! json("bucket") }.jsonl`)
+	conf.File.ReplayIndex = filepath.Join(dir, "index.jsonl")
+	conf.File.ReplayKey = `${! json("id") }`
+
+	out, err := output.New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tChan := make(chan types.Transaction)
+	require.NoError(t, out.Consume(tChan))
+
+	resChan := make(chan types.Response)
+	for _, doc := range docs {
+		select {
+		case tChan <- types.NewTransaction(message.New([][]byte{[]byte(doc)}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out sending message")
+		}
+		select {
+		case res := <-resChan:
+			require.NoError(t, res.Error())
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for response")
+		}
+	}
+
+	out.CloseAsync()
+	require.NoError(t, out.WaitForClose(time.Second))
+}
+
+func TestReplayKeyRange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_replay_test")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	writeReplayArchive(t, dir,
+		`{"id":"a","bucket":"foo"}`,
+		`{"id":"b","bucket":"foo"}`,
+		`{"id":"c","bucket":"bar"}`,
+		`{"id":"d","bucket":"foo"}`,
+		`{"id":"e","bucket":"bar"}`,
+		`{"id":"f","bucket":"foo"}`,
+	)
+
+	conf := NewConfig()
+	conf.Replay.Index = filepath.Join(dir, "index.jsonl")
+	conf.Replay.StartKey = "b"
+	conf.Replay.EndKey = "f"
+
+	f, err := NewReplay(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	defer func() {
+		f.CloseAsync()
+		assert.NoError(t, f.WaitForClose(time.Second))
+	}()
+
+	for _, exp := range []struct {
+		doc, path, position, key string
+	}{
+		{doc: `{"id":"b","bucket":"foo"}`, path: "foo.jsonl", position: "1", key: "b"},
+		{doc: `{"id":"d","bucket":"foo"}`, path: "foo.jsonl", position: "2", key: "d"},
+		{doc: `{"id":"c","bucket":"bar"}`, path: "bar.jsonl", position: "0", key: "c"},
+		{doc: `{"id":"e","bucket":"bar"}`, path: "bar.jsonl", position: "1", key: "e"},
+	} {
+		var ts types.Transaction
+		var open bool
+		select {
+		case ts, open = <-f.TransactionChan():
+			require.True(t, open)
+			p := ts.Payload.Get(0)
+			assert.Equal(t, exp.doc, string(p.Get()))
+			assert.Equal(t, filepath.Join(dir, exp.path), p.Metadata().Get("path"))
+			assert.Equal(t, exp.position, p.Metadata().Get("replay_position"))
+			assert.Equal(t, exp.key, p.Metadata().Get("replay_key"))
+			_, err := time.Parse(time.RFC3339Nano, p.Metadata().Get("replay_timestamp"))
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for message")
+		}
+		select {
+		case ts.ResponseChan <- response.NewAck():
+		case <-time.After(time.Second):
+			t.Error("Timed out waiting for response")
+		}
+	}
+
+	select {
+	case _, open := <-f.TransactionChan():
+		require.False(t, open)
+	case <-time.After(time.Second):
+		t.Error("Timed out waiting for channel close")
+	}
+}
+
+func TestReplayBadConfig(t *testing.T) {
+	conf := NewConfig()
+	_, err := NewReplay(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "a replay index must be specified")
+
+	conf.Replay.Index = "./foo.jsonl"
+	conf.Replay.StartTime = "yesterday"
+	_, err = NewReplay(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse start_time")
+}
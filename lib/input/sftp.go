@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/checkpoint"
 	"github.com/Jeffail/benthos/v3/internal/codec"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	sftpSetup "github.com/Jeffail/benthos/v3/internal/service/sftp"
@@ -83,10 +85,12 @@ You can access these metadata fields using [function interpolation](/docs/config
 			codec.ReaderDocs,
 			docs.FieldAdvanced("delete_on_finish", "Whether to delete files from the server once they are processed."),
 			docs.FieldAdvanced("max_buffer", "The largest token size expected when consuming delimited files."),
+			docs.FieldAdvanced("max_message_size", "The largest message in bytes that will be consumed, records that exceed this size are rejected as they are read in order to prevent them from being loaded into memory. Set to zero in order to disable the limit.").AtVersion("3.44.0"),
 			docs.FieldCommon(
 				"watcher",
 				"An experimental mode whereby the input will periodically scan the target paths for new files and consume them, when all files are consumed the input will continue polling for new files.",
 			).WithChildren(watcherDocs...).AtVersion("3.42.0"),
+			docs.FieldAdvanced("checkpoint_cache", "An optional [cache resource](/docs/components/caches/about) for storing the byte offset of each file as messages are acknowledged. When set, a file that was partially consumed before a restart is resumed from the first unacknowledged message by seeking to its offset, which requires one of the codecs `lines`, `delim`, `chunker` or `length-prefixed`. Checkpoints can be inspected and reset via the `/checkpoints/{cache}` HTTP endpoint.").AtVersion("3.44.0"),
 		},
 		Categories: []Category{
 			CategoryNetwork,
@@ -111,7 +115,9 @@ type SFTPConfig struct {
 	Codec          string                `json:"codec" yaml:"codec"`
 	DeleteOnFinish bool                  `json:"delete_on_finish" yaml:"delete_on_finish"`
 	MaxBuffer      int                   `json:"max_buffer" yaml:"max_buffer"`
+	MaxMessageSize int                   `json:"max_message_size" yaml:"max_message_size"`
 	Watcher        watcherConfig         `json:"watcher" yaml:"watcher"`
+	Checkpoint     string                `json:"checkpoint_cache" yaml:"checkpoint_cache"`
 }
 
 // NewSFTPConfig creates a new SFTPConfig with default values.
@@ -123,12 +129,14 @@ func NewSFTPConfig() SFTPConfig {
 		Codec:          "all-bytes",
 		DeleteOnFinish: false,
 		MaxBuffer:      1000000,
+		MaxMessageSize: 0,
 		Watcher: watcherConfig{
 			Enabled:      false,
 			MinimumAge:   "1s",
 			PollInterval: "1s",
 			Cache:        "",
 		},
+		Checkpoint: "",
 	}
 }
 
@@ -152,11 +160,16 @@ type sftpReader struct {
 
 	watcherPollInterval time.Duration
 	watcherMinAge       time.Duration
+
+	checkpoints *checkpoint.Store
+	source      *checkpoint.Source
+	offsets     codec.OffsetReader
 }
 
 func newSFTPReader(conf SFTPConfig, mgr types.Manager, log log.Modular, stats metrics.Type) (*sftpReader, error) {
 	codecConf := codec.NewReaderConfig()
 	codecConf.MaxScanTokenSize = conf.MaxBuffer
+	codecConf.MaxMessageSize = conf.MaxMessageSize
 	ctor, err := codec.GetReader(conf.Codec, codecConf)
 	if err != nil {
 		return nil, err
@@ -182,6 +195,16 @@ func newSFTPReader(conf SFTPConfig, mgr types.Manager, log log.Modular, stats me
 		}
 	}
 
+	var checkpoints *checkpoint.Store
+	if len(conf.Checkpoint) > 0 {
+		if !codec.ReaderSupportsOffsets(conf.Codec) {
+			return nil, fmt.Errorf("codec '%v' does not support checkpoints, a checkpoint cache requires one of the codecs lines, delim, chunker or length-prefixed", conf.Codec)
+		}
+		if checkpoints, err = checkpoint.GetStore(mgr, conf.Checkpoint); err != nil {
+			return nil, err
+		}
+	}
+
 	s := &sftpReader{
 		conf:                conf,
 		log:                 log,
@@ -190,6 +213,7 @@ func newSFTPReader(conf SFTPConfig, mgr types.Manager, log log.Modular, stats me
 		scannerCtor:         ctor,
 		watcherPollInterval: watcherPollInterval,
 		watcherMinAge:       watcherMinAge,
+		checkpoints:         checkpoints,
 	}
 
 	return s, err
@@ -233,13 +257,34 @@ func (s *sftpReader) ConnectWithContext(ctx context.Context) error {
 
 	nextPath := s.paths[0]
 
+	var source *checkpoint.Source
+	if s.checkpoints != nil {
+		if source, err = s.checkpoints.Source("sftp://" + s.conf.Address + "/" + strings.TrimPrefix(nextPath, "/")); err != nil {
+			return err
+		}
+	}
+
 	file, err := s.client.Open(nextPath)
 	if err != nil {
 		return err
 	}
 
+	if source != nil && source.Resume() > 0 {
+		// Messages prior to the checkpoint were acknowledged before the file
+		// was last closed.
+		if _, err = file.Seek(source.Resume(), io.SeekStart); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
 	if s.scanner, err = s.scannerCtor(nextPath, file, func(ctx context.Context, err error) error {
 		if err == nil && s.conf.DeleteOnFinish {
+			if source != nil {
+				if err := source.Reset(); err != nil {
+					return err
+				}
+			}
 			return s.client.Remove(nextPath)
 		}
 		return nil
@@ -250,8 +295,14 @@ func (s *sftpReader) ConnectWithContext(ctx context.Context) error {
 
 	s.currentPath = nextPath
 	s.paths = s.paths[1:]
+	s.source = source
+	s.offsets, _ = s.scanner.(codec.OffsetReader)
 
-	s.log.Infof("Consuming from file '%v'\n", nextPath)
+	if source != nil && source.Resume() > 0 {
+		s.log.Infof("Resuming from file '%v' at byte offset %v\n", nextPath, source.Resume())
+	} else {
+		s.log.Infof("Consuming from file '%v'\n", nextPath)
+	}
 	return err
 }
 
@@ -290,6 +341,11 @@ func (s *sftpReader) ReadWithContext(ctx context.Context) (types.Message, reader
 		return nil, nil, err
 	}
 
+	checkpointFn := func() error { return nil }
+	if s.source != nil && s.offsets != nil {
+		checkpointFn = s.source.Track(s.source.Resume() + s.offsets.Offset())
+	}
+
 	for _, part := range parts {
 		part.Metadata().Set("sftp_path", s.currentPath)
 	}
@@ -297,6 +353,11 @@ func (s *sftpReader) ReadWithContext(ctx context.Context) (types.Message, reader
 	msg.Append(parts...)
 
 	return msg, func(ctx context.Context, res types.Response) error {
+		if res.Error() == nil {
+			if err := checkpointFn(); err != nil {
+				s.log.Errorf("Failed to store checkpoint: %v\n", err)
+			}
+		}
 		return codecAckFn(ctx, res.Error())
 	}, nil
 }
@@ -0,0 +1,295 @@
+package input
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+func init() {
+	Constructors[TypeSlackEvents] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			r, err := newSlackEventsReader(conf.SlackEvents, mgr, log)
+			if err != nil {
+				return nil, err
+			}
+			return NewAsyncReader(
+				TypeSlackEvents,
+				true,
+				reader.NewAsyncPreserver(r),
+				log, stats,
+			)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `Receives events from the [Slack Events API](https://api.slack.com/apis/connections/events-api) and creates a message for each event.`,
+		Description: `
+The input serves the request URL of a Slack app, which must be configured within the app as the address of Benthos followed by ` + "`path`" + `. When ` + "`address`" + ` is empty the path is registered on the [service-wide HTTP server](/docs/components/http/about), otherwise a dedicated server is started on that address.
+
+The signature of every request is verified with the ` + "`signing_secret`" + ` of the app, and requests with an invalid signature or a timestamp more than five minutes old are rejected. URL verification challenges are answered automatically.
+
+Each event callback is emitted as a message containing the entire payload, where the event itself is found under the field ` + "`event`" + `. The request is only responded to once the message has been processed, and a failure to process the message results in an error response, causing Slack to retry the event. Since Slack expects a response within three seconds, retried events may therefore be duplicates of events that were eventually processed, which can be detected with the ` + "`slack_retry_num`" + ` metadata field.
+
+## Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```" + `
+- slack_event_id
+- slack_event_type
+- slack_team_id
+- slack_retry_num
+` + "```" + `
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("signing_secret", "The signing secret of the Slack app, used to verify the signature of requests."),
+			docs.FieldCommon("path", "The path to receive events on."),
+			docs.FieldAdvanced("address", "An optional address to start a dedicated HTTP server on, when empty the path is registered on the service-wide HTTP server.", "0.0.0.0:8080"),
+			docs.FieldAdvanced("timeout", "The maximum period of time to wait for an event to be processed before responding with an error."),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SlackEventsConfig contains configuration fields for the Slack events input
+// type.
+type SlackEventsConfig struct {
+	SigningSecret string `json:"signing_secret" yaml:"signing_secret"`
+	Path          string `json:"path" yaml:"path"`
+	Address       string `json:"address" yaml:"address"`
+	Timeout       string `json:"timeout" yaml:"timeout"`
+}
+
+// NewSlackEventsConfig creates a new SlackEventsConfig with default values.
+func NewSlackEventsConfig() SlackEventsConfig {
+	return SlackEventsConfig{
+		SigningSecret: "",
+		Path:          "/slack/events",
+		Address:       "",
+		Timeout:       "5s",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// slackMaxRequestAge is the maximum age of a request timestamp, older requests
+// are rejected in order to prevent replay attacks.
+const slackMaxRequestAge = time.Minute * 5
+
+type slackEvent struct {
+	msg     types.Message
+	resChan chan error
+}
+
+type slackEventsReader struct {
+	conf    SlackEventsConfig
+	log     log.Modular
+	timeout time.Duration
+
+	server *http.Server
+	events chan slackEvent
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+func newSlackEventsReader(conf SlackEventsConfig, mgr types.Manager, log log.Modular) (*slackEventsReader, error) {
+	if conf.SigningSecret == "" {
+		return nil, errors.New("a signing_secret must be specified")
+	}
+	if conf.Path == "" {
+		return nil, errors.New("a path must be specified")
+	}
+
+	s := &slackEventsReader{
+		conf:      conf,
+		log:       log,
+		events:    make(chan slackEvent),
+		closeChan: make(chan struct{}),
+	}
+
+	var err error
+	if s.timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+		return nil, fmt.Errorf("failed to parse timeout: %w", err)
+	}
+
+	if conf.Address == "" {
+		mgr.RegisterEndpoint(conf.Path, "Receive events from the Slack Events API.", s.handler)
+		return s, nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(conf.Path, s.handler)
+	s.server = &http.Server{Addr: conf.Address, Handler: mux}
+	go func() {
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			s.log.Errorf("Server error: %v\n", err)
+		}
+	}()
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+// verify checks the signature of a request following
+// https://api.slack.com/authentication/verifying-requests-from-slack.
+func (s *slackEventsReader) verify(header http.Header, body []byte, now time.Time) error {
+	tsStr := header.Get("X-Slack-Request-Timestamp")
+	ts, err := strconv.ParseInt(tsStr, 10, 64)
+	if err != nil {
+		return errors.New("missing or invalid request timestamp")
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > slackMaxRequestAge || age < -slackMaxRequestAge {
+		return errors.New("request timestamp is too old")
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.conf.SigningSecret))
+	_, _ = mac.Write([]byte("v0:" + tsStr + ":"))
+	_, _ = mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return errors.New("request signature is invalid")
+	}
+	return nil
+}
+
+type slackEventPayload struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	TeamID    string `json:"team_id"`
+	EventID   string `json:"event_id"`
+	Event     struct {
+		Type string `json:"type"`
+	} `json:"event"`
+}
+
+func (s *slackEventsReader) handler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if err = s.verify(r.Header, body, time.Now()); err != nil {
+		s.log.Debugf("Rejected request: %v\n", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var payload slackEventPayload
+	if err = json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+		return
+	}
+
+	switch payload.Type {
+	case "url_verification":
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(payload.Challenge))
+		return
+	case "event_callback":
+	default:
+		s.log.Debugf("Ignoring payload of unrecognised type: %v\n", payload.Type)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	part := message.NewPart(body)
+	part.Metadata().Set("slack_event_id", payload.EventID)
+	part.Metadata().Set("slack_event_type", payload.Event.Type)
+	part.Metadata().Set("slack_team_id", payload.TeamID)
+	part.Metadata().Set("slack_retry_num", r.Header.Get("X-Slack-Retry-Num"))
+	msg := message.New(nil)
+	msg.Append(part)
+
+	ctx, done := context.WithTimeout(r.Context(), s.timeout)
+	defer done()
+
+	resChan := make(chan error, 1)
+	select {
+	case s.events <- slackEvent{msg: msg, resChan: resChan}:
+	case <-ctx.Done():
+		http.Error(w, "Request timed out", http.StatusRequestTimeout)
+		return
+	case <-s.closeChan:
+		http.Error(w, "Server closing", http.StatusServiceUnavailable)
+		return
+	}
+
+	select {
+	case err = <-resChan:
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-s.closeChan:
+		err = types.ErrTypeClosed
+	}
+	if err != nil {
+		s.log.Debugf("Failed to process event: %v\n", err)
+		http.Error(w, "Failed to process event", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext does nothing as the server is started when the input is
+// created.
+func (s *slackEventsReader) ConnectWithContext(ctx context.Context) error {
+	return nil
+}
+
+// ReadWithContext waits for the next event to be received.
+func (s *slackEventsReader) ReadWithContext(ctx context.Context) (types.Message, reader.AsyncAckFn, error) {
+	select {
+	case e := <-s.events:
+		return e.msg, func(ctx context.Context, res types.Response) error {
+			e.resChan <- res.Error()
+			return nil
+		}, nil
+	case <-ctx.Done():
+		return nil, nil, types.ErrTimeout
+	case <-s.closeChan:
+		return nil, nil, types.ErrTypeClosed
+	}
+}
+
+// CloseAsync shuts down the server.
+func (s *slackEventsReader) CloseAsync() {
+	s.closeOnce.Do(func() {
+		close(s.closeChan)
+		if s.server != nil {
+			_ = s.server.Shutdown(context.Background())
+		}
+	})
+}
+
+// WaitForClose blocks until the input has closed down.
+func (s *slackEventsReader) WaitForClose(timeout time.Duration) error {
+	return nil
+}
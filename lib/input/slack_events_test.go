@@ -0,0 +1,111 @@
+package input
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func slackTestRequest(t *testing.T, secret, body string, ts time.Time) *http.Request {
+	t.Helper()
+
+	tsStr := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + tsStr + ":" + body))
+
+	req := httptest.NewRequest("POST", "/slack/events", strings.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", tsStr)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func newSlackTestReader(t *testing.T) *slackEventsReader {
+	t.Helper()
+
+	conf := NewSlackEventsConfig()
+	conf.SigningSecret = "foo"
+
+	s, err := newSlackEventsReader(conf, types.NoopMgr(), log.Noop())
+	require.NoError(t, err)
+	t.Cleanup(s.CloseAsync)
+	return s
+}
+
+func TestSlackEventsVerification(t *testing.T) {
+	s := newSlackTestReader(t)
+
+	rec := httptest.NewRecorder()
+	s.handler(rec, slackTestRequest(t, "foo", `{"type":"url_verification","challenge":"abc"}`, time.Now()))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "abc", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	s.handler(rec, slackTestRequest(t, "bar", `{"type":"url_verification","challenge":"abc"}`, time.Now()))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.handler(rec, slackTestRequest(t, "foo", `{"type":"url_verification","challenge":"abc"}`, time.Now().Add(-time.Hour)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestSlackEventsCallback(t *testing.T) {
+	s := newSlackTestReader(t)
+
+	body := `{"type":"event_callback","team_id":"T1","event_id":"Ev1","event":{"type":"app_mention","text":"hello"}}`
+
+	resChan := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		req := slackTestRequest(t, "foo", body, time.Now())
+		req.Header.Set("X-Slack-Retry-Num", "1")
+		s.handler(rec, req)
+		resChan <- rec
+	}()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+
+	msg, ackFn, err := s.ReadWithContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, msg.Len())
+
+	part := msg.Get(0)
+	assert.Equal(t, body, string(part.Get()))
+	assert.Equal(t, "Ev1", part.Metadata().Get("slack_event_id"))
+	assert.Equal(t, "app_mention", part.Metadata().Get("slack_event_type"))
+	assert.Equal(t, "T1", part.Metadata().Get("slack_team_id"))
+	assert.Equal(t, "1", part.Metadata().Get("slack_retry_num"))
+
+	require.NoError(t, ackFn(ctx, response.NewAck()))
+
+	select {
+	case rec := <-resChan:
+		assert.Equal(t, http.StatusOK, rec.Code)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for response")
+	}
+}
+
+func TestSlackEventsBadConfig(t *testing.T) {
+	conf := NewSlackEventsConfig()
+	_, err := newSlackEventsReader(conf, types.NoopMgr(), log.Noop())
+	require.EqualError(t, err, "a signing_secret must be specified")
+
+	conf.SigningSecret = "foo"
+	conf.Timeout = "nope"
+	_, err = newSlackEventsReader(conf, types.NoopMgr(), log.Noop())
+	require.Error(t, err)
+}
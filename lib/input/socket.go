@@ -35,6 +35,7 @@ Connects to a tcp or unix socket and consumes a continuous stream of messages.`,
 			docs.FieldDeprecated("delimiter"),
 			docs.FieldDeprecated("multipart"),
 			docs.FieldAdvanced("max_buffer", "The maximum message buffer size. Must exceed the largest message to be consumed."),
+			docs.FieldAdvanced("max_message_size", "The largest message in bytes that will be consumed, records that exceed this size are rejected as they are read in order to prevent them from being loaded into memory. Set to zero in order to disable the limit.").AtVersion("3.44.0"),
 		},
 		Categories: []Category{
 			CategoryNetwork,
@@ -46,10 +47,11 @@ Connects to a tcp or unix socket and consumes a continuous stream of messages.`,
 
 // SocketConfig contains configuration values for the Socket input type.
 type SocketConfig struct {
-	Network   string `json:"network" yaml:"network"`
-	Address   string `json:"address" yaml:"address"`
-	Codec     string `json:"codec" yaml:"codec"`
-	MaxBuffer int    `json:"max_buffer" yaml:"max_buffer"`
+	Network        string `json:"network" yaml:"network"`
+	Address        string `json:"address" yaml:"address"`
+	Codec          string `json:"codec" yaml:"codec"`
+	MaxBuffer      int    `json:"max_buffer" yaml:"max_buffer"`
+	MaxMessageSize int    `json:"max_message_size" yaml:"max_message_size"`
 	// TODO: V4 remove these fields.
 	Multipart bool   `json:"multipart" yaml:"multipart"`
 	Delim     string `json:"delimiter" yaml:"delimiter"`
@@ -58,12 +60,13 @@ type SocketConfig struct {
 // NewSocketConfig creates a new SocketConfig with default values.
 func NewSocketConfig() SocketConfig {
 	return SocketConfig{
-		Network:   "unix",
-		Address:   "/tmp/benthos.sock",
-		Codec:     "lines",
-		Multipart: false,
-		MaxBuffer: 1000000,
-		Delim:     "",
+		Network:        "unix",
+		Address:        "/tmp/benthos.sock",
+		Codec:          "lines",
+		Multipart:      false,
+		MaxBuffer:      1000000,
+		MaxMessageSize: 0,
+		Delim:          "",
 	}
 }
 
@@ -110,6 +113,7 @@ func newSocketClient(conf SocketConfig, logger log.Modular) (*socketClient, erro
 
 	codecConf := codec.NewReaderConfig()
 	codecConf.MaxScanTokenSize = conf.MaxBuffer
+	codecConf.MaxMessageSize = conf.MaxMessageSize
 	ctor, err := codec.GetReader(conf.Codec, codecConf)
 	if err != nil {
 		return nil, err
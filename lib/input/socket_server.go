@@ -32,6 +32,7 @@ The field ` + "`max_buffer`" + ` specifies the maximum amount of memory to alloc
 			docs.FieldCommon("address", "The address to listen from.", "/tmp/benthos.sock", "0.0.0.0:6000"),
 			codec.ReaderDocs.AtVersion("3.42.0"),
 			docs.FieldAdvanced("max_buffer", "The maximum message buffer size. Must exceed the largest message to be consumed."),
+			docs.FieldAdvanced("max_message_size", "The largest message in bytes that will be consumed, records that exceed this size are rejected as they are read in order to prevent them from being loaded into memory. Set to zero in order to disable the limit.").AtVersion("3.44.0"),
 			docs.FieldDeprecated("multipart"),
 			docs.FieldDeprecated("delimiter"),
 		},
@@ -45,21 +46,23 @@ The field ` + "`max_buffer`" + ` specifies the maximum amount of memory to alloc
 
 // SocketServerConfig contains configuration for the SocketServer input type.
 type SocketServerConfig struct {
-	Network   string `json:"network" yaml:"network"`
-	Address   string `json:"address" yaml:"address"`
-	Codec     string `json:"codec" yaml:"codec"`
-	MaxBuffer int    `json:"max_buffer" yaml:"max_buffer"`
-	Multipart bool   `json:"multipart" yaml:"multipart"`
-	Delim     string `json:"delimiter" yaml:"delimiter"`
+	Network        string `json:"network" yaml:"network"`
+	Address        string `json:"address" yaml:"address"`
+	Codec          string `json:"codec" yaml:"codec"`
+	MaxBuffer      int    `json:"max_buffer" yaml:"max_buffer"`
+	MaxMessageSize int    `json:"max_message_size" yaml:"max_message_size"`
+	Multipart      bool   `json:"multipart" yaml:"multipart"`
+	Delim          string `json:"delimiter" yaml:"delimiter"`
 }
 
 // NewSocketServerConfig creates a new SocketServerConfig with default values.
 func NewSocketServerConfig() SocketServerConfig {
 	return SocketServerConfig{
-		Network:   "unix",
-		Address:   "/tmp/benthos.sock",
-		Codec:     "lines",
-		MaxBuffer: 1000000,
+		Network:        "unix",
+		Address:        "/tmp/benthos.sock",
+		Codec:          "lines",
+		MaxBuffer:      1000000,
+		MaxMessageSize: 0,
 
 		// TODO: V4 Remove these fields
 		Multipart: false,
@@ -115,6 +118,7 @@ func NewSocketServer(conf Config, mgr types.Manager, log log.Modular, stats metr
 
 	codecConf := codec.NewReaderConfig()
 	codecConf.MaxScanTokenSize = sconf.MaxBuffer
+	codecConf.MaxMessageSize = sconf.MaxMessageSize
 	ctor, err := codec.GetReader(sconf.Codec, codecConf)
 	if err != nil {
 		return nil, err
@@ -32,6 +32,7 @@ If the delimiter field is left empty then line feed (\n) is used.`,
 		FieldSpecs: docs.FieldSpecs{
 			codec.ReaderDocs.AtVersion("3.42.0"),
 			docs.FieldAdvanced("max_buffer", "The maximum message buffer size. Must exceed the largest message to be consumed."),
+			docs.FieldAdvanced("max_message_size", "The largest message in bytes that will be consumed, records that exceed this size are rejected as they are read in order to prevent them from being loaded into memory. Set to zero in order to disable the limit.").AtVersion("3.44.0"),
 			docs.FieldDeprecated("delimiter"),
 			docs.FieldDeprecated("multipart"),
 		},
@@ -45,19 +46,21 @@ If the delimiter field is left empty then line feed (\n) is used.`,
 
 // STDINConfig contains config fields for the STDIN input type.
 type STDINConfig struct {
-	Codec     string `json:"codec" yaml:"codec"`
-	Multipart bool   `json:"multipart" yaml:"multipart"`
-	MaxBuffer int    `json:"max_buffer" yaml:"max_buffer"`
-	Delim     string `json:"delimiter" yaml:"delimiter"`
+	Codec          string `json:"codec" yaml:"codec"`
+	Multipart      bool   `json:"multipart" yaml:"multipart"`
+	MaxBuffer      int    `json:"max_buffer" yaml:"max_buffer"`
+	MaxMessageSize int    `json:"max_message_size" yaml:"max_message_size"`
+	Delim          string `json:"delimiter" yaml:"delimiter"`
 }
 
 // NewSTDINConfig creates a STDINConfig populated with default values.
 func NewSTDINConfig() STDINConfig {
 	return STDINConfig{
-		Codec:     "lines",
-		Multipart: false,
-		MaxBuffer: 1000000,
-		Delim:     "",
+		Codec:          "lines",
+		Multipart:      false,
+		MaxBuffer:      1000000,
+		MaxMessageSize: 0,
+		Delim:          "",
 	}
 }
 
@@ -92,6 +95,7 @@ func newStdinConsumer(conf STDINConfig) (*stdinConsumer, error) {
 
 	codecConf := codec.NewReaderConfig()
 	codecConf.MaxScanTokenSize = conf.MaxBuffer
+	codecConf.MaxMessageSize = conf.MaxMessageSize
 	ctor, err := codec.GetReader(conf.Codec, codecConf)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,278 @@
+package input
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+func init() {
+	Constructors[TypeTelegram] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			r, err := newTelegramReader(conf.Telegram, log)
+			if err != nil {
+				return nil, err
+			}
+			return NewAsyncReader(
+				TypeTelegram,
+				true,
+				reader.NewAsyncPreserver(r),
+				log, stats,
+			)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `Receives updates for a [Telegram bot](https://core.telegram.org/bots/api) by long polling and creates a message for each update.`,
+		Description: `
+Updates are obtained with the ` + "[`getUpdates`](https://core.telegram.org/bots/api#getupdates)" + ` method of the Bot API, which cannot be used whilst a webhook is set for the bot. Each update is emitted as a message containing the entire update object, where the type of the update determines which field is populated (` + "`message`" + `, ` + "`edited_message`" + `, ` + "`callback_query`" + `, etc).
+
+Telegram considers updates confirmed once a request is made with a greater offset, which happens when the next batch of updates is polled. Updates are therefore only confirmed once they have been read by the pipeline, but may be lost when Benthos is shut down before they are processed.
+
+## Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```" + `
+- telegram_update_id
+- telegram_update_type
+- telegram_chat_id
+` + "```" + `
+
+The field ` + "`telegram_chat_id`" + ` is only set for updates that belong to a chat, such as messages and channel posts.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("bot_token", "The token of the bot, as provided by the BotFather."),
+			docs.FieldCommon("allowed_updates", "An optional list of update types to receive, when empty all update types except `chat_member` are received.", []string{"message", "callback_query"}).Array(),
+			docs.FieldAdvanced("polling_timeout", "The maximum period of time a poll waits for updates before returning empty."),
+			docs.FieldAdvanced("api_url", "The URL of the Bot API server."),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// TelegramConfig contains configuration fields for the Telegram input type.
+type TelegramConfig struct {
+	BotToken       string   `json:"bot_token" yaml:"bot_token"`
+	AllowedUpdates []string `json:"allowed_updates" yaml:"allowed_updates"`
+	PollingTimeout string   `json:"polling_timeout" yaml:"polling_timeout"`
+	APIURL         string   `json:"api_url" yaml:"api_url"`
+}
+
+// NewTelegramConfig creates a new TelegramConfig with default values.
+func NewTelegramConfig() TelegramConfig {
+	return TelegramConfig{
+		BotToken:       "",
+		AllowedUpdates: []string{},
+		PollingTimeout: "30s",
+		APIURL:         "https://api.telegram.org",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type telegramResponse struct {
+	OK          bool              `json:"ok"`
+	Description string            `json:"description"`
+	Result      []json.RawMessage `json:"result"`
+}
+
+type telegramReader struct {
+	conf           TelegramConfig
+	log            log.Modular
+	client         *http.Client
+	pollingTimeout time.Duration
+	allowedUpdates string
+
+	offsetMut sync.Mutex
+	offset    int64
+}
+
+func newTelegramReader(conf TelegramConfig, log log.Modular) (*telegramReader, error) {
+	if conf.BotToken == "" {
+		return nil, errors.New("a bot_token must be specified")
+	}
+
+	t := &telegramReader{
+		conf: conf,
+		log:  log,
+	}
+
+	var err error
+	if t.pollingTimeout, err = time.ParseDuration(conf.PollingTimeout); err != nil {
+		return nil, fmt.Errorf("failed to parse polling timeout: %w", err)
+	}
+	// Allow the request to outlive the poll in order to receive a response.
+	t.client = &http.Client{Timeout: t.pollingTimeout + time.Second*10}
+
+	if len(conf.AllowedUpdates) > 0 {
+		updatesBytes, err := json.Marshal(conf.AllowedUpdates)
+		if err != nil {
+			return nil, err
+		}
+		t.allowedUpdates = string(updatesBytes)
+	}
+	return t, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (t *telegramReader) getUpdates(ctx context.Context) ([]json.RawMessage, error) {
+	t.offsetMut.Lock()
+	offset := t.offset
+	t.offsetMut.Unlock()
+
+	values := url.Values{}
+	values.Set("timeout", strconv.Itoa(int(t.pollingTimeout.Seconds())))
+	if offset > 0 {
+		values.Set("offset", strconv.FormatInt(offset, 10))
+	}
+	if t.allowedUpdates != "" {
+		values.Set("allowed_updates", t.allowedUpdates)
+	}
+
+	reqURL := strings.TrimSuffix(t.conf.APIURL, "/") + "/bot" + t.conf.BotToken + "/getUpdates?" + values.Encode()
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := t.client.Do(req)
+	if err != nil {
+		// Avoid logging the URL as it contains the bot token.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var tRes telegramResponse
+	if err = json.Unmarshal(body, &tRes); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if !tRes.OK {
+		return nil, fmt.Errorf("request failed with status %v: %v", res.StatusCode, tRes.Description)
+	}
+	return tRes.Result, nil
+}
+
+// telegramUpdateMeta extracts the ID, type and chat ID of an update.
+func telegramUpdateMeta(update json.RawMessage) (id int64, updateType, chatID string, err error) {
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(update, &fields); err != nil {
+		return
+	}
+	if err = json.Unmarshal(fields["update_id"], &id); err != nil {
+		err = fmt.Errorf("failed to parse update_id: %w", err)
+		return
+	}
+	for k, v := range fields {
+		if k == "update_id" {
+			continue
+		}
+		updateType = k
+
+		var content struct {
+			Chat *struct {
+				ID json.Number `json:"id"`
+			} `json:"chat"`
+		}
+		if json.Unmarshal(v, &content) == nil && content.Chat != nil {
+			chatID = content.Chat.ID.String()
+		}
+		break
+	}
+	return
+}
+
+// ConnectWithContext does nothing as updates are obtained with individual
+// requests.
+func (t *telegramReader) ConnectWithContext(ctx context.Context) error {
+	return nil
+}
+
+// ReadWithContext polls for updates until at least one is received.
+func (t *telegramReader) ReadWithContext(ctx context.Context) (types.Message, reader.AsyncAckFn, error) {
+	for {
+		updates, err := t.getUpdates(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil, types.ErrTimeout
+			}
+			t.log.Errorf("Failed to get updates: %v\n", err)
+			return nil, nil, types.ErrNotConnected
+		}
+		if len(updates) == 0 {
+			continue
+		}
+
+		msg := message.New(nil)
+		var nextOffset int64
+		for _, update := range updates {
+			id, updateType, chatID, err := telegramUpdateMeta(update)
+			if err != nil {
+				t.log.Errorf("Failed to parse update: %v\n", err)
+				continue
+			}
+			if id >= nextOffset {
+				nextOffset = id + 1
+			}
+
+			part := message.NewPart(update)
+			part.Metadata().Set("telegram_update_id", strconv.FormatInt(id, 10))
+			part.Metadata().Set("telegram_update_type", updateType)
+			if chatID != "" {
+				part.Metadata().Set("telegram_chat_id", chatID)
+			}
+			msg.Append(part)
+		}
+
+		t.offsetMut.Lock()
+		if nextOffset > t.offset {
+			t.offset = nextOffset
+		}
+		t.offsetMut.Unlock()
+
+		if msg.Len() == 0 {
+			continue
+		}
+		return msg, func(context.Context, types.Response) error {
+			return nil
+		}, nil
+	}
+}
+
+// CloseAsync does nothing as there are no connections to close.
+func (t *telegramReader) CloseAsync() {
+}
+
+// WaitForClose blocks until the input has closed down.
+func (t *telegramReader) WaitForClose(timeout time.Duration) error {
+	return nil
+}
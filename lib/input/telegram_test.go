@@ -0,0 +1,95 @@
+package input
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelegramUpdates(t *testing.T) {
+	var offsetsMut sync.Mutex
+	var offsets []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/botfoo/getUpdates", r.URL.Path)
+		assert.Equal(t, "1", r.URL.Query().Get("timeout"))
+		assert.Equal(t, `["message"]`, r.URL.Query().Get("allowed_updates"))
+
+		offset := r.URL.Query().Get("offset")
+		offsetsMut.Lock()
+		offsets = append(offsets, offset)
+		requests := len(offsets)
+		offsetsMut.Unlock()
+
+		switch requests {
+		case 1:
+			w.Write([]byte(`{"ok":true,"result":[
+				{"update_id":10,"message":{"message_id":1,"chat":{"id":-100123},"text":"first"}},
+				{"update_id":11,"message":{"message_id":2,"chat":{"id":-100123},"text":"second"}}
+			]}`))
+		case 2:
+			w.Write([]byte(`{"ok":true,"result":[]}`))
+		case 3:
+			w.Write([]byte(`{"ok":true,"result":[{"update_id":12,"poll":{"id":"5"}}]}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"ok":false,"description":"Unauthorized"}`))
+		}
+	}))
+	defer ts.Close()
+
+	conf := NewTelegramConfig()
+	conf.BotToken = "foo"
+	conf.APIURL = ts.URL
+	conf.PollingTimeout = "1s"
+	conf.AllowedUpdates = []string{"message"}
+
+	tr, err := newTelegramReader(conf, log.Noop())
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	require.NoError(t, tr.ConnectWithContext(ctx))
+
+	msg, _, err := tr.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, []string{
+		telegramTestText(t, msg, 0), telegramTestText(t, msg, 1),
+	})
+	assert.Equal(t, "10", msg.Get(0).Metadata().Get("telegram_update_id"))
+	assert.Equal(t, "message", msg.Get(0).Metadata().Get("telegram_update_type"))
+	assert.Equal(t, "-100123", msg.Get(0).Metadata().Get("telegram_chat_id"))
+	assert.Equal(t, "11", msg.Get(1).Metadata().Get("telegram_update_id"))
+
+	// The reader polls again after an empty result.
+	msg, _, err = tr.ReadWithContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, msg.Len())
+	assert.Equal(t, "12", msg.Get(0).Metadata().Get("telegram_update_id"))
+	assert.Equal(t, "poll", msg.Get(0).Metadata().Get("telegram_update_type"))
+	assert.Equal(t, "", msg.Get(0).Metadata().Get("telegram_chat_id"))
+
+	_, _, err = tr.ReadWithContext(ctx)
+	require.Error(t, err)
+
+	offsetsMut.Lock()
+	assert.Equal(t, []string{"", "12", "12", "13"}, offsets)
+	offsetsMut.Unlock()
+}
+
+func telegramTestText(t *testing.T, msg types.Message, index int) string {
+	t.Helper()
+
+	v, err := msg.Get(index).JSON()
+	require.NoError(t, err)
+	return v.(map[string]interface{})["message"].(map[string]interface{})["text"].(string)
+}
@@ -857,3 +857,139 @@ func TestHTTPSyncResponseHeadersStatus(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestHTTPSyncResponseMapping(t *testing.T) {
+	t.Parallel()
+
+	reg := apiRegMutWrapper{mut: &http.ServeMux{}}
+	mgr, err := manager.New(manager.NewConfig(), reg, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := input.NewConfig()
+	conf.HTTPServer.Path = "/testpost"
+	conf.HTTPServer.Response.Status = `${! meta("status") }`
+	conf.HTTPServer.Response.Headers["Content-Type"] = "application/json"
+	conf.HTTPServer.Response.Headers["foo"] = `${! json("result") }`
+	conf.HTTPServer.Response.Mapping = `
+meta status = if this.field1 == "bar" { "201" } else { "400" }
+root.result = this.foo.uppercase()
+`
+
+	h, err := input.NewHTTPServer(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	server := httptest.NewServer(reg.mut)
+	t.Cleanup(func() {
+		server.Close()
+	})
+
+	tests := []struct {
+		input   string
+		status  int
+		header  string
+		payload string
+	}{
+		{input: `{"foo":"first","field1":"bar"}`, status: 201, header: "FIRST", payload: `{"result":"FIRST"}`},
+		{input: `{"foo":"second","field1":"baz"}`, status: 400, header: "SECOND", payload: `{"result":"SECOND"}`},
+	}
+
+	for _, test := range tests {
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res, err := http.Post(server.URL+"/testpost", "application/octet-stream", bytes.NewBufferString(test.input))
+			require.NoError(t, err)
+			assert.Equal(t, test.status, res.StatusCode)
+			assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+			assert.Equal(t, test.header, res.Header.Get("foo"))
+
+			resBytes, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, test.payload, string(resBytes))
+		}()
+
+		var ts types.Transaction
+		select {
+		case ts = <-h.TransactionChan():
+			roundtrip.SetAsResponse(ts.Payload)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for message")
+		}
+		select {
+		case ts.ResponseChan <- response.NewAck():
+		case <-time.After(time.Second):
+			t.Error("Timed out waiting for response")
+		}
+		wg.Wait()
+	}
+
+	h.CloseAsync()
+	require.NoError(t, h.WaitForClose(time.Second*5))
+}
+
+func TestHTTPSyncResponseStreaming(t *testing.T) {
+	t.Parallel()
+
+	reg := apiRegMutWrapper{mut: &http.ServeMux{}}
+	mgr, err := manager.New(manager.NewConfig(), reg, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := input.NewConfig()
+	conf.HTTPServer.Path = "/testpost"
+	conf.HTTPServer.Response.Headers["Content-Type"] = "application/x-ndjson"
+	conf.HTTPServer.Response.Mapping = `root = if this.drop { deleted() } else { content().string() + "\n" }`
+	conf.HTTPServer.Response.Streaming = true
+
+	h, err := input.NewHTTPServer(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	server := httptest.NewServer(reg.mut)
+	t.Cleanup(func() {
+		server.Close()
+	})
+
+	input := []string{
+		`{"id":1,"drop":false}`,
+		`{"id":2,"drop":true}`,
+		`{"id":3,"drop":false}`,
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		hdr, body, err := createMultipart(input, "application/octet-stream")
+		require.NoError(t, err)
+
+		res, err := http.Post(server.URL+"/testpost", hdr, bytes.NewReader(body))
+		require.NoError(t, err)
+		require.Equal(t, 200, res.StatusCode)
+		assert.Equal(t, "application/x-ndjson", res.Header.Get("Content-Type"))
+		assert.Equal(t, []string{"chunked"}, res.TransferEncoding)
+
+		resBytes, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, `{"id":1,"drop":false}`+"\n"+`{"id":3,"drop":false}`+"\n", string(resBytes))
+	}()
+
+	var ts types.Transaction
+	select {
+	case ts = <-h.TransactionChan():
+		roundtrip.SetAsResponse(ts.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for message")
+	}
+	select {
+	case ts.ResponseChan <- response.NewAck():
+	case <-time.After(time.Second):
+		t.Error("Timed out waiting for response")
+	}
+
+	h.CloseAsync()
+	require.NoError(t, h.WaitForClose(time.Second*5))
+
+	wg.Wait()
+}
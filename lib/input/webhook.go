@@ -0,0 +1,460 @@
+package input
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+func init() {
+	Constructors[TypeWebhook] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			r, err := newWebhookReader(conf.Webhook, mgr, log, stats)
+			if err != nil {
+				return nil, err
+			}
+			return NewAsyncReader(
+				TypeWebhook,
+				true,
+				reader.NewAsyncPreserver(r),
+				log, stats,
+			)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `Receives webhooks from a provider and verifies their signatures, creating a message for each request with a valid signature.`,
+		Description: `
+The signature of each request is verified according to the scheme of the configured ` + "`provider`" + ` using the ` + "`secret`" + ` shared with it, and requests with a missing or invalid signature are rejected with a 401 response before reaching the pipeline. Requests with a body larger than ` + "`max_body_size`" + ` are rejected with a 413 response before their signature is verified. The following providers are supported:
+
+- ` + "`github`" + `: The ` + "`X-Hub-Signature-256`" + ` header is verified with the secret of the webhook.
+- ` + "`stripe`" + `: The ` + "`Stripe-Signature`" + ` header is verified with the signing secret of the endpoint, and requests with a timestamp more than five minutes old are rejected.
+- ` + "`shopify`" + `: The ` + "`X-Shopify-Hmac-Sha256`" + ` header is verified with the shared secret of the app.
+- ` + "`twilio`" + `: The ` + "`X-Twilio-Signature`" + ` header is verified with the auth token of the account. Since Twilio signs the URL of the request, ` + "`url`" + ` should be set when Benthos is served behind a proxy.
+
+When ` + "`address`" + ` is empty the path is registered on the [service-wide HTTP server](/docs/components/http/about), otherwise a dedicated server is started on that address.
+
+The body of each request is emitted as a message, and the request is only responded to once the message has been processed. A failure to process the message results in an error response, causing the provider to retry the delivery.
+
+## Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```" + `
+- webhook_provider
+- webhook_event_type
+- webhook_delivery_id
+` + "```" + `
+
+The event type is obtained from the ` + "`X-GitHub-Event`" + ` and ` + "`X-Shopify-Topic`" + ` headers and from the ` + "`type`" + ` field of Stripe events, and is empty for Twilio. The delivery ID is obtained from the ` + "`X-GitHub-Delivery`" + `, ` + "`X-Shopify-Webhook-Id`" + ` and ` + "`I-Twilio-Idempotency-Token`" + ` headers and from the ` + "`id`" + ` field of Stripe events, and can be used in order to detect retried deliveries.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("provider", "The provider of the webhooks, which determines how signatures are verified.").HasOptions("github", "stripe", "shopify", "twilio"),
+			docs.FieldCommon("secret", "The secret shared with the provider, used to verify the signature of requests."),
+			docs.FieldCommon("path", "The path to receive webhooks on."),
+			docs.FieldAdvanced("address", "An optional address to start a dedicated HTTP server on, when empty the path is registered on the service-wide HTTP server.", "0.0.0.0:8080"),
+			docs.FieldAdvanced("url", "The public URL of the webhook as configured with the provider, which is only used by the `twilio` provider. When empty the URL is derived from the request.", "https://example.com/webhook"),
+			docs.FieldAdvanced("timeout", "The maximum period of time to wait for a request to be processed before responding with an error."),
+			docs.FieldAdvanced("max_body_size", "The maximum size of a request body in bytes, larger requests are rejected before their signature is verified."),
+		},
+		Categories: []Category{
+			CategoryNetwork,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// WebhookConfig contains configuration fields for the webhook input type.
+type WebhookConfig struct {
+	Provider    string `json:"provider" yaml:"provider"`
+	Secret      string `json:"secret" yaml:"secret"`
+	Path        string `json:"path" yaml:"path"`
+	Address     string `json:"address" yaml:"address"`
+	URL         string `json:"url" yaml:"url"`
+	Timeout     string `json:"timeout" yaml:"timeout"`
+	MaxBodySize int    `json:"max_body_size" yaml:"max_body_size"`
+}
+
+// NewWebhookConfig creates a new WebhookConfig with default values.
+func NewWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		Provider:    "github",
+		Secret:      "",
+		Path:        "/webhook",
+		Address:     "",
+		URL:         "",
+		Timeout:     "5s",
+		MaxBodySize: 26214400,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// webhookMaxRequestAge is the maximum age of a signed timestamp, older
+// requests are rejected in order to prevent replay attacks.
+const webhookMaxRequestAge = time.Minute * 5
+
+// webhookMeta contains the metadata extracted from a verified request.
+type webhookMeta struct {
+	eventType  string
+	deliveryID string
+}
+
+type webhookVerifier func(r *http.Request, body []byte, now time.Time) (webhookMeta, error)
+
+func webhookHMAC(hashFn func() hash.Hash, secret string, parts ...[]byte) []byte {
+	mac := hmac.New(hashFn, []byte(secret))
+	for _, p := range parts {
+		_, _ = mac.Write(p)
+	}
+	return mac.Sum(nil)
+}
+
+var errWebhookSignature = errors.New("request signature is invalid")
+
+// webhookGitHubVerifier follows
+// https://docs.github.com/en/developers/webhooks-and-events/securing-your-webhooks.
+func webhookGitHubVerifier(secret, _ string) webhookVerifier {
+	return func(r *http.Request, body []byte, now time.Time) (webhookMeta, error) {
+		expected := "sha256=" + hex.EncodeToString(webhookHMAC(sha256.New, secret, body))
+		if !hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Hub-Signature-256"))) {
+			return webhookMeta{}, errWebhookSignature
+		}
+		return webhookMeta{
+			eventType:  r.Header.Get("X-GitHub-Event"),
+			deliveryID: r.Header.Get("X-GitHub-Delivery"),
+		}, nil
+	}
+}
+
+// webhookStripeVerifier follows
+// https://stripe.com/docs/webhooks/signatures#verify-manually.
+func webhookStripeVerifier(secret, _ string) webhookVerifier {
+	return func(r *http.Request, body []byte, now time.Time) (webhookMeta, error) {
+		var tsStr string
+		var signatures []string
+		for _, kv := range strings.Split(r.Header.Get("Stripe-Signature"), ",") {
+			i := strings.Index(kv, "=")
+			if i < 0 {
+				continue
+			}
+			switch kv[:i] {
+			case "t":
+				tsStr = kv[i+1:]
+			case "v1":
+				signatures = append(signatures, kv[i+1:])
+			}
+		}
+
+		ts, err := strconv.ParseInt(tsStr, 10, 64)
+		if err != nil {
+			return webhookMeta{}, errors.New("missing or invalid request timestamp")
+		}
+		if age := now.Sub(time.Unix(ts, 0)); age > webhookMaxRequestAge || age < -webhookMaxRequestAge {
+			return webhookMeta{}, errors.New("request timestamp is too old")
+		}
+
+		expected := hex.EncodeToString(webhookHMAC(sha256.New, secret, []byte(tsStr+"."), body))
+		valid := false
+		for _, sig := range signatures {
+			if hmac.Equal([]byte(expected), []byte(sig)) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return webhookMeta{}, errWebhookSignature
+		}
+
+		var event struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		}
+		_ = json.Unmarshal(body, &event)
+		return webhookMeta{
+			eventType:  event.Type,
+			deliveryID: event.ID,
+		}, nil
+	}
+}
+
+// webhookShopifyVerifier follows
+// https://shopify.dev/tutorials/manage-webhooks#verifying-webhooks.
+func webhookShopifyVerifier(secret, _ string) webhookVerifier {
+	return func(r *http.Request, body []byte, now time.Time) (webhookMeta, error) {
+		expected := base64.StdEncoding.EncodeToString(webhookHMAC(sha256.New, secret, body))
+		if !hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Shopify-Hmac-Sha256"))) {
+			return webhookMeta{}, errWebhookSignature
+		}
+		return webhookMeta{
+			eventType:  r.Header.Get("X-Shopify-Topic"),
+			deliveryID: r.Header.Get("X-Shopify-Webhook-Id"),
+		}, nil
+	}
+}
+
+// webhookTwilioVerifier follows
+// https://www.twilio.com/docs/usage/security#validating-requests, where the
+// signature covers the URL followed by the sorted parameters of form encoded
+// requests.
+func webhookTwilioVerifier(secret, publicURL string) webhookVerifier {
+	return func(r *http.Request, body []byte, now time.Time) (webhookMeta, error) {
+		signed := publicURL
+		if signed == "" {
+			scheme := "http"
+			if r.TLS != nil {
+				scheme = "https"
+			}
+			if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+				scheme = proto
+			}
+			signed = scheme + "://" + r.Host + r.URL.RequestURI()
+		}
+
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+			params, err := url.ParseQuery(string(body))
+			if err != nil {
+				return webhookMeta{}, fmt.Errorf("failed to parse form parameters: %w", err)
+			}
+			keys := make([]string, 0, len(params))
+			for k := range params {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				values := params[k]
+				sort.Strings(values)
+				for _, v := range values {
+					signed += k + v
+				}
+			}
+		}
+
+		expected := base64.StdEncoding.EncodeToString(webhookHMAC(sha1.New, secret, []byte(signed)))
+		if !hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Twilio-Signature"))) {
+			return webhookMeta{}, errWebhookSignature
+		}
+		return webhookMeta{
+			deliveryID: r.Header.Get("I-Twilio-Idempotency-Token"),
+		}, nil
+	}
+}
+
+var webhookVerifiers = map[string]func(secret, publicURL string) webhookVerifier{
+	"github":  webhookGitHubVerifier,
+	"stripe":  webhookStripeVerifier,
+	"shopify": webhookShopifyVerifier,
+	"twilio":  webhookTwilioVerifier,
+}
+
+//------------------------------------------------------------------------------
+
+type webhookEvent struct {
+	msg     types.Message
+	resChan chan error
+}
+
+type webhookReader struct {
+	conf    WebhookConfig
+	log     log.Modular
+	timeout time.Duration
+	verify  webhookVerifier
+
+	mRejected metrics.StatCounter
+
+	server *http.Server
+	events chan webhookEvent
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+func newWebhookReader(conf WebhookConfig, mgr types.Manager, log log.Modular, stats metrics.Type) (*webhookReader, error) {
+	verifierCtor, exists := webhookVerifiers[conf.Provider]
+	if !exists {
+		return nil, fmt.Errorf("provider not recognised: %v", conf.Provider)
+	}
+	if conf.Secret == "" {
+		return nil, errors.New("a secret must be specified")
+	}
+	if conf.Path == "" {
+		return nil, errors.New("a path must be specified")
+	}
+	if conf.MaxBodySize <= 0 {
+		return nil, errors.New("max_body_size must be greater than zero")
+	}
+
+	w := &webhookReader{
+		conf:       conf,
+		log:        log,
+		verify:     verifierCtor(conf.Secret, conf.URL),
+		mRejected:  stats.GetCounter("rejected"),
+		events:     make(chan webhookEvent),
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}
+
+	var err error
+	if w.timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+		return nil, fmt.Errorf("failed to parse timeout: %w", err)
+	}
+
+	if conf.Address == "" {
+		mgr.RegisterEndpoint(conf.Path, fmt.Sprintf("Receive webhooks from %v.", conf.Provider), w.handler)
+		return w, nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(conf.Path, w.handler)
+	w.server = &http.Server{Addr: conf.Address, Handler: mux}
+	go func() {
+		if err := w.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			w.log.Errorf("Server error: %v\n", err)
+		}
+	}()
+	return w, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (w *webhookReader) handler(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	maxSize := int64(w.conf.MaxBodySize)
+	if r.ContentLength > maxSize {
+		w.mRejected.Incr(1)
+		http.Error(rw, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(rw, r.Body, maxSize))
+	if err != nil {
+		if int64(len(body)) >= maxSize {
+			w.mRejected.Incr(1)
+			http.Error(rw, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(rw, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	meta, err := w.verify(r, body, time.Now())
+	if err != nil {
+		w.mRejected.Incr(1)
+		w.log.Debugf("Rejected request: %v\n", err)
+		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	part := message.NewPart(body)
+	part.Metadata().Set("webhook_provider", w.conf.Provider)
+	part.Metadata().Set("webhook_event_type", meta.eventType)
+	part.Metadata().Set("webhook_delivery_id", meta.deliveryID)
+	msg := message.New(nil)
+	msg.Append(part)
+
+	ctx, done := context.WithTimeout(r.Context(), w.timeout)
+	defer done()
+
+	resChan := make(chan error, 1)
+	select {
+	case w.events <- webhookEvent{msg: msg, resChan: resChan}:
+	case <-ctx.Done():
+		http.Error(rw, "Request timed out", http.StatusRequestTimeout)
+		return
+	case <-w.closeChan:
+		http.Error(rw, "Server closing", http.StatusServiceUnavailable)
+		return
+	}
+
+	select {
+	case err = <-resChan:
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-w.closeChan:
+		err = types.ErrTypeClosed
+	}
+	if err != nil {
+		w.log.Debugf("Failed to process webhook: %v\n", err)
+		http.Error(rw, "Failed to process webhook", http.StatusInternalServerError)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext does nothing as the server is started when the input is
+// created.
+func (w *webhookReader) ConnectWithContext(ctx context.Context) error {
+	return nil
+}
+
+// ReadWithContext waits for the next webhook to be received.
+func (w *webhookReader) ReadWithContext(ctx context.Context) (types.Message, reader.AsyncAckFn, error) {
+	select {
+	case e := <-w.events:
+		return e.msg, func(ctx context.Context, res types.Response) error {
+			e.resChan <- res.Error()
+			return nil
+		}, nil
+	case <-ctx.Done():
+		return nil, nil, types.ErrTimeout
+	case <-w.closeChan:
+		return nil, nil, types.ErrTypeClosed
+	}
+}
+
+// CloseAsync shuts down the server.
+func (w *webhookReader) CloseAsync() {
+	w.closeOnce.Do(func() {
+		close(w.closeChan)
+		if w.server == nil {
+			close(w.closedChan)
+			return
+		}
+		go func() {
+			if err := w.server.Shutdown(context.Background()); err != nil {
+				w.log.Errorf("Failed to gracefully terminate webhook server: %v\n", err)
+			}
+			close(w.closedChan)
+		}()
+	})
+}
+
+// WaitForClose blocks until the input has closed down.
+func (w *webhookReader) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-w.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
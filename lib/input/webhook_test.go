@@ -0,0 +1,261 @@
+package input
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWebhookTestReader(t *testing.T, provider string) *webhookReader {
+	t.Helper()
+
+	conf := NewWebhookConfig()
+	conf.Provider = provider
+	conf.Secret = "foo"
+
+	w, err := newWebhookReader(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	t.Cleanup(w.CloseAsync)
+	return w
+}
+
+func TestWebhookVerifiers(t *testing.T) {
+	now := time.Now()
+	body := `{"id":"evt_1","type":"charge.succeeded"}`
+	form := "To=%2B15550001111&From=%2B15552223333&Body=hello"
+
+	sign := func(hashFn func() hash.Hash, parts ...string) []byte {
+		mac := hmac.New(hashFn, []byte("foo"))
+		for _, p := range parts {
+			mac.Write([]byte(p))
+		}
+		return mac.Sum(nil)
+	}
+	tsStr := strconv.FormatInt(now.Unix(), 10)
+	oldTSStr := strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name     string
+		provider string
+		body     string
+		headers  map[string]string
+		meta     webhookMeta
+		err      string
+	}{
+		{
+			name:     "github valid",
+			provider: "github",
+			body:     body,
+			headers: map[string]string{
+				"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(sign(sha256.New, body)),
+				"X-GitHub-Event":      "push",
+				"X-GitHub-Delivery":   "abc",
+			},
+			meta: webhookMeta{eventType: "push", deliveryID: "abc"},
+		},
+		{
+			name:     "github invalid",
+			provider: "github",
+			body:     body,
+			headers: map[string]string{
+				"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(sign(sha256.New, "nope")),
+			},
+			err: "request signature is invalid",
+		},
+		{
+			name:     "stripe valid",
+			provider: "stripe",
+			body:     body,
+			headers: map[string]string{
+				"Stripe-Signature": "t=" + tsStr + ",v1=nope,v1=" + hex.EncodeToString(sign(sha256.New, tsStr+".", body)),
+			},
+			meta: webhookMeta{eventType: "charge.succeeded", deliveryID: "evt_1"},
+		},
+		{
+			name:     "stripe old",
+			provider: "stripe",
+			body:     body,
+			headers: map[string]string{
+				"Stripe-Signature": "t=" + oldTSStr + ",v1=" + hex.EncodeToString(sign(sha256.New, oldTSStr+".", body)),
+			},
+			err: "request timestamp is too old",
+		},
+		{
+			name:     "stripe missing",
+			provider: "stripe",
+			body:     body,
+			err:      "missing or invalid request timestamp",
+		},
+		{
+			name:     "shopify valid",
+			provider: "shopify",
+			body:     body,
+			headers: map[string]string{
+				"X-Shopify-Hmac-Sha256": base64.StdEncoding.EncodeToString(sign(sha256.New, body)),
+				"X-Shopify-Topic":       "orders/create",
+				"X-Shopify-Webhook-Id":  "def",
+			},
+			meta: webhookMeta{eventType: "orders/create", deliveryID: "def"},
+		},
+		{
+			name:     "shopify invalid",
+			provider: "shopify",
+			body:     body,
+			headers: map[string]string{
+				"X-Shopify-Hmac-Sha256": base64.StdEncoding.EncodeToString(sign(sha256.New, "nope")),
+			},
+			err: "request signature is invalid",
+		},
+		{
+			name:     "twilio valid",
+			provider: "twilio",
+			body:     form,
+			headers: map[string]string{
+				"Content-Type":               "application/x-www-form-urlencoded",
+				"X-Forwarded-Proto":          "https",
+				"X-Twilio-Signature":         base64.StdEncoding.EncodeToString(sign(sha1.New, "https://example.com/webhook?foo=bar", "Bodyhello", "From+15552223333", "To+15550001111")),
+				"I-Twilio-Idempotency-Token": "ghi",
+			},
+			meta: webhookMeta{deliveryID: "ghi"},
+		},
+		{
+			name:     "twilio invalid",
+			provider: "twilio",
+			body:     form,
+			headers: map[string]string{
+				"Content-Type":       "application/x-www-form-urlencoded",
+				"X-Twilio-Signature": base64.StdEncoding.EncodeToString(sign(sha1.New, "https://example.com/webhook?foo=bar")),
+			},
+			err: "request signature is invalid",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "http://example.com/webhook?foo=bar", strings.NewReader(test.body))
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+
+			meta, err := webhookVerifiers[test.provider]("foo", "")(req, []byte(test.body), now)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.meta, meta)
+		})
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	w := newWebhookTestReader(t, "github")
+
+	body := `{"action":"opened"}`
+	mac := hmac.New(sha256.New, []byte("foo"))
+	mac.Write([]byte(body))
+
+	resChan := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+		req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		req.Header.Set("X-GitHub-Event", "pull_request")
+		w.handler(rec, req)
+		resChan <- rec
+	}()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+
+	msg, ackFn, err := w.ReadWithContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, msg.Len())
+
+	part := msg.Get(0)
+	assert.Equal(t, body, string(part.Get()))
+	assert.Equal(t, "github", part.Metadata().Get("webhook_provider"))
+	assert.Equal(t, "pull_request", part.Metadata().Get("webhook_event_type"))
+
+	require.NoError(t, ackFn(ctx, response.NewError(errors.New("failed"))))
+
+	select {
+	case rec := <-resChan:
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for response")
+	}
+
+	rec := httptest.NewRecorder()
+	w.handler(rec, httptest.NewRequest("POST", "/webhook", strings.NewReader(body)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestWebhookMaxBodySize(t *testing.T) {
+	conf := NewWebhookConfig()
+	conf.Secret = "foo"
+	conf.MaxBodySize = 10
+
+	w, err := newWebhookReader(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	t.Cleanup(w.CloseAsync)
+
+	body := `{"action":"opened"}`
+
+	rec := httptest.NewRecorder()
+	w.handler(rec, httptest.NewRequest("POST", "/webhook", strings.NewReader(body)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	// Requests without a content length are limited as they are read.
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	w.handler(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestWebhookWaitForServerClose(t *testing.T) {
+	conf := NewWebhookConfig()
+	conf.Secret = "foo"
+	conf.Address = "localhost:0"
+
+	w, err := newWebhookReader(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	assert.Equal(t, types.ErrTimeout, w.WaitForClose(time.Millisecond*10))
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+}
+
+func TestWebhookBadConfig(t *testing.T) {
+	conf := NewWebhookConfig()
+	conf.Secret = "foo"
+	conf.Provider = "nope"
+	_, err := newWebhookReader(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "provider not recognised: nope")
+
+	conf.Provider = "stripe"
+	conf.Secret = ""
+	_, err = newWebhookReader(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "a secret must be specified")
+}
@@ -11,8 +11,57 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/processor"
 	"github.com/Jeffail/benthos/v3/lib/ratelimit"
 	"github.com/Jeffail/benthos/v3/lib/util/config"
+	"github.com/Jeffail/benthos/v3/lib/util/contract"
+	"github.com/Jeffail/benthos/v3/lib/util/schemaregistry"
+	"github.com/Jeffail/benthos/v3/lib/util/sqlpool"
 )
 
+// InitConfig describes how resources are initialised and which of them are
+// required in order for a pipeline to be considered ready.
+type InitConfig struct {
+	Lazy     []string `json:"lazy,omitempty" yaml:"lazy,omitempty"`
+	Required []string `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+// NewInitConfig returns an InitConfig with default values.
+func NewInitConfig() InitConfig {
+	return InitConfig{
+		Lazy:     []string{},
+		Required: []string{},
+	}
+}
+
+// LineageConfig describes whether the lineage of messages is tracked, and
+// where lineage events are sent.
+type LineageConfig struct {
+	Enabled      bool   `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	EventsOutput string `json:"events_output,omitempty" yaml:"events_output,omitempty"`
+}
+
+// NewLineageConfig returns a LineageConfig with default values.
+func NewLineageConfig() LineageConfig {
+	return LineageConfig{
+		Enabled:      false,
+		EventsOutput: "",
+	}
+}
+
+// ProtobufDescriptorsConfig describes sources of protobuf message descriptors
+// to register for use within Bloblang mappings.
+type ProtobufDescriptorsConfig struct {
+	ImportPaths    []string `json:"import_paths,omitempty" yaml:"import_paths,omitempty"`
+	DescriptorSets []string `json:"descriptor_sets,omitempty" yaml:"descriptor_sets,omitempty"`
+}
+
+// NewProtobufDescriptorsConfig returns a ProtobufDescriptorsConfig with default
+// values.
+func NewProtobufDescriptorsConfig() ProtobufDescriptorsConfig {
+	return ProtobufDescriptorsConfig{
+		ImportPaths:    []string{},
+		DescriptorSets: []string{},
+	}
+}
+
 type ResourceConfig struct {
 	// Called manager for backwards compatibility.
 	Manager            Config             `json:"resources,omitempty" yaml:"resources,omitempty"`
@@ -21,6 +70,13 @@ type ResourceConfig struct {
 	ResourceOutputs    []output.Config    `json:"output_resources,omitempty" yaml:"output_resources,omitempty"`
 	ResourceCaches     []cache.Config     `json:"cache_resources,omitempty" yaml:"cache_resources,omitempty"`
 	ResourceRateLimits []ratelimit.Config `json:"rate_limit_resources,omitempty" yaml:"rate_limit_resources,omitempty"`
+	ResourceInit       InitConfig         `json:"resource_init,omitempty" yaml:"resource_init,omitempty"`
+	Lineage            LineageConfig      `json:"lineage,omitempty" yaml:"lineage,omitempty"`
+
+	ResourceSchemaRegistries []schemaregistry.Config   `json:"schema_registry_resources,omitempty" yaml:"schema_registry_resources,omitempty"`
+	ResourceSQL              []sqlpool.Config          `json:"sql_resources,omitempty" yaml:"sql_resources,omitempty"`
+	Contracts                []contract.Config         `json:"contracts,omitempty" yaml:"contracts,omitempty"`
+	ProtobufDescriptors      ProtobufDescriptorsConfig `json:"protobuf_descriptors,omitempty" yaml:"protobuf_descriptors,omitempty"`
 }
 
 func NewResourceConfig() ResourceConfig {
@@ -31,6 +87,13 @@ func NewResourceConfig() ResourceConfig {
 		ResourceOutputs:    []output.Config{},
 		ResourceCaches:     []cache.Config{},
 		ResourceRateLimits: []ratelimit.Config{},
+		ResourceInit:       NewInitConfig(),
+		Lineage:            NewLineageConfig(),
+
+		ResourceSchemaRegistries: []schemaregistry.Config{},
+		ResourceSQL:              []sqlpool.Config{},
+		Contracts:                []contract.Config{},
+		ProtobufDescriptors:      NewProtobufDescriptorsConfig(),
 	}
 }
 
@@ -112,8 +175,48 @@ func (r *ResourceConfig) collapsed() (ResourceConfig, error) {
 		newMaps.RateLimits[c.Label] = c
 	}
 
+	registryLabels := map[string]struct{}{}
+	for _, c := range r.ResourceSchemaRegistries {
+		if c.Label == "" {
+			return *r, errors.New("schema registry resource has an empty label")
+		}
+		if _, exists := registryLabels[c.Label]; exists {
+			return *r, fmt.Errorf("schema registry resource label '%v' collides with a previously defined resource", c.Label)
+		}
+		registryLabels[c.Label] = struct{}{}
+	}
+
+	sqlLabels := map[string]struct{}{}
+	for _, c := range r.ResourceSQL {
+		if c.Label == "" {
+			return *r, errors.New("sql resource has an empty label")
+		}
+		if _, exists := sqlLabels[c.Label]; exists {
+			return *r, fmt.Errorf("sql resource label '%v' collides with a previously defined resource", c.Label)
+		}
+		sqlLabels[c.Label] = struct{}{}
+	}
+
+	contractLabels := map[string]struct{}{}
+	for _, c := range r.Contracts {
+		if c.Label == "" {
+			return *r, errors.New("contract has an empty label")
+		}
+		if _, exists := contractLabels[c.Label]; exists {
+			return *r, fmt.Errorf("contract label '%v' collides with a previously defined contract", c.Label)
+		}
+		contractLabels[c.Label] = struct{}{}
+	}
+
 	return ResourceConfig{
-		Manager: newMaps,
+		Manager:      newMaps,
+		ResourceInit: r.ResourceInit,
+		Lineage:      r.Lineage,
+
+		ResourceSchemaRegistries: r.ResourceSchemaRegistries,
+		ResourceSQL:              r.ResourceSQL,
+		Contracts:                r.Contracts,
+		ProtobufDescriptors:      r.ProtobufDescriptors,
 	}, nil
 }
 
@@ -129,6 +232,16 @@ func (r *ResourceConfig) AddFrom(extra *ResourceConfig) error {
 	r.ResourceOutputs = append(r.ResourceOutputs, extra.ResourceOutputs...)
 	r.ResourceCaches = append(r.ResourceCaches, extra.ResourceCaches...)
 	r.ResourceRateLimits = append(r.ResourceRateLimits, extra.ResourceRateLimits...)
+	r.ResourceSchemaRegistries = append(r.ResourceSchemaRegistries, extra.ResourceSchemaRegistries...)
+	r.ResourceSQL = append(r.ResourceSQL, extra.ResourceSQL...)
+	r.Contracts = append(r.Contracts, extra.Contracts...)
+	r.ProtobufDescriptors.ImportPaths = append(r.ProtobufDescriptors.ImportPaths, extra.ProtobufDescriptors.ImportPaths...)
+	r.ProtobufDescriptors.DescriptorSets = append(r.ProtobufDescriptors.DescriptorSets, extra.ProtobufDescriptors.DescriptorSets...)
+	r.ResourceInit.Lazy = append(r.ResourceInit.Lazy, extra.ResourceInit.Lazy...)
+	r.ResourceInit.Required = append(r.ResourceInit.Required, extra.ResourceInit.Required...)
+	if extra.Lineage.Enabled {
+		r.Lineage = extra.Lineage
+	}
 	return nil
 }
 
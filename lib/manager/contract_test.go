@@ -0,0 +1,121 @@
+package manager_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/util/contract"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerContractQuarantine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_contract_test")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	quarantinePath := filepath.Join(dir, "quarantine.jsonl")
+
+	qConf := output.NewConfig()
+	qConf.Type = output.TypeFile
+	qConf.File.Path = quarantinePath
+
+	cConf := contract.NewConfig()
+	cConf.Label = "users"
+	cConf.Checks = []string{`this.age >= 0`}
+	cConf.Quarantine = "dead"
+
+	conf := manager.NewResourceConfig()
+	conf.Manager.Outputs["dead"] = qConf
+	conf.Contracts = append(conf.Contracts, cConf)
+
+	mgr, err := manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	procConf := processor.NewConfig()
+	procConf.Type = processor.TypeContract
+	procConf.Contract = "users"
+
+	proc, err := mgr.NewProcessor(procConf)
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"age":10}`),
+		[]byte(`{"age":-1}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 1, msgs[0].Len())
+	assert.Equal(t, `{"age":10}`, string(msgs[0].Get(0).Get()))
+	assert.Equal(t, "", msgs[0].Get(0).Metadata().Get("contract"))
+
+	msgs, res = proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"age":-2}`),
+	}))
+	assert.Empty(t, msgs)
+	require.NotNil(t, res)
+	require.NoError(t, res.Error())
+
+	quarantined, err := ioutil.ReadFile(quarantinePath)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"age\":-1}\n{\"age\":-2}\n", string(quarantined))
+
+	c, err := contract.FromManager(mgr, "users")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), c.Violations())
+
+	mgr.CloseAsync()
+	require.NoError(t, mgr.WaitForClose(time.Second*5))
+}
+
+func TestManagerContractFlagged(t *testing.T) {
+	cConf := contract.NewConfig()
+	cConf.Label = "users"
+	cConf.Checks = []string{`this.age >= 0`}
+
+	conf := manager.NewResourceConfig()
+	conf.Contracts = append(conf.Contracts, cConf)
+
+	mgr, err := manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	procConf := processor.NewConfig()
+	procConf.Type = processor.TypeContract
+	procConf.Contract = "users"
+
+	proc, err := mgr.NewProcessor(procConf)
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"age":10}`),
+		[]byte(`{"age":-1}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 2, msgs[0].Len())
+	assert.Equal(t, "", processor.GetFail(msgs[0].Get(0)))
+	assert.Equal(t, "contract 'users' violated: check 'this.age >= 0' was not satisfied", processor.GetFail(msgs[0].Get(1)))
+}
+
+func TestManagerContractBadQuarantine(t *testing.T) {
+	cConf := contract.NewConfig()
+	cConf.Label = "users"
+	cConf.Checks = []string{`this.age >= 0`}
+	cConf.Quarantine = "nope"
+
+	conf := manager.NewResourceConfig()
+	conf.Contracts = append(conf.Contracts, cConf)
+
+	_, err := manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "quarantine 'nope' of contract 'users' is not an output resource")
+}
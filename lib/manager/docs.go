@@ -2,6 +2,9 @@ package manager
 
 import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/util/contract"
+	"github.com/Jeffail/benthos/v3/lib/util/schemaregistry"
+	"github.com/Jeffail/benthos/v3/lib/util/sqlpool"
 	"github.com/Jeffail/gabs/v2"
 )
 
@@ -55,5 +58,38 @@ func Spec() docs.FieldSpecs {
 		docs.FieldCommon(
 			"rate_limit_resources", "A list of rate limit resources, each must have a unique label.",
 		).Array().HasType(docs.FieldRateLimit).Linter(lintResource),
+
+		docs.FieldAdvanced(
+			"schema_registry_resources", "A list of [schema registry](/docs/configuration/resources#schema-registries) resources, each must have a unique label.",
+		).Array().WithChildren(schemaregistry.FieldSpecs()...).Linter(lintResource).AtVersion("3.44.0"),
+
+		docs.FieldAdvanced(
+			"sql_resources", "A list of [SQL](/docs/configuration/resources#sql-connection-pools) resources, each must have a unique label.",
+		).Array().WithChildren(sqlpool.FieldSpecs()...).Linter(lintResource).AtVersion("3.44.0"),
+
+		docs.FieldAdvanced(
+			"contracts", "A list of [data contracts](/docs/configuration/contracts), each must have a unique label. Contracts declare the expected schema and invariants of messages, and are enforced at points of a pipeline with the `contract` processor.",
+		).Array().WithChildren(contract.FieldSpecs()...).Linter(lintResource).AtVersion("3.44.0"),
+
+		docs.FieldAdvanced(
+			"resource_init", "Describes how cache, rate limit and output resources are initialised, and which of them must be available in order for the pipeline to be considered ready.",
+		).WithChildren(
+			docs.FieldCommon("lazy", "A list of resource labels to initialise on first use rather than at start up. When initialisation fails the error is returned to the component using the resource, and initialisation is attempted again on a later use with an exponential backoff.").Array(),
+			docs.FieldCommon("required", "A list of resource labels that must be available in order for the pipeline to be considered ready. Lazy resources that fail to initialise and output resources that are not connected cause the `/ready` endpoint to return a 503 when they are required, otherwise the pipeline is reported as degraded.").Array(),
+		).AtVersion("3.44.0"),
+
+		docs.FieldAdvanced(
+			"protobuf_descriptors", "Sources of protobuf message descriptors to register, allowing the messages to be parsed and formatted within [Bloblang](/docs/guides/bloblang/about) mappings with the methods `parse_protobuf` and `format_protobuf`.",
+		).WithChildren(
+			docs.FieldCommon("import_paths", "A list of directories containing .proto files, each directory is walked with all found .proto files parsed.", []string{"./schemas"}).Array(),
+			docs.FieldCommon("descriptor_sets", "A list of paths to serialized descriptor sets, as produced by `protoc` with the flag `--descriptor_set_out`.", []string{"./schemas/all.pb"}).Array(),
+		).AtVersion("3.44.0"),
+
+		docs.FieldAdvanced(
+			"lineage", "Describes whether the [lineage](/docs/configuration/lineage) of messages is tracked, where each message is assigned a stable identifier and the chain of components it passes through is recorded within metadata.",
+		).WithChildren(
+			docs.FieldCommon("enabled", "Whether to track the lineage of messages."),
+			docs.FieldCommon("events_output", "An optional label of an output resource to send a lineage event to each time a message is delivered to, or rejected by, an output."),
+		).AtVersion("3.44.0"),
 	}
 }
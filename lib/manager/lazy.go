@@ -0,0 +1,234 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/cenkalti/backoff/v4"
+)
+
+// lazyResource wraps the constructor of a resource that is initialised on
+// first use rather than when the manager is created. When initialisation fails
+// the error is returned to the caller and the constructor is attempted again
+// on a subsequent use once a backoff period has elapsed.
+type lazyResource struct {
+	name string
+	log  log.Modular
+	ctor func() (types.Closable, error)
+
+	mut       sync.Mutex
+	res       types.Closable
+	err       error
+	boff      backoff.BackOff
+	nextRetry time.Time
+	closed    bool
+}
+
+func newLazyResource(name string, log log.Modular, ctor func() (types.Closable, error)) *lazyResource {
+	boff := backoff.NewExponentialBackOff()
+	boff.InitialInterval = time.Second
+	boff.MaxInterval = time.Minute
+	boff.MaxElapsedTime = 0
+	return &lazyResource{
+		name: name,
+		log:  log,
+		ctor: ctor,
+		boff: boff,
+	}
+}
+
+func (l *lazyResource) get() (types.Closable, error) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	if l.res != nil {
+		return l.res, nil
+	}
+	if l.closed {
+		return nil, types.ErrTypeClosed
+	}
+	if time.Now().Before(l.nextRetry) {
+		return nil, l.err
+	}
+
+	res, err := l.ctor()
+	if err != nil {
+		wait := l.boff.NextBackOff()
+		l.err = fmt.Errorf("resource '%v' is not available: %w", l.name, err)
+		l.nextRetry = time.Now().Add(wait)
+		l.log.Errorf("Failed to initialise resource, retrying in %v: %v\n", wait, err)
+		return nil, l.err
+	}
+
+	l.log.Infof("Resource '%v' initialised\n", l.name)
+	l.res = res
+	return res, nil
+}
+
+// available attempts to obtain the resource, initialising it if necessary, and
+// returns whether it is available.
+func (l *lazyResource) available() bool {
+	_, err := l.get()
+	return err == nil
+}
+
+func (l *lazyResource) CloseAsync() {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	l.closed = true
+	if l.res != nil {
+		l.res.CloseAsync()
+	}
+}
+
+func (l *lazyResource) WaitForClose(timeout time.Duration) error {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	l.closed = true
+	if l.res != nil {
+		return l.res.WaitForClose(timeout)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type lazyCache struct {
+	*lazyResource
+}
+
+func (l lazyCache) cache() (types.Cache, error) {
+	res, err := l.get()
+	if err != nil {
+		return nil, err
+	}
+	return res.(types.Cache), nil
+}
+
+func (l lazyCache) Get(key string) ([]byte, error) {
+	c, err := l.cache()
+	if err != nil {
+		return nil, err
+	}
+	return c.Get(key)
+}
+
+func (l lazyCache) Set(key string, value []byte) error {
+	c, err := l.cache()
+	if err != nil {
+		return err
+	}
+	return c.Set(key, value)
+}
+
+func (l lazyCache) SetWithTTL(key string, value []byte, ttl *time.Duration) error {
+	c, err := l.cache()
+	if err != nil {
+		return err
+	}
+	if cttl, ok := c.(types.CacheWithTTL); ok {
+		return cttl.SetWithTTL(key, value, ttl)
+	}
+	return c.Set(key, value)
+}
+
+func (l lazyCache) SetMulti(items map[string][]byte) error {
+	c, err := l.cache()
+	if err != nil {
+		return err
+	}
+	return c.SetMulti(items)
+}
+
+func (l lazyCache) SetMultiWithTTL(items map[string]types.CacheTTLItem) error {
+	c, err := l.cache()
+	if err != nil {
+		return err
+	}
+	if cttl, ok := c.(types.CacheWithTTL); ok {
+		return cttl.SetMultiWithTTL(items)
+	}
+	sitems := make(map[string][]byte, len(items))
+	for k, v := range items {
+		sitems[k] = v.Value
+	}
+	return c.SetMulti(sitems)
+}
+
+func (l lazyCache) Add(key string, value []byte) error {
+	c, err := l.cache()
+	if err != nil {
+		return err
+	}
+	return c.Add(key, value)
+}
+
+func (l lazyCache) AddWithTTL(key string, value []byte, ttl *time.Duration) error {
+	c, err := l.cache()
+	if err != nil {
+		return err
+	}
+	if cttl, ok := c.(types.CacheWithTTL); ok {
+		return cttl.AddWithTTL(key, value, ttl)
+	}
+	return c.Add(key, value)
+}
+
+func (l lazyCache) Delete(key string) error {
+	c, err := l.cache()
+	if err != nil {
+		return err
+	}
+	return c.Delete(key)
+}
+
+//------------------------------------------------------------------------------
+
+type lazyRateLimit struct {
+	*lazyResource
+}
+
+func (l lazyRateLimit) Access() (time.Duration, error) {
+	res, err := l.get()
+	if err != nil {
+		return 0, err
+	}
+	return res.(types.RateLimit).Access()
+}
+
+//------------------------------------------------------------------------------
+
+type lazyOutput struct {
+	*lazyResource
+}
+
+// WriteTransaction writes a transaction to the output once it is available,
+// otherwise the transaction is rejected with the initialisation error so that
+// it can be retried upstream.
+func (l lazyOutput) WriteTransaction(ctx context.Context, t types.Transaction) error {
+	res, err := l.get()
+	if err != nil {
+		select {
+		case t.ResponseChan <- response.NewError(err):
+		case <-ctx.Done():
+			return types.ErrTimeout
+		}
+		return nil
+	}
+	return res.(types.OutputWriter).WriteTransaction(ctx, t)
+}
+
+func (l lazyOutput) Connected() bool {
+	res, err := l.get()
+	if err != nil {
+		return false
+	}
+	return res.(types.OutputWriter).Connected()
+}
@@ -0,0 +1,54 @@
+package manager
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/ratelimit"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazyResourceBackoff(t *testing.T) {
+	var attempts int
+	var fail = true
+
+	l := newLazyResource("foo", log.Noop(), func() (types.Closable, error) {
+		attempts++
+		if fail {
+			return nil, errors.New("nope")
+		}
+		conf := ratelimit.NewConfig()
+		return ratelimit.New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	})
+	rl := lazyRateLimit{l}
+
+	_, err := rl.Access()
+	require.EqualError(t, err, "resource 'foo' is not available: nope")
+	assert.Equal(t, 1, attempts)
+
+	// Subsequent uses within the backoff period do not attempt to initialise.
+	fail = false
+	_, err = rl.Access()
+	require.EqualError(t, err, "resource 'foo' is not available: nope")
+	assert.Equal(t, 1, attempts)
+
+	l.mut.Lock()
+	l.nextRetry = time.Now()
+	l.mut.Unlock()
+
+	_, err = rl.Access()
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	_, err = rl.Access()
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	rl.CloseAsync()
+	require.NoError(t, rl.WaitForClose(time.Second))
+}
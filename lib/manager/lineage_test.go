@@ -0,0 +1,132 @@
+package manager_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerLineage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_lineage_test")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	eventsPath := filepath.Join(dir, "events.jsonl")
+
+	auditConf := output.NewConfig()
+	auditConf.Type = output.TypeFile
+	auditConf.File.Path = eventsPath
+
+	conf := manager.NewResourceConfig()
+	conf.Manager.Outputs["audit"] = auditConf
+	conf.Lineage.Enabled = true
+	conf.Lineage.EventsOutput = "audit"
+
+	mgr, err := manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	inConf := input.NewConfig()
+	inConf.Type = input.TypeGenerate
+	inConf.Generate.Mapping = `root.id = "foo"`
+	inConf.Generate.Count = 1
+	inConf.Generate.Interval = ""
+
+	in, err := mgr.NewInput(inConf, false)
+	require.NoError(t, err)
+
+	procConf := processor.NewConfig()
+	procConf.Type = processor.TypeBloblang
+	procConf.Label = "thing"
+	procConf.Bloblang = `root.id = this.id.uppercase()`
+
+	proc, err := mgr.NewProcessor(procConf)
+	require.NoError(t, err)
+
+	outConf := output.NewConfig()
+	outConf.Type = output.TypeDrop
+	outConf.Label = "sink"
+
+	out, err := mgr.NewOutput(outConf)
+	require.NoError(t, err)
+
+	tChan := make(chan types.Transaction)
+	require.NoError(t, out.Consume(tChan))
+
+	var tran types.Transaction
+	select {
+	case tran = <-in.TransactionChan():
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	id := tran.Payload.Get(0).Metadata().Get("benthos_lineage_id")
+	assert.NotEmpty(t, id)
+	assert.Equal(t, "1", tran.Payload.Get(0).Metadata().Get("benthos_lineage_offset"))
+
+	msgs, res := proc.ProcessMessage(tran.Payload)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, `{"id":"FOO"}`, string(msgs[0].Get(0).Get()))
+	assert.Equal(t, "input.generate,processor.thing", msgs[0].Get(0).Metadata().Get("benthos_lineage_chain"))
+
+	resChan := make(chan types.Response)
+	select {
+	case tChan <- types.NewTransaction(msgs[0], resChan):
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	select {
+	case res = <-resChan:
+		require.NoError(t, res.Error())
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	select {
+	case tran.ResponseChan <- res:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	eventBytes, err := ioutil.ReadFile(eventsPath)
+	require.NoError(t, err)
+
+	var event map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(string(eventBytes))), &event))
+	assert.Equal(t, id, event["id"])
+	assert.Equal(t, "1", event["offset"])
+	assert.Equal(t, []interface{}{"input.generate", "processor.thing"}, event["chain"])
+	assert.Equal(t, "output.sink", event["output"])
+	assert.Equal(t, true, event["delivered"])
+
+	in.CloseAsync()
+	out.CloseAsync()
+	close(tChan)
+	require.NoError(t, in.WaitForClose(time.Second*5))
+	require.NoError(t, out.WaitForClose(time.Second*5))
+	mgr.CloseAsync()
+	require.NoError(t, mgr.WaitForClose(time.Second*5))
+}
+
+func TestManagerLineageBadEventsOutput(t *testing.T) {
+	conf := manager.NewResourceConfig()
+	conf.Lineage.Enabled = true
+	conf.Lineage.EventsOutput = "nope"
+
+	_, err := manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "lineage events output 'nope' is not an output resource")
+}
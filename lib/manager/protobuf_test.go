@@ -0,0 +1,48 @@
+package manager_test
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerProtobufDescriptors(t *testing.T) {
+	conf := manager.NewResourceConfig()
+	conf.ProtobufDescriptors.ImportPaths = []string{"../../config/test/protobuf/schema"}
+
+	mgr, err := manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	procConf := processor.NewConfig()
+	procConf.Type = processor.TypeBloblang
+	procConf.Bloblang = `
+let pb = this.format_protobuf("testing.Person")
+root.size = $pb.length()
+root.person = $pb.parse_protobuf("testing.Person")
+`
+
+	proc, err := mgr.NewProcessor(procConf)
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"firstName":"john","lastName":"oates","age":10}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, `{"person":{"age":10,"firstName":"john","lastName":"oates"},"size":15}`, string(msgs[0].Get(0).Get()))
+}
+
+func TestManagerProtobufDescriptorsBadPath(t *testing.T) {
+	conf := manager.NewResourceConfig()
+	conf.ProtobufDescriptors.DescriptorSets = []string{"./does_not_exist.pb"}
+
+	_, err := manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load protobuf descriptors")
+}
@@ -0,0 +1,103 @@
+package manager_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/input"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerInprocTopic(t *testing.T) {
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	var outputs []types.Output
+	var outChans []chan types.Transaction
+	for _, streamID := range []string{"foo", "bar"} {
+		outConf := output.NewConfig()
+		outConf.Type = output.TypeInprocTopic
+		outConf.InprocTopic.Topic = "things"
+
+		out, err := mgr.ForStream(streamID).(*manager.Type).NewOutput(outConf)
+		require.NoError(t, err)
+
+		tChan := make(chan types.Transaction)
+		require.NoError(t, out.Consume(tChan))
+
+		outputs = append(outputs, out)
+		outChans = append(outChans, tChan)
+	}
+
+	var inputs []types.Input
+	for _, group := range []string{"", "", "shared", "shared"} {
+		inConf := input.NewConfig()
+		inConf.Type = input.TypeInprocTopic
+		inConf.InprocTopic.Topic = "things"
+		inConf.InprocTopic.Group = group
+
+		in, err := mgr.ForStream("baz").(*manager.Type).NewInput(inConf, false)
+		require.NoError(t, err)
+		require.Eventually(t, in.Connected, time.Second*5, time.Millisecond*10)
+		inputs = append(inputs, in)
+	}
+
+	for i, content := range []string{"hello", "world"} {
+		resChan := make(chan types.Response)
+		select {
+		case outChans[i] <- types.NewTransaction(message.New([][]byte{[]byte(content)}), resChan):
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+		select {
+		case res := <-resChan:
+			require.NoError(t, res.Error())
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+	}
+
+	readAll := func(ins ...types.Input) []string {
+		var results []string
+		for len(results) < 2 {
+			var tran types.Transaction
+			select {
+			case tran = <-ins[len(results)%len(ins)].TransactionChan():
+			case tran = <-ins[(len(results)+1)%len(ins)].TransactionChan():
+			case <-time.After(time.Second * 5):
+				t.Fatal("timed out")
+			}
+			results = append(results, string(tran.Payload.Get(0).Get()))
+			select {
+			case tran.ResponseChan <- response.NewAck():
+			case <-time.After(time.Second * 5):
+				t.Fatal("timed out")
+			}
+		}
+		return results
+	}
+
+	assert.Equal(t, []string{"hello", "world"}, readAll(inputs[0]))
+	assert.Equal(t, []string{"hello", "world"}, readAll(inputs[1]))
+	assert.ElementsMatch(t, []string{"hello", "world"}, readAll(inputs[2], inputs[3]))
+
+	for _, in := range inputs {
+		in.CloseAsync()
+		require.NoError(t, in.WaitForClose(time.Second*5))
+	}
+	for i, out := range outputs {
+		out.CloseAsync()
+		close(outChans[i])
+		require.NoError(t, out.WaitForClose(time.Second*5))
+	}
+	mgr.CloseAsync()
+	require.NoError(t, mgr.WaitForClose(time.Second*5))
+}
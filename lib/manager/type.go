@@ -5,21 +5,30 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/bundle"
+	"github.com/Jeffail/benthos/v3/internal/checkpoint"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/lineage"
+	"github.com/Jeffail/benthos/v3/internal/protobuf"
 	"github.com/Jeffail/benthos/v3/lib/buffer"
 	"github.com/Jeffail/benthos/v3/lib/cache"
 	"github.com/Jeffail/benthos/v3/lib/condition"
 	"github.com/Jeffail/benthos/v3/lib/input"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output"
 	"github.com/Jeffail/benthos/v3/lib/processor"
 	"github.com/Jeffail/benthos/v3/lib/ratelimit"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/contract"
+	"github.com/Jeffail/benthos/v3/lib/util/schemaregistry"
+	"github.com/Jeffail/benthos/v3/lib/util/sqlpool"
+	"github.com/Jeffail/benthos/v3/lib/util/topic"
 )
 
 // ErrResourceNotFound represents an error where a named resource could not be
@@ -64,6 +73,25 @@ type Type struct {
 	plugins      map[string]interface{}
 	resourceLock *sync.RWMutex
 
+	schemaRegistries map[string]*schemaregistry.Client
+	sqlPools         map[string]*sqlpool.Pool
+	contracts        map[string]*contract.Contract
+
+	// Checkpoint stores are created on first use and are scoped to a stream,
+	// as their endpoints are namespaced by the stream identifier.
+	checkpoints     map[string]*checkpoint.Store
+	checkpointsLock *sync.Mutex
+
+	// Resources that are initialised on first use, and resources that must be
+	// available in order for the pipeline to be considered ready.
+	lazyResources     map[string]struct{}
+	requiredResources []string
+
+	// When enabled components are wrapped in order to record the lineage of
+	// messages, and lineage events are sent to an optional output resource.
+	lineage             bool
+	lineageEventsOutput string
+
 	// Collections of component constructors
 	bufferBundle    *bundle.BufferSet
 	cacheBundle     *bundle.CacheSet
@@ -77,6 +105,7 @@ type Type struct {
 	stats  metrics.Type
 
 	pipes    map[string]<-chan types.Transaction
+	topics   map[string]*topic.Topic
 	pipeLock *sync.RWMutex
 
 	// TODO: V4 Remove this
@@ -103,6 +132,15 @@ func NewV2(conf ResourceConfig, apiReg APIReg, log log.Modular, stats metrics.Ty
 		plugins:      map[string]interface{}{},
 		resourceLock: &sync.RWMutex{},
 
+		schemaRegistries: map[string]*schemaregistry.Client{},
+		sqlPools:         map[string]*sqlpool.Pool{},
+		contracts:        map[string]*contract.Contract{},
+
+		checkpoints:     map[string]*checkpoint.Store{},
+		checkpointsLock: &sync.Mutex{},
+
+		lazyResources: map[string]struct{}{},
+
 		// All bundles default to everything that was imported.
 		bufferBundle:    bundle.AllBuffers,
 		cacheBundle:     bundle.AllCaches,
@@ -116,6 +154,7 @@ func NewV2(conf ResourceConfig, apiReg APIReg, log log.Modular, stats metrics.Ty
 		stats:  stats,
 
 		pipes:    map[string]<-chan types.Transaction{},
+		topics:   map[string]*topic.Topic{},
 		pipeLock: &sync.RWMutex{},
 
 		conditions: map[string]types.Condition{},
@@ -126,6 +165,81 @@ func NewV2(conf ResourceConfig, apiReg APIReg, log log.Modular, stats metrics.Ty
 		return nil, err
 	}
 
+	isInitResource := func(name string) bool {
+		_, isCache := conf.Manager.Caches[name]
+		_, isRateLimit := conf.Manager.RateLimits[name]
+		_, isOutput := conf.Manager.Outputs[name]
+		return isCache || isRateLimit || isOutput
+	}
+	for _, name := range conf.ResourceInit.Lazy {
+		if !isInitResource(name) {
+			return nil, fmt.Errorf("lazy resource '%v' is not a cache, rate limit or output resource", name)
+		}
+		t.lazyResources[name] = struct{}{}
+	}
+	for _, name := range conf.ResourceInit.Required {
+		if !isInitResource(name) {
+			return nil, fmt.Errorf("required resource '%v' is not a cache, rate limit or output resource", name)
+		}
+		t.requiredResources = append(t.requiredResources, name)
+	}
+
+	t.lineage = conf.Lineage.Enabled
+	if name := conf.Lineage.EventsOutput; len(name) > 0 {
+		if _, isOutput := conf.Manager.Outputs[name]; !isOutput {
+			return nil, fmt.Errorf("lineage events output '%v' is not an output resource", name)
+		}
+		t.lineageEventsOutput = name
+	}
+
+	// Protobuf descriptors are registered before any components are created so
+	// that they are available to Bloblang mappings.
+	if len(conf.ProtobufDescriptors.ImportPaths) > 0 {
+		fds, err := protobuf.LoadImportPaths(conf.ProtobufDescriptors.ImportPaths)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load protobuf descriptors: %v", err)
+		}
+		protobuf.Register(fds...)
+	}
+	for _, path := range conf.ProtobufDescriptors.DescriptorSets {
+		fds, err := protobuf.LoadDescriptorSet(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load protobuf descriptors: %v", err)
+		}
+		protobuf.Register(fds...)
+	}
+
+	// Schema registries and SQL pools are created first as they do not depend
+	// on other resources, but components of all types might depend on them.
+	for _, conf := range conf.ResourceSchemaRegistries {
+		client, err := schemaregistry.New(conf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create schema registry resource '%v': %v", conf.Label, err)
+		}
+		t.schemaRegistries[conf.Label] = client
+	}
+	for _, conf := range conf.ResourceSQL {
+		pMgr := t.forComponent("resource.sql." + conf.Label)
+		pool, err := sqlpool.New(conf, pMgr.Logger(), pMgr.Metrics())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create sql resource '%v': %v", conf.Label, err)
+		}
+		t.sqlPools[conf.Label] = pool
+	}
+	for _, cConf := range conf.Contracts {
+		if cConf.Quarantine != "" {
+			if _, isOutput := conf.Manager.Outputs[cConf.Quarantine]; !isOutput {
+				return nil, fmt.Errorf("quarantine '%v' of contract '%v' is not an output resource", cConf.Quarantine, cConf.Label)
+			}
+		}
+		cMgr := t.forComponent("contract." + cConf.Label)
+		c, err := contract.New(cConf, cMgr.Logger(), cMgr.Metrics())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create contract '%v': %v", cConf.Label, err)
+		}
+		t.contracts[cConf.Label] = c
+	}
+
 	// Sometimes resources of a type might refer to other resources of the same
 	// type. When they are constructed they will check with the manager to
 	// ensure the resource they point to is valid, but not keep the reference.
@@ -241,6 +355,8 @@ func (t *Type) ForStream(id string) types.Manager {
 func (t *Type) forStream(id string) *Type {
 	newT := *t
 	newT.stream = id
+	newT.checkpoints = map[string]*checkpoint.Store{}
+	newT.checkpointsLock = &sync.Mutex{}
 	newT.logger = t.logger.WithFields(map[string]string{
 		"stream": id,
 	})
@@ -333,6 +449,19 @@ func (t *Type) UnsetPipe(name string, tran <-chan types.Transaction) {
 	t.pipeLock.Unlock()
 }
 
+// GetTopic returns a named inproc topic, creating it if it does not yet exist.
+// Topics are shared by all streams of the manager.
+func (t *Type) GetTopic(name string) *topic.Topic {
+	t.pipeLock.Lock()
+	defer t.pipeLock.Unlock()
+	tp, exists := t.topics[name]
+	if !exists {
+		tp = topic.New()
+		t.topics[name] = tp
+	}
+	return tp
+}
+
 //------------------------------------------------------------------------------
 
 // Metrics returns an aggregator preset with the current component context.
@@ -420,6 +549,14 @@ func (t *Type) StoreCache(ctx context.Context, name string, conf cache.Config) e
 		}
 	}
 
+	if t.isLazy(name) {
+		cMgr := t.forComponent("resource.cache." + name)
+		t.caches[name] = lazyCache{newLazyResource(name, cMgr.Logger(), func() (types.Closable, error) {
+			return cMgr.NewCache(conf)
+		})}
+		return nil
+	}
+
 	newCache, err := t.forComponent("resource.cache." + name).NewCache(conf)
 	if err != nil {
 		return fmt.Errorf(
@@ -466,7 +603,11 @@ func (t *Type) NewInput(conf input.Config, hasBatchProc bool, pipelines ...types
 		}
 		mgr = t.forComponent(conf.Label)
 	}
-	return t.inputBundle.Init(hasBatchProc, conf, mgr, pipelines...)
+	in, err := t.inputBundle.Init(hasBatchProc, conf, mgr, pipelines...)
+	if err != nil || !t.lineage {
+		return in, err
+	}
+	return lineage.WrapInput(lineageComponent("input", conf.Label, conf.Type), in), nil
 }
 
 // StoreInput attempts to store a new input resource. If an existing resource
@@ -535,7 +676,11 @@ func (t *Type) NewProcessor(conf processor.Config) (types.Processor, error) {
 		}
 		mgr = t.forComponent(conf.Label)
 	}
-	return t.processorBundle.Init(conf, mgr)
+	proc, err := t.processorBundle.Init(conf, mgr)
+	if err != nil || !t.lineage {
+		return proc, err
+	}
+	return lineage.WrapProcessor(lineageComponent("processor", conf.Label, conf.Type), proc), nil
 }
 
 // StoreProcessor attempts to store a new processor resource. If an existing
@@ -603,7 +748,50 @@ func (t *Type) NewOutput(conf output.Config, pipelines ...types.PipelineConstruc
 		}
 		mgr = t.forComponent(conf.Label)
 	}
-	return t.outputBundle.Init(conf, mgr, pipelines...)
+	out, err := t.outputBundle.Init(conf, mgr, pipelines...)
+	if err != nil || !t.lineage {
+		return out, err
+	}
+	return lineage.WrapOutput(lineageComponent("output", conf.Label, conf.Type), out, t.emitLineageEvents), nil
+}
+
+func lineageComponent(kind, label, typeStr string) string {
+	if len(label) > 0 {
+		return kind + "." + label
+	}
+	return kind + "." + typeStr
+}
+
+// emitLineageEvents sends lineage events as a batch of JSON documents to the
+// lineage events output resource and waits for them to be acknowledged.
+func (t *Type) emitLineageEvents(events []lineage.Event) {
+	if len(t.lineageEventsOutput) == 0 {
+		return
+	}
+
+	msg := message.New(nil)
+	for _, e := range events {
+		part := message.NewPart(nil)
+		if err := part.SetJSON(e); err != nil {
+			t.logger.Errorf("Failed to serialise lineage event: %v\n", err)
+			continue
+		}
+		msg.Append(part)
+	}
+
+	resChan := make(chan types.Response)
+	var err error
+	if aerr := t.AccessOutput(context.Background(), t.lineageEventsOutput, func(o types.OutputWriter) {
+		err = o.WriteTransaction(context.Background(), types.NewTransaction(msg, resChan))
+	}); aerr != nil {
+		err = aerr
+	}
+	if err == nil {
+		err = (<-resChan).Error()
+	}
+	if err != nil {
+		t.logger.Errorf("Failed to send lineage events: %v\n", err)
+	}
 }
 
 // StoreOutput attempts to store a new output resource. If an existing resource
@@ -627,7 +815,29 @@ func (t *Type) StoreOutput(ctx context.Context, name string, conf output.Config)
 		return fmt.Errorf("label '%v' must be empty or match the resource name '%v'", conf.Label, name)
 	}
 
-	tmpOutput, err := t.forComponent("resource.output." + name).NewOutput(conf)
+	oMgr := t.forComponent("resource.output." + name)
+	if name == t.lineageEventsOutput {
+		// The lineage of lineage events themselves is not tracked.
+		oMgr.lineage = false
+	}
+
+	if t.isLazy(name) {
+		t.outputs[name] = lazyOutput{newLazyResource(name, oMgr.Logger(), func() (types.Closable, error) {
+			tmpOutput, err := oMgr.NewOutput(conf)
+			if err != nil {
+				return nil, err
+			}
+			w, err := wrapOutput(tmpOutput)
+			if err != nil {
+				tmpOutput.CloseAsync()
+				return nil, err
+			}
+			return w, nil
+		})}
+		return nil
+	}
+
+	tmpOutput, err := oMgr.NewOutput(conf)
 	if err == nil {
 		if t.outputs[name], err = wrapOutput(tmpOutput); err != nil {
 			tmpOutput.CloseAsync()
@@ -695,6 +905,14 @@ func (t *Type) StoreRateLimit(ctx context.Context, name string, conf ratelimit.C
 		}
 	}
 
+	if t.isLazy(name) {
+		rMgr := t.forComponent("resource.rate_limit." + name)
+		t.rateLimits[name] = lazyRateLimit{newLazyResource(name, rMgr.Logger(), func() (types.Closable, error) {
+			return rMgr.NewRateLimit(conf)
+		})}
+		return nil
+	}
+
 	newRateLimit, err := t.forComponent("resource.rate_limit." + name).NewRateLimit(conf)
 	if err != nil {
 		return fmt.Errorf(
@@ -709,6 +927,61 @@ func (t *Type) StoreRateLimit(ctx context.Context, name string, conf ratelimit.C
 
 //------------------------------------------------------------------------------
 
+func (t *Type) isLazy(name string) bool {
+	_, exists := t.lazyResources[name]
+	return exists
+}
+
+func (t *Type) resourceAvailable(name string) bool {
+	if c, exists := t.caches[name]; exists {
+		if l, ok := c.(lazyCache); ok && !l.available() {
+			return false
+		}
+	}
+	if r, exists := t.rateLimits[name]; exists {
+		if l, ok := r.(lazyRateLimit); ok && !l.available() {
+			return false
+		}
+	}
+	if o, exists := t.outputs[name]; exists && o != nil && !o.Connected() {
+		return false
+	}
+	return true
+}
+
+// UnavailableResources returns the names of resources that are not currently
+// available, either because they are lazily initialised and have so far failed
+// to initialise, or because they are outputs that are not connected. Resources
+// declared as required are returned separately from those that are optional,
+// as an unavailable required resource means the pipeline is not ready, whereas
+// an unavailable optional resource means the pipeline is merely degraded.
+//
+// Lazy resources that have not yet been used are initialised by this call.
+func (t *Type) UnavailableResources() (required, optional []string) {
+	t.resourceLock.RLock()
+	defer t.resourceLock.RUnlock()
+
+	isRequired := map[string]struct{}{}
+	for _, name := range t.requiredResources {
+		isRequired[name] = struct{}{}
+		if !t.resourceAvailable(name) {
+			required = append(required, name)
+		}
+	}
+	for name := range t.lazyResources {
+		if _, exists := isRequired[name]; exists {
+			continue
+		}
+		if !t.resourceAvailable(name) {
+			optional = append(optional, name)
+		}
+	}
+	sort.Strings(optional)
+	return
+}
+
+//------------------------------------------------------------------------------
+
 // CloseAsync triggers the shut down of all resource types that implement the
 // lifetime interface types.Closable.
 func (t *Type) CloseAsync() {
@@ -740,6 +1013,9 @@ func (t *Type) CloseAsync() {
 	for _, c := range t.outputs {
 		c.CloseAsync()
 	}
+	for _, p := range t.sqlPools {
+		p.CloseAsync()
+	}
 }
 
 // WaitForClose blocks until either all closable resource types are shut down or
@@ -787,6 +1063,12 @@ func (t *Type) WaitForClose(timeout time.Duration) error {
 		}
 		delete(t.plugins, k)
 	}
+	for k, p := range t.sqlPools {
+		if err := p.WaitForClose(time.Until(timesOut)); err != nil {
+			return fmt.Errorf("resource '%s' failed to cleanly shutdown: %v", k, err)
+		}
+		delete(t.sqlPools, k)
+	}
 	return nil
 }
 
@@ -835,6 +1117,47 @@ func (t *Type) GetRateLimit(name string) (types.RateLimit, error) {
 	return nil, types.ErrRateLimitNotFound
 }
 
+// GetSchemaRegistry attempts to find a schema registry resource by its label.
+func (t *Type) GetSchemaRegistry(name string) (*schemaregistry.Client, error) {
+	if c, exists := t.schemaRegistries[name]; exists {
+		return c, nil
+	}
+	return nil, ErrResourceNotFound(name)
+}
+
+// GetContract attempts to find a contract by its label.
+func (t *Type) GetContract(name string) (*contract.Contract, error) {
+	if c, exists := t.contracts[name]; exists {
+		return c, nil
+	}
+	return nil, ErrResourceNotFound(name)
+}
+
+// GetSQLPool attempts to find a SQL resource by its label.
+func (t *Type) GetSQLPool(name string) (*sqlpool.Pool, error) {
+	if p, exists := t.sqlPools[name]; exists {
+		return p, nil
+	}
+	return nil, ErrResourceNotFound(name)
+}
+
+// GetCheckpointStore returns a checkpoint store backed by a cache resource,
+// which is created the first time it is requested.
+func (t *Type) GetCheckpointStore(cacheName string) (*checkpoint.Store, error) {
+	t.checkpointsLock.Lock()
+	defer t.checkpointsLock.Unlock()
+
+	if s, exists := t.checkpoints[cacheName]; exists {
+		return s, nil
+	}
+	s, err := checkpoint.NewStore(t, cacheName, t.stream)
+	if err != nil {
+		return nil, err
+	}
+	t.checkpoints[cacheName] = s
+	return s, nil
+}
+
 // GetOutput attempts to find a service wide output by its name.
 func (t *Type) GetOutput(name string) (types.OutputWriter, error) {
 	if c, exists := t.outputs[name]; exists {
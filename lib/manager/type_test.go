@@ -2,8 +2,11 @@ package manager_test
 
 import (
 	"context"
+	"net/http"
 	"testing"
+	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/checkpoint"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/cache"
 	"github.com/Jeffail/benthos/v3/lib/condition"
@@ -15,6 +18,8 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/processor"
 	"github.com/Jeffail/benthos/v3/lib/ratelimit"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/schemaregistry"
+	"github.com/Jeffail/benthos/v3/lib/util/sqlpool"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -133,6 +138,66 @@ func TestManagerCacheListErrors(t *testing.T) {
 	require.EqualError(t, err, "cache resource has an empty label")
 }
 
+func TestManagerLazyCache(t *testing.T) {
+	cFoo := cache.NewConfig()
+	cFoo.Label = "foo"
+
+	cBar := cache.NewConfig()
+	cBar.Label = "bar"
+	cBar.Type = cache.TypeRedis
+	cBar.Redis.Expiration = "not a duration"
+
+	conf := manager.NewResourceConfig()
+	conf.ResourceCaches = append(conf.ResourceCaches, cFoo, cBar)
+	conf.ResourceInit.Lazy = []string{"foo", "bar"}
+
+	mgr, err := manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.NoError(t, mgr.AccessCache(context.Background(), "foo", func(c types.Cache) {
+		require.NoError(t, c.Set("foo", []byte("bar")))
+		v, err := c.Get("foo")
+		require.NoError(t, err)
+		assert.Equal(t, "bar", string(v))
+	}))
+
+	require.NoError(t, mgr.AccessCache(context.Background(), "bar", func(c types.Cache) {
+		_, err := c.Get("foo")
+		assert.Contains(t, err.Error(), "resource 'bar' is not available")
+	}))
+
+	required, optional := mgr.UnavailableResources()
+	assert.Empty(t, required)
+	assert.Equal(t, []string{"bar"}, optional)
+
+	conf.ResourceInit.Required = []string{"bar"}
+
+	mgr, err = manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	required, optional = mgr.UnavailableResources()
+	assert.Equal(t, []string{"bar"}, required)
+	assert.Empty(t, optional)
+}
+
+func TestManagerLazyResourceErrors(t *testing.T) {
+	cFoo := cache.NewConfig()
+	cFoo.Label = "foo"
+
+	conf := manager.NewResourceConfig()
+	conf.ResourceCaches = append(conf.ResourceCaches, cFoo)
+	conf.ResourceInit.Lazy = []string{"bar"}
+
+	_, err := manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "lazy resource 'bar' is not a cache, rate limit or output resource")
+
+	conf.ResourceInit.Lazy = []string{}
+	conf.ResourceInit.Required = []string{"baz"}
+
+	_, err = manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "required resource 'baz' is not a cache, rate limit or output resource")
+}
+
 func TestManagerBadCache(t *testing.T) {
 	testLog := log.Noop()
 
@@ -525,3 +590,136 @@ func TestManagerPipeGetSet(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestManagerSchemaRegistryList(t *testing.T) {
+	rFoo := schemaregistry.NewConfig()
+	rFoo.Label = "foo"
+	rFoo.URL = "http://localhost:8081"
+
+	rBar := schemaregistry.NewConfig()
+	rBar.Label = "bar"
+	rBar.URL = "http://localhost:8082"
+
+	conf := manager.NewResourceConfig()
+	conf.ResourceSchemaRegistries = append(conf.ResourceSchemaRegistries, rFoo, rBar)
+
+	mgr, err := manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	foo, err := mgr.GetSchemaRegistry("foo")
+	require.NoError(t, err)
+	assert.NotNil(t, foo)
+
+	_, err = schemaregistry.FromManager(mgr.ForStream("baz"), "bar")
+	require.NoError(t, err)
+
+	_, err = mgr.GetSchemaRegistry("baz")
+	require.EqualError(t, err, "unable to locate resource: baz")
+}
+
+func TestManagerSchemaRegistryListErrors(t *testing.T) {
+	rFoo := schemaregistry.NewConfig()
+	rFoo.Label = "foo"
+	rFoo.URL = "http://localhost:8081"
+
+	conf := manager.NewResourceConfig()
+	conf.ResourceSchemaRegistries = append(conf.ResourceSchemaRegistries, rFoo, rFoo)
+
+	_, err := manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "schema registry resource label 'foo' collides with a previously defined resource")
+
+	rFoo.URL = ""
+	conf.ResourceSchemaRegistries = []schemaregistry.Config{rFoo}
+
+	_, err = manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "failed to create schema registry resource 'foo': a url is required")
+}
+
+func TestManagerSQLList(t *testing.T) {
+	sFoo := sqlpool.NewConfig()
+	sFoo.Label = "foo"
+	sFoo.DataSourceName = "foouser:foopassword@tcp(localhost:3306)/foodb"
+	sFoo.MaxOpenConnections = 10
+	sFoo.HealthCheckPeriod = ""
+
+	conf := manager.NewResourceConfig()
+	conf.ResourceSQL = append(conf.ResourceSQL, sFoo)
+
+	mgr, err := manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	foo, err := sqlpool.FromManager(mgr.ForStream("bar"), "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "mysql", foo.Driver())
+	assert.Equal(t, 10, foo.DB().Stats().MaxOpenConnections)
+
+	_, err = mgr.GetSQLPool("bar")
+	require.EqualError(t, err, "unable to locate resource: bar")
+
+	mgr.CloseAsync()
+	require.NoError(t, mgr.WaitForClose(time.Second))
+	require.EqualError(t, foo.DB().Ping(), "sql: database is closed")
+}
+
+func TestManagerSQLListErrors(t *testing.T) {
+	sFoo := sqlpool.NewConfig()
+	sFoo.Label = "foo"
+	sFoo.DataSourceName = "foouser:foopassword@tcp(localhost:3306)/foodb"
+
+	conf := manager.NewResourceConfig()
+	conf.ResourceSQL = append(conf.ResourceSQL, sFoo, sFoo)
+
+	_, err := manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "sql resource label 'foo' collides with a previously defined resource")
+
+	sFoo.DataSourceName = ""
+	conf.ResourceSQL = []sqlpool.Config{sFoo}
+
+	_, err = manager.NewV2(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "failed to create sql resource 'foo': a data_source_name is required")
+}
+
+//------------------------------------------------------------------------------
+
+type endpointReg map[string]http.HandlerFunc
+
+func (e endpointReg) RegisterEndpoint(path, desc string, h http.HandlerFunc) {
+	e[path] = h
+}
+
+func TestManagerCheckpointStores(t *testing.T) {
+	conf := manager.NewConfig()
+	conf.Caches["foo"] = cache.NewConfig()
+
+	reg := endpointReg{}
+	mgr, err := manager.New(conf, reg, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	fooA, err := checkpoint.GetStore(mgr, "foo")
+	require.NoError(t, err)
+
+	fooB, err := checkpoint.GetStore(mgr.ForComponent("bar"), "foo")
+	require.NoError(t, err)
+	assert.True(t, fooA == fooB, "components of a stream must share a store")
+
+	streamFoo, err := checkpoint.GetStore(mgr.ForStream("baz"), "foo")
+	require.NoError(t, err)
+	assert.False(t, fooA == streamFoo, "streams must not share a store")
+
+	require.NoError(t, fooA.Set("a.txt", 10))
+	require.NoError(t, streamFoo.Set("a.txt", 20))
+
+	pos, err := fooA.Get("a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), pos)
+
+	pos, err = streamFoo.Get("a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), pos)
+
+	assert.Contains(t, reg, "/checkpoints/foo")
+	assert.Contains(t, reg, "/baz/checkpoints/foo")
+
+	_, err = checkpoint.GetStore(mgr, "bar")
+	require.Error(t, err)
+}
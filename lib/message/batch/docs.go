@@ -38,6 +38,11 @@ Allows you to configure a [batching policy](/docs/configuration/batching).`,
 				"A period in which an incomplete batch should be flushed regardless of its size.",
 				"1s", "1m", "500ms",
 			),
+			docs.FieldAdvanced(
+				"jitter",
+				"A non-negative factor that adds random variance to the `period` of each batch, where the period is extended by a random duration up to the period multiplied by this factor. This is useful for preventing many instances with the same config from flushing batches in lockstep.",
+				0.1,
+			).HasDefault(0.0),
 			docs.FieldCommon(
 				"check",
 				"A [Bloblang query](/docs/guides/bloblang/about/) that should return a boolean value indicating whether a message should end a batch.",
@@ -3,6 +3,7 @@ package batch
 import (
 	"errors"
 	"fmt"
+	"math/rand"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
@@ -31,6 +32,7 @@ func SanitisePolicyConfig(policy PolicyConfig) (interface{}, error) {
 		"count":      policy.Count,
 		"check":      policy.Check,
 		"period":     policy.Period,
+		"jitter":     policy.Jitter,
 		"processors": procConfs,
 	}
 	if !isNoopCondition(policy.Condition) {
@@ -56,6 +58,7 @@ type PolicyConfig struct {
 	Condition  condition.Config   `json:"condition" yaml:"condition"`
 	Check      string             `json:"check" yaml:"check"`
 	Period     string             `json:"period" yaml:"period"`
+	Jitter     float64            `json:"jitter" yaml:"jitter"`
 	Processors []processor.Config `json:"processors" yaml:"processors"`
 }
 
@@ -70,6 +73,7 @@ func NewPolicyConfig() PolicyConfig {
 		Condition:  cond,
 		Check:      "",
 		Period:     "",
+		Jitter:     0,
 		Processors: []processor.Config{},
 	}
 }
@@ -139,14 +143,16 @@ type Policy struct {
 	byteSize  int
 	count     int
 	period    time.Duration
+	jitter    float64
 	cond      condition.Type
 	check     *mapping.Executor
 	procs     []types.Processor
 	sizeTally int
 	parts     []types.Part
 
-	triggered bool
-	lastBatch time.Time
+	triggered  bool
+	lastBatch  time.Time
+	nextPeriod time.Duration
 
 	mSizeBatch   metrics.StatCounter
 	mCountBatch  metrics.StatCounter
@@ -188,6 +194,9 @@ func NewPolicy(
 			return nil, fmt.Errorf("failed to parse duration string: %v", err)
 		}
 	}
+	if conf.Jitter < 0 {
+		return nil, errors.New("jitter must not be negative")
+	}
 	var procs []types.Processor
 	for i, pconf := range conf.Processors {
 		pMgr, pLog, pStats := interop.LabelChild(fmt.Sprintf("%v", i), mgr, log, stats)
@@ -197,12 +206,13 @@ func NewPolicy(
 		}
 		procs = append(procs, proc)
 	}
-	return &Policy{
+	p := &Policy{
 		log: log,
 
 		byteSize: conf.ByteSize,
 		count:    conf.Count,
 		period:   period,
+		jitter:   conf.Jitter,
 		cond:     cond,
 		check:    check,
 		procs:    procs,
@@ -214,7 +224,18 @@ func NewPolicy(
 		mPeriodBatch: stats.GetCounter("on_period"),
 		mCheckBatch:  stats.GetCounter("on_check"),
 		mCondBatch:   stats.GetCounter("on_condition"),
-	}, nil
+	}
+	p.nextPeriod = p.jitteredPeriod()
+	return p, nil
+}
+
+// jitteredPeriod returns the configured period with a random amount of jitter
+// added, which is at most the period multiplied by the jitter factor.
+func (p *Policy) jitteredPeriod() time.Duration {
+	if p.period <= 0 || p.jitter <= 0 {
+		return p.period
+	}
+	return p.period + time.Duration(rand.Float64()*p.jitter*float64(p.period))
 }
 
 //------------------------------------------------------------------------------
@@ -222,7 +243,7 @@ func NewPolicy(
 // Add a new message part to this batch policy. Returns true if this part
 // triggers the conditions of the policy.
 func (p *Policy) Add(part types.Part) bool {
-	p.sizeTally += len(part.Get())
+	p.sizeTally += message.GetPartSize(part)
 	p.parts = append(p.parts, part)
 
 	if !p.triggered && p.count > 0 && len(p.parts) >= p.count {
@@ -255,7 +276,7 @@ func (p *Policy) Add(part types.Part) bool {
 			p.log.Traceln("Batching based on check query")
 		}
 	}
-	return p.triggered || (p.period > 0 && time.Since(p.lastBatch) > p.period)
+	return p.triggered || (p.nextPeriod > 0 && time.Since(p.lastBatch) > p.nextPeriod)
 }
 
 // Flush clears all messages stored by this batch policy. Returns nil if the
@@ -286,7 +307,7 @@ func (p *Policy) Flush() types.Message {
 func (p *Policy) FlushAny() []types.Message {
 	var newMsg types.Message
 	if len(p.parts) > 0 {
-		if !p.triggered && p.period > 0 && time.Since(p.lastBatch) > p.period {
+		if !p.triggered && p.nextPeriod > 0 && time.Since(p.lastBatch) > p.nextPeriod {
 			p.mPeriodBatch.Incr(1)
 			p.log.Traceln("Batching based on period")
 		}
@@ -296,6 +317,7 @@ func (p *Policy) FlushAny() []types.Message {
 	p.parts = nil
 	p.sizeTally = 0
 	p.lastBatch = time.Now()
+	p.nextPeriod = p.jitteredPeriod()
 	p.triggered = false
 
 	if newMsg == nil {
@@ -326,10 +348,10 @@ func (p *Policy) Count() int {
 // should be flushed due to a configured period. A negative duration indicates
 // a period has not been set.
 func (p *Policy) UntilNext() time.Duration {
-	if p.period <= 0 {
+	if p.nextPeriod <= 0 {
 		return -1
 	}
-	return time.Until(p.lastBatch.Add(p.period))
+	return time.Until(p.lastBatch.Add(p.nextPeriod))
 }
 
 //------------------------------------------------------------------------------
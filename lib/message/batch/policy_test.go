@@ -124,6 +124,31 @@ func TestPolicyPeriod(t *testing.T) {
 	}
 }
 
+func TestPolicyPeriodJitter(t *testing.T) {
+	conf := NewPolicyConfig()
+	conf.Period = "300ms"
+	conf.Jitter = 1
+
+	pol, err := NewPolicy(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		pol.CloseAsync()
+		require.NoError(t, pol.WaitForClose(time.Second))
+	})
+
+	for i := 0; i < 10; i++ {
+		v := pol.UntilNext()
+		assert.Less(t, int64(v), int64(time.Millisecond*600))
+		assert.Greater(t, int64(v), int64(time.Millisecond*200))
+		pol.Flush()
+	}
+
+	conf.Jitter = -1
+	_, err = NewPolicy(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+}
+
 func TestPolicySize(t *testing.T) {
 	conf := NewPolicyConfig()
 	conf.ByteSize = 10
@@ -2,6 +2,7 @@ package message
 
 import (
 	"errors"
+	"fmt"
 )
 
 // Errors returned by the message type.
@@ -10,3 +11,18 @@ var (
 	ErrBadMessageBytes     = errors.New("serialised message bytes were in unexpected format")
 	ErrBlockCorrupted      = errors.New("serialised messages block was in unexpected format")
 )
+
+// ErrMessageTooLarge is returned when a message part exceeds a configured size
+// limit. The size is zero when a message is rejected before being fully read.
+type ErrMessageTooLarge struct {
+	Size  int
+	Limit int
+}
+
+// Error returns the Error string.
+func (e ErrMessageTooLarge) Error() string {
+	if e.Size <= 0 {
+		return fmt.Sprintf("message size exceeds the limit of %v bytes", e.Limit)
+	}
+	return fmt.Sprintf("message size of %v bytes exceeds the limit of %v bytes", e.Size, e.Limit)
+}
@@ -0,0 +1,52 @@
+package message
+
+import (
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// FailureDetails describes the most recent failure of a message part. Failure
+// details are kept separate from the metadata of a message part and are
+// therefore never written by outputs.
+type FailureDetails struct {
+	// Class is the class of the error, either types.ErrorClassRetryable or
+	// types.ErrorClassPermanent.
+	Class string
+
+	// Path is the path of the component that flagged the error.
+	Path string
+
+	// Timestamp is the time at which the error was flagged.
+	Timestamp time.Time
+
+	// Count is the number of times the message part has been flagged since
+	// its failure was last cleared.
+	Count int
+}
+
+// GetFailureDetails returns the failure details attached to a message part, or
+// empty details if none have been attached.
+func GetFailureDetails(p types.Part) FailureDetails {
+	if fProvider, ok := p.(interface {
+		FailureDetails() FailureDetails
+	}); ok {
+		return fProvider.FailureDetails()
+	}
+	return FailureDetails{}
+}
+
+// SetFailureDetails attaches failure details to a message part, replacing any
+// that were previously attached. Message part implementations that do not
+// support failure details are left unchanged.
+func SetFailureDetails(p types.Part, details FailureDetails) {
+	if fProvider, ok := p.(interface {
+		SetFailureDetails(FailureDetails)
+	}); ok {
+		fProvider.SetFailureDetails(details)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,304 @@
+package message
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/Jeffail/benthos/v3/internal/bufpool"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+// JSONCodec is an implementation of JSON parsing and serialization used for
+// the structured contents of message parts. Implementations must produce and
+// accept the generic types of the standard library (map[string]interface{},
+// []interface{}, etc). Numbers should be parsed as json.Number values in order
+// to avoid losing precision, unless the environment variable
+// BENTHOS_USE_NUMBER is set to false, in which case they are parsed as float64
+// values.
+type JSONCodec interface {
+	Unmarshal(data []byte) (interface{}, error)
+	Marshal(v interface{}) ([]byte, error)
+}
+
+type stdJSONCodec struct{}
+
+func (stdJSONCodec) Unmarshal(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if useNumber {
+		dec.UseNumber()
+	}
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (stdJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if buf.Len() <= 1 {
+		return nil, nil
+	}
+	buf.Truncate(buf.Len() - 1)
+	return bufpool.Bytes(buf), nil
+}
+
+var (
+	defaultJSONCodec JSONCodec = stdJSONCodec{}
+	jsonCodec                  = defaultJSONCodec
+)
+
+// SetJSONCodec replaces the JSON implementation used for parsing and
+// serializing the structured contents of message parts. This should be called
+// before any messages are processed, usually from an init function. Setting a
+// nil codec restores the default.
+//
+// The default is encoding/json, or github.com/goccy/go-json when Benthos is
+// built with the tag GOCCY_JSON.
+//
+// The codec is not used for parsing documents when key order preservation is
+// enabled.
+func SetJSONCodec(codec JSONCodec) {
+	if codec == nil {
+		codec = defaultJSONCodec
+	}
+	jsonCodec = codec
+}
+
+//------------------------------------------------------------------------------
+
+var preserveKeyOrder bool
+
+func init() {
+	if os.Getenv("BENTHOS_JSON_PRESERVE_ORDER") == "true" {
+		preserveKeyOrder = true
+	}
+}
+
+// SetPreserveKeyOrder sets whether the key order of parsed JSON documents is
+// recorded and used when they are serialized, overriding the environment
+// variable BENTHOS_JSON_PRESERVE_ORDER. This is called by the service when the
+// config field json_preserve_key_order is set, and should otherwise be called
+// before any messages are processed, usually from an init function.
+func SetPreserveKeyOrder(enabled bool) {
+	preserveKeyOrder = enabled
+}
+
+// InheritKeyOrder copies the key order recorded when parsing the structured
+// contents of src onto dst, allowing a document derived from src and set on dst
+// to be serialized with the same key order. This has no effect unless both
+// parts are of type *Part.
+func InheritKeyOrder(dst, src types.Part) {
+	dstPart, ok := dst.(*Part)
+	if !ok {
+		return
+	}
+	if srcPart, ok := src.(*Part); ok {
+		dstPart.keyOrder = srcPart.keyOrder
+	}
+}
+
+// jsonKeyOrder records the order in which keys of objects were parsed within a
+// JSON document. Structured contents remain as generic maps, and when the
+// document is serialized the keys that were present at parse time are written
+// in their original order, followed by any new keys in alphabetical order.
+//
+// The orders of array elements are aligned by index while the length of an
+// array is unchanged. Otherwise each element is matched with the next recorded
+// order of the same shape, so that removing or inserting elements does not
+// shift the orders of those that follow.
+type jsonKeyOrder struct {
+	keys     []string
+	children map[string]*jsonKeyOrder
+	items    []*jsonKeyOrder
+}
+
+func parseJSONWithKeyOrder(data []byte) (interface{}, *jsonKeyOrder, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if useNumber {
+		dec.UseNumber()
+	}
+	return decodeWithKeyOrder(dec)
+}
+
+func decodeWithKeyOrder(dec *json.Decoder) (interface{}, *jsonKeyOrder, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	delim, isDelim := tok.(json.Delim)
+	if !isDelim {
+		return tok, nil, nil
+	}
+
+	switch delim {
+	case '{':
+		obj := map[string]interface{}{}
+		order := &jsonKeyOrder{}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+			key, ok := keyTok.(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("expected object key, got %v", keyTok)
+			}
+			v, child, err := decodeWithKeyOrder(dec)
+			if err != nil {
+				return nil, nil, err
+			}
+			if _, exists := obj[key]; !exists {
+				order.keys = append(order.keys, key)
+			}
+			obj[key] = v
+			if child != nil {
+				if order.children == nil {
+					order.children = map[string]*jsonKeyOrder{}
+				}
+				order.children[key] = child
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, nil, err
+		}
+		return obj, order, nil
+	case '[':
+		arr := []interface{}{}
+		order := &jsonKeyOrder{}
+		var hasChildren bool
+		for dec.More() {
+			v, child, err := decodeWithKeyOrder(dec)
+			if err != nil {
+				return nil, nil, err
+			}
+			arr = append(arr, v)
+			order.items = append(order.items, child)
+			hasChildren = hasChildren || child != nil
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, nil, err
+		}
+		if !hasChildren {
+			order = nil
+		}
+		return arr, order, nil
+	}
+	return nil, nil, fmt.Errorf("unexpected delimiter: %v", delim)
+}
+
+func marshalWithKeyOrder(v interface{}, order *jsonKeyOrder) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeWithKeyOrder(&buf, v, order); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeWithKeyOrder(buf *bytes.Buffer, v interface{}, order *jsonKeyOrder) error {
+	if order == nil {
+		b, err := jsonCodec.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		return nil
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		seen := make(map[string]struct{}, len(order.keys))
+		for _, k := range order.keys {
+			if _, exists := t[k]; exists {
+				keys = append(keys, k)
+				seen[k] = struct{}{}
+			}
+		}
+		var newKeys []string
+		for k := range t {
+			if _, exists := seen[k]; !exists {
+				newKeys = append(newKeys, k)
+			}
+		}
+		sort.Strings(newKeys)
+		keys = append(keys, newKeys...)
+
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeWithKeyOrder(buf, k, nil); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeWithKeyOrder(buf, t[k], order.children[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case []interface{}:
+		aligned := len(t) == len(order.items)
+		next := 0
+		buf.WriteByte('[')
+		for i, ele := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			var child *jsonKeyOrder
+			if aligned {
+				child = order.items[i]
+			} else {
+				for j := next; j < len(order.items); j++ {
+					if order.items[j].matches(ele) {
+						child, next = order.items[j], j+1
+						break
+					}
+				}
+			}
+			if err := writeWithKeyOrder(buf, ele, child); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+	return writeWithKeyOrder(buf, v, nil)
+}
+
+// matches returns whether a recorded order has the same shape as a value,
+// meaning an object with exactly the recorded keys or an array of the recorded
+// length.
+func (o *jsonKeyOrder) matches(v interface{}) bool {
+	if o == nil {
+		return false
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if o.items != nil || len(t) != len(o.keys) {
+			return false
+		}
+		for _, k := range o.keys {
+			if _, exists := t[k]; !exists {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		return o.keys == nil && len(t) == len(o.items)
+	}
+	return false
+}
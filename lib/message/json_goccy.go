@@ -0,0 +1,48 @@
+// +build GOCCY_JSON
+
+package message
+
+import (
+	"bytes"
+
+	"github.com/Jeffail/benthos/v3/internal/bufpool"
+	gojson "github.com/goccy/go-json"
+)
+
+// goccyJSONCodec is a JSONCodec implemented with github.com/goccy/go-json,
+// which is registered in place of encoding/json when Benthos is built with the
+// tag GOCCY_JSON.
+type goccyJSONCodec struct{}
+
+func (goccyJSONCodec) Unmarshal(data []byte) (interface{}, error) {
+	dec := gojson.NewDecoder(bytes.NewReader(data))
+	if useNumber {
+		dec.UseNumber()
+	}
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (goccyJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+
+	enc := gojson.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if buf.Len() <= 1 {
+		return nil, nil
+	}
+	buf.Truncate(buf.Len() - 1)
+	return bufpool.Bytes(buf), nil
+}
+
+func init() {
+	defaultJSONCodec = goccyJSONCodec{}
+	SetJSONCodec(nil)
+}
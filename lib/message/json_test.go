@@ -0,0 +1,188 @@
+package message
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPartJSONPreserveKeyOrder(t *testing.T) {
+	preserveKeyOrder = true
+	defer func() {
+		preserveKeyOrder = false
+	}()
+
+	input := `{"zed":1,"alpha":{"b":"b","a":[{"y":1,"x":2},3]},"mid":"<html>"}`
+
+	p := NewPart([]byte(input))
+	jObj, err := p.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := jObj.(map[string]interface{})
+	if exp, act := json.Number("1"), root["zed"]; exp != act {
+		t.Errorf("Wrong number value: %v != %v", act, exp)
+	}
+
+	// Mutate a copy of the document, deleting and adding keys.
+	cp, err := CopyJSON(jObj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpRoot := cp.(map[string]interface{})
+	delete(cpRoot, "mid")
+	cpRoot["new_b"] = true
+	cpRoot["new_a"] = false
+	cpRoot["alpha"].(map[string]interface{})["c"] = "c"
+
+	p2 := p.Copy()
+	if err = p2.SetJSON(cp); err != nil {
+		t.Fatal(err)
+	}
+
+	if exp, act := input, string(p.Get()); exp != act {
+		t.Errorf("Wrong original result: %v != %v", act, exp)
+	}
+	if exp, act := `{"zed":1,"alpha":{"b":"b","a":[{"y":1,"x":2},3],"c":"c"},"new_a":false,"new_b":true}`, string(p2.Get()); exp != act {
+		t.Errorf("Wrong mutated result: %v != %v", act, exp)
+	}
+
+	p3 := p2.DeepCopy()
+	if exp, act := string(p2.Get()), string(p3.Get()); exp != act {
+		t.Errorf("Wrong deep copied result: %v != %v", act, exp)
+	}
+
+	p2.Set([]byte(`{"b":1,"a":2}`))
+	if err = p2.SetJSON(map[string]interface{}{"b": 1, "a": 2}); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := `{"a":2,"b":1}`, string(p2.Get()); exp != act {
+		t.Errorf("Wrong reset result: %v != %v", act, exp)
+	}
+}
+
+func TestPartJSONPreserveKeyOrderArrays(t *testing.T) {
+	preserveKeyOrder = true
+	defer func() {
+		preserveKeyOrder = false
+	}()
+
+	input := `[{"b":1,"a":2},{"d":3,"c":4,"e":5},{"z":6,"y":7},[{"n":8,"m":9}]]`
+
+	tests := map[string]struct {
+		mutate func(arr []interface{}) []interface{}
+		output string
+	}{
+		"unchanged": {
+			mutate: func(arr []interface{}) []interface{} {
+				return arr
+			},
+			output: input,
+		},
+		"remove first": {
+			mutate: func(arr []interface{}) []interface{} {
+				return arr[1:]
+			},
+			output: `[{"d":3,"c":4,"e":5},{"z":6,"y":7},[{"n":8,"m":9}]]`,
+		},
+		"remove middle": {
+			mutate: func(arr []interface{}) []interface{} {
+				return append(arr[:1:1], arr[2:]...)
+			},
+			output: `[{"b":1,"a":2},{"z":6,"y":7},[{"n":8,"m":9}]]`,
+		},
+		"insert first": {
+			mutate: func(arr []interface{}) []interface{} {
+				return append([]interface{}{map[string]interface{}{"q": 0, "p": 0}}, arr...)
+			},
+			output: `[{"p":0,"q":0},{"b":1,"a":2},{"d":3,"c":4,"e":5},{"z":6,"y":7},[{"n":8,"m":9}]]`,
+		},
+		"modify element": {
+			mutate: func(arr []interface{}) []interface{} {
+				arr[1].(map[string]interface{})["a"] = 0
+				return arr
+			},
+			output: `[{"b":1,"a":2},{"d":3,"c":4,"e":5,"a":0},{"z":6,"y":7},[{"n":8,"m":9}]]`,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			p := NewPart([]byte(input))
+			jObj, err := p.JSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			cp, err := CopyJSON(jObj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = p.SetJSON(test.mutate(cp.([]interface{}))); err != nil {
+				t.Fatal(err)
+			}
+			if exp, act := test.output, string(p.Get()); exp != act {
+				t.Errorf("Wrong result: %v != %v", act, exp)
+			}
+		})
+	}
+}
+
+func TestPartJSONPreserveKeyOrderErrors(t *testing.T) {
+	preserveKeyOrder = true
+	defer func() {
+		preserveKeyOrder = false
+	}()
+
+	for _, input := range []string{
+		`{"foo":`,
+		`{"foo":"bar"`,
+		`[1,2`,
+		`nope`,
+	} {
+		if _, err := NewPart([]byte(input)).JSON(); err == nil {
+			t.Errorf("Expected error from input: %v", input)
+		}
+	}
+}
+
+type testJSONCodec struct {
+	unmarshals, marshals int
+}
+
+func (c *testJSONCodec) Unmarshal(data []byte) (interface{}, error) {
+	c.unmarshals++
+	return stdJSONCodec{}.Unmarshal(data)
+}
+
+func (c *testJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshals++
+	return stdJSONCodec{}.Marshal(v)
+}
+
+func TestPartJSONCodec(t *testing.T) {
+	codec := &testJSONCodec{}
+	SetJSONCodec(codec)
+	defer SetJSONCodec(nil)
+
+	p := NewPart([]byte(`{"foo":"bar"}`))
+	jObj, err := p.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.SetJSON(map[string]interface{}{
+		"foo": jObj.(map[string]interface{})["foo"],
+		"baz": "<qux>",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := `{"baz":"<qux>","foo":"bar"}`, string(p.Get()); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := 1, codec.unmarshals; exp != act {
+		t.Errorf("Wrong count of unmarshals: %v != %v", act, exp)
+	}
+	if exp, act := 1, codec.marshals; exp != act {
+		t.Errorf("Wrong count of marshals: %v != %v", act, exp)
+	}
+}
@@ -18,12 +18,12 @@ func (l *lazyCopy) ensureCopied() {
 	if l.copied {
 		return
 	}
-	var newMap map[string]string
 	if t, ok := l.m.(*Type); ok {
-		newMap = make(map[string]string, len(t.m))
-	} else {
-		newMap = map[string]string{}
+		l.m = t.Copy()
+		l.copied = true
+		return
 	}
+	newMap := map[string]string{}
 	l.m.Iter(func(k, v string) error {
 		newMap[k] = v
 		return nil
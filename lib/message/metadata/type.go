@@ -8,73 +8,142 @@ import (
 
 // Type is an implementation of types.Metadata representing the metadata of a
 // message part within a batch.
+//
+// Copies of a metadata object share an immutable base map, and each copy
+// records its own modifications in a private overlay. Copying metadata
+// therefore only costs as much as the modifications made since the base was
+// last compacted, and copies that are discarded without being modified cost
+// nothing to the original.
 type Type struct {
-	m map[string]string
+	// base is never modified once a Type is created, and may be shared by any
+	// number of copies.
+	base map[string]string
+
+	// overlay and deleted are owned exclusively by this Type, and record keys
+	// that have been set or removed since the base was created.
+	overlay map[string]string
+	deleted map[string]struct{}
 }
 
 // New creates a new metadata implementation from a map[string]string. It is
-// safe to provide a nil map.
+// safe to provide a nil map. The map is copied and never modified, and callers
+// are free to keep using it.
 func New(m map[string]string) *Type {
+	var base map[string]string
+	if len(m) > 0 {
+		base = make(map[string]string, len(m))
+		for k, v := range m {
+			base[k] = v
+		}
+	}
 	return &Type{
-		m: m,
+		base: base,
 	}
 }
 
 //------------------------------------------------------------------------------
 
+// compactThreshold is the minimum number of modifications recorded in an
+// overlay before they are folded into a new base map.
+const compactThreshold = 8
+
+// compact folds the modifications of the overlay into a new base map once they
+// grow large relative to the base, which prevents copies from repeatedly
+// cloning a large overlay.
+func (m *Type) compact() {
+	changes := len(m.overlay) + len(m.deleted)
+	if changes < compactThreshold || changes < len(m.base)/2 {
+		return
+	}
+	newBase := make(map[string]string, len(m.base)+len(m.overlay))
+	m.iter(func(k, v string) error {
+		newBase[k] = v
+		return nil
+	})
+	m.base, m.overlay, m.deleted = newBase, nil, nil
+}
+
 // Copy returns a copy of the metadata object that can be edited without
-// changing the contents of the original.
+// changing the contents of the original. The base map is shared with the copy
+// and only modifications made to the original are cloned. Copy does not modify
+// the original, and can therefore be called concurrently on the same object.
 func (m *Type) Copy() types.Metadata {
-	var newMap map[string]string
-	if m.m != nil {
-		newMap = make(map[string]string, len(m.m))
-		for k, v := range m.m {
-			newMap[k] = v
+	newM := &Type{
+		base: m.base,
+	}
+	if len(m.overlay) > 0 {
+		newM.overlay = make(map[string]string, len(m.overlay))
+		for k, v := range m.overlay {
+			newM.overlay[k] = v
+		}
+	}
+	if len(m.deleted) > 0 {
+		newM.deleted = make(map[string]struct{}, len(m.deleted))
+		for k := range m.deleted {
+			newM.deleted[k] = struct{}{}
 		}
 	}
-	return New(newMap)
+	return newM
 }
 
 // Get returns a metadata value if a key exists, otherwise an empty string.
 func (m *Type) Get(key string) string {
-	if m.m == nil {
+	if v, exists := m.overlay[key]; exists {
+		return v
+	}
+	if _, exists := m.deleted[key]; exists {
 		return ""
 	}
-	return m.m[key]
+	return m.base[key]
 }
 
 // Set sets the value of a metadata key.
 func (m *Type) Set(key, value string) types.Metadata {
-	if m.m == nil {
-		m.m = map[string]string{
-			key: value,
-		}
-		return m
+	if m.overlay == nil {
+		m.overlay = map[string]string{}
 	}
-	m.m[key] = value
+	m.overlay[key] = value
+	delete(m.deleted, key)
+	m.compact()
 	return m
 }
 
 // Delete removes the value of a metadata key.
 func (m *Type) Delete(key string) types.Metadata {
-	if m.m == nil {
-		return m
+	delete(m.overlay, key)
+	if _, exists := m.base[key]; exists {
+		if m.deleted == nil {
+			m.deleted = map[string]struct{}{}
+		}
+		m.deleted[key] = struct{}{}
+		m.compact()
 	}
-	delete(m.m, key)
 	return m
 }
 
-// Iter iterates each metadata key/value pair.
-func (m *Type) Iter(f func(k, v string) error) error {
-	if m.m == nil {
-		return nil
+func (m *Type) iter(f func(k, v string) error) error {
+	for k, v := range m.overlay {
+		if err := f(k, v); err != nil {
+			return err
+		}
 	}
-	for ak, av := range m.m {
-		if err := f(ak, av); err != nil {
+	for k, v := range m.base {
+		if _, exists := m.overlay[k]; exists {
+			continue
+		}
+		if _, exists := m.deleted[k]; exists {
+			continue
+		}
+		if err := f(k, v); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// Iter iterates each metadata key/value pair.
+func (m *Type) Iter(f func(k, v string) error) error {
+	return m.iter(f)
+}
+
 //------------------------------------------------------------------------------
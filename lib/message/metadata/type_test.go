@@ -2,7 +2,9 @@ package metadata
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -83,3 +85,208 @@ func TestMetadataNil(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestMetadataCopyOnWrite(t *testing.T) {
+	orig := New(map[string]string{
+		"foo": "bar",
+		"baz": "buz",
+	})
+
+	copyA := orig.Copy()
+	copyB := copyA.Copy()
+
+	copyA.Set("foo", "changed a")
+	if exp, act := "changed a", copyA.Get("foo"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "bar", orig.Get("foo"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "bar", copyB.Get("foo"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+
+	orig.Delete("baz")
+	if exp, act := "", orig.Get("baz"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "buz", copyA.Get("baz"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "buz", copyB.Get("baz"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+
+	copyB.Set("baz", "changed b")
+	if exp, act := "changed b", copyB.Get("baz"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "buz", copyA.Get("baz"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+}
+
+func TestMetadataProvidedMapUnmodified(t *testing.T) {
+	provided := map[string]string{
+		"foo": "bar",
+	}
+	m := New(provided)
+
+	// Modifications made by the caller after the metadata is created are not
+	// visible.
+	provided["baz"] = "buz"
+	if exp, act := "", m.Get("baz"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+
+	copied := m.Copy()
+	m.Set("foo", "changed")
+	m.Delete("baz")
+
+	// Neither the metadata nor its copies modify the provided map, and later
+	// modifications of the provided map do not leak into either.
+	provided["qux"] = "quz"
+	if exp, act := map[string]string{"foo": "bar", "baz": "buz", "qux": "quz"}, provided; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong provided map: %v != %v", act, exp)
+	}
+	for _, md := range []types.Metadata{m, copied} {
+		if exp, act := "", md.Get("qux"); exp != act {
+			t.Errorf("Wrong result: %v != %v", act, exp)
+		}
+	}
+	if exp, act := "bar", copied.Get("foo"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "changed", m.Get("foo"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+
+	// A provided map is also left unmodified when the metadata is modified
+	// without having been copied.
+	provided = map[string]string{"foo": "bar"}
+	New(provided).Set("foo", "changed").Set("baz", "buz")
+	if exp, act := map[string]string{"foo": "bar"}, provided; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong provided map: %v != %v", act, exp)
+	}
+}
+
+func TestMetadataConcurrentCopy(t *testing.T) {
+	orig := New(map[string]string{
+		"foo": "bar",
+	})
+	orig.Set("baz", "buz")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			copied := orig.Copy()
+			copied.Set("foo", fmt.Sprintf("bar%v", i))
+			if exp, act := fmt.Sprintf("bar%v", i), copied.Get("foo"); exp != act {
+				t.Errorf("Wrong result: %v != %v", act, exp)
+			}
+			if exp, act := "buz", copied.Get("baz"); exp != act {
+				t.Errorf("Wrong result: %v != %v", act, exp)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if exp, act := "bar", orig.Get("foo"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+}
+
+func TestMetadataCopyCompaction(t *testing.T) {
+	orig := New(map[string]string{
+		"foo": "bar",
+	})
+	for i := 0; i < compactThreshold*2; i++ {
+		orig.Set(fmt.Sprintf("key%v", i), "value")
+	}
+	if len(orig.overlay) >= compactThreshold {
+		t.Errorf("Overlay was not compacted: %v", orig.overlay)
+	}
+	orig.Delete("foo")
+
+	copied := orig.Copy()
+	copied.Set("foo", "baz")
+
+	if exp, act := "", orig.Get("foo"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "baz", copied.Get("foo"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+
+	count := 0
+	copied.Iter(func(k, v string) error {
+		count++
+		return nil
+	})
+	if exp, act := compactThreshold*2+1, count; exp != act {
+		t.Errorf("Wrong count of keys: %v != %v", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func benchMetadataMap() map[string]string {
+	m := map[string]string{}
+	for i := 0; i < 20; i++ {
+		m[fmt.Sprintf("kafka_header_%v", i)] = "some header value"
+	}
+	return m
+}
+
+// eagerCopy clones the full map of a metadata object, which is how copies were
+// made before metadata was copy-on-write, and is used as a benchmark baseline.
+func eagerCopy(m types.Metadata) types.Metadata {
+	newMap := map[string]string{}
+	m.Iter(func(k, v string) error {
+		newMap[k] = v
+		return nil
+	})
+	return New(newMap)
+}
+
+func BenchmarkMetadataCopyDiscardEager(b *testing.B) {
+	m := New(benchMetadataMap())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = eagerCopy(m)
+		m.Set("foo", "bar")
+	}
+}
+
+func BenchmarkMetadataCopyDiscard(b *testing.B) {
+	m := New(benchMetadataMap())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = m.Copy()
+		m.Set("foo", "bar")
+	}
+}
+
+func BenchmarkMetadataCopyModifyEager(b *testing.B) {
+	m := New(benchMetadataMap())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := eagerCopy(m)
+		c.Set("foo", "bar")
+	}
+}
+
+func BenchmarkMetadataCopyModify(b *testing.B) {
+	m := New(benchMetadataMap())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := m.Copy()
+		c.Set("foo", "bar")
+	}
+}
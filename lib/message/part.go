@@ -1,8 +1,6 @@
 package message
 
 import (
-	"bytes"
-	"encoding/json"
 	"os"
 
 	"github.com/Jeffail/benthos/v3/lib/message/metadata"
@@ -25,6 +23,8 @@ type Part struct {
 	data      []byte
 	metadata  types.Metadata
 	jsonCache interface{}
+	keyOrder  *jsonKeyOrder
+	failure   *FailureDetails
 }
 
 // NewPart initializes a new message part.
@@ -36,7 +36,9 @@ func NewPart(data []byte) *Part {
 
 //------------------------------------------------------------------------------
 
-// Copy creates a shallow copy of the message part.
+// Copy creates a shallow copy of the message part. The raw bytes and cached
+// JSON structure of the part are shared with the copy until either is set, and
+// metadata is cloned lazily on the first modification.
 func (p *Part) Copy() types.Part {
 	var clonedMeta types.Metadata
 	if p.metadata != nil {
@@ -46,6 +48,8 @@ func (p *Part) Copy() types.Part {
 		data:      p.data,
 		metadata:  clonedMeta,
 		jsonCache: p.jsonCache,
+		keyOrder:  p.keyOrder,
+		failure:   p.failure,
 	}
 }
 
@@ -71,6 +75,8 @@ func (p *Part) DeepCopy() types.Part {
 		data:      np,
 		metadata:  clonedMeta,
 		jsonCache: clonedJSON,
+		keyOrder:  p.keyOrder,
+		failure:   p.failure,
 	}
 }
 
@@ -79,15 +85,14 @@ func (p *Part) DeepCopy() types.Part {
 // Get returns the body of the message part.
 func (p *Part) Get() []byte {
 	if p.data == nil && p.jsonCache != nil {
-		var buf bytes.Buffer
-		enc := json.NewEncoder(&buf)
-		enc.SetEscapeHTML(false)
-		err := enc.Encode(p.jsonCache)
-		if err != nil {
-			return nil
+		var err error
+		if p.keyOrder != nil {
+			p.data, err = marshalWithKeyOrder(p.jsonCache, p.keyOrder)
+		} else {
+			p.data, err = jsonCodec.Marshal(p.jsonCache)
 		}
-		if buf.Len() > 1 {
-			p.data = buf.Bytes()[:buf.Len()-1]
+		if err != nil {
+			p.data = nil
 		}
 	}
 	return p.data
@@ -110,13 +115,19 @@ func (p *Part) JSON() (interface{}, error) {
 	if p.data == nil {
 		return nil, ErrMessagePartNotExist
 	}
-	dec := json.NewDecoder(bytes.NewReader(p.data))
-	if useNumber {
-		dec.UseNumber()
+	if preserveKeyOrder {
+		jObj, keyOrder, err := parseJSONWithKeyOrder(p.data)
+		if err != nil {
+			return nil, err
+		}
+		p.jsonCache, p.keyOrder = jObj, keyOrder
+		return p.jsonCache, nil
 	}
-	if err := dec.Decode(&p.jsonCache); err != nil {
+	jObj, err := jsonCodec.Unmarshal(p.data)
+	if err != nil {
 		return nil, err
 	}
+	p.jsonCache = jObj
 	return p.jsonCache, nil
 }
 
@@ -124,6 +135,7 @@ func (p *Part) JSON() (interface{}, error) {
 func (p *Part) Set(data []byte) types.Part {
 	p.data = data
 	p.jsonCache = nil
+	p.keyOrder = nil
 	return p
 }
 
@@ -133,6 +145,24 @@ func (p *Part) SetMetadata(meta types.Metadata) types.Part {
 	return p
 }
 
+// FailureDetails returns the failure details of the message part.
+func (p *Part) FailureDetails() FailureDetails {
+	if p.failure == nil {
+		return FailureDetails{}
+	}
+	return *p.failure
+}
+
+// SetFailureDetails sets the failure details of the message part. Copies of a
+// message part share the same details until either is set.
+func (p *Part) SetFailureDetails(details FailureDetails) {
+	if details == (FailureDetails{}) {
+		p.failure = nil
+		return
+	}
+	p.failure = &details
+}
+
 // SetJSON attempts to marshal a JSON document into a byte slice and stores the
 // result as the contents of the message part.
 func (p *Part) SetJSON(jObj interface{}) error {
@@ -100,6 +100,22 @@ func TestPartShallowCopy(t *testing.T) {
 	}
 }
 
+func TestPartShallowCopyOriginalModified(t *testing.T) {
+	p := NewPart([]byte(`hello world`))
+	p.Metadata().Set("foo", "bar")
+
+	p2 := p.Copy()
+	p.Metadata().Set("foo", "changed")
+	p.Set([]byte(`changed`))
+
+	if exp, act := "bar", p2.Metadata().Get("foo"); exp != act {
+		t.Errorf("Metadata changed after copy: %v != %v", act, exp)
+	}
+	if exp, act := "hello world", string(p2.Get()); exp != act {
+		t.Errorf("Contents changed after copy: %v != %v", act, exp)
+	}
+}
+
 func TestPartCopyDirtyJSON(t *testing.T) {
 	p := NewPart(nil)
 	dirtyObj := map[string]int{
@@ -105,6 +105,16 @@ func (p *partWithContext) SetMetadata(meta types.Metadata) types.Part {
 	return p
 }
 
+// FailureDetails returns the failure details of the underlying message part.
+func (p *partWithContext) FailureDetails() FailureDetails {
+	return GetFailureDetails(p.p)
+}
+
+// SetFailureDetails sets the failure details of the underlying message part.
+func (p *partWithContext) SetFailureDetails(details FailureDetails) {
+	SetFailureDetails(p.p, details)
+}
+
 // SetJSON attempts to marshal a JSON document into a byte slice and stores the
 // result as the contents of the message part.
 func (p *partWithContext) SetJSON(jObj interface{}) error {
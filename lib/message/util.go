@@ -47,6 +47,28 @@ func GetAllBytesLen(m types.Message) int {
 	return length
 }
 
+// GetPartSize returns the size in bytes of a message part, including the keys
+// and values of its metadata.
+func GetPartSize(p types.Part) int {
+	size := len(p.Get())
+	p.Metadata().Iter(func(k, v string) error {
+		size += len(k) + len(v)
+		return nil
+	})
+	return size
+}
+
+// GetSize returns the total size in bytes of all parts of a message, including
+// the keys and values of their metadata.
+func GetSize(m types.Message) int {
+	size := 0
+	m.Iter(func(i int, p types.Part) error {
+		size += GetPartSize(p)
+		return nil
+	})
+	return size
+}
+
 //------------------------------------------------------------------------------
 
 // MetaPartCopy creates a new empty message part by copying any meta fields
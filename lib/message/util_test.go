@@ -22,6 +22,24 @@ func TestGetAllBytes(t *testing.T) {
 	}
 }
 
+func TestGetSize(t *testing.T) {
+	m := New([][]byte{
+		[]byte("foo"),
+		[]byte("barbaz"),
+	})
+	m.Get(0).Metadata().Set("key", "value")
+
+	if exp, act := 11, GetPartSize(m.Get(0)); exp != act {
+		t.Errorf("Wrong part size: %v != %v", act, exp)
+	}
+	if exp, act := 6, GetPartSize(m.Get(1)); exp != act {
+		t.Errorf("Wrong part size: %v != %v", act, exp)
+	}
+	if exp, act := 17, GetSize(m); exp != act {
+		t.Errorf("Wrong size: %v != %v", act, exp)
+	}
+}
+
 func TestSetAllMetadata(t *testing.T) {
 	meta := metadata.New(map[string]string{
 		"foo": "bar",
@@ -0,0 +1,305 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeAWSCloudWatchEMF] = TypeSpec{
+		constructor: NewAWSCloudWatchEMF,
+		Status:      docs.StatusExperimental,
+		Version:     "3.44.0",
+		Summary: `
+Writes metrics as structured logs in the [CloudWatch Embedded Metric Format (EMF)](https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html), which CloudWatch extracts into metrics asynchronously.`,
+		Description: `
+Unlike the ` + "[`aws_cloudwatch`](/docs/components/metrics/aws_cloudwatch)" + ` metrics type no API requests are made, which avoids the costs and throttling of the PutMetricData endpoint. When running within AWS Lambda or with the ` + "`awslogs`" + ` log driver of ECS the documents can be written to stdout by leaving the field ` + "`address`" + ` empty, otherwise they can be sent to the CloudWatch agent by setting ` + "`address`" + ` to its EMF endpoint, e.g. ` + "`tcp://127.0.0.1:25888`" + `.
+
+Metrics are aggregated in memory and at the end of each ` + "`flush_period`" + ` a document is written for each distinct set of dimensions. Counters are written as the sum of the increments made since the previous flush, gauges are written with their current value, and timings are written as an array of up to 100 sampled timing values in microseconds.
+
+Labels of metrics are written as dimensions, along with any static ` + "`dimensions`" + `.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("namespace", "The CloudWatch namespace of metrics."),
+			docs.FieldCommon("address", "An optional address of the CloudWatch agent to send documents to in the form `tcp://host:port` or `udp://host:port`. When empty documents are written to stdout.", "tcp://127.0.0.1:25888", "udp://127.0.0.1:25888"),
+			docs.FieldCommon("dimensions", "A map of static dimensions to add to all metrics.").Map(),
+			pathMappingDocs(true, false),
+			docs.FieldAdvanced("flush_period", "The period of time between writes of metrics."),
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CloudWatchEMFConfig contains config fields for the CloudWatch EMF metrics
+// type.
+type CloudWatchEMFConfig struct {
+	Namespace   string            `json:"namespace" yaml:"namespace"`
+	Address     string            `json:"address" yaml:"address"`
+	Dimensions  map[string]string `json:"dimensions" yaml:"dimensions"`
+	PathMapping string            `json:"path_mapping" yaml:"path_mapping"`
+	FlushPeriod string            `json:"flush_period" yaml:"flush_period"`
+}
+
+// NewCloudWatchEMFConfig creates an CloudWatchEMFConfig struct with default
+// values.
+func NewCloudWatchEMFConfig() CloudWatchEMFConfig {
+	return CloudWatchEMFConfig{
+		Namespace:   "Benthos",
+		Address:     "",
+		Dimensions:  map[string]string{},
+		PathMapping: "",
+		FlushPeriod: "10s",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	maxEMFMetrics    = 100
+	maxEMFDimensions = 30
+)
+
+// CloudWatchEMF is a metrics type that writes aggregated metrics as documents
+// in the CloudWatch Embedded Metric Format.
+type CloudWatchEMF struct {
+	*pushAggregator
+
+	network     string
+	addr        string
+	out         io.Writer
+	flushPeriod time.Duration
+
+	ctx    context.Context
+	cancel func()
+
+	config CloudWatchEMFConfig
+	log    log.Modular
+}
+
+// NewAWSCloudWatchEMF creates and returns a new CloudWatchEMF object.
+func NewAWSCloudWatchEMF(config Config, opts ...func(Type)) (Type, error) {
+	c := &CloudWatchEMF{
+		config: config.AWSCloudWatchEMF,
+		out:    os.Stdout,
+		log:    log.Noop(),
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.config.Namespace == "" {
+		return nil, errors.New("a namespace must be specified")
+	}
+	if len(c.config.Dimensions) > maxEMFDimensions {
+		return nil, fmt.Errorf("a maximum of %v dimensions can be specified", maxEMFDimensions)
+	}
+	if c.config.Address != "" {
+		u, err := url.Parse(c.config.Address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse address: %v", err)
+		}
+		if u.Scheme != "tcp" && u.Scheme != "udp" {
+			return nil, fmt.Errorf("address scheme must be tcp or udp, received: %v", u.Scheme)
+		}
+		c.network, c.addr = u.Scheme, u.Host
+	}
+
+	var err error
+	if c.flushPeriod, err = time.ParseDuration(c.config.FlushPeriod); err != nil {
+		return nil, fmt.Errorf("failed to parse flush period: %v", err)
+	}
+
+	pathMapping, err := newPathMapping(c.config.PathMapping, c.log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init path mapping: %v", err)
+	}
+	c.pushAggregator = newPushAggregator(pathMapping)
+
+	go c.loop()
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (c *CloudWatchEMF) loop() {
+	ticker := time.NewTicker(c.flushPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.flush(); err != nil {
+				c.log.Errorf("Failed to write metric data: %v\n", err)
+			}
+		}
+	}
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+// toDocuments groups series by their dimensions and converts each group into
+// EMF documents of up to 100 metrics.
+func (c *CloudWatchEMF) toDocuments(now time.Time, series []pushSeries) []map[string]interface{} {
+	type group struct {
+		dimensions map[string]string
+		series     []pushSeries
+	}
+	groups := map[string]*group{}
+	var keys []string
+
+	for _, s := range series {
+		dims := map[string]string{}
+		for k, v := range c.config.Dimensions {
+			dims[k] = v
+		}
+		for k, v := range s.Labels() {
+			if _, exists := dims[k]; exists || len(dims) < maxEMFDimensions {
+				dims[k] = v
+			}
+		}
+
+		names := make([]string, 0, len(dims))
+		for k := range dims {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		var key strings.Builder
+		for _, k := range names {
+			key.WriteString(k)
+			key.WriteByte('=')
+			key.WriteString(dims[k])
+			key.WriteByte(0)
+		}
+
+		g, exists := groups[key.String()]
+		if !exists {
+			g = &group{dimensions: dims}
+			groups[key.String()] = g
+			keys = append(keys, key.String())
+		}
+		g.series = append(g.series, s)
+	}
+	sort.Strings(keys)
+
+	var documents []map[string]interface{}
+	for _, k := range keys {
+		g := groups[k]
+
+		dimNames := make([]string, 0, len(g.dimensions))
+		for name := range g.dimensions {
+			dimNames = append(dimNames, name)
+		}
+		sort.Strings(dimNames)
+
+		for len(g.series) > 0 {
+			batch := g.series
+			if len(batch) > maxEMFMetrics {
+				batch = batch[:maxEMFMetrics]
+			}
+			g.series = g.series[len(batch):]
+
+			doc := map[string]interface{}{}
+			for name, value := range g.dimensions {
+				doc[name] = value
+			}
+
+			metrics := make([]emfMetric, 0, len(batch))
+			for _, s := range batch {
+				switch s.Kind {
+				case pushCounter:
+					doc[s.Name] = s.Value
+					metrics = append(metrics, emfMetric{Name: s.Name, Unit: "Count"})
+				case pushGauge:
+					doc[s.Name] = s.Value
+					metrics = append(metrics, emfMetric{Name: s.Name, Unit: "None"})
+				case pushTimer:
+					values := make([]int64, len(s.Samples))
+					for i, v := range s.Samples {
+						values[i] = v / 1000
+					}
+					doc[s.Name] = values
+					metrics = append(metrics, emfMetric{Name: s.Name, Unit: "Microseconds"})
+				}
+			}
+
+			doc["_aws"] = map[string]interface{}{
+				"Timestamp": now.UnixNano() / int64(time.Millisecond),
+				"CloudWatchMetrics": []interface{}{
+					map[string]interface{}{
+						"Namespace":  c.config.Namespace,
+						"Dimensions": [][]string{dimNames},
+						"Metrics":    metrics,
+					},
+				},
+			}
+			documents = append(documents, doc)
+		}
+	}
+	return documents
+}
+
+func (c *CloudWatchEMF) flush() error {
+	documents := c.toDocuments(time.Now(), c.pushAggregator.flush())
+	if len(documents) == 0 {
+		return nil
+	}
+
+	out := c.out
+	if c.network != "" {
+		conn, err := net.DialTimeout(c.network, c.addr, time.Second*5)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		out = conn
+	}
+
+	for _, doc := range documents {
+		b, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		// Each document is written with a single call in order to send it
+		// within a single datagram over UDP.
+		if _, err = out.Write(append(b, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// SetLogger sets the logger used to print connection errors.
+func (c *CloudWatchEMF) SetLogger(log log.Modular) {
+	c.log = log
+}
+
+// Close stops the CloudWatchEMF object from aggregating metrics and cleans up
+// resources.
+func (c *CloudWatchEMF) Close() error {
+	c.cancel()
+	if err := c.flush(); err != nil {
+		c.log.Errorf("Failed to write metric data: %v\n", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseEMFDocuments(t *testing.T, b []byte) []map[string]interface{} {
+	t.Helper()
+
+	var docs []map[string]interface{}
+	for _, line := range bytes.Split(bytes.TrimSpace(b), []byte("\n")) {
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(line, &doc))
+
+		aws := doc["_aws"].(map[string]interface{})
+		assert.Greater(t, aws["Timestamp"], float64(0))
+		aws["Timestamp"] = 0.0
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+func TestCloudWatchEMFFlush(t *testing.T) {
+	conf := NewConfig()
+	conf.AWSCloudWatchEMF.Namespace = "Foo"
+	conf.AWSCloudWatchEMF.FlushPeriod = "1h"
+	conf.AWSCloudWatchEMF.Dimensions = map[string]string{"service": "bar"}
+
+	m, err := NewAWSCloudWatchEMF(conf)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	c := m.(*CloudWatchEMF)
+	c.out = &buf
+
+	c.GetCounter("input.received").Incr(2)
+	c.GetCounter("input.received").Incr(3)
+	c.GetCounterVec("output.error", []string{"reason"}).With("timeout").Incr(1)
+	c.GetGauge("buffer.size").Set(10)
+	c.GetTimer("output.latency").Timing(100000)
+	c.GetTimer("output.latency").Timing(300000)
+
+	require.NoError(t, m.Close())
+
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"_aws": map[string]interface{}{
+				"Timestamp": 0.0,
+				"CloudWatchMetrics": []interface{}{
+					map[string]interface{}{
+						"Namespace":  "Foo",
+						"Dimensions": []interface{}{[]interface{}{"reason", "service"}},
+						"Metrics": []interface{}{
+							map[string]interface{}{"Name": "output.error", "Unit": "Count"},
+						},
+					},
+				},
+			},
+			"reason":       "timeout",
+			"service":      "bar",
+			"output.error": 1.0,
+		},
+		{
+			"_aws": map[string]interface{}{
+				"Timestamp": 0.0,
+				"CloudWatchMetrics": []interface{}{
+					map[string]interface{}{
+						"Namespace":  "Foo",
+						"Dimensions": []interface{}{[]interface{}{"service"}},
+						"Metrics": []interface{}{
+							map[string]interface{}{"Name": "buffer.size", "Unit": "None"},
+							map[string]interface{}{"Name": "input.received", "Unit": "Count"},
+							map[string]interface{}{"Name": "output.latency", "Unit": "Microseconds"},
+						},
+					},
+				},
+			},
+			"service":        "bar",
+			"buffer.size":    10.0,
+			"input.received": 5.0,
+			"output.latency": []interface{}{100.0, 300.0},
+		},
+	}, parseEMFDocuments(t, buf.Bytes()))
+}
+
+func TestCloudWatchEMFAgent(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	linesChan := make(chan []byte)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			linesChan <- append([]byte(nil), scanner.Bytes()...)
+		}
+	}()
+
+	conf := NewConfig()
+	conf.AWSCloudWatchEMF.Address = "tcp://" + ln.Addr().String()
+	conf.AWSCloudWatchEMF.FlushPeriod = "1h"
+
+	m, err := NewAWSCloudWatchEMF(conf)
+	require.NoError(t, err)
+
+	m.GetCounter("input.received").Incr(1)
+	require.NoError(t, m.Close())
+
+	select {
+	case line := <-linesChan:
+		docs := parseEMFDocuments(t, line)
+		require.Len(t, docs, 1)
+		assert.Equal(t, 1.0, docs[0]["input.received"])
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+}
+
+func TestCloudWatchEMFBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.AWSCloudWatchEMF.Address = "http://localhost:25888"
+	_, err := NewAWSCloudWatchEMF(conf)
+	require.EqualError(t, err, "address scheme must be tcp or udp, received: http")
+
+	conf = NewConfig()
+	conf.AWSCloudWatchEMF.Namespace = ""
+	_, err = NewAWSCloudWatchEMF(conf)
+	require.EqualError(t, err, "a namespace must be specified")
+}
@@ -68,17 +68,19 @@ var Constructors = map[string]TypeSpec{}
 
 // String constants representing each metric type.
 const (
-	TypeAWSCloudWatch = "aws_cloudwatch"
-	TypeBlackList     = "blacklist"
-	TypeCloudWatch    = "cloudwatch"
-	TypeHTTPServer    = "http_server"
-	TypeInfluxDB      = "influxdb"
-	TypeNone          = "none"
-	TypePrometheus    = "prometheus"
-	TypeRename        = "rename"
-	TypeStatsd        = "statsd"
-	TypeStdout        = "stdout"
-	TypeWhiteList     = "whitelist"
+	TypeAWSCloudWatch    = "aws_cloudwatch"
+	TypeAWSCloudWatchEMF = "aws_cloudwatch_emf"
+	TypeBlackList        = "blacklist"
+	TypeCloudWatch       = "cloudwatch"
+	TypeDatadog          = "datadog"
+	TypeHTTPServer       = "http_server"
+	TypeInfluxDB         = "influxdb"
+	TypeNone             = "none"
+	TypePrometheus       = "prometheus"
+	TypeRename           = "rename"
+	TypeStatsd           = "statsd"
+	TypeStdout           = "stdout"
+	TypeWhiteList        = "whitelist"
 )
 
 //------------------------------------------------------------------------------
@@ -86,35 +88,39 @@ const (
 // Config is the all encompassing configuration struct for all metric output
 // types.
 type Config struct {
-	Type          string           `json:"type" yaml:"type"`
-	AWSCloudWatch CloudWatchConfig `json:"aws_cloudwatch" yaml:"aws_cloudwatch"`
-	Blacklist     BlacklistConfig  `json:"blacklist" yaml:"blacklist"`
-	CloudWatch    CloudWatchConfig `json:"cloudwatch" yaml:"cloudwatch"`
-	HTTP          HTTPConfig       `json:"http_server" yaml:"http_server"`
-	InfluxDB      InfluxDBConfig   `json:"influxdb" yaml:"influxdb"`
-	None          struct{}         `json:"none" yaml:"none"`
-	Prometheus    PrometheusConfig `json:"prometheus" yaml:"prometheus"`
-	Rename        RenameConfig     `json:"rename" yaml:"rename"`
-	Statsd        StatsdConfig     `json:"statsd" yaml:"statsd"`
-	Stdout        StdoutConfig     `json:"stdout" yaml:"stdout"`
-	Whitelist     WhitelistConfig  `json:"whitelist" yaml:"whitelist"`
+	Type             string              `json:"type" yaml:"type"`
+	AWSCloudWatch    CloudWatchConfig    `json:"aws_cloudwatch" yaml:"aws_cloudwatch"`
+	AWSCloudWatchEMF CloudWatchEMFConfig `json:"aws_cloudwatch_emf" yaml:"aws_cloudwatch_emf"`
+	Blacklist        BlacklistConfig     `json:"blacklist" yaml:"blacklist"`
+	CloudWatch       CloudWatchConfig    `json:"cloudwatch" yaml:"cloudwatch"`
+	Datadog          DatadogConfig       `json:"datadog" yaml:"datadog"`
+	HTTP             HTTPConfig          `json:"http_server" yaml:"http_server"`
+	InfluxDB         InfluxDBConfig      `json:"influxdb" yaml:"influxdb"`
+	None             struct{}            `json:"none" yaml:"none"`
+	Prometheus       PrometheusConfig    `json:"prometheus" yaml:"prometheus"`
+	Rename           RenameConfig        `json:"rename" yaml:"rename"`
+	Statsd           StatsdConfig        `json:"statsd" yaml:"statsd"`
+	Stdout           StdoutConfig        `json:"stdout" yaml:"stdout"`
+	Whitelist        WhitelistConfig     `json:"whitelist" yaml:"whitelist"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Type:          "http_server",
-		AWSCloudWatch: NewCloudWatchConfig(),
-		Blacklist:     NewBlacklistConfig(),
-		CloudWatch:    NewCloudWatchConfig(),
-		HTTP:          NewHTTPConfig(),
-		InfluxDB:      NewInfluxDBConfig(),
-		None:          struct{}{},
-		Prometheus:    NewPrometheusConfig(),
-		Rename:        NewRenameConfig(),
-		Statsd:        NewStatsdConfig(),
-		Stdout:        NewStdoutConfig(),
-		Whitelist:     NewWhitelistConfig(),
+		Type:             "http_server",
+		AWSCloudWatch:    NewCloudWatchConfig(),
+		AWSCloudWatchEMF: NewCloudWatchEMFConfig(),
+		Blacklist:        NewBlacklistConfig(),
+		CloudWatch:       NewCloudWatchConfig(),
+		Datadog:          NewDatadogConfig(),
+		HTTP:             NewHTTPConfig(),
+		InfluxDB:         NewInfluxDBConfig(),
+		None:             struct{}{},
+		Prometheus:       NewPrometheusConfig(),
+		Rename:           NewRenameConfig(),
+		Statsd:           NewStatsdConfig(),
+		Stdout:           NewStdoutConfig(),
+		Whitelist:        NewWhitelistConfig(),
 	}
 }
 
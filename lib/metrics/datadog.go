@@ -0,0 +1,254 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeDatadog] = TypeSpec{
+		constructor: NewDatadog,
+		Status:      docs.StatusExperimental,
+		Version:     "3.44.0",
+		Summary: `
+Pushes metrics to the [Datadog API](https://docs.datadoghq.com/api/latest/metrics/) without the need for an agent.`,
+		Description: `
+Metrics are aggregated in memory and submitted as series at the end of each ` + "`flush_period`" + `. Counters are submitted as counts of the increments made since the previous flush, and gauges are submitted with their current value.
+
+Timings are submitted as a count of the timings made since the previous flush with the suffix ` + "`.count`" + `, and gauges of the average and maximum timing with the suffixes ` + "`.avg`" + ` and ` + "`.max`" + `, where timing values are in nanoseconds.
+
+Labels of metrics are submitted as tags of the form ` + "`name:value`" + `, along with any static ` + "`tags`" + `.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("api_key", "A Datadog API key."),
+			docs.FieldCommon("site", "The [Datadog site](https://docs.datadoghq.com/getting_started/site/) to submit metrics to.", "datadoghq.com", "datadoghq.eu", "us3.datadoghq.com"),
+			docs.FieldCommon("prefix", "A string prefix to add to all metrics."),
+			pathMappingDocs(true, false),
+			docs.FieldCommon("tags", "A list of static tags to add to all metrics.", []string{"env:prod", "service:benthos"}).Array(),
+			docs.FieldAdvanced("host", "An optional host name to submit metrics with."),
+			docs.FieldAdvanced("flush_period", "The period of time between submissions of metrics."),
+			docs.FieldAdvanced("timeout", "The maximum period of time to wait for a submission to complete."),
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// DatadogConfig contains config fields for the Datadog metrics type.
+type DatadogConfig struct {
+	APIKey      string   `json:"api_key" yaml:"api_key"`
+	Site        string   `json:"site" yaml:"site"`
+	Prefix      string   `json:"prefix" yaml:"prefix"`
+	PathMapping string   `json:"path_mapping" yaml:"path_mapping"`
+	Tags        []string `json:"tags" yaml:"tags"`
+	Host        string   `json:"host" yaml:"host"`
+	FlushPeriod string   `json:"flush_period" yaml:"flush_period"`
+	Timeout     string   `json:"timeout" yaml:"timeout"`
+}
+
+// NewDatadogConfig creates an DatadogConfig struct with default values.
+func NewDatadogConfig() DatadogConfig {
+	return DatadogConfig{
+		APIKey:      "",
+		Site:        "datadoghq.com",
+		Prefix:      "benthos",
+		PathMapping: "",
+		Tags:        []string{},
+		Host:        "",
+		FlushPeriod: "10s",
+		Timeout:     "5s",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type datadogSeries struct {
+	Metric   string       `json:"metric"`
+	Type     string       `json:"type"`
+	Interval int64        `json:"interval,omitempty"`
+	Points   [][2]float64 `json:"points"`
+	Host     string       `json:"host,omitempty"`
+	Tags     []string     `json:"tags,omitempty"`
+}
+
+// Datadog is a metrics type that pushes aggregated metrics to the Datadog API.
+type Datadog struct {
+	*pushAggregator
+
+	url         string
+	prefix      string
+	flushPeriod time.Duration
+	timeout     time.Duration
+	client      *http.Client
+
+	ctx    context.Context
+	cancel func()
+
+	config DatadogConfig
+	log    log.Modular
+}
+
+// NewDatadog creates and returns a new Datadog object.
+func NewDatadog(config Config, opts ...func(Type)) (Type, error) {
+	d := &Datadog{
+		config: config.Datadog,
+		client: &http.Client{},
+		log:    log.Noop(),
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	if d.config.APIKey == "" {
+		return nil, errors.New("an api_key must be specified")
+	}
+	if d.config.Site == "" {
+		return nil, errors.New("a site must be specified")
+	}
+	d.url = "https://api." + d.config.Site + "/api/v1/series"
+
+	d.prefix = d.config.Prefix
+	if len(d.prefix) > 0 && d.prefix[len(d.prefix)-1] != '.' {
+		d.prefix += "."
+	}
+
+	var err error
+	if d.flushPeriod, err = time.ParseDuration(d.config.FlushPeriod); err != nil {
+		return nil, fmt.Errorf("failed to parse flush period: %v", err)
+	}
+	if d.timeout, err = time.ParseDuration(d.config.Timeout); err != nil {
+		return nil, fmt.Errorf("failed to parse timeout: %v", err)
+	}
+
+	pathMapping, err := newPathMapping(d.config.PathMapping, d.log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init path mapping: %v", err)
+	}
+	d.pushAggregator = newPushAggregator(pathMapping)
+
+	go d.loop()
+	return d, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (d *Datadog) loop() {
+	ticker := time.NewTicker(d.flushPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.flush(); err != nil {
+				d.log.Errorf("Failed to send metric data: %v\n", err)
+			}
+		}
+	}
+}
+
+func (d *Datadog) toSeries(now time.Time, series []pushSeries) []datadogSeries {
+	ts := float64(now.Unix())
+	interval := int64(d.flushPeriod / time.Second)
+
+	var out []datadogSeries
+	for _, s := range series {
+		tags := append([]string{}, d.config.Tags...)
+		for i, k := range s.LabelNames {
+			if i < len(s.LabelValues) {
+				tags = append(tags, k+":"+s.LabelValues[i])
+			}
+		}
+		newSeries := func(suffix, kind string, value float64) datadogSeries {
+			ds := datadogSeries{
+				Metric: d.prefix + s.Name + suffix,
+				Type:   kind,
+				Points: [][2]float64{{ts, value}},
+				Host:   d.config.Host,
+				Tags:   tags,
+			}
+			if kind == "count" {
+				ds.Interval = interval
+			}
+			return ds
+		}
+		switch s.Kind {
+		case pushCounter:
+			out = append(out, newSeries("", "count", float64(s.Value)))
+		case pushGauge:
+			out = append(out, newSeries("", "gauge", float64(s.Value)))
+		case pushTimer:
+			out = append(out,
+				newSeries(".count", "count", float64(s.Count)),
+				newSeries(".avg", "gauge", float64(s.Sum)/float64(s.Count)),
+				newSeries(".max", "gauge", float64(s.Max)),
+			)
+		}
+	}
+	return out
+}
+
+func (d *Datadog) flush() error {
+	series := d.toSeries(time.Now(), d.pushAggregator.flush())
+	if len(series) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"series": series,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), d.timeout)
+	defer done()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", d.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("DD-API-KEY", d.config.APIKey)
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		resBody, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("unexpected status code %v: %s", res.StatusCode, resBody)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// SetLogger sets the logger used to print connection errors.
+func (d *Datadog) SetLogger(log log.Modular) {
+	d.log = log
+}
+
+// Close stops the Datadog object from aggregating metrics and cleans up
+// resources.
+func (d *Datadog) Close() error {
+	d.cancel()
+	if err := d.flush(); err != nil {
+		d.log.Errorf("Failed to send metric data: %v\n", err)
+	}
+	return nil
+}
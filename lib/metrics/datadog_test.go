@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatadogFlush(t *testing.T) {
+	var reqMut sync.Mutex
+	var reqs []map[string][]datadogSeries
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "fookey", r.Header.Get("DD-API-KEY"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		var payload map[string][]datadogSeries
+		assert.NoError(t, json.Unmarshal(body, &payload))
+
+		reqMut.Lock()
+		reqs = append(reqs, payload)
+		reqMut.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	conf := NewConfig()
+	conf.Datadog.APIKey = "fookey"
+	conf.Datadog.FlushPeriod = "1h"
+	conf.Datadog.Tags = []string{"env:test"}
+	conf.Datadog.Host = "foohost"
+
+	m, err := NewDatadog(conf)
+	require.NoError(t, err)
+
+	d := m.(*Datadog)
+	d.url = srv.URL
+
+	d.GetCounter("input.received").Incr(2)
+	d.GetCounter("input.received").Incr(3)
+	d.GetCounterVec("output.error", []string{"reason"}).With("timeout").Incr(1)
+	d.GetGauge("buffer.size").Set(10)
+	d.GetTimer("output.latency").Timing(100)
+	d.GetTimer("output.latency").Timing(300)
+
+	require.NoError(t, d.flush())
+
+	d.GetGauge("buffer.size").Decr(4)
+	require.NoError(t, m.Close())
+
+	reqMut.Lock()
+	defer reqMut.Unlock()
+	require.Len(t, reqs, 2)
+
+	toMap := func(series []datadogSeries) map[string]datadogSeries {
+		m := map[string]datadogSeries{}
+		for _, s := range series {
+			require.Len(t, s.Points, 1)
+			assert.Greater(t, s.Points[0][0], float64(0))
+			s.Points[0][0] = 0
+			m[s.Metric] = s
+		}
+		return m
+	}
+
+	assert.Equal(t, map[string]datadogSeries{
+		"benthos.input.received": {
+			Metric: "benthos.input.received", Type: "count", Interval: 3600,
+			Points: [][2]float64{{0, 5}}, Host: "foohost", Tags: []string{"env:test"},
+		},
+		"benthos.output.error": {
+			Metric: "benthos.output.error", Type: "count", Interval: 3600,
+			Points: [][2]float64{{0, 1}}, Host: "foohost", Tags: []string{"env:test", "reason:timeout"},
+		},
+		"benthos.buffer.size": {
+			Metric: "benthos.buffer.size", Type: "gauge",
+			Points: [][2]float64{{0, 10}}, Host: "foohost", Tags: []string{"env:test"},
+		},
+		"benthos.output.latency.count": {
+			Metric: "benthos.output.latency.count", Type: "count", Interval: 3600,
+			Points: [][2]float64{{0, 2}}, Host: "foohost", Tags: []string{"env:test"},
+		},
+		"benthos.output.latency.avg": {
+			Metric: "benthos.output.latency.avg", Type: "gauge",
+			Points: [][2]float64{{0, 200}}, Host: "foohost", Tags: []string{"env:test"},
+		},
+		"benthos.output.latency.max": {
+			Metric: "benthos.output.latency.max", Type: "gauge",
+			Points: [][2]float64{{0, 300}}, Host: "foohost", Tags: []string{"env:test"},
+		},
+	}, toMap(reqs[0]["series"]))
+
+	// Counters and timers are reset by each flush, gauges are not.
+	assert.Equal(t, map[string]datadogSeries{
+		"benthos.buffer.size": {
+			Metric: "benthos.buffer.size", Type: "gauge",
+			Points: [][2]float64{{0, 6}}, Host: "foohost", Tags: []string{"env:test"},
+		},
+	}, toMap(reqs[1]["series"]))
+}
+
+func TestDatadogErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	conf := NewConfig()
+	_, err := NewDatadog(conf)
+	require.EqualError(t, err, "an api_key must be specified")
+
+	conf.Datadog.APIKey = "fookey"
+	conf.Datadog.FlushPeriod = "1h"
+	m, err := NewDatadog(conf)
+	require.NoError(t, err)
+
+	d := m.(*Datadog)
+	d.url = srv.URL
+
+	d.GetCounter("input.received").Incr(1)
+	require.EqualError(t, d.flush(), "unexpected status code 403: nope\n")
+	require.NoError(t, m.Close())
+}
@@ -0,0 +1,264 @@
+package metrics
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+
+// maxPushSamples is the maximum number of timing values sampled for each timer
+// series between flushes.
+const maxPushSamples = 100
+
+type pushKind int
+
+const (
+	pushCounter pushKind = iota
+	pushGauge
+	pushTimer
+)
+
+// pushSeries is the aggregated state of a single metric series between
+// flushes.
+type pushSeries struct {
+	Name        string
+	Kind        pushKind
+	LabelNames  []string
+	LabelValues []string
+
+	// Value is the sum of counter increments since the last flush, or the
+	// current value of a gauge.
+	Value int64
+
+	// Count, Sum, Min and Max summarise the timing values since the last
+	// flush, and Samples contains a uniform sample of them.
+	Count   int64
+	Sum     int64
+	Min     int64
+	Max     int64
+	Samples []int64
+}
+
+// Labels returns the labels of the series as a map.
+func (s *pushSeries) Labels() map[string]string {
+	labels := make(map[string]string, len(s.LabelNames))
+	for i, k := range s.LabelNames {
+		if i < len(s.LabelValues) {
+			labels[k] = s.LabelValues[i]
+		}
+	}
+	return labels
+}
+
+// pushAggregator aggregates metrics in memory so that they can be flushed
+// periodically by metrics types that push series to a remote service.
+type pushAggregator struct {
+	pathMapping *pathMapping
+
+	mut    sync.Mutex
+	series map[string]*pushSeries
+}
+
+func newPushAggregator(pathMapping *pathMapping) *pushAggregator {
+	return &pushAggregator{
+		pathMapping: pathMapping,
+		series:      map[string]*pushSeries{},
+	}
+}
+
+type pushStat struct {
+	root        *pushAggregator
+	id          string
+	name        string
+	kind        pushKind
+	labelNames  []string
+	labelValues []string
+}
+
+func (p *pushAggregator) stat(kind pushKind, name string, labelNames, labelValues []string) *pushStat {
+	var id strings.Builder
+	id.WriteString(name)
+	id.WriteByte(byte('0' + kind))
+	for i, k := range labelNames {
+		id.WriteByte(0)
+		id.WriteString(k)
+		id.WriteByte('=')
+		if i < len(labelValues) {
+			id.WriteString(labelValues[i])
+		}
+	}
+	return &pushStat{
+		root:        p,
+		id:          id.String(),
+		name:        name,
+		kind:        kind,
+		labelNames:  labelNames,
+		labelValues: labelValues,
+	}
+}
+
+func (s *pushStat) update(fn func(series *pushSeries)) {
+	s.root.mut.Lock()
+	series, exists := s.root.series[s.id]
+	if !exists {
+		series = &pushSeries{
+			Name:        s.name,
+			Kind:        s.kind,
+			LabelNames:  s.labelNames,
+			LabelValues: s.labelValues,
+		}
+		s.root.series[s.id] = series
+	}
+	fn(series)
+	s.root.mut.Unlock()
+}
+
+// Incr increments a metric by an amount.
+func (s *pushStat) Incr(count int64) error {
+	s.update(func(series *pushSeries) {
+		series.Value += count
+	})
+	return nil
+}
+
+// Decr decrements a metric by an amount.
+func (s *pushStat) Decr(count int64) error {
+	s.update(func(series *pushSeries) {
+		series.Value -= count
+	})
+	return nil
+}
+
+// Timing sets a timing metric.
+func (s *pushStat) Timing(delta int64) error {
+	s.update(func(series *pushSeries) {
+		if series.Count == 0 || delta < series.Min {
+			series.Min = delta
+		}
+		if series.Count == 0 || delta > series.Max {
+			series.Max = delta
+		}
+		series.Count++
+		series.Sum += delta
+		if len(series.Samples) < maxPushSamples {
+			series.Samples = append(series.Samples, delta)
+		} else if i := rand.Int63n(series.Count); i < maxPushSamples {
+			series.Samples[i] = delta
+		}
+	})
+	return nil
+}
+
+// Set sets a gauge metric.
+func (s *pushStat) Set(value int64) error {
+	s.update(func(series *pushSeries) {
+		series.Value = value
+	})
+	return nil
+}
+
+// flush returns the aggregated series in a deterministic order and resets the
+// counters and timers, gauges keep their values and are therefore reported by
+// each flush.
+func (p *pushAggregator) flush() []pushSeries {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	ids := make([]string, 0, len(p.series))
+	for id := range p.series {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	series := make([]pushSeries, 0, len(ids))
+	for _, id := range ids {
+		s := p.series[id]
+		series = append(series, *s)
+		if s.Kind != pushGauge {
+			delete(p.series, id)
+		}
+	}
+	return series
+}
+
+//------------------------------------------------------------------------------
+
+func (p *pushAggregator) get(kind pushKind, path string) *pushStat {
+	name, labels, values := p.pathMapping.mapPathWithTags(path)
+	if len(name) == 0 {
+		return nil
+	}
+	return p.stat(kind, name, labels, values)
+}
+
+func (p *pushAggregator) getVec(kind pushKind, path string, n []string) func(vs []string) *pushStat {
+	name, labels, values := p.pathMapping.mapPathWithTags(path)
+	if len(name) == 0 {
+		return nil
+	}
+	labels = append(append([]string{}, labels...), n...)
+	return func(vs []string) *pushStat {
+		fvs := append(append([]string{}, values...), vs...)
+		return p.stat(kind, name, labels, fvs)
+	}
+}
+
+// GetCounter returns a stat counter object for a path.
+func (p *pushAggregator) GetCounter(path string) StatCounter {
+	if s := p.get(pushCounter, path); s != nil {
+		return s
+	}
+	return DudStat{}
+}
+
+// GetCounterVec returns a stat counter object for a path with the labels
+func (p *pushAggregator) GetCounterVec(path string, n []string) StatCounterVec {
+	fn := p.getVec(pushCounter, path, n)
+	return fakeCounterVec(func(vs []string) StatCounter {
+		if fn == nil {
+			return DudStat{}
+		}
+		return fn(vs)
+	})
+}
+
+// GetTimer returns a stat timer object for a path.
+func (p *pushAggregator) GetTimer(path string) StatTimer {
+	if s := p.get(pushTimer, path); s != nil {
+		return s
+	}
+	return DudStat{}
+}
+
+// GetTimerVec returns a stat timer object for a path with the labels
+func (p *pushAggregator) GetTimerVec(path string, n []string) StatTimerVec {
+	fn := p.getVec(pushTimer, path, n)
+	return fakeTimerVec(func(vs []string) StatTimer {
+		if fn == nil {
+			return DudStat{}
+		}
+		return fn(vs)
+	})
+}
+
+// GetGauge returns a stat gauge object for a path.
+func (p *pushAggregator) GetGauge(path string) StatGauge {
+	if s := p.get(pushGauge, path); s != nil {
+		return s
+	}
+	return DudStat{}
+}
+
+// GetGaugeVec returns a stat timer object for a path with the labels
+func (p *pushAggregator) GetGaugeVec(path string, n []string) StatGaugeVec {
+	fn := p.getVec(pushGauge, path, n)
+	return fakeGaugeVec(func(vs []string) StatGauge {
+		if fn == nil {
+			return DudStat{}
+		}
+		return fn(vs)
+	})
+}
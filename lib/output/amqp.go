@@ -4,6 +4,7 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -18,7 +19,8 @@ func init() {
 		Description: `
 DEPRECATED: This output is deprecated and scheduled for removal in Benthos V4.
 Please use [` + "`amqp_0_9`" + `](amqp_0_9) instead.`,
-		Status: docs.StatusDeprecated,
+		Status:  docs.StatusDeprecated,
+		Batches: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("url",
 				"A URL to connect to.",
@@ -33,16 +35,26 @@ Please use [` + "`amqp_0_9`" + `](amqp_0_9) instead.`,
 				),
 				docs.FieldCommon("durable", "Whether the exchange should be durable."),
 			),
+			docs.FieldAdvanced("queue_declare", "Optionally declare a queue and bind it to the target exchange.").WithChildren(
+				docs.FieldCommon("enabled", "Whether to declare the queue."),
+				docs.FieldCommon("queue", "The name of the queue."),
+				docs.FieldCommon("durable", "Whether the queue should be durable."),
+				docs.FieldCommon("key", "The binding key of the queue."),
+				docs.FieldCommon("arguments", "Optional arguments of the queue.").Map(),
+			),
 			docs.FieldCommon("key", "The binding key to set for each message.").IsInterpolated(),
 			docs.FieldCommon("type", "The type property to set for each message.").IsInterpolated(),
 			docs.FieldAdvanced("content_type", "The content type attribute to set for each message.").IsInterpolated(),
 			docs.FieldAdvanced("content_encoding", "The content encoding attribute to set for each message.").IsInterpolated(),
 			docs.FieldCommon("metadata", "Specify criteria for which metadata values are attached to objects as headers.").WithChildren(output.MetadataFields()...),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			docs.FieldAdvanced("priority", "Set the priority of each message as an integer between 0 and 255.").IsInterpolated(),
+			docs.FieldAdvanced("expiration", "Set the TTL of each message in milliseconds.").IsInterpolated(),
 			docs.FieldAdvanced("persistent", "Whether message delivery should be persistent (transient by default)."),
 			docs.FieldAdvanced("mandatory", "Whether to set the mandatory flag on published messages. When set if a published message is routed to zero queues it is returned."),
 			docs.FieldAdvanced("immediate", "Whether to set the immediate flag on published messages. When set if there are no ready consumers of a queue then the message is dropped instead of waiting."),
 			tls.FieldSpec(),
+			batch.FieldSpec(),
 		},
 	}
 }
@@ -57,9 +69,13 @@ func NewAMQP(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type
 	if err != nil {
 		return nil, err
 	}
-	return NewWriter(
+	w, err := NewWriter(
 		"amqp", a, log, stats,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return NewBatcherFromConfig(conf.AMQP.Batching, OnlySinglePayloads(w), mgr, log, stats)
 }
 
 //------------------------------------------------------------------------------
@@ -3,6 +3,7 @@ package output
 import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -20,6 +21,7 @@ func init() {
 Sends messages to an AMQP (1.0) server.`,
 		Description: ``,
 		Async:       true,
+		Batches:     true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("url",
 				"A URL to connect to.",
@@ -28,6 +30,7 @@ Sends messages to an AMQP (1.0) server.`,
 			),
 			docs.FieldCommon("target_address", "The target address to write to.", "/foo", "queue:/bar", "topic:/baz"),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
 			tls.FieldSpec(),
 			sasl.FieldSpec(),
 		},
@@ -51,7 +54,7 @@ func NewAMQP1(conf Config, mgr types.Manager, log log.Modular, stats metrics.Typ
 	if err != nil {
 		return nil, err
 	}
-	return OnlySinglePayloads(w), nil
+	return NewBatcherFromConfig(conf.AMQP1.Batching, OnlySinglePayloads(w), mgr, log, stats)
 }
 
 //------------------------------------------------------------------------------
@@ -25,6 +25,27 @@ fields can be dynamically set using function interpolations described
 [here](/docs/configuration/interpolation#bloblang-queries). When sending batched messages the
 interpolations are performed per message part.
 
+### Aggregation
+
+When ` + "`aggregation.enabled`" + ` is set messages written to the same shard
+are packed into records following the
+[Kinesis Producer Library (KPL) aggregation format](https://github.com/awslabs/amazon-kinesis-producer/blob/master/aggregation-format.md),
+which greatly increases the number of messages that each shard can accept.
+Consumers must deaggregate these records, which the Kinesis Client Library does
+automatically.
+
+### Shard Limits
+
+When ` + "`respect_shard_limits`" + ` is set writes that would exceed the
+throughput limits of a shard, 1,000 records and 1 MiB per second, are delayed.
+Other producers may also be writing to the same shards, and therefore the limits
+applied to a shard are reduced each time writes to it are throttled and recover
+gradually as writes succeed.
+
+Both aggregation and shard limits require permission to list the shards of the
+stream (` + "`kinesis:ListShards`" + `). Records that are rejected by Kinesis
+due to throttling or internal failures are retried individually.
+
 ### Credentials
 
 By default Benthos will use a shared credentials file when connecting to AWS
@@ -37,6 +58,11 @@ allowing you to transfer data across accounts. You can find out more
 			docs.FieldCommon("stream", "The stream to publish messages to."),
 			docs.FieldCommon("partition_key", "A required key for partitioning messages.").IsInterpolated(),
 			docs.FieldAdvanced("hash_key", "A optional hash key for partitioning messages.").IsInterpolated(),
+			docs.FieldAdvanced("aggregation", "Aggregate messages into records in the KPL aggregation format.").WithChildren(
+				docs.FieldAdvanced("enabled", "Whether to aggregate messages."),
+				docs.FieldAdvanced("max_size", "The maximum size in bytes of an aggregated record. Messages larger than this are written as individual records."),
+			).AtVersion("3.44.0"),
+			docs.FieldAdvanced("respect_shard_limits", "Whether to delay writes that would exceed the throughput limits of the shards of the stream.").AtVersion("3.44.0"),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			batch.FieldSpec(),
 		}.Merge(session.FieldSpecs()).Merge(retries.FieldSpecs()),
@@ -61,6 +87,27 @@ fields can be dynamically set using function interpolations described
 [here](/docs/configuration/interpolation#bloblang-queries). When sending batched messages the
 interpolations are performed per message part.
 
+### Aggregation
+
+When ` + "`aggregation.enabled`" + ` is set messages written to the same shard
+are packed into records following the
+[Kinesis Producer Library (KPL) aggregation format](https://github.com/awslabs/amazon-kinesis-producer/blob/master/aggregation-format.md),
+which greatly increases the number of messages that each shard can accept.
+Consumers must deaggregate these records, which the Kinesis Client Library does
+automatically.
+
+### Shard Limits
+
+When ` + "`respect_shard_limits`" + ` is set writes that would exceed the
+throughput limits of a shard, 1,000 records and 1 MiB per second, are delayed.
+Other producers may also be writing to the same shards, and therefore the limits
+applied to a shard are reduced each time writes to it are throttled and recover
+gradually as writes succeed.
+
+Both aggregation and shard limits require permission to list the shards of the
+stream (` + "`kinesis:ListShards`" + `). Records that are rejected by Kinesis
+due to throttling or internal failures are retried individually.
+
 ### Credentials
 
 By default Benthos will use a shared credentials file when connecting to AWS
@@ -73,6 +120,11 @@ allowing you to transfer data across accounts. You can find out more
 			docs.FieldCommon("stream", "The stream to publish messages to."),
 			docs.FieldCommon("partition_key", "A required key for partitioning messages.").IsInterpolated(),
 			docs.FieldAdvanced("hash_key", "A optional hash key for partitioning messages.").IsInterpolated(),
+			docs.FieldAdvanced("aggregation", "Aggregate messages into records in the KPL aggregation format.").WithChildren(
+				docs.FieldAdvanced("enabled", "Whether to aggregate messages."),
+				docs.FieldAdvanced("max_size", "The maximum size in bytes of an aggregated record. Messages larger than this are written as individual records."),
+			).AtVersion("3.44.0"),
+			docs.FieldAdvanced("respect_shard_limits", "Whether to delay writes that would exceed the throughput limits of the shards of the stream.").AtVersion("3.44.0"),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			batch.FieldSpec(),
 		}.Merge(session.FieldSpecs()).Merge(retries.FieldSpecs()),
@@ -1,8 +1,10 @@
 package output
 
 import (
+	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -23,12 +25,26 @@ Sends messages to an AWS SNS topic.`,
 By default Benthos will use a shared credentials file when connecting to AWS
 services. It's also possible to set them explicitly at the component level,
 allowing you to transfer data across accounts. You can find out more
-[in this document](/docs/guides/aws).`,
-		Async: true,
+[in this document](/docs/guides/aws).
+
+### FIFO Topics
+
+The fields ` + "`message_group_id` and `message_deduplication_id`" + ` can be
+set dynamically using
+[function interpolations](/docs/configuration/interpolation#bloblang-queries),
+which are resolved individually for each message of a batch. A group ID is
+required when publishing to a FIFO topic, and a deduplication ID is required
+unless the topic has content-based deduplication enabled.`,
+		Async:   true,
+		Batches: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("topic_arn", "The topic to publish to."),
+			docs.FieldCommon("message_group_id", "An optional group ID to set for messages.").IsInterpolated().AtVersion("3.44.0"),
+			docs.FieldCommon("message_deduplication_id", "An optional deduplication ID to set for messages.").IsInterpolated().AtVersion("3.44.0"),
+			docs.FieldCommon("metadata", "Specify criteria for which metadata values are sent as message attributes.").WithChildren(output.MetadataFields()...).AtVersion("3.44.0"),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			docs.FieldAdvanced("timeout", "The maximum period to wait on an upload before abandoning it and reattempting."),
+			batch.FieldSpec(),
 		}.Merge(session.FieldSpecs()),
 		Categories: []Category{
 			CategoryServices,
@@ -52,11 +68,16 @@ By default Benthos will use a shared credentials file when connecting to AWS
 services. It's also possible to set them explicitly at the component level,
 allowing you to transfer data across accounts. You can find out more
 [in this document](/docs/guides/aws).`,
-		Async: true,
+		Async:   true,
+		Batches: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("topic_arn", "The topic to publish to."),
+			docs.FieldCommon("message_group_id", "An optional group ID to set for messages.").IsInterpolated(),
+			docs.FieldCommon("message_deduplication_id", "An optional deduplication ID to set for messages.").IsInterpolated(),
+			docs.FieldCommon("metadata", "Specify criteria for which metadata values are sent as message attributes.").WithChildren(output.MetadataFields()...),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			docs.FieldAdvanced("timeout", "The maximum period to wait on an upload before abandoning it and reattempting."),
+			batch.FieldSpec(),
 		}.Merge(session.FieldSpecs()),
 		Categories: []Category{
 			CategoryServices,
@@ -86,7 +107,7 @@ func newAmazonSNS(name string, conf writer.SNSConfig, mgr types.Manager, log log
 	if err != nil {
 		return nil, err
 	}
-	return OnlySinglePayloads(a), nil
+	return NewBatcherFromConfig(conf.Batching, OnlySinglePayloads(a), mgr, log, stats)
 }
 
 //------------------------------------------------------------------------------
@@ -3,6 +3,7 @@ package output
 import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -25,7 +26,8 @@ Only one authentication method is required, ` + "`storage_connection_string`" +
 In order to have a different path for each object you should use function
 interpolations described [here](/docs/configuration/interpolation#bloblang-queries), which are
 calculated per message of a batch.`,
-		Async: true,
+		Async:   true,
+		Batches: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon(
 				"storage_account",
@@ -60,6 +62,7 @@ calculated per message of a batch.`,
 				"BLOCK", "APPEND",
 			).IsInterpolated(),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
 		},
 		Categories: []Category{
 			CategoryServices,
@@ -72,6 +75,7 @@ calculated per message of a batch.`,
 		Status:      docs.StatusDeprecated,
 		Summary:     "This component has been renamed to [`azure_blob_storage`](/docs/components/outputs/azure_blob_storage).",
 		Async:       true,
+		Batches:     true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon(
 				"storage_account",
@@ -106,6 +110,7 @@ calculated per message of a batch.`,
 				"BLOCK", "APPEND",
 			).IsInterpolated(),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
 		},
 		Categories: []Category{
 			CategoryServices,
@@ -128,7 +133,7 @@ func NewAzureBlobStorage(conf Config, mgr types.Manager, log log.Modular, stats
 	if err != nil {
 		return nil, err
 	}
-	return OnlySinglePayloads(a), nil
+	return NewBatcherFromConfig(conf.AzureBlobStorage.Batching, OnlySinglePayloads(a), mgr, log, stats)
 }
 
 func newDeprecatedBlobStorage(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
@@ -136,14 +141,20 @@ func newDeprecatedBlobStorage(conf Config, mgr types.Manager, log log.Modular, s
 	if err != nil {
 		return nil, err
 	}
+	var w Type
 	if conf.BlobStorage.MaxInFlight == 1 {
-		return NewWriter(
+		w, err = NewWriter(
 			TypeBlobStorage, blobStorage, log, stats,
 		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypeBlobStorage, conf.BlobStorage.MaxInFlight, blobStorage, log, stats,
+		)
 	}
-	return NewAsyncWriter(
-		TypeBlobStorage, conf.BlobStorage.MaxInFlight, blobStorage, log, stats,
-	)
+	if err != nil {
+		return nil, err
+	}
+	return NewBatcherFromConfig(conf.BlobStorage.Batching, w, mgr, log, stats)
 }
 
 //------------------------------------------------------------------------------
@@ -0,0 +1,356 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	"github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/bloblang"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeAzureServiceBus] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			s, err := newAzureServiceBusWriter(conf.AzureServiceBus, log)
+			if err != nil {
+				return nil, err
+			}
+			a, err := NewAsyncWriter(TypeAzureServiceBus, conf.AzureServiceBus.MaxInFlight, s, log, stats)
+			if err != nil {
+				return nil, err
+			}
+			return NewBatcherFromConfig(conf.AzureServiceBus.Batching, a, mgr, log, stats)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `Sends messages to an Azure Service Bus queue or topic.`,
+		Description: `
+Messages are sent over AMQP 1.0 and authenticated with a shared access key taken
+from the connection string of the namespace, which can be found in the Azure
+portal under "Shared access policies".
+
+The metadata of each message is sent as application properties, which can be
+restricted with the field ` + "`metadata`" + `.
+
+### Sessions
+
+When the field ` + "`session_id`" + ` is set messages are sent with a session
+ID, which is required by queues and subscriptions that have sessions enabled.
+Messages with the same session ID are delivered to consumers in order.
+
+### Scheduled Delivery
+
+The field ` + "`scheduled_enqueue_time`" + ` can be used to set a time at which
+each message becomes available to consumers. The value must be an RFC 3339
+timestamp, and messages with an empty value are available immediately:
+
+` + "```yaml" + `
+output:
+  azure_service_bus:
+    connection_string: ${SERVICE_BUS_CONNECTION_STRING}
+    queue_or_topic: foo
+    scheduled_enqueue_time: '${! meta("deliver_at") }'
+` + "```" + `
+
+### Duplicate Detection
+
+Queues and topics with duplicate detection enabled discard messages with an ID
+that has already been sent within the configured window. Since messages are
+retried when a delivery isn't confirmed it's recommended to derive the field
+` + "`message_id`" + ` from the contents of each message.`,
+		Async:   true,
+		Batches: true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon(
+				"connection_string", "The connection string of a Service Bus namespace.",
+				"Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=bar",
+			),
+			docs.FieldCommon("queue_or_topic", "The name of the queue or topic to send messages to. Can be left empty if the connection string has an `EntityPath`."),
+			docs.FieldCommon("session_id", "An optional session ID to set for each message.", `${! meta("tenant") }`).IsInterpolated(),
+			docs.FieldCommon("message_id", "An optional message ID to set for each message, which is used for duplicate detection.", `${! json("id") }`).IsInterpolated(),
+			docs.FieldAdvanced("scheduled_enqueue_time", "An optional RFC 3339 timestamp at which each message becomes available to consumers.", `${! meta("deliver_at") }`).IsInterpolated(),
+			docs.FieldAdvanced("metadata", "Specify criteria for which metadata values are sent as application properties.").WithChildren(output.MetadataFields()...),
+			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+			CategoryAzure,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// AzureServiceBusConfig contains configuration fields for the Azure Service
+// Bus output type.
+type AzureServiceBusConfig struct {
+	ConnectionString     string             `json:"connection_string" yaml:"connection_string"`
+	QueueOrTopic         string             `json:"queue_or_topic" yaml:"queue_or_topic"`
+	SessionID            string             `json:"session_id" yaml:"session_id"`
+	MessageID            string             `json:"message_id" yaml:"message_id"`
+	ScheduledEnqueueTime string             `json:"scheduled_enqueue_time" yaml:"scheduled_enqueue_time"`
+	Metadata             output.Metadata    `json:"metadata" yaml:"metadata"`
+	MaxInFlight          int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching             batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewAzureServiceBusConfig creates a new AzureServiceBusConfig with default
+// values.
+func NewAzureServiceBusConfig() AzureServiceBusConfig {
+	return AzureServiceBusConfig{
+		ConnectionString:     "",
+		QueueOrTopic:         "",
+		SessionID:            "",
+		MessageID:            "",
+		ScheduledEnqueueTime: "",
+		Metadata:             output.NewMetadata(),
+		MaxInFlight:          1,
+		Batching:             batch.NewPolicyConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// serviceBusConnection contains the fields of a Service Bus connection string
+// that are required for connecting over AMQP.
+type serviceBusConnection struct {
+	host       string
+	keyName    string
+	key        string
+	entityPath string
+}
+
+func parseServiceBusConnectionString(connStr string) (serviceBusConnection, error) {
+	var conn serviceBusConnection
+	for _, kv := range strings.Split(connStr, ";") {
+		if kv == "" {
+			continue
+		}
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			return conn, fmt.Errorf("connection string segment is not a key/value pair: %v", kv)
+		}
+		k, v := kv[:i], kv[i+1:]
+		switch strings.ToLower(k) {
+		case "endpoint":
+			u, err := url.Parse(v)
+			if err != nil {
+				return conn, fmt.Errorf("failed to parse endpoint: %w", err)
+			}
+			conn.host = u.Host
+		case "sharedaccesskeyname":
+			conn.keyName = v
+		case "sharedaccesskey":
+			conn.key = v
+		case "entitypath":
+			conn.entityPath = v
+		}
+	}
+	if conn.host == "" {
+		return conn, errors.New("connection string is missing an Endpoint")
+	}
+	if conn.keyName == "" || conn.key == "" {
+		return conn, errors.New("connection string is missing a SharedAccessKeyName or SharedAccessKey")
+	}
+	return conn, nil
+}
+
+type azureServiceBusWriter struct {
+	conn   serviceBusConnection
+	entity string
+
+	sessionID            bloblang.Field
+	messageID            bloblang.Field
+	scheduledEnqueueTime bloblang.Field
+	metaFilter           *output.MetadataFilter
+
+	log log.Modular
+
+	connMut sync.RWMutex
+	client  *amqp.Client
+	session *amqp.Session
+	sender  *amqp.Sender
+}
+
+func newAzureServiceBusWriter(conf AzureServiceBusConfig, log log.Modular) (*azureServiceBusWriter, error) {
+	s := &azureServiceBusWriter{
+		entity: conf.QueueOrTopic,
+		log:    log,
+	}
+
+	var err error
+	if s.conn, err = parseServiceBusConnectionString(conf.ConnectionString); err != nil {
+		return nil, fmt.Errorf("failed to parse connection string: %w", err)
+	}
+	if s.entity == "" {
+		if s.entity = s.conn.entityPath; s.entity == "" {
+			return nil, errors.New("a queue_or_topic must be specified when the connection string has no EntityPath")
+		}
+	}
+	if s.sessionID, err = bloblang.NewField(conf.SessionID); err != nil {
+		return nil, fmt.Errorf("failed to parse session_id expression: %w", err)
+	}
+	if s.messageID, err = bloblang.NewField(conf.MessageID); err != nil {
+		return nil, fmt.Errorf("failed to parse message_id expression: %w", err)
+	}
+	if s.scheduledEnqueueTime, err = bloblang.NewField(conf.ScheduledEnqueueTime); err != nil {
+		return nil, fmt.Errorf("failed to parse scheduled_enqueue_time expression: %w", err)
+	}
+	if s.metaFilter, err = conf.Metadata.Filter(); err != nil {
+		return nil, fmt.Errorf("failed to construct metadata filter: %w", err)
+	}
+	return s, nil
+}
+
+// ConnectWithContext establishes a connection to the Service Bus namespace.
+func (s *azureServiceBusWriter) ConnectWithContext(ctx context.Context) error {
+	s.connMut.Lock()
+	defer s.connMut.Unlock()
+
+	if s.client != nil {
+		return nil
+	}
+
+	client, err := amqp.Dial(
+		"amqps://"+s.conn.host,
+		amqp.ConnSASLPlain(s.conn.keyName, s.conn.key),
+	)
+	if err != nil {
+		return err
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	sender, err := session.NewSender(amqp.LinkTargetAddress(s.entity))
+	if err != nil {
+		session.Close(ctx)
+		client.Close()
+		return err
+	}
+
+	s.client = client
+	s.session = session
+	s.sender = sender
+
+	s.log.Infof("Sending messages to Azure Service Bus entity: %v\n", s.entity)
+	return nil
+}
+
+// newMessage creates an AMQP message from a message of a batch.
+func (s *azureServiceBusWriter) newMessage(i int, msg types.Message) (*amqp.Message, error) {
+	p := msg.Get(i)
+
+	m := amqp.NewMessage(p.Get())
+	m.Properties = &amqp.MessageProperties{}
+	if id := s.messageID.String(i, msg); id != "" {
+		m.Properties.MessageID = id
+	}
+	if sessionID := s.sessionID.String(i, msg); sessionID != "" {
+		m.Properties.GroupID = sessionID
+	}
+	if enqueueStr := s.scheduledEnqueueTime.String(i, msg); enqueueStr != "" {
+		enqueueTime, err := time.Parse(time.RFC3339Nano, enqueueStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse scheduled enqueue time: %w", err)
+		}
+		m.Annotations = amqp.Annotations{
+			"x-opt-scheduled-enqueue-time": enqueueTime.UTC(),
+		}
+	}
+	s.metaFilter.Iter(p.Metadata(), func(k, v string) error {
+		if m.ApplicationProperties == nil {
+			m.ApplicationProperties = map[string]interface{}{}
+		}
+		m.ApplicationProperties[k] = v
+		return nil
+	})
+	return m, nil
+}
+
+// WriteWithContext sends the messages of a batch and waits for each to be
+// accepted.
+func (s *azureServiceBusWriter) WriteWithContext(ctx context.Context, msg types.Message) error {
+	s.connMut.RLock()
+	sender := s.sender
+	s.connMut.RUnlock()
+
+	if sender == nil {
+		return types.ErrNotConnected
+	}
+
+	return writer.IterateBatchedSend(msg, func(i int, p types.Part) error {
+		m, err := s.newMessage(i, msg)
+		if err != nil {
+			return err
+		}
+		if err = sender.Send(ctx, m); err != nil {
+			if err == amqp.ErrTimeout {
+				return types.ErrTimeout
+			}
+			var amqpErr *amqp.Error
+			if errors.As(err, &amqpErr) {
+				// The message was rejected, which doesn't affect the link.
+				return err
+			}
+			if dErr, isDetachError := err.(*amqp.DetachError); isDetachError && dErr.RemoteError != nil {
+				s.log.Errorf("Lost connection due to: %v\n", dErr.RemoteError)
+			} else {
+				s.log.Errorf("Lost connection due to: %v\n", err)
+			}
+			s.disconnect(ctx)
+			return types.ErrNotConnected
+		}
+		return nil
+	})
+}
+
+func (s *azureServiceBusWriter) disconnect(ctx context.Context) {
+	s.connMut.Lock()
+	defer s.connMut.Unlock()
+
+	if s.client == nil {
+		return
+	}
+	if err := s.sender.Close(ctx); err != nil {
+		s.log.Errorf("Failed to cleanly close sender: %v\n", err)
+	}
+	if err := s.session.Close(ctx); err != nil {
+		s.log.Errorf("Failed to cleanly close session: %v\n", err)
+	}
+	if err := s.client.Close(); err != nil {
+		s.log.Errorf("Failed to cleanly close client: %v\n", err)
+	}
+	s.client = nil
+	s.session = nil
+	s.sender = nil
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (s *azureServiceBusWriter) CloseAsync() {
+	go s.disconnect(context.Background())
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (s *azureServiceBusWriter) WaitForClose(time.Duration) error {
+	return nil
+}
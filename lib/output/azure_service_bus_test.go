@@ -0,0 +1,74 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureServiceBusConnectionString(t *testing.T) {
+	conn, err := parseServiceBusConnectionString("Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=bar;SharedAccessKey=baz=;EntityPath=qux")
+	require.NoError(t, err)
+	assert.Equal(t, serviceBusConnection{
+		host:       "foo.servicebus.windows.net",
+		keyName:    "bar",
+		key:        "baz=",
+		entityPath: "qux",
+	}, conn)
+
+	_, err = parseServiceBusConnectionString("Endpoint=sb://foo.servicebus.windows.net/")
+	require.EqualError(t, err, "connection string is missing a SharedAccessKeyName or SharedAccessKey")
+
+	conf := NewAzureServiceBusConfig()
+	conf.ConnectionString = "Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=bar;SharedAccessKey=baz"
+	_, err = newAzureServiceBusWriter(conf, log.Noop())
+	require.EqualError(t, err, "a queue_or_topic must be specified when the connection string has no EntityPath")
+}
+
+func TestAzureServiceBusMessage(t *testing.T) {
+	conf := NewAzureServiceBusConfig()
+	conf.ConnectionString = "Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=bar;SharedAccessKey=baz"
+	conf.QueueOrTopic = "foo"
+	conf.SessionID = `${! meta("tenant") }`
+	conf.MessageID = `${! json("id") }`
+	conf.ScheduledEnqueueTime = `${! meta("deliver_at") }`
+
+	w, err := newAzureServiceBusWriter(conf, log.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{
+		[]byte(`{"id":"first"}`),
+		[]byte(`{"id":"second"}`),
+	})
+	msg.Get(0).Metadata().Set("tenant", "acme")
+	msg.Get(0).Metadata().Set("deliver_at", "2021-01-01T00:00:00+01:00")
+
+	m, err := w.newMessage(0, msg)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`{"id":"first"}`)}, m.Data)
+	assert.Equal(t, "first", m.Properties.MessageID)
+	assert.Equal(t, "acme", m.Properties.GroupID)
+	assert.Equal(t, amqp.Annotations{
+		"x-opt-scheduled-enqueue-time": time.Date(2020, 12, 31, 23, 0, 0, 0, time.UTC),
+	}, m.Annotations)
+	assert.Equal(t, map[string]interface{}{
+		"tenant":     "acme",
+		"deliver_at": "2021-01-01T00:00:00+01:00",
+	}, m.ApplicationProperties)
+
+	m, err = w.newMessage(1, msg)
+	require.NoError(t, err)
+	assert.Equal(t, "second", m.Properties.MessageID)
+	assert.Equal(t, "", m.Properties.GroupID)
+	assert.Nil(t, m.Annotations)
+	assert.Nil(t, m.ApplicationProperties)
+
+	msg.Get(1).Metadata().Set("deliver_at", "nope")
+	_, err = w.newMessage(1, msg)
+	require.Error(t, err)
+}
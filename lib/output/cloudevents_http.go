@@ -0,0 +1,266 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/cloudevents"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/http/client"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeCloudEventsHTTP] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			c, err := newCloudEventsHTTPWriter(conf.CloudEventsHTTP, mgr, log, stats)
+			if err != nil {
+				return nil, err
+			}
+			a, err := NewAsyncWriter(TypeCloudEventsHTTP, conf.CloudEventsHTTP.MaxInFlight, c, log, stats)
+			if err != nil {
+				return nil, err
+			}
+			return NewBatcherFromConfig(conf.CloudEventsHTTP.Batching, a, mgr, log, stats)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `Sends messages as [CloudEvents](https://cloudevents.io/) to an HTTP server following the CloudEvents v1.0 HTTP protocol binding.`,
+		Description: `
+Each message must be an event in the structured mode JSON format, such as those
+created with the ` + "[`cloudevents` processor](/docs/components/processors/cloudevents)" + `,
+and messages of another form can be converted into events with a
+[Bloblang mapping](/docs/guides/bloblang/about) in the field ` + "`mapping`" + `.
+Events are validated before they are sent, and a message that isn't a valid
+event is rejected.
+
+### Modes
+
+The field ` + "`mode`" + ` determines how events are encoded within requests:
+
+- ` + "`structured`" + `: Each event is sent as a JSON document with the content type ` + "`application/cloudevents+json`" + `.
+- ` + "`binary`" + `: The data of each event is sent as the request body with the content type of the ` + "`datacontenttype`" + ` attribute, and the remaining attributes are sent as ` + "`ce-`" + ` prefixed headers.
+- ` + "`batch`" + `: The events of a batch are sent as a single JSON array with the content type ` + "`application/cloudevents-batch+json`" + `.`,
+		Async:   true,
+		Batches: true,
+		FieldSpecs: client.FieldSpecs().Add(
+			docs.FieldCommon("mode", "The [mode](#modes) in which events are sent.").HasOptions("structured", "binary", "batch"),
+			docs.FieldCommon(
+				"mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) that converts each message into an event.",
+				`root.specversion = "1.0"
+root.id = uuid_v4()
+root.source = "benthos"
+root.type = "com.example.order.created"
+root.data = this`,
+			),
+			docs.FieldCommon("max_in_flight", "The maximum number of requests to have in flight at a given time. Increase this to improve throughput."),
+		).Add(batch.FieldSpec()),
+		Categories: []Category{
+			CategoryNetwork,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CloudEventsHTTPConfig contains configuration fields for the cloudevents_http
+// output type.
+type CloudEventsHTTPConfig struct {
+	client.Config `json:",inline" yaml:",inline"`
+	Mode          string             `json:"mode" yaml:"mode"`
+	Mapping       string             `json:"mapping" yaml:"mapping"`
+	MaxInFlight   int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching      batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewCloudEventsHTTPConfig creates a new CloudEventsHTTPConfig with default
+// values.
+func NewCloudEventsHTTPConfig() CloudEventsHTTPConfig {
+	cConf := client.NewConfig()
+	cConf.Headers = map[string]string{}
+	return CloudEventsHTTPConfig{
+		Config:      cConf,
+		Mode:        "structured",
+		Mapping:     "",
+		MaxInFlight: 1,
+		Batching:    batch.NewPolicyConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// cloudEventsHTTPHeadersKey is the context key of the headers to add to the
+// request of an event.
+type cloudEventsHTTPHeadersKey struct{}
+
+type cloudEventsHTTPWriter struct {
+	conf    CloudEventsHTTPConfig
+	mapping *mapping.Executor
+	client  *client.Type
+	log     log.Modular
+
+	closeChan chan struct{}
+}
+
+func newCloudEventsHTTPWriter(conf CloudEventsHTTPConfig, mgr types.Manager, log log.Modular, stats metrics.Type) (*cloudEventsHTTPWriter, error) {
+	switch conf.Mode {
+	case "structured", "binary", "batch":
+	default:
+		return nil, fmt.Errorf("unrecognised mode: %v", conf.Mode)
+	}
+
+	c := &cloudEventsHTTPWriter{
+		conf:      conf,
+		log:       log,
+		closeChan: make(chan struct{}),
+	}
+
+	var err error
+	if conf.Mapping != "" {
+		if c.mapping, err = bloblang.NewMapping("", conf.Mapping); err != nil {
+			return nil, fmt.Errorf("failed to parse mapping: %w", err)
+		}
+	}
+	if c.client, err = client.New(
+		conf.Config,
+		client.OptSetCloseChan(c.closeChan),
+		client.OptSetLogger(log),
+		client.OptSetManager(mgr),
+		client.OptSetStats(metrics.Namespaced(stats, "client")),
+		client.OptSetRequestModifier(func(req *http.Request, msg types.Message) error {
+			headers, _ := message.GetContext(msg.Get(0)).Value(cloudEventsHTTPHeadersKey{}).(http.Header)
+			for k, v := range headers {
+				req.Header[k] = v
+			}
+			return nil
+		}),
+	); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ConnectWithContext does nothing.
+func (c *cloudEventsHTTPWriter) ConnectWithContext(ctx context.Context) error {
+	c.log.Infof("Sending CloudEvents in %v mode to: %s\n", c.conf.Mode, c.conf.URL)
+	return nil
+}
+
+// toEvent extracts a validated event from a message.
+func (c *cloudEventsHTTPWriter) toEvent(i int, msg types.Message) (map[string]interface{}, error) {
+	part := msg.Get(i)
+	if c.mapping != nil {
+		var err error
+		if part, err = c.mapping.MapPart(i, msg); err != nil {
+			return nil, fmt.Errorf("failed to execute mapping: %w", err)
+		}
+	}
+
+	jv, err := part.JSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse event: %w", err)
+	}
+	event, ok := jv.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected event to be an object, got %T", jv)
+	}
+	if err = cloudevents.Validate(event); err != nil {
+		return nil, fmt.Errorf("invalid event: %w", err)
+	}
+	return event, nil
+}
+
+// send performs a single request with a body and headers, where the part is
+// copied so that its metadata remains available to interpolations of the URL
+// and configured headers.
+func (c *cloudEventsHTTPWriter) send(ctx context.Context, p types.Part, body []byte, headers http.Header) error {
+	reqPart := p.Copy()
+	reqPart.Set(body)
+	reqPart = message.WithContext(context.WithValue(message.GetContext(reqPart), cloudEventsHTTPHeadersKey{}, headers), reqPart)
+	reqMsg := message.New(nil)
+	reqMsg.Append(reqPart)
+
+	res, err := c.client.DoWithContext(ctx, reqMsg)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// WriteWithContext sends the events of a batch.
+func (c *cloudEventsHTTPWriter) WriteWithContext(ctx context.Context, msg types.Message) error {
+	if c.conf.Mode == "batch" {
+		events := make([]interface{}, msg.Len())
+		for i := range events {
+			var err error
+			if events[i], err = c.toEvent(i, msg); err != nil {
+				c.log.Errorf("Failed to convert message to event: %v\n", err)
+				return err
+			}
+		}
+		body, err := json.Marshal(events)
+		if err != nil {
+			return err
+		}
+		return c.send(ctx, msg.Get(0), body, http.Header{
+			"Content-Type": []string{cloudevents.ContentTypeBatch},
+		})
+	}
+
+	return writer.IterateBatchedSend(msg, func(i int, p types.Part) error {
+		event, err := c.toEvent(i, msg)
+		if err != nil {
+			c.log.Errorf("Failed to convert message to event: %v\n", err)
+			return err
+		}
+
+		if c.conf.Mode == "structured" {
+			body, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			return c.send(ctx, p, body, http.Header{
+				"Content-Type": []string{cloudevents.ContentTypeStructured},
+			})
+		}
+
+		body, err := cloudevents.Data(event)
+		if err != nil {
+			return fmt.Errorf("failed to decode event data: %w", err)
+		}
+		headers := http.Header{}
+		for _, k := range cloudevents.Attributes(event) {
+			v := cloudevents.AttributeString(event[k])
+			if k == "datacontenttype" {
+				headers.Set("Content-Type", v)
+				continue
+			}
+			headers.Set("ce-"+k, cloudevents.EncodeHeaderValue(v))
+		}
+		return c.send(ctx, p, body, headers)
+	})
+}
+
+// CloseAsync shuts down the output and stops processing messages.
+func (c *cloudEventsHTTPWriter) CloseAsync() {
+	close(c.closeChan)
+	c.client.CloseAsync()
+}
+
+// WaitForClose blocks until the output has closed down.
+func (c *cloudEventsHTTPWriter) WaitForClose(timeout time.Duration) error {
+	return nil
+}
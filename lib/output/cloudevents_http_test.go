@@ -0,0 +1,133 @@
+package output
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cloudEventsTestRequest struct {
+	header http.Header
+	body   string
+}
+
+func cloudEventsTestServer(t *testing.T) (string, <-chan cloudEventsTestRequest) {
+	t.Helper()
+
+	reqChan := make(chan cloudEventsTestRequest, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		reqChan <- cloudEventsTestRequest{header: r.Header, body: string(b)}
+	}))
+	t.Cleanup(ts.Close)
+	return ts.URL, reqChan
+}
+
+func cloudEventsTestMessage() *message.Type {
+	return message.New([][]byte{
+		[]byte(`{"specversion":"1.0","id":"foo","source":"/a","type":"b","myext":5,"datacontenttype":"application/json","data":{"c":"d"}}`),
+		[]byte(`{"specversion":"1.0","id":"bar","source":"/a","type":"b","datacontenttype":"text/plain","data":"hello world"}`),
+	})
+}
+
+func TestCloudEventsHTTPStructured(t *testing.T) {
+	url, reqChan := cloudEventsTestServer(t)
+
+	conf := NewCloudEventsHTTPConfig()
+	conf.URL = url
+
+	c, err := newCloudEventsHTTPWriter(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer c.CloseAsync()
+
+	require.NoError(t, c.WriteWithContext(context.Background(), cloudEventsTestMessage()))
+	require.Len(t, reqChan, 2)
+
+	req := <-reqChan
+	assert.Equal(t, "application/cloudevents+json", req.header.Get("Content-Type"))
+	assert.JSONEq(t, `{"specversion":"1.0","id":"foo","source":"/a","type":"b","myext":5,"datacontenttype":"application/json","data":{"c":"d"}}`, req.body)
+
+	req = <-reqChan
+	assert.JSONEq(t, `{"specversion":"1.0","id":"bar","source":"/a","type":"b","datacontenttype":"text/plain","data":"hello world"}`, req.body)
+}
+
+func TestCloudEventsHTTPBinary(t *testing.T) {
+	url, reqChan := cloudEventsTestServer(t)
+
+	conf := NewCloudEventsHTTPConfig()
+	conf.URL = url
+	conf.Mode = "binary"
+	conf.Headers = map[string]string{"X-Foo": "bar"}
+
+	c, err := newCloudEventsHTTPWriter(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer c.CloseAsync()
+
+	require.NoError(t, c.WriteWithContext(context.Background(), cloudEventsTestMessage()))
+	require.Len(t, reqChan, 2)
+
+	req := <-reqChan
+	assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+	assert.Equal(t, "bar", req.header.Get("X-Foo"))
+	assert.Equal(t, "1.0", req.header.Get("ce-specversion"))
+	assert.Equal(t, "foo", req.header.Get("ce-id"))
+	assert.Equal(t, "/a", req.header.Get("ce-source"))
+	assert.Equal(t, "b", req.header.Get("ce-type"))
+	assert.Equal(t, "5", req.header.Get("ce-myext"))
+	assert.Equal(t, `{"c":"d"}`, req.body)
+
+	req = <-reqChan
+	assert.Equal(t, "text/plain", req.header.Get("Content-Type"))
+	assert.Equal(t, "bar", req.header.Get("ce-id"))
+	assert.Equal(t, "hello world", req.body)
+}
+
+func TestCloudEventsHTTPBatch(t *testing.T) {
+	url, reqChan := cloudEventsTestServer(t)
+
+	conf := NewCloudEventsHTTPConfig()
+	conf.URL = url
+	conf.Mode = "batch"
+	conf.Mapping = `root = this
+root.source = "/mapped"`
+
+	c, err := newCloudEventsHTTPWriter(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer c.CloseAsync()
+
+	require.NoError(t, c.WriteWithContext(context.Background(), cloudEventsTestMessage()))
+	require.Len(t, reqChan, 1)
+
+	req := <-reqChan
+	assert.Equal(t, "application/cloudevents-batch+json", req.header.Get("Content-Type"))
+	assert.JSONEq(t, `[
+	{"specversion":"1.0","id":"foo","source":"/mapped","type":"b","myext":5,"datacontenttype":"application/json","data":{"c":"d"}},
+	{"specversion":"1.0","id":"bar","source":"/mapped","type":"b","datacontenttype":"text/plain","data":"hello world"}
+]`, req.body)
+}
+
+func TestCloudEventsHTTPInvalidEvent(t *testing.T) {
+	url, reqChan := cloudEventsTestServer(t)
+
+	conf := NewCloudEventsHTTPConfig()
+	conf.URL = url
+
+	c, err := newCloudEventsHTTPWriter(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer c.CloseAsync()
+
+	err = c.WriteWithContext(context.Background(), message.New([][]byte{
+		[]byte(`{"specversion":"1.0","source":"/a","type":"b"}`),
+	}))
+	require.EqualError(t, err, "invalid event: missing required attribute: id")
+	assert.Len(t, reqChan, 0)
+}
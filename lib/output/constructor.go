@@ -151,214 +151,241 @@ var Constructors = map[string]TypeSpec{}
 
 // String constants representing each output type.
 const (
-	TypeAMQP               = "amqp"
-	TypeAMQP09             = "amqp_0_9"
-	TypeAMQP1              = "amqp_1"
-	TypeAWSDynamoDB        = "aws_dynamodb"
-	TypeAWSKinesis         = "aws_kinesis"
-	TypeAWSKinesisFirehose = "aws_kinesis_firehose"
-	TypeAWSS3              = "aws_s3"
-	TypeAWSSNS             = "aws_sns"
-	TypeAWSSQS             = "aws_sqs"
-	TypeAzureBlobStorage   = "azure_blob_storage"
-	TypeAzureQueueStorage  = "azure_queue_storage"
-	TypeAzureTableStorage  = "azure_table_storage"
-	TypeBlobStorage        = "blob_storage"
-	TypeBroker             = "broker"
-	TypeCache              = "cache"
-	TypeCassandra          = "cassandra"
-	TypeDrop               = "drop"
-	TypeDropOn             = "drop_on"
-	TypeDropOnError        = "drop_on_error"
-	TypeDynamic            = "dynamic"
-	TypeDynamoDB           = "dynamodb"
-	TypeElasticsearch      = "elasticsearch"
-	TypeFile               = "file"
-	TypeFiles              = "files"
-	TypeGCPCloudStorage    = "gcp_cloud_storage"
-	TypeGCPPubSub          = "gcp_pubsub"
-	TypeHDFS               = "hdfs"
-	TypeHTTPClient         = "http_client"
-	TypeHTTPServer         = "http_server"
-	TypeInproc             = "inproc"
-	TypeKafka              = "kafka"
-	TypeKinesis            = "kinesis"
-	TypeKinesisFirehose    = "kinesis_firehose"
-	TypeMongoDB            = "mongodb"
-	TypeMQTT               = "mqtt"
-	TypeNanomsg            = "nanomsg"
-	TypeNATS               = "nats"
-	TypeNATSStream         = "nats_stream"
-	TypeNSQ                = "nsq"
-	TypePulsar             = "pulsar"
-	TypeRedisHash          = "redis_hash"
-	TypeRedisList          = "redis_list"
-	TypeRedisPubSub        = "redis_pubsub"
-	TypeRedisStreams       = "redis_streams"
-	TypeReject             = "reject"
-	TypeResource           = "resource"
-	TypeRetry              = "retry"
-	TypeS3                 = "s3"
-	TypeSFTP               = "sftp"
-	TypeSNS                = "sns"
-	TypeSQL                = "sql"
-	TypeSQS                = "sqs"
-	TypeSTDOUT             = "stdout"
-	TypeSubprocess         = "subprocess"
-	TypeSwitch             = "switch"
-	TypeSyncResponse       = "sync_response"
-	TypeTableStorage       = "table_storage"
-	TypeTCP                = "tcp"
-	TypeTry                = "try"
-	TypeUDP                = "udp"
-	TypeSocket             = "socket"
-	TypeWebsocket          = "websocket"
-	TypeZMQ4               = "zmq4"
+	TypeAMQP                  = "amqp"
+	TypeAMQP09                = "amqp_0_9"
+	TypeAMQP1                 = "amqp_1"
+	TypeAWSDynamoDB           = "aws_dynamodb"
+	TypeAWSKinesis            = "aws_kinesis"
+	TypeAWSKinesisFirehose    = "aws_kinesis_firehose"
+	TypeAWSS3                 = "aws_s3"
+	TypeAWSSNS                = "aws_sns"
+	TypeAWSSQS                = "aws_sqs"
+	TypeAzureBlobStorage      = "azure_blob_storage"
+	TypeAzureQueueStorage     = "azure_queue_storage"
+	TypeAzureServiceBus       = "azure_service_bus"
+	TypeAzureTableStorage     = "azure_table_storage"
+	TypeBlobStorage           = "blob_storage"
+	TypeBroker                = "broker"
+	TypeCache                 = "cache"
+	TypeCassandra             = "cassandra"
+	TypeCloudEventsHTTP       = "cloudevents_http"
+	TypeDeadLetter            = "dead_letter"
+	TypeDrop                  = "drop"
+	TypeDropOn                = "drop_on"
+	TypeDropOnError           = "drop_on_error"
+	TypeDynamic               = "dynamic"
+	TypeDynamoDB              = "dynamodb"
+	TypeElasticsearch         = "elasticsearch"
+	TypeFile                  = "file"
+	TypeFiles                 = "files"
+	TypeGCPCloudStorage       = "gcp_cloud_storage"
+	TypeGCPPubSub             = "gcp_pubsub"
+	TypeHDFS                  = "hdfs"
+	TypeHTTPClient            = "http_client"
+	TypeHTTPServer            = "http_server"
+	TypeIdempotent            = "idempotent"
+	TypeInproc                = "inproc"
+	TypeInprocTopic           = "inproc_topic"
+	TypeKafka                 = "kafka"
+	TypeKafkaRequestReply     = "kafka_request_reply"
+	TypeKinesis               = "kinesis"
+	TypeKinesisFirehose       = "kinesis_firehose"
+	TypeMongoDB               = "mongodb"
+	TypeMQTT                  = "mqtt"
+	TypeNanomsg               = "nanomsg"
+	TypeNATS                  = "nats"
+	TypeNATSStream            = "nats_stream"
+	TypeNSQ                   = "nsq"
+	TypeOTLP                  = "otlp"
+	TypePrometheusRemoteWrite = "prometheus_remote_write"
+	TypePulsar                = "pulsar"
+	TypeRedisHash             = "redis_hash"
+	TypeRedisList             = "redis_list"
+	TypeRedisPubSub           = "redis_pubsub"
+	TypeRedisStreams          = "redis_streams"
+	TypeReject                = "reject"
+	TypeResource              = "resource"
+	TypeRetry                 = "retry"
+	TypeS3                    = "s3"
+	TypeSFTP                  = "sftp"
+	TypeSNS                   = "sns"
+	TypeSQL                   = "sql"
+	TypeSQS                   = "sqs"
+	TypeSTDOUT                = "stdout"
+	TypeSubprocess            = "subprocess"
+	TypeSwitch                = "switch"
+	TypeSyncResponse          = "sync_response"
+	TypeTableStorage          = "table_storage"
+	TypeTCP                   = "tcp"
+	TypeTry                   = "try"
+	TypeUDP                   = "udp"
+	TypeSocket                = "socket"
+	TypeWebHDFS               = "webhdfs"
+	TypeWebsocket             = "websocket"
+	TypeZMQ4                  = "zmq4"
 )
 
 //------------------------------------------------------------------------------
 
 // Config is the all encompassing configuration struct for all output types.
 type Config struct {
-	Label              string                         `json:"label" yaml:"label"`
-	Type               string                         `json:"type" yaml:"type"`
-	AMQP               writer.AMQPConfig              `json:"amqp" yaml:"amqp"`
-	AMQP09             writer.AMQPConfig              `json:"amqp_0_9" yaml:"amqp_0_9"`
-	AMQP1              writer.AMQP1Config             `json:"amqp_1" yaml:"amqp_1"`
-	AWSDynamoDB        writer.DynamoDBConfig          `json:"aws_dynamodb" yaml:"aws_dynamodb"`
-	AWSKinesis         writer.KinesisConfig           `json:"aws_kinesis" yaml:"aws_kinesis"`
-	AWSKinesisFirehose writer.KinesisFirehoseConfig   `json:"aws_kinesis_firehose" yaml:"aws_kinesis_firehose"`
-	AWSS3              writer.AmazonS3Config          `json:"aws_s3" yaml:"aws_s3"`
-	AWSSNS             writer.SNSConfig               `json:"aws_sns" yaml:"aws_sns"`
-	AWSSQS             writer.AmazonSQSConfig         `json:"aws_sqs" yaml:"aws_sqs"`
-	AzureBlobStorage   writer.AzureBlobStorageConfig  `json:"azure_blob_storage" yaml:"azure_blob_storage"`
-	AzureQueueStorage  writer.AzureQueueStorageConfig `json:"azure_queue_storage" yaml:"azure_queue_storage"`
-	AzureTableStorage  writer.AzureTableStorageConfig `json:"azure_table_storage" yaml:"azure_table_storage"`
-	BlobStorage        writer.AzureBlobStorageConfig  `json:"blob_storage" yaml:"blob_storage"`
-	Broker             BrokerConfig                   `json:"broker" yaml:"broker"`
-	Cache              writer.CacheConfig             `json:"cache" yaml:"cache"`
-	Cassandra          CassandraConfig                `json:"cassandra" yaml:"cassandra"`
-	Drop               writer.DropConfig              `json:"drop" yaml:"drop"`
-	DropOn             DropOnConfig                   `json:"drop_on" yaml:"drop_on"`
-	DropOnError        DropOnErrorConfig              `json:"drop_on_error" yaml:"drop_on_error"`
-	Dynamic            DynamicConfig                  `json:"dynamic" yaml:"dynamic"`
-	DynamoDB           writer.DynamoDBConfig          `json:"dynamodb" yaml:"dynamodb"`
-	Elasticsearch      writer.ElasticsearchConfig     `json:"elasticsearch" yaml:"elasticsearch"`
-	File               FileConfig                     `json:"file" yaml:"file"`
-	Files              writer.FilesConfig             `json:"files" yaml:"files"`
-	GCPCloudStorage    GCPCloudStorageConfig          `json:"gcp_cloud_storage" yaml:"gcp_cloud_storage"`
-	GCPPubSub          writer.GCPPubSubConfig         `json:"gcp_pubsub" yaml:"gcp_pubsub"`
-	HDFS               writer.HDFSConfig              `json:"hdfs" yaml:"hdfs"`
-	HTTPClient         writer.HTTPClientConfig        `json:"http_client" yaml:"http_client"`
-	HTTPServer         HTTPServerConfig               `json:"http_server" yaml:"http_server"`
-	Inproc             InprocConfig                   `json:"inproc" yaml:"inproc"`
-	Kafka              writer.KafkaConfig             `json:"kafka" yaml:"kafka"`
-	Kinesis            writer.KinesisConfig           `json:"kinesis" yaml:"kinesis"`
-	KinesisFirehose    writer.KinesisFirehoseConfig   `json:"kinesis_firehose" yaml:"kinesis_firehose"`
-	MongoDB            MongoDBConfig                  `json:"mongodb" yaml:"mongodb"`
-	MQTT               writer.MQTTConfig              `json:"mqtt" yaml:"mqtt"`
-	Nanomsg            writer.NanomsgConfig           `json:"nanomsg" yaml:"nanomsg"`
-	NATS               writer.NATSConfig              `json:"nats" yaml:"nats"`
-	NATSStream         writer.NATSStreamConfig        `json:"nats_stream" yaml:"nats_stream"`
-	NSQ                writer.NSQConfig               `json:"nsq" yaml:"nsq"`
-	Plugin             interface{}                    `json:"plugin,omitempty" yaml:"plugin,omitempty"`
-	Pulsar             PulsarConfig                   `json:"pulsar" yaml:"pulsar"`
-	RedisHash          writer.RedisHashConfig         `json:"redis_hash" yaml:"redis_hash"`
-	RedisList          writer.RedisListConfig         `json:"redis_list" yaml:"redis_list"`
-	RedisPubSub        writer.RedisPubSubConfig       `json:"redis_pubsub" yaml:"redis_pubsub"`
-	RedisStreams       writer.RedisStreamsConfig      `json:"redis_streams" yaml:"redis_streams"`
-	Reject             RejectConfig                   `json:"reject" yaml:"reject"`
-	Resource           string                         `json:"resource" yaml:"resource"`
-	Retry              RetryConfig                    `json:"retry" yaml:"retry"`
-	S3                 writer.AmazonS3Config          `json:"s3" yaml:"s3"`
-	SFTP               SFTPConfig                     `json:"sftp" yaml:"sftp"`
-	SNS                writer.SNSConfig               `json:"sns" yaml:"sns"`
-	SQL                SQLConfig                      `json:"sql" yaml:"sql"`
-	SQS                writer.AmazonSQSConfig         `json:"sqs" yaml:"sqs"`
-	STDOUT             STDOUTConfig                   `json:"stdout" yaml:"stdout"`
-	Subprocess         SubprocessConfig               `json:"subprocess" yaml:"subprocess"`
-	Switch             SwitchConfig                   `json:"switch" yaml:"switch"`
-	SyncResponse       struct{}                       `json:"sync_response" yaml:"sync_response"`
-	TableStorage       writer.AzureTableStorageConfig `json:"table_storage" yaml:"table_storage"`
-	TCP                writer.TCPConfig               `json:"tcp" yaml:"tcp"`
-	Try                TryConfig                      `json:"try" yaml:"try"`
-	UDP                writer.UDPConfig               `json:"udp" yaml:"udp"`
-	Socket             writer.SocketConfig            `json:"socket" yaml:"socket"`
-	Websocket          writer.WebsocketConfig         `json:"websocket" yaml:"websocket"`
-	ZMQ4               *writer.ZMQ4Config             `json:"zmq4,omitempty" yaml:"zmq4,omitempty"`
-	Processors         []processor.Config             `json:"processors" yaml:"processors"`
+	Label                 string                         `json:"label" yaml:"label"`
+	Type                  string                         `json:"type" yaml:"type"`
+	AMQP                  writer.AMQPConfig              `json:"amqp" yaml:"amqp"`
+	AMQP09                writer.AMQPConfig              `json:"amqp_0_9" yaml:"amqp_0_9"`
+	AMQP1                 writer.AMQP1Config             `json:"amqp_1" yaml:"amqp_1"`
+	AWSDynamoDB           writer.DynamoDBConfig          `json:"aws_dynamodb" yaml:"aws_dynamodb"`
+	AWSKinesis            writer.KinesisConfig           `json:"aws_kinesis" yaml:"aws_kinesis"`
+	AWSKinesisFirehose    writer.KinesisFirehoseConfig   `json:"aws_kinesis_firehose" yaml:"aws_kinesis_firehose"`
+	AWSS3                 writer.AmazonS3Config          `json:"aws_s3" yaml:"aws_s3"`
+	AWSSNS                writer.SNSConfig               `json:"aws_sns" yaml:"aws_sns"`
+	AWSSQS                writer.AmazonSQSConfig         `json:"aws_sqs" yaml:"aws_sqs"`
+	AzureBlobStorage      writer.AzureBlobStorageConfig  `json:"azure_blob_storage" yaml:"azure_blob_storage"`
+	AzureQueueStorage     writer.AzureQueueStorageConfig `json:"azure_queue_storage" yaml:"azure_queue_storage"`
+	AzureServiceBus       AzureServiceBusConfig          `json:"azure_service_bus" yaml:"azure_service_bus"`
+	AzureTableStorage     writer.AzureTableStorageConfig `json:"azure_table_storage" yaml:"azure_table_storage"`
+	BlobStorage           writer.AzureBlobStorageConfig  `json:"blob_storage" yaml:"blob_storage"`
+	Broker                BrokerConfig                   `json:"broker" yaml:"broker"`
+	Cache                 writer.CacheConfig             `json:"cache" yaml:"cache"`
+	Cassandra             CassandraConfig                `json:"cassandra" yaml:"cassandra"`
+	CloudEventsHTTP       CloudEventsHTTPConfig          `json:"cloudevents_http" yaml:"cloudevents_http"`
+	DeadLetter            DeadLetterConfig               `json:"dead_letter" yaml:"dead_letter"`
+	Drop                  writer.DropConfig              `json:"drop" yaml:"drop"`
+	DropOn                DropOnConfig                   `json:"drop_on" yaml:"drop_on"`
+	DropOnError           DropOnErrorConfig              `json:"drop_on_error" yaml:"drop_on_error"`
+	Dynamic               DynamicConfig                  `json:"dynamic" yaml:"dynamic"`
+	DynamoDB              writer.DynamoDBConfig          `json:"dynamodb" yaml:"dynamodb"`
+	Elasticsearch         writer.ElasticsearchConfig     `json:"elasticsearch" yaml:"elasticsearch"`
+	File                  FileConfig                     `json:"file" yaml:"file"`
+	Files                 writer.FilesConfig             `json:"files" yaml:"files"`
+	GCPCloudStorage       GCPCloudStorageConfig          `json:"gcp_cloud_storage" yaml:"gcp_cloud_storage"`
+	GCPPubSub             writer.GCPPubSubConfig         `json:"gcp_pubsub" yaml:"gcp_pubsub"`
+	HDFS                  writer.HDFSConfig              `json:"hdfs" yaml:"hdfs"`
+	HTTPClient            writer.HTTPClientConfig        `json:"http_client" yaml:"http_client"`
+	HTTPServer            HTTPServerConfig               `json:"http_server" yaml:"http_server"`
+	Idempotent            IdempotentConfig               `json:"idempotent" yaml:"idempotent"`
+	Inproc                InprocConfig                   `json:"inproc" yaml:"inproc"`
+	InprocTopic           InprocTopicConfig              `json:"inproc_topic" yaml:"inproc_topic"`
+	Kafka                 writer.KafkaConfig             `json:"kafka" yaml:"kafka"`
+	KafkaRequestReply     KafkaRequestReplyConfig        `json:"kafka_request_reply" yaml:"kafka_request_reply"`
+	Kinesis               writer.KinesisConfig           `json:"kinesis" yaml:"kinesis"`
+	KinesisFirehose       writer.KinesisFirehoseConfig   `json:"kinesis_firehose" yaml:"kinesis_firehose"`
+	MongoDB               MongoDBConfig                  `json:"mongodb" yaml:"mongodb"`
+	MQTT                  writer.MQTTConfig              `json:"mqtt" yaml:"mqtt"`
+	Nanomsg               writer.NanomsgConfig           `json:"nanomsg" yaml:"nanomsg"`
+	NATS                  writer.NATSConfig              `json:"nats" yaml:"nats"`
+	NATSStream            writer.NATSStreamConfig        `json:"nats_stream" yaml:"nats_stream"`
+	NSQ                   writer.NSQConfig               `json:"nsq" yaml:"nsq"`
+	Plugin                interface{}                    `json:"plugin,omitempty" yaml:"plugin,omitempty"`
+	OTLP                  OTLPConfig                     `json:"otlp" yaml:"otlp"`
+	PrometheusRemoteWrite PrometheusRemoteWriteConfig    `json:"prometheus_remote_write" yaml:"prometheus_remote_write"`
+	Pulsar                PulsarConfig                   `json:"pulsar" yaml:"pulsar"`
+	RedisHash             writer.RedisHashConfig         `json:"redis_hash" yaml:"redis_hash"`
+	RedisList             writer.RedisListConfig         `json:"redis_list" yaml:"redis_list"`
+	RedisPubSub           writer.RedisPubSubConfig       `json:"redis_pubsub" yaml:"redis_pubsub"`
+	RedisStreams          writer.RedisStreamsConfig      `json:"redis_streams" yaml:"redis_streams"`
+	Reject                RejectConfig                   `json:"reject" yaml:"reject"`
+	Resource              string                         `json:"resource" yaml:"resource"`
+	Retry                 RetryConfig                    `json:"retry" yaml:"retry"`
+	S3                    writer.AmazonS3Config          `json:"s3" yaml:"s3"`
+	SFTP                  SFTPConfig                     `json:"sftp" yaml:"sftp"`
+	SNS                   writer.SNSConfig               `json:"sns" yaml:"sns"`
+	SQL                   SQLConfig                      `json:"sql" yaml:"sql"`
+	SQS                   writer.AmazonSQSConfig         `json:"sqs" yaml:"sqs"`
+	STDOUT                STDOUTConfig                   `json:"stdout" yaml:"stdout"`
+	Subprocess            SubprocessConfig               `json:"subprocess" yaml:"subprocess"`
+	Switch                SwitchConfig                   `json:"switch" yaml:"switch"`
+	SyncResponse          struct{}                       `json:"sync_response" yaml:"sync_response"`
+	TableStorage          writer.AzureTableStorageConfig `json:"table_storage" yaml:"table_storage"`
+	TCP                   writer.TCPConfig               `json:"tcp" yaml:"tcp"`
+	Try                   TryConfig                      `json:"try" yaml:"try"`
+	UDP                   writer.UDPConfig               `json:"udp" yaml:"udp"`
+	Socket                writer.SocketConfig            `json:"socket" yaml:"socket"`
+	WebHDFS               WebHDFSConfig                  `json:"webhdfs" yaml:"webhdfs"`
+	Websocket             writer.WebsocketConfig         `json:"websocket" yaml:"websocket"`
+	ZMQ4                  *writer.ZMQ4Config             `json:"zmq4,omitempty" yaml:"zmq4,omitempty"`
+	Processors            []processor.Config             `json:"processors" yaml:"processors"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Label:              "",
-		Type:               "stdout",
-		AMQP:               writer.NewAMQPConfig(),
-		AMQP09:             writer.NewAMQPConfig(),
-		AMQP1:              writer.NewAMQP1Config(),
-		AWSDynamoDB:        writer.NewDynamoDBConfig(),
-		AWSKinesis:         writer.NewKinesisConfig(),
-		AWSKinesisFirehose: writer.NewKinesisFirehoseConfig(),
-		AWSS3:              writer.NewAmazonS3Config(),
-		AWSSNS:             writer.NewSNSConfig(),
-		AWSSQS:             writer.NewAmazonSQSConfig(),
-		AzureBlobStorage:   writer.NewAzureBlobStorageConfig(),
-		AzureQueueStorage:  writer.NewAzureQueueStorageConfig(),
-		AzureTableStorage:  writer.NewAzureTableStorageConfig(),
-		BlobStorage:        writer.NewAzureBlobStorageConfig(),
-		Broker:             NewBrokerConfig(),
-		Cache:              writer.NewCacheConfig(),
-		Cassandra:          NewCassandraConfig(),
-		Drop:               writer.NewDropConfig(),
-		DropOn:             NewDropOnConfig(),
-		DropOnError:        NewDropOnErrorConfig(),
-		Dynamic:            NewDynamicConfig(),
-		DynamoDB:           writer.NewDynamoDBConfig(),
-		Elasticsearch:      writer.NewElasticsearchConfig(),
-		File:               NewFileConfig(),
-		Files:              writer.NewFilesConfig(),
-		GCPCloudStorage:    NewGCPCloudStorageConfig(),
-		GCPPubSub:          writer.NewGCPPubSubConfig(),
-		HDFS:               writer.NewHDFSConfig(),
-		HTTPClient:         writer.NewHTTPClientConfig(),
-		HTTPServer:         NewHTTPServerConfig(),
-		Inproc:             NewInprocConfig(),
-		Kafka:              writer.NewKafkaConfig(),
-		Kinesis:            writer.NewKinesisConfig(),
-		KinesisFirehose:    writer.NewKinesisFirehoseConfig(),
-		MQTT:               writer.NewMQTTConfig(),
-		MongoDB:            NewMongoDBConfig(),
-		Nanomsg:            writer.NewNanomsgConfig(),
-		NATS:               writer.NewNATSConfig(),
-		NATSStream:         writer.NewNATSStreamConfig(),
-		NSQ:                writer.NewNSQConfig(),
-		Plugin:             nil,
-		Pulsar:             NewPulsarConfig(),
-		RedisHash:          writer.NewRedisHashConfig(),
-		RedisList:          writer.NewRedisListConfig(),
-		RedisPubSub:        writer.NewRedisPubSubConfig(),
-		RedisStreams:       writer.NewRedisStreamsConfig(),
-		Reject:             NewRejectConfig(),
-		Resource:           "",
-		Retry:              NewRetryConfig(),
-		S3:                 writer.NewAmazonS3Config(),
-		SFTP:               NewSFTPConfig(),
-		SNS:                writer.NewSNSConfig(),
-		SQL:                NewSQLConfig(),
-		SQS:                writer.NewAmazonSQSConfig(),
-		STDOUT:             NewSTDOUTConfig(),
-		Subprocess:         NewSubprocessConfig(),
-		Switch:             NewSwitchConfig(),
-		SyncResponse:       struct{}{},
-		TableStorage:       writer.NewAzureTableStorageConfig(),
-		TCP:                writer.NewTCPConfig(),
-		Try:                NewTryConfig(),
-		UDP:                writer.NewUDPConfig(),
-		Socket:             writer.NewSocketConfig(),
-		Websocket:          writer.NewWebsocketConfig(),
-		ZMQ4:               writer.NewZMQ4Config(),
-		Processors:         []processor.Config{},
+		Label:                 "",
+		Type:                  "stdout",
+		AMQP:                  writer.NewAMQPConfig(),
+		AMQP09:                writer.NewAMQPConfig(),
+		AMQP1:                 writer.NewAMQP1Config(),
+		AWSDynamoDB:           writer.NewDynamoDBConfig(),
+		AWSKinesis:            writer.NewKinesisConfig(),
+		AWSKinesisFirehose:    writer.NewKinesisFirehoseConfig(),
+		AWSS3:                 writer.NewAmazonS3Config(),
+		AWSSNS:                writer.NewSNSConfig(),
+		AWSSQS:                writer.NewAmazonSQSConfig(),
+		AzureBlobStorage:      writer.NewAzureBlobStorageConfig(),
+		AzureQueueStorage:     writer.NewAzureQueueStorageConfig(),
+		AzureServiceBus:       NewAzureServiceBusConfig(),
+		AzureTableStorage:     writer.NewAzureTableStorageConfig(),
+		BlobStorage:           writer.NewAzureBlobStorageConfig(),
+		Broker:                NewBrokerConfig(),
+		Cache:                 writer.NewCacheConfig(),
+		Cassandra:             NewCassandraConfig(),
+		CloudEventsHTTP:       NewCloudEventsHTTPConfig(),
+		DeadLetter:            NewDeadLetterConfig(),
+		Drop:                  writer.NewDropConfig(),
+		DropOn:                NewDropOnConfig(),
+		DropOnError:           NewDropOnErrorConfig(),
+		Dynamic:               NewDynamicConfig(),
+		DynamoDB:              writer.NewDynamoDBConfig(),
+		Elasticsearch:         writer.NewElasticsearchConfig(),
+		File:                  NewFileConfig(),
+		Files:                 writer.NewFilesConfig(),
+		GCPCloudStorage:       NewGCPCloudStorageConfig(),
+		GCPPubSub:             writer.NewGCPPubSubConfig(),
+		HDFS:                  writer.NewHDFSConfig(),
+		HTTPClient:            writer.NewHTTPClientConfig(),
+		HTTPServer:            NewHTTPServerConfig(),
+		Idempotent:            NewIdempotentConfig(),
+		Inproc:                NewInprocConfig(),
+		InprocTopic:           NewInprocTopicConfig(),
+		Kafka:                 writer.NewKafkaConfig(),
+		KafkaRequestReply:     NewKafkaRequestReplyConfig(),
+		Kinesis:               writer.NewKinesisConfig(),
+		KinesisFirehose:       writer.NewKinesisFirehoseConfig(),
+		MQTT:                  writer.NewMQTTConfig(),
+		MongoDB:               NewMongoDBConfig(),
+		Nanomsg:               writer.NewNanomsgConfig(),
+		NATS:                  writer.NewNATSConfig(),
+		NATSStream:            writer.NewNATSStreamConfig(),
+		NSQ:                   writer.NewNSQConfig(),
+		Plugin:                nil,
+		OTLP:                  NewOTLPConfig(),
+		PrometheusRemoteWrite: NewPrometheusRemoteWriteConfig(),
+		Pulsar:                NewPulsarConfig(),
+		RedisHash:             writer.NewRedisHashConfig(),
+		RedisList:             writer.NewRedisListConfig(),
+		RedisPubSub:           writer.NewRedisPubSubConfig(),
+		RedisStreams:          writer.NewRedisStreamsConfig(),
+		Reject:                NewRejectConfig(),
+		Resource:              "",
+		Retry:                 NewRetryConfig(),
+		S3:                    writer.NewAmazonS3Config(),
+		SFTP:                  NewSFTPConfig(),
+		SNS:                   writer.NewSNSConfig(),
+		SQL:                   NewSQLConfig(),
+		SQS:                   writer.NewAmazonSQSConfig(),
+		STDOUT:                NewSTDOUTConfig(),
+		Subprocess:            NewSubprocessConfig(),
+		Switch:                NewSwitchConfig(),
+		SyncResponse:          struct{}{},
+		TableStorage:          writer.NewAzureTableStorageConfig(),
+		TCP:                   writer.NewTCPConfig(),
+		Try:                   NewTryConfig(),
+		UDP:                   writer.NewUDPConfig(),
+		Socket:                writer.NewSocketConfig(),
+		WebHDFS:               NewWebHDFSConfig(),
+		Websocket:             writer.NewWebsocketConfig(),
+		ZMQ4:                  writer.NewZMQ4Config(),
+		Processors:            []processor.Config{},
 	}
 }
 
@@ -0,0 +1,327 @@
+package output
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/broker"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeDeadLetter] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			if conf.DeadLetter.Output == nil {
+				return nil, errors.New("cannot create a dead_letter output without a child")
+			}
+			wrapped, err := New(*conf.DeadLetter.Output, mgr, log, stats)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create output '%v': %v", conf.DeadLetter.Output.Type, err)
+			}
+			return newDeadLetter(conf.DeadLetter.Component, wrapped, log, stats)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `
+Wraps each message in a dead letter envelope before writing it to a child output. The envelope follows the conventions of Kafka Connect dead letter queues so that it can be consumed by existing tooling.`,
+		Description: `
+This output is intended to be used as a fallback tier of a ` + "[`try`](/docs/components/outputs/try)" + ` output, or as the target of a ` + "[`switch`](/docs/components/outputs/switch)" + ` case that routes messages that failed processing. Each message is replaced with a JSON document of the form:
+
+` + "```json" + `
+{
+  "value": "<original payload, base64 encoded>",
+  "error": "<the reason the message failed>",
+  "component": "<the component the message failed at>",
+  "timestamp": "<RFC 3339 timestamp of when the envelope was created>",
+  "metadata": { "<original metadata key>": "<original metadata value>" }
+}
+` + "```" + `
+
+The error is taken from the output that previously rejected the message within a ` + "`try`" + ` output, or otherwise from the processing error of the message (if any).
+
+The original metadata of the message is preserved, and the following metadata fields are added following the Kafka Connect header conventions, which are written as headers by outputs such as ` + "`kafka`" + `:
+
+` + "```text" + `
+- __connect.errors.topic
+- __connect.errors.partition
+- __connect.errors.offset
+- __connect.errors.connector.name
+- __connect.errors.exception.message
+` + "```" + `
+
+The topic, partition and offset fields are only added when the message was consumed with a ` + "`kafka`" + ` input.`,
+		Categories: []Category{
+			CategoryUtility,
+		},
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("component", "A name identifying the component that messages failed at, which is written to the `component` field of the envelope and the `__connect.errors.connector.name` metadata field.", "http_client_foo"),
+			docs.FieldCommon("output", "A child output.").HasType(docs.FieldOutput),
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title:   "Kafka dead letter queue",
+				Summary: "In this example we attempt to deliver messages to an HTTP endpoint, and messages that fail are wrapped in a dead letter envelope and written to a Kafka topic.",
+				Config: `
+output:
+  try:
+    - http_client:
+        url: http://example.com/foo/messages
+        verb: POST
+        retries: 3
+    - dead_letter:
+        component: foo_http_client
+        output:
+          kafka:
+            addresses: [ localhost:9092 ]
+            topic: foo_dlq
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// DeadLetterConfig contains configuration values for the DeadLetter output
+// type.
+type DeadLetterConfig struct {
+	Component string  `json:"component" yaml:"component"`
+	Output    *Config `json:"output" yaml:"output"`
+}
+
+// NewDeadLetterConfig creates a new DeadLetterConfig with default values.
+func NewDeadLetterConfig() DeadLetterConfig {
+	return DeadLetterConfig{
+		Component: "",
+		Output:    nil,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type dummyDeadLetterConfig struct {
+	Component string      `json:"component" yaml:"component"`
+	Output    interface{} `json:"output" yaml:"output"`
+}
+
+// MarshalJSON prints an empty object instead of nil.
+func (d DeadLetterConfig) MarshalJSON() ([]byte, error) {
+	dummy := dummyDeadLetterConfig{
+		Component: d.Component,
+		Output:    d.Output,
+	}
+	if d.Output == nil {
+		dummy.Output = struct{}{}
+	}
+	return json.Marshal(dummy)
+}
+
+// MarshalYAML prints an empty object instead of nil.
+func (d DeadLetterConfig) MarshalYAML() (interface{}, error) {
+	dummy := dummyDeadLetterConfig{
+		Component: d.Component,
+		Output:    d.Output,
+	}
+	if d.Output == nil {
+		dummy.Output = struct{}{}
+	}
+	return dummy, nil
+}
+
+//------------------------------------------------------------------------------
+
+type deadLetterEnvelope struct {
+	Value     string            `json:"value"`
+	Error     string            `json:"error"`
+	Component string            `json:"component"`
+	Timestamp string            `json:"timestamp"`
+	Metadata  map[string]string `json:"metadata"`
+}
+
+// deadLetter wraps messages in a dead letter envelope before forwarding them
+// to a child output.
+type deadLetter struct {
+	stats metrics.Type
+	log   log.Modular
+
+	component string
+	wrapped   Type
+	timeNow   func() time.Time
+
+	transactionsIn  <-chan types.Transaction
+	transactionsOut chan types.Transaction
+
+	ctx        context.Context
+	done       func()
+	closedChan chan struct{}
+}
+
+func newDeadLetter(component string, wrapped Type, log log.Modular, stats metrics.Type) (*deadLetter, error) {
+	ctx, done := context.WithCancel(context.Background())
+	return &deadLetter{
+		log:             log,
+		stats:           stats,
+		component:       component,
+		wrapped:         wrapped,
+		timeNow:         time.Now,
+		transactionsOut: make(chan types.Transaction),
+
+		ctx:        ctx,
+		done:       done,
+		closedChan: make(chan struct{}),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (d *deadLetter) wrapPart(p types.Part) (types.Part, error) {
+	var errStr string
+	if err := broker.TryError(p); err != nil {
+		errStr = err.Error()
+	} else {
+		errStr = p.Metadata().Get(types.FailFlagKey)
+	}
+
+	meta := map[string]string{}
+	p.Metadata().Iter(func(k, v string) error {
+		meta[k] = v
+		return nil
+	})
+
+	envelope, err := json.Marshal(deadLetterEnvelope{
+		Value:     base64.StdEncoding.EncodeToString(p.Get()),
+		Error:     errStr,
+		Component: d.component,
+		Timestamp: d.timeNow().UTC().Format(time.RFC3339Nano),
+		Metadata:  meta,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	newPart := p.Copy()
+	newPart.Set(envelope)
+
+	newMeta := newPart.Metadata()
+	for k, connectK := range map[string]string{
+		"kafka_topic":     "__connect.errors.topic",
+		"kafka_partition": "__connect.errors.partition",
+		"kafka_offset":    "__connect.errors.offset",
+	} {
+		if v, exists := meta[k]; exists {
+			newMeta.Set(connectK, v)
+		}
+	}
+	newMeta.Set("__connect.errors.connector.name", d.component)
+	newMeta.Set("__connect.errors.exception.message", errStr)
+	return newPart, nil
+}
+
+func (d *deadLetter) loop() {
+	var (
+		mWrapped    = d.stats.GetCounter("dead_letter.wrapped")
+		mWrapFailed = d.stats.GetCounter("dead_letter.error")
+	)
+
+	defer func() {
+		close(d.transactionsOut)
+		d.wrapped.CloseAsync()
+		err := d.wrapped.WaitForClose(time.Second)
+		for ; err != nil; err = d.wrapped.WaitForClose(time.Second) {
+		}
+		close(d.closedChan)
+	}()
+
+	resChan := make(chan types.Response)
+
+	for {
+		var ts types.Transaction
+		var open bool
+		select {
+		case ts, open = <-d.transactionsIn:
+			if !open {
+				return
+			}
+		case <-d.ctx.Done():
+			return
+		}
+
+		msg := message.New(nil)
+		ts.Payload.Iter(func(i int, p types.Part) error {
+			newPart, err := d.wrapPart(p)
+			if err != nil {
+				mWrapFailed.Incr(1)
+				d.log.Errorf("Failed to create dead letter envelope: %v\n", err)
+				newPart = p.Copy()
+			}
+			msg.Append(newPart)
+			return nil
+		})
+		mWrapped.Incr(int64(msg.Len()))
+
+		select {
+		case d.transactionsOut <- types.NewTransaction(msg, resChan):
+		case <-d.ctx.Done():
+			return
+		}
+
+		var res types.Response
+		select {
+		case res = <-resChan:
+		case <-d.ctx.Done():
+			return
+		}
+
+		select {
+		case ts.ResponseChan <- res:
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// Consume assigns a messages channel for the output to read.
+func (d *deadLetter) Consume(ts <-chan types.Transaction) error {
+	if d.transactionsIn != nil {
+		return types.ErrAlreadyStarted
+	}
+	if err := d.wrapped.Consume(d.transactionsOut); err != nil {
+		return err
+	}
+	d.transactionsIn = ts
+	go d.loop()
+	return nil
+}
+
+// Connected returns a boolean indicating whether this output is currently
+// connected to its target.
+func (d *deadLetter) Connected() bool {
+	return d.wrapped.Connected()
+}
+
+// CloseAsync shuts down the DeadLetter output and stops processing requests.
+func (d *deadLetter) CloseAsync() {
+	d.done()
+}
+
+// WaitForClose blocks until the DeadLetter output has closed down.
+func (d *deadLetter) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-d.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
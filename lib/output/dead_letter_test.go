@@ -0,0 +1,164 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/broker"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterTryFallback(t *testing.T) {
+	primary, child := &MockOutputType{}, &MockOutputType{}
+
+	dl, err := newDeadLetter("foo_http", child, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	dl.timeNow = func() time.Time {
+		return time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	}
+
+	try, err := broker.NewTry([]types.Output{primary, dl}, metrics.Noop())
+	require.NoError(t, err)
+
+	readChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	require.NoError(t, try.Consume(readChan))
+
+	defer func() {
+		try.CloseAsync()
+		assert.NoError(t, try.WaitForClose(time.Second))
+	}()
+
+	msg := message.New([][]byte{[]byte("hello world")})
+	msg.Get(0).Metadata().
+		Set("kafka_topic", "foo").
+		Set("kafka_partition", "2").
+		Set("kafka_offset", "34")
+
+	select {
+	case readChan <- types.NewTransaction(msg, resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	var ts types.Transaction
+	select {
+	case ts = <-primary.TChan:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	select {
+	case ts.ResponseChan <- response.NewError(errors.New("http request failed")):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	select {
+	case ts = <-child.TChan:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	require.Equal(t, 1, ts.Payload.Len())
+
+	var envelope map[string]interface{}
+	require.NoError(t, json.Unmarshal(ts.Payload.Get(0).Get(), &envelope))
+	assert.Equal(t, map[string]interface{}{
+		"value":     "aGVsbG8gd29ybGQ=",
+		"error":     "http request failed",
+		"component": "foo_http",
+		"timestamp": "2021-03-04T05:06:07Z",
+		"metadata": map[string]interface{}{
+			"kafka_topic":     "foo",
+			"kafka_partition": "2",
+			"kafka_offset":    "34",
+		},
+	}, envelope)
+
+	meta := ts.Payload.Get(0).Metadata()
+	assert.Equal(t, "foo", meta.Get("__connect.errors.topic"))
+	assert.Equal(t, "2", meta.Get("__connect.errors.partition"))
+	assert.Equal(t, "34", meta.Get("__connect.errors.offset"))
+	assert.Equal(t, "foo_http", meta.Get("__connect.errors.connector.name"))
+	assert.Equal(t, "http request failed", meta.Get("__connect.errors.exception.message"))
+
+	assert.Equal(t, "hello world", string(msg.Get(0).Get()))
+	assert.Equal(t, "", msg.Get(0).Metadata().Get("__connect.errors.topic"))
+
+	select {
+	case ts.ResponseChan <- response.NewAck():
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	select {
+	case res := <-resChan:
+		assert.NoError(t, res.Error())
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+func TestDeadLetterProcessingError(t *testing.T) {
+	child := &MockOutputType{}
+
+	dl, err := newDeadLetter("", child, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	readChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	require.NoError(t, dl.Consume(readChan))
+
+	defer func() {
+		dl.CloseAsync()
+		assert.NoError(t, dl.WaitForClose(time.Second))
+	}()
+
+	msg := message.New([][]byte{[]byte("foo"), []byte("bar")})
+	msg.Get(1).Metadata().Set(types.FailFlagKey, "bad thing happened")
+
+	select {
+	case readChan <- types.NewTransaction(msg, resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	var ts types.Transaction
+	select {
+	case ts = <-child.TChan:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	require.Equal(t, 2, ts.Payload.Len())
+
+	var envelope map[string]interface{}
+	require.NoError(t, json.Unmarshal(ts.Payload.Get(0).Get(), &envelope))
+	assert.Equal(t, "Zm9v", envelope["value"])
+	assert.Equal(t, "", envelope["error"])
+
+	require.NoError(t, json.Unmarshal(ts.Payload.Get(1).Get(), &envelope))
+	assert.Equal(t, "YmFy", envelope["value"])
+	assert.Equal(t, "bad thing happened", envelope["error"])
+	assert.Equal(t, "bad thing happened", ts.Payload.Get(1).Metadata().Get("__connect.errors.exception.message"))
+	assert.Equal(t, "", ts.Payload.Get(1).Metadata().Get("__connect.errors.topic"))
+
+	select {
+	case ts.ResponseChan <- response.NewError(errors.New("nope")):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	select {
+	case res := <-resChan:
+		assert.EqualError(t, res.Error(), "nope")
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
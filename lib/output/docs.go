@@ -108,7 +108,9 @@ conditions please [read the docs here](/docs/components/conditions/about).
 ### Dead Letter Queues
 
 It's possible to create fallback outputs for when an output target fails using
-a ` + "[`try`](/docs/components/outputs/try)" + ` output.`
+a ` + "[`try`](/docs/components/outputs/try)" + ` output. Failed messages can be
+wrapped in a standard envelope describing the failure with a
+` + "[`dead_letter`](/docs/components/outputs/dead_letter)" + ` output.`
 
 // Descriptions returns a formatted string of collated descriptions of each
 // type.
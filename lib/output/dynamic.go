@@ -44,6 +44,7 @@ already exists it will be changed.`,
 			docs.FieldCommon(
 				"max_in_flight", "The maximum number of messages to dispatch across child outputs at any given time.",
 			),
+			api.DynamicPersistenceFieldSpec("outputs"),
 		},
 		Categories: []Category{
 			CategoryUtility,
@@ -55,10 +56,11 @@ already exists it will be changed.`,
 
 // DynamicConfig contains configuration fields for the Dynamic output type.
 type DynamicConfig struct {
-	Outputs     map[string]Config `json:"outputs" yaml:"outputs"`
-	Prefix      string            `json:"prefix" yaml:"prefix"`
-	Timeout     string            `json:"timeout" yaml:"timeout"`
-	MaxInFlight int               `json:"max_in_flight" yaml:"max_in_flight"`
+	Outputs     map[string]Config            `json:"outputs" yaml:"outputs"`
+	Prefix      string                       `json:"prefix" yaml:"prefix"`
+	Timeout     string                       `json:"timeout" yaml:"timeout"`
+	MaxInFlight int                          `json:"max_in_flight" yaml:"max_in_flight"`
+	Persistence api.DynamicPersistenceConfig `json:"persistence" yaml:"persistence"`
 }
 
 // NewDynamicConfig creates a new DynamicConfig with default values.
@@ -68,6 +70,7 @@ func NewDynamicConfig() DynamicConfig {
 		Prefix:      "",
 		Timeout:     "5s",
 		MaxInFlight: 1,
+		Persistence: api.NewDynamicPersistenceConfig(),
 	}
 }
 
@@ -82,8 +85,48 @@ func NewDynamic(
 ) (Type, error) {
 	dynAPI := api.NewDynamic()
 
+	newDynamicOutput := func(id string, c []byte) (Config, Type, error) {
+		newConf := NewConfig()
+		if err := yaml.Unmarshal(c, &newConf); err != nil {
+			return newConf, nil, err
+		}
+		oMgr, oLog, oStats := interop.LabelChild(fmt.Sprintf("dynamic.outputs.%v", id), mgr, log, stats)
+		oStats = metrics.Combine(stats, oStats)
+		newOutput, err := New(newConf, oMgr, oLog, oStats)
+		return newConf, newOutput, err
+	}
+
+	outputConfigs := conf.Dynamic.Outputs
+	outputConfigsMut := sync.RWMutex{}
+
 	outputs := map[string]broker.DynamicOutput{}
+
+	store, err := api.NewDynamicStore(conf.Dynamic.Persistence, "outputs", mgr)
+	if err != nil {
+		return nil, err
+	}
+	if store != nil {
+		persisted, err := store.List()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read persisted outputs: %w", err)
+		}
+		for id, c := range persisted {
+			newConf, newOutput, err := newDynamicOutput(id, c)
+			if err != nil {
+				log.Errorf("Failed to restore persisted output '%v': %v\n", id, err)
+				continue
+			}
+			outputs[id] = newOutput
+			outputConfigs[id] = newConf
+			dynAPI.Restored(id, c)
+		}
+		dynAPI.SetStore(store)
+	}
+
 	for k, v := range conf.Dynamic.Outputs {
+		if _, exists := outputs[k]; exists {
+			continue
+		}
 		newOutput, err := New(v, mgr, log, stats)
 		if err != nil {
 			return nil, err
@@ -99,9 +142,6 @@ func NewDynamic(
 		}
 	}
 
-	outputConfigs := conf.Dynamic.Outputs
-	outputConfigsMut := sync.RWMutex{}
-
 	fanOut, err := broker.NewDynamicFanOut(
 		outputs, log, stats,
 		broker.OptDynamicFanOutSetOnAdd(func(l string) {
@@ -131,13 +171,7 @@ func NewDynamic(
 	fanOut = fanOut.WithMaxInFlight(conf.Dynamic.MaxInFlight)
 
 	dynAPI.OnUpdate(func(id string, c []byte) error {
-		newConf := NewConfig()
-		if err := yaml.Unmarshal(c, &newConf); err != nil {
-			return err
-		}
-		oMgr, oLog, oStats := interop.LabelChild(fmt.Sprintf("dynamic.outputs.%v", id), mgr, log, stats)
-		oStats = metrics.Combine(stats, oStats)
-		newOutput, err := New(newConf, oMgr, oLog, oStats)
+		newConf, newOutput, err := newDynamicOutput(id, c)
 		if err != nil {
 			return err
 		}
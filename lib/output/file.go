@@ -10,6 +10,7 @@ import (
 
 	"github.com/Jeffail/benthos/v3/internal/codec"
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/replay"
 	"github.com/Jeffail/benthos/v3/lib/bloblang"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
@@ -34,6 +35,14 @@ Messages can be written to different files by using [interpolation functions](/d
 				`/tmp/${! json("document.id") }.json`,
 			).IsInterpolated().AtVersion("3.33.0"),
 			codec.WriterDocs.AtVersion("3.33.0"),
+			docs.FieldAdvanced(
+				"replay_index", "An optional path of an index file to append an entry to for each message written, which allows a range of the archived messages to be read back with a [`replay` input](/docs/components/inputs/replay).",
+				"/tmp/archive/index.jsonl",
+			).AtVersion("3.44.0"),
+			docs.FieldAdvanced(
+				"replay_key", "An optional key to record within the replay index for each message written, which can be used in order to select a range of keys to replay.",
+				`${! json("id") }`,
+			).IsInterpolated().AtVersion("3.44.0"),
 			docs.FieldDeprecated("delimiter"),
 		},
 		Categories: []Category{
@@ -46,17 +55,21 @@ Messages can be written to different files by using [interpolation functions](/d
 
 // FileConfig contains configuration fields for the file based output type.
 type FileConfig struct {
-	Path  string `json:"path" yaml:"path"`
-	Codec string `json:"codec" yaml:"codec"`
-	Delim string `json:"delimiter" yaml:"delimiter"`
+	Path        string `json:"path" yaml:"path"`
+	Codec       string `json:"codec" yaml:"codec"`
+	ReplayIndex string `json:"replay_index" yaml:"replay_index"`
+	ReplayKey   string `json:"replay_key" yaml:"replay_key"`
+	Delim       string `json:"delimiter" yaml:"delimiter"`
 }
 
 // NewFileConfig creates a new FileConfig with default values.
 func NewFileConfig() FileConfig {
 	return FileConfig{
-		Path:  "",
-		Codec: "lines",
-		Delim: "",
+		Path:        "",
+		Codec:       "lines",
+		ReplayIndex: "",
+		ReplayKey:   "",
+		Delim:       "",
 	}
 }
 
@@ -67,7 +80,7 @@ func NewFile(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type
 	if len(conf.File.Delim) > 0 {
 		conf.File.Codec = "delim:" + conf.File.Delim
 	}
-	f, err := newFileWriter(conf.File.Path, conf.File.Codec, log, stats)
+	f, err := newFileWriter(conf.File, log, stats)
 	if err != nil {
 		return nil, err
 	}
@@ -84,29 +97,53 @@ type fileWriter struct {
 	codec     codec.WriterConstructor
 	codecConf codec.WriterConfig
 
+	index     *replay.IndexWriter
+	replayKey bloblang.Field
+
 	handleMut  sync.Mutex
 	handlePath string
 	handle     codec.Writer
 }
 
-func newFileWriter(pathStr string, codecStr string, log log.Modular, stats metrics.Type) (*fileWriter, error) {
-	codec, codecConf, err := codec.GetWriter(codecStr)
+func newFileWriter(conf FileConfig, log log.Modular, stats metrics.Type) (*fileWriter, error) {
+	codec, codecConf, err := codec.GetWriter(conf.Codec)
 	if err != nil {
 		return nil, err
 	}
-	path, err := bloblang.NewField(pathStr)
+	path, err := bloblang.NewField(conf.Path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse path expression: %w", err)
 	}
+	replayKey, err := bloblang.NewField(conf.ReplayKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse replay key expression: %w", err)
+	}
+	var index *replay.IndexWriter
+	if len(conf.ReplayIndex) > 0 {
+		if index, err = replay.NewIndexWriter(conf.ReplayIndex); err != nil {
+			return nil, fmt.Errorf("failed to open replay index: %w", err)
+		}
+	}
 	return &fileWriter{
 		codec:     codec,
 		codecConf: codecConf,
 		path:      path,
+		index:     index,
+		replayKey: replayKey,
 		log:       log,
 		stats:     stats,
 	}, nil
 }
 
+// addIndexEntry records a written message part within the replay index, empty
+// parts are skipped as they are also skipped when read back.
+func (w *fileWriter) addIndexEntry(path string, i int, msg types.Message) error {
+	if w.index == nil || len(msg.Get(i).Get()) == 0 {
+		return nil
+	}
+	return w.index.Add(path, time.Now(), w.replayKey.String(i, msg))
+}
+
 //------------------------------------------------------------------------------
 
 func (w *fileWriter) ConnectWithContext(ctx context.Context) error {
@@ -121,7 +158,10 @@ func (w *fileWriter) WriteWithContext(ctx context.Context, msg types.Message) er
 		defer w.handleMut.Unlock()
 
 		if w.handle != nil && path == w.handlePath {
-			return w.handle.Write(ctx, p)
+			if err := w.handle.Write(ctx, p); err != nil {
+				return err
+			}
+			return w.addIndexEntry(path, i, msg)
 		}
 		if w.handle != nil {
 			if err := w.handle.Close(ctx); err != nil {
@@ -135,6 +175,9 @@ func (w *fileWriter) WriteWithContext(ctx context.Context, msg types.Message) er
 		}
 		if w.codecConf.Truncate {
 			flag = flag | os.O_TRUNC
+			if w.index != nil {
+				w.index.Reset(path)
+			}
 		}
 
 		if err := os.MkdirAll(filepath.Dir(path), os.FileMode(0777)); err != nil {
@@ -156,6 +199,10 @@ func (w *fileWriter) WriteWithContext(ctx context.Context, msg types.Message) er
 			handle.Close(ctx)
 			return err
 		}
+		if err = w.addIndexEntry(path, i, msg); err != nil {
+			handle.Close(ctx)
+			return err
+		}
 
 		if !w.codecConf.CloseAfter {
 			w.handle = handle
@@ -186,6 +233,10 @@ func (w *fileWriter) CloseAsync() {
 			w.handle.Close(context.Background())
 			w.handle = nil
 		}
+		if w.index != nil {
+			w.index.Close()
+			w.index = nil
+		}
 		w.handleMut.Unlock()
 	}()
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -19,14 +20,41 @@ Sends messages to a GCP Cloud Pub/Sub topic. [Metadata](/docs/configuration/meta
 from messages are sent as attributes.`,
 		Description: `
 For information on how to set up credentials check out
-[this guide](https://cloud.google.com/docs/authentication/production).`,
-		Async: true,
+[this guide](https://cloud.google.com/docs/authentication/production).
+
+### Ordering
+
+When the field ` + "`ordering_key`" + ` is set messages with the same key are
+delivered to subscriptions that have message ordering enabled in the order that
+they were published. When a message fails to publish then publishing for its
+key is paused until the message is retried, which guarantees that subsequent
+messages of the key aren't delivered before it.
+
+Messages of separate batches can be published out of order when
+` + "`max_in_flight`" + ` is greater than one, and therefore ordering should be
+combined with a ` + "`max_in_flight`" + ` of one.
+
+### Flow Control
+
+The fields within ` + "`flow_control`" + ` limit the messages that are
+published but not yet acknowledged by the server, which bounds the memory used
+when publishing large batches with a high ` + "`max_in_flight`" + `. Publishing
+waits when the number of outstanding messages is reached, and when the limit
+of outstanding bytes is exceeded messages fail to publish and are retried.`,
+		Async:   true,
+		Batches: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("project", "The project ID of the topic to publish to."),
 			docs.FieldCommon("topic", "The topic to publish to.").IsInterpolated(),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
 			docs.FieldAdvanced("publish_timeout", "The maximum length of time to wait before abandoning a publish attempt for a message.", "10s", "5m", "60m"),
 			docs.FieldCommon("metadata", "Specify criteria for which metadata values are sent as attributes.").WithChildren(output.MetadataFields()...),
+			docs.FieldAdvanced("ordering_key", "An optional key used to [order messages](#ordering) published to the topic.", `${! meta("kafka_key") }`).IsInterpolated().AtVersion("3.44.0"),
+			docs.FieldAdvanced("flow_control", "Limits on the messages that are published but not yet acknowledged.").WithChildren(
+				docs.FieldAdvanced("max_outstanding_messages", "The maximum number of outstanding messages, or zero for no limit."),
+				docs.FieldAdvanced("max_outstanding_bytes", "The maximum number of outstanding bytes, or zero for the client default."),
+			).AtVersion("3.44.0"),
 		},
 		Categories: []Category{
 			CategoryServices,
@@ -49,7 +77,7 @@ func NewGCPPubSub(conf Config, mgr types.Manager, log log.Modular, stats metrics
 	if err != nil {
 		return nil, err
 	}
-	return OnlySinglePayloads(w), nil
+	return NewBatcherFromConfig(conf.GCPPubSub.Batching, OnlySinglePayloads(w), mgr, log, stats)
 }
 
 //------------------------------------------------------------------------------
@@ -0,0 +1,358 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeIdempotent] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			if conf.Idempotent.Output == nil {
+				return nil, errors.New("cannot create an idempotent output without a child")
+			}
+			wrapped, err := New(*conf.Idempotent.Output, mgr, log, stats)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create output '%v': %v", conf.Idempotent.Output.Type, err)
+			}
+			return newIdempotent(conf.Idempotent, mgr, wrapped, log, stats)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `
+Writes messages to a child output and records the ID of each message that is successfully delivered within a cache, messages with an ID that has already been delivered are acknowledged without being written again.`,
+		Description: `
+Benthos delivers messages at-least-once, and therefore a message can be written to an output more than once when a send is retried after an ambiguous failure, or when messages are reconsumed after a restart. Many sinks have no native mechanism for removing duplicates, and wrapping such an output with ` + "`idempotent`" + ` prevents messages that were already delivered from being written again, giving effectively exactly-once delivery.
+
+The ID of each message is derived with the [Bloblang mapping](/docs/guides/bloblang/about) ` + "`id`" + `, which must result in a string that uniquely identifies the message, such as a field of the message or a metadata field set by the input. Once the child output confirms that a batch was delivered the IDs of its messages are written to the ` + "`cache`" + `, and messages of subsequent batches with an ID that exists within the cache are skipped. A batch that fails to be delivered does not record any IDs and is therefore retried in full. Messages within a batch that share an ID are written only once.
+
+If the ID of a message cannot be derived, or the cache cannot be read, the batch is rejected with an error rather than risking a duplicate write. If the IDs of a delivered batch cannot be written to the cache the error is logged and the batch is acknowledged, as it was delivered successfully.
+
+### Guarantees
+
+The cache is only written after the child output has confirmed delivery, and therefore a crash between a successful write and the IDs being recorded results in the batch being written again once the messages are reconsumed. For the strongest guarantee the cache should be persistent and shared by all instances that could write the same messages, such as a ` + "[`redis`](/docs/components/caches/redis)" + ` cache, and the ` + "`ttl`" + ` of recorded IDs should exceed the longest period in which a message could be redelivered.
+
+### Metrics
+
+Messages that are skipped as duplicates are counted by ` + "`idempotent.skipped`" + `, and batches that are skipped entirely are counted by ` + "`idempotent.batch.skipped`" + `.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("cache", "A [cache resource](/docs/components/caches/about) for storing the IDs of delivered messages."),
+			docs.FieldCommon("id", "A [Bloblang mapping](/docs/guides/bloblang/about) that derives a unique ID of each message as a string.", `root = this.event_id`, `root = meta("kafka_topic") + ":" + meta("kafka_partition") + ":" + meta("kafka_offset")`).Linter(docs.LintBloblangMapping),
+			docs.FieldAdvanced("key_prefix", "A prefix added to the IDs of messages when they are stored within the cache, which prevents collisions with other keys stored within the same cache resource."),
+			docs.FieldAdvanced("ttl", "An optional duration after which recorded IDs are eligible for removal from the cache. Not all caches support per-key TTLs, and those that do not will fall back to their generally configured TTL setting.", "24h", "168h"),
+			docs.FieldCommon("output", "A child output.").HasType(docs.FieldOutput),
+		},
+		Categories: []Category{
+			CategoryUtility,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title:   "Exactly-Once HTTP Deliveries",
+				Summary: "In order to avoid posting an event to an HTTP endpoint more than once when it is redelivered from Kafka we can record the ID of each event within a Redis cache:",
+				Config: `
+output:
+  idempotent:
+    cache: delivered
+    id: root = this.event_id
+    ttl: 72h
+    output:
+      http_client:
+        url: http://example.com/events
+        verb: POST
+
+resources:
+  caches:
+    delivered:
+      redis:
+        url: tcp://localhost:6379
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// IdempotentConfig contains configuration values for the Idempotent output
+// type.
+type IdempotentConfig struct {
+	Cache     string  `json:"cache" yaml:"cache"`
+	ID        string  `json:"id" yaml:"id"`
+	KeyPrefix string  `json:"key_prefix" yaml:"key_prefix"`
+	TTL       string  `json:"ttl" yaml:"ttl"`
+	Output    *Config `json:"output" yaml:"output"`
+}
+
+// NewIdempotentConfig creates a new IdempotentConfig with default values.
+func NewIdempotentConfig() IdempotentConfig {
+	return IdempotentConfig{
+		Cache:     "",
+		ID:        "",
+		KeyPrefix: "benthos_delivered:",
+		TTL:       "",
+		Output:    nil,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type dummyIdempotentConfig struct {
+	Cache     string      `json:"cache" yaml:"cache"`
+	ID        string      `json:"id" yaml:"id"`
+	KeyPrefix string      `json:"key_prefix" yaml:"key_prefix"`
+	TTL       string      `json:"ttl" yaml:"ttl"`
+	Output    interface{} `json:"output" yaml:"output"`
+}
+
+func (i IdempotentConfig) dummy() dummyIdempotentConfig {
+	dummy := dummyIdempotentConfig{
+		Cache:     i.Cache,
+		ID:        i.ID,
+		KeyPrefix: i.KeyPrefix,
+		TTL:       i.TTL,
+		Output:    i.Output,
+	}
+	if i.Output == nil {
+		dummy.Output = struct{}{}
+	}
+	return dummy
+}
+
+// MarshalJSON prints an empty object instead of nil.
+func (i IdempotentConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.dummy())
+}
+
+// MarshalYAML prints an empty object instead of nil.
+func (i IdempotentConfig) MarshalYAML() (interface{}, error) {
+	return i.dummy(), nil
+}
+
+//------------------------------------------------------------------------------
+
+// idempotent forwards messages to a child output, skipping messages with an
+// ID that has already been delivered and recording the IDs of delivered
+// messages within a cache.
+type idempotent struct {
+	stats metrics.Type
+	log   log.Modular
+
+	cache     types.Cache
+	id        *mapping.Executor
+	keyPrefix string
+	ttl       *time.Duration
+	wrapped   Type
+
+	transactionsIn  <-chan types.Transaction
+	transactionsOut chan types.Transaction
+
+	ctx        context.Context
+	done       func()
+	closedChan chan struct{}
+}
+
+func newIdempotent(conf IdempotentConfig, mgr types.Manager, wrapped Type, log log.Modular, stats metrics.Type) (*idempotent, error) {
+	if len(conf.ID) == 0 {
+		return nil, errors.New("an id mapping must be specified")
+	}
+	id, err := bloblang.NewMapping("", conf.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse id mapping: %w", err)
+	}
+
+	var ttl *time.Duration
+	if len(conf.TTL) > 0 {
+		t, err := time.ParseDuration(conf.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ttl duration: %w", err)
+		}
+		ttl = &t
+	}
+
+	cache, err := mgr.GetCache(conf.Cache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain cache '%v': %w", conf.Cache, err)
+	}
+
+	ctx, done := context.WithCancel(context.Background())
+	return &idempotent{
+		log:             log,
+		stats:           stats,
+		cache:           cache,
+		id:              id,
+		keyPrefix:       conf.KeyPrefix,
+		ttl:             ttl,
+		wrapped:         wrapped,
+		transactionsOut: make(chan types.Transaction),
+
+		ctx:        ctx,
+		done:       done,
+		closedChan: make(chan struct{}),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// pending returns the messages of a batch that have not yet been delivered
+// along with the cache keys of their IDs.
+func (i *idempotent) pending(msg types.Message) (types.Message, []string, error) {
+	pendingMsg := message.New(nil)
+	var keys []string
+
+	seen := map[string]struct{}{}
+	err := msg.Iter(func(index int, part types.Part) error {
+		idPart, err := i.id.MapPart(index, msg)
+		if err != nil {
+			return fmt.Errorf("failed to derive message id: %w", err)
+		}
+		key := i.keyPrefix + string(idPart.Get())
+		if _, exists := seen[key]; exists {
+			return nil
+		}
+		seen[key] = struct{}{}
+
+		if _, err = i.cache.Get(key); err == nil {
+			return nil
+		}
+		if err != types.ErrKeyNotFound {
+			return fmt.Errorf("failed to check message id: %w", err)
+		}
+		pendingMsg.Append(part)
+		keys = append(keys, key)
+		return nil
+	})
+	return pendingMsg, keys, err
+}
+
+// record stores the cache keys of delivered messages.
+func (i *idempotent) record(keys []string) error {
+	if cttl, ok := i.cache.(types.CacheWithTTL); ok && i.ttl != nil {
+		items := make(map[string]types.CacheTTLItem, len(keys))
+		for _, k := range keys {
+			items[k] = types.CacheTTLItem{Value: []byte{'t'}, TTL: i.ttl}
+		}
+		return cttl.SetMultiWithTTL(items)
+	}
+	items := make(map[string][]byte, len(keys))
+	for _, k := range keys {
+		items[k] = []byte{'t'}
+	}
+	return i.cache.SetMulti(items)
+}
+
+func (i *idempotent) loop() {
+	// Metrics paths
+	var (
+		mSkipped      = i.stats.GetCounter("idempotent.skipped")
+		mSkippedBatch = i.stats.GetCounter("idempotent.batch.skipped")
+	)
+
+	defer func() {
+		close(i.transactionsOut)
+		i.wrapped.CloseAsync()
+		err := i.wrapped.WaitForClose(time.Second)
+		for ; err != nil; err = i.wrapped.WaitForClose(time.Second) {
+		}
+		close(i.closedChan)
+	}()
+
+	resChan := make(chan types.Response)
+
+	for {
+		var ts types.Transaction
+		var open bool
+		select {
+		case ts, open = <-i.transactionsIn:
+			if !open {
+				return
+			}
+		case <-i.ctx.Done():
+			return
+		}
+
+		var res types.Response
+		pendingMsg, keys, err := i.pending(ts.Payload)
+		if err != nil {
+			i.log.Errorf("Rejecting message: %v\n", err)
+			res = response.NewError(err)
+		} else {
+			if skipped := ts.Payload.Len() - pendingMsg.Len(); skipped > 0 {
+				mSkipped.Incr(int64(skipped))
+			}
+			if pendingMsg.Len() == 0 {
+				mSkippedBatch.Incr(1)
+				res = response.NewAck()
+			} else {
+				select {
+				case i.transactionsOut <- types.NewTransaction(pendingMsg, resChan):
+				case <-i.ctx.Done():
+					return
+				}
+				select {
+				case res = <-resChan:
+				case <-i.ctx.Done():
+					return
+				}
+				if res.Error() == nil {
+					if err := i.record(keys); err != nil {
+						i.log.Errorf("Failed to record delivered message ids: %v\n", err)
+					}
+				}
+			}
+		}
+
+		select {
+		case ts.ResponseChan <- res:
+		case <-i.ctx.Done():
+			return
+		}
+	}
+}
+
+// Consume assigns a messages channel for the output to read.
+func (i *idempotent) Consume(ts <-chan types.Transaction) error {
+	if i.transactionsIn != nil {
+		return types.ErrAlreadyStarted
+	}
+	if err := i.wrapped.Consume(i.transactionsOut); err != nil {
+		return err
+	}
+	i.transactionsIn = ts
+	go i.loop()
+	return nil
+}
+
+// Connected returns a boolean indicating whether this output is currently
+// connected to its target.
+func (i *idempotent) Connected() bool {
+	return i.wrapped.Connected()
+}
+
+// CloseAsync shuts down the Idempotent output and stops processing requests.
+func (i *idempotent) CloseAsync() {
+	i.done()
+}
+
+// WaitForClose blocks until the Idempotent output has closed down.
+func (i *idempotent) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-i.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
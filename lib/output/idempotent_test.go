@@ -0,0 +1,143 @@
+package output
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type idempotentMgr struct {
+	types.DudMgr
+	cache types.Cache
+}
+
+func (m idempotentMgr) GetCache(name string) (types.Cache, error) {
+	if name == "foo" {
+		return m.cache, nil
+	}
+	return nil, types.ErrCacheNotFound
+}
+
+func TestIdempotentSkipsDelivered(t *testing.T) {
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := NewIdempotentConfig()
+	conf.Cache = "foo"
+	conf.ID = `root = this.id`
+
+	child := &MockOutputType{}
+	o, err := newIdempotent(conf, idempotentMgr{cache: memCache}, child, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tChan := make(chan types.Transaction)
+	rChan := make(chan types.Response)
+	require.NoError(t, o.Consume(tChan))
+
+	defer func() {
+		o.CloseAsync()
+		assert.NoError(t, o.WaitForClose(time.Second))
+	}()
+
+	send := func(contents ...string) {
+		t.Helper()
+		var parts [][]byte
+		for _, c := range contents {
+			parts = append(parts, []byte(c))
+		}
+		select {
+		case tChan <- types.NewTransaction(message.New(parts), rChan):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	forward := func(res types.Response, exp ...string) {
+		t.Helper()
+		var ts types.Transaction
+		select {
+		case ts = <-child.TChan:
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		var contents []string
+		for _, b := range message.GetAllBytes(ts.Payload) {
+			contents = append(contents, string(b))
+		}
+		assert.Equal(t, exp, contents)
+		select {
+		case ts.ResponseChan <- res:
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	receive := func() error {
+		t.Helper()
+		select {
+		case res := <-rChan:
+			return res.Error()
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		return nil
+	}
+
+	send(`{"id":"a"}`, `{"id":"b"}`)
+	forward(response.NewAck(), `{"id":"a"}`, `{"id":"b"}`)
+	require.NoError(t, receive())
+
+	_, err = memCache.Get("benthos_delivered:a")
+	require.NoError(t, err)
+
+	// Delivered messages and duplicates within a batch are skipped.
+	send(`{"id":"b"}`, `{"id":"c"}`, `{"id":"c"}`)
+	forward(response.NewError(errors.New("nope")), `{"id":"c"}`)
+	require.EqualError(t, receive(), "nope")
+
+	_, err = memCache.Get("benthos_delivered:c")
+	require.Equal(t, types.ErrKeyNotFound, err)
+
+	send(`{"id":"b"}`, `{"id":"c"}`, `{"id":"c"}`)
+	forward(response.NewAck(), `{"id":"c"}`)
+	require.NoError(t, receive())
+
+	// A batch that was delivered entirely never reaches the child.
+	send(`{"id":"a"}`, `{"id":"c"}`)
+	require.NoError(t, receive())
+
+	send(`not json`)
+	require.Error(t, receive())
+}
+
+func TestIdempotentBadConfig(t *testing.T) {
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	mgr := idempotentMgr{cache: memCache}
+
+	conf := NewIdempotentConfig()
+	conf.Cache = "foo"
+	_, err = newIdempotent(conf, mgr, &MockOutputType{}, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "an id mapping must be specified")
+
+	conf.ID = `root = this.id`
+	conf.TTL = "nope"
+	_, err = newIdempotent(conf, mgr, &MockOutputType{}, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse ttl duration")
+
+	conf.TTL = ""
+	conf.Cache = "bar"
+	_, err = newIdempotent(conf, mgr, &MockOutputType{}, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to obtain cache 'bar'")
+}
@@ -25,7 +25,8 @@ feedback loops can lead to deadlocks in your message flow.
 It is possible to connect multiple inputs to the same inproc ID, resulting in
 messages dispatching in a round-robin fashion to connected inputs. However, only
 one output can assume an inproc ID, and will replace existing outputs if a
-collision occurs.`,
+collision occurs. For multiple producers and consumers with backpressure use the
+` + "[`inproc_topic` output](/docs/components/outputs/inproc_topic)" + ` instead.`,
 		Categories: []Category{
 			CategoryUtility,
 		},
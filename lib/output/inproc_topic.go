@@ -0,0 +1,108 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/topic"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeInprocTopic] = TypeSpec{
+		constructor: fromSimpleConstructor(NewInprocTopic),
+		Status:      docs.StatusExperimental,
+		Version:     "3.44.0",
+		Summary: `
+Publishes messages to a named topic shared by all streams of a Benthos process.`,
+		Description: `
+Unlike the ` + "[`inproc` output](/docs/components/outputs/inproc)" + `, any number of outputs can publish to the same topic, and each group of ` + "[`inproc_topic` inputs](/docs/components/inputs/inproc_topic)" + ` consuming it receives every message. This allows streams running in [streams mode](/docs/guides/streams_mode/about) to fan-in and fan-out to each other.
+
+A message is acknowledged once it has been added to the topic. Messages are retained by the topic until every consumer group has acknowledged them, and when the number of retained messages reaches the capacity of the topic this output blocks until space becomes available, which applies backpressure from the slowest consumer group to all producers.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("topic", "The name of the topic to publish to."),
+			docs.FieldAdvanced("capacity", "The maximum number of messages retained by the topic. When multiple outputs publish to the same topic the largest capacity is used."),
+		},
+		Categories: []Category{
+			CategoryUtility,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// InprocTopicConfig contains configuration fields for the InprocTopic output
+// type.
+type InprocTopicConfig struct {
+	Topic    string `json:"topic" yaml:"topic"`
+	Capacity int    `json:"capacity" yaml:"capacity"`
+}
+
+// NewInprocTopicConfig creates a new InprocTopicConfig with default values.
+func NewInprocTopicConfig() InprocTopicConfig {
+	return InprocTopicConfig{
+		Topic:    "",
+		Capacity: topic.DefaultCapacity,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewInprocTopic creates a new InprocTopic output type.
+func NewInprocTopic(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	w, err := newInprocTopicWriter(conf.InprocTopic, mgr, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncWriter(TypeInprocTopic, 1, w, log, stats)
+}
+
+type inprocTopicWriter struct {
+	topic *topic.Topic
+
+	mRetained metrics.StatGauge
+	mBlocked  metrics.StatTimer
+}
+
+func newInprocTopicWriter(conf InprocTopicConfig, mgr types.Manager, stats metrics.Type) (*inprocTopicWriter, error) {
+	if len(conf.Topic) == 0 {
+		return nil, errors.New("a topic must be specified")
+	}
+	t, err := topic.FromManager(mgr, conf.Topic)
+	if err != nil {
+		return nil, err
+	}
+	t.DeclareCapacity(conf.Capacity)
+	return &inprocTopicWriter{
+		topic:     t,
+		mRetained: stats.GetGauge("topic.retained"),
+		mBlocked:  stats.GetTimer("topic.publish_latency_ns"),
+	}, nil
+}
+
+func (w *inprocTopicWriter) ConnectWithContext(ctx context.Context) error {
+	return nil
+}
+
+func (w *inprocTopicWriter) WriteWithContext(ctx context.Context, msg types.Message) error {
+	t0 := time.Now()
+	if err := w.topic.Publish(ctx, msg); err != nil {
+		return err
+	}
+	w.mBlocked.Timing(time.Since(t0).Nanoseconds())
+	w.mRetained.Set(int64(w.topic.Len()))
+	return nil
+}
+
+func (w *inprocTopicWriter) CloseAsync() {
+}
+
+func (w *inprocTopicWriter) WaitForClose(timeout time.Duration) error {
+	return nil
+}
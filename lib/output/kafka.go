@@ -25,7 +25,9 @@ The config field ` + "`ack_replicas`" + ` determines whether we wait for acknowl
 
 Both the ` + "`key` and `topic`" + ` fields can be dynamically set using function interpolations described [here](/docs/configuration/interpolation#bloblang-queries).
 
-[Metadata](/docs/configuration/metadata) will be added to each message sent as headers, but can be restricted using the field ` + "[`metadata`](#metadata)" + `.
+[Metadata](/docs/configuration/metadata) will be added to each message sent as headers, but can be restricted using the field ` + "[`metadata`](#metadata)" + `. Headers can also be set explicitly with the field ` + "[`headers`](#headers)" + `, where values are interpolated as raw bytes and can therefore contain binary data, e.g. ` + "`${! meta(\"foo\").decode(\"base64\") }`" + `.
+
+Since the ` + "`topic`" + ` field supports interpolation it's possible to route messages to topics based on headers consumed from a Kafka input, e.g. ` + "`events_${! meta(\"tenant\") }`" + `.
 
 ### Strict Ordering and Retries
 
@@ -41,12 +43,13 @@ However, this also means that manual intervention will eventually be required in
 			docs.FieldCommon("addresses", "A list of broker addresses to connect to. If an item of the list contains commas it will be expanded into multiple addresses.", []string{"localhost:9092"}, []string{"localhost:9041,localhost:9042"}, []string{"localhost:9041", "localhost:9042"}).Array(),
 			tls.FieldSpec(),
 			sasl.FieldSpec(),
-			docs.FieldCommon("topic", "The topic to publish messages to.").IsInterpolated(),
+			docs.FieldCommon("topic", "The topic to publish messages to.", "foo", `${! meta("tenant") }_events`).IsInterpolated(),
 			docs.FieldCommon("client_id", "An identifier for the client connection."),
 			docs.FieldCommon("key", "The key to publish messages with.").IsInterpolated(),
 			docs.FieldCommon("partitioner", "The partitioning algorithm to use.").HasOptions("fnv1a_hash", "murmur2_hash", "random", "round_robin"),
-			docs.FieldCommon("compression", "The compression algorithm to use.").HasOptions("none", "snappy", "lz4", "gzip"),
+			docs.FieldCommon("compression", "The compression algorithm to use. The `zstd` algorithm requires a `target_version` of at least `2.1.0`.").HasOptions("none", "snappy", "lz4", "gzip", "zstd"),
 			docs.FieldCommon("static_headers", "An optional map of static headers that should be added to messages in addition to metadata.", map[string]string{"first-static-header": "value-1", "second-static-header": "value-2"}).Map(),
+			docs.FieldCommon("headers", "An optional map of headers to add to messages, where values are interpolated per message. Headers set here are added after those derived from metadata and `static_headers`.", map[string]string{"tenant": `${! meta("tenant") }`, "trace": `${! meta("trace_b64").decode("base64") }`}).IsInterpolated().Map().AtVersion("3.44.0"),
 			docs.FieldCommon("metadata", "Specify criteria for which metadata values are sent with messages as headers.").WithChildren(output.MetadataFields()...),
 			docs.FieldCommon("max_in_flight", "The maximum number of parallel message batches to have in flight at any given time."),
 			docs.FieldAdvanced("ack_replicas", "Ensure that messages have been copied across all replicas before acknowledging receipt."),
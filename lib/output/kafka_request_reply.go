@@ -0,0 +1,465 @@
+package output
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/component/output"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/roundtrip"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/kafka/sasl"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"github.com/Shopify/sarama"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeKafkaRequestReply] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			k, err := newKafkaRequestReplyWriter(conf.KafkaRequestReply, mgr, log)
+			if err != nil {
+				return nil, err
+			}
+			return NewAsyncWriter(TypeKafkaRequestReply, conf.KafkaRequestReply.MaxInFlight, k, log, stats)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `
+Sends messages as requests to a Kafka topic and waits for replies on a reply
+topic, which are returned to the input origin of the messages as
+[synchronous responses](/docs/guides/sync_responses).`,
+		Description: `
+Each request is sent with a header containing a correlation ID and a header
+containing the reply topic, and a request is considered delivered once a reply
+with the same correlation ID header is consumed from the reply topic. When a
+reply is not received within the period ` + "`timeout`" + ` the request is
+failed, and replies that are received after that period are ignored.
+
+Replies are returned to the origin of the messages in the same way as the
+` + "[`sync_response` output](/docs/components/outputs/sync_response)" + `,
+where the headers of each reply are added as metadata. This makes it possible
+to bridge HTTP requests received by the
+` + "[`http_server` input](/docs/components/inputs/http_server)" + ` to a
+service that communicates over Kafka:
+
+` + "```yaml" + `
+input:
+  http_server:
+    path: /rpc
+
+output:
+  kafka_request_reply:
+    addresses: [ localhost:9092 ]
+    request_topic: rpc_requests
+    reply_topic: rpc_replies_a
+    timeout: 10s
+` + "```" + `
+
+Replies are consumed from every partition of the reply topic starting from the
+newest offset, and since each instance ignores replies that it doesn't expect
+each instance should have a reply topic of its own.
+
+### Responding
+
+A responder can be built with the ` + "[`kafka` input](/docs/components/inputs/kafka)" + `
+and output, where the request headers are available as metadata and the
+correlation ID header is sent back with the reply:
+
+` + "```yaml" + `
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ rpc_requests ]
+    consumer_group: rpc_responder
+
+pipeline:
+  processors:
+    - bloblang: 'root.result = this.value * 2'
+
+output:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topic: ${! meta("reply_topic") }
+    metadata:
+      exclude_prefixes: [ kafka_, reply_topic ]
+` + "```" + `
+
+The responder relies on the metadata of each request being kept by its
+processors, as the reply is sent to the topic within the ` + "`reply_topic`" + `
+metadata field and the ` + "`correlation_id`" + ` metadata field is sent back as a
+header.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("addresses", "A list of broker addresses to connect to. If an item of the list contains commas it will be expanded into multiple addresses.", []string{"localhost:9092"}, []string{"localhost:9041,localhost:9042"}).Array(),
+			btls.FieldSpec(),
+			sasl.FieldSpec(),
+			docs.FieldCommon("request_topic", "The topic to send requests to.", "rpc_requests", `${! meta("service") }_requests`).IsInterpolated(),
+			docs.FieldCommon("reply_topic", "The topic to consume replies from, which is sent with each request in the header `reply_topic_header`."),
+			docs.FieldCommon("timeout", "The maximum period to wait for the reply of a request."),
+			docs.FieldCommon("key", "The key to send requests with.").IsInterpolated(),
+			docs.FieldAdvanced("correlation_id", "The correlation ID of each request, which must be unique.").IsInterpolated(),
+			docs.FieldAdvanced("correlation_id_header", "The header of requests and replies that contains the correlation ID."),
+			docs.FieldAdvanced("reply_topic_header", "The header of requests that contains the reply topic."),
+			docs.FieldCommon("metadata", "Specify criteria for which metadata values are sent with requests as headers.").WithChildren(output.MetadataFields()...),
+			docs.FieldAdvanced("client_id", "An identifier for the client connection."),
+			docs.FieldAdvanced("target_version", "The version of the Kafka protocol to use."),
+			docs.FieldCommon("max_in_flight", "The maximum number of requests to have in flight at a given time. Increase this to improve throughput."),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// KafkaRequestReplyConfig contains configuration fields for the
+// kafka_request_reply output type.
+type KafkaRequestReplyConfig struct {
+	Addresses           []string        `json:"addresses" yaml:"addresses"`
+	TLS                 btls.Config     `json:"tls" yaml:"tls"`
+	SASL                sasl.Config     `json:"sasl" yaml:"sasl"`
+	RequestTopic        string          `json:"request_topic" yaml:"request_topic"`
+	ReplyTopic          string          `json:"reply_topic" yaml:"reply_topic"`
+	Timeout             string          `json:"timeout" yaml:"timeout"`
+	Key                 string          `json:"key" yaml:"key"`
+	CorrelationID       string          `json:"correlation_id" yaml:"correlation_id"`
+	CorrelationIDHeader string          `json:"correlation_id_header" yaml:"correlation_id_header"`
+	ReplyTopicHeader    string          `json:"reply_topic_header" yaml:"reply_topic_header"`
+	Metadata            output.Metadata `json:"metadata" yaml:"metadata"`
+	ClientID            string          `json:"client_id" yaml:"client_id"`
+	TargetVersion       string          `json:"target_version" yaml:"target_version"`
+	MaxInFlight         int             `json:"max_in_flight" yaml:"max_in_flight"`
+}
+
+// NewKafkaRequestReplyConfig creates a new KafkaRequestReplyConfig with default
+// values.
+func NewKafkaRequestReplyConfig() KafkaRequestReplyConfig {
+	return KafkaRequestReplyConfig{
+		Addresses:           []string{"localhost:9092"},
+		TLS:                 btls.NewConfig(),
+		SASL:                sasl.NewConfig(),
+		RequestTopic:        "",
+		ReplyTopic:          "",
+		Timeout:             "5s",
+		Key:                 "",
+		CorrelationID:       `${! uuid_v4() }`,
+		CorrelationIDHeader: "correlation_id",
+		ReplyTopicHeader:    "reply_topic",
+		Metadata:            output.NewMetadata(),
+		ClientID:            "benthos_kafka_request_reply",
+		TargetVersion:       sarama.V1_0_0_0.String(),
+		MaxInFlight:         64,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type kafkaRequestReplyWriter struct {
+	conf KafkaRequestReplyConfig
+	mgr  types.Manager
+	log  log.Modular
+
+	addresses     []string
+	timeout       time.Duration
+	version       sarama.KafkaVersion
+	tlsConf       *tls.Config
+	requestTopic  field.Expression
+	key           field.Expression
+	correlationID field.Expression
+	metaFilter    *output.MetadataFilter
+
+	clientsCtor func(config *sarama.Config) (sarama.SyncProducer, sarama.Consumer, error)
+
+	connMut    sync.RWMutex
+	producer   sarama.SyncProducer
+	consumer   sarama.Consumer
+	partitions []sarama.PartitionConsumer
+
+	pendingMut sync.Mutex
+	pending    map[string]chan *sarama.ConsumerMessage
+}
+
+func newKafkaRequestReplyWriter(conf KafkaRequestReplyConfig, mgr types.Manager, log log.Modular) (*kafkaRequestReplyWriter, error) {
+	if conf.RequestTopic == "" {
+		return nil, errors.New("a request_topic is required")
+	}
+	if conf.ReplyTopic == "" {
+		return nil, errors.New("a reply_topic is required")
+	}
+
+	k := &kafkaRequestReplyWriter{
+		conf:    conf,
+		mgr:     mgr,
+		log:     log,
+		pending: map[string]chan *sarama.ConsumerMessage{},
+	}
+	k.clientsCtor = k.newClients
+
+	var err error
+	if k.requestTopic, err = bloblang.NewField(conf.RequestTopic); err != nil {
+		return nil, fmt.Errorf("failed to parse request_topic expression: %v", err)
+	}
+	if k.key, err = bloblang.NewField(conf.Key); err != nil {
+		return nil, fmt.Errorf("failed to parse key expression: %v", err)
+	}
+	if k.correlationID, err = bloblang.NewField(conf.CorrelationID); err != nil {
+		return nil, fmt.Errorf("failed to parse correlation_id expression: %v", err)
+	}
+	if k.metaFilter, err = conf.Metadata.Filter(); err != nil {
+		return nil, fmt.Errorf("failed to construct metadata filter: %w", err)
+	}
+	if k.timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+		return nil, fmt.Errorf("failed to parse timeout string: %v", err)
+	}
+	if k.version, err = sarama.ParseKafkaVersion(conf.TargetVersion); err != nil {
+		return nil, err
+	}
+	if conf.TLS.Enabled {
+		if k.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+	for _, addr := range conf.Addresses {
+		for _, splitAddr := range strings.Split(addr, ",") {
+			if trimmed := strings.TrimSpace(splitAddr); len(trimmed) > 0 {
+				k.addresses = append(k.addresses, trimmed)
+			}
+		}
+	}
+	return k, nil
+}
+
+func (k *kafkaRequestReplyWriter) newClients(config *sarama.Config) (sarama.SyncProducer, sarama.Consumer, error) {
+	producer, err := sarama.NewSyncProducer(k.addresses, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	consumer, err := sarama.NewConsumer(k.addresses, config)
+	if err != nil {
+		producer.Close()
+		return nil, nil, err
+	}
+	return producer, consumer, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext creates a producer for requests and starts consuming
+// replies from each partition of the reply topic.
+func (k *kafkaRequestReplyWriter) ConnectWithContext(ctx context.Context) error {
+	k.connMut.Lock()
+	defer k.connMut.Unlock()
+
+	if k.producer != nil {
+		return nil
+	}
+
+	config := sarama.NewConfig()
+	config.ClientID = k.conf.ClientID
+	config.Version = k.version
+	config.Producer.Return.Errors = true
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForLocal
+	config.Consumer.Offsets.Initial = sarama.OffsetNewest
+	config.Net.TLS.Enable = k.conf.TLS.Enabled
+	if k.conf.TLS.Enabled {
+		config.Net.TLS.Config = k.tlsConf
+	}
+	if err := k.conf.SASL.Apply(k.mgr, config); err != nil {
+		return err
+	}
+
+	producer, consumer, err := k.clientsCtor(config)
+	if err != nil {
+		return err
+	}
+
+	partitionIDs, err := consumer.Partitions(k.conf.ReplyTopic)
+	if err != nil {
+		producer.Close()
+		consumer.Close()
+		return fmt.Errorf("failed to obtain partitions of reply topic: %w", err)
+	}
+
+	var partitions []sarama.PartitionConsumer
+	for _, id := range partitionIDs {
+		pc, err := consumer.ConsumePartition(k.conf.ReplyTopic, id, sarama.OffsetNewest)
+		if err != nil {
+			for _, p := range partitions {
+				p.AsyncClose()
+			}
+			producer.Close()
+			consumer.Close()
+			return fmt.Errorf("failed to consume reply topic partition %v: %w", id, err)
+		}
+		partitions = append(partitions, pc)
+		go k.consumeReplies(pc)
+	}
+
+	k.producer, k.consumer, k.partitions = producer, consumer, partitions
+	k.log.Infof("Sending Kafka requests to addresses %s and consuming replies from topic: %v\n", k.addresses, k.conf.ReplyTopic)
+	return nil
+}
+
+// consumeReplies dispatches the replies of a partition to pending requests.
+func (k *kafkaRequestReplyWriter) consumeReplies(pc sarama.PartitionConsumer) {
+	for reply := range pc.Messages() {
+		var correlationID string
+		for _, h := range reply.Headers {
+			if h != nil && string(h.Key) == k.conf.CorrelationIDHeader {
+				correlationID = string(h.Value)
+				break
+			}
+		}
+
+		k.pendingMut.Lock()
+		replyChan, exists := k.pending[correlationID]
+		delete(k.pending, correlationID)
+		k.pendingMut.Unlock()
+
+		if !exists {
+			k.log.Debugf("Ignoring reply with unexpected correlation ID: %v\n", correlationID)
+			continue
+		}
+		replyChan <- reply
+	}
+}
+
+// WriteWithContext sends each message of a batch as a request and waits for
+// their replies.
+func (k *kafkaRequestReplyWriter) WriteWithContext(ctx context.Context, msg types.Message) error {
+	k.connMut.RLock()
+	producer := k.producer
+	k.connMut.RUnlock()
+
+	if producer == nil {
+		return types.ErrNotConnected
+	}
+
+	correlationIDs := make([]string, msg.Len())
+	replyChans := make([]chan *sarama.ConsumerMessage, msg.Len())
+	requests := make([]*sarama.ProducerMessage, msg.Len())
+
+	defer func() {
+		k.pendingMut.Lock()
+		for _, id := range correlationIDs {
+			delete(k.pending, id)
+		}
+		k.pendingMut.Unlock()
+	}()
+
+	k.pendingMut.Lock()
+	for i := 0; i < msg.Len(); i++ {
+		id := k.correlationID.String(i, msg)
+		if _, exists := k.pending[id]; exists || id == "" {
+			k.pendingMut.Unlock()
+			return fmt.Errorf("correlation ID is empty or already in use: %v", id)
+		}
+		correlationIDs[i] = id
+		replyChans[i] = make(chan *sarama.ConsumerMessage, 1)
+		k.pending[id] = replyChans[i]
+	}
+	k.pendingMut.Unlock()
+
+	for i := 0; i < msg.Len(); i++ {
+		p := msg.Get(i)
+
+		var headers []sarama.RecordHeader
+		k.metaFilter.Iter(p.Metadata(), func(k, v string) error {
+			headers = append(headers, sarama.RecordHeader{
+				Key:   []byte(k),
+				Value: []byte(v),
+			})
+			return nil
+		})
+		headers = append(headers,
+			sarama.RecordHeader{Key: []byte(k.conf.CorrelationIDHeader), Value: []byte(correlationIDs[i])},
+			sarama.RecordHeader{Key: []byte(k.conf.ReplyTopicHeader), Value: []byte(k.conf.ReplyTopic)},
+		)
+
+		requests[i] = &sarama.ProducerMessage{
+			Topic:   k.requestTopic.String(i, msg),
+			Value:   sarama.ByteEncoder(p.Get()),
+			Headers: headers,
+		}
+		if key := k.key.Bytes(i, msg); len(key) > 0 {
+			requests[i].Key = sarama.ByteEncoder(key)
+		}
+	}
+
+	if err := producer.SendMessages(requests); err != nil {
+		return err
+	}
+
+	timeout := time.NewTimer(k.timeout)
+	defer timeout.Stop()
+
+	resParts := make([]types.Part, msg.Len())
+	for i, replyChan := range replyChans {
+		var reply *sarama.ConsumerMessage
+		select {
+		case reply = <-replyChan:
+		case <-timeout.C:
+			return fmt.Errorf("timed out waiting for reply with correlation ID: %v", correlationIDs[i])
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		part := msg.Get(i).Copy()
+		part.Set(reply.Value)
+		meta := part.Metadata()
+		for _, h := range reply.Headers {
+			if h != nil {
+				meta.Set(string(h.Key), string(h.Value))
+			}
+		}
+		meta.Set("kafka_key", string(reply.Key))
+		meta.Set("kafka_topic", reply.Topic)
+		meta.Set("kafka_partition", strconv.Itoa(int(reply.Partition)))
+		meta.Set("kafka_offset", strconv.FormatInt(reply.Offset, 10))
+		resParts[i] = part
+	}
+
+	resMsg := message.New(nil)
+	resMsg.SetAll(resParts)
+	if err := roundtrip.SetAsResponse(resMsg); err != nil && err != roundtrip.ErrNoStore {
+		return err
+	}
+	return nil
+}
+
+// CloseAsync shuts down the output and stops processing messages.
+func (k *kafkaRequestReplyWriter) CloseAsync() {
+	k.connMut.Lock()
+	for _, pc := range k.partitions {
+		pc.AsyncClose()
+	}
+	if k.producer != nil {
+		k.producer.Close()
+		k.producer = nil
+	}
+	if k.consumer != nil {
+		k.consumer.Close()
+		k.consumer = nil
+	}
+	k.partitions = nil
+	k.connMut.Unlock()
+}
+
+// WaitForClose blocks until the output has closed down.
+func (k *kafkaRequestReplyWriter) WaitForClose(timeout time.Duration) error {
+	return nil
+}
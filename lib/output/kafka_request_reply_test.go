@@ -0,0 +1,177 @@
+package output
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/roundtrip"
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// kafkaRequestReplyTestProducer records requests and responds to them by
+// calling a responder function.
+type kafkaRequestReplyTestProducer struct {
+	sarama.SyncProducer
+
+	requests  []*sarama.ProducerMessage
+	responder func(req *sarama.ProducerMessage)
+}
+
+func (p *kafkaRequestReplyTestProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	p.requests = append(p.requests, msgs...)
+	for _, m := range msgs {
+		p.responder(m)
+	}
+	return nil
+}
+
+func (p *kafkaRequestReplyTestProducer) Close() error {
+	return nil
+}
+
+func kafkaRequestReplyTestHeader(m *sarama.ProducerMessage, key string) string {
+	for _, h := range m.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func TestKafkaRequestReply(t *testing.T) {
+	conf := NewKafkaRequestReplyConfig()
+	conf.RequestTopic = `${! meta("service") }_requests`
+	conf.ReplyTopic = "replies"
+	conf.Key = `${! json("id") }`
+
+	k, err := newKafkaRequestReplyWriter(conf, nil, log.Noop())
+	require.NoError(t, err)
+
+	consumer := mocks.NewConsumer(t, nil)
+	consumer.SetTopicMetadata(map[string][]int32{"replies": {0, 1}})
+	pcs := []*mocks.PartitionConsumer{
+		consumer.ExpectConsumePartition("replies", 0, sarama.OffsetNewest),
+		consumer.ExpectConsumePartition("replies", 1, sarama.OffsetNewest),
+	}
+
+	var offset int64
+	producer := &kafkaRequestReplyTestProducer{
+		responder: func(req *sarama.ProducerMessage) {
+			value, _ := req.Value.Encode()
+			offset++
+			// Replies are sent out of order across partitions.
+			pcs[offset%2].YieldMessage(&sarama.ConsumerMessage{
+				Topic:     kafkaRequestReplyTestHeader(req, "reply_topic"),
+				Partition: int32(offset % 2),
+				Offset:    offset,
+				Value:     append([]byte("reply to "), value...),
+				Headers: []*sarama.RecordHeader{
+					{Key: []byte("correlation_id"), Value: []byte(kafkaRequestReplyTestHeader(req, "correlation_id"))},
+					{Key: []byte("status"), Value: []byte("ok")},
+				},
+			})
+		},
+	}
+	k.clientsCtor = func(config *sarama.Config) (sarama.SyncProducer, sarama.Consumer, error) {
+		return producer, consumer, nil
+	}
+
+	require.NoError(t, k.ConnectWithContext(context.Background()))
+	defer k.CloseAsync()
+
+	msg := message.New([][]byte{
+		[]byte(`{"id":"foo"}`),
+		[]byte(`{"id":"bar"}`),
+	})
+	msg.Get(0).Metadata().Set("service", "users")
+	msg.Get(1).Metadata().Set("service", "orders")
+
+	store := roundtrip.NewResultStore()
+	roundtrip.AddResultStore(msg, store)
+
+	require.NoError(t, k.WriteWithContext(context.Background(), msg))
+
+	require.Len(t, producer.requests, 2)
+	assert.Equal(t, "users_requests", producer.requests[0].Topic)
+	assert.Equal(t, "orders_requests", producer.requests[1].Topic)
+	key, _ := producer.requests[0].Key.Encode()
+	assert.Equal(t, "foo", string(key))
+	assert.Equal(t, "users", kafkaRequestReplyTestHeader(producer.requests[0], "service"))
+	assert.Equal(t, "replies", kafkaRequestReplyTestHeader(producer.requests[0], "reply_topic"))
+	assert.NotEqual(t,
+		kafkaRequestReplyTestHeader(producer.requests[0], "correlation_id"),
+		kafkaRequestReplyTestHeader(producer.requests[1], "correlation_id"),
+	)
+
+	results := store.Get()
+	require.Len(t, results, 1)
+	assert.Equal(t, [][]byte{
+		[]byte(`reply to {"id":"foo"}`),
+		[]byte(`reply to {"id":"bar"}`),
+	}, message.GetAllBytes(results[0]))
+	assert.Equal(t, "ok", results[0].Get(0).Metadata().Get("status"))
+	assert.Equal(t, "replies", results[0].Get(1).Metadata().Get("kafka_topic"))
+	assert.Equal(t, "0", results[0].Get(1).Metadata().Get("kafka_partition"))
+}
+
+func TestKafkaRequestReplyTimeout(t *testing.T) {
+	conf := NewKafkaRequestReplyConfig()
+	conf.RequestTopic = "requests"
+	conf.ReplyTopic = "replies"
+	conf.CorrelationID = "foo"
+	conf.Timeout = "10ms"
+
+	k, err := newKafkaRequestReplyWriter(conf, nil, log.Noop())
+	require.NoError(t, err)
+
+	consumer := mocks.NewConsumer(t, nil)
+	consumer.SetTopicMetadata(map[string][]int32{"replies": {0}})
+	pc := consumer.ExpectConsumePartition("replies", 0, sarama.OffsetNewest)
+
+	producer := &kafkaRequestReplyTestProducer{
+		responder: func(req *sarama.ProducerMessage) {},
+	}
+	k.clientsCtor = func(config *sarama.Config) (sarama.SyncProducer, sarama.Consumer, error) {
+		return producer, consumer, nil
+	}
+
+	require.NoError(t, k.ConnectWithContext(context.Background()))
+	defer k.CloseAsync()
+
+	err = k.WriteWithContext(context.Background(), message.New([][]byte{[]byte(`hello`)}))
+	require.EqualError(t, err, "timed out waiting for reply with correlation ID: foo")
+
+	// A late reply is ignored, and the correlation ID can be reused.
+	pc.YieldMessage(&sarama.ConsumerMessage{
+		Value:   []byte("late"),
+		Headers: []*sarama.RecordHeader{{Key: []byte("correlation_id"), Value: []byte("foo")}},
+	})
+	time.Sleep(time.Millisecond * 10)
+
+	producer.responder = func(req *sarama.ProducerMessage) {
+		pc.YieldMessage(&sarama.ConsumerMessage{
+			Value:   []byte("on time"),
+			Headers: []*sarama.RecordHeader{{Key: []byte("correlation_id"), Value: []byte("foo")}},
+		})
+	}
+	require.NoError(t, k.WriteWithContext(context.Background(), message.New([][]byte{[]byte(`hello`)})))
+}
+
+func TestKafkaRequestReplyBadConfig(t *testing.T) {
+	conf := NewKafkaRequestReplyConfig()
+	conf.ReplyTopic = "replies"
+
+	_, err := newKafkaRequestReplyWriter(conf, nil, log.Noop())
+	require.EqualError(t, err, "a request_topic is required")
+
+	conf.RequestTopic = "requests"
+	conf.ReplyTopic = ""
+	_, err = newKafkaRequestReplyWriter(conf, nil, log.Noop())
+	require.EqualError(t, err, "a reply_topic is required")
+}
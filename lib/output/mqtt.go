@@ -3,6 +3,7 @@ package output
 import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -19,7 +20,8 @@ Pushes messages to an MQTT broker.`,
 The ` + "`topic`" + ` field can be dynamically set using function interpolations
 described [here](/docs/configuration/interpolation#bloblang-queries). When sending batched
 messages these interpolations are performed per message part.`,
-		Async: true,
+		Async:   true,
+		Batches: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.", []string{"tcp://localhost:1883"}).Array(),
 			docs.FieldCommon("qos", "The QoS value to set for each message.").HasOptions("0", "1", "2"),
@@ -28,6 +30,7 @@ messages these interpolations are performed per message part.`,
 			docs.FieldAdvanced("user", "A username to connect with."),
 			docs.FieldAdvanced("password", "A password to connect with."),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
 		},
 		Categories: []Category{
 			CategoryServices,
@@ -47,7 +50,7 @@ func NewMQTT(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type
 	if err != nil {
 		return nil, err
 	}
-	return OnlySinglePayloads(a), nil
+	return NewBatcherFromConfig(conf.MQTT.Batching, OnlySinglePayloads(a), mgr, log, stats)
 }
 
 //------------------------------------------------------------------------------
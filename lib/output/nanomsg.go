@@ -3,6 +3,7 @@ package output
 import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -17,13 +18,15 @@ func init() {
 Send messages over a Nanomsg socket.`,
 		Description: `
 Currently only PUSH and PUB sockets are supported.`,
-		Async: true,
+		Async:   true,
+		Batches: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.", []string{"tcp://localhost:5556"}).Array(),
 			docs.FieldCommon("bind", "Whether the URLs listed should be bind (otherwise they are connected to)."),
 			docs.FieldCommon("socket_type", "The socket type to send with.").HasOptions("PUSH", "PUB"),
 			docs.FieldCommon("poll_timeout", "The maximum period of time to wait for a message to send before the request is abandoned and reattempted."),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
 		},
 		Categories: []Category{
 			CategoryNetwork,
@@ -43,7 +46,7 @@ func NewNanomsg(conf Config, mgr types.Manager, log log.Modular, stats metrics.T
 	if err != nil {
 		return nil, err
 	}
-	return OnlySinglePayloads(a), nil
+	return NewBatcherFromConfig(conf.Nanomsg.Batching, OnlySinglePayloads(a), mgr, log, stats)
 }
 
 //------------------------------------------------------------------------------
@@ -3,6 +3,7 @@ package output
 import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -19,7 +20,8 @@ Publish to an NATS subject.`,
 		Description: `
 This output will interpolate functions within the subject field, you
 can find a list of functions [here](/docs/configuration/interpolation#bloblang-queries).`,
-		Async: true,
+		Async:   true,
+		Batches: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon(
 				"urls",
@@ -29,6 +31,7 @@ can find a list of functions [here](/docs/configuration/interpolation#bloblang-q
 			).Array(),
 			docs.FieldCommon("subject", "The subject to publish to.").IsInterpolated(),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
 			tls.FieldSpec(),
 		},
 		Categories: []Category{
@@ -43,10 +46,16 @@ func NewNATS(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type
 	if err != nil {
 		return nil, err
 	}
+	var o Type
 	if conf.NATS.MaxInFlight == 1 {
-		return NewWriter(TypeNATS, w, log, stats)
+		o, err = NewWriter(TypeNATS, w, log, stats)
+	} else {
+		o, err = NewAsyncWriter(TypeNATS, conf.NATS.MaxInFlight, w, log, stats)
 	}
-	return NewAsyncWriter(TypeNATS, conf.NATS.MaxInFlight, w, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewBatcherFromConfig(conf.NATS.Batching, o, mgr, log, stats)
 }
 
 //------------------------------------------------------------------------------
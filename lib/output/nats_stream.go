@@ -3,6 +3,7 @@ package output
 import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -16,7 +17,8 @@ func init() {
 		constructor: fromSimpleConstructor(NewNATSStream),
 		Summary: `
 Publish to a NATS Stream subject.`,
-		Async: true,
+		Async:   true,
+		Batches: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon(
 				"urls",
@@ -28,6 +30,7 @@ Publish to a NATS Stream subject.`,
 			docs.FieldCommon("subject", "The subject to publish to."),
 			docs.FieldCommon("client_id", "The client ID to connect with."),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
 			tls.FieldSpec(),
 		},
 		Categories: []Category{
@@ -48,7 +51,7 @@ func NewNATSStream(conf Config, mgr types.Manager, log log.Modular, stats metric
 	if err != nil {
 		return nil, err
 	}
-	return OnlySinglePayloads(a), nil
+	return NewBatcherFromConfig(conf.NATSStream.Batching, OnlySinglePayloads(a), mgr, log, stats)
 }
 
 //------------------------------------------------------------------------------
@@ -3,6 +3,7 @@ package output
 import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -20,13 +21,15 @@ Publish to an NSQ topic.`,
 The ` + "`topic`" + ` field can be dynamically set using function interpolations
 described [here](/docs/configuration/interpolation#bloblang-queries). When sending
 batched messages these interpolations are performed per message part.`,
-		Async: true,
+		Async:   true,
+		Batches: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("nsqd_tcp_address", "The address of the target NSQD server."),
 			docs.FieldCommon("topic", "The topic to publish to.").IsInterpolated(),
 			docs.FieldCommon("user_agent", "A user agent string to connect with."),
 			tls.FieldSpec(),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
 		},
 		Categories: []Category{
 			CategoryServices,
@@ -42,10 +45,16 @@ func NewNSQ(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type)
 	if err != nil {
 		return nil, err
 	}
+	var o Type
 	if conf.NSQ.MaxInFlight == 1 {
-		return NewWriter(TypeNSQ, w, log, stats)
+		o, err = NewWriter(TypeNSQ, w, log, stats)
+	} else {
+		o, err = NewAsyncWriter(TypeNSQ, conf.NSQ.MaxInFlight, w, log, stats)
 	}
-	return NewAsyncWriter(TypeNSQ, conf.NSQ.MaxInFlight, w, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewBatcherFromConfig(conf.NSQ.Batching, o, mgr, log, stats)
 }
 
 //------------------------------------------------------------------------------
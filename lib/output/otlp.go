@@ -0,0 +1,639 @@
+package output
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpcmeta "google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeOTLP] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			o, err := newOTLPWriter(conf.OTLP, log)
+			if err != nil {
+				return nil, err
+			}
+			a, err := NewAsyncWriter(TypeOTLP, conf.OTLP.MaxInFlight, o, log, stats)
+			if err != nil {
+				return nil, err
+			}
+			return NewBatcherFromConfig(conf.OTLP.Batching, a, mgr, log, stats)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `Sends messages as OpenTelemetry log records or spans to an [OpenTelemetry collector](https://opentelemetry.io/docs/collector/) over OTLP/gRPC.`,
+		Description: `
+The field ` + "`signal`" + ` determines whether messages are sent as log records
+(` + "`logs`" + `) or as spans (` + "`traces`" + `). Each message must be a JSON
+object describing a single log record or span, and messages of another form can
+be converted with a [Bloblang mapping](/docs/guides/bloblang/about) in the field
+` + "`mapping`" + `.
+
+Timestamps can either be a number of nanoseconds since the unix epoch or an
+RFC 3339 formatted string, and trace and span IDs are hex encoded strings.
+Attributes can be any JSON value, where objects and arrays are converted into
+nested attribute values.
+
+### Logs
+
+A log record has the following form, where all fields are optional:
+
+` + "```json" + `
+{
+  "timestamp": 1609459200000000000,
+  "severity_number": 9,
+  "severity_text": "INFO",
+  "body": "user logged in",
+  "attributes": {"user.id":"foo"},
+  "trace_id": "5b8efff798038103d269b633813fc60c",
+  "span_id": "eee19b7ec3c1b174"
+}
+` + "```" + `
+
+When ` + "`timestamp`" + ` is omitted the time at which the record is sent is
+used instead.
+
+### Traces
+
+A span has the following form, where ` + "`trace_id`" + `, ` + "`span_id`" + `,
+` + "`name`" + `, ` + "`start_time`" + ` and ` + "`end_time`" + ` are required:
+
+` + "```json" + `
+{
+  "trace_id": "5b8efff798038103d269b633813fc60c",
+  "span_id": "eee19b7ec3c1b174",
+  "parent_span_id": "eee19b7ec3c1b173",
+  "name": "GET /users",
+  "kind": "server",
+  "start_time": "2021-01-01T00:00:00.000Z",
+  "end_time": "2021-01-01T00:00:00.250Z",
+  "attributes": {"http.status_code":200},
+  "status": {"code":"ok","message":""}
+}
+` + "```" + `
+
+The ` + "`kind`" + ` of a span is one of ` + "`internal`, `server`, `client`, `producer` or `consumer`" + `,
+and the ` + "`code`" + ` of a status is one of ` + "`unset`, `ok` or `error`" + `.
+
+The messages of a batch are sent as a single export request.`,
+		Async:   true,
+		Batches: true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("address", "The address of the collector OTLP/gRPC receiver."),
+			docs.FieldCommon("signal", "The type of signal to send messages as.").HasOptions("logs", "traces"),
+			docs.FieldCommon(
+				"mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) that converts each message into a log record or span object.",
+				`root.body = content().string()
+root.severity_text = meta("level").uppercase()
+root.attributes = meta()`,
+			),
+			docs.FieldCommon(
+				"resource_attributes", "A map of attributes that describe the resource that the log records or spans originate from.",
+				map[string]string{
+					"service.name": "benthos",
+				},
+			).Map(),
+			docs.FieldAdvanced(
+				"headers", "A map of metadata headers to add to each export request.",
+				map[string]string{
+					"authorization": "Bearer foo",
+				},
+			).Map(),
+			docs.FieldAdvanced("timeout", "The maximum period to wait for an export request to complete."),
+			btls.FieldSpec(),
+			docs.FieldCommon("max_in_flight", "The maximum number of export requests to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryNetwork,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// OTLPConfig contains configuration fields for the otlp output type.
+type OTLPConfig struct {
+	Address            string             `json:"address" yaml:"address"`
+	Signal             string             `json:"signal" yaml:"signal"`
+	Mapping            string             `json:"mapping" yaml:"mapping"`
+	ResourceAttributes map[string]string  `json:"resource_attributes" yaml:"resource_attributes"`
+	Headers            map[string]string  `json:"headers" yaml:"headers"`
+	Timeout            string             `json:"timeout" yaml:"timeout"`
+	TLS                btls.Config        `json:"tls" yaml:"tls"`
+	MaxInFlight        int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching           batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewOTLPConfig creates a new OTLPConfig with default values.
+func NewOTLPConfig() OTLPConfig {
+	return OTLPConfig{
+		Address:            "localhost:4317",
+		Signal:             "logs",
+		Mapping:            "",
+		ResourceAttributes: map[string]string{},
+		Headers:            map[string]string{},
+		Timeout:            "5s",
+		TLS:                btls.NewConfig(),
+		MaxInFlight:        1,
+		Batching:           batch.NewPolicyConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	otlpLogsExportMethod   = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+	otlpTracesExportMethod = "/opentelemetry.proto.collector.trace.v1.TraceService/Export"
+)
+
+// otlpRawCodec passes pre-encoded protobuf messages straight through to gRPC.
+type otlpRawCodec struct{}
+
+func (otlpRawCodec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type: %T", v)
+	}
+	return b, nil
+}
+
+func (otlpRawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unexpected message type: %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (otlpRawCodec) Name() string {
+	return "proto"
+}
+
+//------------------------------------------------------------------------------
+
+type otlpWriter struct {
+	conf     OTLPConfig
+	log      log.Modular
+	mapping  *mapping.Executor
+	tlsConf  *tls.Config
+	timeout  time.Duration
+	method   string
+	encodeFn func(obj map[string]interface{}) ([]byte, error)
+	resource []byte
+
+	nowFn func() time.Time
+
+	connMut sync.RWMutex
+	conn    *grpc.ClientConn
+}
+
+func newOTLPWriter(conf OTLPConfig, log log.Modular) (*otlpWriter, error) {
+	o := &otlpWriter{
+		conf:  conf,
+		log:   log,
+		nowFn: time.Now,
+	}
+
+	switch conf.Signal {
+	case "logs":
+		o.method = otlpLogsExportMethod
+		o.encodeFn = o.encodeLogRecord
+	case "traces":
+		o.method = otlpTracesExportMethod
+		o.encodeFn = o.encodeSpan
+	default:
+		return nil, fmt.Errorf("unrecognised signal: %v", conf.Signal)
+	}
+
+	var err error
+	if conf.Mapping != "" {
+		if o.mapping, err = bloblang.NewMapping("", conf.Mapping); err != nil {
+			return nil, fmt.Errorf("failed to parse mapping: %w", err)
+		}
+	}
+	if conf.Timeout != "" {
+		if o.timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse timeout string: %v", err)
+		}
+	}
+	if conf.TLS.Enabled {
+		if o.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+
+	resAttrs := make(map[string]interface{}, len(conf.ResourceAttributes))
+	for k, v := range conf.ResourceAttributes {
+		resAttrs[k] = v
+	}
+	if o.resource, err = otlpAppendAttributes(nil, 1, resAttrs); err != nil {
+		return nil, fmt.Errorf("failed to encode resource attributes: %w", err)
+	}
+	return o, nil
+}
+
+// ConnectWithContext attempts to establish a connection to the collector.
+func (o *otlpWriter) ConnectWithContext(ctx context.Context) error {
+	o.connMut.Lock()
+	defer o.connMut.Unlock()
+
+	if o.conn != nil {
+		return nil
+	}
+
+	opts := []grpc.DialOption{grpc.WithInsecure()}
+	if o.tlsConf != nil {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(o.tlsConf))}
+	}
+
+	conn, err := grpc.DialContext(ctx, o.conf.Address, opts...)
+	if err != nil {
+		return err
+	}
+	o.conn = conn
+
+	o.log.Infof("Sending OTLP %v to collector: %v\n", o.conf.Signal, o.conf.Address)
+	return nil
+}
+
+// WriteWithContext sends the messages of a batch as a single export request.
+func (o *otlpWriter) WriteWithContext(ctx context.Context, msg types.Message) error {
+	o.connMut.RLock()
+	conn := o.conn
+	o.connMut.RUnlock()
+
+	if conn == nil {
+		return types.ErrNotConnected
+	}
+
+	req, err := o.exportRequest(msg)
+	if err != nil {
+		o.log.Errorf("Failed to convert messages to OTLP %v: %v\n", o.conf.Signal, err)
+		return err
+	}
+
+	if len(o.conf.Headers) > 0 {
+		ctx = grpcmeta.NewOutgoingContext(ctx, grpcmeta.New(o.conf.Headers))
+	}
+	if o.timeout > 0 {
+		var done func()
+		ctx, done = context.WithTimeout(ctx, o.timeout)
+		defer done()
+	}
+
+	var res []byte
+	return conn.Invoke(ctx, o.method, req, &res, grpc.ForceCodec(otlpRawCodec{}))
+}
+
+// CloseAsync shuts down the output and stops processing messages.
+func (o *otlpWriter) CloseAsync() {
+	o.connMut.Lock()
+	if o.conn != nil {
+		o.conn.Close()
+		o.conn = nil
+	}
+	o.connMut.Unlock()
+}
+
+// WaitForClose blocks until the output has closed down.
+func (o *otlpWriter) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// exportRequest encodes the messages of a batch as an export request, where
+// all records share a single resource and instrumentation scope.
+func (o *otlpWriter) exportRequest(msg types.Message) ([]byte, error) {
+	var scope []byte
+	scope = otlpAppendBytes(scope, 1, otlpAppendString(nil, 1, "benthos"))
+	for i := 0; i < msg.Len(); i++ {
+		part := msg.Get(i)
+		if o.mapping != nil {
+			var err error
+			if part, err = o.mapping.MapPart(i, msg); err != nil {
+				return nil, fmt.Errorf("failed to execute mapping: %w", err)
+			}
+		}
+		jv, err := part.JSON()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse message: %w", err)
+		}
+		obj, ok := jv.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected message to be an object, got %T", jv)
+		}
+		record, err := o.encodeFn(obj)
+		if err != nil {
+			return nil, err
+		}
+		scope = otlpAppendBytes(scope, 2, record)
+	}
+
+	var resourceRecords []byte
+	resourceRecords = otlpAppendBytes(resourceRecords, 1, o.resource)
+	resourceRecords = otlpAppendBytes(resourceRecords, 2, scope)
+	return otlpAppendBytes(nil, 1, resourceRecords), nil
+}
+
+func (o *otlpWriter) encodeLogRecord(obj map[string]interface{}) ([]byte, error) {
+	var b []byte
+
+	ts := uint64(o.nowFn().UnixNano())
+	if v, exists := obj["timestamp"]; exists {
+		var err error
+		if ts, err = otlpTimestamp(v); err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+	}
+	b = protowire.AppendTag(b, 1, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, ts)
+
+	if v, exists := obj["severity_number"]; exists {
+		n, err := query.IGetInt(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse severity_number: %w", err)
+		}
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(n))
+	}
+	if v, exists := obj["severity_text"]; exists {
+		b = otlpAppendString(b, 3, query.IToString(v))
+	}
+	if v, exists := obj["body"]; exists {
+		anyV, err := otlpAnyValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode body: %w", err)
+		}
+		b = otlpAppendBytes(b, 5, anyV)
+	}
+
+	var err error
+	if b, err = otlpAppendAttributesField(b, 6, obj["attributes"]); err != nil {
+		return nil, err
+	}
+	if b, err = otlpAppendID(b, 9, obj, "trace_id", 16); err != nil {
+		return nil, err
+	}
+	if b, err = otlpAppendID(b, 10, obj, "span_id", 8); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+var otlpSpanKinds = map[string]uint64{
+	"unspecified": 0,
+	"internal":    1,
+	"server":      2,
+	"client":      3,
+	"producer":    4,
+	"consumer":    5,
+}
+
+var otlpStatusCodes = map[string]uint64{
+	"unset": 0,
+	"ok":    1,
+	"error": 2,
+}
+
+func (o *otlpWriter) encodeSpan(obj map[string]interface{}) ([]byte, error) {
+	var b []byte
+	var err error
+
+	for _, k := range []string{"trace_id", "span_id", "name", "start_time", "end_time"} {
+		if _, exists := obj[k]; !exists {
+			return nil, fmt.Errorf("span is missing field: %v", k)
+		}
+	}
+
+	if b, err = otlpAppendID(b, 1, obj, "trace_id", 16); err != nil {
+		return nil, err
+	}
+	if b, err = otlpAppendID(b, 2, obj, "span_id", 8); err != nil {
+		return nil, err
+	}
+	if b, err = otlpAppendID(b, 4, obj, "parent_span_id", 8); err != nil {
+		return nil, err
+	}
+	b = otlpAppendString(b, 5, query.IToString(obj["name"]))
+
+	if v, exists := obj["kind"]; exists {
+		kind, err := otlpEnum(v, otlpSpanKinds)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse span kind: %w", err)
+		}
+		b = protowire.AppendTag(b, 6, protowire.VarintType)
+		b = protowire.AppendVarint(b, kind)
+	}
+
+	for _, f := range []struct {
+		num  protowire.Number
+		name string
+	}{{7, "start_time"}, {8, "end_time"}} {
+		ts, err := otlpTimestamp(obj[f.name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %v: %w", f.name, err)
+		}
+		b = protowire.AppendTag(b, f.num, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, ts)
+	}
+
+	if b, err = otlpAppendAttributesField(b, 9, obj["attributes"]); err != nil {
+		return nil, err
+	}
+
+	if v, exists := obj["status"]; exists {
+		statusObj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected status to be an object, got %T", v)
+		}
+		var status []byte
+		if msg, exists := statusObj["message"]; exists {
+			status = otlpAppendString(status, 2, query.IToString(msg))
+		}
+		if c, exists := statusObj["code"]; exists {
+			code, err := otlpEnum(c, otlpStatusCodes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse status code: %w", err)
+			}
+			status = protowire.AppendTag(status, 3, protowire.VarintType)
+			status = protowire.AppendVarint(status, code)
+		}
+		b = otlpAppendBytes(b, 15, status)
+	}
+	return b, nil
+}
+
+//------------------------------------------------------------------------------
+
+func otlpAppendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func otlpAppendString(b []byte, num protowire.Number, v string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func otlpAppendID(b []byte, num protowire.Number, obj map[string]interface{}, key string, size int) ([]byte, error) {
+	v, exists := obj[key]
+	if !exists {
+		return b, nil
+	}
+	id, err := hex.DecodeString(query.IToString(v))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %v: %w", key, err)
+	}
+	if len(id) != size {
+		return nil, fmt.Errorf("expected %v to be %v bytes, got %v", key, size, len(id))
+	}
+	return otlpAppendBytes(b, num, id), nil
+}
+
+func otlpEnum(v interface{}, values map[string]uint64) (uint64, error) {
+	if s, ok := v.(string); ok {
+		n, exists := values[strings.ToLower(s)]
+		if !exists {
+			return 0, fmt.Errorf("unrecognised value: %v", s)
+		}
+		return n, nil
+	}
+	n, err := query.IGetInt(v)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(n), nil
+}
+
+func otlpTimestamp(v interface{}) (uint64, error) {
+	if s, ok := v.(string); ok {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return 0, err
+		}
+		return uint64(t.UnixNano()), nil
+	}
+	n, err := query.IGetInt(v)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(n), nil
+}
+
+func otlpAppendAttributesField(b []byte, num protowire.Number, v interface{}) ([]byte, error) {
+	if v == nil {
+		return b, nil
+	}
+	attrs, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected attributes to be an object, got %T", v)
+	}
+	b, err := otlpAppendAttributes(b, num, attrs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode attributes: %w", err)
+	}
+	return b, nil
+}
+
+// otlpAppendAttributes appends each attribute as a KeyValue field, sorted by
+// key.
+func otlpAppendAttributes(b []byte, num protowire.Number, attrs map[string]interface{}) ([]byte, error) {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		anyV, err := otlpAnyValue(attrs[k])
+		if err != nil {
+			return nil, err
+		}
+		var kv []byte
+		kv = otlpAppendString(kv, 1, k)
+		kv = otlpAppendBytes(kv, 2, anyV)
+		b = otlpAppendBytes(b, num, kv)
+	}
+	return b, nil
+}
+
+// otlpAnyValue encodes a structured value as an AnyValue message.
+func otlpAnyValue(v interface{}) ([]byte, error) {
+	var b []byte
+	switch t := v.(type) {
+	case nil:
+	case string:
+		b = otlpAppendString(b, 1, t)
+	case bool:
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(t))
+	case int:
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(t))
+	case int64:
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(t))
+	case uint64:
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, t)
+	case float64:
+		b = protowire.AppendTag(b, 4, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(t))
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return otlpAnyValue(i)
+		}
+		f, err := t.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return otlpAnyValue(f)
+	case []byte:
+		b = otlpAppendBytes(b, 7, t)
+	case []interface{}:
+		var arr []byte
+		for _, e := range t {
+			eV, err := otlpAnyValue(e)
+			if err != nil {
+				return nil, err
+			}
+			arr = otlpAppendBytes(arr, 1, eV)
+		}
+		b = otlpAppendBytes(b, 5, arr)
+	case map[string]interface{}:
+		kvList, err := otlpAppendAttributes(nil, 1, t)
+		if err != nil {
+			return nil, err
+		}
+		b = otlpAppendBytes(b, 6, kvList)
+	default:
+		return nil, fmt.Errorf("unsupported value type: %T", v)
+	}
+	return b, nil
+}
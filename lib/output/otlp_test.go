@@ -0,0 +1,203 @@
+package output
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	grpcmeta "google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type otlpTestServerCodec struct {
+	otlpRawCodec
+}
+
+func (otlpTestServerCodec) String() string {
+	return "proto"
+}
+
+type otlpTestRequest struct {
+	method string
+	auth   []string
+	body   []byte
+}
+
+func otlpTestServer(t *testing.T) (string, <-chan otlpTestRequest) {
+	t.Helper()
+
+	reqChan := make(chan otlpTestRequest, 10)
+	srv := grpc.NewServer(
+		grpc.CustomCodec(otlpTestServerCodec{}),
+		grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+			var req otlpTestRequest
+			req.method, _ = grpc.MethodFromServerStream(stream)
+			md, _ := grpcmeta.FromIncomingContext(stream.Context())
+			req.auth = md.Get("authorization")
+			if err := stream.RecvMsg(&req.body); err != nil {
+				return err
+			}
+			reqChan <- req
+			return stream.SendMsg([]byte{})
+		}),
+	)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = srv.Serve(ln)
+	}()
+	t.Cleanup(srv.Stop)
+	return ln.Addr().String(), reqChan
+}
+
+// otlpTestFields decodes the top level fields of a protobuf message.
+func otlpTestFields(t *testing.T, b []byte) map[protowire.Number][]interface{} {
+	t.Helper()
+
+	fields := map[protowire.Number][]interface{}{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.True(t, n > 0)
+		b = b[n:]
+
+		var v interface{}
+		switch typ {
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			v, n = protowire.ConsumeFixed64(b)
+		default:
+			t.Fatalf("unexpected wire type: %v", typ)
+		}
+		require.True(t, n > 0)
+		b = b[n:]
+		fields[num] = append(fields[num], v)
+	}
+	return fields
+}
+
+func TestOTLPLogs(t *testing.T) {
+	addr, reqChan := otlpTestServer(t)
+
+	conf := NewOTLPConfig()
+	conf.Address = addr
+	conf.Mapping = `root.body = this.msg
+root.severity_text = meta("level")
+root.attributes.user = this.user
+root.trace_id = "5b8efff798038103d269b633813fc60c"`
+	conf.ResourceAttributes = map[string]string{"service.name": "foo"}
+	conf.Headers = map[string]string{"authorization": "Bearer bar"}
+
+	o, err := newOTLPWriter(conf, log.Noop())
+	require.NoError(t, err)
+	o.nowFn = func() time.Time {
+		return time.Unix(0, 5)
+	}
+	require.NoError(t, o.ConnectWithContext(context.Background()))
+	defer o.CloseAsync()
+
+	msg := message.New([][]byte{
+		[]byte(`{"msg":"hello","user":"alice"}`),
+		[]byte(`{"msg":"world","user":"bob"}`),
+	})
+	msg.Get(0).Metadata().Set("level", "INFO")
+	msg.Get(1).Metadata().Set("level", "WARN")
+	require.NoError(t, o.WriteWithContext(context.Background(), msg))
+
+	var req otlpTestRequest
+	select {
+	case req = <-reqChan:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	assert.Equal(t, otlpLogsExportMethod, req.method)
+	assert.Equal(t, []string{"Bearer bar"}, req.auth)
+
+	resourceLogs := otlpTestFields(t, req.body)[1]
+	require.Len(t, resourceLogs, 1)
+	rlFields := otlpTestFields(t, resourceLogs[0].([]byte))
+
+	resource := otlpTestFields(t, rlFields[1][0].([]byte))
+	resAttr := otlpTestFields(t, resource[1][0].([]byte))
+	assert.Equal(t, []byte("service.name"), resAttr[1][0])
+
+	scopeLogs := otlpTestFields(t, rlFields[2][0].([]byte))
+	records := scopeLogs[2]
+	require.Len(t, records, 2)
+
+	first := otlpTestFields(t, records[0].([]byte))
+	assert.Equal(t, []interface{}{uint64(5)}, first[1])
+	assert.Equal(t, []interface{}{[]byte("INFO")}, first[3])
+	assert.Equal(t, []interface{}{otlpAppendString(nil, 1, "hello")}, first[5])
+	assert.Len(t, first[9][0], 16)
+
+	second := otlpTestFields(t, records[1].([]byte))
+	assert.Equal(t, []interface{}{[]byte("WARN")}, second[3])
+	attr := otlpTestFields(t, second[6][0].([]byte))
+	assert.Equal(t, []interface{}{[]byte("user")}, attr[1])
+	assert.Equal(t, []interface{}{otlpAppendString(nil, 1, "bob")}, attr[2])
+}
+
+func TestOTLPSpans(t *testing.T) {
+	conf := NewOTLPConfig()
+	conf.Signal = "traces"
+
+	o, err := newOTLPWriter(conf, log.Noop())
+	require.NoError(t, err)
+
+	span, err := o.encodeSpan(map[string]interface{}{
+		"trace_id":   "5b8efff798038103d269b633813fc60c",
+		"span_id":    "eee19b7ec3c1b174",
+		"name":       "foo",
+		"kind":       "client",
+		"start_time": "2021-01-01T00:00:00Z",
+		"end_time":   int64(1609459200250000000),
+		"status":     map[string]interface{}{"code": "error", "message": "bar"},
+	})
+	require.NoError(t, err)
+
+	fields := otlpTestFields(t, span)
+	assert.Len(t, fields[1][0], 16)
+	assert.Len(t, fields[2][0], 8)
+	assert.Nil(t, fields[4])
+	assert.Equal(t, []interface{}{[]byte("foo")}, fields[5])
+	assert.Equal(t, []interface{}{uint64(3)}, fields[6])
+	assert.Equal(t, []interface{}{uint64(1609459200000000000)}, fields[7])
+	assert.Equal(t, []interface{}{uint64(1609459200250000000)}, fields[8])
+
+	status := otlpTestFields(t, fields[15][0].([]byte))
+	assert.Equal(t, []interface{}{[]byte("bar")}, status[2])
+	assert.Equal(t, []interface{}{uint64(2)}, status[3])
+
+	_, err = o.encodeSpan(map[string]interface{}{
+		"trace_id": "5b8efff798038103d269b633813fc60c",
+		"span_id":  "eee19b7ec3c1b174",
+	})
+	require.EqualError(t, err, "span is missing field: name")
+
+	_, err = o.encodeSpan(map[string]interface{}{
+		"trace_id":   "5b8e",
+		"span_id":    "eee19b7ec3c1b174",
+		"name":       "foo",
+		"start_time": 1,
+		"end_time":   2,
+	})
+	require.EqualError(t, err, "expected trace_id to be 16 bytes, got 2")
+}
+
+func TestOTLPBadSignal(t *testing.T) {
+	conf := NewOTLPConfig()
+	conf.Signal = "metrics"
+
+	_, err := newOTLPWriter(conf, log.Noop())
+	require.EqualError(t, err, "unrecognised signal: metrics")
+}
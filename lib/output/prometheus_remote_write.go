@@ -0,0 +1,344 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/http/client"
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypePrometheusRemoteWrite] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			p, err := newPrometheusRemoteWriter(conf.PrometheusRemoteWrite, mgr, log, stats)
+			if err != nil {
+				return nil, err
+			}
+			a, err := NewAsyncWriter(TypePrometheusRemoteWrite, conf.PrometheusRemoteWrite.MaxInFlight, p, log, stats)
+			if err != nil {
+				return nil, err
+			}
+			return NewBatcherFromConfig(conf.PrometheusRemoteWrite.Batching, a, mgr, log, stats)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `Sends metric samples to a Prometheus [remote write](https://prometheus.io/docs/prometheus/latest/configuration/configuration/#remote_write) endpoint, such as Cortex, Thanos or VictoriaMetrics.`,
+		Description: `
+Each message is a single sample of a metric, which must be a JSON object of the
+following form:
+
+` + "```json" + `
+{"name":"http_requests_total","labels":{"method":"GET","code":"200"},"value":1027,"timestamp":1609459200000}
+` + "```" + `
+
+The ` + "`timestamp`" + ` is a unix timestamp in milliseconds, and when it is
+omitted the time at which the message is sent is used instead. Messages of
+another form can be converted with a [Bloblang mapping](/docs/guides/bloblang/about)
+in the field ` + "`mapping`" + `:
+
+` + "```yaml" + `
+output:
+  prometheus_remote_write:
+    url: http://localhost:9090/api/v1/write
+    mapping: |
+      root.name = "sensor_temperature_celsius"
+      root.labels.sensor = this.sensor_id
+      root.labels.site = meta("site")
+      root.value = this.reading
+      root.timestamp = (this.time.parse_timestamp_unix() * 1000).floor()
+    batching:
+      count: 500
+      period: 1s
+` + "```" + `
+
+The messages of a batch are sent as a single request, where samples of the same
+series (a metric name and set of labels) are grouped together and ordered by
+their timestamps. Requests are encoded as snappy compressed protobuf.
+
+### Retries
+
+Following the remote write protocol, requests that fail with a status code
+listed in ` + "`backoff_on`" + ` (a ` + "`429`" + ` or ` + "`5xx`" + ` by
+default) are retried with a backoff, whereas requests that fail with a status
+code listed in ` + "`drop_on`" + ` (a ` + "`400`" + ` by default, which is
+returned for samples that are out of order or malformed) are not retried by the
+client. In either case the batch is reported as failed once the client gives
+up.`,
+		Async:   true,
+		Batches: true,
+		FieldSpecs: client.FieldSpecs().Add(
+			docs.FieldCommon(
+				"mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) that converts each message into a sample object.",
+				`root.name = this.metric
+root.labels = this.tags
+root.value = this.value`,
+			),
+			docs.FieldCommon("max_in_flight", "The maximum number of requests to have in flight at a given time. Increase this to improve throughput."),
+		).Add(batch.FieldSpec()),
+		Categories: []Category{
+			CategoryNetwork,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// PrometheusRemoteWriteConfig contains configuration fields for the
+// prometheus_remote_write output type.
+type PrometheusRemoteWriteConfig struct {
+	client.Config `json:",inline" yaml:",inline"`
+	Mapping       string             `json:"mapping" yaml:"mapping"`
+	MaxInFlight   int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching      batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewPrometheusRemoteWriteConfig creates a new PrometheusRemoteWriteConfig with
+// default values.
+func NewPrometheusRemoteWriteConfig() PrometheusRemoteWriteConfig {
+	cConf := client.NewConfig()
+	cConf.URL = "http://localhost:9090/api/v1/write"
+	cConf.Headers = map[string]string{
+		"Content-Type":                      "application/x-protobuf",
+		"Content-Encoding":                  "snappy",
+		"X-Prometheus-Remote-Write-Version": "0.1.0",
+	}
+	cConf.BackoffOn = []int{429, 500, 502, 503, 504}
+	cConf.DropOn = []int{400}
+	return PrometheusRemoteWriteConfig{
+		Config:      cConf,
+		Mapping:     "",
+		MaxInFlight: 1,
+		Batching:    batch.NewPolicyConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type promLabel struct {
+	name, value string
+}
+
+type promSample struct {
+	value     float64
+	timestamp int64
+}
+
+type promSeries struct {
+	labels  []promLabel
+	samples []promSample
+}
+
+type prometheusRemoteWriter struct {
+	conf    PrometheusRemoteWriteConfig
+	mapping *mapping.Executor
+	client  *client.Type
+	log     log.Modular
+
+	nowFn     func() time.Time
+	closeChan chan struct{}
+}
+
+func newPrometheusRemoteWriter(conf PrometheusRemoteWriteConfig, mgr types.Manager, log log.Modular, stats metrics.Type) (*prometheusRemoteWriter, error) {
+	p := &prometheusRemoteWriter{
+		conf:      conf,
+		log:       log,
+		nowFn:     time.Now,
+		closeChan: make(chan struct{}),
+	}
+
+	var err error
+	if conf.Mapping != "" {
+		if p.mapping, err = bloblang.NewMapping("", conf.Mapping); err != nil {
+			return nil, fmt.Errorf("failed to parse mapping: %w", err)
+		}
+	}
+	if p.client, err = client.New(
+		conf.Config,
+		client.OptSetCloseChan(p.closeChan),
+		client.OptSetLogger(log),
+		client.OptSetManager(mgr),
+		client.OptSetStats(metrics.Namespaced(stats, "client")),
+	); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ConnectWithContext does nothing.
+func (p *prometheusRemoteWriter) ConnectWithContext(ctx context.Context) error {
+	p.log.Infof("Sending metric samples via remote write to: %s\n", p.conf.URL)
+	return nil
+}
+
+// toSample extracts the labels and sample of a message.
+func (p *prometheusRemoteWriter) toSample(i int, msg types.Message) ([]promLabel, promSample, error) {
+	part := msg.Get(i)
+	if p.mapping != nil {
+		var err error
+		if part, err = p.mapping.MapPart(i, msg); err != nil {
+			return nil, promSample{}, fmt.Errorf("failed to execute mapping: %w", err)
+		}
+	}
+
+	jv, err := part.JSON()
+	if err != nil {
+		return nil, promSample{}, fmt.Errorf("failed to parse sample: %w", err)
+	}
+	obj, ok := jv.(map[string]interface{})
+	if !ok {
+		return nil, promSample{}, fmt.Errorf("expected sample to be an object, got %T", jv)
+	}
+
+	name, _ := obj["name"].(string)
+	if name == "" {
+		return nil, promSample{}, errors.New("sample is missing a name")
+	}
+	labels := []promLabel{{name: "__name__", value: name}}
+	if labelsObj, exists := obj["labels"]; exists {
+		lObj, ok := labelsObj.(map[string]interface{})
+		if !ok {
+			return nil, promSample{}, fmt.Errorf("expected labels to be an object, got %T", labelsObj)
+		}
+		for k, v := range lObj {
+			if k == "__name__" {
+				continue
+			}
+			labels = append(labels, promLabel{name: k, value: query.IToString(v)})
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].name < labels[j].name
+	})
+
+	var sample promSample
+	if sample.value, err = query.IGetNumber(obj["value"]); err != nil {
+		return nil, promSample{}, fmt.Errorf("failed to parse sample value: %w", err)
+	}
+	if ts, exists := obj["timestamp"]; exists {
+		if sample.timestamp, err = query.IGetInt(ts); err != nil {
+			return nil, promSample{}, fmt.Errorf("failed to parse sample timestamp: %w", err)
+		}
+	} else {
+		sample.timestamp = p.nowFn().UnixNano() / int64(time.Millisecond)
+	}
+	return labels, sample, nil
+}
+
+// toSeries groups the samples of a batch by their series.
+func (p *prometheusRemoteWriter) toSeries(msg types.Message) ([]*promSeries, error) {
+	seriesByKey := map[string]*promSeries{}
+	var series []*promSeries
+	for i := 0; i < msg.Len(); i++ {
+		labels, sample, err := p.toSample(i, msg)
+		if err != nil {
+			return nil, err
+		}
+
+		var keyBuilder strings.Builder
+		for _, l := range labels {
+			keyBuilder.WriteString(l.name)
+			keyBuilder.WriteByte(0xff)
+			keyBuilder.WriteString(l.value)
+			keyBuilder.WriteByte(0xff)
+		}
+		key := keyBuilder.String()
+
+		s, exists := seriesByKey[key]
+		if !exists {
+			s = &promSeries{labels: labels}
+			seriesByKey[key] = s
+			series = append(series, s)
+		}
+		s.samples = append(s.samples, sample)
+	}
+	for _, s := range series {
+		sort.SliceStable(s.samples, func(i, j int) bool {
+			return s.samples[i].timestamp < s.samples[j].timestamp
+		})
+	}
+	return series, nil
+}
+
+// encodeWriteRequest encodes series as a remote write WriteRequest protobuf.
+func encodeWriteRequest(series []*promSeries) []byte {
+	var req []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, lb)
+		}
+		for _, sample := range s.samples {
+			var sb []byte
+			sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+			sb = protowire.AppendFixed64(sb, math.Float64bits(sample.value))
+			sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+			sb = protowire.AppendVarint(sb, uint64(sample.timestamp))
+
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, sb)
+		}
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, ts)
+	}
+	return req
+}
+
+// WriteWithContext sends the samples of a batch as a single remote write
+// request.
+func (p *prometheusRemoteWriter) WriteWithContext(ctx context.Context, msg types.Message) error {
+	series, err := p.toSeries(msg)
+	if err != nil {
+		p.log.Errorf("Failed to convert messages to samples: %v\n", err)
+		return err
+	}
+
+	// The first message is copied so that its metadata remains available to
+	// interpolations of the URL and headers.
+	reqPart := msg.Get(0).Copy()
+	reqPart.Set(snappy.Encode(nil, encodeWriteRequest(series)))
+	reqMsg := message.New(nil)
+	reqMsg.Append(reqPart)
+
+	res, err := p.client.DoWithContext(ctx, reqMsg)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// CloseAsync shuts down the output and stops processing messages.
+func (p *prometheusRemoteWriter) CloseAsync() {
+	close(p.closeChan)
+	p.client.CloseAsync()
+}
+
+// WaitForClose blocks until the output has closed down.
+func (p *prometheusRemoteWriter) WaitForClose(timeout time.Duration) error {
+	return nil
+}
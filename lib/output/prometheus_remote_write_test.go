@@ -0,0 +1,164 @@
+package output
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusRemoteWriteSeries(t *testing.T) {
+	conf := NewPrometheusRemoteWriteConfig()
+	conf.Mapping = `root.name = this.metric
+root.labels = this.tags
+root.labels.host = meta("host")
+root.value = this.value
+root.timestamp = this.ts | deleted()`
+
+	p, err := newPrometheusRemoteWriter(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	p.nowFn = func() time.Time {
+		return time.Unix(10, 0)
+	}
+
+	msg := message.New([][]byte{
+		[]byte(`{"metric":"foo","tags":{"b":"1","a":"2"},"value":3,"ts":2000}`),
+		[]byte(`{"metric":"bar","tags":{},"value":4.5}`),
+		[]byte(`{"metric":"foo","tags":{"a":"2","b":"1"},"value":5,"ts":1000}`),
+		[]byte(`{"metric":"foo","tags":{"a":"2","b":"1"},"value":6,"ts":3000}`),
+	})
+	msg.Iter(func(i int, p types.Part) error {
+		p.Metadata().Set("host", "x")
+		return nil
+	})
+
+	series, err := p.toSeries(msg)
+	require.NoError(t, err)
+	assert.Equal(t, []*promSeries{
+		{
+			labels: []promLabel{
+				{name: "__name__", value: "foo"},
+				{name: "a", value: "2"},
+				{name: "b", value: "1"},
+				{name: "host", value: "x"},
+			},
+			samples: []promSample{
+				{value: 5, timestamp: 1000},
+				{value: 3, timestamp: 2000},
+				{value: 6, timestamp: 3000},
+			},
+		},
+		{
+			labels: []promLabel{
+				{name: "__name__", value: "bar"},
+				{name: "host", value: "x"},
+			},
+			samples: []promSample{
+				{value: 4.5, timestamp: 10000},
+			},
+		},
+	}, series)
+
+	msg = message.New([][]byte{[]byte(`{"metric":"","value":1}`)})
+	msg.Get(0).Metadata().Set("host", "x")
+	_, err = p.toSeries(msg)
+	require.EqualError(t, err, "sample is missing a name")
+}
+
+func TestPrometheusRemoteWriteEncode(t *testing.T) {
+	b := encodeWriteRequest([]*promSeries{
+		{
+			labels:  []promLabel{{name: "__name__", value: "a"}},
+			samples: []promSample{{value: 1, timestamp: 2}},
+		},
+	})
+	assert.Equal(t, []byte{
+		0x0a, 0x1c, // timeseries
+		0x0a, 0x0d, // labels
+		0x0a, 0x08, '_', '_', 'n', 'a', 'm', 'e', '_', '_',
+		0x12, 0x01, 'a',
+		0x12, 0x0b, // samples
+		0x09, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f,
+		0x10, 0x02,
+	}, b)
+}
+
+func TestPrometheusRemoteWriteSend(t *testing.T) {
+	var reqCount int32
+	resultChan := make(chan []byte, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&reqCount, 1) == 1 {
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		resultChan <- b
+	}))
+	defer ts.Close()
+
+	conf := NewPrometheusRemoteWriteConfig()
+	conf.URL = ts.URL
+	conf.Retry = "1ms"
+	conf.BackoffOn = []int{429}
+
+	p, err := newPrometheusRemoteWriter(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer p.CloseAsync()
+
+	require.NoError(t, p.WriteWithContext(context.Background(), message.New([][]byte{
+		[]byte(`{"name":"a","value":1,"timestamp":2}`),
+	})))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&reqCount))
+
+	var body []byte
+	select {
+	case body = <-resultChan:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	decoded, err := snappy.Decode(nil, body)
+	require.NoError(t, err)
+	assert.Equal(t, encodeWriteRequest([]*promSeries{
+		{
+			labels:  []promLabel{{name: "__name__", value: "a"}},
+			samples: []promSample{{value: 1, timestamp: 2}},
+		},
+	}), decoded)
+}
+
+func TestPrometheusRemoteWriteDropOn(t *testing.T) {
+	var reqCount int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&reqCount, 1)
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	conf := NewPrometheusRemoteWriteConfig()
+	conf.URL = ts.URL
+	conf.Retry = "1ms"
+
+	p, err := newPrometheusRemoteWriter(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer p.CloseAsync()
+
+	require.Error(t, p.WriteWithContext(context.Background(), message.New([][]byte{
+		[]byte(`{"name":"a","value":1,"timestamp":2}`),
+	})))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&reqCount))
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/internal/service/redis"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -49,7 +50,8 @@ The order of hash field extraction is as follows:
 3. Explicit fields
 
 Where latter stages will overwrite matching field names of a former stage.`,
-		Async: true,
+		Async:   true,
+		Batches: true,
 		FieldSpecs: redis.ConfigDocs().Add(
 			docs.FieldCommon(
 				"key", "The key for each message, function interpolations should be used to create a unique key per message.",
@@ -59,6 +61,7 @@ Where latter stages will overwrite matching field names of a former stage.`,
 			docs.FieldCommon("walk_json_object", "Whether to walk each message as a JSON object and add each key/value pair to the list of hash fields to set."),
 			docs.FieldCommon("fields", "A map of key/value pairs to set as hash fields.").IsInterpolated().Map(),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
 		),
 		Categories: []Category{
 			CategoryServices,
@@ -80,7 +83,7 @@ func NewRedisHash(conf Config, mgr types.Manager, log log.Modular, stats metrics
 	if err != nil {
 		return nil, err
 	}
-	return OnlySinglePayloads(a), nil
+	return NewBatcherFromConfig(conf.RedisHash.Batching, OnlySinglePayloads(a), mgr, log, stats)
 }
 
 //------------------------------------------------------------------------------
@@ -5,6 +5,7 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/internal/service/redis"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -26,13 +27,29 @@ able to remove a whole macro node, for efficiency.
 Redis stream entries are key/value pairs, as such it is necessary to specify the
 key to be set to the body of the message. All metadata fields of the message
 will also be set as key/value pairs, if there is a key collision between
-a metadata item and the body then the body takes precedence.`,
-		Async: true,
+a metadata item and the body then the body takes precedence.
+
+Additional key/value pairs can be added to entries with a
+[Bloblang mapping](/docs/guides/bloblang/about) in the field ` + "`fields_mapping`" + `,
+which must result in an object. Keys from this mapping take precedence over both
+the body and metadata, and values that are not strings are serialised.
+
+The messages of a batch are written to the stream with a single pipeline, and
+when ` + "`kind`" + ` is set to ` + "`cluster`" + ` the pipeline is sent to the
+node that owns the slot of the stream.`,
+		Async:   true,
+		Batches: true,
 		FieldSpecs: redis.ConfigDocs().Add(
 			docs.FieldCommon("stream", "The stream to add messages to."),
-			docs.FieldCommon("body_key", "A key to set the raw body of the message to."),
+			docs.FieldCommon("body_key", "A key to set the raw body of the message to. If empty the raw body is not added to the entry."),
+			docs.FieldAdvanced(
+				"fields_mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) that results in an object of key/value pairs to add to each stream entry.",
+				`root.id = this.id
+root.topic = meta("kafka_topic")`,
+			).AtVersion("3.44.0"),
 			docs.FieldCommon("max_length", "When greater than zero enforces a rough cap on the length of the target stream."),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
 			docs.FieldCommon("metadata", "Specify criteria for which metadata values are included in the message body.").WithChildren(output.MetadataFields()...),
 		),
 		Categories: []Category{
@@ -53,7 +70,7 @@ func NewRedisStreams(conf Config, mgr types.Manager, log log.Modular, stats metr
 	if err != nil {
 		return nil, err
 	}
-	return OnlySinglePayloads(a), nil
+	return NewBatcherFromConfig(conf.RedisStreams.Batching, a, mgr, log, stats)
 }
 
 //------------------------------------------------------------------------------
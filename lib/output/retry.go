@@ -37,9 +37,22 @@ we want to avoid reapplying to the same message more than once in the pipeline.
 
 Rather than retrying the same output you may wish to retry the send using a
 different output target (a dead letter queue). In which case you should instead
-use the ` + "[`try`](/docs/components/outputs/try)" + ` output type.`,
+use the ` + "[`try`](/docs/components/outputs/try)" + ` output type.
+
+### Retry Budget
+
+When a downstream service is struggling, retrying every failed send can amplify the load placed upon it. The field ` + "`budget`" + ` can be used to enable a token bucket that is shared by all messages in flight through this output. The bucket starts full with ` + "`max_tokens`" + ` tokens, each failed send removes a token and each successful send adds ` + "`token_ratio`" + ` tokens, up to the maximum.
+
+Failed sends are only retried whilst the bucket holds more than half of its maximum tokens. Otherwise the message is rejected immediately, which allows it to be routed to a fallback or dead letter queue by wrapping this output within a ` + "[`try`](/docs/components/outputs/try)" + ` output. Retries resume once enough sends have succeeded to refill the bucket.
+
+The current number of tokens is exposed as the gauge ` + "`retry.budget.tokens`" + ` and messages rejected by the budget are counted by ` + "`retry.budget.rejected`" + `.`,
 		FieldSpecs: retries.FieldSpecs().Add(
 			docs.FieldCommon("output", "A child output.").HasType(docs.FieldOutput),
+			docs.FieldAdvanced("budget", "Configure a budget that limits retries when a large proportion of sends are failing.").WithChildren(
+				docs.FieldCommon("enabled", "Whether the retry budget is enabled."),
+				docs.FieldCommon("max_tokens", "The maximum number of tokens in the bucket."),
+				docs.FieldCommon("token_ratio", "The number of tokens added to the bucket for each successful send."),
+			).AtVersion("3.44.0"),
 		),
 		Categories: []Category{
 			CategoryUtility,
@@ -53,6 +66,15 @@ use the ` + "[`try`](/docs/components/outputs/try)" + ` output type.`,
 type RetryConfig struct {
 	Output         *Config `json:"output" yaml:"output"`
 	retries.Config `json:",inline" yaml:",inline"`
+	Budget         RetryBudgetConfig `json:"budget" yaml:"budget"`
+}
+
+// RetryBudgetConfig contains configuration values for the token bucket that
+// limits retries of the Retry output type.
+type RetryBudgetConfig struct {
+	Enabled    bool    `json:"enabled" yaml:"enabled"`
+	MaxTokens  float64 `json:"max_tokens" yaml:"max_tokens"`
+	TokenRatio float64 `json:"token_ratio" yaml:"token_ratio"`
 }
 
 // NewRetryConfig creates a new RetryConfig with default values.
@@ -65,6 +87,11 @@ func NewRetryConfig() RetryConfig {
 	return RetryConfig{
 		Output: nil,
 		Config: retries.NewConfig(),
+		Budget: RetryBudgetConfig{
+			Enabled:    false,
+			MaxTokens:  10,
+			TokenRatio: 0.1,
+		},
 	}
 }
 
@@ -73,6 +100,7 @@ func NewRetryConfig() RetryConfig {
 type dummyRetryConfig struct {
 	Output         interface{} `json:"output" yaml:"output"`
 	retries.Config `json:",inline" yaml:",inline"`
+	Budget         RetryBudgetConfig `json:"budget" yaml:"budget"`
 }
 
 // MarshalJSON prints an empty object instead of nil.
@@ -80,6 +108,7 @@ func (r RetryConfig) MarshalJSON() ([]byte, error) {
 	dummy := dummyRetryConfig{
 		Output: r.Output,
 		Config: r.Config,
+		Budget: r.Budget,
 	}
 	if r.Output == nil {
 		dummy.Output = struct{}{}
@@ -92,6 +121,7 @@ func (r RetryConfig) MarshalYAML() (interface{}, error) {
 	dummy := dummyRetryConfig{
 		Output: r.Output,
 		Config: r.Config,
+		Budget: r.Budget,
 	}
 	if r.Output == nil {
 		dummy.Output = struct{}{}
@@ -101,6 +131,60 @@ func (r RetryConfig) MarshalYAML() (interface{}, error) {
 
 //------------------------------------------------------------------------------
 
+// retryBudget is a token bucket shared by all messages in flight through a
+// Retry output, where failed sends are only retried whilst the bucket holds
+// more than half of its maximum tokens.
+type retryBudget struct {
+	maxTokens  float64
+	tokenRatio float64
+
+	mut    sync.Mutex
+	tokens float64
+
+	mTokens metrics.StatGauge
+}
+
+func newRetryBudget(conf RetryBudgetConfig, stats metrics.Type) (*retryBudget, error) {
+	if conf.MaxTokens <= 0 {
+		return nil, errors.New("budget max_tokens must be greater than zero")
+	}
+	if conf.TokenRatio <= 0 {
+		return nil, errors.New("budget token_ratio must be greater than zero")
+	}
+	b := &retryBudget{
+		maxTokens:  conf.MaxTokens,
+		tokenRatio: conf.TokenRatio,
+		tokens:     conf.MaxTokens,
+		mTokens:    stats.GetGauge("retry.budget.tokens"),
+	}
+	b.mTokens.Set(int64(b.tokens))
+	return b, nil
+}
+
+// onSuccess adds tokens to the bucket for a successful send.
+func (b *retryBudget) onSuccess() {
+	b.mut.Lock()
+	if b.tokens += b.tokenRatio; b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+	b.mTokens.Set(int64(b.tokens))
+	b.mut.Unlock()
+}
+
+// onFailure removes a token from the bucket for a failed send and returns
+// whether the send may be retried.
+func (b *retryBudget) onFailure() bool {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	if b.tokens--; b.tokens < 0 {
+		b.tokens = 0
+	}
+	b.mTokens.Set(int64(b.tokens))
+	return b.tokens > b.maxTokens/2
+}
+
+//------------------------------------------------------------------------------
+
 // Retry is an output type that continuously writes a message to a child output
 // until the send is successful.
 type Retry struct {
@@ -109,6 +193,7 @@ type Retry struct {
 
 	wrapped     Type
 	backoffCtor func() backoff.BackOff
+	budget      *retryBudget
 
 	stats metrics.Type
 	log   log.Modular
@@ -141,6 +226,13 @@ func NewRetry(
 		return nil, err
 	}
 
+	var budget *retryBudget
+	if conf.Retry.Budget.Enabled {
+		if budget, err = newRetryBudget(conf.Retry.Budget, stats); err != nil {
+			return nil, err
+		}
+	}
+
 	return &Retry{
 		running: 1,
 		conf:    conf.Retry,
@@ -149,6 +241,7 @@ func NewRetry(
 		stats:           stats,
 		wrapped:         wrapped,
 		backoffCtor:     boffCtor,
+		budget:          budget,
 		transactionsOut: make(chan types.Transaction),
 
 		closeChan:  make(chan struct{}),
@@ -167,6 +260,7 @@ func (r *Retry) loop() {
 		mPartsSuccess = r.stats.GetCounter("retry.parts.send.success")
 		mError        = r.stats.GetCounter("retry.send.error")
 		mEndOfRetries = r.stats.GetCounter("retry.end_of_retries")
+		mBudgetReject = r.stats.GetCounter("retry.budget.rejected")
 	)
 
 	wg := sync.WaitGroup{}
@@ -255,6 +349,11 @@ func (r *Retry) loop() {
 
 					mError.Incr(1)
 					r.log.Errorf("Failed to send message: %v\n", res.Error())
+					if r.budget != nil && !r.budget.onFailure() {
+						mBudgetReject.Incr(1)
+						resOut = response.NewNoack()
+						break retryLoop
+					}
 					if backOff == nil {
 						backOff = r.backoffCtor()
 					}
@@ -277,6 +376,9 @@ func (r *Retry) loop() {
 						return
 					}
 				} else {
+					if r.budget != nil {
+						r.budget.onSuccess()
+					}
 					mSuccess.Incr(1)
 					mPartsSuccess.Incr(int64(ts.Payload.Len()))
 					resOut = response.NewAck()
@@ -9,6 +9,8 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRetryConfigErrs(t *testing.T) {
@@ -322,3 +324,105 @@ func TestRetryParallel(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestRetryBudgetTokens(t *testing.T) {
+	conf := NewRetryConfig().Budget
+	conf.MaxTokens = 4
+	conf.TokenRatio = 0.5
+
+	stats := metrics.NewLocal()
+	b, err := newRetryBudget(conf, stats)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), stats.GetCounters()["retry.budget.tokens"])
+
+	assert.True(t, b.onFailure())
+	assert.False(t, b.onFailure())
+	assert.False(t, b.onFailure())
+	assert.Equal(t, int64(1), stats.GetCounters()["retry.budget.tokens"])
+
+	for i := 0; i < 5; i++ {
+		b.onSuccess()
+	}
+	assert.True(t, b.onFailure())
+
+	for i := 0; i < 10; i++ {
+		b.onSuccess()
+	}
+	assert.Equal(t, int64(4), stats.GetCounters()["retry.budget.tokens"])
+
+	conf.TokenRatio = 0
+	_, err = newRetryBudget(conf, stats)
+	require.EqualError(t, err, "budget token_ratio must be greater than zero")
+}
+
+func TestRetryBudgetRejects(t *testing.T) {
+	conf := NewConfig()
+
+	childConf := NewConfig()
+	conf.Retry.Output = &childConf
+	conf.Retry.Backoff.InitialInterval = "10us"
+	conf.Retry.Backoff.MaxInterval = "10us"
+	conf.Retry.Budget.Enabled = true
+	conf.Retry.Budget.MaxTokens = 4
+	conf.Retry.Budget.TokenRatio = 1
+
+	stats := metrics.NewLocal()
+	output, err := NewRetry(conf, nil, log.Noop(), stats)
+	require.NoError(t, err)
+
+	mOut := &mockOutput{
+		ts: make(chan types.Transaction),
+	}
+	output.(*Retry).wrapped = mOut
+
+	tChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	require.NoError(t, output.Consume(tChan))
+
+	sendAndRespond := func(responses ...types.Response) types.Response {
+		t.Helper()
+		select {
+		case tChan <- types.NewTransaction(message.New(nil), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		for _, res := range responses {
+			var tran types.Transaction
+			select {
+			case tran = <-mOut.ts:
+			case <-time.After(time.Second):
+				t.Fatal("timed out")
+			}
+			select {
+			case tran.ResponseChan <- res:
+			case <-time.After(time.Second):
+				t.Fatal("timed out")
+			}
+		}
+		select {
+		case res := <-resChan:
+			return res
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		return nil
+	}
+
+	// The second failure drops the bucket to half of its maximum tokens.
+	res := sendAndRespond(response.NewNoack(), response.NewNoack())
+	assert.Error(t, res.Error())
+	assert.Equal(t, int64(1), stats.GetCounters()["retry.budget.rejected"])
+
+	// Successes refill the bucket, allowing failures to be retried again.
+	for i := 0; i < 2; i++ {
+		res = sendAndRespond(response.NewAck())
+		assert.NoError(t, res.Error())
+	}
+	res = sendAndRespond(response.NewNoack(), response.NewAck())
+	assert.NoError(t, res.Error())
+	assert.Equal(t, int64(4), stats.GetCounters()["retry.budget.tokens"])
+	assert.Equal(t, int64(1), stats.GetCounters()["retry.budget.rejected"])
+
+	output.CloseAsync()
+	require.NoError(t, output.WaitForClose(time.Second))
+}
@@ -2,10 +2,12 @@ package output
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/codec"
@@ -13,6 +15,7 @@ import (
 	sftpSetup "github.com/Jeffail/benthos/v3/internal/service/sftp"
 	"github.com/Jeffail/benthos/v3/lib/bloblang"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -34,13 +37,37 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return OnlySinglePayloads(a), nil
+			if conf.SFTP.TempSuffix == "" {
+				a = OnlySinglePayloads(a)
+			}
+			return NewBatcherFromConfig(conf.SFTP.Batching, a, mgr, log, stats)
 		}),
 		Status:  docs.StatusExperimental,
 		Version: "3.39.0",
 		Summary: `Writes files to a server over SFTP.`,
 		Description: `
-In order to have a different path for each object you should use function interpolations described [here](/docs/configuration/interpolation#bloblang-queries).`,
+In order to have a different path for each object you should use function interpolations described [here](/docs/configuration/interpolation#bloblang-queries).
+
+### Temporary Files
+
+When the field ` + "`temp_suffix`" + ` is set each file is first written to a temporary path, made from the path of the file followed by the suffix, and is then renamed to its final path once written. This prevents other systems from observing partially written files. The rename uses the ` + "`posix-rename@openssh.com`" + ` extension when supported by the server, which replaces existing files atomically.
+
+In this mode the messages of a batch that share a path are written to the same file, and each file is renamed once the batch is complete. If any message of a batch fails to be written then the temporary files of the batch are removed and the whole batch is retried. This makes it possible to upload batches as files using a codec such as ` + "`lines`" + `:
+
+` + "```yaml" + `
+output:
+  sftp:
+    address: sftp.example.com:22
+    path: /uploads/${! timestamp_unix_nano() }.jsonl
+    codec: lines
+    temp_suffix: .tmp
+    credentials:
+      username: foo
+      password: bar
+    batching:
+      count: 100
+      period: 10s
+` + "```" + ``,
 		Async: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon(
@@ -57,6 +84,9 @@ In order to have a different path for each object you should use function interp
 				"The credentials to use to log into the server.",
 			).WithChildren(sftpSetup.CredentialsDocs()...),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			docs.FieldAdvanced("temp_suffix", "An optional suffix of a [temporary path](#temporary-files) to write each file to before renaming it to its final path.", ".tmp", ".partial").AtVersion("3.44.0"),
+			docs.FieldAdvanced("max_connections", "The number of connections to establish with the server. When `temp_suffix` is set batches that are in flight are written concurrently, distributed across the connections.").AtVersion("3.44.0"),
+			batch.FieldSpec().AtVersion("3.44.0"),
 		},
 		Categories: []Category{
 			CategoryNetwork,
@@ -68,11 +98,14 @@ In order to have a different path for each object you should use function interp
 
 // SFTPConfig contains configuration fields for the SFTP output type.
 type SFTPConfig struct {
-	Address     string                `json:"address" yaml:"address"`
-	Path        string                `json:"path" yaml:"path"`
-	Codec       string                `json:"codec" yaml:"codec"`
-	Credentials sftpSetup.Credentials `json:"credentials" yaml:"credentials"`
-	MaxInFlight int                   `json:"max_in_flight" yaml:"max_in_flight"`
+	Address        string                `json:"address" yaml:"address"`
+	Path           string                `json:"path" yaml:"path"`
+	Codec          string                `json:"codec" yaml:"codec"`
+	Credentials    sftpSetup.Credentials `json:"credentials" yaml:"credentials"`
+	MaxInFlight    int                   `json:"max_in_flight" yaml:"max_in_flight"`
+	TempSuffix     string                `json:"temp_suffix" yaml:"temp_suffix"`
+	MaxConnections int                   `json:"max_connections" yaml:"max_connections"`
+	Batching       batch.PolicyConfig    `json:"batching" yaml:"batching"`
 }
 
 // NewSFTPConfig creates a new Config with default values.
@@ -85,14 +118,19 @@ func NewSFTPConfig() SFTPConfig {
 			Username: "",
 			Password: "",
 		},
-		MaxInFlight: 1,
+		MaxInFlight:    1,
+		TempSuffix:     "",
+		MaxConnections: 1,
+		Batching:       batch.NewPolicyConfig(),
 	}
 }
 
 type sftpWriter struct {
 	conf SFTPConfig
 
-	client *sftp.Client
+	clientCtor func() (*sftp.Client, error)
+	clients    []*sftp.Client
+	nextClient uint32
 
 	log   log.Modular
 	stats metrics.Type
@@ -116,6 +154,9 @@ func newSFTPWriter(
 		log:   log,
 		stats: stats,
 	}
+	s.clientCtor = func() (*sftp.Client, error) {
+		return s.conf.Credentials.GetClient(s.conf.Address)
+	}
 
 	var err error
 	if s.codec, s.codecConf, err = codec.GetWriter(conf.Codec); err != nil {
@@ -124,6 +165,9 @@ func newSFTPWriter(
 	if s.path, err = bloblang.NewField(conf.Path); err != nil {
 		return nil, fmt.Errorf("failed to parse path expression: %w", err)
 	}
+	if conf.MaxConnections < 1 {
+		return nil, errors.New("max_connections must be at least one")
+	}
 
 	return s, nil
 }
@@ -133,24 +177,72 @@ func (s *sftpWriter) ConnectWithContext(ctx context.Context) error {
 	s.handleMut.Lock()
 	defer s.handleMut.Unlock()
 
-	if s.client != nil {
+	if len(s.clients) > 0 {
 		return nil
 	}
 
-	var err error
-	s.client, err = s.conf.Credentials.GetClient(s.conf.Address)
-	return err
+	clients := make([]*sftp.Client, 0, s.conf.MaxConnections)
+	for i := 0; i < s.conf.MaxConnections; i++ {
+		client, err := s.clientCtor()
+		if err != nil {
+			for _, c := range clients {
+				c.Close()
+			}
+			return err
+		}
+		clients = append(clients, client)
+	}
+	s.clients = clients
+	return nil
+}
+
+// getClient returns the next connection to write with, or nil if there are no
+// connections.
+func (s *sftpWriter) getClient() *sftp.Client {
+	s.handleMut.Lock()
+	defer s.handleMut.Unlock()
+
+	if len(s.clients) == 0 {
+		return nil
+	}
+	i := atomic.AddUint32(&s.nextClient, 1)
+	return s.clients[int(i)%len(s.clients)]
+}
+
+// checkConnLost closes all connections when an error indicates that a
+// connection has been lost, in which case types.ErrNotConnected is returned in
+// order for the connections to be re-established.
+func (s *sftpWriter) checkConnLost(err error) error {
+	if !errors.Is(err, sftp.ErrSSHFxConnectionLost) {
+		return err
+	}
+
+	s.handleMut.Lock()
+	defer s.handleMut.Unlock()
+
+	s.log.Errorf("Connection to server lost: %v\n", err)
+	if s.handle != nil {
+		s.handle.Close(context.Background())
+		s.handle = nil
+	}
+	for _, c := range s.clients {
+		c.Close()
+	}
+	s.clients = nil
+	return types.ErrNotConnected
 }
 
 // WriteWithContext attempts to write message contents to a target file via an SFTP connection.
 func (s *sftpWriter) WriteWithContext(ctx context.Context, msg types.Message) error {
-	s.handleMut.Lock()
-	client := s.client
-	s.handleMut.Unlock()
+	client := s.getClient()
 	if client == nil {
 		return types.ErrNotConnected
 	}
 
+	if s.conf.TempSuffix != "" {
+		return s.checkConnLost(s.writeWithRename(ctx, client, msg))
+	}
+
 	return writer.IterateBatchedSend(msg, func(i int, p types.Part) error {
 		path := s.path.String(i, msg)
 
@@ -158,7 +250,6 @@ func (s *sftpWriter) WriteWithContext(ctx context.Context, msg types.Message) er
 		defer s.handleMut.Unlock()
 
 		if s.handle != nil && path == s.handlePath {
-			// TODO: Detect underlying connection failure here and drop client.
 			return s.handle.Write(ctx, p)
 		}
 		if s.handle != nil {
@@ -175,11 +266,11 @@ func (s *sftpWriter) WriteWithContext(ctx context.Context, msg types.Message) er
 			flag = flag | os.O_TRUNC
 		}
 
-		if err := s.client.MkdirAll(filepath.Dir(path)); err != nil {
+		if err := client.MkdirAll(filepath.Dir(path)); err != nil {
 			return err
 		}
 
-		file, err := s.client.OpenFile(path, flag)
+		file, err := client.OpenFile(path, flag)
 		if err != nil {
 			return err
 		}
@@ -204,6 +295,96 @@ func (s *sftpWriter) WriteWithContext(ctx context.Context, msg types.Message) er
 	})
 }
 
+// writeWithRename writes the messages of a batch to temporary files, and once
+// all messages are written renames each file to its final path. If any write
+// fails the temporary files are removed and the error is returned for the
+// whole batch.
+func (s *sftpWriter) writeWithRename(ctx context.Context, client *sftp.Client, msg types.Message) (err error) {
+	handles := map[string]codec.Writer{}
+	var written []string
+
+	defer func() {
+		if err == nil {
+			return
+		}
+		for path, h := range handles {
+			h.Close(ctx)
+			delete(handles, path)
+		}
+		for _, path := range written {
+			if rerr := client.Remove(path + s.conf.TempSuffix); rerr != nil && !os.IsNotExist(rerr) {
+				s.log.Errorf("Failed to remove temporary file '%v': %v\n", path+s.conf.TempSuffix, rerr)
+			}
+		}
+	}()
+
+	var toRename []string
+	for i := 0; i < msg.Len(); i++ {
+		path := s.path.String(i, msg)
+
+		h, exists := handles[path]
+		if !exists {
+			if err = client.MkdirAll(filepath.Dir(path)); err != nil {
+				return err
+			}
+			var file *sftp.File
+			if file, err = client.OpenFile(path+s.conf.TempSuffix, os.O_CREATE|os.O_RDWR|os.O_TRUNC); err != nil {
+				return err
+			}
+			written = append(written, path)
+			if h, err = s.codec(file); err != nil {
+				file.Close()
+				return err
+			}
+			handles[path] = h
+		}
+
+		if err = h.Write(ctx, msg.Get(i)); err != nil {
+			return err
+		}
+		if s.codecConf.CloseAfter {
+			if err = handles[path].Close(ctx); err != nil {
+				return err
+			}
+			delete(handles, path)
+			toRename = append(toRename, path)
+		}
+	}
+
+	for path := range handles {
+		if err = handles[path].Close(ctx); err != nil {
+			return err
+		}
+		delete(handles, path)
+		toRename = append(toRename, path)
+	}
+
+	for _, path := range toRename {
+		if err = s.rename(client, path+s.conf.TempSuffix, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rename moves a file to a new path, replacing any existing file.
+func (s *sftpWriter) rename(client *sftp.Client, from, to string) error {
+	err := client.PosixRename(from, to)
+	if err == nil {
+		return nil
+	}
+	var statusErr *sftp.StatusError
+	if errors.As(err, &statusErr) && statusErr.FxCode() == sftp.ErrSSHFxOpUnsupported {
+		// Fall back to removing the existing file before renaming, which isn't
+		// atomic.
+		if rerr := client.Remove(to); rerr != nil && !os.IsNotExist(rerr) {
+			return rerr
+		}
+		return client.Rename(from, to)
+	}
+	return err
+}
+
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
 func (s *sftpWriter) CloseAsync() {
 	go func() {
@@ -212,10 +393,10 @@ func (s *sftpWriter) CloseAsync() {
 			s.handle.Close(context.Background())
 			s.handle = nil
 		}
-		if s.client != nil {
-			s.client.Close()
-			s.client = nil
+		for _, c := range s.clients {
+			c.Close()
 		}
+		s.clients = nil
 		s.handleMut.Unlock()
 	}()
 }
@@ -0,0 +1,85 @@
+package output
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pipeSFTPClient(t *testing.T) (*sftp.Client, error) {
+	t.Helper()
+
+	serverConn, clientConn := net.Pipe()
+	server, err := sftp.NewServer(serverConn)
+	require.NoError(t, err)
+	go server.Serve()
+	t.Cleanup(func() {
+		server.Close()
+	})
+	return sftp.NewClientPipe(clientConn, clientConn)
+}
+
+func TestSFTPTempRename(t *testing.T) {
+	dir := t.TempDir()
+
+	conf := NewSFTPConfig()
+	conf.Path = filepath.Join(dir, `${! meta("dir") }/out.txt`)
+	conf.Codec = "lines"
+	conf.TempSuffix = ".tmp"
+	conf.MaxConnections = 2
+
+	w, err := newSFTPWriter(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	var ctorCalls int
+	w.clientCtor = func() (*sftp.Client, error) {
+		ctorCalls++
+		return pipeSFTPClient(t)
+	}
+	require.NoError(t, w.ConnectWithContext(context.Background()))
+	assert.Equal(t, 2, ctorCalls)
+
+	msg := message.New([][]byte{
+		[]byte("foo"), []byte("bar"), []byte("baz"),
+	})
+	msg.Get(0).Metadata().Set("dir", "a")
+	msg.Get(1).Metadata().Set("dir", "b")
+	msg.Get(2).Metadata().Set("dir", "a")
+	require.NoError(t, w.WriteWithContext(context.Background(), msg))
+
+	// Existing files are replaced.
+	msg = message.New([][]byte{[]byte("qux")})
+	msg.Get(0).Metadata().Set("dir", "b")
+	require.NoError(t, w.WriteWithContext(context.Background(), msg))
+
+	aBytes, err := ioutil.ReadFile(filepath.Join(dir, "a", "out.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nbaz\n", string(aBytes))
+
+	bBytes, err := ioutil.ReadFile(filepath.Join(dir, "b", "out.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "qux\n", string(bBytes))
+
+	tmpFiles, err := filepath.Glob(filepath.Join(dir, "*", "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, tmpFiles)
+
+	w.CloseAsync()
+}
+
+func TestSFTPBadMaxConnections(t *testing.T) {
+	conf := NewSFTPConfig()
+	conf.MaxConnections = 0
+
+	_, err := newSFTPWriter(conf, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "max_connections must be at least one")
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/sqlpool"
 
 	// SQL Drivers
 	_ "github.com/ClickHouse/clickhouse-go"
@@ -26,7 +27,7 @@ import (
 func init() {
 	Constructors[TypeSQL] = TypeSpec{
 		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
-			s, err := newSQLWriter(conf.SQL, log)
+			s, err := newSQLWriter(conf.SQL, mgr, log)
 			if err != nil {
 				return nil, err
 			}
@@ -58,7 +59,11 @@ The following is a list of supported drivers and their respective DSN formats:
 ` + "| `mysql` | `[username[:password]@][protocol[(address)]]/dbname[?param1=value1&...&paramN=valueN]` |" + `
 ` + "| `postgres` | `postgres://[user[:password]@][netloc][:port][/dbname][?param1=value1&...]` |" + `
 
-Please note that the ` + "`postgres`" + ` driver enforces SSL by default, you can override this with the parameter ` + "`sslmode=disable`" + ` if required.`,
+Please note that the ` + "`postgres`" + ` driver enforces SSL by default, you can override this with the parameter ` + "`sslmode=disable`" + ` if required.
+
+## Shared Connection Pools
+
+Instead of opening a connection pool for the output alone, the field ` + "`resource`" + ` can be set to the label of a [SQL resource](/docs/configuration/resources#sql-connection-pools), in which case the fields ` + "`driver` and `data_source_name`" + ` are ignored and the connection pool of the resource is shared with any other components that reference it.`,
 		Examples: []docs.AnnotatedExample{
 			{
 				Title: "Table Insert (MySQL)",
@@ -114,6 +119,7 @@ output:
 				s, ok := v.(string)
 				return "field dsn is deprecated in favour of data_source_name", ok && len(s) == 0
 			}),
+			docs.FieldAdvanced("resource", "The label of a [SQL resource](/docs/configuration/resources#sql-connection-pools) to use instead of opening a connection pool for this output.").AtVersion("3.44.0"),
 			docs.FieldCommon(
 				"query", "The query to run against the database.",
 				"INSERT INTO footable (foo, bar, baz) VALUES (?, ?, ?);",
@@ -134,6 +140,7 @@ output:
 type SQLConfig struct {
 	Driver         string             `json:"driver" yaml:"driver"`
 	DataSourceName string             `json:"data_source_name" yaml:"data_source_name"`
+	Resource       string             `json:"resource" yaml:"resource"`
 	Query          string             `json:"query" yaml:"query"`
 	Args           []string           `json:"args" yaml:"args"`
 	MaxInFlight    int                `json:"max_in_flight" yaml:"max_in_flight"`
@@ -145,6 +152,7 @@ func NewSQLConfig() SQLConfig {
 	return SQLConfig{
 		Driver:         "mysql",
 		DataSourceName: "",
+		Resource:       "",
 		Query:          "",
 		Args:           []string{},
 		MaxInFlight:    1,
@@ -167,6 +175,9 @@ type sqlWriter struct {
 	log  log.Modular
 	conf SQLConfig
 
+	pool   *sqlpool.Pool
+	driver string
+
 	db    *sql.DB
 	dbMut sync.Mutex
 	args  []field.Expression
@@ -174,7 +185,7 @@ type sqlWriter struct {
 	query *sql.Stmt
 }
 
-func newSQLWriter(conf SQLConfig, log log.Modular) (*sqlWriter, error) {
+func newSQLWriter(conf SQLConfig, mgr types.Manager, log log.Modular) (*sqlWriter, error) {
 	var args []field.Expression
 	for i, v := range conf.Args {
 		expr, err := bloblang.NewField(v)
@@ -185,9 +196,17 @@ func newSQLWriter(conf SQLConfig, log log.Modular) (*sqlWriter, error) {
 	}
 
 	s := &sqlWriter{
-		log:  log,
-		conf: conf,
-		args: args,
+		log:    log,
+		conf:   conf,
+		driver: conf.Driver,
+		args:   args,
+	}
+	if conf.Resource != "" {
+		var err error
+		if s.pool, err = sqlpool.FromManager(mgr, conf.Resource); err != nil {
+			return nil, fmt.Errorf("failed to obtain sql resource '%v': %v", conf.Resource, err)
+		}
+		s.driver = s.pool.Driver()
 	}
 
 	return s, nil
@@ -204,21 +223,25 @@ func (s *sqlWriter) ConnectWithContext(ctx context.Context) error {
 		return nil
 	}
 
+	var db *sql.DB
 	var err error
-	db, err := sql.Open(s.conf.Driver, s.conf.DataSourceName)
-	if err != nil {
+	if s.pool != nil {
+		db = s.pool.DB()
+	} else if db, err = sql.Open(s.driver, s.conf.DataSourceName); err != nil {
 		return err
 	}
 
 	// Some drivers only support transactional prepared inserts.
-	if !insertOnlyBatchDriver(s.conf.Driver) {
+	if !insertOnlyBatchDriver(s.driver) {
 		if s.query, err = db.Prepare(s.conf.Query); err != nil {
-			db.Close()
+			if s.pool == nil {
+				db.Close()
+			}
 			return fmt.Errorf("failed to prepare query: %v", err)
 		}
 	}
 
-	s.log.Infof("Writing messages to %v database.\n", s.conf.Driver)
+	s.log.Infof("Writing messages to %v database.\n", s.driver)
 	s.db = db
 	return nil
 }
@@ -300,7 +323,7 @@ func (s *sqlWriter) WriteWithContext(ctx context.Context, msg types.Message) err
 func (s *sqlWriter) CloseAsync() {
 	go func() {
 		s.dbMut.Lock()
-		if s.db != nil {
+		if s.db != nil && s.pool == nil {
 			s.db.Close()
 		}
 		if s.query != nil {
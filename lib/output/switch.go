@@ -15,6 +15,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/processor"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Jeffail/benthos/v3/lib/util/throttle"
@@ -94,6 +95,11 @@ The maximum number of parallel message batches to have in flight at any given ti
 					`this.type == "foo"`,
 					`this.contents.urls.contains("https://benthos.dev/")`,
 				).HasDefault("").Linter(docs.LintBloblangMapping),
+				docs.FieldAdvanced(
+					"error_classes",
+					"An optional list of error classes that restricts the case to messages that have failed with an error of one of the classes, where the classes are `retryable` and `permanent`. When combined with a `check` both must pass. For more information about error classes read [here](/docs/configuration/error_handling#route-by-error-class).",
+					[]string{"permanent"},
+				).Array().HasDefault([]interface{}{}).AtVersion("3.44.0"),
 				docs.FieldCommon(
 					"output", "An [output](/docs/components/outputs/about/) for messages that pass the check to be routed to.",
 				).HasDefault(map[string]interface{}{}).HasType(docs.FieldOutput),
@@ -200,17 +206,19 @@ func NewSwitchConfig() SwitchConfig {
 
 // SwitchConfigCase contains configuration fields per output of a switch type.
 type SwitchConfigCase struct {
-	Check    string `json:"check" yaml:"check"`
-	Continue bool   `json:"continue" yaml:"continue"`
-	Output   Config `json:"output" yaml:"output"`
+	Check        string   `json:"check" yaml:"check"`
+	ErrorClasses []string `json:"error_classes" yaml:"error_classes"`
+	Continue     bool     `json:"continue" yaml:"continue"`
+	Output       Config   `json:"output" yaml:"output"`
 }
 
 // NewSwitchConfigCase creates a new switch output config with default values.
 func NewSwitchConfigCase() SwitchConfigCase {
 	return SwitchConfigCase{
-		Check:    "",
-		Continue: false,
-		Output:   NewConfig(),
+		Check:        "",
+		ErrorClasses: []string{},
+		Continue:     false,
+		Output:       NewConfig(),
 	}
 }
 
@@ -230,6 +238,7 @@ type Switch struct {
 	outputTsChans     []chan types.Transaction
 	outputs           []types.Output
 	checks            []*mapping.Executor
+	errorClasses      []map[string]struct{}
 	conditions        []types.Condition
 	continues         []bool
 	fallthroughs      []bool
@@ -271,6 +280,7 @@ func NewSwitch(
 		}
 		o.outputs = make([]types.Output, lCases)
 		o.checks = make([]*mapping.Executor, lCases)
+		o.errorClasses = make([]map[string]struct{}, lCases)
 		o.continues = make([]bool, lCases)
 		o.fallthroughs = make([]bool, lCases)
 	} else {
@@ -305,6 +315,15 @@ func NewSwitch(
 				return nil, fmt.Errorf("failed to parse case '%v' check mapping: %v", i, err)
 			}
 		}
+		for _, class := range cConf.ErrorClasses {
+			if class != types.ErrorClassRetryable && class != types.ErrorClassPermanent {
+				return nil, fmt.Errorf("case '%v' error class '%v' not recognised", i, class)
+			}
+			if o.errorClasses[i] == nil {
+				o.errorClasses[i] = map[string]struct{}{}
+			}
+			o.errorClasses[i][class] = struct{}{}
+		}
 		o.continues[i] = cConf.Continue
 	}
 
@@ -395,7 +414,10 @@ func (o *Switch) loop() {
 				routedAtLeastOnce := false
 				for j, exe := range o.checks {
 					test := true
-					if exe != nil {
+					if classes := o.errorClasses[j]; classes != nil {
+						_, test = classes[processor.GetFailClass(p)]
+					}
+					if test && exe != nil {
 						var err error
 						if test, err = exe.QueryPart(i, ts.Payload); err != nil {
 							test = false
@@ -10,6 +10,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/processor"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/stretchr/testify/assert"
@@ -571,6 +572,73 @@ func TestSwitchNoMatchStrict(t *testing.T) {
 	}
 }
 
+func TestSwitchErrorClasses(t *testing.T) {
+	mockOutputs := []*MockOutputType{{}, {}, {}}
+
+	conf := NewConfig()
+	for i := 0; i < len(mockOutputs); i++ {
+		conf.Switch.Cases = append(conf.Switch.Cases, NewSwitchConfigCase())
+	}
+	conf.Switch.Cases[0].ErrorClasses = []string{"retryable"}
+	conf.Switch.Cases[1].ErrorClasses = []string{"permanent"}
+
+	s := newSwitch(t, conf, mockOutputs)
+
+	readChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+
+	require.NoError(t, s.Consume(readChan))
+
+	msg := message.New([][]byte{
+		[]byte("retryable"), []byte("permanent"), []byte("fine"),
+	})
+	processor.FlagErr(msg.Get(0), types.ErrTimeout)
+	processor.FlagErr(msg.Get(1), errors.New("nope"))
+
+	select {
+	case readChan <- types.NewTransaction(msg, resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for output send")
+	}
+
+	for i, exp := range []string{"retryable", "permanent", "fine"} {
+		select {
+		case ts := <-mockOutputs[i].TChan:
+			assert.Equal(t, [][]byte{[]byte(exp)}, message.GetAllBytes(ts.Payload), i)
+			select {
+			case ts.ResponseChan <- response.NewAck():
+			case <-time.After(time.Second):
+				t.Fatal("Timed out responding to output")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for output to propagate")
+		}
+	}
+
+	select {
+	case res := <-resChan:
+		assert.NoError(t, res.Error())
+	case <-time.After(time.Second):
+		t.Fatal("Timed out responding to output")
+	}
+
+	s.CloseAsync()
+	require.NoError(t, s.WaitForClose(time.Second*5))
+}
+
+func TestSwitchBadErrorClass(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSwitch
+	for i := 0; i < 2; i++ {
+		conf.Switch.Cases = append(conf.Switch.Cases, NewSwitchConfigCase())
+	}
+	conf.Switch.Cases[0].ErrorClasses = []string{"sometimes"}
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "case '0' error class 'sometimes' not recognised")
+}
+
 func TestSwitchWithConditionsNoFallthrough(t *testing.T) {
 	nMsgs := 100
 
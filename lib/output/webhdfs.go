@@ -0,0 +1,261 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/service/webhdfs"
+	"github.com/Jeffail/benthos/v3/lib/bloblang"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeWebHDFS] = TypeSpec{
+		constructor: fromSimpleConstructor(func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			w, err := newWebHDFSWriter(conf.WebHDFS, log, stats)
+			if err != nil {
+				return nil, err
+			}
+			a, err := NewAsyncWriter(
+				TypeWebHDFS, conf.WebHDFS.MaxInFlight, w, log, stats,
+			)
+			if err != nil {
+				return nil, err
+			}
+			return NewBatcherFromConfig(conf.WebHDFS.Batching, a, mgr, log, stats)
+		}),
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `Writes files to HDFS via the WebHDFS REST API, either directly to a NameNode or through an Apache Knox gateway.`,
+		Description: `
+Each message is written to a file at the path given by ` + "`directory`" + ` followed by ` + "`path`" + `. In order to have a different path for each message you should use function interpolations described [here](/docs/configuration/interpolation#bloblang-queries).
+
+### Write Modes
+
+By default each message replaces the contents of the file at its path.
+
+When the field ` + "`append`" + ` is set to ` + "`true`" + ` messages are instead appended to the file at their path, which is created if it does not already exist. The messages of a batch that share a path are appended with a single request, and are joined without a delimiter, therefore in order to write messages as lines you can add a newline to each with a processor such as ` + "`bloblang: 'root = content().string() + \"\\n\"'`" + `.
+
+When the field ` + "`temp_suffix`" + ` is set each file is first written to a temporary path, made from the path of the file followed by the suffix, and is then renamed to its final path. This prevents other systems from observing partially written files. This field cannot be combined with ` + "`append`" + `.
+
+### Authentication
+
+Clusters using simple authentication only require the field ` + "`user`" + `. Secure clusters can be accessed with either a ` + "`delegation_token`" + ` or with [Kerberos](#kerberos), in which case requests are authenticated using SPNEGO. When writing through a Knox gateway credentials are usually provided with ` + "`basic_auth`" + `.
+
+Credentials are only sent to the host of the configured URL, requests that are redirected to a DataNode are authorised by the redirect location.`,
+		Async: true,
+		FieldSpecs: append(
+			webhdfs.Docs(),
+			docs.FieldCommon("directory", "A directory to store message files within, which is created if it does not exist."),
+			docs.FieldCommon(
+				"path", "The path of each message file within the directory.",
+				`${!count("files")}-${!timestamp_unix_nano()}.txt`,
+				`${!meta("kafka_key")}.json`,
+				`${!timestamp("2006-01-02")}/events.jsonl`,
+			).IsInterpolated(),
+			docs.FieldCommon("append", "Whether to append messages to the file at their path rather than replacing its contents."),
+			docs.FieldAdvanced("temp_suffix", "An optional suffix of a temporary path to write each file to before renaming it to its final path.", ".tmp", "._COPYING_"),
+			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		),
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// WebHDFSConfig contains configuration fields for the WebHDFS output type.
+type WebHDFSConfig struct {
+	webhdfs.Config `json:",inline" yaml:",inline"`
+	Directory      string             `json:"directory" yaml:"directory"`
+	Path           string             `json:"path" yaml:"path"`
+	Append         bool               `json:"append" yaml:"append"`
+	TempSuffix     string             `json:"temp_suffix" yaml:"temp_suffix"`
+	MaxInFlight    int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching       batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewWebHDFSConfig creates a new Config with default values.
+func NewWebHDFSConfig() WebHDFSConfig {
+	return WebHDFSConfig{
+		Config:      webhdfs.NewConfig(),
+		Directory:   "",
+		Path:        `${!count("files")}-${!timestamp_unix_nano()}.txt`,
+		Append:      false,
+		TempSuffix:  "",
+		MaxInFlight: 1,
+		Batching:    batch.NewPolicyConfig(),
+	}
+}
+
+type webhdfsWriter struct {
+	conf WebHDFSConfig
+	path bloblang.Field
+
+	clientMut sync.Mutex
+	client    *webhdfs.Client
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+func newWebHDFSWriter(
+	conf WebHDFSConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*webhdfsWriter, error) {
+	if conf.Append && conf.TempSuffix != "" {
+		return nil, errors.New("fields append and temp_suffix cannot be used together")
+	}
+	w := &webhdfsWriter{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+	var err error
+	if w.path, err = bloblang.NewField(conf.Path); err != nil {
+		return nil, fmt.Errorf("failed to parse path expression: %w", err)
+	}
+	return w, nil
+}
+
+// ConnectWithContext creates a client for the WebHDFS API and ensures that the
+// target directory exists.
+func (w *webhdfsWriter) ConnectWithContext(ctx context.Context) error {
+	w.clientMut.Lock()
+	defer w.clientMut.Unlock()
+
+	if w.client != nil {
+		return nil
+	}
+
+	client, err := w.conf.NewClient()
+	if err != nil {
+		return err
+	}
+	if w.conf.Directory != "" {
+		if err := client.Mkdirs(ctx, w.conf.Directory); err != nil {
+			client.Close()
+			return err
+		}
+	}
+	w.client = client
+
+	w.log.Infof("Writing message parts as files via WebHDFS to: %v\n", w.conf.URL)
+	return nil
+}
+
+func (w *webhdfsWriter) getClient() *webhdfs.Client {
+	w.clientMut.Lock()
+	defer w.clientMut.Unlock()
+	return w.client
+}
+
+// WriteWithContext writes the messages of a batch to files via WebHDFS.
+func (w *webhdfsWriter) WriteWithContext(ctx context.Context, msg types.Message) error {
+	client := w.getClient()
+	if client == nil {
+		return types.ErrNotConnected
+	}
+
+	if w.conf.Append {
+		return w.writeAppend(ctx, client, msg)
+	}
+
+	return writer.IterateBatchedSend(msg, func(i int, p types.Part) error {
+		return w.create(ctx, client, w.filePath(i, msg), p.Get())
+	})
+}
+
+func (w *webhdfsWriter) filePath(i int, msg types.Message) string {
+	return path.Join(w.conf.Directory, w.path.String(i, msg))
+}
+
+// writeAppend appends the messages of a batch to files, where messages that
+// share a path are appended with a single request.
+func (w *webhdfsWriter) writeAppend(ctx context.Context, client *webhdfs.Client, msg types.Message) error {
+	var paths []string
+	contents := map[string]*bytes.Buffer{}
+	for i := 0; i < msg.Len(); i++ {
+		p := w.filePath(i, msg)
+		buf, exists := contents[p]
+		if !exists {
+			buf = &bytes.Buffer{}
+			contents[p] = buf
+			paths = append(paths, p)
+		}
+		buf.Write(msg.Get(i).Get())
+	}
+
+	for _, p := range paths {
+		if err := w.appendFile(ctx, client, p, contents[p].Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendFile appends data to a file, creating it if it does not exist.
+func (w *webhdfsWriter) appendFile(ctx context.Context, client *webhdfs.Client, p string, data []byte) error {
+	err := client.Append(ctx, p, data)
+	if !webhdfs.IsNotFound(err) {
+		return err
+	}
+	if err = client.Create(ctx, p, data, false); webhdfs.IsAlreadyExists(err) {
+		// The file was created by another writer since we attempted to append.
+		err = client.Append(ctx, p, data)
+	}
+	return err
+}
+
+// create replaces the contents of a file, optionally writing to a temporary
+// path first and then renaming it.
+func (w *webhdfsWriter) create(ctx context.Context, client *webhdfs.Client, p string, data []byte) error {
+	if w.conf.TempSuffix == "" {
+		return client.Create(ctx, p, data, true)
+	}
+
+	tmpPath := p + w.conf.TempSuffix
+	if err := client.Create(ctx, tmpPath, data, true); err != nil {
+		return err
+	}
+	if err := client.Rename(ctx, tmpPath, p); err != nil {
+		if derr := client.Delete(ctx, tmpPath); derr != nil {
+			w.log.Errorf("Failed to remove temporary file '%v': %v\n", tmpPath, derr)
+		}
+		return err
+	}
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (w *webhdfsWriter) CloseAsync() {
+	go func() {
+		w.clientMut.Lock()
+		if w.client != nil {
+			w.client.Close()
+			w.client = nil
+		}
+		w.clientMut.Unlock()
+	}()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (w *webhdfsWriter) WaitForClose(time.Duration) error {
+	return nil
+}
@@ -0,0 +1,139 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhdfsFileServer emulates a WebHDFS API where the NameNode redirects writes
+// to itself, with files held in memory.
+func webhdfsFileServer(t *testing.T) (*httptest.Server, func() map[string]string) {
+	t.Helper()
+
+	var mut sync.Mutex
+	files := map[string]string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		mut.Lock()
+		defer mut.Unlock()
+
+		path := strings.TrimPrefix(r.URL.Path, "/webhdfs/v1")
+		q := r.URL.Query()
+		redirected := q.Get("datanode") == "true"
+
+		switch op := q.Get("op"); {
+		case op == "MKDIRS":
+			fmt.Fprint(w, `{"boolean":true}`)
+		case op == "APPEND" && !redirected:
+			if _, exists := files[path]; !exists {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"RemoteException":{"exception":"FileNotFoundException","message":"nope"}}`)
+				return
+			}
+			fallthrough
+		case op == "CREATE" && !redirected:
+			http.Redirect(w, r, r.URL.String()+"&datanode=true", http.StatusTemporaryRedirect)
+		case op == "CREATE":
+			files[path] = string(body)
+			w.WriteHeader(http.StatusCreated)
+		case op == "APPEND":
+			files[path] += string(body)
+		case op == "RENAME":
+			files[q.Get("destination")] = files[path]
+			delete(files, path)
+			fmt.Fprint(w, `{"boolean":true}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() map[string]string {
+		mut.Lock()
+		defer mut.Unlock()
+		res := map[string]string{}
+		for k, v := range files {
+			res[k] = v
+		}
+		return res
+	}
+}
+
+func TestWebHDFSAppend(t *testing.T) {
+	server, files := webhdfsFileServer(t)
+
+	conf := NewWebHDFSConfig()
+	conf.URL = server.URL + "/webhdfs/v1"
+	conf.Directory = "/data"
+	conf.Path = `${! meta("dir") }/out.txt`
+	conf.Append = true
+
+	w, err := newWebHDFSWriter(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, w.ConnectWithContext(context.Background()))
+	defer w.CloseAsync()
+
+	msg := message.New([][]byte{
+		[]byte("foo\n"), []byte("bar\n"), []byte("baz\n"),
+	})
+	msg.Get(0).Metadata().Set("dir", "a")
+	msg.Get(1).Metadata().Set("dir", "b")
+	msg.Get(2).Metadata().Set("dir", "a")
+	require.NoError(t, w.WriteWithContext(context.Background(), msg))
+
+	msg = message.New([][]byte{[]byte("qux\n")})
+	msg.Get(0).Metadata().Set("dir", "a")
+	require.NoError(t, w.WriteWithContext(context.Background(), msg))
+
+	assert.Equal(t, map[string]string{
+		"/data/a/out.txt": "foo\nbaz\nqux\n",
+		"/data/b/out.txt": "bar\n",
+	}, files())
+}
+
+func TestWebHDFSTempRename(t *testing.T) {
+	server, files := webhdfsFileServer(t)
+
+	conf := NewWebHDFSConfig()
+	conf.URL = server.URL + "/webhdfs/v1"
+	conf.Directory = "/data"
+	conf.Path = `${! content() }.txt`
+	conf.TempSuffix = ".tmp"
+
+	w, err := newWebHDFSWriter(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, w.ConnectWithContext(context.Background()))
+	defer w.CloseAsync()
+
+	require.NoError(t, w.WriteWithContext(context.Background(), message.New([][]byte{
+		[]byte("foo"), []byte("bar"),
+	})))
+
+	assert.Equal(t, map[string]string{
+		"/data/foo.txt": "foo",
+		"/data/bar.txt": "bar",
+	}, files())
+}
+
+func TestWebHDFSBadConfig(t *testing.T) {
+	conf := NewWebHDFSConfig()
+	conf.Append = true
+	conf.TempSuffix = ".tmp"
+
+	_, err := newWebHDFSWriter(conf, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "fields append and temp_suffix cannot be used together")
+}
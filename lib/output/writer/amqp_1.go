@@ -8,6 +8,7 @@ import (
 
 	"github.com/Azure/go-amqp"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Jeffail/benthos/v3/lib/util/amqp/sasl"
@@ -18,11 +19,12 @@ import (
 
 // AMQP1Config contains configuration fields for the AMQP1 output type.
 type AMQP1Config struct {
-	URL           string      `json:"url" yaml:"url"`
-	TargetAddress string      `json:"target_address" yaml:"target_address"`
-	MaxInFlight   int         `json:"max_in_flight" yaml:"max_in_flight"`
-	TLS           btls.Config `json:"tls" yaml:"tls"`
-	SASL          sasl.Config `json:"sasl" yaml:"sasl"`
+	URL           string             `json:"url" yaml:"url"`
+	TargetAddress string             `json:"target_address" yaml:"target_address"`
+	MaxInFlight   int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching      batch.PolicyConfig `json:"batching" yaml:"batching"`
+	TLS           btls.Config        `json:"tls" yaml:"tls"`
+	SASL          sasl.Config        `json:"sasl" yaml:"sasl"`
 }
 
 // NewAMQP1Config creates a new AMQP1Config with default values.
@@ -31,6 +33,7 @@ func NewAMQP1Config() AMQP1Config {
 		URL:           "",
 		TargetAddress: "",
 		MaxInFlight:   1,
+		Batching:      batch.NewPolicyConfig(),
 		TLS:           btls.NewConfig(),
 		SASL:          sasl.NewConfig(),
 	}
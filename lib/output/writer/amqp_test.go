@@ -0,0 +1,19 @@
+package writer
+
+import (
+	"testing"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAMQPArguments(t *testing.T) {
+	assert.Nil(t, amqpArguments(nil))
+	assert.Equal(t, amqp.Table{
+		"x-queue-type":     "quorum",
+		"x-delivery-limit": int64(10),
+	}, amqpArguments(map[string]string{
+		"x-queue-type":     "quorum",
+		"x-delivery-limit": "10",
+	}))
+}
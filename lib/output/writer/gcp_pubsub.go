@@ -7,24 +7,35 @@ import (
 	"time"
 
 	"cloud.google.com/go/pubsub"
-	"github.com/Jeffail/benthos/v3/internal/batch"
+	batchInternal "github.com/Jeffail/benthos/v3/internal/batch"
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
 //------------------------------------------------------------------------------
 
+// GCPPubSubFlowControlConfig contains configuration fields for limiting the
+// messages that are published but not yet acknowledged.
+type GCPPubSubFlowControlConfig struct {
+	MaxOutstandingMessages int `json:"max_outstanding_messages" yaml:"max_outstanding_messages"`
+	MaxOutstandingBytes    int `json:"max_outstanding_bytes" yaml:"max_outstanding_bytes"`
+}
+
 // GCPPubSubConfig contains configuration fields for the output GCPPubSub type.
 type GCPPubSubConfig struct {
-	ProjectID      string          `json:"project" yaml:"project"`
-	TopicID        string          `json:"topic" yaml:"topic"`
-	MaxInFlight    int             `json:"max_in_flight" yaml:"max_in_flight"`
-	PublishTimeout string          `json:"publish_timeout" yaml:"publish_timeout"`
-	Metadata       output.Metadata `json:"metadata" yaml:"metadata"`
+	ProjectID      string                     `json:"project" yaml:"project"`
+	TopicID        string                     `json:"topic" yaml:"topic"`
+	MaxInFlight    int                        `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching       batch.PolicyConfig         `json:"batching" yaml:"batching"`
+	PublishTimeout string                     `json:"publish_timeout" yaml:"publish_timeout"`
+	Metadata       output.Metadata            `json:"metadata" yaml:"metadata"`
+	OrderingKey    string                     `json:"ordering_key" yaml:"ordering_key"`
+	FlowControl    GCPPubSubFlowControlConfig `json:"flow_control" yaml:"flow_control"`
 }
 
 // NewGCPPubSubConfig creates a new Config with default values.
@@ -33,8 +44,14 @@ func NewGCPPubSubConfig() GCPPubSubConfig {
 		ProjectID:      "",
 		TopicID:        "",
 		MaxInFlight:    1,
+		Batching:       batch.NewPolicyConfig(),
 		PublishTimeout: "60s",
 		Metadata:       output.NewMetadata(),
+		OrderingKey:    "",
+		FlowControl: GCPPubSubFlowControlConfig{
+			MaxOutstandingMessages: 0,
+			MaxOutstandingBytes:    0,
+		},
 	}
 }
 
@@ -49,9 +66,13 @@ type GCPPubSub struct {
 	publishTimeout time.Duration
 	metaFilter     *output.MetadataFilter
 
-	topicID  field.Expression
-	topics   map[string]*pubsub.Topic
-	topicMut sync.Mutex
+	topicID     field.Expression
+	orderingKey field.Expression
+	topics      map[string]*pubsub.Topic
+	topicMut    sync.Mutex
+
+	// Limits the number of messages published but not yet acknowledged.
+	outstanding chan struct{}
 
 	log   log.Modular
 	stats metrics.Type
@@ -79,7 +100,7 @@ func NewGCPPubSub(
 	if err != nil {
 		return nil, fmt.Errorf("failed to construct metadata filter: %w", err)
 	}
-	return &GCPPubSub{
+	c := &GCPPubSub{
 		conf:           conf,
 		log:            log,
 		metaFilter:     metaFilter,
@@ -87,7 +108,16 @@ func NewGCPPubSub(
 		publishTimeout: pubTimeout,
 		stats:          stats,
 		topicID:        topic,
-	}, nil
+	}
+	if conf.OrderingKey != "" {
+		if c.orderingKey, err = bloblang.NewField(conf.OrderingKey); err != nil {
+			return nil, fmt.Errorf("failed to parse ordering key expression: %v", err)
+		}
+	}
+	if conf.FlowControl.MaxOutstandingMessages > 0 {
+		c.outstanding = make(chan struct{}, conf.FlowControl.MaxOutstandingMessages)
+	}
+	return c, nil
 }
 
 // ConnectWithContext attempts to establish a connection to the target GCP
@@ -123,6 +153,10 @@ func (c *GCPPubSub) getTopic(ctx context.Context, t string) (*pubsub.Topic, erro
 		return nil, fmt.Errorf("topic '%v' does not exist", t)
 	}
 	topic.PublishSettings.Timeout = c.publishTimeout
+	if c.conf.FlowControl.MaxOutstandingBytes > 0 {
+		topic.PublishSettings.BufferedByteLimit = c.conf.FlowControl.MaxOutstandingBytes
+	}
+	topic.EnableMessageOrdering = c.orderingKey != nil
 	c.topics[t] = topic
 	return topic, nil
 }
@@ -146,7 +180,8 @@ func (c *GCPPubSub) WriteWithContext(ctx context.Context, msg types.Message) err
 	}
 
 	results := make([]*pubsub.PublishResult, msg.Len())
-	msg.Iter(func(i int, part types.Part) error {
+	orderingKeys := make([]string, msg.Len())
+	if err := msg.Iter(func(i int, part types.Part) error {
 		topic := topics[i]
 		attr := map[string]string{}
 		c.metaFilter.Iter(part.Metadata(), func(k, v string) error {
@@ -159,15 +194,39 @@ func (c *GCPPubSub) WriteWithContext(ctx context.Context, msg types.Message) err
 		if len(attr) > 0 {
 			gmsg.Attributes = attr
 		}
+		if c.orderingKey != nil {
+			orderingKeys[i] = c.orderingKey.String(i, msg)
+			gmsg.OrderingKey = orderingKeys[i]
+		}
+		if c.outstanding != nil {
+			select {
+			case c.outstanding <- struct{}{}:
+			case <-ctx.Done():
+				return types.ErrTimeout
+			}
+		}
 		results[i] = topic.Publish(ctx, gmsg)
+		if c.outstanding != nil {
+			go func(r *pubsub.PublishResult) {
+				<-r.Ready()
+				<-c.outstanding
+			}(results[i])
+		}
 		return nil
-	})
+	}); err != nil {
+		return err
+	}
 
-	var batchErr *batch.Error
+	var batchErr *batchInternal.Error
 	for i, r := range results {
 		if _, err := r.Get(ctx); err != nil {
+			if orderingKeys[i] != "" {
+				// Publishing is paused for an ordering key after an error,
+				// messages are resumed in order when the batch is retried.
+				topics[i].ResumePublish(orderingKeys[i])
+			}
 			if batchErr == nil {
-				batchErr = batch.NewError(msg, err)
+				batchErr = batchInternal.NewError(msg, err)
 			}
 			batchErr.Failed(i, err)
 		}
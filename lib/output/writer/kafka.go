@@ -45,6 +45,7 @@ type KafkaConfig struct {
 	RetryAsBatch   bool               `json:"retry_as_batch" yaml:"retry_as_batch"`
 	Batching       batch.PolicyConfig `json:"batching" yaml:"batching"`
 	StaticHeaders  map[string]string  `json:"static_headers" yaml:"static_headers"`
+	Headers        map[string]string  `json:"headers" yaml:"headers"`
 	Metadata       output.Metadata    `json:"metadata" yaml:"metadata"`
 
 	// TODO: V4 remove this.
@@ -71,6 +72,7 @@ func NewKafkaConfig() KafkaConfig {
 		AckReplicas:          false,
 		TargetVersion:        sarama.V1_0_0_0.String(),
 		StaticHeaders:        map[string]string{},
+		Headers:              map[string]string{},
 		Metadata:             output.NewMetadata(),
 		TLS:                  btls.NewConfig(),
 		SASL:                 sasl.NewConfig(),
@@ -106,6 +108,7 @@ type Kafka struct {
 	partitioner sarama.PartitionerConstructor
 
 	staticHeaders map[string]string
+	headers       map[string]field.Expression
 	metaFilter    *output.MetadataFilter
 
 	connMut sync.RWMutex
@@ -137,6 +140,7 @@ func NewKafka(conf KafkaConfig, mgr types.Manager, log log.Modular, stats metric
 		compression:   compression,
 		partitioner:   partitioner,
 		staticHeaders: conf.StaticHeaders,
+		headers:       map[string]field.Expression{},
 	}
 
 	if k.metaFilter, err = conf.Metadata.Filter(); err != nil {
@@ -149,6 +153,11 @@ func NewKafka(conf KafkaConfig, mgr types.Manager, log log.Modular, stats metric
 	if k.topic, err = bloblang.NewField(conf.Topic); err != nil {
 		return nil, fmt.Errorf("failed to parse topic expression: %v", err)
 	}
+	for name, value := range conf.Headers {
+		if k.headers[name], err = bloblang.NewField(value); err != nil {
+			return nil, fmt.Errorf("failed to parse header '%v' expression: %v", name, err)
+		}
+	}
 	if k.backoffCtor, err = conf.Config.GetCtor(); err != nil {
 		return nil, err
 	}
@@ -194,6 +203,8 @@ func strToCompressionCodec(str string) (sarama.CompressionCodec, error) {
 		return sarama.CompressionLZ4, nil
 	case "gzip":
 		return sarama.CompressionGZIP, nil
+	case "zstd":
+		return sarama.CompressionZSTD, nil
 	}
 	return sarama.CompressionNone, fmt.Errorf("compression codec not recognised: %v", str)
 }
@@ -257,6 +268,20 @@ func (k *Kafka) buildUserDefinedHeaders(staticHeaders map[string]string) []saram
 	return nil
 }
 
+func (k *Kafka) buildInterpolatedHeaders(i int, msg types.Message) []sarama.RecordHeader {
+	if len(k.headers) == 0 || !k.version.IsAtLeast(sarama.V0_11_0_0) {
+		return nil
+	}
+	out := make([]sarama.RecordHeader, 0, len(k.headers))
+	for name, value := range k.headers {
+		out = append(out, sarama.RecordHeader{
+			Key:   []byte(name),
+			Value: value.Bytes(i, msg),
+		})
+	}
+	return out
+}
+
 //------------------------------------------------------------------------------
 
 // ConnectWithContext attempts to establish a connection to a Kafka broker.
@@ -333,7 +358,7 @@ func (k *Kafka) WriteWithContext(ctx context.Context, msg types.Message) error {
 		nextMsg := &sarama.ProducerMessage{
 			Topic:    k.topic.String(i, msg),
 			Value:    sarama.ByteEncoder(p.Get()),
-			Headers:  append(k.buildSystemHeaders(p), userDefinedHeaders...),
+			Headers:  append(append(k.buildSystemHeaders(p), userDefinedHeaders...), k.buildInterpolatedHeaders(i, msg)...),
 			Metadata: i, // Store the original index for later reference.
 		}
 		if len(key) > 0 {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
+	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
@@ -26,21 +28,32 @@ import (
 const (
 	kinesisMaxRecordsCount = 500
 	mebibyte               = 1048576
+
+	kinesisErrCodeInternalFailure = "InternalFailure"
 )
 
 type sessionConfig struct {
 	sess.Config `json:",inline" yaml:",inline"`
 }
 
+// KinesisAggregationConfig contains configuration fields for aggregating
+// records in the format of the Kinesis Producer Library.
+type KinesisAggregationConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	MaxSize int  `json:"max_size" yaml:"max_size"`
+}
+
 // KinesisConfig contains configuration fields for the Kinesis output type.
 type KinesisConfig struct {
-	sessionConfig  `json:",inline" yaml:",inline"`
-	Stream         string `json:"stream" yaml:"stream"`
-	HashKey        string `json:"hash_key" yaml:"hash_key"`
-	PartitionKey   string `json:"partition_key" yaml:"partition_key"`
-	MaxInFlight    int    `json:"max_in_flight" yaml:"max_in_flight"`
-	retries.Config `json:",inline" yaml:",inline"`
-	Batching       batch.PolicyConfig `json:"batching" yaml:"batching"`
+	sessionConfig      `json:",inline" yaml:",inline"`
+	Stream             string                   `json:"stream" yaml:"stream"`
+	HashKey            string                   `json:"hash_key" yaml:"hash_key"`
+	PartitionKey       string                   `json:"partition_key" yaml:"partition_key"`
+	Aggregation        KinesisAggregationConfig `json:"aggregation" yaml:"aggregation"`
+	RespectShardLimits bool                     `json:"respect_shard_limits" yaml:"respect_shard_limits"`
+	MaxInFlight        int                      `json:"max_in_flight" yaml:"max_in_flight"`
+	retries.Config     `json:",inline" yaml:",inline"`
+	Batching           batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
 
 // NewKinesisConfig creates a new Config with default values.
@@ -56,9 +69,14 @@ func NewKinesisConfig() KinesisConfig {
 		Stream:       "",
 		HashKey:      "",
 		PartitionKey: "",
-		MaxInFlight:  1,
-		Config:       rConf,
-		Batching:     batch.NewPolicyConfig(),
+		Aggregation: KinesisAggregationConfig{
+			Enabled: false,
+			MaxSize: 51200,
+		},
+		RespectShardLimits: false,
+		MaxInFlight:        1,
+		Config:             rConf,
+		Batching:           batch.NewPolicyConfig(),
 	}
 }
 
@@ -77,6 +95,11 @@ type Kinesis struct {
 	partitionKey field.Expression
 	streamName   *string
 
+	limiter       *kinesisShardLimiter
+	shardsMut     sync.RWMutex
+	shards        kinesisShardMap
+	shardsUpdated time.Time
+
 	log   log.Modular
 	stats metrics.Type
 
@@ -95,6 +118,9 @@ func NewKinesis(
 	if len(conf.PartitionKey) == 0 {
 		return nil, errors.New("partition key must not be empty")
 	}
+	if conf.Aggregation.Enabled && (conf.Aggregation.MaxSize <= 0 || conf.Aggregation.MaxSize > mebibyte) {
+		return nil, fmt.Errorf("aggregation max size must be between 1 and %v bytes", mebibyte)
+	}
 
 	k := Kinesis{
 		conf:            conf,
@@ -114,6 +140,9 @@ func NewKinesis(
 	if k.backoffCtor, err = conf.Config.GetCtor(); err != nil {
 		return nil, err
 	}
+	if conf.RespectShardLimits {
+		k.limiter = newKinesisShardLimiter()
+	}
 	return &k, nil
 }
 
@@ -151,6 +180,186 @@ func (a *Kinesis) toRecords(msg types.Message) ([]*kinesis.PutRecordsRequestEntr
 
 //------------------------------------------------------------------------------
 
+// shardAware returns whether the shards of the stream need to be known in
+// order to write records.
+func (a *Kinesis) shardAware() bool {
+	return a.conf.Aggregation.Enabled || a.limiter != nil
+}
+
+// refreshShards obtains the hash key ranges of the open shards of the stream.
+func (a *Kinesis) refreshShards(ctx context.Context) error {
+	var shards []kinesisShard
+	input := &kinesis.ListShardsInput{StreamName: a.streamName}
+	for {
+		output, err := a.kinesis.ListShardsWithContext(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to list shards: %w", err)
+		}
+		for _, s := range output.Shards {
+			// Shards with an ending sequence number have been closed by
+			// resharding and no longer accept records.
+			if s.SequenceNumberRange != nil && s.SequenceNumberRange.EndingSequenceNumber != nil {
+				continue
+			}
+			if s.HashKeyRange == nil {
+				continue
+			}
+			start, startOk := new(big.Int).SetString(aws.StringValue(s.HashKeyRange.StartingHashKey), 10)
+			end, endOk := new(big.Int).SetString(aws.StringValue(s.HashKeyRange.EndingHashKey), 10)
+			if !startOk || !endOk {
+				return fmt.Errorf("shard %v has an invalid hash key range", aws.StringValue(s.ShardId))
+			}
+			shards = append(shards, kinesisShard{
+				id:    aws.StringValue(s.ShardId),
+				start: start,
+				end:   end,
+			})
+		}
+		if output.NextToken == nil {
+			break
+		}
+		input = &kinesis.ListShardsInput{NextToken: output.NextToken}
+	}
+
+	a.shardsMut.Lock()
+	a.shards = newKinesisShardMap(shards)
+	a.shardsUpdated = time.Now()
+	a.shardsMut.Unlock()
+	return nil
+}
+
+// getShards returns the shards of the stream, refreshing them first when they
+// are stale. If a refresh fails the stale shards are returned.
+func (a *Kinesis) getShards(ctx context.Context) kinesisShardMap {
+	a.shardsMut.Lock()
+	shards := a.shards
+	stale := time.Since(a.shardsUpdated) >= kinesisShardRefreshPeriod
+	if stale {
+		// Claim the refresh so that concurrent writes continue with the
+		// current shards rather than each refreshing them.
+		a.shardsUpdated = time.Now()
+	}
+	a.shardsMut.Unlock()
+
+	if !stale {
+		return shards
+	}
+	if err := a.refreshShards(ctx); err != nil {
+		a.log.Warnf("Failed to refresh Kinesis shards: %v\n", err)
+		return shards
+	}
+
+	a.shardsMut.RLock()
+	defer a.shardsMut.RUnlock()
+	return a.shards
+}
+
+// prepareRecords determines the shard that each record will be written to and,
+// when aggregation is enabled, packs records destined for the same shard into
+// aggregated records. Records with a shard that cannot be determined are
+// written individually.
+func (a *Kinesis) prepareRecords(
+	ctx context.Context,
+	records []*kinesis.PutRecordsRequestEntry,
+) ([]*kinesis.PutRecordsRequestEntry, map[*kinesis.PutRecordsRequestEntry]string, error) {
+	shards := a.getShards(ctx)
+
+	shardOf := make(map[*kinesis.PutRecordsRequestEntry]string, len(records))
+	hashKeys := make(map[*kinesis.PutRecordsRequestEntry]*big.Int, len(records))
+	groups := map[string][]*kinesis.PutRecordsRequestEntry{}
+	var groupOrder []string
+
+	for _, r := range records {
+		hashKey, err := kinesisHashKey(*r.PartitionKey, r.ExplicitHashKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		id := shards.shardFor(hashKey)
+		shardOf[r], hashKeys[r] = id, hashKey
+		if _, exists := groups[id]; !exists {
+			groupOrder = append(groupOrder, id)
+		}
+		groups[id] = append(groups[id], r)
+	}
+	if !a.conf.Aggregation.Enabled {
+		return records, shardOf, nil
+	}
+
+	aggregated := make([]*kinesis.PutRecordsRequestEntry, 0, len(records))
+	agg := newKinesisAggregator(a.conf.Aggregation.MaxSize)
+	for _, id := range groupOrder {
+		group := groups[id]
+		if id == "" {
+			aggregated = append(aggregated, group...)
+			continue
+		}
+
+		var first *kinesis.PutRecordsRequestEntry
+		flush := func() {
+			if agg.len() == 1 {
+				agg.reset()
+				aggregated = append(aggregated, first)
+				return
+			}
+			entry := &kinesis.PutRecordsRequestEntry{
+				Data:         agg.bytes(),
+				PartitionKey: first.PartitionKey,
+				// Route the aggregated record using the hash key of its first
+				// record so that it is written to the shard of all records.
+				ExplicitHashKey: aws.String(hashKeys[first].String()),
+			}
+			shardOf[entry] = id
+			aggregated = append(aggregated, entry)
+		}
+
+		for _, r := range group {
+			if agg.len() == 0 {
+				first = r
+			}
+			if !agg.add(*r.PartitionKey, r.ExplicitHashKey, r.Data) {
+				flush()
+				first = r
+				agg.add(*r.PartitionKey, r.ExplicitHashKey, r.Data)
+			}
+		}
+		flush()
+	}
+	return aggregated, shardOf, nil
+}
+
+// waitForShards blocks until the throughput limits of the shards targeted by a
+// set of records allow them to be written.
+func (a *Kinesis) waitForShards(
+	ctx context.Context,
+	records []*kinesis.PutRecordsRequestEntry,
+	shardOf map[*kinesis.PutRecordsRequestEntry]string,
+) error {
+	load := map[string]kinesisShardLoad{}
+	for _, r := range records {
+		id := shardOf[r]
+		if id == "" {
+			continue
+		}
+		l := load[id]
+		l.records++
+		l.bytes += len(r.Data) + len(*r.PartitionKey)
+		load[id] = l
+	}
+	for {
+		wait := a.limiter.reserve(time.Now(), load)
+		if wait == 0 {
+			return nil
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
 // Connect creates a new Kinesis client and ensures that the target Kinesis
 // stream exists.
 func (a *Kinesis) Connect() error {
@@ -169,29 +378,38 @@ func (a *Kinesis) ConnectWithContext(ctx context.Context) error {
 		return err
 	}
 
-	a.session = sess
-	a.kinesis = kinesis.New(sess)
-
-	if err := a.kinesis.WaitUntilStreamExists(&kinesis.DescribeStreamInput{
+	k := kinesis.New(sess)
+	if err := k.WaitUntilStreamExists(&kinesis.DescribeStreamInput{
 		StreamName: a.streamName,
 	}); err != nil {
 		return err
 	}
 
+	a.kinesis = k
+	if a.shardAware() {
+		if err := a.refreshShards(ctx); err != nil {
+			a.kinesis = nil
+			return err
+		}
+	}
+	a.session = sess
+
 	a.log.Infof("Sending messages to Kinesis stream: %v\n", a.conf.Stream)
 	return nil
 }
 
 // Write attempts to write message contents to a target Kinesis stream in
-// batches of 500. If throttling is detected, failed messages are retried
-// according to the configurable backoff settings.
+// batches of 500. If records are rejected due to throttling or internal
+// failures only those records are retried according to the configurable backoff
+// settings.
 func (a *Kinesis) Write(msg types.Message) error {
 	return a.WriteWithContext(context.Background(), msg)
 }
 
 // WriteWithContext attempts to write message contents to a target Kinesis
-// stream in batches of 500. If throttling is detected, failed messages are
-// retried according to the configurable backoff settings.
+// stream in batches of 500. If records are rejected due to throttling or
+// internal failures only those records are retried according to the
+// configurable backoff settings.
 func (a *Kinesis) WriteWithContext(ctx context.Context, msg types.Message) error {
 	if a.session == nil {
 		return types.ErrNotConnected
@@ -204,6 +422,13 @@ func (a *Kinesis) WriteWithContext(ctx context.Context, msg types.Message) error
 		return err
 	}
 
+	var shardOf map[*kinesis.PutRecordsRequestEntry]string
+	if a.shardAware() {
+		if records, shardOf, err = a.prepareRecords(ctx, records); err != nil {
+			return err
+		}
+	}
+
 	input := &kinesis.PutRecordsInput{
 		Records:    records,
 		StreamName: a.streamName,
@@ -221,6 +446,12 @@ func (a *Kinesis) WriteWithContext(ctx context.Context, msg types.Message) error
 	for len(input.Records) > 0 {
 		wait := backOff.NextBackOff()
 
+		if a.limiter != nil {
+			if err := a.waitForShards(ctx, input.Records, shardOf); err != nil {
+				return err
+			}
+		}
+
 		// batch write to kinesis
 		output, err := a.kinesis.PutRecords(input)
 		if err != nil {
@@ -232,28 +463,37 @@ func (a *Kinesis) WriteWithContext(ctx context.Context, msg types.Message) error
 			continue
 		}
 
-		// requeue any individual records that failed due to throttling
+		// requeue any individual records that failed due to throttling or
+		// internal failures
 		failed = nil
+		throttledShards := map[string]struct{}{}
 		if output.FailedRecordCount != nil {
 			for i, entry := range output.Records {
 				if entry.ErrorCode != nil {
 					failed = append(failed, input.Records[i])
-					if *entry.ErrorCode != kinesis.ErrCodeProvisionedThroughputExceededException && *entry.ErrorCode != kinesis.ErrCodeKMSThrottlingException {
-						err = fmt.Errorf("record failed with code [%s] %s: %+v", *entry.ErrorCode, *entry.ErrorMessage, input.Records[i])
+					switch *entry.ErrorCode {
+					case kinesis.ErrCodeProvisionedThroughputExceededException, kinesis.ErrCodeKMSThrottlingException:
+						throttledShards[shardOf[input.Records[i]]] = struct{}{}
+					case kinesisErrCodeInternalFailure:
+					default:
+						err = fmt.Errorf("record failed with code [%s] %s: %+v", *entry.ErrorCode, aws.StringValue(entry.ErrorMessage), input.Records[i])
 						a.log.Errorf("kinesis record error: %v\n", err)
 						return err
 					}
 				}
 			}
 		}
+		if a.limiter != nil {
+			a.updateShardLimits(input.Records, shardOf, throttledShards)
+		}
 		input.Records = failed
 
-		// if throttling errors detected, pause briefly
+		// if records were rejected, pause briefly
 		l := len(failed)
 		if l > 0 {
 			a.mThrottled.Incr(1)
 			a.mPartsThrottled.Incr(int64(l))
-			a.log.Warnf("scheduling retry of throttled records (%d)\n", l)
+			a.log.Warnf("scheduling retry of rejected records (%d)\n", l)
 			if wait == backoff.Stop {
 				return types.ErrTimeout
 			}
@@ -272,6 +512,28 @@ func (a *Kinesis) WriteWithContext(ctx context.Context, msg types.Message) error
 	return err
 }
 
+// updateShardLimits adjusts the throughput limits of each shard written to
+// depending on whether any of its records were throttled.
+func (a *Kinesis) updateShardLimits(
+	records []*kinesis.PutRecordsRequestEntry,
+	shardOf map[*kinesis.PutRecordsRequestEntry]string,
+	throttledShards map[string]struct{},
+) {
+	seen := map[string]struct{}{}
+	for _, r := range records {
+		id := shardOf[r]
+		if _, exists := seen[id]; exists || id == "" {
+			continue
+		}
+		seen[id] = struct{}{}
+		if _, throttled := throttledShards[id]; throttled {
+			a.limiter.throttled(id)
+		} else {
+			a.limiter.succeeded(id)
+		}
+	}
+}
+
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
 func (a *Kinesis) CloseAsync() {
 }
@@ -0,0 +1,163 @@
+package writer
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+)
+
+//------------------------------------------------------------------------------
+
+// kplMagic is the prefix of records aggregated in the format of the Kinesis
+// Producer Library (KPL), which allows consumers such as the Kinesis Client
+// Library to identify and deaggregate them.
+var kplMagic = []byte{0xF3, 0x89, 0x9A, 0xC2}
+
+// kinesisAggregator packs multiple user records into a single Kinesis record
+// following the KPL aggregation format, which is the magic prefix followed by
+// an AggregatedRecord protobuf message and the MD5 checksum of that message:
+//
+//	message AggregatedRecord {
+//	  repeated string partition_key_table     = 1;
+//	  repeated string explicit_hash_key_table = 2;
+//	  repeated Record records                 = 3;
+//	}
+//
+//	message Record {
+//	  required uint64 partition_key_index     = 1;
+//	  optional uint64 explicit_hash_key_index = 2;
+//	  required bytes  data                    = 3;
+//	}
+type kinesisAggregator struct {
+	maxSize int
+
+	partitionKeys     map[string]uint64
+	partitionKeyTable []string
+	hashKeys          map[string]uint64
+	hashKeyTable      []string
+	records           [][]byte
+
+	// size is the encoded size of the AggregatedRecord message.
+	size int
+}
+
+func newKinesisAggregator(maxSize int) *kinesisAggregator {
+	a := &kinesisAggregator{maxSize: maxSize}
+	a.reset()
+	return a
+}
+
+func (a *kinesisAggregator) reset() {
+	a.partitionKeys = map[string]uint64{}
+	a.partitionKeyTable = nil
+	a.hashKeys = map[string]uint64{}
+	a.hashKeyTable = nil
+	a.records = nil
+	a.size = 0
+}
+
+// len returns the number of records currently aggregated.
+func (a *kinesisAggregator) len() int {
+	return len(a.records)
+}
+
+func uvarintSize(v uint64) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+	return n
+}
+
+// fieldSize returns the encoded size of a length delimited field.
+func fieldSize(l int) int {
+	return 1 + uvarintSize(uint64(l)) + l
+}
+
+// add attempts to add a record to the aggregate and returns false if doing so
+// would exceed the maximum size of the aggregated record. A record is always
+// added when the aggregate is empty.
+func (a *kinesisAggregator) add(partitionKey string, hashKey *string, data []byte) bool {
+	newSize := a.size
+
+	pkIndex, pkExists := a.partitionKeys[partitionKey]
+	if !pkExists {
+		pkIndex = uint64(len(a.partitionKeyTable))
+		newSize += fieldSize(len(partitionKey))
+	}
+
+	recordSize := 1 + uvarintSize(pkIndex) + fieldSize(len(data))
+
+	var hkIndex uint64
+	var hkExists bool
+	if hashKey != nil {
+		if hkIndex, hkExists = a.hashKeys[*hashKey]; !hkExists {
+			hkIndex = uint64(len(a.hashKeyTable))
+			newSize += fieldSize(len(*hashKey))
+		}
+		recordSize += 1 + uvarintSize(hkIndex)
+	}
+	newSize += fieldSize(recordSize)
+
+	if len(a.records) > 0 && len(kplMagic)+newSize+md5.Size > a.maxSize {
+		return false
+	}
+
+	if !pkExists {
+		a.partitionKeys[partitionKey] = pkIndex
+		a.partitionKeyTable = append(a.partitionKeyTable, partitionKey)
+	}
+	if hashKey != nil && !hkExists {
+		a.hashKeys[*hashKey] = hkIndex
+		a.hashKeyTable = append(a.hashKeyTable, *hashKey)
+	}
+
+	record := make([]byte, 0, recordSize)
+	record = append(record, 0x08)
+	record = appendUvarint(record, pkIndex)
+	if hashKey != nil {
+		record = append(record, 0x10)
+		record = appendUvarint(record, hkIndex)
+	}
+	record = appendBytesField(record, 0x1A, data)
+	a.records = append(a.records, record)
+
+	a.size = newSize
+	return true
+}
+
+// bytes encodes the aggregated record and resets the aggregator.
+func (a *kinesisAggregator) bytes() []byte {
+	msg := make([]byte, 0, a.size)
+	for _, k := range a.partitionKeyTable {
+		msg = appendBytesField(msg, 0x0A, []byte(k))
+	}
+	for _, k := range a.hashKeyTable {
+		msg = appendBytesField(msg, 0x12, []byte(k))
+	}
+	for _, r := range a.records {
+		msg = appendBytesField(msg, 0x1A, r)
+	}
+	a.reset()
+
+	sum := md5.Sum(msg)
+
+	b := make([]byte, 0, len(kplMagic)+len(msg)+md5.Size)
+	b = append(b, kplMagic...)
+	b = append(b, msg...)
+	return append(b, sum[:]...)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+func appendBytesField(b []byte, tag byte, v []byte) []byte {
+	b = append(b, tag)
+	b = appendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+//------------------------------------------------------------------------------
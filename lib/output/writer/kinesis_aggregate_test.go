@@ -0,0 +1,201 @@
+package writer
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type kplRecord struct {
+	partitionKey string
+	hashKey      string
+	data         string
+}
+
+// readKPLFields reads the length delimited and varint fields of a protobuf
+// message, which is all that the KPL aggregation format uses.
+func readKPLFields(t *testing.T, b []byte, fn func(field int, v uint64, data []byte)) {
+	t.Helper()
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		require.True(t, n > 0)
+		b = b[n:]
+
+		v, n := binary.Uvarint(b)
+		require.True(t, n > 0)
+		b = b[n:]
+
+		switch tag & 0x7 {
+		case 0:
+			fn(int(tag>>3), v, nil)
+		case 2:
+			require.True(t, int(v) <= len(b))
+			fn(int(tag>>3), 0, b[:v])
+			b = b[v:]
+		default:
+			t.Fatalf("unexpected wire type: %v", tag&0x7)
+		}
+	}
+}
+
+func decodeKPL(t *testing.T, b []byte) []kplRecord {
+	t.Helper()
+
+	require.True(t, len(b) > len(kplMagic)+md5.Size)
+	require.Equal(t, kplMagic, b[:len(kplMagic)])
+
+	msg := b[len(kplMagic) : len(b)-md5.Size]
+	sum := md5.Sum(msg)
+	require.Equal(t, sum[:], b[len(b)-md5.Size:])
+
+	var partitionKeys, hashKeys []string
+	var records []kplRecord
+	readKPLFields(t, msg, func(field int, _ uint64, data []byte) {
+		switch field {
+		case 1:
+			partitionKeys = append(partitionKeys, string(data))
+		case 2:
+			hashKeys = append(hashKeys, string(data))
+		case 3:
+			var r kplRecord
+			readKPLFields(t, data, func(field int, v uint64, data []byte) {
+				switch field {
+				case 1:
+					r.partitionKey = partitionKeys[v]
+				case 2:
+					r.hashKey = hashKeys[v]
+				case 3:
+					r.data = string(data)
+				}
+			})
+			records = append(records, r)
+		}
+	})
+	return records
+}
+
+func TestKinesisAggregator(t *testing.T) {
+	agg := newKinesisAggregator(mebibyte)
+
+	hashKey := "12345"
+	require.True(t, agg.add("foo", nil, []byte("first")))
+	require.True(t, agg.add("bar", &hashKey, []byte("second")))
+	require.True(t, agg.add("foo", &hashKey, []byte(strings.Repeat("x", 200))))
+	assert.Equal(t, 3, agg.len())
+
+	b := agg.bytes()
+	assert.Equal(t, 0, agg.len())
+	assert.Equal(t, []kplRecord{
+		{partitionKey: "foo", data: "first"},
+		{partitionKey: "bar", hashKey: "12345", data: "second"},
+		{partitionKey: "foo", hashKey: "12345", data: strings.Repeat("x", 200)},
+	}, decodeKPL(t, b))
+}
+
+func TestKinesisAggregatorMaxSize(t *testing.T) {
+	agg := newKinesisAggregator(100)
+
+	var aggregated [][]byte
+	for i := 0; i < 10; i++ {
+		data := []byte(fmt.Sprintf("record %v", i))
+		if !agg.add("foo", nil, data) {
+			aggregated = append(aggregated, agg.bytes())
+			require.True(t, agg.add("foo", nil, data))
+		}
+	}
+	aggregated = append(aggregated, agg.bytes())
+
+	var i int
+	for _, b := range aggregated {
+		assert.True(t, len(b) <= 100, len(b))
+		for _, r := range decodeKPL(t, b) {
+			assert.Equal(t, fmt.Sprintf("record %v", i), r.data)
+			i++
+		}
+	}
+	assert.Equal(t, 10, i)
+	assert.True(t, len(aggregated) > 1)
+
+	// A record larger than the max size is still accepted when alone.
+	require.True(t, agg.add("foo", nil, []byte(strings.Repeat("x", 200))))
+	require.False(t, agg.add("foo", nil, []byte("y")))
+}
+
+func TestKinesisShardMap(t *testing.T) {
+	m := newKinesisShardMap([]kinesisShard{
+		{id: "b", start: big.NewInt(100), end: big.NewInt(199)},
+		{id: "a", start: big.NewInt(0), end: big.NewInt(99)},
+		{id: "c", start: big.NewInt(300), end: big.NewInt(399)},
+	})
+
+	for k, exp := range map[int64]string{
+		0:   "a",
+		99:  "a",
+		100: "b",
+		150: "b",
+		250: "",
+		399: "c",
+		400: "",
+	} {
+		assert.Equal(t, exp, m.shardFor(big.NewInt(k)), k)
+	}
+
+	hashKey := "150"
+	k, err := kinesisHashKey("foo", &hashKey)
+	require.NoError(t, err)
+	assert.Equal(t, "150", k.String())
+
+	// The MD5 hash of "foo" as a 128-bit integer.
+	k, err = kinesisHashKey("foo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "229609063533823256041787889330700985560", k.String())
+
+	hashKey = "nope"
+	_, err = kinesisHashKey("foo", &hashKey)
+	require.Error(t, err)
+}
+
+func TestKinesisShardLimiter(t *testing.T) {
+	l := newKinesisShardLimiter()
+	now := time.Now()
+
+	load := map[string]kinesisShardLoad{
+		"a": {records: 600, bytes: 100},
+	}
+	assert.Equal(t, time.Duration(0), l.reserve(now, load))
+	assert.Equal(t, time.Second, l.reserve(now, load))
+	assert.Equal(t, 500*time.Millisecond, l.reserve(now.Add(500*time.Millisecond), load))
+
+	// Other shards are unaffected.
+	assert.Equal(t, time.Duration(0), l.reserve(now, map[string]kinesisShardLoad{
+		"b": {records: 600, bytes: 100},
+	}))
+
+	// A new window accepts the load again.
+	assert.Equal(t, time.Duration(0), l.reserve(now.Add(time.Second), load))
+
+	// Throttling halves the limits of a shard.
+	l.throttled("a")
+	now = now.Add(2 * time.Second)
+	assert.Equal(t, time.Duration(0), l.reserve(now, map[string]kinesisShardLoad{
+		"a": {records: 400},
+	}))
+	assert.Equal(t, time.Second, l.reserve(now, map[string]kinesisShardLoad{
+		"a": {records: 101},
+	}))
+
+	// Successes recover the limits of a shard.
+	for i := 0; i < 5; i++ {
+		l.succeeded("a")
+	}
+	assert.Equal(t, time.Duration(0), l.reserve(now, map[string]kinesisShardLoad{
+		"a": {records: 101},
+	}))
+}
@@ -0,0 +1,155 @@
+package writer
+
+import (
+	"crypto/md5"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	kinesisShardRecordsPerSecond = 1000
+	kinesisShardBytesPerSecond   = mebibyte
+	kinesisShardRefreshPeriod    = time.Minute
+)
+
+// kinesisHashKey returns the hash key that Kinesis uses in order to determine
+// which shard a record is written to, which is either the explicit hash key of
+// the record or the MD5 hash of its partition key.
+func kinesisHashKey(partitionKey string, explicitHashKey *string) (*big.Int, error) {
+	if explicitHashKey != nil {
+		k, ok := new(big.Int).SetString(*explicitHashKey, 10)
+		if !ok {
+			return nil, fmt.Errorf("explicit hash key '%v' is not a decimal integer", *explicitHashKey)
+		}
+		return k, nil
+	}
+	sum := md5.Sum([]byte(partitionKey))
+	return new(big.Int).SetBytes(sum[:]), nil
+}
+
+type kinesisShard struct {
+	id         string
+	start, end *big.Int
+}
+
+// kinesisShardMap is the set of open shards of a stream sorted by the start of
+// their hash key ranges.
+type kinesisShardMap []kinesisShard
+
+func newKinesisShardMap(shards []kinesisShard) kinesisShardMap {
+	sort.Slice(shards, func(i, j int) bool {
+		return shards[i].start.Cmp(shards[j].start) < 0
+	})
+	return kinesisShardMap(shards)
+}
+
+// shardFor returns the ID of the shard that owns a hash key, or an empty
+// string if the hash key is not within the range of any known shard.
+func (m kinesisShardMap) shardFor(hashKey *big.Int) string {
+	i := sort.Search(len(m), func(i int) bool {
+		return m[i].end.Cmp(hashKey) >= 0
+	})
+	if i < len(m) && m[i].start.Cmp(hashKey) <= 0 {
+		return m[i].id
+	}
+	return ""
+}
+
+//------------------------------------------------------------------------------
+
+type kinesisShardLoad struct {
+	records int
+	bytes   int
+}
+
+type kinesisShardUsage struct {
+	window  time.Time
+	records int
+	bytes   int
+	factor  float64
+}
+
+// kinesisShardLimiter tracks the records and bytes written to each shard of a
+// stream within the current second, and delays writes that would exceed the
+// throughput limits of a shard. Other producers may be writing to the same
+// shards, and so the limits of a shard are halved each time writes to it are
+// throttled and recover gradually as writes succeed.
+type kinesisShardLimiter struct {
+	mut    sync.Mutex
+	shards map[string]*kinesisShardUsage
+}
+
+func newKinesisShardLimiter() *kinesisShardLimiter {
+	return &kinesisShardLimiter{
+		shards: map[string]*kinesisShardUsage{},
+	}
+}
+
+func (l *kinesisShardLimiter) usage(id string) *kinesisShardUsage {
+	u, exists := l.shards[id]
+	if !exists {
+		u = &kinesisShardUsage{factor: 1}
+		l.shards[id] = u
+	}
+	return u
+}
+
+// reserve attempts to reserve throughput for a load of records across shards,
+// and returns zero if successful. Otherwise nothing is reserved and the period
+// to wait before trying again is returned.
+func (l *kinesisShardLimiter) reserve(now time.Time, load map[string]kinesisShardLoad) time.Duration {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	var wait time.Duration
+	for id, ld := range load {
+		u := l.usage(id)
+		if now.Sub(u.window) >= time.Second {
+			u.window, u.records, u.bytes = now, 0, 0
+		}
+		// An empty window always accepts a load as otherwise loads larger than
+		// the limits of a shard would never be written.
+		if u.records == 0 {
+			continue
+		}
+		if float64(u.records+ld.records) > kinesisShardRecordsPerSecond*u.factor ||
+			float64(u.bytes+ld.bytes) > kinesisShardBytesPerSecond*u.factor {
+			if w := u.window.Add(time.Second).Sub(now); w > wait {
+				wait = w
+			}
+		}
+	}
+	if wait > 0 {
+		return wait
+	}
+	for id, ld := range load {
+		u := l.usage(id)
+		u.records += ld.records
+		u.bytes += ld.bytes
+	}
+	return 0
+}
+
+// throttled reduces the limits of a shard after writes to it were throttled.
+func (l *kinesisShardLimiter) throttled(id string) {
+	l.mut.Lock()
+	u := l.usage(id)
+	u.factor = math.Max(0.1, u.factor/2)
+	l.mut.Unlock()
+}
+
+// succeeded recovers the limits of a shard after writes to it succeeded.
+func (l *kinesisShardLimiter) succeeded(id string) {
+	l.mut.Lock()
+	u := l.usage(id)
+	u.factor = math.Min(1, u.factor+0.1)
+	l.mut.Unlock()
+}
+
+//------------------------------------------------------------------------------
@@ -1,9 +1,11 @@
 package writer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -11,10 +13,13 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/kinesis"
 	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
 	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -30,13 +35,46 @@ var (
 
 type mockKinesis struct {
 	kinesisiface.KinesisAPI
-	fn func(input *kinesis.PutRecordsInput) (*kinesis.PutRecordsOutput, error)
+	fn     func(input *kinesis.PutRecordsInput) (*kinesis.PutRecordsOutput, error)
+	shards []*kinesis.Shard
 }
 
 func (m *mockKinesis) PutRecords(input *kinesis.PutRecordsInput) (*kinesis.PutRecordsOutput, error) {
 	return m.fn(input)
 }
 
+func (m *mockKinesis) ListShardsWithContext(ctx context.Context, input *kinesis.ListShardsInput, opts ...request.Option) (*kinesis.ListShardsOutput, error) {
+	// Return one shard per page in order to exercise pagination.
+	i := 0
+	if input.NextToken != nil {
+		fmt.Sscanf(*input.NextToken, "%d", &i)
+	}
+	output := &kinesis.ListShardsOutput{
+		Shards: m.shards[i : i+1],
+	}
+	if i+1 < len(m.shards) {
+		output.NextToken = aws.String(fmt.Sprintf("%d", i+1))
+	}
+	return output, nil
+}
+
+func mockShard(id, start, end string, closed bool) *kinesis.Shard {
+	s := &kinesis.Shard{
+		ShardId: aws.String(id),
+		HashKeyRange: &kinesis.HashKeyRange{
+			StartingHashKey: aws.String(start),
+			EndingHashKey:   aws.String(end),
+		},
+		SequenceNumberRange: &kinesis.SequenceNumberRange{
+			StartingSequenceNumber: aws.String("0"),
+		},
+	}
+	if closed {
+		s.SequenceNumberRange.EndingSequenceNumber = aws.String("1")
+	}
+	return s
+}
+
 func TestKinesisWriteSinglePartMessage(t *testing.T) {
 	k := Kinesis{
 		backoffCtor: func() backoff.BackOff {
@@ -357,3 +395,112 @@ func TestKinesisWriteBackoffMaxRetriesExceeded(t *testing.T) {
 		t.Errorf("Expected kinesis.PutRecords to have call count %d, got %d", exp, calls)
 	}
 }
+
+func TestKinesisWriteAggregated(t *testing.T) {
+	var calls [][]*kinesis.PutRecordsRequestEntry
+	mock := &mockKinesis{
+		fn: func(input *kinesis.PutRecordsInput) (*kinesis.PutRecordsOutput, error) {
+			calls = append(calls, input.Records)
+			output := &kinesis.PutRecordsOutput{FailedRecordCount: aws.Int64(0)}
+			for range input.Records {
+				output.Records = append(output.Records, &kinesis.PutRecordsResultEntry{})
+			}
+			return output, nil
+		},
+		shards: []*kinesis.Shard{
+			mockShard("shard-0", "0", "170141183460469231731687303715884105727", false),
+			mockShard("shard-1", "170141183460469231731687303715884105728", "340282366920938463463374607431768211455", false),
+			mockShard("shard-2", "0", "340282366920938463463374607431768211455", true),
+		},
+	}
+
+	conf := NewKinesisConfig()
+	conf.PartitionKey = `${! json("id") }`
+	conf.Aggregation.Enabled = true
+	conf.RespectShardLimits = true
+
+	k, err := NewKinesis(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	k.session = session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("xxxxx", "xxxxx", "xxxxx"),
+	}))
+	k.kinesis = mock
+	require.NoError(t, k.refreshShards(context.Background()))
+	require.Len(t, k.shards, 2)
+
+	// The MD5 hashes of the partition keys a and c fall within shard-0, and
+	// the hash of b falls within shard-1.
+	msg := message.New([][]byte{
+		[]byte(`{"id":"a","n":1}`),
+		[]byte(`{"id":"b","n":2}`),
+		[]byte(`{"id":"c","n":3}`),
+	})
+	require.NoError(t, k.Write(msg))
+	require.Len(t, calls, 1)
+	require.Len(t, calls[0], 2)
+
+	agg := calls[0][0]
+	assert.Equal(t, "a", *agg.PartitionKey)
+	hashKey, err := kinesisHashKey("a", nil)
+	require.NoError(t, err)
+	assert.Equal(t, hashKey.String(), *agg.ExplicitHashKey)
+	assert.Equal(t, []kplRecord{
+		{partitionKey: "a", data: `{"id":"a","n":1}`},
+		{partitionKey: "c", data: `{"id":"c","n":3}`},
+	}, decodeKPL(t, agg.Data))
+
+	single := calls[0][1]
+	assert.Equal(t, "b", *single.PartitionKey)
+	assert.Nil(t, single.ExplicitHashKey)
+	assert.Equal(t, `{"id":"b","n":2}`, string(single.Data))
+}
+
+func TestKinesisWriteRetriesRejectedRecords(t *testing.T) {
+	var calls [][]string
+	k := Kinesis{
+		backoffCtor: func() backoff.BackOff {
+			return backoff.NewConstantBackOff(time.Millisecond)
+		},
+		session: session.Must(session.NewSession(&aws.Config{
+			Credentials: credentials.NewStaticCredentials("xxxxx", "xxxxx", "xxxxx"),
+		})),
+		kinesis: &mockKinesis{
+			fn: func(input *kinesis.PutRecordsInput) (*kinesis.PutRecordsOutput, error) {
+				var keys []string
+				var failed int64
+				output := &kinesis.PutRecordsOutput{}
+				for _, r := range input.Records {
+					keys = append(keys, *r.PartitionKey)
+					entry := &kinesis.PutRecordsResultEntry{}
+					if len(calls) == 0 && *r.PartitionKey != "123" {
+						failed++
+						entry.ErrorCode = aws.String(kinesisErrCodeInternalFailure)
+						entry.ErrorMessage = aws.String("Internal service failure.")
+					}
+					output.Records = append(output.Records, entry)
+				}
+				output.FailedRecordCount = aws.Int64(failed)
+				calls = append(calls, keys)
+				return output, nil
+			},
+		},
+		mThrottled:      mThrottled,
+		mPartsThrottled: mPartsThrottled,
+		log:             log.Noop(),
+	}
+
+	k.partitionKey, _ = bloblang.NewField("${!json(\"id\")}")
+	k.hashKey, _ = bloblang.NewField("")
+
+	msg := message.New([][]byte{
+		[]byte(`{"foo":"bar","id":123}`),
+		[]byte(`{"foo":"baz","id":456}`),
+		[]byte(`{"foo":"qux","id":789}`),
+	})
+	require.NoError(t, k.Write(msg))
+	assert.Equal(t, [][]string{
+		{"123", "456", "789"},
+		{"456", "789"},
+	}, calls)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
@@ -19,13 +20,14 @@ import (
 
 // MQTTConfig contains configuration fields for the MQTT output type.
 type MQTTConfig struct {
-	URLs        []string `json:"urls" yaml:"urls"`
-	QoS         uint8    `json:"qos" yaml:"qos"`
-	Topic       string   `json:"topic" yaml:"topic"`
-	ClientID    string   `json:"client_id" yaml:"client_id"`
-	User        string   `json:"user" yaml:"user"`
-	Password    string   `json:"password" yaml:"password"`
-	MaxInFlight int      `json:"max_in_flight" yaml:"max_in_flight"`
+	URLs        []string           `json:"urls" yaml:"urls"`
+	QoS         uint8              `json:"qos" yaml:"qos"`
+	Topic       string             `json:"topic" yaml:"topic"`
+	ClientID    string             `json:"client_id" yaml:"client_id"`
+	User        string             `json:"user" yaml:"user"`
+	Password    string             `json:"password" yaml:"password"`
+	MaxInFlight int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching    batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
 
 // NewMQTTConfig creates a new MQTTConfig with default values.
@@ -38,6 +40,7 @@ func NewMQTTConfig() MQTTConfig {
 		User:        "",
 		Password:    "",
 		MaxInFlight: 1,
+		Batching:    batch.NewPolicyConfig(),
 	}
 }
 
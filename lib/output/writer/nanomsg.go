@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"go.nanomsg.org/mangos/v3"
@@ -22,11 +23,12 @@ import (
 
 // NanomsgConfig contains configuration fields for the Nanomsg output type.
 type NanomsgConfig struct {
-	URLs        []string `json:"urls" yaml:"urls"`
-	Bind        bool     `json:"bind" yaml:"bind"`
-	SocketType  string   `json:"socket_type" yaml:"socket_type"`
-	PollTimeout string   `json:"poll_timeout" yaml:"poll_timeout"`
-	MaxInFlight int      `json:"max_in_flight" yaml:"max_in_flight"`
+	URLs        []string           `json:"urls" yaml:"urls"`
+	Bind        bool               `json:"bind" yaml:"bind"`
+	SocketType  string             `json:"socket_type" yaml:"socket_type"`
+	PollTimeout string             `json:"poll_timeout" yaml:"poll_timeout"`
+	MaxInFlight int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching    batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
 
 // NewNanomsgConfig creates a new NanomsgConfig with default values.
@@ -37,6 +39,7 @@ func NewNanomsgConfig() NanomsgConfig {
 		SocketType:  "PUSH",
 		PollTimeout: "5s",
 		MaxInFlight: 1,
+		Batching:    batch.NewPolicyConfig(),
 	}
 }
 
@@ -13,6 +13,7 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/nats-io/nats.go"
@@ -22,10 +23,11 @@ import (
 
 // NATSConfig contains configuration fields for the NATS output type.
 type NATSConfig struct {
-	URLs        []string    `json:"urls" yaml:"urls"`
-	Subject     string      `json:"subject" yaml:"subject"`
-	MaxInFlight int         `json:"max_in_flight" yaml:"max_in_flight"`
-	TLS         btls.Config `json:"tls" yaml:"tls"`
+	URLs        []string           `json:"urls" yaml:"urls"`
+	Subject     string             `json:"subject" yaml:"subject"`
+	MaxInFlight int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching    batch.PolicyConfig `json:"batching" yaml:"batching"`
+	TLS         btls.Config        `json:"tls" yaml:"tls"`
 }
 
 // NewNATSConfig creates a new NATSConfig with default values.
@@ -34,6 +36,7 @@ func NewNATSConfig() NATSConfig {
 		URLs:        []string{nats.DefaultURL},
 		Subject:     "benthos_messages",
 		MaxInFlight: 1,
+		Batching:    batch.NewPolicyConfig(),
 		TLS:         btls.NewConfig(),
 	}
 }
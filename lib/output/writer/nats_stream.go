@@ -13,6 +13,7 @@ import (
 	"github.com/nats-io/nats.go"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/nats-io/stan.go"
@@ -23,12 +24,13 @@ import (
 // NATSStreamConfig contains configuration fields for the NATSStream output
 // type.
 type NATSStreamConfig struct {
-	URLs        []string    `json:"urls" yaml:"urls"`
-	ClusterID   string      `json:"cluster_id" yaml:"cluster_id"`
-	ClientID    string      `json:"client_id" yaml:"client_id"`
-	Subject     string      `json:"subject" yaml:"subject"`
-	MaxInFlight int         `json:"max_in_flight" yaml:"max_in_flight"`
-	TLS         btls.Config `json:"tls" yaml:"tls"`
+	URLs        []string           `json:"urls" yaml:"urls"`
+	ClusterID   string             `json:"cluster_id" yaml:"cluster_id"`
+	ClientID    string             `json:"client_id" yaml:"client_id"`
+	Subject     string             `json:"subject" yaml:"subject"`
+	MaxInFlight int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching    batch.PolicyConfig `json:"batching" yaml:"batching"`
+	TLS         btls.Config        `json:"tls" yaml:"tls"`
 }
 
 // NewNATSStreamConfig creates a new NATSStreamConfig with default values.
@@ -39,6 +41,7 @@ func NewNATSStreamConfig() NATSStreamConfig {
 		ClientID:    "benthos_client",
 		Subject:     "benthos_messages",
 		MaxInFlight: 1,
+		Batching:    batch.NewPolicyConfig(),
 		TLS:         btls.NewConfig(),
 	}
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
@@ -22,11 +23,12 @@ import (
 
 // NSQConfig contains configuration fields for the NSQ output type.
 type NSQConfig struct {
-	Address     string      `json:"nsqd_tcp_address" yaml:"nsqd_tcp_address"`
-	Topic       string      `json:"topic" yaml:"topic"`
-	UserAgent   string      `json:"user_agent" yaml:"user_agent"`
-	TLS         btls.Config `json:"tls" yaml:"tls"`
-	MaxInFlight int         `json:"max_in_flight" yaml:"max_in_flight"`
+	Address     string             `json:"nsqd_tcp_address" yaml:"nsqd_tcp_address"`
+	Topic       string             `json:"topic" yaml:"topic"`
+	UserAgent   string             `json:"user_agent" yaml:"user_agent"`
+	TLS         btls.Config        `json:"tls" yaml:"tls"`
+	MaxInFlight int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching    batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
 
 // NewNSQConfig creates a new NSQConfig with default values.
@@ -37,6 +39,7 @@ func NewNSQConfig() NSQConfig {
 		UserAgent:   "benthos_producer",
 		TLS:         btls.NewConfig(),
 		MaxInFlight: 1,
+		Batching:    batch.NewPolicyConfig(),
 	}
 }
 
@@ -11,6 +11,7 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	bredis "github.com/Jeffail/benthos/v3/internal/service/redis"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/go-redis/redis/v7"
@@ -21,11 +22,12 @@ import (
 // RedisHashConfig contains configuration fields for the RedisHash output type.
 type RedisHashConfig struct {
 	bredis.Config  `json:",inline" yaml:",inline"`
-	Key            string            `json:"key" yaml:"key"`
-	WalkMetadata   bool              `json:"walk_metadata" yaml:"walk_metadata"`
-	WalkJSONObject bool              `json:"walk_json_object" yaml:"walk_json_object"`
-	Fields         map[string]string `json:"fields" yaml:"fields"`
-	MaxInFlight    int               `json:"max_in_flight" yaml:"max_in_flight"`
+	Key            string             `json:"key" yaml:"key"`
+	WalkMetadata   bool               `json:"walk_metadata" yaml:"walk_metadata"`
+	WalkJSONObject bool               `json:"walk_json_object" yaml:"walk_json_object"`
+	Fields         map[string]string  `json:"fields" yaml:"fields"`
+	MaxInFlight    int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching       batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
 
 // NewRedisHashConfig creates a new RedisHashConfig with default values.
@@ -37,6 +39,7 @@ func NewRedisHashConfig() RedisHashConfig {
 		WalkJSONObject: false,
 		Fields:         map[string]string{},
 		MaxInFlight:    1,
+		Batching:       batch.NewPolicyConfig(),
 	}
 }
 
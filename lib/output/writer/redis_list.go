@@ -10,6 +10,7 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	bredis "github.com/Jeffail/benthos/v3/internal/service/redis"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/go-redis/redis/v7"
@@ -20,8 +21,9 @@ import (
 // RedisListConfig contains configuration fields for the RedisList output type.
 type RedisListConfig struct {
 	bredis.Config `json:",inline" yaml:",inline"`
-	Key           string `json:"key" yaml:"key"`
-	MaxInFlight   int    `json:"max_in_flight" yaml:"max_in_flight"`
+	Key           string             `json:"key" yaml:"key"`
+	MaxInFlight   int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching      batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
 
 // NewRedisListConfig creates a new RedisListConfig with default values.
@@ -30,6 +32,7 @@ func NewRedisListConfig() RedisListConfig {
 		Config:      bredis.NewConfig(),
 		Key:         "benthos_list",
 		MaxInFlight: 1,
+		Batching:    batch.NewPolicyConfig(),
 	}
 }
 
@@ -10,6 +10,7 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	bredis "github.com/Jeffail/benthos/v3/internal/service/redis"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/go-redis/redis/v7"
@@ -21,8 +22,9 @@ import (
 // type.
 type RedisPubSubConfig struct {
 	bredis.Config `json:",inline" yaml:",inline"`
-	Channel       string `json:"channel" yaml:"channel"`
-	MaxInFlight   int    `json:"max_in_flight" yaml:"max_in_flight"`
+	Channel       string             `json:"channel" yaml:"channel"`
+	MaxInFlight   int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching      batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
 
 // NewRedisPubSubConfig creates a new RedisPubSubConfig with default values.
@@ -31,6 +33,7 @@ func NewRedisPubSubConfig() RedisPubSubConfig {
 		Config:      bredis.NewConfig(),
 		Channel:     "benthos_chan",
 		MaxInFlight: 1,
+		Batching:    batch.NewPolicyConfig(),
 	}
 }
 
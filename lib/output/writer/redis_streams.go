@@ -6,9 +6,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
 	"github.com/Jeffail/benthos/v3/internal/component/output"
 	bredis "github.com/Jeffail/benthos/v3/internal/service/redis"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/go-redis/redis/v7"
@@ -19,22 +23,26 @@ import (
 // RedisStreamsConfig contains configuration fields for the RedisStreams output type.
 type RedisStreamsConfig struct {
 	bredis.Config `json:",inline" yaml:",inline"`
-	Stream        string          `json:"stream" yaml:"stream"`
-	BodyKey       string          `json:"body_key" yaml:"body_key"`
-	MaxLenApprox  int64           `json:"max_length" yaml:"max_length"`
-	MaxInFlight   int             `json:"max_in_flight" yaml:"max_in_flight"`
-	Metadata      output.Metadata `json:"metadata" yaml:"metadata"`
+	Stream        string             `json:"stream" yaml:"stream"`
+	BodyKey       string             `json:"body_key" yaml:"body_key"`
+	FieldsMapping string             `json:"fields_mapping" yaml:"fields_mapping"`
+	MaxLenApprox  int64              `json:"max_length" yaml:"max_length"`
+	MaxInFlight   int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching      batch.PolicyConfig `json:"batching" yaml:"batching"`
+	Metadata      output.Metadata    `json:"metadata" yaml:"metadata"`
 }
 
 // NewRedisStreamsConfig creates a new RedisStreamsConfig with default values.
 func NewRedisStreamsConfig() RedisStreamsConfig {
 	return RedisStreamsConfig{
-		Config:       bredis.NewConfig(),
-		Stream:       "benthos_stream",
-		BodyKey:      "body",
-		MaxLenApprox: 0,
-		MaxInFlight:  1,
-		Metadata:     output.NewMetadata(),
+		Config:        bredis.NewConfig(),
+		Stream:        "benthos_stream",
+		BodyKey:       "body",
+		FieldsMapping: "",
+		MaxLenApprox:  0,
+		MaxInFlight:   1,
+		Batching:      batch.NewPolicyConfig(),
+		Metadata:      output.NewMetadata(),
 	}
 }
 
@@ -45,8 +53,9 @@ type RedisStreams struct {
 	log   log.Modular
 	stats metrics.Type
 
-	conf       RedisStreamsConfig
-	metaFilter *output.MetadataFilter
+	conf          RedisStreamsConfig
+	metaFilter    *output.MetadataFilter
+	fieldsMapping *mapping.Executor
 
 	client  redis.UniversalClient
 	connMut sync.RWMutex
@@ -69,6 +78,11 @@ func NewRedisStreams(
 	if r.metaFilter, err = conf.Metadata.Filter(); err != nil {
 		return nil, fmt.Errorf("failed to construct metadata filter: %w", err)
 	}
+	if conf.FieldsMapping != "" {
+		if r.fieldsMapping, err = bloblang.NewMapping("", conf.FieldsMapping); err != nil {
+			return nil, fmt.Errorf("failed to parse fields mapping: %w", err)
+		}
+	}
 
 	if _, err = conf.Config.Client(); err != nil {
 		return nil, err
@@ -109,7 +123,8 @@ func (r *RedisStreams) WriteWithContext(ctx context.Context, msg types.Message)
 	return r.Write(msg)
 }
 
-// Write attempts to write a message by pushing it to a Redis stream.
+// Write attempts to write a message by pushing it to a Redis stream. The
+// messages of a batch are written with a single pipeline.
 func (r *RedisStreams) Write(msg types.Message) error {
 	r.connMut.RLock()
 	client := r.client
@@ -119,25 +134,84 @@ func (r *RedisStreams) Write(msg types.Message) error {
 		return types.ErrNotConnected
 	}
 
-	return IterateBatchedSend(msg, func(i int, p types.Part) error {
-		values := map[string]interface{}{}
-		r.metaFilter.Iter(p.Metadata(), func(k, v string) error {
-			values[k] = v
-			return nil
-		})
-		values[r.conf.BodyKey] = p.Get()
-		if err := client.XAdd(&redis.XAddArgs{
-			ID:           "*",
-			Stream:       r.conf.Stream,
-			MaxLenApprox: r.conf.MaxLenApprox,
-			Values:       values,
-		}).Err(); err != nil {
+	entries := make([]map[string]interface{}, msg.Len())
+	if err := msg.Iter(func(i int, p types.Part) error {
+		var err error
+		entries[i], err = r.entryValues(i, msg)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if len(entries) == 1 {
+		if err := client.XAdd(r.addArgs(entries[0])).Err(); err != nil {
 			r.disconnect()
 			r.log.Errorf("Error from redis: %v\n", err)
 			return types.ErrNotConnected
 		}
 		return nil
+	}
+
+	pipe := client.Pipeline()
+	for _, values := range entries {
+		_ = pipe.XAdd(r.addArgs(values))
+	}
+	if _, err := pipe.Exec(); err != nil {
+		r.disconnect()
+		r.log.Errorf("Error from redis: %v\n", err)
+		return types.ErrNotConnected
+	}
+	return nil
+}
+
+func (r *RedisStreams) addArgs(values map[string]interface{}) *redis.XAddArgs {
+	return &redis.XAddArgs{
+		ID:           "*",
+		Stream:       r.conf.Stream,
+		MaxLenApprox: r.conf.MaxLenApprox,
+		Values:       values,
+	}
+}
+
+// entryValues returns the key/value pairs of a stream entry for a message.
+func (r *RedisStreams) entryValues(i int, msg types.Message) (map[string]interface{}, error) {
+	p := msg.Get(i)
+
+	values := map[string]interface{}{}
+	r.metaFilter.Iter(p.Metadata(), func(k, v string) error {
+		values[k] = v
+		return nil
 	})
+	if r.conf.BodyKey != "" {
+		values[r.conf.BodyKey] = p.Get()
+	}
+	if r.fieldsMapping == nil {
+		return values, nil
+	}
+
+	v, err := r.fieldsMapping.Exec(query.FunctionContext{
+		Maps:     map[string]query.Function{},
+		Vars:     map[string]interface{}{},
+		Index:    i,
+		MsgBatch: msg,
+	}.WithValueFunc(func() *interface{} {
+		jObj, err := p.JSON()
+		if err != nil {
+			return nil
+		}
+		return &jObj
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute fields mapping: %w", err)
+	}
+	vObj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("fields mapping yielded a non-object result: %T", v)
+	}
+	for k, fv := range vObj {
+		values[k] = query.IToString(fv)
+	}
+	return values, nil
 }
 
 // disconnect safely closes a connection to an RedisStreams server.
@@ -0,0 +1,52 @@
+package writer
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisStreamsEntryValues(t *testing.T) {
+	conf := NewRedisStreamsConfig()
+	conf.FieldsMapping = `root.id = this.id
+root.count = this.count
+root.topic = meta("topic")`
+
+	r, err := NewRedisStreams(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{
+		[]byte(`{"id":"foo","count":5}`),
+		[]byte(`{"id":"bar","count":6}`),
+	})
+	msg.Get(1).Metadata().Set("topic", "baz")
+
+	values, err := r.entryValues(1, msg)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"body":  []byte(`{"id":"bar","count":6}`),
+		"topic": "baz",
+		"id":    "bar",
+		"count": "6",
+	}, values)
+}
+
+func TestRedisStreamsEntryValuesNoBody(t *testing.T) {
+	conf := NewRedisStreamsConfig()
+	conf.BodyKey = ""
+	conf.FieldsMapping = `root = this`
+
+	r, err := NewRedisStreams(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	values, err := r.entryValues(0, message.New([][]byte{[]byte(`{"id":"foo"}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"id": "foo"}, values)
+
+	_, err = r.entryValues(0, message.New([][]byte{[]byte(`"foo"`)}))
+	require.EqualError(t, err, "fields mapping yielded a non-object result: string")
+}
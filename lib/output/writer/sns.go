@@ -3,9 +3,14 @@ package writer
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/component/output"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
@@ -18,10 +23,14 @@ import (
 
 // SNSConfig contains configuration fields for the output SNS type.
 type SNSConfig struct {
-	TopicArn      string `json:"topic_arn" yaml:"topic_arn"`
-	sessionConfig `json:",inline" yaml:",inline"`
-	Timeout       string `json:"timeout" yaml:"timeout"`
-	MaxInFlight   int    `json:"max_in_flight" yaml:"max_in_flight"`
+	TopicArn               string `json:"topic_arn" yaml:"topic_arn"`
+	MessageGroupID         string `json:"message_group_id" yaml:"message_group_id"`
+	MessageDeduplicationID string `json:"message_deduplication_id" yaml:"message_deduplication_id"`
+	sessionConfig          `json:",inline" yaml:",inline"`
+	Metadata               output.Metadata    `json:"metadata" yaml:"metadata"`
+	Timeout                string             `json:"timeout" yaml:"timeout"`
+	MaxInFlight            int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching               batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
 
 // NewSNSConfig creates a new Config with default values.
@@ -30,9 +39,13 @@ func NewSNSConfig() SNSConfig {
 		sessionConfig: sessionConfig{
 			Config: sess.NewConfig(),
 		},
-		TopicArn:    "",
-		Timeout:     "5s",
-		MaxInFlight: 1,
+		TopicArn:               "",
+		MessageGroupID:         "",
+		MessageDeduplicationID: "",
+		Metadata:               output.NewMetadata(),
+		Timeout:                "5s",
+		MaxInFlight:            1,
+		Batching:               batch.NewPolicyConfig(),
 	}
 }
 
@@ -46,6 +59,10 @@ type SNS struct {
 	session *session.Session
 	sns     *sns.SNS
 
+	groupID    field.Expression
+	dedupeID   field.Expression
+	metaFilter *output.MetadataFilter
+
 	tout time.Duration
 
 	log   log.Modular
@@ -59,8 +76,21 @@ func NewSNS(conf SNSConfig, log log.Modular, stats metrics.Type) (*SNS, error) {
 		log:   log,
 		stats: stats,
 	}
+	var err error
+	if id := conf.MessageGroupID; len(id) > 0 {
+		if s.groupID, err = bloblang.NewField(id); err != nil {
+			return nil, fmt.Errorf("failed to parse group ID expression: %v", err)
+		}
+	}
+	if id := conf.MessageDeduplicationID; len(id) > 0 {
+		if s.dedupeID, err = bloblang.NewField(id); err != nil {
+			return nil, fmt.Errorf("failed to parse dedupe ID expression: %v", err)
+		}
+	}
+	if s.metaFilter, err = conf.Metadata.Filter(); err != nil {
+		return nil, fmt.Errorf("failed to construct metadata filter: %w", err)
+	}
 	if tout := conf.Timeout; len(tout) > 0 {
-		var err error
 		if s.tout, err = time.ParseDuration(tout); err != nil {
 			return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
 		}
@@ -91,6 +121,45 @@ func (a *SNS) Connect() error {
 	return nil
 }
 
+func (a *SNS) getPublishInput(msg types.Message, i int) *sns.PublishInput {
+	p := msg.Get(i)
+	input := &sns.PublishInput{
+		TopicArn: aws.String(a.conf.TopicArn),
+		Message:  aws.String(string(p.Get())),
+	}
+
+	keys := []string{}
+	a.metaFilter.Iter(p.Metadata(), func(k, v string) error {
+		if isValidSQSAttribute(k, v) {
+			keys = append(keys, k)
+		} else {
+			a.log.Debugf("Rejecting metadata key '%v' due to invalid characters\n", k)
+		}
+		return nil
+	})
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		input.MessageAttributes = map[string]*sns.MessageAttributeValue{}
+		for i, k := range keys {
+			input.MessageAttributes[k] = &sns.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(p.Metadata().Get(k)),
+			}
+			if i == 9 {
+				break
+			}
+		}
+	}
+
+	if a.groupID != nil {
+		input.MessageGroupId = aws.String(a.groupID.String(i, msg))
+	}
+	if a.dedupeID != nil {
+		input.MessageDeduplicationId = aws.String(a.dedupeID.String(i, msg))
+	}
+	return input
+}
+
 // Write attempts to write message contents to a target SNS.
 func (a *SNS) Write(msg types.Message) error {
 	return a.WriteWithContext(context.Background(), msg)
@@ -106,11 +175,7 @@ func (a *SNS) WriteWithContext(wctx context.Context, msg types.Message) error {
 	defer cancel()
 
 	return IterateBatchedSend(msg, func(i int, p types.Part) error {
-		message := &sns.PublishInput{
-			TopicArn: aws.String(a.conf.TopicArn),
-			Message:  aws.String(string(p.Get())),
-		}
-		_, err := a.sns.PublishWithContext(ctx, message)
+		_, err := a.sns.PublishWithContext(ctx, a.getPublishInput(msg, i))
 		return err
 	})
 }
@@ -0,0 +1,53 @@
+package writer
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSNSPublishInput(t *testing.T) {
+	conf := NewSNSConfig()
+	conf.TopicArn = "arn:aws:sns:us-east-1:123456789012:foo.fifo"
+	conf.MessageGroupID = `${! meta("group") }`
+	conf.MessageDeduplicationID = `${! json("id") }`
+	conf.Metadata.ExcludePrefixes = []string{"group"}
+
+	s, err := NewSNS(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{
+		[]byte(`{"id":"first"}`),
+		[]byte(`{"id":"second"}`),
+	})
+	msg.Get(0).Metadata().Set("group", "a")
+	msg.Get(0).Metadata().Set("foo", "bar")
+	msg.Get(0).Metadata().Set("aws.nope", "baz")
+	msg.Get(1).Metadata().Set("group", "b")
+
+	assert.Equal(t, &sns.PublishInput{
+		TopicArn:               aws.String("arn:aws:sns:us-east-1:123456789012:foo.fifo"),
+		Message:                aws.String(`{"id":"first"}`),
+		MessageGroupId:         aws.String("a"),
+		MessageDeduplicationId: aws.String("first"),
+		MessageAttributes: map[string]*sns.MessageAttributeValue{
+			"foo": {
+				DataType:    aws.String("String"),
+				StringValue: aws.String("bar"),
+			},
+		},
+	}, s.getPublishInput(msg, 0))
+
+	assert.Equal(t, &sns.PublishInput{
+		TopicArn:               aws.String("arn:aws:sns:us-east-1:123456789012:foo.fifo"),
+		Message:                aws.String(`{"id":"second"}`),
+		MessageGroupId:         aws.String("b"),
+		MessageDeduplicationId: aws.String("second"),
+	}, s.getPublishInput(msg, 1))
+}
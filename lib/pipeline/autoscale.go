@@ -0,0 +1,495 @@
+package pipeline
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// AutoscaleConfig contains configuration fields for automatically scaling the
+// number of processing threads of a pipeline.
+type AutoscaleConfig struct {
+	Enabled       bool   `json:"enabled" yaml:"enabled"`
+	MinThreads    int    `json:"min_threads" yaml:"min_threads"`
+	MaxThreads    int    `json:"max_threads" yaml:"max_threads"`
+	CheckInterval string `json:"check_interval" yaml:"check_interval"`
+}
+
+// NewAutoscaleConfig returns an AutoscaleConfig with default values.
+func NewAutoscaleConfig() AutoscaleConfig {
+	return AutoscaleConfig{
+		Enabled:       false,
+		MinThreads:    1,
+		MaxThreads:    0,
+		CheckInterval: "1s",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// When the proportion of time spent waiting for an available processing thread
+// exceeds scaleUpRatio a new thread is added, and when the proportion is below
+// scaleDownRatio for scaleDownChecks consecutive checks a thread is removed.
+//
+// Threads are not added while the proportion of available CPU time consumed by
+// the process exceeds cpuSaturatedRatio, as additional threads would only
+// compete for the same CPU. When the CPU is saturated a thread is removed after
+// scaleDownChecks consecutive checks.
+const (
+	scaleUpRatio      = 0.5
+	scaleDownRatio    = 0.1
+	scaleDownChecks   = 3
+	cpuSaturatedRatio = 0.9
+)
+
+type scaledWorker struct {
+	pipe     types.Pipeline
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// AutoscalePool is a pool of pipelines where the number of pipelines is scaled
+// between a minimum and maximum based on how saturated the existing pipelines
+// are. Saturation is measured as the proportion of time that messages spend
+// waiting for a pipeline to become available, and scaling up is prevented
+// while the CPU time consumed by the process saturates the available CPUs.
+type AutoscalePool struct {
+	running uint32
+
+	constructor types.PipelineConstructorFunc
+	minThreads  int
+	maxThreads  int
+	interval    time.Duration
+
+	workerMut sync.Mutex
+	workers   []*scaledWorker
+	draining  []*scaledWorker
+	workerWG  sync.WaitGroup
+
+	waitMut      sync.Mutex
+	waitingSince time.Time
+	waited       time.Duration
+
+	log   log.Modular
+	stats metrics.Type
+
+	mThreads   metrics.StatGauge
+	mCPU       metrics.StatGauge
+	mScaleUp   metrics.StatCounter
+	mScaleDown metrics.StatCounter
+
+	messagesIn  <-chan types.Transaction
+	workChan    chan types.Transaction
+	messagesOut chan types.Transaction
+
+	closeChan chan struct{}
+	closed    chan struct{}
+}
+
+// NewAutoscalePool returns a new pipeline pool that scales the number of
+// processor threads according to demand.
+func NewAutoscalePool(
+	constructor types.PipelineConstructorFunc,
+	conf AutoscaleConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*AutoscalePool, error) {
+	p := &AutoscalePool{
+		running:     1,
+		constructor: constructor,
+		minThreads:  conf.MinThreads,
+		maxThreads:  conf.MaxThreads,
+		log:         log,
+		stats:       stats,
+		mThreads:    stats.GetGauge("pipeline.threads"),
+		mCPU:        stats.GetGauge("pipeline.cpu_percent"),
+		mScaleUp:    stats.GetCounter("pipeline.scale_up"),
+		mScaleDown:  stats.GetCounter("pipeline.scale_down"),
+		workChan:    make(chan types.Transaction),
+		messagesOut: make(chan types.Transaction),
+		closeChan:   make(chan struct{}),
+		closed:      make(chan struct{}),
+	}
+	if p.minThreads <= 0 {
+		p.minThreads = 1
+	}
+	if p.maxThreads <= 0 {
+		p.maxThreads = runtime.NumCPU()
+		if p.maxThreads < p.minThreads {
+			p.maxThreads = p.minThreads
+		}
+	}
+	if p.maxThreads < p.minThreads {
+		return nil, fmt.Errorf("max_threads (%v) must not be lower than min_threads (%v)", p.maxThreads, p.minThreads)
+	}
+
+	var err error
+	if p.interval, err = time.ParseDuration(conf.CheckInterval); err != nil {
+		return nil, fmt.Errorf("failed to parse check_interval: %v", err)
+	}
+	if p.interval <= 0 {
+		return nil, fmt.Errorf("check_interval must be greater than zero")
+	}
+
+	for i := 0; i < p.minThreads; i++ {
+		if err := p.addWorker(); err != nil {
+			p.CloseAsync()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// addWorker constructs a new pipeline and begins feeding it transactions from
+// the shared work channel.
+func (p *AutoscalePool) addWorker() error {
+	procs := 0
+	pipe, err := p.constructor(&procs)
+	if err != nil {
+		return err
+	}
+
+	inChan := make(chan types.Transaction)
+	if err := pipe.Consume(inChan); err != nil {
+		pipe.CloseAsync()
+		if cerr := pipe.WaitForClose(time.Second); cerr != nil {
+			p.log.Debugf("Failed to close unused pipeline: %v\n", cerr)
+		}
+		return err
+	}
+
+	w := &scaledWorker{
+		pipe:     pipe,
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+
+	p.workerWG.Add(2)
+
+	// Closing the input channel of a pipeline allows it to finish any
+	// transaction in progress before shutting down.
+	go func() {
+		defer p.workerWG.Done()
+		defer close(inChan)
+		for {
+			select {
+			case t, open := <-p.workChan:
+				if !open {
+					return
+				}
+				select {
+				case inChan <- t:
+				case <-p.closeChan:
+					return
+				}
+			case <-w.stopChan:
+				return
+			case <-p.closeChan:
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer p.workerWG.Done()
+		for {
+			select {
+			case t, open := <-pipe.TransactionChan():
+				if !open {
+					close(w.doneChan)
+					return
+				}
+				select {
+				case p.messagesOut <- t:
+				case <-p.closeChan:
+					return
+				}
+			case <-p.closeChan:
+				return
+			}
+		}
+	}()
+
+	p.workerMut.Lock()
+	p.workers = append(p.workers, w)
+	p.mThreads.Set(int64(len(p.workers)))
+	p.workerMut.Unlock()
+	return nil
+}
+
+// removeWorker stops the most recently added pipeline once it has finished
+// any transaction in progress. The pipeline is kept as draining until it has
+// closed so that shutting down the pool can still wait for it.
+func (p *AutoscalePool) removeWorker() {
+	p.workerMut.Lock()
+	defer p.workerMut.Unlock()
+
+	if len(p.workers) == 0 {
+		return
+	}
+	w := p.workers[len(p.workers)-1]
+	p.workers = p.workers[:len(p.workers)-1]
+	p.mThreads.Set(int64(len(p.workers)))
+	close(w.stopChan)
+	p.draining = append(p.draining, w)
+}
+
+// pruneDraining forgets about removed pipelines that have finished closing.
+func (p *AutoscalePool) pruneDraining() {
+	p.workerMut.Lock()
+	defer p.workerMut.Unlock()
+
+	remaining := p.draining[:0]
+	for _, w := range p.draining {
+		select {
+		case <-w.doneChan:
+		default:
+			remaining = append(remaining, w)
+		}
+	}
+	for i := len(remaining); i < len(p.draining); i++ {
+		p.draining[i] = nil
+	}
+	p.draining = remaining
+}
+
+func (p *AutoscalePool) numWorkers() int {
+	p.workerMut.Lock()
+	n := len(p.workers)
+	p.workerMut.Unlock()
+	return n
+}
+
+//------------------------------------------------------------------------------
+
+func (p *AutoscalePool) startWaiting() {
+	p.waitMut.Lock()
+	p.waitingSince = time.Now()
+	p.waitMut.Unlock()
+}
+
+func (p *AutoscalePool) stopWaiting() {
+	p.waitMut.Lock()
+	p.waited += time.Since(p.waitingSince)
+	p.waitingSince = time.Time{}
+	p.waitMut.Unlock()
+}
+
+// resetWaited returns the total duration spent waiting for an available
+// pipeline since the last reset.
+func (p *AutoscalePool) resetWaited() time.Duration {
+	p.waitMut.Lock()
+	defer p.waitMut.Unlock()
+
+	waited := p.waited
+	if !p.waitingSince.IsZero() {
+		waited += time.Since(p.waitingSince)
+		p.waitingSince = time.Now()
+	}
+	p.waited = 0
+	return waited
+}
+
+// cpuMonitor measures the proportion of available CPU time consumed by the
+// process between calls to utilisation.
+type cpuMonitor struct {
+	lastCPU  time.Duration
+	lastTime time.Time
+}
+
+func newCPUMonitor() *cpuMonitor {
+	c := &cpuMonitor{lastTime: time.Now()}
+	c.lastCPU, _ = processCPUTime()
+	return c
+}
+
+// utilisation returns the proportion of available CPU time consumed by the
+// process since the last call, and false if it cannot be measured on this
+// platform.
+func (c *cpuMonitor) utilisation() (float64, bool) {
+	cpu, ok := processCPUTime()
+	if !ok {
+		return 0, false
+	}
+	now := time.Now()
+	elapsed := now.Sub(c.lastTime) * time.Duration(runtime.GOMAXPROCS(0))
+	used := cpu - c.lastCPU
+	c.lastCPU, c.lastTime = cpu, now
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return float64(used) / float64(elapsed), true
+}
+
+// scaleLoop periodically checks the saturation of the pool and adds or removes
+// pipelines accordingly.
+func (p *AutoscalePool) scaleLoop(stopChan <-chan struct{}) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	cpuMon := newCPUMonitor()
+
+	lowChecks := 0
+	for {
+		select {
+		case <-ticker.C:
+		case <-stopChan:
+			return
+		}
+
+		p.pruneDraining()
+
+		ratio := float64(p.resetWaited()) / float64(p.interval)
+		n := p.numWorkers()
+
+		cpuSaturated := false
+		if cpu, ok := cpuMon.utilisation(); ok {
+			p.mCPU.Set(int64(cpu * 100))
+			cpuSaturated = cpu > cpuSaturatedRatio
+		}
+
+		if ratio > scaleUpRatio && !cpuSaturated {
+			lowChecks = 0
+			if n < p.maxThreads {
+				if err := p.addWorker(); err != nil {
+					p.log.Errorf("Failed to scale up pipeline threads: %v\n", err)
+					continue
+				}
+				p.mScaleUp.Incr(1)
+				p.log.Debugf("Scaled pipeline threads up to %v\n", n+1)
+			}
+			continue
+		}
+
+		if ratio < scaleDownRatio || cpuSaturated {
+			if lowChecks++; lowChecks >= scaleDownChecks && n > p.minThreads {
+				lowChecks = 0
+				p.removeWorker()
+				p.mScaleDown.Incr(1)
+				p.log.Debugf("Scaled pipeline threads down to %v\n", n-1)
+			}
+		} else {
+			lowChecks = 0
+		}
+	}
+}
+
+// loop is the dispatching loop of this pipeline.
+func (p *AutoscalePool) loop() {
+	stopScaling, scalingStopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		p.scaleLoop(stopScaling)
+		close(scalingStopped)
+	}()
+
+	defer func() {
+		atomic.StoreUint32(&p.running, 0)
+
+		close(stopScaling)
+		<-scalingStopped
+
+		// If we are closing early then signal all workers to close.
+		select {
+		case <-p.closeChan:
+			p.workerMut.Lock()
+			for _, w := range p.workers {
+				w.pipe.CloseAsync()
+			}
+			for _, w := range p.draining {
+				w.pipe.CloseAsync()
+			}
+			p.workerMut.Unlock()
+		default:
+		}
+
+		// Once the feeding and forwarding goroutines of all workers have
+		// returned nothing else writes to our output channel. Waiting for the
+		// workers themselves to close is left to WaitForClose so that it
+		// respects the timeout of the caller.
+		p.workerWG.Wait()
+		close(p.messagesOut)
+		close(p.closed)
+	}()
+
+	for atomic.LoadUint32(&p.running) == 1 {
+		var t types.Transaction
+		var open bool
+		select {
+		case t, open = <-p.messagesIn:
+			if !open {
+				close(p.workChan)
+				return
+			}
+		case <-p.closeChan:
+			return
+		}
+
+		p.startWaiting()
+		select {
+		case p.workChan <- t:
+		case <-p.closeChan:
+			return
+		}
+		p.stopWaiting()
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Consume assigns a messages channel for the pipeline to read.
+func (p *AutoscalePool) Consume(msgs <-chan types.Transaction) error {
+	if p.messagesIn != nil {
+		return types.ErrAlreadyStarted
+	}
+	p.messagesIn = msgs
+	go p.loop()
+	return nil
+}
+
+// TransactionChan returns the channel used for consuming messages from this
+// pipeline.
+func (p *AutoscalePool) TransactionChan() <-chan types.Transaction {
+	return p.messagesOut
+}
+
+// CloseAsync shuts down the pipeline and stops processing messages.
+func (p *AutoscalePool) CloseAsync() {
+	if atomic.CompareAndSwapUint32(&p.running, 1, 0) {
+		close(p.closeChan)
+	}
+}
+
+// WaitForClose blocks until the pipeline has closed down.
+func (p *AutoscalePool) WaitForClose(timeout time.Duration) error {
+	stopBy := time.Now().Add(timeout)
+	select {
+	case <-p.closed:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+
+	p.workerMut.Lock()
+	workers := make([]*scaledWorker, 0, len(p.workers)+len(p.draining))
+	workers = append(workers, p.workers...)
+	workers = append(workers, p.draining...)
+	p.workerMut.Unlock()
+
+	for _, w := range workers {
+		if err := w.pipe.WaitForClose(time.Until(stopBy)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,223 @@
+package pipeline
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoscalePoolConfigErrors(t *testing.T) {
+	constr := func(i *int) (types.Pipeline, error) {
+		return NewProcessor(log.Noop(), metrics.Noop()), nil
+	}
+
+	conf := NewAutoscaleConfig()
+	conf.MinThreads = 4
+	conf.MaxThreads = 2
+	_, err := NewAutoscalePool(constr, conf, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+
+	conf = NewAutoscaleConfig()
+	conf.CheckInterval = "nope"
+	_, err = NewAutoscalePool(constr, conf, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+}
+
+func TestAutoscalePoolDefaultMaxThreads(t *testing.T) {
+	constr := func(i *int) (types.Pipeline, error) {
+		return NewProcessor(log.Noop(), metrics.Noop()), nil
+	}
+
+	conf := NewAutoscaleConfig()
+	conf.MinThreads = runtime.NumCPU() + 1
+
+	pool, err := NewAutoscalePool(constr, conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, conf.MinThreads, pool.maxThreads)
+	assert.Equal(t, conf.MinThreads, pool.numWorkers())
+
+	tChan := make(chan types.Transaction)
+	require.NoError(t, pool.Consume(tChan))
+
+	close(tChan)
+	require.NoError(t, pool.WaitForClose(time.Second))
+}
+
+func TestAutoscalePoolClosesFailedWorkers(t *testing.T) {
+	var pipes []*Processor
+	constr := func(i *int) (types.Pipeline, error) {
+		pipe := NewProcessor(log.Noop(), metrics.Noop())
+		pipes = append(pipes, pipe)
+
+		// Consuming before the pool does causes the pool to fail.
+		if err := pipe.Consume(make(chan types.Transaction)); err != nil {
+			return nil, err
+		}
+		return pipe, nil
+	}
+
+	_, err := NewAutoscalePool(constr, NewAutoscaleConfig(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	require.Len(t, pipes, 1)
+
+	select {
+	case <-pipes[0].closed:
+	case <-time.After(time.Second):
+		t.Fatal("pipeline of failed worker was not closed")
+	}
+}
+
+func TestAutoscalePoolScaling(t *testing.T) {
+	sleepConf := processor.NewConfig()
+	sleepConf.Type = processor.TypeSleep
+	sleepConf.Sleep.Duration = "20ms"
+
+	constr := func(i *int) (types.Pipeline, error) {
+		proc, err := processor.New(sleepConf, nil, log.Noop(), metrics.Noop())
+		if err != nil {
+			return nil, err
+		}
+		return NewProcessor(log.Noop(), metrics.Noop(), proc), nil
+	}
+
+	conf := NewAutoscaleConfig()
+	conf.MinThreads = 1
+	conf.MaxThreads = 4
+	conf.CheckInterval = "50ms"
+
+	pool, err := NewAutoscalePool(constr, conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tChan := make(chan types.Transaction)
+	require.NoError(t, pool.Consume(tChan))
+
+	go func() {
+		for tran := range pool.TransactionChan() {
+			go func(t types.Transaction) {
+				t.ResponseChan <- response.NewAck()
+			}(tran)
+		}
+	}()
+
+	stopFeeding := make(chan struct{})
+	var feederWG sync.WaitGroup
+	feederWG.Add(1)
+	go func() {
+		defer feederWG.Done()
+		for {
+			select {
+			case <-time.After(time.Millisecond):
+			case <-stopFeeding:
+				return
+			}
+			feederWG.Add(1)
+			go func() {
+				defer feederWG.Done()
+				resChan := make(chan types.Response)
+				select {
+				case tChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan):
+				case <-stopFeeding:
+					return
+				}
+				<-resChan
+			}()
+		}
+	}()
+
+	assert.Equal(t, 1, pool.numWorkers())
+
+	assert.Eventually(t, func() bool {
+		return pool.numWorkers() == 4
+	}, time.Second*5, time.Millisecond*10)
+
+	close(stopFeeding)
+	feederWG.Wait()
+
+	assert.Eventually(t, func() bool {
+		return pool.numWorkers() == 1
+	}, time.Second*5, time.Millisecond*50)
+
+	// Removed workers are forgotten once they have finished closing.
+	assert.Eventually(t, func() bool {
+		pool.workerMut.Lock()
+		defer pool.workerMut.Unlock()
+		return len(pool.draining) == 0
+	}, time.Second*5, time.Millisecond*50)
+
+	close(tChan)
+	require.NoError(t, pool.WaitForClose(time.Second*5))
+}
+
+func TestAutoscalePoolWaitsForRemovedWorkers(t *testing.T) {
+	var procs []*blockingProc
+	constr := func(i *int) (types.Pipeline, error) {
+		bProc := &blockingProc{release: make(chan struct{})}
+		procs = append(procs, bProc)
+		return NewProcessor(log.Noop(), metrics.Noop(), bProc), nil
+	}
+
+	conf := NewAutoscaleConfig()
+	conf.MinThreads = 2
+	conf.MaxThreads = 2
+	conf.CheckInterval = "1h"
+
+	pool, err := NewAutoscalePool(constr, conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.Len(t, procs, 2)
+
+	tChan := make(chan types.Transaction)
+	require.NoError(t, pool.Consume(tChan))
+
+	go func() {
+		for tran := range pool.TransactionChan() {
+			go func(t types.Transaction) {
+				t.ResponseChan <- response.NewAck()
+			}(tran)
+		}
+	}()
+
+	// Feed transactions until both workers are blocked within executions,
+	// which can take more than two as a busy worker may hold another whilst
+	// waiting for its pipeline.
+	resChan := make(chan types.Response, 10)
+	stopFeeding, feederDone := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(feederDone)
+		for {
+			select {
+			case tChan <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan):
+			case <-stopFeeding:
+				return
+			}
+		}
+	}()
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&procs[0].running) == 1 && atomic.LoadInt32(&procs[1].running) == 1
+	}, time.Second, time.Millisecond*10)
+	close(stopFeeding)
+	<-feederDone
+
+	// Remove the most recently added worker whilst it is still processing.
+	pool.removeWorker()
+	pool.pruneDraining()
+	assert.Equal(t, 1, pool.numWorkers())
+
+	// Closing the pool early must still wait for the removed worker.
+	close(procs[0].release)
+	pool.CloseAsync()
+	assert.Equal(t, types.ErrTimeout, pool.WaitForClose(time.Millisecond*100))
+
+	close(procs[1].release)
+	require.NoError(t, pool.WaitForClose(time.Second*5))
+}
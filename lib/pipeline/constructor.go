@@ -2,8 +2,10 @@ package pipeline
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/internal/lineage"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/processor"
@@ -21,15 +23,23 @@ import (
 // number of parallel inputs that matches or surpasses the number of pipeline
 // threads, or use a memory buffer.
 type Config struct {
-	Threads    int                `json:"threads" yaml:"threads"`
-	Processors []processor.Config `json:"processors" yaml:"processors"`
+	Threads           int                `json:"threads" yaml:"threads"`
+	Autoscaling       AutoscaleConfig    `json:"autoscaling" yaml:"autoscaling"`
+	MaxMessageSize    int                `json:"max_message_size" yaml:"max_message_size"`
+	ProcessingTimeout string             `json:"processing_timeout" yaml:"processing_timeout"`
+	FuseBloblang      bool               `json:"fuse_bloblang" yaml:"fuse_bloblang"`
+	Processors        []processor.Config `json:"processors" yaml:"processors"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Threads:    1,
-		Processors: []processor.Config{},
+		Threads:           1,
+		Autoscaling:       NewAutoscaleConfig(),
+		MaxMessageSize:    0,
+		ProcessingTimeout: "",
+		FuseBloblang:      false,
+		Processors:        []processor.Config{},
 	}
 }
 
@@ -51,8 +61,17 @@ func (conf Config) Sanitised(removeDeprecated bool) (interface{}, error) {
 		}
 	}
 	return map[string]interface{}{
-		"threads":    conf.Threads,
-		"processors": procConfs,
+		"threads": conf.Threads,
+		"autoscaling": map[string]interface{}{
+			"enabled":        conf.Autoscaling.Enabled,
+			"min_threads":    conf.Autoscaling.MinThreads,
+			"max_threads":    conf.Autoscaling.MaxThreads,
+			"check_interval": conf.Autoscaling.CheckInterval,
+		},
+		"max_message_size":   conf.MaxMessageSize,
+		"processing_timeout": conf.ProcessingTimeout,
+		"fuse_bloblang":      conf.FuseBloblang,
+		"processors":         procConfs,
 	}, nil
 }
 
@@ -66,9 +85,18 @@ func New(
 	stats metrics.Type,
 	processorCtors ...types.ProcessorConstructorFunc,
 ) (Type, error) {
+	var timeout time.Duration
+	if len(conf.ProcessingTimeout) > 0 {
+		var err error
+		if timeout, err = time.ParseDuration(conf.ProcessingTimeout); err != nil {
+			return nil, fmt.Errorf("failed to parse processing_timeout: %v", err)
+		}
+	}
+
 	procs := 0
 	procCtor := func(i *int) (types.Pipeline, error) {
-		processors := make([]types.Processor, len(conf.Processors)+len(processorCtors))
+		processors := make([]types.Processor, len(conf.Processors))
+		failPaths := make([]string, len(conf.Processors))
 		for j, procConf := range conf.Processors {
 			pMgr, pLog, pMetrics := interop.LabelChild(fmt.Sprintf("processor.%v", *i), mgr, log, stats)
 			var err error
@@ -76,16 +104,30 @@ func New(
 			if err != nil {
 				return nil, fmt.Errorf("failed to create processor '%v': %v", procConf.Type, err)
 			}
+			failPaths[j] = failPath(j, procConf)
 			*i++
 		}
+		if conf.FuseBloblang {
+			processors = fuseProcessors(processors, failPaths)
+		} else {
+			for j, proc := range processors {
+				processors[j] = processor.WithFailPath(failPaths[j], proc)
+			}
+		}
 		for j, procCtor := range processorCtors {
-			var err error
-			processors[j+len(conf.Processors)], err = procCtor()
+			proc, err := procCtor()
 			if err != nil {
 				return nil, fmt.Errorf("failed to create processor: %v", err)
 			}
+			processors = append(processors, processor.WithFailPath(failPath(len(conf.Processors)+j, processor.Config{}), proc))
 		}
-		return NewProcessor(log, stats, processors...), nil
+		proc := NewProcessor(log, stats, processors...)
+		proc.maxMessageSize = conf.MaxMessageSize
+		proc.timeout = timeout
+		return proc, nil
+	}
+	if conf.Autoscaling.Enabled {
+		return NewAutoscalePool(procCtor, conf.Autoscaling, log, stats)
 	}
 	if conf.Threads == 1 {
 		return procCtor(&procs)
@@ -94,3 +136,54 @@ func New(
 }
 
 //------------------------------------------------------------------------------
+
+// failPath returns the component path recorded against messages that fail
+// within a processor, which is the label of the processor when set. The index
+// is the position of the processor within the pipeline config, and is
+// therefore the same for each pipeline thread.
+func failPath(index int, conf processor.Config) string {
+	if len(conf.Label) > 0 {
+		return conf.Label
+	}
+	return fmt.Sprintf("pipeline.processors.%v", index)
+}
+
+//------------------------------------------------------------------------------
+
+// fuseProcessors wraps each processor so that failed messages record its
+// component path, and fuses runs of consecutive bloblang processors into a
+// single processor that executes them batch-at-a-time.
+func fuseProcessors(procs []types.Processor, failPaths []string) []types.Processor {
+	fused := make([]types.Processor, 0, len(procs))
+	for i := 0; i < len(procs); {
+		var steps []*processor.Bloblang
+		var components []string
+		for _, proc := range procs[i:] {
+			// Processors wrapped for lineage tracking are fused by their
+			// underlying processor, and the chain is wrapped instead.
+			inner, component := lineage.UnwrapProcessor(proc)
+			bProc, ok := inner.(*processor.Bloblang)
+			if !ok {
+				break
+			}
+			steps = append(steps, bProc)
+			if len(component) > 0 {
+				components = append(components, component)
+			}
+		}
+		if len(steps) > 1 {
+			var chain types.Processor = processor.NewBloblangChain(steps, failPaths[i:i+len(steps)])
+			for _, component := range components {
+				chain = lineage.WrapProcessor(component, chain)
+			}
+			fused = append(fused, chain)
+			i += len(steps)
+			continue
+		}
+		fused = append(fused, processor.WithFailPath(failPaths[i], procs[i]))
+		i++
+	}
+	return fused
+}
+
+//------------------------------------------------------------------------------
@@ -1,6 +1,7 @@
 package pipeline_test
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -97,3 +98,89 @@ func TestProcCtor(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestProcCtorFailPaths(t *testing.T) {
+	firstProc := processor.NewConfig()
+	firstProc.Type = processor.TypeBloblang
+	firstProc.Bloblang = `root = content().uppercase()`
+
+	secondProc := processor.NewConfig()
+	secondProc.Type = processor.TypeBloblang
+	secondProc.Bloblang = `root = this`
+
+	// Fail paths are the same regardless of which pipeline thread processes
+	// a message.
+	for _, threads := range []int{1, 3} {
+		threads := threads
+		t.Run(fmt.Sprintf("%v threads", threads), func(t *testing.T) {
+			conf := pipeline.NewConfig()
+			conf.Threads = threads
+			conf.Processors = append(conf.Processors, firstProc)
+
+			pipe, err := pipeline.New(
+				conf, nil,
+				log.Noop(),
+				metrics.Noop(),
+				func() (types.Processor, error) {
+					return processor.New(
+						secondProc, nil,
+						log.Noop(),
+						metrics.Noop(),
+					)
+				},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tChan := make(chan types.Transaction)
+			resChan := make(chan types.Response)
+
+			if err = pipe.Consume(tChan); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case <-time.After(time.Second):
+				t.Fatal("timed out")
+			case tChan <- types.NewTransaction(
+				message.New([][]byte{[]byte("not json")}), resChan,
+			):
+			}
+
+			var tran types.Transaction
+			select {
+			case <-time.After(time.Second):
+				t.Fatal("timed out")
+			case tran = <-pipe.TransactionChan():
+			}
+
+			part := tran.Payload.Get(0)
+			if !processor.HasFailed(part) {
+				t.Fatal("Expected message to fail")
+			}
+			if exp, act := "pipeline.processors.1", message.GetFailureDetails(part).Path; exp != act {
+				t.Errorf("Wrong fail path: %v != %v", act, exp)
+			}
+
+			go func() {
+				select {
+				case <-time.After(time.Second):
+					t.Error("timed out")
+				case tran.ResponseChan <- response.NewAck():
+				}
+			}()
+
+			select {
+			case <-time.After(time.Second):
+				t.Fatal("timed out")
+			case <-resChan:
+			}
+
+			pipe.CloseAsync()
+			if err = pipe.WaitForClose(time.Second); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
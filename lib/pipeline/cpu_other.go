@@ -0,0 +1,11 @@
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package pipeline
+
+import "time"
+
+// processCPUTime is not supported on this platform, and therefore autoscaling
+// is based on thread saturation alone.
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
@@ -0,0 +1,18 @@
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package pipeline
+
+import (
+	"syscall"
+	"time"
+)
+
+// processCPUTime returns the total user and system CPU time consumed by the
+// process so far, and whether the value could be obtained.
+func processCPUTime() (time.Duration, bool) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, false
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), true
+}
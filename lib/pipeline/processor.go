@@ -1,11 +1,14 @@
 package pipeline
 
 import (
+	"context"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/processor"
 	"github.com/Jeffail/benthos/v3/lib/response"
@@ -15,6 +18,56 @@ import (
 
 //------------------------------------------------------------------------------
 
+// ErrProcessingTimeout is flagged on messages that exceed the processing
+// timeout of a pipeline.
+type ErrProcessingTimeout struct {
+	Timeout time.Duration
+}
+
+// Error returns the Error string.
+func (e ErrProcessingTimeout) Error() string {
+	return fmt.Sprintf("message processing exceeded the timeout of %v", e.Timeout)
+}
+
+// Temporary returns true, indicating that processing may succeed if attempted
+// again.
+func (e ErrProcessingTimeout) Temporary() bool {
+	return true
+}
+
+type processResult struct {
+	msgs []types.Message
+	res  types.Response
+}
+
+type processJob struct {
+	ctx context.Context
+	msg types.Message
+}
+
+// executor runs the processors of a pipeline on a dedicated goroutine so that
+// an execution can be abandoned when it exceeds the processing timeout.
+type executor struct {
+	jobs    chan processJob
+	results chan processResult
+}
+
+func newExecutor(procs []types.Processor) *executor {
+	e := &executor{
+		jobs:    make(chan processJob),
+		results: make(chan processResult, 1),
+	}
+	go func() {
+		for job := range e.jobs {
+			msgs, res := processor.ExecuteAllWithContext(job.ctx, procs, job.msg)
+			e.results <- processResult{msgs: msgs, res: res}
+		}
+	}()
+	return e
+}
+
+//------------------------------------------------------------------------------
+
 // Processor is a pipeline that supports both Consumer and Producer interfaces.
 // The processor will read from a source, perform some processing, and then
 // either propagate a new message or drop it.
@@ -24,7 +77,15 @@ type Processor struct {
 	log   log.Modular
 	stats metrics.Type
 
-	msgProcessors []types.Processor
+	msgProcessors  []types.Processor
+	maxMessageSize int
+	timeout        time.Duration
+	exec           *executor
+	execBusy       bool
+	timer          *time.Timer
+
+	mOversized metrics.StatCounter
+	mTimeout   metrics.StatCounter
 
 	messagesOut chan types.Transaction
 	responsesIn chan types.Response
@@ -44,7 +105,10 @@ func NewProcessor(
 	return &Processor{
 		running:       1,
 		msgProcessors: msgProcessors,
+		log:           log,
 		stats:         stats,
+		mOversized:    stats.GetCounter("pipeline.oversized"),
+		mTimeout:      stats.GetCounter("pipeline.timeout"),
 		messagesOut:   make(chan types.Transaction),
 		responsesIn:   make(chan types.Response),
 		closeChan:     make(chan struct{}),
@@ -57,6 +121,10 @@ func NewProcessor(
 // loop is the processing loop of this pipeline.
 func (p *Processor) loop() {
 	defer func() {
+		if p.exec != nil {
+			close(p.exec.jobs)
+		}
+
 		// Signal all children to close.
 		for _, c := range p.msgProcessors {
 			c.CloseAsync()
@@ -78,7 +146,16 @@ func (p *Processor) loop() {
 			return
 		}
 
-		resultMsgs, resultRes := processor.ExecuteAll(p.msgProcessors, tran.Payload)
+		msg, oversized, indexes := p.splitOversized(tran.Payload)
+
+		var resultMsgs []types.Message
+		var resultRes types.Response
+		if oversized == nil || msg.Len() > 0 {
+			resultMsgs, resultRes = p.execute(msg)
+		}
+		if oversized != nil {
+			resultMsgs = mergeOversized(resultMsgs, msg.Len(), oversized, indexes)
+		}
 		if len(resultMsgs) == 0 {
 			if resultRes == nil {
 				resultRes = response.NewUnack()
@@ -104,6 +181,161 @@ func (p *Processor) loop() {
 	}
 }
 
+// execute runs the processors of the pipeline on a message. When a processing
+// timeout is configured and exceeded the context provided to the processors is
+// cancelled, the execution is abandoned and a copy of the original message is
+// returned with each part flagged with an ErrProcessingTimeout error.
+//
+// Processors that do not support cancellation continue on the goroutine of
+// the executor after being abandoned. The processors of a pipeline are not
+// safe to execute in parallel, and so the next message waits for the abandoned
+// execution to return within its own timeout. If it is still running when
+// that timeout is exceeded the message is flagged without being processed.
+func (p *Processor) execute(msg types.Message) ([]types.Message, types.Response) {
+	if p.timeout <= 0 {
+		return processor.ExecuteAll(p.msgProcessors, msg)
+	}
+
+	if p.exec == nil {
+		p.exec = newExecutor(p.msgProcessors)
+	}
+	if p.timer == nil {
+		p.timer = time.NewTimer(p.timeout)
+	} else {
+		p.timer.Reset(p.timeout)
+	}
+
+	if p.execBusy {
+		select {
+		case <-p.exec.results:
+			p.execBusy = false
+		case <-p.timer.C:
+			p.mTimeout.Incr(1)
+			p.log.Warnf("Message processing exceeded the timeout of %v whilst waiting for an abandoned execution to return\n", p.timeout)
+			return p.timedOut(msg), nil
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p.exec.jobs <- processJob{ctx: ctx, msg: msg}
+
+	select {
+	case r := <-p.exec.results:
+		if !p.timer.Stop() {
+			select {
+			case <-p.timer.C:
+			default:
+			}
+		}
+		return r.msgs, r.res
+	case <-p.timer.C:
+	}
+
+	// The result of the abandoned execution is discarded when it returns.
+	p.execBusy = true
+
+	p.mTimeout.Incr(1)
+	p.log.Warnf("Message processing exceeded the timeout of %v and has been abandoned\n", p.timeout)
+	return p.timedOut(msg), nil
+}
+
+// timedOut returns a copy of a message with each part flagged with an
+// ErrProcessingTimeout error.
+func (p *Processor) timedOut(msg types.Message) []types.Message {
+	// Processors do not modify the messages they are given, and therefore a
+	// shallow copy is enough to flag the original contents.
+	fallback := msg.Copy()
+	fallback.Iter(func(i int, part types.Part) error {
+		processor.FlagErr(part, ErrProcessingTimeout{Timeout: p.timeout})
+		processor.SetFailPath(part, "pipeline")
+		return nil
+	})
+	return []types.Message{fallback}
+}
+
+// splitOversized removes any message parts that exceed the configured maximum
+// message size, including their metadata, from a message. The removed parts are
+// returned as a separate message of copies flagged with an ErrMessageTooLarge
+// error, which skips the processors of the pipeline and can be routed with
+// standard error handling patterns, along with their indexes within the
+// original message. The original message is not modified.
+func (p *Processor) splitOversized(msg types.Message) (types.Message, types.Message, []int) {
+	if p.maxMessageSize <= 0 {
+		return msg, nil, nil
+	}
+
+	var remaining []types.Part
+	var oversized []types.Part
+	var indexes []int
+	msg.Iter(func(i int, part types.Part) error {
+		size := message.GetPartSize(part)
+		if size <= p.maxMessageSize {
+			if oversized != nil {
+				remaining = append(remaining, part)
+			}
+			return nil
+		}
+		if oversized == nil {
+			remaining = make([]types.Part, 0, msg.Len()-1)
+			for j := 0; j < i; j++ {
+				remaining = append(remaining, msg.Get(j))
+			}
+		}
+		flagged := part.Copy()
+		processor.FlagErr(flagged, message.ErrMessageTooLarge{
+			Size:  size,
+			Limit: p.maxMessageSize,
+		})
+		processor.SetFailPath(flagged, "pipeline")
+		oversized = append(oversized, flagged)
+		indexes = append(indexes, i)
+		return nil
+	})
+	if oversized == nil {
+		return msg, nil, nil
+	}
+
+	p.mOversized.Incr(int64(len(oversized)))
+	p.log.Debugf("Removed %v message parts that exceeded the max_message_size of the pipeline\n", len(oversized))
+
+	remainingMsg := message.New(nil)
+	remainingMsg.SetAll(remaining)
+	oversizedMsg := message.New(nil)
+	oversizedMsg.SetAll(oversized)
+	return remainingMsg, oversizedMsg, indexes
+}
+
+// mergeOversized returns the oversized parts removed from a batch to the
+// results of the processors. When the processors yield a single message with
+// the same number of parts as they were given the oversized parts are placed
+// back at their original indexes, preserving the order of the batch. Otherwise
+// the processors have changed the shape of the batch, in which case the
+// original indexes are meaningless and the oversized parts are appended as a
+// separate message.
+func mergeOversized(results []types.Message, remainingLen int, oversized types.Message, indexes []int) []types.Message {
+	if len(results) != 1 || results[0].Len() != remainingLen {
+		return append(results, oversized)
+	}
+
+	parts := make([]types.Part, 0, remainingLen+oversized.Len())
+	next := 0
+	results[0].Iter(func(i int, part types.Part) error {
+		for next < len(indexes) && indexes[next] == len(parts) {
+			parts = append(parts, oversized.Get(next))
+			next++
+		}
+		parts = append(parts, part)
+		return nil
+	})
+	for ; next < len(indexes); next++ {
+		parts = append(parts, oversized.Get(next))
+	}
+	results[0].SetAll(parts)
+	return results
+}
+
 // dispatchMessages attempts to send a multiple messages results of processors
 // over the shared messages channel. This send is retried until success.
 func (p *Processor) dispatchMessages(msgs []types.Message, ogResChan chan<- types.Response) {
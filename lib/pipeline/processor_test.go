@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/lineage"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/processor"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
@@ -403,3 +406,365 @@ func TestProcessorMultiMsgsOddSync(t *testing.T) {
 		t.Error("Expected mockproc to have waited for close")
 	}
 }
+
+func TestProcessorMaxMessageSize(t *testing.T) {
+	conf := processor.NewConfig()
+	conf.Type = processor.TypeBloblang
+	conf.Bloblang = `root = content().uppercase()`
+
+	bProc, err := processor.New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proc := NewProcessor(log.Noop(), metrics.Noop(), bProc)
+	proc.maxMessageSize = 10
+
+	tChan, resChan := make(chan types.Transaction), make(chan types.Response)
+	if err := proc.Consume(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := message.New([][]byte{
+		[]byte(`small`),
+		[]byte(`this is too large`),
+		[]byte(`tiny`),
+	})
+
+	select {
+	case tChan <- types.NewTransaction(msg, resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	// The oversized part skips the processors and keeps its position within
+	// the batch.
+	expParts := []string{"SMALL", "this is too large", "TINY"}
+	expFails := []string{"", "message size of 17 bytes exceeds the limit of 10 bytes", ""}
+	select {
+	case procT := <-proc.TransactionChan():
+		if exp, act := len(expParts), procT.Payload.Len(); exp != act {
+			t.Fatalf("Wrong count of message parts: %v != %v", act, exp)
+		}
+		for i, exp := range expParts {
+			if act := string(procT.Payload.Get(i).Get()); exp != act {
+				t.Errorf("Wrong result at %v: %v != %v", i, act, exp)
+			}
+			if act := processor.GetFail(procT.Payload.Get(i)); expFails[i] != act {
+				t.Errorf("Wrong fail at %v: %v != %v", i, act, expFails[i])
+			}
+		}
+		go func() {
+			procT.ResponseChan <- response.NewAck()
+		}()
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case res := <-resChan:
+		if res.Error() != nil {
+			t.Error(res.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	// The original message is not flagged.
+	if exp, act := "", processor.GetFail(msg.Get(1)); exp != act {
+		t.Errorf("Wrong fail of original: %v != %v", act, exp)
+	}
+
+	proc.CloseAsync()
+	if err := proc.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProcessorMergeOversized(t *testing.T) {
+	oversized := message.New([][]byte{[]byte(`first`), []byte(`last`)})
+	indexes := []int{0, 3}
+
+	results := mergeOversized([]types.Message{
+		message.New([][]byte{[]byte(`foo`), []byte(`bar`)}),
+	}, 2, oversized, indexes)
+	if exp, act := 1, len(results); exp != act {
+		t.Fatalf("Wrong count of messages: %v != %v", act, exp)
+	}
+	if exp, act := [][]byte{
+		[]byte(`first`), []byte(`foo`), []byte(`bar`), []byte(`last`),
+	}, message.GetAllBytes(results[0]); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+
+	// Processors that change the shape of the batch result in the oversized
+	// parts being appended as a separate message.
+	results = mergeOversized([]types.Message{
+		message.New([][]byte{[]byte(`foo`)}),
+	}, 2, oversized, indexes)
+	if exp, act := 2, len(results); exp != act {
+		t.Fatalf("Wrong count of messages: %v != %v", act, exp)
+	}
+	if exp, act := [][]byte{
+		[]byte(`first`), []byte(`last`),
+	}, message.GetAllBytes(results[1]); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+}
+
+func TestProcessorTimeout(t *testing.T) {
+	conf := processor.NewConfig()
+	conf.Type = processor.TypeSleep
+	conf.Sleep.Duration = "500ms"
+
+	sProc, err := processor.New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proc := NewProcessor(log.Noop(), metrics.Noop(), sProc)
+	proc.timeout = time.Millisecond * 50
+
+	tChan, resChan := make(chan types.Transaction), make(chan types.Response)
+	if err := proc.Consume(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case tChan <- types.NewTransaction(message.New([][]byte{[]byte(`foo`)}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case procT := <-proc.TransactionChan():
+		if exp, act := "foo", string(procT.Payload.Get(0).Get()); exp != act {
+			t.Errorf("Wrong result: %v != %v", act, exp)
+		}
+		if exp, act := "message processing exceeded the timeout of 50ms", processor.GetFail(procT.Payload.Get(0)); exp != act {
+			t.Errorf("Wrong error flag: %v != %v", act, exp)
+		}
+		go func() {
+			procT.ResponseChan <- response.NewAck()
+		}()
+	case <-time.After(time.Millisecond * 300):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case res := <-resChan:
+		if res.Error() != nil {
+			t.Error(res.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	// The next message must not wait for the abandoned processors to return.
+	select {
+	case tChan <- types.NewTransaction(message.New([][]byte{[]byte(`bar`)}), resChan):
+	case <-time.After(time.Millisecond * 100):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case procT := <-proc.TransactionChan():
+		if exp, act := "bar", string(procT.Payload.Get(0).Get()); exp != act {
+			t.Errorf("Wrong result: %v != %v", act, exp)
+		}
+		if exp, act := "message processing exceeded the timeout of 50ms", processor.GetFail(procT.Payload.Get(0)); exp != act {
+			t.Errorf("Wrong error flag: %v != %v", act, exp)
+		}
+		go func() {
+			procT.ResponseChan <- response.NewAck()
+		}()
+	case <-time.After(time.Millisecond * 300):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case res := <-resChan:
+		if res.Error() != nil {
+			t.Error(res.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	proc.CloseAsync()
+	if err := proc.WaitForClose(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFuseProcessorsLineage(t *testing.T) {
+	var procs []types.Processor
+	for _, mapping := range []string{`root = content().uppercase()`, `root = content() + "!"`} {
+		conf := processor.NewConfig()
+		conf.Type = processor.TypeBloblang
+		conf.Bloblang = processor.BloblangConfig(mapping)
+
+		proc, err := processor.New(conf, nil, log.Noop(), metrics.Noop())
+		if err != nil {
+			t.Fatal(err)
+		}
+		procs = append(procs, lineage.WrapProcessor(fmt.Sprintf("processor.%v", len(procs)), proc))
+	}
+
+	fused := fuseProcessors(procs, []string{"pipeline.processors.0", "pipeline.processors.1"})
+	if exp, act := 1, len(fused); exp != act {
+		t.Fatalf("Wrong count of fused processors: %v != %v", act, exp)
+	}
+	if _, ok := fused[0].(processor.ContextProcessor); !ok {
+		t.Error("Expected fused processor to support contexts")
+	}
+
+	part := message.NewPart([]byte("foo"))
+	lineage.Stamp(part, "input.foo", 1)
+	msg := message.New(nil)
+	msg.Append(part)
+
+	msgs, res := processor.ExecuteAll(fused, msg)
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if exp, act := "FOO!", string(msgs[0].Get(0).Get()); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+
+	e, ok := lineage.NewEvent(msgs[0].Get(0), "output.bar", nil)
+	if !ok {
+		t.Fatal("Expected lineage event")
+	}
+	if exp, act := []string{"input.foo", "processor.0", "processor.1"}, e.Chain; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong lineage chain: %v != %v", act, exp)
+	}
+}
+
+func TestFuseBloblangConfig(t *testing.T) {
+	for _, fuse := range []bool{false, true} {
+		conf := NewConfig()
+		conf.FuseBloblang = fuse
+		for _, mapping := range []string{`root = content().uppercase()`, `root = content() + "!"`} {
+			pConf := processor.NewConfig()
+			pConf.Type = processor.TypeBloblang
+			pConf.Bloblang = processor.BloblangConfig(mapping)
+			conf.Processors = append(conf.Processors, pConf)
+		}
+
+		pipe, err := New(conf, nil, log.Noop(), metrics.Noop())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		exp := 2
+		if fuse {
+			exp = 1
+		}
+		if act := len(pipe.(*Processor).msgProcessors); exp != act {
+			t.Errorf("Wrong count of processors with fuse_bloblang %v: %v != %v", fuse, act, exp)
+		}
+		pipe.CloseAsync()
+	}
+}
+
+// blockingProc ignores cancellation and blocks each execution until released,
+// recording the number of concurrent executions.
+type blockingProc struct {
+	release chan struct{}
+
+	running    int32
+	maxRunning int32
+	calls      int32
+}
+
+func (b *blockingProc) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	atomic.AddInt32(&b.calls, 1)
+	n := atomic.AddInt32(&b.running, 1)
+	for {
+		max := atomic.LoadInt32(&b.maxRunning)
+		if n <= max || atomic.CompareAndSwapInt32(&b.maxRunning, max, n) {
+			break
+		}
+	}
+	<-b.release
+	atomic.AddInt32(&b.running, -1)
+	return []types.Message{msg}, nil
+}
+
+func (b *blockingProc) CloseAsync() {}
+
+func (b *blockingProc) WaitForClose(time.Duration) error {
+	return nil
+}
+
+func TestProcessorTimeoutNoContext(t *testing.T) {
+	bProc := &blockingProc{release: make(chan struct{})}
+
+	proc := NewProcessor(log.Noop(), metrics.Noop(), bProc)
+	proc.timeout = time.Millisecond * 50
+
+	tChan, resChan := make(chan types.Transaction), make(chan types.Response)
+	if err := proc.Consume(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	sendAndRead := func(content, expErr string) {
+		t.Helper()
+
+		select {
+		case tChan <- types.NewTransaction(message.New([][]byte{[]byte(content)}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+
+		select {
+		case procT := <-proc.TransactionChan():
+			if exp, act := content, string(procT.Payload.Get(0).Get()); exp != act {
+				t.Errorf("Wrong result: %v != %v", act, exp)
+			}
+			if exp, act := expErr, processor.GetFail(procT.Payload.Get(0)); exp != act {
+				t.Errorf("Wrong error flag: %v != %v", act, exp)
+			}
+			go func() {
+				procT.ResponseChan <- response.NewAck()
+			}()
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+
+		select {
+		case res := <-resChan:
+			if res.Error() != nil {
+				t.Error(res.Error())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	// Both messages time out, and the second is not executed whilst the
+	// abandoned execution of the first is still running.
+	sendAndRead("foo", "message processing exceeded the timeout of 50ms")
+	sendAndRead("bar", "message processing exceeded the timeout of 50ms")
+
+	if exp, act := int32(1), atomic.LoadInt32(&bProc.calls); exp != act {
+		t.Errorf("Wrong count of executions: %v != %v", act, exp)
+	}
+
+	// Once the abandoned execution returns messages are processed again.
+	close(bProc.release)
+	sendAndRead("baz", "")
+
+	if exp, act := int32(2), atomic.LoadInt32(&bProc.calls); exp != act {
+		t.Errorf("Wrong count of executions: %v != %v", act, exp)
+	}
+	if exp, act := int32(1), atomic.LoadInt32(&bProc.maxRunning); exp != act {
+		t.Errorf("Wrong count of concurrent executions: %v != %v", act, exp)
+	}
+
+	proc.CloseAsync()
+	if err := proc.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
@@ -4,7 +4,11 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"bytes"
+	"encoding/binary"
 	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
 	"os"
 	"time"
 
@@ -18,6 +22,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/klauspost/compress/zstd"
 	olog "github.com/opentracing/opentracing-go/log"
 )
 
@@ -44,7 +49,7 @@ of the batch.`,
 		},
 		UsesBatches: true,
 		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("format", "The archiving [format](#formats) to apply.").HasOptions("tar", "zip", "binary", "lines", "json_array", "concatenate"),
+			docs.FieldCommon("format", "The archiving [format](#formats) to apply.").HasOptions("tar", "zip", "multipart", "binary", "lines", "json_array", "concatenate", "zstd_seekable"),
 			docs.FieldCommon(
 				"path", "The path to set for each message in the archive (when applicable).",
 				"${!count(\"files\")}-${!timestamp_unix_nano()}.txt", "${!meta(\"kafka_key\")}-${!json(\"id\")}.json",
@@ -65,6 +70,15 @@ Archive messages to a unix standard tape archive.
 
 Archive messages to a zip file.
 
+### ` + "`multipart`" + `
+
+Archive messages to a MIME multipart body, where each message becomes a part
+with a filename set from the ` + "`path`" + ` field and a content type set from
+the metadata field ` + "`content_type`" + ` of the message, defaulting to
+` + "`application/octet-stream`" + `. The metadata field ` + "`content_type`" + `
+of the resulting message is set to ` + "`multipart/mixed`" + ` along with the
+generated boundary.
+
 ### ` + "`binary`" + `
 
 Archive messages to a binary blob format consisting of:
@@ -83,6 +97,14 @@ Join the raw contents of each message and insert a line break between each one.
 Attempt to parse each message as a JSON document and append the result to an
 array, which becomes the contents of the resulting message.
 
+### ` + "`zstd_seekable`" + `
+
+Compress each message into its own zstd frame and concatenate the frames,
+followed by a seek table following the [zstd seekable format](https://github.com/facebook/zstd/blob/dev/contrib/seekable_format/zstd_seekable_compression_format.md).
+The result can be decompressed as a whole by any zstd decoder, and individual
+messages can be read without decompressing the entire archive by tools that
+support the seekable format.
+
 ## Examples
 
 If we had JSON messages in a batch each of the form:
@@ -185,6 +207,43 @@ func zipArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
 	return newPart, nil
 }
 
+func multipartArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+
+	// Iterate through the parts of the message.
+	err := msg.Iter(func(i int, part types.Part) error {
+		contentType := part.Metadata().Get("content_type")
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+			"filename": hFunc(i, part).Name(),
+		}))
+		h.Set("Content-Type", contentType)
+
+		w, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(part.Get())
+		return err
+	})
+	mw.Close()
+
+	if err != nil {
+		return nil, err
+	}
+	newPart := msg.Get(0).Copy()
+	newPart.Set(buf.Bytes())
+	newPart.Metadata().Set("content_type", mime.FormatMediaType("multipart/mixed", map[string]string{
+		"boundary": mw.Boundary(),
+	}))
+	return newPart, nil
+}
+
 func binaryArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
 	newPart := msg.Get(0).Copy()
 	newPart.Set(message.ToBytes(msg))
@@ -236,12 +295,57 @@ func jsonArrayArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
 	return newPart, nil
 }
 
+const (
+	zstdSkippableMagic = 0x184D2A5E
+	zstdSeekableMagic  = 0x8F92EAB1
+)
+
+func zstdSeekableArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
+	// Every message must produce a frame, including empty ones, in order for
+	// the seek table to index them.
+	enc, err := zstd.NewWriter(nil, zstd.WithZeroFrames(true))
+	if err != nil {
+		return nil, err
+	}
+	defer enc.Close()
+
+	var buf bytes.Buffer
+	seekTable := make([]byte, msg.Len()*8)
+	_ = msg.Iter(func(i int, part types.Part) error {
+		frame := enc.EncodeAll(part.Get(), nil)
+		buf.Write(frame)
+		binary.LittleEndian.PutUint32(seekTable[i*8:], uint32(len(frame)))
+		binary.LittleEndian.PutUint32(seekTable[i*8+4:], uint32(len(part.Get())))
+		return nil
+	})
+
+	// The seek table is a skippable frame that ends with a footer containing
+	// the number of frames, a descriptor without checksums and the magic
+	// number of the seekable format.
+	var header [8]byte
+	binary.LittleEndian.PutUint32(header[:4], zstdSkippableMagic)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(seekTable)+9))
+	buf.Write(header[:])
+	buf.Write(seekTable)
+
+	var footer [9]byte
+	binary.LittleEndian.PutUint32(footer[:4], uint32(msg.Len()))
+	binary.LittleEndian.PutUint32(footer[5:], zstdSeekableMagic)
+	buf.Write(footer[:])
+
+	newPart := msg.Get(0).Copy()
+	newPart.Set(buf.Bytes())
+	return newPart, nil
+}
+
 func strToArchiver(str string) (archiveFunc, error) {
 	switch str {
 	case "tar":
 		return tarArchive, nil
 	case "zip":
 		return zipArchive, nil
+	case "multipart":
+		return multipartArchive, nil
 	case "binary":
 		return binaryArchive, nil
 	case "lines":
@@ -250,6 +354,8 @@ func strToArchiver(str string) (archiveFunc, error) {
 		return jsonArrayArchive, nil
 	case "concatenate":
 		return concatenateArchive, nil
+	case "zstd_seekable":
+		return zstdSeekableArchive, nil
 	}
 	return nil, fmt.Errorf("archive format not recognised: %v", str)
 }
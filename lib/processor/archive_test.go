@@ -6,6 +6,9 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
 	"reflect"
 	"testing"
 
@@ -14,6 +17,8 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -290,6 +295,31 @@ func TestArchiveBinary(t *testing.T) {
 	}
 }
 
+func TestArchiveZstdSeekable(t *testing.T) {
+	conf := NewConfig()
+	conf.Archive.Format = "zstd_seekable"
+
+	proc, err := NewArchive(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte("hello"), []byte("world"),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 1, msgs[0].Len())
+
+	// The seek table is a skippable frame and therefore the archive decodes
+	// into the concatenated messages.
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	act, err := dec.DecodeAll(msgs[0].Get(0).Get(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "helloworld", string(act))
+}
+
 func TestArchiveEmpty(t *testing.T) {
 	conf := NewConfig()
 
@@ -305,3 +335,47 @@ func TestArchiveEmpty(t *testing.T) {
 		t.Error("Expected failure with zero part message")
 	}
 }
+
+func TestArchiveMultipart(t *testing.T) {
+	conf := NewConfig()
+	conf.Archive.Format = "multipart"
+	conf.Archive.Path = `${! meta("name") }`
+
+	proc, err := NewArchive(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	input := message.New([][]byte{[]byte(`{"foo":"bar"}`), []byte("hello world")})
+	input.Get(0).Metadata().Set("name", "foo.json")
+	input.Get(0).Metadata().Set("content_type", "application/json")
+	input.Get(1).Metadata().Set("name", "bar.txt")
+
+	msgs, res := proc.ProcessMessage(input)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 1, msgs[0].Len())
+
+	mediaType, params, err := mime.ParseMediaType(msgs[0].Get(0).Metadata().Get("content_type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	mr := multipart.NewReader(bytes.NewReader(msgs[0].Get(0).Get()), params["boundary"])
+
+	p, err := mr.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "foo.json", p.FileName())
+	assert.Equal(t, "application/json", p.Header.Get("Content-Type"))
+	b, err := ioutil.ReadAll(p)
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"bar"}`, string(b))
+
+	p, err = mr.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "bar.txt", p.FileName())
+	assert.Equal(t, "application/octet-stream", p.Header.Get("Content-Type"))
+	b, err = ioutil.ReadAll(p)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(b))
+
+	_, err = mr.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
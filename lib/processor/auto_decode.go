@@ -0,0 +1,353 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/xml"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/klauspost/compress/zstd"
+	"github.com/linkedin/goavro/v2"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeAutoDecode] = TypeSpec{
+		constructor: NewAutoDecode,
+		Status:      docs.StatusExperimental,
+		Version:     "3.44.0",
+		Categories: []Category{
+			CategoryParsing,
+		},
+		Summary: `
+Detects the format of messages from their contents and decodes them accordingly, which is useful when consuming dumps of mixed formats.`,
+		Description: `
+Compression formats are detected by their magic bytes and, once decompressed, detection is repeated on the result up to a maximum of ` + "`max_depth`" + ` times, which allows for nested encodings such as gzipped JSON. Structured formats are decoded into a JSON document and end the chain.
+
+Only the formats listed in the field ` + "`formats`" + ` are detected, and messages that match none of them are left unchanged. Messages that match a format but fail to decode are flagged as having failed, allowing you to [error handle them](/docs/configuration/error_handling).
+
+### Metadata
+
+The metadata field ` + "`auto_decode_type`" + ` is set to the format that the message was decoded from last, or ` + "`unknown`" + ` if no format was detected. The metadata field ` + "`auto_decode_chain`" + ` is set to a comma separated list of all formats that were decoded, in the order they were detected, e.g. ` + "`gzip,json`" + `.
+
+Protobuf messages cannot be reliably detected or decoded without a schema and are therefore not supported, use the ` + "[`protobuf` processor](/docs/components/processors/protobuf)" + ` instead.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("formats", "The [formats](#formats) to detect.").Array().HasOptions(
+				"gzip", "zstd", "avro_ocf", "json", "xml", "csv",
+			),
+			docs.FieldAdvanced("max_depth", "The maximum number of formats to decode from a single message."),
+		},
+		Footnotes: `
+## Formats
+
+### ` + "`gzip`" + `
+
+Detected by the gzip magic bytes and decompressed.
+
+### ` + "`zstd`" + `
+
+Detected by the zstd magic bytes and decompressed.
+
+### ` + "`avro_ocf`" + `
+
+Detected by the magic bytes of an Avro Object Container File, each record is decoded into JSON and the message is replaced with an array of the records.
+
+### ` + "`json`" + `
+
+Detected when the message is a valid JSON object or array, the contents are left unchanged.
+
+### ` + "`xml`" + `
+
+Detected when the message begins with an XML element and decoded into JSON following the rules of the ` + "[`xml` processor](/docs/components/processors/xml)" + `.
+
+### ` + "`csv`" + `
+
+Detected when the message consists of at least two lines of comma separated values with a consistent number of columns greater than one. The message is replaced with an array of objects, where the first row provides the keys.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// AutoDecodeConfig contains configuration fields for the AutoDecode processor.
+type AutoDecodeConfig struct {
+	Formats  []string `json:"formats" yaml:"formats"`
+	MaxDepth int      `json:"max_depth" yaml:"max_depth"`
+}
+
+// NewAutoDecodeConfig returns a AutoDecodeConfig with default values.
+func NewAutoDecodeConfig() AutoDecodeConfig {
+	return AutoDecodeConfig{
+		Formats:  []string{"gzip", "zstd", "avro_ocf", "json", "xml", "csv"},
+		MaxDepth: 4,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// autoDecoder attempts to decode a payload. When the format is not detected
+// ok is false, and when the decoded result is a structured document and cannot
+// be decoded further done is true.
+type autoDecoder func(b []byte) (result []byte, ok, done bool, err error)
+
+func gzipAutoDecode(b []byte) ([]byte, bool, bool, error) {
+	if !bytes.HasPrefix(b, []byte{0x1f, 0x8b}) {
+		return nil, false, false, nil
+	}
+	res, err := gzipDecompress(b)
+	return res, true, false, err
+}
+
+func zstdAutoDecode(b []byte) ([]byte, bool, bool, error) {
+	if !bytes.HasPrefix(b, []byte{0x28, 0xb5, 0x2f, 0xfd}) {
+		return nil, false, false, nil
+	}
+	dec, err := zstd.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, true, false, err
+	}
+	defer dec.Close()
+	res, err := ioutil.ReadAll(dec)
+	return res, true, false, err
+}
+
+func avroOCFAutoDecode(b []byte) ([]byte, bool, bool, error) {
+	if !bytes.HasPrefix(b, []byte{'O', 'b', 'j', 0x01}) {
+		return nil, false, false, nil
+	}
+	ocf, err := goavro.NewOCFReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, true, true, err
+	}
+	records := []json.RawMessage{}
+	for ocf.Scan() {
+		datum, err := ocf.Read()
+		if err != nil {
+			return nil, true, true, err
+		}
+		textual, err := ocf.Codec().TextualFromNative(nil, datum)
+		if err != nil {
+			return nil, true, true, err
+		}
+		records = append(records, textual)
+	}
+	if err = ocf.Err(); err != nil {
+		return nil, true, true, err
+	}
+	res, err := json.Marshal(records)
+	return res, true, true, err
+}
+
+func jsonAutoDecode(b []byte) ([]byte, bool, bool, error) {
+	trimmed := bytes.TrimSpace(b)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') || !json.Valid(trimmed) {
+		return nil, false, false, nil
+	}
+	return b, true, true, nil
+}
+
+func xmlAutoDecode(b []byte) ([]byte, bool, bool, error) {
+	trimmed := bytes.TrimSpace(b)
+	if len(trimmed) < 2 || trimmed[0] != '<' || trimmed[len(trimmed)-1] != '>' {
+		return nil, false, false, nil
+	}
+	root, err := xml.ToMap(trimmed)
+	if err != nil {
+		return nil, true, true, err
+	}
+	res, err := json.Marshal(root)
+	return res, true, true, err
+}
+
+func csvAutoDecode(b []byte) ([]byte, bool, bool, error) {
+	if !utf8.Valid(b) || !bytes.Contains(b, []byte("\n")) {
+		return nil, false, false, nil
+	}
+
+	r := csv.NewReader(bytes.NewReader(b))
+	headers, err := r.Read()
+	if err != nil || len(headers) < 2 {
+		return nil, false, false, nil
+	}
+
+	rows := []map[string]string{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Inconsistent columns indicate that the data is not CSV.
+			return nil, false, false, nil
+		}
+		row := make(map[string]string, len(headers))
+		for i, h := range headers {
+			row[h] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, false, false, nil
+	}
+
+	res, err := json.Marshal(rows)
+	return res, true, true, err
+}
+
+func strToAutoDecoder(str string) (autoDecoder, error) {
+	switch str {
+	case "gzip":
+		return gzipAutoDecode, nil
+	case "zstd":
+		return zstdAutoDecode, nil
+	case "avro_ocf":
+		return avroOCFAutoDecode, nil
+	case "json":
+		return jsonAutoDecode, nil
+	case "xml":
+		return xmlAutoDecode, nil
+	case "csv":
+		return csvAutoDecode, nil
+	}
+	return nil, fmt.Errorf("format not recognised: %v", str)
+}
+
+//------------------------------------------------------------------------------
+
+// AutoDecode is a processor that detects the format of messages and decodes
+// them accordingly.
+type AutoDecode struct {
+	formats  []string
+	decoders []autoDecoder
+	maxDepth int
+
+	log log.Modular
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mUnknown   metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewAutoDecode returns a AutoDecode processor.
+func NewAutoDecode(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	if len(conf.AutoDecode.Formats) == 0 {
+		return nil, errors.New("at least one format must be specified")
+	}
+	if conf.AutoDecode.MaxDepth < 1 {
+		return nil, errors.New("max_depth must be greater than zero")
+	}
+	var decoders []autoDecoder
+	for _, f := range conf.AutoDecode.Formats {
+		dec, err := strToAutoDecoder(f)
+		if err != nil {
+			return nil, err
+		}
+		decoders = append(decoders, dec)
+	}
+	return &AutoDecode{
+		formats:  conf.AutoDecode.Formats,
+		decoders: decoders,
+		maxDepth: conf.AutoDecode.MaxDepth,
+		log:      log,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mUnknown:   stats.GetCounter("unknown"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (a *AutoDecode) decode(b []byte) ([]byte, []string, error) {
+	var chain []string
+	for len(chain) < a.maxDepth {
+		detected := false
+		for i, dec := range a.decoders {
+			res, ok, done, err := dec(b)
+			if !ok {
+				continue
+			}
+			chain = append(chain, a.formats[i])
+			if err != nil {
+				return nil, chain, fmt.Errorf("failed to decode %v: %w", a.formats[i], err)
+			}
+			if done {
+				return res, chain, nil
+			}
+			b, detected = res, true
+			break
+		}
+		if !detected {
+			break
+		}
+	}
+	return b, chain, nil
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (a *AutoDecode) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	a.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	proc := func(i int, span opentracing.Span, part types.Part) error {
+		res, chain, err := a.decode(part.Get())
+		if err != nil {
+			a.mErr.Incr(1)
+			a.log.Debugf("Failed to decode message part: %v\n", err)
+			return err
+		}
+
+		detectedType := "unknown"
+		if len(chain) > 0 {
+			detectedType = chain[len(chain)-1]
+			part.Set(res)
+		} else {
+			a.mUnknown.Incr(1)
+		}
+		part.Metadata().Set("auto_decode_type", detectedType)
+		part.Metadata().Set("auto_decode_chain", strings.Join(chain, ","))
+		return nil
+	}
+
+	if newMsg.Len() == 0 {
+		return nil, response.NewAck()
+	}
+
+	IteratePartsWithSpan(TypeAutoDecode, nil, newMsg, proc)
+
+	a.mBatchSent.Incr(1)
+	a.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (a *AutoDecode) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (a *AutoDecode) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
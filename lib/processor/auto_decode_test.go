@@ -0,0 +1,167 @@
+package processor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/klauspost/compress/zstd"
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func autoDecodeGzip(t *testing.T, b []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func autoDecodeZstd(t *testing.T, b []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	require.NoError(t, err)
+	_, err = zw.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func autoDecodeOCF(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := goavro.NewOCFWriter(goavro.OCFConfig{
+		W:      &buf,
+		Schema: `{"type":"record","name":"foo","fields":[{"name":"name","type":"string"}]}`,
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Append([]interface{}{
+		map[string]interface{}{"name": "foo"},
+		map[string]interface{}{"name": "bar"},
+	}))
+	return buf.Bytes()
+}
+
+func TestAutoDecodeFormats(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeAutoDecode
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		input    []byte
+		output   string
+		detected string
+		chain    string
+	}{
+		{
+			name:     "json",
+			input:    []byte(`{"foo":"bar"}`),
+			output:   `{"foo":"bar"}`,
+			detected: "json",
+			chain:    "json",
+		},
+		{
+			name:     "gzipped json",
+			input:    autoDecodeGzip(t, []byte(`{"foo":"bar"}`)),
+			output:   `{"foo":"bar"}`,
+			detected: "json",
+			chain:    "gzip,json",
+		},
+		{
+			name:     "zstd gzipped csv",
+			input:    autoDecodeZstd(t, autoDecodeGzip(t, []byte("a,b\n1,2\n3,4\n"))),
+			output:   `[{"a":"1","b":"2"},{"a":"3","b":"4"}]`,
+			detected: "csv",
+			chain:    "zstd,gzip,csv",
+		},
+		{
+			name:     "xml",
+			input:    []byte(`<root><foo>bar</foo></root>`),
+			output:   `{"root":{"foo":"bar"}}`,
+			detected: "xml",
+			chain:    "xml",
+		},
+		{
+			name:     "avro ocf",
+			input:    autoDecodeOCF(t),
+			output:   `[{"name":"foo"},{"name":"bar"}]`,
+			detected: "avro_ocf",
+			chain:    "avro_ocf",
+		},
+		{
+			name:     "unknown",
+			input:    []byte(`hello world`),
+			output:   `hello world`,
+			detected: "unknown",
+			chain:    "",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			msgs, res := proc.ProcessMessage(message.New([][]byte{test.input}))
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+
+			part := msgs[0].Get(0)
+			assert.False(t, HasFailed(part), GetFail(part))
+			assert.Equal(t, test.output, string(part.Get()))
+			assert.Equal(t, test.detected, part.Metadata().Get("auto_decode_type"))
+			assert.Equal(t, test.chain, part.Metadata().Get("auto_decode_chain"))
+		})
+	}
+}
+
+func TestAutoDecodeLimits(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeAutoDecode
+	conf.AutoDecode.Formats = []string{"gzip", "csv"}
+	conf.AutoDecode.MaxDepth = 1
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	inner := autoDecodeGzip(t, []byte("a,b\n1,2\n"))
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		autoDecodeGzip(t, inner),
+		[]byte(`{"foo":"bar"}`),
+		{0x1f, 0x8b, 0x00},
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, inner, msgs[0].Get(0).Get())
+	assert.Equal(t, "gzip", msgs[0].Get(0).Metadata().Get("auto_decode_type"))
+
+	assert.Equal(t, `{"foo":"bar"}`, string(msgs[0].Get(1).Get()))
+	assert.Equal(t, "unknown", msgs[0].Get(1).Metadata().Get("auto_decode_type"))
+
+	assert.True(t, HasFailed(msgs[0].Get(2)))
+	assert.Contains(t, GetFail(msgs[0].Get(2)), "failed to decode gzip")
+}
+
+func TestAutoDecodeBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeAutoDecode
+	conf.AutoDecode.Formats = []string{"protobuf"}
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "format not recognised: protobuf")
+
+	conf.AutoDecode.Formats = []string{"json"}
+	conf.AutoDecode.MaxDepth = 0
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "max_depth must be greater than zero")
+}
@@ -1,9 +1,12 @@
 package processor
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"strings"
+	"sync"
 	"time"
 
 	"net/http"
@@ -12,6 +15,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/schemaregistry"
 	"github.com/linkedin/goavro/v2"
 	"github.com/opentracing/opentracing-go"
 )
@@ -39,7 +43,33 @@ specifies how the source documents are encoded.
 ### ` + "`from_json`" + `
 
 Attempts to convert JSON documents into Avro documents according to the
-specified encoding.`,
+specified encoding.
+
+## Schema Registry
+
+Schemas can be obtained from a
+[schema registry resource](/docs/configuration/resources#schema-registries) by
+setting the field ` + "`schema_registry`" + ` to its label, in which case the
+fields ` + "`encoding`, `schema` and `schema_path`" + ` are ignored and documents
+are in the Confluent wire format, where the binary encoded document is prefixed
+with the ID of its schema.
+
+The ` + "`to_json`" + ` operator obtains the schema of each document by the
+ID it is prefixed with, and the ` + "`from_json`" + ` operator encodes documents
+with the latest schema of the subject specified in the field ` + "`subject`" + `.
+
+` + "```yaml" + `
+pipeline:
+  processors:
+    - avro:
+        operator: from_json
+        schema_registry: registry
+        subject: users-value
+
+schema_registry_resources:
+  - label: registry
+    url: http://localhost:8081
+` + "```" + ``,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("operator", "The [operator](#operators) to execute").HasOptions("to_json", "from_json"),
 			docs.FieldCommon("encoding", "An Avro encoding format to use for conversions to and from a schema.").HasOptions("textual", "binary", "single"),
@@ -49,6 +79,8 @@ specified encoding.`,
 				"file://path/to/spec.avsc",
 				"http://localhost:8081/path/to/spec/versions/1",
 			),
+			docs.FieldCommon("schema_registry", "The label of a [schema registry resource](/docs/configuration/resources#schema-registries) to obtain schemas from.").AtVersion("3.44.0"),
+			docs.FieldCommon("subject", "The subject of the schema used to encode documents with the `from_json` operator when a `schema_registry` is set.", "users-value").AtVersion("3.44.0"),
 			PartsFieldSpec,
 		},
 	}
@@ -58,21 +90,25 @@ specified encoding.`,
 
 // AvroConfig contains configuration fields for the Avro processor.
 type AvroConfig struct {
-	Parts      []int  `json:"parts" yaml:"parts"`
-	Operator   string `json:"operator" yaml:"operator"`
-	Encoding   string `json:"encoding" yaml:"encoding"`
-	Schema     string `json:"schema" yaml:"schema"`
-	SchemaPath string `json:"schema_path" yaml:"schema_path"`
+	Parts          []int  `json:"parts" yaml:"parts"`
+	Operator       string `json:"operator" yaml:"operator"`
+	Encoding       string `json:"encoding" yaml:"encoding"`
+	Schema         string `json:"schema" yaml:"schema"`
+	SchemaPath     string `json:"schema_path" yaml:"schema_path"`
+	SchemaRegistry string `json:"schema_registry" yaml:"schema_registry"`
+	Subject        string `json:"subject" yaml:"subject"`
 }
 
 // NewAvroConfig returns a AvroConfig with default values.
 func NewAvroConfig() AvroConfig {
 	return AvroConfig{
-		Parts:      []int{},
-		Operator:   "to_json",
-		Encoding:   "textual",
-		Schema:     "",
-		SchemaPath: "",
+		Parts:          []int{},
+		Operator:       "to_json",
+		Encoding:       "textual",
+		Schema:         "",
+		SchemaPath:     "",
+		SchemaRegistry: "",
+		Subject:        "",
 	}
 }
 
@@ -174,6 +210,87 @@ func strToAvroOperator(opStr, encoding string, codec *goavro.Codec) (avroOperato
 	return nil, fmt.Errorf("operator not recognised: %v", opStr)
 }
 
+// avroRegistryCodecs caches the codecs of schemas obtained from a schema
+// registry by schema ID.
+type avroRegistryCodecs struct {
+	mut    sync.Mutex
+	codecs map[int]*goavro.Codec
+}
+
+func (a *avroRegistryCodecs) codecFor(schema schemaregistry.Schema) (*goavro.Codec, error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if codec, exists := a.codecs[schema.ID]; exists {
+		return codec, nil
+	}
+	if schema.Type != "AVRO" {
+		return nil, fmt.Errorf("schema %v has unsupported type: %v", schema.ID, schema.Type)
+	}
+	codec, err := goavro.NewCodec(schema.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema %v: %v", schema.ID, err)
+	}
+	a.codecs[schema.ID] = codec
+	return codec, nil
+}
+
+func newAvroRegistryOperator(opStr, subject string, registry *schemaregistry.Client) (avroOperator, error) {
+	codecs := &avroRegistryCodecs{
+		codecs: map[int]*goavro.Codec{},
+	}
+	switch opStr {
+	case "to_json":
+		return func(part types.Part) error {
+			id, payload, err := schemaregistry.ParseWireHeader(part.Get())
+			if err != nil {
+				return fmt.Errorf("failed to read schema ID: %v", err)
+			}
+			schema, err := registry.GetSchemaByID(context.Background(), id)
+			if err != nil {
+				return fmt.Errorf("failed to obtain schema %v: %v", id, err)
+			}
+			codec, err := codecs.codecFor(schema)
+			if err != nil {
+				return err
+			}
+			jObj, _, err := codec.NativeFromBinary(payload)
+			if err != nil {
+				return fmt.Errorf("failed to convert Avro document to JSON: %v", err)
+			}
+			if err = part.SetJSON(jObj); err != nil {
+				return fmt.Errorf("failed to set JSON: %v", err)
+			}
+			return nil
+		}, nil
+	case "from_json":
+		if subject == "" {
+			return nil, errors.New("a subject is required for the from_json operator when using a schema registry")
+		}
+		return func(part types.Part) error {
+			jObj, err := part.JSON()
+			if err != nil {
+				return fmt.Errorf("failed to parse message as JSON: %v", err)
+			}
+			schema, err := registry.GetLatestSchema(context.Background(), subject)
+			if err != nil {
+				return fmt.Errorf("failed to obtain schema of subject %v: %v", subject, err)
+			}
+			codec, err := codecs.codecFor(schema)
+			if err != nil {
+				return err
+			}
+			var binary []byte
+			if binary, err = codec.BinaryFromNative(nil, jObj); err != nil {
+				return fmt.Errorf("failed to convert JSON to Avro schema: %v", err)
+			}
+			part.Set(schemaregistry.AddWireHeader(schema.ID, binary))
+			return nil
+		}, nil
+	}
+	return nil, fmt.Errorf("operator not recognised: %v", opStr)
+}
+
 func loadSchema(schemaPath string) (string, error) {
 	t := &http.Transport{}
 	t.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
@@ -231,6 +348,17 @@ func NewAvro(
 	var schema string
 	var err error
 
+	if conf.Avro.SchemaRegistry != "" {
+		registry, err := schemaregistry.FromManager(mgr, conf.Avro.SchemaRegistry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain schema registry '%v': %v", conf.Avro.SchemaRegistry, err)
+		}
+		if a.operator, err = newAvroRegistryOperator(conf.Avro.Operator, conf.Avro.Subject, registry); err != nil {
+			return nil, err
+		}
+		return a, nil
+	}
+
 	if schemaPath := conf.Avro.SchemaPath; schemaPath != "" {
 		if !(strings.HasPrefix(schemaPath, "file://") || strings.HasPrefix(schemaPath, "http://")) {
 			return nil, fmt.Errorf("invalid schema_path provided, must start with file:// or http://")
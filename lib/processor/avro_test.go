@@ -3,6 +3,8 @@ package processor
 import (
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"strconv"
@@ -12,6 +14,9 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/schemaregistry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAvroBasic(t *testing.T) {
@@ -331,3 +336,90 @@ func TestAvroSchemaPathNotExist(t *testing.T) {
 		t.Error("expected error from loading non existant schema file")
 	}
 }
+
+type fakeSchemaRegistryMgr struct {
+	types.DudMgr
+	registries map[string]*schemaregistry.Client
+}
+
+func (f fakeSchemaRegistryMgr) GetSchemaRegistry(name string) (*schemaregistry.Client, error) {
+	if c, exists := f.registries[name]; exists {
+		return c, nil
+	}
+	return nil, fmt.Errorf("schema registry %v not found", name)
+}
+
+func newFakeSchemaRegistryMgr(t *testing.T, url string) fakeSchemaRegistryMgr {
+	t.Helper()
+
+	conf := schemaregistry.NewConfig()
+	conf.URL = url
+	c, err := schemaregistry.New(conf)
+	require.NoError(t, err)
+
+	return fakeSchemaRegistryMgr{
+		registries: map[string]*schemaregistry.Client{"registry": c},
+	}
+}
+
+func TestAvroSchemaRegistry(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/subjects/users-value/versions/latest":
+			w.Write([]byte(`{"subject":"users-value","id":3,"version":1,"schema":"{\"type\":\"record\",\"name\":\"user\",\"fields\":[{\"name\":\"name\",\"type\":\"string\"}]}"}`))
+		case "/schemas/ids/3":
+			w.Write([]byte(`{"schema":"{\"type\":\"record\",\"name\":\"user\",\"fields\":[{\"name\":\"name\",\"type\":\"string\"}]}"}`))
+		case "/schemas/ids/4":
+			w.Write([]byte(`{"schemaType":"JSON","schema":"{}"}`))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	mgr := newFakeSchemaRegistryMgr(t, ts.URL)
+
+	conf := NewConfig()
+	conf.Avro.Operator = "from_json"
+	conf.Avro.SchemaRegistry = "registry"
+	conf.Avro.Subject = "users-value"
+
+	enc, err := NewAvro(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf.Avro.Operator = "to_json"
+	dec, err := NewAvro(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := enc.ProcessMessage(message.New([][]byte{[]byte(`{"name":"foo"}`)}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, []byte{0, 0, 0, 0, 3, 6, 'f', 'o', 'o'}, msgs[0].Get(0).Get())
+
+	msgs, res = dec.ProcessMessage(msgs[0])
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, `{"name":"foo"}`, string(msgs[0].Get(0).Get()))
+
+	msgs, _ = dec.ProcessMessage(message.New([][]byte{
+		{0, 0, 0, 0, 4, 6, 'f', 'o', 'o'},
+		{0, 0, 0, 0, 5, 6, 'f', 'o', 'o'},
+	}))
+	assert.Equal(t, "schema 4 has unsupported type: JSON", GetFail(msgs[0].Get(0)))
+	assert.Contains(t, GetFail(msgs[0].Get(1)), "failed to obtain schema 5")
+}
+
+func TestAvroSchemaRegistryBadConfig(t *testing.T) {
+	mgr := newFakeSchemaRegistryMgr(t, "http://localhost:8081")
+
+	conf := NewConfig()
+	conf.Avro.Operator = "from_json"
+	conf.Avro.SchemaRegistry = "registry"
+
+	_, err := NewAvro(conf, mgr, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "a subject is required for the from_json operator when using a schema registry")
+
+	conf.Avro.SchemaRegistry = "nope"
+	_, err = NewAvro(conf, mgr, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "failed to obtain schema registry 'nope': schema registry nope not found")
+}
@@ -0,0 +1,501 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeAwait] = TypeSpec{
+		constructor: NewAwait,
+		Status:      docs.StatusExperimental,
+		Version:     "3.44.0",
+		Categories: []Category{
+			CategoryIntegration,
+		},
+		Summary: `
+Publishes a request for each message to an output resource and joins the asynchronous response, consumed from an input resource, back into the original message by a correlation ID.`,
+		Description: `
+This processor enables enrichment from services that communicate over a pair of queues or topics rather than with a synchronous request and response, such as RPC patterns over Kafka, AMQP or NATS.
+
+For each message of a batch a correlation ID is created with the interpolated ` + "`correlation_id`" + ` field, and a request is created from the message with the optional ` + "`request_map`" + ` mapping. The correlation ID is added to each request as the metadata field ` + "`metadata_key`" + ` and the requests are written as a batch to the ` + "`output`" + ` resource.
+
+Responses are consumed from the ` + "`input`" + ` resource and matched to pending requests by the value of the same metadata field, which must therefore be copied from requests to their responses by the responding service. If a service places the correlation ID elsewhere then it can be moved into metadata with processors on the input resource. Responses that do not match a pending request, such as those arriving after a timeout, are acknowledged and dropped.
+
+Once a response is matched it is mapped back into the original message with the ` + "`result_map`" + ` mapping, where ` + "`this`" + ` refers to the response and the origin message is the starting point of the result. If the ` + "`result_map`" + ` is left empty the response replaces the original message, including its metadata.
+
+Messages are held by this processor until their response arrives or the ` + "`timeout`" + ` elapses, in which case they are flagged as having failed and can be handled with [error handling patterns](/docs/configuration/error_handling). The messages of a batch are awaited concurrently, and therefore the number of requests in flight can be increased with batching or with the number of processing threads.
+
+All instances of this processor that consume the same input resource share a single consumer, and so the input resource should be dedicated to responses of this processor and each instance must use the same ` + "`metadata_key`" + `.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("output", "The label of an [output resource](/docs/components/outputs/about#resources) to send requests to."),
+			docs.FieldCommon("input", "The label of an [input resource](/docs/components/inputs/about#resources) to consume responses from."),
+			docs.FieldAdvanced("correlation_id", "A unique ID created for each message in order to correlate its response.").IsInterpolated(),
+			docs.FieldAdvanced("metadata_key", "The metadata field that carries the correlation ID of requests and responses."),
+			docs.FieldCommon(
+				"request_map",
+				"An optional [Bloblang mapping](/docs/guides/bloblang/about) that describes how to create a request from a message. If left empty the request is an exact copy of the message (including metadata).",
+				`root.id = this.user.id`,
+			).Linter(docs.LintBloblangMapping),
+			docs.FieldCommon(
+				"result_map",
+				"An optional [Bloblang mapping](/docs/guides/bloblang/about) that describes how a response should be mapped back into the original message. If left empty the response replaces the original message (including metadata).",
+				`root.user.profile = this`,
+			).Linter(docs.LintBloblangMapping),
+			docs.FieldCommon("timeout", "The maximum period to wait for the response of a message."),
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title:   "Kafka RPC",
+				Summary: "Here we enrich user documents with a profile obtained from a service that consumes requests from a Kafka topic and publishes responses to another, copying the `correlation_id` header from each request to its response:",
+				Config: `
+pipeline:
+  processors:
+    - await:
+        output: profile_requests
+        input: profile_responses
+        request_map: 'root.user_id = this.user.id'
+        result_map: 'root.user.profile = this'
+        timeout: 10s
+
+output_resources:
+  - label: profile_requests
+    kafka:
+      addresses: [ localhost:9092 ]
+      topic: profile_requests
+
+input_resources:
+  - label: profile_responses
+    kafka:
+      addresses: [ localhost:9092 ]
+      topics: [ profile_responses ]
+      consumer_group: benthos_profiles
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// AwaitConfig contains configuration fields for the Await processor.
+type AwaitConfig struct {
+	Output        string `json:"output" yaml:"output"`
+	Input         string `json:"input" yaml:"input"`
+	CorrelationID string `json:"correlation_id" yaml:"correlation_id"`
+	MetadataKey   string `json:"metadata_key" yaml:"metadata_key"`
+	RequestMap    string `json:"request_map" yaml:"request_map"`
+	ResultMap     string `json:"result_map" yaml:"result_map"`
+	Timeout       string `json:"timeout" yaml:"timeout"`
+}
+
+// NewAwaitConfig returns a AwaitConfig with default values.
+func NewAwaitConfig() AwaitConfig {
+	return AwaitConfig{
+		Output:        "",
+		Input:         "",
+		CorrelationID: "${! uuid_v4() }",
+		MetadataKey:   "correlation_id",
+		RequestMap:    "",
+		ResultMap:     "",
+		Timeout:       "30s",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type awaitResourceProvider interface {
+	GetInput(name string) (types.Input, error)
+	GetOutput(name string) (types.OutputWriter, error)
+}
+
+// awaitJoiner consumes responses from an input resource and delivers them to
+// the pending requests of any await processor sharing that input, since
+// processors are constructed once per processing thread.
+type awaitJoiner struct {
+	in   types.Input
+	key  string
+	refs int
+
+	pendingMut sync.Mutex
+	pending    map[string]chan types.Part
+
+	log        log.Modular
+	mUnmatched metrics.StatCounter
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+var (
+	awaitJoinersMut sync.Mutex
+	awaitJoiners    = map[types.Input]*awaitJoiner{}
+)
+
+func acquireAwaitJoiner(in types.Input, key string, log log.Modular, stats metrics.Type) (*awaitJoiner, error) {
+	awaitJoinersMut.Lock()
+	defer awaitJoinersMut.Unlock()
+
+	if j, exists := awaitJoiners[in]; exists {
+		if j.key != key {
+			return nil, fmt.Errorf("input resource is already awaited with metadata key '%v'", j.key)
+		}
+		j.refs++
+		return j, nil
+	}
+
+	j := &awaitJoiner{
+		in:         in,
+		key:        key,
+		refs:       1,
+		pending:    map[string]chan types.Part{},
+		log:        log,
+		mUnmatched: stats.GetCounter("response.unmatched"),
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}
+	awaitJoiners[in] = j
+	go j.loop()
+	return j, nil
+}
+
+func (j *awaitJoiner) release() {
+	awaitJoinersMut.Lock()
+	defer awaitJoinersMut.Unlock()
+
+	if j.refs--; j.refs == 0 {
+		delete(awaitJoiners, j.in)
+		close(j.closeChan)
+	}
+}
+
+func (j *awaitJoiner) register(id string) (chan types.Part, bool) {
+	j.pendingMut.Lock()
+	defer j.pendingMut.Unlock()
+
+	if _, exists := j.pending[id]; exists {
+		return nil, false
+	}
+	resChan := make(chan types.Part, 1)
+	j.pending[id] = resChan
+	return resChan, true
+}
+
+func (j *awaitJoiner) unregister(id string) {
+	j.pendingMut.Lock()
+	delete(j.pending, id)
+	j.pendingMut.Unlock()
+}
+
+func (j *awaitJoiner) deliver(p types.Part) {
+	id := p.Metadata().Get(j.key)
+
+	j.pendingMut.Lock()
+	resChan, exists := j.pending[id]
+	delete(j.pending, id)
+	j.pendingMut.Unlock()
+
+	if !exists {
+		j.mUnmatched.Incr(1)
+		j.log.Debugf("Dropping response with unmatched correlation ID '%v'\n", id)
+		return
+	}
+	resChan <- p
+}
+
+func (j *awaitJoiner) loop() {
+	defer close(j.closedChan)
+
+	for {
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-j.in.TransactionChan():
+			if !open {
+				return
+			}
+		case <-j.closeChan:
+			return
+		}
+
+		_ = tran.Payload.Iter(func(i int, p types.Part) error {
+			j.deliver(p.Copy())
+			return nil
+		})
+
+		select {
+		case tran.ResponseChan <- response.NewAck():
+		case <-j.closeChan:
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Await is a processor that sends requests to an output resource and joins
+// their asynchronous responses from an input resource.
+type Await struct {
+	conf    AwaitConfig
+	mgr     awaitResourceProvider
+	joiner  *awaitJoiner
+	timeout time.Duration
+
+	correlationID field.Expression
+	requestMap    *mapping.Executor
+	resultMap     *mapping.Executor
+
+	log log.Modular
+
+	mCount      metrics.StatCounter
+	mErr        metrics.StatCounter
+	mErrReq     metrics.StatCounter
+	mErrRes     metrics.StatCounter
+	mErrSend    metrics.StatCounter
+	mErrTimeout metrics.StatCounter
+	mSent       metrics.StatCounter
+	mBatchSent  metrics.StatCounter
+
+	closeOnce sync.Once
+}
+
+// NewAwait returns an Await processor.
+func NewAwait(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	// TODO: V4 Remove this
+	provider, ok := mgr.(awaitResourceProvider)
+	if !ok {
+		return nil, errors.New("manager does not support input and output resources")
+	}
+	if _, err := provider.GetOutput(conf.Await.Output); err != nil {
+		return nil, fmt.Errorf("failed to obtain output resource '%v': %v", conf.Await.Output, err)
+	}
+	in, err := provider.GetInput(conf.Await.Input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain input resource '%v': %v", conf.Await.Input, err)
+	}
+	if len(conf.Await.MetadataKey) == 0 {
+		return nil, errors.New("metadata_key must not be empty")
+	}
+
+	timeout, err := time.ParseDuration(conf.Await.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeout: %v", err)
+	}
+
+	a := &Await{
+		conf:    conf.Await,
+		mgr:     provider,
+		timeout: timeout,
+		log:     log,
+
+		mCount:      stats.GetCounter("count"),
+		mErr:        stats.GetCounter("error"),
+		mErrReq:     stats.GetCounter("error_request_map"),
+		mErrRes:     stats.GetCounter("error_result_map"),
+		mErrSend:    stats.GetCounter("error_send"),
+		mErrTimeout: stats.GetCounter("error_timeout"),
+		mSent:       stats.GetCounter("sent"),
+		mBatchSent:  stats.GetCounter("batch.sent"),
+	}
+
+	if a.correlationID, err = bloblang.NewField(conf.Await.CorrelationID); err != nil {
+		return nil, fmt.Errorf("failed to parse correlation_id expression: %v", err)
+	}
+	if len(conf.Await.RequestMap) > 0 {
+		if a.requestMap, err = bloblang.NewMapping("", conf.Await.RequestMap); err != nil {
+			return nil, fmt.Errorf("failed to parse request mapping: %w", err)
+		}
+	}
+	if len(conf.Await.ResultMap) > 0 {
+		if a.resultMap, err = bloblang.NewMapping("", conf.Await.ResultMap); err != nil {
+			return nil, fmt.Errorf("failed to parse result mapping: %w", err)
+		}
+	}
+
+	if a.joiner, err = acquireAwaitJoiner(in, conf.Await.MetadataKey, log, stats); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (a *Await) send(msg types.Message) error {
+	out, err := a.mgr.GetOutput(a.conf.Output)
+	if err != nil {
+		return err
+	}
+	ctx, done := context.WithTimeout(context.Background(), a.timeout)
+	defer done()
+
+	resChan := make(chan types.Response)
+	if err = out.WriteTransaction(ctx, types.NewTransaction(msg, resChan)); err != nil {
+		return err
+	}
+	select {
+	case res := <-resChan:
+		return res.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (a *Await) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	a.mCount.Incr(1)
+
+	ids := make([]string, msg.Len())
+	resChans := make([]chan types.Part, msg.Len())
+	requests := message.New(nil)
+
+	defer func() {
+		for i, resChan := range resChans {
+			if resChan != nil {
+				a.joiner.unregister(ids[i])
+			}
+		}
+	}()
+
+	_ = msg.Iter(func(i int, p types.Part) error {
+		ids[i] = a.correlationID.String(i, msg)
+
+		var req types.Part
+		if a.requestMap != nil {
+			var err error
+			if req, err = a.requestMap.MapPart(i, msg); err != nil {
+				a.mErrReq.Incr(1)
+				a.log.Debugf("Failed to map request '%v': %v\n", i, err)
+				FlagErr(p, fmt.Errorf("request map: %w", err))
+				return nil
+			}
+			if req == nil {
+				return nil
+			}
+		} else {
+			req = p.Copy()
+		}
+
+		resChan, ok := a.joiner.register(ids[i])
+		if !ok {
+			a.mErr.Incr(1)
+			FlagErr(p, fmt.Errorf("correlation ID '%v' is already awaiting a response", ids[i]))
+			return nil
+		}
+		resChans[i] = resChan
+
+		req.Metadata().Set(a.conf.MetadataKey, ids[i])
+		requests.Append(req)
+		return nil
+	})
+
+	if requests.Len() > 0 {
+		if err := a.send(requests); err != nil {
+			a.mErrSend.Incr(1)
+			a.log.Errorf("Failed to send requests to output '%v': %v\n", a.conf.Output, err)
+			err = fmt.Errorf("failed to send request: %w", err)
+			for i, resChan := range resChans {
+				if resChan != nil {
+					FlagErr(msg.Get(i), err)
+				}
+			}
+			a.mBatchSent.Incr(1)
+			a.mSent.Incr(int64(msg.Len()))
+			return []types.Message{msg}, nil
+		}
+	}
+
+	responses := make([]types.Part, msg.Len())
+	deadline := time.NewTimer(a.timeout)
+	defer deadline.Stop()
+
+	timedOut := false
+	for i, resChan := range resChans {
+		if resChan == nil {
+			continue
+		}
+		if !timedOut {
+			select {
+			case responses[i] = <-resChan:
+				continue
+			case <-deadline.C:
+				timedOut = true
+			}
+		}
+		select {
+		case responses[i] = <-resChan:
+		default:
+			a.mErrTimeout.Incr(1)
+			FlagErr(msg.Get(i), fmt.Errorf("timed out after %v awaiting response", a.timeout))
+		}
+	}
+
+	a.overlayResponses(msg, responses)
+
+	a.mBatchSent.Incr(1)
+	a.mSent.Incr(int64(msg.Len()))
+	return []types.Message{msg}, nil
+}
+
+func (a *Await) overlayResponses(msg types.Message, responses []types.Part) {
+	resMsg := message.New(nil)
+	for _, res := range responses {
+		if res == nil {
+			res = message.NewPart(nil)
+		}
+		resMsg.Append(res)
+	}
+
+	parts := make([]types.Part, msg.Len())
+	_ = msg.Iter(func(i int, p types.Part) error {
+		parts[i] = p
+		if responses[i] == nil {
+			return nil
+		}
+		if a.resultMap == nil {
+			parts[i] = responses[i]
+			return nil
+		}
+		newPart, err := a.resultMap.MapOnto(p, i, resMsg)
+		if err != nil {
+			a.mErrRes.Incr(1)
+			a.log.Debugf("Failed to map result '%v': %v\n", i, err)
+			FlagErr(p, fmt.Errorf("result map: %w", err))
+			return nil
+		}
+		if newPart != nil {
+			parts[i] = newPart
+		}
+		return nil
+	})
+	msg.SetAll(parts)
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (a *Await) CloseAsync() {
+	a.closeOnce.Do(a.joiner.release)
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (a *Await) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,259 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAwaitInput struct {
+	tChan chan types.Transaction
+}
+
+func (f *fakeAwaitInput) TransactionChan() <-chan types.Transaction {
+	return f.tChan
+}
+
+func (f *fakeAwaitInput) Connected() bool {
+	return true
+}
+
+func (f *fakeAwaitInput) CloseAsync() {}
+
+func (f *fakeAwaitInput) WaitForClose(time.Duration) error {
+	return nil
+}
+
+// fakeAwaitOutput responds to each request in reverse order by sending the
+// result of a function of the request to the input.
+type fakeAwaitOutput struct {
+	in      *fakeAwaitInput
+	err     error
+	respond func(p types.Part) types.Part
+}
+
+func (f *fakeAwaitOutput) WriteTransaction(ctx context.Context, t types.Transaction) error {
+	var parts []types.Part
+	_ = t.Payload.Iter(func(i int, p types.Part) error {
+		if f.respond != nil {
+			parts = append([]types.Part{f.respond(p.Copy())}, parts...)
+		}
+		return nil
+	})
+	go func() {
+		for _, p := range parts {
+			msg := message.New(nil)
+			msg.Append(p)
+			resChan := make(chan types.Response)
+			f.in.tChan <- types.NewTransaction(msg, resChan)
+			<-resChan
+		}
+	}()
+	go func() {
+		t.ResponseChan <- response.NewError(f.err)
+	}()
+	return nil
+}
+
+func (f *fakeAwaitOutput) Connected() bool {
+	return true
+}
+
+func (f *fakeAwaitOutput) CloseAsync() {}
+
+func (f *fakeAwaitOutput) WaitForClose(time.Duration) error {
+	return nil
+}
+
+type fakeAwaitMgr struct {
+	types.DudMgr
+	in  *fakeAwaitInput
+	out *fakeAwaitOutput
+}
+
+func (f *fakeAwaitMgr) GetInput(name string) (types.Input, error) {
+	if name == "foo_in" {
+		return f.in, nil
+	}
+	return nil, types.ErrInputNotFound
+}
+
+func (f *fakeAwaitMgr) GetOutput(name string) (types.OutputWriter, error) {
+	if name == "foo_out" {
+		return f.out, nil
+	}
+	return nil, types.ErrOutputNotFound
+}
+
+func newFakeAwaitMgr(respond func(p types.Part) types.Part) *fakeAwaitMgr {
+	in := &fakeAwaitInput{tChan: make(chan types.Transaction)}
+	return &fakeAwaitMgr{
+		in:  in,
+		out: &fakeAwaitOutput{in: in, respond: respond},
+	}
+}
+
+func TestAwaitJoinsResponses(t *testing.T) {
+	mgr := newFakeAwaitMgr(func(p types.Part) types.Part {
+		p.Set([]byte(`{"name":"user ` + p.Metadata().Get("id") + `"}`))
+		return p
+	})
+
+	conf := NewConfig()
+	conf.Type = TypeAwait
+	conf.Await.Output = "foo_out"
+	conf.Await.Input = "foo_in"
+	conf.Await.RequestMap = `meta id = this.id
+root = ""`
+	conf.Await.ResultMap = `root.user = this.name`
+	conf.Await.Timeout = "1s"
+
+	proc, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer func() {
+		proc.CloseAsync()
+		assert.NoError(t, proc.WaitForClose(time.Second))
+	}()
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"id":"a"}`),
+		[]byte(`{"id":"b"}`),
+		[]byte(`{"id":"c"}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, [][]byte{
+		[]byte(`{"id":"a","user":"user a"}`),
+		[]byte(`{"id":"b","user":"user b"}`),
+		[]byte(`{"id":"c","user":"user c"}`),
+	}, message.GetAllBytes(msgs[0]))
+	_ = msgs[0].Iter(func(i int, p types.Part) error {
+		assert.Empty(t, GetFail(p))
+		assert.Empty(t, p.Metadata().Get("correlation_id"))
+		return nil
+	})
+}
+
+func TestAwaitNoResultMap(t *testing.T) {
+	mgr := newFakeAwaitMgr(func(p types.Part) types.Part {
+		p.Set([]byte("response: " + string(p.Get())))
+		return p
+	})
+
+	conf := NewConfig()
+	conf.Type = TypeAwait
+	conf.Await.Output = "foo_out"
+	conf.Await.Input = "foo_in"
+	conf.Await.Timeout = "1s"
+
+	proc, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer proc.CloseAsync()
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte("foo"), []byte("bar")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, [][]byte{
+		[]byte("response: foo"),
+		[]byte("response: bar"),
+	}, message.GetAllBytes(msgs[0]))
+	assert.NotEmpty(t, msgs[0].Get(0).Metadata().Get("correlation_id"))
+}
+
+func TestAwaitTimeout(t *testing.T) {
+	mgr := newFakeAwaitMgr(func(p types.Part) types.Part {
+		if string(p.Get()) == "bar" {
+			p.Metadata().Set("correlation_id", "nope")
+		}
+		return p
+	})
+
+	conf := NewConfig()
+	conf.Type = TypeAwait
+	conf.Await.Output = "foo_out"
+	conf.Await.Input = "foo_in"
+	conf.Await.Timeout = "50ms"
+
+	proc, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer proc.CloseAsync()
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte("foo"), []byte("bar")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, [][]byte{[]byte("foo"), []byte("bar")}, message.GetAllBytes(msgs[0]))
+	assert.Empty(t, GetFail(msgs[0].Get(0)))
+	assert.Equal(t, "timed out after 50ms awaiting response", GetFail(msgs[0].Get(1)))
+}
+
+func TestAwaitSendError(t *testing.T) {
+	mgr := newFakeAwaitMgr(nil)
+	mgr.out.err = errors.New("nope")
+
+	conf := NewConfig()
+	conf.Type = TypeAwait
+	conf.Await.Output = "foo_out"
+	conf.Await.Input = "foo_in"
+
+	proc, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer proc.CloseAsync()
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, [][]byte{[]byte("foo")}, message.GetAllBytes(msgs[0]))
+	assert.Equal(t, "failed to send request: nope", GetFail(msgs[0].Get(0)))
+}
+
+func TestAwaitSharedInput(t *testing.T) {
+	mgr := newFakeAwaitMgr(func(p types.Part) types.Part { return p })
+
+	conf := NewConfig()
+	conf.Type = TypeAwait
+	conf.Await.Output = "foo_out"
+	conf.Await.Input = "foo_in"
+
+	procA, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer procA.CloseAsync()
+
+	procB, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer procB.CloseAsync()
+
+	conf.Await.MetadataKey = "other_key"
+	_, err = New(conf, mgr, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "input resource is already awaited with metadata key 'correlation_id'")
+}
+
+func TestAwaitBadResources(t *testing.T) {
+	mgr := newFakeAwaitMgr(nil)
+
+	conf := NewConfig()
+	conf.Type = TypeAwait
+	conf.Await.Output = "bar_out"
+	conf.Await.Input = "foo_in"
+
+	_, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "failed to obtain output resource 'bar_out': output not found")
+
+	conf.Await.Output = "foo_out"
+	conf.Await.Input = "bar_in"
+
+	_, err = New(conf, mgr, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "failed to obtain input resource 'bar_in': input not found")
+}
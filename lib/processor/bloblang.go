@@ -152,7 +152,7 @@ type Bloblang struct {
 func NewBloblang(
 	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
 ) (Type, error) {
-	exec, err := bloblang.NewMapping("", string(conf.Bloblang))
+	exec, err := bloblang.NewMappingWithResources("", string(conf.Bloblang), mgr)
 	if err != nil {
 		if perr, ok := err.(*parser.Error); ok {
 			return nil, fmt.Errorf("%v", perr.ErrorAtPosition([]rune(conf.Bloblang)))
@@ -183,11 +183,27 @@ func NewBloblangFromExecutor(exec *mapping.Executor, log log.Modular, stats metr
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (b *Bloblang) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	newParts := b.mapParts(msg, make([]types.Part, 0, msg.Len()))
+	if len(newParts) == 0 {
+		return nil, response.NewAck()
+	}
+
+	newMsg := message.New(nil)
+	newMsg.SetAll(newParts)
+
+	b.mBatchSent.Incr(1)
+	b.mSent.Incr(int64(newMsg.Len()))
+	return []types.Message{newMsg}, nil
+}
+
+// mapParts executes the mapping on each part of a batch and appends the
+// resulting parts to a slice, omitting any parts deleted by the mapping.
+func (b *Bloblang) mapParts(batch mapping.Message, newParts []types.Part) []types.Part {
 	b.mCount.Incr(1)
 
-	newParts := make([]types.Part, 0, msg.Len())
+	for i := 0; i < batch.Len(); i++ {
+		part := batch.Get(i)
 
-	msg.Iter(func(i int, part types.Part) error {
 		span := tracing.GetSpan(part)
 		if span == nil {
 			span = opentracing.StartSpan(TypeBloblang)
@@ -198,7 +214,7 @@ func (b *Bloblang) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 			)
 		}
 
-		p, err := b.exec.MapPart(i, msg)
+		p, err := b.exec.MapPart(i, batch)
 		if err != nil {
 			p = part.Copy()
 			b.mErr.Incr(1)
@@ -217,19 +233,8 @@ func (b *Bloblang) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 		} else {
 			b.mDropped.Incr(1)
 		}
-		return nil
-	})
-
-	if len(newParts) == 0 {
-		return nil, response.NewAck()
 	}
-
-	newMsg := message.New(nil)
-	newMsg.SetAll(newParts)
-
-	b.mBatchSent.Incr(1)
-	b.mSent.Incr(int64(newMsg.Len()))
-	return []types.Message{newMsg}, nil
+	return newParts
 }
 
 // CloseAsync shuts down the processor and stops processing requests.
@@ -0,0 +1,119 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// partBatch is a batch of message parts that can be referenced by a Bloblang
+// mapping without constructing a message from them.
+type partBatch []types.Part
+
+// Get returns a part of the batch by its index, where a negative index counts
+// backwards from the end of the batch.
+func (b partBatch) Get(index int) types.Part {
+	if index < 0 {
+		index = len(b) + index
+	}
+	if index < 0 || index >= len(b) {
+		return message.NewPart(nil)
+	}
+	return b[index]
+}
+
+// Len returns the number of parts in the batch.
+func (b partBatch) Len() int {
+	return len(b)
+}
+
+//------------------------------------------------------------------------------
+
+// BloblangChain executes a chain of consecutive Bloblang processors as a single
+// processor.
+type BloblangChain struct {
+	steps     []*Bloblang
+	failPaths []string
+}
+
+// NewBloblangChain returns a processor that executes a chain of Bloblang
+// processors batch-at-a-time, where each mapping is executed across the entire
+// batch before the next begins. The parts resulting from each mapping are
+// passed directly to the next without being collected into an intermediate
+// message, and structured results are passed without being serialized.
+//
+// The behaviour of the chain matches that of executing each processor in turn,
+// including the metrics and tracing spans of each processor, and failed parts
+// have the provided component path of the processor that flagged them
+// recorded.
+func NewBloblangChain(steps []*Bloblang, failPaths []string) *BloblangChain {
+	return &BloblangChain{
+		steps:     steps,
+		failPaths: failPaths,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (c *BloblangChain) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return c.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext is the context aware version of ProcessMessage,
+// once the context is cancelled the remaining mappings are skipped and an
+// error response is returned.
+func (c *BloblangChain) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
+	var batch mapping.Message = msg
+	var parts partBatch
+	for i, step := range c.steps {
+		if err := ctx.Err(); err != nil {
+			return nil, response.NewError(err)
+		}
+
+		parts = step.mapParts(batch, make([]types.Part, 0, batch.Len()))
+		if len(parts) == 0 {
+			return nil, response.NewAck()
+		}
+		if i < len(c.failPaths) {
+			for _, p := range parts {
+				SetFailPath(p, c.failPaths[i])
+			}
+		}
+
+		step.mBatchSent.Incr(1)
+		step.mSent.Incr(int64(len(parts)))
+		batch = parts
+	}
+
+	newMsg := message.New(nil)
+	newMsg.SetAll(parts)
+	return []types.Message{newMsg}, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (c *BloblangChain) CloseAsync() {
+	for _, step := range c.steps {
+		step.CloseAsync()
+	}
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (c *BloblangChain) WaitForClose(timeout time.Duration) error {
+	stopBy := time.Now().Add(timeout)
+	for _, step := range c.steps {
+		if err := step.WaitForClose(time.Until(stopBy)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
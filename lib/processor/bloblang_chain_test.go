@@ -0,0 +1,127 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloblangChainMatchesSequential(t *testing.T) {
+	mappings := []string{
+		`root = this
+root.index = batch_index()
+meta step = "one"`,
+		`root = if this.index == 1 { deleted() } else { this }`,
+		`root = this
+root.size = batch_size()
+root.first = json("index").from(0)
+root.step = meta("step")
+meta step = "three"`,
+		`root = this
+root.name = this.name.uppercase()`,
+	}
+	paths := []string{"a", "b", "c", "d"}
+
+	newSteps := func() []*Bloblang {
+		steps := make([]*Bloblang, len(mappings))
+		for i, m := range mappings {
+			conf := NewConfig()
+			conf.Bloblang = BloblangConfig(m)
+			proc, err := NewBloblang(conf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+			steps[i] = proc.(*Bloblang)
+		}
+		return steps
+	}
+
+	newMsg := func() types.Message {
+		return message.New([][]byte{
+			[]byte(`{"name":"foo"}`),
+			[]byte(`{"name":"bar"}`),
+			[]byte(`{"id":"baz"}`),
+		})
+	}
+
+	var seqProcs []types.Processor
+	for i, step := range newSteps() {
+		seqProcs = append(seqProcs, WithFailPath(paths[i], step))
+	}
+	seqMsgs, seqRes := ExecuteAll(seqProcs, newMsg())
+	require.Nil(t, seqRes)
+	require.Len(t, seqMsgs, 1)
+
+	input := newMsg()
+	chainMsgs, chainRes := NewBloblangChain(newSteps(), paths).ProcessMessage(input)
+	require.Nil(t, chainRes)
+	require.Len(t, chainMsgs, 1)
+
+	assert.Equal(t, []string{
+		`{"name":"foo"}`,
+		`{"name":"bar"}`,
+		`{"id":"baz"}`,
+	}, toStrings(input))
+
+	assert.Equal(t, []string{
+		`{"first":0,"index":0,"name":"FOO","size":2,"step":"one"}`,
+		`{"first":0,"id":"baz","index":2,"size":2,"step":"one"}`,
+	}, toStrings(chainMsgs[0]))
+	assert.Equal(t, toStrings(seqMsgs[0]), toStrings(chainMsgs[0]))
+
+	require.Equal(t, seqMsgs[0].Len(), chainMsgs[0].Len())
+	for i := 0; i < chainMsgs[0].Len(); i++ {
+		seqPart, chainPart := seqMsgs[0].Get(i), chainMsgs[0].Get(i)
+		assert.Equal(t, "three", chainPart.Metadata().Get("step"))
+		assert.Equal(t, GetFail(seqPart), GetFail(chainPart))
+		assert.Equal(t, message.GetFailureDetails(seqPart).Path, message.GetFailureDetails(chainPart).Path)
+	}
+
+	assert.False(t, HasFailed(chainMsgs[0].Get(0)))
+	assert.True(t, HasFailed(chainMsgs[0].Get(1)))
+	assert.Equal(t, "d", message.GetFailureDetails(chainMsgs[0].Get(1)).Path)
+}
+
+func TestBloblangChainDeleteAll(t *testing.T) {
+	var steps []*Bloblang
+	for _, m := range []string{`root = deleted()`, `root = "never"`} {
+		conf := NewConfig()
+		conf.Bloblang = BloblangConfig(m)
+		proc, err := NewBloblang(conf, nil, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+		steps = append(steps, proc.(*Bloblang))
+	}
+
+	msgs, res := NewBloblangChain(steps, nil).ProcessMessage(message.New([][]byte{[]byte(`foo`)}))
+	assert.Empty(t, msgs)
+	require.NotNil(t, res)
+	assert.NoError(t, res.Error())
+}
+
+func TestBloblangChainCancelled(t *testing.T) {
+	conf := NewConfig()
+	conf.Bloblang = `root = "bar"`
+	proc, err := NewBloblang(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	msgs, res := NewBloblangChain([]*Bloblang{proc.(*Bloblang)}, nil).ProcessMessageWithContext(ctx, message.New([][]byte{[]byte(`foo`)}))
+	assert.Empty(t, msgs)
+	require.NotNil(t, res)
+	assert.Error(t, res.Error())
+}
+
+func toStrings(msg types.Message) []string {
+	var strs []string
+	_ = msg.Iter(func(_ int, p types.Part) error {
+		strs = append(strs, string(p.Get()))
+		return nil
+	})
+	return strs
+}
@@ -138,6 +138,80 @@ func TestBloblangCustomObject(t *testing.T) {
 	assert.Equal(t, `{"foos":[{"foo":"FROM NEW OBJECT"},5,null]}`, string(resPart.Get()))
 }
 
+func TestBloblangIntegerPreservation(t *testing.T) {
+	conf := NewConfig()
+	conf.Bloblang = `
+root.id = this.id
+root.next_id = this.id + 1
+root.parsed = this.raw.parse_json(true).id
+root.parsed_float = this.raw.parse_json().id
+root.converted = this.id_str.to_int64()
+root.is_bigger = this.id > 1234567890123456788
+root.is_equal = this.id == 1234567890123456788
+root.decimal = this.price.to_decimal() * 3
+meta id = this.id
+`
+	proc, err := NewBloblang(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	// Process the result a second time in order to ensure values survive
+	// being set as structured documents with SetJSON and then copied.
+	reproc, err := NewBloblang(func() Config {
+		c := NewConfig()
+		c.Bloblang = `root = this`
+		return c
+	}(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{
+		[]byte(`{"id":1234567890123456789,"raw":"{\"id\":1234567890123456789}","id_str":"1234567890123456789","price":19.99}`),
+	})
+
+	outMsgs, res := proc.ProcessMessage(msg)
+	require.Nil(t, res)
+	require.Len(t, outMsgs, 1)
+
+	outMsgs, res = reproc.ProcessMessage(outMsgs[0].DeepCopy())
+	require.Nil(t, res)
+	require.Len(t, outMsgs, 1)
+
+	resPart := outMsgs[0].Get(0)
+	assert.Equal(t, `{"converted":1234567890123456789,"decimal":59.97,"id":1234567890123456789,"is_bigger":true,"is_equal":false,"next_id":1234567890123456790,"parsed":1234567890123456789,"parsed_float":1234567890123456800}`, string(resPart.Get()))
+	assert.Equal(t, "1234567890123456789", resPart.Metadata().Get("id"))
+}
+
+func TestBloblangPreserveKeyOrder(t *testing.T) {
+	message.SetPreserveKeyOrder(true)
+	defer message.SetPreserveKeyOrder(false)
+
+	input := `{"zed":1,"alpha":{"b":"b","a":[{"y":1,"x":2}]},"mid":true}`
+	for _, test := range []struct {
+		mapping string
+		output  string
+	}{
+		{
+			mapping: `root = this`,
+			output:  input,
+		},
+		{
+			mapping: `root = this
+root.added = "foo"
+root.zed = deleted()`,
+			output: `{"alpha":{"b":"b","a":[{"y":1,"x":2}]},"mid":true,"added":"foo"}`,
+		},
+	} {
+		conf := NewConfig()
+		conf.Bloblang = BloblangConfig(test.mapping)
+		proc, err := NewBloblang(conf, nil, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+
+		outMsgs, res := proc.ProcessMessage(message.New([][]byte{[]byte(input)}))
+		require.Nil(t, res)
+		require.Len(t, outMsgs, 1)
+		assert.Equal(t, test.output, string(outMsgs[0].Get(0).Get()), test.mapping)
+	}
+}
+
 func TestBloblangFiltering(t *testing.T) {
 	msg := message.New([][]byte{
 		[]byte(`{"foo":{"delete":true}}`),
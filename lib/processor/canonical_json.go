@@ -0,0 +1,198 @@
+package processor
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/canonicaljson"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/OneOfOne/xxhash"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeCanonicalJSON] = TypeSpec{
+		constructor: NewCanonicalJSON,
+		Status:      docs.StatusExperimental,
+		Version:     "3.44.0",
+		Categories: []Category{
+			CategoryParsing,
+		},
+		Summary: `
+Replaces JSON documents with their canonical form following the [JSON Canonicalization Scheme (RFC 8785)](https://tools.ietf.org/html/rfc8785), and optionally stores a hash of the canonical form within metadata.`,
+		Description: `
+The canonical form of a document has object keys sorted, numbers normalized and no insignificant whitespace, and therefore documents that differ only in key order or number formatting, such as ` + "`{\"b\":1.0,\"a\":2}`" + ` and ` + "`{\"a\":2,\"b\":1}`" + `, serialize identically. This makes the canonical form suitable for deduplicating messages and for calculating signatures.
+
+As required by the RFC numbers are represented as double precision floating point values, and therefore integers beyond 2^53 lose precision.
+
+When ` + "`hash`" + ` is not ` + "`none`" + ` a hex encoded hash of the canonical form of each message is stored within the metadata key ` + "`hash_metadata`" + `.
+
+The Bloblang method [` + "`canonical_json`" + `](/docs/guides/bloblang/methods#canonical_json) provides the same serialization within mappings.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Deduplication",
+				Summary: `
+Here we drop messages that are duplicates of each other regardless of the order of their keys, by deduplicating on the hash of their canonical form:`,
+				Config: `
+pipeline:
+  processors:
+    - canonical_json:
+        hash: sha256
+        hash_metadata: content_hash
+    - dedupe:
+        cache: keycache
+        key: ${! meta("content_hash") }
+
+cache_resources:
+  - label: keycache
+    memory:
+      ttl: 60
+`,
+			},
+		},
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("hash", "The hash algorithm to apply to the canonical form of each message, or `none` in order to skip hashing.").HasOptions("none", "md5", "sha1", "sha256", "sha512", "xxhash64"),
+			docs.FieldCommon("hash_metadata", "The metadata key to store the hex encoded hash within."),
+			PartsFieldSpec,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CanonicalJSONConfig contains configuration fields for the CanonicalJSON
+// processor.
+type CanonicalJSONConfig struct {
+	Parts        []int  `json:"parts" yaml:"parts"`
+	Hash         string `json:"hash" yaml:"hash"`
+	HashMetadata string `json:"hash_metadata" yaml:"hash_metadata"`
+}
+
+// NewCanonicalJSONConfig returns a CanonicalJSONConfig with default values.
+func NewCanonicalJSONConfig() CanonicalJSONConfig {
+	return CanonicalJSONConfig{
+		Parts:        []int{},
+		Hash:         "sha256",
+		HashMetadata: "canonical_json_hash",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func canonicalJSONHasher(algorithm string) (func() hash.Hash, error) {
+	switch algorithm {
+	case "none":
+		return nil, nil
+	case "md5":
+		return md5.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha256":
+		return sha256.New, nil
+	case "sha512":
+		return sha512.New, nil
+	case "xxhash64":
+		return func() hash.Hash {
+			return xxhash.New64()
+		}, nil
+	}
+	return nil, fmt.Errorf("hash algorithm not recognised: %v", algorithm)
+}
+
+//------------------------------------------------------------------------------
+
+// CanonicalJSON is a processor that replaces JSON documents with their
+// canonical form and optionally hashes it.
+type CanonicalJSON struct {
+	conf   CanonicalJSONConfig
+	hasher func() hash.Hash
+
+	log log.Modular
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewCanonicalJSON returns a CanonicalJSON processor.
+func NewCanonicalJSON(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	hasher, err := canonicalJSONHasher(conf.CanonicalJSON.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if hasher != nil && conf.CanonicalJSON.HashMetadata == "" {
+		return nil, fmt.Errorf("a hash_metadata key is required when hash is set to %v", conf.CanonicalJSON.Hash)
+	}
+	return &CanonicalJSON{
+		conf:   conf.CanonicalJSON,
+		hasher: hasher,
+		log:    log,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (c *CanonicalJSON) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	c.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	proc := func(i int, span opentracing.Span, part types.Part) error {
+		canon, err := canonicaljson.Canonicalize(part.Get())
+		if err != nil {
+			c.mErr.Incr(1)
+			c.log.Debugf("Failed to canonicalize JSON: %v\n", err)
+			return err
+		}
+		part.Set(canon)
+		if c.hasher != nil {
+			h := c.hasher()
+			h.Write(canon)
+			part.Metadata().Set(c.conf.HashMetadata, hex.EncodeToString(h.Sum(nil)))
+		}
+		return nil
+	}
+
+	if newMsg.Len() == 0 {
+		return nil, response.NewAck()
+	}
+
+	IteratePartsWithSpan(TypeCanonicalJSON, c.conf.Parts, newMsg, proc)
+
+	c.mBatchSent.Incr(1)
+	c.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (c *CanonicalJSON) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (c *CanonicalJSON) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,64 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeCanonicalJSON
+	conf.CanonicalJSON.HashMetadata = "hash"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"b":1.0,"a":[2, {"d":"foo","c":1e3}]}`),
+		[]byte(`{"a": [2, {"c": 1000, "d": "foo"}], "b": 1}`),
+		[]byte(`not json`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	out := msgs[0]
+	assert.Equal(t, `{"a":[2,{"c":1000,"d":"foo"}],"b":1}`, string(out.Get(0).Get()))
+	assert.Equal(t, `{"a":[2,{"c":1000,"d":"foo"}],"b":1}`, string(out.Get(1).Get()))
+	assert.Equal(t, "a9cc2d449205e548a67397f4cfe14a7c6076db3af1396702dc636194b8562d1a", out.Get(0).Metadata().Get("hash"))
+	assert.Equal(t, out.Get(0).Metadata().Get("hash"), out.Get(1).Metadata().Get("hash"))
+
+	assert.Equal(t, "not json", string(out.Get(2).Get()))
+	assert.Equal(t, "", out.Get(2).Metadata().Get("hash"))
+	assert.True(t, HasFailed(out.Get(2)))
+}
+
+func TestCanonicalJSONNoHash(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeCanonicalJSON
+	conf.CanonicalJSON.Hash = "none"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"b":"é","a":null}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, `{"a":null,"b":"é"}`, string(msgs[0].Get(0).Get()))
+	assert.Equal(t, "", msgs[0].Get(0).Metadata().Get("canonical_json_hash"))
+}
+
+func TestCanonicalJSONBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeCanonicalJSON
+	conf.CanonicalJSON.Hash = "nope"
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "hash algorithm not recognised: nope")
+}
@@ -0,0 +1,423 @@
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/cloudevents"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeCloudEvents] = TypeSpec{
+		constructor: NewCloudEvents,
+		Categories: []Category{
+			CategoryParsing,
+		},
+		Summary: `
+Converts messages to and from [CloudEvents](https://cloudevents.io/) following
+the CloudEvents v1.0 specification.`,
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Description: `
+Events can either be represented in the structured mode JSON format, or in
+binary mode where the contents of a message are the event data and the context
+attributes are metadata fields. Events in structured mode can be sent over HTTP
+with the ` + "[`cloudevents_http` output](/docs/components/outputs/cloudevents_http)" + `.
+
+## Operators
+
+### ` + "`wrap`" + `
+
+Wraps the contents of messages within events, where the context attributes of
+each event are the result of the [Bloblang mapping](/docs/guides/bloblang/about)
+` + "`mapping`" + `. The attribute ` + "`specversion`" + ` is added when the
+mapping does not set it, and the attribute ` + "`datacontenttype`" + ` is added
+to match the message contents when the mapping does not set it.
+
+In ` + "`structured`" + ` mode messages that are JSON documents are embedded
+within the ` + "`data`" + ` field of the event, other text is embedded as a
+string and binary contents are base64 encoded within the field
+` + "`data_base64`" + `. In ` + "`binary`" + ` mode the contents of messages
+are left unchanged and each attribute is added as a metadata field with the
+prefix ` + "`ce_`" + `.
+
+The resulting event is validated, and messages that result in an invalid event
+are flagged as failed.
+
+### ` + "`unwrap`" + `
+
+Validates events and replaces the contents of each message with the event data,
+adding each attribute of the event as a metadata field with the prefix
+` + "`ce_`" + `. In ` + "`binary`" + ` mode the attributes are read from
+metadata fields prefixed with either ` + "`ce_`" + ` or ` + "`ce-`" + `
+(ignoring case), which includes events received in binary mode by the
+` + "[`http_server` input](/docs/components/inputs/http_server)" + `, and the
+` + "`datacontenttype`" + ` defaults to the metadata field ` + "`Content-Type`" + `.
+Messages that are not valid events are flagged as failed.
+
+### ` + "`validate`" + `
+
+Validates events without modifying messages, and messages that are not valid
+events are flagged as failed.
+
+## Extension Attributes
+
+The field ` + "`extensions`" + ` maps extension attributes to metadata fields of
+a different name. When wrapping messages, the value of each metadata field is
+added as the extension attribute unless the mapping sets it, and when unwrapping
+events the extension attribute is added as the metadata field instead of a
+` + "`ce_`" + ` prefixed field.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("operator", "The [operator](#operators) to execute.").HasOptions("wrap", "unwrap", "validate"),
+			docs.FieldCommon("mode", "Whether events are represented in structured mode as JSON documents, or in binary mode with attributes as metadata.").HasOptions("structured", "binary"),
+			docs.FieldCommon(
+				"mapping", "A [Bloblang mapping](/docs/guides/bloblang/about) that results in an object of context attributes for each message.",
+				`root.id = uuid_v4()
+root.source = "benthos"
+root.type = "com.example.order.created"
+root.subject = this.order_id
+root.time = now()`,
+			),
+			docs.FieldAdvanced(
+				"extensions", "A map of [extension attributes](#extension-attributes) to the metadata fields they are converted to and from.",
+				map[string]string{
+					"traceparent":  "traceparent",
+					"partitionkey": "kafka_key",
+				},
+			).Map(),
+			PartsFieldSpec,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Wrap Kafka Records",
+				Summary: `
+Wrapping Kafka records within events where the event type is the topic of the
+record:`,
+				Config: `
+pipeline:
+  processors:
+    - cloudevents:
+        operator: wrap
+        mapping: |
+          root.id = "%s-%v-%v".format(meta("kafka_topic"), meta("kafka_partition"), meta("kafka_offset"))
+          root.source = "kafka"
+          root.type = meta("kafka_topic")
+          root.time = meta("kafka_timestamp_unix").number().format_timestamp()
+`,
+			},
+			{
+				Title: "Unwrap Binary HTTP Events",
+				Summary: `
+Receiving events in binary mode over HTTP, where the events are validated and
+the partition key extension is kept as the metadata field ` + "`key`" + `:`,
+				Config: `
+input:
+  http_server:
+    path: /events
+  processors:
+    - cloudevents:
+        operator: unwrap
+        mode: binary
+        extensions:
+          partitionkey: key
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CloudEventsConfig contains configuration fields for the CloudEvents
+// processor.
+type CloudEventsConfig struct {
+	Parts      []int             `json:"parts" yaml:"parts"`
+	Operator   string            `json:"operator" yaml:"operator"`
+	Mode       string            `json:"mode" yaml:"mode"`
+	Mapping    string            `json:"mapping" yaml:"mapping"`
+	Extensions map[string]string `json:"extensions" yaml:"extensions"`
+}
+
+// NewCloudEventsConfig returns a CloudEventsConfig with default values.
+func NewCloudEventsConfig() CloudEventsConfig {
+	return CloudEventsConfig{
+		Parts:      []int{},
+		Operator:   "wrap",
+		Mode:       "structured",
+		Mapping:    "",
+		Extensions: map[string]string{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type cloudEventsOperator func(i int, msg types.Message, part types.Part) error
+
+// cloudEventsMetaPrefix is the prefix of metadata fields that hold the context
+// attributes of events.
+const cloudEventsMetaPrefix = "ce_"
+
+// setCloudEventsMeta adds the context attributes of an event to the metadata of
+// a part.
+func setCloudEventsMeta(conf CloudEventsConfig, event map[string]interface{}, part types.Part) {
+	meta := part.Metadata()
+	for _, k := range cloudevents.Attributes(event) {
+		key := cloudEventsMetaPrefix + k
+		if extKey, exists := conf.Extensions[k]; exists {
+			key = extKey
+		}
+		meta.Set(key, cloudevents.AttributeString(event[k]))
+	}
+}
+
+// cloudEventsFromMeta extracts the context attributes of a binary mode event
+// from the metadata of a part.
+func cloudEventsFromMeta(conf CloudEventsConfig, part types.Part) map[string]interface{} {
+	event := map[string]interface{}{}
+	meta := part.Metadata()
+	meta.Iter(func(k, v string) error {
+		if len(k) > len(cloudEventsMetaPrefix) {
+			if prefix := strings.ToLower(k[:len(cloudEventsMetaPrefix)]); prefix == "ce_" || prefix == "ce-" {
+				event[strings.ToLower(k[len(cloudEventsMetaPrefix):])] = v
+			}
+		}
+		return nil
+	})
+	if _, exists := event["datacontenttype"]; !exists {
+		if v := meta.Get("Content-Type"); v != "" {
+			event["datacontenttype"] = v
+		}
+	}
+	for ext, key := range conf.Extensions {
+		if _, exists := event[ext]; exists {
+			continue
+		}
+		if v := meta.Get(key); v != "" {
+			event[ext] = v
+		}
+	}
+	return event
+}
+
+// cloudEventsFromPart parses a structured mode event from the contents of a
+// part.
+func cloudEventsFromPart(part types.Part) (map[string]interface{}, error) {
+	jv, err := part.JSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse event: %w", err)
+	}
+	event, ok := jv.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected event to be an object, got %T", jv)
+	}
+	return event, nil
+}
+
+func newCloudEventsWrapOperator(conf CloudEventsConfig) (cloudEventsOperator, error) {
+	if conf.Mapping == "" {
+		return nil, errors.New("a mapping is required for the wrap operator")
+	}
+	exec, err := bloblang.NewMapping("", conf.Mapping)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mapping: %w", err)
+	}
+	return func(i int, msg types.Message, part types.Part) error {
+		attrs, err := cloudEventsAttributes(exec, i, msg)
+		if err != nil {
+			return err
+		}
+		for ext, key := range conf.Extensions {
+			if _, exists := attrs[ext]; exists {
+				continue
+			}
+			if v := part.Metadata().Get(key); v != "" {
+				attrs[ext] = v
+			}
+		}
+
+		event := cloudevents.New(attrs, part.Get())
+		if err := cloudevents.Validate(event); err != nil {
+			return fmt.Errorf("invalid event: %w", err)
+		}
+
+		if conf.Mode == "binary" {
+			setCloudEventsMeta(conf, event, part)
+			return nil
+		}
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+		part.Set(data)
+		return nil
+	}, nil
+}
+
+func newCloudEventsUnwrapOperator(conf CloudEventsConfig) (cloudEventsOperator, error) {
+	return func(i int, msg types.Message, part types.Part) error {
+		if conf.Mode == "binary" {
+			event := cloudEventsFromMeta(conf, part)
+			if err := cloudevents.Validate(event); err != nil {
+				return fmt.Errorf("invalid event: %w", err)
+			}
+			setCloudEventsMeta(conf, event, part)
+			return nil
+		}
+
+		event, err := cloudEventsFromPart(part)
+		if err != nil {
+			return err
+		}
+		if err = cloudevents.Validate(event); err != nil {
+			return fmt.Errorf("invalid event: %w", err)
+		}
+		data, err := cloudevents.Data(event)
+		if err != nil {
+			return fmt.Errorf("failed to decode event data: %w", err)
+		}
+		part.Set(data)
+		setCloudEventsMeta(conf, event, part)
+		return nil
+	}, nil
+}
+
+func newCloudEventsValidateOperator(conf CloudEventsConfig) (cloudEventsOperator, error) {
+	return func(i int, msg types.Message, part types.Part) error {
+		var event map[string]interface{}
+		if conf.Mode == "binary" {
+			event = cloudEventsFromMeta(conf, part)
+		} else {
+			var err error
+			if event, err = cloudEventsFromPart(part); err != nil {
+				return err
+			}
+		}
+		if err := cloudevents.Validate(event); err != nil {
+			return fmt.Errorf("invalid event: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// cloudEventsAttributes executes a mapping that results in an object of context
+// attributes.
+func cloudEventsAttributes(exec *mapping.Executor, i int, msg types.Message) (map[string]interface{}, error) {
+	p, err := exec.MapPart(i, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute mapping: %w", err)
+	}
+	jv, err := p.JSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mapping result: %w", err)
+	}
+	attrs, ok := jv.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected mapping to result in an object, got %T", jv)
+	}
+	return attrs, nil
+}
+
+func strToCloudEventsOperator(conf CloudEventsConfig) (cloudEventsOperator, error) {
+	switch conf.Mode {
+	case "structured", "binary":
+	default:
+		return nil, fmt.Errorf("mode not recognised: %v", conf.Mode)
+	}
+	switch conf.Operator {
+	case "wrap":
+		return newCloudEventsWrapOperator(conf)
+	case "unwrap":
+		return newCloudEventsUnwrapOperator(conf)
+	case "validate":
+		return newCloudEventsValidateOperator(conf)
+	}
+	return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
+}
+
+//------------------------------------------------------------------------------
+
+// CloudEvents is a processor that converts messages to and from CloudEvents.
+type CloudEvents struct {
+	parts    []int
+	operator cloudEventsOperator
+
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewCloudEvents returns a CloudEvents processor.
+func NewCloudEvents(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	c := &CloudEvents{
+		parts: conf.CloudEvents.Parts,
+		conf:  conf,
+		log:   log,
+		stats: stats,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+
+	var err error
+	if c.operator, err = strToCloudEventsOperator(conf.CloudEvents); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (c *CloudEvents) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	c.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	proc := func(index int, span opentracing.Span, part types.Part) error {
+		if err := c.operator(index, msg, part); err != nil {
+			c.mErr.Incr(1)
+			c.log.Debugf("Operator failed: %v\n", err)
+			return err
+		}
+		return nil
+	}
+
+	IteratePartsWithSpan(TypeCloudEvents, c.parts, newMsg, proc)
+
+	c.mBatchSent.Incr(1)
+	c.mSent.Incr(int64(newMsg.Len()))
+	return []types.Message{newMsg}, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (c *CloudEvents) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (c *CloudEvents) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
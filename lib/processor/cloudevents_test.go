@@ -0,0 +1,213 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudEventsWrap(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeCloudEvents
+	conf.CloudEvents.Operator = "wrap"
+	conf.CloudEvents.Mapping = `root.id = this.id
+root.source = "benthos"
+root.type = meta("type")`
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{
+		[]byte(`{"id":"foo","value":10}`),
+		[]byte(`{"id":"bar"}`),
+	})
+	msg.Get(0).Metadata().Set("type", "com.example.created")
+
+	msgs, res := proc.ProcessMessage(msg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 2, msgs[0].Len())
+
+	assert.JSONEq(t, `{
+	"specversion": "1.0",
+	"id": "foo",
+	"source": "benthos",
+	"type": "com.example.created",
+	"datacontenttype": "application/json",
+	"data": {"id":"foo","value":10}
+}`, string(msgs[0].Get(0).Get()))
+	assert.Empty(t, GetFail(msgs[0].Get(0)))
+
+	// The mapping fails to resolve the type of the second message.
+	assert.Equal(t, `{"id":"bar"}`, string(msgs[0].Get(1).Get()))
+	assert.NotEmpty(t, GetFail(msgs[0].Get(1)))
+}
+
+func TestCloudEventsWrapInvalid(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeCloudEvents
+	conf.CloudEvents.Mapping = `root.id = "foo"
+root.source = "benthos"
+root.type = "bar"
+root.my_ext = "baz"`
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte(`hello world`)}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "hello world", string(msgs[0].Get(0).Get()))
+	assert.Equal(t, "invalid event: attribute name my_ext must only contain lowercase letters and digits", GetFail(msgs[0].Get(0)))
+}
+
+func TestCloudEventsBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeCloudEvents
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "a mapping is required for the wrap operator")
+
+	conf.CloudEvents.Operator = "nope"
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "operator not recognised: nope")
+
+	conf.CloudEvents.Operator = "unwrap"
+	conf.CloudEvents.Mode = "nope"
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "mode not recognised: nope")
+}
+
+func TestCloudEventsWrapBinary(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeCloudEvents
+	conf.CloudEvents.Mode = "binary"
+	conf.CloudEvents.Mapping = `root.id = "foo"
+root.source = "benthos"
+root.type = "bar"`
+	conf.CloudEvents.Extensions = map[string]string{
+		"partitionkey": "kafka_key",
+	}
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{[]byte(`hello world`)})
+	msg.Get(0).Metadata().Set("kafka_key", "baz")
+
+	msgs, res := proc.ProcessMessage(msg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	part := msgs[0].Get(0)
+	assert.Empty(t, GetFail(part))
+	assert.Equal(t, "hello world", string(part.Get()))
+
+	meta := map[string]string{}
+	part.Metadata().Iter(func(k, v string) error {
+		meta[k] = v
+		return nil
+	})
+	assert.Equal(t, map[string]string{
+		"ce_specversion":     "1.0",
+		"ce_id":              "foo",
+		"ce_source":          "benthos",
+		"ce_type":            "bar",
+		"ce_datacontenttype": "text/plain",
+		"kafka_key":          "baz",
+	}, meta)
+}
+
+func TestCloudEventsUnwrap(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeCloudEvents
+	conf.CloudEvents.Operator = "unwrap"
+	conf.CloudEvents.Extensions = map[string]string{
+		"partitionkey": "key",
+	}
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"specversion":"1.0","id":"foo","source":"/a","type":"b","partitionkey":"c","datacontenttype":"application/json","data":{"d":"e"}}`),
+		[]byte(`{"specversion":"1.0","id":"bar","source":"/a","type":"b","data_base64":"/wA="}`),
+		[]byte(`{"specversion":"1.0","source":"/a","type":"b"}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	first := msgs[0].Get(0)
+	assert.Empty(t, GetFail(first))
+	assert.Equal(t, `{"d":"e"}`, string(first.Get()))
+	assert.Equal(t, "foo", first.Metadata().Get("ce_id"))
+	assert.Equal(t, "application/json", first.Metadata().Get("ce_datacontenttype"))
+	assert.Equal(t, "c", first.Metadata().Get("key"))
+	assert.Equal(t, "", first.Metadata().Get("ce_partitionkey"))
+
+	second := msgs[0].Get(1)
+	assert.Empty(t, GetFail(second))
+	assert.Equal(t, []byte{0xff, 0x00}, second.Get())
+	assert.Equal(t, "bar", second.Metadata().Get("ce_id"))
+
+	assert.Equal(t, "invalid event: missing required attribute: id", GetFail(msgs[0].Get(2)))
+}
+
+func TestCloudEventsUnwrapBinary(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeCloudEvents
+	conf.CloudEvents.Operator = "unwrap"
+	conf.CloudEvents.Mode = "binary"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{[]byte(`{"d":"e"}`), []byte(`foo`)})
+	msg.Get(0).Metadata().
+		Set("Ce-Specversion", "1.0").
+		Set("Ce-Id", "foo").
+		Set("Ce-Source", "/a").
+		Set("Ce-Type", "b").
+		Set("Content-Type", "application/json")
+	msg.Get(1).Metadata().Set("ce_specversion", "0.3")
+
+	msgs, res := proc.ProcessMessage(msg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	first := msgs[0].Get(0)
+	assert.Empty(t, GetFail(first))
+	assert.Equal(t, `{"d":"e"}`, string(first.Get()))
+	assert.Equal(t, "foo", first.Metadata().Get("ce_id"))
+	assert.Equal(t, "/a", first.Metadata().Get("ce_source"))
+	assert.Equal(t, "application/json", first.Metadata().Get("ce_datacontenttype"))
+
+	assert.Equal(t, "invalid event: missing required attribute: id", GetFail(msgs[0].Get(1)))
+}
+
+func TestCloudEventsValidate(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeCloudEvents
+	conf.CloudEvents.Operator = "validate"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	input := [][]byte{
+		[]byte(`{"specversion":"1.0","id":"foo","source":"/a","type":"b"}`),
+		[]byte(`{"specversion":"1.0","id":"foo","source":"/a","type":"b","time":"nope"}`),
+		[]byte(`not json`),
+	}
+	msgs, res := proc.ProcessMessage(message.New(input))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, input, message.GetAllBytes(msgs[0]))
+
+	assert.Empty(t, GetFail(msgs[0].Get(0)))
+	assert.Contains(t, GetFail(msgs[0].Get(1)), "invalid event: attribute time is invalid")
+	assert.Contains(t, GetFail(msgs[0].Get(2)), "failed to parse event")
+}
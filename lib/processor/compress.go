@@ -6,6 +6,7 @@ import (
 	"compress/gzip"
 	"compress/zlib"
 	"fmt"
+	"io/ioutil"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
@@ -14,6 +15,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/opentracing/opentracing-go"
 )
 
@@ -27,12 +29,23 @@ func init() {
 		},
 		Summary: `
 Compresses messages according to the selected algorithm. Supported compression
-algorithms are: gzip, zlib, flate, snappy.`,
+algorithms are: gzip, zlib, flate, snappy, zstd.`,
 		Description: `
-The 'level' field might not apply to all algorithms.`,
+The 'level' field might not apply to all algorithms.
+
+The ` + "`zstd`" + ` algorithm supports compressing with a dictionary trained with
+` + "`zstd --train`" + `, which is loaded from the file ` + "`dictionary_file`" + `.
+Dictionaries greatly improve the compression ratio of small messages, and the
+same dictionary must be provided when decompressing.
+
+This processor can be used as a [batching processor](/docs/configuration/batching#post-batch-processing)
+in order to compress batches before they are sent by an output. Batches can
+also be archived into a seekable zstd file with the ` + "[`archive`](/docs/components/processors/archive)" + `
+processor.`,
 		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("algorithm", "The compression algorithm to use.").HasOptions("gzip", "zlib", "flate", "snappy"),
+			docs.FieldCommon("algorithm", "The compression algorithm to use.").HasOptions("gzip", "zlib", "flate", "snappy", "zstd"),
 			docs.FieldCommon("level", "The level of compression to use. May not be applicable to all algorithms."),
+			docs.FieldAdvanced("dictionary_file", "An optional path to a dictionary file to compress with. Only applicable to the `zstd` algorithm.").AtVersion("3.44.0"),
 			PartsFieldSpec,
 		},
 	}
@@ -42,17 +55,19 @@ The 'level' field might not apply to all algorithms.`,
 
 // CompressConfig contains configuration fields for the Compress processor.
 type CompressConfig struct {
-	Algorithm string `json:"algorithm" yaml:"algorithm"`
-	Level     int    `json:"level" yaml:"level"`
-	Parts     []int  `json:"parts" yaml:"parts"`
+	Algorithm      string `json:"algorithm" yaml:"algorithm"`
+	Level          int    `json:"level" yaml:"level"`
+	DictionaryFile string `json:"dictionary_file" yaml:"dictionary_file"`
+	Parts          []int  `json:"parts" yaml:"parts"`
 }
 
 // NewCompressConfig returns a CompressConfig with default values.
 func NewCompressConfig() CompressConfig {
 	return CompressConfig{
-		Algorithm: "gzip",
-		Level:     gzip.DefaultCompression,
-		Parts:     []int{},
+		Algorithm:      "gzip",
+		Level:          gzip.DefaultCompression,
+		DictionaryFile: "",
+		Parts:          []int{},
 	}
 }
 
@@ -106,7 +121,35 @@ func snappyCompress(level int, b []byte) ([]byte, error) {
 	return snappy.Encode(nil, b), nil
 }
 
-func strToCompressor(str string) (compressFunc, error) {
+// zstdEncoderLevel maps a zstd compression level to the closest level
+// supported by the encoder, where levels below one use the default.
+func zstdEncoderLevel(level int) zstd.EncoderLevel {
+	if level < 1 {
+		return zstd.SpeedDefault
+	}
+	return zstd.EncoderLevelFromZstd(level)
+}
+
+func zstdCompressor(level int, dict []byte) (compressFunc, error) {
+	opts := []zstd.EOption{
+		zstd.WithEncoderLevel(zstdEncoderLevel(level)),
+	}
+	if len(dict) > 0 {
+		opts = append(opts, zstd.WithEncoderDict(dict))
+	}
+	enc, err := zstd.NewWriter(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return func(level int, b []byte) ([]byte, error) {
+		return enc.EncodeAll(b, nil), nil
+	}, nil
+}
+
+func strToCompressor(str string, level int, dict []byte) (compressFunc, error) {
+	if len(dict) > 0 && str != "zstd" {
+		return nil, fmt.Errorf("compression type %v does not support dictionaries", str)
+	}
 	switch str {
 	case "gzip":
 		return gzipCompress, nil
@@ -116,6 +159,8 @@ func strToCompressor(str string) (compressFunc, error) {
 		return flateCompress, nil
 	case "snappy":
 		return snappyCompress, nil
+	case "zstd":
+		return zstdCompressor(level, dict)
 	}
 	return nil, fmt.Errorf("compression type not recognised: %v", str)
 }
@@ -141,7 +186,14 @@ type Compress struct {
 func NewCompress(
 	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
 ) (Type, error) {
-	cor, err := strToCompressor(conf.Compress.Algorithm)
+	var dict []byte
+	if conf.Compress.DictionaryFile != "" {
+		var err error
+		if dict, err = ioutil.ReadFile(conf.Compress.DictionaryFile); err != nil {
+			return nil, fmt.Errorf("failed to read dictionary file: %v", err)
+		}
+	}
+	cor, err := strToCompressor(conf.Compress.Algorithm, conf.Compress.Level, dict)
 	if err != nil {
 		return nil, err
 	}
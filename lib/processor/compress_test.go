@@ -5,6 +5,8 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"compress/zlib"
+	"io/ioutil"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 )
 
 func TestCompressBadAlgo(t *testing.T) {
@@ -208,6 +211,66 @@ func TestCompressSnappy(t *testing.T) {
 	}
 }
 
+func TestCompressZstd(t *testing.T) {
+	conf := NewConfig()
+	conf.Compress.Algorithm = "zstd"
+	conf.Compress.Level = 19
+
+	input := [][]byte{
+		[]byte("hello world first part"),
+		[]byte("hello world second part"),
+		[]byte("third part"),
+	}
+
+	proc, err := NewCompress(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, res := proc.ProcessMessage(message.New(input))
+	if len(msgs) != 1 {
+		t.Fatal("Compress failed")
+	} else if res != nil {
+		t.Errorf("Expected nil response: %v", res)
+	}
+
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dec.Close()
+
+	for i, part := range message.GetAllBytes(msgs[0]) {
+		act, err := dec.DecodeAll(part, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := input[i]; !reflect.DeepEqual(exp, act) {
+			t.Errorf("Unexpected output: %s != %s", act, exp)
+		}
+	}
+}
+
+func TestCompressDictionaryBadAlgo(t *testing.T) {
+	dictPath := filepath.Join(t.TempDir(), "dict")
+	if err := ioutil.WriteFile(dictPath, []byte("not a real dictionary"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewConfig()
+	conf.Compress.Algorithm = "gzip"
+	conf.Compress.DictionaryFile = dictPath
+
+	if _, err := NewCompress(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from dictionary with gzip")
+	}
+
+	conf.Compress.DictionaryFile = filepath.Join(t.TempDir(), "does_not_exist")
+	if _, err := NewCompress(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from missing dictionary")
+	}
+}
+
 func TestCompressIndexBounds(t *testing.T) {
 	conf := NewConfig()
 
@@ -0,0 +1,191 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/contract"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeContract] = TypeSpec{
+		constructor: NewContract,
+		Categories: []Category{
+			CategoryUtility,
+		},
+		Status:  docs.StatusExperimental,
+		Version: "3.44.0",
+		Summary: `
+Enforces a [data contract](/docs/configuration/contracts) identified by its label at this point of a pipeline.`,
+		Description: `
+Each message is checked against the schema and checks of the contract. Messages that satisfy the contract are passed on unchanged.
+
+If the contract has a ` + "`quarantine`" + ` output resource then messages that violate it are removed from the batch and sent to that output with the metadata field ` + "`contract`" + ` set to the label of the contract. Otherwise violating messages are flagged as having failed, which allows them to be handled with [error handling patterns](/docs/configuration/error_handling).
+
+The name of the enforcement point logged with violations is the label of this processor.`,
+		Footnotes: `
+## Examples
+
+` + "```yaml" + `
+pipeline:
+  processors:
+    - label: ingest
+      contract: users
+
+contracts:
+  - label: users
+    checks:
+      - this.age >= 0
+    quarantine: dead_users
+
+output_resources:
+  - label: dead_users
+    file:
+      path: ./quarantine.jsonl
+` + "```" + ``,
+		config: docs.FieldComponent().HasType(docs.FieldString),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type outputProvider interface {
+	GetOutput(name string) (types.OutputWriter, error)
+}
+
+//------------------------------------------------------------------------------
+
+// Contract is a processor that enforces a data contract resource.
+type Contract struct {
+	contract   *contract.Contract
+	point      string
+	quarantine outputProvider
+	log        log.Modular
+
+	mCount      metrics.StatCounter
+	mViolations metrics.StatCounter
+	mErr        metrics.StatCounter
+	mSent       metrics.StatCounter
+	mBatchSent  metrics.StatCounter
+}
+
+// NewContract returns a contract processor.
+func NewContract(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	c, err := contract.FromManager(mgr, conf.Contract)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain contract '%v': %v", conf.Contract, err)
+	}
+
+	point := TypeContract
+	if l, ok := mgr.(interface{ Label() string }); ok && len(l.Label()) > 0 {
+		point = l.Label()
+	}
+
+	p := &Contract{
+		contract: c,
+		point:    point,
+		log:      log,
+
+		mCount:      stats.GetCounter("count"),
+		mViolations: stats.GetCounter("violations"),
+		mErr:        stats.GetCounter("error"),
+		mSent:       stats.GetCounter("sent"),
+		mBatchSent:  stats.GetCounter("batch.sent"),
+	}
+	if len(c.Quarantine()) > 0 {
+		var ok bool
+		if p.quarantine, ok = mgr.(outputProvider); !ok {
+			return nil, errors.New("manager does not support output resources")
+		}
+	}
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (c *Contract) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	c.mCount.Incr(1)
+
+	kept := message.New(nil)
+	violated := message.New(nil)
+	violatedErrs := []error{}
+
+	_ = msg.Iter(func(i int, p types.Part) error {
+		err := c.contract.Check(c.point, i, msg)
+		if err == nil {
+			kept.Append(p)
+			return nil
+		}
+		c.mViolations.Incr(1)
+		if c.quarantine == nil {
+			FlagErr(p, err)
+			kept.Append(p)
+			return nil
+		}
+		qPart := p.Copy()
+		qPart.Metadata().Set("contract", c.contract.Label())
+		violated.Append(qPart)
+		violatedErrs = append(violatedErrs, err)
+		return nil
+	})
+
+	if violated.Len() > 0 {
+		if err := c.sendQuarantine(violated); err != nil {
+			c.mErr.Incr(1)
+			c.log.Errorf("Failed to send violating messages to quarantine '%v': %v\n", c.contract.Quarantine(), err)
+			_ = violated.Iter(func(i int, p types.Part) error {
+				p.Metadata().Delete("contract")
+				FlagErr(p, violatedErrs[i])
+				kept.Append(p)
+				return nil
+			})
+		} else {
+			c.contract.Quarantined(violated.Len())
+		}
+	}
+
+	if kept.Len() == 0 {
+		return nil, response.NewAck()
+	}
+
+	c.mBatchSent.Incr(1)
+	c.mSent.Incr(int64(kept.Len()))
+	return []types.Message{kept}, nil
+}
+
+func (c *Contract) sendQuarantine(msg types.Message) error {
+	out, err := c.quarantine.GetOutput(c.contract.Quarantine())
+	if err != nil {
+		return err
+	}
+	resChan := make(chan types.Response)
+	if err = out.WriteTransaction(context.Background(), types.NewTransaction(msg, resChan)); err != nil {
+		return err
+	}
+	return (<-resChan).Error()
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (c *Contract) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (c *Contract) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,407 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/gabs/v2"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeConvert] = TypeSpec{
+		constructor: NewConvert,
+		Status:      docs.StatusExperimental,
+		Version:     "3.44.0",
+		Categories: []Category{
+			CategoryMapping,
+		},
+		Summary: `
+Converts amounts within JSON documents between currencies or units using a table of rates that is periodically refreshed from a URL or file.`,
+		Description: `
+The rate table must be a JSON object consisting of a ` + "`base`" + ` currency or unit and a map of ` + "`rates`" + `, where each rate is the amount of a currency or unit that equals one of the base:
+
+` + "```json" + `
+{"base":"USD","rates":{"EUR":0.92,"GBP":0.79,"JPY":151.2}}
+` + "```" + `
+
+An amount is converted by dividing it by the rate of the ` + "`from`" + ` currency or unit and then multiplying it by the rate of the ` + "`to`" + ` currency or unit, where the base has an implicit rate of one. The result is written to the field ` + "`result_path`" + ` of the document.
+
+The table is loaded when the processor is created and refreshed every ` + "`refresh_interval`" + `. When a refresh fails the previous rates continue to be used, and once the last successful refresh is older than ` + "`stale_after`" + ` the rates are considered stale. Messages are only flagged as failed when no rates have been loaded yet, or when a currency or unit is not present within the table.
+
+### Metadata
+
+The metadata field ` + "`convert_rates_updated`" + ` of each converted message is set to the time of the last successful refresh in RFC 3339 format, and the field ` + "`convert_rates_stale`" + ` is set to ` + "`true`" + ` when the rates are stale and ` + "`false`" + ` otherwise.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Normalising Prices",
+				Summary: `
+Here we convert the prices of orders into euros, refreshing exchange rates every hour and dropping orders when the rates are more than a day old:`,
+				Config: `
+pipeline:
+  processors:
+    - convert:
+        rates_url: https://example.com/rates/latest.json
+        refresh_interval: 1h
+        stale_after: 24h
+        amount: ${! json("price") }
+        from: ${! json("currency") }
+        to: EUR
+        result_path: price_eur
+    - bloblang: |
+        root = if meta("convert_rates_stale") == "true" { deleted() }
+`,
+			},
+		},
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("rates_url", "A URL to fetch the rate table from with a GET request."),
+			docs.FieldCommon("rates_file", "A path to a file to read the rate table from."),
+			docs.FieldCommon("refresh_interval", "The period between refreshes of the rate table."),
+			docs.FieldAdvanced("stale_after", "The age of the last successful refresh after which rates are considered stale."),
+			docs.FieldAdvanced("timeout", "The maximum period to wait for a refresh from a URL."),
+			docs.FieldCommon("amount", "The amount to convert, which must resolve to a number.", `${! json("price") }`).IsInterpolated(),
+			docs.FieldCommon("from", "The currency or unit to convert from.", "USD", `${! json("currency") }`).IsInterpolated(),
+			docs.FieldCommon("to", "The currency or unit to convert to.", "EUR", `${! meta("target_currency") }`).IsInterpolated(),
+			docs.FieldCommon("result_path", "A [dot separated path](/docs/configuration/field_paths) of the field to write the converted amount to."),
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ConvertConfig contains configuration fields for the Convert processor.
+type ConvertConfig struct {
+	RatesURL        string `json:"rates_url" yaml:"rates_url"`
+	RatesFile       string `json:"rates_file" yaml:"rates_file"`
+	RefreshInterval string `json:"refresh_interval" yaml:"refresh_interval"`
+	StaleAfter      string `json:"stale_after" yaml:"stale_after"`
+	Timeout         string `json:"timeout" yaml:"timeout"`
+	Amount          string `json:"amount" yaml:"amount"`
+	From            string `json:"from" yaml:"from"`
+	To              string `json:"to" yaml:"to"`
+	ResultPath      string `json:"result_path" yaml:"result_path"`
+}
+
+// NewConvertConfig returns a ConvertConfig with default values.
+func NewConvertConfig() ConvertConfig {
+	return ConvertConfig{
+		RatesURL:        "",
+		RatesFile:       "",
+		RefreshInterval: "1h",
+		StaleAfter:      "24h",
+		Timeout:         "10s",
+		Amount:          "",
+		From:            "",
+		To:              "",
+		ResultPath:      "converted_amount",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type convertRateTable struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+func (r *convertRateTable) rate(name string) (float64, error) {
+	if name == r.Base {
+		return 1, nil
+	}
+	rate, exists := r.Rates[name]
+	if !exists {
+		return 0, fmt.Errorf("rate not found for: %v", name)
+	}
+	if rate <= 0 {
+		return 0, fmt.Errorf("invalid rate for %v: %v", name, rate)
+	}
+	return rate, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Convert is a processor that converts amounts between currencies or units.
+type Convert struct {
+	conf       ConvertConfig
+	client     *http.Client
+	interval   time.Duration
+	staleAfter time.Duration
+
+	amount     field.Expression
+	from       field.Expression
+	to         field.Expression
+	resultPath string
+
+	ratesMut     sync.RWMutex
+	rates        *convertRateTable
+	ratesUpdated time.Time
+
+	log log.Modular
+
+	mCount        metrics.StatCounter
+	mErr          metrics.StatCounter
+	mRefreshSucc  metrics.StatCounter
+	mRefreshError metrics.StatCounter
+	mRatesAge     metrics.StatGauge
+	mSent         metrics.StatCounter
+	mBatchSent    metrics.StatCounter
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewConvert returns a Convert processor.
+func NewConvert(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	cConf := conf.Convert
+	if (cConf.RatesURL == "") == (cConf.RatesFile == "") {
+		return nil, errors.New("exactly one of rates_url or rates_file must be specified")
+	}
+	if cConf.ResultPath == "" {
+		return nil, errors.New("a result_path must be specified")
+	}
+
+	c := &Convert{
+		conf:       cConf,
+		client:     &http.Client{},
+		resultPath: cConf.ResultPath,
+		log:        log,
+
+		mCount:        stats.GetCounter("count"),
+		mErr:          stats.GetCounter("error"),
+		mRefreshSucc:  stats.GetCounter("refresh.success"),
+		mRefreshError: stats.GetCounter("refresh.error"),
+		mRatesAge:     stats.GetGauge("rates.age_seconds"),
+		mSent:         stats.GetCounter("sent"),
+		mBatchSent:    stats.GetCounter("batch.sent"),
+
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}
+
+	var err error
+	if c.interval, err = time.ParseDuration(cConf.RefreshInterval); err != nil {
+		return nil, fmt.Errorf("failed to parse refresh_interval: %v", err)
+	}
+	if c.interval <= 0 {
+		return nil, errors.New("refresh_interval must be greater than zero")
+	}
+	if c.staleAfter, err = time.ParseDuration(cConf.StaleAfter); err != nil {
+		return nil, fmt.Errorf("failed to parse stale_after: %v", err)
+	}
+	if c.client.Timeout, err = time.ParseDuration(cConf.Timeout); err != nil {
+		return nil, fmt.Errorf("failed to parse timeout: %v", err)
+	}
+	if c.amount, err = bloblang.NewField(cConf.Amount); err != nil {
+		return nil, fmt.Errorf("failed to parse amount expression: %v", err)
+	}
+	if c.from, err = bloblang.NewField(cConf.From); err != nil {
+		return nil, fmt.Errorf("failed to parse from expression: %v", err)
+	}
+	if c.to, err = bloblang.NewField(cConf.To); err != nil {
+		return nil, fmt.Errorf("failed to parse to expression: %v", err)
+	}
+
+	c.refresh()
+	go c.loop()
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (c *Convert) fetchRates() (*convertRateTable, error) {
+	var body []byte
+	var err error
+	if c.conf.RatesFile != "" {
+		if body, err = ioutil.ReadFile(c.conf.RatesFile); err != nil {
+			return nil, err
+		}
+	} else {
+		ctx, done := context.WithCancel(context.Background())
+		defer done()
+		go func() {
+			select {
+			case <-c.closeChan:
+				done()
+			case <-ctx.Done():
+			}
+		}()
+
+		req, err := http.NewRequestWithContext(ctx, "GET", c.conf.RatesURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		res, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		if body, err = ioutil.ReadAll(res.Body); err != nil {
+			return nil, err
+		}
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return nil, fmt.Errorf("request returned status: %v", res.StatusCode)
+		}
+	}
+
+	var table convertRateTable
+	if err = json.Unmarshal(body, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse rate table: %v", err)
+	}
+	if table.Base == "" {
+		return nil, errors.New("rate table is missing a base")
+	}
+	return &table, nil
+}
+
+func (c *Convert) refresh() {
+	table, err := c.fetchRates()
+	if err != nil {
+		c.mRefreshError.Incr(1)
+		c.log.Errorf("Failed to refresh rates: %v\n", err)
+	} else {
+		c.mRefreshSucc.Incr(1)
+	}
+
+	c.ratesMut.Lock()
+	if table != nil {
+		c.rates = table
+		c.ratesUpdated = time.Now()
+	}
+	if c.rates != nil {
+		c.mRatesAge.Set(int64(time.Since(c.ratesUpdated).Seconds()))
+	}
+	c.ratesMut.Unlock()
+}
+
+func (c *Convert) loop() {
+	defer close(c.closedChan)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.refresh()
+		case <-c.closeChan:
+			return
+		}
+	}
+}
+
+func (c *Convert) getRates() (*convertRateTable, time.Time) {
+	c.ratesMut.RLock()
+	defer c.ratesMut.RUnlock()
+	return c.rates, c.ratesUpdated
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (c *Convert) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	c.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	rates, updated := c.getRates()
+	stale := strconv.FormatBool(time.Since(updated) > c.staleAfter)
+
+	proc := func(i int, span opentracing.Span, part types.Part) error {
+		if rates == nil {
+			c.mErr.Incr(1)
+			return errors.New("no rates have been loaded")
+		}
+
+		amountStr := c.amount.String(i, msg)
+		amount, err := strconv.ParseFloat(amountStr, 64)
+		if err != nil {
+			c.mErr.Incr(1)
+			return fmt.Errorf("failed to parse amount '%v' as a number: %v", amountStr, err)
+		}
+
+		fromRate, err := rates.rate(c.from.String(i, msg))
+		if err != nil {
+			c.mErr.Incr(1)
+			return err
+		}
+		toRate, err := rates.rate(c.to.String(i, msg))
+		if err != nil {
+			c.mErr.Incr(1)
+			return err
+		}
+
+		jObj, err := part.JSON()
+		if err == nil {
+			jObj, err = message.CopyJSON(jObj)
+		}
+		if err != nil {
+			c.mErr.Incr(1)
+			c.log.Debugf("Failed to parse message as JSON: %v\n", err)
+			return err
+		}
+
+		gObj := gabs.Wrap(jObj)
+		if _, err = gObj.SetP(amount/fromRate*toRate, c.resultPath); err != nil {
+			c.mErr.Incr(1)
+			return err
+		}
+		if err = part.SetJSON(gObj.Data()); err != nil {
+			c.mErr.Incr(1)
+			return err
+		}
+
+		part.Metadata().Set("convert_rates_updated", updated.Format(time.RFC3339))
+		part.Metadata().Set("convert_rates_stale", stale)
+		return nil
+	}
+
+	if newMsg.Len() == 0 {
+		return nil, response.NewAck()
+	}
+
+	IteratePartsWithSpan(TypeConvert, nil, newMsg, proc)
+
+	c.mBatchSent.Incr(1)
+	c.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (c *Convert) CloseAsync() {
+	c.closeOnce.Do(func() {
+		close(c.closeChan)
+	})
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (c *Convert) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-c.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
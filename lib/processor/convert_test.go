@@ -0,0 +1,142 @@
+package processor
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_convert_test")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	ratesPath := filepath.Join(dir, "rates.json")
+	require.NoError(t, ioutil.WriteFile(ratesPath, []byte(`{"base":"m","rates":{"km":0.001,"cm":100}}`), 0644))
+
+	conf := NewConfig()
+	conf.Type = TypeConvert
+	conf.Convert.RatesFile = ratesPath
+	conf.Convert.Amount = `${! json("distance") }`
+	conf.Convert.From = `${! json("unit") }`
+	conf.Convert.To = "cm"
+	conf.Convert.ResultPath = "result.cm"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		proc.CloseAsync()
+		require.NoError(t, proc.WaitForClose(time.Second))
+	})
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"distance":2.5,"unit":"km"}`),
+		[]byte(`{"distance":3,"unit":"m"}`),
+		[]byte(`{"distance":3,"unit":"mi"}`),
+		[]byte(`{"distance":"nope","unit":"m"}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, `{"distance":2.5,"result":{"cm":250000},"unit":"km"}`, string(msgs[0].Get(0).Get()))
+	assert.Equal(t, "false", msgs[0].Get(0).Metadata().Get("convert_rates_stale"))
+	assert.NotEmpty(t, msgs[0].Get(0).Metadata().Get("convert_rates_updated"))
+	assert.Equal(t, `{"distance":3,"result":{"cm":300},"unit":"m"}`, string(msgs[0].Get(1).Get()))
+	assert.Equal(t, "rate not found for: mi", GetFail(msgs[0].Get(2)))
+	assert.Contains(t, GetFail(msgs[0].Get(3)), "failed to parse amount 'nope' as a number")
+}
+
+func TestConvertStaleRates(t *testing.T) {
+	var fail int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&fail) == 1 {
+			http.Error(w, "nope", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"base":"USD","rates":{"EUR":0.5}}`))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.Type = TypeConvert
+	conf.Convert.RatesURL = ts.URL
+	conf.Convert.RefreshInterval = "1ms"
+	conf.Convert.StaleAfter = "50ms"
+	conf.Convert.Amount = `${! json("price") }`
+	conf.Convert.From = "EUR"
+	conf.Convert.To = "USD"
+
+	stats := metrics.NewLocal()
+	proc, err := New(conf, nil, log.Noop(), stats)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		proc.CloseAsync()
+		require.NoError(t, proc.WaitForClose(time.Second))
+	})
+
+	atomic.StoreInt32(&fail, 1)
+	assert.Eventually(t, func() bool {
+		return stats.GetCounters()["refresh.error"] > 0
+	}, time.Second, time.Millisecond)
+
+	// The previous rates are still used whilst refreshes are failing.
+	assert.Eventually(t, func() bool {
+		msgs, _ := proc.ProcessMessage(message.New([][]byte{[]byte(`{"price":10}`)}))
+		part := msgs[0].Get(0)
+		return !HasFailed(part) &&
+			string(part.Get()) == `{"converted_amount":20,"price":10}` &&
+			part.Metadata().Get("convert_rates_stale") == "true"
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestConvertNoRates(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.Type = TypeConvert
+	conf.Convert.RatesURL = ts.URL
+	conf.Convert.Amount = `${! json("price") }`
+	conf.Convert.From = "EUR"
+	conf.Convert.To = "USD"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		proc.CloseAsync()
+		require.NoError(t, proc.WaitForClose(time.Second))
+	})
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte(`{"price":10}`)}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "no rates have been loaded", GetFail(msgs[0].Get(0)))
+}
+
+func TestConvertBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeConvert
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "exactly one of rates_url or rates_file must be specified")
+
+	conf.Convert.RatesFile = "foo.json"
+	conf.Convert.RefreshInterval = "0s"
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "refresh_interval must be greater than zero")
+}
@@ -8,6 +8,7 @@ import (
 	"compress/zlib"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
@@ -16,6 +17,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/opentracing/opentracing-go"
 )
 
@@ -29,9 +31,14 @@ func init() {
 		},
 		Summary: `
 Decompresses messages according to the selected algorithm. Supported
-decompression types are: gzip, zlib, bzip2, flate.`,
+decompression types are: gzip, zlib, bzip2, flate, snappy, zstd.`,
+		Description: `
+Messages compressed by the ` + "`zstd`" + ` algorithm with a dictionary can only
+be decompressed when the same dictionary is loaded from the file
+` + "`dictionary_file`" + `.`,
 		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("algorithm", "The decompression algorithm to use.").HasOptions("gzip", "zlib", "bzip2", "flate", "snappy"),
+			docs.FieldCommon("algorithm", "The decompression algorithm to use.").HasOptions("gzip", "zlib", "bzip2", "flate", "snappy", "zstd"),
+			docs.FieldAdvanced("dictionary_file", "An optional path to a dictionary file to decompress with. Only applicable to the `zstd` algorithm.").AtVersion("3.44.0"),
 			PartsFieldSpec,
 		},
 	}
@@ -41,15 +48,17 @@ decompression types are: gzip, zlib, bzip2, flate.`,
 
 // DecompressConfig contains configuration fields for the Decompress processor.
 type DecompressConfig struct {
-	Algorithm string `json:"algorithm" yaml:"algorithm"`
-	Parts     []int  `json:"parts" yaml:"parts"`
+	Algorithm      string `json:"algorithm" yaml:"algorithm"`
+	DictionaryFile string `json:"dictionary_file" yaml:"dictionary_file"`
+	Parts          []int  `json:"parts" yaml:"parts"`
 }
 
 // NewDecompressConfig returns a DecompressConfig with default values.
 func NewDecompressConfig() DecompressConfig {
 	return DecompressConfig{
-		Algorithm: "gzip",
-		Parts:     []int{},
+		Algorithm:      "gzip",
+		DictionaryFile: "",
+		Parts:          []int{},
 	}
 }
 
@@ -114,7 +123,24 @@ func bzip2Decompress(b []byte) ([]byte, error) {
 	return outBuf.Bytes(), nil
 }
 
-func strToDecompressor(str string) (decompressFunc, error) {
+func zstdDecompressor(dict []byte) (decompressFunc, error) {
+	var opts []zstd.DOption
+	if len(dict) > 0 {
+		opts = append(opts, zstd.WithDecoderDicts(dict))
+	}
+	dec, err := zstd.NewReader(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return func(b []byte) ([]byte, error) {
+		return dec.DecodeAll(b, nil)
+	}, nil
+}
+
+func strToDecompressor(str string, dict []byte) (decompressFunc, error) {
+	if len(dict) > 0 && str != "zstd" {
+		return nil, fmt.Errorf("decompression type %v does not support dictionaries", str)
+	}
 	switch str {
 	case "gzip":
 		return gzipDecompress, nil
@@ -126,6 +152,8 @@ func strToDecompressor(str string) (decompressFunc, error) {
 		return bzip2Decompress, nil
 	case "snappy":
 		return snappyDecompress, nil
+	case "zstd":
+		return zstdDecompressor(dict)
 	}
 	return nil, fmt.Errorf("decompression type not recognised: %v", str)
 }
@@ -151,7 +179,14 @@ type Decompress struct {
 func NewDecompress(
 	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
 ) (Type, error) {
-	dcor, err := strToDecompressor(conf.Decompress.Algorithm)
+	var dict []byte
+	if conf.Decompress.DictionaryFile != "" {
+		var err error
+		if dict, err = ioutil.ReadFile(conf.Decompress.DictionaryFile); err != nil {
+			return nil, fmt.Errorf("failed to read dictionary file: %v", err)
+		}
+	}
+	dcor, err := strToDecompressor(conf.Decompress.Algorithm, dict)
 	if err != nil {
 		return nil, err
 	}
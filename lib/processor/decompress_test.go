@@ -12,6 +12,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 )
 
 func TestDecompressBadAlgo(t *testing.T) {
@@ -212,6 +213,42 @@ func TestDecompressFlate(t *testing.T) {
 	}
 }
 
+func TestDecompressZstd(t *testing.T) {
+	conf := NewConfig()
+	conf.Decompress.Algorithm = "zstd"
+
+	exp := [][]byte{
+		[]byte("hello world first part"),
+		[]byte("hello world second part"),
+		[]byte("third part"),
+	}
+
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := [][]byte{}
+	for _, b := range exp {
+		input = append(input, enc.EncodeAll(b, nil))
+	}
+	enc.Close()
+
+	proc, err := NewDecompress(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, res := proc.ProcessMessage(message.New(input))
+	if len(msgs) != 1 {
+		t.Fatal("Decompress failed")
+	} else if res != nil {
+		t.Errorf("Expected nil response: %v", res)
+	}
+	if act := message.GetAllBytes(msgs[0]); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Unexpected output: %s != %s", act, exp)
+	}
+}
+
 func TestDecompressIndexBounds(t *testing.T) {
 	conf := NewConfig()
 
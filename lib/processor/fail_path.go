@@ -0,0 +1,57 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// failPathProc wraps a processor and records a component path against any
+// message parts that it flags as failed.
+type failPathProc struct {
+	path  string
+	child types.Processor
+}
+
+// WithFailPath wraps a processor so that any message parts newly flagged as
+// failed by it have the provided component path recorded in their metadata.
+func WithFailPath(path string, proc types.Processor) types.Processor {
+	return &failPathProc{
+		path:  path,
+		child: proc,
+	}
+}
+
+// ProcessMessage applies the child processor to a message and records the
+// component path against any failed parts of the results.
+func (f *failPathProc) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return f.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext is the context aware version of ProcessMessage,
+// the context is passed to the child processor if it supports it.
+func (f *failPathProc) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
+	msgs, res := processWithContext(ctx, f.child, msg)
+	for _, m := range msgs {
+		_ = m.Iter(func(_ int, p types.Part) error {
+			SetFailPath(p, f.path)
+			return nil
+		})
+	}
+	return msgs, res
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (f *failPathProc) CloseAsync() {
+	f.child.CloseAsync()
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (f *failPathProc) WaitForClose(timeout time.Duration) error {
+	return f.child.WaitForClose(timeout)
+}
+
+//------------------------------------------------------------------------------
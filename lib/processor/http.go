@@ -1,10 +1,14 @@
 package processor
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
@@ -63,7 +67,21 @@ field ` + "`http_status_code`" + ` on the resulting message.
 
 If the field ` + "`copy_response_headers` is set to `true`" + ` then any headers
 in the response will also be set in the resulting message as metadata.
- 
+
+## Caching
+
+When a ` + "[cache resource](/docs/components/caches/about)" + ` is specified
+with the field ` + "`cache.resource`" + ` responses are stored within it and
+reused for subsequent requests with the same verb, URL and body, which avoids
+duplicate requests when enriching messages with data that rarely changes.
+
+Responses remain fresh for the period specified by their ` + "`Cache-Control`" + `
+or ` + "`Expires`" + ` headers, or ` + "`cache.default_ttl`" + ` when neither is
+present, and are never stored when ` + "`Cache-Control`" + ` contains
+` + "`no-store`" + `. Once a response is stale and it has an ` + "`ETag`" + ` or
+` + "`Last-Modified`" + ` header it is revalidated with a conditional request,
+and reused when the server responds with a 304 status code.
+
 ## Error Handling
 
 When all retry attempts for a message are exhausted the processor cancels the
@@ -84,7 +102,7 @@ can read about these patterns [here](/docs/configuration/error_handling).`,
 				}
 				return "field request is deprecated", cmp.Equal(v, iDefault)
 			}),
-		}, client.FieldSpecs()...),
+		}, append(client.FieldSpecs(), httpCacheFieldSpec())...),
 		Examples: []docs.AnnotatedExample{
 			{
 				Title: "Branched Request",
@@ -110,9 +128,10 @@ pipeline:
 
 // HTTPConfig contains configuration fields for the HTTP processor.
 type HTTPConfig struct {
-	Parallel      bool          `json:"parallel" yaml:"parallel"`
-	MaxParallel   int           `json:"max_parallel" yaml:"max_parallel"`
-	Client        client.Config `json:"request" yaml:"request"`
+	Parallel      bool            `json:"parallel" yaml:"parallel"`
+	MaxParallel   int             `json:"max_parallel" yaml:"max_parallel"`
+	Client        client.Config   `json:"request" yaml:"request"`
+	Cache         HTTPCacheConfig `json:"cache" yaml:"cache"`
 	client.Config `json:",inline" yaml:",inline"`
 }
 
@@ -122,6 +141,7 @@ func NewHTTPConfig() HTTPConfig {
 		Client:      client.NewConfig(),
 		Parallel:    false,
 		MaxParallel: 0,
+		Cache:       NewHTTPCacheConfig(),
 		Config:      client.NewConfig(),
 	}
 }
@@ -136,6 +156,10 @@ type HTTP struct {
 	parallel bool
 	max      int
 
+	cache           types.Cache
+	cacheDefaultTTL time.Duration
+	url             field.Expression
+
 	conf  Config
 	log   log.Modular
 	stats metrics.Type
@@ -145,6 +169,11 @@ type HTTP struct {
 	mErr       metrics.StatCounter
 	mSent      metrics.StatCounter
 	mBatchSent metrics.StatCounter
+
+	mCacheHit         metrics.StatCounter
+	mCacheMiss        metrics.StatCounter
+	mCacheRevalidated metrics.StatCounter
+	mCacheErr         metrics.StatCounter
 }
 
 // NewHTTP returns a HTTP processor.
@@ -171,14 +200,41 @@ func NewHTTP(
 		mErr:       stats.GetCounter("error"),
 		mSent:      stats.GetCounter("sent"),
 		mBatchSent: stats.GetCounter("batch.sent"),
+
+		mCacheHit:         stats.GetCounter("cache.hit"),
+		mCacheMiss:        stats.GetCounter("cache.miss"),
+		mCacheRevalidated: stats.GetCounter("cache.revalidated"),
+		mCacheErr:         stats.GetCounter("cache.error"),
 	}
-	var err error
-	if g.client, err = client.New(
-		conf.HTTP.Config,
+
+	opts := []func(*client.Type){
 		client.OptSetLogger(g.log),
 		client.OptSetStats(metrics.Namespaced(g.stats, "client")),
 		client.OptSetManager(mgr),
-	); err != nil {
+	}
+
+	var err error
+	if conf.HTTP.Cache.Resource != "" {
+		if g.cache, err = mgr.GetCache(conf.HTTP.Cache.Resource); err != nil {
+			return nil, err
+		}
+		if conf.HTTP.Cache.DefaultTTL != "" {
+			if g.cacheDefaultTTL, err = time.ParseDuration(conf.HTTP.Cache.DefaultTTL); err != nil {
+				return nil, fmt.Errorf("failed to parse cache default_ttl: %v", err)
+			}
+		}
+		if g.url, err = bloblang.NewField(conf.HTTP.URL); err != nil {
+			return nil, fmt.Errorf("failed to parse URL expression: %v", err)
+		}
+		// Revalidated responses are considered successful so that the cached
+		// response can be reused.
+		conf.HTTP.Config.SuccessfulOn = append(
+			append([]int{}, conf.HTTP.Config.SuccessfulOn...), http.StatusNotModified,
+		)
+		opts = append(opts, client.OptSetRequestModifier(addCacheValidators))
+	}
+
+	if g.client, err = client.New(conf.HTTP.Config, opts...); err != nil {
 		return nil, err
 	}
 	return g, nil
@@ -189,12 +245,18 @@ func NewHTTP(
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (h *HTTP) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	return h.ProcessMessageWithContext(context.Background(), msg)
+}
+
+// ProcessMessageWithContext is the context aware version of ProcessMessage,
+// where requests are cancelled along with the context.
+func (h *HTTP) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
 	h.mCount.Incr(1)
 	var responseMsg types.Message
 
 	if !h.parallel || msg.Len() == 1 {
 		// Easy, just do a single request.
-		resultMsg, err := h.client.Send(msg)
+		resultMsg, err := h.send(ctx, msg)
 		if err != nil {
 			var codeStr string
 			if hErr, ok := err.(types.ErrUnexpectedHTTPRes); ok {
@@ -246,7 +308,7 @@ func (h *HTTP) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 		for i := 0; i < max; i++ {
 			go func() {
 				for index := range reqChan {
-					result, err := h.client.Send(message.Lock(msg, index))
+					result, err := h.send(ctx, message.Lock(msg, index))
 					if err == nil && result.Len() != 1 {
 						err = fmt.Errorf("unexpected response size: %v", result.Len())
 					}
@@ -297,6 +359,15 @@ func (h *HTTP) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 	return msgs[:], nil
 }
 
+// send performs a request for a message, using the cache when configured and
+// the request is made from a single message.
+func (h *HTTP) send(ctx context.Context, msg types.Message) (types.Message, error) {
+	if h.cache != nil && msg.Len() == 1 {
+		return h.sendCached(ctx, msg)
+	}
+	return h.client.SendWithContext(ctx, msg)
+}
+
 // CloseAsync shuts down the processor and stops processing requests.
 func (h *HTTP) CloseAsync() {
 	h.client.CloseAsync()
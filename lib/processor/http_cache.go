@@ -0,0 +1,232 @@
+package processor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// HTTPCacheConfig contains configuration fields for caching the responses of
+// the HTTP processor.
+type HTTPCacheConfig struct {
+	Resource   string `json:"resource" yaml:"resource"`
+	DefaultTTL string `json:"default_ttl" yaml:"default_ttl"`
+}
+
+// NewHTTPCacheConfig returns a HTTPCacheConfig with default values.
+func NewHTTPCacheConfig() HTTPCacheConfig {
+	return HTTPCacheConfig{
+		Resource:   "",
+		DefaultTTL: "",
+	}
+}
+
+func httpCacheFieldSpec() docs.FieldSpec {
+	return docs.FieldAdvanced(
+		"cache", "Optionally cache responses within a [cache resource](/docs/components/caches/about) in order to avoid duplicate requests. Responses are cached by the verb, URL and a hash of the body of each request, and only when each request is made from a single message.",
+	).WithChildren(
+		docs.FieldCommon("resource", "The cache resource to store responses in, caching is disabled when empty."),
+		docs.FieldCommon("default_ttl", "The period of time a response remains fresh when it has neither a `Cache-Control` max age nor an `Expires` header. When empty such responses are only reused after revalidating them with the server.", "5m"),
+	).AtVersion("3.44.0")
+}
+
+//------------------------------------------------------------------------------
+
+type httpCacheValidatorsKey struct{}
+
+// httpCacheEntry is a cached response along with the information required in
+// order to determine its freshness and revalidate it.
+type httpCacheEntry struct {
+	Body         []byte            `json:"body"`
+	Metadata     map[string]string `json:"metadata"`
+	ETag         string            `json:"etag,omitempty"`
+	LastModified string            `json:"last_modified,omitempty"`
+	FreshUntil   int64             `json:"fresh_until"`
+}
+
+func (e *httpCacheEntry) hasValidators() bool {
+	return e.ETag != "" || e.LastModified != ""
+}
+
+func (e *httpCacheEntry) message() types.Message {
+	part := message.NewPart(e.Body)
+	for k, v := range e.Metadata {
+		part.Metadata().Set(k, v)
+	}
+	msg := message.New(nil)
+	msg.Append(part)
+	return msg
+}
+
+// httpCacheFreshness determines how long a response remains fresh according to
+// its headers, and whether it may be stored at all.
+func httpCacheFreshness(header http.Header, now time.Time, defaultTTL time.Duration) (ttl time.Duration, store bool) {
+	var maxAge, sMaxAge string
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store":
+			return 0, false
+		case directive == "no-cache":
+			return 0, true
+		case strings.HasPrefix(directive, "s-maxage="):
+			sMaxAge = strings.TrimPrefix(directive, "s-maxage=")
+		case strings.HasPrefix(directive, "max-age="):
+			maxAge = strings.TrimPrefix(directive, "max-age=")
+		}
+	}
+	if sMaxAge != "" {
+		maxAge = sMaxAge
+	}
+	if maxAge != "" {
+		secs, err := strconv.ParseInt(maxAge, 10, 64)
+		if err != nil {
+			return 0, true
+		}
+		ttl = time.Duration(secs) * time.Second
+		if age, err := strconv.ParseInt(header.Get("Age"), 10, 64); err == nil {
+			ttl -= time.Duration(age) * time.Second
+		}
+		return ttl, true
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0, true
+		}
+		return t.Sub(now), true
+	}
+	return defaultTTL, true
+}
+
+// httpCacheKey identifies a request by its verb, URL and a hash of its body.
+func httpCacheKey(verb, url string, body []byte) string {
+	hash := sha256.Sum256(body)
+	return verb + " " + url + " " + hex.EncodeToString(hash[:])
+}
+
+// addCacheValidators adds conditional headers to a request when the message it
+// was created from carries a stale cache entry.
+func addCacheValidators(req *http.Request, msg types.Message) error {
+	if msg.Len() != 1 {
+		return nil
+	}
+	entry, ok := message.GetContext(msg.Get(0)).Value(httpCacheValidatorsKey{}).(*httpCacheEntry)
+	if !ok {
+		return nil
+	}
+	if entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.LastModified)
+	}
+	return nil
+}
+
+func (h *HTTP) getCached(key string) *httpCacheEntry {
+	entryBytes, err := h.cache.Get(key)
+	if err != nil {
+		if err != types.ErrKeyNotFound {
+			h.mCacheErr.Incr(1)
+			h.log.Errorf("Failed to get cached response: %v\n", err)
+		}
+		return nil
+	}
+	var entry httpCacheEntry
+	if err = json.Unmarshal(entryBytes, &entry); err != nil {
+		h.mCacheErr.Incr(1)
+		h.log.Errorf("Failed to parse cached response: %v\n", err)
+		return nil
+	}
+	return &entry
+}
+
+func (h *HTTP) setCached(key string, entry *httpCacheEntry, header http.Header, now time.Time) {
+	ttl, store := httpCacheFreshness(header, now, h.cacheDefaultTTL)
+	if !store || (ttl <= 0 && !entry.hasValidators()) {
+		return
+	}
+	entry.FreshUntil = now.Add(ttl).UnixNano()
+
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		h.mCacheErr.Incr(1)
+		h.log.Errorf("Failed to serialise response: %v\n", err)
+		return
+	}
+	if cttl, ok := h.cache.(types.CacheWithTTL); ok && !entry.hasValidators() {
+		err = cttl.SetWithTTL(key, entryBytes, &ttl)
+	} else {
+		err = h.cache.Set(key, entryBytes)
+	}
+	if err != nil {
+		h.mCacheErr.Incr(1)
+		h.log.Errorf("Failed to cache response: %v\n", err)
+	}
+}
+
+// sendCached performs a request for a single message, serving the response
+// from the cache when it is fresh and revalidating it when it is stale.
+func (h *HTTP) sendCached(ctx context.Context, msg types.Message) (types.Message, error) {
+	key := httpCacheKey(h.conf.HTTP.Verb, h.url.String(0, msg), msg.Get(0).Get())
+	now := time.Now()
+
+	entry := h.getCached(key)
+	if entry != nil && now.UnixNano() < entry.FreshUntil {
+		h.mCacheHit.Incr(1)
+		return entry.message(), nil
+	}
+
+	reqMsg := msg
+	if entry != nil && entry.hasValidators() {
+		reqMsg = message.New(nil)
+		reqMsg.Append(message.WithContext(
+			context.WithValue(message.GetContext(msg.Get(0)), httpCacheValidatorsKey{}, entry),
+			msg.Get(0),
+		))
+	}
+
+	res, err := h.client.DoWithContext(ctx, reqMsg)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotModified && reqMsg != msg {
+		if res.Body != nil {
+			res.Body.Close()
+		}
+		h.mCacheRevalidated.Incr(1)
+		h.setCached(key, entry, res.Header, now)
+		return entry.message(), nil
+	}
+	h.mCacheMiss.Incr(1)
+
+	resMsg, err := h.client.ParseResponse(res)
+	if err != nil || resMsg.Len() != 1 {
+		return resMsg, err
+	}
+
+	newEntry := &httpCacheEntry{
+		Body:         resMsg.Get(0).Get(),
+		Metadata:     map[string]string{},
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+	}
+	resMsg.Get(0).Metadata().Iter(func(k, v string) error {
+		newEntry.Metadata[k] = v
+		return nil
+	})
+	h.setCached(key, newEntry, res.Header, now)
+	return resMsg, nil
+}
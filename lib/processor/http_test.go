@@ -9,9 +9,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/lib/cache"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
 func TestHTTPClientRetries(t *testing.T) {
@@ -405,3 +407,85 @@ func TestHTTPClientParallelCapped(t *testing.T) {
 		t.Errorf("Wrong result: %v != %v", act, exp)
 	}
 }
+
+func TestHTTPClientCache(t *testing.T) {
+	var reqCount, notModifiedCount uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		reqBytes, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/stale":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				atomic.AddUint32(&notModifiedCount, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+		}
+		w.Write(append([]byte("echo: "), reqBytes...))
+	}))
+	defer ts.Close()
+
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := &fakeMgr{
+		caches: map[string]types.Cache{
+			"foocache": memCache,
+		},
+	}
+
+	tests := []struct {
+		path        string
+		payloads    []string
+		expRequests uint32
+	}{
+		{path: "/fresh", payloads: []string{"foo", "foo", "bar", "foo"}, expRequests: 2},
+		{path: "/stale", payloads: []string{"foo", "foo", "foo"}, expRequests: 3},
+		{path: "/none", payloads: []string{"foo", "foo"}, expRequests: 2},
+	}
+
+	for _, test := range tests {
+		atomic.StoreUint32(&reqCount, 0)
+
+		conf := NewConfig()
+		conf.HTTP.Config.URL = ts.URL + test.path
+		conf.HTTP.Cache.Resource = "foocache"
+
+		h, err := NewHTTP(conf, mgr, log.Noop(), metrics.Noop())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, payload := range test.payloads {
+			msgs, res := h.ProcessMessage(message.New([][]byte{[]byte(payload)}))
+			if res != nil {
+				t.Fatal(res.Error())
+			}
+			if len(msgs) != 1 || msgs[0].Len() != 1 {
+				t.Fatalf("%v: wrong count of result messages", test.path)
+			}
+			if HasFailed(msgs[0].Get(0)) {
+				t.Errorf("%v: message part flagged as failed", test.path)
+			}
+			if exp, act := "echo: "+payload, string(msgs[0].Get(0).Get()); exp != act {
+				t.Errorf("%v: wrong message contents: %v != %v", test.path, act, exp)
+			}
+		}
+
+		if exp, act := test.expRequests, atomic.LoadUint32(&reqCount); exp != act {
+			t.Errorf("%v: wrong count of HTTP requests: %v != %v", test.path, act, exp)
+		}
+	}
+
+	if exp, act := uint32(2), atomic.LoadUint32(&notModifiedCount); exp != act {
+		t.Errorf("Wrong count of revalidated requests: %v != %v", act, exp)
+	}
+}
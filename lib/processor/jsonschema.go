@@ -1,9 +1,11 @@
 package processor
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
@@ -12,6 +14,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/response"
 
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/schemaregistry"
 	"github.com/opentracing/opentracing-go"
 	jsonschema "github.com/xeipuuv/gojsonschema"
 )
@@ -30,7 +33,13 @@ payload under any circumstances. If a message does not match the schema it can
 be caught using error handling methods outlined [here](/docs/configuration/error_handling).`,
 		Description: `
 Please refer to the [JSON Schema website](https://json-schema.org/) for
-information and tutorials regarding the syntax of the schema.`,
+information and tutorials regarding the syntax of the schema.
+
+The schema can also be obtained from a
+[schema registry resource](/docs/configuration/resources#schema-registries) by
+setting the field ` + "`schema_registry`" + ` to its label, in which case
+messages are validated against the latest schema of the subject specified in
+the field ` + "`subject`" + `.`,
 		Footnotes: `
 ## Examples
 
@@ -85,6 +94,8 @@ dropped.`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("schema", "A schema to apply. Use either this or the `schema_path` field."),
 			docs.FieldCommon("schema_path", "The path of a schema document to apply. Use either this or the `schema` field."),
+			docs.FieldAdvanced("schema_registry", "The label of a [schema registry resource](/docs/configuration/resources#schema-registries) to obtain the schema from. Use either this or the `schema` field.").AtVersion("3.44.0"),
+			docs.FieldAdvanced("subject", "The subject of the schema to obtain from the `schema_registry`.", "users-value").AtVersion("3.44.0"),
 			PartsFieldSpec,
 		},
 	}
@@ -95,17 +106,21 @@ dropped.`,
 // JSONSchemaConfig is a configuration struct containing fields for the
 // jsonschema processor.
 type JSONSchemaConfig struct {
-	Parts      []int  `json:"parts" yaml:"parts"`
-	SchemaPath string `json:"schema_path" yaml:"schema_path"`
-	Schema     string `json:"schema" yaml:"schema"`
+	Parts          []int  `json:"parts" yaml:"parts"`
+	SchemaPath     string `json:"schema_path" yaml:"schema_path"`
+	Schema         string `json:"schema" yaml:"schema"`
+	SchemaRegistry string `json:"schema_registry" yaml:"schema_registry"`
+	Subject        string `json:"subject" yaml:"subject"`
 }
 
 // NewJSONSchemaConfig returns a JSONSchemaConfig with default values.
 func NewJSONSchemaConfig() JSONSchemaConfig {
 	return JSONSchemaConfig{
-		Parts:      []int{},
-		SchemaPath: "",
-		Schema:     "",
+		Parts:          []int{},
+		SchemaPath:     "",
+		Schema:         "",
+		SchemaRegistry: "",
+		Subject:        "",
 	}
 }
 
@@ -118,6 +133,11 @@ type JSONSchema struct {
 	log    log.Modular
 	schema *jsonschema.Schema
 
+	registry       *schemaregistry.Client
+	registryMut    sync.Mutex
+	registryID     int
+	registrySchema *jsonschema.Schema
+
 	mCount     metrics.StatCounter
 	mErrJSONP  metrics.StatCounter
 	mErr       metrics.StatCounter
@@ -130,10 +150,18 @@ func NewJSONSchema(
 	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
 ) (Type, error) {
 	var schema *jsonschema.Schema
+	var registry *schemaregistry.Client
 	var err error
 
 	// load JSONSchema definition
-	if schemaPath := conf.JSONSchema.SchemaPath; schemaPath != "" {
+	if conf.JSONSchema.SchemaRegistry != "" {
+		if conf.JSONSchema.Subject == "" {
+			return nil, fmt.Errorf("a subject must be provided with a schema_registry")
+		}
+		if registry, err = schemaregistry.FromManager(mgr, conf.JSONSchema.SchemaRegistry); err != nil {
+			return nil, fmt.Errorf("failed to obtain schema registry '%v': %v", conf.JSONSchema.SchemaRegistry, err)
+		}
+	} else if schemaPath := conf.JSONSchema.SchemaPath; schemaPath != "" {
 		if !(strings.HasPrefix(schemaPath, "file://") || strings.HasPrefix(schemaPath, "http://")) {
 			return nil, fmt.Errorf("invalid schema_path provided, must start with file:// or http://")
 		}
@@ -148,13 +176,15 @@ func NewJSONSchema(
 			return nil, fmt.Errorf("failed to load JSON schema definition: %v", err)
 		}
 	} else {
-		return nil, fmt.Errorf("either schema or schema_path must be provided")
+		return nil, fmt.Errorf("either schema, schema_path or schema_registry must be provided")
 	}
 
 	return &JSONSchema{
-		stats:  stats,
-		log:    log,
-		schema: schema,
+		conf:     conf.JSONSchema,
+		stats:    stats,
+		log:      log,
+		schema:   schema,
+		registry: registry,
 
 		mCount:     stats.GetCounter("count"),
 		mErrJSONP:  stats.GetCounter("error_json_parse"),
@@ -166,6 +196,34 @@ func NewJSONSchema(
 
 //------------------------------------------------------------------------------
 
+// getSchema returns the schema to validate against, which is the latest schema
+// of the subject when a schema registry is used.
+func (s *JSONSchema) getSchema() (*jsonschema.Schema, error) {
+	if s.registry == nil {
+		return s.schema, nil
+	}
+
+	regSchema, err := s.registry.GetLatestSchema(context.Background(), s.conf.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain schema of subject %v: %v", s.conf.Subject, err)
+	}
+
+	s.registryMut.Lock()
+	defer s.registryMut.Unlock()
+	if s.registrySchema != nil && s.registryID == regSchema.ID {
+		return s.registrySchema, nil
+	}
+	if regSchema.Type != "JSON" {
+		return nil, fmt.Errorf("schema %v has unsupported type: %v", regSchema.ID, regSchema.Type)
+	}
+	schema, err := jsonschema.NewSchema(jsonschema.NewStringLoader(regSchema.Schema))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JSON schema definition: %v", err)
+	}
+	s.registryID, s.registrySchema = regSchema.ID, schema
+	return schema, nil
+}
+
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (s *JSONSchema) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
@@ -180,8 +238,15 @@ func (s *JSONSchema) ProcessMessage(msg types.Message) ([]types.Message, types.R
 			return err
 		}
 
+		schema, err := s.getSchema()
+		if err != nil {
+			s.log.Debugf("Failed to obtain schema: %v\n", err)
+			s.mErr.Incr(1)
+			return err
+		}
+
 		partLoader := jsonschema.NewGoLoader(jsonPart)
-		result, err := s.schema.Validate(partLoader)
+		result, err := schema.Validate(partLoader)
 		if err != nil {
 			s.log.Debugf("Failed to validate json: %v\n", err)
 			s.mErr.Incr(1)
@@ -3,13 +3,19 @@ package processor
 import (
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"sync/atomic"
 	"testing"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/schemaregistry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestJSONSchemaExternalSchemaCheck(t *testing.T) {
@@ -354,3 +360,43 @@ func TestJSONSchemaInvalidSchema(t *testing.T) {
 		t.Error("expected error from loading bad schema")
 	}
 }
+
+func TestJSONSchemaRegistry(t *testing.T) {
+	var version int32 = 1
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/subjects/users-value/versions/latest", r.URL.Path)
+		if atomic.LoadInt32(&version) == 1 {
+			w.Write([]byte(`{"subject":"users-value","id":1,"version":1,"schemaType":"JSON","schema":"{\"properties\":{\"age\":{\"type\":\"integer\"}}}"}`))
+		} else {
+			w.Write([]byte(`{"subject":"users-value","id":2,"version":2,"schemaType":"JSON","schema":"{\"properties\":{\"age\":{\"type\":\"string\"}}}"}`))
+		}
+	}))
+	defer ts.Close()
+
+	regConf := schemaregistry.NewConfig()
+	regConf.URL = ts.URL
+	regConf.SubjectCacheTTL = "0s"
+	c, err := schemaregistry.New(regConf)
+	require.NoError(t, err)
+	mgr := fakeSchemaRegistryMgr{
+		registries: map[string]*schemaregistry.Client{"registry": c},
+	}
+
+	conf := NewConfig()
+	conf.JSONSchema.SchemaRegistry = "registry"
+	conf.JSONSchema.Subject = "users-value"
+
+	proc, err := NewJSONSchema(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, _ := proc.ProcessMessage(message.New([][]byte{[]byte(`{"age":10}`)}))
+	assert.Equal(t, "", GetFail(msgs[0].Get(0)))
+
+	atomic.StoreInt32(&version, 2)
+	msgs, _ = proc.ProcessMessage(message.New([][]byte{[]byte(`{"age":10}`)}))
+	assert.Equal(t, "age invalid type. expected: string, given: integer", GetFail(msgs[0].Get(0)))
+
+	conf.JSONSchema.Subject = ""
+	_, err = NewJSONSchema(conf, mgr, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "a subject must be provided with a schema_registry")
+}
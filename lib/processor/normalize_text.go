@@ -0,0 +1,359 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/gabs/v2"
+	"github.com/opentracing/opentracing-go"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeNormalizeText] = TypeSpec{
+		constructor: NewNormalizeText,
+		Status:      docs.StatusExperimental,
+		Version:     "3.44.0",
+		Categories: []Category{
+			CategoryParsing,
+		},
+		Summary: `
+Prepares text for search ingestion by detecting its language, applying a Unicode normalization form, stripping stopwords and transliterating it to ASCII.`,
+		Description: `
+The text operated on is either the full contents of a message or, when ` + "`field`" + ` is set, a string field of a JSON document. Operations are applied in the following order, and each one is optional:
+
+1. The language of the text is detected, and stored within the metadata key ` + "`language_metadata`" + ` as an ISO 639-1 code, or ` + "`und`" + ` if the language could not be determined.
+2. The text is converted to the Unicode normalization form ` + "`normalization`" + `.
+3. Stopwords of the language are removed, after which words are separated by a single space.
+4. The text is transliterated to ASCII by removing diacritics and replacing letters such as ` + "`ß`" + ` and ` + "`æ`" + ` with their ASCII equivalents. Characters without an equivalent are removed.
+
+### Language Detection
+
+Languages are detected by counting the occurrences of their stopwords within the text, and therefore detection is only reliable for text containing at least a handful of words. The supported languages are ` + "`de`, `en`, `es`, `fr`, `it`, `nl` and `pt`" + `.
+
+When ` + "`language`" + ` is set detection is skipped and stopwords of that language are stripped instead.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Search Ingestion",
+				Summary: `
+Here we normalize the description field of documents before indexing them, storing the detected language so that it can be used to select an analyzer:`,
+				Config: `
+pipeline:
+  processors:
+    - normalize_text:
+        field: description
+        normalization: NFKC
+        transliterate: true
+        strip_stopwords: true
+        language_metadata: language
+`,
+			},
+		},
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("field", "An optional [dot separated path](/docs/configuration/field_paths) of a string field of a JSON document to operate on. When empty the full contents of messages are used."),
+			docs.FieldCommon("normalization", "The Unicode normalization form to apply, or an empty string in order to leave the text as it is.").HasOptions("NFC", "NFD", "NFKC", "NFKD"),
+			docs.FieldCommon("transliterate", "Whether to transliterate the text to ASCII."),
+			docs.FieldCommon("strip_stopwords", "Whether to remove stopwords from the text."),
+			docs.FieldCommon("language", "The ISO 639-1 code of the language of the text. When empty the language is detected.", "en", "de"),
+			docs.FieldCommon("language_metadata", "An optional metadata key to store the detected language within."),
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NormalizeTextConfig contains configuration fields for the NormalizeText
+// processor.
+type NormalizeTextConfig struct {
+	Field            string `json:"field" yaml:"field"`
+	Normalization    string `json:"normalization" yaml:"normalization"`
+	Transliterate    bool   `json:"transliterate" yaml:"transliterate"`
+	StripStopwords   bool   `json:"strip_stopwords" yaml:"strip_stopwords"`
+	Language         string `json:"language" yaml:"language"`
+	LanguageMetadata string `json:"language_metadata" yaml:"language_metadata"`
+}
+
+// NewNormalizeTextConfig returns a NormalizeTextConfig with default values.
+func NewNormalizeTextConfig() NormalizeTextConfig {
+	return NormalizeTextConfig{
+		Field:            "",
+		Normalization:    "NFC",
+		Transliterate:    false,
+		StripStopwords:   false,
+		Language:         "",
+		LanguageMetadata: "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// undeterminedLanguage is the ISO 639-2 code for a language that could not be
+// identified.
+const undeterminedLanguage = "und"
+
+// textStopwords contains the stopwords of each supported language, which are
+// used both for stripping and for detecting languages.
+var textStopwords = map[string]map[string]struct{}{
+	"de": stopwordSet("aber", "als", "am", "an", "auch", "auf", "aus", "bei", "bin", "bis", "das", "dass", "dem", "den", "der", "des", "die", "doch", "du", "durch", "ein", "eine", "einem", "einen", "einer", "er", "es", "für", "hat", "ich", "ihr", "im", "in", "ist", "ja", "mit", "nach", "nicht", "noch", "nur", "oder", "sich", "sie", "sind", "so", "über", "um", "und", "uns", "von", "vor", "war", "was", "wie", "wir", "zu", "zum", "zur"),
+	"en": stopwordSet("a", "about", "all", "an", "and", "are", "as", "at", "be", "been", "but", "by", "for", "from", "had", "has", "have", "he", "her", "his", "i", "if", "in", "into", "is", "it", "its", "my", "no", "not", "of", "on", "or", "our", "she", "so", "that", "the", "their", "them", "there", "they", "this", "to", "was", "we", "were", "what", "which", "will", "with", "would", "you", "your"),
+	"es": stopwordSet("al", "como", "con", "de", "del", "el", "ella", "en", "es", "esta", "este", "fue", "ha", "hay", "la", "las", "le", "lo", "los", "más", "me", "mi", "muy", "no", "nos", "o", "para", "pero", "por", "que", "se", "si", "sin", "son", "su", "sus", "también", "te", "un", "una", "y", "ya", "yo"),
+	"fr": stopwordSet("au", "aux", "avec", "ce", "ces", "dans", "de", "des", "du", "elle", "en", "est", "et", "il", "ils", "je", "la", "le", "les", "leur", "mais", "me", "mes", "mon", "ne", "nous", "on", "ou", "par", "pas", "pour", "qu", "que", "qui", "sa", "se", "ses", "son", "sont", "sur", "ta", "te", "tu", "un", "une", "vous", "été", "être"),
+	"it": stopwordSet("a", "al", "alla", "anche", "che", "chi", "ci", "come", "con", "da", "dei", "del", "della", "di", "e", "è", "gli", "ha", "ho", "il", "in", "io", "la", "le", "lo", "ma", "mi", "nel", "nella", "non", "per", "più", "quando", "questo", "se", "si", "sono", "su", "sua", "suo", "tu", "un", "una", "uno"),
+	"nl": stopwordSet("aan", "al", "als", "bij", "dan", "dat", "de", "die", "dit", "door", "een", "en", "er", "het", "hij", "hoe", "ik", "in", "is", "je", "kan", "maar", "met", "naar", "niet", "nog", "of", "om", "ook", "op", "over", "te", "tot", "uit", "van", "voor", "was", "wat", "we", "wel", "zij", "zijn", "zo"),
+	"pt": stopwordSet("ao", "as", "com", "como", "da", "das", "de", "do", "dos", "e", "ela", "ele", "em", "era", "essa", "esse", "está", "eu", "foi", "já", "mais", "mas", "me", "muito", "na", "não", "nas", "no", "nos", "o", "os", "ou", "para", "pela", "pelo", "por", "que", "se", "sem", "seu", "sua", "são", "também", "um", "uma", "você"),
+}
+
+// textLanguages is the order in which languages are scored, which breaks ties
+// deterministically.
+var textLanguages = []string{"en", "de", "es", "fr", "it", "nl", "pt"}
+
+func stopwordSet(words ...string) map[string]struct{} {
+	set := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		set[w] = struct{}{}
+	}
+	return set
+}
+
+// textTransliterations contains letters that are not decomposed into an ASCII
+// letter and a combining mark by NFKD normalization.
+var textTransliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE",
+	'ø': "o", 'Ø': "O", 'ł': "l", 'Ł': "L", 'đ': "d", 'Đ': "D",
+	'ð': "d", 'Ð': "D", 'þ': "th", 'Þ': "TH", 'ı': "i",
+	'‘': "'", '’': "'", '“': "\"", '”': "\"", '–': "-", '—': "-",
+}
+
+// normalizeTextWord returns a word lower cased and with surrounding
+// punctuation removed, for comparing it against stopwords.
+func normalizeTextWord(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	}))
+}
+
+// detectTextLanguage returns the supported language with the most stopwords
+// occurring within the text.
+func detectTextLanguage(text string) string {
+	scores := map[string]int{}
+	for _, word := range strings.Fields(norm.NFC.String(text)) {
+		word = normalizeTextWord(word)
+		for lang, stopwords := range textStopwords {
+			if _, exists := stopwords[word]; exists {
+				scores[lang]++
+			}
+		}
+	}
+
+	detected, best := undeterminedLanguage, 0
+	for _, lang := range textLanguages {
+		if scores[lang] > best {
+			detected, best = lang, scores[lang]
+		}
+	}
+	return detected
+}
+
+// stripTextStopwords removes the stopwords of a language from text.
+func stripTextStopwords(text string, stopwords map[string]struct{}) string {
+	words := strings.Fields(text)
+	kept := words[:0]
+	for _, word := range words {
+		if _, exists := stopwords[normalizeTextWord(word)]; !exists {
+			kept = append(kept, word)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// transliterateText converts text to ASCII.
+func transliterateText(text string) (string, error) {
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	decomposed, _, err := transform.String(t, text)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, r := range decomposed {
+		if r <= unicode.MaxASCII {
+			b.WriteRune(r)
+		} else if replacement, exists := textTransliterations[r]; exists {
+			b.WriteString(replacement)
+		}
+	}
+	return b.String(), nil
+}
+
+//------------------------------------------------------------------------------
+
+// NormalizeText is a processor that prepares text for search ingestion.
+type NormalizeText struct {
+	conf NormalizeTextConfig
+	form *norm.Form
+
+	log log.Modular
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewNormalizeText returns a NormalizeText processor.
+func NewNormalizeText(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	nConf := conf.NormalizeText
+
+	n := &NormalizeText{
+		conf: nConf,
+		log:  log,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+
+	var form norm.Form
+	switch nConf.Normalization {
+	case "":
+	case "NFC":
+		form = norm.NFC
+	case "NFD":
+		form = norm.NFD
+	case "NFKC":
+		form = norm.NFKC
+	case "NFKD":
+		form = norm.NFKD
+	default:
+		return nil, fmt.Errorf("normalization form not recognised: %v", nConf.Normalization)
+	}
+	if nConf.Normalization != "" {
+		n.form = &form
+	}
+
+	if nConf.Language != "" {
+		if _, exists := textStopwords[nConf.Language]; !exists {
+			return nil, fmt.Errorf("language not supported: %v", nConf.Language)
+		}
+	}
+	if nConf.Normalization == "" && !nConf.Transliterate && !nConf.StripStopwords && nConf.LanguageMetadata == "" {
+		return nil, errors.New("at least one operation must be enabled")
+	}
+	return n, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (n *NormalizeText) normalize(text string) (string, string, error) {
+	lang := n.conf.Language
+	if lang == "" && (n.conf.StripStopwords || n.conf.LanguageMetadata != "") {
+		lang = detectTextLanguage(text)
+	}
+	if n.form != nil {
+		text = n.form.String(text)
+	}
+	if n.conf.StripStopwords {
+		if stopwords, exists := textStopwords[lang]; exists {
+			text = stripTextStopwords(text, stopwords)
+		}
+	}
+	if n.conf.Transliterate {
+		var err error
+		if text, err = transliterateText(text); err != nil {
+			return "", "", err
+		}
+	}
+	return text, lang, nil
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (n *NormalizeText) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	n.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	proc := func(i int, span opentracing.Span, part types.Part) error {
+		if n.conf.Field == "" {
+			res, lang, err := n.normalize(string(part.Get()))
+			if err != nil {
+				n.mErr.Incr(1)
+				n.log.Debugf("Failed to normalize text: %v\n", err)
+				return err
+			}
+			part.Set([]byte(res))
+			if n.conf.LanguageMetadata != "" {
+				part.Metadata().Set(n.conf.LanguageMetadata, lang)
+			}
+			return nil
+		}
+
+		jObj, err := part.JSON()
+		if err == nil {
+			jObj, err = message.CopyJSON(jObj)
+		}
+		if err != nil {
+			n.mErr.Incr(1)
+			n.log.Debugf("Failed to parse message as JSON: %v\n", err)
+			return err
+		}
+
+		gObj := gabs.Wrap(jObj)
+		str, ok := gObj.Path(n.conf.Field).Data().(string)
+		if !ok {
+			n.mErr.Incr(1)
+			return fmt.Errorf("field %v is not a string", n.conf.Field)
+		}
+		res, lang, err := n.normalize(str)
+		if err != nil {
+			n.mErr.Incr(1)
+			n.log.Debugf("Failed to normalize text: %v\n", err)
+			return err
+		}
+		if _, err = gObj.SetP(res, n.conf.Field); err != nil {
+			n.mErr.Incr(1)
+			return err
+		}
+		if n.conf.LanguageMetadata != "" {
+			part.Metadata().Set(n.conf.LanguageMetadata, lang)
+		}
+		return part.SetJSON(gObj.Data())
+	}
+
+	if newMsg.Len() == 0 {
+		return nil, response.NewAck()
+	}
+
+	IteratePartsWithSpan(TypeNormalizeText, nil, newMsg, proc)
+
+	n.mBatchSent.Incr(1)
+	n.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (n *NormalizeText) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (n *NormalizeText) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,117 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectTextLanguage(t *testing.T) {
+	tests := map[string]string{
+		"The quick brown fox jumps over the lazy dog and it was fun":      "en",
+		"Der schnelle braune Fuchs springt über den faulen Hund":          "de",
+		"El rápido zorro marrón salta sobre el perro perezoso y se va":    "es",
+		"Le renard brun rapide saute par-dessus le chien paresseux et il": "fr",
+		"Il veloce cane non è stato visto nella piazza della città":       "it",
+		"De snelle bruine vos springt over de luie hond en het is leuk":   "nl",
+		"A raposa marrom rápida não pula sobre o cão preguiçoso":          "pt",
+		"12345 !!!": "und",
+		"":          "und",
+	}
+
+	for input, exp := range tests {
+		assert.Equal(t, exp, detectTextLanguage(input), input)
+	}
+}
+
+func TestTransliterateText(t *testing.T) {
+	tests := map[string]string{
+		"Crème brûlée":        "Creme brulee",
+		"Straße in Köln":      "Strasse in Koln",
+		"Łódź and Ærøskøbing": "Lodz and AEroskobing",
+		"ﬁnancial ①":          "financial 1",
+		"emoji 🎉 removed":     "emoji  removed",
+	}
+
+	for input, exp := range tests {
+		res, err := transliterateText(input)
+		require.NoError(t, err)
+		assert.Equal(t, exp, res, input)
+	}
+}
+
+func TestNormalizeTextContents(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeNormalizeText
+	conf.NormalizeText.Transliterate = true
+	conf.NormalizeText.StripStopwords = true
+	conf.NormalizeText.LanguageMetadata = "lang"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte("Le café est très bon, et la crème brûlée aussi."),
+		[]byte("The café was closed for the winter."),
+		[]byte("12345"),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 3, msgs[0].Len())
+
+	assert.Equal(t, "cafe tres bon, creme brulee aussi.", string(msgs[0].Get(0).Get()))
+	assert.Equal(t, "fr", msgs[0].Get(0).Metadata().Get("lang"))
+
+	assert.Equal(t, "cafe closed winter.", string(msgs[0].Get(1).Get()))
+	assert.Equal(t, "en", msgs[0].Get(1).Metadata().Get("lang"))
+
+	assert.Equal(t, "12345", string(msgs[0].Get(2).Get()))
+	assert.Equal(t, "und", msgs[0].Get(2).Metadata().Get("lang"))
+}
+
+func TestNormalizeTextField(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeNormalizeText
+	conf.NormalizeText.Field = "doc.title"
+	conf.NormalizeText.Normalization = "NFKC"
+	conf.NormalizeText.StripStopwords = true
+	conf.NormalizeText.Language = "de"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"doc":{"title":"Die ﬁnale Straße der Stadt","id":"foo"}}`),
+		[]byte(`{"doc":{"title":10}}`),
+		[]byte(`not json`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, `{"doc":{"id":"foo","title":"finale Straße Stadt"}}`, string(msgs[0].Get(0).Get()))
+	assert.False(t, HasFailed(msgs[0].Get(0)))
+	assert.Equal(t, "field doc.title is not a string", GetFail(msgs[0].Get(1)))
+	assert.True(t, HasFailed(msgs[0].Get(2)))
+}
+
+func TestNormalizeTextConfigErrors(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeNormalizeText
+	conf.NormalizeText.Normalization = "NFX"
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "normalization form not recognised: NFX")
+
+	conf.NormalizeText.Normalization = "NFC"
+	conf.NormalizeText.Language = "xx"
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "language not supported: xx")
+
+	conf.NormalizeText.Normalization = ""
+	conf.NormalizeText.Language = ""
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "at least one operation must be enabled")
+}
@@ -0,0 +1,412 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeOPA] = TypeSpec{
+		constructor: NewOPA,
+		Status:      docs.StatusExperimental,
+		Version:     "3.44.0",
+		Categories: []Category{
+			CategoryUtility,
+		},
+		Summary: `
+Evaluates messages against [Open Policy Agent](https://www.openpolicyagent.org/) policies written in Rego, and either stores the decision within metadata or removes messages that are denied.`,
+		Description: `
+Policies are loaded from the Rego and data files of ` + "`policy_paths`" + `, which can be files or directories, and from an [OPA bundle](https://www.openpolicyagent.org/docs/latest/management-bundles/) fetched from ` + "`bundle_url`" + ` when it is set. Both can be combined, in which case the policies of the bundle are evaluated alongside those of the files.
+
+The ` + "`query`" + ` is evaluated with the JSON document of each message as the ` + "`input`" + ` document, and the value of the first expression of the first result is the decision. When the query is undefined for a message the decision is ` + "`undefined`" + `.
+
+When ` + "`decision_metadata`" + ` is set the decision is stored within that metadata key, where strings are stored as they are, booleans as ` + "`true`" + ` or ` + "`false`" + ` and any other value as JSON. When ` + "`drop_denied`" + ` is ` + "`true`" + ` messages with a decision other than ` + "`true`" + ` are removed from the batch.
+
+Messages that are not valid JSON, or that cause an error during evaluation, are flagged as having failed and are never removed from the batch.
+
+### Bundle Polling
+
+The bundle is fetched when the processor is created and then every ` + "`bundle_poll_interval`" + `. Requests include the ` + "`ETag`" + ` of the last bundle such that unchanged bundles are not downloaded again. When a fetch fails the previously loaded policies continue to be used, and messages are only flagged as failed when no bundle has been loaded yet.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Compliance Routing",
+				Summary: `
+Here we evaluate orders against a bundle of policies that is refreshed every minute, and route orders that are not allowed to a separate topic:`,
+				Config: `
+pipeline:
+  processors:
+    - opa:
+        bundle_url: https://policies.example.com/bundles/orders.tar.gz
+        bundle_poll_interval: 60s
+        query: data.orders.allow
+        decision_metadata: allowed
+
+output:
+  switch:
+    cases:
+      - check: meta("allowed") == "true"
+        output:
+          kafka:
+            addresses: [ localhost:9092 ]
+            topic: orders
+      - output:
+          kafka:
+            addresses: [ localhost:9092 ]
+            topic: orders_denied
+`,
+			},
+		},
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("policy_paths", "A list of Rego and data files, or directories containing them, to load policies from.", []string{"./policies"}).Array(),
+			docs.FieldCommon("bundle_url", "An optional URL of an OPA bundle to load policies from."),
+			docs.FieldAdvanced("bundle_poll_interval", "The period between fetches of the bundle."),
+			docs.FieldAdvanced("timeout", "The maximum period to wait for the bundle to be fetched."),
+			docs.FieldCommon("query", "The Rego query to evaluate for each message.", "data.orders.allow"),
+			docs.FieldCommon("decision_metadata", "A metadata key to store the decision within, or an empty string to disable."),
+			docs.FieldCommon("drop_denied", "Whether to remove messages from the batch when the decision is not `true`."),
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// OPAConfig contains configuration fields for the OPA processor.
+type OPAConfig struct {
+	PolicyPaths        []string `json:"policy_paths" yaml:"policy_paths"`
+	BundleURL          string   `json:"bundle_url" yaml:"bundle_url"`
+	BundlePollInterval string   `json:"bundle_poll_interval" yaml:"bundle_poll_interval"`
+	Timeout            string   `json:"timeout" yaml:"timeout"`
+	Query              string   `json:"query" yaml:"query"`
+	DecisionMetadata   string   `json:"decision_metadata" yaml:"decision_metadata"`
+	DropDenied         bool     `json:"drop_denied" yaml:"drop_denied"`
+}
+
+// NewOPAConfig returns a OPAConfig with default values.
+func NewOPAConfig() OPAConfig {
+	return OPAConfig{
+		PolicyPaths:        []string{},
+		BundleURL:          "",
+		BundlePollInterval: "60s",
+		Timeout:            "10s",
+		Query:              "",
+		DecisionMetadata:   "opa_decision",
+		DropDenied:         false,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// OPA is a processor that evaluates messages against Rego policies.
+type OPA struct {
+	conf     OPAConfig
+	client   *http.Client
+	interval time.Duration
+
+	queryMut   sync.RWMutex
+	query      *rego.PreparedEvalQuery
+	bundleETag string
+
+	log log.Modular
+
+	mCount        metrics.StatCounter
+	mErr          metrics.StatCounter
+	mDenied       metrics.StatCounter
+	mRefreshSucc  metrics.StatCounter
+	mRefreshError metrics.StatCounter
+	mSent         metrics.StatCounter
+	mBatchSent    metrics.StatCounter
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewOPA returns an OPA processor.
+func NewOPA(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	oConf := conf.OPA
+	if oConf.Query == "" {
+		return nil, errors.New("a query must be specified")
+	}
+	if len(oConf.PolicyPaths) == 0 && oConf.BundleURL == "" {
+		return nil, errors.New("at least one of policy_paths or bundle_url must be specified")
+	}
+
+	o := &OPA{
+		conf:   oConf,
+		client: &http.Client{},
+		log:    log,
+
+		mCount:        stats.GetCounter("count"),
+		mErr:          stats.GetCounter("error"),
+		mDenied:       stats.GetCounter("denied"),
+		mRefreshSucc:  stats.GetCounter("bundle.refresh.success"),
+		mRefreshError: stats.GetCounter("bundle.refresh.error"),
+		mSent:         stats.GetCounter("sent"),
+		mBatchSent:    stats.GetCounter("batch.sent"),
+
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}
+
+	if oConf.BundleURL == "" {
+		close(o.closedChan)
+		query, err := o.prepare(nil)
+		if err != nil {
+			return nil, err
+		}
+		o.query = query
+		return o, nil
+	}
+
+	var err error
+	if o.interval, err = time.ParseDuration(oConf.BundlePollInterval); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle_poll_interval: %v", err)
+	}
+	if o.interval <= 0 {
+		return nil, errors.New("bundle_poll_interval must be greater than zero")
+	}
+	if o.client.Timeout, err = time.ParseDuration(oConf.Timeout); err != nil {
+		return nil, fmt.Errorf("failed to parse timeout: %v", err)
+	}
+
+	o.refresh()
+	go o.loop()
+	return o, nil
+}
+
+//------------------------------------------------------------------------------
+
+// prepare compiles the query along with the policies of the configured paths
+// and an optional bundle.
+func (o *OPA) prepare(b *bundle.Bundle) (*rego.PreparedEvalQuery, error) {
+	opts := []func(*rego.Rego){
+		rego.Query(o.conf.Query),
+	}
+	if len(o.conf.PolicyPaths) > 0 {
+		opts = append(opts, rego.Load(o.conf.PolicyPaths, nil))
+	}
+	if b != nil {
+		opts = append(opts, rego.ParsedBundle(o.conf.BundleURL, b))
+	}
+	query, err := rego.New(opts...).PrepareForEval(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare query: %w", err)
+	}
+	return &query, nil
+}
+
+// fetchBundle downloads the bundle, and returns nil when it has not changed
+// since it was last downloaded.
+func (o *OPA) fetchBundle() (*bundle.Bundle, string, error) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+	go func() {
+		select {
+		case <-o.closeChan:
+			done()
+		case <-ctx.Done():
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", o.conf.BundleURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	o.queryMut.RLock()
+	if o.bundleETag != "" {
+		req.Header.Set("If-None-Match", o.bundleETag)
+	}
+	o.queryMut.RUnlock()
+
+	res, err := o.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotModified {
+		return nil, "", nil
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, "", fmt.Errorf("request returned status: %v", res.StatusCode)
+	}
+
+	b, err := bundle.NewReader(bytes.NewReader(body)).Read()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read bundle: %w", err)
+	}
+	return &b, res.Header.Get("ETag"), nil
+}
+
+func (o *OPA) refresh() {
+	b, etag, err := o.fetchBundle()
+	var query *rego.PreparedEvalQuery
+	if err == nil && b != nil {
+		query, err = o.prepare(b)
+	}
+	if err != nil {
+		o.mRefreshError.Incr(1)
+		o.log.Errorf("Failed to refresh policy bundle: %v\n", err)
+		return
+	}
+	o.mRefreshSucc.Incr(1)
+	if query == nil {
+		return
+	}
+
+	o.queryMut.Lock()
+	o.query = query
+	o.bundleETag = etag
+	o.queryMut.Unlock()
+}
+
+func (o *OPA) loop() {
+	defer close(o.closedChan)
+
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			o.refresh()
+		case <-o.closeChan:
+			return
+		}
+	}
+}
+
+func (o *OPA) getQuery() *rego.PreparedEvalQuery {
+	o.queryMut.RLock()
+	defer o.queryMut.RUnlock()
+	return o.query
+}
+
+//------------------------------------------------------------------------------
+
+// opaDecisionString returns the representation of a decision stored within
+// metadata.
+func opaDecisionString(decision interface{}) string {
+	switch t := decision.(type) {
+	case nil:
+		return "undefined"
+	case string:
+		return t
+	case bool:
+		return strconv.FormatBool(t)
+	}
+	b, err := json.Marshal(decision)
+	if err != nil {
+		return fmt.Sprintf("%v", decision)
+	}
+	return string(b)
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (o *OPA) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	o.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	query := o.getQuery()
+	allowed := make([]bool, newMsg.Len())
+
+	proc := func(i int, span opentracing.Span, part types.Part) error {
+		if query == nil {
+			o.mErr.Incr(1)
+			return errors.New("no policy bundle has been loaded")
+		}
+
+		input, err := part.JSON()
+		if err != nil {
+			o.mErr.Incr(1)
+			o.log.Debugf("Failed to parse message as JSON: %v\n", err)
+			return err
+		}
+
+		rs, err := query.Eval(context.Background(), rego.EvalInput(input))
+		if err != nil {
+			o.mErr.Incr(1)
+			o.log.Debugf("Failed to evaluate policy: %v\n", err)
+			return err
+		}
+
+		var decision interface{}
+		if len(rs) > 0 && len(rs[0].Expressions) > 0 {
+			decision = rs[0].Expressions[0].Value
+		}
+		allowed[i] = decision == true
+		if !allowed[i] {
+			o.mDenied.Incr(1)
+		}
+		if o.conf.DecisionMetadata != "" {
+			part.Metadata().Set(o.conf.DecisionMetadata, opaDecisionString(decision))
+		}
+		return nil
+	}
+
+	IteratePartsWithSpan(TypeOPA, nil, newMsg, proc)
+
+	if o.conf.DropDenied {
+		kept := message.New(nil)
+		_ = newMsg.Iter(func(i int, p types.Part) error {
+			if allowed[i] || HasFailed(p) {
+				kept.Append(p)
+			}
+			return nil
+		})
+		if kept.Len() == 0 {
+			return nil, response.NewAck()
+		}
+		newMsg = kept
+	}
+
+	o.mBatchSent.Incr(1)
+	o.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (o *OPA) CloseAsync() {
+	o.closeOnce.Do(func() {
+		close(o.closeChan)
+	})
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (o *OPA) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-o.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
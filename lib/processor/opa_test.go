@@ -0,0 +1,223 @@
+package processor
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const opaTestPolicy = `package orders
+
+default allow = false
+
+allow {
+	input.amount < 100
+}
+
+reason = "too expensive" {
+	input.amount >= 100
+}
+`
+
+func TestOPAPolicyFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "policy.rego"), []byte(opaTestPolicy), 0644))
+
+	conf := NewConfig()
+	conf.Type = TypeOPA
+	conf.OPA.PolicyPaths = []string{dir}
+	conf.OPA.Query = "data.orders.allow"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer func() {
+		proc.CloseAsync()
+		require.NoError(t, proc.WaitForClose(time.Second))
+	}()
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"amount":10}`),
+		[]byte(`{"amount":200}`),
+		[]byte(`not json`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	out := msgs[0]
+	require.Equal(t, 3, out.Len())
+	assert.Equal(t, "true", out.Get(0).Metadata().Get("opa_decision"))
+	assert.Equal(t, "false", out.Get(1).Metadata().Get("opa_decision"))
+	assert.Equal(t, "", out.Get(2).Metadata().Get("opa_decision"))
+	assert.True(t, HasFailed(out.Get(2)))
+}
+
+func TestOPADropDenied(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "policy.rego"), []byte(opaTestPolicy), 0644))
+
+	conf := NewConfig()
+	conf.Type = TypeOPA
+	conf.OPA.PolicyPaths = []string{filepath.Join(dir, "policy.rego")}
+	conf.OPA.Query = "data.orders.allow"
+	conf.OPA.DecisionMetadata = ""
+	conf.OPA.DropDenied = true
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"amount":10}`),
+		[]byte(`{"amount":200}`),
+		[]byte(`{"amount":20}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 2, msgs[0].Len())
+	assert.Equal(t, `{"amount":10}`, string(msgs[0].Get(0).Get()))
+	assert.Equal(t, `{"amount":20}`, string(msgs[0].Get(1).Get()))
+	assert.Equal(t, "", msgs[0].Get(0).Metadata().Get("opa_decision"))
+
+	msgs, res = proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"amount":200}`),
+	}))
+	assert.Empty(t, msgs)
+	require.NotNil(t, res)
+	assert.NoError(t, res.Error())
+}
+
+func TestOPAUndefinedDecision(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "policy.rego"), []byte(opaTestPolicy), 0644))
+
+	conf := NewConfig()
+	conf.Type = TypeOPA
+	conf.OPA.PolicyPaths = []string{dir}
+	conf.OPA.Query = "data.orders.reason"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"amount":10}`),
+		[]byte(`{"amount":200}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "undefined", msgs[0].Get(0).Metadata().Get("opa_decision"))
+	assert.Equal(t, "too expensive", msgs[0].Get(1).Metadata().Get("opa_decision"))
+}
+
+func opaTestBundle(t *testing.T, policy string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name: "/policy.rego",
+		Mode: 0600,
+		Size: int64(len(policy)),
+	}))
+	_, err := tw.Write([]byte(policy))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func TestOPABundle(t *testing.T) {
+	bundle := opaTestBundle(t, opaTestPolicy)
+
+	var requests, notModified int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(bundle)
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.Type = TypeOPA
+	conf.OPA.BundleURL = ts.URL + "/bundle.tar.gz"
+	conf.OPA.BundlePollInterval = "10ms"
+	conf.OPA.Query = "data.orders.allow"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer func() {
+		proc.CloseAsync()
+		require.NoError(t, proc.WaitForClose(time.Second))
+	}()
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&notModified) > 0
+	}, time.Second, time.Millisecond*10)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"amount":10}`),
+		[]byte(`{"amount":200}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "true", msgs[0].Get(0).Metadata().Get("opa_decision"))
+	assert.Equal(t, "false", msgs[0].Get(1).Metadata().Get("opa_decision"))
+}
+
+func TestOPABundleNotLoaded(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.Type = TypeOPA
+	conf.OPA.BundleURL = ts.URL
+	conf.OPA.Query = "data.orders.allow"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer func() {
+		proc.CloseAsync()
+		require.NoError(t, proc.WaitForClose(time.Second))
+	}()
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"amount":10}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.True(t, HasFailed(msgs[0].Get(0)))
+	assert.Equal(t, "", msgs[0].Get(0).Metadata().Get("opa_decision"))
+}
+
+func TestOPABadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeOPA
+	conf.OPA.Query = "data.orders.allow"
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "at least one of policy_paths or bundle_url must be specified")
+
+	conf.OPA.BundleURL = "http://localhost:4195"
+	conf.OPA.Query = ""
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "a query must be specified")
+}
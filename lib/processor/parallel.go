@@ -1,7 +1,9 @@
 package processor
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -30,11 +32,28 @@ though they were each a batch of one message (similar to the
 processed in parallel.`,
 		Description: `
 The field ` + "`cap`" + `, if greater than zero, caps the maximum number of
-parallel processing threads.`,
+parallel processing threads.
+
+### Partial Failures
+
+A message that fails a child processor is flagged with the error and continues
+through the pipeline alongside the rest of the batch, which allows it to be
+handled using [standard error handling patterns](/docs/configuration/error_handling).
+
+When the field ` + "`error_summary_meta`" + ` is set, a summary of the failures
+of the batch is stored as a JSON object within that metadata key of each
+resulting message. The summary contains the total number of messages processed,
+the number that failed, and the error of each failed message keyed by its index
+within the original batch:
+
+` + "```json" + `
+{"total":5,"failed":1,"errors":{"2":"http request returned unexpected response code (403): 403 Forbidden"}}
+` + "```" + ``,
 		UsesBatches: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("cap", "The maximum number of messages to have processing at a given time."),
 			docs.FieldCommon("processors", "A list of child processors to apply.").Array().HasType(docs.FieldProcessor),
+			docs.FieldAdvanced("error_summary_meta", "An optional metadata key to store a [summary of failed messages](#partial-failures) within for each resulting message. When empty no summary is added.").AtVersion("3.44.0"),
 		},
 	}
 }
@@ -44,15 +63,17 @@ parallel processing threads.`,
 // ParallelConfig is a config struct containing fields for the Parallel
 // processor.
 type ParallelConfig struct {
-	Cap        int      `json:"cap" yaml:"cap"`
-	Processors []Config `json:"processors" yaml:"processors"`
+	Cap              int      `json:"cap" yaml:"cap"`
+	Processors       []Config `json:"processors" yaml:"processors"`
+	ErrorSummaryMeta string   `json:"error_summary_meta" yaml:"error_summary_meta"`
 }
 
 // NewParallelConfig returns a default ParallelConfig.
 func NewParallelConfig() ParallelConfig {
 	return ParallelConfig{
-		Cap:        0,
-		Processors: []Config{},
+		Cap:              0,
+		Processors:       []Config{},
+		ErrorSummaryMeta: "",
 	}
 }
 
@@ -61,8 +82,9 @@ func NewParallelConfig() ParallelConfig {
 // Parallel is a processor that applies a list of child processors to each
 // message of a batch individually.
 type Parallel struct {
-	children []types.Processor
-	cap      int
+	children    []types.Processor
+	cap         int
+	summaryMeta string
 
 	log log.Modular
 
@@ -86,9 +108,10 @@ func NewParallel(
 		children = append(children, proc)
 	}
 	return &Parallel{
-		children: children,
-		cap:      conf.Parallel.Cap,
-		log:      log,
+		children:    children,
+		cap:         conf.Parallel.Cap,
+		summaryMeta: conf.Parallel.ErrorSummaryMeta,
+		log:         log,
 
 		mCount:     stats.GetCounter("count"),
 		mErr:       stats.GetCounter("error"),
@@ -99,6 +122,15 @@ func NewParallel(
 
 //------------------------------------------------------------------------------
 
+// parallelErrorSummary describes the messages of a batch that failed
+// processing, where errors are keyed by the index of the message within the
+// original batch.
+type parallelErrorSummary struct {
+	Total  int               `json:"total"`
+	Failed int               `json:"failed"`
+	Errors map[string]string `json:"errors"`
+}
+
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (p *Parallel) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
@@ -121,10 +153,14 @@ func (p *Parallel) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 	wg := sync.WaitGroup{}
 	wg.Add(max)
 
+	// Errors are recorded by index so that no locking is required, a message
+	// that had already failed before reaching this processor isn't recorded.
+	partErrs := make([]string, msg.Len())
 	var unAcks int32
 	for i := 0; i < max; i++ {
 		go func() {
 			for index := range reqChan {
+				failedBefore := HasFailed(resultMsgs[index].Get(0))
 				resMsgs, res := ExecuteAll(p.children, resultMsgs[index])
 				if res != nil && res.SkipAck() {
 					atomic.AddInt32(&unAcks, 1)
@@ -132,6 +168,9 @@ func (p *Parallel) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 				resultParts := []types.Part{}
 				for _, m := range resMsgs {
 					m.Iter(func(i int, p types.Part) error {
+						if !failedBefore && len(partErrs[index]) == 0 {
+							partErrs[index] = GetFail(p)
+						}
 						resultParts = append(resultParts, p)
 						return nil
 					})
@@ -147,6 +186,21 @@ func (p *Parallel) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 	close(reqChan)
 	wg.Wait()
 
+	summary := parallelErrorSummary{
+		Total:  msg.Len(),
+		Errors: map[string]string{},
+	}
+	for i, errStr := range partErrs {
+		if len(errStr) > 0 {
+			summary.Failed++
+			summary.Errors[strconv.Itoa(i)] = errStr
+		}
+	}
+	if summary.Failed > 0 {
+		p.mErr.Incr(int64(summary.Failed))
+		p.log.Debugf("%v of %v messages failed processing\n", summary.Failed, summary.Total)
+	}
+
 	resMsg := message.New(nil)
 	for _, m := range resultMsgs {
 		m.Iter(func(i int, p types.Part) error {
@@ -158,6 +212,18 @@ func (p *Parallel) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 		return nil, response.NewUnack()
 	}
 
+	if len(p.summaryMeta) > 0 {
+		summaryBytes, err := json.Marshal(summary)
+		if err != nil {
+			p.log.Errorf("Failed to marshal error summary: %v\n", err)
+		} else {
+			resMsg.Iter(func(i int, part types.Part) error {
+				part.Metadata().Set(p.summaryMeta, string(summaryBytes))
+				return nil
+			})
+		}
+	}
+
 	p.mBatchSent.Incr(1)
 	p.mSent.Incr(int64(resMsg.Len()))
 
@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -187,3 +188,60 @@ func TestParallelCapped(t *testing.T) {
 		t.Errorf("Wrong result: %v != %v", act, exp)
 	}
 }
+
+func TestParallelErrorSummary(t *testing.T) {
+	blobConf := NewConfig()
+	blobConf.Type = TypeBloblang
+	blobConf.Bloblang = `root = if content().string().has_prefix("bad") { throw("nope") } else { content().uppercase() }`
+
+	conf := NewConfig()
+	conf.Parallel.Processors = []Config{blobConf}
+	conf.Parallel.Cap = 2
+	conf.Parallel.ErrorSummaryMeta = "summary"
+
+	h, err := NewParallel(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inMsg := message.New([][]byte{
+		[]byte("foo"),
+		[]byte("bad1"),
+		[]byte("bar"),
+		[]byte("bad2"),
+		[]byte("baz"),
+	})
+	FlagErr(inMsg.Get(4), errors.New("already failed"))
+
+	msgs, res := h.ProcessMessage(inMsg)
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Wrong count of result batches: %v", len(msgs))
+	}
+
+	exp := []string{"FOO", "bad1", "BAR", "bad2", "BAZ"}
+	if act := message.GetAllBytes(msgs[0]); len(act) != len(exp) {
+		t.Fatalf("Wrong result count: %v != %v", len(act), len(exp))
+	} else {
+		for i, e := range exp {
+			if string(act[i]) != e {
+				t.Errorf("Wrong result at %v: %s != %v", i, act[i], e)
+			}
+		}
+	}
+
+	for i, failed := range []bool{false, true, false, true, true} {
+		if act := HasFailed(msgs[0].Get(i)); act != failed {
+			t.Errorf("Wrong failed flag at %v: %v != %v", i, act, failed)
+		}
+	}
+
+	expSummary := `{"total":5,"failed":2,"errors":{"1":"failed assignment (line 1): nope","3":"failed assignment (line 1): nope"}}`
+	for i := 0; i < msgs[0].Len(); i++ {
+		if act := msgs[0].Get(i).Metadata().Get("summary"); act != expSummary {
+			t.Errorf("Wrong summary at %v: %v != %v", i, act, expSummary)
+		}
+	}
+}
@@ -3,11 +3,10 @@ package processor
 import (
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/protobuf"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -17,7 +16,6 @@ import (
 	"github.com/golang/protobuf/proto"
 
 	"github.com/jhump/protoreflect/desc"
-	"github.com/jhump/protoreflect/desc/protoparse"
 	"github.com/jhump/protoreflect/dynamic"
 	"github.com/opentracing/opentracing-go"
 )
@@ -237,46 +235,12 @@ func loadDescriptor(message string, importPaths []string) (*desc.MessageDescript
 		return nil, errors.New("message field must not be empty")
 	}
 
-	var parser protoparse.Parser
-	if len(importPaths) == 0 {
-		importPaths = []string{"."}
-	} else {
-		parser.ImportPaths = importPaths
-	}
-
-	var files []string
-	for _, importPath := range importPaths {
-		if err := filepath.Walk(importPath, func(path string, info os.FileInfo, ferr error) error {
-			if ferr != nil || info.IsDir() {
-				return ferr
-			}
-			if filepath.Ext(info.Name()) == ".proto" {
-				rPath, ferr := filepath.Rel(importPath, path)
-				if ferr != nil {
-					return fmt.Errorf("failed to get relative path: %v", ferr)
-				}
-				files = append(files, rPath)
-			}
-			return nil
-		}); err != nil {
-			return nil, err
-		}
-	}
-
-	fds, err := parser.ParseFiles(files...)
+	fds, err := protobuf.LoadImportPaths(importPaths)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse .proto file: %v", err)
-	}
-	if len(fds) == 0 {
-		return nil, fmt.Errorf("no .proto files were found in the paths '%v'", importPaths)
+		return nil, err
 	}
 
-	var msg *desc.MessageDescriptor
-	for _, d := range fds {
-		if msg = d.FindMessage(message); msg != nil {
-			break
-		}
-	}
+	msg := protobuf.FindMessage(fds, message)
 	if msg == nil {
 		err = fmt.Errorf("unable to find message '%v' definition within '%v'", message, importPaths)
 	}
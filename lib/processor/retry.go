@@ -0,0 +1,309 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/interop"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/tracing"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/retries"
+	"github.com/cenkalti/backoff/v4"
+	opentracinglog "github.com/opentracing/opentracing-go/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeRetry] = TypeSpec{
+		constructor: NewRetry,
+		Status:      docs.StatusExperimental,
+		Version:     "3.44.0",
+		Categories: []Category{
+			CategoryComposition,
+		},
+		Summary: `
+Executes child processors on a message batch and, when any message of the resulting batch has failed, executes them again on the original batch with an exponential backoff between attempts.`,
+		Description: `
+Each attempt executes the child processors on a fresh copy of the batch that was received, and therefore the children must be safe to run more than once for the same messages.
+
+The field ` + "`retry_if`" + ` can be used to only retry on errors that are likely to be transient. It is a [Bloblang query](/docs/guides/bloblang/about/) that is executed against each failed message of an attempt, where the error can be obtained with the function ` + "[`error()`](/docs/guides/bloblang/functions#error)" + `, and when it resolves to ` + "`true`" + ` for any of them the batch is retried. When left empty any error results in a retry.
+
+Once the retries are exhausted, or the query resolves to ` + "`false`" + ` for all failed messages, the result of the last attempt is passed on, including its failed messages, which can be handled with [error handling patterns](/docs/configuration/error_handling).
+
+### Metadata
+
+The resulting messages have the metadata field ` + "`retry_count`" + ` set to the number of retries that were attempted before they were produced.
+
+### Backoff
+
+The period between attempts starts at ` + "`backoff.initial_interval`" + ` and grows exponentially up to ` + "`backoff.max_interval`" + `. The field ` + "`jitter`" + ` randomises each period by the given factor in either direction, which prevents many messages that failed together from being retried at the same time.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Retrying Throttled Requests",
+				Summary: `
+Here we enrich documents with the result of an HTTP request, retrying requests that fail with a rate limit or server error status, whilst other errors are passed on immediately:`,
+				Config: `
+pipeline:
+  processors:
+    - retry:
+        max_retries: 5
+        backoff:
+          initial_interval: 1s
+          max_interval: 30s
+        retry_if: 'error().contains("429") || error().re_match("5[0-9]{2}")'
+        processors:
+          - branch:
+              request_map: 'root.id = this.id'
+              processors:
+                - http:
+                    url: http://example.com/enrich
+                    verb: POST
+              result_map: 'root.enriched = this'
+`,
+			},
+		},
+		FieldSpecs: retries.FieldSpecs().Add(
+			docs.FieldAdvanced("jitter", "A factor between 0 and 1 by which each backoff period is randomised, where zero disables jitter."),
+			docs.FieldCommon(
+				"retry_if",
+				"An optional [Bloblang query](/docs/guides/bloblang/about/) executed on each failed message that should return a boolean value indicating whether the error is retriable. When empty all errors are retried.",
+				`error().contains("timeout")`,
+				`meta("http_status_code").number() >= 500`,
+			).HasDefault("").Linter(docs.LintBloblangMapping),
+			docs.FieldCommon("processors", "A list of child processors to execute on each attempt.").Array().HasType(docs.FieldProcessor),
+		),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// RetryConfig is a config struct containing fields for the Retry processor.
+type RetryConfig struct {
+	retries.Config `json:",inline" yaml:",inline"`
+	Jitter         float64  `json:"jitter" yaml:"jitter"`
+	RetryIf        string   `json:"retry_if" yaml:"retry_if"`
+	Processors     []Config `json:"processors" yaml:"processors"`
+}
+
+// NewRetryConfig returns a default RetryConfig.
+func NewRetryConfig() RetryConfig {
+	rConf := retries.NewConfig()
+	rConf.MaxRetries = 3
+	rConf.Backoff.InitialInterval = "500ms"
+	rConf.Backoff.MaxInterval = "10s"
+	rConf.Backoff.MaxElapsedTime = "1m"
+	return RetryConfig{
+		Config:     rConf,
+		Jitter:     0.5,
+		RetryIf:    "",
+		Processors: []Config{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Retry is a processor that executes child processors on a message batch and
+// retries them with a backoff for as long as the result contains failed
+// messages.
+type Retry struct {
+	maxRetries uint64
+	boffCtor   func() backoff.BackOff
+	retryIf    *mapping.Executor
+	children   []types.Processor
+
+	log log.Modular
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+
+	mCount      metrics.StatCounter
+	mRetry      metrics.StatCounter
+	mExhausted  metrics.StatCounter
+	mQueryError metrics.StatCounter
+	mSent       metrics.StatCounter
+	mBatchSent  metrics.StatCounter
+}
+
+// NewRetry returns a Retry processor.
+func NewRetry(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	if conf.Retry.Jitter < 0 || conf.Retry.Jitter > 1 {
+		return nil, errors.New("jitter must be between 0 and 1")
+	}
+
+	// Max retries are counted by the processor itself so that the backoff
+	// remains an exponential backoff with a configurable jitter.
+	rConf := conf.Retry.Config
+	rConf.MaxRetries = 0
+	ctor, err := rConf.GetCtor()
+	if err != nil {
+		return nil, err
+	}
+	jitter := conf.Retry.Jitter
+	boffCtor := func() backoff.BackOff {
+		boff := ctor()
+		if eBoff, ok := boff.(*backoff.ExponentialBackOff); ok {
+			eBoff.RandomizationFactor = jitter
+			eBoff.Reset()
+		}
+		return boff
+	}
+
+	var retryIf *mapping.Executor
+	if len(conf.Retry.RetryIf) > 0 {
+		if retryIf, err = bloblang.NewMapping("", conf.Retry.RetryIf); err != nil {
+			return nil, fmt.Errorf("failed to parse retry_if query: %w", err)
+		}
+	}
+
+	var children []types.Processor
+	for i, pconf := range conf.Retry.Processors {
+		pMgr, pLog, pStats := interop.LabelChild(fmt.Sprintf("retry.%v", i), mgr, log, stats)
+		var proc Type
+		if proc, err = New(pconf, pMgr, pLog, pStats); err != nil {
+			return nil, err
+		}
+		children = append(children, proc)
+	}
+
+	return &Retry{
+		maxRetries: conf.Retry.MaxRetries,
+		boffCtor:   boffCtor,
+		retryIf:    retryIf,
+		children:   children,
+
+		log: log,
+
+		closeChan: make(chan struct{}),
+
+		mCount:      stats.GetCounter("count"),
+		mRetry:      stats.GetCounter("retry"),
+		mExhausted:  stats.GetCounter("exhausted"),
+		mQueryError: stats.GetCounter("retry_if.error"),
+		mSent:       stats.GetCounter("sent"),
+		mBatchSent:  stats.GetCounter("batch.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// shouldRetry returns true if any failed message of the batches has an error
+// that is considered retriable.
+func (r *Retry) shouldRetry(msgs []types.Message) bool {
+	for _, m := range msgs {
+		for i := 0; i < m.Len(); i++ {
+			if !HasFailed(m.Get(i)) {
+				continue
+			}
+			if r.retryIf == nil {
+				return true
+			}
+			retry, err := r.retryIf.QueryPart(i, m)
+			if err != nil {
+				r.mQueryError.Incr(1)
+				r.log.Errorf("Query failed for retry_if: %v\n", err)
+				continue
+			}
+			if retry {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (r *Retry) ProcessMessage(msg types.Message) (msgs []types.Message, res types.Response) {
+	r.mCount.Incr(1)
+
+	spans := tracing.CreateChildSpans(TypeRetry, msg)
+	boff := r.boffCtor()
+
+	var retries uint64
+	for {
+		msgs, res = ExecuteAll(r.children, msg.Copy())
+		if !r.shouldRetry(msgs) {
+			break
+		}
+		if r.maxRetries > 0 && retries >= r.maxRetries {
+			r.mExhausted.Incr(1)
+			r.log.Debugln("Reached max retries count")
+			break
+		}
+		nextSleep := boff.NextBackOff()
+		if nextSleep == backoff.Stop {
+			r.mExhausted.Incr(1)
+			r.log.Debugln("Reached max elapsed time of retries")
+			break
+		}
+
+		r.mRetry.Incr(1)
+		r.log.Traceln("Retrying")
+		for _, s := range spans {
+			s.LogFields(opentracinglog.Event("retry"))
+		}
+
+		select {
+		case <-time.After(nextSleep):
+		case <-r.closeChan:
+			for _, s := range spans {
+				s.Finish()
+			}
+			return nil, response.NewError(types.ErrTypeClosed)
+		}
+		retries++
+	}
+
+	for _, s := range spans {
+		s.SetTag("retries", retries)
+		s.Finish()
+	}
+
+	retryCount := strconv.FormatUint(retries, 10)
+	totalParts := 0
+	for _, m := range msgs {
+		m.Iter(func(i int, p types.Part) error {
+			p.Metadata().Set("retry_count", retryCount)
+			return nil
+		})
+		totalParts += m.Len()
+	}
+	r.mBatchSent.Incr(int64(len(msgs)))
+	r.mSent.Incr(int64(totalParts))
+	return
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (r *Retry) CloseAsync() {
+	r.closeOnce.Do(func() {
+		close(r.closeChan)
+	})
+	for _, p := range r.children {
+		p.CloseAsync()
+	}
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (r *Retry) WaitForClose(timeout time.Duration) error {
+	stopBy := time.Now().Add(timeout)
+	for _, p := range r.children {
+		if err := p.WaitForClose(time.Until(stopBy)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,124 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func retryTestConf(mapping string) Config {
+	conf := NewConfig()
+	conf.Type = TypeRetry
+	conf.Retry.Backoff.InitialInterval = "1ms"
+	conf.Retry.Backoff.MaxInterval = "1ms"
+
+	procConf := NewConfig()
+	procConf.Type = TypeBloblang
+	procConf.Bloblang = BloblangConfig(mapping)
+	conf.Retry.Processors = append(conf.Retry.Processors, procConf)
+	return conf
+}
+
+func TestRetryErrs(t *testing.T) {
+	conf := retryTestConf("root = this")
+	conf.Retry.Jitter = 2
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "jitter must be between 0 and 1")
+
+	conf.Retry.Jitter = 0.5
+	conf.Retry.Backoff.InitialInterval = "nope"
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid backoff initial interval")
+}
+
+func TestRetryUntilSuccess(t *testing.T) {
+	conf := retryTestConf(`root = if count("retry_test_1") < 3 { throw("transient") } else { content().uppercase() }`)
+
+	stats := metrics.NewLocal()
+	r, err := New(conf, nil, log.Noop(), stats)
+	require.NoError(t, err)
+
+	input := message.New([][]byte{[]byte("foo")})
+	msgs, res := r.ProcessMessage(input)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, "FOO", string(msgs[0].Get(0).Get()))
+	assert.False(t, HasFailed(msgs[0].Get(0)))
+	assert.Equal(t, "2", msgs[0].Get(0).Metadata().Get("retry_count"))
+	assert.Equal(t, "foo", string(input.Get(0).Get()))
+
+	assert.Equal(t, int64(2), stats.GetCounters()["retry"])
+	assert.Equal(t, int64(0), stats.GetCounters()["exhausted"])
+}
+
+func TestRetryExhausted(t *testing.T) {
+	conf := retryTestConf(`root = throw("nope")`)
+	conf.Retry.MaxRetries = 2
+
+	stats := metrics.NewLocal()
+	r, err := New(conf, nil, log.Noop(), stats)
+	require.NoError(t, err)
+
+	msgs, res := r.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.True(t, HasFailed(msgs[0].Get(0)))
+	assert.Equal(t, "2", msgs[0].Get(0).Metadata().Get("retry_count"))
+	assert.Equal(t, int64(2), stats.GetCounters()["retry"])
+	assert.Equal(t, int64(1), stats.GetCounters()["exhausted"])
+}
+
+func TestRetryIf(t *testing.T) {
+	conf := retryTestConf(`root = if content() == "foo" { throw("permanent") } else { throw("transient") }`)
+	conf.Retry.MaxRetries = 2
+	conf.Retry.RetryIf = `error().contains("transient")`
+
+	stats := metrics.NewLocal()
+	r, err := New(conf, nil, log.Noop(), stats)
+	require.NoError(t, err)
+
+	msgs, res := r.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.True(t, HasFailed(msgs[0].Get(0)))
+	assert.Equal(t, "0", msgs[0].Get(0).Metadata().Get("retry_count"))
+	assert.Equal(t, int64(0), stats.GetCounters()["retry"])
+
+	msgs, res = r.ProcessMessage(message.New([][]byte{[]byte("foo"), []byte("bar")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "2", msgs[0].Get(0).Metadata().Get("retry_count"))
+	assert.Equal(t, int64(2), stats.GetCounters()["retry"])
+}
+
+func TestRetryClose(t *testing.T) {
+	conf := retryTestConf(`root = throw("nope")`)
+	conf.Retry.MaxRetries = 0
+	conf.Retry.Backoff.InitialInterval = "1h"
+	conf.Retry.Backoff.MaxInterval = "1h"
+	conf.Retry.Backoff.MaxElapsedTime = "0s"
+
+	r, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	go func() {
+		<-time.After(time.Millisecond * 50)
+		r.CloseAsync()
+	}()
+
+	msgs, res := r.ProcessMessage(message.New([][]byte{[]byte("foo")}))
+	assert.Empty(t, msgs)
+	require.NotNil(t, res)
+	assert.Error(t, res.Error())
+
+	require.NoError(t, r.WaitForClose(time.Second))
+}
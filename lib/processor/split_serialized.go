@@ -0,0 +1,288 @@
+package processor
+
+import (
+	"compress/gzip"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeSplitSerialized] = TypeSpec{
+		constructor: NewSplitSerialized,
+		Status:      docs.StatusExperimental,
+		Version:     "3.44.0",
+		Categories: []Category{
+			CategoryUtility,
+		},
+		Summary: `
+Breaks message batches into smaller batches such that the size of each batch, once serialized and optionally compressed the way a sink would send it, does not exceed a limit in bytes.`,
+		Description: `
+Sinks such as AWS SQS, AWS Kinesis and many HTTP APIs reject requests with a payload beyond a certain size. The size of a payload depends on how a batch is serialized into a request and whether it is compressed, and therefore limiting batches by their message count, or by the sum of their raw message sizes with the ` + "[`split` processor](/docs/components/processors/split)" + `, either wastes capacity or risks exceeding the limit.
+
+This processor measures batches by serializing them with the archive ` + "`format`" + ` and compressing the result with the ` + "`compression`" + ` algorithm, matching the behaviour of the ` + "[`archive`](/docs/components/processors/archive) and [`compress`](/docs/components/processors/compress)" + ` processors, and adds ` + "`message_overhead`" + ` bytes for each message in order to account for per-message costs of a request such as metadata attributes or partition keys. Messages keep their original order, and each resulting batch is filled as far as possible without its measured size exceeding ` + "`max_bytes`" + `.
+
+This processor should be placed where batches are processed as discrete messages, such as within the ` + "`pipeline`" + ` section, as the processors of an output ` + "`batching`" + ` policy merge their results back into a single batch.
+
+A single message that exceeds ` + "`max_bytes`" + ` by itself is sent as a batch of one and a warning is logged. If a batch cannot be serialized, for example when messages are not valid JSON with the format ` + "`json_array`" + `, the batch is not split and its messages are flagged as having failed, allowing you to [error handle them](/docs/configuration/error_handling).`,
+		UsesBatches: true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("max_bytes", "The maximum size in bytes of a serialized batch."),
+			docs.FieldCommon("format", "The [archive format](/docs/components/processors/archive#formats) used to serialize batches when measuring them.").HasOptions("binary", "lines", "json_array", "concatenate"),
+			docs.FieldCommon("compression", "An optional compression algorithm applied to serialized batches when measuring them.").HasOptions("none", "gzip", "zlib", "flate", "snappy", "zstd"),
+			docs.FieldAdvanced("level", "The compression level to use, which should match the level used by the sink."),
+			docs.FieldAdvanced("message_overhead", "A number of bytes added to the size of a batch for each message it contains."),
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title:   "Gzipped HTTP Payloads",
+				Summary: "Here we consume batches of JSON documents from Kafka and send them as gzipped arrays to an HTTP API that rejects request bodies larger than 1MB:",
+				Config: `
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ events ]
+    consumer_group: benthos_bulk
+    batching:
+      count: 5000
+      period: 1s
+
+pipeline:
+  processors:
+    - split_serialized:
+        max_bytes: 1000000
+        format: json_array
+        compression: gzip
+    - archive:
+        format: json_array
+    - compress:
+        algorithm: gzip
+
+output:
+  http_client:
+    url: http://example.com/bulk
+    verb: POST
+    headers:
+      Content-Encoding: gzip
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SplitSerializedConfig contains configuration fields for the SplitSerialized
+// processor.
+type SplitSerializedConfig struct {
+	MaxBytes        int    `json:"max_bytes" yaml:"max_bytes"`
+	Format          string `json:"format" yaml:"format"`
+	Compression     string `json:"compression" yaml:"compression"`
+	Level           int    `json:"level" yaml:"level"`
+	MessageOverhead int    `json:"message_overhead" yaml:"message_overhead"`
+}
+
+// NewSplitSerializedConfig returns a SplitSerializedConfig with default values.
+func NewSplitSerializedConfig() SplitSerializedConfig {
+	return SplitSerializedConfig{
+		MaxBytes:        1000000,
+		Format:          "binary",
+		Compression:     "none",
+		Level:           gzip.DefaultCompression,
+		MessageOverhead: 0,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SplitSerialized is a processor that splits batches such that each resulting
+// batch does not exceed a size once serialized.
+type SplitSerialized struct {
+	conf    SplitSerializedConfig
+	archive archiveFunc
+	comp    compressFunc
+
+	log   log.Modular
+	stats metrics.Type
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mDropped   metrics.StatCounter
+	mOversized metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewSplitSerialized returns a SplitSerialized processor.
+func NewSplitSerialized(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	if conf.SplitSerialized.MaxBytes <= 0 {
+		return nil, fmt.Errorf("max_bytes must be greater than zero, received %v", conf.SplitSerialized.MaxBytes)
+	}
+	if conf.SplitSerialized.MessageOverhead < 0 {
+		return nil, fmt.Errorf("message_overhead must not be negative, received %v", conf.SplitSerialized.MessageOverhead)
+	}
+
+	var archiver archiveFunc
+	switch conf.SplitSerialized.Format {
+	case "binary", "lines", "json_array", "concatenate":
+		archiver, _ = strToArchiver(conf.SplitSerialized.Format)
+	default:
+		return nil, fmt.Errorf("archive format not supported: %v", conf.SplitSerialized.Format)
+	}
+
+	var comp compressFunc
+	if conf.SplitSerialized.Compression != "none" {
+		var err error
+		if comp, err = strToCompressor(conf.SplitSerialized.Compression, conf.SplitSerialized.Level, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return &SplitSerialized{
+		conf:    conf.SplitSerialized,
+		archive: archiver,
+		comp:    comp,
+
+		log:   log,
+		stats: stats,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mDropped:   stats.GetCounter("dropped"),
+		mOversized: stats.GetCounter("oversized"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (s *SplitSerialized) serializedSize(parts []types.Part) (int, error) {
+	msg := message.New(nil)
+	msg.SetAll(parts)
+
+	part, err := s.archive(nil, msg)
+	if err != nil {
+		return 0, err
+	}
+	b := part.Get()
+	if s.comp != nil {
+		if b, err = s.comp(s.conf.Level, b); err != nil {
+			return 0, err
+		}
+	}
+	return len(b) + len(parts)*s.conf.MessageOverhead, nil
+}
+
+func (s *SplitSerialized) fits(parts []types.Part) (bool, error) {
+	size, err := s.serializedSize(parts)
+	if err != nil {
+		return false, err
+	}
+	return size <= s.conf.MaxBytes, nil
+}
+
+// nextBatchLen returns the number of parts from the beginning of a slice that
+// fit within a single batch. The serialized size of a batch is not strictly
+// proportional to its number of messages when compressed, therefore the
+// largest fitting batch is searched for by doubling and then bisecting, and
+// only a batch that has been measured to fit is ever returned.
+func (s *SplitSerialized) nextBatchLen(parts []types.Part) (int, error) {
+	size, err := s.serializedSize(parts[:1])
+	if err != nil {
+		return 0, err
+	}
+	if size > s.conf.MaxBytes {
+		s.mOversized.Incr(1)
+		s.log.Warnf("A single message exceeds the maximum serialized batch size of '%v', actual size: '%v'", s.conf.MaxBytes, size)
+		return 1, nil
+	}
+
+	lo, hi := 1, len(parts)+1
+	for n := 2; n < hi; n *= 2 {
+		ok, err := s.fits(parts[:n])
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			hi = n
+			break
+		}
+		lo = n
+	}
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		ok, err := s.fits(parts[:mid])
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (s *SplitSerialized) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	s.mCount.Incr(1)
+
+	if msg.Len() == 0 {
+		s.mDropped.Incr(1)
+		return nil, response.NewAck()
+	}
+
+	parts := make([]types.Part, msg.Len())
+	msg.Iter(func(i int, p types.Part) error {
+		parts[i] = p
+		return nil
+	})
+
+	msgs := []types.Message{}
+	for remaining := parts; len(remaining) > 0; {
+		n, err := s.nextBatchLen(remaining)
+		if err != nil {
+			s.mErr.Incr(1)
+			s.log.Debugf("Failed to serialize batch: %v\n", err)
+			err = fmt.Errorf("failed to serialize batch: %w", err)
+			msg.Iter(func(i int, p types.Part) error {
+				FlagErr(p, err)
+				return nil
+			})
+			s.mBatchSent.Incr(1)
+			s.mSent.Incr(int64(msg.Len()))
+			return []types.Message{msg}, nil
+		}
+
+		nextMsg := message.New(nil)
+		nextMsg.SetAll(remaining[:n:n])
+		msgs = append(msgs, nextMsg)
+		remaining = remaining[n:]
+	}
+
+	s.mBatchSent.Incr(int64(len(msgs)))
+	s.mSent.Incr(int64(msg.Len()))
+	return msgs, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (s *SplitSerialized) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (s *SplitSerialized) WaitForClose(timeout time.Duration) error {
+	return nil
+}
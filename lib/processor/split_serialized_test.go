@@ -0,0 +1,167 @@
+package processor
+
+import (
+	"compress/gzip"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func splitSerializedContents(msgs []types.Message) [][]string {
+	var batches [][]string
+	for _, m := range msgs {
+		var batch []string
+		for _, b := range message.GetAllBytes(m) {
+			batch = append(batch, string(b))
+		}
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+func TestSplitSerializedFormats(t *testing.T) {
+	tests := map[string]struct {
+		format   string
+		maxBytes int
+		overhead int
+		input    []string
+		output   [][]string
+	}{
+		"lines": {
+			format:   "lines",
+			maxBytes: 14,
+			input:    []string{"aaaa", "bbbb", "cccc", "dddd", "eeee", "ffff", "gggg"},
+			output: [][]string{
+				{"aaaa", "bbbb", "cccc"},
+				{"dddd", "eeee", "ffff"},
+				{"gggg"},
+			},
+		},
+		"concatenate": {
+			format:   "concatenate",
+			maxBytes: 8,
+			input:    []string{"aaaa", "bbbb", "cccc"},
+			output: [][]string{
+				{"aaaa", "bbbb"},
+				{"cccc"},
+			},
+		},
+		"json array with overhead": {
+			format:   "json_array",
+			maxBytes: 40,
+			overhead: 10,
+			input:    []string{`{"a":1}`, `{"a":2}`, `{"a":3}`, `{"a":4}`, `{"a":5}`},
+			output: [][]string{
+				{`{"a":1}`, `{"a":2}`},
+				{`{"a":3}`, `{"a":4}`},
+				{`{"a":5}`},
+			},
+		},
+		"oversized message": {
+			format:   "lines",
+			maxBytes: 3,
+			input:    []string{"a", "foobar", "b", "c"},
+			output: [][]string{
+				{"a"},
+				{"foobar"},
+				{"b", "c"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewConfig()
+			conf.Type = TypeSplitSerialized
+			conf.SplitSerialized.Format = test.format
+			conf.SplitSerialized.MaxBytes = test.maxBytes
+			conf.SplitSerialized.MessageOverhead = test.overhead
+
+			proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			var input [][]byte
+			for _, s := range test.input {
+				input = append(input, []byte(s))
+			}
+			msgs, res := proc.ProcessMessage(message.New(input))
+			require.Nil(t, res)
+			assert.Equal(t, test.output, splitSerializedContents(msgs))
+		})
+	}
+}
+
+func TestSplitSerializedCompressed(t *testing.T) {
+	conf := NewConfig()
+	conf.SplitSerialized.MaxBytes = 300
+	conf.SplitSerialized.Format = "lines"
+	conf.SplitSerialized.Compression = "gzip"
+
+	proc, err := NewSplitSerialized(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	var input [][]byte
+	var exp []string
+	for i := 0; i < 200; i++ {
+		doc := fmt.Sprintf(`{"id":%v,"name":"user-%v","hash":"%x"}`, i, i*7919, i*104729)
+		input = append(input, []byte(doc))
+		exp = append(exp, doc)
+	}
+
+	msgs, res := proc.ProcessMessage(message.New(input))
+	require.Nil(t, res)
+	require.Greater(t, len(msgs), 1)
+
+	var act []string
+	for i, batch := range splitSerializedContents(msgs) {
+		compressed, err := gzipCompress(gzip.DefaultCompression, []byte(strings.Join(batch, "\n")))
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(compressed), 300, i)
+		act = append(act, batch...)
+	}
+	assert.Equal(t, exp, act)
+}
+
+func TestSplitSerializedBadJSON(t *testing.T) {
+	conf := NewConfig()
+	conf.SplitSerialized.Format = "json_array"
+
+	proc, err := NewSplitSerialized(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"a":1}`),
+		[]byte(`not json`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 2, msgs[0].Len())
+	for i := 0; i < 2; i++ {
+		assert.True(t, HasFailed(msgs[0].Get(i)))
+	}
+}
+
+func TestSplitSerializedBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.SplitSerialized.MaxBytes = 0
+	_, err := NewSplitSerialized(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "max_bytes must be greater than zero, received 0")
+
+	conf = NewConfig()
+	conf.SplitSerialized.Format = "tar"
+	_, err = NewSplitSerialized(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "archive format not supported: tar")
+
+	conf = NewConfig()
+	conf.SplitSerialized.Compression = "lz4"
+	_, err = NewSplitSerialized(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "compression type not recognised: lz4")
+}
@@ -8,10 +8,13 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/sqlpool"
 	"github.com/ory/dockertest/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -466,3 +469,53 @@ func testSQLMySQLDeprecated(t *testing.T, dsn string) {
 		t.Fatalf("Wrong result: %s != %s", act, exp)
 	}
 }
+
+type fakeSQLPoolMgr struct {
+	types.DudMgr
+	pools map[string]*sqlpool.Pool
+}
+
+func (f fakeSQLPoolMgr) GetSQLPool(name string) (*sqlpool.Pool, error) {
+	if p, exists := f.pools[name]; exists {
+		return p, nil
+	}
+	return nil, fmt.Errorf("sql resource %v not found", name)
+}
+
+func TestSQLResource(t *testing.T) {
+	poolConf := sqlpool.NewConfig()
+	poolConf.Driver = "clickhouse"
+	poolConf.DataSourceName = "tcp://localhost:1/"
+	poolConf.HealthCheckPeriod = ""
+
+	pool, err := sqlpool.New(poolConf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pool.CloseAsync()
+	})
+
+	mgr := fakeSQLPoolMgr{pools: map[string]*sqlpool.Pool{"foo": pool}}
+
+	conf := NewConfig()
+	conf.Type = TypeSQL
+	conf.SQL.Resource = "foo"
+	conf.SQL.Query = "INSERT INTO footable (foo) VALUES (?);"
+
+	// The driver of the resource is used, which does not prepare inserts.
+	s, err := NewSQL(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	s.CloseAsync()
+	require.NoError(t, s.WaitForClose(time.Second))
+
+	// Closing the processor must not close the shared pool.
+	assert.NotEqual(t, "sql: database is closed", pool.DB().Ping().Error())
+
+	conf.SQL.Resource = "bar"
+	_, err = NewSQL(conf, mgr, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "failed to obtain sql resource 'bar': sql resource bar not found")
+
+	conf.SQL.DSN = "foo"
+	_, err = NewSQL(conf, mgr, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "a resource cannot be used with deprecated SQL features (using field 'dsn')")
+}
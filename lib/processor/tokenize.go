@@ -0,0 +1,443 @@
+package processor
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+	"unicode"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/sqlpool"
+	"github.com/Jeffail/gabs/v2"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeTokenize] = TypeSpec{
+		constructor: NewTokenize,
+		Status:      docs.StatusExperimental,
+		Version:     "3.44.0",
+		Categories: []Category{
+			CategoryIntegration,
+		},
+		Summary: `
+Replaces sensitive string fields of JSON documents with format preserving tokens, storing the mapping between tokens and their original values within a vault in order to allow them to be detokenized later.`,
+		Description: `
+When the ` + "`operator`" + ` is ` + "`tokenize`" + ` each digit of a field value is replaced with a random digit and each letter with a random letter of the same case, whilst all other characters as well as the first ` + "`preserve_prefix`" + ` and last ` + "`preserve_suffix`" + ` characters are left unchanged. A value is always replaced with the same token, which allows tokenized values to be joined and deduplicated without exposing them.
+
+When the ` + "`operator`" + ` is ` + "`detokenize`" + ` the tokens of the fields are replaced with their original values as obtained from the vault.
+
+Fields that do not exist within a document are ignored, and fields that are not strings, as well as tokens that cannot be found in the vault, result in the message being flagged as having failed, allowing you to [error handle them](/docs/configuration/error_handling).
+
+## Vaults
+
+Exactly one of the fields ` + "`cache`" + ` or ` + "`sql.resource`" + ` must be set in order to choose where tokens are stored. Only this processor and trusted detokenizing pipelines should have access to the vault.
+
+When using a [cache resource](/docs/components/caches/about) two keys are stored for each value: ` + "`t:<token>`" + ` with the original value, and ` + "`v:<hash>`" + ` with the token, where the hash is a SHA-256 digest of the value. The cache must be persistent and must not expire keys, otherwise tokens cannot be detokenized.
+
+When using a [SQL resource](/docs/configuration/resources#sql-connection-pools) tokens are stored within the table ` + "`sql.table`" + `, which must exist with unique constraints on both the ` + "`token`" + ` and ` + "`value_hash`" + ` columns:
+
+` + "```sql" + `
+CREATE TABLE tokens (
+  token VARCHAR(255) NOT NULL UNIQUE,
+  value_hash CHAR(64) NOT NULL UNIQUE,
+  value TEXT NOT NULL
+);
+` + "```" + ``,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Card Numbers",
+				Summary: `
+Here we replace card numbers with tokens that preserve their format as well as their last four digits, storing the tokens within a SQL database:`,
+				Config: `
+pipeline:
+  processors:
+    - tokenize:
+        operator: tokenize
+        fields: [ payment.card_number ]
+        preserve_suffix: 4
+        sql:
+          resource: token_vault
+          table: tokens
+
+sql_resources:
+  - label: token_vault
+    driver: mysql
+    data_source_name: vault:${VAULT_PASSWORD}@tcp(localhost:3306)/vault
+`,
+			},
+		},
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("operator", "The operation to perform.").HasOptions("tokenize", "detokenize"),
+			docs.FieldCommon("fields", "A list of [dot separated paths](/docs/configuration/field_paths) of fields to operate on.", []string{"card_number", "customer.ssn"}).Array(),
+			docs.FieldCommon("preserve_prefix", "The number of characters at the start of values to leave unchanged when tokenizing."),
+			docs.FieldCommon("preserve_suffix", "The number of characters at the end of values to leave unchanged when tokenizing."),
+			docs.FieldCommon("cache", "The label of a [cache resource](/docs/components/caches/about) to use as the vault."),
+			docs.FieldCommon("sql", "Configure a SQL table to use as the vault.").WithChildren(
+				docs.FieldCommon("resource", "The label of a [SQL resource](/docs/configuration/resources#sql-connection-pools) to use."),
+				docs.FieldCommon("table", "The table to store tokens within."),
+			),
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// TokenizeSQLConfig contains configuration fields for the SQL vault of the
+// Tokenize processor.
+type TokenizeSQLConfig struct {
+	Resource string `json:"resource" yaml:"resource"`
+	Table    string `json:"table" yaml:"table"`
+}
+
+// TokenizeConfig contains configuration fields for the Tokenize processor.
+type TokenizeConfig struct {
+	Operator       string            `json:"operator" yaml:"operator"`
+	Fields         []string          `json:"fields" yaml:"fields"`
+	PreservePrefix int               `json:"preserve_prefix" yaml:"preserve_prefix"`
+	PreserveSuffix int               `json:"preserve_suffix" yaml:"preserve_suffix"`
+	Cache          string            `json:"cache" yaml:"cache"`
+	SQL            TokenizeSQLConfig `json:"sql" yaml:"sql"`
+}
+
+// NewTokenizeConfig returns a TokenizeConfig with default values.
+func NewTokenizeConfig() TokenizeConfig {
+	return TokenizeConfig{
+		Operator:       "tokenize",
+		Fields:         []string{},
+		PreservePrefix: 0,
+		PreserveSuffix: 0,
+		Cache:          "",
+		SQL: TokenizeSQLConfig{
+			Resource: "",
+			Table:    "tokens",
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var errTokenNotFound = errors.New("token not found in vault")
+
+// tokenVault stores the mapping between tokens and their values.
+type tokenVault interface {
+	// tokenFor returns the existing token of a value, or stores the value
+	// against a new token obtained from newToken.
+	tokenFor(value string, newToken func() (string, error)) (string, error)
+
+	// valueFor returns the value of a token.
+	valueFor(token string) (string, error)
+}
+
+func tokenValueHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// maxTokenCollisions is the number of tokens generated for a value before
+// giving up, where collisions are only likely when the values are short.
+const maxTokenCollisions = 10
+
+//------------------------------------------------------------------------------
+
+type cacheTokenVault struct {
+	cache types.Cache
+}
+
+func (c *cacheTokenVault) tokenFor(value string, newToken func() (string, error)) (string, error) {
+	valueKey := "v:" + tokenValueHash(value)
+	if token, err := c.cache.Get(valueKey); err == nil {
+		return string(token), nil
+	} else if err != types.ErrKeyNotFound {
+		return "", err
+	}
+
+	for i := 0; i < maxTokenCollisions; i++ {
+		token, err := newToken()
+		if err != nil {
+			return "", err
+		}
+		if err = c.cache.Add("t:"+token, []byte(value)); err == types.ErrKeyAlreadyExists {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+
+		if err = c.cache.Add(valueKey, []byte(token)); err == types.ErrKeyAlreadyExists {
+			// The value was tokenized concurrently, in which case we use the
+			// token that was stored first.
+			existing, err := c.cache.Get(valueKey)
+			if err != nil {
+				return "", err
+			}
+			return string(existing), nil
+		} else if err != nil {
+			return "", err
+		}
+		return token, nil
+	}
+	return "", errors.New("failed to generate a unique token")
+}
+
+func (c *cacheTokenVault) valueFor(token string) (string, error) {
+	value, err := c.cache.Get("t:" + token)
+	if err == types.ErrKeyNotFound {
+		return "", errTokenNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+//------------------------------------------------------------------------------
+
+type sqlTokenVault struct {
+	db *sql.DB
+
+	selectToken string
+	selectValue string
+	insert      string
+}
+
+func newSQLTokenVault(pool *sqlpool.Pool, table string) *sqlTokenVault {
+	placeholders := []string{"?", "?", "?"}
+	if pool.Driver() == "postgres" {
+		placeholders = []string{"$1", "$2", "$3"}
+	}
+	return &sqlTokenVault{
+		db:          pool.DB(),
+		selectToken: fmt.Sprintf("SELECT token FROM %v WHERE value_hash = %v", table, placeholders[0]),
+		selectValue: fmt.Sprintf("SELECT value FROM %v WHERE token = %v", table, placeholders[0]),
+		insert:      fmt.Sprintf("INSERT INTO %v (token, value_hash, value) VALUES (%v, %v, %v)", table, placeholders[0], placeholders[1], placeholders[2]),
+	}
+}
+
+func (s *sqlTokenVault) tokenFor(value string, newToken func() (string, error)) (string, error) {
+	hash := tokenValueHash(value)
+
+	var token string
+	err := s.db.QueryRow(s.selectToken, hash).Scan(&token)
+	if err == nil {
+		return token, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", err
+	}
+
+	for i := 0; i < maxTokenCollisions; i++ {
+		if token, err = newToken(); err != nil {
+			return "", err
+		}
+		if _, err = s.db.Exec(s.insert, token, hash, value); err == nil {
+			return token, nil
+		}
+
+		// The insert failed due to either the value being tokenized
+		// concurrently, a token collision or the database being unavailable.
+		var existing string
+		if serr := s.db.QueryRow(s.selectToken, hash).Scan(&existing); serr == nil {
+			return existing, nil
+		} else if serr != sql.ErrNoRows {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("failed to store token: %w", err)
+}
+
+func (s *sqlTokenVault) valueFor(token string) (string, error) {
+	var value string
+	err := s.db.QueryRow(s.selectValue, token).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", errTokenNotFound
+	}
+	return value, err
+}
+
+//------------------------------------------------------------------------------
+
+func randomTokenRune(from, to rune) (rune, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(to-from)+1))
+	if err != nil {
+		return 0, err
+	}
+	return from + rune(n.Int64()), nil
+}
+
+// formatPreservingToken generates a random token with the same format as a
+// value, where digits are replaced with digits and letters with letters of the
+// same case.
+func formatPreservingToken(value string, preservePrefix, preserveSuffix int) (string, error) {
+	runes := []rune(value)
+	for i, r := range runes {
+		if i < preservePrefix || i >= len(runes)-preserveSuffix {
+			continue
+		}
+		var err error
+		switch {
+		case unicode.IsDigit(r):
+			runes[i], err = randomTokenRune('0', '9')
+		case unicode.IsUpper(r):
+			runes[i], err = randomTokenRune('A', 'Z')
+		case unicode.IsLower(r):
+			runes[i], err = randomTokenRune('a', 'z')
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return string(runes), nil
+}
+
+//------------------------------------------------------------------------------
+
+// Tokenize is a processor that replaces fields with tokens stored in a vault,
+// or replaces tokens with their original values.
+type Tokenize struct {
+	conf  TokenizeConfig
+	vault tokenVault
+
+	log log.Modular
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewTokenize returns a Tokenize processor.
+func NewTokenize(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	tConf := conf.Tokenize
+	if tConf.Operator != "tokenize" && tConf.Operator != "detokenize" {
+		return nil, fmt.Errorf("operator not recognised: %v", tConf.Operator)
+	}
+	if len(tConf.Fields) == 0 {
+		return nil, errors.New("at least one field must be specified")
+	}
+	if tConf.PreservePrefix < 0 || tConf.PreserveSuffix < 0 {
+		return nil, errors.New("preserve_prefix and preserve_suffix must not be negative")
+	}
+
+	t := &Tokenize{
+		conf: tConf,
+		log:  log,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+
+	switch {
+	case tConf.Cache != "" && tConf.SQL.Resource != "":
+		return nil, errors.New("cannot specify both a cache and a sql resource")
+	case tConf.Cache != "":
+		c, err := mgr.GetCache(tConf.Cache)
+		if err != nil {
+			return nil, err
+		}
+		t.vault = &cacheTokenVault{cache: c}
+	case tConf.SQL.Resource != "":
+		if tConf.SQL.Table == "" {
+			return nil, errors.New("a sql table must be specified")
+		}
+		pool, err := sqlpool.FromManager(mgr, tConf.SQL.Resource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain sql resource '%v': %v", tConf.SQL.Resource, err)
+		}
+		t.vault = newSQLTokenVault(pool, tConf.SQL.Table)
+	default:
+		return nil, errors.New("either a cache or a sql resource must be specified")
+	}
+	return t, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (t *Tokenize) transform(value string) (string, error) {
+	if t.conf.Operator == "detokenize" {
+		return t.vault.valueFor(value)
+	}
+	return t.vault.tokenFor(value, func() (string, error) {
+		return formatPreservingToken(value, t.conf.PreservePrefix, t.conf.PreserveSuffix)
+	})
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (t *Tokenize) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	t.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	proc := func(i int, span opentracing.Span, part types.Part) error {
+		jObj, err := part.JSON()
+		if err == nil {
+			jObj, err = message.CopyJSON(jObj)
+		}
+		if err != nil {
+			t.mErr.Incr(1)
+			t.log.Debugf("Failed to parse message as JSON: %v\n", err)
+			return err
+		}
+
+		gObj := gabs.Wrap(jObj)
+		for _, path := range t.conf.Fields {
+			target := gObj.Path(path)
+			if target.Data() == nil {
+				continue
+			}
+			str, ok := target.Data().(string)
+			if !ok {
+				t.mErr.Incr(1)
+				return fmt.Errorf("field %v is not a string", path)
+			}
+			res, err := t.transform(str)
+			if err != nil {
+				t.mErr.Incr(1)
+				t.log.Errorf("Failed to %v field %v: %v\n", t.conf.Operator, path, err)
+				return fmt.Errorf("failed to %v field %v: %w", t.conf.Operator, path, err)
+			}
+			if _, err = gObj.SetP(res, path); err != nil {
+				t.mErr.Incr(1)
+				return err
+			}
+		}
+		return part.SetJSON(gObj.Data())
+	}
+
+	if newMsg.Len() == 0 {
+		return nil, response.NewAck()
+	}
+
+	IteratePartsWithSpan(TypeTokenize, nil, newMsg, proc)
+
+	t.mBatchSent.Incr(1)
+	t.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (t *Tokenize) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (t *Tokenize) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,110 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatPreservingToken(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		token, err := formatPreservingToken("4111-1111-1111-1111", 0, 4)
+		require.NoError(t, err)
+		assert.Regexp(t, `^\d{4}-\d{4}-\d{4}-1111$`, token)
+
+		token, err = formatPreservingToken("Foo Bar-42", 1, 0)
+		require.NoError(t, err)
+		assert.Regexp(t, `^F[a-z]{2} [A-Z][a-z]{2}-\d{2}$`, token)
+	}
+
+	token, err := formatPreservingToken("foo", 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", token)
+}
+
+func TestTokenizeCacheRoundTrip(t *testing.T) {
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	mgr := &fakeMgr{
+		caches: map[string]types.Cache{
+			"vault": memCache,
+		},
+	}
+
+	conf := NewConfig()
+	conf.Type = TypeTokenize
+	conf.Tokenize.Fields = []string{"card", "customer.ssn", "missing"}
+	conf.Tokenize.PreserveSuffix = 4
+	conf.Tokenize.Cache = "vault"
+
+	tokenizer, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf.Tokenize.Operator = "detokenize"
+	detokenizer, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	input := []byte(`{"card":"4111111111111111","customer":{"name":"foo","ssn":"123-45-6789"}}`)
+	msgs, res := tokenizer.ProcessMessage(message.New([][]byte{input, input}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	first, err := msgs[0].Get(0).JSON()
+	require.NoError(t, err)
+	second, err := msgs[0].Get(1).JSON()
+	require.NoError(t, err)
+
+	// The same values always result in the same tokens.
+	assert.Equal(t, first, second)
+
+	doc := first.(map[string]interface{})
+	assert.Regexp(t, `^\d{12}1111$`, doc["card"])
+	assert.NotEqual(t, "4111111111111111", doc["card"])
+	assert.Regexp(t, `^\d{3}-\d{2}-6789$`, doc["customer"].(map[string]interface{})["ssn"])
+	assert.Equal(t, "foo", doc["customer"].(map[string]interface{})["name"])
+
+	value, err := memCache.Get("t:" + doc["card"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, "4111111111111111", string(value))
+
+	msgs, res = detokenizer.ProcessMessage(msgs[0])
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.JSONEq(t, string(input), string(msgs[0].Get(0).Get()))
+
+	msgs, res = detokenizer.ProcessMessage(message.New([][]byte{
+		[]byte(`{"card":"0000000000000000"}`),
+		[]byte(`{"card":10}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "failed to detokenize field card: token not found in vault", GetFail(msgs[0].Get(0)))
+	assert.Equal(t, "field card is not a string", GetFail(msgs[0].Get(1)))
+}
+
+func TestTokenizeBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeTokenize
+
+	_, err := New(conf, &fakeMgr{}, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "at least one field must be specified")
+
+	conf.Tokenize.Fields = []string{"foo"}
+	_, err = New(conf, &fakeMgr{}, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "either a cache or a sql resource must be specified")
+
+	conf.Tokenize.Cache = "foo"
+	conf.Tokenize.SQL.Resource = "bar"
+	_, err = New(conf, &fakeMgr{}, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "cannot specify both a cache and a sql resource")
+
+	conf.Tokenize.Operator = "nope"
+	_, err = New(conf, &fakeMgr{}, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "operator not recognised: nope")
+}
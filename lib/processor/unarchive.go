@@ -4,9 +4,15 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
@@ -15,6 +21,8 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message/tracing"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/bodgit/sevenzip"
+	"github.com/klauspost/compress/zstd"
 	olog "github.com/opentracing/opentracing-go/log"
 )
 
@@ -35,13 +43,19 @@ the batch. Messages that are selected but fail to unarchive (invalid format)
 will remain unchanged in the message batch but will be flagged as having failed,
 allowing you to [error handle them](/docs/configuration/error_handling).
 
-For the unarchive formats that contain file information (tar, zip), a metadata
-field is added to each message called ` + "`archive_filename`" + ` with the
-extracted filename.`,
+For the unarchive formats that contain file information (tar, zip, 7z, multipart),
+a metadata field is added to each message called ` + "`archive_filename`" + `
+with the extracted filename. For these formats the field ` + "`include`" + ` can
+be used in order to only extract files with a path that matches any of a list
+of glob patterns.`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("format", "The unarchive [format](#formats) to use.").HasOptions(
-				"tar", "zip", "binary", "lines", "json_documents", "json_array", "json_map",
+				"tar", "zip", "7z", "multipart", "binary", "lines", "json_documents", "json_array", "json_map", "zstd_seekable",
 			),
+			docs.FieldAdvanced(
+				"include", "An optional list of glob patterns, where only files with a path matching any of the patterns are extracted. Only applies to formats that contain file information.",
+				[]string{"*.json", "data/*.csv"},
+			).Array().AtVersion("3.44.0"),
 			PartsFieldSpec,
 		},
 		Footnotes: `
@@ -55,6 +69,20 @@ Extract messages from a unix standard tape archive.
 
 Extract messages from a zip file.
 
+### ` + "`7z`" + `
+
+Extract messages from a 7z archive. Directories are skipped and encrypted
+archives are not supported.
+
+### ` + "`multipart`" + `
+
+Extract messages from the parts of a MIME multipart body. The boundary is
+obtained from the metadata field ` + "`Content-Type`" + ` or ` + "`content_type`" + `
+when present, and otherwise from the first line of the body. Each message has
+the metadata field ` + "`archive_filename`" + ` set to the filename of the part,
+or the form name when the part has no filename, and ` + "`archive_content_type`" + `
+set to the content type of the part.
+
 ### ` + "`binary`" + `
 
 Extract messages from a binary blob format consisting of:
@@ -83,7 +111,12 @@ own message.
 Attempt to parse the message as a JSON map and for each element of the map
 expands its contents into a new message. A metadata field is added to each
 message called ` + "`archive_key`" + ` with the relevant key from the top-level
-map.`,
+map.
+
+### ` + "`zstd_seekable`" + `
+
+Extract messages from the frames of a file following the [zstd seekable format](https://github.com/facebook/zstd/blob/dev/contrib/seekable_format/zstd_seekable_compression_format.md),
+where each frame is decompressed into its own message.`,
 	}
 }
 
@@ -91,16 +124,18 @@ map.`,
 
 // UnarchiveConfig contains configuration fields for the Unarchive processor.
 type UnarchiveConfig struct {
-	Format string `json:"format" yaml:"format"`
-	Parts  []int  `json:"parts" yaml:"parts"`
+	Format  string   `json:"format" yaml:"format"`
+	Include []string `json:"include" yaml:"include"`
+	Parts   []int    `json:"parts" yaml:"parts"`
 }
 
 // NewUnarchiveConfig returns a UnarchiveConfig with default values.
 func NewUnarchiveConfig() UnarchiveConfig {
 	return UnarchiveConfig{
 		// TODO: V4 change this default
-		Format: "binary",
-		Parts:  []int{},
+		Format:  "binary",
+		Include: []string{},
+		Parts:   []int{},
 	}
 }
 
@@ -108,7 +143,30 @@ func NewUnarchiveConfig() UnarchiveConfig {
 
 type unarchiveFunc func(part types.Part) ([]types.Part, error)
 
-func tarUnarchive(part types.Part) ([]types.Part, error) {
+// pathFilter returns true if an archived file with a given path should be
+// extracted.
+type pathFilter func(name string) bool
+
+func newPathFilter(patterns []string) (pathFilter, error) {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("failed to parse include pattern '%v': %v", p, err)
+		}
+	}
+	return func(name string) bool {
+		if len(patterns) == 0 {
+			return true
+		}
+		for _, p := range patterns {
+			if matched, _ := path.Match(p, name); matched {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func tarUnarchive(part types.Part, include pathFilter) ([]types.Part, error) {
 	buf := bytes.NewBuffer(part.Get())
 	tr := tar.NewReader(buf)
 
@@ -124,6 +182,9 @@ func tarUnarchive(part types.Part) ([]types.Part, error) {
 		if err != nil {
 			return nil, err
 		}
+		if !include(h.Name) {
+			continue
+		}
 
 		newPartBuf := bytes.Buffer{}
 		if _, err = newPartBuf.ReadFrom(tr); err != nil {
@@ -139,7 +200,7 @@ func tarUnarchive(part types.Part) ([]types.Part, error) {
 	return newParts, nil
 }
 
-func zipUnarchive(part types.Part) ([]types.Part, error) {
+func zipUnarchive(part types.Part, include pathFilter) ([]types.Part, error) {
 	buf := bytes.NewReader(part.Get())
 	zr, err := zip.NewReader(buf, int64(buf.Len()))
 	if err != nil {
@@ -150,6 +211,9 @@ func zipUnarchive(part types.Part) ([]types.Part, error) {
 
 	// Iterate through the files in the archive.
 	for _, f := range zr.File {
+		if !include(f.Name) {
+			continue
+		}
 		fr, err := f.Open()
 		if err != nil {
 			return nil, err
@@ -169,6 +233,107 @@ func zipUnarchive(part types.Part) ([]types.Part, error) {
 	return newParts, nil
 }
 
+func sevenZipUnarchive(part types.Part, include pathFilter) ([]types.Part, error) {
+	buf := bytes.NewReader(part.Get())
+	zr, err := sevenzip.NewReader(buf, int64(buf.Len()))
+	if err != nil {
+		return nil, err
+	}
+
+	var newParts []types.Part
+
+	// Iterate through the files in the archive.
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !include(f.Name) {
+			continue
+		}
+		fr, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		newPartBuf := bytes.Buffer{}
+		_, err = newPartBuf.ReadFrom(fr)
+		fr.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		newPart := part.Copy()
+		newPart.Set(newPartBuf.Bytes())
+		newPart.Metadata().Set("archive_filename", f.Name)
+		newParts = append(newParts, newPart)
+	}
+
+	return newParts, nil
+}
+
+func multipartBoundary(part types.Part) (string, error) {
+	for _, k := range []string{"Content-Type", "content_type"} {
+		v := part.Metadata().Get(k)
+		if v == "" {
+			continue
+		}
+		if mediaType, params, err := mime.ParseMediaType(v); err == nil && strings.HasPrefix(mediaType, "multipart/") {
+			if boundary := params["boundary"]; boundary != "" {
+				return boundary, nil
+			}
+		}
+	}
+
+	b := part.Get()
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		b = b[:i]
+	}
+	b = bytes.TrimSpace(b)
+	if !bytes.HasPrefix(b, []byte("--")) || len(b) == 2 {
+		return "", errors.New("failed to find multipart boundary")
+	}
+	return string(b[2:]), nil
+}
+
+func multipartUnarchive(part types.Part, include pathFilter) ([]types.Part, error) {
+	boundary, err := multipartBoundary(part)
+	if err != nil {
+		return nil, err
+	}
+	mr := multipart.NewReader(bytes.NewReader(part.Get()), boundary)
+
+	var newParts []types.Part
+
+	// Iterate through the parts of the body.
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := p.FileName()
+		if name == "" {
+			name = p.FormName()
+		}
+		if !include(name) {
+			continue
+		}
+
+		newPartBuf := bytes.Buffer{}
+		if _, err = newPartBuf.ReadFrom(p); err != nil {
+			return nil, err
+		}
+
+		newPart := part.Copy()
+		newPart.Set(newPartBuf.Bytes())
+		newPart.Metadata().Set("archive_filename", name)
+		newPart.Metadata().Set("archive_content_type", p.Header.Get("Content-Type"))
+		newParts = append(newParts, newPart)
+	}
+
+	return newParts, nil
+}
+
 func binaryUnarchive(part types.Part) ([]types.Part, error) {
 	msg, err := message.FromBytes(part.Get())
 	if err != nil {
@@ -262,12 +427,72 @@ func jsonMapUnarchive(part types.Part) ([]types.Part, error) {
 	return parts, nil
 }
 
-func strToUnarchiver(str string) (unarchiveFunc, error) {
+func zstdSeekableUnarchive(part types.Part) ([]types.Part, error) {
+	b := part.Get()
+	if len(b) < 17 || binary.LittleEndian.Uint32(b[len(b)-4:]) != zstdSeekableMagic {
+		return nil, errors.New("failed to find zstd seek table")
+	}
+
+	footer := b[len(b)-9:]
+	if footer[4]&0x80 != 0 {
+		return nil, errors.New("zstd seek tables with checksums are not supported")
+	}
+	frames := int(binary.LittleEndian.Uint32(footer[:4]))
+	tableSize := frames*8 + 9
+	if len(b) < tableSize+8 {
+		return nil, errors.New("zstd seek table exceeds the size of the message")
+	}
+	table := b[len(b)-tableSize : len(b)-9]
+	header := b[len(b)-tableSize-8 : len(b)-tableSize]
+	if binary.LittleEndian.Uint32(header[:4]) != zstdSkippableMagic ||
+		int(binary.LittleEndian.Uint32(header[4:])) != tableSize {
+		return nil, errors.New("failed to parse zstd seek table header")
+	}
+
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+
+	data := b[:len(b)-tableSize-8]
+	parts := make([]types.Part, frames)
+	for i := 0; i < frames; i++ {
+		compSize := int(binary.LittleEndian.Uint32(table[i*8:]))
+		if compSize > len(data) {
+			return nil, fmt.Errorf("frame %v exceeds the size of the message", i)
+		}
+		decompressed, err := dec.DecodeAll(data[:compSize], nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress frame %v: %v", i, err)
+		}
+		data = data[compSize:]
+
+		newPart := part.Copy()
+		newPart.Set(decompressed)
+		parts[i] = newPart
+	}
+	return parts, nil
+}
+
+func strToUnarchiver(str string, include pathFilter) (unarchiveFunc, error) {
 	switch str {
 	case "tar":
-		return tarUnarchive, nil
+		return func(part types.Part) ([]types.Part, error) {
+			return tarUnarchive(part, include)
+		}, nil
 	case "zip":
-		return zipUnarchive, nil
+		return func(part types.Part) ([]types.Part, error) {
+			return zipUnarchive(part, include)
+		}, nil
+	case "7z":
+		return func(part types.Part) ([]types.Part, error) {
+			return sevenZipUnarchive(part, include)
+		}, nil
+	case "multipart":
+		return func(part types.Part) ([]types.Part, error) {
+			return multipartUnarchive(part, include)
+		}, nil
 	case "binary":
 		return binaryUnarchive, nil
 	case "lines":
@@ -278,6 +503,8 @@ func strToUnarchiver(str string) (unarchiveFunc, error) {
 		return jsonArrayUnarchive, nil
 	case "json_map":
 		return jsonMapUnarchive, nil
+	case "zstd_seekable":
+		return zstdSeekableUnarchive, nil
 	}
 	return nil, fmt.Errorf("archive format not recognised: %v", str)
 }
@@ -305,7 +532,11 @@ type Unarchive struct {
 func NewUnarchive(
 	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
 ) (Type, error) {
-	dcor, err := strToUnarchiver(conf.Unarchive.Format)
+	include, err := newPathFilter(conf.Unarchive.Include)
+	if err != nil {
+		return nil, err
+	}
+	dcor, err := strToUnarchiver(conf.Unarchive.Format, include)
 	if err != nil {
 		return nil, err
 	}
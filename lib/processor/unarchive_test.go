@@ -4,13 +4,17 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"bytes"
+	"encoding/base64"
 	"fmt"
+	"mime/multipart"
 	"reflect"
 	"testing"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUnarchiveBadAlgo(t *testing.T) {
@@ -351,6 +355,33 @@ func TestUnarchiveBinary(t *testing.T) {
 	}
 }
 
+func TestUnarchiveZstdSeekable(t *testing.T) {
+	conf := NewConfig()
+	conf.Archive.Format = "zstd_seekable"
+	conf.Unarchive.Format = "zstd_seekable"
+
+	archiver, err := NewArchive(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	proc, err := NewUnarchive(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	exp := [][]byte{[]byte("hello"), []byte("world")}
+	msgs, res := archiver.ProcessMessage(message.New(exp))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	msgs, res = proc.ProcessMessage(msgs[0])
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, exp, message.GetAllBytes(msgs[0]))
+
+	msgs, _ = proc.ProcessMessage(message.New([][]byte{[]byte("not an archive")}))
+	require.Len(t, msgs, 1)
+	require.Equal(t, 1, msgs[0].Len())
+	assert.True(t, HasFailed(msgs[0].Get(0)))
+}
+
 func TestUnarchiveIndexBounds(t *testing.T) {
 	conf := NewConfig()
 	conf.Unarchive.Format = "tar"
@@ -457,3 +488,124 @@ func TestUnarchiveIndexBounds(t *testing.T) {
 		}
 	}
 }
+
+func TestUnarchiveZipInclude(t *testing.T) {
+	conf := NewConfig()
+	conf.Unarchive.Format = "zip"
+	conf.Unarchive.Include = []string{"*.json", "data/*.csv"}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"foo.json", "foo.txt", "data/bar.csv", "other/baz.csv", "bar.json"} {
+		fw, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(name))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	proc, err := NewUnarchive(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{buf.Bytes()}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	exp := [][]byte{[]byte("foo.json"), []byte("data/bar.csv"), []byte("bar.json")}
+	assert.Equal(t, exp, message.GetAllBytes(msgs[0]))
+	assert.Equal(t, "data/bar.csv", msgs[0].Get(1).Metadata().Get("archive_filename"))
+}
+
+func TestUnarchiveBadInclude(t *testing.T) {
+	conf := NewConfig()
+	conf.Unarchive.Format = "tar"
+	conf.Unarchive.Include = []string{"[a-"}
+
+	_, err := NewUnarchive(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "failed to parse include pattern '[a-': syntax error in pattern")
+}
+
+func TestUnarchiveMultipart(t *testing.T) {
+	conf := NewConfig()
+	conf.Unarchive.Format = "multipart"
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	require.NoError(t, mw.WriteField("foo", "foo value"))
+	fw, err := mw.CreateFormFile("bar", "bar.txt")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("bar value"))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	proc, err := NewUnarchive(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	// The boundary is obtained from the body.
+	msgs, res := proc.ProcessMessage(message.New([][]byte{buf.Bytes()}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 2, msgs[0].Len())
+
+	assert.Equal(t, "foo value", string(msgs[0].Get(0).Get()))
+	assert.Equal(t, "foo", msgs[0].Get(0).Metadata().Get("archive_filename"))
+	assert.Equal(t, "bar value", string(msgs[0].Get(1).Get()))
+	assert.Equal(t, "bar.txt", msgs[0].Get(1).Metadata().Get("archive_filename"))
+	assert.Equal(t, "application/octet-stream", msgs[0].Get(1).Metadata().Get("archive_content_type"))
+
+	// The boundary is obtained from metadata, allowing for a preamble.
+	part := message.NewPart(append([]byte("preamble\r\n"), buf.Bytes()...))
+	part.Metadata().Set("Content-Type", mw.FormDataContentType())
+
+	conf.Unarchive.Include = []string{"*.txt"}
+	proc, err = NewUnarchive(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New(nil)
+	msg.Append(part)
+	msgs, res = proc.ProcessMessage(msg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, [][]byte{[]byte("bar value")}, message.GetAllBytes(msgs[0]))
+
+	msgs, _ = proc.ProcessMessage(message.New([][]byte{[]byte("not multipart")}))
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "failed to find multipart boundary", GetFail(msgs[0].Get(0)))
+}
+
+func TestUnarchiveSevenZip(t *testing.T) {
+	// A 7z archive containing the files bar and foo, with the contents "bar\n"
+	// and "foo\n" respectively.
+	archive, err := base64.StdEncoding.DecodeString(
+		"N3q8ryccAASgR6WICAAAAAAAAABmAAAAAAAAAN2R8/FiYXIKZm9vCgEEBgACCQQEAAcLAgABAQABAQAMBAQACAoB6bOiBKhlMn4AAAUCGQUAAAAAABERAGIAYQByAAAAZgBvAG8AAAAZAgAAFBIBAACFM3PyY9YBAFgCcvJj1gEVCgEAIICkgSCApIEAAA==",
+	)
+	require.NoError(t, err)
+
+	conf := NewConfig()
+	conf.Unarchive.Format = "7z"
+
+	proc, err := NewUnarchive(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{archive}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, [][]byte{[]byte("bar\n"), []byte("foo\n")}, message.GetAllBytes(msgs[0]))
+	assert.Equal(t, "bar", msgs[0].Get(0).Metadata().Get("archive_filename"))
+	assert.Equal(t, "foo", msgs[0].Get(1).Metadata().Get("archive_filename"))
+
+	conf.Unarchive.Include = []string{"f*"}
+	proc, err = NewUnarchive(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res = proc.ProcessMessage(message.New([][]byte{archive}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, [][]byte{[]byte("foo\n")}, message.GetAllBytes(msgs[0]))
+
+	msgs, res = proc.ProcessMessage(message.New([][]byte{[]byte("not a 7z archive")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.True(t, HasFailed(msgs[0].Get(0)))
+}
@@ -1,6 +1,11 @@
 package processor
 
 import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/message/tracing"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -10,19 +15,45 @@ import (
 
 //------------------------------------------------------------------------------
 
+// ContextProcessor is an optional interface implemented by processors that are
+// able to abandon their work when a context is cancelled.
+type ContextProcessor interface {
+	// ProcessMessageWithContext is the context aware version of
+	// ProcessMessage.
+	ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response)
+}
+
+func processWithContext(ctx context.Context, proc types.Processor, msg types.Message) ([]types.Message, types.Response) {
+	if cProc, ok := proc.(ContextProcessor); ok {
+		return cProc.ProcessMessageWithContext(ctx, msg)
+	}
+	return proc.ProcessMessage(msg)
+}
+
 // ExecuteAll attempts to execute a slice of processors to a message. Returns
 // N resulting messages or a response. The response may indicate either a NoAck
 // in the event of the message being buffered or an unrecoverable error.
 func ExecuteAll(procs []types.Processor, msgs ...types.Message) ([]types.Message, types.Response) {
+	return ExecuteAllWithContext(context.Background(), procs, msgs...)
+}
+
+// ExecuteAllWithContext is the context aware version of ExecuteAll. The context
+// is provided to processors that implement ContextProcessor, and once it is
+// cancelled the remaining processors are skipped and an error response is
+// returned.
+func ExecuteAllWithContext(ctx context.Context, procs []types.Processor, msgs ...types.Message) ([]types.Message, types.Response) {
 	resultMsgs := make([]types.Message, len(msgs))
 	copy(resultMsgs, msgs)
 
 	var resultRes types.Response
 	for i := 0; len(resultMsgs) > 0 && i < len(procs); i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, response.NewError(err)
+		}
 		var nextResultMsgs []types.Message
 		for _, m := range resultMsgs {
 			var rMsgs []types.Message
-			if rMsgs, resultRes = procs[i].ProcessMessage(m); resultRes != nil && resultRes.Error() != nil {
+			if rMsgs, resultRes = processWithContext(ctx, procs[i], m); resultRes != nil && resultRes.Error() != nil {
 				// We immediately return if a processor hits an unrecoverable
 				// error on a message.
 				return nil, resultRes
@@ -125,15 +156,47 @@ var FailFlagKey = types.FailFlagKey
 
 // FlagFail marks a message part as having failed at a processing step.
 func FlagFail(part types.Part) {
-	part.Metadata().Set(FailFlagKey, "true")
+	flagFailure(part, "true", types.ErrorClassPermanent)
 }
 
 // FlagErr marks a message part as having failed at a processing step with an
 // error message. If the error is nil the message part remains unchanged.
 func FlagErr(part types.Part, err error) {
 	if err != nil {
-		part.Metadata().Set(FailFlagKey, err.Error())
+		flagFailure(part, err.Error(), ErrorClass(err))
+	}
+}
+
+func flagFailure(part types.Part, reason, class string) {
+	part.Metadata().Set(FailFlagKey, reason)
+	details := message.GetFailureDetails(part)
+	message.SetFailureDetails(part, message.FailureDetails{
+		Class:     class,
+		Timestamp: time.Now(),
+		Count:     details.Count + 1,
+	})
+}
+
+// ErrorClass returns the class of an error, which is either retryable, where
+// the error is likely to be temporary (timeouts, rate limits, etc), or
+// permanent.
+func ErrorClass(err error) string {
+	var temp interface{ Temporary() bool }
+	if errors.As(err, &temp) && temp.Temporary() {
+		return types.ErrorClassRetryable
+	}
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return types.ErrorClassRetryable
+	}
+	if errors.Is(err, types.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return types.ErrorClassRetryable
 	}
+	var httpErr types.ErrUnexpectedHTTPRes
+	if errors.As(err, &httpErr) && (httpErr.Code == 429 || httpErr.Code >= 500) {
+		return types.ErrorClassRetryable
+	}
+	return types.ErrorClassPermanent
 }
 
 // GetFail returns an error string for a message part if it has failed, or an
@@ -142,14 +205,43 @@ func GetFail(part types.Part) string {
 	return part.Metadata().Get(FailFlagKey)
 }
 
+// GetFailClass returns the class of the error a message part has failed with,
+// or an empty string if it has not failed. Parts that have failed without a
+// recorded class are considered to have failed permanently.
+func GetFailClass(part types.Part) string {
+	if !HasFailed(part) {
+		return ""
+	}
+	if class := message.GetFailureDetails(part).Class; len(class) > 0 {
+		return class
+	}
+	return types.ErrorClassPermanent
+}
+
 // HasFailed checks whether a message part has failed a processing step.
 func HasFailed(part types.Part) bool {
 	return len(part.Metadata().Get(FailFlagKey)) > 0
 }
 
-// ClearFail removes any existing failure flags from a message part.
+// ClearFail removes any existing failure flags and failure details from a
+// message part.
 func ClearFail(part types.Part) {
 	part.Metadata().Delete(FailFlagKey)
+	message.SetFailureDetails(part, message.FailureDetails{})
+}
+
+// SetFailPath sets the path of the component responsible for the failure of a
+// message part, unless the part has not failed or a path is already set.
+func SetFailPath(part types.Part, path string) {
+	if !HasFailed(part) {
+		return
+	}
+	details := message.GetFailureDetails(part)
+	if len(details.Path) > 0 {
+		return
+	}
+	details.Path = path
+	message.SetFailureDetails(part, details)
 }
 
 //------------------------------------------------------------------------------
@@ -1,7 +1,10 @@
 package processor
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -213,4 +216,134 @@ func TestExecuteAllErrored(t *testing.T) {
 	}
 }
 
+type cancelling struct {
+	passthrough
+	cancel func()
+	ctx    context.Context
+}
+
+func (c *cancelling) ProcessMessageWithContext(ctx context.Context, msg types.Message) ([]types.Message, types.Response) {
+	c.ctx = ctx
+	c.cancel()
+	return c.ProcessMessage(msg)
+}
+
+func TestExecuteAllWithContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cProc := &cancelling{cancel: cancel}
+	procs := []types.Processor{
+		&passthrough{},
+		cProc,
+		&passthrough{},
+	}
+
+	msgs, res := ExecuteAllWithContext(ctx, procs, message.New([][]byte{[]byte("test message")}))
+	if len(msgs) > 0 {
+		t.Fatal("received messages after cancellation")
+	}
+	if res == nil || res.Error() != context.Canceled {
+		t.Fatalf("Wrong response: %v", res)
+	}
+	if cProc.ctx != ctx {
+		t.Error("Context was not provided to processor")
+	}
+	if exp, act := 1, procs[0].(*passthrough).called; exp != act {
+		t.Errorf("Wrong call count from processor: %v != %v", act, exp)
+	}
+	if exp, act := 1, cProc.called; exp != act {
+		t.Errorf("Wrong call count from processor: %v != %v", act, exp)
+	}
+	if exp, act := 0, procs[2].(*passthrough).called; exp != act {
+		t.Errorf("Wrong call count from processor: %v != %v", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func TestFlagErrDetails(t *testing.T) {
+	part := message.NewPart([]byte("foo"))
+
+	FlagErr(part, types.ErrTimeout)
+	SetFailPath(part, "foo")
+	SetFailPath(part, "bar")
+
+	if exp, act := "action timed out", GetFail(part); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	details := message.GetFailureDetails(part)
+	if exp, act := types.ErrorClassRetryable, details.Class; exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "foo", details.Path; exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := 1, details.Count; exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if details.Timestamp.IsZero() {
+		t.Error("Expected timestamp to be set")
+	}
+
+	copied := part.Copy()
+
+	FlagErr(part, errors.New("bad thing"))
+	details = message.GetFailureDetails(part)
+	if exp, act := types.ErrorClassPermanent, details.Class; exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "", details.Path; exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := 2, details.Count; exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := 1, message.GetFailureDetails(copied).Count; exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+
+	if exp, act := map[string]string{FailFlagKey: "bad thing"}, metadataMap(part); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+
+	ClearFail(part)
+	if HasFailed(part) {
+		t.Error("Expected failure to be cleared")
+	}
+	if exp, act := (message.FailureDetails{}), message.GetFailureDetails(part); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+
+	SetFailPath(part, "baz")
+	if exp, act := "", message.GetFailureDetails(part).Path; exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+}
+
+func metadataMap(part types.Part) map[string]string {
+	m := map[string]string{}
+	part.Metadata().Iter(func(k, v string) error {
+		m[k] = v
+		return nil
+	})
+	return m
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := map[error]string{
+		errors.New("foo"): types.ErrorClassPermanent,
+		types.ErrTimeout:  types.ErrorClassRetryable,
+		types.ErrUnexpectedHTTPRes{Code: 429, S: "nope"}: types.ErrorClassRetryable,
+		types.ErrUnexpectedHTTPRes{Code: 503, S: "nope"}: types.ErrorClassRetryable,
+		types.ErrUnexpectedHTTPRes{Code: 400, S: "nope"}: types.ErrorClassPermanent,
+		fmt.Errorf("wrapped: %w", types.ErrTimeout):      types.ErrorClassRetryable,
+	}
+	for err, exp := range tests {
+		if act := ErrorClass(err); exp != act {
+			t.Errorf("Wrong result for '%v': %v != %v", err, act, exp)
+		}
+	}
+}
+
 //------------------------------------------------------------------------------
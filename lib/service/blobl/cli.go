@@ -19,6 +19,7 @@ import (
 )
 
 var red = color.New(color.FgRed).SprintFunc()
+var yellow = color.New(color.FgYellow).SprintFunc()
 
 // CliCommand is a cli.Command definition for running a blobl mapping.
 func CliCommand() *cli.Command {
@@ -218,6 +219,14 @@ func run(c *cli.Context) error {
 		os.Exit(1)
 	}
 
+	deprecations, _ := parser.MappingDeprecations(file, m, parser.Context{
+		Functions: query.AllFunctions,
+		Methods:   query.AllMethods,
+	})
+	for _, d := range deprecations {
+		fmt.Fprintf(os.Stderr, "%v %v\n", yellow("warning:"), d)
+	}
+
 	inputsChan := make(chan []byte)
 	go func() {
 		defer close(inputsChan)
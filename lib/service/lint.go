@@ -40,7 +40,7 @@ type pathLint struct {
 	err    string
 }
 
-func lintFile(path string) (pathLints []pathLint) {
+func lintFile(path string, deprecated bool) (pathLints []pathLint) {
 	conf := config.New()
 	lints, err := config.Read(path, true, &conf)
 	if err != nil {
@@ -50,6 +50,22 @@ func lintFile(path string) (pathLints []pathLint) {
 		})
 		return
 	}
+	if deprecated {
+		configBytes, err := config.ReadWithJSONPointers(path, true)
+		if err == nil {
+			var warnings []string
+			if warnings, err = config.LintWarnings(configBytes); err == nil {
+				lints = append(lints, warnings...)
+			}
+		}
+		if err != nil {
+			pathLints = append(pathLints, pathLint{
+				source: path,
+				err:    err.Error(),
+			})
+			return
+		}
+	}
 	for _, l := range lints {
 		pathLints = append(pathLints, pathLint{
 			source: path,
@@ -135,8 +151,20 @@ func lintCliCommand() *cli.Command {
    benthos lint ./configs/...
    
    If a path ends with '...' then Benthos will walk the target and lint any
-   files with the .yaml or .yml extension.`[4:],
+   files with the .yaml or .yml extension.
+
+   When the --deprecated flag is set linting warnings are also reported, such
+   as the usage of deprecated interpolation functions or Bloblang functions
+   and methods.`[4:],
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "deprecated",
+				Value: false,
+				Usage: "Also report linting warnings such as the usage of deprecated functions.",
+			},
+		},
 		Action: func(c *cli.Context) error {
+			deprecated := c.Bool("deprecated")
 			var targets []string
 			for _, p := range c.Args().Slice() {
 				var recurse bool
@@ -184,7 +212,7 @@ func lintCliCommand() *cli.Command {
 						if path.Ext(target) == ".md" {
 							lints = lintMDSnippets(target)
 						} else {
-							lints = lintFile(target)
+							lints = lintFile(target, deprecated)
 						}
 						if len(lints) > 0 {
 							pathLintMut.Lock()
@@ -18,6 +18,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/config"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/stream"
 	strmmgr "github.com/Jeffail/benthos/v3/lib/stream/manager"
@@ -169,6 +170,10 @@ func cmdService(
 		return 1
 	}
 
+	if conf.JSONPreserveKeyOrder {
+		message.SetPreserveKeyOrder(true)
+	}
+
 	if len(overrideLogLevel) > 0 {
 		conf.Logger.LogLevel = strings.ToUpper(overrideLogLevel)
 	}
@@ -250,12 +255,16 @@ func cmdService(
 
 	// Create data streams.
 	if streamsMode {
-		streamMgr := strmmgr.New(
-			strmmgr.OptSetAPITimeout(time.Second*5),
+		mgrOpts := []func(*strmmgr.Type){
+			strmmgr.OptSetAPITimeout(time.Second * 5),
 			strmmgr.OptSetLogger(logger),
 			strmmgr.OptSetManager(manager),
 			strmmgr.OptSetStats(stats),
-		)
+		}
+		if conf.HTTP.FlowMonitoring {
+			mgrOpts = append(mgrOpts, strmmgr.OptEnableFlowMonitor())
+		}
+		streamMgr := strmmgr.New(mgrOpts...)
 		streamConfs := map[string]stream.Config{}
 		var streamLints []string
 		for _, path := range streamsConfigs {
@@ -289,15 +298,18 @@ func cmdService(
 		}
 		logger.Infoln("Launching benthos in streams mode, use CTRL+C to close.")
 	} else {
-		if dataStream, err = stream.New(
-			conf.Config,
+		strmOpts := []func(*stream.Type){
 			stream.OptSetLogger(logger),
 			stream.OptSetStats(stats),
 			stream.OptSetManager(manager),
 			stream.OptOnClose(func() {
 				close(dataStreamClosedChan)
 			}),
-		); err != nil {
+		}
+		if conf.HTTP.FlowMonitoring {
+			strmOpts = append(strmOpts, stream.OptEnableFlowMonitor())
+		}
+		if dataStream, err = stream.New(conf.Config, strmOpts...); err != nil {
 			logger.Errorf("Service closing due to: %v\n", err)
 			return 1
 		}
@@ -349,6 +361,7 @@ func cmdService(
 		}()
 
 		timesOut := time.Now().Add(exitTimeout)
+		logger.Infof("Draining in-flight messages with a deadline of %v.\n", exitTimeout)
 		if err := dataStream.Stop(exitTimeout); err != nil {
 			os.Exit(1)
 		}
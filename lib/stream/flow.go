@@ -0,0 +1,227 @@
+package stream
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// flowSamplePeriod is the interval at which the flow between the layers of a
+// stream is sampled.
+var flowSamplePeriod = time.Second
+
+// flowSaturatedRatio is the ratio of a sample period that a boundary must be
+// occupied for in order for the layer after it to be considered saturated.
+const flowSaturatedRatio = 0.5
+
+// flowBoundary forwards transactions between two layers of a stream and
+// measures the time that transactions spend waiting at the boundary for the
+// downstream layer to accept them.
+type flowBoundary struct {
+	from, to string
+
+	mut          sync.Mutex
+	waitingSince time.Time
+	waited       time.Duration
+	transactions int64
+
+	mOccupancy  metrics.StatGauge
+	mBottleneck metrics.StatGauge
+}
+
+// FlowBoundaryStatus describes the flow of transactions between two layers of
+// a stream during the last sample period.
+type FlowBoundaryStatus struct {
+	From         string  `json:"from"`
+	To           string  `json:"to"`
+	Occupancy    float64 `json:"occupancy"`
+	Transactions int64   `json:"transactions"`
+}
+
+// FlowStatus describes the flow of transactions through each layer of a stream
+// during the last sample period.
+type FlowStatus struct {
+	Bottleneck string               `json:"bottleneck"`
+	InFlight   int64                `json:"in_flight"`
+	Boundaries []FlowBoundaryStatus `json:"boundaries"`
+}
+
+// forward relays transactions from a channel unchanged whilst measuring the
+// time each one waits to be accepted. Forwarding is abandoned once the stopped
+// channel is closed.
+func (b *flowBoundary) forward(in <-chan types.Transaction, stopped <-chan struct{}) <-chan types.Transaction {
+	out := make(chan types.Transaction)
+	go func() {
+		defer close(out)
+		for tran := range in {
+			b.mut.Lock()
+			b.waitingSince = time.Now()
+			b.mut.Unlock()
+
+			select {
+			case out <- tran:
+			case <-stopped:
+				return
+			}
+
+			b.mut.Lock()
+			b.waited += time.Since(b.waitingSince)
+			b.waitingSince = time.Time{}
+			b.transactions++
+			b.mut.Unlock()
+		}
+	}()
+	return out
+}
+
+// sample returns the total time transactions have waited at the boundary,
+// including any transaction that is currently waiting, and the total number of
+// transactions forwarded.
+func (b *flowBoundary) sample(now time.Time) (time.Duration, int64) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	waited := b.waited
+	if !b.waitingSince.IsZero() {
+		waited += now.Sub(b.waitingSince)
+	}
+	return waited, b.transactions
+}
+
+//------------------------------------------------------------------------------
+
+// flowMonitor periodically samples each boundary between the layers of a
+// stream in order to identify the layer that is limiting throughput.
+type flowMonitor struct {
+	boundaries []*flowBoundary
+	inFlight   *int64
+	period     time.Duration
+	stats      metrics.Type
+
+	mut    sync.Mutex
+	status FlowStatus
+}
+
+func newFlowMonitor(inFlight *int64, stats metrics.Type) *flowMonitor {
+	return &flowMonitor{
+		inFlight: inFlight,
+		period:   flowSamplePeriod,
+		stats:    stats,
+		status: FlowStatus{
+			Bottleneck: "input",
+			Boundaries: []FlowBoundaryStatus{},
+		},
+	}
+}
+
+// boundary adds a boundary between two layers of a stream, and returns a
+// channel forwarding the transactions of the upstream layer. When the monitor
+// is nil the upstream channel is returned unchanged.
+func (f *flowMonitor) boundary(from, to string, in <-chan types.Transaction, stopped <-chan struct{}) <-chan types.Transaction {
+	if f == nil {
+		return in
+	}
+	b := &flowBoundary{
+		from:        from,
+		to:          to,
+		mOccupancy:  f.stats.GetGauge("flow." + from + "_to_" + to + ".occupancy"),
+		mBottleneck: f.stats.GetGauge("flow." + to + ".bottleneck"),
+	}
+	f.boundaries = append(f.boundaries, b)
+	return b.forward(in, stopped)
+}
+
+// loop samples the boundaries of the stream until the stopped channel is
+// closed.
+func (f *flowMonitor) loop(stopped <-chan struct{}) {
+	prevWaited := make([]time.Duration, len(f.boundaries))
+	prevCounts := make([]int64, len(f.boundaries))
+	prevTime := time.Now()
+
+	mInputBottleneck := f.stats.GetGauge("flow.input.bottleneck")
+
+	for {
+		select {
+		case <-time.After(f.period):
+		case <-stopped:
+			return
+		}
+
+		now := time.Now()
+		period := now.Sub(prevTime)
+		prevTime = now
+
+		status := FlowStatus{
+			Bottleneck: "input",
+			Boundaries: make([]FlowBoundaryStatus, len(f.boundaries)),
+		}
+		if f.inFlight != nil {
+			status.InFlight = atomic.LoadInt64(f.inFlight)
+		}
+		for i, b := range f.boundaries {
+			waited, count := b.sample(now)
+			occupancy := 0.0
+			if period > 0 {
+				occupancy = float64(waited-prevWaited[i]) / float64(period)
+			}
+			if occupancy > 1 {
+				occupancy = 1
+			}
+			status.Boundaries[i] = FlowBoundaryStatus{
+				From:         b.from,
+				To:           b.to,
+				Occupancy:    occupancy,
+				Transactions: count - prevCounts[i],
+			}
+			prevWaited[i], prevCounts[i] = waited, count
+			b.mOccupancy.Set(int64(occupancy * 100))
+
+			// The layer after the furthest downstream saturated boundary is
+			// the bottleneck, as all layers before it are waiting on it.
+			if occupancy >= flowSaturatedRatio {
+				status.Bottleneck = b.to
+			}
+		}
+
+		for _, b := range f.boundaries {
+			if b.to == status.Bottleneck {
+				b.mBottleneck.Set(1)
+			} else {
+				b.mBottleneck.Set(0)
+			}
+		}
+		if status.Bottleneck == "input" {
+			mInputBottleneck.Set(1)
+		} else {
+			mInputBottleneck.Set(0)
+		}
+
+		f.mut.Lock()
+		f.status = status
+		f.mut.Unlock()
+	}
+}
+
+// Status returns the flow of the stream during the last sample period.
+func (f *flowMonitor) Status() FlowStatus {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	return f.status
+}
+
+func (f *flowMonitor) handler(w http.ResponseWriter, r *http.Request) {
+	resBytes, err := json.Marshal(f.Status())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resBytes)
+}
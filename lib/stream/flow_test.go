@@ -0,0 +1,105 @@
+package stream
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+func TestFlowMonitorBottleneck(t *testing.T) {
+	tmpPeriod := flowSamplePeriod
+	flowSamplePeriod = time.Millisecond * 50
+	defer func() {
+		flowSamplePeriod = tmpPeriod
+	}()
+
+	stopped := make(chan struct{})
+	defer close(stopped)
+
+	var inFlight int64
+	flow := newFlowMonitor(&inFlight, metrics.Noop())
+
+	in := make(chan types.Transaction)
+	pipelineIn := flow.boundary("input", "pipeline", in, stopped)
+
+	// The pipeline forwards transactions immediately, and the output never
+	// accepts them.
+	pipelineOut := make(chan types.Transaction)
+	go func() {
+		for tran := range pipelineIn {
+			select {
+			case pipelineOut <- tran:
+			case <-stopped:
+				return
+			}
+		}
+	}()
+	outputIn := flow.boundary("pipeline", "output", pipelineOut, stopped)
+
+	go flow.loop(stopped)
+
+	go func() {
+		select {
+		case in <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), nil):
+		case <-stopped:
+		}
+	}()
+
+	deadline := time.Now().Add(time.Second * 5)
+	for flow.Status().Bottleneck != "output" {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for output bottleneck: %+v", flow.Status())
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	status := flow.Status()
+	if exp, act := 2, len(status.Boundaries); exp != act {
+		t.Fatalf("Wrong count of boundaries: %v != %v", act, exp)
+	}
+	if exp, act := "input", status.Boundaries[0].From; exp != act {
+		t.Errorf("Wrong boundary origin: %v != %v", act, exp)
+	}
+	if status.Boundaries[1].Occupancy < flowSaturatedRatio {
+		t.Errorf("Expected output boundary to be saturated: %v", status.Boundaries[1].Occupancy)
+	}
+
+	rec := httptest.NewRecorder()
+	flow.handler(rec, httptest.NewRequest("GET", "/flow", nil))
+
+	var resStatus FlowStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &resStatus); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "output", resStatus.Bottleneck; exp != act {
+		t.Errorf("Wrong bottleneck: %v != %v", act, exp)
+	}
+
+	select {
+	case <-outputIn:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	for flow.Status().Bottleneck != "input" {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for input bottleneck: %+v", flow.Status())
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestFlowMonitorDisabled(t *testing.T) {
+	var flow *flowMonitor
+
+	in := make(chan types.Transaction)
+	var inRead <-chan types.Transaction = in
+	if out := flow.boundary("input", "output", in, nil); out != inRead {
+		t.Error("Expected a disabled flow monitor to return the upstream channel")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"path"
 
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/schemaregistry"
+	"github.com/Jeffail/benthos/v3/lib/util/sqlpool"
 )
 
 //------------------------------------------------------------------------------
@@ -67,6 +69,28 @@ func (n *NamespacedManager) GetProcessor(name string) (types.Processor, error) {
 	return nil, errors.New("wrapped manager does not support processor resources")
 }
 
+// GetSchemaRegistry attempts to find a schema registry resource by its label.
+func (n *NamespacedManager) GetSchemaRegistry(name string) (*schemaregistry.Client, error) {
+	// TODO: V4 Simplify this.
+	if regProv, ok := n.mgr.(interface {
+		GetSchemaRegistry(name string) (*schemaregistry.Client, error)
+	}); ok {
+		return regProv.GetSchemaRegistry(name)
+	}
+	return nil, errors.New("wrapped manager does not support schema registry resources")
+}
+
+// GetSQLPool attempts to find a SQL resource by its label.
+func (n *NamespacedManager) GetSQLPool(name string) (*sqlpool.Pool, error) {
+	// TODO: V4 Simplify this.
+	if poolProv, ok := n.mgr.(interface {
+		GetSQLPool(name string) (*sqlpool.Pool, error)
+	}); ok {
+		return poolProv.GetSQLPool(name)
+	}
+	return nil, errors.New("wrapped manager does not support sql resources")
+}
+
 // GetRateLimit attempts to find a service wide rate limit by its name.
 func (n *NamespacedManager) GetRateLimit(name string) (types.RateLimit, error) {
 	return n.mgr.GetRateLimit(name)
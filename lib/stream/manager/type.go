@@ -102,6 +102,8 @@ type Type struct {
 	logger     log.Modular
 	apiTimeout time.Duration
 
+	flowMonitoring bool
+
 	pipelineProcCtors []StreamProcConstructorFunc
 
 	lock sync.Mutex
@@ -156,6 +158,14 @@ func OptSetAPITimeout(tout time.Duration) func(*Type) {
 	}
 }
 
+// OptEnableFlowMonitor enables measuring the flow of messages between the
+// layers of all child streams.
+func OptEnableFlowMonitor() func(*Type) {
+	return func(t *Type) {
+		t.flowMonitoring = true
+	}
+}
+
 // OptAddProcessors adds processor constructors that will be called for every
 // new stream and attached to the processor pipelines. The constructor is given
 // the name of the stream as an argument.
@@ -203,8 +213,7 @@ func (m *Type) Create(id string, conf stream.Config) error {
 	sStats = metrics.Combine(sStats, strmFlatMetrics)
 
 	var wrapper *StreamStatus
-	strm, err := stream.New(
-		conf,
+	strmOpts := []func(*stream.Type){
 		stream.OptAddProcessors(procCtors...),
 		stream.OptSetLogger(sLog),
 		stream.OptSetStats(sStats),
@@ -212,7 +221,11 @@ func (m *Type) Create(id string, conf stream.Config) error {
 		stream.OptOnClose(func() {
 			wrapper.setClosed()
 		}),
-	)
+	}
+	if m.flowMonitoring {
+		strmOpts = append(strmOpts, stream.OptEnableFlowMonitor())
+	}
+	strm, err := stream.New(conf, strmOpts...)
 	if err != nil {
 		return err
 	}
@@ -2,8 +2,11 @@ package stream
 
 import (
 	"bytes"
+	"fmt"
 	"net/http"
 	"runtime/pprof"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/interop"
@@ -33,6 +36,18 @@ type Type struct {
 
 	complementaryProcs []types.ProcessorConstructorFunc
 
+	// The number of transactions read from the input layer that have not yet
+	// reached the output layer, which is only counted when trackInFlight is
+	// set.
+	inFlight      int64
+	trackInFlight bool
+
+	flowEnabled bool
+	flow        *flowMonitor
+
+	stoppedOnce sync.Once
+	stoppedChan chan struct{}
+
 	manager types.Manager
 	stats   metrics.Type
 	logger  log.Modular
@@ -48,6 +63,8 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 		logger:  log.Noop(),
 		manager: types.NoopMgr(),
 		onClose: func() {},
+
+		stoppedChan: make(chan struct{}),
 	}
 	for _, opt := range opts {
 		opt(t)
@@ -68,15 +85,33 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 			w.WriteHeader(http.StatusServiceUnavailable)
 			w.Write([]byte("output not connected\n"))
 		}
+		required, optional := t.unavailableResources()
+		for _, name := range required {
+			if connected {
+				connected = false
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			w.Write([]byte(fmt.Sprintf("required resource %v not available\n", name)))
+		}
 		if connected {
 			w.Write([]byte("OK"))
+			for _, name := range optional {
+				w.Write([]byte(fmt.Sprintf("\nresource %v not available (degraded)", name)))
+			}
 		}
 	}
 	t.manager.RegisterEndpoint(
 		"/ready",
-		"Returns 200 OK if all inputs and outputs are connected, otherwise a 503 is returned.",
+		"Returns 200 OK if all inputs, outputs and required resources are connected, otherwise a 503 is returned.",
 		healthCheck,
 	)
+	if t.flow != nil {
+		t.manager.RegisterEndpoint(
+			"/flow",
+			"Returns a JSON object describing the flow of messages between each layer of the stream during the last second, including the ratio of time messages spent waiting for each layer to accept them and the layer that is currently the bottleneck.",
+			t.flow.handler,
+		)
+	}
 	return t, nil
 }
 
@@ -90,6 +125,14 @@ func OptAddProcessors(procs ...types.ProcessorConstructorFunc) func(*Type) {
 	}
 }
 
+// OptEnableFlowMonitor enables measuring the flow of messages between each
+// layer of the stream, which is served at the endpoint /flow.
+func OptEnableFlowMonitor() func(*Type) {
+	return func(t *Type) {
+		t.flowEnabled = true
+	}
+}
+
 // OptSetStats sets the metrics aggregator to be used by all components of the
 // stream.
 func OptSetStats(stats metrics.Type) func(*Type) {
@@ -133,9 +176,22 @@ func OptOnClose(onClose func()) func(*Type) {
 //------------------------------------------------------------------------------
 
 // IsReady returns a boolean indicating whether both the input and output layers
-// of the stream are connected.
+// of the stream are connected, and all required resources are available.
 func (t *Type) IsReady() bool {
-	return t.inputLayer.Connected() && t.outputLayer.Connected()
+	if !t.inputLayer.Connected() || !t.outputLayer.Connected() {
+		return false
+	}
+	required, _ := t.unavailableResources()
+	return len(required) == 0
+}
+
+func (t *Type) unavailableResources() (required, optional []string) {
+	if rMgr, ok := t.manager.(interface {
+		UnavailableResources() (required, optional []string)
+	}); ok {
+		return rMgr.UnavailableResources()
+	}
+	return nil, nil
 }
 
 func (t *Type) start() (err error) {
@@ -164,22 +220,50 @@ func (t *Type) start() (err error) {
 	// Start chaining components
 	var nextTranChan <-chan types.Transaction
 
+	// Transactions are only counted when flow monitoring is enabled and there
+	// are layers between the input and output for them to be held within.
+	// Counting them forwards transactions through a goroutine at either side of
+	// those layers, each of which holds a transaction that would otherwise
+	// apply back pressure to the layer before it.
+	t.trackInFlight = t.flowEnabled && (t.bufferLayer != nil || t.pipelineLayer != nil)
+
+	// When enabled each boundary between layers is measured in order to
+	// identify which layer is limiting throughput.
+	if t.flowEnabled {
+		t.flow = newFlowMonitor(&t.inFlight, t.stats)
+	}
+	prevLayer := "input"
+
 	nextTranChan = t.inputLayer.TransactionChan()
+	if t.trackInFlight {
+		nextTranChan = countTransactions(nextTranChan, &t.inFlight, 1, t.stoppedChan)
+	}
 	if t.bufferLayer != nil {
+		nextTranChan = t.flow.boundary(prevLayer, "buffer", nextTranChan, t.stoppedChan)
 		if err = t.bufferLayer.Consume(nextTranChan); err != nil {
 			return
 		}
 		nextTranChan = t.bufferLayer.TransactionChan()
+		prevLayer = "buffer"
 	}
 	if t.pipelineLayer != nil {
+		nextTranChan = t.flow.boundary(prevLayer, "pipeline", nextTranChan, t.stoppedChan)
 		if err = t.pipelineLayer.Consume(nextTranChan); err != nil {
 			return
 		}
 		nextTranChan = t.pipelineLayer.TransactionChan()
+		prevLayer = "pipeline"
 	}
+	if t.trackInFlight {
+		nextTranChan = countTransactions(nextTranChan, &t.inFlight, -1, t.stoppedChan)
+	}
+	nextTranChan = t.flow.boundary(prevLayer, "output", nextTranChan, t.stoppedChan)
 	if err = t.outputLayer.Consume(nextTranChan); err != nil {
 		return
 	}
+	if t.flow != nil {
+		go t.flow.loop(t.stoppedChan)
+	}
 
 	go func(out output.Type) {
 		for {
@@ -193,28 +277,102 @@ func (t *Type) start() (err error) {
 	return nil
 }
 
+// Flow returns the flow of messages between each layer of the stream during the
+// last sample period, or an empty status when flow monitoring is not enabled.
+func (t *Type) Flow() FlowStatus {
+	if t.flow == nil {
+		return FlowStatus{Boundaries: []FlowBoundaryStatus{}}
+	}
+	return t.flow.Status()
+}
+
+// drainLogPeriod is the interval at which the progress of a graceful shutdown
+// is logged whilst waiting for a component layer to drain.
+var drainLogPeriod = 5 * time.Second
+
+// countTransactions forwards transactions from a channel unchanged and adds a
+// delta to a count for each one. When the delta is positive the count is
+// modified before forwarding, and otherwise after, so that counting at either
+// side of a layer never results in a negative total. Forwarding is abandoned
+// once the stopped channel is closed.
+func countTransactions(in <-chan types.Transaction, count *int64, delta int64, stopped <-chan struct{}) <-chan types.Transaction {
+	out := make(chan types.Transaction)
+	go func() {
+		defer close(out)
+		for tran := range in {
+			if delta > 0 {
+				atomic.AddInt64(count, delta)
+			}
+			select {
+			case out <- tran:
+			case <-stopped:
+				return
+			}
+			if delta < 0 {
+				atomic.AddInt64(count, delta)
+			}
+		}
+	}()
+	return out
+}
+
+// inFlightSuffix returns a description of the number of messages in flight to
+// be appended to log messages, or an empty string when they are not counted.
+func (t *Type) inFlightSuffix() string {
+	if !t.trackInFlight {
+		return ""
+	}
+	return fmt.Sprintf(" with %v messages in flight", atomic.LoadInt64(&t.inFlight))
+}
+
+// waitForDrain blocks until a component layer has closed, periodically logging
+// the number of messages still in flight, and returns an error if the layer
+// does not close before the deadline.
+func (t *Type) waitForDrain(name string, layer types.Closable, deadline time.Time) error {
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			t.logger.Warnf(
+				"Failed to drain %v before the shutdown deadline%v.\n",
+				name, t.inFlightSuffix(),
+			)
+			return types.ErrTimeout
+		}
+		if remaining > drainLogPeriod {
+			remaining = drainLogPeriod
+		}
+		err := layer.WaitForClose(remaining)
+		if err == nil {
+			t.logger.Debugf("Stream %v has drained.\n", name)
+			return nil
+		}
+		if err != types.ErrTimeout {
+			return err
+		}
+		t.logger.Infof(
+			"Waiting for %v to drain%v, %v until forced shutdown.\n",
+			name, t.inFlightSuffix(), time.Until(deadline).Round(time.Second),
+		)
+	}
+}
+
 // stopGracefully attempts to close the stream in the most graceful way by only
 // closing the input layer and waiting for all other layers to terminate by
 // proxy. This should guarantee that all in-flight and buffered data is resolved
 // before shutting down.
 func (t *Type) stopGracefully(timeout time.Duration) (err error) {
+	deadline := time.Now().Add(timeout)
+
 	t.inputLayer.CloseAsync()
-	started := time.Now()
-	if err = t.inputLayer.WaitForClose(timeout); err != nil {
+	if err = t.waitForDrain("input", t.inputLayer, deadline); err != nil {
 		return
 	}
 
-	var remaining time.Duration
-
 	// If we have a buffer then wait right here. We want to try and allow the
 	// buffer to empty out before prompting the other layers to shut down.
 	if t.bufferLayer != nil {
 		t.bufferLayer.StopConsuming()
-		remaining = timeout - time.Since(started)
-		if remaining < 0 {
-			return types.ErrTimeout
-		}
-		if err = t.bufferLayer.WaitForClose(remaining); err != nil {
+		if err = t.waitForDrain("buffer", t.bufferLayer, deadline); err != nil {
 			return
 		}
 	}
@@ -222,25 +380,13 @@ func (t *Type) stopGracefully(timeout time.Duration) (err error) {
 	// After this point we can start closing the remaining components.
 	if t.pipelineLayer != nil {
 		t.pipelineLayer.CloseAsync()
-		remaining = timeout - time.Since(started)
-		if remaining < 0 {
-			return types.ErrTimeout
-		}
-		if err = t.pipelineLayer.WaitForClose(remaining); err != nil {
+		if err = t.waitForDrain("pipeline", t.pipelineLayer, deadline); err != nil {
 			return
 		}
 	}
 
 	t.outputLayer.CloseAsync()
-	remaining = timeout - time.Since(started)
-	if remaining < 0 {
-		return types.ErrTimeout
-	}
-	if err = t.outputLayer.WaitForClose(remaining); err != nil {
-		return
-	}
-
-	return nil
+	return t.waitForDrain("output", t.outputLayer, deadline)
 }
 
 // stopOrdered attempts to close all components of the stream in the order of
@@ -345,6 +491,10 @@ func (t *Type) stopUnordered(timeout time.Duration) (err error) {
 // Initially the attempt is graceful, but as the timeout draws close the attempt
 // becomes progressively less graceful.
 func (t *Type) Stop(timeout time.Duration) error {
+	defer t.stoppedOnce.Do(func() {
+		close(t.stoppedChan)
+	})
+
 	tOutUnordered := timeout / 4
 	tOutGraceful := timeout - tOutUnordered
 
@@ -353,7 +503,10 @@ func (t *Type) Stop(timeout time.Duration) error {
 		return nil
 	}
 	if err == types.ErrTimeout {
-		t.logger.Infoln("Unable to fully drain buffered messages within target time.")
+		t.logger.Infof(
+			"Unable to fully drain buffered messages within target time, forcing shutdown%v.\n",
+			t.inFlightSuffix(),
+		)
 	} else {
 		t.logger.Errorf("Encountered error whilst shutting down: %v\n", err)
 	}
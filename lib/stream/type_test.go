@@ -1,11 +1,13 @@
 package stream
 
 import (
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/input"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output"
 	"github.com/Jeffail/benthos/v3/lib/processor"
@@ -181,3 +183,49 @@ func TestTypeCloseUnordered(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestTypeCountTransactions(t *testing.T) {
+	var count int64
+	stopped := make(chan struct{})
+	defer close(stopped)
+
+	in := make(chan types.Transaction)
+	out := countTransactions(countTransactions(in, &count, 1, stopped), &count, -1, stopped)
+
+	resChan := make(chan types.Response)
+	go func() {
+		in <- types.NewTransaction(message.New([][]byte{[]byte("foo")}), resChan)
+	}()
+
+	var tran types.Transaction
+	select {
+	case tran = <-out:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	if tran.ResponseChan != resChan {
+		t.Error("Expected transaction to be forwarded unchanged")
+	}
+	if exp, act := int64(0), atomic.LoadInt64(&count); exp != act {
+		t.Errorf("Wrong in flight count: %v != %v", act, exp)
+	}
+
+	mid := make(chan types.Transaction)
+	midOut := countTransactions(mid, &count, 1, stopped)
+	go func() {
+		mid <- types.NewTransaction(message.New([][]byte{[]byte("bar")}), resChan)
+	}()
+	select {
+	case <-midOut:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	if exp, act := int64(1), atomic.LoadInt64(&count); exp != act {
+		t.Errorf("Wrong in flight count: %v != %v", act, exp)
+	}
+
+	close(in)
+	if _, open := <-out; open {
+		t.Error("Expected output channel to close")
+	}
+}
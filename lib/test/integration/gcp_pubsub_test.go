@@ -59,6 +59,8 @@ output:
     max_in_flight: $MAX_IN_FLIGHT
     metadata:
       exclude_prefixes: [ $OUTPUT_META_EXCLUDE_PREFIX ]
+    flow_control:
+      max_outstanding_messages: 10
 
 input:
   gcp_pubsub:
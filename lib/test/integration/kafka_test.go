@@ -4,19 +4,27 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/lib/config"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
 	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/roundtrip"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/stream"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Shopify/sarama"
+	"github.com/gofrs/uuid"
 	"github.com/ory/dockertest/v3"
 	"github.com/ory/dockertest/v3/docker"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 var _ = registerIntegrationTest("kafka_redpanda", func(t *testing.T) {
@@ -209,8 +217,103 @@ input:
 			testOptVarThree("false"),
 		)
 	})
+
+	t.Run("request reply", func(t *testing.T) {
+		t.Parallel()
+		testKafkaRequestReply(t, "localhost:"+kafkaPortStr)
+	})
 })
 
+// testKafkaRequestReply sends a request with the kafka_request_reply output to
+// a responder built from the kafka input and output as described in the docs
+// of kafka_request_reply, and checks that the reply is returned as a
+// synchronous response.
+func testKafkaRequestReply(t *testing.T, address string) {
+	u4, err := uuid.NewV4()
+	require.NoError(t, err)
+
+	id := u4.String()
+	require.NoError(t, createKafkaTopic(address, id, 1))
+	require.NoError(t, createKafkaTopic(address, id+"-replies", 1))
+
+	responderConfig := strings.NewReplacer("$ADDRESS", address, "$ID", id).Replace(`
+input:
+  kafka:
+    addresses: [ $ADDRESS ]
+    topics: [ topic-$ID ]
+    consumer_group: group-$ID
+    start_from_oldest: true
+
+pipeline:
+  processors:
+    - bloblang: 'root.result = this.value * 2'
+
+output:
+  kafka:
+    addresses: [ $ADDRESS ]
+    topic: ${! meta("reply_topic") }
+    metadata:
+      exclude_prefixes: [ kafka_, reply_topic ]
+`)
+
+	conf := config.New()
+	require.NoError(t, yaml.Unmarshal([]byte(responderConfig), &conf))
+
+	mgr, err := manager.NewV2(conf.ResourceConfig, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	responder, err := stream.New(conf.Config, stream.OptSetManager(mgr), stream.OptSetLogger(log.Noop()), stream.OptSetStats(metrics.Noop()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, responder.Stop(time.Second*10))
+	})
+
+	outConf := output.NewConfig()
+	outConf.Type = output.TypeKafkaRequestReply
+	outConf.KafkaRequestReply.Addresses = []string{address}
+	outConf.KafkaRequestReply.RequestTopic = "topic-" + id
+	outConf.KafkaRequestReply.ReplyTopic = "topic-" + id + "-replies"
+	outConf.KafkaRequestReply.Timeout = "30s"
+
+	requester, err := output.New(outConf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		requester.CloseAsync()
+		assert.NoError(t, requester.WaitForClose(time.Second*10))
+	})
+
+	tranChan := make(chan types.Transaction)
+	require.NoError(t, requester.Consume(tranChan))
+
+	msg := message.New([][]byte{[]byte(`{"value":21}`)})
+	msg.Get(0).Metadata().Set("service", "doubler")
+	store := roundtrip.NewResultStore()
+	roundtrip.AddResultStore(msg, store)
+
+	resChan := make(chan types.Response)
+	select {
+	case tranChan <- types.NewTransaction(msg, resChan):
+	case <-time.After(time.Second * 30):
+		t.Fatal("timed out on send")
+	}
+	select {
+	case res := <-resChan:
+		require.NoError(t, res.Error())
+	case <-time.After(time.Second * 60):
+		t.Fatal("timed out on response")
+	}
+
+	results := store.Get()
+	require.Len(t, results, 1)
+	require.Equal(t, 1, results[0].Len())
+
+	reply := results[0].Get(0)
+	assert.Equal(t, `{"result":42}`, string(reply.Get()))
+	assert.NotEmpty(t, reply.Metadata().Get("correlation_id"))
+	assert.Equal(t, "doubler", reply.Metadata().Get("service"))
+	assert.Equal(t, "topic-"+id+"-replies", reply.Metadata().Get("kafka_topic"))
+}
+
 func createKafkaTopic(address, id string, partitions int32) error {
 	topicName := fmt.Sprintf("topic-%v", id)
 
@@ -55,6 +55,8 @@ output:
     max_in_flight: $MAX_IN_FLIGHT
     metadata:
       exclude_prefixes: [ $OUTPUT_META_EXCLUDE_PREFIX ]
+    batching:
+      count: $OUTPUT_BATCH_COUNT
 
 input:
   redis_streams:
@@ -70,6 +72,7 @@ input:
 			integrationTestMetadata(),
 			integrationTestMetadataFilter(),
 			integrationTestSendBatch(10),
+			integrationTestSendBatchCount(10),
 			integrationTestStreamSequential(1000),
 			integrationTestStreamParallel(1000),
 			integrationTestStreamParallelLossy(1000),
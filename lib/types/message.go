@@ -11,6 +11,17 @@ import (
 // be interpretted as having failed a processor step somewhere in the pipeline.
 var FailFlagKey = "benthos_processing_failed"
 
+// Error classes that are recorded against a failed message part.
+const (
+	// ErrorClassRetryable indicates that an error is likely to be temporary
+	// and that the failed step may succeed if attempted again.
+	ErrorClassRetryable = "retryable"
+
+	// ErrorClassPermanent indicates that an error is unlikely to be resolved
+	// by attempting the failed step again.
+	ErrorClassPermanent = "permanent"
+)
+
 //------------------------------------------------------------------------------
 
 // Metadata is an interface representing the metadata of a message part within
@@ -6,14 +6,26 @@ import "github.com/Jeffail/benthos/v3/internal/docs"
 func FieldSpecs() docs.FieldSpecs {
 	return docs.FieldSpecs{
 		docs.FieldCommon("region", "The AWS region to target."),
-		docs.FieldAdvanced("endpoint", "Allows you to specify a custom endpoint for the AWS API."),
+		docs.FieldAdvanced("endpoint", "Allows you to specify a custom endpoint for the AWS API. This endpoint is also used when assuming roles, which makes it possible to target emulators such as [LocalStack](https://github.com/localstack/localstack) with a single override.", "http://localhost:4566"),
 		docs.FieldAdvanced("credentials", "Optional manual configuration of AWS credentials to use. More information can be found [in this document](/docs/guides/aws).").WithChildren(
 			docs.FieldAdvanced("profile", "A profile from `~/.aws/credentials` to use."),
 			docs.FieldAdvanced("id", "The ID of credentials to use."),
 			docs.FieldAdvanced("secret", "The secret for the credentials being used."),
 			docs.FieldAdvanced("token", "The token for the credentials being used, required when using short term credentials."),
+			docs.FieldAdvanced("web_identity_token_file", "An optional path of a web identity token file used to assume `role`, such as those provided to Kubernetes service accounts by IAM roles for service accounts (IRSA). When the `AWS_WEB_IDENTITY_TOKEN_FILE` and `AWS_ROLE_ARN` environment variables are set this is done automatically.", "/var/run/secrets/eks.amazonaws.com/serviceaccount/token").AtVersion("3.44.0"),
 			docs.FieldAdvanced("role", "A role ARN to assume."),
 			docs.FieldAdvanced("role_external_id", "An external ID to provide when assuming a role."),
+			docs.FieldAdvanced("role_session_name", "An optional session name to use when assuming roles.").AtVersion("3.44.0"),
+			docs.FieldAdvanced("role_chain", "An optional list of roles to assume in order after `role`, where each role is assumed using the credentials of the previous one.", []interface{}{
+				map[string]interface{}{
+					"role":             "arn:aws:iam::123456789012:role/foo",
+					"role_external_id": "bar",
+				},
+			}).Array().WithChildren(
+				docs.FieldAdvanced("role", "A role ARN to assume.").HasDefault(""),
+				docs.FieldAdvanced("role_external_id", "An external ID to provide when assuming the role.").HasDefault(""),
+			).AtVersion("3.44.0"),
+			docs.FieldAdvanced("sts_regional_endpoint", "Whether to use the regional STS endpoint of `region` when assuming roles rather than the global endpoint.").AtVersion("3.44.0"),
 		),
 	}
 }
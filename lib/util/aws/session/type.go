@@ -1,24 +1,37 @@
 package session
 
 import (
+	"errors"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/session"
 )
 
 //------------------------------------------------------------------------------
 
-// CredentialsConfig contains configuration params for AWS credentials.
-type CredentialsConfig struct {
-	Profile    string `json:"profile" yaml:"profile"`
-	ID         string `json:"id" yaml:"id"`
-	Secret     string `json:"secret" yaml:"secret"`
-	Token      string `json:"token" yaml:"token"`
+// RoleConfig contains configuration params for a role to assume.
+type RoleConfig struct {
 	Role       string `json:"role" yaml:"role"`
 	ExternalID string `json:"role_external_id" yaml:"role_external_id"`
 }
 
+// CredentialsConfig contains configuration params for AWS credentials.
+type CredentialsConfig struct {
+	Profile              string       `json:"profile" yaml:"profile"`
+	ID                   string       `json:"id" yaml:"id"`
+	Secret               string       `json:"secret" yaml:"secret"`
+	Token                string       `json:"token" yaml:"token"`
+	WebIdentityTokenFile string       `json:"web_identity_token_file" yaml:"web_identity_token_file"`
+	Role                 string       `json:"role" yaml:"role"`
+	ExternalID           string       `json:"role_external_id" yaml:"role_external_id"`
+	RoleSessionName      string       `json:"role_session_name" yaml:"role_session_name"`
+	RoleChain            []RoleConfig `json:"role_chain" yaml:"role_chain"`
+	STSRegionalEndpoint  bool         `json:"sts_regional_endpoint" yaml:"sts_regional_endpoint"`
+}
+
 // Config contains configuration fields for an AWS session. This config is
 // common across any AWS components.
 type Config struct {
@@ -31,12 +44,16 @@ type Config struct {
 func NewConfig() Config {
 	return Config{
 		Credentials: CredentialsConfig{
-			Profile:    "",
-			ID:         "",
-			Secret:     "",
-			Token:      "",
-			Role:       "",
-			ExternalID: "",
+			Profile:              "",
+			ID:                   "",
+			Secret:               "",
+			Token:                "",
+			WebIdentityTokenFile: "",
+			Role:                 "",
+			ExternalID:           "",
+			RoleSessionName:      "",
+			RoleChain:            []RoleConfig{},
+			STSRegionalEndpoint:  false,
 		},
 		Endpoint: "",
 		Region:   "eu-west-1",
@@ -68,6 +85,10 @@ func (c Config) GetSession(opts ...func(*aws.Config)) (*session.Session, error)
 		))
 	}
 
+	if c.Credentials.STSRegionalEndpoint {
+		awsConf = awsConf.WithSTSRegionalEndpoint(endpoints.RegionalSTSEndpoint)
+	}
+
 	for _, opt := range opts {
 		opt(awsConf)
 	}
@@ -77,21 +98,54 @@ func (c Config) GetSession(opts ...func(*aws.Config)) (*session.Session, error)
 		return nil, err
 	}
 
-	if len(c.Credentials.Role) > 0 {
-		var opts []func(*stscreds.AssumeRoleProvider)
-		if len(c.Credentials.ExternalID) > 0 {
-			opts = []func(*stscreds.AssumeRoleProvider){
-				func(p *stscreds.AssumeRoleProvider) {
-					p.ExternalID = &c.Credentials.ExternalID
-				},
-			}
-		}
-		sess.Config = sess.Config.WithCredentials(
-			stscreds.NewCredentials(sess, c.Credentials.Role, opts...),
-		)
+	creds, err := c.Credentials.assumeRoles(sess)
+	if err != nil {
+		return nil, err
+	}
+	if creds != nil {
+		sess.Config = sess.Config.WithCredentials(creds)
 	}
 
 	return sess, nil
 }
 
+func (c CredentialsConfig) roleOpts(externalID string) func(*stscreds.AssumeRoleProvider) {
+	return func(p *stscreds.AssumeRoleProvider) {
+		if len(externalID) > 0 {
+			p.ExternalID = aws.String(externalID)
+		}
+		if len(c.RoleSessionName) > 0 {
+			p.RoleSessionName = c.RoleSessionName
+		}
+	}
+}
+
+// assumeRoles returns credentials obtained by assuming the configured role,
+// either with the credentials of the session or a web identity token, followed
+// by each role of the chain in order using the credentials of the previous
+// role. Returns nil when no roles are configured.
+func (c CredentialsConfig) assumeRoles(sess *session.Session) (*credentials.Credentials, error) {
+	var creds *credentials.Credentials
+	if len(c.WebIdentityTokenFile) > 0 {
+		if len(c.Role) == 0 {
+			return nil, errors.New("a role must be specified when using a web_identity_token_file")
+		}
+		creds = stscreds.NewWebIdentityCredentials(sess, c.Role, c.RoleSessionName, c.WebIdentityTokenFile)
+	} else if len(c.Role) > 0 {
+		creds = stscreds.NewCredentials(sess, c.Role, c.roleOpts(c.ExternalID))
+	}
+
+	for _, r := range c.RoleChain {
+		if len(r.Role) == 0 {
+			return nil, errors.New("each role of a role_chain must specify a role")
+		}
+		chainSess := sess
+		if creds != nil {
+			chainSess = sess.Copy(aws.NewConfig().WithCredentials(creds))
+		}
+		creds = stscreds.NewCredentials(chainSess, r.Role, c.roleOpts(r.ExternalID))
+	}
+	return creds, nil
+}
+
 //------------------------------------------------------------------------------
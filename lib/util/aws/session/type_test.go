@@ -0,0 +1,45 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSessionRoles(t *testing.T) {
+	conf := NewConfig()
+	conf.Credentials.ID = "foo"
+	conf.Credentials.Secret = "bar"
+
+	sess, err := conf.GetSession()
+	require.NoError(t, err)
+
+	staticCreds := sess.Config.Credentials
+
+	conf.Credentials.Role = "arn:aws:iam::123456789012:role/foo"
+	conf.Credentials.RoleChain = []RoleConfig{
+		{Role: "arn:aws:iam::123456789012:role/bar", ExternalID: "baz"},
+	}
+	conf.Credentials.STSRegionalEndpoint = true
+
+	sess, err = conf.GetSession()
+	require.NoError(t, err)
+	assert.NotEqual(t, staticCreds, sess.Config.Credentials)
+	assert.Equal(t, endpoints.RegionalSTSEndpoint, sess.Config.STSRegionalEndpoint)
+}
+
+func TestGetSessionRoleErrors(t *testing.T) {
+	conf := NewConfig()
+	conf.Credentials.WebIdentityTokenFile = "/tmp/token"
+
+	_, err := conf.GetSession()
+	require.Error(t, err)
+
+	conf = NewConfig()
+	conf.Credentials.RoleChain = []RoleConfig{{}}
+
+	_, err = conf.GetSession()
+	require.Error(t, err)
+}
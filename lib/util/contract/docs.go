@@ -0,0 +1,17 @@
+package contract
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+)
+
+// FieldSpecs returns documentation specs for contract fields.
+func FieldSpecs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldCommon("label", "A unique label of the contract, used by `contract` processors to enforce it."),
+		docs.FieldCommon("schema", "An optional [JSON Schema](https://json-schema.org/) document that messages must satisfy. Use either this or the `schema_path` field."),
+		docs.FieldCommon("schema_path", "The path of an optional JSON Schema document that messages must satisfy, which must start with `file://` or `http://`. Use either this or the `schema` field.", "file://./schemas/order.json"),
+		docs.FieldCommon("checks", "A list of [Bloblang queries](/docs/guides/bloblang/about) that must each return `true` for a message to satisfy the contract.", []string{"this.amount > 0", `this.currency.length() == 3`}).Array(),
+		docs.FieldCommon("quarantine", "The label of an optional output resource to send messages that violate the contract to. When empty messages that violate the contract are flagged as having failed and continue through the pipeline."),
+		docs.FieldAdvanced("log_every", "Violations are sampled to logs, where the first violation and every nth violation thereafter is logged at the `WARN` level."),
+	}
+}
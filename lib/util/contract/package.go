@@ -0,0 +1,4 @@
+// Package contract provides Benthos configuration fields and an implementation
+// of data contracts, which declare the expected schema and invariants of
+// messages and are enforced at named points of a pipeline.
+package contract
@@ -0,0 +1,194 @@
+package contract
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	jsonschema "github.com/xeipuuv/gojsonschema"
+)
+
+//------------------------------------------------------------------------------
+
+// Config contains configuration fields for a data contract.
+type Config struct {
+	Label      string   `json:"label" yaml:"label"`
+	Schema     string   `json:"schema" yaml:"schema"`
+	SchemaPath string   `json:"schema_path" yaml:"schema_path"`
+	Checks     []string `json:"checks" yaml:"checks"`
+	Quarantine string   `json:"quarantine" yaml:"quarantine"`
+	LogEvery   int      `json:"log_every" yaml:"log_every"`
+}
+
+// NewConfig creates a new Config with default values.
+func NewConfig() Config {
+	return Config{
+		Label:      "",
+		Schema:     "",
+		SchemaPath: "",
+		Checks:     []string{},
+		Quarantine: "",
+		LogEvery:   100,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type check struct {
+	query string
+	exec  *mapping.Executor
+}
+
+// Contract checks messages against a declared schema and invariants, counting
+// violations and sampling them to logs.
+//
+// This component is safe to use concurrently across goroutines.
+type Contract struct {
+	label      string
+	quarantine string
+	schema     *jsonschema.Schema
+	checks     []check
+	logEvery   int64
+
+	violations int64
+
+	log          log.Modular
+	mChecked     metrics.StatCounter
+	mViolations  metrics.StatCounter
+	mQuarantined metrics.StatCounter
+}
+
+// New creates a contract from a config.
+func New(conf Config, log log.Modular, stats metrics.Type) (*Contract, error) {
+	c := &Contract{
+		label:      conf.Label,
+		quarantine: conf.Quarantine,
+		logEvery:   int64(conf.LogEvery),
+
+		log:          log,
+		mChecked:     stats.GetCounter("checked"),
+		mViolations:  stats.GetCounter("violations"),
+		mQuarantined: stats.GetCounter("quarantined"),
+	}
+	if c.logEvery <= 0 {
+		c.logEvery = 1
+	}
+
+	var err error
+	if conf.SchemaPath != "" {
+		if conf.Schema != "" {
+			return nil, errors.New("cannot specify both a schema and a schema_path")
+		}
+		if !(strings.HasPrefix(conf.SchemaPath, "file://") || strings.HasPrefix(conf.SchemaPath, "http://")) {
+			return nil, errors.New("invalid schema_path provided, must start with file:// or http://")
+		}
+		if c.schema, err = jsonschema.NewSchema(jsonschema.NewReferenceLoader(conf.SchemaPath)); err != nil {
+			return nil, fmt.Errorf("failed to load JSON schema definition: %v", err)
+		}
+	} else if conf.Schema != "" {
+		if c.schema, err = jsonschema.NewSchema(jsonschema.NewStringLoader(conf.Schema)); err != nil {
+			return nil, fmt.Errorf("failed to load JSON schema definition: %v", err)
+		}
+	}
+
+	for i, query := range conf.Checks {
+		exec, err := bloblang.NewMapping("", query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse check %v: %v", i, err)
+		}
+		c.checks = append(c.checks, check{query: query, exec: exec})
+	}
+
+	if c.schema == nil && len(c.checks) == 0 {
+		return nil, errors.New("a contract must have a schema, a schema_path or at least one check")
+	}
+	return c, nil
+}
+
+// Label returns the label of the contract.
+func (c *Contract) Label() string {
+	return c.label
+}
+
+// Quarantine returns the label of the output resource that messages violating
+// the contract should be sent to, or an empty string.
+func (c *Contract) Quarantine() string {
+	return c.quarantine
+}
+
+// Violations returns the total number of violations of the contract.
+func (c *Contract) Violations() int64 {
+	return atomic.LoadInt64(&c.violations)
+}
+
+func (c *Contract) violation(index int, msg types.Message) error {
+	if c.schema != nil {
+		jsonPart, err := msg.Get(index).JSON()
+		if err != nil {
+			return fmt.Errorf("failed to parse message as JSON: %v", err)
+		}
+		result, err := c.schema.Validate(jsonschema.NewGoLoader(jsonPart))
+		if err != nil {
+			return fmt.Errorf("failed to validate schema: %v", err)
+		}
+		if !result.Valid() {
+			descs := make([]string, 0, len(result.Errors()))
+			for _, desc := range result.Errors() {
+				descs = append(descs, desc.String())
+			}
+			return fmt.Errorf("schema mismatch: %v", strings.Join(descs, ", "))
+		}
+	}
+	for _, check := range c.checks {
+		pass, err := check.exec.QueryPart(index, msg)
+		if err != nil {
+			return fmt.Errorf("check '%v' failed: %v", check.query, err)
+		}
+		if !pass {
+			return fmt.Errorf("check '%v' was not satisfied", check.query)
+		}
+	}
+	return nil
+}
+
+// Check a message part against the contract at a named enforcement point,
+// returns an error describing the violation if the part does not satisfy the
+// contract.
+func (c *Contract) Check(point string, index int, msg types.Message) error {
+	c.mChecked.Incr(1)
+	err := c.violation(index, msg)
+	if err == nil {
+		return nil
+	}
+
+	c.mViolations.Incr(1)
+	if n := atomic.AddInt64(&c.violations, 1); (n-1)%c.logEvery == 0 {
+		c.log.Warnf("Contract '%v' violated at '%v' (%v violations in total): %v\n", c.label, point, n, err)
+	}
+	return fmt.Errorf("contract '%v' violated: %w", c.label, err)
+}
+
+// Quarantined records that a number of violating messages were sent to the
+// quarantine output.
+func (c *Contract) Quarantined(n int) {
+	c.mQuarantined.Incr(int64(n))
+}
+
+//------------------------------------------------------------------------------
+
+// FromManager obtains a contract by its label from a manager.
+func FromManager(mgr types.Manager, label string) (*Contract, error) {
+	cProv, ok := mgr.(interface {
+		GetContract(name string) (*Contract, error)
+	})
+	if !ok {
+		return nil, errors.New("manager does not support contracts")
+	}
+	return cProv.GetContract(label)
+}
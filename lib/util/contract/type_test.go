@@ -0,0 +1,71 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContractConfigErrors(t *testing.T) {
+	tests := map[string]struct {
+		conf Config
+		err  string
+	}{
+		"empty": {
+			conf: Config{Label: "foo"},
+			err:  "a contract must have a schema, a schema_path or at least one check",
+		},
+		"both schemas": {
+			conf: Config{Label: "foo", Schema: `{}`, SchemaPath: "file://./foo.json"},
+			err:  "cannot specify both a schema and a schema_path",
+		},
+		"bad check": {
+			conf: Config{Label: "foo", Checks: []string{`root = this.`}},
+			err:  "failed to parse check 0",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			_, err := New(test.conf, log.Noop(), metrics.Noop())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+}
+
+func TestContractCheck(t *testing.T) {
+	conf := NewConfig()
+	conf.Label = "users"
+	conf.Schema = `{
+  "type": "object",
+  "properties": {
+    "name": { "type": "string" },
+    "age": { "type": "number" }
+  },
+  "required": [ "name" ]
+}`
+	conf.Checks = []string{`this.age >= 0`}
+
+	c, err := New(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, "users", c.Label())
+
+	msg := message.New([][]byte{
+		[]byte(`{"name":"foo","age":10}`),
+		[]byte(`{"age":10}`),
+		[]byte(`{"name":"foo","age":-1}`),
+		[]byte(`not json`),
+	})
+
+	assert.NoError(t, c.Check("test", 0, msg))
+	assert.EqualError(t, c.Check("test", 1, msg), "contract 'users' violated: schema mismatch: (root): name is required")
+	assert.EqualError(t, c.Check("test", 2, msg), "contract 'users' violated: check 'this.age >= 0' was not satisfied")
+	assert.Error(t, c.Check("test", 3, msg))
+	assert.Equal(t, int64(3), c.Violations())
+}
@@ -0,0 +1,112 @@
+package murmur3
+
+import "hash"
+
+type murmur3 struct {
+	data   []byte
+	cached *uint32
+}
+
+// New32 creates a murmur 3 (x86, 32-bit) based hash.Hash32 implementation
+// with a seed of zero.
+func New32() hash.Hash32 {
+	return &murmur3{
+		data: make([]byte, 0),
+	}
+}
+
+// Write a slice of data to the hasher.
+func (mur *murmur3) Write(p []byte) (n int, err error) {
+	mur.data = append(mur.data, p...)
+	mur.cached = nil
+	return len(p), nil
+}
+
+// Sum appends the current hash to b and returns the resulting slice.
+// It does not change the underlying hash state.
+func (mur *murmur3) Sum(b []byte) []byte {
+	v := mur.Sum32()
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// Reset resets the Hash to its initial state.
+func (mur *murmur3) Reset() {
+	mur.data = mur.data[0:0]
+	mur.cached = nil
+}
+
+// Size returns the number of bytes Sum will return.
+func (mur *murmur3) Size() int {
+	return 4
+}
+
+// BlockSize returns the hash's underlying block size.
+// The Write method must be able to accept any amount
+// of data, but it may operate more efficiently if all writes
+// are a multiple of the block size.
+func (mur *murmur3) BlockSize() int {
+	return 4
+}
+
+const (
+	c1 uint32 = 0xcc9e2d51
+	c2 uint32 = 0x1b873593
+)
+
+func rotl32(x uint32, r uint) uint32 {
+	return (x << r) | (x >> (32 - r))
+}
+
+func (mur *murmur3) Sum32() uint32 {
+	if mur.cached != nil {
+		return *mur.cached
+	}
+
+	length := len(mur.data)
+	nblocks := length / 4
+
+	var h uint32
+	for i := 0; i < nblocks; i++ {
+		i4 := i * 4
+		k := uint32(mur.data[i4]) |
+			uint32(mur.data[i4+1])<<8 |
+			uint32(mur.data[i4+2])<<16 |
+			uint32(mur.data[i4+3])<<24
+		k *= c1
+		k = rotl32(k, 15)
+		k *= c2
+
+		h ^= k
+		h = rotl32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+
+	tail := mur.data[nblocks*4:]
+
+	var k uint32
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = rotl32(k, 15)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(length)
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+
+	cached := h
+	mur.cached = &cached
+	return cached
+}
@@ -0,0 +1,35 @@
+package murmur3
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestMurmur3SanityCheck(t *testing.T) {
+	tests := []struct {
+		data     []string
+		expected uint32
+	}{
+		{[]string{""}, 0},
+		{[]string{"a"}, 0x3c2569b2},
+		{[]string{"abc"}, 0xb3dd93fa},
+		{[]string{"hello"}, 0x248bfa47},
+		{[]string{"hello world"}, 0x5e928f0f},
+		{[]string{"hello" + " " + "world"}, 0x5e928f0f},
+		{[]string{"The quick brown fox jumps over the lazy dog"}, 0x2e4ff723},
+		{[]string{"a", "b", "c"}, 0xb3dd93fa},
+	}
+	for i, tt := range tests {
+		t.Run(strconv.Itoa(i)+". ", func(t *testing.T) {
+			mur := New32()
+			for _, datum := range tt.data {
+				_, _ = mur.Write([]byte(datum))
+			}
+			calculated := mur.Sum32()
+			if calculated != tt.expected {
+				t.Errorf("murmur3 hash failed: is -> %v != %v <- should be", calculated, tt.expected)
+				return
+			}
+		})
+	}
+}
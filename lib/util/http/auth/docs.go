@@ -26,14 +26,24 @@ func oAuthFieldSpec() docs.FieldSpec {
 	)
 }
 
-func oAuth2FieldSpec() docs.FieldSpec {
+// OAuth2FieldSpec returns a field spec for an OAuth2 token exchange.
+func OAuth2FieldSpec() docs.FieldSpec {
 	return docs.FieldAdvanced("oauth2",
-		"Allows you to specify open authentication via OAuth version 2 using the client credentials token flow.",
+		"Allows you to specify open authentication via OAuth version 2 using either the client credentials or the JWT bearer token flow. Tokens are cached and refreshed automatically once they expire.",
 	).WithChildren(
 		docs.FieldCommon("enabled", "Whether to use OAuth version 2 in requests."),
-		docs.FieldCommon("client_key", "A value used to identify the client to the token provider."),
+		docs.FieldCommon("client_key", "A value used to identify the client to the token provider. When the JWT bearer flow is enabled this is used as the issuer of the signed assertion."),
 		docs.FieldCommon("client_secret", "A secret used to establish ownership of the client key."),
 		docs.FieldCommon("token_url", "The URL of the token provider."),
+		docs.FieldAdvanced("scopes", "A list of scopes to request from the token provider.", []string{"read", "write"}).Array().AtVersion("3.44.0"),
+		docs.FieldAdvanced("endpoint_params", "A map of additional parameters to send to the token provider with client credentials token requests.", map[string]string{"audience": "https://example.com/api"}).Map().AtVersion("3.44.0"),
+		docs.FieldAdvanced("jwt_bearer", "Allows you to obtain tokens using the JWT bearer flow (RFC 7523), where a JWT signed with a private key is exchanged for an access token, instead of the client credentials flow.").WithChildren(
+			docs.FieldCommon("enabled", "Whether to use the JWT bearer flow."),
+			docs.FieldCommon("private_key_file", "A file containing a PEM encoded RSA private key used to sign assertions."),
+			docs.FieldAdvanced("private_key_id", "An optional key ID to set within the header of assertions."),
+			docs.FieldAdvanced("subject", "An optional subject of assertions, used when impersonating a user."),
+			docs.FieldAdvanced("audience", "An optional audience of assertions, defaults to the token URL."),
+		).AtVersion("3.44.0"),
 	)
 }
 
@@ -50,7 +60,32 @@ func FieldSpecs() docs.FieldSpecs {
 func FieldSpecsExpanded() docs.FieldSpecs {
 	return docs.FieldSpecs{
 		oAuthFieldSpec(),
-		oAuth2FieldSpec(),
+		OAuth2FieldSpec(),
 		BasicAuthFieldSpec(),
 	}
 }
+
+// ServerFieldSpec returns a field spec for authenticating requests made to an
+// HTTP server.
+func ServerFieldSpec() docs.FieldSpec {
+	return docs.FieldAdvanced("auth",
+		"Allows you to require authentication of requests made to the server. When any of `tokens`, `basic_auth` or `jwt` are configured a request must satisfy at least one of them.",
+	).WithChildren(
+		docs.FieldAdvanced("client_ca_file", "An optional CA certificate file used to verify client certificates, enabling mutual TLS. Requests without a valid client certificate are rejected. Only valid when the server has TLS enabled."),
+		docs.FieldAdvanced("tokens", "A list of static tokens, one of which must be provided as a bearer token within the `Authorization` header of requests.").Array(),
+		docs.FieldAdvanced("basic_auth", "Allows you to require basic authentication of requests.").WithChildren(
+			docs.FieldCommon("enabled", "Whether to require basic authentication of requests."),
+			docs.FieldCommon("username", "The username that requests must authenticate as."),
+			docs.FieldCommon("password", "The password that requests must authenticate with."),
+		),
+		docs.FieldAdvanced("jwt", "Allows you to require a JSON Web Token signed by an OpenID Connect provider as a bearer token within the `Authorization` header of requests. RSA and ECDSA signing algorithms are supported.").WithChildren(
+			docs.FieldCommon("enabled", "Whether to validate JSON Web Tokens."),
+			docs.FieldCommon("jwks_url", "The URL of the JSON Web Key Set used to verify token signatures.", "https://example.com/.well-known/jwks.json"),
+			docs.FieldCommon("issuer", "An optional issuer that the `iss` claim of tokens must match."),
+			docs.FieldCommon("audience", "An optional audience that the `aud` claim of tokens must contain."),
+			docs.FieldAdvanced("refresh_interval", "The period after which the key set is fetched again. Unknown key IDs also trigger a fetch at most once per minute."),
+		),
+		docs.FieldAdvanced("allowed_ips", "An optional list of IP addresses or CIDR ranges that requests must originate from.", []string{"10.0.0.0/8", "127.0.0.1"}).Array(),
+		docs.FieldAdvanced("exempt_paths", "A list of endpoint paths that do not require authentication, such as health checks.", []string{"/ping", "/ready"}).Array(),
+	)
+}
@@ -2,45 +2,143 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/oauth2/jwt"
 )
 
 //------------------------------------------------------------------------------
 
+// OAuth2JWTBearerConfig holds the configuration parameters for obtaining
+// OAuth2 tokens with the JWT bearer grant type.
+type OAuth2JWTBearerConfig struct {
+	Enabled        bool   `json:"enabled" yaml:"enabled"`
+	PrivateKeyFile string `json:"private_key_file" yaml:"private_key_file"`
+	PrivateKeyID   string `json:"private_key_id" yaml:"private_key_id"`
+	Subject        string `json:"subject" yaml:"subject"`
+	Audience       string `json:"audience" yaml:"audience"`
+}
+
 // OAuth2Config holds the configuration parameters for an OAuth2 exchange.
 type OAuth2Config struct {
-	Enabled      bool   `json:"enabled" yaml:"enabled"`
-	ClientKey    string `json:"client_key" yaml:"client_key"`
-	ClientSecret string `json:"client_secret" yaml:"client_secret"`
-	TokenURL     string `json:"token_url" yaml:"token_url"`
+	Enabled        bool                  `json:"enabled" yaml:"enabled"`
+	ClientKey      string                `json:"client_key" yaml:"client_key"`
+	ClientSecret   string                `json:"client_secret" yaml:"client_secret"`
+	TokenURL       string                `json:"token_url" yaml:"token_url"`
+	Scopes         []string              `json:"scopes" yaml:"scopes"`
+	EndpointParams map[string]string     `json:"endpoint_params" yaml:"endpoint_params"`
+	JWTBearer      OAuth2JWTBearerConfig `json:"jwt_bearer" yaml:"jwt_bearer"`
 }
 
 // NewOAuth2Config returns a new OAuth2Config with default values.
 func NewOAuth2Config() OAuth2Config {
 	return OAuth2Config{
-		Enabled:      false,
-		ClientKey:    "",
-		ClientSecret: "",
-		TokenURL:     "",
+		Enabled:        false,
+		ClientKey:      "",
+		ClientSecret:   "",
+		TokenURL:       "",
+		Scopes:         []string{},
+		EndpointParams: map[string]string{},
+		JWTBearer: OAuth2JWTBearerConfig{
+			Enabled:        false,
+			PrivateKeyFile: "",
+			PrivateKeyID:   "",
+			Subject:        "",
+			Audience:       "",
+		},
 	}
 }
 
 //------------------------------------------------------------------------------
 
 // Client returns an http.Client with OAuth2 configured.
+//
+// A configuration that cannot be used to obtain tokens, such as a missing
+// private key file, results in a client where all requests fail. Use
+// ClientWithBase in order to handle such errors at construction.
 func (oauth OAuth2Config) Client(ctx context.Context) *http.Client {
 	if !oauth.Enabled {
 		var client http.Client
 		return &client
 	}
+	client, err := oauth.ClientWithBase(ctx, &http.Client{})
+	if err != nil {
+		return &http.Client{Transport: errTransport{err}}
+	}
+	return client
+}
 
-	conf := &clientcredentials.Config{
-		ClientID:     oauth.ClientKey,
-		ClientSecret: oauth.ClientSecret,
-		TokenURL:     oauth.TokenURL,
+type errTransport struct {
+	err error
+}
+
+func (e errTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, e.err
+}
+
+// ClientWithBase returns an http.Client with OAuth2 configured. Requests,
+// including those made to the token URL, are sent with the transport of the
+// provided base client. Tokens are cached and refreshed automatically once they
+// expire.
+func (oauth OAuth2Config) ClientWithBase(ctx context.Context, base *http.Client) (*http.Client, error) {
+	if !oauth.Enabled {
+		return base, nil
+	}
+	ts, err := oauth.TokenSource(ctx, base)
+	if err != nil {
+		return nil, err
 	}
+	return &http.Client{
+		Transport: &oauth2.Transport{
+			Source: ts,
+			Base:   base.Transport,
+		},
+		Timeout: base.Timeout,
+	}, nil
+}
 
-	return conf.Client(ctx)
+// TokenSource returns a source of OAuth2 tokens obtained from the token URL
+// using the provided base client, where tokens are cached and refreshed
+// automatically once they expire.
+func (oauth OAuth2Config) TokenSource(ctx context.Context, base *http.Client) (oauth2.TokenSource, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, base)
+
+	if oauth.JWTBearer.Enabled {
+		if len(oauth.JWTBearer.PrivateKeyFile) == 0 {
+			return nil, errors.New("a private_key_file must be specified for the jwt_bearer grant type")
+		}
+		keyBytes, err := ioutil.ReadFile(oauth.JWTBearer.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private_key_file: %w", err)
+		}
+		conf := &jwt.Config{
+			Email:        oauth.ClientKey,
+			PrivateKey:   keyBytes,
+			PrivateKeyID: oauth.JWTBearer.PrivateKeyID,
+			Subject:      oauth.JWTBearer.Subject,
+			Audience:     oauth.JWTBearer.Audience,
+			Scopes:       oauth.Scopes,
+			TokenURL:     oauth.TokenURL,
+		}
+		return conf.TokenSource(ctx), nil
+	}
+
+	params := url.Values{}
+	for k, v := range oauth.EndpointParams {
+		params.Set(k, v)
+	}
+	conf := &clientcredentials.Config{
+		ClientID:       oauth.ClientKey,
+		ClientSecret:   oauth.ClientSecret,
+		TokenURL:       oauth.TokenURL,
+		Scopes:         oauth.Scopes,
+		EndpointParams: params,
+	}
+	return oauth2.ReuseTokenSource(nil, conf.TokenSource(ctx)), nil
 }
@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuth2ClientDisabled(t *testing.T) {
+	base := &http.Client{}
+
+	client, err := NewOAuth2Config().ClientWithBase(context.Background(), base)
+	require.NoError(t, err)
+	assert.True(t, client == base)
+
+	assert.NotNil(t, NewOAuth2Config().Client(context.Background()))
+}
+
+func TestOAuth2ClientBadKeyFile(t *testing.T) {
+	conf := NewOAuth2Config()
+	conf.Enabled = true
+	conf.JWTBearer.Enabled = true
+
+	_, err := conf.ClientWithBase(context.Background(), &http.Client{})
+	require.EqualError(t, err, "a private_key_file must be specified for the jwt_bearer grant type")
+
+	client := conf.Client(context.Background())
+	_, err = client.Get("http://localhost:1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a private_key_file must be specified for the jwt_bearer grant type")
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// ServerConfig contains configuration params for authenticating requests made
+// to an HTTP server.
+type ServerConfig struct {
+	ClientCAFile string          `json:"client_ca_file" yaml:"client_ca_file"`
+	Tokens       []string        `json:"tokens" yaml:"tokens"`
+	BasicAuth    BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
+	JWT          JWTConfig       `json:"jwt" yaml:"jwt"`
+	AllowedIPs   []string        `json:"allowed_ips" yaml:"allowed_ips"`
+	ExemptPaths  []string        `json:"exempt_paths" yaml:"exempt_paths"`
+}
+
+// NewServerConfig creates a new ServerConfig with default values.
+func NewServerConfig() ServerConfig {
+	return ServerConfig{
+		ClientCAFile: "",
+		Tokens:       []string{},
+		BasicAuth:    NewBasicAuthConfig(),
+		JWT:          NewJWTConfig(),
+		AllowedIPs:   []string{},
+		ExemptPaths:  []string{},
+	}
+}
+
+// ApplyTLS modifies the TLS configuration of a server in order to verify
+// client certificates against the configured CA when mutual TLS is enabled.
+// Client certificates are verified when given and requests without one are
+// rejected by the server authenticator, which allows exempt paths to be served
+// without a certificate.
+func (s ServerConfig) ApplyTLS(conf *tls.Config) error {
+	if len(s.ClientCAFile) == 0 {
+		return nil
+	}
+	caBytes, err := ioutil.ReadFile(s.ClientCAFile)
+	if err != nil {
+		return fmt.Errorf("failed to read client_ca_file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBytes) {
+		return errors.New("failed to parse any certificates from client_ca_file")
+	}
+	conf.ClientCAs = pool
+	conf.ClientAuth = tls.VerifyClientCertIfGiven
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// ServerAuth authenticates requests made to an HTTP server according to a
+// ServerConfig.
+type ServerAuth struct {
+	requireCert bool
+	tokens      [][]byte
+	basic       BasicAuthConfig
+	jwt         *jwtValidator
+	allowedNets []*net.IPNet
+	exempt      map[string]struct{}
+}
+
+// NewServerAuth creates a server authenticator from a config.
+func NewServerAuth(conf ServerConfig) (*ServerAuth, error) {
+	s := &ServerAuth{
+		requireCert: len(conf.ClientCAFile) > 0,
+		basic:       conf.BasicAuth,
+		exempt:      map[string]struct{}{},
+	}
+	for _, t := range conf.Tokens {
+		s.tokens = append(s.tokens, []byte(t))
+	}
+	if conf.JWT.Enabled {
+		var err error
+		if s.jwt, err = newJWTValidator(conf.JWT); err != nil {
+			return nil, err
+		}
+	}
+	for _, ip := range conf.AllowedIPs {
+		if !strings.Contains(ip, "/") {
+			if strings.Contains(ip, ":") {
+				ip += "/128"
+			} else {
+				ip += "/32"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(ip)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse allowed IP '%v': %w", ip, err)
+		}
+		s.allowedNets = append(s.allowedNets, ipNet)
+	}
+	for _, p := range conf.ExemptPaths {
+		s.exempt[p] = struct{}{}
+	}
+	return s, nil
+}
+
+// Exempt adds paths that are exempt from authentication.
+func (s *ServerAuth) Exempt(paths ...string) {
+	for _, p := range paths {
+		s.exempt[p] = struct{}{}
+	}
+}
+
+// Enabled returns true if any authentication is required by the server.
+func (s *ServerAuth) Enabled() bool {
+	return s.requireCert || s.credentialsRequired() || len(s.allowedNets) > 0
+}
+
+func (s *ServerAuth) credentialsRequired() bool {
+	return len(s.tokens) > 0 || s.basic.Enabled || s.jwt != nil
+}
+
+func (s *ServerAuth) ipAllowed(r *http.Request) bool {
+	if len(s.allowedNets) == 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range s.allowedNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ServerAuth) credentialsValid(r *http.Request) bool {
+	if user, pass, ok := r.BasicAuth(); ok && s.basic.Enabled {
+		userMatch := subtle.ConstantTimeCompare([]byte(user), []byte(s.basic.Username)) == 1
+		passMatch := subtle.ConstantTimeCompare([]byte(pass), []byte(s.basic.Password)) == 1
+		if userMatch && passMatch {
+			return true
+		}
+	}
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return false
+	}
+	token := []byte(strings.TrimPrefix(authHeader, "Bearer "))
+	for _, t := range s.tokens {
+		if subtle.ConstantTimeCompare(token, t) == 1 {
+			return true
+		}
+	}
+	if s.jwt != nil {
+		return s.jwt.validate(string(token)) == nil
+	}
+	return false
+}
+
+// Authenticate returns the HTTP status code to respond with if a request is
+// not authorised, or zero if the request is authorised.
+func (s *ServerAuth) Authenticate(r *http.Request) int {
+	if _, exempt := s.exempt[r.URL.Path]; exempt {
+		return 0
+	}
+	if !s.ipAllowed(r) {
+		return http.StatusForbidden
+	}
+	if s.requireCert && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+		return http.StatusUnauthorized
+	}
+	if s.credentialsRequired() && !s.credentialsValid(r) {
+		return http.StatusUnauthorized
+	}
+	return 0
+}
+
+// Wrap returns an http.Handler that authenticates requests before passing them
+// to a child handler.
+func (s *ServerAuth) Wrap(h http.Handler) http.Handler {
+	if !s.Enabled() {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if code := s.Authenticate(r); code != 0 {
+			if code == http.StatusUnauthorized && s.basic.Enabled {
+				w.Header().Set("WWW-Authenticate", `Basic realm="benthos"`)
+			}
+			http.Error(w, http.StatusText(code), code)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// WrapFunc returns an http.HandlerFunc that authenticates requests before
+// passing them to a child handler func.
+func (s *ServerAuth) WrapFunc(h http.HandlerFunc) http.HandlerFunc {
+	return s.Wrap(h).ServeHTTP
+}
+
+//------------------------------------------------------------------------------
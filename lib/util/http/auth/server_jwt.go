@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+//------------------------------------------------------------------------------
+
+// JWTConfig contains configuration params for validating JSON Web Tokens
+// issued by an OpenID Connect provider.
+type JWTConfig struct {
+	Enabled         bool   `json:"enabled" yaml:"enabled"`
+	JWKSURL         string `json:"jwks_url" yaml:"jwks_url"`
+	Issuer          string `json:"issuer" yaml:"issuer"`
+	Audience        string `json:"audience" yaml:"audience"`
+	RefreshInterval string `json:"refresh_interval" yaml:"refresh_interval"`
+}
+
+// NewJWTConfig returns a new JWTConfig with default values.
+func NewJWTConfig() JWTConfig {
+	return JWTConfig{
+		Enabled:         false,
+		JWKSURL:         "",
+		Issuer:          "",
+		Audience:        "",
+		RefreshInterval: "1h",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var (
+	errJWTMalformed = errors.New("malformed token")
+	errJWTSignature = errors.New("invalid token signature")
+)
+
+type jwtValidator struct {
+	conf     JWTConfig
+	client   *http.Client
+	interval time.Duration
+
+	// Key sets are fetched without holding keysMut, and concurrent fetches
+	// are collapsed into one request.
+	fetchGroup singleflight.Group
+
+	keysMut   sync.Mutex
+	keys      map[string]jose.JSONWebKey
+	fetchedAt time.Time
+}
+
+func newJWTValidator(conf JWTConfig) (*jwtValidator, error) {
+	if len(conf.JWKSURL) == 0 {
+		return nil, errors.New("a jwks_url must be specified when jwt validation is enabled")
+	}
+	interval, err := time.ParseDuration(conf.RefreshInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse refresh_interval: %w", err)
+	}
+	return &jwtValidator{
+		conf:     conf,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		interval: interval,
+		keys:     map[string]jose.JSONWebKey{},
+	}, nil
+}
+
+func (j *jwtValidator) fetchKeys() error {
+	res, err := j.client.Get(j.conf.JWKSURL)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code from jwks_url: %v", res.StatusCode)
+	}
+
+	// Keys are parsed individually so that a key of an unsupported type does
+	// not prevent the remaining keys of the set from being used.
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to parse key set: %w", err)
+	}
+
+	keys := map[string]jose.JSONWebKey{}
+	for _, raw := range set.Keys {
+		var k jose.JSONWebKey
+		if err := json.Unmarshal(raw, &k); err != nil {
+			continue
+		}
+		// Only public keys of asymmetric algorithms are accepted, as a token
+		// signed with a symmetric key could be forged by anyone able to
+		// read the key set.
+		if k.IsPublic() {
+			keys[k.KeyID] = k
+		}
+	}
+
+	j.keysMut.Lock()
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	j.keysMut.Unlock()
+	return nil
+}
+
+// getKey returns the public key of a given ID, refreshing the key set when it
+// is stale or when the key is unknown, at most once per minute.
+func (j *jwtValidator) getKey(kid string) (jose.JSONWebKey, error) {
+	j.keysMut.Lock()
+	key, exists := j.keys[kid]
+	sinceFetch := time.Since(j.fetchedAt)
+	j.keysMut.Unlock()
+
+	if (!exists && sinceFetch > time.Minute) || sinceFetch > j.interval {
+		_, err, _ := j.fetchGroup.Do("", func() (interface{}, error) {
+			return nil, j.fetchKeys()
+		})
+		if err != nil && !exists {
+			return jose.JSONWebKey{}, err
+		}
+
+		j.keysMut.Lock()
+		if refreshed, ok := j.keys[kid]; ok {
+			key, exists = refreshed, true
+		}
+		j.keysMut.Unlock()
+	}
+	if !exists {
+		return jose.JSONWebKey{}, fmt.Errorf("unknown key id: %v", kid)
+	}
+	return key, nil
+}
+
+func (j *jwtValidator) validate(token string) error {
+	tok, err := jwt.ParseSigned(token)
+	if err != nil || len(tok.Headers) != 1 {
+		return errJWTMalformed
+	}
+
+	header := tok.Headers[0]
+	key, err := j.getKey(header.KeyID)
+	if err != nil {
+		return err
+	}
+	if len(key.Algorithm) > 0 && key.Algorithm != header.Algorithm {
+		return errJWTSignature
+	}
+
+	var claims jwt.Claims
+	if err := tok.Claims(key.Key, &claims); err != nil {
+		return errJWTSignature
+	}
+
+	expected := jwt.Expected{
+		Issuer: j.conf.Issuer,
+		Time:   time.Now(),
+	}
+	if len(j.conf.Audience) > 0 {
+		expected.Audience = jwt.Audience{j.conf.Audience}
+	}
+	return claims.Validate(expected)
+}
+
+//------------------------------------------------------------------------------
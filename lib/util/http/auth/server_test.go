@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerAuthDisabled(t *testing.T) {
+	s, err := NewServerAuth(NewServerConfig())
+	require.NoError(t, err)
+	assert.False(t, s.Enabled())
+
+	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	assert.Equal(t, 0, s.Authenticate(req))
+}
+
+func TestServerAuthTokensAndBasic(t *testing.T) {
+	conf := NewServerConfig()
+	conf.Tokens = []string{"footoken"}
+	conf.BasicAuth.Enabled = true
+	conf.BasicAuth.Username = "foo"
+	conf.BasicAuth.Password = "bar"
+	conf.ExemptPaths = []string{"/ping"}
+
+	s, err := NewServerAuth(conf)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	assert.Equal(t, http.StatusUnauthorized, s.Authenticate(req))
+
+	req = httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.Header.Set("Authorization", "Bearer footoken")
+	assert.Equal(t, 0, s.Authenticate(req))
+
+	req = httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.Header.Set("Authorization", "Bearer nottoken")
+	assert.Equal(t, http.StatusUnauthorized, s.Authenticate(req))
+
+	req = httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.SetBasicAuth("foo", "bar")
+	assert.Equal(t, 0, s.Authenticate(req))
+
+	req = httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.SetBasicAuth("foo", "baz")
+	assert.Equal(t, http.StatusUnauthorized, s.Authenticate(req))
+
+	req = httptest.NewRequest(http.MethodGet, "/ping", nil)
+	assert.Equal(t, 0, s.Authenticate(req))
+
+	rec := httptest.NewRecorder()
+	s.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected call to child handler")
+	})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/foo", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Basic realm="benthos"`, rec.Header().Get("WWW-Authenticate"))
+}
+
+func TestServerAuthAllowedIPs(t *testing.T) {
+	conf := NewServerConfig()
+	conf.AllowedIPs = []string{"10.0.0.0/8", "192.168.0.1"}
+
+	s, err := NewServerAuth(conf)
+	require.NoError(t, err)
+
+	for addr, exp := range map[string]int{
+		"10.1.2.3:5000":    0,
+		"192.168.0.1:5000": 0,
+		"192.168.0.2:5000": http.StatusForbidden,
+		"127.0.0.1:5000":   http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+		req.RemoteAddr = addr
+		assert.Equal(t, exp, s.Authenticate(req), addr)
+	}
+
+	conf.AllowedIPs = []string{"not an ip"}
+	_, err = NewServerAuth(conf)
+	require.Error(t, err)
+}
+
+func TestServerAuthClientCert(t *testing.T) {
+	s := &ServerAuth{requireCert: true, exempt: map[string]struct{}{}}
+
+	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	assert.Equal(t, http.StatusUnauthorized, s.Authenticate(req))
+}
+
+func TestServerAuthJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	b64 := base64.RawURLEncoding
+	var fetches int32
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		<-time.After(50 * time.Millisecond)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []interface{}{
+				map[string]interface{}{
+					"kid": "foo",
+					"kty": "RSA",
+					"n":   b64.EncodeToString(key.N.Bytes()),
+					"e":   b64.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				},
+				map[string]interface{}{
+					"kid": "unsupported",
+					"kty": "nope",
+				},
+			},
+		})
+	}))
+	defer jwksServer.Close()
+
+	sign := func(kid string, claims map[string]interface{}) string {
+		header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid})
+		payload, _ := json.Marshal(claims)
+		signed := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(signed))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+		return signed + "." + b64.EncodeToString(sig)
+	}
+
+	conf := NewServerConfig()
+	conf.JWT.Enabled = true
+	conf.JWT.JWKSURL = jwksServer.URL
+	conf.JWT.Issuer = "benthos"
+	conf.JWT.Audience = "api"
+
+	s, err := NewServerAuth(conf)
+	require.NoError(t, err)
+
+	exp := time.Now().Add(time.Hour).Unix()
+
+	// Concurrent requests that require the key set must share one fetch.
+	validToken := sign("foo", map[string]interface{}{"iss": "benthos", "aud": "api", "exp": exp})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+			req.Header.Set("Authorization", "Bearer "+validToken)
+			assert.Equal(t, 0, s.Authenticate(req))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	hmacSign := func(claims map[string]interface{}) string {
+		header, _ := json.Marshal(map[string]string{"alg": "HS256", "kid": "foo"})
+		payload, _ := json.Marshal(claims)
+		signed := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+		mac := hmac.New(sha256.New, key.N.Bytes())
+		mac.Write([]byte(signed))
+		return signed + "." + b64.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := map[string]struct {
+		token string
+		code  int
+	}{
+		"valid": {
+			token: sign("foo", map[string]interface{}{"iss": "benthos", "aud": []string{"api"}, "exp": exp}),
+			code:  0,
+		},
+		"expired": {
+			token: sign("foo", map[string]interface{}{"iss": "benthos", "aud": "api", "exp": time.Now().Add(-time.Hour).Unix()}),
+			code:  http.StatusUnauthorized,
+		},
+		"wrong issuer": {
+			token: sign("foo", map[string]interface{}{"iss": "nope", "aud": "api", "exp": exp}),
+			code:  http.StatusUnauthorized,
+		},
+		"wrong audience": {
+			token: sign("foo", map[string]interface{}{"iss": "benthos", "aud": "nope", "exp": exp}),
+			code:  http.StatusUnauthorized,
+		},
+		"unknown key": {
+			token: sign("bar", map[string]interface{}{"iss": "benthos", "aud": "api", "exp": exp}),
+			code:  http.StatusUnauthorized,
+		},
+		"tampered": {
+			token: sign("foo", map[string]interface{}{"iss": "benthos", "aud": "api", "exp": exp}) + "x",
+			code:  http.StatusUnauthorized,
+		},
+		"malformed": {
+			token: "not.a.token",
+			code:  http.StatusUnauthorized,
+		},
+		"symmetric algorithm": {
+			token: hmacSign(map[string]interface{}{"iss": "benthos", "aud": "api", "exp": exp}),
+			code:  http.StatusUnauthorized,
+		},
+		"unsupported key": {
+			token: sign("unsupported", map[string]interface{}{"iss": "benthos", "aud": "api", "exp": exp}),
+			code:  http.StatusUnauthorized,
+		},
+	}
+
+	for name, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+		req.Header.Set("Authorization", "Bearer "+test.token)
+		assert.Equal(t, test.code, s.Authenticate(req), name)
+	}
+}
@@ -3,12 +3,14 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"mime"
 	"mime/multipart"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/textproto"
 	"net/url"
 	"strconv"
@@ -24,7 +26,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
 	"github.com/Jeffail/benthos/v3/lib/util/throttle"
-	"github.com/Jeffail/benthos/v3/lib/util/tls"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
 	"github.com/opentracing/opentracing-go"
 	olog "github.com/opentracing/opentracing-go/log"
 )
@@ -45,7 +47,7 @@ type Config struct {
 	BackoffOn           []int             `json:"backoff_on" yaml:"backoff_on"`
 	DropOn              []int             `json:"drop_on" yaml:"drop_on"`
 	SuccessfulOn        []int             `json:"successful_on" yaml:"successful_on"`
-	TLS                 tls.Config        `json:"tls" yaml:"tls"`
+	TLS                 btls.Config       `json:"tls" yaml:"tls"`
 	ProxyURL            string            `json:"proxy_url" yaml:"proxy_url"`
 	auth.Config         `json:",inline" yaml:",inline"`
 	OAuth2              auth.OAuth2Config `json:"oauth2" yaml:"oauth2"`
@@ -68,7 +70,7 @@ func NewConfig() Config {
 		BackoffOn:           []int{429},
 		DropOn:              []int{},
 		SuccessfulOn:        []int{},
-		TLS:                 tls.NewConfig(),
+		TLS:                 btls.NewConfig(),
 		Config:              auth.NewConfig(),
 		OAuth2:              auth.NewOAuth2Config(),
 	}
@@ -88,6 +90,8 @@ type Type struct {
 	headers map[string]field.Expression
 	host    field.Expression
 
+	reqModifier func(req *http.Request, msg types.Message) error
+
 	conf          Config
 	retryThrottle *throttle.Type
 	rateLimit     types.RateLimit
@@ -106,6 +110,9 @@ type Type struct {
 	mLimitErr      metrics.StatCounter
 	mSucc          metrics.StatCounter
 	mLatency       metrics.StatTimer
+	mDNSLatency    metrics.StatTimer
+	mConnLatency   metrics.StatTimer
+	mTLSLatency    metrics.StatTimer
 
 	mCodes   map[int]metrics.StatCounter
 	codesMut sync.RWMutex
@@ -135,7 +142,7 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 		host:      nil,
 	}
 	h.ctx, h.done = context.WithCancel(context.Background())
-	h.client = conf.OAuth2.Client(h.ctx)
+	h.client = &http.Client{}
 
 	if tout := conf.Timeout; len(tout) > 0 {
 		var err error
@@ -159,6 +166,11 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse proxy_url string: %v", err)
 		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("unsupported proxy_url scheme '%v', expected http, https or socks5", proxyURL.Scheme)
+		}
 		if h.client.Transport != nil {
 			if tr, ok := h.client.Transport.(*http.Transport); ok {
 				tr.Proxy = http.ProxyURL(proxyURL)
@@ -172,6 +184,12 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 		}
 	}
 
+	// OAuth2 wraps the transport configured above so that token requests also
+	// respect the TLS and proxy settings.
+	if h.client, err = conf.OAuth2.ClientWithBase(h.ctx, h.client); err != nil {
+		return nil, fmt.Errorf("failed to create oauth2 client: %v", err)
+	}
+
 	for _, c := range conf.BackoffOn {
 		h.backoffOn[c] = struct{}{}
 	}
@@ -207,6 +225,9 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 	h.mLimitFor = h.stats.GetCounter("rate_limit.total_ms")
 	h.mLimitErr = h.stats.GetCounter("rate_limit.error")
 	h.mLatency = h.stats.GetTimer("latency")
+	h.mDNSLatency = h.stats.GetTimer("dns.latency")
+	h.mConnLatency = h.stats.GetTimer("connect.latency")
+	h.mTLSLatency = h.stats.GetTimer("tls_handshake.latency")
 	h.mSucc = h.stats.GetCounter("success")
 	h.mCodes = map[int]metrics.StatCounter{}
 
@@ -291,6 +312,14 @@ func OptSetRoundTripper(rt http.RoundTripper) func(*Type) {
 	}
 }
 
+// OptSetRequestModifier sets a function that is called with each request
+// created from a message before it is sent, allowing it to be modified.
+func OptSetRequestModifier(fn func(req *http.Request, msg types.Message) error) func(*Type) {
+	return func(t *Type) {
+		t.reqModifier = fn
+	}
+}
+
 //------------------------------------------------------------------------------
 
 func (h *Type) incrCode(code int) {
@@ -396,6 +425,9 @@ func (h *Type) CreateRequest(msg types.Message) (req *http.Request, err error) {
 		}
 	}
 
+	if err == nil && h.reqModifier != nil {
+		err = h.reqModifier(req, msg)
+	}
 	if err == nil {
 		err = h.conf.Config.Sign(req)
 	}
@@ -516,6 +548,54 @@ func (h *Type) checkStatus(code int) (succeeded bool, retStrat retryStrategy) {
 	return true, noRetry
 }
 
+// connTrace returns a client trace that records the latency of DNS lookups,
+// dials and TLS handshakes performed when establishing new connections.
+func (h *Type) connTrace() *httptrace.ClientTrace {
+	var mut sync.Mutex
+	var dnsStart, tlsStart time.Time
+	connStarts := map[string]time.Time{}
+
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mut.Lock()
+			dnsStart = time.Now()
+			mut.Unlock()
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			mut.Lock()
+			defer mut.Unlock()
+			if info.Err == nil && !dnsStart.IsZero() {
+				h.mDNSLatency.Timing(int64(time.Since(dnsStart)))
+			}
+		},
+		ConnectStart: func(network, addr string) {
+			mut.Lock()
+			connStarts[network+addr] = time.Now()
+			mut.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			mut.Lock()
+			defer mut.Unlock()
+			if start, exists := connStarts[network+addr]; exists && err == nil {
+				h.mConnLatency.Timing(int64(time.Since(start)))
+			}
+			delete(connStarts, network+addr)
+		},
+		TLSHandshakeStart: func() {
+			mut.Lock()
+			tlsStart = time.Now()
+			mut.Unlock()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			mut.Lock()
+			defer mut.Unlock()
+			if err == nil && !tlsStart.IsZero() {
+				h.mTLSLatency.Timing(int64(time.Since(tlsStart)))
+			}
+		},
+	}
+}
+
 // Do attempts to create and perform an HTTP request from a message payload.
 // This attempt may include retries, and if all retries fail an error is
 // returned.
@@ -558,6 +638,7 @@ func (h *Type) DoWithContext(ctx context.Context, msg types.Message) (res *http.
 	}
 
 	startedAt := time.Now()
+	ctx = httptrace.WithClientTrace(ctx, h.connTrace())
 
 	if !h.waitForAccess() {
 		return nil, types.ErrTypeClosed
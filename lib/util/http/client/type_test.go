@@ -20,6 +20,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
 )
 
 //------------------------------------------------------------------------------
@@ -67,6 +68,115 @@ func TestHTTPClientBadRequest(t *testing.T) {
 	}
 }
 
+func TestHTTPClientOAuth2(t *testing.T) {
+	var tokenReqs uint32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&tokenReqs, 1)
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		if exp, act := "client_credentials", r.Form.Get("grant_type"); exp != act {
+			t.Errorf("Wrong grant type: %v != %v", act, exp)
+		}
+		if exp, act := "foo bar", r.Form.Get("scope"); exp != act {
+			t.Errorf("Wrong scope: %v != %v", act, exp)
+		}
+		if exp, act := "baz", r.Form.Get("audience"); exp != act {
+			t.Errorf("Wrong audience param: %v != %v", act, exp)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"footoken","token_type":"bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	var reqs uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqs, 1)
+		if exp, act := "Bearer footoken", r.Header.Get("Authorization"); exp != act {
+			t.Errorf("Wrong auth header: %v != %v", act, exp)
+		}
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.URL = ts.URL + "/testpost"
+	conf.OAuth2.Enabled = true
+	conf.OAuth2.ClientKey = "fookey"
+	conf.OAuth2.ClientSecret = "foosecret"
+	conf.OAuth2.TokenURL = tokenServer.URL
+	conf.OAuth2.Scopes = []string{"foo", "bar"}
+	conf.OAuth2.EndpointParams = map[string]string{"audience": "baz"}
+
+	h, err := New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := h.Send(message.New([][]byte{[]byte("test")})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if exp, act := uint32(3), atomic.LoadUint32(&reqs); exp != act {
+		t.Errorf("Wrong count of HTTP requests: %v != %v", act, exp)
+	}
+	if exp, act := uint32(1), atomic.LoadUint32(&tokenReqs); exp != act {
+		t.Errorf("Wrong count of token requests: %v != %v", act, exp)
+	}
+}
+
+func TestHTTPClientPinnedPublicKeys(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("foo"))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.URL = ts.URL + "/testpost"
+	conf.NumRetries = 0
+	conf.TLS.Enabled = true
+	conf.TLS.InsecureSkipVerify = true
+	conf.TLS.PinnedPublicKeys = []string{"sha256//" + btls.PublicKeyHash(ts.Certificate())}
+
+	stats := metrics.NewLocal()
+	h, err := New(conf, OptSetStats(stats))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = h.Send(message.New([][]byte{[]byte("test")})); err != nil {
+		t.Fatal(err)
+	}
+
+	timings := stats.GetTimings()
+	for _, k := range []string{"connect.latency", "tls_handshake.latency"} {
+		if _, exists := timings[k]; !exists {
+			t.Errorf("Missing timing metric: %v", k)
+		}
+	}
+
+	conf.TLS.PinnedPublicKeys = []string{"YhKJKSzoTt2b5FP18fvpHo7fJYqQCjAa3HWY3tvRMwE="}
+	if h, err = New(conf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = h.Send(message.New([][]byte{[]byte("test")})); err == nil {
+		t.Error("Expected error from mismatched public key pin")
+	}
+}
+
+func TestHTTPClientProxyScheme(t *testing.T) {
+	conf := NewConfig()
+	conf.ProxyURL = "socks5://localhost:1080"
+	if _, err := New(conf); err != nil {
+		t.Error(err)
+	}
+
+	conf.ProxyURL = "ftp://localhost:1080"
+	if _, err := New(conf); err == nil {
+		t.Error("Expected error from unsupported proxy scheme")
+	}
+}
+
 func TestHTTPClientSendBasic(t *testing.T) {
 	nTestLoops := 1000
 
@@ -22,6 +22,17 @@ func (w gzipResponseWriter) Write(b []byte) (int, error) {
 	return w.Writer.Write(b)
 }
 
+// Flush writes any buffered compressed data to the underlying response writer
+// and flushes it when supported.
+func (w gzipResponseWriter) Flush() {
+	if f, ok := w.Writer.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 // GzipHandler wraps a handlerfunc with a handler that automatically Gzips
 // outbound messages.
 func GzipHandler(fn http.HandlerFunc) http.HandlerFunc {
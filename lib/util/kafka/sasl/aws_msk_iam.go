@@ -0,0 +1,72 @@
+package sasl
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// SASLTypeAWSMSKIAM is the mechanism name used for authenticating with Amazon
+// MSK clusters using IAM, which is performed over OAUTHBEARER.
+const SASLTypeAWSMSKIAM = "AWS_MSK_IAM"
+
+const (
+	mskIAMService = "kafka-cluster"
+	mskIAMAction  = "kafka-cluster:Connect"
+	mskIAMExpiry  = 15 * time.Minute
+)
+
+//------------------------------------------------------------------------------
+
+// mskIAMAccessTokenProvider generates SASL OAUTHBEARER access tokens for Amazon
+// MSK IAM authentication, which are presigned SigV4 URLs encoded as base64.
+// Tokens are signed on demand and therefore always reflect the latest
+// credentials of the session.
+type mskIAMAccessTokenProvider struct {
+	region string
+	signer *v4.Signer
+	nowFn  func() time.Time
+}
+
+func newMSKIAMAccessTokenProvider(awsConf *aws.Config) (*mskIAMAccessTokenProvider, error) {
+	region := aws.StringValue(awsConf.Region)
+	if len(region) == 0 {
+		return nil, errors.New("a region must be specified for the AWS_MSK_IAM mechanism")
+	}
+	var creds *credentials.Credentials
+	if creds = awsConf.Credentials; creds == nil {
+		return nil, errors.New("failed to obtain AWS credentials for the AWS_MSK_IAM mechanism")
+	}
+	return &mskIAMAccessTokenProvider{
+		region: region,
+		signer: v4.NewSigner(creds),
+		nowFn:  time.Now,
+	}, nil
+}
+
+func (m *mskIAMAccessTokenProvider) Token() (*sarama.AccessToken, error) {
+	endpoint := fmt.Sprintf("https://kafka.%v.amazonaws.com/?Action=%v", m.region, url.QueryEscape(mskIAMAction))
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = m.signer.Presign(req, nil, mskIAMService, m.region, mskIAMExpiry, m.nowFn()); err != nil {
+		return nil, fmt.Errorf("failed to sign AWS_MSK_IAM token: %w", err)
+	}
+
+	query := req.URL.Query()
+	query.Set("User-Agent", "benthos")
+	req.URL.RawQuery = query.Encode()
+
+	return &sarama.AccessToken{
+		Token: base64.RawURLEncoding.EncodeToString([]byte(req.URL.String())),
+	}, nil
+}
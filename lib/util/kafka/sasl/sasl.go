@@ -1,11 +1,18 @@
 package sasl
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/session"
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
 	"github.com/Shopify/sarama"
+	"golang.org/x/oauth2"
 )
 
 // SASL specific error types.
@@ -16,22 +23,30 @@ var (
 // Config contains configuration for SASL based authentication.
 // TODO: V4 Remove "enabled" and set a default mechanism
 type Config struct {
-	Enabled     bool   `json:"enabled" yaml:"enabled"` // DEPRECATED
-	Mechanism   string `json:"mechanism" yaml:"mechanism"`
-	User        string `json:"user" yaml:"user"`
-	Password    string `json:"password" yaml:"password"`
-	AccessToken string `json:"access_token" yaml:"access_token"`
-	TokenCache  string `json:"token_cache" yaml:"token_cache"`
-	TokenKey    string `json:"token_key" yaml:"token_key"`
+	Enabled     bool              `json:"enabled" yaml:"enabled"` // DEPRECATED
+	Mechanism   string            `json:"mechanism" yaml:"mechanism"`
+	User        string            `json:"user" yaml:"user"`
+	Password    string            `json:"password" yaml:"password"`
+	AccessToken string            `json:"access_token" yaml:"access_token"`
+	TokenCache  string            `json:"token_cache" yaml:"token_cache"`
+	TokenKey    string            `json:"token_key" yaml:"token_key"`
+	OAuth2      auth.OAuth2Config `json:"oauth2" yaml:"oauth2"`
+	AWS         session.Config    `json:"aws" yaml:"aws"`
 }
 
 // NewConfig returns a new SASL config for Kafka with default values.
 func NewConfig() Config {
-	return Config{} // Well, that seemed pointless.
+	return Config{
+		OAuth2: auth.NewOAuth2Config(),
+		AWS:    session.NewConfig(),
+	}
 }
 
 // FieldSpec returns specs for SASL fields.
 func FieldSpec() docs.FieldSpec {
+	oauth2Spec := auth.OAuth2FieldSpec().AtVersion("3.44.0")
+	oauth2Spec.Description = "Allows you to obtain `" + sarama.SASLTypeOAuth + "` tokens from an OAuth2 token provider using either the client credentials or the JWT bearer token flow, instead of using a static `access_token`. Tokens are cached and refreshed automatically once they expire."
+
 	return docs.FieldAdvanced("sasl", "Enables SASL authentication.").WithChildren(
 		docs.FieldDeprecated("enabled"),
 		docs.FieldCommon("mechanism", "The SASL authentication mechanism, if left empty SASL authentication is not used. Warning: SCRAM based methods within Benthos have not received a security audit.").HasAnnotatedOptions(
@@ -39,12 +54,15 @@ func FieldSpec() docs.FieldSpec {
 			sarama.SASLTypeOAuth, "OAuth Bearer based authentication.",
 			sarama.SASLTypeSCRAMSHA256, "Authentication using the SCRAM-SHA-256 mechanism.",
 			sarama.SASLTypeSCRAMSHA512, "Authentication using the SCRAM-SHA-512 mechanism.",
+			SASLTypeAWSMSKIAM, "IAM based authentication for Amazon MSK clusters, using the credentials configured within `aws`.",
 		),
 		docs.FieldCommon("user", "A `"+sarama.SASLTypePlaintext+"` username. It is recommended that you use environment variables to populate this field.", "${USER}"),
 		docs.FieldCommon("password", "A `"+sarama.SASLTypePlaintext+"` password. It is recommended that you use environment variables to populate this field.", "${PASSWORD}"),
 		docs.FieldAdvanced("access_token", "A static `"+sarama.SASLTypeOAuth+"` access token"),
 		docs.FieldAdvanced("token_cache", "Instead of using a static `access_token` allows you to query a [`cache`](/docs/components/caches/about) resource to fetch `"+sarama.SASLTypeOAuth+"` tokens from"),
 		docs.FieldAdvanced("token_key", "Required when using a `token_cache`, the key to query the cache with for tokens."),
+		oauth2Spec,
+		docs.FieldAdvanced("aws", "AWS settings used to sign tokens when using the `"+SASLTypeAWSMSKIAM+"` mechanism.").WithChildren(session.FieldSpecs()...).AtVersion("3.44.0"),
 	)
 }
 
@@ -63,6 +81,11 @@ func (s Config) Apply(mgr types.Manager, conf *sarama.Config) error {
 			if err != nil {
 				return err
 			}
+		} else if s.OAuth2.Enabled {
+			tp, err = newOAuth2AccessTokenProvider(s.OAuth2)
+			if err != nil {
+				return err
+			}
 		} else {
 			tp, err = newStaticAccessTokenProvider(s.AccessToken)
 			if err != nil {
@@ -70,6 +93,19 @@ func (s Config) Apply(mgr types.Manager, conf *sarama.Config) error {
 			}
 		}
 		conf.Net.SASL.TokenProvider = tp
+	case SASLTypeAWSMSKIAM:
+		sess, err := s.AWS.GetSession()
+		if err != nil {
+			return fmt.Errorf("failed to create AWS session: %w", err)
+		}
+		tp, err := newMSKIAMAccessTokenProvider(sess.Config)
+		if err != nil {
+			return err
+		}
+		conf.Net.SASL.TokenProvider = tp
+		conf.Net.SASL.Enable = true
+		conf.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+		return nil
 	case sarama.SASLTypeSCRAMSHA256:
 		conf.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
 			return &XDGSCRAMClient{HashGeneratorFcn: SHA256}
@@ -125,6 +161,30 @@ func (c *cacheAccessTokenProvider) Token() (*sarama.AccessToken, error) {
 
 //------------------------------------------------------------------------------
 
+// oauth2AccessTokenProvider fetches SASL OAUTHBEARER access tokens from an
+// OAuth2 token provider, refreshing them once they expire.
+type oauth2AccessTokenProvider struct {
+	source oauth2.TokenSource
+}
+
+func newOAuth2AccessTokenProvider(conf auth.OAuth2Config) (*oauth2AccessTokenProvider, error) {
+	ts, err := conf.TokenSource(context.Background(), &http.Client{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2AccessTokenProvider{ts}, nil
+}
+
+func (o *oauth2AccessTokenProvider) Token() (*sarama.AccessToken, error) {
+	tok, err := o.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	return &sarama.AccessToken{Token: tok.AccessToken}, nil
+}
+
+//------------------------------------------------------------------------------
+
 // staticAccessTokenProvider provides a static SASL OAUTHBEARER access token.
 type staticAccessTokenProvider struct {
 	token string
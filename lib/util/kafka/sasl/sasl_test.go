@@ -1,6 +1,12 @@
 package sasl
 
 import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -174,6 +180,88 @@ func TestApplyOAuthBearerCacheProvider(t *testing.T) {
 	}
 }
 
+func TestApplyOAuthBearerOAuth2(t *testing.T) {
+	var tokenReqs uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&tokenReqs, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"footoken","token_type":"bearer","expires_in":3600}`))
+	}))
+	defer ts.Close()
+
+	conf := &sarama.Config{}
+
+	saslConf := NewConfig()
+	saslConf.Mechanism = sarama.SASLTypeOAuth
+	saslConf.OAuth2.Enabled = true
+	saslConf.OAuth2.ClientKey = "foo"
+	saslConf.OAuth2.ClientSecret = "bar"
+	saslConf.OAuth2.TokenURL = ts.URL
+
+	if err := saslConf.Apply(types.NoopMgr(), conf); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		token, err := conf.Net.SASL.TokenProvider.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := token.Token; act != "footoken" {
+			t.Errorf("Wrong SASL token: %v != %v", act, "footoken")
+		}
+	}
+
+	if exp, act := uint32(1), atomic.LoadUint32(&tokenReqs); exp != act {
+		t.Errorf("Wrong count of token requests: %v != %v", act, exp)
+	}
+}
+
+func TestApplyAWSMSKIAM(t *testing.T) {
+	conf := &sarama.Config{}
+
+	saslConf := NewConfig()
+	saslConf.Mechanism = SASLTypeAWSMSKIAM
+	saslConf.AWS.Region = "us-east-1"
+	saslConf.AWS.Credentials.ID = "foo"
+	saslConf.AWS.Credentials.Secret = "bar"
+
+	if err := saslConf.Apply(types.NoopMgr(), conf); err != nil {
+		t.Fatal(err)
+	}
+
+	if conf.Net.SASL.Mechanism != sarama.SASLTypeOAuth {
+		t.Errorf("Wrong SASL mechanism: %v != %v", conf.Net.SASL.Mechanism, sarama.SASLTypeOAuth)
+	}
+
+	token, err := conf.Net.SASL.TokenProvider.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	urlBytes, err := base64.RawURLEncoding.DecodeString(token.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(string(urlBytes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp, act := "kafka.us-east-1.amazonaws.com", u.Host; exp != act {
+		t.Errorf("Wrong token host: %v != %v", act, exp)
+	}
+	if exp, act := "kafka-cluster:Connect", u.Query().Get("Action"); exp != act {
+		t.Errorf("Wrong token action: %v != %v", act, exp)
+	}
+	if act := u.Query().Get("X-Amz-Signature"); act == "" {
+		t.Error("Expected token to be signed")
+	}
+	if act := u.Query().Get("X-Amz-Credential"); !strings.HasPrefix(act, "foo/") {
+		t.Errorf("Wrong token credential: %v", act)
+	}
+}
+
 func TestApplyUnknownMechanism(t *testing.T) {
 	conf := &sarama.Config{}
 
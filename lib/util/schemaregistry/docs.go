@@ -0,0 +1,20 @@
+package schemaregistry
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+// FieldSpecs returns the field specs of a schema registry resource.
+func FieldSpecs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldCommon("label", "A unique label of the schema registry, used by components to reference it."),
+		docs.FieldCommon("url", "The base URL of the schema registry.", "http://localhost:8081"),
+		auth.BasicAuthFieldSpec(),
+		tls.FieldSpec(),
+		docs.FieldAdvanced("timeout", "The maximum period to wait for a request to the schema registry to complete."),
+		docs.FieldAdvanced("schema_cache_ttl", "The period for which schemas obtained by their ID are cached. Schemas are immutable once registered and so this can be long."),
+		docs.FieldAdvanced("subject_cache_ttl", "The period for which the latest schema of a subject is cached before the registry is checked for a newer version."),
+	}
+}
@@ -0,0 +1,4 @@
+// Package schemaregistry provides Benthos configuration fields and a client for
+// a Confluent compatible schema registry, which is shared by components as a
+// resource.
+package schemaregistry
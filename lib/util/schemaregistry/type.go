@@ -0,0 +1,252 @@
+package schemaregistry
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+// Config contains configuration fields for a schema registry resource.
+type Config struct {
+	Label           string               `json:"label" yaml:"label"`
+	URL             string               `json:"url" yaml:"url"`
+	BasicAuth       auth.BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
+	TLS             btls.Config          `json:"tls" yaml:"tls"`
+	Timeout         string               `json:"timeout" yaml:"timeout"`
+	SchemaCacheTTL  string               `json:"schema_cache_ttl" yaml:"schema_cache_ttl"`
+	SubjectCacheTTL string               `json:"subject_cache_ttl" yaml:"subject_cache_ttl"`
+}
+
+// NewConfig creates a new Config with default values.
+func NewConfig() Config {
+	return Config{
+		Label:           "",
+		URL:             "",
+		BasicAuth:       auth.NewBasicAuthConfig(),
+		TLS:             btls.NewConfig(),
+		Timeout:         "5s",
+		SchemaCacheTTL:  "1h",
+		SubjectCacheTTL: "1m",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Schema is a schema obtained from the registry.
+type Schema struct {
+	ID      int
+	Subject string
+	Version int
+
+	// Type is the type of the schema, which is one of AVRO, JSON or PROTOBUF.
+	Type   string
+	Schema string
+}
+
+type cachedSchema struct {
+	schema  Schema
+	expires time.Time
+}
+
+// Client obtains schemas from a schema registry and caches them.
+type Client struct {
+	url       *url.URL
+	basicAuth auth.BasicAuthConfig
+	client    *http.Client
+
+	schemaTTL  time.Duration
+	subjectTTL time.Duration
+
+	cacheMut     sync.Mutex
+	schemaCache  map[int]cachedSchema
+	subjectCache map[string]cachedSchema
+}
+
+// New creates a schema registry client from a config.
+func New(conf Config) (*Client, error) {
+	if conf.URL == "" {
+		return nil, errors.New("a url is required")
+	}
+	u, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	c := &Client{
+		url:          u,
+		basicAuth:    conf.BasicAuth,
+		client:       &http.Client{},
+		schemaCache:  map[int]cachedSchema{},
+		subjectCache: map[string]cachedSchema{},
+	}
+	if c.client.Timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+		return nil, fmt.Errorf("failed to parse timeout: %w", err)
+	}
+	if c.schemaTTL, err = time.ParseDuration(conf.SchemaCacheTTL); err != nil {
+		return nil, fmt.Errorf("failed to parse schema_cache_ttl: %w", err)
+	}
+	if c.subjectTTL, err = time.ParseDuration(conf.SubjectCacheTTL); err != nil {
+		return nil, fmt.Errorf("failed to parse subject_cache_ttl: %w", err)
+	}
+	if conf.TLS.Enabled {
+		tlsConf, err := conf.TLS.Get()
+		if err != nil {
+			return nil, err
+		}
+		c.client.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConf,
+		}
+	}
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+
+type schemaResponse struct {
+	ID         int    `json:"id"`
+	Subject    string `json:"subject"`
+	Version    int    `json:"version"`
+	SchemaType string `json:"schemaType"`
+	Schema     string `json:"schema"`
+}
+
+func (c *Client) get(ctx context.Context, path string) (*schemaResponse, error) {
+	u := *c.url
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if err = c.basicAuth.Sign(req); err != nil {
+		return nil, err
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request to %v returned status %v: %s", path, res.StatusCode, body)
+	}
+
+	var sRes schemaResponse
+	if err = json.Unmarshal(body, &sRes); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if sRes.SchemaType == "" {
+		sRes.SchemaType = "AVRO"
+	}
+	return &sRes, nil
+}
+
+// GetSchemaByID obtains a schema by its globally unique ID.
+func (c *Client) GetSchemaByID(ctx context.Context, id int) (Schema, error) {
+	c.cacheMut.Lock()
+	cached, exists := c.schemaCache[id]
+	c.cacheMut.Unlock()
+	if exists && time.Now().Before(cached.expires) {
+		return cached.schema, nil
+	}
+
+	res, err := c.get(ctx, fmt.Sprintf("/schemas/ids/%d", id))
+	if err != nil {
+		return Schema{}, err
+	}
+	schema := Schema{
+		ID:     id,
+		Type:   res.SchemaType,
+		Schema: res.Schema,
+	}
+
+	c.cacheMut.Lock()
+	c.schemaCache[id] = cachedSchema{schema: schema, expires: time.Now().Add(c.schemaTTL)}
+	c.cacheMut.Unlock()
+	return schema, nil
+}
+
+// GetLatestSchema obtains the latest version of the schema of a subject.
+func (c *Client) GetLatestSchema(ctx context.Context, subject string) (Schema, error) {
+	c.cacheMut.Lock()
+	cached, exists := c.subjectCache[subject]
+	c.cacheMut.Unlock()
+	if exists && time.Now().Before(cached.expires) {
+		return cached.schema, nil
+	}
+
+	res, err := c.get(ctx, fmt.Sprintf("/subjects/%s/versions/latest", url.PathEscape(subject)))
+	if err != nil {
+		return Schema{}, err
+	}
+	schema := Schema{
+		ID:      res.ID,
+		Subject: res.Subject,
+		Version: res.Version,
+		Type:    res.SchemaType,
+		Schema:  res.Schema,
+	}
+
+	c.cacheMut.Lock()
+	c.subjectCache[subject] = cachedSchema{schema: schema, expires: time.Now().Add(c.subjectTTL)}
+	c.schemaCache[schema.ID] = cachedSchema{schema: schema, expires: time.Now().Add(c.schemaTTL)}
+	c.cacheMut.Unlock()
+	return schema, nil
+}
+
+//------------------------------------------------------------------------------
+
+// AddWireHeader prefixes a payload with the header of the Confluent wire
+// format, consisting of a zero magic byte followed by the schema ID.
+func AddWireHeader(id int, payload []byte) []byte {
+	b := make([]byte, 5+len(payload))
+	binary.BigEndian.PutUint32(b[1:5], uint32(id))
+	copy(b[5:], payload)
+	return b
+}
+
+// ParseWireHeader extracts the schema ID and payload from a message in the
+// Confluent wire format.
+func ParseWireHeader(b []byte) (int, []byte, error) {
+	if len(b) < 5 {
+		return 0, nil, errors.New("message is too short to contain a schema ID")
+	}
+	if b[0] != 0 {
+		return 0, nil, fmt.Errorf("unrecognised magic byte: %v", b[0])
+	}
+	return int(binary.BigEndian.Uint32(b[1:5])), b[5:], nil
+}
+
+//------------------------------------------------------------------------------
+
+// FromManager obtains a schema registry resource by its label from a manager.
+func FromManager(mgr types.Manager, label string) (*Client, error) {
+	regProv, ok := mgr.(interface {
+		GetSchemaRegistry(name string) (*Client, error)
+	})
+	if !ok {
+		return nil, errors.New("manager does not support schema registry resources")
+	}
+	return regProv.GetSchemaRegistry(label)
+}
@@ -0,0 +1,100 @@
+package schemaregistry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientGetSchemas(t *testing.T) {
+	var reqs int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&reqs, 1)
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "foo", user)
+		assert.Equal(t, "bar", pass)
+
+		switch r.URL.Path {
+		case "/registry/schemas/ids/3":
+			w.Write([]byte(`{"schema":"{\"type\":\"string\"}"}`))
+		case "/registry/subjects/users-value/versions/latest":
+			w.Write([]byte(`{"subject":"users-value","id":4,"version":2,"schemaType":"JSON","schema":"{}"}`))
+		default:
+			http.Error(w, `{"error_code":40403,"message":"Schema not found"}`, http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.URL = ts.URL + "/registry/"
+	conf.BasicAuth.Enabled = true
+	conf.BasicAuth.Username = "foo"
+	conf.BasicAuth.Password = "bar"
+
+	c, err := New(conf)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		schema, err := c.GetSchemaByID(ctx, 3)
+		require.NoError(t, err)
+		assert.Equal(t, Schema{ID: 3, Type: "AVRO", Schema: `{"type":"string"}`}, schema)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&reqs))
+
+	schema, err := c.GetLatestSchema(ctx, "users-value")
+	require.NoError(t, err)
+	assert.Equal(t, Schema{ID: 4, Subject: "users-value", Version: 2, Type: "JSON", Schema: "{}"}, schema)
+
+	// The schema of a subject is also cached by its ID.
+	_, err = c.GetSchemaByID(ctx, 4)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&reqs))
+
+	_, err = c.GetSchemaByID(ctx, 5)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "returned status 404")
+}
+
+func TestClientSubjectCacheTTL(t *testing.T) {
+	var reqs int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&reqs, 1)
+		w.Write([]byte(`{"subject":"foo","id":1,"version":1,"schema":"\"string\""}`))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.URL = ts.URL
+	conf.SubjectCacheTTL = "0s"
+
+	c, err := New(conf)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = c.GetLatestSchema(context.Background(), "foo")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&reqs))
+}
+
+func TestWireHeader(t *testing.T) {
+	b := AddWireHeader(258, []byte("hello"))
+	assert.Equal(t, []byte{0, 0, 0, 1, 2, 'h', 'e', 'l', 'l', 'o'}, b)
+
+	id, payload, err := ParseWireHeader(b)
+	require.NoError(t, err)
+	assert.Equal(t, 258, id)
+	assert.Equal(t, "hello", string(payload))
+
+	_, _, err = ParseWireHeader([]byte{0, 1})
+	require.EqualError(t, err, "message is too short to contain a schema ID")
+
+	_, _, err = ParseWireHeader([]byte{1, 0, 0, 0, 1})
+	require.EqualError(t, err, "unrecognised magic byte: 1")
+}
@@ -0,0 +1,3 @@
+// Package sqlpool provides Benthos configuration fields and a wrapper for a SQL
+// database connection pool, which is shared by components as a resource.
+package sqlpool
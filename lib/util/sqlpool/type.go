@@ -0,0 +1,252 @@
+package sqlpool
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"github.com/go-sql-driver/mysql"
+)
+
+//------------------------------------------------------------------------------
+
+// Config contains configuration fields for a SQL resource.
+type Config struct {
+	Label              string      `json:"label" yaml:"label"`
+	Driver             string      `json:"driver" yaml:"driver"`
+	DataSourceName     string      `json:"data_source_name" yaml:"data_source_name"`
+	MaxOpenConnections int         `json:"max_open_connections" yaml:"max_open_connections"`
+	MaxIdleConnections int         `json:"max_idle_connections" yaml:"max_idle_connections"`
+	ConnMaxLifetime    string      `json:"conn_max_lifetime" yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime    string      `json:"conn_max_idle_time" yaml:"conn_max_idle_time"`
+	HealthCheckPeriod  string      `json:"health_check_period" yaml:"health_check_period"`
+	TLS                btls.Config `json:"tls" yaml:"tls"`
+}
+
+// NewConfig creates a new Config with default values.
+func NewConfig() Config {
+	return Config{
+		Label:              "",
+		Driver:             "mysql",
+		DataSourceName:     "",
+		MaxOpenConnections: 0,
+		MaxIdleConnections: 2,
+		ConnMaxLifetime:    "",
+		ConnMaxIdleTime:    "",
+		HealthCheckPeriod:  "30s",
+		TLS:                btls.NewConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var tlsConfigCounter int64
+
+// dsnWithTLS registers a custom TLS config with a driver and returns the data
+// source name modified to use it.
+func dsnWithTLS(conf Config) (string, error) {
+	tlsConf, err := conf.TLS.Get()
+	if err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("benthos_%v_%v", conf.Label, atomic.AddInt64(&tlsConfigCounter, 1))
+	var params string
+	switch conf.Driver {
+	case "mysql":
+		if err = mysql.RegisterTLSConfig(key, tlsConf); err != nil {
+			return "", err
+		}
+		params = "tls=" + key
+	case "clickhouse":
+		if err = clickhouse.RegisterTLSConfig(key, tlsConf); err != nil {
+			return "", err
+		}
+		params = "secure=true&tls_config=" + key
+	case "postgres":
+		return "", errors.New("custom tls settings are not supported by the postgres driver, use the sslmode, sslrootcert, sslcert and sslkey parameters of the data_source_name instead")
+	default:
+		return "", fmt.Errorf("custom tls settings are not supported by the %v driver", conf.Driver)
+	}
+
+	if strings.Contains(conf.DataSourceName, "?") {
+		return conf.DataSourceName + "&" + params, nil
+	}
+	return conf.DataSourceName + "?" + params, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Pool is a SQL database connection pool that can be shared by components.
+type Pool struct {
+	db     *sql.DB
+	driver string
+	log    log.Modular
+
+	healthCheckPeriod time.Duration
+
+	mOpenConns   metrics.StatGauge
+	mInUseConns  metrics.StatGauge
+	mIdleConns   metrics.StatGauge
+	mWaitCount   metrics.StatGauge
+	mHealthSucc  metrics.StatCounter
+	mHealthError metrics.StatCounter
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// New opens a SQL database connection pool from a config.
+func New(conf Config, log log.Modular, stats metrics.Type) (*Pool, error) {
+	if conf.DataSourceName == "" {
+		return nil, errors.New("a data_source_name is required")
+	}
+
+	dsn := conf.DataSourceName
+	if conf.TLS.Enabled {
+		var err error
+		if dsn, err = dsnWithTLS(conf); err != nil {
+			return nil, err
+		}
+	}
+
+	var connMaxLifetime, connMaxIdleTime time.Duration
+	var err error
+	if conf.ConnMaxLifetime != "" {
+		if connMaxLifetime, err = time.ParseDuration(conf.ConnMaxLifetime); err != nil {
+			return nil, fmt.Errorf("failed to parse conn_max_lifetime: %v", err)
+		}
+	}
+	if conf.ConnMaxIdleTime != "" {
+		if connMaxIdleTime, err = time.ParseDuration(conf.ConnMaxIdleTime); err != nil {
+			return nil, fmt.Errorf("failed to parse conn_max_idle_time: %v", err)
+		}
+	}
+
+	p := &Pool{
+		driver: conf.Driver,
+		log:    log,
+
+		mOpenConns:   stats.GetGauge("connections.open"),
+		mInUseConns:  stats.GetGauge("connections.in_use"),
+		mIdleConns:   stats.GetGauge("connections.idle"),
+		mWaitCount:   stats.GetGauge("connections.wait_count"),
+		mHealthSucc:  stats.GetCounter("health_check.success"),
+		mHealthError: stats.GetCounter("health_check.error"),
+
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}
+	if conf.HealthCheckPeriod != "" {
+		if p.healthCheckPeriod, err = time.ParseDuration(conf.HealthCheckPeriod); err != nil {
+			return nil, fmt.Errorf("failed to parse health_check_period: %v", err)
+		}
+	}
+
+	if p.db, err = sql.Open(conf.Driver, dsn); err != nil {
+		return nil, err
+	}
+	p.db.SetMaxOpenConns(conf.MaxOpenConnections)
+	p.db.SetMaxIdleConns(conf.MaxIdleConnections)
+	p.db.SetConnMaxLifetime(connMaxLifetime)
+	p.db.SetConnMaxIdleTime(connMaxIdleTime)
+
+	go p.loop()
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// DB returns the underlying database handle of the pool, which must not be
+// closed by components using it.
+func (p *Pool) DB() *sql.DB {
+	return p.db
+}
+
+// Driver returns the name of the driver used by the pool.
+func (p *Pool) Driver() string {
+	return p.driver
+}
+
+func (p *Pool) healthCheck() {
+	ctx, done := context.WithTimeout(context.Background(), p.healthCheckPeriod)
+	defer done()
+
+	if err := p.db.PingContext(ctx); err != nil {
+		p.mHealthError.Incr(1)
+		p.log.Warnf("Health check of %v database failed: %v\n", p.driver, err)
+	} else {
+		p.mHealthSucc.Incr(1)
+	}
+
+	stats := p.db.Stats()
+	p.mOpenConns.Set(int64(stats.OpenConnections))
+	p.mInUseConns.Set(int64(stats.InUse))
+	p.mIdleConns.Set(int64(stats.Idle))
+	p.mWaitCount.Set(stats.WaitCount)
+}
+
+func (p *Pool) loop() {
+	defer func() {
+		p.db.Close()
+		close(p.closedChan)
+	}()
+
+	if p.healthCheckPeriod <= 0 {
+		<-p.closeChan
+		return
+	}
+
+	ticker := time.NewTicker(p.healthCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.healthCheck()
+		case <-p.closeChan:
+			return
+		}
+	}
+}
+
+// CloseAsync shuts down the pool and closes its connections.
+func (p *Pool) CloseAsync() {
+	p.closeOnce.Do(func() {
+		close(p.closeChan)
+	})
+}
+
+// WaitForClose blocks until the pool has closed down.
+func (p *Pool) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-p.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// FromManager obtains a SQL resource by its label from a manager.
+func FromManager(mgr types.Manager, label string) (*Pool, error) {
+	poolProv, ok := mgr.(interface {
+		GetSQLPool(name string) (*Pool, error)
+	})
+	if !ok {
+		return nil, errors.New("manager does not support sql resources")
+	}
+	return poolProv.GetSQLPool(label)
+}
@@ -0,0 +1,117 @@
+package sqlpool
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDriver is a database driver where connections can be opened and fail to
+// do anything else.
+type fakeDriver struct{}
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not implemented")
+}
+
+func init() {
+	sql.Register("sqlpool_test", fakeDriver{})
+}
+
+func TestPoolConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Driver = "sqlpool_test"
+	conf.DataSourceName = "foo"
+	conf.MaxOpenConnections = 5
+	conf.HealthCheckPeriod = ""
+
+	p, err := New(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	assert.Equal(t, "sqlpool_test", p.Driver())
+	assert.Equal(t, 5, p.DB().Stats().MaxOpenConnections)
+
+	p.CloseAsync()
+	require.NoError(t, p.WaitForClose(time.Second))
+
+	require.EqualError(t, p.DB().Ping(), "sql: database is closed")
+}
+
+func TestPoolHealthCheck(t *testing.T) {
+	conf := NewConfig()
+	conf.Driver = "sqlpool_test"
+	conf.DataSourceName = "foo"
+	conf.HealthCheckPeriod = "1ms"
+
+	stats := metrics.NewLocal()
+	p, err := New(conf, log.Noop(), stats)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return stats.GetCounters()["health_check.success"] > 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int64(1), stats.GetCounters()["connections.open"])
+	assert.Equal(t, int64(1), stats.GetCounters()["connections.idle"])
+
+	p.CloseAsync()
+	require.NoError(t, p.WaitForClose(time.Second))
+}
+
+func TestPoolBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Driver = "sqlpool_test"
+
+	_, err := New(conf, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "a data_source_name is required")
+
+	conf.DataSourceName = "foo"
+	conf.ConnMaxLifetime = "nope"
+	_, err = New(conf, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, `failed to parse conn_max_lifetime: time: invalid duration "nope"`)
+
+	conf.ConnMaxLifetime = ""
+	conf.Driver = "postgres"
+	conf.TLS.Enabled = true
+	_, err = New(conf, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported by the postgres driver")
+}
+
+func TestDSNWithTLS(t *testing.T) {
+	conf := NewConfig()
+	conf.Label = "foo"
+	conf.TLS.Enabled = true
+
+	conf.Driver = "mysql"
+	conf.DataSourceName = "user:pass@tcp(localhost:3306)/db"
+	dsn, err := dsnWithTLS(conf)
+	require.NoError(t, err)
+	assert.Regexp(t, `^user:pass@tcp\(localhost:3306\)/db\?tls=benthos_foo_\d+$`, dsn)
+
+	conf.Driver = "clickhouse"
+	conf.DataSourceName = "tcp://localhost:9000?debug=true"
+	dsn, err = dsnWithTLS(conf)
+	require.NoError(t, err)
+	assert.Regexp(t, `^tcp://localhost:9000\?debug=true&secure=true&tls_config=benthos_foo_\d+$`, dsn)
+}
@@ -7,6 +7,7 @@ func FieldSpec() docs.FieldSpec {
 	return docs.FieldAdvanced("tls", "Custom TLS settings can be used to override system defaults.").WithChildren(
 		docs.FieldCommon("enabled", "Whether custom TLS settings are enabled."),
 		docs.FieldCommon("skip_cert_verify", "Whether to skip server side certificate verification."),
+		docs.FieldCommon("root_cas", "An optional root certificate authority to use. This is a string, representing a certificate chain from the parent trusted root certificate, to possible intermediate signing certificates, to the host certificate. Certificates provided here are combined with those of `root_cas_file`.", "-----BEGIN CERTIFICATE-----\n...\n-----END CERTIFICATE-----").AtVersion("3.44.0"),
 		docs.FieldCommon("root_cas_file", "An optional path of a root certificate authority file to use. This is a file, often with a .pem extension, containing a certificate chain from the parent trusted root certificate, to possible intermediate signing certificates, to the host certificate.", "./root_cas.pem"),
 		docs.FieldCommon("client_certs", "A list of client certificates to use. For each certificate either the fields `cert` and `key`, or `cert_file` and `key_file` should be specified, but not both.",
 			[]interface{}{
@@ -27,5 +28,14 @@ func FieldSpec() docs.FieldSpec {
 			docs.FieldCommon("cert_file", "The path to a certificate to use.").HasDefault(""),
 			docs.FieldCommon("key_file", "The path of a certificate key to use.").HasDefault(""),
 		),
+		docs.FieldAdvanced("pinned_public_keys", "An optional list of public key pins, where connections are rejected unless a certificate presented by the server has a public key matching one of them. Each pin is the base64 encoded SHA-256 hash of a certificate's subject public key info, optionally prefixed with `sha256//`.", []string{"sha256//YhKJKSzoTt2b5FP18fvpHo7fJYqQCjAa3HWY3tvRMwE="}).Array().AtVersion("3.44.0"),
+		docs.FieldAdvanced("min_version", "An optional minimum TLS version to accept, where the default of the Go standard library is used when empty.").HasOptions("1.0", "1.1", "1.2", "1.3").AtVersion("3.44.0"),
+		docs.FieldAdvanced("cipher_suites", "An optional list of cipher suites to restrict connections to, identified by their IANA names. Cipher suites are not configurable with TLS 1.3.", []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}).Array().AtVersion("3.44.0"),
+		docs.FieldAdvanced("reload_interval", "An optional period after which the files of `root_cas_file` and `client_certs` are checked for changes as new connections are made, and reloaded when modified. This allows certificates to be rotated without restarting. When empty the files are only loaded once.", "1m").AtVersion("3.44.0"),
+		docs.FieldAdvanced("spiffe", "Obtain the client certificate and the trust bundle used to verify servers from a [SPIFFE](https://spiffe.io) workload API, both of which are rotated automatically. When enabled the fields `root_cas`, `root_cas_file` and `client_certs` must be empty.").WithChildren(
+			docs.FieldCommon("enabled", "Whether to obtain certificates from a SPIFFE workload API."),
+			docs.FieldCommon("workload_api_address", "The address of the workload API, where the environment variable `SPIFFE_ENDPOINT_SOCKET` is used when empty.", "unix:///run/spire/sockets/agent.sock"),
+			docs.FieldCommon("allowed_ids", "An optional list of SPIFFE IDs that servers must present, where any server with a certificate trusted by the workload API is accepted when empty.", []string{"spiffe://example.org/service"}).Array(),
+		).AtVersion("3.44.0"),
 	)
 }
@@ -0,0 +1,150 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// reloader holds the client certificates and root certificate authorities of a
+// config, and reloads them when the files they were loaded from are modified.
+type reloader struct {
+	conf     Config
+	interval time.Duration
+	files    []string
+
+	mut       sync.Mutex
+	checkedAt time.Time
+	modTimes  []time.Time
+	certs     []tls.Certificate
+	rootCAs   *x509.CertPool
+}
+
+func newReloader(conf *Config, interval time.Duration) (*reloader, error) {
+	r := &reloader{
+		conf:     *conf,
+		interval: interval,
+	}
+	if conf.RootCAsFile != "" {
+		r.files = append(r.files, conf.RootCAsFile)
+	}
+	for _, cert := range conf.ClientCertificates {
+		if cert.CertFile != "" {
+			r.files = append(r.files, cert.CertFile)
+		}
+		if cert.KeyFile != "" {
+			r.files = append(r.files, cert.KeyFile)
+		}
+	}
+
+	var err error
+	if r.modTimes, err = r.statFiles(); err != nil {
+		return nil, err
+	}
+	if r.rootCAs, err = r.conf.loadRootCAs(); err != nil {
+		return nil, err
+	}
+	if r.certs, err = r.conf.loadClientCerts(); err != nil {
+		return nil, err
+	}
+	r.checkedAt = time.Now()
+	return r, nil
+}
+
+func (r *reloader) statFiles() ([]time.Time, error) {
+	modTimes := make([]time.Time, len(r.files))
+	for i, path := range r.files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// get returns the current certificates and root certificate authorities,
+// reloading them first when the files they were loaded from have been modified
+// since the last check. When reloading fails the previous certificates are
+// kept and reloading is attempted again after the next interval.
+func (r *reloader) get() ([]tls.Certificate, *x509.CertPool) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if time.Since(r.checkedAt) < r.interval {
+		return r.certs, r.rootCAs
+	}
+	r.checkedAt = time.Now()
+
+	modTimes, err := r.statFiles()
+	if err != nil {
+		return r.certs, r.rootCAs
+	}
+	changed := false
+	for i, t := range modTimes {
+		if !t.Equal(r.modTimes[i]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return r.certs, r.rootCAs
+	}
+
+	rootCAs, err := r.conf.loadRootCAs()
+	if err != nil {
+		return r.certs, r.rootCAs
+	}
+	certs, err := r.conf.loadClientCerts()
+	if err != nil {
+		return r.certs, r.rootCAs
+	}
+	r.modTimes, r.certs, r.rootCAs = modTimes, certs, rootCAs
+	return r.certs, r.rootCAs
+}
+
+// apply sets a TLS config to obtain client certificates and verify servers
+// with the latest certificates of the reloader.
+func (r *reloader) apply(tlsConf *tls.Config) {
+	tlsConf.GetClientCertificate = func(cri *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		certs, _ := r.get()
+		for i := range certs {
+			if err := cri.SupportsCertificate(&certs[i]); err == nil {
+				return &certs[i], nil
+			}
+		}
+		// Sending an empty certificate leaves the server to decide whether
+		// the connection is permitted.
+		return &tls.Certificate{}, nil
+	}
+
+	if r.conf.RootCAsFile == "" || tlsConf.InsecureSkipVerify {
+		tlsConf.RootCAs = r.rootCAs
+		return
+	}
+
+	// Root certificate authorities of a TLS config cannot be swapped after
+	// use, therefore the default verification is disabled and replaced with
+	// one that uses the latest root certificate authorities.
+	tlsConf.InsecureSkipVerify = true
+	tlsConf.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("no certificates presented by the server")
+		}
+		_, rootCAs := r.get()
+		opts := x509.VerifyOptions{
+			Roots:         rootCAs,
+			DNSName:       cs.ServerName,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range cs.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := cs.PeerCertificates[0].Verify(opts)
+		return err
+	}
+}
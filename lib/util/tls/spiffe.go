@@ -0,0 +1,108 @@
+package tls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+)
+
+//------------------------------------------------------------------------------
+
+// SPIFFEConfig contains config fields for obtaining certificates from a SPIFFE
+// workload API.
+type SPIFFEConfig struct {
+	Enabled            bool     `json:"enabled" yaml:"enabled"`
+	WorkloadAPIAddress string   `json:"workload_api_address" yaml:"workload_api_address"`
+	AllowedIDs         []string `json:"allowed_ids" yaml:"allowed_ids"`
+}
+
+// NewSPIFFEConfig creates a new SPIFFEConfig with default values.
+func NewSPIFFEConfig() SPIFFEConfig {
+	return SPIFFEConfig{
+		Enabled:            false,
+		WorkloadAPIAddress: "",
+		AllowedIDs:         []string{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// The time to wait for the first certificate of a workload API.
+const spiffeSourceTimeout = time.Second * 30
+
+var (
+	spiffeSourcesMut sync.Mutex
+	spiffeSources    = map[string]*workloadapi.X509Source{}
+)
+
+// spiffeSource returns a source of certificates and trust bundles from a
+// workload API. Sources keep a stream open in order to receive rotated
+// certificates, and are therefore shared by all components using the same
+// address for the lifetime of the process.
+func spiffeSource(addr string) (*workloadapi.X509Source, error) {
+	spiffeSourcesMut.Lock()
+	defer spiffeSourcesMut.Unlock()
+
+	if source, exists := spiffeSources[addr]; exists {
+		return source, nil
+	}
+
+	var opts []workloadapi.X509SourceOption
+	if addr != "" {
+		opts = append(opts, workloadapi.WithClientOptions(workloadapi.WithAddr(addr)))
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), spiffeSourceTimeout)
+	defer done()
+
+	source, err := workloadapi.NewX509Source(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain certificates from SPIFFE workload API: %w", err)
+	}
+	spiffeSources[addr] = source
+	return source, nil
+}
+
+// apply sets a TLS config to present the certificate of the workload and to
+// verify servers with the trust bundles of the workload API.
+func (s SPIFFEConfig) apply(tlsConf *tls.Config) error {
+	authorizer := tlsconfig.AuthorizeAny()
+	if len(s.AllowedIDs) > 0 {
+		ids := make([]spiffeid.ID, 0, len(s.AllowedIDs))
+		for _, idStr := range s.AllowedIDs {
+			id, err := spiffeid.FromString(idStr)
+			if err != nil {
+				return fmt.Errorf("failed to parse allowed SPIFFE ID '%v': %w", idStr, err)
+			}
+			ids = append(ids, id)
+		}
+		authorizer = tlsconfig.AuthorizeOneOf(ids...)
+	}
+
+	source, err := spiffeSource(s.WorkloadAPIAddress)
+	if err != nil {
+		return err
+	}
+
+	// The SPIFFE verification replaces any existing peer verification, which
+	// is therefore chained after it.
+	verifyPeer := tlsConf.VerifyPeerCertificate
+	tlsconfig.HookMTLSClientConfig(tlsConf, source, source, authorizer)
+	if verifyPeer != nil {
+		spiffeVerifyPeer := tlsConf.VerifyPeerCertificate
+		tlsConf.VerifyPeerCertificate = func(rawCerts [][]byte, chains [][]*x509.Certificate) error {
+			if err := spiffeVerifyPeer(rawCerts, chains); err != nil {
+				return err
+			}
+			return verifyPeer(rawCerts, chains)
+		}
+	}
+	return nil
+}
@@ -1,10 +1,15 @@
 package tls
 
 import (
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"strings"
+	"time"
 )
 
 //------------------------------------------------------------------------------
@@ -26,7 +31,16 @@ client_certs:
     key_file: ./example.key
   - cert: foo
     key: bar
-` + "```" + ``
+` + "```" + `
+
+Certificates and root certificate authorities loaded from files can be reloaded
+without a restart by setting ` + "`reload_interval`" + `, in which case the files
+are checked for changes at most once per interval as new connections are made.
+
+Certificates can also be obtained from a [SPIFFE](https://spiffe.io)
+workload API by enabling the ` + "`spiffe`" + ` fields, in which case both the
+client certificate and the trust bundle used to verify servers are rotated
+automatically.`
 
 //------------------------------------------------------------------------------
 
@@ -41,18 +55,30 @@ type ClientCertConfig struct {
 // Config contains configuration params for TLS.
 type Config struct {
 	Enabled            bool               `json:"enabled" yaml:"enabled"`
+	RootCAs            string             `json:"root_cas" yaml:"root_cas"`
 	RootCAsFile        string             `json:"root_cas_file" yaml:"root_cas_file"`
 	InsecureSkipVerify bool               `json:"skip_cert_verify" yaml:"skip_cert_verify"`
 	ClientCertificates []ClientCertConfig `json:"client_certs" yaml:"client_certs"`
+	PinnedPublicKeys   []string           `json:"pinned_public_keys" yaml:"pinned_public_keys"`
+	MinVersion         string             `json:"min_version" yaml:"min_version"`
+	CipherSuites       []string           `json:"cipher_suites" yaml:"cipher_suites"`
+	ReloadInterval     string             `json:"reload_interval" yaml:"reload_interval"`
+	SPIFFE             SPIFFEConfig       `json:"spiffe" yaml:"spiffe"`
 }
 
 // NewConfig creates a new Config with default values.
 func NewConfig() Config {
 	return Config{
 		Enabled:            false,
+		RootCAs:            "",
 		RootCAsFile:        "",
 		InsecureSkipVerify: false,
 		ClientCertificates: []ClientCertConfig{},
+		PinnedPublicKeys:   []string{},
+		MinVersion:         "",
+		CipherSuites:       []string{},
+		ReloadInterval:     "",
+		SPIFFE:             NewSPIFFEConfig(),
 	}
 }
 
@@ -60,19 +86,96 @@ func NewConfig() Config {
 
 // Get returns a valid *tls.Config based on the configuration values of Config.
 func (c *Config) Get() (*tls.Config, error) {
+	if c.SPIFFE.Enabled && (len(c.ClientCertificates) > 0 || c.RootCAs != "" || c.RootCAsFile != "") {
+		return nil, errors.New("client_certs, root_cas and root_cas_file cannot be specified when spiffe is enabled")
+	}
+
+	tlsConf := &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	var err error
+	if tlsConf.MinVersion, err = parseVersion(c.MinVersion); err != nil {
+		return nil, err
+	}
+	if tlsConf.CipherSuites, err = parseCipherSuites(c.CipherSuites); err != nil {
+		return nil, err
+	}
+
+	if c.ReloadInterval != "" {
+		interval, err := time.ParseDuration(c.ReloadInterval)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse reload_interval: %w", err)
+		}
+		r, err := newReloader(c, interval)
+		if err != nil {
+			return nil, err
+		}
+		r.apply(tlsConf)
+	} else {
+		if tlsConf.RootCAs, err = c.loadRootCAs(); err != nil {
+			return nil, err
+		}
+		if tlsConf.Certificates, err = c.loadClientCerts(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(c.PinnedPublicKeys) > 0 {
+		pins := map[string]struct{}{}
+		for _, p := range c.PinnedPublicKeys {
+			p = strings.TrimPrefix(p, "sha256//")
+			if _, err := base64.StdEncoding.DecodeString(p); err != nil {
+				return nil, fmt.Errorf("failed to parse pinned public key '%v': %w", p, err)
+			}
+			pins[p] = struct{}{}
+		}
+		tlsConf.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			for _, raw := range rawCerts {
+				cert, err := x509.ParseCertificate(raw)
+				if err != nil {
+					continue
+				}
+				if _, exists := pins[PublicKeyHash(cert)]; exists {
+					return nil
+				}
+			}
+			return errors.New("no certificate presented by the server matches a pinned public key")
+		}
+	}
+
+	if c.SPIFFE.Enabled {
+		if err = c.SPIFFE.apply(tlsConf); err != nil {
+			return nil, err
+		}
+	}
+	return tlsConf, nil
+}
+
+func (c *Config) loadRootCAs() (*x509.CertPool, error) {
 	var rootCAs *x509.CertPool
+	if len(c.RootCAs) > 0 {
+		rootCAs = x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM([]byte(c.RootCAs)) {
+			return nil, errors.New("failed to parse any certificates from root_cas")
+		}
+	}
 	if len(c.RootCAsFile) > 0 {
 		caCert, err := ioutil.ReadFile(c.RootCAsFile)
 		if err != nil {
 			return nil, err
 		}
 
-		rootCAs = x509.NewCertPool()
+		if rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
 		rootCAs.AppendCertsFromPEM(caCert)
 	}
+	return rootCAs, nil
+}
 
+func (c *Config) loadClientCerts() ([]tls.Certificate, error) {
 	clientCerts := []tls.Certificate{}
-
 	for _, conf := range c.ClientCertificates {
 		cert, err := conf.Load()
 		if nil != err {
@@ -80,12 +183,55 @@ func (c *Config) Get() (*tls.Config, error) {
 		}
 		clientCerts = append(clientCerts, cert)
 	}
+	return clientCerts, nil
+}
 
-	return &tls.Config{
-		InsecureSkipVerify: c.InsecureSkipVerify,
-		RootCAs:            rootCAs,
-		Certificates:       clientCerts,
-	}, nil
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func parseVersion(v string) (uint16, error) {
+	if v == "" {
+		return 0, nil
+	}
+	version, exists := tlsVersions[v]
+	if !exists {
+		return 0, fmt.Errorf("unrecognised TLS version: %v", v)
+	}
+	return version, nil
+}
+
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	suites := map[string]uint16{}
+	for _, s := range tls.CipherSuites() {
+		suites[s.Name] = s.ID
+	}
+	for _, s := range tls.InsecureCipherSuites() {
+		suites[s.Name] = s.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, exists := suites[name]
+		if !exists {
+			return nil, fmt.Errorf("unrecognised cipher suite: %v", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// PublicKeyHash returns the base64 encoded SHA-256 hash of the subject public
+// key info of a certificate, which is the format expected by pinned public
+// keys.
+func PublicKeyHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
 }
 
 // Load returns a TLS certificate, based on either file paths in the
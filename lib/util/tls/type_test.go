@@ -0,0 +1,117 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestCert(t *testing.T, dir, name string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cert.pem"), pem.EncodeToMemory(&pem.Block{
+		Type: "CERTIFICATE", Bytes: certBytes,
+	}), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "key.pem"), pem.EncodeToMemory(&pem.Block{
+		Type: "EC PRIVATE KEY", Bytes: keyBytes,
+	}), 0600))
+}
+
+func TestConfigVersionAndCipherSuites(t *testing.T) {
+	conf := NewConfig()
+	conf.MinVersion = "1.2"
+	conf.CipherSuites = []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}
+
+	tlsConf, err := conf.Get()
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), tlsConf.MinVersion)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, tlsConf.CipherSuites)
+
+	conf.MinVersion = "1.4"
+	_, err = conf.Get()
+	require.EqualError(t, err, "unrecognised TLS version: 1.4")
+
+	conf.MinVersion = ""
+	conf.CipherSuites = []string{"nope"}
+	_, err = conf.Get()
+	require.EqualError(t, err, "unrecognised cipher suite: nope")
+
+	conf = NewConfig()
+	conf.SPIFFE.Enabled = true
+	conf.RootCAsFile = "./root_cas.pem"
+	_, err = conf.Get()
+	require.EqualError(t, err, "client_certs, root_cas and root_cas_file cannot be specified when spiffe is enabled")
+}
+
+func TestConfigReloadClientCerts(t *testing.T) {
+	dir := t.TempDir()
+	writeTestCert(t, dir, "first")
+
+	conf := NewConfig()
+	conf.ReloadInterval = "1ns"
+	conf.ClientCertificates = []ClientCertConfig{
+		{
+			CertFile: filepath.Join(dir, "cert.pem"),
+			KeyFile:  filepath.Join(dir, "key.pem"),
+		},
+	}
+
+	tlsConf, err := conf.Get()
+	require.NoError(t, err)
+	require.NotNil(t, tlsConf.GetClientCertificate)
+
+	commonName := func() string {
+		t.Helper()
+		cert, err := tlsConf.GetClientCertificate(&tls.CertificateRequestInfo{
+			Version:          tls.VersionTLS13,
+			SignatureSchemes: []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+		})
+		require.NoError(t, err)
+		require.Len(t, cert.Certificate, 1)
+		parsed, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		return parsed.Subject.CommonName
+	}
+	assert.Equal(t, "first", commonName())
+
+	writeTestCert(t, dir, "second")
+	future := time.Now().Add(time.Minute)
+	for _, f := range []string{"cert.pem", "key.pem"} {
+		require.NoError(t, os.Chtimes(filepath.Join(dir, f), future, future))
+	}
+	assert.Equal(t, "second", commonName())
+
+	// An invalid certificate is ignored in favour of the previous one.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cert.pem"), []byte("nope"), 0600))
+	future = future.Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "cert.pem"), future, future))
+	assert.Equal(t, "second", commonName())
+}
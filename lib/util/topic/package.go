@@ -0,0 +1,4 @@
+// Package topic provides an implementation of named in-process topics, which
+// allow multiple producers and consumers of a Benthos process, such as the
+// streams of streams mode, to exchange messages with backpressure.
+package topic
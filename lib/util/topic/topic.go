@@ -0,0 +1,300 @@
+package topic
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+// DefaultCapacity is the number of messages retained by a topic when none of
+// its producers have declared a capacity.
+const DefaultCapacity = 1000
+
+//------------------------------------------------------------------------------
+
+type group struct {
+	members int
+	next    int64
+	pending map[int64]struct{}
+	retry   []int64
+}
+
+// low returns the lowest offset that the group still requires.
+func (g *group) low() int64 {
+	low := g.next
+	for offset := range g.pending {
+		if offset < low {
+			low = offset
+		}
+	}
+	return low
+}
+
+//------------------------------------------------------------------------------
+
+// Topic is a bounded log of messages that can be written to by any number of
+// producers and read by any number of consumer groups, where each group has
+// its own cursor and therefore receives every message. Consumers of the same
+// group share the messages of that group.
+//
+// Messages are retained until they have been acknowledged by every group, and
+// producers are blocked whilst the topic is at capacity, which applies
+// backpressure from the slowest group.
+//
+// This component is safe to use concurrently across goroutines.
+type Topic struct {
+	mut sync.Mutex
+
+	capacity int
+	head     int64
+	msgs     []types.Message
+	groups   map[string]*group
+	anonSeq  int64
+	changed  chan struct{}
+}
+
+// New creates a new empty topic.
+func New() *Topic {
+	return &Topic{
+		groups:  map[string]*group{},
+		changed: make(chan struct{}),
+	}
+}
+
+// signal must be called with the lock held whenever the state of the topic
+// changes in a way that waiting producers or consumers may be interested in.
+func (t *Topic) signal() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+func (t *Topic) tail() int64 {
+	return t.head + int64(len(t.msgs))
+}
+
+func (t *Topic) limit() int {
+	if t.capacity <= 0 {
+		return DefaultCapacity
+	}
+	return t.capacity
+}
+
+// trim drops all messages that are no longer required by any group. Messages
+// are retained when there are no groups so that consumers that connect late
+// still receive them.
+func (t *Topic) trim() {
+	if len(t.groups) == 0 {
+		return
+	}
+	low := t.tail()
+	for _, g := range t.groups {
+		if l := g.low(); l < low {
+			low = l
+		}
+	}
+	if low <= t.head {
+		return
+	}
+	drop := int(low - t.head)
+	for i := 0; i < drop; i++ {
+		t.msgs[i] = nil
+	}
+	t.msgs = t.msgs[drop:]
+	t.head = low
+	t.signal()
+}
+
+// DeclareCapacity sets the maximum number of messages retained by the topic.
+// When multiple producers declare a capacity the largest is used.
+func (t *Topic) DeclareCapacity(n int) {
+	t.mut.Lock()
+	if n > t.capacity {
+		t.capacity = n
+		t.signal()
+	}
+	t.mut.Unlock()
+}
+
+// Len returns the number of messages currently retained by the topic.
+func (t *Topic) Len() int {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return len(t.msgs)
+}
+
+// Publish adds a message to the topic, blocking until there is capacity for
+// it or the context is cancelled.
+func (t *Topic) Publish(ctx context.Context, msg types.Message) error {
+	for {
+		t.mut.Lock()
+		if len(t.msgs) < t.limit() {
+			t.msgs = append(t.msgs, msg.Copy())
+			t.signal()
+			t.mut.Unlock()
+			return nil
+		}
+		changed := t.changed
+		t.mut.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Subscribe adds a consumer to a named group, creating the group if it does
+// not yet exist. When the group name is empty the consumer is given a group of
+// its own. New groups begin reading from the oldest message retained by the
+// topic.
+func (t *Topic) Subscribe(groupName string) *Subscription {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if len(groupName) == 0 {
+		t.anonSeq++
+		groupName = "\x00anonymous-" + strconv.FormatInt(t.anonSeq, 10)
+	}
+	g, exists := t.groups[groupName]
+	if !exists {
+		g = &group{
+			next:    t.head,
+			pending: map[int64]struct{}{},
+		}
+		t.groups[groupName] = g
+	}
+	g.members++
+	return &Subscription{
+		topic: t,
+		group: groupName,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ErrClosed is returned when reading from a closed subscription.
+var ErrClosed = errors.New("subscription closed")
+
+// Subscription is a consumer of a topic belonging to a group.
+type Subscription struct {
+	topic *Topic
+	group string
+
+	closed    bool
+	closeOnce sync.Once
+}
+
+// Next blocks until the next message of the group is available and returns it
+// along with a function that must be called once the message has been
+// processed. Calling the function with an error results in the message being
+// redelivered to the group.
+func (s *Subscription) Next(ctx context.Context) (types.Message, func(err error), error) {
+	t := s.topic
+	for {
+		t.mut.Lock()
+		g, exists := t.groups[s.group]
+		if !exists || s.closed {
+			t.mut.Unlock()
+			return nil, nil, ErrClosed
+		}
+
+		offset := int64(-1)
+		if len(g.retry) > 0 {
+			offset = g.retry[0]
+			g.retry = g.retry[1:]
+		} else if g.next < t.tail() {
+			offset = g.next
+			g.next++
+			g.pending[offset] = struct{}{}
+		}
+		if offset >= 0 {
+			msg := t.msgs[offset-t.head].DeepCopy()
+			t.mut.Unlock()
+			return msg, func(err error) {
+				s.ack(offset, err)
+			}, nil
+		}
+
+		changed := t.changed
+		t.mut.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+func (s *Subscription) ack(offset int64, err error) {
+	t := s.topic
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	g, exists := t.groups[s.group]
+	if !exists {
+		return
+	}
+	if _, pending := g.pending[offset]; !pending {
+		return
+	}
+	if err != nil {
+		g.retry = append(g.retry, offset)
+		t.signal()
+		return
+	}
+	delete(g.pending, offset)
+	t.trim()
+}
+
+// Lag returns the number of messages published to the topic that have not yet
+// been read by the group of the subscription.
+func (s *Subscription) Lag() int64 {
+	t := s.topic
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	if g, exists := t.groups[s.group]; exists {
+		return t.tail() - g.next
+	}
+	return 0
+}
+
+// Close removes the subscription from its group. When the last member of a
+// group is closed the group is removed from the topic, along with any messages
+// that it had not acknowledged, so that it no longer blocks producers.
+func (s *Subscription) Close() {
+	s.closeOnce.Do(func() {
+		t := s.topic
+		t.mut.Lock()
+		defer t.mut.Unlock()
+
+		s.closed = true
+		g, exists := t.groups[s.group]
+		if !exists {
+			return
+		}
+		if g.members--; g.members <= 0 {
+			delete(t.groups, s.group)
+		}
+		t.trim()
+		t.signal()
+	})
+}
+
+//------------------------------------------------------------------------------
+
+// FromManager obtains a topic by its name from a manager, the topic is created
+// if it does not already exist.
+func FromManager(mgr types.Manager, name string) (*Topic, error) {
+	tProv, ok := mgr.(interface {
+		GetTopic(name string) *Topic
+	})
+	if !ok {
+		return nil, errors.New("manager does not support inproc topics")
+	}
+	return tProv.GetTopic(name), nil
+}
@@ -0,0 +1,158 @@
+package topic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readOne(t *testing.T, sub *Subscription) (string, func(error)) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	msg, ackFn, err := sub.Next(ctx)
+	require.NoError(t, err)
+	return string(msg.Get(0).Get()), ackFn
+}
+
+func publish(t *testing.T, topic *Topic, content string) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	require.NoError(t, topic.Publish(ctx, message.New([][]byte{[]byte(content)})))
+}
+
+func TestTopicFanOut(t *testing.T) {
+	topic := New()
+
+	subA := topic.Subscribe("")
+	subB := topic.Subscribe("")
+
+	publish(t, topic, "foo")
+	publish(t, topic, "bar")
+
+	for _, sub := range []*Subscription{subA, subB} {
+		v, ackFn := readOne(t, sub)
+		assert.Equal(t, "foo", v)
+		ackFn(nil)
+
+		v, ackFn = readOne(t, sub)
+		assert.Equal(t, "bar", v)
+		ackFn(nil)
+	}
+	assert.Equal(t, 0, topic.Len())
+}
+
+func TestTopicSharedGroup(t *testing.T) {
+	topic := New()
+
+	subA := topic.Subscribe("workers")
+	subB := topic.Subscribe("workers")
+
+	publish(t, topic, "foo")
+	publish(t, topic, "bar")
+
+	v, ackA := readOne(t, subA)
+	assert.Equal(t, "foo", v)
+
+	v, ackB := readOne(t, subB)
+	assert.Equal(t, "bar", v)
+
+	ackA(errors.New("nope"))
+	ackB(nil)
+	assert.Equal(t, 2, topic.Len())
+
+	v, ackB = readOne(t, subB)
+	assert.Equal(t, "foo", v)
+	ackB(nil)
+	assert.Equal(t, 0, topic.Len())
+}
+
+func TestTopicBackpressure(t *testing.T) {
+	topic := New()
+	topic.DeclareCapacity(2)
+
+	sub := topic.Subscribe("")
+
+	publish(t, topic, "foo")
+	publish(t, topic, "bar")
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*50)
+	err := topic.Publish(ctx, message.New([][]byte{[]byte("baz")}))
+	done()
+	require.Equal(t, context.DeadlineExceeded, err)
+
+	published := make(chan error)
+	go func() {
+		ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+		defer done()
+		published <- topic.Publish(ctx, message.New([][]byte{[]byte("baz")}))
+	}()
+
+	v, ackFn := readOne(t, sub)
+	assert.Equal(t, "foo", v)
+	assert.Equal(t, int64(1), sub.Lag())
+
+	select {
+	case <-published:
+		t.Fatal("expected publish to block until acknowledged")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	ackFn(nil)
+	select {
+	case err := <-published:
+		require.NoError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	assert.Equal(t, int64(2), sub.Lag())
+}
+
+func TestTopicLateSubscriber(t *testing.T) {
+	topic := New()
+
+	publish(t, topic, "foo")
+
+	sub := topic.Subscribe("")
+	v, ackFn := readOne(t, sub)
+	assert.Equal(t, "foo", v)
+	ackFn(nil)
+
+	sub.Close()
+	_, _, err := sub.Next(context.Background())
+	assert.Equal(t, ErrClosed, err)
+}
+
+func TestTopicCloseUnblocksProducers(t *testing.T) {
+	topic := New()
+	topic.DeclareCapacity(1)
+
+	fast := topic.Subscribe("")
+	slow := topic.Subscribe("")
+
+	publish(t, topic, "foo")
+
+	v, ackFn := readOne(t, fast)
+	assert.Equal(t, "foo", v)
+	ackFn(nil)
+	assert.Equal(t, 1, topic.Len())
+
+	slow.Close()
+	assert.Equal(t, 0, topic.Len())
+
+	publish(t, topic, "bar")
+
+	v, ackFn = readOne(t, fast)
+	assert.Equal(t, "bar", v)
+	ackFn(nil)
+}
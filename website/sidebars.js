@@ -24,6 +24,8 @@ module.exports = {
         'configuration/batching',
         'configuration/windowed_processing',
         'configuration/metadata',
+        'configuration/lineage',
+        'configuration/contracts',
         'configuration/error_handling',
         'configuration/interpolation',
         'configuration/field_paths',